@@ -0,0 +1,63 @@
+-- Schema version: 1.0.0
+-- Description: Cold archive bookkeeping for portfolio transactions moved to object storage
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Archived transaction batches stored as Parquet objects
+CREATE TABLE transaction_archives (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    object_key TEXT NOT NULL UNIQUE,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    transaction_count INTEGER NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT valid_archive_period CHECK (period_start < period_end),
+    CONSTRAINT positive_archive_count CHECK (transaction_count > 0)
+);
+
+-- Per-asset positions carried forward from archived transactions
+CREATE TABLE archived_transaction_lots (
+    archive_id UUID NOT NULL REFERENCES transaction_archives(id) ON DELETE CASCADE,
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    asset_id UUID NOT NULL REFERENCES portfolio_assets(asset_id),
+    quantity DECIMAL(24,8) NOT NULL,
+    cost_basis DECIMAL(24,8) NOT NULL,
+    total_fees DECIMAL(24,8) NOT NULL DEFAULT 0,
+    transaction_count INTEGER NOT NULL,
+    acquired_before TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (archive_id, asset_id),
+    CONSTRAINT non_negative_lot CHECK (quantity >= 0 AND cost_basis >= 0)
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_transaction_archives_portfolio_period
+ON transaction_archives(portfolio_id, period_start, period_end);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_archived_transaction_lots_portfolio_id
+ON archived_transaction_lots(portfolio_id);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_portfolio_transactions_timestamp
+ON portfolio_transactions(timestamp);
+
+-- Enable row level security
+ALTER TABLE transaction_archives ENABLE ROW LEVEL SECURITY;
+ALTER TABLE archived_transaction_lots ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY transaction_archives_access ON transaction_archives
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+CREATE POLICY archived_transaction_lots_access ON archived_transaction_lots
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE transaction_archives IS 'Transaction history batches moved to cold object storage';
+COMMENT ON TABLE archived_transaction_lots IS 'Summary lots preserving positions of archived transactions';
@@ -0,0 +1,31 @@
+-- Schema version: 1.0.0
+-- Description: Scheduled historical valuation snapshots of portfolios
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Point-in-time portfolio valuations captured on a fixed interval
+CREATE TABLE portfolio_snapshots (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    total_value DECIMAL(24,8) NOT NULL,
+    profit_loss DECIMAL(24,8) NOT NULL,
+    assets JSONB NOT NULL DEFAULT '[]'::JSONB,
+    captured_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT unique_snapshot_slot UNIQUE (portfolio_id, captured_at),
+    CONSTRAINT valid_snapshot_value CHECK (total_value >= 0)
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_portfolio_snapshots_captured_at
+ON portfolio_snapshots(captured_at);
+
+-- Enable row level security
+ALTER TABLE portfolio_snapshots ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY portfolio_snapshots_access ON portfolio_snapshots
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE portfolio_snapshots IS 'Historical portfolio valuations for charts and period returns';
@@ -0,0 +1,68 @@
+-- Schema version: 1.0.0
+-- Description: Materialized 24h/7d/30d portfolio value change columns maintained from snapshots
+-- Dependencies: 009_portfolio_snapshots.sql
+
+ALTER TABLE portfolios
+    ADD COLUMN change_24h DECIMAL(12,4),
+    ADD COLUMN change_7d DECIMAL(12,4),
+    ADD COLUMN change_30d DECIMAL(12,4),
+    ADD COLUMN changes_as_of TIMESTAMPTZ;
+
+-- Percentage change between two values, NULL when no baseline exists
+CREATE OR REPLACE FUNCTION portfolio_change_pct(p_current DECIMAL(24,8), p_previous DECIMAL(24,8))
+RETURNS DECIMAL(12,4)
+IMMUTABLE
+PARALLEL SAFE
+AS $$
+BEGIN
+    IF p_previous IS NULL OR p_previous = 0 THEN
+        RETURN NULL;
+    END IF;
+    RETURN ROUND(((p_current - p_previous) / p_previous * 100)::numeric, 4);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Portfolio value from the latest snapshot captured at or before the given time
+CREATE OR REPLACE FUNCTION portfolio_value_at(p_portfolio_id UUID, p_at TIMESTAMPTZ)
+RETURNS DECIMAL(24,8)
+STABLE
+PARALLEL SAFE
+AS $$
+    SELECT total_value
+    FROM portfolio_snapshots
+    WHERE portfolio_id = p_portfolio_id AND captured_at <= p_at
+    ORDER BY captured_at DESC
+    LIMIT 1;
+$$ LANGUAGE sql;
+
+-- Recompute change columns as of a snapshot time. Older snapshots never
+-- overwrite changes computed from newer ones, keeping updates incremental
+-- and safe to replay during backfills.
+CREATE OR REPLACE FUNCTION refresh_portfolio_changes(p_portfolio_id UUID, p_as_of TIMESTAMPTZ)
+RETURNS void
+SECURITY DEFINER
+AS $$
+DECLARE
+    current_value DECIMAL(24,8);
+BEGIN
+    current_value := portfolio_value_at(p_portfolio_id, p_as_of);
+    IF current_value IS NULL THEN
+        RETURN;
+    END IF;
+
+    UPDATE portfolios
+    SET change_24h = portfolio_change_pct(current_value, portfolio_value_at(p_portfolio_id, p_as_of - INTERVAL '24 hours')),
+        change_7d = portfolio_change_pct(current_value, portfolio_value_at(p_portfolio_id, p_as_of - INTERVAL '7 days')),
+        change_30d = portfolio_change_pct(current_value, portfolio_value_at(p_portfolio_id, p_as_of - INTERVAL '30 days')),
+        changes_as_of = p_as_of
+    WHERE portfolio_id = p_portfolio_id
+      AND (changes_as_of IS NULL OR changes_as_of <= p_as_of);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_portfolio_snapshots_portfolio_captured
+ON portfolio_snapshots(portfolio_id, captured_at DESC);
+
+COMMENT ON COLUMN portfolios.change_24h IS 'Value change percentage over 24 hours, maintained on snapshot writes';
+COMMENT ON COLUMN portfolios.change_7d IS 'Value change percentage over 7 days, maintained on snapshot writes';
+COMMENT ON COLUMN portfolios.change_30d IS 'Value change percentage over 30 days, maintained on snapshot writes';
@@ -0,0 +1,20 @@
+-- Schema version: 1.0.0
+-- Description: Latest market price per symbol, refreshed in bulk independent of portfolio rows
+-- Dependencies: 003_portfolio_tables.sql
+
+-- One row per symbol; asset values are derived from it at read time so a price
+-- refresh writes a single row per symbol regardless of how many assets hold it
+CREATE TABLE asset_prices_current (
+    symbol VARCHAR(20) PRIMARY KEY,
+    price DECIMAL(24,8) NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT positive_current_price CHECK (price >= 0)
+);
+
+-- Prices change on nearly every refresh; leave room for HOT updates
+ALTER TABLE asset_prices_current SET (fillfactor = 70);
+
+CREATE INDEX IF NOT EXISTS idx_portfolio_assets_symbol
+ON portfolio_assets(symbol);
+
+COMMENT ON TABLE asset_prices_current IS 'Latest price per symbol used to value portfolio assets at read time';
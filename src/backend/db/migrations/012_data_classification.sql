@@ -0,0 +1,18 @@
+-- Schema version: 1.0.0
+-- Description: Data classification and encryption-at-rest markers on portfolio columns
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Markers mirror the registry in portfolio-service internal/models/classification.go
+-- and are read during security reviews. Format: classification=<level>; encrypted_at_rest=<yes|no>
+COMMENT ON COLUMN portfolios.user_id IS 'classification=internal; encrypted_at_rest=no';
+COMMENT ON COLUMN portfolios.name IS 'classification=sensitive; encrypted_at_rest=no; excluded from logs and exports';
+COMMENT ON COLUMN portfolios.description IS 'classification=sensitive; encrypted_at_rest=no; excluded from logs and exports';
+COMMENT ON COLUMN portfolios.encryption_key_id IS 'classification=internal; encrypted_at_rest=no; references the key encrypting sensitive columns';
+
+COMMENT ON COLUMN portfolio_assets.quantity IS 'classification=internal; encrypted_at_rest=no; excluded from logs';
+COMMENT ON COLUMN portfolio_assets.metadata IS 'classification=sensitive; encrypted_at_rest=no; excluded from logs and exports';
+
+COMMENT ON COLUMN portfolio_transactions.quantity IS 'classification=internal; encrypted_at_rest=no; excluded from logs';
+COMMENT ON COLUMN portfolio_transactions.fee IS 'classification=internal; encrypted_at_rest=no; excluded from logs';
+COMMENT ON COLUMN portfolio_transactions.blockchain_tx_hash IS 'classification=sensitive; encrypted_at_rest=no; excluded from logs and exports';
+COMMENT ON COLUMN portfolio_transactions.notes IS 'classification=sensitive; encrypted_at_rest=no; excluded from logs and exports';
@@ -0,0 +1,29 @@
+-- Schema version: 1.0.0
+-- Description: User-defined target allocations per symbol or category for drift detection
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Target share of portfolio value per symbol or category. A portfolio's targets
+-- are replaced as a set, so rows carry no identity beyond their key.
+CREATE TABLE portfolio_allocation_targets (
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    dimension VARCHAR(10) NOT NULL,
+    target_key VARCHAR(20) NOT NULL,
+    percentage DECIMAL(5,2) NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (portfolio_id, dimension, target_key),
+    CONSTRAINT valid_target_dimension CHECK (dimension IN ('symbol', 'category')),
+    CONSTRAINT valid_target_percentage CHECK (percentage > 0 AND percentage <= 100)
+);
+
+-- Enable row level security
+ALTER TABLE portfolio_allocation_targets ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY portfolio_allocation_targets_access ON portfolio_allocation_targets
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE portfolio_allocation_targets IS 'Target allocation percentages compared against actual weights for drift detection';
@@ -0,0 +1,56 @@
+-- Schema version: 1.0.0
+-- Description: Persisted dollar-cost averaging plans and their scheduled installments
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Recurring contribution plans toward target symbol weights
+CREATE TABLE dca_plans (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    contribution DECIMAL(24,2) NOT NULL,
+    frequency VARCHAR(10) NOT NULL,
+    targets JSONB NOT NULL DEFAULT '[]'::JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT positive_contribution CHECK (contribution > 0),
+    CONSTRAINT valid_dca_frequency CHECK (frequency IN ('weekly', 'biweekly', 'monthly'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_dca_plans_portfolio
+ON dca_plans(portfolio_id, created_at DESC);
+
+-- One row per scheduled contribution; executed_at is set when the user marks it done
+CREATE TABLE dca_installments (
+    plan_id UUID NOT NULL REFERENCES dca_plans(id) ON DELETE CASCADE,
+    sequence INTEGER NOT NULL,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    purchases JSONB NOT NULL DEFAULT '[]'::JSONB,
+    projected_allocation JSONB NOT NULL DEFAULT '[]'::JSONB,
+    executed_at TIMESTAMPTZ,
+    PRIMARY KEY (plan_id, sequence),
+    CONSTRAINT positive_sequence CHECK (sequence > 0)
+);
+
+-- Enable row level security
+ALTER TABLE dca_plans ENABLE ROW LEVEL SECURITY;
+ALTER TABLE dca_installments ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY dca_plans_access ON dca_plans
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+CREATE POLICY dca_installments_access ON dca_installments
+    FOR ALL
+    TO authenticated
+    USING (plan_id IN (
+        SELECT id FROM dca_plans
+        WHERE portfolio_id IN (
+            SELECT portfolio_id FROM portfolios
+            WHERE user_id = current_user_id()
+        )
+    ));
+
+COMMENT ON TABLE dca_plans IS 'Dollar-cost averaging plans with contributions split toward target weights';
+COMMENT ON TABLE dca_installments IS 'Scheduled contributions of a DCA plan with projected allocation after each';
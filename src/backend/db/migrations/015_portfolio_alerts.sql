@@ -0,0 +1,57 @@
+-- Schema version: 1.0.0
+-- Description: Alert rules on portfolio value and P&L, evaluated against snapshots
+-- Dependencies: 009_portfolio_snapshots.sql
+
+-- User-defined conditions on portfolio aggregates
+CREATE TABLE portfolio_alert_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    threshold DECIMAL(24,8) NOT NULL,
+    window_seconds INTEGER NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    last_triggered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT valid_alert_kind CHECK (kind IN ('value_drop', 'pnl_above', 'pnl_below')),
+    CONSTRAINT valid_alert_window CHECK (window_seconds >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_portfolio_alert_rules_portfolio
+ON portfolio_alert_rules(portfolio_id);
+
+-- Rules that triggered, newest first per portfolio
+CREATE TABLE portfolio_alert_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rule_id UUID NOT NULL REFERENCES portfolio_alert_rules(id) ON DELETE CASCADE,
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    threshold DECIMAL(24,8) NOT NULL,
+    observed DECIMAL(24,8) NOT NULL,
+    triggered_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_portfolio_alert_events_portfolio
+ON portfolio_alert_events(portfolio_id, triggered_at DESC);
+
+-- Enable row level security
+ALTER TABLE portfolio_alert_rules ENABLE ROW LEVEL SECURITY;
+ALTER TABLE portfolio_alert_events ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY portfolio_alert_rules_access ON portfolio_alert_rules
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+CREATE POLICY portfolio_alert_events_access ON portfolio_alert_events
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE portfolio_alert_rules IS 'Value drop and P&L crossing rules evaluated on each portfolio snapshot';
+COMMENT ON TABLE portfolio_alert_events IS 'History of triggered portfolio alert rules';
@@ -0,0 +1,33 @@
+-- Schema version: 1.0.0
+-- Description: Dead letters of notifications that could not be delivered
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Notifications a sender failed to deliver after all retries
+CREATE TABLE notification_dead_letters (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    portfolio_id UUID REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    sender VARCHAR(50) NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    notification JSONB NOT NULL,
+    error TEXT NOT NULL,
+    attempts INTEGER NOT NULL,
+    failed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT positive_dead_letter_attempts CHECK (attempts > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_dead_letters_failed_at
+ON notification_dead_letters(failed_at);
+
+-- Enable row level security
+ALTER TABLE notification_dead_letters ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY notification_dead_letters_access ON notification_dead_letters
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE notification_dead_letters IS 'Undelivered notifications kept for inspection and redelivery';
+COMMENT ON COLUMN notification_dead_letters.notification IS 'classification=internal; encrypted_at_rest=no; excluded from logs';
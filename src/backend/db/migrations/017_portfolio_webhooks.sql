@@ -0,0 +1,68 @@
+-- Schema version: 1.0.0
+-- Description: User-registered webhook endpoints and their delivery outbox
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Endpoints receiving signed portfolio mutation events; endpoints without a
+-- portfolio receive events for all portfolios of the user
+CREATE TABLE portfolio_webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    portfolio_id UUID REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT webhook_events_present CHECK (cardinality(events) > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_portfolio_webhooks_portfolio
+ON portfolio_webhooks(portfolio_id);
+
+CREATE INDEX IF NOT EXISTS idx_portfolio_webhooks_user
+ON portfolio_webhooks(user_id) WHERE portfolio_id IS NULL;
+
+-- Events queued per endpoint, retried with exponential backoff
+CREATE TABLE portfolio_webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id UUID NOT NULL REFERENCES portfolio_webhooks(id) ON DELETE CASCADE,
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    event VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_code INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ,
+    CONSTRAINT valid_delivery_status CHECK (status IN ('pending', 'succeeded', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_portfolio_webhook_deliveries_due
+ON portfolio_webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_portfolio_webhook_deliveries_webhook
+ON portfolio_webhook_deliveries(webhook_id, created_at DESC);
+
+-- Enable row level security
+ALTER TABLE portfolio_webhooks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE portfolio_webhook_deliveries ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY portfolio_webhooks_access ON portfolio_webhooks
+    FOR ALL
+    TO authenticated
+    USING (user_id = current_user_id());
+
+CREATE POLICY portfolio_webhook_deliveries_access ON portfolio_webhook_deliveries
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE portfolio_webhooks IS 'User-registered endpoints for signed portfolio mutation events';
+COMMENT ON TABLE portfolio_webhook_deliveries IS 'Webhook delivery outbox and status history';
+COMMENT ON COLUMN portfolio_webhooks.url IS 'classification=sensitive; encrypted_at_rest=no; excluded from logs and exports';
+COMMENT ON COLUMN portfolio_webhooks.secret IS 'classification=sensitive; encrypted_at_rest=no; excluded from logs and exports; never returned after registration';
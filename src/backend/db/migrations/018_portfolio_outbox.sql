@@ -0,0 +1,38 @@
+-- Schema version: 1.0.0
+-- Description: Transactional outbox of portfolio domain events published to Kafka
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Events written in the same transaction as the mutation they describe.
+-- Rows are kept without a foreign key so events of deleted portfolios are
+-- still published.
+CREATE TABLE portfolio_outbox (
+    id UUID PRIMARY KEY,
+    portfolio_id UUID NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ
+);
+
+-- Unpublished events in publishing order
+CREATE INDEX IF NOT EXISTS idx_portfolio_outbox_unpublished
+ON portfolio_outbox(created_at)
+WHERE published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_portfolio_outbox_published_at
+ON portfolio_outbox(published_at)
+WHERE published_at IS NOT NULL;
+
+-- Enable row level security
+ALTER TABLE portfolio_outbox ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY portfolio_outbox_access ON portfolio_outbox
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE portfolio_outbox IS 'Portfolio domain events awaiting or after publication to Kafka';
+COMMENT ON COLUMN portfolio_outbox.payload IS 'classification=internal; encrypted_at_rest=no; excluded from logs';
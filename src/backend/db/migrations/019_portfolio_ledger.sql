@@ -0,0 +1,62 @@
+-- Schema version: 1.0.0
+-- Description: Append-only portfolio event ledger and projection checkpoints
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Portfolio state changes in event-sourced storage mode. Sequence numbers
+-- start at 1 and are gapless per portfolio; the primary key rejects
+-- concurrent appends of the same sequence.
+CREATE TABLE portfolio_ledger_events (
+    portfolio_id UUID NOT NULL,
+    sequence BIGINT NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    data JSONB NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (portfolio_id, sequence),
+    CONSTRAINT positive_ledger_sequence CHECK (sequence > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_portfolio_ledger_events_recorded_at
+ON portfolio_ledger_events(portfolio_id, recorded_at);
+
+-- Reject changes to recorded events
+CREATE OR REPLACE FUNCTION reject_ledger_mutation() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'portfolio ledger events are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER portfolio_ledger_events_append_only
+    BEFORE UPDATE OR DELETE ON portfolio_ledger_events
+    FOR EACH ROW EXECUTE FUNCTION reject_ledger_mutation();
+
+-- Last ledger sequence applied to the portfolio read model tables
+CREATE TABLE portfolio_ledger_projections (
+    portfolio_id UUID PRIMARY KEY,
+    sequence BIGINT NOT NULL DEFAULT 0,
+    projected_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT non_negative_projection_sequence CHECK (sequence >= 0)
+);
+
+-- Enable row level security
+ALTER TABLE portfolio_ledger_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE portfolio_ledger_projections ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY portfolio_ledger_events_access ON portfolio_ledger_events
+    FOR SELECT
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+CREATE POLICY portfolio_ledger_projections_access ON portfolio_ledger_projections
+    FOR SELECT
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE portfolio_ledger_events IS 'Append-only portfolio event stream, the source of truth in event-sourced storage mode';
+COMMENT ON TABLE portfolio_ledger_projections IS 'Projection checkpoints of the portfolio read model';
+COMMENT ON COLUMN portfolio_ledger_events.data IS 'classification=sensitive; encrypted_at_rest=no; excluded from logs and exports';
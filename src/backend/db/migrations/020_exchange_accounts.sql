@@ -0,0 +1,36 @@
+-- Schema version: 1.0.0
+-- Description: Connected exchange accounts with encrypted credentials and sync checkpoints
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Exchange accounts synced into a portfolio in the background. Credentials
+-- are sealed by the service with AES-256-GCM bound to the portfolio and
+-- exchange; cursor is the checkpoint the next sync resumes from.
+CREATE TABLE exchange_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    exchange VARCHAR(32) NOT NULL,
+    credentials BYTEA NOT NULL,
+    cursor TEXT NOT NULL DEFAULT '',
+    last_synced_at TIMESTAMPTZ,
+    last_error TEXT,
+    next_sync_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_portfolio_exchange UNIQUE (portfolio_id, exchange)
+);
+
+CREATE INDEX IF NOT EXISTS idx_exchange_accounts_next_sync
+ON exchange_accounts(next_sync_at);
+
+-- Enable row level security
+ALTER TABLE exchange_accounts ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY exchange_accounts_access ON exchange_accounts
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE exchange_accounts IS 'Exchange accounts connected to portfolios for recurring import';
+COMMENT ON COLUMN exchange_accounts.credentials IS 'classification=secret; encrypted_at_rest=yes; excluded from logs and exports; never returned after connection';
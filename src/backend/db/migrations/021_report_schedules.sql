@@ -0,0 +1,44 @@
+-- Schema version: 1.0.0
+-- Description: Scheduled weekly and monthly performance report delivery
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Report schedules deliver the report of the period just completed to the
+-- portfolio owner. Delivery times and periods are taken in the user's
+-- timezone; next_run_at is the next delivery time in UTC.
+CREATE TABLE report_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    frequency VARCHAR(16) NOT NULL CHECK (frequency IN ('weekly', 'monthly')),
+    format VARCHAR(8) NOT NULL CHECK (format IN ('pdf', 'csv')),
+    timezone VARCHAR(64) NOT NULL,
+    hour SMALLINT NOT NULL CHECK (hour BETWEEN 0 AND 23),
+    weekday SMALLINT NOT NULL DEFAULT 0 CHECK (weekday BETWEEN 0 AND 6),
+    day_of_month SMALLINT NOT NULL DEFAULT 0 CHECK (day_of_month BETWEEN 0 AND 28),
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_run_at TIMESTAMPTZ,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_portfolio_report_frequency UNIQUE (portfolio_id, frequency)
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_schedules_next_run
+ON report_schedules(next_run_at);
+
+CREATE INDEX IF NOT EXISTS idx_report_schedules_user
+ON report_schedules(user_id);
+
+-- Enable row level security
+ALTER TABLE report_schedules ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY report_schedules_access ON report_schedules
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE report_schedules IS 'Recurring performance report delivery to portfolio owners';
+COMMENT ON COLUMN report_schedules.timezone IS 'IANA time zone delivery times and report periods are taken in';
+COMMENT ON COLUMN report_schedules.last_error IS 'Error of the last delivery attempt; cleared by a successful delivery';
@@ -0,0 +1,38 @@
+-- Schema version: 1.0.0
+-- Description: Tracked on-chain wallets materialized as read-only portfolios
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Wallet addresses whose public chain activity is synced into a portfolio of
+-- their owner. The portfolio is read-only to users while the wallet is
+-- tracked; cursor is the checkpoint the next sync resumes from.
+CREATE TABLE tracked_wallets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    chain VARCHAR(32) NOT NULL,
+    address VARCHAR(128) NOT NULL,
+    cursor TEXT NOT NULL DEFAULT '',
+    last_synced_at TIMESTAMPTZ,
+    last_error TEXT,
+    next_sync_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_user_chain_address UNIQUE (user_id, chain, address),
+    CONSTRAINT unique_tracked_wallet_portfolio UNIQUE (portfolio_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tracked_wallets_next_sync
+ON tracked_wallets(next_sync_at);
+
+-- Enable row level security
+ALTER TABLE tracked_wallets ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tracked_wallets_access ON tracked_wallets
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE tracked_wallets IS 'On-chain wallets synced into read-only portfolios';
+COMMENT ON COLUMN tracked_wallets.address IS 'classification=confidential; public chain address in canonical form; links the user to on-chain activity';
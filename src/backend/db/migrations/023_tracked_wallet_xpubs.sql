@@ -0,0 +1,6 @@
+-- Schema version: 1.0.0
+-- Description: Bitcoin HD wallets tracked by extended public key
+-- Dependencies: 022_tracked_wallets.sql
+
+-- An extended public key reveals every address of a wallet, not only one
+COMMENT ON COLUMN tracked_wallets.address IS 'classification=confidential; public chain address in canonical form, or the account xpub/ypub/zpub of a Bitcoin HD wallet; links the user to on-chain activity';
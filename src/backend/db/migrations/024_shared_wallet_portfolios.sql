@@ -0,0 +1,13 @@
+-- Schema version: 1.0.0
+-- Description: Tracked wallets on several chains sharing one portfolio
+-- Dependencies: 022_tracked_wallets.sql
+
+-- A portfolio may hold several tracked wallets of its owner, so holdings
+-- across chains form one portfolio; it stays read-only while any is tracked
+ALTER TABLE tracked_wallets DROP CONSTRAINT IF EXISTS unique_tracked_wallet_portfolio;
+
+-- Replaces the index of the dropped constraint for the read-only check
+CREATE INDEX IF NOT EXISTS idx_tracked_wallets_portfolio
+ON tracked_wallets(portfolio_id);
+
+COMMENT ON TABLE tracked_wallets IS 'On-chain wallets synced into read-only portfolios, which wallets on several chains may share';
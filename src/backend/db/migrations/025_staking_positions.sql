@@ -0,0 +1,42 @@
+-- Schema version: 1.0.0
+-- Description: Staking positions whose rewards are accrued automatically
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Staked asset holdings linked to where their rewards are read from. Chain
+-- positions are accrued by the staking worker from cursor; exchange
+-- positions by the sync of the portfolio's exchange account.
+CREATE TABLE staking_positions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    asset_id UUID NOT NULL REFERENCES portfolio_assets(asset_id) ON DELETE CASCADE,
+    source VARCHAR(16) NOT NULL CHECK (source IN ('chain', 'exchange')),
+    provider VARCHAR(32) NOT NULL,
+    address VARCHAR(128) NOT NULL DEFAULT '',
+    cursor TEXT NOT NULL DEFAULT '',
+    last_accrued_at TIMESTAMPTZ,
+    last_error TEXT,
+    next_accrual_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_staking_position_asset UNIQUE (asset_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_staking_positions_portfolio
+ON staking_positions(portfolio_id);
+
+CREATE INDEX IF NOT EXISTS idx_staking_positions_next_accrual
+ON staking_positions(next_accrual_at)
+WHERE source = 'chain';
+
+-- Enable row level security
+ALTER TABLE staking_positions ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY staking_positions_access ON staking_positions
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE staking_positions IS 'Staked asset holdings with automatically accrued rewards';
+COMMENT ON COLUMN staking_positions.address IS 'classification=confidential; stake account or validator on the chain; links the user to on-chain activity';
@@ -0,0 +1,56 @@
+-- Schema version: 1.0.0
+-- Description: Token symbol migrations with an audit trail of migrated holdings
+-- Dependencies: 003_portfolio_tables.sql, 008_transaction_archive.sql
+
+-- Tokens that migrated contracts or rebranded, mapped to their new symbol.
+-- Each unit of from_symbol became ratio units of to_symbol.
+CREATE TABLE symbol_migrations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    from_symbol VARCHAR(20) NOT NULL,
+    to_symbol VARCHAR(20) NOT NULL,
+    ratio NUMERIC(36,18) NOT NULL,
+    effective_at TIMESTAMPTZ NOT NULL,
+    assets_migrated INTEGER NOT NULL DEFAULT 0,
+    applied_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_symbol_migration_from UNIQUE (from_symbol),
+    CONSTRAINT distinct_migration_symbols CHECK (from_symbol <> to_symbol),
+    CONSTRAINT positive_migration_ratio CHECK (ratio > 0)
+);
+
+-- One row per holding moved to the new symbol of a migration
+CREATE TABLE symbol_migration_audit (
+    migration_id UUID NOT NULL REFERENCES symbol_migrations(id) ON DELETE CASCADE,
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    asset_id UUID NOT NULL REFERENCES portfolio_assets(asset_id) ON DELETE CASCADE,
+    from_symbol VARCHAR(20) NOT NULL,
+    to_symbol VARCHAR(20) NOT NULL,
+    ratio NUMERIC(36,18) NOT NULL,
+    amount_before DECIMAL(24,8) NOT NULL,
+    amount_after DECIMAL(24,8) NOT NULL,
+    transactions_relinked INTEGER NOT NULL DEFAULT 0,
+    lots_relinked INTEGER NOT NULL DEFAULT 0,
+    migrated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (migration_id, asset_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_symbol_migration_audit_portfolio
+ON symbol_migration_audit(portfolio_id);
+
+-- Finds the holdings of a migrated symbol
+CREATE INDEX IF NOT EXISTS idx_portfolio_assets_symbol
+ON portfolio_assets(symbol);
+
+-- Enable row level security on the per-portfolio audit trail
+ALTER TABLE symbol_migration_audit ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY symbol_migration_audit_access ON symbol_migration_audit
+    FOR SELECT
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE symbol_migrations IS 'Token contract migrations and rebrands mapped to their new symbol';
+COMMENT ON TABLE symbol_migration_audit IS 'Audit trail of holdings, transactions and lots moved to a new symbol';
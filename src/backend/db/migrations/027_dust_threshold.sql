@@ -0,0 +1,11 @@
+-- Schema version: 1.0.0
+-- Description: Per-portfolio dust threshold excluding small holdings from allocations
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Holdings worth less than dust_threshold units of the base currency are
+-- left out of allocation percentages, and hidden from portfolio responses
+-- when hide_dust is set. A zero threshold disables both.
+ALTER TABLE portfolios
+    ADD COLUMN dust_threshold NUMERIC(36,18) NOT NULL DEFAULT 0,
+    ADD COLUMN hide_dust BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD CONSTRAINT non_negative_dust_threshold CHECK (dust_threshold >= 0);
@@ -0,0 +1,17 @@
+-- Schema version: 1.0.0
+-- Description: Free-form tags attached to portfolio assets
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Tags are stored normalized to lower case, one row per asset and tag
+CREATE TABLE asset_tags (
+    asset_id UUID NOT NULL REFERENCES portfolio_assets(asset_id) ON DELETE CASCADE,
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    tag VARCHAR(32) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (asset_id, tag),
+    CONSTRAINT lower_case_tag CHECK (tag = lower(tag))
+);
+
+-- Filtering a portfolio's holdings, and a user's portfolios, by tag
+CREATE INDEX idx_asset_tags_portfolio_tag ON asset_tags(portfolio_id, tag);
+CREATE INDEX idx_asset_tags_tag ON asset_tags(tag, portfolio_id);
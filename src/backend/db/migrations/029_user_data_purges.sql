@@ -0,0 +1,30 @@
+-- Schema version: 1.0.0
+-- Description: Record of permanent user data erasures and audit trail lookups by portfolio
+-- Dependencies: 001_init.sql, 003_portfolio_tables.sql
+
+-- One row per erasure, holding only counts so it can be kept as proof that
+-- the user's portfolio data was removed. No foreign key, as the user is gone.
+CREATE TABLE user_data_purges (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    portfolios INTEGER NOT NULL DEFAULT 0,
+    assets INTEGER NOT NULL DEFAULT 0,
+    transactions INTEGER NOT NULL DEFAULT 0,
+    snapshots INTEGER NOT NULL DEFAULT 0,
+    alerts INTEGER NOT NULL DEFAULT 0,
+    audit_entries INTEGER NOT NULL DEFAULT 0,
+    archives INTEGER NOT NULL DEFAULT 0,
+    completed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_data_purges_user ON user_data_purges(user_id);
+
+-- Audit entries of a user's changes and of their portfolio rows are erased
+-- with the portfolios
+CREATE INDEX IF NOT EXISTS idx_audit_trail_changed_by ON audit_trail(changed_by);
+
+CREATE INDEX IF NOT EXISTS idx_audit_trail_portfolio
+ON audit_trail ((COALESCE(new_data, old_data)->>'portfolio_id'))
+WHERE table_name IN ('portfolios', 'portfolio_assets', 'portfolio_transactions');
+
+COMMENT ON TABLE user_data_purges IS 'classification=internal; counts of records erased per user data purge';
@@ -0,0 +1,32 @@
+-- Schema version: 1.0.0
+-- Description: Revocable, expiring read-only share links of portfolios
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Only the SHA-256 hash of a share token is stored; the token itself is
+-- returned once when the share is created
+CREATE TABLE portfolio_shares (
+    id UUID PRIMARY KEY,
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    token_hash BYTEA NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    CONSTRAINT share_expires_after_creation CHECK (expires_at > created_at)
+);
+
+-- Listing the shares of a portfolio
+CREATE INDEX idx_portfolio_shares_portfolio ON portfolio_shares(portfolio_id, created_at);
+
+-- Enable row level security
+ALTER TABLE portfolio_shares ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY portfolio_shares_access ON portfolio_shares
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE portfolio_shares IS 'Read-only share links granting access to a portfolio valuation and allocation';
+COMMENT ON COLUMN portfolio_shares.token_hash IS 'classification=secret; SHA-256 of the share token; excluded from logs and exports';
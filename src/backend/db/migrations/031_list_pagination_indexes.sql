@@ -0,0 +1,22 @@
+-- Schema version: 1.0.0
+-- Description: Keyset indexes serving paginated list endpoints
+-- Dependencies: 003_portfolio_tables.sql, 015_portfolio_alerts.sql, 017_portfolio_webhooks.sql
+
+-- List endpoints page through rows ordered by a sort key and ID, resuming
+-- after the last row of the previous page; the ID breaks ties between rows
+-- with equal sort keys
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_portfolios_user_created
+ON portfolios(user_id, created_at, portfolio_id);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_portfolio_transactions_portfolio_timestamp
+ON portfolio_transactions(portfolio_id, timestamp, transaction_id);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_portfolio_alert_events_portfolio_page
+ON portfolio_alert_events(portfolio_id, triggered_at DESC, id DESC);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_portfolio_webhook_deliveries_webhook_page
+ON portfolio_webhook_deliveries(webhook_id, created_at DESC, id DESC);
+
+-- Superseded by the keyset indexes above
+DROP INDEX CONCURRENTLY IF EXISTS idx_portfolio_alert_events_portfolio;
+DROP INDEX CONCURRENTLY IF EXISTS idx_portfolio_webhook_deliveries_webhook;
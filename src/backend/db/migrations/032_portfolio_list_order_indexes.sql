@@ -0,0 +1,13 @@
+-- Schema version: 1.0.0
+-- Description: Indexes serving portfolio listings sorted by value or name
+-- Dependencies: 031_list_pagination_indexes.sql
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_portfolios_user_total_value
+ON portfolios(user_id, total_value, portfolio_id);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_portfolios_user_name
+ON portfolios(user_id, name, portfolio_id);
+
+-- Filtering portfolios by the types of assets they hold
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_portfolio_assets_portfolio_type
+ON portfolio_assets(portfolio_id, type);
@@ -0,0 +1,10 @@
+-- Schema version: 1.0.0
+-- Description: Record envelope encryption of exchange credentials and webhook signing secrets
+-- Dependencies: 020_exchange_accounts.sql, 017_portfolio_webhooks.sql
+
+-- Values are sealed by the service: each is encrypted with its own AES-256-GCM
+-- data key, stored alongside it wrapped by a key encryption key held in Vault
+-- or configured locally. Webhook secrets registered before encryption was
+-- configured stay plaintext until the rotate-encryption-keys command seals them.
+COMMENT ON COLUMN exchange_accounts.credentials IS 'classification=secret; encrypted_at_rest=yes (envelope); excluded from logs and exports; decrypted only by account sync; never returned after connection';
+COMMENT ON COLUMN portfolio_webhooks.secret IS 'classification=secret; encrypted_at_rest=yes (envelope); excluded from logs and exports; decrypted only by webhook delivery; never returned after registration';
@@ -0,0 +1,17 @@
+-- Schema version: 1.0.0
+-- Description: Record when each current price was last confirmed by a market data provider
+-- Dependencies: 011_asset_prices_current.sql
+
+-- updated_at only moves when a price changes, so a symbol whose price is
+-- steady looks as old as one no provider returns any more. fetched_at moves
+-- on every refresh, letting valuations tell stale prices from steady ones.
+ALTER TABLE asset_prices_current
+ADD COLUMN IF NOT EXISTS fetched_at TIMESTAMPTZ;
+
+UPDATE asset_prices_current SET fetched_at = updated_at WHERE fetched_at IS NULL;
+
+ALTER TABLE asset_prices_current
+ALTER COLUMN fetched_at SET DEFAULT NOW(),
+ALTER COLUMN fetched_at SET NOT NULL;
+
+COMMENT ON COLUMN asset_prices_current.fetched_at IS 'Last time a provider returned this price, whether or not it changed';
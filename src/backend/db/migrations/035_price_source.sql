@@ -0,0 +1,11 @@
+-- Schema version: 1.0.0
+-- Description: Record which market data provider supplied each current price
+-- Dependencies: 034_price_fetched_at.sql
+
+-- Providers are consulted in priority order, so the price of a symbol comes
+-- from whichever one answered first. Keeping its name next to the price lets
+-- discrepancies between exchanges be traced back to the provider.
+ALTER TABLE asset_prices_current
+ADD COLUMN IF NOT EXISTS source VARCHAR(64);
+
+COMMENT ON COLUMN asset_prices_current.source IS 'Name of the provider that last returned this price, NULL when stored without one';
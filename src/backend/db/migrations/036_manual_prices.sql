@@ -0,0 +1,37 @@
+-- Schema version: 1.0.0
+-- Description: User-supplied prices of assets no market data provider quotes
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Illiquid tokens and private placements have no provider price. Users may
+-- record their own for a portfolio, valid from valid_from until valid_until
+-- or indefinitely. Valuations only use them for symbols without a provider
+-- quote, preferring the latest valid_from when several overlap.
+CREATE TABLE manual_prices (
+    id UUID PRIMARY KEY,
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    symbol VARCHAR(20) NOT NULL,
+    price NUMERIC(36,18) NOT NULL,
+    valid_from TIMESTAMPTZ NOT NULL,
+    valid_until TIMESTAMPTZ,
+    note TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT non_negative_manual_price CHECK (price >= 0),
+    CONSTRAINT manual_price_valid_window CHECK (valid_until IS NULL OR valid_until > valid_from)
+);
+
+-- Finding the prices of a portfolio valid at a point in time
+CREATE INDEX idx_manual_prices_portfolio_symbol ON manual_prices(portfolio_id, symbol, valid_from DESC);
+
+-- Enable row level security
+ALTER TABLE manual_prices ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY manual_prices_access ON manual_prices
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE manual_prices IS 'User-supplied prices valuing assets without a market data provider quote';
+COMMENT ON COLUMN manual_prices.note IS 'classification=sensitive; encrypted_at_rest=no; excluded from logs and exports';
@@ -0,0 +1,35 @@
+-- Schema version: 1.0.0
+-- Description: User-defined assets absent from every market data provider listing
+-- Dependencies: 003_portfolio_tables.sql, 036_manual_prices.sql
+
+-- Holdings of custom assets are stored with the 'custom' asset type and are
+-- never sent to market data providers; they are valued at manual prices
+ALTER TYPE portfolio_asset_type ADD VALUE IF NOT EXISTS 'custom';
+
+-- A custom asset is defined once per portfolio and symbol. decimals is the
+-- number of fractional digits amounts of it may carry.
+CREATE TABLE custom_assets (
+    id UUID PRIMARY KEY,
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    symbol VARCHAR(20) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    decimals SMALLINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_custom_asset_symbol UNIQUE (portfolio_id, symbol),
+    CONSTRAINT valid_custom_asset_decimals CHECK (decimals BETWEEN 0 AND 18),
+    CONSTRAINT valid_custom_asset_name CHECK (length(trim(name)) > 0)
+);
+
+-- Enable row level security
+ALTER TABLE custom_assets ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY custom_assets_access ON custom_assets
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE custom_assets IS 'User-defined assets tracked without market data provider prices';
+COMMENT ON COLUMN custom_assets.name IS 'classification=sensitive; encrypted_at_rest=no; excluded from logs and exports';
@@ -0,0 +1,9 @@
+-- Schema version: 1.0.0
+-- Description: Fiat cash balances held on exchanges as portfolio assets
+-- Dependencies: 003_portfolio_tables.sql, 035_price_source.sql
+
+-- Fiat balances are stored with the 'fiat' asset type and the ISO 4217 code
+-- of their currency as symbol. They are valued at exchange rates from the FX
+-- providers, stored in asset_prices_current like any other price, and the
+-- base currency is always valued at 1.
+ALTER TYPE portfolio_asset_type ADD VALUE IF NOT EXISTS 'fiat';
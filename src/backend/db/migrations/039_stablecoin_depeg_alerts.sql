@@ -0,0 +1,17 @@
+-- Schema version: 1.0.0
+-- Description: Alert rules on the pegs of held stablecoins
+-- Dependencies: 015_portfolio_alerts.sql
+
+-- Depeg rules fire when a held stablecoin's price deviates threshold percent
+-- or more from its peg, then stay quiet for window_seconds
+ALTER TABLE portfolio_alert_rules DROP CONSTRAINT IF EXISTS valid_alert_kind;
+ALTER TABLE portfolio_alert_rules
+ADD CONSTRAINT valid_alert_kind CHECK (kind IN ('value_drop', 'pnl_above', 'pnl_below', 'stablecoin_depeg'));
+
+-- The stablecoin that lost its peg, kept with the event so the history shows
+-- which holding triggered the rule
+ALTER TABLE portfolio_alert_events
+ADD COLUMN IF NOT EXISTS symbol VARCHAR(20);
+
+COMMENT ON TABLE portfolio_alert_rules IS 'Value drop, P&L crossing and stablecoin depeg rules evaluated on each portfolio snapshot';
+COMMENT ON COLUMN portfolio_alert_events.symbol IS 'Stablecoin that deviated from its peg for depeg rules, NULL otherwise';
@@ -0,0 +1,19 @@
+-- Schema version: 1.0.0
+-- Description: Held symbols no market data provider quotes anymore
+-- Dependencies: 011_asset_prices_current.sql, 034_price_fetched_at.sql
+
+-- A held symbol is delisted once providers answer the price refresh but none
+-- has quoted it for the configured delisting period. Its last stored price is
+-- kept so assets can be frozen at it, and the row is removed as soon as a
+-- provider quotes the symbol again.
+CREATE TABLE delisted_symbols (
+    symbol VARCHAR(20) PRIMARY KEY,
+    last_price DECIMAL(24,8) NOT NULL,
+    last_quoted_at TIMESTAMPTZ NOT NULL,
+    delisted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT positive_delisted_price CHECK (last_price >= 0)
+);
+
+COMMENT ON TABLE delisted_symbols IS 'Held symbols valued by the delisted price policy because no provider quotes them';
+COMMENT ON COLUMN delisted_symbols.last_price IS 'Last price a provider returned, used when delisted assets are frozen at their last known price';
+COMMENT ON COLUMN delisted_symbols.last_quoted_at IS 'When a provider last returned a price of the symbol';
@@ -0,0 +1,47 @@
+-- Schema version: 1.0.0
+-- Description: Links between transfers moving an asset across a user's portfolios
+-- Dependencies: 003_portfolio_tables.sql
+
+-- A transfer_out from one of a user's portfolios is linked to the
+-- transfer_in it arrived as in another, either automatically when the two
+-- have the same symbol and amount within the match window or by the user.
+-- The transfer_in is repriced to carry over the cost basis the transfer_out
+-- took from its lots; in_price_before keeps its own price so unlinking can
+-- restore it. Each transaction belongs to at most one link.
+CREATE TABLE transfer_links (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    out_transaction_id UUID NOT NULL REFERENCES portfolio_transactions(transaction_id) ON DELETE CASCADE,
+    in_transaction_id UUID NOT NULL REFERENCES portfolio_transactions(transaction_id) ON DELETE CASCADE,
+    out_portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    in_portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    symbol VARCHAR(20) NOT NULL,
+    amount NUMERIC(36,18) NOT NULL,
+    cost_basis NUMERIC(36,18) NOT NULL,
+    in_price_before NUMERIC(36,18) NOT NULL,
+    matched_by VARCHAR(10) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_transfer_link_out UNIQUE (out_transaction_id),
+    CONSTRAINT unique_transfer_link_in UNIQUE (in_transaction_id),
+    CONSTRAINT transfer_link_across_portfolios CHECK (out_portfolio_id <> in_portfolio_id),
+    CONSTRAINT valid_transfer_link_matched_by CHECK (matched_by IN ('auto', 'manual'))
+);
+
+-- Listing the links of a user
+CREATE INDEX idx_transfer_links_user ON transfer_links(user_id, created_at);
+
+-- Finding unlinked transfers of a symbol around a point in time
+CREATE INDEX idx_portfolio_transactions_transfers ON portfolio_transactions(portfolio_id, timestamp)
+    WHERE type IN ('transfer_in', 'transfer_out');
+
+-- Enable row level security
+ALTER TABLE transfer_links ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY transfer_links_access ON transfer_links
+    FOR ALL
+    TO authenticated
+    USING (user_id = current_user_id());
+
+COMMENT ON TABLE transfer_links IS 'Transfers between a user''s portfolios whose transfer_in carries over the cost basis of the transfer_out';
+COMMENT ON COLUMN transfer_links.cost_basis IS 'Cost basis the transfer_out took from its lots, carried to the transfer_in';
+COMMENT ON COLUMN transfer_links.in_price_before IS 'Price of the transfer_in before linking, restored when the link is removed';
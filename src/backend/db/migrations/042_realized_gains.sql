@@ -0,0 +1,48 @@
+-- Schema version: 1.0.0
+-- Description: Ledger of gains and losses realized by sells
+-- Dependencies: 003_portfolio_tables.sql
+
+-- Each sell records the gain or loss it realized against the FIFO lots it
+-- disposed of when it is recorded, so tax reports and income statements
+-- read stored figures instead of replaying lots. A transaction changed or
+-- recorded earlier than existing sells of its asset restates the gains of
+-- the sells from its timestamp forward. Rows are kept when their sells are
+-- archived, so there is no reference to the transaction.
+CREATE TABLE realized_gains (
+    id UUID PRIMARY KEY,
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    asset_id UUID NOT NULL,
+    transaction_id UUID NOT NULL,
+    symbol VARCHAR(20) NOT NULL,
+    quantity NUMERIC(36,18) NOT NULL,
+    cost_basis NUMERIC(36,18) NOT NULL,
+    proceeds NUMERIC(36,18) NOT NULL,
+    gain NUMERIC(36,18) NOT NULL,
+    disposed_at TIMESTAMPTZ NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_realized_gain_transaction UNIQUE (transaction_id),
+    CONSTRAINT realized_gain_quantity_non_negative CHECK (quantity >= 0),
+    CONSTRAINT realized_gain_cost_basis_non_negative CHECK (cost_basis >= 0)
+);
+
+-- Listing a portfolio's gains over a period
+CREATE INDEX idx_realized_gains_portfolio ON realized_gains(portfolio_id, disposed_at);
+
+-- Restating an asset's gains from a point in time
+CREATE INDEX idx_realized_gains_asset ON realized_gains(portfolio_id, asset_id, disposed_at);
+
+-- Enable row level security
+ALTER TABLE realized_gains ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY realized_gains_access ON realized_gains
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE realized_gains IS 'Gains and losses realized by sells against FIFO lots, recorded with the sells';
+COMMENT ON COLUMN realized_gains.cost_basis IS 'Cost basis of the lots the sell disposed of';
+COMMENT ON COLUMN realized_gains.proceeds IS 'Proceeds of the quantity taken from lots, net of capitalized fees';
+COMMENT ON COLUMN realized_gains.gain IS 'Proceeds less cost basis; negative for a loss';
@@ -0,0 +1,34 @@
+-- Schema version: 1.0.0
+-- Description: Tax settings of a user's jurisdiction applied by tax reports
+-- Dependencies: 001_init.sql
+
+-- Tax reports of a user's portfolios follow the rules of their
+-- jurisdiction: the day the tax year starts, how long a lot must be held
+-- to be long-term, and whether losses replaced within the wash-sale window
+-- are disallowed. Users without a row get the service's configured
+-- jurisdiction.
+CREATE TABLE user_tax_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    country CHAR(2) NOT NULL,
+    tax_year_start_month SMALLINT NOT NULL,
+    tax_year_start_day SMALLINT NOT NULL,
+    long_term_days INTEGER NOT NULL,
+    wash_sales BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT valid_tax_country CHECK (country ~ '^[A-Z]{2}$'),
+    CONSTRAINT valid_tax_year_start CHECK (tax_year_start_month BETWEEN 1 AND 12 AND tax_year_start_day BETWEEN 1 AND 28),
+    CONSTRAINT non_negative_long_term_days CHECK (long_term_days >= 0)
+);
+
+-- Enable row level security
+ALTER TABLE user_tax_settings ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY user_tax_settings_access ON user_tax_settings
+    FOR ALL
+    TO authenticated
+    USING (user_id = current_user_id());
+
+COMMENT ON TABLE user_tax_settings IS 'Tax rules of the jurisdiction of a user, applied by tax reports';
+COMMENT ON COLUMN user_tax_settings.country IS 'ISO 3166-1 alpha-2 code of the tax jurisdiction';
+COMMENT ON COLUMN user_tax_settings.long_term_days IS 'Days a lot must be held beyond to be long-term; 0 when holding period does not matter';
+COMMENT ON COLUMN user_tax_settings.wash_sales IS 'Whether losses replaced within the wash-sale window are disallowed';
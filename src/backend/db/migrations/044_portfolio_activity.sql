@@ -0,0 +1,43 @@
+-- Schema version: 1.0.0
+-- Description: Sync history and indexes of the portfolio activity feed
+-- Dependencies: 001_init.sql, 020_exchange_accounts.sql, 022_tracked_wallets.sql
+
+-- Each sync of a connected exchange account or tracked wallet, successful
+-- or not, so the activity feed can list them alongside transactions, asset
+-- changes and alerts. The accounts themselves only keep their latest sync.
+CREATE TABLE portfolio_sync_events (
+    id UUID PRIMARY KEY,
+    portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+    source VARCHAR(16) NOT NULL,
+    name VARCHAR(32) NOT NULL,
+    imported INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    synced_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT valid_sync_source CHECK (source IN ('exchange', 'wallet')),
+    CONSTRAINT non_negative_sync_imported CHECK (imported >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_portfolio_sync_events_portfolio_page
+ON portfolio_sync_events(portfolio_id, synced_at DESC, id DESC);
+
+-- Asset additions and removals are read from the audit trail of
+-- portfolio_assets by portfolio
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_audit_trail_portfolio_assets
+ON audit_trail((COALESCE(new_data, old_data)->>'portfolio_id'), changed_at DESC)
+WHERE table_name = 'portfolio_assets';
+
+-- Enable row level security
+ALTER TABLE portfolio_sync_events ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY portfolio_sync_events_access ON portfolio_sync_events
+    FOR ALL
+    TO authenticated
+    USING (portfolio_id IN (
+        SELECT portfolio_id FROM portfolios
+        WHERE user_id = current_user_id()
+    ));
+
+COMMENT ON TABLE portfolio_sync_events IS 'Syncs of connected exchange accounts and tracked wallets, listed by the activity feed';
+COMMENT ON COLUMN portfolio_sync_events.name IS 'Exchange of an exchange account or chain of a tracked wallet';
+COMMENT ON COLUMN portfolio_sync_events.imported IS 'Transactions recorded by the sync';
+COMMENT ON COLUMN portfolio_sync_events.error IS 'Why the sync failed; NULL when it succeeded';
@@ -0,0 +1,15 @@
+-- Schema version: 1.0.0
+-- Description: Archive objects still to be deleted after their records were purged
+-- Dependencies: 008_transaction_archive.sql, 029_user_data_purges.sql
+
+-- Written in the purge transaction that removes the archive records, so the
+-- objects are only deleted once the purge committed; rows are cleared as the
+-- objects are deleted and retried by the archiver until they are.
+CREATE TABLE archive_object_deletions (
+    object_key TEXT PRIMARY KEY,
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_archive_object_deletions_requested ON archive_object_deletions(requested_at);
+
+COMMENT ON TABLE archive_object_deletions IS 'classification=internal; archive objects pending deletion after a user data purge';
@@ -0,0 +1,64 @@
+-- Schema version: 1.0.0
+-- Description: Record of applied schema migrations checked by services at startup
+-- Dependencies: 001_init.sql
+
+-- One row per applied migration. Every migration from this one on ends by
+-- recording itself, so services can refuse to start against a schema older
+-- than the one they were built for.
+CREATE TABLE schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Migrations applied before the record existed
+INSERT INTO schema_migrations (version, name) VALUES
+    (1, '001_init'),
+    (2, '002_user_tables'),
+    (3, '003_portfolio_tables'),
+    (4, '004_market_tables'),
+    (5, '005_education_tables'),
+    (6, '006_security_tables'),
+    (7, '007_community_tables'),
+    (8, '008_transaction_archive'),
+    (9, '009_portfolio_snapshots'),
+    (10, '010_portfolio_changes'),
+    (11, '011_asset_prices_current'),
+    (12, '012_data_classification'),
+    (13, '013_allocation_targets'),
+    (14, '014_dca_plans'),
+    (15, '015_portfolio_alerts'),
+    (16, '016_notification_dead_letters'),
+    (17, '017_portfolio_webhooks'),
+    (18, '018_portfolio_outbox'),
+    (19, '019_portfolio_ledger'),
+    (20, '020_exchange_accounts'),
+    (21, '021_report_schedules'),
+    (22, '022_tracked_wallets'),
+    (23, '023_tracked_wallet_xpubs'),
+    (24, '024_shared_wallet_portfolios'),
+    (25, '025_staking_positions'),
+    (26, '026_symbol_migrations'),
+    (27, '027_dust_threshold'),
+    (28, '028_asset_tags'),
+    (29, '029_user_data_purges'),
+    (30, '030_portfolio_shares'),
+    (31, '031_list_pagination_indexes'),
+    (32, '032_portfolio_list_order_indexes'),
+    (33, '033_envelope_encryption'),
+    (34, '034_price_fetched_at'),
+    (35, '035_price_source'),
+    (36, '036_manual_prices'),
+    (37, '037_custom_assets'),
+    (38, '038_fiat_balances'),
+    (39, '039_stablecoin_depeg_alerts'),
+    (40, '040_delisted_symbols'),
+    (41, '041_transfer_links'),
+    (42, '042_realized_gains'),
+    (43, '043_user_tax_settings'),
+    (44, '044_portfolio_activity'),
+    (45, '045_archive_object_deletions'),
+    (46, '046_schema_migrations')
+ON CONFLICT (version) DO NOTHING;
+
+COMMENT ON TABLE schema_migrations IS 'classification=internal; schema migrations applied to the database';
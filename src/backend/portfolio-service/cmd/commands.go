@@ -0,0 +1,431 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "flag"
+    "fmt"
+    "os/signal"
+    "sort"
+    "strings"
+    "syscall"
+    "time"
+
+    "github.com/google/uuid"                        // v1.3.0
+    "github.com/shopspring/decimal"                 // v1.3.1
+    "go.uber.org/zap"                               // v1.24.0
+
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/envelope"
+    "bookman/portfolio-service/internal/exchanges"
+    "bookman/portfolio-service/internal/ledger"
+    "bookman/portfolio-service/internal/notifications"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/pricefeed"
+    "bookman/portfolio-service/internal/repository"
+    "bookman/portfolio-service/internal/services"
+    "bookman/portfolio-service/internal/snapshot"
+)
+
+// command implements a one-off maintenance task run instead of the server
+type command func(ctx context.Context, args []string, logger *zap.Logger) error
+
+// commands lists the maintenance subcommands by name
+var commands = map[string]command{
+    "backfill-changes":       runBackfillChanges,
+    "backfill-prices":        runBackfillPrices,
+    "config":                 runConfig,
+    "migrate-symbol":         runMigrateSymbol,
+    "redrive-notifications":  runRedriveNotifications,
+    "rebuild-projections":    runRebuildProjections,
+    "rotate-encryption-keys": runRotateEncryptionKeys,
+}
+
+// runCommand executes the named maintenance subcommand until it completes or
+// the process receives a termination signal
+func runCommand(name string, args []string, logger *zap.Logger) error {
+    cmd, ok := commands[name]
+    if !ok {
+        names := make([]string, 0, len(commands))
+        for n := range commands {
+            names = append(names, n)
+        }
+        sort.Strings(names)
+        return fmt.Errorf("unknown command %q (available: %s)", name, strings.Join(names, ", "))
+    }
+
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+    defer stop()
+
+    return cmd(ctx, args, logger)
+}
+
+// runBackfillChanges populates materialized portfolio change columns from snapshots
+func runBackfillChanges(ctx context.Context, args []string, logger *zap.Logger) error {
+    cfg, err := config.LoadConfig()
+    if err != nil {
+        return fmt.Errorf("failed to load configuration: %w", err)
+    }
+
+    repo, err := repository.NewPostgresRepository(cfg, logger)
+    if err != nil {
+        return fmt.Errorf("failed to initialize database: %w", err)
+    }
+    defer repo.Close()
+
+    return snapshot.BackfillChanges(ctx, repo, cfg.Snapshots.BatchSize, logger)
+}
+
+// runBackfillPrices stores daily price history of held or given symbols from
+// the configured providers, so returns and risk metrics have history from
+// the start instead of only after snapshots accumulate
+func runBackfillPrices(ctx context.Context, args []string, logger *zap.Logger) error {
+    flags := flag.NewFlagSet("backfill-prices", flag.ContinueOnError)
+    days := flags.Int("days", 365, "number of complete days before today to backfill; history is retained for 365 days")
+    symbols := flags.String("symbols", "", "comma separated symbols to backfill; all held symbols when empty")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if *days <= 0 {
+        return errors.New("days must be positive")
+    }
+
+    var list []string
+    for _, symbol := range strings.Split(*symbols, ",") {
+        if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+            list = append(list, symbol)
+        }
+    }
+
+    cfg, err := config.LoadConfig()
+    if err != nil {
+        return fmt.Errorf("failed to load configuration: %w", err)
+    }
+
+    repo, err := repository.NewPostgresRepository(cfg, logger)
+    if err != nil {
+        return fmt.Errorf("failed to initialize database: %w", err)
+    }
+    defer repo.Close()
+
+    providers, err := setupPriceProviders(cfg)
+    if err != nil {
+        return err
+    }
+
+    backfiller, err := pricefeed.NewBackfiller(repo, providers, logger)
+    if err != nil {
+        return fmt.Errorf("failed to initialize price backfill: %w", err)
+    }
+
+    result, err := backfiller.Backfill(ctx, list, *days)
+    if result != nil {
+        for _, symbol := range result.Missing {
+            logger.Warn("No price history found", zap.String("symbol", symbol))
+        }
+        logger.Info("Price backfill finished",
+            zap.Int("symbols", result.Symbols),
+            zap.Int64("bars_inserted", result.Inserted),
+            zap.Int("symbols_missing", len(result.Missing)),
+        )
+    }
+    return err
+}
+
+// runRotateEncryptionKeys rewraps stored exchange credentials and webhook
+// secrets under the current key encryption key, and seals those stored before
+// envelope encryption was enabled. Once it completes, the previous key can be
+// removed from the configuration.
+func runRotateEncryptionKeys(ctx context.Context, args []string, logger *zap.Logger) error {
+    flags := flag.NewFlagSet("rotate-encryption-keys", flag.ContinueOnError)
+    batchSize := flags.Int("batch-size", 100, "number of rows read per batch")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if *batchSize <= 0 {
+        return errors.New("batch-size must be positive")
+    }
+
+    cfg, err := config.LoadConfig()
+    if err != nil {
+        return fmt.Errorf("failed to load configuration: %w", err)
+    }
+
+    encryptor, err := setupEncryption(cfg)
+    if err != nil {
+        return err
+    }
+    if encryptor == nil {
+        return errors.New("no encryption keys are configured")
+    }
+    cipher, err := exchanges.NewCredentialCipher(encryptor, cfg.Exchanges.CredentialKeys())
+    if err != nil {
+        return fmt.Errorf("failed to initialize exchange credential cipher: %w", err)
+    }
+
+    repo, err := repository.NewPostgresRepository(cfg, logger)
+    if err != nil {
+        return fmt.Errorf("failed to initialize database: %w", err)
+    }
+    defer repo.Close()
+
+    var credentials, secrets int
+    for after := uuid.Nil; ; {
+        accounts, err := repo.ListSealedExchangeCredentials(ctx, after, *batchSize)
+        if err != nil {
+            return err
+        }
+        for _, account := range accounts {
+            rewrapped, changed, err := cipher.Rewrap(ctx, account.PortfolioID, account.Exchange, account.Credentials)
+            if err != nil {
+                return fmt.Errorf("failed to rewrap credentials of exchange account %s: %w", account.ID, err)
+            }
+            if !changed {
+                continue
+            }
+            replaced, err := repo.ReplaceExchangeCredentials(ctx, account.ID, account.Credentials, rewrapped)
+            if err != nil {
+                return err
+            }
+            if replaced {
+                credentials++
+            }
+        }
+        if len(accounts) < *batchSize {
+            break
+        }
+        after = accounts[len(accounts)-1].ID
+    }
+
+    for after := uuid.Nil; ; {
+        endpoints, err := repo.ListWebhookSecrets(ctx, after, *batchSize)
+        if err != nil {
+            return err
+        }
+        for _, endpoint := range endpoints {
+            var sealed string
+            changed := true
+            if envelope.IsSealedString(endpoint.Secret) {
+                sealed, changed, err = encryptor.RewrapString(ctx, endpoint.Secret)
+            } else {
+                sealed, err = encryptor.SealString(ctx, endpoint.Secret, models.WebhookSecretScope(endpoint.ID))
+            }
+            if err != nil {
+                return fmt.Errorf("failed to rewrap secret of webhook %s: %w", endpoint.ID, err)
+            }
+            if !changed {
+                continue
+            }
+            replaced, err := repo.ReplaceWebhookSecret(ctx, endpoint.ID, endpoint.Secret, sealed)
+            if err != nil {
+                return err
+            }
+            if replaced {
+                secrets++
+            }
+        }
+        if len(endpoints) < *batchSize {
+            break
+        }
+        after = endpoints[len(endpoints)-1].ID
+    }
+
+    logger.Info("Encryption key rotation finished",
+        zap.Int("exchange_credentials_rewrapped", credentials),
+        zap.Int("webhook_secrets_rewrapped", secrets),
+    )
+    return nil
+}
+
+// runRedriveNotifications redelivers dead-lettered notifications through the
+// configured senders
+func runRedriveNotifications(ctx context.Context, args []string, logger *zap.Logger) error {
+    flags := flag.NewFlagSet("redrive-notifications", flag.ContinueOnError)
+    limit := flags.Int("limit", 500, "maximum number of dead letters to redeliver")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if *limit <= 0 {
+        return errors.New("limit must be positive")
+    }
+
+    cfg, err := config.LoadConfig()
+    if err != nil {
+        return fmt.Errorf("failed to load configuration: %w", err)
+    }
+    if !cfg.Notifications.Enabled {
+        return errors.New("notifications are not enabled")
+    }
+
+    repo, err := repository.NewPostgresRepository(cfg, logger)
+    if err != nil {
+        return fmt.Errorf("failed to initialize database: %w", err)
+    }
+    defer repo.Close()
+
+    dispatcher, err := notifications.NewConfiguredDispatcher(repo, cfg.Notifications, logger)
+    if err != nil {
+        return fmt.Errorf("failed to initialize notifications: %w", err)
+    }
+    defer dispatcher.Close()
+
+    delivered, err := dispatcher.Redrive(ctx, *limit)
+    logger.Info("Notification redrive finished", zap.Int("delivered", delivered))
+    return err
+}
+
+// runRebuildProjections replays portfolio ledgers into the read model tables,
+// for one portfolio or all of them
+func runRebuildProjections(ctx context.Context, args []string, logger *zap.Logger) error {
+    flags := flag.NewFlagSet("rebuild-projections", flag.ContinueOnError)
+    portfolio := flags.String("portfolio", "", "portfolio ID to rebuild; all portfolios when empty")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+
+    portfolioID := uuid.Nil
+    if *portfolio != "" {
+        id, err := uuid.Parse(*portfolio)
+        if err != nil {
+            return fmt.Errorf("invalid portfolio ID: %w", err)
+        }
+        portfolioID = id
+    }
+
+    cfg, err := config.LoadConfig()
+    if err != nil {
+        return fmt.Errorf("failed to load configuration: %w", err)
+    }
+    if !cfg.Ledger.EventSourced() {
+        return errors.New("event-sourced storage is not enabled")
+    }
+
+    repo, err := repository.NewPostgresRepository(cfg, logger)
+    if err != nil {
+        return fmt.Errorf("failed to initialize database: %w", err)
+    }
+    defer repo.Close()
+
+    projector, err := ledger.NewProjector(repo, cfg.Ledger, logger)
+    if err != nil {
+        return fmt.Errorf("failed to initialize ledger projector: %w", err)
+    }
+
+    reset, err := repo.ResetLedgerProjections(ctx, portfolioID)
+    if err != nil {
+        return err
+    }
+
+    var applied int
+    if portfolioID != uuid.Nil {
+        applied, err = projector.Project(ctx, portfolioID)
+    } else {
+        applied, err = projector.RunOnce(ctx)
+    }
+    logger.Info("Projection rebuild finished",
+        zap.Int64("portfolios", reset),
+        zap.Int("events", applied),
+    )
+    return err
+}
+
+// runMigrateSymbol maps a migrated or rebranded token to its new symbol and
+// moves every holding of the old symbol to it with its history
+func runMigrateSymbol(ctx context.Context, args []string, logger *zap.Logger) error {
+    flags := flag.NewFlagSet("migrate-symbol", flag.ContinueOnError)
+    from := flags.String("from", "", "symbol of the token before the migration")
+    to := flags.String("to", "", "symbol of the token after the migration")
+    ratio := flags.String("ratio", "1", "units of the new token per unit of the old one")
+    effective := flags.String("effective", "", "RFC 3339 time the token migrated; now when empty")
+    batch := flags.Int("batch", 500, "number of holdings moved per batch")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+
+    migration := &models.SymbolMigration{FromSymbol: *from, ToSymbol: *to}
+    r, err := decimal.NewFromString(*ratio)
+    if err != nil {
+        return fmt.Errorf("invalid ratio: %w", err)
+    }
+    migration.Ratio = r
+    if *effective != "" {
+        at, err := time.Parse(time.RFC3339, *effective)
+        if err != nil {
+            return fmt.Errorf("invalid effective time: %w", err)
+        }
+        migration.EffectiveAt = at.UTC()
+    }
+
+    cfg, err := config.LoadConfig()
+    if err != nil {
+        return fmt.Errorf("failed to load configuration: %w", err)
+    }
+
+    repo, err := repository.NewPostgresRepository(cfg, logger)
+    if err != nil {
+        return fmt.Errorf("failed to initialize database: %w", err)
+    }
+    defer repo.Close()
+
+    // Event-sourced portfolios record the move in their ledgers
+    var opts []services.Option
+    if cfg.Ledger.EventSourced() {
+        opts = append(opts, services.WithEventSourcing())
+    }
+    svc, err := services.NewPortfolioService(repo, logger, opts...)
+    if err != nil {
+        return fmt.Errorf("failed to initialize portfolio service: %w", err)
+    }
+
+    applied, skipped, err := svc.MigrateSymbol(ctx, migration, *batch)
+    if err != nil {
+        return err
+    }
+    for _, assetID := range skipped {
+        logger.Warn("Holding not migrated", zap.String("asset_id", assetID.String()))
+    }
+    logger.Info("Symbol migration finished",
+        zap.String("migration_id", applied.ID.String()),
+        zap.Int("assets_migrated", applied.AssetsMigrated),
+        zap.Int("assets_skipped", len(skipped)),
+    )
+    return nil
+}
+
+// runConfig runs configuration tooling. "validate" loads and validates the
+// configuration, then prints the effective configuration with secrets masked,
+// so misconfigurations fail a CI/CD step instead of crash-looping pods.
+func runConfig(ctx context.Context, args []string, logger *zap.Logger) error {
+    if len(args) == 0 || args[0] != "validate" {
+        return errors.New("usage: config validate [-quiet]")
+    }
+
+    flags := flag.NewFlagSet("config validate", flag.ContinueOnError)
+    quiet := flags.Bool("quiet", false, "validate without printing the effective configuration")
+    if err := flags.Parse(args[1:]); err != nil {
+        return err
+    }
+
+    cfg, err := config.LoadConfig()
+    if err != nil {
+        return fmt.Errorf("invalid configuration: %w", err)
+    }
+
+    if !*quiet {
+        effective, err := json.MarshalIndent(config.Effective(cfg), "", "  ")
+        if err != nil {
+            return fmt.Errorf("failed to encode configuration: %w", err)
+        }
+        fmt.Println(string(effective))
+    }
+
+    if cfg.Origin.RemoteErr != nil {
+        logger.Warn("Remote configuration unavailable, using local configuration", zap.Error(cfg.Origin.RemoteErr))
+    }
+    logger.Info("Configuration is valid",
+        zap.String("source", cfg.Origin.Source),
+        zap.String("version", cfg.Origin.Version),
+    )
+    return nil
+}
@@ -7,73 +7,91 @@ import (
     "fmt"
     "log"
     "net"
+    "net/http"
     "os"
     "os/signal"
     "syscall"
     "time"
 
-    "github.com/prometheus/client_golang/prometheus" // v1.14.0
     "go.uber.org/zap"                               // v1.24.0
     "google.golang.org/grpc"                        // v1.50.0
     grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus" // v1.2.0
+    "google.golang.org/grpc/credentials/insecure"
     "google.golang.org/grpc/health/grpc_health_v1"
-    "google.golang.org/grpc/keepalive"
-    "google.golang.org/grpc/reflection"
 
+    "bookman/portfolio-service/internal/admin"
+    "bookman/portfolio-service/internal/archive"
+    "bookman/portfolio-service/internal/buildinfo"
+    "bookman/portfolio-service/internal/businessmetrics"
+    "bookman/portfolio-service/internal/chains"
+    "bookman/portfolio-service/internal/chainsync"
     "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/consistency"
+    "bookman/portfolio-service/internal/deadline"
+    "bookman/portfolio-service/internal/deprecation"
+    "bookman/portfolio-service/internal/drift"
+    "bookman/portfolio-service/internal/envelope"
+    "bookman/portfolio-service/internal/exchanges"
+    "bookman/portfolio-service/internal/exchangesync"
     "bookman/portfolio-service/internal/handlers"
+    "bookman/portfolio-service/internal/jobs"
+    "bookman/portfolio-service/internal/loadshed"
+    "bookman/portfolio-service/internal/logging"
+    "bookman/portfolio-service/internal/ledger"
+    "bookman/portfolio-service/internal/marketdata"
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/migrate"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/notifications"
+    "bookman/portfolio-service/internal/ops"
+    "bookman/portfolio-service/internal/outbox"
+    "bookman/portfolio-service/internal/pagination"
+    "bookman/portfolio-service/internal/playground"
+    "bookman/portfolio-service/internal/pricecache"
+    "bookman/portfolio-service/internal/pricefeed"
+    "bookman/portfolio-service/internal/reports"
+    "bookman/portfolio-service/internal/reportschedule"
+    "bookman/portfolio-service/internal/scheduler"
     "bookman/portfolio-service/internal/services"
+    "bookman/portfolio-service/internal/sharing"
+    "bookman/portfolio-service/internal/slo"
+    "bookman/portfolio-service/internal/staking"
+    "bookman/portfolio-service/internal/snapshot"
     "bookman/portfolio-service/internal/repository"
+    "bookman/portfolio-service/internal/transport"
+    "bookman/portfolio-service/internal/verifier"
+    "bookman/portfolio-service/internal/warmup"
+    "bookman/portfolio-service/internal/web"
+    "bookman/portfolio-service/internal/webhooks"
 )
 
 const (
-    version         = "1.0.0"
     shutdownTimeout = 30 * time.Second
-)
-
-// Define service metrics
-var (
-    requestLatency = prometheus.NewHistogramVec(
-        prometheus.HistogramOpts{
-            Name:    "portfolio_request_duration_seconds",
-            Help:    "Request latency in seconds",
-            Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
-        },
-        []string{"method"},
-    )
-
-    activeConnections = prometheus.NewGauge(
-        prometheus.GaugeOpts{
-            Name: "portfolio_active_connections",
-            Help: "Number of active gRPC connections",
-        },
-    )
 
-    errorCounter = prometheus.NewCounterVec(
-        prometheus.CounterOpts{
-            Name: "portfolio_errors_total",
-            Help: "Total number of portfolio service errors",
-        },
-        []string{"type"},
-    )
+    // sharedPortfolioMethod is the only RPC reachable with a share token
+    sharedPortfolioMethod = "/portfolio.PortfolioService/GetSharedPortfolio"
 )
 
-func init() {
-    // Register metrics with Prometheus
-    prometheus.MustRegister(requestLatency)
-    prometheus.MustRegister(activeConnections)
-    prometheus.MustRegister(errorCounter)
-}
-
 func main() {
-    // Initialize structured logging
+    // Initialize bootstrap logging until the logging configuration is loaded
     logger, err := zap.NewProduction()
     if err != nil {
         log.Fatalf("Failed to initialize logger: %v", err)
     }
     defer logger.Sync()
 
-    logger.Info("Starting portfolio service", zap.String("version", version))
+    // Run maintenance subcommand instead of the server when requested
+    if len(os.Args) > 1 {
+        if err := runCommand(os.Args[1], os.Args[2:], logger); err != nil {
+            logger.Fatal("Command failed", zap.String("command", os.Args[1]), zap.Error(err))
+        }
+        return
+    }
+
+    logger.Info("Starting portfolio service",
+        zap.String("version", buildinfo.Version),
+        zap.String("git_sha", buildinfo.GitSHA),
+    )
 
     // Load and validate configuration
     cfg, err := config.LoadConfig()
@@ -81,31 +99,509 @@ func main() {
         logger.Fatal("Failed to load configuration", zap.Error(err))
     }
 
-    // Initialize database connection
-    repo, err := repository.NewPostgresRepository(cfg, logger)
+    // Switch to the configured structured logging
+    logger, err = logging.New(cfg.Logging)
+    if err != nil {
+        log.Fatalf("Failed to initialize logger: %v", err)
+    }
+    defer logger.Sync()
+
+    // Register request metrics with the configured latency buckets
+    if err := metrics.Register(cfg.Metrics); err != nil {
+        logger.Fatal("Failed to register request metrics", zap.Error(err))
+    }
+
+    // Report the configuration in use, warning when the remote provider was
+    // unreachable and the local configuration applies
+    metrics.SetConfigVersion(metrics.ConfigActive, cfg.Origin.Source, cfg.Origin.Version)
+    logger.Info("Configuration loaded",
+        zap.String("source", cfg.Origin.Source),
+        zap.String("version", cfg.Origin.Version),
+    )
+    if cfg.Origin.RemoteErr != nil {
+        logger.Warn("Remote configuration unavailable, using local configuration", zap.Error(cfg.Origin.RemoteErr))
+    }
+
+    // Warm up before serving, bounded so a missing dependency fails the deploy
+    warmupCtx, cancelWarmup := context.WithTimeout(context.Background(), cfg.Startup.WarmupTimeout)
+    defer cancelWarmup()
+
+    // Apply pending migrations first when the service owns its schema, as
+    // the repository prepares its statements against the migrated tables
+    if cfg.Startup.Migrate {
+        migrations, err := migrate.Load(cfg.Startup.MigrationsDir)
+        if err != nil {
+            logger.Fatal("Failed to load schema migrations", zap.Error(err))
+        }
+        err = warmup.Wait(warmupCtx, "schema migrations", cfg.Startup.RetryInterval, logger, func(ctx context.Context) error {
+            db, err := repository.OpenDB(&cfg.Database)
+            if err != nil {
+                return err
+            }
+            defer db.Close()
+
+            applied, err := migrate.Apply(ctx, db, migrations)
+            for _, m := range applied {
+                logger.Info("Applied schema migration", zap.String("migration", m.Name))
+            }
+            return err
+        })
+        if err != nil {
+            logger.Fatal("Failed to apply schema migrations", zap.Error(err))
+        }
+    }
+
+    // Initialize database connection, waiting for the database to come up and
+    // its schema to be migrated
+    var repo *repository.PostgresRepository
+    err = warmup.Wait(warmupCtx, "database", cfg.Startup.RetryInterval, logger, func(ctx context.Context) error {
+        repo, err = repository.NewPostgresRepository(cfg, logger)
+        return err
+    })
     if err != nil {
         logger.Fatal("Failed to initialize database", zap.Error(err))
     }
     defer repo.Close()
 
+    // Refuse to start against a schema older than this build, waiting for
+    // the deploy pipeline to finish applying migrations
+    err = warmup.Wait(warmupCtx, "schema migrations", cfg.Startup.RetryInterval, logger, repo.CheckSchemaVersion)
+    if err != nil {
+        logger.Fatal("Database schema is not migrated", zap.Error(err), zap.Int("required", repository.SchemaVersion))
+    }
+
+    // Background jobs run until shutdown is requested
+    jobsCtx, stopJobs := context.WithCancel(context.Background())
+    defer stopJobs()
+
+    // Watch the remote configuration, reporting changes to apply on restart
+    if cfg.Remote.Provider != "" && cfg.Remote.WatchInterval > 0 {
+        metrics.SetConfigVersion(metrics.ConfigLatest, cfg.Origin.Source, cfg.Origin.Version)
+        go config.WatchRemote(jobsCtx, cfg, func(latest *config.Config, err error) {
+            if err != nil {
+                logger.Warn("Failed to reload remote configuration", zap.Error(err))
+                return
+            }
+            metrics.SetConfigVersion(metrics.ConfigLatest, latest.Origin.Source, latest.Origin.Version)
+            logger.Warn("Remote configuration changed, restart to apply", zap.String("version", latest.Origin.Version))
+        })
+    }
+
+    // Scheduled jobs run on one replica at a time, elected through advisory locks
+    coordinator, err := jobs.NewCoordinator(jobs.NewPostgresLocker(repo), cfg.Jobs, logger)
+    if err != nil {
+        logger.Fatal("Failed to initialize job coordinator", zap.Error(err))
+    }
+
+    var svcOpts []services.Option
+
+    // Initialize historical market data access for risk analytics
+    market, err := marketdata.NewReader(repo)
+    if err != nil {
+        logger.Fatal("Failed to initialize market data reader", zap.Error(err))
+    }
+    svcOpts = append(svcOpts, services.WithMarketData(market))
+
+    // Keep valuing with stored prices while providers are unavailable,
+    // flagging stale ones
+    if cfg.PriceRefresh.Enabled {
+        freshness, err := marketdata.NewFreshness(repo, cfg.PriceRefresh.StaleAfter, cfg.PriceRefresh.MaxStaleness)
+        if err != nil {
+            logger.Fatal("Failed to initialize price freshness", zap.Error(err))
+        }
+        svcOpts = append(svcOpts, services.WithPriceFreshness(freshness))
+
+        // Value assets no provider quotes anymore by policy rather than
+        // leaving them out
+        if cfg.PriceRefresh.DelistAfter > 0 {
+            svcOpts = append(svcOpts, services.WithDelisting(cfg.PriceRefresh.DelistedPricePolicy))
+        }
+    }
+
+    // Serve current prices from the in-process and Redis price caches
+    var priceCache *pricecache.Cache
+    var priceStore *pricecache.RedisStore
+    if cfg.Cache.Enabled {
+        prices, store, err := setupPriceCache(jobsCtx, cfg, repo)
+        if err != nil {
+            logger.Fatal("Failed to initialize price cache", zap.Error(err))
+        }
+        defer store.Close()
+        priceCache, priceStore = prices, store
+        svcOpts = append(svcOpts, services.WithPriceCache(prices))
+    }
+
+    // Initialize page token codec shared by list endpoints
+    pageTokens, err := setupPageTokens(cfg, logger)
+    if err != nil {
+        logger.Fatal("Failed to initialize page tokens", zap.Error(err))
+    }
+    svcOpts = append(svcOpts, services.WithPageTokens(pageTokens))
+
+    // Serialize imports and syncs of a portfolio across replicas
+    if cfg.Locking.Enabled {
+        svcOpts = append(svcOpts, services.WithPortfolioLocks(cfg.Locking.WaitTimeout))
+    }
+
+    // Cost airdropped and forked positions by the configured policies
+    svcOpts = append(svcOpts, services.WithCostBasisPolicies(cfg.CorporateActions.AirdropCostBasis, cfg.CorporateActions.ForkCostBasis))
+
+    // Tax assumptions of loss harvesting and holding-period splits
+    svcOpts = append(svcOpts, services.WithTaxEstimates(cfg.Tax.EstimatedRate, cfg.Tax.WashSaleWindow))
+    svcOpts = append(svcOpts, services.WithLongTermHoldingPeriod(cfg.Tax.LongTermHoldingPeriod))
+    svcOpts = append(svcOpts, services.WithTaxJurisdiction(cfg.Tax.Jurisdiction, cfg.Tax.WashSaleJurisdictions))
+
+    // Initialize cold archive of transaction history
+    if cfg.Archive.Enabled {
+        reader, archiver, err := setupArchive(jobsCtx, cfg, repo, logger)
+        if err != nil {
+            logger.Fatal("Failed to initialize transaction archive", zap.Error(err))
+        }
+        svcOpts = append(svcOpts, services.WithArchive(reader))
+        go archiver.Run(jobsCtx)
+    }
+
+    // Initialize notification dispatch for alerts and lifecycle events
+    var notifier *notifications.Dispatcher
+    if cfg.Notifications.Enabled {
+        notifier, err = notifications.NewConfiguredDispatcher(repo, cfg.Notifications, logger)
+        if err != nil {
+            logger.Fatal("Failed to initialize notifications", zap.Error(err))
+        }
+        defer notifier.Close()
+        svcOpts = append(svcOpts, services.WithNotifications(notifier))
+        go notifier.Run(jobsCtx)
+    }
+
+    // Envelope encryption of stored secrets; nil when no keys are configured
+    encryptor, err := setupEncryption(cfg)
+    if err != nil {
+        logger.Fatal("Failed to initialize envelope encryption", zap.Error(err))
+    }
+
+    // Start outbound webhook delivery for portfolio mutations
+    if cfg.Webhooks.Enabled {
+        deliverer, err := webhooks.NewDeliverer(repo, cfg.Webhooks, encryptor, logger)
+        if err != nil {
+            logger.Fatal("Failed to initialize webhook deliverer", zap.Error(err))
+        }
+        svcOpts = append(svcOpts, services.WithWebhooks(cfg.Webhooks.AllowInsecure, encryptor))
+        go deliverer.Run(jobsCtx)
+    }
+
+    // Start publishing outbox events to Kafka
+    if cfg.Outbox.Enabled {
+        publisher, err := outbox.NewKafkaPublisher(cfg.Outbox)
+        if err != nil {
+            logger.Fatal("Failed to initialize Kafka publisher", zap.Error(err))
+        }
+        defer publisher.Close()
+
+        relay, err := outbox.NewRelay(repo, publisher, cfg.Outbox, logger)
+        if err != nil {
+            logger.Fatal("Failed to initialize outbox relay", zap.Error(err))
+        }
+        go relay.Run(jobsCtx)
+    }
+
+    // Store portfolio changes as ledger events and keep the projection current
+    if cfg.Ledger.EventSourced() {
+        projector, err := ledger.NewProjector(repo, cfg.Ledger, logger)
+        if err != nil {
+            logger.Fatal("Failed to initialize ledger projector", zap.Error(err))
+        }
+        svcOpts = append(svcOpts, services.WithEventSourcing())
+        go projector.Run(jobsCtx)
+    }
+
+    // Registered exchanges and chains by sync source, which label the sync
+    // metrics
+    connectors := make(map[string][]string)
+
+    // Import exchange accounts; connectors share one HTTP client and the
+    // rate limits of their exchange
+    if cfg.Exchanges.Enabled {
+        registry := exchanges.NewRegistry(
+            &http.Client{Timeout: cfg.Exchanges.RequestTimeout},
+            exchanges.RetryPolicy{
+                MaxAttempts: cfg.Exchanges.MaxAttempts,
+                BaseDelay:   cfg.Exchanges.RetryBaseDelay,
+                MaxDelay:    cfg.Exchanges.RetryMaxDelay,
+            },
+        )
+        registry.Register(exchanges.Binance(cfg.Exchanges.Binance))
+        registry.Register(exchanges.Kraken(cfg.Exchanges.Kraken))
+        connectors[models.SyncSourceExchange] = registry.Names()
+
+        var cipher *exchanges.CredentialCipher
+        if encryptor != nil {
+            cipher, err = exchanges.NewCredentialCipher(encryptor, cfg.Exchanges.CredentialKeys())
+            if err != nil {
+                logger.Fatal("Failed to initialize exchange credential cipher", zap.Error(err))
+            }
+        }
+        svcOpts = append(svcOpts, services.WithExchanges(registry, cipher, cfg.Exchanges.CashAssets, cfg.Exchanges.SyncInterval))
+    }
+
+    // Track on-chain wallets; adapters share one HTTP client and the rate
+    // limits of their chain's backends
+    if cfg.Chains.Enabled {
+        registry := chains.NewRegistry(
+            &http.Client{Timeout: cfg.Chains.RequestTimeout},
+            exchanges.RetryPolicy{
+                MaxAttempts: cfg.Chains.MaxAttempts,
+                BaseDelay:   cfg.Chains.RetryBaseDelay,
+                MaxDelay:    cfg.Chains.RetryMaxDelay,
+            },
+        )
+        var enabledChains []chains.Chain
+        for _, evm := range []struct {
+            name   string
+            config config.EVMConfig
+        }{
+            {"ethereum", cfg.Chains.Ethereum},
+            {"polygon", cfg.Chains.Polygon},
+            {"bsc", cfg.Chains.BSC},
+        } {
+            if evm.config.Enabled {
+                enabledChains = append(enabledChains, chains.EVM(evm.name, evm.config))
+            }
+        }
+        if cfg.Chains.Bitcoin.Enabled {
+            enabledChains = append(enabledChains, chains.Bitcoin(cfg.Chains.Bitcoin))
+        }
+        if cfg.Chains.Solana.Enabled {
+            enabledChains = append(enabledChains, chains.Solana(cfg.Chains.Solana))
+        }
+        for _, chain := range enabledChains {
+            if err := registry.Register(chain); err != nil {
+                logger.Fatal("Failed to initialize chain adapter", zap.Error(err), zap.String("chain", chain.Name))
+            }
+        }
+        connectors[models.SyncSourceWallet] = registry.Names()
+        svcOpts = append(svcOpts, services.WithWallets(registry, cfg.Chains.PeggedAssets, cfg.Chains.SyncInterval))
+    }
+
+    // Allow owners to share read-only views of their portfolios
+    if cfg.Sharing.Enabled {
+        svcOpts = append(svcOpts, services.WithSharing(cfg.Sharing.DefaultTTL, cfg.Sharing.MaxTTL))
+    }
+
+    // Expose permanent erasure of user data to the compliance pipeline
+    if cfg.Compliance.PurgeEnabled {
+        svcOpts = append(svcOpts, services.WithUserDataPurge())
+    }
+
+    // Accrue staking rewards of chain and exchange staking positions
+    if cfg.Staking.Enabled {
+        svcOpts = append(svcOpts, services.WithStaking(cfg.Staking.AccrualInterval))
+    }
+
+    // Store generated performance reports for download
+    if cfg.Reports.Enabled {
+        store, err := reports.NewS3Store(jobsCtx, &cfg.Reports)
+        if err != nil {
+            logger.Fatal("Failed to initialize report store", zap.Error(err))
+        }
+        svcOpts = append(svcOpts, services.WithReports(store, cfg.Reports.Prefix, cfg.Reports.URLExpiry, cfg.Reports.DeliveryURLExpiry))
+    }
+
     // Initialize portfolio service
-    portfolioService, err := services.NewPortfolioService(repo, logger)
+    portfolioService, err := services.NewPortfolioService(repo, logger, svcOpts...)
     if err != nil {
         logger.Fatal("Failed to initialize portfolio service", zap.Error(err))
     }
 
+    // Run scheduled jobs on cron expressions when the scheduler is enabled
+    var sched *scheduler.Scheduler
+    if cfg.Scheduler.Enabled {
+        var locker jobs.Locker
+        if cfg.Jobs.LeaderElection {
+            locker = jobs.NewPostgresLocker(repo)
+        }
+        sched, err = scheduler.NewScheduler(cfg.Scheduler, locker, logger)
+        if err != nil {
+            logger.Fatal("Failed to initialize scheduler", zap.Error(err))
+        }
+    }
+
+    // Let operators run jobs on demand. Scheduled exclusive jobs hold their
+    // lock only while running, so triggered runs take it as well; jobs led
+    // by the coordinator hold theirs throughout, so triggered runs of those
+    // proceed on the replica they were triggered on.
+    var triggerLocker jobs.Locker
+    if sched != nil && cfg.Jobs.LeaderElection {
+        triggerLocker = jobs.NewPostgresLocker(repo)
+    }
+    triggers, err := jobs.NewTriggers(jobsCtx, triggerLocker, logger)
+    if err != nil {
+        logger.Fatal("Failed to initialize job triggers", zap.Error(err))
+    }
+
+    // startJob registers a job with the scheduler, or otherwise runs it on its
+    // own interval. Exclusive jobs run on one replica at a time; the others
+    // lease their work and run everywhere. Every job can also be triggered.
+    startJob := func(name string, exclusive bool, runOnce scheduler.RunFunc, run jobs.Job) {
+        triggers.Register(name, exclusive, runOnce)
+        switch {
+        case sched != nil:
+            register := sched.Register
+            if exclusive {
+                register = sched.RegisterExclusive
+            }
+            if err := register(name, runOnce); err != nil {
+                logger.Fatal("Failed to schedule job", zap.Error(err), zap.String("job", name))
+            }
+        case exclusive:
+            go coordinator.Run(jobsCtx, name, run)
+        default:
+            go run(jobsCtx)
+        }
+    }
+
+    // Start scheduled valuation snapshots
+    if cfg.Snapshots.Enabled {
+        recorder, err := snapshot.NewRecorder(repo, portfolioService, cfg.Snapshots, logger)
+        if err != nil {
+            logger.Fatal("Failed to initialize snapshot recorder", zap.Error(err))
+        }
+        startJob("snapshots", true, func(ctx context.Context) error {
+            return recorder.RunOnce(ctx, time.Now())
+        }, recorder.Run)
+    }
+
+    // Start ledger invariant verification
+    if cfg.Verifier.Enabled {
+        v, err := verifier.NewVerifier(repo, cfg.Verifier, logger)
+        if err != nil {
+            logger.Fatal("Failed to initialize ledger verifier", zap.Error(err))
+        }
+        startJob("ledger_verification", true, v.RunOnce, v.Run)
+    }
+
+    // Export aggregate business metrics for product dashboards
+    if cfg.BusinessMetrics.Enabled {
+        collector, err := businessmetrics.NewCollector(repo, cfg.BusinessMetrics, businessmetrics.NewConnectors(connectors), logger)
+        if err != nil {
+            logger.Fatal("Failed to initialize business metrics collector", zap.Error(err))
+        }
+        go collector.Run(jobsCtx)
+    }
+
+    // Refresh current prices of held symbols in the background so requests
+    // never wait on provider APIs, tracking provider availability
+    var priceHealth *pricefeed.Health
+    if cfg.PriceRefresh.Enabled {
+        priceHealth = pricefeed.NewHealth(cfg.PriceRefresh.StaleAfter, cfg.PriceRefresh.FailureThreshold)
+        refresher, err := setupPriceRefresh(cfg, repo, portfolioService, priceHealth, logger)
+        if err != nil {
+            logger.Fatal("Failed to initialize price refresher", zap.Error(err))
+        }
+        startJob("price_refresh", true, refresher.RunOnce, refresher.Run)
+    }
+
+    // Start allocation drift alerts
+    if cfg.Drift.AlertsEnabled {
+        monitor, err := drift.NewMonitor(repo, portfolioService, notifier, cfg.Drift, logger)
+        if err != nil {
+            logger.Fatal("Failed to initialize drift monitor", zap.Error(err))
+        }
+        startJob("drift_alerts", true, monitor.RunOnce, monitor.Run)
+    }
+
+    // Start background sync of connected exchange accounts
+    if cfg.Exchanges.Enabled && cfg.Exchanges.CredentialKeyEnv != "" {
+        syncer, err := exchangesync.NewSyncer(repo, portfolioService, cfg.Exchanges, logger)
+        if err != nil {
+            logger.Fatal("Failed to initialize exchange syncer", zap.Error(err))
+        }
+        startJob("exchange_syncs", false, syncer.RunOnce, syncer.Run)
+    }
+
+    // Start background sync of tracked wallets
+    if cfg.Chains.Enabled {
+        syncer, err := chainsync.NewSyncer(repo, portfolioService, cfg.Chains, logger)
+        if err != nil {
+            logger.Fatal("Failed to initialize wallet syncer", zap.Error(err))
+        }
+        startJob("wallet_syncs", false, syncer.RunOnce, syncer.Run)
+    }
+
+    // Start staking reward accrual of chain positions
+    if cfg.Staking.Enabled && cfg.Chains.Enabled {
+        accruer, err := staking.NewAccruer(repo, portfolioService, cfg.Staking, logger)
+        if err != nil {
+            logger.Fatal("Failed to initialize staking accruer", zap.Error(err))
+        }
+        startJob("staking_accruals", false, accruer.RunOnce, accruer.Run)
+    }
+
+    // Start scheduled report delivery
+    if cfg.Reports.Enabled && cfg.Notifications.Enabled {
+        deliveries, err := reportschedule.NewScheduler(repo, portfolioService, cfg.Reports, logger)
+        if err != nil {
+            logger.Fatal("Failed to initialize report scheduler", zap.Error(err))
+        }
+        startJob("report_deliveries", false, deliveries.RunOnce, deliveries.Run)
+    }
+
+    // Start the scheduler once all jobs are registered; it drains running
+    // jobs after shutdown is requested
+    schedulerDone := make(chan struct{})
+    if sched != nil {
+        go func() {
+            defer close(schedulerDone)
+            sched.Run(jobsCtx)
+        }()
+    } else {
+        close(schedulerDone)
+    }
+
+    info := buildinfo.Get(enabledFeatures(cfg)...)
+
+    // Measure calls against the service level objectives
+    var objectives *slo.Recorder
+    if cfg.SLO.Enabled {
+        objectives, err = slo.NewRecorder(cfg.SLO)
+        if err != nil {
+            logger.Fatal("Failed to initialize SLO recorder", zap.Error(err))
+        }
+        go objectives.Run(jobsCtx)
+    }
+
     // Initialize gRPC server
-    grpcServer, err := setupGRPCServer(cfg, portfolioService, logger)
+    // Start metrics server
+    metricsServer, closePlayground, err := setupMetricsServer(cfg, repo, priceStore, priceHealth, info, logger)
+    if err != nil {
+        logger.Fatal("Failed to setup metrics server", zap.Error(err))
+    }
+
+    // The gRPC health service reports NOT_SERVING until startup completes
+    health := ops.NewHealth(metricsServer)
+    grpcServer, err := setupGRPCServer(cfg, portfolioService, triggers, objectives, health, info, logger)
     if err != nil {
         logger.Fatal("Failed to setup gRPC server", zap.Error(err))
     }
+    if metricsServer != nil {
+        defer closePlayground()
+        go func() {
+            if err := metricsServer.ListenAndServe(); err != nil {
+                logger.Error("Metrics server failed", zap.Error(err))
+            }
+        }()
+    }
 
-    // Start metrics server
-    go func() {
-        if err := setupMetricsServer(cfg); err != nil {
-            logger.Error("Metrics server failed", zap.Error(err))
+    // Prime the price cache so first valuations do not all miss it; serving
+    // starts regardless once the warm-up timeout is reached
+    if priceCache != nil && cfg.Startup.PrimePrices {
+        primed, err := warmup.PrimePrices(warmupCtx, repo, priceCache, cfg.Startup.PrimeBatchSize)
+        if err != nil {
+            logger.Warn("Failed to prime price cache", zap.Error(err), zap.Int("primed", primed))
+        } else {
+            logger.Info("Price cache primed", zap.Int("symbols", primed))
         }
-    }()
+    }
+    cancelWarmup()
 
     // Start gRPC server
     listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port))
@@ -123,6 +619,26 @@ func main() {
         }
     }()
 
+    // Start gRPC-Web server for browser clients
+    var webServer *web.Server
+    if cfg.Server.GRPCWeb.Enabled {
+        webServer, err = web.NewServer(&cfg.Server, grpcServer, logger)
+        if err != nil {
+            logger.Fatal("Failed to setup gRPC-Web server", zap.Error(err))
+        }
+        go func() {
+            if err := webServer.ListenAndServe(); err != nil {
+                logger.Fatal("Failed to serve gRPC-Web", zap.Error(err))
+            }
+        }()
+    }
+
+    // Startup and readiness probes pass once every listener is serving
+    health.MarkStarted()
+    if metricsServer != nil {
+        metricsServer.MarkStarted()
+    }
+
     logger.Info("Portfolio service started",
         zap.String("address", listener.Addr().String()),
         zap.Int("port", cfg.Server.Port),
@@ -131,11 +647,19 @@ func main() {
     // Wait for shutdown signal
     sig := <-sigChan
     logger.Info("Received shutdown signal", zap.String("signal", sig.String()))
+    stopJobs()
 
     // Create shutdown context with timeout
     ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
     defer cancel()
 
+    // Stop accepting browser requests before draining gRPC
+    if webServer != nil {
+        if err := webServer.Shutdown(ctx); err != nil {
+            logger.Warn("gRPC-Web server shutdown failed", zap.Error(err))
+        }
+    }
+
     // Initiate graceful shutdown
     stopped := make(chan struct{})
     go func() {
@@ -150,26 +674,93 @@ func main() {
     case <-stopped:
         logger.Info("Graceful shutdown completed")
     }
+
+    // Keep serving metrics and probes until gRPC has drained
+    if metricsServer != nil {
+        if err := metricsServer.Shutdown(ctx); err != nil {
+            logger.Warn("Metrics server shutdown failed", zap.Error(err))
+        }
+    }
+
+    // Wait for scheduled jobs to drain before the database is closed
+    <-schedulerDone
 }
 
-// setupGRPCServer configures and returns a new gRPC server instance
-func setupGRPCServer(cfg *config.Config, svc *services.PortfolioService, logger *zap.Logger) (*grpc.Server, error) {
+// setupGRPCServer configures and returns a new gRPC server instance serving
+// health as its health service.
+func setupGRPCServer(cfg *config.Config, svc *services.PortfolioService, triggers *jobs.Triggers, objectives *slo.Recorder, health *ops.Health, info buildinfo.Info, logger *zap.Logger) (*grpc.Server, error) {
+    unaryInterceptors := []grpc.UnaryServerInterceptor{
+        grpc_prometheus.UnaryServerInterceptor,
+    }
+
+    streamInterceptors := []grpc.StreamServerInterceptor{
+        grpc_prometheus.StreamServerInterceptor,
+    }
+
+    // Measure every call, including those rejected by later interceptors
+    if objectives != nil {
+        unaryInterceptors = append(unaryInterceptors, objectives.UnaryServerInterceptor())
+        streamInterceptors = append(streamInterceptors, objectives.StreamServerInterceptor())
+    }
+
+    // Bound calls without a client deadline so abandoned requests release
+    // their database connections
+    if cfg.Deadlines.Enabled {
+        enforcer, err := deadline.NewEnforcer(cfg.Deadlines)
+        if err != nil {
+            return nil, fmt.Errorf("failed to create deadline enforcer: %w", err)
+        }
+        unaryInterceptors = append(unaryInterceptors, enforcer.UnaryServerInterceptor())
+        streamInterceptors = append(streamInterceptors, enforcer.StreamServerInterceptor())
+    }
+
+    // Reject calls early once a method is saturated
+    if cfg.LoadShedding.Enabled {
+        shedder, err := loadshed.NewShedder(cfg.LoadShedding)
+        if err != nil {
+            return nil, fmt.Errorf("failed to create load shedder: %w", err)
+        }
+        unaryInterceptors = append(unaryInterceptors, shedder.UnaryServerInterceptor())
+        streamInterceptors = append(streamInterceptors, shedder.StreamServerInterceptor())
+    }
+    unaryInterceptors = append(unaryInterceptors, consistency.UnaryServerInterceptor())
+
+    // Confine the AdminService and admin-only methods such as PurgeUserData
+    // to operators holding the admin scope
+    if cfg.Admin.Enabled {
+        authenticator, err := admin.NewAuthenticator(cfg.Admin)
+        if err != nil {
+            return nil, fmt.Errorf("failed to create admin authenticator: %w", err)
+        }
+        unaryInterceptors = append(unaryInterceptors, authenticator.UnaryServerInterceptor())
+        streamInterceptors = append(streamInterceptors, authenticator.StreamServerInterceptor())
+    }
+
+    // Confine share tokens to reads of the shared portfolio
+    if cfg.Sharing.Enabled {
+        unaryInterceptors = append(unaryInterceptors, sharing.UnaryServerInterceptor(svc, sharedPortfolioMethod))
+        streamInterceptors = append(streamInterceptors, sharing.StreamServerInterceptor())
+    }
+
+    // Signal deprecated methods and fields to callers
+    if cfg.Deprecation.Enabled {
+        signaler, err := deprecation.NewSignaler(cfg.Deprecation)
+        if err != nil {
+            return nil, fmt.Errorf("failed to create deprecation signaler: %w", err)
+        }
+        unaryInterceptors = append(unaryInterceptors, signaler.UnaryServerInterceptor())
+        streamInterceptors = append(streamInterceptors, signaler.StreamServerInterceptor())
+    }
+
     // Configure server options
-    opts := []grpc.ServerOption{
-        grpc.KeepaliveParams(keepalive.ServerParameters{
-            MaxConnectionIdle:     time.Minute * 5,
-            MaxConnectionAge:      time.Hour * 4,
-            MaxConnectionAgeGrace: time.Minute,
-            Time:                 time.Minute,
-            Timeout:             time.Second * 20,
-        }),
-        grpc.ChainUnaryInterceptor(
-            grpc_prometheus.UnaryServerInterceptor,
-        ),
-        grpc.ChainStreamInterceptor(
-            grpc_prometheus.StreamServerInterceptor,
-        ),
+    opts, err := transport.ServerOptions(&cfg.Server)
+    if err != nil {
+        return nil, err
     }
+    opts = append(opts,
+        grpc.ChainUnaryInterceptor(unaryInterceptors...),
+        grpc.ChainStreamInterceptor(streamInterceptors...),
+    )
 
     // Add TLS configuration if enabled
     if cfg.Server.TLSEnabled {
@@ -184,43 +775,339 @@ func setupGRPCServer(cfg *config.Config, svc *services.PortfolioService, logger
     server := grpc.NewServer(opts...)
 
     // Initialize portfolio handler
-    portfolioHandler, err := handlers.NewPortfolioHandler(svc, logger)
+    portfolioHandler, err := handlers.NewPortfolioHandler(svc, info, logger)
     if err != nil {
         return nil, fmt.Errorf("failed to create portfolio handler: %w", err)
     }
 
+    // Serve support and ops tooling behind the admin interceptor
+    if cfg.Admin.Enabled {
+        adminHandler, err := handlers.NewAdminHandler(portfolioHandler, triggers, logger)
+        if err != nil {
+            return nil, fmt.Errorf("failed to create admin handler: %w", err)
+        }
+        models.RegisterAdminServiceServer(server, adminHandler)
+    }
+
     // Register services
-    grpc_health_v1.RegisterHealthServer(server, &healthServer{})
+    grpc_health_v1.RegisterHealthServer(server, health)
     grpc_prometheus.Register(server)
-    reflection.Register(server)
+    transport.RegisterReflection(server, &cfg.Server)
 
-    // Enable metrics for all RPCs
+    // Enable metrics for all RPCs, exported through the service registry
     grpc_prometheus.EnableHandlingTimeHistogram()
+    metrics.MustRegister(grpc_prometheus.DefaultServerMetrics)
 
     return server, nil
 }
 
-// setupMetricsServer initializes and starts the metrics HTTP server
-func setupMetricsServer(cfg *config.Config) error {
+// setupMetricsServer initializes the metrics HTTP server along with the
+// health, startup, readiness and build information endpoints, returning nil
+// when metrics are disabled. The price cache is checked for readiness when
+// priceStore is set, and market data availability is reported when
+// priceHealth is set. The returned function releases the playground's
+// connection once the server has shut down.
+func setupMetricsServer(cfg *config.Config, repo *repository.PostgresRepository, priceStore *pricecache.RedisStore, priceHealth *pricefeed.Health, info buildinfo.Info, logger *zap.Logger) (*ops.Server, func(), error) {
     if !cfg.Metrics.Enabled {
-        return nil
+        return nil, func() {}, nil
+    }
+
+    server, err := ops.NewServer(&cfg.Metrics, info, logger)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to create ops server: %w", err)
+    }
+    server.AddReadinessCheck("database", repo.Ping)
+    if priceStore != nil {
+        server.AddReadinessCheck("cache", priceStore.Ping)
+    }
+    if priceHealth != nil {
+        server.AddDependencyCheck("market_data", func(ctx context.Context) ops.DependencyStatus {
+            market := priceHealth.Status(time.Now())
+            return ops.DependencyStatus{Degraded: market.Degraded, Details: market}
+        })
+    }
+
+    closePlayground := func() {}
+    if cfg.Playground.Enabled {
+        pg, closeConn, err := setupPlayground(cfg, logger)
+        if err != nil {
+            return nil, nil, fmt.Errorf("failed to create API playground: %w", err)
+        }
+        closePlayground = closeConn
+        server.Handle(pg.Prefix(), pg)
+        logger.Warn("API playground enabled; do not use in production",
+            zap.String("path", pg.Prefix()),
+        )
+    }
+
+    return server, closePlayground, nil
+}
+
+// setupPlayground creates the developer API playground bridged to the local gRPC server
+func setupPlayground(cfg *config.Config, logger *zap.Logger) (*playground.Playground, func(), error) {
+    host := cfg.Server.Host
+    if host == "" || host == "0.0.0.0" || host == "::" {
+        host = "localhost"
+    }
+
+    conn, err := grpc.Dial(
+        net.JoinHostPort(host, fmt.Sprint(cfg.Server.Port)),
+        grpc.WithTransportCredentials(insecure.NewCredentials()),
+        grpc.WithDefaultCallOptions(
+            grpc.MaxCallRecvMsgSize(cfg.Server.MaxSendMsgSize),
+            grpc.MaxCallSendMsgSize(cfg.Server.MaxRecvMsgSize),
+        ),
+    )
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to dial local gRPC server: %w", err)
+    }
+
+    pg, err := playground.New(conn, "portfolio.PortfolioService", cfg.Playground.Path, logger)
+    if err != nil {
+        conn.Close()
+        return nil, nil, err
+    }
+
+    return pg, func() { conn.Close() }, nil
+}
+
+// setupArchive initializes the object store backed archive reader and archival job
+func setupArchive(ctx context.Context, cfg *config.Config, repo *repository.PostgresRepository, logger *zap.Logger) (*archive.Reader, *archive.Archiver, error) {
+    store, err := archive.NewS3Store(ctx, &cfg.Archive)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to create archive store: %w", err)
+    }
+
+    reader, err := archive.NewReader(repo, store)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to create archive reader: %w", err)
+    }
+
+    archiver, err := archive.NewArchiver(repo, store, cfg.Archive, logger)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to create archiver: %w", err)
+    }
+
+    return reader, archiver, nil
+}
+
+// setupPriceCache connects the Redis price store and layers the in-process
+// cache over it
+func setupPriceCache(ctx context.Context, cfg *config.Config, repo *repository.PostgresRepository) (*pricecache.Cache, *pricecache.RedisStore, error) {
+    store, err := pricecache.NewRedisStore(ctx, cfg.Cache)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to create price store: %w", err)
+    }
+
+    cache, err := pricecache.New(repo, store, cfg.Cache.LocalSize, cfg.Cache.LocalTTL, cfg.Cache.TTL)
+    if err != nil {
+        store.Close()
+        return nil, nil, fmt.Errorf("failed to create price cache: %w", err)
+    }
+
+    return cache, store, nil
+}
+
+// setupPriceRefresh creates the price refresh job over the configured
+// providers in priority order, refreshing fiat exchange rates as well when FX
+// providers are configured
+func setupPriceRefresh(cfg *config.Config, repo *repository.PostgresRepository, svc *services.PortfolioService, health *pricefeed.Health, logger *zap.Logger) (*pricefeed.Refresher, error) {
+    providers, err := setupPriceProviders(cfg)
+    if err != nil {
+        return nil, err
+    }
+
+    fetcher, err := pricefeed.NewFetcher(providers, health, logger)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create price fetcher: %w", err)
+    }
+
+    var fx *pricefeed.Fetcher
+    if len(cfg.FXProviders) > 0 {
+        fxProviders, err := pricefeed.NewFXProviders(
+            cfg.FXProvidersByPriority(),
+            &http.Client{Timeout: cfg.PriceRefresh.RequestTimeout},
+            priceRetryPolicy(cfg),
+        )
+        if err != nil {
+            return nil, fmt.Errorf("failed to create fx providers: %w", err)
+        }
+
+        fx, err = pricefeed.NewFetcher(fxProviders, health, logger)
+        if err != nil {
+            return nil, fmt.Errorf("failed to create fx fetcher: %w", err)
+        }
     }
 
-    http.Handle(cfg.Metrics.Path, promhttp.Handler())
-    addr := fmt.Sprintf("%s:%d", cfg.Metrics.Host, cfg.Metrics.Port)
+    return pricefeed.NewRefresher(repo, svc, fetcher, fx, cfg.PriceRefresh, logger)
+}
+
+// setupPriceProviders creates clients of the configured price providers in
+// priority order, with the request settings of the price refresh
+func setupPriceProviders(cfg *config.Config) ([]pricefeed.Provider, error) {
+    providers, err := pricefeed.NewProviders(
+        cfg.ProvidersByPriority(),
+        &http.Client{Timeout: cfg.PriceRefresh.RequestTimeout},
+        priceRetryPolicy(cfg),
+    )
+    if err != nil {
+        return nil, fmt.Errorf("failed to create price providers: %w", err)
+    }
+    return providers, nil
+}
 
-    return http.ListenAndServe(addr, nil)
+// priceRetryPolicy returns the retry policy of price provider requests
+func priceRetryPolicy(cfg *config.Config) exchanges.RetryPolicy {
+    return exchanges.RetryPolicy{
+        MaxAttempts: cfg.PriceRefresh.MaxAttempts,
+        BaseDelay:   cfg.PriceRefresh.RetryBaseDelay,
+        MaxDelay:    cfg.PriceRefresh.RetryMaxDelay,
+    }
 }
 
-// healthServer implements the gRPC health check service
-type healthServer struct{}
+// setupEncryption creates the envelope encryptor of stored secrets from the
+// configured key encryption keys. Without an encryption provider the exchange
+// credential keys serve as local keys; nil is returned when neither is set.
+func setupEncryption(cfg *config.Config) (*envelope.Encryptor, error) {
+    var keks []envelope.KeyEncryptionKey
+
+    switch cfg.Encryption.Provider {
+    case config.EncryptionProviderVault:
+        vault := cfg.Encryption.Vault
+        client := &http.Client{Timeout: vault.Timeout}
+        for _, name := range []string{vault.Key, vault.PreviousKey} {
+            if name == "" {
+                continue
+            }
+            kek, err := envelope.NewVaultTransitKey(client, vault.Address, vault.Mount, name, os.Getenv(vault.TokenEnv))
+            if err != nil {
+                return nil, fmt.Errorf("failed to create Vault key encryption key: %w", err)
+            }
+            keks = append(keks, kek)
+        }
+    default:
+        secrets := cfg.Encryption.LocalKeys()
+        if !cfg.Encryption.Enabled() {
+            secrets = cfg.Exchanges.CredentialKeys()
+        }
+        for _, secret := range secrets {
+            kek, err := envelope.NewLocalKey(secret)
+            if err != nil {
+                return nil, fmt.Errorf("failed to create local key encryption key: %w", err)
+            }
+            keks = append(keks, kek)
+        }
+    }
+
+    if len(keks) == 0 {
+        return nil, nil
+    }
+    return envelope.NewEncryptor(keks[0], keks[1:]...)
+}
 
-func (s *healthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
-    return &grpc_health_v1.HealthCheckResponse{
-        Status: grpc_health_v1.HealthCheckResponse_SERVING,
-    }, nil
+// setupPageTokens creates the page token codec from the configured secrets,
+// falling back to a per-process secret when none is configured
+func setupPageTokens(cfg *config.Config, logger *zap.Logger) (*pagination.Codec, error) {
+    secrets := cfg.Pagination.Secrets()
+    if len(secrets) == 0 {
+        logger.Warn("No pagination secret configured; page tokens will not survive restarts or work across replicas")
+        return pagination.NewEphemeralCodec(cfg.Pagination.TokenTTL)
+    }
+    return pagination.NewCodec(secrets, cfg.Pagination.TokenTTL)
 }
 
-func (s *healthServer) Watch(*grpc_health_v1.HealthCheckRequest, grpc_health_v1.Health_WatchServer) error {
-    return status.Error(codes.Unimplemented, "health check watching is not implemented")
-}
\ No newline at end of file
+// enabledFeatures lists the optional features enabled by configuration
+func enabledFeatures(cfg *config.Config) []string {
+    var features []string
+    if cfg.Server.TLSEnabled {
+        features = append(features, "tls")
+    }
+    if cfg.Metrics.Enabled {
+        features = append(features, "metrics")
+    }
+    if cfg.Cache.Enabled {
+        features = append(features, "cache")
+    }
+    if cfg.PriceRefresh.Enabled {
+        features = append(features, "price_refresh")
+    }
+    if cfg.Jobs.LeaderElection {
+        features = append(features, "leader_election")
+    }
+    if cfg.Locking.Enabled {
+        features = append(features, "portfolio_locks")
+    }
+    if cfg.Scheduler.Enabled {
+        features = append(features, "cron_scheduler")
+    }
+    if cfg.Archive.Enabled {
+        features = append(features, "archive")
+    }
+    if cfg.Encryption.Enabled() {
+        features = append(features, "envelope_encryption")
+    }
+    if cfg.Snapshots.Enabled {
+        features = append(features, "snapshots")
+    }
+    if cfg.Verifier.Enabled {
+        features = append(features, "verifier")
+    }
+    if cfg.BusinessMetrics.Enabled {
+        features = append(features, "business_metrics")
+    }
+    if cfg.Playground.Enabled {
+        features = append(features, "playground")
+    }
+    if cfg.Server.GRPCWeb.Enabled {
+        features = append(features, "grpc_web")
+    }
+    if cfg.Server.Reflection {
+        features = append(features, "reflection")
+    }
+    if cfg.Drift.AlertsEnabled {
+        features = append(features, "drift_alerts")
+    }
+    if cfg.SLO.Enabled {
+        features = append(features, "slo")
+    }
+    if cfg.Deadlines.Enabled {
+        features = append(features, "default_deadlines")
+    }
+    if cfg.LoadShedding.Enabled {
+        features = append(features, "load_shedding")
+    }
+    if cfg.Database.ReplicaHost != "" {
+        features = append(features, "read_replica")
+    }
+    if cfg.Notifications.Enabled {
+        features = append(features, "notifications")
+    }
+    if cfg.Webhooks.Enabled {
+        features = append(features, "webhooks")
+    }
+    if cfg.Outbox.Enabled {
+        features = append(features, "outbox")
+    }
+    if cfg.Ledger.EventSourced() {
+        features = append(features, "event_sourced")
+    }
+    if cfg.Exchanges.Enabled {
+        features = append(features, "exchange_import")
+    }
+    if cfg.Exchanges.Enabled && cfg.Exchanges.CredentialKeyEnv != "" {
+        features = append(features, "exchange_sync")
+    }
+    if cfg.Reports.Enabled {
+        features = append(features, "reports")
+    }
+    if cfg.Reports.Enabled && cfg.Notifications.Enabled {
+        features = append(features, "report_schedules")
+    }
+    if cfg.Chains.Enabled {
+        features = append(features, "wallet_tracking")
+    }
+    if cfg.Staking.Enabled {
+        features = append(features, "staking_rewards")
+    }
+    return features
+}
@@ -0,0 +1,187 @@
+// Package admin authenticates support and ops tooling calling the internal
+// AdminService. Operators send a bearer token in the authorization metadata;
+// only the SHA-256 hash of each token is configured, along with the scopes it
+// grants. The interceptor confines AdminService methods, and the admin-only
+// methods of other services, to callers holding the admin scope.
+package admin
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc"          // v1.50.0
+	"google.golang.org/grpc/codes"    // v1.50.0
+	"google.golang.org/grpc/metadata" // v1.50.0
+	"google.golang.org/grpc/status"   // v1.50.0
+
+	"bookman/portfolio-service/internal/config"
+)
+
+// MetadataKey is the gRPC metadata key carrying operator tokens
+const MetadataKey = "authorization"
+
+// bearerPrefix precedes the token in the metadata value
+const bearerPrefix = "Bearer "
+
+// ScopeAdmin is the scope AdminService methods require
+const ScopeAdmin = "admin"
+
+// ServicePrefix prefixes the full method names of AdminService methods
+const ServicePrefix = "/portfolio.AdminService/"
+
+// PurgeUserDataMethod is the full method name of the admin-only erasure of a
+// user's data, served by the PortfolioService
+const PurgeUserDataMethod = "/portfolio.PortfolioService/PurgeUserData"
+
+// gatedMethods are the admin-only methods outside the AdminService
+var gatedMethods = map[string]bool{
+	PurgeUserDataMethod: true,
+}
+
+// RequiresAdmin reports whether calls to fullMethod need an operator token
+// granting the admin scope
+func RequiresAdmin(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, ServicePrefix) || gatedMethods[fullMethod]
+}
+
+type operatorKey struct{}
+
+// Operator is the holder of a token that authenticated a call
+type Operator struct {
+	Name   string
+	Scopes []string
+}
+
+// HasScope reports whether the operator was granted scope
+func (o *Operator) HasScope(scope string) bool {
+	for _, granted := range o.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// operatorToken is a configured token by its hash
+type operatorToken struct {
+	hash     []byte
+	operator *Operator
+}
+
+// Authenticator resolves operator tokens to the operators they were issued to
+type Authenticator struct {
+	tokens []operatorToken
+}
+
+// NewAuthenticator creates an authenticator of the configured tokens
+func NewAuthenticator(cfg config.AdminConfig) (*Authenticator, error) {
+	if len(cfg.Tokens) == 0 {
+		return nil, errors.New("at least one admin token is required")
+	}
+
+	a := &Authenticator{tokens: make([]operatorToken, 0, len(cfg.Tokens))}
+	for _, token := range cfg.Tokens {
+		hash, err := hex.DecodeString(token.Hash)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, errors.New("admin token hashes must be hex-encoded SHA-256 digests")
+		}
+		a.tokens = append(a.tokens, operatorToken{
+			hash:     hash,
+			operator: &Operator{Name: token.Name, Scopes: append([]string(nil), token.Scopes...)},
+		})
+	}
+	return a, nil
+}
+
+// HashToken returns the hex-encoded hash a token is configured by
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticate returns the operator a token was issued to, or nil when it
+// matches no configured token. Every configured hash is compared in constant
+// time, so timing does not reveal which one nearly matched.
+func (a *Authenticator) Authenticate(token string) *Operator {
+	if token == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(token))
+
+	var found *Operator
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(sum[:], t.hash) == 1 {
+			found = t.operator
+		}
+	}
+	return found
+}
+
+// WithOperator returns a context authenticated as operator
+func WithOperator(ctx context.Context, operator *Operator) context.Context {
+	return context.WithValue(ctx, operatorKey{}, operator)
+}
+
+// FromContext returns the operator that authenticated the request in ctx,
+// if any
+func FromContext(ctx context.Context) (*Operator, bool) {
+	operator, ok := ctx.Value(operatorKey{}).(*Operator)
+	return operator, ok && operator != nil
+}
+
+// bearerToken returns the bearer token of the incoming metadata of ctx
+func bearerToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(MetadataKey)
+	if len(values) == 0 || !strings.HasPrefix(values[0], bearerPrefix) {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(values[0], bearerPrefix))
+}
+
+// authorize authenticates calls to admin-only methods and checks the
+// operator holds the admin scope. Other calls pass through unchanged.
+func (a *Authenticator) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	if !RequiresAdmin(fullMethod) {
+		return ctx, nil
+	}
+
+	operator := a.Authenticate(bearerToken(ctx))
+	if operator == nil {
+		return nil, status.Error(codes.Unauthenticated, "a valid operator token is required")
+	}
+	if !operator.HasScope(ScopeAdmin) {
+		return nil, status.Error(codes.PermissionDenied, "operator token lacks the admin scope")
+	}
+	return WithOperator(ctx, operator), nil
+}
+
+// UnaryServerInterceptor rejects calls to admin-only methods without an
+// operator token granting the admin scope
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor rejects streaming calls to admin-only methods
+// without an operator token granting the admin scope
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if _, err := a.authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
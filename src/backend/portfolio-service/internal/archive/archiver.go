@@ -0,0 +1,217 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+	"go.uber.org/zap"        // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/models"
+	"bookman/portfolio-service/internal/repository"
+)
+
+// Archiver periodically moves transactions older than the retention window
+// into object storage, leaving summary lots behind in Postgres
+type Archiver struct {
+	repo   *repository.PostgresRepository
+	store  ObjectStore
+	cfg    config.ArchiveConfig
+	logger *zap.Logger
+}
+
+// NewArchiver creates a new transaction archival job
+func NewArchiver(repo *repository.PostgresRepository, store ObjectStore, cfg config.ArchiveConfig, logger *zap.Logger) (*Archiver, error) {
+	if repo == nil || store == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	return &Archiver{
+		repo:   repo,
+		store:  store,
+		cfg:    cfg,
+		logger: logger.With(zap.String("component", "archiver")),
+	}, nil
+}
+
+// Run executes archival passes at the configured interval until ctx is cancelled
+func (a *Archiver) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			a.logger.Error("Archival pass failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce archives one batch of portfolios holding transactions past
+// retention, after retrying the deletion of objects of purged archives
+func (a *Archiver) RunOnce(ctx context.Context) error {
+	a.deletePendingObjects(ctx)
+
+	cutoff := time.Now().UTC().AddDate(-a.cfg.RetentionYears, 0, 0)
+
+	portfolioIDs, err := a.repo.PortfoliosWithTransactionsBefore(ctx, cutoff, a.cfg.BatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list archivable portfolios: %w", err)
+	}
+
+	var failed int
+	for _, id := range portfolioIDs {
+		if err := a.archivePortfolio(ctx, id, cutoff); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed++
+			a.logger.Error("Failed to archive portfolio transactions",
+				zap.Error(err),
+				zap.String("portfolio_id", id.String()),
+			)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d portfolios failed to archive", failed, len(portfolioIDs))
+	}
+	return nil
+}
+
+// deletePendingObjects deletes one batch of objects whose archives were
+// purged but whose deletion failed at the time
+func (a *Archiver) deletePendingObjects(ctx context.Context) {
+	keys, err := a.repo.ListArchiveObjectDeletions(ctx, a.cfg.BatchSize)
+	if err != nil {
+		a.logger.Error("Failed to list pending archive object deletions", zap.Error(err))
+		return
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	deleted, err := DeleteObjects(ctx, a.store, a.repo, keys)
+	if err != nil {
+		a.logger.Error("Failed to delete purged archive objects",
+			zap.Error(err),
+			zap.Int("deleted", deleted),
+			zap.Int("pending", len(keys)-deleted),
+		)
+		return
+	}
+
+	a.logger.Info("Deleted purged archive objects", zap.Int("count", deleted))
+}
+
+// archivePortfolio uploads a portfolio's aged transactions and then removes
+// them from Postgres. The upload happens first so a failed commit only leaves
+// an unreferenced object behind, never lost history.
+func (a *Archiver) archivePortfolio(ctx context.Context, portfolioID uuid.UUID, cutoff time.Time) error {
+	txs, err := a.repo.GetTransactions(ctx, portfolioID, time.Time{}, cutoff)
+	if err != nil {
+		return err
+	}
+	if len(txs) == 0 {
+		return nil
+	}
+
+	data, err := EncodeTransactions(txs)
+	if err != nil {
+		return err
+	}
+
+	record := models.TransactionArchive{
+		ID:               uuid.New(),
+		PortfolioID:      portfolioID,
+		PeriodStart:      txs[0].Timestamp,
+		PeriodEnd:        cutoff,
+		TransactionCount: len(txs),
+		SizeBytes:        int64(len(data)),
+		CreatedAt:        time.Now().UTC(),
+	}
+	record.ObjectKey = fmt.Sprintf("%s/%s/%s.parquet", a.cfg.Prefix, portfolioID, record.ID)
+
+	if err := a.store.Put(ctx, record.ObjectKey, data, ContentType); err != nil {
+		return err
+	}
+
+	ids := make([]uuid.UUID, len(txs))
+	for i, tx := range txs {
+		ids[i] = tx.ID
+	}
+	lots := SummarizeLots(record.ID, portfolioID, txs, cutoff)
+
+	if err := a.repo.CommitTransactionArchive(ctx, &record, lots, ids); err != nil {
+		return fmt.Errorf("failed to commit archive %s: %w", record.ObjectKey, err)
+	}
+
+	a.logger.Info("Archived portfolio transactions",
+		zap.String("portfolio_id", portfolioID.String()),
+		zap.String("object_key", record.ObjectKey),
+		zap.Int("count", len(txs)),
+	)
+
+	return nil
+}
+
+// Reader serves historical transaction queries from archived objects
+type Reader struct {
+	repo  *repository.PostgresRepository
+	store ObjectStore
+}
+
+// NewReader creates a new archived transaction reader
+func NewReader(repo *repository.PostgresRepository, store ObjectStore) (*Reader, error) {
+	if repo == nil || store == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+	return &Reader{repo: repo, store: store}, nil
+}
+
+// Transactions returns archived transactions of a portfolio recorded within [start, end)
+func (r *Reader) Transactions(ctx context.Context, portfolioID uuid.UUID, start, end time.Time) ([]models.Transaction, error) {
+	archives, err := r.repo.GetTransactionArchives(ctx, portfolioID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up archives: %w", err)
+	}
+
+	var result []models.Transaction
+	for _, archive := range archives {
+		data, err := r.store.Get(ctx, archive.ObjectKey)
+		if err != nil {
+			return nil, err
+		}
+
+		txs, err := DecodeTransactions(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode archive %s: %w", archive.ObjectKey, err)
+		}
+
+		for _, tx := range txs {
+			if !tx.Timestamp.Before(start) && tx.Timestamp.Before(end) {
+				result = append(result, tx)
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result, nil
+}
+
+// PurgeUser runs erase, the database transaction purging a user's data, and
+// deletes the objects of the archives it removed once it committed
+func (r *Reader) PurgeUser(ctx context.Context, erase func(ctx context.Context) ([]string, error)) (int, error) {
+	return PurgeObjects(ctx, r.store, r.repo, erase)
+}
@@ -0,0 +1,80 @@
+package archive
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"bookman/portfolio-service/internal/models"
+)
+
+// SummarizeLots folds archived transactions into one summary lot per asset
+// using average cost accounting. The resulting lots carry the remaining
+// quantity and cost basis forward so live holdings stay reconcilable.
+func SummarizeLots(archiveID, portfolioID uuid.UUID, txs []models.Transaction, cutoff time.Time) []models.ArchivedLot {
+	ordered := make([]models.Transaction, len(txs))
+	copy(ordered, txs)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Timestamp.Before(ordered[j].Timestamp)
+	})
+
+	lots := make(map[uuid.UUID]*models.ArchivedLot)
+	var assetOrder []uuid.UUID
+
+	for _, tx := range ordered {
+		lot, ok := lots[tx.AssetID]
+		if !ok {
+			lot = &models.ArchivedLot{
+				ArchiveID:      archiveID,
+				PortfolioID:    portfolioID,
+				AssetID:        tx.AssetID,
+				Quantity:       decimal.Zero,
+				CostBasis:      decimal.Zero,
+				TotalFees:      decimal.Zero,
+				AcquiredBefore: cutoff,
+			}
+			lots[tx.AssetID] = lot
+			assetOrder = append(assetOrder, tx.AssetID)
+		}
+
+		lot.TransactionCount++
+		lot.TotalFees = lot.TotalFees.Add(tx.Fee)
+
+		switch tx.Type {
+		case "buy":
+			lot.Quantity = lot.Quantity.Add(tx.Amount)
+			lot.CostBasis = lot.CostBasis.Add(tx.Amount.Mul(tx.Price)).Add(tx.Fee)
+		case "transfer_in", "unstake", "reward", "airdrop", "fork", "interest":
+			lot.Quantity = lot.Quantity.Add(tx.Amount)
+			lot.CostBasis = lot.CostBasis.Add(tx.Amount.Mul(tx.Price))
+		default:
+			// sell, transfer_out, stake and fee reduce the position at average cost
+			removeAtAverageCost(lot, tx.Amount)
+		}
+	}
+
+	result := make([]models.ArchivedLot, 0, len(assetOrder))
+	for _, id := range assetOrder {
+		result = append(result, *lots[id])
+	}
+	return result
+}
+
+// removeAtAverageCost reduces a lot's quantity and its cost basis proportionally
+func removeAtAverageCost(lot *models.ArchivedLot, amount decimal.Decimal) {
+	if !lot.Quantity.IsPositive() {
+		return
+	}
+
+	if amount.GreaterThanOrEqual(lot.Quantity) {
+		lot.Quantity = decimal.Zero
+		lot.CostBasis = decimal.Zero
+		return
+	}
+
+	removedCost := lot.CostBasis.Mul(amount).Div(lot.Quantity)
+	lot.Quantity = lot.Quantity.Sub(amount)
+	lot.CostBasis = lot.CostBasis.Sub(removedCost)
+}
@@ -0,0 +1,109 @@
+// Package archive moves aged transaction history to compressed Parquet files
+// in object storage and reads it back transparently for historical queries.
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"           // v1.3.0
+	"github.com/parquet-go/parquet-go" // v0.20.0
+	"github.com/shopspring/decimal"    // v1.3.1
+
+	"bookman/portfolio-service/internal/models"
+)
+
+// ContentType is the media type of archived transaction objects
+const ContentType = "application/vnd.apache.parquet"
+
+// transactionRow is the Parquet schema of an archived transaction. Decimal
+// values are stored as strings to preserve exact precision.
+type transactionRow struct {
+	ID          string    `parquet:"id"`
+	PortfolioID string    `parquet:"portfolio_id,dict"`
+	AssetID     string    `parquet:"asset_id,dict"`
+	Type        string    `parquet:"type,dict"`
+	Amount      string    `parquet:"amount"`
+	Price       string    `parquet:"price"`
+	Fee         string    `parquet:"fee"`
+	Timestamp   time.Time `parquet:"timestamp,timestamp(microsecond)"`
+}
+
+// EncodeTransactions serializes transactions into a zstd-compressed Parquet file
+func EncodeTransactions(txs []models.Transaction) ([]byte, error) {
+	rows := make([]transactionRow, len(txs))
+	for i, tx := range txs {
+		rows[i] = transactionRow{
+			ID:          tx.ID.String(),
+			PortfolioID: tx.PortfolioID.String(),
+			AssetID:     tx.AssetID.String(),
+			Type:        tx.Type,
+			Amount:      tx.Amount.String(),
+			Price:       tx.Price.String(),
+			Fee:         tx.Fee.String(),
+			Timestamp:   tx.Timestamp.UTC(),
+		}
+	}
+
+	var buf bytes.Buffer
+	w := parquet.NewGenericWriter[transactionRow](&buf, parquet.Compression(&parquet.Zstd))
+	if _, err := w.Write(rows); err != nil {
+		return nil, fmt.Errorf("failed to write parquet rows: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize parquet file: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// DecodeTransactions parses a Parquet file produced by EncodeTransactions
+func DecodeTransactions(data []byte) ([]models.Transaction, error) {
+	rows, err := parquet.Read[transactionRow](bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read parquet rows: %w", err)
+	}
+
+	txs := make([]models.Transaction, len(rows))
+	for i, row := range rows {
+		tx, err := row.toModel()
+		if err != nil {
+			return nil, fmt.Errorf("invalid archived transaction %s: %w", row.ID, err)
+		}
+		txs[i] = tx
+	}
+
+	return txs, nil
+}
+
+// toModel converts a Parquet row back into a transaction model
+func (r transactionRow) toModel() (models.Transaction, error) {
+	var (
+		tx  models.Transaction
+		err error
+	)
+
+	if tx.ID, err = uuid.Parse(r.ID); err != nil {
+		return tx, err
+	}
+	if tx.PortfolioID, err = uuid.Parse(r.PortfolioID); err != nil {
+		return tx, err
+	}
+	if tx.AssetID, err = uuid.Parse(r.AssetID); err != nil {
+		return tx, err
+	}
+	if tx.Amount, err = decimal.NewFromString(r.Amount); err != nil {
+		return tx, err
+	}
+	if tx.Price, err = decimal.NewFromString(r.Price); err != nil {
+		return tx, err
+	}
+	if tx.Fee, err = decimal.NewFromString(r.Fee); err != nil {
+		return tx, err
+	}
+
+	tx.Type = r.Type
+	tx.Timestamp = r.Timestamp.UTC()
+	return tx, nil
+}
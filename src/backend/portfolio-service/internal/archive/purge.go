@@ -0,0 +1,58 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrDeletionPending reports archive objects that could not be deleted after
+// their records were purged; they stay pending until the archiver deletes them
+var ErrDeletionPending = errors.New("archive object deletion pending")
+
+// PendingDeletions tracks archive objects whose records were purged and which
+// are still to be deleted
+type PendingDeletions interface {
+	ListArchiveObjectDeletions(ctx context.Context, limit int) ([]string, error)
+	DeleteArchiveObjectDeletions(ctx context.Context, keys []string) error
+}
+
+// PurgeObjects runs erase, which removes archive records in a database
+// transaction, records their objects as pending deletions and returns their
+// keys, and only then deletes the objects. A failed erase therefore leaves
+// every archive intact and the purge can be retried. It returns the number of
+// archives removed; objects that failed to delete are reported wrapping
+// ErrDeletionPending, as the purge itself succeeded.
+func PurgeObjects(ctx context.Context, store ObjectStore, pending PendingDeletions, erase func(ctx context.Context) ([]string, error)) (int, error) {
+	keys, err := erase(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := DeleteObjects(ctx, store, pending, keys); err != nil {
+		return len(keys), fmt.Errorf("%w: %v", ErrDeletionPending, err)
+	}
+	return len(keys), nil
+}
+
+// DeleteObjects deletes the archive objects at keys and clears their pending
+// deletions. Objects that fail to delete stay pending; it returns the number
+// deleted along with the first failure.
+func DeleteObjects(ctx context.Context, store ObjectStore, pending PendingDeletions, keys []string) (int, error) {
+	var deleted []string
+	var firstErr error
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		deleted = append(deleted, key)
+	}
+
+	if err := pending.DeleteArchiveObjectDeletions(ctx, deleted); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return len(deleted), firstErr
+}
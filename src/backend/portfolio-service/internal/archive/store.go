@@ -0,0 +1,102 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"              // v1.21.2
+	awsconfig "github.com/aws/aws-sdk-go-v2/config" // v1.18.45
+	"github.com/aws/aws-sdk-go-v2/service/s3"       // v1.40.0
+	"github.com/aws/aws-sdk-go-v2/service/s3/types" // v1.40.0
+
+	"bookman/portfolio-service/internal/config"
+)
+
+// ObjectStore persists archive objects in long-term storage
+type ObjectStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// S3Store implements ObjectStore on top of Amazon S3 or an S3-compatible service
+type S3Store struct {
+	client       *s3.Client
+	bucket       string
+	storageClass types.StorageClass
+}
+
+// NewS3Store creates an S3-backed object store from archive configuration
+func NewS3Store(ctx context.Context, cfg *config.ArchiveConfig) (*S3Store, error) {
+	if cfg == nil {
+		return nil, errors.New("archive configuration is required")
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(cfg.Region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return &S3Store{
+		client:       client,
+		bucket:       cfg.Bucket,
+		storageClass: types.StorageClass(cfg.StorageClass),
+	}, nil
+}
+
+// Put uploads an object encrypted at rest with the bucket's KMS key
+func (s *S3Store) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(key),
+		Body:                 bytes.NewReader(data),
+		ContentLength:        int64(len(data)),
+		ContentType:          aws.String(contentType),
+		StorageClass:         s.storageClass,
+		ServerSideEncryption: types.ServerSideEncryptionAwsKms,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload archive object %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get downloads an archive object
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download archive object %s: %w", key, err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive object %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// Delete permanently removes an archive object
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete archive object %s: %w", key, err)
+	}
+	return nil
+}
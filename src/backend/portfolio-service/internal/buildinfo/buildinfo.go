@@ -0,0 +1,39 @@
+// Package buildinfo exposes build metadata injected at link time for
+// diagnostics endpoints and client-facing server information.
+package buildinfo
+
+import (
+	"runtime"
+	"sort"
+)
+
+// Build metadata populated via -ldflags "-X" at build time
+var (
+	Version   = "dev"
+	GitSHA    = "unknown"
+	BuildTime = "unknown"
+)
+
+// Info describes the running binary and the features enabled for this instance
+type Info struct {
+	Version   string   `json:"version"`
+	GitSHA    string   `json:"git_sha"`
+	BuildTime string   `json:"build_time"`
+	GoVersion string   `json:"go_version"`
+	Features  []string `json:"features"`
+}
+
+// Get returns the build metadata together with the given enabled features
+func Get(features ...string) Info {
+	enabled := make([]string, len(features))
+	copy(enabled, features)
+	sort.Strings(enabled)
+
+	return Info{
+		Version:   Version,
+		GitSHA:    GitSHA,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		Features:  enabled,
+	}
+}
@@ -0,0 +1,327 @@
+// Package businessmetrics periodically exports aggregate business metrics,
+// such as portfolio and user counts, tracked value, snapshot lag and sync
+// failures, as gauges, so product dashboards need no direct database access.
+// Every label takes its values from a fixed set: value buckets from
+// configuration, activity windows from this package and connectors from the
+// registered exchanges and chains, with any others reported as "other".
+package businessmetrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"github.com/shopspring/decimal"                  // v1.3.1
+	"go.uber.org/zap"                                // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/metrics"
+	"bookman/portfolio-service/internal/repository"
+)
+
+// OtherConnector labels syncs of connectors that are not registered
+const OtherConnector = "other"
+
+// Sync results reported in metrics
+const (
+	resultSucceeded = "succeeded"
+	resultFailed    = "failed"
+)
+
+// activityWindows are the trailing windows active users are counted over
+var activityWindows = []struct {
+	label  string
+	window time.Duration
+}{
+	{"1d", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// Define business metrics
+var (
+	portfolios = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "portfolio_business_portfolios",
+			Help: "Number of active portfolios",
+		},
+	)
+
+	users = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "portfolio_business_users",
+			Help: "Number of users with an active portfolio",
+		},
+	)
+
+	activeUsers = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_business_active_users",
+			Help: "Number of users whose holdings changed within the trailing window",
+		},
+		[]string{"window"},
+	)
+
+	portfoliosByValue = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_business_portfolios_by_value",
+			Help: "Number of active portfolios by value bucket, labelled with the bucket's lower bound in the base currency",
+		},
+		[]string{"min_value"},
+	)
+
+	trackedValue = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_business_tracked_value",
+			Help: "Sum of the last valuations of active portfolios in the base currency by value bucket",
+		},
+		[]string{"min_value"},
+	)
+
+	snapshotLag = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "portfolio_business_snapshot_lag_seconds",
+			Help: "Seconds since the latest portfolio valuation snapshot was captured",
+		},
+	)
+
+	syncs = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_business_syncs",
+			Help: "Number of exchange and wallet syncs within the sync window by source, connector and result",
+		},
+		[]string{"source", "connector", "result"},
+	)
+
+	collectionErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "portfolio_business_metrics_errors_total",
+			Help: "Total number of business metric queries that failed",
+		},
+		[]string{"metric"},
+	)
+
+	lastSuccess = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "portfolio_business_metrics_last_success_timestamp_seconds",
+			Help: "Unix time of the last collection in which every query succeeded",
+		},
+	)
+)
+
+func init() {
+	metrics.MustRegister(portfolios, users, activeUsers, portfoliosByValue, trackedValue)
+	metrics.MustRegister(snapshotLag, syncs, collectionErrors, lastSuccess)
+}
+
+// ValueBucketLabels returns the labels of the value buckets under ascending
+// bounds: the lower bound of each, starting at zero
+func ValueBucketLabels(bounds []float64) []string {
+	labels := make([]string, 0, len(bounds)+1)
+	labels = append(labels, "0")
+	for _, bound := range bounds {
+		labels = append(labels, strconv.FormatFloat(bound, 'f', -1, 64))
+	}
+	return labels
+}
+
+// Connectors are the registered exchanges and chains syncs are labelled by
+type Connectors map[string]map[string]bool
+
+// NewConnectors returns the connectors of each sync source
+func NewConnectors(names map[string][]string) Connectors {
+	connectors := make(Connectors, len(names))
+	for source, sourceNames := range names {
+		connectors[source] = make(map[string]bool, len(sourceNames))
+		for _, name := range sourceNames {
+			connectors[source][name] = true
+		}
+	}
+	return connectors
+}
+
+// Label returns the connector label of a sync, which is OtherConnector for
+// connectors that are not registered
+func (c Connectors) Label(source, name string) string {
+	if c[source][name] {
+		return name
+	}
+	return OtherConnector
+}
+
+// Collector exports business metrics on a fixed interval
+type Collector struct {
+	repo       *repository.PostgresRepository
+	cfg        config.BusinessMetricsConfig
+	bounds     []decimal.Decimal
+	labels     []string
+	connectors Connectors
+	logger     *zap.Logger
+}
+
+// NewCollector creates a business metrics collector labelling syncs by the
+// registered connectors of each sync source
+func NewCollector(repo *repository.PostgresRepository, cfg config.BusinessMetricsConfig, connectors Connectors, logger *zap.Logger) (*Collector, error) {
+	if repo == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	bounds := make([]decimal.Decimal, len(cfg.ValueBuckets))
+	for i, bound := range cfg.ValueBuckets {
+		bounds[i] = decimal.NewFromFloat(bound)
+	}
+
+	return &Collector{
+		repo:       repo,
+		cfg:        cfg,
+		bounds:     bounds,
+		labels:     ValueBucketLabels(cfg.ValueBuckets),
+		connectors: connectors,
+		logger:     logger.With(zap.String("component", "business_metrics")),
+	}, nil
+}
+
+// Run collects metrics immediately and then at each interval until ctx is
+// cancelled
+func (c *Collector) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			c.logger.Error("Business metrics collection failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce runs every query and updates its gauges. A failed query leaves its
+// gauges at their previous values so dashboards do not drop to zero.
+func (c *Collector) RunOnce(ctx context.Context) error {
+	now := time.Now().UTC()
+	steps := []struct {
+		metric  string
+		collect func(ctx context.Context, now time.Time) error
+	}{
+		{"portfolios", c.collectPortfolios},
+		{"users", c.collectUsers},
+		{"active_users", c.collectActiveUsers},
+		{"snapshot_lag", c.collectSnapshotLag},
+		{"syncs", c.collectSyncs},
+	}
+
+	var failed int
+	for _, step := range steps {
+		stepCtx, cancel := context.WithTimeout(ctx, c.cfg.QueryTimeout)
+		err := step.collect(stepCtx, now)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed++
+			collectionErrors.WithLabelValues(step.metric).Inc()
+			c.logger.Warn("Business metric query failed",
+				zap.String("metric", step.metric),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d business metric queries failed", failed)
+	}
+
+	lastSuccess.SetToCurrentTime()
+	return nil
+}
+
+// collectPortfolios counts active portfolios and their value by bucket
+func (c *Collector) collectPortfolios(ctx context.Context, now time.Time) error {
+	buckets, err := c.repo.CountPortfoliosByValue(ctx, c.bounds)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for i, bucket := range buckets {
+		portfoliosByValue.WithLabelValues(c.labels[i]).Set(float64(bucket.Portfolios))
+		trackedValue.WithLabelValues(c.labels[i]).Set(bucket.Value.InexactFloat64())
+		total += bucket.Portfolios
+	}
+	portfolios.Set(float64(total))
+	return nil
+}
+
+// collectUsers counts the users with an active portfolio
+func (c *Collector) collectUsers(ctx context.Context, now time.Time) error {
+	owners, err := c.repo.CountPortfolioOwners(ctx)
+	if err != nil {
+		return err
+	}
+	users.Set(float64(owners))
+	return nil
+}
+
+// collectActiveUsers counts the users active within each window
+func (c *Collector) collectActiveUsers(ctx context.Context, now time.Time) error {
+	for _, w := range activityWindows {
+		active, err := c.repo.CountActiveUsers(ctx, now.Add(-w.window))
+		if err != nil {
+			return err
+		}
+		activeUsers.WithLabelValues(w.label).Set(float64(active))
+	}
+	return nil
+}
+
+// collectSnapshotLag measures how long ago the latest snapshot was captured.
+// The gauge is left unset until a snapshot exists.
+func (c *Collector) collectSnapshotLag(ctx context.Context, now time.Time) error {
+	latest, err := c.repo.GetLatestSnapshotTime(ctx)
+	if err != nil {
+		return err
+	}
+	if !latest.IsZero() {
+		snapshotLag.Set(now.Sub(latest).Seconds())
+	}
+	return nil
+}
+
+// collectSyncs counts the syncs of each connector within the sync window.
+// Registered connectors are reported even without syncs, so their series
+// stay continuous.
+func (c *Collector) collectSyncs(ctx context.Context, now time.Time) error {
+	counts, err := c.repo.CountSyncsByConnector(ctx, now.Add(-c.cfg.SyncWindow))
+	if err != nil {
+		return err
+	}
+
+	type key struct{ source, connector, result string }
+	values := make(map[key]float64)
+	for source, names := range c.connectors {
+		for name := range names {
+			values[key{source, name, resultSucceeded}] = 0
+			values[key{source, name, resultFailed}] = 0
+		}
+	}
+	for _, count := range counts {
+		connector := c.connectors.Label(count.Source, count.Name)
+		values[key{count.Source, connector, resultSucceeded}] += float64(count.Syncs - count.Failures)
+		values[key{count.Source, connector, resultFailed}] += float64(count.Failures)
+	}
+
+	syncs.Reset()
+	for k, value := range values {
+		syncs.WithLabelValues(k.source, k.connector, k.result).Set(value)
+	}
+	return nil
+}
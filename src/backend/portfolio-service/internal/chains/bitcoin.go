@@ -0,0 +1,437 @@
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"            // v1.1.5
+	"github.com/btcsuite/btcd/btcutil/hdkeychain" // v1.1.5
+	"github.com/btcsuite/btcd/chaincfg"           // v0.24.0
+	"github.com/shopspring/decimal"               // v1.3.1
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/exchanges"
+	"bookman/portfolio-service/internal/models"
+)
+
+const (
+	// bitcoinDecimals is the number of decimals of bitcoin amounts in satoshi
+	bitcoinDecimals = 8
+
+	// esploraPageSize is the number of confirmed transactions Esplora
+	// returns per page of an address history
+	esploraPageSize = 25
+
+	// bitcoinMaxAddresses bounds the addresses scanned per branch of a wallet
+	bitcoinMaxAddresses = 10000
+
+	// bitcoinScanReuse is how long the address scan of a wallet is reused,
+	// so a sync reading transfers and then balances scans once
+	bitcoinScanReuse = time.Minute
+)
+
+// bitcoinScript is the output script type of the addresses derived from an
+// extended public key, given by its prefix
+type bitcoinScript int
+
+const (
+	// p2pkh addresses are derived from BIP44 xpub keys
+	p2pkh bitcoinScript = iota
+	// p2shP2wpkh addresses are derived from BIP49 ypub keys
+	p2shP2wpkh
+	// p2wpkh addresses are derived from BIP84 zpub keys
+	p2wpkh
+)
+
+// bitcoinScripts maps extended public key prefixes to their address type
+var bitcoinScripts = map[string]bitcoinScript{
+	"xpub": p2pkh,
+	"ypub": p2shP2wpkh,
+	"zpub": p2wpkh,
+}
+
+// bitcoin reads HD wallets given by an account-level extended public key.
+// Receive and change addresses are derived until the gap limit and read from
+// an Esplora API; the wallet's history is the union of their histories.
+type bitcoin struct {
+	cfg    config.BitcoinConfig
+	client *exchanges.Client
+
+	mutex sync.Mutex
+	scans map[string]*bitcoinScan
+}
+
+// bitcoinScan is the set of used addresses of a wallet
+type bitcoinScan struct {
+	addresses []bitcoinAddress
+	owned     map[string]bool
+	at        time.Time
+}
+
+// bitcoinAddress is a used wallet address with its confirmed totals in satoshi
+type bitcoinAddress struct {
+	address string
+	funded  int64
+	spent   int64
+	txCount int
+}
+
+// esploraStats are the funding totals of an address
+type esploraStats struct {
+	FundedTxoSum int64 `json:"funded_txo_sum"`
+	SpentTxoSum  int64 `json:"spent_txo_sum"`
+	TxCount      int   `json:"tx_count"`
+}
+
+// esploraAddress is the Esplora summary of an address
+type esploraAddress struct {
+	ChainStats   esploraStats `json:"chain_stats"`
+	MempoolStats esploraStats `json:"mempool_stats"`
+}
+
+// esploraOutput is a transaction output; Address is empty for non-standard
+// scripts
+type esploraOutput struct {
+	Address string `json:"scriptpubkey_address"`
+	Value   int64  `json:"value"`
+}
+
+// esploraTx is an Esplora transaction with the outputs its inputs spend
+type esploraTx struct {
+	TxID string `json:"txid"`
+	Vin  []struct {
+		Prevout *esploraOutput `json:"prevout"`
+	} `json:"vin"`
+	Vout   []esploraOutput `json:"vout"`
+	Fee    int64           `json:"fee"`
+	Status struct {
+		Confirmed   bool   `json:"confirmed"`
+		BlockHeight uint64 `json:"block_height"`
+		BlockTime   int64  `json:"block_time"`
+	} `json:"status"`
+}
+
+// Bitcoin describes the Bitcoin adapter
+func Bitcoin(cfg config.BitcoinConfig) Chain {
+	burst := int(cfg.RequestsPerSecond)
+	if burst < 1 {
+		burst = 1
+	}
+
+	return Chain{
+		Name:  "bitcoin",
+		Rate:  cfg.RequestsPerSecond,
+		Burst: burst,
+		New: func(client *exchanges.Client) (ChainAdapter, error) {
+			cfg.EsploraURL = strings.TrimSuffix(cfg.EsploraURL, "/")
+			return &bitcoin{cfg: cfg, client: client, scans: make(map[string]*bitcoinScan)}, nil
+		},
+	}
+}
+
+// NormalizeAddress validates a mainnet account-level xpub, ypub or zpub key.
+// Extended private keys are rejected so they are never stored.
+func (b *bitcoin) NormalizeAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if _, ok := bitcoinScripts[keyPrefix(address)]; !ok {
+		return "", ErrInvalidAddress
+	}
+	key, err := hdkeychain.NewKeyFromString(address)
+	if err != nil || key.IsPrivate() {
+		return "", ErrInvalidAddress
+	}
+	return address, nil
+}
+
+// FetchBalances returns the confirmed bitcoin balance of a wallet's used
+// addresses
+func (b *bitcoin) FetchBalances(ctx context.Context, address string) ([]models.ExchangeBalance, error) {
+	scan, err := b.scan(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, a := range scan.addresses {
+		total += a.funded - a.spent
+	}
+	if total <= 0 {
+		return []models.ExchangeBalance{}, nil
+	}
+	return []models.ExchangeBalance{{Asset: "BTC", Free: decimal.New(total, -bitcoinDecimals)}}, nil
+}
+
+// FetchTransfers returns the bitcoin received and sent by a wallet in blocks
+// from the cursor up to the latest block with the configured number of
+// confirmations. Each transaction is netted over the wallet's addresses, so
+// change returned to the wallet is not a transfer; the network fee of a
+// transaction funded by the wallet is the fee of its withdrawal, or a fee
+// entry when the wallet only moved coins between its own addresses.
+func (b *bitcoin) FetchTransfers(ctx context.Context, address, cursor string) (models.ExchangeActivity, string, error) {
+	cur, err := decodeBlockCursor(cursor)
+	if err != nil {
+		return models.ExchangeActivity{}, "", err
+	}
+
+	var tip uint64
+	err = b.get(ctx, "/blocks/tip/height", func(body []byte) error {
+		tip, err = strconv.ParseUint(strings.TrimSpace(string(body)), 10, 64)
+		return err
+	})
+	if err != nil {
+		return models.ExchangeActivity{}, "", err
+	}
+	// A transaction in the tip block has one confirmation; unconfirmed
+	// transactions are never read
+	confirmations := uint64(b.cfg.Confirmations)
+	if confirmations == 0 {
+		confirmations = 1
+	}
+	if tip+1 < confirmations || tip+1-confirmations < cur.Block {
+		return models.ExchangeActivity{}, cursor, nil
+	}
+	end := tip + 1 - confirmations
+
+	scan, err := b.scan(ctx, address)
+	if err != nil {
+		return models.ExchangeActivity{}, "", err
+	}
+
+	txs := make(map[string]esploraTx)
+	for _, a := range scan.addresses {
+		if a.txCount == 0 {
+			continue
+		}
+		if err := b.history(ctx, a.address, cur.Block, end, txs); err != nil {
+			return models.ExchangeActivity{}, "", err
+		}
+	}
+
+	ordered := make([]esploraTx, 0, len(txs))
+	for _, tx := range txs {
+		ordered = append(ordered, tx)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].Status.BlockHeight != ordered[j].Status.BlockHeight {
+			return ordered[i].Status.BlockHeight < ordered[j].Status.BlockHeight
+		}
+		return ordered[i].TxID < ordered[j].TxID
+	})
+
+	var activity models.ExchangeActivity
+	for _, tx := range ordered {
+		activity.Entries = append(activity.Entries, bitcoinEntries(tx, scan.owned)...)
+	}
+
+	next, err := encodeBlockCursor(&blockCursor{Block: end + 1})
+	if err != nil {
+		return models.ExchangeActivity{}, "", err
+	}
+	return activity, next, nil
+}
+
+// bitcoinEntries nets a transaction over the owned addresses into entries
+func bitcoinEntries(tx esploraTx, owned map[string]bool) []models.ExchangeEntry {
+	var received, spent int64
+	for _, out := range tx.Vout {
+		if owned[out.Address] {
+			received += out.Value
+		}
+	}
+	for _, in := range tx.Vin {
+		if in.Prevout != nil && owned[in.Prevout.Address] {
+			spent += in.Prevout.Value
+		}
+	}
+
+	at := time.Unix(tx.Status.BlockTime, 0).UTC()
+	btc := func(sats int64) decimal.Decimal {
+		return decimal.New(sats, -bitcoinDecimals)
+	}
+
+	// Transactions the wallet did not fund only credit it
+	if spent == 0 {
+		if received == 0 {
+			return nil
+		}
+		return []models.ExchangeEntry{{ID: tx.TxID, Asset: "BTC", Type: "transfer_in", Amount: btc(received), Fee: decimal.Zero, ExecutedAt: at}}
+	}
+
+	// The wallet pays the whole fee of transactions it funds; collaborative
+	// transactions whose other inputs pay their share net to a receipt
+	var entries []models.ExchangeEntry
+	sent := spent - tx.Fee - received
+	switch {
+	case sent > 0:
+		return []models.ExchangeEntry{{ID: tx.TxID, Asset: "BTC", Type: "transfer_out", Amount: btc(sent), Fee: btc(tx.Fee), ExecutedAt: at}}
+	case sent < 0:
+		entries = append(entries, models.ExchangeEntry{ID: tx.TxID, Asset: "BTC", Type: "transfer_in", Amount: btc(-sent), Fee: decimal.Zero, ExecutedAt: at})
+	}
+	if tx.Fee > 0 {
+		entries = append(entries, models.ExchangeEntry{ID: tx.TxID + "/fee", Asset: "BTC", Type: "fee", Amount: btc(tx.Fee), Fee: decimal.Zero, ExecutedAt: at})
+	}
+	return entries
+}
+
+// scan returns the used addresses of a wallet, derived on the receive and
+// change branches until the gap limit
+func (b *bitcoin) scan(ctx context.Context, xpub string) (*bitcoinScan, error) {
+	b.mutex.Lock()
+	for key, scan := range b.scans {
+		if time.Since(scan.at) > bitcoinScanReuse {
+			delete(b.scans, key)
+		}
+	}
+	scan, ok := b.scans[xpub]
+	b.mutex.Unlock()
+	if ok {
+		return scan, nil
+	}
+
+	script, ok := bitcoinScripts[keyPrefix(xpub)]
+	if !ok {
+		return nil, ErrInvalidAddress
+	}
+	account, err := hdkeychain.NewKeyFromString(xpub)
+	if err != nil || account.IsPrivate() {
+		return nil, ErrInvalidAddress
+	}
+
+	scan = &bitcoinScan{owned: make(map[string]bool)}
+	for _, change := range []uint32{0, 1} {
+		branch, err := account.Derive(change)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive bitcoin branch %d: %w", change, err)
+		}
+
+		unused := 0
+		for index := uint32(0); unused < b.cfg.GapLimit; index++ {
+			if index >= bitcoinMaxAddresses {
+				return nil, fmt.Errorf("bitcoin wallet has more than %d addresses on branch %d", bitcoinMaxAddresses, change)
+			}
+			address, err := deriveBitcoinAddress(branch, script, index)
+			if err != nil {
+				return nil, err
+			}
+
+			var summary esploraAddress
+			err = b.get(ctx, "/address/"+url.PathEscape(address), func(body []byte) error {
+				return json.Unmarshal(body, &summary)
+			})
+			if err != nil {
+				return nil, err
+			}
+			if summary.ChainStats.TxCount+summary.MempoolStats.TxCount == 0 {
+				unused++
+				continue
+			}
+
+			unused = 0
+			scan.owned[address] = true
+			scan.addresses = append(scan.addresses, bitcoinAddress{
+				address: address,
+				funded:  summary.ChainStats.FundedTxoSum,
+				spent:   summary.ChainStats.SpentTxoSum,
+				txCount: summary.ChainStats.TxCount,
+			})
+		}
+	}
+	scan.at = time.Now()
+
+	b.mutex.Lock()
+	b.scans[xpub] = scan
+	b.mutex.Unlock()
+	return scan, nil
+}
+
+// history adds the confirmed transactions of an address within blocks
+// [start, end] to txs. Esplora lists them newest first in pages continued
+// after the last transaction seen.
+func (b *bitcoin) history(ctx context.Context, address string, start, end uint64, txs map[string]esploraTx) error {
+	path := "/address/" + url.PathEscape(address) + "/txs/chain"
+	for {
+		var page []esploraTx
+		err := b.get(ctx, path, func(body []byte) error {
+			return json.Unmarshal(body, &page)
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, tx := range page {
+			if !tx.Status.Confirmed || tx.Status.BlockHeight > end {
+				continue
+			}
+			if tx.Status.BlockHeight < start {
+				return nil
+			}
+			txs[tx.TxID] = tx
+		}
+		if len(page) < esploraPageSize {
+			return nil
+		}
+		path = "/address/" + url.PathEscape(address) + "/txs/chain/" + page[len(page)-1].TxID
+	}
+}
+
+// get sends a GET request to the Esplora API and passes the body of a
+// successful response to decode
+func (b *bitcoin) get(ctx context.Context, path string, decode func(body []byte) error) error {
+	build := func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, b.cfg.EsploraURL+path, nil)
+	}
+	return b.client.Do(ctx, build, func(resp *exchanges.Response) error {
+		if err := checkStatus("bitcoin esplora", resp); err != nil {
+			return err
+		}
+		if err := decode(resp.Body); err != nil {
+			return fmt.Errorf("failed to decode bitcoin esplora response: %w", err)
+		}
+		return nil
+	})
+}
+
+// deriveBitcoinAddress returns the mainnet address of a script type at an
+// index of a branch key
+func deriveBitcoinAddress(branch *hdkeychain.ExtendedKey, script bitcoinScript, index uint32) (string, error) {
+	child, err := branch.Derive(index)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive bitcoin address %d: %w", index, err)
+	}
+	pub, err := child.ECPubKey()
+	if err != nil {
+		return "", fmt.Errorf("failed to derive bitcoin address %d: %w", index, err)
+	}
+	hash := btcutil.Hash160(pub.SerializeCompressed())
+
+	var address btcutil.Address
+	switch script {
+	case p2pkh:
+		address, err = btcutil.NewAddressPubKeyHash(hash, &chaincfg.MainNetParams)
+	case p2shP2wpkh:
+		// The redeem script is a version 0 witness program of the key hash
+		address, err = btcutil.NewAddressScriptHash(append([]byte{0x00, 0x14}, hash...), &chaincfg.MainNetParams)
+	default:
+		address, err = btcutil.NewAddressWitnessPubKeyHash(hash, &chaincfg.MainNetParams)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to encode bitcoin address %d: %w", index, err)
+	}
+	return address.EncodeAddress(), nil
+}
+
+// keyPrefix returns the version prefix of an extended key
+func keyPrefix(key string) string {
+	if len(key) < 4 {
+		return ""
+	}
+	return key[:4]
+}
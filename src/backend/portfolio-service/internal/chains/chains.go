@@ -0,0 +1,259 @@
+// Package chains reads public blockchain wallets for tracked wallet
+// portfolios. A chain adapter reads the balances and transfer history of an
+// address on one chain through the rate-limited client of the exchange SDK;
+// transfers are returned as exchange activity entries so they are mapped onto
+// transactions by models.ExchangeMapper like exchange deposits and
+// withdrawals.
+//
+// Adding a chain takes a single file defining a Chain descriptor whose
+// constructor returns a ChainAdapter. EVM chains only need configuration.
+package chains
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"bookman/portfolio-service/internal/exchanges"
+	"bookman/portfolio-service/internal/models"
+)
+
+var (
+	// ErrUnsupportedChain is returned for chains without an adapter
+	ErrUnsupportedChain = errors.New("unsupported chain")
+
+	// ErrInvalidAddress is returned for addresses not valid on the chain
+	ErrInvalidAddress = errors.New("invalid wallet address")
+
+	// ErrInvalidCursor is returned for cursors not issued by the adapter
+	ErrInvalidCursor = errors.New("invalid wallet sync cursor")
+
+	// ErrUnsupportedStaking is returned for chains whose adapter does not
+	// read staking rewards
+	ErrUnsupportedStaking = errors.New("staking rewards not supported on chain")
+)
+
+// ChainAdapter reads wallets on one chain
+type ChainAdapter interface {
+	// NormalizeAddress validates an address and returns its canonical form,
+	// or ErrInvalidAddress
+	NormalizeAddress(address string) (string, error)
+
+	// FetchBalances returns the non-zero balances of the native asset and
+	// the tokens the adapter tracks held by address
+	FetchBalances(ctx context.Context, address string) ([]models.ExchangeBalance, error)
+
+	// FetchTransfers returns the transfers and network fees of address after
+	// the opaque cursor, or its whole history for an empty cursor, as
+	// transfer_in, transfer_out and fee entries with the cursor to resume from
+	FetchTransfers(ctx context.Context, address, cursor string) (models.ExchangeActivity, string, error)
+}
+
+// RewardReader is implemented by the adapters of chains with native staking
+type RewardReader interface {
+	// FetchRewards returns the staking rewards credited to a stake account
+	// after the opaque cursor, or since the stake was activated for an empty
+	// cursor, as reward entries with the cursor to resume from
+	FetchRewards(ctx context.Context, address, cursor string) (models.ExchangeActivity, string, error)
+}
+
+// Chain describes an adapter implementation and the request rate its
+// backends allow
+type Chain struct {
+	Name string
+	// Rate and Burst bound requests per second to the chain's backends
+	Rate  float64
+	Burst int
+	// New creates an adapter calling the chain's backends through client
+	New func(client *exchanges.Client) (ChainAdapter, error)
+}
+
+// Registry holds the adapters of the available chains
+type Registry struct {
+	http  *http.Client
+	retry exchanges.RetryPolicy
+
+	mutex    sync.Mutex
+	adapters map[string]ChainAdapter
+}
+
+// NewRegistry creates an empty registry whose adapters send requests with
+// httpClient and retry failures according to retry
+func NewRegistry(httpClient *http.Client, retry exchanges.RetryPolicy) *Registry {
+	return &Registry{
+		http:     httpClient,
+		retry:    retry,
+		adapters: make(map[string]ChainAdapter),
+	}
+}
+
+// Register makes a chain available, replacing one of the same name. Wallets
+// on a chain share its adapter and rate limit.
+func (r *Registry) Register(c Chain) error {
+	adapter, err := c.New(exchanges.NewClient(c.Name, r.http, c.Rate, c.Burst, r.retry))
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.adapters[c.Name] = adapter
+	return nil
+}
+
+// Names returns the registered chain names in order
+func (r *Registry) Names() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	names := make([]string, 0, len(r.adapters))
+	for name := range r.adapters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Adapter returns the adapter of the named chain
+func (r *Registry) Adapter(name string) (ChainAdapter, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	adapter, ok := r.adapters[name]
+	if !ok {
+		return nil, ErrUnsupportedChain
+	}
+	return adapter, nil
+}
+
+// Rewards returns the reward reader of the named chain
+func (r *Registry) Rewards(name string) (RewardReader, error) {
+	adapter, err := r.Adapter(name)
+	if err != nil {
+		return nil, err
+	}
+	reader, ok := adapter.(RewardReader)
+	if !ok {
+		return nil, ErrUnsupportedStaking
+	}
+	return reader, nil
+}
+
+// blockCursor records the first block the next sync of a wallet reads
+type blockCursor struct {
+	Block uint64 `json:"block"`
+}
+
+// decodeBlockCursor parses a sync cursor; empty cursors start from the first
+// block
+func decodeBlockCursor(cursor string) (*blockCursor, error) {
+	cur := &blockCursor{}
+	if cursor == "" {
+		return cur, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, cur); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return cur, nil
+}
+
+// encodeBlockCursor renders an opaque sync cursor
+func encodeBlockCursor(cur *blockCursor) (string, error) {
+	raw, err := json.Marshal(cur)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode wallet cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// checkStatus classifies unsuccessful HTTP responses of a chain backend
+func checkStatus(backend string, resp *exchanges.Response) error {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %s returned status %d", exchanges.ErrRateLimited, backend, resp.StatusCode)
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("%w: %s returned status %d", exchanges.ErrTemporary, backend, resp.StatusCode)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("%s returned status %d", backend, resp.StatusCode)
+	}
+	return nil
+}
+
+// rpcRequest is a JSON-RPC 2.0 call
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// rpcResponse is a JSON-RPC 2.0 result or error
+type rpcResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// callRPC sends a batch of JSON-RPC calls to the node of a chain backend at
+// endpoint and returns their results in order
+func callRPC(ctx context.Context, client *exchanges.Client, endpoint, backend string, calls []rpcRequest) ([]json.RawMessage, error) {
+	for i := range calls {
+		calls[i].JSONRPC = "2.0"
+		calls[i].ID = i
+	}
+	payload, err := json.Marshal(calls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s request: %w", backend, err)
+	}
+
+	results := make([]json.RawMessage, len(calls))
+	build := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}
+	err = client.Do(ctx, build, func(resp *exchanges.Response) error {
+		if err := checkStatus(backend, resp); err != nil {
+			return err
+		}
+
+		var responses []rpcResponse
+		if err := json.Unmarshal(resp.Body, &responses); err != nil {
+			return fmt.Errorf("failed to decode %s response: %w", backend, err)
+		}
+		if len(responses) != len(calls) {
+			return fmt.Errorf("%w: %s returned %d of %d results", exchanges.ErrTemporary, backend, len(responses), len(calls))
+		}
+		for _, r := range responses {
+			if r.ID < 0 || r.ID >= len(calls) {
+				return fmt.Errorf("%s returned unknown id %d", backend, r.ID)
+			}
+			if r.Error != nil {
+				return fmt.Errorf("%s %s failed: %d %s", backend, calls[r.ID].Method, r.Error.Code, r.Error.Message)
+			}
+			results[r.ID] = r.Result
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
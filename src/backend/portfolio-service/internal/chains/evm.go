@@ -0,0 +1,411 @@
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/exchanges"
+	"bookman/portfolio-service/internal/models"
+)
+
+const (
+	// evmDecimals is the number of decimals of native asset amounts in wei
+	evmDecimals = 18
+
+	// evmPageSize is the number of history rows requested per page
+	evmPageSize = 1000
+
+	// evmMaxPages bounds the pages read per history list in one sync;
+	// longer histories are read over several syncs
+	evmMaxPages = 20
+
+	// erc20BalanceOf is the selector of the ERC-20 balanceOf(address) call
+	erc20BalanceOf = "0x70a08231"
+)
+
+// evmAddressPattern matches hex addresses; the EIP-55 checksum of
+// mixed-case addresses is not verified
+var evmAddressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// evmHistoryLists are the indexer account actions listing native
+// transactions, internal transfers of the native asset and ERC-20 transfers
+var evmHistoryLists = []string{"txlist", "txlistinternal", "tokentx"}
+
+// evm reads wallets on an EVM chain through a JSON-RPC node for balances and
+// an Etherscan-compatible indexer for history. Only the native asset and the
+// configured tokens are tracked, so tokens airdropped to spoof known symbols
+// are ignored.
+type evm struct {
+	name   string
+	cfg    config.EVMConfig
+	apiKey string
+	client *exchanges.Client
+	tokens map[string]config.EVMToken
+}
+
+// evmTransfer is a row of the indexer's transaction and transfer lists
+type evmTransfer struct {
+	BlockNumber     string `json:"blockNumber"`
+	TimeStamp       string `json:"timeStamp"`
+	Hash            string `json:"hash"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	Value           string `json:"value"`
+	GasUsed         string `json:"gasUsed"`
+	GasPrice        string `json:"gasPrice"`
+	IsError         string `json:"isError"`
+	TraceID         string `json:"traceId"`
+	ContractAddress string `json:"contractAddress"`
+	LogIndex        string `json:"logIndex"`
+
+	block uint64
+}
+
+// EVM describes the adapter of the named EVM chain, such as Ethereum or a
+// sidechain. The rate limit is shared by the node and the indexer, whose free
+// tiers allow a few requests per second.
+func EVM(name string, cfg config.EVMConfig) Chain {
+	burst := int(cfg.RequestsPerSecond)
+	if burst < 1 {
+		burst = 1
+	}
+
+	return Chain{
+		Name:  name,
+		Rate:  cfg.RequestsPerSecond,
+		Burst: burst,
+		New: func(client *exchanges.Client) (ChainAdapter, error) {
+			tokens := make(map[string]config.EVMToken, len(cfg.Tokens))
+			for _, token := range cfg.Tokens {
+				if !evmAddressPattern.MatchString(token.Contract) {
+					return nil, fmt.Errorf("%w: %s token contract %q", ErrInvalidAddress, name, token.Contract)
+				}
+				tokens[strings.ToLower(token.Contract)] = token
+			}
+
+			var apiKey string
+			if cfg.IndexerAPIKeyEnv != "" {
+				apiKey = os.Getenv(cfg.IndexerAPIKeyEnv)
+			}
+
+			return &evm{name: name, cfg: cfg, apiKey: apiKey, client: client, tokens: tokens}, nil
+		},
+	}
+}
+
+// NormalizeAddress returns the lower-case form of a hex address
+func (e *evm) NormalizeAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if !evmAddressPattern.MatchString(address) {
+		return "", ErrInvalidAddress
+	}
+	return strings.ToLower(address), nil
+}
+
+// FetchBalances returns the non-zero native and tracked token balances of an
+// address at the latest block, read with one batched JSON-RPC request
+func (e *evm) FetchBalances(ctx context.Context, address string) ([]models.ExchangeBalance, error) {
+	contracts := make([]string, 0, len(e.tokens))
+	for contract := range e.tokens {
+		contracts = append(contracts, contract)
+	}
+	sort.Strings(contracts)
+
+	calls := []rpcRequest{{Method: "eth_getBalance", Params: []interface{}{address, "latest"}}}
+	owner := strings.Repeat("0", 24) + strings.TrimPrefix(address, "0x")
+	for _, contract := range contracts {
+		calls = append(calls, rpcRequest{
+			Method: "eth_call",
+			Params: []interface{}{map[string]string{"to": contract, "data": erc20BalanceOf + owner}, "latest"},
+		})
+	}
+
+	results, err := e.rpc(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]models.ExchangeBalance, 0, len(results))
+	for i, result := range results {
+		asset, decimals := e.cfg.NativeSymbol, int32(evmDecimals)
+		if i > 0 {
+			token := e.tokens[contracts[i-1]]
+			asset, decimals = token.Symbol, token.Decimals
+		}
+
+		amount, err := parseHexAmount(result, decimals)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s balance: %w", e.name, asset, err)
+		}
+		if amount.IsPositive() {
+			balances = append(balances, models.ExchangeBalance{Asset: asset, Free: amount})
+		}
+	}
+	return balances, nil
+}
+
+// FetchTransfers returns the native and tracked token transfers of an address
+// in blocks from the cursor up to the latest block with the configured number
+// of confirmations. Gas paid by the address is returned as fee entries, or as
+// the fee of a native withdrawal sent in the same transaction.
+func (e *evm) FetchTransfers(ctx context.Context, address, cursor string) (models.ExchangeActivity, string, error) {
+	cur, err := decodeBlockCursor(cursor)
+	if err != nil {
+		return models.ExchangeActivity{}, "", err
+	}
+
+	results, err := e.rpc(ctx, []rpcRequest{{Method: "eth_blockNumber", Params: []interface{}{}}})
+	if err != nil {
+		return models.ExchangeActivity{}, "", err
+	}
+	head, err := parseHexUint(results[0])
+	if err != nil {
+		return models.ExchangeActivity{}, "", fmt.Errorf("invalid %s block number: %w", e.name, err)
+	}
+	confirmations := uint64(e.cfg.Confirmations)
+	if head < confirmations || head-confirmations < cur.Block {
+		return models.ExchangeActivity{}, cursor, nil
+	}
+	end := head - confirmations
+
+	var activity models.ExchangeActivity
+	reached := end
+	for _, list := range evmHistoryLists {
+		rows, last, err := e.history(ctx, list, address, cur.Block, end)
+		if err != nil {
+			return models.ExchangeActivity{}, "", err
+		}
+		if last < reached {
+			reached = last
+		}
+		entries, err := e.entries(list, address, rows)
+		if err != nil {
+			return models.ExchangeActivity{}, "", err
+		}
+		activity.Entries = append(activity.Entries, entries...)
+	}
+	sort.SliceStable(activity.Entries, func(i, j int) bool {
+		return activity.Entries[i].ExecutedAt.Before(activity.Entries[j].ExecutedAt)
+	})
+
+	// Lists read past the least advanced one are read again by the next
+	// sync; their transfers are recognized as duplicates
+	next, err := encodeBlockCursor(&blockCursor{Block: reached + 1})
+	if err != nil {
+		return models.ExchangeActivity{}, "", err
+	}
+	return activity, next, nil
+}
+
+// history reads an indexer list of an address within [start, end] in pages
+// and returns its rows with the last block read completely. Pages ending
+// within a block are cut before it, so the next page starts at the block.
+func (e *evm) history(ctx context.Context, list, address string, start, end uint64) ([]evmTransfer, uint64, error) {
+	var rows []evmTransfer
+	for page := 0; page < evmMaxPages; page++ {
+		batch, err := e.indexer(ctx, list, address, start, end)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(batch) < evmPageSize {
+			return append(rows, batch...), end, nil
+		}
+
+		last := batch[len(batch)-1].block
+		cut := len(batch)
+		for cut > 0 && batch[cut-1].block == last {
+			cut--
+		}
+		if cut == 0 {
+			return nil, 0, fmt.Errorf("%s %s has more than %d rows in block %d", e.name, list, evmPageSize, last)
+		}
+		rows = append(rows, batch[:cut]...)
+		start = last
+	}
+	return rows, start - 1, nil
+}
+
+// entries maps the rows of an indexer list onto activity entries
+func (e *evm) entries(list, address string, rows []evmTransfer) ([]models.ExchangeEntry, error) {
+	var entries []models.ExchangeEntry
+	ordinals := make(map[string]int)
+	for _, row := range rows {
+		seconds, err := strconv.ParseInt(row.TimeStamp, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s timestamp %q", e.name, list, row.TimeStamp)
+		}
+		at := time.Unix(seconds, 0).UTC()
+		from, to := strings.ToLower(row.From), strings.ToLower(row.To)
+		failed := row.IsError == "1"
+
+		asset, decimals, id := e.cfg.NativeSymbol, int32(evmDecimals), row.Hash
+		switch list {
+		case "txlistinternal":
+			id = row.Hash + "/internal/" + row.TraceID
+		case "tokentx":
+			token, ok := e.tokens[strings.ToLower(row.ContractAddress)]
+			if !ok {
+				continue
+			}
+			asset, decimals = token.Symbol, token.Decimals
+			// Etherscan omits log indexes; transfers of a transaction are
+			// listed in log order
+			index := row.LogIndex
+			if index == "" {
+				key := row.Hash + "/" + row.ContractAddress
+				index = strconv.Itoa(ordinals[key])
+				ordinals[key]++
+			}
+			id = row.Hash + "/token/" + strings.ToLower(row.ContractAddress) + "/" + index
+		}
+
+		amount, err := parseAmount(row.Value, decimals)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s value %q", e.name, list, row.Value)
+		}
+		if failed {
+			amount = decimal.Zero
+		}
+
+		var gas decimal.Decimal
+		if list == "txlist" && from == address {
+			used, err := parseAmount(row.GasUsed, 0)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s gas used %q", e.name, row.GasUsed)
+			}
+			price, err := parseAmount(row.GasPrice, evmDecimals)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s gas price %q", e.name, row.GasPrice)
+			}
+			gas = used.Mul(price)
+		}
+
+		switch {
+		case amount.IsPositive() && from == address && to != address:
+			entries = append(entries, models.ExchangeEntry{ID: id, Asset: asset, Type: "transfer_out", Amount: amount, Fee: gas, ExecutedAt: at})
+			continue
+		case amount.IsPositive() && to == address && from != address:
+			entries = append(entries, models.ExchangeEntry{ID: id, Asset: asset, Type: "transfer_in", Amount: amount, Fee: decimal.Zero, ExecutedAt: at})
+		}
+		if gas.IsPositive() {
+			entries = append(entries, models.ExchangeEntry{ID: id + "/gas", Asset: e.cfg.NativeSymbol, Type: "fee", Amount: gas, Fee: decimal.Zero, ExecutedAt: at})
+		}
+	}
+	return entries, nil
+}
+
+// indexer reads one page of an indexer account list in ascending block order
+func (e *evm) indexer(ctx context.Context, list, address string, start, end uint64) ([]evmTransfer, error) {
+	params := url.Values{
+		"chainid":    {strconv.FormatInt(e.cfg.ChainID, 10)},
+		"module":     {"account"},
+		"action":     {list},
+		"address":    {address},
+		"startblock": {strconv.FormatUint(start, 10)},
+		"endblock":   {strconv.FormatUint(end, 10)},
+		"page":       {"1"},
+		"offset":     {strconv.Itoa(evmPageSize)},
+		"sort":       {"asc"},
+	}
+	if e.apiKey != "" {
+		params.Set("apikey", e.apiKey)
+	}
+
+	var rows []evmTransfer
+	build := func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, e.cfg.IndexerURL+"?"+params.Encode(), nil)
+	}
+	err := e.client.Do(ctx, build, func(resp *exchanges.Response) error {
+		if err := checkStatus(e.name+" indexer "+list, resp); err != nil {
+			return err
+		}
+
+		var body struct {
+			Status  string          `json:"status"`
+			Message string          `json:"message"`
+			Result  json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(resp.Body, &body); err != nil {
+			return fmt.Errorf("failed to decode %s indexer %s response: %w", e.name, list, err)
+		}
+		// An empty list is reported with status 0 and an empty result
+		if err := json.Unmarshal(body.Result, &rows); err == nil {
+			return nil
+		}
+
+		var message string
+		_ = json.Unmarshal(body.Result, &message)
+		if strings.Contains(strings.ToLower(message), "rate limit") {
+			return fmt.Errorf("%w: %s indexer: %s", exchanges.ErrRateLimited, e.name, message)
+		}
+		return fmt.Errorf("%s indexer %s failed: %s %s", e.name, list, body.Message, message)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rows {
+		block, err := strconv.ParseUint(rows[i].BlockNumber, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s block number %q", e.name, list, rows[i].BlockNumber)
+		}
+		rows[i].block = block
+	}
+	return rows, nil
+}
+
+// rpc sends a batch of JSON-RPC calls to the node and returns their results
+// in order
+func (e *evm) rpc(ctx context.Context, calls []rpcRequest) ([]json.RawMessage, error) {
+	return callRPC(ctx, e.client, e.cfg.RPCURL, e.name+" rpc", calls)
+}
+
+// parseAmount converts a decimal integer amount in base units into units of
+// an asset with the given decimals
+func parseAmount(value string, decimals int32) (decimal.Decimal, error) {
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok || n.Sign() < 0 {
+		return decimal.Zero, fmt.Errorf("invalid amount %q", value)
+	}
+	return decimal.NewFromBigInt(n, -decimals), nil
+}
+
+// parseHexAmount converts a hex-encoded JSON-RPC quantity or uint256 return
+// value in base units into units of an asset with the given decimals
+func parseHexAmount(raw json.RawMessage, decimals int32) (decimal.Decimal, error) {
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return decimal.Zero, err
+	}
+	digits := strings.TrimLeft(strings.TrimPrefix(value, "0x"), "0")
+	if digits == "" {
+		return decimal.Zero, nil
+	}
+	n, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return decimal.Zero, fmt.Errorf("invalid quantity %q", value)
+	}
+	return decimal.NewFromBigInt(n, -decimals), nil
+}
+
+// parseHexUint converts a hex-encoded JSON-RPC quantity
+func parseHexUint(raw json.RawMessage) (uint64, error) {
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimPrefix(value, "0x"), 16, 64)
+}
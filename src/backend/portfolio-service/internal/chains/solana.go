@@ -0,0 +1,572 @@
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil/base58" // v1.1.5
+	"github.com/shopspring/decimal"           // v1.3.1
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/exchanges"
+	"bookman/portfolio-service/internal/models"
+)
+
+const (
+	// solanaDecimals is the number of decimals of SOL amounts in lamports
+	solanaDecimals = 9
+
+	// solanaSignaturePage is the number of signatures requested per page of
+	// an address history, the maximum the node returns
+	solanaSignaturePage = 1000
+
+	// solanaMaxTransactions bounds the transactions read in one sync; longer
+	// histories are read over several syncs
+	solanaMaxTransactions = 2000
+
+	// solanaTransactionBatch is the number of transactions requested per
+	// JSON-RPC batch
+	solanaTransactionBatch = 50
+
+	// solanaTokenProgram is the SPL Token program owning token accounts
+	solanaTokenProgram = "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"
+
+	// solanaMaxRewardEpochs bounds the epochs read in one reward accrual;
+	// older stakes catch up over several accruals
+	solanaMaxRewardEpochs = 50
+)
+
+// solana reads wallets on Solana through a JSON-RPC node. SOL transfers are
+// derived from the balance changes of the wallet in each transaction and
+// token transfers from those of its token accounts, so transfers made by
+// programs are read like plain transfers. Only SOL and the configured mints
+// are tracked. Staking rewards are read per epoch for stake accounts.
+type solana struct {
+	cfg    config.SolanaConfig
+	client *exchanges.Client
+	tokens map[string]config.SolanaToken
+}
+
+// solanaTokenAmount is an SPL token amount in base units
+type solanaTokenAmount struct {
+	Amount   string `json:"amount"`
+	Decimals int32  `json:"decimals"`
+}
+
+// solanaTokenAccounts is the jsonParsed result of getTokenAccountsByOwner
+type solanaTokenAccounts struct {
+	Value []struct {
+		Pubkey  string `json:"pubkey"`
+		Account struct {
+			Data struct {
+				Parsed struct {
+					Info struct {
+						Mint        string            `json:"mint"`
+						TokenAmount solanaTokenAmount `json:"tokenAmount"`
+					} `json:"info"`
+				} `json:"parsed"`
+			} `json:"data"`
+		} `json:"account"`
+	} `json:"value"`
+}
+
+// solanaSignature is a transaction listed in an address history
+type solanaSignature struct {
+	Signature string `json:"signature"`
+	Slot      uint64 `json:"slot"`
+}
+
+// solanaTokenBalance is the balance of a token account before or after a
+// transaction
+type solanaTokenBalance struct {
+	Mint          string            `json:"mint"`
+	Owner         string            `json:"owner"`
+	UITokenAmount solanaTokenAmount `json:"uiTokenAmount"`
+}
+
+// solanaTransaction is the jsonParsed result of getTransaction
+type solanaTransaction struct {
+	Slot      uint64 `json:"slot"`
+	BlockTime *int64 `json:"blockTime"`
+	Meta      struct {
+		Fee               uint64               `json:"fee"`
+		PreBalances       []uint64             `json:"preBalances"`
+		PostBalances      []uint64             `json:"postBalances"`
+		PreTokenBalances  []solanaTokenBalance `json:"preTokenBalances"`
+		PostTokenBalances []solanaTokenBalance `json:"postTokenBalances"`
+	} `json:"meta"`
+	Transaction struct {
+		Signatures []string `json:"signatures"`
+		Message    struct {
+			AccountKeys []struct {
+				Pubkey string `json:"pubkey"`
+			} `json:"accountKeys"`
+		} `json:"message"`
+	} `json:"transaction"`
+}
+
+// Solana describes the Solana adapter
+func Solana(cfg config.SolanaConfig) Chain {
+	burst := int(cfg.RequestsPerSecond)
+	if burst < 1 {
+		burst = 1
+	}
+
+	return Chain{
+		Name:  "solana",
+		Rate:  cfg.RequestsPerSecond,
+		Burst: burst,
+		New: func(client *exchanges.Client) (ChainAdapter, error) {
+			tokens := make(map[string]config.SolanaToken, len(cfg.Tokens))
+			for _, token := range cfg.Tokens {
+				if len(base58.Decode(token.Mint)) != 32 {
+					return nil, fmt.Errorf("%w: solana token mint %q", ErrInvalidAddress, token.Mint)
+				}
+				tokens[token.Mint] = token
+			}
+			return &solana{cfg: cfg, client: client, tokens: tokens}, nil
+		},
+	}
+}
+
+// NormalizeAddress validates a base58-encoded account address. Addresses are
+// case-sensitive and returned unchanged.
+func (s *solana) NormalizeAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if address == "" || len(base58.Decode(address)) != 32 {
+		return "", ErrInvalidAddress
+	}
+	return address, nil
+}
+
+// FetchBalances returns the non-zero SOL and tracked token balances of an
+// address at the latest finalized slot, read with one batched request
+func (s *solana) FetchBalances(ctx context.Context, address string) ([]models.ExchangeBalance, error) {
+	results, err := s.rpc(ctx, []rpcRequest{
+		{Method: "getBalance", Params: []interface{}{address, map[string]string{"commitment": "finalized"}}},
+		s.tokenAccountsCall(address),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var lamports struct {
+		Value uint64 `json:"value"`
+	}
+	if err := json.Unmarshal(results[0], &lamports); err != nil {
+		return nil, fmt.Errorf("invalid solana SOL balance: %w", err)
+	}
+	var accounts solanaTokenAccounts
+	if err := json.Unmarshal(results[1], &accounts); err != nil {
+		return nil, fmt.Errorf("invalid solana token accounts: %w", err)
+	}
+
+	var balances []models.ExchangeBalance
+	if lamports.Value > 0 {
+		amount := decimal.NewFromBigInt(new(big.Int).SetUint64(lamports.Value), -solanaDecimals)
+		balances = append(balances, models.ExchangeBalance{Asset: "SOL", Free: amount})
+	}
+
+	// A wallet may hold a mint in several token accounts
+	totals := make(map[string]decimal.Decimal)
+	for _, account := range accounts.Value {
+		info := account.Account.Data.Parsed.Info
+		token, ok := s.tokens[info.Mint]
+		if !ok {
+			continue
+		}
+		amount, err := parseAmount(info.TokenAmount.Amount, info.TokenAmount.Decimals)
+		if err != nil {
+			return nil, fmt.Errorf("invalid solana %s balance: %w", token.Symbol, err)
+		}
+		totals[token.Symbol] = totals[token.Symbol].Add(amount)
+	}
+	symbols := make([]string, 0, len(totals))
+	for symbol := range totals {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		if totals[symbol].IsPositive() {
+			balances = append(balances, models.ExchangeBalance{Asset: symbol, Free: totals[symbol]})
+		}
+	}
+	return balances, nil
+}
+
+// FetchTransfers returns the SOL and tracked token transfers of an address
+// in finalized slots from the cursor. The histories of the address and of its
+// current token accounts of tracked mints are read; transfers of token
+// accounts closed before the wallet was tracked are not. Fees paid by the
+// address are returned as fee entries, or as the fee of a SOL withdrawal
+// sent in the same transaction.
+func (s *solana) FetchTransfers(ctx context.Context, address, cursor string) (models.ExchangeActivity, string, error) {
+	cur, err := decodeBlockCursor(cursor)
+	if err != nil {
+		return models.ExchangeActivity{}, "", err
+	}
+
+	results, err := s.rpc(ctx, []rpcRequest{
+		{Method: "getSlot", Params: []interface{}{map[string]string{"commitment": "finalized"}}},
+		s.tokenAccountsCall(address),
+	})
+	if err != nil {
+		return models.ExchangeActivity{}, "", err
+	}
+	var end uint64
+	if err := json.Unmarshal(results[0], &end); err != nil {
+		return models.ExchangeActivity{}, "", fmt.Errorf("invalid solana slot: %w", err)
+	}
+	if end < cur.Block {
+		return models.ExchangeActivity{}, cursor, nil
+	}
+	var accounts solanaTokenAccounts
+	if err := json.Unmarshal(results[1], &accounts); err != nil {
+		return models.ExchangeActivity{}, "", fmt.Errorf("invalid solana token accounts: %w", err)
+	}
+
+	watched := []string{address}
+	for _, account := range accounts.Value {
+		if _, ok := s.tokens[account.Account.Data.Parsed.Info.Mint]; ok {
+			watched = append(watched, account.Pubkey)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var signatures []solanaSignature
+	for _, account := range watched {
+		listed, err := s.signatures(ctx, account, cur.Block, end)
+		if err != nil {
+			return models.ExchangeActivity{}, "", err
+		}
+		for _, sig := range listed {
+			if !seen[sig.Signature] {
+				seen[sig.Signature] = true
+				signatures = append(signatures, sig)
+			}
+		}
+	}
+	sort.SliceStable(signatures, func(i, j int) bool {
+		return signatures[i].Slot < signatures[j].Slot
+	})
+
+	// Long histories are cut before the slot of the first transaction left
+	// out, so the next sync starts at that slot
+	reached := end
+	if len(signatures) > solanaMaxTransactions {
+		last := signatures[solanaMaxTransactions].Slot
+		cut := solanaMaxTransactions
+		for cut > 0 && signatures[cut-1].Slot == last {
+			cut--
+		}
+		if cut == 0 {
+			return models.ExchangeActivity{}, "", fmt.Errorf("solana slot %d has more than %d transactions of the wallet", last, solanaMaxTransactions)
+		}
+		signatures = signatures[:cut]
+		reached = last - 1
+	}
+
+	var activity models.ExchangeActivity
+	for start := 0; start < len(signatures); start += solanaTransactionBatch {
+		stop := start + solanaTransactionBatch
+		if stop > len(signatures) {
+			stop = len(signatures)
+		}
+		calls := make([]rpcRequest, 0, stop-start)
+		for _, sig := range signatures[start:stop] {
+			calls = append(calls, rpcRequest{
+				Method: "getTransaction",
+				Params: []interface{}{sig.Signature, map[string]interface{}{
+					"encoding":                       "jsonParsed",
+					"commitment":                     "finalized",
+					"maxSupportedTransactionVersion": 0,
+				}},
+			})
+		}
+		results, err := s.rpc(ctx, calls)
+		if err != nil {
+			return models.ExchangeActivity{}, "", err
+		}
+		for i, raw := range results {
+			var tx *solanaTransaction
+			if err := json.Unmarshal(raw, &tx); err != nil {
+				return models.ExchangeActivity{}, "", fmt.Errorf("invalid solana transaction %s: %w", signatures[start+i].Signature, err)
+			}
+			// Nodes briefly miss finalized transactions they listed
+			if tx == nil {
+				return models.ExchangeActivity{}, "", fmt.Errorf("%w: solana transaction %s not found", exchanges.ErrTemporary, signatures[start+i].Signature)
+			}
+			entries, err := s.entries(address, signatures[start+i].Signature, tx)
+			if err != nil {
+				return models.ExchangeActivity{}, "", err
+			}
+			activity.Entries = append(activity.Entries, entries...)
+		}
+	}
+
+	next, err := encodeBlockCursor(&blockCursor{Block: reached + 1})
+	if err != nil {
+		return models.ExchangeActivity{}, "", err
+	}
+	return activity, next, nil
+}
+
+// signatures lists the transactions of an account within slots [start, end],
+// reading its history newest first in pages
+func (s *solana) signatures(ctx context.Context, account string, start, end uint64) ([]solanaSignature, error) {
+	var listed []solanaSignature
+	before := ""
+	for {
+		options := map[string]interface{}{"limit": solanaSignaturePage, "commitment": "finalized"}
+		if before != "" {
+			options["before"] = before
+		}
+		results, err := s.rpc(ctx, []rpcRequest{{Method: "getSignaturesForAddress", Params: []interface{}{account, options}}})
+		if err != nil {
+			return nil, err
+		}
+		var page []solanaSignature
+		if err := json.Unmarshal(results[0], &page); err != nil {
+			return nil, fmt.Errorf("invalid solana signatures of %s: %w", account, err)
+		}
+
+		for _, sig := range page {
+			if sig.Slot < start {
+				return listed, nil
+			}
+			if sig.Slot <= end {
+				listed = append(listed, sig)
+			}
+		}
+		if len(page) < solanaSignaturePage {
+			return listed, nil
+		}
+		before = page[len(page)-1].Signature
+	}
+}
+
+// entries maps the balance changes of a wallet in a transaction onto
+// activity entries
+func (s *solana) entries(address, signature string, tx *solanaTransaction) ([]models.ExchangeEntry, error) {
+	if tx.BlockTime == nil {
+		return nil, fmt.Errorf("solana transaction %s has no block time", signature)
+	}
+	at := time.Unix(*tx.BlockTime, 0).UTC()
+
+	var entries []models.ExchangeEntry
+	index := -1
+	for i, key := range tx.Transaction.Message.AccountKeys {
+		if key.Pubkey == address {
+			index = i
+			break
+		}
+	}
+	if index >= 0 && index < len(tx.Meta.PreBalances) && index < len(tx.Meta.PostBalances) {
+		delta := new(big.Int).SetUint64(tx.Meta.PostBalances[index])
+		delta.Sub(delta, new(big.Int).SetUint64(tx.Meta.PreBalances[index]))
+		// The first account pays the fee, including for failed transactions
+		fee := decimal.Zero
+		if index == 0 {
+			delta.Add(delta, new(big.Int).SetUint64(tx.Meta.Fee))
+			fee = decimal.NewFromBigInt(new(big.Int).SetUint64(tx.Meta.Fee), -solanaDecimals)
+		}
+
+		amount := decimal.NewFromBigInt(new(big.Int).Abs(delta), -solanaDecimals)
+		switch delta.Sign() {
+		case -1:
+			entries = append(entries, models.ExchangeEntry{ID: signature, Asset: "SOL", Type: "transfer_out", Amount: amount, Fee: fee, ExecutedAt: at})
+			fee = decimal.Zero
+		case 1:
+			entries = append(entries, models.ExchangeEntry{ID: signature, Asset: "SOL", Type: "transfer_in", Amount: amount, Fee: decimal.Zero, ExecutedAt: at})
+		}
+		if fee.IsPositive() {
+			entries = append(entries, models.ExchangeEntry{ID: signature + "/fee", Asset: "SOL", Type: "fee", Amount: fee, Fee: decimal.Zero, ExecutedAt: at})
+		}
+	}
+
+	deltas := make(map[string]decimal.Decimal)
+	for sign, balances := range map[int64][]solanaTokenBalance{-1: tx.Meta.PreTokenBalances, 1: tx.Meta.PostTokenBalances} {
+		for _, balance := range balances {
+			if balance.Owner != address {
+				continue
+			}
+			if _, ok := s.tokens[balance.Mint]; !ok {
+				continue
+			}
+			amount, err := parseAmount(balance.UITokenAmount.Amount, balance.UITokenAmount.Decimals)
+			if err != nil {
+				return nil, fmt.Errorf("invalid solana token balance in %s: %w", signature, err)
+			}
+			deltas[balance.Mint] = deltas[balance.Mint].Add(amount.Mul(decimal.NewFromInt(sign)))
+		}
+	}
+	mints := make([]string, 0, len(deltas))
+	for mint := range deltas {
+		mints = append(mints, mint)
+	}
+	sort.Strings(mints)
+	for _, mint := range mints {
+		entry := models.ExchangeEntry{ID: signature + "/" + mint, Asset: s.tokens[mint].Symbol, Amount: deltas[mint].Abs(), Fee: decimal.Zero, ExecutedAt: at}
+		switch deltas[mint].Sign() {
+		case -1:
+			entry.Type = "transfer_out"
+		case 1:
+			entry.Type = "transfer_in"
+		default:
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// FetchRewards returns the inflation rewards credited to a stake account in
+// the completed epochs from the cursor, whose block is the next epoch to
+// read. Rewards of an epoch are credited in the first block of the next one,
+// which dates them.
+func (s *solana) FetchRewards(ctx context.Context, address, cursor string) (models.ExchangeActivity, string, error) {
+	cur, err := decodeBlockCursor(cursor)
+	if err != nil {
+		return models.ExchangeActivity{}, "", err
+	}
+
+	results, err := s.rpc(ctx, []rpcRequest{
+		{Method: "getEpochInfo", Params: []interface{}{map[string]string{"commitment": "finalized"}}},
+		{Method: "getAccountInfo", Params: []interface{}{address, map[string]string{"encoding": "jsonParsed", "commitment": "finalized"}}},
+	})
+	if err != nil {
+		return models.ExchangeActivity{}, "", err
+	}
+	var info struct {
+		Epoch uint64 `json:"epoch"`
+	}
+	if err := json.Unmarshal(results[0], &info); err != nil {
+		return models.ExchangeActivity{}, "", fmt.Errorf("invalid solana epoch info: %w", err)
+	}
+
+	if cursor == "" {
+		var account struct {
+			Value *struct {
+				Data struct {
+					Parsed struct {
+						Type string `json:"type"`
+						Info struct {
+							Stake struct {
+								Delegation struct {
+									ActivationEpoch string `json:"activationEpoch"`
+								} `json:"delegation"`
+							} `json:"stake"`
+						} `json:"info"`
+					} `json:"parsed"`
+				} `json:"data"`
+			} `json:"value"`
+		}
+		if err := json.Unmarshal(results[1], &account); err != nil {
+			return models.ExchangeActivity{}, "", fmt.Errorf("invalid solana stake account: %w", err)
+		}
+		if account.Value == nil {
+			return models.ExchangeActivity{}, "", ErrInvalidAddress
+		}
+		// Stakes not delegated yet have earned nothing
+		if account.Value.Data.Parsed.Type != "delegated" {
+			return models.ExchangeActivity{}, cursor, nil
+		}
+		cur.Block, err = strconv.ParseUint(account.Value.Data.Parsed.Info.Stake.Delegation.ActivationEpoch, 10, 64)
+		if err != nil {
+			return models.ExchangeActivity{}, "", fmt.Errorf("invalid solana activation epoch: %w", err)
+		}
+	}
+
+	// The current epoch is still running
+	if info.Epoch == 0 || cur.Block >= info.Epoch {
+		return models.ExchangeActivity{}, cursor, nil
+	}
+	last := info.Epoch - 1
+	if last-cur.Block >= solanaMaxRewardEpochs {
+		last = cur.Block + solanaMaxRewardEpochs - 1
+	}
+
+	calls := make([]rpcRequest, 0, last-cur.Block+1)
+	for epoch := cur.Block; epoch <= last; epoch++ {
+		calls = append(calls, rpcRequest{
+			Method: "getInflationReward",
+			Params: []interface{}{[]string{address}, map[string]interface{}{"epoch": epoch, "commitment": "finalized"}},
+		})
+	}
+	results, err = s.rpc(ctx, calls)
+	if err != nil {
+		return models.ExchangeActivity{}, "", err
+	}
+
+	type reward struct {
+		Epoch         uint64 `json:"epoch"`
+		EffectiveSlot uint64 `json:"effectiveSlot"`
+		Amount        uint64 `json:"amount"`
+	}
+	var rewards []reward
+	for i, raw := range results {
+		var listed []*reward
+		if err := json.Unmarshal(raw, &listed); err != nil || len(listed) != 1 {
+			return models.ExchangeActivity{}, "", fmt.Errorf("invalid solana inflation reward of epoch %d", cur.Block+uint64(i))
+		}
+		if listed[0] != nil && listed[0].Amount > 0 {
+			rewards = append(rewards, *listed[0])
+		}
+	}
+
+	var activity models.ExchangeActivity
+	if len(rewards) > 0 {
+		calls = calls[:0]
+		for _, r := range rewards {
+			calls = append(calls, rpcRequest{Method: "getBlockTime", Params: []interface{}{r.EffectiveSlot}})
+		}
+		results, err = s.rpc(ctx, calls)
+		if err != nil {
+			return models.ExchangeActivity{}, "", err
+		}
+		for i, r := range rewards {
+			var seconds int64
+			if err := json.Unmarshal(results[i], &seconds); err != nil {
+				return models.ExchangeActivity{}, "", fmt.Errorf("invalid solana block time of slot %d: %w", r.EffectiveSlot, err)
+			}
+			activity.Entries = append(activity.Entries, models.ExchangeEntry{
+				ID:         "epoch/" + strconv.FormatUint(r.Epoch, 10),
+				Asset:      "SOL",
+				Type:       "reward",
+				Amount:     decimal.NewFromBigInt(new(big.Int).SetUint64(r.Amount), -solanaDecimals),
+				Fee:        decimal.Zero,
+				ExecutedAt: time.Unix(seconds, 0).UTC(),
+			})
+		}
+	}
+
+	next, err := encodeBlockCursor(&blockCursor{Block: last + 1})
+	if err != nil {
+		return models.ExchangeActivity{}, "", err
+	}
+	return activity, next, nil
+}
+
+// tokenAccountsCall requests the token accounts of an address
+func (s *solana) tokenAccountsCall(address string) rpcRequest {
+	return rpcRequest{
+		Method: "getTokenAccountsByOwner",
+		Params: []interface{}{
+			address,
+			map[string]string{"programId": solanaTokenProgram},
+			map[string]string{"encoding": "jsonParsed", "commitment": "finalized"},
+		},
+	}
+}
+
+// rpc sends a batch of JSON-RPC calls to the node and returns their results
+// in order
+func (s *solana) rpc(ctx context.Context, calls []rpcRequest) ([]json.RawMessage, error) {
+	return callRPC(ctx, s.client, s.cfg.RPCURL, "solana rpc", calls)
+}
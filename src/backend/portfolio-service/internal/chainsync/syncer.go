@@ -0,0 +1,123 @@
+// Package chainsync syncs the transfers of tracked on-chain wallets into their
+// portfolios in the background. Due wallets are leased so several service
+// instances can sync concurrently without importing a wallet twice.
+package chainsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"go.uber.org/zap"                                // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/metrics"
+	"bookman/portfolio-service/internal/repository"
+	"bookman/portfolio-service/internal/services"
+)
+
+// pollInterval is how often the syncer looks for due wallets
+const pollInterval = time.Minute
+
+var walletSyncs = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "portfolio_wallet_syncs_total",
+		Help: "Total number of tracked wallet syncs by chain and status",
+	},
+	[]string{"chain", "status"},
+)
+
+func init() {
+	metrics.MustRegister(walletSyncs)
+}
+
+// Syncer syncs due tracked wallets on a fixed interval
+type Syncer struct {
+	repo   *repository.PostgresRepository
+	svc    *services.PortfolioService
+	cfg    config.ChainsConfig
+	logger *zap.Logger
+}
+
+// NewSyncer creates a new tracked wallet sync job
+func NewSyncer(repo *repository.PostgresRepository, svc *services.PortfolioService, cfg config.ChainsConfig, logger *zap.Logger) (*Syncer, error) {
+	if repo == nil || svc == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	return &Syncer{
+		repo:   repo,
+		svc:    svc,
+		cfg:    cfg,
+		logger: logger.With(zap.String("component", "wallet_syncer")),
+	}, nil
+}
+
+// Run syncs due wallets at each poll until ctx is cancelled
+func (s *Syncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("Wallet sync pass failed", zap.Error(err))
+		}
+	}
+}
+
+// RunOnce claims and syncs batches of due wallets until none are left. A
+// failed sync is recorded on its wallet and retried at its next sync.
+func (s *Syncer) RunOnce(ctx context.Context) error {
+	failed := 0
+	for {
+		now := time.Now().UTC()
+		// The lease outlives a sync so a crashed worker's claims are retried
+		// after one interval
+		wallets, err := s.repo.ClaimDueTrackedWallets(ctx, now, now.Add(s.cfg.SyncInterval), s.cfg.SyncBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to claim tracked wallets: %w", err)
+		}
+
+		for i := range wallets {
+			wallet := &wallets[i]
+			result, err := s.svc.SyncTrackedWallet(ctx, wallet)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				failed++
+				walletSyncs.WithLabelValues(wallet.Chain, "error").Inc()
+				s.logger.Warn("Tracked wallet sync failed",
+					zap.Error(err),
+					zap.String("wallet_id", wallet.ID.String()),
+					zap.String("portfolio_id", wallet.PortfolioID.String()),
+					zap.String("chain", wallet.Chain),
+				)
+				continue
+			}
+
+			walletSyncs.WithLabelValues(wallet.Chain, "success").Inc()
+			s.logger.Debug("Tracked wallet synced",
+				zap.String("wallet_id", wallet.ID.String()),
+				zap.Int("transactions", result.TransactionsRecorded),
+			)
+		}
+
+		if len(wallets) < s.cfg.SyncBatchSize {
+			break
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d tracked wallets failed to sync", failed)
+	}
+	return nil
+}
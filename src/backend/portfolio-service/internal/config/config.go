@@ -3,9 +3,16 @@
 package config
 
 import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper" // v1.15.0
@@ -16,15 +23,111 @@ const (
 	defaultDBPort    = 5432
 	defaultServerPort = 8080
 	defaultMetricsPort = 9090
+
+	// minPaginationSecretLength matches the minimum accepted by the token codec
+	minPaginationSecretLength = 32
+
+	// minCredentialKeyLength matches the minimum accepted by the exchange
+	// credential cipher
+	minCredentialKeyLength = 32
+
+	// minEncryptionKeyLength matches the minimum accepted for local key
+	// encryption keys
+	minEncryptionKeyLength = 32
+
+	// maxSLOBuckets bounds the evaluation intervals a burn rate window may
+	// span, as the SLO recorder keeps counts per interval of the longest one
+	maxSLOBuckets = 10080
+
+	// maxBusinessValueBuckets bounds the label values of the portfolio value
+	// distribution exported to dashboards
+	maxBusinessValueBuckets = 10
+)
+
+// Key encryption key providers for envelope encryption
+const (
+	// EncryptionProviderLocal wraps data keys with a key held by the service
+	EncryptionProviderLocal = "local"
+	// EncryptionProviderVault wraps data keys with a Vault transit key
+	EncryptionProviderVault = "vault"
 )
 
+// SupportedProviderTypes lists the market data provider implementations
+var SupportedProviderTypes = []string{
+	"coingecko",
+	"coinmarketcap",
+	"cryptocompare",
+	"binance",
+}
+
+// SupportedFXProviderTypes lists the foreign exchange rate provider
+// implementations pricing fiat balances
+var SupportedFXProviderTypes = []string{
+	"frankfurter",
+}
+
+// Storage modes for portfolio state
+const (
+	// StorageModeState writes portfolio tables directly
+	StorageModeState = "state"
+	// StorageModeEventSourced appends changes to the portfolio ledger and
+	// projects them into the portfolio tables
+	StorageModeEventSourced = "event_sourced"
+)
+
+// SupportedNotificationEvents lists the notification events senders may subscribe to
+var SupportedNotificationEvents = []string{
+	"alert.triggered",
+	"drift.breached",
+	"portfolio.created",
+	"report.ready",
+}
+
 // Config represents the main configuration structure containing all service settings
 type Config struct {
-	Database DatabaseConfig `mapstructure:"database"`
-	Server   ServerConfig   `mapstructure:"server"`
-	Metrics  MetricsConfig  `mapstructure:"metrics"`
-	Cache    CacheConfig    `mapstructure:"cache"`
-	Version  string         `mapstructure:"version"`
+	Database      DatabaseConfig      `mapstructure:"database"`
+	Server        ServerConfig        `mapstructure:"server"`
+	Metrics       MetricsConfig       `mapstructure:"metrics"`
+	Logging       LoggingConfig       `mapstructure:"logging"`
+	Cache         CacheConfig         `mapstructure:"cache"`
+	Providers     []ProviderConfig    `mapstructure:"providers"`
+	FXProviders   []ProviderConfig    `mapstructure:"fx_providers"`
+	Archive       ArchiveConfig       `mapstructure:"archive"`
+	Snapshots     SnapshotConfig      `mapstructure:"snapshots"`
+	Verifier      VerifierConfig      `mapstructure:"verifier"`
+	BusinessMetrics BusinessMetricsConfig `mapstructure:"business_metrics"`
+	Drift         DriftConfig         `mapstructure:"drift"`
+	Deprecation   DeprecationConfig   `mapstructure:"deprecation"`
+	SLO           SLOConfig           `mapstructure:"slo"`
+	Deadlines     DeadlineConfig      `mapstructure:"deadlines"`
+	LoadShedding  LoadSheddingConfig  `mapstructure:"load_shedding"`
+	Playground    PlaygroundConfig    `mapstructure:"playground"`
+	Pagination    PaginationConfig    `mapstructure:"pagination"`
+	Notifications NotificationsConfig `mapstructure:"notifications"`
+	Webhooks      WebhooksConfig      `mapstructure:"webhooks"`
+	Outbox        OutboxConfig        `mapstructure:"outbox"`
+	Ledger        LedgerConfig        `mapstructure:"ledger"`
+	Exchanges     ExchangesConfig     `mapstructure:"exchanges"`
+	Encryption    EncryptionConfig    `mapstructure:"encryption"`
+	Reports       ReportsConfig       `mapstructure:"reports"`
+	Chains        ChainsConfig        `mapstructure:"chains"`
+	Staking       StakingConfig       `mapstructure:"staking"`
+	CorporateActions CorporateActionsConfig `mapstructure:"corporate_actions"`
+	CostBasis     CostBasisConfig     `mapstructure:"cost_basis"`
+	Tax           TaxConfig           `mapstructure:"tax"`
+	Compliance    ComplianceConfig    `mapstructure:"compliance"`
+	Sharing       SharingConfig       `mapstructure:"sharing"`
+	Admin         AdminConfig         `mapstructure:"admin"`
+	PriceRefresh  PriceRefreshConfig  `mapstructure:"price_refresh"`
+	Jobs          JobsConfig          `mapstructure:"jobs"`
+	Locking       LockingConfig       `mapstructure:"locking"`
+	Scheduler     SchedulerConfig     `mapstructure:"scheduler"`
+	Startup       StartupConfig       `mapstructure:"startup"`
+	Remote        RemoteConfig        `mapstructure:"remote"`
+	Version       string              `mapstructure:"version"`
+
+	// Origin describes where the configuration was loaded from
+	Origin Origin `mapstructure:"-" json:"-"`
 }
 
 // DatabaseConfig contains comprehensive database connection settings
@@ -32,7 +135,7 @@ type DatabaseConfig struct {
 	Host            string        `mapstructure:"host"`
 	Port            int           `mapstructure:"port"`
 	User            string        `mapstructure:"user"`
-	Password        string        `mapstructure:"password"`
+	Password        string        `mapstructure:"password" secret:"true"`
 	Database        string        `mapstructure:"database"`
 	SSLMode         string        `mapstructure:"ssl_mode"`
 	SSLCert         string        `mapstructure:"ssl_cert"`
@@ -43,30 +146,109 @@ type DatabaseConfig struct {
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
 	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
 	StatementTimeout time.Duration `mapstructure:"statement_timeout"`
+	ReplicaHost     string        `mapstructure:"replica_host"`
+	ReplicaPort     int           `mapstructure:"replica_port"`
 }
 
 // ServerConfig contains API server configuration settings
 type ServerConfig struct {
-	Host            string        `mapstructure:"host"`
-	Port            int           `mapstructure:"port"`
-	ReadTimeout     time.Duration `mapstructure:"read_timeout"`
-	WriteTimeout    time.Duration `mapstructure:"write_timeout"`
-	IdleTimeout     time.Duration `mapstructure:"idle_timeout"`
-	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
-	MaxHeaderBytes  int           `mapstructure:"max_header_bytes"`
-	TLSEnabled      bool          `mapstructure:"tls_enabled"`
-	TLSCert         string        `mapstructure:"tls_cert"`
-	TLSKey          string        `mapstructure:"tls_key"`
+	Host            string          `mapstructure:"host"`
+	Port            int             `mapstructure:"port"`
+	ReadTimeout     time.Duration   `mapstructure:"read_timeout"`
+	WriteTimeout    time.Duration   `mapstructure:"write_timeout"`
+	IdleTimeout     time.Duration   `mapstructure:"idle_timeout"`
+	ShutdownTimeout time.Duration   `mapstructure:"shutdown_timeout"`
+	MaxHeaderBytes  int             `mapstructure:"max_header_bytes"`
+	TLSEnabled      bool            `mapstructure:"tls_enabled"`
+	TLSCert         string          `mapstructure:"tls_cert"`
+	TLSKey          string          `mapstructure:"tls_key"`
+	GRPCWeb         GRPCWebConfig   `mapstructure:"grpc_web"`
+	Keepalive       KeepaliveConfig `mapstructure:"keepalive"`
+	// MaxRecvMsgSize and MaxSendMsgSize bound the size in bytes of gRPC
+	// messages the server receives and sends
+	MaxRecvMsgSize int `mapstructure:"max_recv_msg_size"`
+	MaxSendMsgSize int `mapstructure:"max_send_msg_size"`
+	// MaxConcurrentStreams limits the concurrent streams of each client
+	// connection; zero leaves them unlimited
+	MaxConcurrentStreams uint32 `mapstructure:"max_concurrent_streams"`
+	// Reflection registers the gRPC reflection service, which should be
+	// disabled in production so the API is not discoverable
+	Reflection bool `mapstructure:"reflection"`
+	// GzipLevel is the compression level of responses to clients that
+	// request gzip encoding
+	GzipLevel int `mapstructure:"gzip_level"`
+}
+
+// GRPCWebConfig contains settings for serving gRPC-Web to browser clients.
+// TLS reuses the certificate of the gRPC server.
+type GRPCWebConfig struct {
+	Enabled               bool          `mapstructure:"enabled"`
+	Host                  string        `mapstructure:"host"`
+	Port                  int           `mapstructure:"port"`
+	AllowedOrigins        []string      `mapstructure:"allowed_origins"`
+	Websockets            bool          `mapstructure:"websockets"`
+	WebsocketPingInterval time.Duration `mapstructure:"websocket_ping_interval"`
+	IdleTimeout           time.Duration `mapstructure:"idle_timeout"`
+}
+
+// KeepaliveConfig contains the keepalive and connection age settings of the
+// gRPC server. Zero connection idle and age limits keep connections open
+// indefinitely.
+type KeepaliveConfig struct {
+	MaxConnectionIdle     time.Duration `mapstructure:"max_connection_idle"`
+	MaxConnectionAge      time.Duration `mapstructure:"max_connection_age"`
+	MaxConnectionAgeGrace time.Duration `mapstructure:"max_connection_age_grace"`
+	Time                  time.Duration `mapstructure:"time"`
+	Timeout               time.Duration `mapstructure:"timeout"`
+	// MinTime and PermitWithoutStream form the enforcement policy: clients
+	// pinging more often, or without active streams when not permitted, are
+	// disconnected
+	MinTime             time.Duration `mapstructure:"min_time"`
+	PermitWithoutStream bool          `mapstructure:"permit_without_stream"`
 }
 
 // MetricsConfig contains metrics and monitoring configuration
 type MetricsConfig struct {
-	Enabled            bool              `mapstructure:"enabled"`
-	Host               string            `mapstructure:"host"`
-	Port               int               `mapstructure:"port"`
-	Path               string            `mapstructure:"path"`
-	CollectionInterval time.Duration     `mapstructure:"collection_interval"`
-	Labels             map[string]string `mapstructure:"labels"`
+	Enabled            bool          `mapstructure:"enabled"`
+	Host               string        `mapstructure:"host"`
+	Port               int           `mapstructure:"port"`
+	Path               string        `mapstructure:"path"`
+	CollectionInterval time.Duration `mapstructure:"collection_interval"`
+	// Labels are added to every exported metric to identify the deployment,
+	// such as its environment, region or cluster
+	Labels map[string]string `mapstructure:"labels"`
+	// LatencyBuckets are the upper bounds in seconds of the request latency
+	// histogram buckets
+	LatencyBuckets []float64 `mapstructure:"latency_buckets"`
+	// NativeHistograms additionally exposes request latency as a Prometheus
+	// native histogram to scrapers negotiating the protobuf format
+	NativeHistograms NativeHistogramConfig `mapstructure:"native_histograms"`
+	// GoCollector exports Go runtime metrics such as goroutines and GC pauses
+	GoCollector bool `mapstructure:"go_collector"`
+	// ProcessCollector exports process metrics such as CPU, memory and open
+	// file descriptors
+	ProcessCollector bool `mapstructure:"process_collector"`
+
+	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout  time.Duration `mapstructure:"idle_timeout"`
+	TLSEnabled   bool          `mapstructure:"tls_enabled"`
+	TLSCert      string        `mapstructure:"tls_cert"`
+	TLSKey       string        `mapstructure:"tls_key"`
+	// TLSClientCA, when set, is the CA bundle client certificates must be
+	// signed by; scrapers without a valid certificate are refused
+	TLSClientCA string `mapstructure:"tls_client_ca"`
+}
+
+// NativeHistogramConfig contains Prometheus native histogram settings
+type NativeHistogramConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// BucketFactor is the maximum ratio between the bounds of adjacent
+	// buckets, which sets the histogram's resolution
+	BucketFactor float64 `mapstructure:"bucket_factor"`
+	// MaxBuckets bounds the buckets of each series; resolution is reduced
+	// once it is reached. Zero leaves the bucket count unbounded.
+	MaxBuckets uint32 `mapstructure:"max_buckets"`
 }
 
 // CacheConfig contains Redis cache configuration settings
@@ -74,7 +256,7 @@ type CacheConfig struct {
 	Enabled       bool          `mapstructure:"enabled"`
 	Host          string        `mapstructure:"host"`
 	Port          int           `mapstructure:"port"`
-	Password      string        `mapstructure:"password"`
+	Password      string        `mapstructure:"password" secret:"true"`
 	DB            int           `mapstructure:"db"`
 	TTL           time.Duration `mapstructure:"ttl"`
 	PoolSize      int           `mapstructure:"pool_size"`
@@ -82,6 +264,648 @@ type CacheConfig struct {
 	MaxRetries    int           `mapstructure:"max_retries"`
 	TLSEnabled    bool          `mapstructure:"tls_enabled"`
 	TLSCert       string        `mapstructure:"tls_cert"`
+	// LocalSize bounds the in-process price cache kept in front of Redis
+	LocalSize     int           `mapstructure:"local_size"`
+	// LocalTTL is how long prices are served from the in-process cache
+	LocalTTL      time.Duration `mapstructure:"local_ttl"`
+}
+
+// ProviderConfig describes a named market data price provider
+type ProviderConfig struct {
+	Name      string `mapstructure:"name"`
+	Type      string `mapstructure:"type"`
+	BaseURL   string `mapstructure:"base_url"`
+	APIKeyEnv string `mapstructure:"api_key_env"`
+	RateLimit int    `mapstructure:"rate_limit"` // requests per minute
+	Priority  int    `mapstructure:"priority"`   // lower values are consulted first
+}
+
+// ArchiveConfig contains cold storage archival settings for transaction history
+type ArchiveConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	RetentionYears int           `mapstructure:"retention_years"`
+	Interval       time.Duration `mapstructure:"interval"`
+	BatchSize      int           `mapstructure:"batch_size"`
+	Bucket         string        `mapstructure:"bucket"`
+	Prefix         string        `mapstructure:"prefix"`
+	Region         string        `mapstructure:"region"`
+	Endpoint       string        `mapstructure:"endpoint"`
+	StorageClass   string        `mapstructure:"storage_class"`
+}
+
+// SnapshotConfig contains settings for scheduled portfolio valuation snapshots
+type SnapshotConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	Interval  time.Duration `mapstructure:"interval"`
+	BatchSize int           `mapstructure:"batch_size"`
+}
+
+// VerifierConfig contains settings for periodic ledger invariant verification
+type VerifierConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Interval     time.Duration `mapstructure:"interval"`
+	CheckTimeout time.Duration `mapstructure:"check_timeout"`
+}
+
+// BusinessMetricsConfig contains settings for the periodic export of
+// aggregate business metrics to product dashboards. Portfolios are counted
+// by value under ValueBuckets, ascending lower bounds in the base currency,
+// and syncs are counted over the trailing SyncWindow.
+type BusinessMetricsConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Interval     time.Duration `mapstructure:"interval"`
+	QueryTimeout time.Duration `mapstructure:"query_timeout"`
+	ValueBuckets []float64     `mapstructure:"value_buckets"`
+	SyncWindow   time.Duration `mapstructure:"sync_window"`
+}
+
+// DriftConfig contains settings for periodic allocation drift alerts on
+// portfolios with target allocations
+type DriftConfig struct {
+	AlertsEnabled bool          `mapstructure:"alerts_enabled"`
+	Interval      time.Duration `mapstructure:"interval"`
+	Threshold     float64       `mapstructure:"threshold"`
+	BatchSize     int           `mapstructure:"batch_size"`
+}
+
+// DeprecationConfig contains settings for signaling deprecated API usage.
+// Sunsets maps proto full names of deprecated methods or fields, e.g.
+// "portfolio.GetPerformanceMetricsRequest.time_period", to YYYY-MM-DD dates.
+// Calls are counted by client version bucketed to major.minor; when
+// ClientVersions lists major.minor versions, any other is counted as "other".
+type DeprecationConfig struct {
+	Enabled        bool              `mapstructure:"enabled"`
+	Sunsets        map[string]string `mapstructure:"sunsets"`
+	ClientVersions []string          `mapstructure:"client_versions"`
+}
+
+// SLOConfig contains the service level objectives of the gRPC API. Each call
+// is measured against the first objective listing its method, or against an
+// objective listing no methods. Error budget burn rates are recorded over each
+// window; the longest window is the compliance period of the error budget.
+type SLOConfig struct {
+	Enabled            bool               `mapstructure:"enabled"`
+	Objectives         []ServiceObjective `mapstructure:"objectives"`
+	BurnRateWindows    []time.Duration    `mapstructure:"burn_rate_windows"`
+	EvaluationInterval time.Duration      `mapstructure:"evaluation_interval"`
+}
+
+// ServiceObjective defines the availability and latency targets of a set of
+// methods, given as full gRPC method names or bare method names. Availability
+// is the ratio of calls without server errors; LatencyTarget is the ratio of
+// those completing within LatencyThreshold. A zero threshold disables the
+// latency objective.
+type ServiceObjective struct {
+	Name             string        `mapstructure:"name"`
+	Methods          []string      `mapstructure:"methods"`
+	Availability     float64       `mapstructure:"availability"`
+	LatencyThreshold time.Duration `mapstructure:"latency_threshold"`
+	LatencyTarget    float64       `mapstructure:"latency_target"`
+}
+
+// DeadlineConfig contains the deadlines applied to calls whose client sent
+// none. Methods maps bare method names, matched case-insensitively, to their
+// deadline. Unary calls of other methods get Default; streaming calls only get
+// a listed deadline, as they last as long as the client keeps sending.
+type DeadlineConfig struct {
+	Enabled bool                     `mapstructure:"enabled"`
+	Default time.Duration            `mapstructure:"default"`
+	Methods map[string]time.Duration `mapstructure:"methods"`
+}
+
+// LoadSheddingConfig contains settings for rejecting calls early when the
+// service is saturated. Each method runs at most its concurrency limit of
+// calls at once; further calls wait up to QueueTimeout in a queue of at most
+// QueueSize calls. The limit of each method adapts between MinLimit and
+// MaxLimit, shrinking once recent unary latency exceeds the long-term latency
+// by more than Tolerance and growing while it does not. Streams hold a slot
+// until they end but do not adapt the limit. Exempt methods are given by bare
+// name.
+type LoadSheddingConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	InitialLimit  int           `mapstructure:"initial_limit"`
+	MinLimit      int           `mapstructure:"min_limit"`
+	MaxLimit      int           `mapstructure:"max_limit"`
+	Tolerance     float64       `mapstructure:"tolerance"`
+	QueueSize     int           `mapstructure:"queue_size"`
+	QueueTimeout  time.Duration `mapstructure:"queue_timeout"`
+	ExemptMethods []string      `mapstructure:"exempt_methods"`
+}
+
+// LoggingConfig contains structured logging settings. Encoding is json or
+// console, defaulting to console with colored levels in development mode and
+// json otherwise. Sampling keeps the first Initial entries with the same level
+// and message each second, then every Thereafter-th one. Stack traces are
+// added to entries at or above StacktraceLevel; an empty level disables them.
+type LoggingConfig struct {
+	Level            string                `mapstructure:"level"`
+	Encoding         string                `mapstructure:"encoding"`
+	Development      bool                  `mapstructure:"development"`
+	Sampling         LoggingSamplingConfig `mapstructure:"sampling"`
+	OutputPaths      []string              `mapstructure:"output_paths"`
+	ErrorOutputPaths []string              `mapstructure:"error_output_paths"`
+	DisableCaller    bool                  `mapstructure:"disable_caller"`
+	StacktraceLevel  string                `mapstructure:"stacktrace_level"`
+}
+
+// LoggingSamplingConfig contains the log sampling rates
+type LoggingSamplingConfig struct {
+	Enabled    bool `mapstructure:"enabled"`
+	Initial    int  `mapstructure:"initial"`
+	Thereafter int  `mapstructure:"thereafter"`
+}
+
+// NotificationsConfig contains settings for dispatching alert and lifecycle
+// notifications. Each sender receives the events it lists, or all events when
+// none are listed. Undeliverable notifications are kept as dead letters.
+type NotificationsConfig struct {
+	Enabled        bool                      `mapstructure:"enabled"`
+	QueueSize      int                       `mapstructure:"queue_size"`
+	Workers        int                       `mapstructure:"workers"`
+	MaxAttempts    int                       `mapstructure:"max_attempts"`
+	InitialBackoff time.Duration             `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration             `mapstructure:"max_backoff"`
+	SendTimeout    time.Duration             `mapstructure:"send_timeout"`
+	Webhook        WebhookSenderConfig       `mapstructure:"webhook"`
+	Email          EmailSenderConfig         `mapstructure:"email"`
+	Service        NotificationServiceConfig `mapstructure:"service"`
+}
+
+// WebhookSenderConfig contains settings for posting notifications to an HTTP
+// endpoint. Payloads are signed with the secret read from SecretEnv.
+type WebhookSenderConfig struct {
+	Enabled   bool     `mapstructure:"enabled"`
+	URL       string   `mapstructure:"url"`
+	SecretEnv string   `mapstructure:"secret_env"`
+	Events    []string `mapstructure:"events"`
+}
+
+// EmailSenderConfig contains settings for mailing notifications over SMTP to
+// a fixed list of recipients. The password is read from PasswordEnv.
+type EmailSenderConfig struct {
+	Enabled     bool     `mapstructure:"enabled"`
+	Host        string   `mapstructure:"host"`
+	Port        int      `mapstructure:"port"`
+	Username    string   `mapstructure:"username"`
+	PasswordEnv string   `mapstructure:"password_env"`
+	From        string   `mapstructure:"from"`
+	To          []string `mapstructure:"to"`
+	Events      []string `mapstructure:"events"`
+}
+
+// NotificationServiceConfig contains settings for forwarding notifications to
+// the bookman notification service, which routes them to users
+type NotificationServiceConfig struct {
+	Enabled    bool     `mapstructure:"enabled"`
+	Address    string   `mapstructure:"address"`
+	TLSEnabled bool     `mapstructure:"tls_enabled"`
+	Events     []string `mapstructure:"events"`
+}
+
+// WebhooksConfig contains settings for user-registered webhooks on portfolio
+// mutations. Private network targets are rejected unless explicitly allowed.
+type WebhooksConfig struct {
+	Enabled             bool          `mapstructure:"enabled"`
+	Interval            time.Duration `mapstructure:"interval"`
+	BatchSize           int           `mapstructure:"batch_size"`
+	MaxAttempts         int           `mapstructure:"max_attempts"`
+	InitialBackoff      time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff          time.Duration `mapstructure:"max_backoff"`
+	Timeout             time.Duration `mapstructure:"timeout"`
+	AllowInsecure       bool          `mapstructure:"allow_insecure"`
+	AllowPrivateTargets bool          `mapstructure:"allow_private_targets"`
+}
+
+// OutboxConfig contains settings for publishing portfolio domain events to
+// Kafka through the transactional outbox. Events are keyed by portfolio ID;
+// the SASL/PLAIN password is read from SASLPasswordEnv.
+type OutboxConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	Brokers         []string      `mapstructure:"brokers"`
+	Topic           string        `mapstructure:"topic"`
+	Interval        time.Duration `mapstructure:"interval"`
+	BatchSize       int           `mapstructure:"batch_size"`
+	WriteTimeout    time.Duration `mapstructure:"write_timeout"`
+	Retention       time.Duration `mapstructure:"retention"`
+	TLSEnabled      bool          `mapstructure:"tls_enabled"`
+	SASLUsername    string        `mapstructure:"sasl_username"`
+	SASLPasswordEnv string        `mapstructure:"sasl_password_env"`
+}
+
+// LedgerConfig contains settings for portfolio state storage. In event-sourced
+// mode portfolio changes are appended to a per-portfolio ledger, the portfolio
+// tables become a projection of it, and as-of reconstruction is available.
+// Portfolios created in state mode have no ledger to replay.
+type LedgerConfig struct {
+	StorageMode         string        `mapstructure:"storage_mode"`
+	ProjectionInterval  time.Duration `mapstructure:"projection_interval"`
+	ProjectionBatchSize int           `mapstructure:"projection_batch_size"`
+}
+
+// EventSourced reports whether portfolio changes are stored as ledger events
+func (c LedgerConfig) EventSourced() bool {
+	return c.StorageMode == StorageModeEventSourced
+}
+
+// ExchangesConfig contains settings for importing balances and trade history
+// from centralized exchange accounts. Cash assets are valued at 1 USD and not
+// tracked as holdings. Failed requests are retried up to MaxAttempts times
+// with exponential backoff.
+//
+// Accounts can be connected for background sync every SyncInterval when
+// CredentialKeyEnv names the variable holding the key their API credentials
+// are encrypted with; the previous key is accepted for decryption only, to
+// allow rotation. Without a key, credentials are supplied with each import and
+// never stored. Credentials are envelope encrypted under the encryption
+// settings; when no encryption provider is configured the credential keys
+// serve as local key encryption keys, and credentials sealed directly with
+// them before envelope encryption remain readable.
+type ExchangesConfig struct {
+	Enabled                  bool          `mapstructure:"enabled"`
+	RequestTimeout           time.Duration `mapstructure:"request_timeout"`
+	CashAssets               []string      `mapstructure:"cash_assets"`
+	MaxAttempts              int           `mapstructure:"max_attempts"`
+	RetryBaseDelay           time.Duration `mapstructure:"retry_base_delay"`
+	RetryMaxDelay            time.Duration `mapstructure:"retry_max_delay"`
+	CredentialKeyEnv         string        `mapstructure:"credential_key_env"`
+	PreviousCredentialKeyEnv string        `mapstructure:"previous_credential_key_env"`
+	SyncInterval             time.Duration `mapstructure:"sync_interval"`
+	SyncBatchSize            int           `mapstructure:"sync_batch_size"`
+	Binance                  BinanceConfig `mapstructure:"binance"`
+	Kraken                   KrakenConfig  `mapstructure:"kraken"`
+}
+
+// CredentialKeys resolves the configured credential keys, current key first
+func (e ExchangesConfig) CredentialKeys() [][]byte {
+	var keys [][]byte
+	for _, env := range []string{e.CredentialKeyEnv, e.PreviousCredentialKeyEnv} {
+		if env == "" {
+			continue
+		}
+		if v := os.Getenv(env); v != "" {
+			keys = append(keys, []byte(v))
+		}
+	}
+	return keys
+}
+
+// EncryptionConfig contains settings for envelope encryption of sensitive
+// fields at rest, such as exchange API credentials and webhook signing
+// secrets. Each value is encrypted under its own data key, which is wrapped by
+// a key encryption key: a Vault transit key, or for deployments without a key
+// management service a local key read from KeyEnv. Values wrapped by the
+// previous key stay readable until the rotate-encryption-keys command rewraps
+// them.
+type EncryptionConfig struct {
+	Provider       string      `mapstructure:"provider"`
+	KeyEnv         string      `mapstructure:"key_env"`
+	PreviousKeyEnv string      `mapstructure:"previous_key_env"`
+	Vault          VaultConfig `mapstructure:"vault"`
+}
+
+// VaultConfig contains settings for the Vault transit secrets engine. Rotating
+// the transit key within Vault needs no configuration change; PreviousKey is
+// only set while moving to a different transit key.
+type VaultConfig struct {
+	Address     string        `mapstructure:"address"`
+	TokenEnv    string        `mapstructure:"token_env"`
+	Mount       string        `mapstructure:"mount"`
+	Key         string        `mapstructure:"key"`
+	PreviousKey string        `mapstructure:"previous_key"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+}
+
+// Enabled reports whether a key encryption key provider is configured
+func (e EncryptionConfig) Enabled() bool {
+	return e.Provider != ""
+}
+
+// LocalKeys resolves the configured local key encryption keys, current key
+// first
+func (e EncryptionConfig) LocalKeys() [][]byte {
+	var keys [][]byte
+	for _, env := range []string{e.KeyEnv, e.PreviousKeyEnv} {
+		if env == "" {
+			continue
+		}
+		if v := os.Getenv(env); v != "" {
+			keys = append(keys, []byte(v))
+		}
+	}
+	return keys
+}
+
+// ReportsConfig contains settings for generated performance reports.
+// Reports are stored in an S3-compatible bucket and downloaded through
+// signed URLs valid for URLExpiry, or DeliveryURLExpiry for scheduled
+// reports delivered through notifications.
+type ReportsConfig struct {
+	Enabled           bool          `mapstructure:"enabled"`
+	Bucket            string        `mapstructure:"bucket"`
+	Prefix            string        `mapstructure:"prefix"`
+	Region            string        `mapstructure:"region"`
+	Endpoint          string        `mapstructure:"endpoint"`
+	URLExpiry         time.Duration `mapstructure:"url_expiry"`
+	DeliveryURLExpiry time.Duration `mapstructure:"delivery_url_expiry"`
+	ScheduleBatchSize int           `mapstructure:"schedule_batch_size"`
+}
+
+// BinanceConfig contains settings for the Binance spot connector. Trade
+// history is fetched for pairs of held assets against QuoteAssets.
+type BinanceConfig struct {
+	BaseURL     string        `mapstructure:"base_url"`
+	QuoteAssets []string      `mapstructure:"quote_assets"`
+	RecvWindow  time.Duration `mapstructure:"recv_window"`
+}
+
+// KrakenConfig contains settings for the Kraken connector. History is read
+// from the account ledger; QuoteAssets orders the assets treated as the quote
+// side of a trade, most preferred first.
+type KrakenConfig struct {
+	BaseURL     string   `mapstructure:"base_url"`
+	QuoteAssets []string `mapstructure:"quote_assets"`
+}
+
+// ChainsConfig contains settings for tracked on-chain wallets, which are read
+// from public chain data into read-only portfolios refreshed every
+// SyncInterval. Transfers are valued at the daily close of their day;
+// PeggedAssets are valued at 1 USD. Each chain is tracked when its own
+// Enabled flag is set.
+type ChainsConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	RequestTimeout time.Duration `mapstructure:"request_timeout"`
+	MaxAttempts    int           `mapstructure:"max_attempts"`
+	RetryBaseDelay time.Duration `mapstructure:"retry_base_delay"`
+	RetryMaxDelay  time.Duration `mapstructure:"retry_max_delay"`
+	PeggedAssets   []string      `mapstructure:"pegged_assets"`
+	SyncInterval   time.Duration `mapstructure:"sync_interval"`
+	SyncBatchSize  int           `mapstructure:"sync_batch_size"`
+	Ethereum       EVMConfig     `mapstructure:"ethereum"`
+	Polygon        EVMConfig     `mapstructure:"polygon"`
+	BSC            EVMConfig     `mapstructure:"bsc"`
+	Bitcoin        BitcoinConfig `mapstructure:"bitcoin"`
+	Solana         SolanaConfig  `mapstructure:"solana"`
+}
+
+// EVMConfig contains settings for the adapter of an EVM chain. Balances are
+// read from a JSON-RPC node and history from an Etherscan-compatible
+// indexer, whose API key is read from IndexerAPIKeyEnv. Transfers are read
+// once they have Confirmations blocks on top; only the native asset,
+// reported as NativeSymbol, and the listed Tokens are tracked.
+type EVMConfig struct {
+	Enabled           bool       `mapstructure:"enabled"`
+	RPCURL            string     `mapstructure:"rpc_url"`
+	IndexerURL        string     `mapstructure:"indexer_url"`
+	IndexerAPIKeyEnv  string     `mapstructure:"indexer_api_key_env"`
+	ChainID           int64      `mapstructure:"chain_id"`
+	Confirmations     int        `mapstructure:"confirmations"`
+	NativeSymbol      string     `mapstructure:"native_symbol"`
+	RequestsPerSecond float64    `mapstructure:"requests_per_second"`
+	Tokens            []EVMToken `mapstructure:"tokens"`
+}
+
+// BitcoinConfig contains settings for the Bitcoin adapter, which tracks
+// HD wallets by extended public key. Addresses and history are read from an
+// Esplora HTTP API such as the one served by electrs. Derived addresses are
+// scanned until GapLimit consecutive unused ones; transfers are read once
+// they have Confirmations blocks.
+type BitcoinConfig struct {
+	Enabled           bool    `mapstructure:"enabled"`
+	EsploraURL        string  `mapstructure:"esplora_url"`
+	Confirmations     int     `mapstructure:"confirmations"`
+	GapLimit          int     `mapstructure:"gap_limit"`
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
+}
+
+// SolanaConfig contains settings for the Solana adapter, which reads
+// balances and finalized history from a JSON-RPC node. Only SOL and the SPL
+// tokens listed in Tokens are tracked.
+type SolanaConfig struct {
+	Enabled           bool          `mapstructure:"enabled"`
+	RPCURL            string        `mapstructure:"rpc_url"`
+	RequestsPerSecond float64       `mapstructure:"requests_per_second"`
+	Tokens            []SolanaToken `mapstructure:"tokens"`
+}
+
+// StakingConfig contains settings for staking reward accrual. Rewards of
+// chain staking positions are read every AccrualInterval and need chain
+// tracking; rewards of exchange positions are recorded by the exchange
+// account sync.
+type StakingConfig struct {
+	Enabled          bool          `mapstructure:"enabled"`
+	AccrualInterval  time.Duration `mapstructure:"accrual_interval"`
+	AccrualBatchSize int           `mapstructure:"accrual_batch_size"`
+}
+
+// CorporateActionsConfig contains the cost basis policies of positions
+// credited by airdrops and chain forks. With "zero" the position has no
+// cost basis; with "allocated" an airdrop is valued at its market value when
+// received and a fork takes the share of its parent's cost basis matching
+// their market values at the fork.
+type CorporateActionsConfig struct {
+	AirdropCostBasis string `mapstructure:"airdrop_cost_basis"`
+	ForkCostBasis    string `mapstructure:"fork_cost_basis"`
+}
+
+// CostBasisConfig contains the treatment of transaction fees in cost basis
+// and realized profit and loss. With "capitalize" the fee of a buy adds to
+// the cost of the lot it opens and the fee of a sell reduces its proceeds;
+// with "expense" fees are left out of both. Fees paid are reported either
+// way.
+type CostBasisConfig struct {
+	FeePolicy string `mapstructure:"fee_policy"`
+}
+
+// TaxConfig contains the assumptions of tax estimates. EstimatedRate, a
+// fraction, values the tax saved by harvesting a loss when the request sets
+// no rate; a purchase within WashSaleWindow before or after a loss disposal
+// flags a potential wash sale. The rest apply to users who have stored no
+// tax settings of their own: lots held longer than LongTermHoldingPeriod
+// are long-term, and tax reports disallow the losses of wash sales only when
+// Jurisdiction, an ISO 3166-1 alpha-2 country code, is one of
+// WashSaleJurisdictions.
+type TaxConfig struct {
+	EstimatedRate         float64       `mapstructure:"estimated_rate"`
+	WashSaleWindow        time.Duration `mapstructure:"wash_sale_window"`
+	LongTermHoldingPeriod time.Duration `mapstructure:"long_term_holding_period"`
+	Jurisdiction          string        `mapstructure:"jurisdiction"`
+	WashSaleJurisdictions []string      `mapstructure:"wash_sale_jurisdictions"`
+}
+
+// ComplianceConfig contains data protection settings. PurgeEnabled exposes
+// the PurgeUserData RPC, which permanently erases all portfolio data of a
+// user; it is admin only, so it requires admin authentication to be enabled.
+type ComplianceConfig struct {
+	PurgeEnabled bool `mapstructure:"purge_enabled"`
+}
+
+// SharingConfig contains settings for read-only portfolio share links.
+// Share tokens expire after DefaultTTL unless the owner asks for another
+// lifetime, which may not exceed MaxTTL.
+type SharingConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	DefaultTTL time.Duration `mapstructure:"default_ttl"`
+	MaxTTL     time.Duration `mapstructure:"max_ttl"`
+}
+
+// AdminConfig contains settings of the internal AdminService used by
+// support and ops tooling. Operators authenticate with bearer tokens in the
+// authorization metadata; only the hex-encoded SHA-256 hash of each token is
+// configured, along with the scopes it grants. AdminService methods require
+// the admin scope.
+type AdminConfig struct {
+	Enabled bool         `mapstructure:"enabled"`
+	Tokens  []AdminToken `mapstructure:"tokens"`
+}
+
+// AdminToken is an operator token granting scopes
+type AdminToken struct {
+	Name   string   `mapstructure:"name"` // operator or tool the token was issued to, for audit logs
+	Hash   string   `mapstructure:"hash"`
+	Scopes []string `mapstructure:"scopes"`
+}
+
+// PriceRefreshConfig contains settings of the background job refreshing the
+// current prices of all held symbols from the configured providers
+type PriceRefreshConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	Interval       time.Duration `mapstructure:"interval"`
+	BatchSize      int           `mapstructure:"batch_size"` // symbols stored per refresh
+	RequestTimeout time.Duration `mapstructure:"request_timeout"`
+	MaxAttempts    int           `mapstructure:"max_attempts"`
+	RetryBaseDelay time.Duration `mapstructure:"retry_base_delay"`
+	RetryMaxDelay  time.Duration `mapstructure:"retry_max_delay"`
+	// StaleAfter and FailureThreshold mark market data degraded once no
+	// provider succeeded for StaleAfter, or a provider failed
+	// FailureThreshold requests in a row. Valuations using prices older than
+	// StaleAfter are flagged stale, and prices older than MaxStaleness are
+	// not used; zero keeps prices of any age.
+	StaleAfter       time.Duration `mapstructure:"stale_after"`
+	FailureThreshold int           `mapstructure:"failure_threshold"`
+	MaxStaleness     time.Duration `mapstructure:"max_staleness"`
+	// DelistAfter marks a held symbol delisted once no provider quoted it
+	// for that long while others were quoted; zero disables delisting.
+	// DelistedPricePolicy values delisted assets at their last known price
+	// ("last_price") or at zero ("zero").
+	DelistAfter         time.Duration `mapstructure:"delist_after"`
+	DelistedPricePolicy string        `mapstructure:"delisted_price_policy"`
+}
+
+// JobsConfig contains settings of the leader election that lets exactly one
+// replica run each scheduled job. Without leader election every replica runs
+// every job.
+type JobsConfig struct {
+	LeaderElection bool          `mapstructure:"leader_election"`
+	RetryInterval  time.Duration `mapstructure:"retry_interval"` // how often followers try to take over
+	CheckInterval  time.Duration `mapstructure:"check_interval"` // how often leaders verify their lock
+}
+
+// StartupConfig contains settings of the warm-up phase run before the
+// service starts serving, so the first requests after a deploy do not fail
+// on dependencies that are still coming up or caches that are still cold
+type StartupConfig struct {
+	// WarmupTimeout bounds waiting for the database and its schema; the
+	// service exits when they are not available within it
+	WarmupTimeout time.Duration `mapstructure:"warmup_timeout"`
+	RetryInterval time.Duration `mapstructure:"retry_interval"` // wait between connection attempts
+	// PrimePrices loads the prices of held symbols into the price cache
+	PrimePrices    bool `mapstructure:"prime_prices"`
+	PrimeBatchSize int  `mapstructure:"prime_batch_size"` // symbols fetched per lookup
+	// Migrate applies pending schema migrations from MigrationsDir before
+	// connecting, for deployments without a pipeline applying them
+	Migrate       bool   `mapstructure:"migrate"`
+	MigrationsDir string `mapstructure:"migrations_dir"`
+}
+
+// LockingConfig contains settings of the per-portfolio lock taken across
+// replicas around multi-step mutations such as imports and syncs
+type LockingConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	WaitTimeout time.Duration `mapstructure:"wait_timeout"` // how long a mutation waits for a locked portfolio
+}
+
+// SchedulerConfig contains settings of the in-process cron scheduler. When
+// enabled, the scheduled jobs run on the cron expressions in Jobs, keyed by
+// job name, instead of their own fixed intervals. Running jobs are given
+// DrainTimeout to finish on shutdown before they are cancelled.
+type SchedulerConfig struct {
+	Enabled      bool              `mapstructure:"enabled"`
+	Timezone     string            `mapstructure:"timezone"` // location cron expressions are evaluated in
+	DrainTimeout time.Duration     `mapstructure:"drain_timeout"`
+	Jobs         map[string]string `mapstructure:"jobs"`
+}
+
+// EVMToken is an ERC-20 token tracked in wallets on an EVM chain
+type EVMToken struct {
+	Contract string `mapstructure:"contract"`
+	Symbol   string `mapstructure:"symbol"`
+	Decimals int32  `mapstructure:"decimals"`
+}
+
+// SolanaToken is an SPL token tracked in Solana wallets; its decimals are
+// read from the chain
+type SolanaToken struct {
+	Mint   string `mapstructure:"mint"`
+	Symbol string `mapstructure:"symbol"`
+}
+
+// PlaygroundConfig contains settings for the developer API playground served on
+// the metrics listener. It is intended for non-production environments only.
+type PlaygroundConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path"`
+}
+
+// PaginationConfig contains settings for opaque page tokens. Secrets are read
+// from the referenced environment variables; the previous secret is accepted
+// for decoding only, to allow rotation.
+type PaginationConfig struct {
+	SecretEnv         string        `mapstructure:"secret_env"`
+	PreviousSecretEnv string        `mapstructure:"previous_secret_env"`
+	TokenTTL          time.Duration `mapstructure:"token_ttl"`
+}
+
+// Secrets resolves the configured token secrets, current secret first
+func (p PaginationConfig) Secrets() [][]byte {
+	var secrets [][]byte
+	for _, env := range []string{p.SecretEnv, p.PreviousSecretEnv} {
+		if env == "" {
+			continue
+		}
+		if v := os.Getenv(env); v != "" {
+			secrets = append(secrets, []byte(v))
+		}
+	}
+	return secrets
+}
+
+// APIKey resolves the provider API key from its referenced environment variable
+func (p ProviderConfig) APIKey() string {
+	if p.APIKeyEnv == "" {
+		return ""
+	}
+	return os.Getenv(p.APIKeyEnv)
+}
+
+// ProvidersByPriority returns the configured providers ordered by priority
+func (c *Config) ProvidersByPriority() []ProviderConfig {
+	return byPriority(c.Providers)
+}
+
+// FXProvidersByPriority returns the configured foreign exchange rate
+// providers ordered by priority
+func (c *Config) FXProvidersByPriority() []ProviderConfig {
+	return byPriority(c.FXProviders)
+}
+
+// byPriority returns a copy of providers ordered by priority
+func byPriority(providers []ProviderConfig) []ProviderConfig {
+	sorted := make([]ProviderConfig, len(providers))
+	copy(sorted, providers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+	return sorted
 }
 
 // LoadConfig loads and validates service configuration from environment variables
@@ -90,8 +914,12 @@ func LoadConfig() (*Config, error) {
 	v := viper.New()
 
 	// Set up environment variable bindings
-	v.SetEnvPrefix("PORTFOLIO")
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(envKeyReplacer)
 	v.AutomaticEnv()
+	if err := bindEnv(v); err != nil {
+		return nil, fmt.Errorf("error reading environment variables: %w", err)
+	}
 
 	// Set default configuration path
 	configPath := os.Getenv("PORTFOLIO_CONFIG_PATH")
@@ -106,11 +934,25 @@ func LoadConfig() (*Config, error) {
 	// Set default values
 	setDefaults(v)
 
-	// Read configuration file if it exists
+	// Read configuration file if it exists; without one the configuration
+	// comes from defaults and environment variables only
+	origin := Origin{Source: SourceDefaults}
 	if err := v.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok && !errors.Is(err, os.ErrNotExist) {
 			return nil, fmt.Errorf("error reading config file: %w", err)
 		}
+	} else {
+		origin.Source = SourceFile
+	}
+
+	// Merge the remote document over the config file, keeping the local
+	// configuration when the provider is unreachable
+	if v.GetString("remote.provider") != "" {
+		if err := readRemote(v); err != nil {
+			origin.RemoteErr = err
+		} else {
+			origin.Source = SourceRemote
+		}
 	}
 
 	var config Config
@@ -123,6 +965,9 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
 
+	origin.Version = configVersion(&config)
+	config.Origin = origin
+
 	return &config, nil
 }
 
@@ -136,6 +981,7 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("database.conn_max_idle_time", time.Minute*30)
 	v.SetDefault("database.statement_timeout", time.Second*30)
 	v.SetDefault("database.ssl_mode", "verify-full")
+	v.SetDefault("database.replica_port", defaultDBPort)
 
 	// Server defaults
 	v.SetDefault("server.port", defaultServerPort)
@@ -144,12 +990,39 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.idle_timeout", time.Second*60)
 	v.SetDefault("server.shutdown_timeout", time.Second*30)
 	v.SetDefault("server.max_header_bytes", 1<<20) // 1MB
+	v.SetDefault("server.max_recv_msg_size", 16<<20) // 16MB, room for transaction imports
+	v.SetDefault("server.max_send_msg_size", 16<<20) // 16MB
+	v.SetDefault("server.max_concurrent_streams", 100)
+	v.SetDefault("server.reflection", true)
+	v.SetDefault("server.gzip_level", gzip.DefaultCompression)
+	v.SetDefault("server.grpc_web.enabled", false)
+	v.SetDefault("server.grpc_web.port", 8081)
+	v.SetDefault("server.grpc_web.websockets", true)
+	v.SetDefault("server.grpc_web.websocket_ping_interval", time.Second*30)
+	v.SetDefault("server.grpc_web.idle_timeout", time.Second*120)
+	v.SetDefault("server.keepalive.max_connection_idle", time.Minute*5)
+	v.SetDefault("server.keepalive.max_connection_age", time.Hour*4)
+	v.SetDefault("server.keepalive.max_connection_age_grace", time.Minute)
+	v.SetDefault("server.keepalive.time", time.Minute)
+	v.SetDefault("server.keepalive.timeout", time.Second*20)
+	v.SetDefault("server.keepalive.min_time", time.Second*30)
+	v.SetDefault("server.keepalive.permit_without_stream", true)
 
 	// Metrics defaults
 	v.SetDefault("metrics.enabled", true)
 	v.SetDefault("metrics.port", defaultMetricsPort)
 	v.SetDefault("metrics.path", "/metrics")
 	v.SetDefault("metrics.collection_interval", time.Second*15)
+	v.SetDefault("metrics.latency_buckets", []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10})
+	v.SetDefault("metrics.native_histograms.enabled", false)
+	v.SetDefault("metrics.native_histograms.bucket_factor", 1.1)
+	v.SetDefault("metrics.native_histograms.max_buckets", 160)
+	v.SetDefault("metrics.go_collector", true)
+	v.SetDefault("metrics.process_collector", true)
+	v.SetDefault("metrics.read_timeout", time.Second*10)
+	v.SetDefault("metrics.write_timeout", time.Second*30)
+	v.SetDefault("metrics.idle_timeout", time.Minute*2)
+	v.SetDefault("metrics.tls_enabled", false)
 
 	// Cache defaults
 	v.SetDefault("cache.enabled", true)
@@ -159,6 +1032,278 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("cache.pool_size", 10)
 	v.SetDefault("cache.min_idle_conns", 2)
 	v.SetDefault("cache.max_retries", 3)
+	v.SetDefault("cache.local_size", 10000)
+	v.SetDefault("cache.local_ttl", time.Second*5)
+
+	// Archive defaults
+	v.SetDefault("archive.enabled", false)
+	v.SetDefault("archive.retention_years", 3)
+	v.SetDefault("archive.interval", time.Hour*24)
+	v.SetDefault("archive.batch_size", 100)
+	v.SetDefault("archive.prefix", "transactions")
+	v.SetDefault("archive.storage_class", "GLACIER_IR")
+
+	// Snapshot defaults
+	v.SetDefault("snapshots.enabled", true)
+	v.SetDefault("snapshots.interval", time.Hour)
+	v.SetDefault("snapshots.batch_size", 500)
+
+	// Verifier defaults
+	v.SetDefault("verifier.enabled", true)
+	v.SetDefault("verifier.interval", time.Minute*15)
+	v.SetDefault("verifier.check_timeout", time.Minute)
+
+	v.SetDefault("business_metrics.enabled", false)
+	v.SetDefault("business_metrics.interval", time.Minute*5)
+	v.SetDefault("business_metrics.query_timeout", time.Second*30)
+	v.SetDefault("business_metrics.value_buckets", []float64{100, 1000, 10000, 100000, 1000000})
+	v.SetDefault("business_metrics.sync_window", time.Hour*24)
+
+	// Drift defaults
+	v.SetDefault("drift.alerts_enabled", false)
+	v.SetDefault("drift.interval", time.Hour)
+	v.SetDefault("drift.threshold", 5.0)
+	v.SetDefault("drift.batch_size", 500)
+
+	// Deprecation defaults
+	v.SetDefault("deprecation.enabled", true)
+
+	// SLO defaults; the windows pair up for multi-window burn rate alerts
+	v.SetDefault("slo.enabled", true)
+	v.SetDefault("slo.objectives", []map[string]interface{}{
+		{"name": "api", "availability": 0.999, "latency_threshold": time.Millisecond * 500, "latency_target": 0.99},
+	})
+	v.SetDefault("slo.burn_rate_windows", []time.Duration{
+		time.Minute * 5, time.Minute * 30, time.Hour, time.Hour * 2, time.Hour * 6, time.Hour * 24, time.Hour * 72,
+	})
+	v.SetDefault("slo.evaluation_interval", time.Minute)
+
+	// Deadline defaults; exports, imports, reports and syncs work through
+	// whole histories or external services
+	v.SetDefault("deadlines.enabled", true)
+	v.SetDefault("deadlines.default", time.Second*10)
+	v.SetDefault("deadlines.methods", map[string]time.Duration{
+		"exporttransactions": time.Minute,
+		"exportportfolio":    time.Minute,
+		"exportuserdata":     time.Minute * 5,
+		"importtransactions": time.Minute * 5,
+		"importexchange":     time.Minute * 5,
+		"syncexchange":       time.Minute * 2,
+		"syncwallet":         time.Minute * 2,
+		"generatereport":     time.Minute,
+		"purgeuserdata":      time.Minute * 5,
+	})
+
+	// Load shedding defaults
+	v.SetDefault("load_shedding.enabled", false)
+	v.SetDefault("load_shedding.initial_limit", 20)
+	v.SetDefault("load_shedding.min_limit", 4)
+	v.SetDefault("load_shedding.max_limit", 500)
+	v.SetDefault("load_shedding.tolerance", 1.5)
+	v.SetDefault("load_shedding.queue_size", 100)
+	v.SetDefault("load_shedding.queue_timeout", time.Millisecond*50)
+	v.SetDefault("load_shedding.exempt_methods", []string{"Check", "GetServerInfo"})
+
+	// Logging defaults
+	v.SetDefault("logging.level", "info")
+	v.SetDefault("logging.development", false)
+	v.SetDefault("logging.sampling.enabled", true)
+	v.SetDefault("logging.sampling.initial", 100)
+	v.SetDefault("logging.sampling.thereafter", 100)
+	v.SetDefault("logging.output_paths", []string{"stderr"})
+	v.SetDefault("logging.error_output_paths", []string{"stderr"})
+	v.SetDefault("logging.disable_caller", false)
+	v.SetDefault("logging.stacktrace_level", "error")
+
+	// Notification defaults
+	v.SetDefault("notifications.enabled", false)
+	v.SetDefault("notifications.queue_size", 1000)
+	v.SetDefault("notifications.workers", 4)
+	v.SetDefault("notifications.max_attempts", 5)
+	v.SetDefault("notifications.initial_backoff", time.Second)
+	v.SetDefault("notifications.max_backoff", time.Minute)
+	v.SetDefault("notifications.send_timeout", time.Second*10)
+	v.SetDefault("notifications.email.port", 587)
+
+	// Webhook defaults
+	v.SetDefault("webhooks.enabled", false)
+	v.SetDefault("webhooks.interval", time.Second*5)
+	v.SetDefault("webhooks.batch_size", 100)
+	v.SetDefault("webhooks.max_attempts", 10)
+	v.SetDefault("webhooks.initial_backoff", time.Second*30)
+	v.SetDefault("webhooks.max_backoff", time.Hour*6)
+	v.SetDefault("webhooks.timeout", time.Second*10)
+
+	v.SetDefault("outbox.enabled", false)
+	v.SetDefault("outbox.topic", "bookman.portfolio.events")
+	v.SetDefault("outbox.interval", time.Second)
+	v.SetDefault("outbox.batch_size", 500)
+	v.SetDefault("outbox.write_timeout", time.Second*10)
+	v.SetDefault("outbox.retention", time.Hour*24*7)
+
+	v.SetDefault("ledger.storage_mode", StorageModeState)
+	v.SetDefault("ledger.projection_interval", time.Second*5)
+	v.SetDefault("ledger.projection_batch_size", 500)
+
+	v.SetDefault("exchanges.enabled", false)
+	v.SetDefault("exchanges.request_timeout", time.Second*15)
+	v.SetDefault("exchanges.cash_assets", []string{"USD", "USDT", "USDC", "BUSD", "FDUSD", "TUSD", "DAI"})
+	v.SetDefault("exchanges.max_attempts", 4)
+	v.SetDefault("exchanges.retry_base_delay", time.Millisecond*500)
+	v.SetDefault("exchanges.retry_max_delay", time.Second*30)
+	v.SetDefault("exchanges.sync_interval", time.Hour)
+	v.SetDefault("exchanges.sync_batch_size", 10)
+	v.SetDefault("encryption.provider", "")
+	v.SetDefault("encryption.vault.mount", "transit")
+	v.SetDefault("encryption.vault.timeout", time.Second*5)
+
+	v.SetDefault("chains.enabled", false)
+	v.SetDefault("chains.request_timeout", time.Second*15)
+	v.SetDefault("chains.max_attempts", 4)
+	v.SetDefault("chains.retry_base_delay", time.Millisecond*500)
+	v.SetDefault("chains.retry_max_delay", time.Second*30)
+	v.SetDefault("chains.pegged_assets", []string{"USDT", "USDC", "DAI"})
+	v.SetDefault("chains.sync_interval", time.Minute*30)
+	v.SetDefault("chains.sync_batch_size", 10)
+	v.SetDefault("chains.ethereum.enabled", true)
+	v.SetDefault("chains.ethereum.rpc_url", "https://cloudflare-eth.com")
+	v.SetDefault("chains.ethereum.indexer_url", "https://api.etherscan.io/v2/api")
+	v.SetDefault("chains.ethereum.chain_id", 1)
+	v.SetDefault("chains.ethereum.confirmations", 12)
+	v.SetDefault("chains.ethereum.native_symbol", "ETH")
+	v.SetDefault("chains.ethereum.requests_per_second", 4)
+	v.SetDefault("chains.ethereum.tokens", []map[string]interface{}{
+		{"contract": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", "symbol": "USDC", "decimals": 6},
+		{"contract": "0xdac17f958d2ee523a2206206994597c13d831ec7", "symbol": "USDT", "decimals": 6},
+		{"contract": "0x6b175474e89094c44da98b954eedeac495271d0f", "symbol": "DAI", "decimals": 18},
+		{"contract": "0x2260fac5e5542a773aa44fbcfedf7c193bc2c599", "symbol": "WBTC", "decimals": 8},
+		{"contract": "0x514910771af9ca656af840dff83e8264ecf986ca", "symbol": "LINK", "decimals": 18},
+	})
+	v.SetDefault("chains.polygon.enabled", false)
+	v.SetDefault("chains.polygon.rpc_url", "https://polygon-rpc.com")
+	v.SetDefault("chains.polygon.indexer_url", "https://api.etherscan.io/v2/api")
+	v.SetDefault("chains.polygon.chain_id", 137)
+	v.SetDefault("chains.polygon.confirmations", 128)
+	v.SetDefault("chains.polygon.native_symbol", "POL")
+	v.SetDefault("chains.polygon.requests_per_second", 4)
+	v.SetDefault("chains.polygon.tokens", []map[string]interface{}{
+		{"contract": "0x3c499c542cef5e3811e1192ce70d8cc03d5c3359", "symbol": "USDC", "decimals": 6},
+		{"contract": "0x2791bca1f2de4661ed88a30c99a7a9449aa84174", "symbol": "USDC", "decimals": 6},
+		{"contract": "0xc2132d05d31c914a87c6611c10748aeb04b58e8f", "symbol": "USDT", "decimals": 6},
+		{"contract": "0x7ceb23fd6bc0add59e62ac25578270cff1b9f619", "symbol": "WETH", "decimals": 18},
+		{"contract": "0x8f3cf7ad23cd3cadbd9735aff958023239c6a063", "symbol": "DAI", "decimals": 18},
+	})
+	v.SetDefault("chains.bsc.enabled", false)
+	v.SetDefault("chains.bsc.rpc_url", "https://bsc-dataseed.bnbchain.org")
+	v.SetDefault("chains.bsc.indexer_url", "https://api.etherscan.io/v2/api")
+	v.SetDefault("chains.bsc.chain_id", 56)
+	v.SetDefault("chains.bsc.confirmations", 15)
+	v.SetDefault("chains.bsc.native_symbol", "BNB")
+	v.SetDefault("chains.bsc.requests_per_second", 4)
+	v.SetDefault("chains.bsc.tokens", []map[string]interface{}{
+		{"contract": "0x55d398326f99059ff775485246999027b3197955", "symbol": "USDT", "decimals": 18},
+		{"contract": "0x8ac76a51cc950d9822d68b83fe1ad97b32cd580d", "symbol": "USDC", "decimals": 18},
+		{"contract": "0x2170ed0880ac9a755fd29b2688956bd959f933f8", "symbol": "ETH", "decimals": 18},
+		{"contract": "0x7130d2a12b9bcbfae4f2634d864a1ee1ce3ead9c", "symbol": "BTCB", "decimals": 18},
+	})
+	v.SetDefault("chains.bitcoin.enabled", true)
+	v.SetDefault("chains.bitcoin.esplora_url", "https://blockstream.info/api")
+	v.SetDefault("chains.bitcoin.confirmations", 6)
+	v.SetDefault("chains.bitcoin.gap_limit", 20)
+	v.SetDefault("chains.bitcoin.requests_per_second", 5)
+	v.SetDefault("chains.solana.enabled", false)
+	v.SetDefault("chains.solana.rpc_url", "https://api.mainnet-beta.solana.com")
+	v.SetDefault("chains.solana.requests_per_second", 4)
+	v.SetDefault("chains.solana.tokens", []map[string]interface{}{
+		{"mint": "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", "symbol": "USDC"},
+		{"mint": "Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB", "symbol": "USDT"},
+	})
+
+	v.SetDefault("staking.enabled", false)
+	v.SetDefault("staking.accrual_interval", time.Hour*6)
+	v.SetDefault("staking.accrual_batch_size", 10)
+
+	v.SetDefault("corporate_actions.airdrop_cost_basis", "allocated")
+	v.SetDefault("corporate_actions.fork_cost_basis", "allocated")
+	v.SetDefault("cost_basis.fee_policy", "capitalize")
+	v.SetDefault("tax.estimated_rate", 0.25)
+	v.SetDefault("tax.wash_sale_window", time.Hour*24*30)
+	v.SetDefault("tax.long_term_holding_period", time.Hour*24*365)
+	v.SetDefault("tax.jurisdiction", "US")
+	v.SetDefault("tax.wash_sale_jurisdictions", []string{"US"})
+	v.SetDefault("compliance.purge_enabled", false)
+
+	v.SetDefault("sharing.enabled", false)
+	v.SetDefault("sharing.default_ttl", time.Hour*24*7)
+	v.SetDefault("sharing.max_ttl", time.Hour*24*90)
+
+	v.SetDefault("admin.enabled", false)
+
+	// Price refresh defaults
+	v.SetDefault("price_refresh.enabled", false)
+	v.SetDefault("price_refresh.interval", time.Minute)
+	v.SetDefault("price_refresh.batch_size", 250)
+	v.SetDefault("price_refresh.request_timeout", time.Second*10)
+	v.SetDefault("price_refresh.max_attempts", 3)
+	v.SetDefault("price_refresh.retry_base_delay", time.Second)
+	v.SetDefault("price_refresh.retry_max_delay", time.Second*10)
+	v.SetDefault("price_refresh.stale_after", time.Minute*5)
+	v.SetDefault("price_refresh.failure_threshold", 3)
+	v.SetDefault("price_refresh.max_staleness", time.Hour*24)
+	v.SetDefault("price_refresh.delist_after", time.Hour*12)
+	v.SetDefault("price_refresh.delisted_price_policy", "last_price")
+
+	// Background job coordination defaults
+	v.SetDefault("jobs.leader_election", true)
+	v.SetDefault("jobs.retry_interval", time.Second*15)
+	v.SetDefault("jobs.check_interval", time.Second*5)
+
+	// Startup warm-up defaults
+	v.SetDefault("startup.warmup_timeout", time.Minute*2)
+	v.SetDefault("startup.retry_interval", time.Second*2)
+	v.SetDefault("startup.prime_prices", true)
+	v.SetDefault("startup.prime_batch_size", 500)
+	v.SetDefault("startup.migrate", false)
+	v.SetDefault("startup.migrations_dir", "/usr/local/share/portfolio-service/migrations")
+
+	// Remote configuration defaults
+	v.SetDefault("remote.watch_interval", time.Second*30)
+
+	// Portfolio locking defaults
+	v.SetDefault("locking.enabled", false)
+	v.SetDefault("locking.wait_timeout", time.Second*30)
+
+	// Scheduler defaults match the fixed intervals of the jobs
+	v.SetDefault("scheduler.enabled", false)
+	v.SetDefault("scheduler.timezone", "UTC")
+	v.SetDefault("scheduler.drain_timeout", time.Second*30)
+	v.SetDefault("scheduler.jobs.snapshots", "0 * * * *")
+	v.SetDefault("scheduler.jobs.price_refresh", "* * * * *")
+	v.SetDefault("scheduler.jobs.drift_alerts", "0 * * * *")
+	v.SetDefault("scheduler.jobs.exchange_syncs", "* * * * *")
+	v.SetDefault("scheduler.jobs.report_deliveries", "* * * * *")
+	v.SetDefault("scheduler.jobs.wallet_syncs", "* * * * *")
+	v.SetDefault("scheduler.jobs.staking_accruals", "* * * * *")
+	v.SetDefault("scheduler.jobs.ledger_verification", "*/15 * * * *")
+
+	v.SetDefault("exchanges.binance.base_url", "https://api.binance.com")
+	v.SetDefault("exchanges.binance.quote_assets", []string{"USDT", "USDC", "FDUSD", "BTC", "ETH", "BNB"})
+	v.SetDefault("exchanges.binance.recv_window", time.Second*5)
+	v.SetDefault("exchanges.kraken.base_url", "https://api.kraken.com")
+	v.SetDefault("exchanges.kraken.quote_assets", []string{"USD", "USDT", "USDC", "EUR", "BTC", "ETH"})
+
+	v.SetDefault("reports.enabled", false)
+	v.SetDefault("reports.prefix", "reports")
+	v.SetDefault("reports.url_expiry", time.Minute*15)
+	v.SetDefault("reports.delivery_url_expiry", time.Hour*24*7)
+	v.SetDefault("reports.schedule_batch_size", 20)
+
+	// Pagination defaults
+	v.SetDefault("pagination.token_ttl", time.Hour*24)
+
+	// Playground defaults
+	v.SetDefault("playground.enabled", false)
+	v.SetDefault("playground.path", "/playground")
 }
 
 // validateConfig performs comprehensive validation of all configuration values
@@ -179,106 +1324,1478 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("cache config validation failed: %w", err)
 	}
 
-	return nil
-}
+	if err := validateProviders(config.Providers, SupportedProviderTypes); err != nil {
+		return fmt.Errorf("providers config validation failed: %w", err)
+	}
 
-// validateDatabase validates database configuration
-func validateDatabase(config *DatabaseConfig) error {
-	if config.Host == "" {
-		return errors.New("database host is required")
+	if err := validateProviders(config.FXProviders, SupportedFXProviderTypes); err != nil {
+		return fmt.Errorf("fx_providers config validation failed: %w", err)
 	}
 
-	if config.Port <= 0 || config.Port > 65535 {
-		return errors.New("invalid database port")
+	if err := validateArchive(&config.Archive); err != nil {
+		return fmt.Errorf("archive config validation failed: %w", err)
 	}
 
-	if config.User == "" {
-		return errors.New("database user is required")
+	if err := validateSnapshots(&config.Snapshots); err != nil {
+		return fmt.Errorf("snapshots config validation failed: %w", err)
 	}
 
-	if config.Password == "" {
-		return errors.New("database password is required")
+	if err := validateVerifier(&config.Verifier); err != nil {
+		return fmt.Errorf("verifier config validation failed: %w", err)
 	}
 
-	if config.Database == "" {
-		return errors.New("database name is required")
+	if err := validateBusinessMetrics(&config.BusinessMetrics); err != nil {
+		return fmt.Errorf("business metrics config validation failed: %w", err)
 	}
 
-	if config.MaxOpenConns <= 0 {
-		return errors.New("invalid max_open_conns value")
+	if err := validateDrift(&config.Drift); err != nil {
+		return fmt.Errorf("drift config validation failed: %w", err)
 	}
 
-	return nil
-}
+	if err := validateDeprecation(&config.Deprecation); err != nil {
+		return fmt.Errorf("deprecation config validation failed: %w", err)
+	}
 
-// validateServer validates server configuration
-func validateServer(config *ServerConfig) error {
-	if config.Port <= 0 || config.Port > 65535 {
-		return errors.New("invalid server port")
+	if err := validateSLO(&config.SLO); err != nil {
+		return fmt.Errorf("slo config validation failed: %w", err)
 	}
 
-	if config.ReadTimeout <= 0 {
-		return errors.New("invalid read_timeout value")
+	if err := validateDeadlines(&config.Deadlines); err != nil {
+		return fmt.Errorf("deadlines config validation failed: %w", err)
 	}
 
-	if config.WriteTimeout <= 0 {
-		return errors.New("invalid write_timeout value")
+	if err := validateLoadShedding(&config.LoadShedding); err != nil {
+		return fmt.Errorf("load shedding config validation failed: %w", err)
 	}
 
-	if config.TLSEnabled {
-		if config.TLSCert == "" {
-			return errors.New("TLS cert path is required when TLS is enabled")
-		}
-		if config.TLSKey == "" {
-			return errors.New("TLS key path is required when TLS is enabled")
-		}
+	if err := validateLogging(&config.Logging); err != nil {
+		return fmt.Errorf("logging config validation failed: %w", err)
 	}
 
-	return nil
-}
+	if err := validateNotifications(&config.Notifications); err != nil {
+		return fmt.Errorf("notifications config validation failed: %w", err)
+	}
 
-// validateMetrics validates metrics configuration
-func validateMetrics(config *MetricsConfig) error {
-	if !config.Enabled {
-		return nil
+	if err := validateWebhooks(&config.Webhooks); err != nil {
+		return fmt.Errorf("webhooks config validation failed: %w", err)
 	}
 
-	if config.Port <= 0 || config.Port > 65535 {
-		return errors.New("invalid metrics port")
+	if err := validateOutbox(&config.Outbox); err != nil {
+		return fmt.Errorf("outbox config validation failed: %w", err)
 	}
 
-	if config.Path == "" {
-		return errors.New("metrics path is required when metrics are enabled")
+	if err := validateLedger(&config.Ledger); err != nil {
+		return fmt.Errorf("ledger config validation failed: %w", err)
 	}
 
-	if config.CollectionInterval <= 0 {
-		return errors.New("invalid collection_interval value")
+	if err := validateExchanges(&config.Exchanges); err != nil {
+		return fmt.Errorf("exchanges config validation failed: %w", err)
 	}
 
-	return nil
-}
+	if err := validateEncryption(&config.Encryption); err != nil {
+		return fmt.Errorf("encryption config validation failed: %w", err)
+	}
 
-// validateCache validates cache configuration
-func validateCache(config *CacheConfig) error {
-	if !config.Enabled {
-		return nil
+	if err := validateReports(&config.Reports); err != nil {
+		return fmt.Errorf("reports config validation failed: %w", err)
 	}
 
-	if config.Host == "" {
-		return errors.New("cache host is required when cache is enabled")
+	if err := validateChains(&config.Chains); err != nil {
+		return fmt.Errorf("chains config validation failed: %w", err)
 	}
 
-	if config.Port <= 0 || config.Port > 65535 {
-		return errors.New("invalid cache port")
+	if err := validateStaking(&config.Staking, config.Chains.Enabled || config.Exchanges.Enabled); err != nil {
+		return fmt.Errorf("staking config validation failed: %w", err)
 	}
 
-	if config.TTL <= 0 {
-		return errors.New("invalid cache TTL value")
+	if err := validateCorporateActions(&config.CorporateActions); err != nil {
+		return fmt.Errorf("corporate actions config validation failed: %w", err)
 	}
 
-	if config.TLSEnabled && config.TLSCert == "" {
-		return errors.New("TLS cert path is required when cache TLS is enabled")
+	if err := validateCostBasis(&config.CostBasis); err != nil {
+		return fmt.Errorf("cost basis config validation failed: %w", err)
 	}
 
-	return nil
-}
\ No newline at end of file
+	if err := validateTax(&config.Tax); err != nil {
+		return fmt.Errorf("tax config validation failed: %w", err)
+	}
+
+	if err := validateSharing(&config.Sharing); err != nil {
+		return fmt.Errorf("sharing config validation failed: %w", err)
+	}
+
+	if err := validateAdmin(&config.Admin); err != nil {
+		return fmt.Errorf("admin config validation failed: %w", err)
+	}
+
+	if config.Compliance.PurgeEnabled && !config.Admin.Enabled {
+		return errors.New("compliance purge_enabled requires admin authentication to be enabled")
+	}
+
+	if err := validatePriceRefresh(&config.PriceRefresh, config.Providers); err != nil {
+		return fmt.Errorf("price refresh config validation failed: %w", err)
+	}
+
+	if err := validateJobs(&config.Jobs); err != nil {
+		return fmt.Errorf("jobs config validation failed: %w", err)
+	}
+
+	if config.Locking.Enabled && config.Locking.WaitTimeout <= 0 {
+		return errors.New("locking config validation failed: invalid wait_timeout value")
+	}
+
+	if err := validateScheduler(&config.Scheduler); err != nil {
+		return fmt.Errorf("scheduler config validation failed: %w", err)
+	}
+
+	if err := validateStartup(&config.Startup); err != nil {
+		return fmt.Errorf("startup config validation failed: %w", err)
+	}
+
+	if err := validateRemote(&config.Remote); err != nil {
+		return fmt.Errorf("remote config validation failed: %w", err)
+	}
+
+	if err := validatePagination(&config.Pagination); err != nil {
+		return fmt.Errorf("pagination config validation failed: %w", err)
+	}
+
+	if err := validatePlayground(config); err != nil {
+		return fmt.Errorf("playground config validation failed: %w", err)
+	}
+
+	return nil
+}
+
+// validateDatabase validates database configuration
+func validateDatabase(config *DatabaseConfig) error {
+	if config.Host == "" {
+		return errors.New("database host is required")
+	}
+
+	if config.Port <= 0 || config.Port > 65535 {
+		return errors.New("invalid database port")
+	}
+
+	if config.User == "" {
+		return errors.New("database user is required")
+	}
+
+	if config.Password == "" {
+		return errors.New("database password is required")
+	}
+
+	if config.Database == "" {
+		return errors.New("database name is required")
+	}
+
+	if config.MaxOpenConns <= 0 {
+		return errors.New("invalid max_open_conns value")
+	}
+
+	if config.ReplicaHost != "" && (config.ReplicaPort <= 0 || config.ReplicaPort > 65535) {
+		return errors.New("invalid database replica_port")
+	}
+
+	return nil
+}
+
+// validateServer validates server configuration
+func validateServer(config *ServerConfig) error {
+	if config.Port <= 0 || config.Port > 65535 {
+		return errors.New("invalid server port")
+	}
+
+	if config.ReadTimeout <= 0 {
+		return errors.New("invalid read_timeout value")
+	}
+
+	if config.WriteTimeout <= 0 {
+		return errors.New("invalid write_timeout value")
+	}
+
+	if config.MaxRecvMsgSize <= 0 {
+		return errors.New("invalid max_recv_msg_size value")
+	}
+
+	if config.MaxSendMsgSize <= 0 {
+		return errors.New("invalid max_send_msg_size value")
+	}
+
+	if config.GzipLevel < gzip.HuffmanOnly || config.GzipLevel > gzip.BestCompression {
+		return errors.New("invalid gzip_level value")
+	}
+
+	if config.TLSEnabled {
+		if config.TLSCert == "" {
+			return errors.New("TLS cert path is required when TLS is enabled")
+		}
+		if config.TLSKey == "" {
+			return errors.New("TLS key path is required when TLS is enabled")
+		}
+	}
+
+	if web := &config.GRPCWeb; web.Enabled {
+		if web.Port <= 0 || web.Port > 65535 {
+			return errors.New("invalid grpc_web port")
+		}
+		if web.Port == config.Port {
+			return errors.New("grpc_web port must differ from the gRPC port")
+		}
+		if len(web.AllowedOrigins) == 0 {
+			return errors.New("grpc_web allowed_origins is required when gRPC-Web is enabled")
+		}
+		for _, origin := range web.AllowedOrigins {
+			if origin == "*" {
+				continue
+			}
+			u, err := url.Parse(origin)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+				return fmt.Errorf("invalid grpc_web origin %q", origin)
+			}
+		}
+		if web.Websockets && web.WebsocketPingInterval <= 0 {
+			return errors.New("invalid grpc_web websocket_ping_interval value")
+		}
+	}
+
+	if err := validateKeepalive(&config.Keepalive); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateKeepalive validates the keepalive and connection age settings
+func validateKeepalive(config *KeepaliveConfig) error {
+	if config.MaxConnectionIdle < 0 || config.MaxConnectionAge < 0 || config.MaxConnectionAgeGrace < 0 {
+		return errors.New("keepalive connection idle and age limits must not be negative")
+	}
+
+	if config.Time <= 0 {
+		return errors.New("invalid keepalive time value")
+	}
+
+	if config.Timeout <= 0 || config.Timeout >= config.Time {
+		return errors.New("keepalive timeout must be positive and shorter than the keepalive time")
+	}
+
+	if config.MinTime <= 0 {
+		return errors.New("invalid keepalive min_time value")
+	}
+
+	return nil
+}
+
+// validateMetrics validates metrics configuration
+func validateMetrics(config *MetricsConfig) error {
+	// Latency is recorded whether or not metrics are served
+	if len(config.LatencyBuckets) == 0 {
+		return errors.New("at least one latency bucket is required")
+	}
+	for i, bound := range config.LatencyBuckets {
+		if bound <= 0 {
+			return errors.New("latency buckets must be positive")
+		}
+		if i > 0 && bound <= config.LatencyBuckets[i-1] {
+			return errors.New("latency buckets must be in ascending order")
+		}
+	}
+
+	if config.NativeHistograms.Enabled && config.NativeHistograms.BucketFactor <= 1 {
+		return errors.New("native histogram bucket_factor must be greater than 1")
+	}
+
+	if !config.Enabled {
+		return nil
+	}
+
+	if config.Port <= 0 || config.Port > 65535 {
+		return errors.New("invalid metrics port")
+	}
+
+	if config.Path == "" {
+		return errors.New("metrics path is required when metrics are enabled")
+	}
+
+	if config.CollectionInterval <= 0 {
+		return errors.New("invalid collection_interval value")
+	}
+
+	if config.ReadTimeout <= 0 || config.WriteTimeout <= 0 || config.IdleTimeout < 0 {
+		return errors.New("invalid metrics server timeout values")
+	}
+
+	if config.TLSEnabled {
+		if config.TLSCert == "" {
+			return errors.New("TLS cert path is required when TLS is enabled")
+		}
+		if config.TLSKey == "" {
+			return errors.New("TLS key path is required when TLS is enabled")
+		}
+	} else if config.TLSClientCA != "" {
+		return errors.New("tls_client_ca requires TLS to be enabled")
+	}
+
+	for name, value := range config.Labels {
+		if !isLabelName(name) {
+			return fmt.Errorf("invalid metrics label name %q", name)
+		}
+		if value == "" {
+			return fmt.Errorf("metrics label %s requires a value", name)
+		}
+	}
+
+	return nil
+}
+
+// isLabelName reports whether name is a Prometheus label name that is not
+// reserved for internal use
+func isLabelName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "__") {
+		return false
+	}
+	for i, c := range name {
+		letter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !letter && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// validateCache validates cache configuration
+func validateCache(config *CacheConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if config.Host == "" {
+		return errors.New("cache host is required when cache is enabled")
+	}
+
+	if config.Port <= 0 || config.Port > 65535 {
+		return errors.New("invalid cache port")
+	}
+
+	if config.TTL <= 0 {
+		return errors.New("invalid cache TTL value")
+	}
+
+	if config.TLSEnabled && config.TLSCert == "" {
+		return errors.New("TLS cert path is required when cache TLS is enabled")
+	}
+
+	if config.LocalSize <= 0 {
+		return errors.New("cache local size must be positive")
+	}
+
+	if config.LocalTTL <= 0 || config.LocalTTL > config.TTL {
+		return errors.New("cache local TTL must be positive and at most the cache TTL")
+	}
+
+	return nil
+}
+
+// validateArchive validates transaction archival configuration
+func validateArchive(config *ArchiveConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if config.RetentionYears <= 0 {
+		return errors.New("invalid retention_years value")
+	}
+
+	if config.Interval <= 0 {
+		return errors.New("invalid archive interval value")
+	}
+
+	if config.BatchSize <= 0 {
+		return errors.New("invalid archive batch_size value")
+	}
+
+	if config.Bucket == "" {
+		return errors.New("archive bucket is required when archival is enabled")
+	}
+
+	if config.Region == "" {
+		return errors.New("archive region is required when archival is enabled")
+	}
+
+	return nil
+}
+
+// validateSnapshots validates valuation snapshot configuration
+func validateSnapshots(config *SnapshotConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if config.Interval < time.Minute {
+		return errors.New("snapshot interval must be at least one minute")
+	}
+
+	if config.BatchSize <= 0 {
+		return errors.New("invalid snapshot batch_size value")
+	}
+
+	return nil
+}
+
+// validateVerifier validates ledger invariant verification configuration
+func validateVerifier(config *VerifierConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if config.Interval < time.Minute {
+		return errors.New("verifier interval must be at least one minute")
+	}
+
+	if config.CheckTimeout <= 0 {
+		return errors.New("invalid verifier check_timeout value")
+	}
+
+	return nil
+}
+
+// validateBusinessMetrics validates the business metrics export, keeping the
+// value distribution to a few ascending buckets
+func validateBusinessMetrics(config *BusinessMetricsConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if config.Interval < time.Minute {
+		return errors.New("business_metrics interval must be at least one minute")
+	}
+
+	if config.QueryTimeout <= 0 || config.QueryTimeout >= config.Interval {
+		return errors.New("business_metrics query_timeout must be positive and shorter than interval")
+	}
+
+	if len(config.ValueBuckets) == 0 || len(config.ValueBuckets) > maxBusinessValueBuckets {
+		return fmt.Errorf("business_metrics value_buckets must list 1 to %d bounds", maxBusinessValueBuckets)
+	}
+	for i, bound := range config.ValueBuckets {
+		if bound <= 0 || (i > 0 && bound <= config.ValueBuckets[i-1]) {
+			return errors.New("business_metrics value_buckets must be positive and ascending")
+		}
+	}
+
+	if config.SyncWindow < time.Hour {
+		return errors.New("business_metrics sync_window must be at least one hour")
+	}
+
+	return nil
+}
+
+// validateDrift validates allocation drift alert configuration
+func validateDrift(config *DriftConfig) error {
+	if !config.AlertsEnabled {
+		return nil
+	}
+
+	if config.Interval < time.Minute {
+		return errors.New("drift interval must be at least one minute")
+	}
+
+	if config.Threshold <= 0 || config.Threshold > 100 {
+		return errors.New("drift threshold must be within (0, 100]")
+	}
+
+	if config.BatchSize <= 0 {
+		return errors.New("invalid drift batch_size value")
+	}
+
+	return nil
+}
+
+// validateDeprecation validates deprecation signaling configuration
+func validateDeprecation(config *DeprecationConfig) error {
+	for element, date := range config.Sunsets {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("invalid sunset date for %s: %w", element, err)
+		}
+	}
+
+	for _, version := range config.ClientVersions {
+		if !majorMinorPattern.MatchString(version) {
+			return fmt.Errorf("invalid client version %q, expected major.minor", version)
+		}
+	}
+
+	return nil
+}
+
+// majorMinorPattern matches major.minor client versions
+var majorMinorPattern = regexp.MustCompile(`^\d{1,4}\.\d{1,4}$`)
+
+// validateSLO validates service level objective configuration
+func validateSLO(config *SLOConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if len(config.Objectives) == 0 {
+		return errors.New("at least one objective is required")
+	}
+
+	names := make(map[string]bool, len(config.Objectives))
+	for _, objective := range config.Objectives {
+		if objective.Name == "" {
+			return errors.New("objective name is required")
+		}
+		if names[objective.Name] {
+			return fmt.Errorf("duplicate objective %q", objective.Name)
+		}
+		names[objective.Name] = true
+
+		if objective.Availability <= 0 || objective.Availability >= 1 {
+			return fmt.Errorf("availability of objective %q must be within (0, 1)", objective.Name)
+		}
+		if objective.LatencyThreshold < 0 {
+			return fmt.Errorf("invalid latency_threshold of objective %q", objective.Name)
+		}
+		if objective.LatencyThreshold > 0 && (objective.LatencyTarget <= 0 || objective.LatencyTarget >= 1) {
+			return fmt.Errorf("latency_target of objective %q must be within (0, 1)", objective.Name)
+		}
+		for _, method := range objective.Methods {
+			if method == "" {
+				return fmt.Errorf("empty method name in objective %q", objective.Name)
+			}
+		}
+	}
+
+	if config.EvaluationInterval < time.Second {
+		return errors.New("evaluation_interval must be at least one second")
+	}
+
+	if len(config.BurnRateWindows) == 0 {
+		return errors.New("at least one burn rate window is required")
+	}
+	for _, window := range config.BurnRateWindows {
+		if window < config.EvaluationInterval {
+			return fmt.Errorf("burn rate window %s is shorter than the evaluation interval", window)
+		}
+		if window/config.EvaluationInterval > maxSLOBuckets {
+			return fmt.Errorf("burn rate window %s spans more than %d evaluation intervals", window, maxSLOBuckets)
+		}
+	}
+
+	return nil
+}
+
+// validateDeadlines validates default call deadline configuration
+func validateDeadlines(config *DeadlineConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if config.Default <= 0 {
+		return errors.New("invalid default deadline")
+	}
+
+	for method, deadline := range config.Methods {
+		if method == "" || strings.ContainsAny(method, "/.") {
+			return fmt.Errorf("invalid method name %q; use the bare method name", method)
+		}
+		if deadline <= 0 {
+			return fmt.Errorf("invalid deadline for %s", method)
+		}
+	}
+
+	return nil
+}
+
+// validateLoadShedding validates load shedding configuration
+func validateLoadShedding(config *LoadSheddingConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if config.MinLimit <= 0 || config.MaxLimit < config.MinLimit {
+		return errors.New("limits must satisfy 0 < min_limit <= max_limit")
+	}
+
+	if config.InitialLimit < config.MinLimit || config.InitialLimit > config.MaxLimit {
+		return errors.New("initial_limit must be within [min_limit, max_limit]")
+	}
+
+	if config.Tolerance < 1 {
+		return errors.New("tolerance must be at least 1")
+	}
+
+	if config.QueueSize < 0 {
+		return errors.New("invalid queue_size value")
+	}
+
+	if config.QueueSize > 0 && config.QueueTimeout <= 0 {
+		return errors.New("queue_timeout is required with a queue")
+	}
+
+	return nil
+}
+
+// logLevels lists the accepted log levels
+var logLevels = map[string]bool{
+	"debug": true, "info": true, "warn": true, "error": true, "dpanic": true, "panic": true, "fatal": true,
+}
+
+// validateLogging validates structured logging configuration
+func validateLogging(config *LoggingConfig) error {
+	if !logLevels[strings.ToLower(config.Level)] {
+		return fmt.Errorf("invalid level %q", config.Level)
+	}
+
+	if config.StacktraceLevel != "" && !logLevels[strings.ToLower(config.StacktraceLevel)] {
+		return fmt.Errorf("invalid stacktrace_level %q", config.StacktraceLevel)
+	}
+
+	if config.Encoding != "" && config.Encoding != "json" && config.Encoding != "console" {
+		return fmt.Errorf("unsupported encoding %q", config.Encoding)
+	}
+
+	if config.Sampling.Enabled && (config.Sampling.Initial <= 0 || config.Sampling.Thereafter <= 0) {
+		return errors.New("sampling initial and thereafter must be positive")
+	}
+
+	if len(config.OutputPaths) == 0 || len(config.ErrorOutputPaths) == 0 {
+		return errors.New("output_paths and error_output_paths are required")
+	}
+
+	return nil
+}
+
+// validateNotifications validates notification dispatch configuration
+func validateNotifications(config *NotificationsConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if config.QueueSize <= 0 {
+		return errors.New("invalid notifications queue_size value")
+	}
+
+	if config.Workers <= 0 {
+		return errors.New("invalid notifications workers value")
+	}
+
+	if config.MaxAttempts <= 0 {
+		return errors.New("invalid notifications max_attempts value")
+	}
+
+	if config.InitialBackoff <= 0 || config.MaxBackoff < config.InitialBackoff {
+		return errors.New("notifications backoff must be positive with max_backoff >= initial_backoff")
+	}
+
+	if config.SendTimeout <= 0 {
+		return errors.New("invalid notifications send_timeout value")
+	}
+
+	if !config.Webhook.Enabled && !config.Email.Enabled && !config.Service.Enabled {
+		return errors.New("at least one notification sender must be enabled")
+	}
+
+	if config.Webhook.Enabled {
+		u, err := url.Parse(config.Webhook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("invalid notifications webhook url")
+		}
+		if config.Webhook.SecretEnv != "" && os.Getenv(config.Webhook.SecretEnv) == "" {
+			return fmt.Errorf("webhook secret variable %s is not set", config.Webhook.SecretEnv)
+		}
+		if err := validateNotificationEvents(config.Webhook.Events); err != nil {
+			return fmt.Errorf("webhook: %w", err)
+		}
+	}
+
+	if config.Email.Enabled {
+		if config.Email.Host == "" || config.Email.Port <= 0 || config.Email.Port > 65535 {
+			return errors.New("invalid notifications email host or port")
+		}
+		if config.Email.From == "" || len(config.Email.To) == 0 {
+			return errors.New("notifications email from and to are required")
+		}
+		if config.Email.PasswordEnv != "" && os.Getenv(config.Email.PasswordEnv) == "" {
+			return fmt.Errorf("email password variable %s is not set", config.Email.PasswordEnv)
+		}
+		if err := validateNotificationEvents(config.Email.Events); err != nil {
+			return fmt.Errorf("email: %w", err)
+		}
+	}
+
+	if config.Service.Enabled {
+		if config.Service.Address == "" {
+			return errors.New("notification service address is required")
+		}
+		if err := validateNotificationEvents(config.Service.Events); err != nil {
+			return fmt.Errorf("service: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateNotificationEvents checks sender event subscriptions
+func validateNotificationEvents(events []string) error {
+	for _, event := range events {
+		if !isSupportedNotificationEvent(event) {
+			return fmt.Errorf("unsupported notification event %q", event)
+		}
+	}
+	return nil
+}
+
+// validateWebhooks validates user webhook delivery configuration
+func validateWebhooks(config *WebhooksConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if config.Interval <= 0 {
+		return errors.New("invalid webhooks interval value")
+	}
+
+	if config.BatchSize <= 0 {
+		return errors.New("invalid webhooks batch_size value")
+	}
+
+	if config.MaxAttempts <= 0 {
+		return errors.New("invalid webhooks max_attempts value")
+	}
+
+	if config.InitialBackoff <= 0 || config.MaxBackoff < config.InitialBackoff {
+		return errors.New("webhooks backoff must be positive with max_backoff >= initial_backoff")
+	}
+
+	if config.Timeout <= 0 {
+		return errors.New("invalid webhooks timeout value")
+	}
+
+	return nil
+}
+
+// validateOutbox validates Kafka event publishing configuration
+func validateOutbox(config *OutboxConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if len(config.Brokers) == 0 {
+		return errors.New("at least one outbox broker is required")
+	}
+
+	if config.Topic == "" {
+		return errors.New("outbox topic is required")
+	}
+
+	if config.Interval <= 0 {
+		return errors.New("invalid outbox interval value")
+	}
+
+	if config.BatchSize <= 0 {
+		return errors.New("invalid outbox batch_size value")
+	}
+
+	if config.WriteTimeout <= 0 {
+		return errors.New("invalid outbox write_timeout value")
+	}
+
+	if config.Retention <= 0 {
+		return errors.New("invalid outbox retention value")
+	}
+
+	if config.SASLUsername != "" {
+		if config.SASLPasswordEnv == "" || os.Getenv(config.SASLPasswordEnv) == "" {
+			return errors.New("outbox sasl_password_env must name a set variable when sasl_username is set")
+		}
+	}
+
+	return nil
+}
+
+// validateLedger validates portfolio storage configuration
+func validateLedger(config *LedgerConfig) error {
+	switch config.StorageMode {
+	case StorageModeState:
+		return nil
+	case StorageModeEventSourced:
+	default:
+		return fmt.Errorf("unsupported ledger storage_mode %q", config.StorageMode)
+	}
+
+	if config.ProjectionInterval <= 0 {
+		return errors.New("invalid ledger projection_interval value")
+	}
+
+	if config.ProjectionBatchSize <= 0 {
+		return errors.New("invalid ledger projection_batch_size value")
+	}
+
+	return nil
+}
+
+// validateEncryption validates envelope encryption configuration
+func validateEncryption(config *EncryptionConfig) error {
+	switch config.Provider {
+	case "":
+		return nil
+	case EncryptionProviderLocal:
+		if config.KeyEnv == "" {
+			return errors.New("encryption key_env is required for the local provider")
+		}
+		for _, env := range []string{config.KeyEnv, config.PreviousKeyEnv} {
+			if env == "" {
+				continue
+			}
+			key := os.Getenv(env)
+			if key == "" {
+				return fmt.Errorf("encryption key variable %s is not set", env)
+			}
+			if len(key) < minEncryptionKeyLength {
+				return fmt.Errorf("encryption key %s must be at least %d bytes", env, minEncryptionKeyLength)
+			}
+		}
+	case EncryptionProviderVault:
+		vault := config.Vault
+		if vault.Address == "" || vault.Mount == "" || vault.Key == "" {
+			return errors.New("encryption vault address, mount and key are required")
+		}
+		if vault.PreviousKey == vault.Key {
+			return errors.New("encryption vault previous_key must differ from key")
+		}
+		if vault.TokenEnv == "" || os.Getenv(vault.TokenEnv) == "" {
+			return errors.New("encryption vault token_env must name a set variable")
+		}
+		if vault.Timeout <= 0 {
+			return errors.New("invalid encryption vault timeout value")
+		}
+	default:
+		return fmt.Errorf("unsupported encryption provider: %s", config.Provider)
+	}
+
+	return nil
+}
+
+// validateExchanges validates exchange account import configuration
+func validateExchanges(config *ExchangesConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if config.RequestTimeout <= 0 {
+		return errors.New("invalid exchanges request_timeout value")
+	}
+
+	if config.MaxAttempts < 1 || config.MaxAttempts > 10 {
+		return errors.New("exchanges max_attempts must be between 1 and 10")
+	}
+
+	if config.RetryBaseDelay <= 0 || config.RetryMaxDelay < config.RetryBaseDelay {
+		return errors.New("invalid exchanges retry delay values")
+	}
+
+	if config.PreviousCredentialKeyEnv != "" && config.CredentialKeyEnv == "" {
+		return errors.New("exchanges credential_key_env is required when previous_credential_key_env is set")
+	}
+
+	for _, env := range []string{config.CredentialKeyEnv, config.PreviousCredentialKeyEnv} {
+		if env == "" {
+			continue
+		}
+		key := os.Getenv(env)
+		if key == "" {
+			return fmt.Errorf("exchanges credential key variable %s is not set", env)
+		}
+		if len(key) < minCredentialKeyLength {
+			return fmt.Errorf("exchanges credential key %s must be at least %d bytes", env, minCredentialKeyLength)
+		}
+	}
+
+	// Exchanges meter history endpoints heavily; syncing more often than every
+	// few minutes only burns the rate limit
+	if config.SyncInterval < time.Minute*5 {
+		return errors.New("exchanges sync_interval must be at least 5m")
+	}
+
+	if config.SyncBatchSize <= 0 {
+		return errors.New("invalid exchanges sync_batch_size value")
+	}
+
+	binance, err := url.Parse(config.Binance.BaseURL)
+	if err != nil || binance.Scheme != "https" || binance.Host == "" {
+		return errors.New("exchanges binance base_url must be an https URL")
+	}
+
+	if len(config.Binance.QuoteAssets) == 0 {
+		return errors.New("at least one exchanges binance quote asset is required")
+	}
+
+	// Binance rejects receive windows above one minute
+	if config.Binance.RecvWindow <= 0 || config.Binance.RecvWindow > time.Minute {
+		return errors.New("invalid exchanges binance recv_window value")
+	}
+
+	kraken, err := url.Parse(config.Kraken.BaseURL)
+	if err != nil || kraken.Scheme != "https" || kraken.Host == "" {
+		return errors.New("exchanges kraken base_url must be an https URL")
+	}
+
+	if len(config.Kraken.QuoteAssets) == 0 {
+		return errors.New("at least one exchanges kraken quote asset is required")
+	}
+
+	return nil
+}
+
+// validateReports validates performance report configuration
+func validateReports(config *ReportsConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if config.Bucket == "" {
+		return errors.New("reports bucket is required when reports are enabled")
+	}
+
+	if config.Region == "" {
+		return errors.New("reports region is required when reports are enabled")
+	}
+
+	// S3 signature version 4 limits presigned URLs to seven days
+	if config.URLExpiry < time.Minute || config.URLExpiry > time.Hour*24*7 {
+		return errors.New("reports url_expiry must be between 1m and 168h")
+	}
+	if config.DeliveryURLExpiry < time.Hour || config.DeliveryURLExpiry > time.Hour*24*7 {
+		return errors.New("reports delivery_url_expiry must be between 1h and 168h")
+	}
+
+	if config.ScheduleBatchSize <= 0 || config.ScheduleBatchSize > 1000 {
+		return errors.New("reports schedule_batch_size must be between 1 and 1000")
+	}
+
+	return nil
+}
+
+// validateChains validates tracked wallet configuration
+func validateChains(config *ChainsConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if config.RequestTimeout <= 0 {
+		return errors.New("invalid chains request_timeout value")
+	}
+
+	if config.MaxAttempts < 1 || config.MaxAttempts > 10 {
+		return errors.New("chains max_attempts must be between 1 and 10")
+	}
+
+	if config.RetryBaseDelay <= 0 || config.RetryMaxDelay < config.RetryBaseDelay {
+		return errors.New("invalid chains retry delays")
+	}
+
+	if config.SyncInterval < time.Minute*5 {
+		return errors.New("chains sync_interval must be at least 5m")
+	}
+
+	if config.SyncBatchSize <= 0 {
+		return errors.New("invalid chains sync_batch_size value")
+	}
+
+	chainIDs := make(map[int64]string)
+	for _, chain := range []struct {
+		name   string
+		config EVMConfig
+	}{
+		{"ethereum", config.Ethereum},
+		{"polygon", config.Polygon},
+		{"bsc", config.BSC},
+	} {
+		if !chain.config.Enabled {
+			continue
+		}
+		if err := validateEVM(chain.name, &chain.config); err != nil {
+			return err
+		}
+		if other, ok := chainIDs[chain.config.ChainID]; ok {
+			return fmt.Errorf("chains %s and %s have the same chain_id", other, chain.name)
+		}
+		chainIDs[chain.config.ChainID] = chain.name
+	}
+
+	if config.Bitcoin.Enabled {
+		if err := validateBitcoin(&config.Bitcoin); err != nil {
+			return err
+		}
+	}
+
+	if config.Solana.Enabled {
+		if err := validateSolana(&config.Solana); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateEVM validates the settings of the named EVM chain
+func validateEVM(name string, config *EVMConfig) error {
+	for key, raw := range map[string]string{"rpc_url": config.RPCURL, "indexer_url": config.IndexerURL} {
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("chains %s %s must be an https URL", name, key)
+		}
+	}
+
+	if config.IndexerAPIKeyEnv != "" && os.Getenv(config.IndexerAPIKeyEnv) == "" {
+		return fmt.Errorf("chains %s indexer API key variable %s is not set", name, config.IndexerAPIKeyEnv)
+	}
+
+	if config.ChainID <= 0 {
+		return fmt.Errorf("invalid chains %s chain_id value", name)
+	}
+
+	// Sidechains with short block times need more confirmations than
+	// Ethereum, but blocks are final well within a thousand
+	if config.Confirmations < 0 || config.Confirmations > 1000 {
+		return fmt.Errorf("chains %s confirmations must be between 0 and 1000", name)
+	}
+
+	if config.NativeSymbol == "" {
+		return fmt.Errorf("chains %s native_symbol is required", name)
+	}
+
+	if config.RequestsPerSecond <= 0 {
+		return fmt.Errorf("invalid chains %s requests_per_second value", name)
+	}
+
+	seen := make(map[string]bool, len(config.Tokens))
+	for _, token := range config.Tokens {
+		contract := strings.ToLower(token.Contract)
+		if len(contract) != 42 || !strings.HasPrefix(contract, "0x") {
+			return fmt.Errorf("invalid chains %s token contract %q", name, token.Contract)
+		}
+		if token.Symbol == "" || token.Decimals < 0 || token.Decimals > 36 {
+			return fmt.Errorf("invalid chains %s token %s", name, token.Contract)
+		}
+		if seen[contract] {
+			return fmt.Errorf("duplicate chains %s token %s", name, token.Contract)
+		}
+		seen[contract] = true
+	}
+
+	return nil
+}
+
+// validateBitcoin validates the settings of the Bitcoin chain
+func validateBitcoin(btc *BitcoinConfig) error {
+	if u, err := url.Parse(btc.EsploraURL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("chains bitcoin esplora_url must be an https URL")
+	}
+
+	if btc.Confirmations < 0 || btc.Confirmations > 100 {
+		return errors.New("chains bitcoin confirmations must be between 0 and 100")
+	}
+
+	// Wallets following BIP44 leave at most 20 unused addresses in a row
+	if btc.GapLimit < 20 || btc.GapLimit > 1000 {
+		return errors.New("chains bitcoin gap_limit must be between 20 and 1000")
+	}
+
+	if btc.RequestsPerSecond <= 0 {
+		return errors.New("invalid chains bitcoin requests_per_second value")
+	}
+
+	return nil
+}
+
+// validateSolana validates the settings of the Solana chain
+func validateSolana(sol *SolanaConfig) error {
+	if u, err := url.Parse(sol.RPCURL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("chains solana rpc_url must be an https URL")
+	}
+
+	if sol.RequestsPerSecond <= 0 {
+		return errors.New("invalid chains solana requests_per_second value")
+	}
+
+	seen := make(map[string]bool, len(sol.Tokens))
+	for _, token := range sol.Tokens {
+		// Mints are base58 encoded 32-byte keys
+		if len(token.Mint) < 32 || len(token.Mint) > 44 || token.Symbol == "" {
+			return fmt.Errorf("invalid chains solana token %q", token.Mint)
+		}
+		if seen[token.Mint] {
+			return fmt.Errorf("duplicate chains solana token %s", token.Mint)
+		}
+		seen[token.Mint] = true
+	}
+
+	return nil
+}
+
+// validateStaking validates staking reward accrual configuration. Rewards
+// are read from chains or exchanges, so one of them must be enabled.
+func validateStaking(config *StakingConfig, sourcesEnabled bool) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if !sourcesEnabled {
+		return errors.New("staking requires chains or exchanges to be enabled")
+	}
+
+	// Solana pays rewards every two to three days; other chains less often
+	if config.AccrualInterval < time.Minute*15 {
+		return errors.New("staking accrual_interval must be at least 15m")
+	}
+
+	if config.AccrualBatchSize <= 0 {
+		return errors.New("invalid staking accrual_batch_size value")
+	}
+
+	return nil
+}
+
+// validateCorporateActions validates the airdrop and fork cost basis policies
+func validateCorporateActions(config *CorporateActionsConfig) error {
+	for name, policy := range map[string]string{
+		"airdrop_cost_basis": config.AirdropCostBasis,
+		"fork_cost_basis":    config.ForkCostBasis,
+	} {
+		if policy != "zero" && policy != "allocated" {
+			return fmt.Errorf("corporate_actions %s must be zero or allocated", name)
+		}
+	}
+
+	return nil
+}
+
+// validateCostBasis validates the fee policy
+func validateCostBasis(config *CostBasisConfig) error {
+	if config.FeePolicy != "capitalize" && config.FeePolicy != "expense" {
+		return fmt.Errorf("unsupported cost_basis fee_policy %q", config.FeePolicy)
+	}
+
+	return nil
+}
+
+// validateTax validates the estimated tax rate, wash-sale window, long-term
+// holding period and jurisdiction codes
+func validateTax(config *TaxConfig) error {
+	if config.EstimatedRate < 0 || config.EstimatedRate > 1 {
+		return errors.New("tax estimated_rate must be between 0 and 1")
+	}
+	if config.WashSaleWindow < 0 {
+		return errors.New("tax wash_sale_window must not be negative")
+	}
+	if config.LongTermHoldingPeriod <= 0 {
+		return errors.New("tax long_term_holding_period must be positive")
+	}
+	for _, code := range append([]string{config.Jurisdiction}, config.WashSaleJurisdictions...) {
+		if !isCountryCode(code) {
+			return fmt.Errorf("tax jurisdiction %q must be an ISO 3166-1 alpha-2 code", code)
+		}
+	}
+
+	return nil
+}
+
+// isCountryCode reports whether code has the form of an ISO 3166-1 alpha-2
+// country code
+func isCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// validateSharing validates share link lifetimes
+func validateSharing(config *SharingConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if config.DefaultTTL <= 0 {
+		return errors.New("invalid sharing default_ttl value")
+	}
+
+	if config.MaxTTL < config.DefaultTTL {
+		return errors.New("sharing max_ttl must be at least default_ttl")
+	}
+
+	return nil
+}
+
+// validateAdmin validates operator tokens, which must be distinct SHA-256
+// hashes granting at least one scope
+func validateAdmin(config *AdminConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if len(config.Tokens) == 0 {
+		return errors.New("admin requires at least one token")
+	}
+
+	hashes := make(map[string]bool, len(config.Tokens))
+	for _, token := range config.Tokens {
+		if token.Name == "" {
+			return errors.New("admin token name is required")
+		}
+		hash, err := hex.DecodeString(token.Hash)
+		if err != nil || len(hash) != sha256.Size {
+			return fmt.Errorf("admin token %s hash must be a hex-encoded SHA-256 digest", token.Name)
+		}
+		if hashes[strings.ToLower(token.Hash)] {
+			return fmt.Errorf("admin token %s hash is configured more than once", token.Name)
+		}
+		hashes[strings.ToLower(token.Hash)] = true
+		if len(token.Scopes) == 0 {
+			return fmt.Errorf("admin token %s grants no scopes", token.Name)
+		}
+	}
+
+	return nil
+}
+
+// validatePriceRefresh validates the background price refresh job, which
+// needs at least one provider to fetch from
+func validatePriceRefresh(config *PriceRefreshConfig, providers []ProviderConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if len(providers) == 0 {
+		return errors.New("price refresh requires at least one provider")
+	}
+
+	if config.Interval < time.Second*10 {
+		return errors.New("price_refresh interval must be at least 10s")
+	}
+
+	if config.BatchSize <= 0 {
+		return errors.New("invalid price_refresh batch_size value")
+	}
+
+	if config.RequestTimeout <= 0 || config.RequestTimeout >= config.Interval {
+		return errors.New("price_refresh request_timeout must be positive and below interval")
+	}
+
+	if config.MaxAttempts < 1 || config.MaxAttempts > 10 {
+		return errors.New("price_refresh max_attempts must be between 1 and 10")
+	}
+
+	if config.RetryBaseDelay <= 0 || config.RetryMaxDelay < config.RetryBaseDelay {
+		return errors.New("invalid price_refresh retry delays")
+	}
+
+	if config.StaleAfter < config.Interval {
+		return errors.New("price_refresh stale_after must be at least the interval")
+	}
+
+	if config.FailureThreshold < 1 {
+		return errors.New("invalid price_refresh failure_threshold value")
+	}
+
+	if config.MaxStaleness != 0 && config.MaxStaleness < config.StaleAfter {
+		return errors.New("price_refresh max_staleness must be zero or at least stale_after")
+	}
+
+	// Prices are left out past max_staleness, so symbols must be delisted
+	// before then to keep valuing their assets
+	if config.DelistAfter != 0 && (config.DelistAfter < config.StaleAfter ||
+		(config.MaxStaleness != 0 && config.DelistAfter > config.MaxStaleness)) {
+		return errors.New("price_refresh delist_after must be zero or between stale_after and max_staleness")
+	}
+
+	if config.DelistedPricePolicy != "last_price" && config.DelistedPricePolicy != "zero" {
+		return fmt.Errorf("unsupported price_refresh delisted_price_policy %q", config.DelistedPricePolicy)
+	}
+
+	return nil
+}
+
+// validateJobs validates background job leader election
+func validateJobs(config *JobsConfig) error {
+	if !config.LeaderElection {
+		return nil
+	}
+
+	if config.RetryInterval <= 0 {
+		return errors.New("invalid jobs retry_interval value")
+	}
+
+	if config.CheckInterval <= 0 || config.CheckInterval > config.RetryInterval {
+		return errors.New("jobs check_interval must be positive and at most retry_interval")
+	}
+
+	return nil
+}
+
+// validateStartup validates the warm-up phase configuration
+func validateStartup(config *StartupConfig) error {
+	if config.WarmupTimeout <= 0 {
+		return errors.New("invalid warmup_timeout value")
+	}
+
+	if config.RetryInterval <= 0 || config.RetryInterval >= config.WarmupTimeout {
+		return errors.New("startup retry_interval must be positive and shorter than warmup_timeout")
+	}
+
+	if config.PrimePrices && config.PrimeBatchSize <= 0 {
+		return errors.New("invalid prime_batch_size value")
+	}
+
+	if config.Migrate && config.MigrationsDir == "" {
+		return errors.New("startup migrations_dir is required when migrate is enabled")
+	}
+
+	return nil
+}
+
+// validateScheduler validates cron scheduler configuration. Cron expressions
+// are parsed when their jobs are registered.
+func validateScheduler(config *SchedulerConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	if _, err := time.LoadLocation(config.Timezone); err != nil {
+		return fmt.Errorf("invalid scheduler timezone: %w", err)
+	}
+
+	if config.DrainTimeout <= 0 {
+		return errors.New("invalid scheduler drain_timeout value")
+	}
+
+	return nil
+}
+
+// validatePagination validates page token configuration
+func validatePagination(config *PaginationConfig) error {
+	if config.TokenTTL < 0 {
+		return errors.New("invalid pagination token_ttl value")
+	}
+
+	if config.PreviousSecretEnv != "" && config.SecretEnv == "" {
+		return errors.New("pagination secret_env is required when previous_secret_env is set")
+	}
+
+	for _, env := range []string{config.SecretEnv, config.PreviousSecretEnv} {
+		if env == "" {
+			continue
+		}
+		secret := os.Getenv(env)
+		if secret == "" {
+			return fmt.Errorf("pagination secret variable %s is not set", env)
+		}
+		if len(secret) < minPaginationSecretLength {
+			return fmt.Errorf("pagination secret %s must be at least %d bytes", env, minPaginationSecretLength)
+		}
+	}
+
+	return nil
+}
+
+// validatePlayground validates API playground configuration
+func validatePlayground(config *Config) error {
+	if !config.Playground.Enabled {
+		return nil
+	}
+
+	if !config.Metrics.Enabled {
+		return errors.New("playground requires the metrics server to be enabled")
+	}
+
+	if config.Server.TLSEnabled {
+		return errors.New("playground is not supported when server TLS is enabled")
+	}
+
+	path := config.Playground.Path
+	if path == "" || path[0] != '/' || path == "/" {
+		return errors.New("playground path must be an absolute, non-root path")
+	}
+
+	switch path {
+	case config.Metrics.Path, "/healthz", "/readyz", "/buildinfo":
+		return fmt.Errorf("playground path %s conflicts with an ops endpoint", path)
+	}
+
+	return nil
+}
+
+// validateProviders validates a market data provider list whose types must
+// be among supported
+func validateProviders(providers []ProviderConfig, supported []string) error {
+	names := make(map[string]bool, len(providers))
+	priorities := make(map[int]string, len(providers))
+
+	for _, p := range providers {
+		if p.Name == "" {
+			return errors.New("provider name is required")
+		}
+
+		if names[p.Name] {
+			return fmt.Errorf("duplicate provider name %q", p.Name)
+		}
+		names[p.Name] = true
+
+		if !isSupportedProviderType(p.Type, supported) {
+			return fmt.Errorf("provider %q has unsupported type %q", p.Name, p.Type)
+		}
+
+		u, err := url.Parse(p.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("provider %q has invalid base_url", p.Name)
+		}
+
+		if p.APIKeyEnv != "" && os.Getenv(p.APIKeyEnv) == "" {
+			return fmt.Errorf("provider %q API key variable %s is not set", p.Name, p.APIKeyEnv)
+		}
+
+		if p.RateLimit <= 0 {
+			return fmt.Errorf("provider %q has invalid rate_limit value", p.Name)
+		}
+
+		if p.Priority < 0 {
+			return fmt.Errorf("provider %q has invalid priority value", p.Name)
+		}
+
+		if other, ok := priorities[p.Priority]; ok {
+			return fmt.Errorf("providers %q and %q share priority %d", other, p.Name, p.Priority)
+		}
+		priorities[p.Priority] = p.Name
+	}
+
+	return nil
+}
+
+// isSupportedProviderType checks whether the provider type is among the
+// supported implementations
+func isSupportedProviderType(providerType string, supported []string) bool {
+	for _, t := range supported {
+		if providerType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// isSupportedNotificationEvent reports whether event is a known notification event
+func isSupportedNotificationEvent(event string) bool {
+	for _, supported := range SupportedNotificationEvents {
+		if event == supported {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// secretMask replaces secret values in the effective configuration
+const secretMask = "******"
+
+// Effective returns the fully resolved configuration keyed as in the config
+// file, for tooling to print. Fields tagged secret:"true" and credentials in
+// URLs are masked; secrets referenced by environment variable name are shown
+// by name only, as their values are never part of the configuration.
+func Effective(config *Config) map[string]interface{} {
+	return effectiveStruct(reflect.ValueOf(config).Elem())
+}
+
+// effectiveStruct converts a configuration struct to a map keyed by the
+// mapstructure names of its fields
+func effectiveStruct(v reflect.Value) map[string]interface{} {
+	t := v.Type()
+	out := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		value := v.Field(i)
+		if field.Tag.Get("secret") == "true" {
+			if value.IsZero() {
+				out[name] = ""
+			} else {
+				out[name] = secretMask
+			}
+			continue
+		}
+		out[name] = effectiveValue(value)
+	}
+	return out
+}
+
+// effectiveValue converts a configuration value to its printable form
+func effectiveValue(v reflect.Value) interface{} {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(v.Int()).String()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return effectiveStruct(v)
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return effectiveValue(v.Elem())
+	case reflect.Slice, reflect.Array:
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = effectiveValue(v.Index(i))
+		}
+		return values
+	case reflect.Map:
+		values := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			values[iter.Key().String()] = effectiveValue(iter.Value())
+		}
+		return values
+	case reflect.String:
+		return maskURLCredentials(v.String())
+	}
+	return v.Interface()
+}
+
+// maskURLCredentials masks the password of a URL with user information,
+// returning other values unchanged
+func maskURLCredentials(value string) string {
+	if !strings.Contains(value, "@") {
+		return value
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return value
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper" // v1.15.0
+)
+
+const (
+	// envPrefix prefixes the environment variable of every configuration key
+	envPrefix = "PORTFOLIO"
+
+	// envFileSuffix marks a variable naming a file holding the value of the
+	// configuration key, such as a mounted Docker or Kubernetes secret
+	envFileSuffix = "_FILE"
+)
+
+// envKeyReplacer maps configuration keys to environment variable names, so
+// database.host is read from PORTFOLIO_DATABASE_HOST
+var envKeyReplacer = strings.NewReplacer(".", "_")
+
+// envName returns the environment variable of a configuration key
+func envName(key string) string {
+	return envPrefix + "_" + strings.ToUpper(envKeyReplacer.Replace(key))
+}
+
+// bindEnv binds every scalar configuration key to its environment variable.
+// Viper's AutomaticEnv only consults the environment for keys it already
+// knows from defaults or the config file, so without explicit bindings an
+// environment-only deployment silently falls back to defaults. Lists of
+// scalars are read comma-separated; lists of structs and maps, such as
+// providers or labels, can only be set in the config file. Each key can
+// instead be read from the file named by its variable with a _FILE suffix,
+// such as PORTFOLIO_DATABASE_PASSWORD_FILE, so secrets never appear in the
+// environment or the config file.
+func bindEnv(v *viper.Viper) error {
+	return bindEnvKeys(v, reflect.TypeOf(Config{}), "")
+}
+
+// bindEnvKeys binds the keys of the fields of t below prefix
+func bindEnvKeys(v *viper.Viper, t reflect.Type, prefix string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		key := prefix + name
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		switch {
+		case fieldType == reflect.TypeOf(time.Duration(0)):
+		case fieldType.Kind() == reflect.Struct:
+			if err := bindEnvKeys(v, fieldType, key+"."); err != nil {
+				return err
+			}
+			continue
+		case fieldType.Kind() == reflect.Map:
+			continue
+		case fieldType.Kind() == reflect.Slice && (fieldType.Elem().Kind() == reflect.Struct || fieldType.Elem().Kind() == reflect.Map):
+			continue
+		}
+
+		if err := v.BindEnv(key); err != nil {
+			return err
+		}
+		if err := readEnvFile(v, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readEnvFile sets key to the contents of the file named by its _FILE
+// variable, if set, without the trailing newline secret files usually end in
+func readEnvFile(v *viper.Viper, key string) error {
+	name := envName(key)
+	path, ok := os.LookupEnv(name + envFileSuffix)
+	if !ok {
+		return nil
+	}
+	if _, ok := os.LookupEnv(name); ok {
+		return fmt.Errorf("%s and %s%s are mutually exclusive", name, name, envFileSuffix)
+	}
+
+	value, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s%s: %w", name, envFileSuffix, err)
+	}
+	v.Set(key, strings.TrimRight(string(value), "\r\n"))
+	return nil
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"          // v1.15.0
+	_ "github.com/spf13/viper/remote" // v1.15.0
+)
+
+// Configuration sources, from lowest to highest priority
+const (
+	SourceDefaults = "defaults"
+	SourceFile     = "file"
+	SourceRemote   = "remote"
+)
+
+// remoteProviders are the supported key/value stores
+var remoteProviders = map[string]bool{
+	"consul": true,
+	"etcd3":  true,
+}
+
+// versionLength is the number of hex digits of a configuration version
+const versionLength = 12
+
+// RemoteConfig contains settings for loading the configuration from a
+// Consul or etcd key holding a YAML document. The remote document takes
+// precedence over the local config file, which only applies to keys the
+// document leaves unset or when the store is unreachable; environment
+// variables still override both. The key is polled every WatchInterval, zero
+// disabling watching, to report changes, which apply on restart.
+type RemoteConfig struct {
+	Provider      string        `mapstructure:"provider"`
+	Endpoint      string        `mapstructure:"endpoint"`
+	Path          string        `mapstructure:"path"`
+	WatchInterval time.Duration `mapstructure:"watch_interval"`
+}
+
+// Origin describes where a loaded configuration came from
+type Origin struct {
+	// Source is the highest priority source the configuration was read from
+	Source string
+	// Version is the configured version, or else a hash of the resolved
+	// configuration
+	Version string
+	// RemoteErr is why the remote provider was skipped, if it was
+	RemoteErr error
+}
+
+// readRemote merges the configured remote document over the config file read
+// into v, without contacting the provider when its settings are invalid.
+// Viper ranks its own key/value store beneath the config file, so the
+// document is read separately and merged into the file's settings instead.
+func readRemote(v *viper.Viper) error {
+	var remote RemoteConfig
+	if err := v.UnmarshalKey("remote", &remote); err != nil {
+		return err
+	}
+	if err := validateRemote(&remote); err != nil {
+		return err
+	}
+
+	rv := viper.New()
+	rv.SetConfigType("yaml")
+	if err := rv.AddRemoteProvider(remote.Provider, remote.Endpoint, remote.Path); err != nil {
+		return err
+	}
+	if err := rv.ReadRemoteConfig(); err != nil {
+		return fmt.Errorf("failed to read %s config: %w", remote.Provider, err)
+	}
+	if err := v.MergeConfigMap(rv.AllSettings()); err != nil {
+		return fmt.Errorf("failed to merge %s config: %w", remote.Provider, err)
+	}
+	return nil
+}
+
+// configVersion returns the version of a resolved configuration
+func configVersion(config *Config) string {
+	if config.Version != "" {
+		return config.Version
+	}
+
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])[:versionLength]
+}
+
+// WatchRemote reloads the configuration every remote watch interval until
+// ctx is done. It calls onChange with each valid configuration read from the
+// remote provider whose version differs from the last one, starting from
+// current, and with the error of each reload that failed or could not reach
+// the provider.
+func WatchRemote(ctx context.Context, current *Config, onChange func(*Config, error)) {
+	ticker := time.NewTicker(current.Remote.WatchInterval)
+	defer ticker.Stop()
+
+	version := current.Origin.Version
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		loaded, err := LoadConfig()
+		if err != nil {
+			onChange(nil, err)
+			continue
+		}
+		if loaded.Origin.RemoteErr != nil {
+			onChange(nil, loaded.Origin.RemoteErr)
+			continue
+		}
+		if loaded.Origin.Version != version {
+			version = loaded.Origin.Version
+			onChange(loaded, nil)
+		}
+	}
+}
+
+// validateRemote validates remote configuration settings
+func validateRemote(config *RemoteConfig) error {
+	if config.Provider == "" {
+		return nil
+	}
+	if !remoteProviders[config.Provider] {
+		return fmt.Errorf("unsupported remote provider %q, expected consul or etcd3", config.Provider)
+	}
+	if config.Endpoint == "" {
+		return errors.New("remote endpoint is required")
+	}
+	if config.Path == "" {
+		return errors.New("remote path is required")
+	}
+	if config.WatchInterval < 0 {
+		return errors.New("invalid remote watch_interval value")
+	}
+	return nil
+}
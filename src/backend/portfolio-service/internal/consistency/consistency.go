@@ -0,0 +1,136 @@
+// Package consistency implements read-your-writes tokens. Mutating RPCs return
+// a token encoding the primary's WAL position after the write; reads that
+// present it are served from a replica only once the replica has replayed past
+// that position, and from the primary otherwise.
+package consistency
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"          // v1.50.0
+	"google.golang.org/grpc/codes"    // v1.50.0
+	"google.golang.org/grpc/metadata" // v1.50.0
+	"google.golang.org/grpc/status"   // v1.50.0
+)
+
+// MetadataKey is the gRPC metadata key carrying consistency tokens in both directions
+const MetadataKey = "x-consistency-token"
+
+// tokenPrefix versions the token format
+const tokenPrefix = "c1."
+
+// ErrInvalidToken is returned for tokens that cannot be decoded
+var ErrInvalidToken = errors.New("invalid consistency token")
+
+type requirementKey struct{}
+type trackerKey struct{}
+
+// tracker records the latest write position observed while serving a request
+type tracker struct {
+	mutex sync.Mutex
+	lsn   uint64
+}
+
+// Encode returns the token for a WAL position
+func Encode(lsn uint64) string {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], lsn)
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(buf[:])
+}
+
+// Decode returns the WAL position encoded in a token
+func Decode(token string) (uint64, error) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return 0, ErrInvalidToken
+	}
+	buf, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token, tokenPrefix))
+	if err != nil || len(buf) != 8 {
+		return 0, ErrInvalidToken
+	}
+	return binary.BigEndian.Uint64(buf), nil
+}
+
+// ParseLSN parses a PostgreSQL pg_lsn in its text form, e.g. "16/B374D848"
+func ParseLSN(s string) (uint64, error) {
+	hi, lo, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, fmt.Errorf("malformed LSN %q", s)
+	}
+	h, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("malformed LSN %q: %w", s, err)
+	}
+	l, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("malformed LSN %q: %w", s, err)
+	}
+	return h<<32 | l, nil
+}
+
+// WithRequirement returns a context whose reads must observe writes up to lsn
+func WithRequirement(ctx context.Context, lsn uint64) context.Context {
+	return context.WithValue(ctx, requirementKey{}, lsn)
+}
+
+// Requirement returns the WAL position reads in ctx must observe, if any
+func Requirement(ctx context.Context) (uint64, bool) {
+	lsn, ok := ctx.Value(requirementKey{}).(uint64)
+	return lsn, ok
+}
+
+// Tracking reports whether a write position recorded in ctx will be returned
+// to the client, so callers can skip looking it up otherwise
+func Tracking(ctx context.Context) bool {
+	_, ok := ctx.Value(trackerKey{}).(*tracker)
+	return ok
+}
+
+// RecordWrite notes the WAL position after a committed write in ctx
+func RecordWrite(ctx context.Context, lsn uint64) {
+	t, ok := ctx.Value(trackerKey{}).(*tracker)
+	if !ok {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if lsn > t.lsn {
+		t.lsn = lsn
+	}
+}
+
+// UnaryServerInterceptor accepts consistency tokens from request metadata and
+// returns a token in the response header when the handler performed writes
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(MetadataKey); len(values) > 0 && values[0] != "" {
+				lsn, err := Decode(values[0])
+				if err != nil {
+					return nil, status.Error(codes.InvalidArgument, err.Error())
+				}
+				ctx = WithRequirement(ctx, lsn)
+			}
+		}
+
+		t := &tracker{}
+		ctx = context.WithValue(ctx, trackerKey{}, t)
+
+		resp, err := handler(ctx, req)
+
+		t.mutex.Lock()
+		lsn := t.lsn
+		t.mutex.Unlock()
+		if err == nil && lsn > 0 {
+			_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataKey, Encode(lsn)))
+		}
+
+		return resp, err
+	}
+}
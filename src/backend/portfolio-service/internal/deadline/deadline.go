@@ -0,0 +1,113 @@
+// Package deadline applies default deadlines to calls whose client sent none,
+// so abandoned or runaway requests release their database connections and
+// other resources. Deadlines sent by clients are always kept as they are.
+package deadline
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"google.golang.org/grpc"                         // v1.50.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/metrics"
+)
+
+var applied = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "portfolio_default_deadlines_applied_total",
+		Help: "Total number of calls given a default deadline because the client sent none",
+	},
+	[]string{"method"},
+)
+
+func init() {
+	metrics.MustRegister(applied)
+}
+
+// Enforcer applies the configured default deadlines
+type Enforcer struct {
+	fallback time.Duration
+	methods  map[string]time.Duration
+}
+
+// NewEnforcer creates an enforcer of the configured deadlines
+func NewEnforcer(cfg config.DeadlineConfig) (*Enforcer, error) {
+	if cfg.Default <= 0 {
+		return nil, errors.New("a default deadline is required")
+	}
+
+	e := &Enforcer{fallback: cfg.Default, methods: make(map[string]time.Duration, len(cfg.Methods))}
+	for method, timeout := range cfg.Methods {
+		if timeout <= 0 {
+			return nil, errors.New("method deadlines must be positive")
+		}
+		e.methods[strings.ToLower(method)] = timeout
+	}
+
+	return e, nil
+}
+
+// Timeout returns the default deadline of a method, if it gets one
+func (e *Enforcer) Timeout(fullMethod string, stream bool) (time.Duration, bool) {
+	if timeout, ok := e.methods[strings.ToLower(path.Base(fullMethod))]; ok {
+		return timeout, true
+	}
+	if stream {
+		return 0, false
+	}
+	return e.fallback, true
+}
+
+// Apply returns a context with the default deadline of a method when ctx has
+// no deadline, and ctx unchanged otherwise. The cancel function must be called
+// once the call completes.
+func (e *Enforcer) Apply(ctx context.Context, fullMethod string, stream bool) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	timeout, ok := e.Timeout(fullMethod, stream)
+	if !ok {
+		return ctx, func() {}
+	}
+
+	applied.WithLabelValues(fullMethod).Inc()
+	return context.WithTimeout(ctx, timeout)
+}
+
+// UnaryServerInterceptor applies default deadlines to unary calls
+func (e *Enforcer) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel := e.Apply(ctx, info.FullMethod, false)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor applies the deadlines configured for streaming
+// methods
+func (e *Enforcer) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := e.Apply(ss.Context(), info.FullMethod, true)
+		defer cancel()
+		if ctx == ss.Context() {
+			return handler(srv, ss)
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// serverStream overrides the context of a server stream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context carrying the applied deadline
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,314 @@
+// Package deprecation signals use of deprecated RPC methods and request fields
+// to clients through trailer metadata, and counts such calls by client version
+// so old API versions can be sunset once callers have migrated.
+//
+// Methods and fields are deprecated in the proto definition with the standard
+// deprecated option; sunset dates are supplied by configuration. Client
+// versions are bucketed to major.minor, and to a configured allowlist when
+// one is set, as they come from a header any caller controls.
+package deprecation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"   // v1.14.0
+	"google.golang.org/grpc"                           // v1.50.0
+	"google.golang.org/grpc/metadata"                  // v1.50.0
+	"google.golang.org/protobuf/proto"                 // v1.30.0
+	"google.golang.org/protobuf/reflect/protoreflect"  // v1.30.0
+	"google.golang.org/protobuf/reflect/protoregistry" // v1.30.0
+	"google.golang.org/protobuf/types/descriptorpb"    // v1.30.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/metrics"
+)
+
+// Metadata keys used for deprecation signaling
+const (
+	// DeprecatedKey lists the deprecated methods and fields used by a call
+	DeprecatedKey = "x-deprecated"
+	// SunsetKey carries the earliest sunset date of those elements as an HTTP date
+	SunsetKey = "x-sunset"
+	// ClientVersionKey is sent by clients to identify their version
+	ClientVersionKey = "x-client-version"
+)
+
+// maxFieldDepth bounds recursion into nested request messages
+const maxFieldDepth = 8
+
+// Client version labels other than major.minor buckets
+const (
+	// unknownVersion labels calls without a well-formed client version
+	unknownVersion = "unknown"
+	// otherVersion labels calls from versions outside the allowlist
+	otherVersion = "other"
+)
+
+// clientVersionPattern matches plain semantic versions, capturing the major
+// and minor version they are bucketed to
+var clientVersionPattern = regexp.MustCompile(`^v?(\d{1,4})(?:\.(\d{1,4}))?(?:\.\d{1,4})?$`)
+
+var deprecatedCalls = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "portfolio_deprecated_calls_total",
+		Help: "Total number of calls using deprecated methods or fields, by client version",
+	},
+	[]string{"method", "element", "client_version"},
+)
+
+func init() {
+	metrics.MustRegister(deprecatedCalls)
+}
+
+// Signaler detects deprecated API usage and reports it to clients and metrics
+type Signaler struct {
+	sunsets  map[string]time.Time
+	versions map[string]bool // allowed major.minor client versions, if any
+	methods  sync.Map        // full method name -> deprecated element name or ""
+}
+
+// NewSignaler creates a signaler with the configured sunset dates and client
+// version allowlist
+func NewSignaler(cfg config.DeprecationConfig) (*Signaler, error) {
+	sunsets := make(map[string]time.Time, len(cfg.Sunsets))
+	for element, date := range cfg.Sunsets {
+		t, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sunset date for %s: %w", element, err)
+		}
+		sunsets[strings.ToLower(element)] = t
+	}
+
+	var versions map[string]bool
+	if len(cfg.ClientVersions) > 0 {
+		versions = make(map[string]bool, len(cfg.ClientVersions))
+		for _, version := range cfg.ClientVersions {
+			bucket, ok := bucketVersion(version)
+			if !ok {
+				return nil, fmt.Errorf("invalid client version %q", version)
+			}
+			versions[bucket] = true
+		}
+	}
+
+	return &Signaler{sunsets: sunsets, versions: versions}, nil
+}
+
+// UnaryServerInterceptor sets deprecation trailers and records metrics for
+// calls to deprecated methods or with deprecated request fields set
+func (s *Signaler) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		elements := s.Inspect(info.FullMethod, req)
+		if trailer := s.report(ctx, info.FullMethod, elements); trailer != nil {
+			_ = grpc.SetTrailer(ctx, trailer)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor sets deprecation trailers and records metrics for
+// streaming calls to deprecated methods or with deprecated fields set in any
+// received message. Elements are reported once per stream as it ends.
+func (s *Signaler) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		stream := &inspectedStream{ServerStream: ss, seen: make(map[string]bool)}
+		if method := s.deprecatedMethod(info.FullMethod); method != "" {
+			stream.seen[method] = true
+		}
+
+		err := handler(srv, stream)
+
+		if trailer := s.report(ss.Context(), info.FullMethod, stream.elements()); trailer != nil {
+			ss.SetTrailer(trailer)
+		}
+		return err
+	}
+}
+
+// inspectedStream collects the deprecated fields set in received messages
+type inspectedStream struct {
+	grpc.ServerStream
+
+	mutex sync.Mutex
+	seen  map[string]bool
+}
+
+// RecvMsg receives a message and records its deprecated fields
+func (s *inspectedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if msg, ok := m.(proto.Message); ok {
+		s.mutex.Lock()
+		collectDeprecatedFields(msg.ProtoReflect(), 0, s.seen)
+		s.mutex.Unlock()
+	}
+	return nil
+}
+
+// elements returns the deprecated elements used by the stream, sorted
+func (s *inspectedStream) elements() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	elements := make([]string, 0, len(s.seen))
+	for element := range s.seen {
+		elements = append(elements, element)
+	}
+	sort.Strings(elements)
+	return elements
+}
+
+// report records the deprecated elements used by a call to fullMethod and
+// returns the trailer signaling them, or nil when there are none
+func (s *Signaler) report(ctx context.Context, fullMethod string, elements []string) metadata.MD {
+	if len(elements) == 0 {
+		return nil
+	}
+
+	version := s.ClientVersion(ctx)
+	for _, element := range elements {
+		deprecatedCalls.WithLabelValues(fullMethod, element, version).Inc()
+	}
+
+	trailer := metadata.Pairs(DeprecatedKey, strings.Join(elements, ","))
+	if sunset, ok := s.sunset(elements); ok {
+		trailer.Set(SunsetKey, sunset.UTC().Format(http.TimeFormat))
+	}
+	return trailer
+}
+
+// Inspect returns the full names of the deprecated method and request fields
+// used by a call, sorted
+func (s *Signaler) Inspect(fullMethod string, req interface{}) []string {
+	var elements []string
+
+	if method := s.deprecatedMethod(fullMethod); method != "" {
+		elements = append(elements, method)
+	}
+
+	if msg, ok := req.(proto.Message); ok {
+		seen := make(map[string]bool)
+		collectDeprecatedFields(msg.ProtoReflect(), 0, seen)
+		for field := range seen {
+			elements = append(elements, field)
+		}
+	}
+
+	sort.Strings(elements)
+	return elements
+}
+
+// deprecatedMethod returns the method's full name if it is deprecated
+func (s *Signaler) deprecatedMethod(fullMethod string) string {
+	if cached, ok := s.methods.Load(fullMethod); ok {
+		return cached.(string)
+	}
+
+	var name string
+	// "/pkg.Service/Method" -> "pkg.Service.Method"
+	descName := protoreflect.FullName(strings.Replace(strings.TrimPrefix(fullMethod, "/"), "/", ".", 1))
+	if desc, err := protoregistry.GlobalFiles.FindDescriptorByName(descName); err == nil {
+		if method, ok := desc.(protoreflect.MethodDescriptor); ok {
+			if opts, ok := method.Options().(*descriptorpb.MethodOptions); ok && opts.GetDeprecated() {
+				name = string(method.FullName())
+			}
+		}
+	}
+
+	s.methods.Store(fullMethod, name)
+	return name
+}
+
+// sunset returns the earliest configured sunset date among elements
+func (s *Signaler) sunset(elements []string) (time.Time, bool) {
+	var (
+		earliest time.Time
+		found    bool
+	)
+	for _, element := range elements {
+		t, ok := s.sunsets[strings.ToLower(element)]
+		if ok && (!found || t.Before(earliest)) {
+			earliest, found = t, true
+		}
+	}
+	return earliest, found
+}
+
+// collectDeprecatedFields records the full names of deprecated fields set in
+// msg and its nested messages
+func collectDeprecatedFields(msg protoreflect.Message, depth int, seen map[string]bool) {
+	if depth > maxFieldDepth {
+		return
+	}
+
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if opts, ok := fd.Options().(*descriptorpb.FieldOptions); ok && opts.GetDeprecated() {
+			seen[string(fd.FullName())] = true
+		}
+
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				collectDeprecatedFields(list.Get(i).Message(), depth+1, seen)
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				collectDeprecatedFields(mv.Message(), depth+1, seen)
+				return true
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			collectDeprecatedFields(v.Message(), depth+1, seen)
+		}
+		return true
+	})
+}
+
+// ClientVersion returns the caller's version label from request metadata:
+// its major.minor version, "other" when that is not in the allowlist, or
+// "unknown" when it sent no well-formed version
+func (s *Signaler) ClientVersion(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return unknownVersion
+	}
+	values := md.Get(ClientVersionKey)
+	if len(values) == 0 {
+		return unknownVersion
+	}
+
+	bucket, ok := bucketVersion(values[0])
+	if !ok {
+		return unknownVersion
+	}
+	if s.versions != nil && !s.versions[bucket] {
+		return otherVersion
+	}
+	return bucket
+}
+
+// bucketVersion returns the major.minor bucket of a semantic version, with
+// leading zeros dropped so equal versions share a label
+func bucketVersion(version string) (string, bool) {
+	match := clientVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return "", false
+	}
+
+	major, _ := strconv.Atoi(match[1])
+	minor := 0
+	if match[2] != "" {
+		minor, _ = strconv.Atoi(match[2])
+	}
+	return strconv.Itoa(major) + "." + strconv.Itoa(minor), true
+}
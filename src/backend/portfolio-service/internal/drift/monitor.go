@@ -0,0 +1,165 @@
+// Package drift periodically compares portfolios with target allocations to
+// their actual weights and raises alerts for those that drifted too far.
+package drift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"                         // v1.3.0
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"github.com/shopspring/decimal"                  // v1.3.1
+	"go.uber.org/zap"                                // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/metrics"
+	"bookman/portfolio-service/internal/models"
+	"bookman/portfolio-service/internal/notifications"
+	"bookman/portfolio-service/internal/repository"
+	"bookman/portfolio-service/internal/services"
+)
+
+// Define drift monitor metrics
+var (
+	breachedPortfolios = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "portfolio_allocation_drift_breached_portfolios",
+			Help: "Number of portfolios whose allocation drifted beyond the threshold at the last pass",
+		},
+	)
+
+	driftAlerts = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "portfolio_allocation_drift_alerts_total",
+			Help: "Total number of allocation drift alerts raised",
+		},
+	)
+)
+
+func init() {
+	metrics.MustRegister(breachedPortfolios)
+	metrics.MustRegister(driftAlerts)
+}
+
+// Monitor checks allocation drift of portfolios with targets on a fixed interval
+type Monitor struct {
+	repo      *repository.PostgresRepository
+	svc       *services.PortfolioService
+	notifier  *notifications.Dispatcher
+	cfg       config.DriftConfig
+	threshold decimal.Decimal
+	logger    *zap.Logger
+}
+
+// NewMonitor creates a new allocation drift alerting job. The notifier may be
+// nil, in which case alerts are only logged and counted.
+func NewMonitor(repo *repository.PostgresRepository, svc *services.PortfolioService, notifier *notifications.Dispatcher, cfg config.DriftConfig, logger *zap.Logger) (*Monitor, error) {
+	if repo == nil || svc == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	return &Monitor{
+		repo:      repo,
+		svc:       svc,
+		notifier:  notifier,
+		cfg:       cfg,
+		threshold: decimal.NewFromFloat(cfg.Threshold),
+		logger:    logger.With(zap.String("component", "drift_monitor")),
+	}, nil
+}
+
+// Run checks drift at each interval until ctx is cancelled
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := m.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			m.logger.Error("Drift pass failed", zap.Error(err))
+		}
+	}
+}
+
+// RunOnce computes drift for every portfolio with targets and raises an alert
+// for each one beyond the threshold
+func (m *Monitor) RunOnce(ctx context.Context) error {
+	var (
+		after    uuid.UUID
+		breached int
+		failed   int
+	)
+
+	for {
+		ids, err := m.repo.ListPortfoliosWithTargets(ctx, after, m.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list portfolios with targets: %w", err)
+		}
+
+		for _, id := range ids {
+			drift, err := m.svc.GetDrift(ctx, id, m.threshold)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				failed++
+				m.logger.Warn("Failed to compute allocation drift",
+					zap.Error(err),
+					zap.String("portfolio_id", id.String()),
+				)
+				continue
+			}
+			if !drift.Breached {
+				continue
+			}
+
+			breached++
+			driftAlerts.Inc()
+
+			keys := make([]string, 0, len(drift.Entries))
+			for _, entry := range drift.Entries {
+				if entry.Breached {
+					keys = append(keys, string(entry.Dimension)+":"+entry.Key)
+				}
+			}
+			m.logger.Warn("Allocation drifted beyond threshold",
+				zap.String("portfolio_id", id.String()),
+				zap.Strings("breached", keys),
+				zap.String("threshold", drift.Threshold.String()),
+			)
+
+			m.notifier.Notify(models.Notification{
+				Event:       models.EventDriftBreached,
+				PortfolioID: id,
+				Subject:     "Portfolio allocation drifted from targets",
+				Body: fmt.Sprintf("Allocations of %s deviate from their targets by more than %s percentage points.",
+					strings.Join(keys, ", "), drift.Threshold),
+				Attributes: map[string]string{
+					"breached":  strings.Join(keys, ","),
+					"threshold": drift.Threshold.String(),
+				},
+				CreatedAt: drift.CalculatedAt,
+			})
+		}
+
+		if len(ids) < m.cfg.BatchSize {
+			break
+		}
+		after = ids[len(ids)-1]
+	}
+
+	breachedPortfolios.Set(float64(breached))
+
+	if failed > 0 {
+		return fmt.Errorf("%d portfolios failed drift checks", failed)
+	}
+	return nil
+}
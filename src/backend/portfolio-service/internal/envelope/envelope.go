@@ -0,0 +1,346 @@
+// Package envelope encrypts sensitive fields for storage. Each value is
+// encrypted with AES-256-GCM under its own random data key, which is stored
+// alongside the ciphertext wrapped by a key encryption key held in a key
+// management service. Rotating the key encryption key only rewraps data keys;
+// the encrypted values themselves are never re-encrypted.
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+
+	"bookman/portfolio-service/internal/metrics"
+)
+
+const (
+	// magic prefixes sealed values, telling them apart from legacy formats
+	magic = "env1"
+
+	// textPrefix prefixes sealed values stored in text columns
+	textPrefix = magic + ":"
+
+	// dataKeySize is the size of the AES-256 data keys
+	dataKeySize = 32
+
+	// nonceSize is the standard GCM nonce size
+	nonceSize = 12
+)
+
+var (
+	// ErrUndecryptable is returned when a sealed value is malformed, was
+	// sealed for other additional data or its data key was not wrapped by the
+	// key encryption key it names
+	ErrUndecryptable = errors.New("sealed value cannot be decrypted")
+
+	// ErrUnknownKey is returned when a value is sealed under a key encryption
+	// key that is not configured
+	ErrUnknownKey = errors.New("sealed value uses an unknown key encryption key")
+)
+
+var operations = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "portfolio_envelope_operations_total",
+		Help: "Total number of envelope encryption operations by operation and status",
+	},
+	[]string{"operation", "status"},
+)
+
+func init() {
+	metrics.MustRegister(operations)
+}
+
+// KeyEncryptionKey wraps the data keys of sealed values
+type KeyEncryptionKey interface {
+	// ID identifies the key in sealed values; it must not reveal key material
+	ID() string
+	// Wrap encrypts a data key
+	Wrap(ctx context.Context, dataKey []byte) ([]byte, error)
+	// Unwrap decrypts a data key wrapped by Wrap, failing with
+	// ErrUndecryptable when it was not
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// rewrapper is implemented by key encryption keys that rotate their own
+// versions, such as Vault transit keys, and can rewrap a data key under their
+// latest version without exposing it
+type rewrapper interface {
+	Rewrap(ctx context.Context, wrapped []byte) ([]byte, bool, error)
+}
+
+// Encryptor seals values under the current key encryption key and opens
+// values sealed under any configured key
+type Encryptor struct {
+	current KeyEncryptionKey
+	keys    map[string]KeyEncryptionKey
+}
+
+// NewEncryptor creates an encryptor sealing with current. Previous keys are
+// used to open values sealed before a rotation until they are rewrapped.
+func NewEncryptor(current KeyEncryptionKey, previous ...KeyEncryptionKey) (*Encryptor, error) {
+	if current == nil {
+		return nil, errors.New("a key encryption key is required")
+	}
+
+	e := &Encryptor{current: current, keys: make(map[string]KeyEncryptionKey)}
+	for _, kek := range append([]KeyEncryptionKey{current}, previous...) {
+		id := kek.ID()
+		if id == "" || len(id) > 255 {
+			return nil, fmt.Errorf("invalid key encryption key ID %q", id)
+		}
+		if _, ok := e.keys[id]; ok {
+			return nil, fmt.Errorf("duplicate key encryption key %q", id)
+		}
+		e.keys[id] = kek
+	}
+
+	return e, nil
+}
+
+// Seal encrypts plaintext under a new data key. The additional data binds the
+// value to where it is stored and must be given again to open it.
+func (e *Encryptor) Seal(ctx context.Context, plaintext, additionalData []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	defer clear(dataKey)
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	wrapped, err := e.current.Wrap(ctx, dataKey)
+	if err != nil {
+		operations.WithLabelValues("seal", "error").Inc()
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	operations.WithLabelValues("seal", "success").Inc()
+
+	return sealedValue{
+		keyID:      e.current.ID(),
+		wrapped:    wrapped,
+		nonce:      nonce,
+		ciphertext: aead.Seal(nil, nonce, plaintext, additionalData),
+	}.marshal()
+}
+
+// Open decrypts a value sealed with the same additional data
+func (e *Encryptor) Open(ctx context.Context, sealed, additionalData []byte) ([]byte, error) {
+	v, err := parse(sealed)
+	if err != nil {
+		return nil, err
+	}
+	kek, ok := e.keys[v.keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, v.keyID)
+	}
+
+	dataKey, err := kek.Unwrap(ctx, v.wrapped)
+	if err != nil {
+		operations.WithLabelValues("open", "error").Inc()
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	defer clear(dataKey)
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, ErrUndecryptable
+	}
+	plaintext, err := aead.Open(nil, v.nonce, v.ciphertext, additionalData)
+	if err != nil {
+		operations.WithLabelValues("open", "error").Inc()
+		return nil, ErrUndecryptable
+	}
+	operations.WithLabelValues("open", "success").Inc()
+
+	return plaintext, nil
+}
+
+// Rewrap rewraps the data key of a sealed value under the current key
+// encryption key, or its latest version, and reports whether the value
+// changed. The value itself is not decrypted.
+func (e *Encryptor) Rewrap(ctx context.Context, sealed []byte) ([]byte, bool, error) {
+	v, err := parse(sealed)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if v.keyID == e.current.ID() {
+		r, ok := e.current.(rewrapper)
+		if !ok {
+			return sealed, false, nil
+		}
+		wrapped, changed, err := r.Rewrap(ctx, v.wrapped)
+		if err != nil {
+			operations.WithLabelValues("rewrap", "error").Inc()
+			return nil, false, fmt.Errorf("failed to rewrap data key: %w", err)
+		}
+		if !changed {
+			return sealed, false, nil
+		}
+		v.wrapped = wrapped
+	} else {
+		kek, ok := e.keys[v.keyID]
+		if !ok {
+			return nil, false, fmt.Errorf("%w: %s", ErrUnknownKey, v.keyID)
+		}
+		dataKey, err := kek.Unwrap(ctx, v.wrapped)
+		if err != nil {
+			operations.WithLabelValues("rewrap", "error").Inc()
+			return nil, false, fmt.Errorf("failed to unwrap data key: %w", err)
+		}
+		v.wrapped, err = e.current.Wrap(ctx, dataKey)
+		clear(dataKey)
+		if err != nil {
+			operations.WithLabelValues("rewrap", "error").Inc()
+			return nil, false, fmt.Errorf("failed to wrap data key: %w", err)
+		}
+		v.keyID = e.current.ID()
+	}
+	operations.WithLabelValues("rewrap", "success").Inc()
+
+	rewrapped, err := v.marshal()
+	if err != nil {
+		return nil, false, err
+	}
+	return rewrapped, true, nil
+}
+
+// SealString seals a text value for storage in a text column
+func (e *Encryptor) SealString(ctx context.Context, plaintext string, additionalData []byte) (string, error) {
+	sealed, err := e.Seal(ctx, []byte(plaintext), additionalData)
+	if err != nil {
+		return "", err
+	}
+	return textPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenString decrypts a text value sealed by SealString
+func (e *Encryptor) OpenString(ctx context.Context, sealed string, additionalData []byte) (string, error) {
+	data, err := decodeText(sealed)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := e.Open(ctx, data, additionalData)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// RewrapString rewraps a text value sealed by SealString, reporting whether
+// it changed
+func (e *Encryptor) RewrapString(ctx context.Context, sealed string) (string, bool, error) {
+	data, err := decodeText(sealed)
+	if err != nil {
+		return "", false, err
+	}
+	rewrapped, changed, err := e.Rewrap(ctx, data)
+	if err != nil || !changed {
+		return sealed, false, err
+	}
+	return textPrefix + base64.RawStdEncoding.EncodeToString(rewrapped), true, nil
+}
+
+// IsSealed reports whether data is a sealed value rather than a value stored
+// before envelope encryption was enabled
+func IsSealed(data []byte) bool {
+	return len(data) > len(magic) && string(data[:len(magic)]) == magic
+}
+
+// IsSealedString reports whether a text value was sealed by SealString
+func IsSealedString(s string) bool {
+	return strings.HasPrefix(s, textPrefix)
+}
+
+// decodeText returns the sealed value of a text value
+func decodeText(s string) ([]byte, error) {
+	if !IsSealedString(s) {
+		return nil, ErrUndecryptable
+	}
+	data, err := base64.RawStdEncoding.DecodeString(s[len(textPrefix):])
+	if err != nil {
+		return nil, ErrUndecryptable
+	}
+	return data, nil
+}
+
+// sealedValue is the stored form of a value: the magic prefix, the key
+// encryption key ID and wrapped data key, each length-prefixed, then the
+// nonce and the ciphertext with its authentication tag
+type sealedValue struct {
+	keyID      string
+	wrapped    []byte
+	nonce      []byte
+	ciphertext []byte
+}
+
+// marshal encodes the value for storage
+func (v sealedValue) marshal() ([]byte, error) {
+	if len(v.keyID) > 255 || len(v.wrapped) > 0xffff {
+		return nil, errors.New("wrapped data key is too large")
+	}
+
+	out := make([]byte, 0, len(magic)+1+len(v.keyID)+2+len(v.wrapped)+len(v.nonce)+len(v.ciphertext))
+	out = append(out, magic...)
+	out = append(out, byte(len(v.keyID)))
+	out = append(out, v.keyID...)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(v.wrapped)))
+	out = append(out, v.wrapped...)
+	out = append(out, v.nonce...)
+	return append(out, v.ciphertext...), nil
+}
+
+// parse decodes a stored value
+func parse(data []byte) (sealedValue, error) {
+	if !IsSealed(data) {
+		return sealedValue{}, ErrUndecryptable
+	}
+	rest := data[len(magic):]
+
+	var v sealedValue
+	n := int(rest[0])
+	rest = rest[1:]
+	if len(rest) < n+2 {
+		return sealedValue{}, ErrUndecryptable
+	}
+	v.keyID, rest = string(rest[:n]), rest[n:]
+
+	n = int(binary.BigEndian.Uint16(rest))
+	rest = rest[2:]
+	if len(rest) < n+nonceSize {
+		return sealedValue{}, ErrUndecryptable
+	}
+	v.wrapped, rest = rest[:n], rest[n:]
+	v.nonce, v.ciphertext = rest[:nonceSize], rest[nonceSize:]
+
+	return v, nil
+}
+
+// newAEAD creates an AES-GCM cipher from a key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AEAD: %w", err)
+	}
+	return aead, nil
+}
@@ -0,0 +1,70 @@
+package envelope
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// MinLocalKeyLength is the shortest accepted local key secret in bytes
+const MinLocalKeyLength = 32
+
+// localWrapScope is the additional data of wrapped data keys, so local key
+// ciphertexts cannot be confused with other values sealed under the secret
+var localWrapScope = []byte("envelope-data-key")
+
+// LocalKey is a key encryption key derived from a secret held by the service,
+// for deployments without a key management service
+type LocalKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewLocalKey derives a key encryption key from a secret. Its ID is a digest
+// of the derived key, so values record which secret they were sealed under
+// without revealing it.
+func NewLocalKey(secret []byte) (*LocalKey, error) {
+	if len(secret) < MinLocalKeyLength {
+		return nil, fmt.Errorf("local key is shorter than %d bytes", MinLocalKeyLength)
+	}
+
+	key := sha256.Sum256(secret)
+	aead, err := newAEAD(key[:])
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(key[:])
+
+	return &LocalKey{id: "local:" + hex.EncodeToString(digest[:8]), aead: aead}, nil
+}
+
+// ID identifies the key by a digest of its key material
+func (k *LocalKey) ID() string {
+	return k.id
+}
+
+// Wrap encrypts a data key with AES-GCM
+func (k *LocalKey) Wrap(_ context.Context, dataKey []byte) ([]byte, error) {
+	nonce := make([]byte, k.aead.NonceSize(), k.aead.NonceSize()+len(dataKey)+k.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return k.aead.Seal(nonce, nonce, dataKey, localWrapScope), nil
+}
+
+// Unwrap decrypts a data key wrapped by Wrap
+func (k *LocalKey) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < k.aead.NonceSize() {
+		return nil, ErrUndecryptable
+	}
+	nonce, ciphertext := wrapped[:k.aead.NonceSize()], wrapped[k.aead.NonceSize():]
+	dataKey, err := k.aead.Open(nil, nonce, ciphertext, localWrapScope)
+	if err != nil {
+		return nil, ErrUndecryptable
+	}
+	return dataKey, nil
+}
@@ -0,0 +1,166 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxVaultResponseSize bounds the Vault responses read
+const maxVaultResponseSize = 1 << 16
+
+// VaultTransitKey is a key encryption key held by the transit secrets engine
+// of HashiCorp Vault. Data keys are wrapped and unwrapped by Vault, so the key
+// never leaves it; rotating the transit key in Vault creates a new version
+// that Rewrap moves data keys to.
+type VaultTransitKey struct {
+	client  *http.Client
+	address string
+	mount   string
+	name    string
+	token   string
+}
+
+// NewVaultTransitKey creates a key encryption key for a transit key of the
+// Vault at address, authenticating with token
+func NewVaultTransitKey(client *http.Client, address, mount, name, token string) (*VaultTransitKey, error) {
+	if client == nil {
+		return nil, errors.New("an HTTP client is required")
+	}
+	u, err := url.Parse(address)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Vault address %q", address)
+	}
+	if mount == "" || name == "" || strings.ContainsAny(mount+name, "?#") {
+		return nil, errors.New("a Vault transit mount and key name are required")
+	}
+	if token == "" {
+		return nil, errors.New("a Vault token is required")
+	}
+
+	return &VaultTransitKey{
+		client:  client,
+		address: strings.TrimRight(address, "/"),
+		mount:   strings.Trim(mount, "/"),
+		name:    name,
+		token:   token,
+	}, nil
+}
+
+// ID identifies the key by its mount and name; Vault records the key version
+// in the wrapped data key itself
+func (k *VaultTransitKey) ID() string {
+	return "vault:" + k.mount + "/" + k.name
+}
+
+// Wrap encrypts a data key with the latest version of the transit key
+func (k *VaultTransitKey) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	var resp struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	err := k.call(ctx, "encrypt", map[string]string{"plaintext": base64.StdEncoding.EncodeToString(dataKey)}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Ciphertext == "" {
+		return nil, errors.New("vault returned no ciphertext")
+	}
+	return []byte(resp.Ciphertext), nil
+}
+
+// Unwrap decrypts a data key wrapped by any version of the transit key
+func (k *VaultTransitKey) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Plaintext string `json:"plaintext"`
+	}
+	if err := k.call(ctx, "decrypt", map[string]string{"ciphertext": string(wrapped)}, &resp); err != nil {
+		return nil, err
+	}
+	dataKey, err := base64.StdEncoding.DecodeString(resp.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("vault returned a malformed plaintext: %w", err)
+	}
+	return dataKey, nil
+}
+
+// Rewrap moves a wrapped data key to the latest version of the transit key
+// inside Vault and reports whether its version changed
+func (k *VaultTransitKey) Rewrap(ctx context.Context, wrapped []byte) ([]byte, bool, error) {
+	var resp struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	if err := k.call(ctx, "rewrap", map[string]string{"ciphertext": string(wrapped)}, &resp); err != nil {
+		return nil, false, err
+	}
+	if resp.Ciphertext == "" {
+		return nil, false, errors.New("vault returned no ciphertext")
+	}
+	if vaultKeyVersion(resp.Ciphertext) == vaultKeyVersion(string(wrapped)) {
+		return wrapped, false, nil
+	}
+	return []byte(resp.Ciphertext), true, nil
+}
+
+// call posts a request to a transit endpoint of the key and decodes the data
+// of the response
+func (k *VaultTransitKey) call(ctx context.Context, operation string, body map[string]string, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/%s/%s/%s", k.address, k.mount, operation, url.PathEscape(k.name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("invalid Vault request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", k.token)
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("vault %s failed: %w", operation, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxVaultResponseSize))
+	if err != nil {
+		return fmt.Errorf("failed to read Vault response: %w", err)
+	}
+	if resp.StatusCode == http.StatusBadRequest && operation != "encrypt" {
+		// Vault rejects ciphertexts it did not produce with a client error
+		return fmt.Errorf("%w: vault %s rejected the wrapped data key", ErrUndecryptable, operation)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// Vault error bodies never echo the submitted plaintext
+		return fmt.Errorf("vault %s returned status %d: %s", operation, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	result := struct {
+		Data json.RawMessage `json:"data"`
+	}{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("failed to decode Vault response: %w", err)
+	}
+	if err := json.Unmarshal(result.Data, out); err != nil {
+		return fmt.Errorf("failed to decode Vault response: %w", err)
+	}
+	return nil
+}
+
+// vaultKeyVersion returns the key version prefix of a transit ciphertext,
+// such as "vault:v2"
+func vaultKeyVersion(ciphertext string) string {
+	parts := strings.SplitN(ciphertext, ":", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[0] + ":" + parts[1]
+}
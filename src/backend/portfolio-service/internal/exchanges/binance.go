@@ -0,0 +1,426 @@
+package exchanges
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/models"
+)
+
+const (
+	// binanceTradePageSize is the maximum page size of the trade list endpoint
+	binanceTradePageSize = 1000
+
+	// binanceRate and binanceBurst keep request weight well below the IP limit
+	// of 6000 per minute; trade list pages weigh 20
+	binanceRate  = 4
+	binanceBurst = 20
+
+	// binanceDustAsset is the asset small balances are converted into
+	binanceDustAsset = "BNB"
+
+	// binancePricingQuote is the pair quote used to price assets in USD
+	binancePricingQuote = "USDT"
+)
+
+// binanceAuthErrors are API error codes returned for rejected keys or signatures
+var binanceAuthErrors = map[int]bool{
+	-1022: true, // invalid signature
+	-2014: true, // API key format invalid
+	-2015: true, // invalid API key, IP or permissions
+}
+
+// binance reads a Binance spot account with a read-only API key
+type binance struct {
+	cfg    config.BinanceConfig
+	creds  Credentials
+	client *Client
+	quotes map[string]bool
+	prices map[string]decimal.Decimal
+}
+
+// binanceCursor records where the previous import stopped: the last trade ID
+// per symbol and the time of the last dust conversion in milliseconds
+type binanceCursor struct {
+	Trades map[string]int64 `json:"trades"`
+	Dust   int64            `json:"dust"`
+}
+
+// binancePair is a tradable symbol of the exchange
+type binancePair struct {
+	Symbol     string `json:"symbol"`
+	BaseAsset  string `json:"baseAsset"`
+	QuoteAsset string `json:"quoteAsset"`
+}
+
+// binanceTrade is an entry of the account trade list
+type binanceTrade struct {
+	Symbol          string          `json:"symbol"`
+	ID              int64           `json:"id"`
+	Price           decimal.Decimal `json:"price"`
+	Qty             decimal.Decimal `json:"qty"`
+	QuoteQty        decimal.Decimal `json:"quoteQty"`
+	Commission      decimal.Decimal `json:"commission"`
+	CommissionAsset string          `json:"commissionAsset"`
+	Time            int64           `json:"time"`
+	IsBuyer         bool            `json:"isBuyer"`
+}
+
+// binanceDustLog is the response of the dust conversion history endpoint
+type binanceDustLog struct {
+	UserAssetDribblets []struct {
+		Details []struct {
+			TransID             int64           `json:"transId"`
+			FromAsset           string          `json:"fromAsset"`
+			Amount              decimal.Decimal `json:"amount"`
+			TransferedAmount    decimal.Decimal `json:"transferedAmount"`
+			ServiceChargeAmount decimal.Decimal `json:"serviceChargeAmount"`
+			OperateTime         int64           `json:"operateTime"`
+		} `json:"userAssetDribbletDetails"`
+	} `json:"userAssetDribblets"`
+}
+
+// Binance describes the Binance spot connector. Its rate limit is per IP
+// address and so shared by all accounts.
+func Binance(cfg config.BinanceConfig) Exchange {
+	quotes := make(map[string]bool, len(cfg.QuoteAssets))
+	for _, asset := range cfg.QuoteAssets {
+		quotes[strings.ToUpper(asset)] = true
+	}
+
+	return Exchange{
+		Name:  "binance",
+		Rate:  binanceRate,
+		Burst: binanceBurst,
+		New: func(creds Credentials, client *Client) (Connector, error) {
+			return &binance{
+				cfg:    cfg,
+				creds:  creds,
+				client: client,
+				quotes: quotes,
+				prices: make(map[string]decimal.Decimal),
+			}, nil
+		},
+	}
+}
+
+// Authenticate checks the API key can read the account and can neither trade
+// nor withdraw
+func (b *binance) Authenticate(ctx context.Context) error {
+	var restrictions struct {
+		EnableReading              bool `json:"enableReading"`
+		EnableSpotAndMarginTrading bool `json:"enableSpotAndMarginTrading"`
+		EnableWithdrawals          bool `json:"enableWithdrawals"`
+		EnableInternalTransfer     bool `json:"enableInternalTransfer"`
+		EnableMargin               bool `json:"enableMargin"`
+		EnableFutures              bool `json:"enableFutures"`
+	}
+	if err := b.get(ctx, "/sapi/v1/account/apiRestrictions", url.Values{}, true, &restrictions); err != nil {
+		return err
+	}
+
+	if !restrictions.EnableReading {
+		return ErrInvalidCredentials
+	}
+	if restrictions.EnableSpotAndMarginTrading || restrictions.EnableWithdrawals || restrictions.EnableInternalTransfer ||
+		restrictions.EnableMargin || restrictions.EnableFutures {
+		return ErrCredentialPermissions
+	}
+	return nil
+}
+
+// FetchBalances returns the non-zero spot balances of the account
+func (b *binance) FetchBalances(ctx context.Context) ([]models.ExchangeBalance, error) {
+	var account struct {
+		Balances []models.ExchangeBalance `json:"balances"`
+	}
+	params := url.Values{"omitZeroBalances": {"true"}}
+	if err := b.get(ctx, "/api/v3/account", params, true, &account); err != nil {
+		return nil, err
+	}
+
+	balances := make([]models.ExchangeBalance, 0, len(account.Balances))
+	for _, balance := range account.Balances {
+		if balance.Total().IsPositive() {
+			balances = append(balances, balance)
+		}
+	}
+	return balances, nil
+}
+
+// FetchTrades returns trades and dust conversions after the cursor. Trade
+// history is per symbol, so it is fetched for pairs of currently held assets
+// against the configured quote assets and for every symbol traded before.
+func (b *binance) FetchTrades(ctx context.Context, cursor string) (models.ExchangeActivity, string, error) {
+	var activity models.ExchangeActivity
+
+	cur, err := decodeBinanceCursor(cursor)
+	if err != nil {
+		return activity, "", err
+	}
+
+	balances, err := b.FetchBalances(ctx)
+	if err != nil {
+		return activity, "", err
+	}
+	held := make(map[string]bool, len(balances))
+	for _, balance := range balances {
+		held[strings.ToUpper(balance.Asset)] = true
+	}
+
+	pairs, err := b.pairs(ctx, held, cur)
+	if err != nil {
+		return activity, "", err
+	}
+
+	for _, pair := range pairs {
+		fills, last, err := b.trades(ctx, pair, cur.Trades[pair.Symbol])
+		if err != nil {
+			return activity, "", err
+		}
+		activity.Fills = append(activity.Fills, fills...)
+		if last > 0 {
+			cur.Trades[pair.Symbol] = last
+		}
+	}
+
+	conversions, last, err := b.dust(ctx, cur.Dust)
+	if err != nil {
+		return activity, "", err
+	}
+	activity.Conversions = conversions
+	cur.Dust = last
+
+	next, err := encodeBinanceCursor(cur)
+	if err != nil {
+		return activity, "", err
+	}
+	return activity, next, nil
+}
+
+// PriceUSD returns the close of the USDT pair's one-minute candle at a time
+func (b *binance) PriceUSD(ctx context.Context, asset string, at time.Time) (decimal.Decimal, error) {
+	asset = strings.ToUpper(asset)
+	if asset == binancePricingQuote {
+		return decimal.NewFromInt(1), nil
+	}
+
+	minute := at.UTC().Truncate(time.Minute)
+	key := asset + "@" + strconv.FormatInt(minute.Unix(), 10)
+	if price, ok := b.prices[key]; ok {
+		return price, nil
+	}
+
+	var candles [][]json.RawMessage
+	params := url.Values{
+		"symbol":    {asset + binancePricingQuote},
+		"interval":  {"1m"},
+		"startTime": {strconv.FormatInt(minute.UnixMilli(), 10)},
+		"limit":     {"1"},
+	}
+	if err := b.get(ctx, "/api/v3/klines", params, false, &candles); err != nil {
+		return decimal.Zero, err
+	}
+	if len(candles) == 0 || len(candles[0]) < 5 {
+		return decimal.Zero, fmt.Errorf("no %s%s candle at %s", asset, binancePricingQuote, minute.Format(time.RFC3339))
+	}
+
+	var price decimal.Decimal
+	if err := json.Unmarshal(candles[0][4], &price); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to decode %s close price: %w", asset, err)
+	}
+	b.prices[key] = price
+	return price, nil
+}
+
+// pairs returns the symbols to fetch trades for
+func (b *binance) pairs(ctx context.Context, held map[string]bool, cur *binanceCursor) ([]binancePair, error) {
+	var info struct {
+		Symbols []binancePair `json:"symbols"`
+	}
+	if err := b.get(ctx, "/api/v3/exchangeInfo", url.Values{"permissions": {"SPOT"}}, false, &info); err != nil {
+		return nil, err
+	}
+
+	var pairs []binancePair
+	for _, pair := range info.Symbols {
+		_, synced := cur.Trades[pair.Symbol]
+		if synced || (held[pair.BaseAsset] && b.quotes[pair.QuoteAsset]) {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs, nil
+}
+
+// trades pages through the symbol's trades after lastID and returns them with
+// the ID of the newest trade
+func (b *binance) trades(ctx context.Context, pair binancePair, lastID int64) ([]models.ExchangeFill, int64, error) {
+	var fills []models.ExchangeFill
+	for {
+		var page []binanceTrade
+		params := url.Values{
+			"symbol": {pair.Symbol},
+			"fromId": {strconv.FormatInt(lastID+1, 10)},
+			"limit":  {strconv.Itoa(binanceTradePageSize)},
+		}
+		if err := b.get(ctx, "/api/v3/myTrades", params, true, &page); err != nil {
+			return nil, 0, err
+		}
+
+		for _, trade := range page {
+			side := models.ExchangeSell
+			if trade.IsBuyer {
+				side = models.ExchangeBuy
+			}
+			fills = append(fills, models.ExchangeFill{
+				ID:              pair.Symbol + ":" + strconv.FormatInt(trade.ID, 10),
+				Base:            pair.BaseAsset,
+				Quote:           pair.QuoteAsset,
+				Side:            side,
+				Quantity:        trade.Qty,
+				Price:           trade.Price,
+				QuoteQuantity:   trade.QuoteQty,
+				Commission:      trade.Commission,
+				CommissionAsset: trade.CommissionAsset,
+				ExecutedAt:      time.UnixMilli(trade.Time).UTC(),
+			})
+			if trade.ID > lastID {
+				lastID = trade.ID
+			}
+		}
+
+		if len(page) < binanceTradePageSize {
+			return fills, lastID, nil
+		}
+	}
+}
+
+// dust returns the conversions of small balances into BNB after the given
+// time in milliseconds and the time of the newest one
+func (b *binance) dust(ctx context.Context, after int64) ([]models.ExchangeConversion, int64, error) {
+	params := url.Values{}
+	if after > 0 {
+		params.Set("startTime", strconv.FormatInt(after+1, 10))
+	}
+
+	var log binanceDustLog
+	if err := b.get(ctx, "/sapi/v1/asset/dribblet", params, true, &log); err != nil {
+		return nil, 0, err
+	}
+
+	var conversions []models.ExchangeConversion
+	for _, dribblet := range log.UserAssetDribblets {
+		for _, detail := range dribblet.Details {
+			if detail.OperateTime <= after {
+				continue
+			}
+			conversions = append(conversions, models.ExchangeConversion{
+				ID:         "dust:" + strconv.FormatInt(detail.TransID, 10),
+				FromAsset:  detail.FromAsset,
+				FromAmount: detail.Amount,
+				ToAsset:    binanceDustAsset,
+				ToAmount:   detail.TransferedAmount,
+				Fee:        detail.ServiceChargeAmount,
+				ExecutedAt: time.UnixMilli(detail.OperateTime).UTC(),
+			})
+			if detail.OperateTime > after {
+				after = detail.OperateTime
+			}
+		}
+	}
+	return conversions, after, nil
+}
+
+// get calls a GET endpoint and decodes the JSON response into out. Signed
+// requests carry the API key header and an HMAC-SHA256 signature of the query.
+func (b *binance) get(ctx context.Context, path string, params url.Values, signed bool, out interface{}) error {
+	build := func() (*http.Request, error) {
+		if signed {
+			params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+			params.Set("recvWindow", strconv.FormatInt(b.cfg.RecvWindow.Milliseconds(), 10))
+		}
+		query := params.Encode()
+		if signed {
+			mac := hmac.New(sha256.New, []byte(b.creds.APISecret))
+			mac.Write([]byte(query))
+			query += "&signature=" + hex.EncodeToString(mac.Sum(nil))
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(b.cfg.BaseURL, "/")+path+"?"+query, nil)
+		if err != nil {
+			return nil, err
+		}
+		if signed {
+			req.Header.Set("X-MBX-APIKEY", b.creds.APIKey)
+		}
+		return req, nil
+	}
+
+	return b.client.Do(ctx, build, func(resp *Response) error {
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot:
+			// 418 means the IP was banned for ignoring earlier 429 responses
+			return fmt.Errorf("%w: binance %s returned status %d", ErrRateLimited, path, resp.StatusCode)
+		case resp.StatusCode >= 500:
+			return fmt.Errorf("%w: binance %s returned status %d", ErrTemporary, path, resp.StatusCode)
+		case resp.StatusCode >= 300:
+			var apiErr struct {
+				Code int    `json:"code"`
+				Msg  string `json:"msg"`
+			}
+			_ = json.Unmarshal(resp.Body, &apiErr)
+			if resp.StatusCode == http.StatusUnauthorized || binanceAuthErrors[apiErr.Code] {
+				return ErrInvalidCredentials
+			}
+			return fmt.Errorf("binance %s returned status %d: %d %s", path, resp.StatusCode, apiErr.Code, apiErr.Msg)
+		}
+
+		if err := json.Unmarshal(resp.Body, out); err != nil {
+			return fmt.Errorf("failed to decode binance %s response: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// decodeBinanceCursor parses an import cursor; empty cursors start from the
+// beginning of the account history
+func decodeBinanceCursor(cursor string) (*binanceCursor, error) {
+	cur := &binanceCursor{Trades: make(map[string]int64)}
+	if cursor == "" {
+		return cur, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, cur); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cur.Trades == nil {
+		cur.Trades = make(map[string]int64)
+	}
+	return cur, nil
+}
+
+// encodeBinanceCursor renders an opaque import cursor
+func encodeBinanceCursor(cur *binanceCursor) (string, error) {
+	raw, err := json.Marshal(cur)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode binance cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
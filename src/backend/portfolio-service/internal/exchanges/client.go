@@ -0,0 +1,228 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+
+	"bookman/portfolio-service/internal/metrics"
+)
+
+// maxResponseSize bounds exchange response bodies
+const maxResponseSize = 32 << 20
+
+var exchangeRequests = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "portfolio_exchange_requests_total",
+		Help: "Total number of exchange API requests by exchange and outcome",
+	},
+	[]string{"exchange", "outcome"},
+)
+
+func init() {
+	metrics.MustRegister(exchangeRequests)
+}
+
+// RetryPolicy controls how failed exchange requests are retried
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first
+	MaxAttempts int
+	// BaseDelay is doubled after each attempt up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// backoff returns the delay before the given retry, starting at 1
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := time.Duration(float64(p.BaseDelay) * math.Pow(2, float64(retry-1)))
+	if delay > p.MaxDelay || delay <= 0 {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// Response is an exchange API response with its body read
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Client sends a connector's requests to its exchange. Requests wait for the
+// exchange's shared rate limit and are retried with exponential backoff when
+// throttled, failing temporarily or not reaching the exchange.
+type Client struct {
+	name    string
+	http    *http.Client
+	limiter *limiter
+	retry   RetryPolicy
+}
+
+// NewClient creates a client with a rate limit of its own, for APIs read
+// outside the exchange registry such as blockchain nodes and indexers
+func NewClient(name string, httpClient *http.Client, rate float64, burst int, retry RetryPolicy) *Client {
+	return &Client{
+		name:    name,
+		http:    httpClient,
+		limiter: newLimiter(rate, burst),
+		retry:   retry,
+	}
+}
+
+// Do sends the request built by build and passes the response to check, whose
+// error is returned. build runs for every attempt so signed requests carry a
+// fresh timestamp or nonce. Attempts are retried when the transport fails or
+// check returns ErrRateLimited or ErrTemporary; a Retry-After header on a
+// throttled response pauses all requests sharing the rate limit.
+func (c *Client) Do(ctx context.Context, build func() (*http.Request, error), check func(*Response) error) error {
+	for attempt := 1; ; attempt++ {
+		if err := c.limiter.wait(ctx); err != nil {
+			return err
+		}
+
+		req, err := build()
+		if err != nil {
+			return fmt.Errorf("failed to build %s request: %w", c.name, err)
+		}
+
+		var resp *Response
+		resp, err = c.send(ctx, req)
+		if err == nil {
+			err = check(resp)
+		}
+		if err == nil {
+			exchangeRequests.WithLabelValues(c.name, "success").Inc()
+			return nil
+		}
+
+		retryable := !errors.Is(err, ErrInvalidCredentials) &&
+			(errors.Is(err, ErrRateLimited) || errors.Is(err, ErrTemporary) || resp == nil)
+		if !retryable || ctx.Err() != nil {
+			exchangeRequests.WithLabelValues(c.name, "error").Inc()
+			return err
+		}
+		if attempt >= c.retry.MaxAttempts {
+			exchangeRequests.WithLabelValues(c.name, "exhausted").Inc()
+			return err
+		}
+		exchangeRequests.WithLabelValues(c.name, "retry").Inc()
+
+		delay := c.retry.backoff(attempt)
+		if errors.Is(err, ErrRateLimited) && resp != nil {
+			if wait := retryAfter(resp.Header); wait > 0 {
+				c.limiter.pause(wait)
+				if wait > delay {
+					delay = wait
+				}
+			}
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// send performs one attempt; a nil response means the exchange was not reached
+func (c *Client) send(ctx context.Context, req *http.Request) (*Response, error) {
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("%s request failed: %w", c.name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", c.name, err)
+	}
+	return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}, nil
+}
+
+// retryAfter parses a Retry-After header given in seconds
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// limiter is a token bucket shared by all connectors of an exchange or API key
+type limiter struct {
+	mutex       sync.Mutex
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// newLimiter creates a full bucket refilled at rate tokens per second
+func newLimiter(rate float64, burst int) *limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &limiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait blocks until a request may be sent
+func (l *limiter) wait(ctx context.Context) error {
+	for {
+		l.mutex.Lock()
+		now := time.Now()
+		if now.Before(l.pausedUntil) {
+			delay := l.pausedUntil.Sub(now)
+			l.mutex.Unlock()
+			if err := sleep(ctx, delay); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if l.rate <= 0 {
+			l.mutex.Unlock()
+			return nil
+		}
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mutex.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mutex.Unlock()
+
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// pause holds back all requests for d, as asked by a throttled response
+func (l *limiter) pause(d time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// sleep waits for d or until ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,171 @@
+package exchanges
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid" // v1.3.0
+
+	"bookman/portfolio-service/internal/envelope"
+)
+
+// MinCredentialKeyLength is the shortest accepted credential key in bytes
+const MinCredentialKeyLength = 32
+
+var (
+	// ErrUndecryptableCredentials is returned when stored credentials cannot
+	// be opened with any configured key or belong to another account
+	ErrUndecryptableCredentials = errors.New("stored exchange credentials cannot be decrypted")
+
+	// ErrCredentialAccessDenied is returned when credentials are opened
+	// outside of an account sync
+	ErrCredentialAccessDenied = errors.New("exchange credentials can only be decrypted while syncing")
+)
+
+// syncAccessKey marks contexts of account syncs
+type syncAccessKey struct{}
+
+// WithSyncAccess returns a context allowed to decrypt stored credentials. Only
+// the sync path sets it, so no other caller can recover API keys.
+func WithSyncAccess(ctx context.Context) context.Context {
+	return context.WithValue(ctx, syncAccessKey{}, true)
+}
+
+// hasSyncAccess reports whether the context belongs to an account sync
+func hasSyncAccess(ctx context.Context) bool {
+	allowed, _ := ctx.Value(syncAccessKey{}).(bool)
+	return allowed
+}
+
+// CredentialCipher encrypts exchange API credentials for storage with
+// envelope encryption. Ciphertexts are bound to their account, so credentials
+// copied to another portfolio or exchange fail to open.
+//
+// Credentials stored before envelope encryption were sealed directly with
+// AES-256-GCM under a credential key; they are opened with the legacy keys
+// and upgraded by Rewrap.
+type CredentialCipher struct {
+	encryptor *envelope.Encryptor
+	legacy    []cipher.AEAD
+}
+
+// NewCredentialCipher creates a cipher sealing credentials with encryptor.
+// The legacy keys open credentials sealed before envelope encryption.
+func NewCredentialCipher(encryptor *envelope.Encryptor, legacyKeys [][]byte) (*CredentialCipher, error) {
+	if encryptor == nil {
+		return nil, errors.New("an encryptor is required")
+	}
+
+	c := &CredentialCipher{encryptor: encryptor}
+	for i, secret := range legacyKeys {
+		if len(secret) < MinCredentialKeyLength {
+			return nil, fmt.Errorf("credential key %d is shorter than %d bytes", i, MinCredentialKeyLength)
+		}
+
+		key := sha256.Sum256(secret)
+		block, err := aes.NewCipher(key[:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher: %w", err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AEAD: %w", err)
+		}
+		c.legacy = append(c.legacy, aead)
+	}
+
+	return c, nil
+}
+
+// Seal encrypts credentials of an exchange account of a portfolio
+func (c *CredentialCipher) Seal(ctx context.Context, portfolioID uuid.UUID, exchange string, creds Credentials) ([]byte, error) {
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode credentials: %w", err)
+	}
+	defer clear(data)
+
+	return c.encryptor.Seal(ctx, data, credentialScope(portfolioID, exchange))
+}
+
+// Open decrypts credentials sealed for the same account. It fails with
+// ErrCredentialAccessDenied unless ctx comes from WithSyncAccess.
+func (c *CredentialCipher) Open(ctx context.Context, portfolioID uuid.UUID, exchange string, sealed []byte) (Credentials, error) {
+	if !hasSyncAccess(ctx) {
+		return Credentials{}, ErrCredentialAccessDenied
+	}
+
+	data, err := c.open(ctx, portfolioID, exchange, sealed)
+	if err != nil {
+		return Credentials{}, err
+	}
+	defer clear(data)
+
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return Credentials{}, ErrUndecryptableCredentials
+	}
+	return creds, nil
+}
+
+// Rewrap re-seals stored credentials under the current key encryption key
+// and reports whether they changed. Envelope ciphertexts only have their data
+// key rewrapped; legacy ciphertexts are decrypted and sealed anew without the
+// credentials leaving the cipher.
+func (c *CredentialCipher) Rewrap(ctx context.Context, portfolioID uuid.UUID, exchange string, sealed []byte) ([]byte, bool, error) {
+	if envelope.IsSealed(sealed) {
+		return c.encryptor.Rewrap(ctx, sealed)
+	}
+
+	data, err := c.openLegacy(portfolioID, exchange, sealed)
+	if err != nil {
+		return nil, false, err
+	}
+	defer clear(data)
+
+	resealed, err := c.encryptor.Seal(ctx, data, credentialScope(portfolioID, exchange))
+	if err != nil {
+		return nil, false, err
+	}
+	return resealed, true, nil
+}
+
+// open decrypts envelope or legacy ciphertexts of an account
+func (c *CredentialCipher) open(ctx context.Context, portfolioID uuid.UUID, exchange string, sealed []byte) ([]byte, error) {
+	if !envelope.IsSealed(sealed) {
+		return c.openLegacy(portfolioID, exchange, sealed)
+	}
+
+	data, err := c.encryptor.Open(ctx, sealed, credentialScope(portfolioID, exchange))
+	if errors.Is(err, envelope.ErrUndecryptable) || errors.Is(err, envelope.ErrUnknownKey) {
+		return nil, fmt.Errorf("%w: %v", ErrUndecryptableCredentials, err)
+	}
+	return data, err
+}
+
+// openLegacy decrypts credentials sealed directly with a credential key
+func (c *CredentialCipher) openLegacy(portfolioID uuid.UUID, exchange string, sealed []byte) ([]byte, error) {
+	scope := credentialScope(portfolioID, exchange)
+	for _, aead := range c.legacy {
+		if len(sealed) < aead.NonceSize() {
+			return nil, ErrUndecryptableCredentials
+		}
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		data, err := aead.Open(nil, nonce, ciphertext, scope)
+		if err == nil {
+			return data, nil
+		}
+	}
+	return nil, ErrUndecryptableCredentials
+}
+
+// credentialScope is the additional authenticated data binding credentials
+// to their account
+func credentialScope(portfolioID uuid.UUID, exchange string) []byte {
+	return []byte("exchange-credentials\x00" + portfolioID.String() + "\x00" + exchange)
+}
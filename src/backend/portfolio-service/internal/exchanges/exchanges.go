@@ -0,0 +1,159 @@
+// Package exchanges is the SDK exchange connectors are written against. A
+// connector reads balances and account history of one exchange; the package
+// provides the shared pieces: a rate-limited HTTP client with retries,
+// credential encryption and connector registration. Mapping activity onto
+// transactions is done by models.ExchangeMapper and checkpoints are stored
+// with the exchange account.
+//
+// Adding an exchange takes a single file defining an Exchange descriptor
+// whose constructor returns a Connector calling the API through Client.
+package exchanges
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"bookman/portfolio-service/internal/models"
+)
+
+var (
+	// ErrUnsupportedExchange is returned for exchanges without a connector
+	ErrUnsupportedExchange = errors.New("unsupported exchange")
+
+	// ErrInvalidCredentials is returned when the exchange rejects the API key
+	ErrInvalidCredentials = errors.New("exchange rejected the API credentials")
+
+	// ErrCredentialPermissions is returned for API keys allowed to trade or
+	// withdraw, which are not accepted for storage
+	ErrCredentialPermissions = errors.New("exchange API key must be read-only")
+
+	// ErrRateLimited is returned when the exchange throttles the account or IP
+	ErrRateLimited = errors.New("exchange rate limit exceeded")
+
+	// ErrTemporary marks exchange failures worth retrying, such as outages
+	ErrTemporary = errors.New("temporary exchange failure")
+
+	// ErrInvalidCursor is returned for cursors not issued by the connector
+	ErrInvalidCursor = errors.New("invalid exchange import cursor")
+)
+
+// Credentials authenticate read-only API access to an exchange account
+type Credentials struct {
+	APIKey    string `json:"api_key"`
+	APISecret string `json:"api_secret"`
+}
+
+// Connector reads an exchange account
+type Connector interface {
+	// Authenticate verifies the credentials grant read access to the account.
+	// Connectors able to inspect key permissions reject keys that can trade or
+	// withdraw with ErrCredentialPermissions.
+	Authenticate(ctx context.Context) error
+
+	// FetchBalances returns the non-zero spot balances of the account
+	FetchBalances(ctx context.Context) ([]models.ExchangeBalance, error)
+
+	// FetchTrades returns account activity after the opaque cursor, or all
+	// available history for an empty cursor, and the cursor to resume from
+	FetchTrades(ctx context.Context, cursor string) (models.ExchangeActivity, string, error)
+
+	// PriceUSD returns the USD price of one unit of an asset at a time
+	PriceUSD(ctx context.Context, asset string, at time.Time) (decimal.Decimal, error)
+}
+
+// Exchange describes a connector implementation and the request rate its API
+// allows
+type Exchange struct {
+	Name string
+	// Rate and Burst bound requests per second to the exchange
+	Rate  float64
+	Burst int
+	// PerKey scopes the rate limit to each API key instead of the service,
+	// for exchanges that meter keys rather than IP addresses
+	PerKey bool
+	// New creates a connector calling the exchange through client
+	New func(creds Credentials, client *Client) (Connector, error)
+}
+
+// Registry holds the available exchanges and the rate limiters shared by all
+// connectors of an exchange
+type Registry struct {
+	http  *http.Client
+	retry RetryPolicy
+
+	mutex     sync.Mutex
+	exchanges map[string]Exchange
+	limiters  map[string]*limiter
+}
+
+// NewRegistry creates an empty registry whose connectors send requests with
+// httpClient and retry failures according to retry
+func NewRegistry(httpClient *http.Client, retry RetryPolicy) *Registry {
+	return &Registry{
+		http:      httpClient,
+		retry:     retry,
+		exchanges: make(map[string]Exchange),
+		limiters:  make(map[string]*limiter),
+	}
+}
+
+// Register makes an exchange available, replacing one of the same name
+func (r *Registry) Register(e Exchange) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.exchanges[e.Name] = e
+}
+
+// Names returns the registered exchange names in order
+func (r *Registry) Names() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	names := make([]string, 0, len(r.exchanges))
+	for name := range r.exchanges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Connect creates a connector for the named exchange
+func (r *Registry) Connect(name string, creds Credentials) (Connector, error) {
+	r.mutex.Lock()
+	e, ok := r.exchanges[name]
+	if !ok {
+		r.mutex.Unlock()
+		return nil, ErrUnsupportedExchange
+	}
+	if creds.APIKey == "" || creds.APISecret == "" {
+		r.mutex.Unlock()
+		return nil, ErrInvalidCredentials
+	}
+
+	key := e.Name
+	if e.PerKey {
+		sum := sha256.Sum256([]byte(creds.APIKey))
+		key += "/" + hex.EncodeToString(sum[:8])
+	}
+	l, ok := r.limiters[key]
+	if !ok {
+		l = newLimiter(e.Rate, e.Burst)
+		r.limiters[key] = l
+	}
+	r.mutex.Unlock()
+
+	return e.New(creds, &Client{
+		name:    e.Name,
+		http:    r.http,
+		limiter: l,
+		retry:   r.retry,
+	})
+}
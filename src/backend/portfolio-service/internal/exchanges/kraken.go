@@ -0,0 +1,504 @@
+package exchanges
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/models"
+)
+
+const (
+	// krakenRate and krakenBurst follow the starter tier call counter, which
+	// holds 15 and decays by 0.33 per second; ledger queries cost 2
+	krakenRate  = 0.15
+	krakenBurst = 7
+
+	// krakenCursorOverlap re-reads entries this close to the previous cursor,
+	// as the ledger start filter is exclusive at one-second granularity
+	krakenCursorOverlap = time.Second
+)
+
+// krakenAssets maps Kraken's legacy asset codes to common symbols
+var krakenAssets = map[string]string{
+	"XXBT": "BTC", "XBT": "BTC", "XBT.M": "BTC",
+	"XXDG": "DOGE", "XDG": "DOGE",
+	"XETH": "ETH", "ETH2": "ETH",
+	"XETC": "ETC", "XLTC": "LTC", "XXRP": "XRP", "XXLM": "XLM",
+	"XXMR": "XMR", "XZEC": "ZEC", "XMLN": "MLN", "XREP": "REP",
+	"ZUSD": "USD", "ZEUR": "EUR", "ZGBP": "GBP", "ZCAD": "CAD",
+	"ZJPY": "JPY", "ZAUD": "AUD", "ZCHF": "CHF",
+}
+
+// krakenPricingCodes maps common symbols to the codes of Kraken's USD pairs
+var krakenPricingCodes = map[string]string{
+	"BTC":  "XBT",
+	"DOGE": "XDG",
+}
+
+// krakenStakedSuffixes mark balances of assets staked or allocated to earn
+// programs, which are the same asset for portfolio purposes
+var krakenStakedSuffixes = []string{".S", ".M", ".B", ".F", ".P"}
+
+// krakenAuthErrors are API errors returned for rejected keys or signatures
+var krakenAuthErrors = map[string]bool{
+	"EAPI:Invalid key":           true,
+	"EAPI:Invalid signature":     true,
+	"EGeneral:Permission denied": true,
+}
+
+// krakenRateLimitErrors are API errors returned when requests are throttled
+var krakenRateLimitErrors = map[string]bool{
+	"EAPI:Rate limit exceeded":   true,
+	"EGeneral:Too many requests": true,
+	"EGeneral:Temporary lockout": true,
+}
+
+// krakenTemporaryErrors are API errors returned during outages
+var krakenTemporaryErrors = map[string]bool{
+	"EService:Unavailable":    true,
+	"EService:Busy":           true,
+	"EGeneral:Internal error": true,
+}
+
+// kraken reads a Kraken account with a query-only API key
+type kraken struct {
+	cfg    config.KrakenConfig
+	creds  Credentials
+	client *Client
+	quotes map[string]int
+	prices map[string]decimal.Decimal
+
+	// nonces must increase per API key across all requests
+	nonceMutex sync.Mutex
+	lastNonce  int64
+}
+
+// krakenCursor records the time of the newest ledger entry imported
+type krakenCursor struct {
+	Since float64 `json:"since"`
+}
+
+// krakenLedgerEntry is an entry of the account ledger. Amount is the signed
+// change before the fee; the balance changes by amount minus fee.
+type krakenLedgerEntry struct {
+	RefID   string          `json:"refid"`
+	Time    float64         `json:"time"`
+	Type    string          `json:"type"`
+	Subtype string          `json:"subtype"`
+	Asset   string          `json:"asset"`
+	Amount  decimal.Decimal `json:"amount"`
+	Fee     decimal.Decimal `json:"fee"`
+
+	id string
+}
+
+// Kraken describes the Kraken connector. Kraken meters calls per API key.
+func Kraken(cfg config.KrakenConfig) Exchange {
+	quotes := make(map[string]int, len(cfg.QuoteAssets))
+	for i, asset := range cfg.QuoteAssets {
+		quotes[strings.ToUpper(asset)] = i
+	}
+
+	return Exchange{
+		Name:   "kraken",
+		Rate:   krakenRate,
+		Burst:  krakenBurst,
+		PerKey: true,
+		New: func(creds Credentials, client *Client) (Connector, error) {
+			if _, err := base64.StdEncoding.DecodeString(creds.APISecret); err != nil {
+				return nil, ErrInvalidCredentials
+			}
+			return &kraken{
+				cfg:    cfg,
+				creds:  creds,
+				client: client,
+				quotes: quotes,
+				prices: make(map[string]decimal.Decimal),
+			}, nil
+		},
+	}
+}
+
+// Authenticate checks the API key can query the account. Kraken does not
+// expose key permissions, so keys are not checked for trading rights.
+func (k *kraken) Authenticate(ctx context.Context) error {
+	_, err := k.FetchBalances(ctx)
+	return err
+}
+
+// FetchBalances returns the non-zero balances of the account with staked
+// balances merged into their asset
+func (k *kraken) FetchBalances(ctx context.Context) ([]models.ExchangeBalance, error) {
+	var raw map[string]decimal.Decimal
+	if err := k.private(ctx, "/0/private/Balance", url.Values{}, &raw); err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]decimal.Decimal, len(raw))
+	for code, amount := range raw {
+		asset := krakenAsset(code)
+		totals[asset] = totals[asset].Add(amount)
+	}
+
+	balances := make([]models.ExchangeBalance, 0, len(totals))
+	for asset, total := range totals {
+		if total.IsPositive() {
+			balances = append(balances, models.ExchangeBalance{Asset: asset, Free: total})
+		}
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Asset < balances[j].Asset })
+	return balances, nil
+}
+
+// FetchTrades reads ledger entries after the cursor. Trades and instant
+// buys appear as a debit and a credit sharing a reference and are paired into
+// fills; staking rewards, deposits and withdrawals become entries. Transfers
+// between spot and staking balances are internal and ignored.
+func (k *kraken) FetchTrades(ctx context.Context, cursor string) (models.ExchangeActivity, string, error) {
+	var activity models.ExchangeActivity
+
+	cur, err := decodeKrakenCursor(cursor)
+	if err != nil {
+		return activity, "", err
+	}
+
+	entries, err := k.ledger(ctx, cur.Since)
+	if err != nil {
+		return activity, "", err
+	}
+
+	next := cur.Since
+	trades := make(map[string][]krakenLedgerEntry)
+	for _, entry := range entries {
+		if entry.Time > next {
+			next = entry.Time
+		}
+		executedAt := krakenTime(entry.Time)
+		asset := krakenAsset(entry.Asset)
+
+		switch {
+		case entry.Type == "trade" || entry.Type == "spend" || entry.Type == "receive":
+			trades[entry.RefID] = append(trades[entry.RefID], entry)
+
+		case entry.Type == "staking" || (entry.Type == "earn" && entry.Subtype == "reward"):
+			activity.Entries = append(activity.Entries, models.ExchangeEntry{
+				ID: entry.id, Asset: asset, Type: "reward",
+				Amount: entry.Amount.Abs(), Fee: entry.Fee, ExecutedAt: executedAt,
+			})
+
+		case entry.Type == "deposit" && entry.Amount.IsPositive():
+			activity.Entries = append(activity.Entries, models.ExchangeEntry{
+				ID: entry.id, Asset: asset, Type: "transfer_in",
+				Amount: entry.Amount, Fee: entry.Fee, ExecutedAt: executedAt,
+			})
+
+		case entry.Type == "withdrawal" && entry.Amount.IsNegative():
+			activity.Entries = append(activity.Entries, models.ExchangeEntry{
+				ID: entry.id, Asset: asset, Type: "transfer_out",
+				Amount: entry.Amount.Abs(), Fee: entry.Fee, ExecutedAt: executedAt,
+			})
+		}
+	}
+
+	for refID, legs := range trades {
+		fill, ok := k.pairTrade(refID, legs)
+		if !ok {
+			// The other side is newer than this read; resume before it
+			if legs[0].Time-1 < next {
+				next = legs[0].Time - 1
+			}
+			continue
+		}
+		activity.Fills = append(activity.Fills, fill)
+	}
+	sort.Slice(activity.Fills, func(i, j int) bool {
+		return activity.Fills[i].ExecutedAt.Before(activity.Fills[j].ExecutedAt)
+	})
+
+	encoded, err := encodeKrakenCursor(krakenCursor{Since: next})
+	if err != nil {
+		return activity, "", err
+	}
+	return activity, encoded, nil
+}
+
+// PriceUSD returns the price of the first USD trade at or after a time
+func (k *kraken) PriceUSD(ctx context.Context, asset string, at time.Time) (decimal.Decimal, error) {
+	asset = strings.ToUpper(asset)
+	if asset == "USD" {
+		return decimal.NewFromInt(1), nil
+	}
+
+	minute := at.UTC().Truncate(time.Minute)
+	key := asset + "@" + strconv.FormatInt(minute.Unix(), 10)
+	if price, ok := k.prices[key]; ok {
+		return price, nil
+	}
+
+	code := asset
+	if mapped, ok := krakenPricingCodes[asset]; ok {
+		code = mapped
+	}
+
+	var result map[string]json.RawMessage
+	params := url.Values{
+		"pair":  {code + "USD"},
+		"since": {strconv.FormatInt(minute.Unix(), 10)},
+		"count": {"1"},
+	}
+	if err := k.public(ctx, "/0/public/Trades", params, &result); err != nil {
+		return decimal.Zero, err
+	}
+
+	for name, raw := range result {
+		if name == "last" {
+			continue
+		}
+		var trades [][]json.RawMessage
+		if err := json.Unmarshal(raw, &trades); err != nil {
+			return decimal.Zero, fmt.Errorf("failed to decode %sUSD trades: %w", code, err)
+		}
+		if len(trades) == 0 || len(trades[0]) == 0 {
+			break
+		}
+		var price decimal.Decimal
+		if err := json.Unmarshal(trades[0][0], &price); err != nil {
+			return decimal.Zero, fmt.Errorf("failed to decode %sUSD price: %w", code, err)
+		}
+		k.prices[key] = price
+		return price, nil
+	}
+	return decimal.Zero, fmt.Errorf("no %sUSD trade after %s", code, minute.Format(time.RFC3339))
+}
+
+// ledger pages through ledger entries newer than since, newest first
+func (k *kraken) ledger(ctx context.Context, since float64) ([]krakenLedgerEntry, error) {
+	var entries []krakenLedgerEntry
+	for offset := 0; ; {
+		params := url.Values{"ofs": {strconv.Itoa(offset)}}
+		if since > 0 {
+			start := since - krakenCursorOverlap.Seconds()
+			params.Set("start", strconv.FormatFloat(start, 'f', 4, 64))
+		}
+
+		var page struct {
+			Ledger map[string]krakenLedgerEntry `json:"ledger"`
+			Count  int                          `json:"count"`
+		}
+		if err := k.private(ctx, "/0/private/Ledgers", params, &page); err != nil {
+			return nil, err
+		}
+
+		for id, entry := range page.Ledger {
+			entry.id = id
+			entries = append(entries, entry)
+		}
+		offset += len(page.Ledger)
+		if len(page.Ledger) == 0 || offset >= page.Count {
+			return entries, nil
+		}
+	}
+}
+
+// pairTrade turns the debit and credit of a trade into a fill. The quote side
+// is the more preferred quote asset; the commission is charged on either side.
+func (k *kraken) pairTrade(refID string, legs []krakenLedgerEntry) (models.ExchangeFill, bool) {
+	if len(legs) != 2 {
+		return models.ExchangeFill{}, false
+	}
+	spent, received := legs[0], legs[1]
+	if spent.Amount.IsPositive() {
+		spent, received = received, spent
+	}
+	if !spent.Amount.IsNegative() || !received.Amount.IsPositive() {
+		return models.ExchangeFill{}, false
+	}
+
+	fill := models.ExchangeFill{
+		ID:         "trade:" + refID,
+		ExecutedAt: krakenTime(received.Time),
+	}
+
+	// Buying the received asset unless it is the better quote
+	base, quote := received, spent
+	fill.Side = models.ExchangeBuy
+	if k.isBetterQuote(krakenAsset(received.Asset), krakenAsset(spent.Asset)) {
+		base, quote = spent, received
+		fill.Side = models.ExchangeSell
+	}
+
+	fill.Base = krakenAsset(base.Asset)
+	fill.Quote = krakenAsset(quote.Asset)
+	fill.Quantity = base.Amount.Abs()
+	fill.QuoteQuantity = quote.Amount.Abs()
+	fill.Price = fill.QuoteQuantity.Div(fill.Quantity)
+
+	// Kraken charges one side of a trade; should both carry a fee, the quote
+	// side's is taken as the commission as that is where Kraken charges it
+	switch {
+	case quote.Fee.IsPositive():
+		fill.Commission, fill.CommissionAsset = quote.Fee, fill.Quote
+	case base.Fee.IsPositive():
+		fill.Commission, fill.CommissionAsset = base.Fee, fill.Base
+	}
+	return fill, true
+}
+
+// isBetterQuote reports whether a ranks before b as a quote asset
+func (k *kraken) isBetterQuote(a, b string) bool {
+	rankA, okA := k.quotes[a]
+	rankB, okB := k.quotes[b]
+	return okA && (!okB || rankA < rankB)
+}
+
+// private calls a signed endpoint. The signature is an HMAC-SHA512, keyed by
+// the decoded API secret, of the path and the SHA-256 of nonce and body.
+func (k *kraken) private(ctx context.Context, path string, params url.Values, out interface{}) error {
+	secret, err := base64.StdEncoding.DecodeString(k.creds.APISecret)
+	if err != nil {
+		return ErrInvalidCredentials
+	}
+
+	build := func() (*http.Request, error) {
+		nonce := strconv.FormatInt(k.nonce(), 10)
+		params.Set("nonce", nonce)
+		body := params.Encode()
+
+		digest := sha256.Sum256([]byte(nonce + body))
+		mac := hmac.New(sha512.New, secret)
+		mac.Write([]byte(path))
+		mac.Write(digest[:])
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(k.cfg.BaseURL, "/")+path, strings.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("API-Key", k.creds.APIKey)
+		req.Header.Set("API-Sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		return req, nil
+	}
+	return k.client.Do(ctx, build, k.check(path, out))
+}
+
+// public calls an unauthenticated endpoint
+func (k *kraken) public(ctx context.Context, path string, params url.Values, out interface{}) error {
+	build := func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(k.cfg.BaseURL, "/")+path+"?"+params.Encode(), nil)
+	}
+	return k.client.Do(ctx, build, k.check(path, out))
+}
+
+// check returns a response check decoding the result of the response
+// envelope into out. Kraken reports most failures in the envelope.
+func (k *kraken) check(path string, out interface{}) func(*Response) error {
+	return func(resp *Response) error {
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			return fmt.Errorf("%w: kraken %s returned status %d", ErrRateLimited, path, resp.StatusCode)
+		case resp.StatusCode >= 500:
+			return fmt.Errorf("%w: kraken %s returned status %d", ErrTemporary, path, resp.StatusCode)
+		}
+
+		var envelope struct {
+			Error  []string        `json:"error"`
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(resp.Body, &envelope); err != nil {
+			return fmt.Errorf("kraken %s returned status %d: %w", path, resp.StatusCode, err)
+		}
+		for _, apiErr := range envelope.Error {
+			switch {
+			case krakenAuthErrors[apiErr]:
+				return ErrInvalidCredentials
+			case krakenRateLimitErrors[apiErr]:
+				return fmt.Errorf("%w: %s", ErrRateLimited, apiErr)
+			case krakenTemporaryErrors[apiErr]:
+				return fmt.Errorf("%w: %s", ErrTemporary, apiErr)
+			}
+		}
+		if len(envelope.Error) > 0 {
+			return fmt.Errorf("kraken %s failed: %s", path, strings.Join(envelope.Error, ", "))
+		}
+
+		if err := json.Unmarshal(envelope.Result, out); err != nil {
+			return fmt.Errorf("failed to decode kraken %s response: %w", path, err)
+		}
+		return nil
+	}
+}
+
+// nonce returns a strictly increasing microsecond nonce
+func (k *kraken) nonce() int64 {
+	k.nonceMutex.Lock()
+	defer k.nonceMutex.Unlock()
+
+	nonce := time.Now().UnixMicro()
+	if nonce <= k.lastNonce {
+		nonce = k.lastNonce + 1
+	}
+	k.lastNonce = nonce
+	return nonce
+}
+
+// krakenAsset normalizes a Kraken asset code to its common symbol
+func krakenAsset(code string) string {
+	code = strings.ToUpper(code)
+	if mapped, ok := krakenAssets[code]; ok {
+		return mapped
+	}
+	for _, suffix := range krakenStakedSuffixes {
+		if strings.HasSuffix(code, suffix) {
+			return krakenAsset(strings.TrimSuffix(code, suffix))
+		}
+	}
+	return code
+}
+
+// krakenTime converts fractional Unix seconds to a UTC time
+func krakenTime(seconds float64) time.Time {
+	whole := int64(seconds)
+	return time.Unix(whole, int64((seconds-float64(whole))*1e9)).UTC().Truncate(time.Microsecond)
+}
+
+// decodeKrakenCursor parses an import cursor; empty cursors start from the
+// beginning of the account history
+func decodeKrakenCursor(cursor string) (krakenCursor, error) {
+	var cur krakenCursor
+	if cursor == "" {
+		return cur, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return cur, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &cur); err != nil || cur.Since < 0 {
+		return krakenCursor{}, ErrInvalidCursor
+	}
+	return cur, nil
+}
+
+// encodeKrakenCursor renders an opaque import cursor
+func encodeKrakenCursor(cur krakenCursor) (string, error) {
+	raw, err := json.Marshal(cur)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode kraken cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
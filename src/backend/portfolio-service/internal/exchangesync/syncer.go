@@ -0,0 +1,126 @@
+// Package exchangesync imports the activity of connected exchange accounts in
+// the background. Due accounts are leased so several service instances can
+// sync concurrently without importing an account twice.
+package exchangesync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"go.uber.org/zap"                                // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/exchanges"
+	"bookman/portfolio-service/internal/metrics"
+	"bookman/portfolio-service/internal/repository"
+	"bookman/portfolio-service/internal/services"
+)
+
+// pollInterval is how often the syncer looks for due accounts
+const pollInterval = time.Minute
+
+var exchangeSyncs = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "portfolio_exchange_syncs_total",
+		Help: "Total number of exchange account syncs by exchange and status",
+	},
+	[]string{"exchange", "status"},
+)
+
+func init() {
+	metrics.MustRegister(exchangeSyncs)
+}
+
+// Syncer imports due exchange accounts on a fixed interval
+type Syncer struct {
+	repo   *repository.PostgresRepository
+	svc    *services.PortfolioService
+	cfg    config.ExchangesConfig
+	logger *zap.Logger
+}
+
+// NewSyncer creates a new exchange account sync job
+func NewSyncer(repo *repository.PostgresRepository, svc *services.PortfolioService, cfg config.ExchangesConfig, logger *zap.Logger) (*Syncer, error) {
+	if repo == nil || svc == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	return &Syncer{
+		repo:   repo,
+		svc:    svc,
+		cfg:    cfg,
+		logger: logger.With(zap.String("component", "exchange_syncer")),
+	}, nil
+}
+
+// Run syncs due accounts at each poll until ctx is cancelled
+func (s *Syncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("Exchange sync pass failed", zap.Error(err))
+		}
+	}
+}
+
+// RunOnce claims and syncs batches of due accounts until none are left. A
+// failed sync is recorded on its account and retried at its next sync.
+func (s *Syncer) RunOnce(ctx context.Context) error {
+	failed := 0
+	for {
+		now := time.Now().UTC()
+		// The lease outlives a sync so a crashed worker's claims are retried
+		// after one interval
+		accounts, err := s.repo.ClaimDueExchangeAccounts(ctx, now, now.Add(s.cfg.SyncInterval), s.cfg.SyncBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to claim exchange accounts: %w", err)
+		}
+
+		for i := range accounts {
+			account := &accounts[i]
+			// The worker is the only background path allowed to decrypt
+			// stored credentials
+			result, err := s.svc.SyncExchangeAccount(exchanges.WithSyncAccess(ctx), account)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				failed++
+				exchangeSyncs.WithLabelValues(account.Exchange, "error").Inc()
+				s.logger.Warn("Exchange account sync failed",
+					zap.Error(err),
+					zap.String("account_id", account.ID.String()),
+					zap.String("portfolio_id", account.PortfolioID.String()),
+					zap.String("exchange", account.Exchange),
+				)
+				continue
+			}
+
+			exchangeSyncs.WithLabelValues(account.Exchange, "success").Inc()
+			s.logger.Debug("Exchange account synced",
+				zap.String("account_id", account.ID.String()),
+				zap.Int("transactions", result.TransactionsRecorded),
+			)
+		}
+
+		if len(accounts) < s.cfg.SyncBatchSize {
+			break
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d exchange accounts failed to sync", failed)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Package exemplars attaches trace IDs to latency observations as Prometheus
+// exemplars, so operators can jump from a slow histogram bucket straight to a
+// representative trace. The trace ID is taken from the W3C trace context
+// callers propagate in the traceparent metadata; spans recorded by the
+// service continue that trace, so the ID resolves in the tracing backend.
+// Only sampled traces are attached, as unsampled ones are never recorded.
+package exemplars
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"google.golang.org/grpc/metadata"                // v1.50.0
+)
+
+// MetadataKey is the gRPC metadata key carrying the W3C trace context
+const MetadataKey = "traceparent"
+
+// TraceIDLabel is the exemplar label holding the trace ID
+const TraceIDLabel = "trace_id"
+
+// sampledFlag is the trace flag set on sampled traces
+const sampledFlag = 0x01
+
+// ParseTraceparent returns the trace ID of a traceparent header value and
+// whether the trace is sampled. ok is false for malformed values and the
+// all-zero trace ID, which the specification defines as invalid.
+func ParseTraceparent(value string) (traceID string, sampled bool, ok bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return "", false, false
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+
+	// Version ff is forbidden; later versions may append fields, but version
+	// 00 defines exactly four
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return "", false, false
+	}
+	if !isHex(traceID, 32) || !isHex(spanID, 16) || !isHex(flags, 2) {
+		return "", false, false
+	}
+	if traceID == strings.Repeat("0", 32) || spanID == strings.Repeat("0", 16) {
+		return "", false, false
+	}
+
+	decoded, _ := hex.DecodeString(flags)
+	return traceID, decoded[0]&sampledFlag != 0, true
+}
+
+// isHex reports whether s is n lowercase hex digits
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// TraceID returns the ID of the sampled trace propagated in the incoming
+// metadata of ctx, if any
+func TraceID(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	values := md.Get(MetadataKey)
+	if len(values) == 0 {
+		return "", false
+	}
+	traceID, sampled, ok := ParseTraceparent(values[0])
+	if !ok || !sampled {
+		return "", false
+	}
+	return traceID, true
+}
+
+// Observe records value on observer, attaching the trace ID of ctx as an
+// exemplar when the call is part of a sampled trace
+func Observe(ctx context.Context, observer prometheus.Observer, value float64) {
+	if traceID, ok := TraceID(ctx); ok {
+		if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok {
+			exemplarObserver.ObserveWithExemplar(value, prometheus.Labels{TraceIDLabel: traceID})
+			return
+		}
+	}
+	observer.Observe(value)
+}
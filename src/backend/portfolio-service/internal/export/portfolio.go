@@ -0,0 +1,182 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"bookman/portfolio-service/internal/models"
+)
+
+// PortfolioFormat identifies a layout of the full portfolio export
+type PortfolioFormat string
+
+const (
+	// PortfolioCSV is a zip archive of holdings.csv and transactions.csv; the
+	// transactions file uses the native layout accepted by the importer
+	PortfolioCSV PortfolioFormat = "csv"
+	// PortfolioXLSX is an Excel workbook with Holdings and Transactions sheets
+	PortfolioXLSX PortfolioFormat = "xlsx"
+)
+
+// ContentType returns the MIME type of the rendered export
+func (f PortfolioFormat) ContentType() string {
+	if f == PortfolioXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/zip"
+}
+
+// Extension returns the file extension of the rendered export
+func (f PortfolioFormat) Extension() string {
+	if f == PortfolioXLSX {
+		return "xlsx"
+	}
+	return "zip"
+}
+
+// cell is a value of a sheet. Numbers are written as numeric cells to
+// spreadsheets and verbatim to CSV.
+type cell struct {
+	text   string
+	number bool
+}
+
+// text returns a text cell
+func text(s string) cell {
+	return cell{text: s}
+}
+
+// number returns a numeric cell holding the exact decimal
+func number(d decimal.Decimal) cell {
+	return cell{text: d.String(), number: true}
+}
+
+// sheet is a table of the portfolio export
+type sheet struct {
+	name   string
+	header []string
+	rows   [][]cell
+}
+
+// WritePortfolio renders the holdings and the full transaction history of a
+// portfolio. Amounts keep their full decimal precision; see writeXLSX for
+// how spreadsheets represent them.
+func WritePortfolio(w io.Writer, format PortfolioFormat, portfolio *models.Portfolio, txs []models.Transaction) error {
+	if portfolio == nil {
+		return fmt.Errorf("no portfolio to export")
+	}
+
+	sheets, err := portfolioSheets(portfolio, txs)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case PortfolioCSV:
+		return writeCSVArchive(w, sheets)
+	case PortfolioXLSX:
+		return writeXLSX(w, sheets)
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+// portfolioSheets builds the holdings and transactions tables
+func portfolioSheets(portfolio *models.Portfolio, txs []models.Transaction) ([]sheet, error) {
+	assets := make([]models.Asset, len(portfolio.Assets))
+	copy(assets, portfolio.Assets)
+	sort.SliceStable(assets, func(i, j int) bool {
+		return assets[i].Symbol < assets[j].Symbol
+	})
+
+	holdings := sheet{
+		name:   "Holdings",
+		header: []string{"Symbol", "Type", "Amount", "Cost Basis", "Current Value", "Currency", "Last Updated", "Asset ID"},
+	}
+	symbols := make(map[uuid.UUID]string, len(assets))
+	for _, asset := range assets {
+		symbols[asset.ID] = asset.Symbol
+		holdings.rows = append(holdings.rows, []cell{
+			text(asset.Symbol),
+			text(asset.Type),
+			number(asset.Amount),
+			number(asset.CostBasis),
+			number(asset.CurrentValue),
+			text(QuoteCurrency),
+			text(formatTime(asset.LastUpdated)),
+			text(asset.ID.String()),
+		})
+	}
+
+	ordered := make([]models.Transaction, len(txs))
+	copy(ordered, txs)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Timestamp.Before(ordered[j].Timestamp)
+	})
+
+	// Same columns as the native transaction export
+	transactions := sheet{name: "Transactions", header: layouts[FormatCSV].header}
+	for _, tx := range ordered {
+		symbol, ok := symbols[tx.AssetID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAsset, tx.AssetID)
+		}
+		transactions.rows = append(transactions.rows, []cell{
+			text(formatTime(tx.Timestamp)),
+			text(tx.Type),
+			text(symbol),
+			number(tx.Amount),
+			number(tx.Price),
+			number(tx.Fee),
+			text(QuoteCurrency),
+			text(tx.ID.String()),
+		})
+	}
+
+	return []sheet{holdings, transactions}, nil
+}
+
+// writeCSVArchive writes each sheet as a CSV file of a zip archive
+func writeCSVArchive(w io.Writer, sheets []sheet) error {
+	zw := zip.NewWriter(w)
+	for _, s := range sheets {
+		f, err := zw.Create(strings.ToLower(s.name) + ".csv")
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", s.name, err)
+		}
+
+		cw := csv.NewWriter(f)
+		if err := cw.Write(s.header); err != nil {
+			return fmt.Errorf("failed to write %s header: %w", s.name, err)
+		}
+		record := make([]string, len(s.header))
+		for _, row := range s.rows {
+			for i, c := range row {
+				record[i] = c.text
+			}
+			if err := cw.Write(record); err != nil {
+				return fmt.Errorf("failed to write %s row: %w", s.name, err)
+			}
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", s.name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// formatTime renders a timestamp in the layout of the native export
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
@@ -0,0 +1,244 @@
+// Package export renders portfolio data into file formats accepted by
+// third-party tax and accounting tools.
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"bookman/portfolio-service/internal/models"
+)
+
+// Format identifies a transaction export layout
+type Format string
+
+const (
+	FormatCSV         Format = "csv"
+	FormatKoinly      Format = "koinly"
+	FormatCoinTracker Format = "cointracker"
+)
+
+// QuoteCurrency is the fiat currency transaction prices are recorded in
+const QuoteCurrency = "USD"
+
+var (
+	// ErrUnsupportedFormat is returned for unknown export formats
+	ErrUnsupportedFormat = errors.New("unsupported export format")
+
+	// ErrUnknownAsset is returned when a transaction references an asset without a symbol
+	ErrUnknownAsset = errors.New("transaction references unknown asset")
+)
+
+// ContentType returns the MIME type of the rendered export
+func (f Format) ContentType() string {
+	return "text/csv"
+}
+
+// Extension returns the file extension of the rendered export
+func (f Format) Extension() string {
+	return "csv"
+}
+
+// rowWriter renders a single transaction into a CSV record
+type rowWriter func(tx models.Transaction, symbol string) []string
+
+// layout describes the header and row rendering of a CSV export
+type layout struct {
+	header []string
+	row    rowWriter
+}
+
+var layouts = map[Format]layout{
+	FormatCSV: {
+		header: []string{"Date", "Type", "Symbol", "Amount", "Price", "Fee", "Currency", "Transaction ID"},
+		row:    genericRow,
+	},
+	FormatKoinly: {
+		header: []string{
+			"Date", "Sent Amount", "Sent Currency", "Received Amount", "Received Currency",
+			"Fee Amount", "Fee Currency", "Net Worth Amount", "Net Worth Currency",
+			"Label", "Description", "TxHash",
+		},
+		row: koinlyRow,
+	},
+	FormatCoinTracker: {
+		header: []string{
+			"Date", "Received Quantity", "Received Currency", "Sent Quantity", "Sent Currency",
+			"Fee Amount", "Fee Currency", "Tag",
+		},
+		row: coinTrackerRow,
+	},
+}
+
+// WriteTransactions renders transactions in the given format. symbols maps
+// asset IDs to their ticker symbols. Transactions are written oldest first.
+func WriteTransactions(w io.Writer, format Format, txs []models.Transaction, symbols map[uuid.UUID]string) error {
+	tw, err := NewTransactionWriter(w, format, symbols)
+	if err != nil {
+		return err
+	}
+
+	ordered := make([]models.Transaction, len(txs))
+	copy(ordered, txs)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Timestamp.Before(ordered[j].Timestamp)
+	})
+
+	if err := tw.Write(ordered); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// TransactionWriter renders transactions in batches, so exports too large to
+// hold in memory can be written as they are read
+type TransactionWriter struct {
+	cw      *csv.Writer
+	layout  layout
+	symbols map[uuid.UUID]string
+}
+
+// NewTransactionWriter writes the header of the given format and returns a
+// writer for its rows. symbols maps asset IDs to their ticker symbols.
+func NewTransactionWriter(w io.Writer, format Format, symbols map[uuid.UUID]string) (*TransactionWriter, error) {
+	l, ok := layouts[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(l.header); err != nil {
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+	return &TransactionWriter{cw: cw, layout: l, symbols: symbols}, nil
+}
+
+// Write renders transactions in the order given
+func (t *TransactionWriter) Write(txs []models.Transaction) error {
+	for _, tx := range txs {
+		symbol, ok := t.symbols[tx.AssetID]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownAsset, tx.AssetID)
+		}
+		if err := t.cw.Write(t.layout.row(tx, symbol)); err != nil {
+			return fmt.Errorf("failed to write transaction %s: %w", tx.ID, err)
+		}
+	}
+	return nil
+}
+
+// Flush writes buffered rows to the underlying writer
+func (t *TransactionWriter) Flush() error {
+	t.cw.Flush()
+	return t.cw.Error()
+}
+
+// genericRow renders the service's native CSV layout
+func genericRow(tx models.Transaction, symbol string) []string {
+	return []string{
+		tx.Timestamp.UTC().Format("2006-01-02T15:04:05Z"),
+		tx.Type,
+		symbol,
+		tx.Amount.String(),
+		tx.Price.String(),
+		tx.Fee.String(),
+		QuoteCurrency,
+		tx.ID.String(),
+	}
+}
+
+// koinlyRow renders a row of the Koinly universal CSV template
+func koinlyRow(tx models.Transaction, symbol string) []string {
+	sentAmount, sentCurrency, receivedAmount, receivedCurrency := legs(tx, symbol)
+
+	return []string{
+		tx.Timestamp.UTC().Format("2006-01-02 15:04 UTC"),
+		sentAmount,
+		sentCurrency,
+		receivedAmount,
+		receivedCurrency,
+		optional(tx.Fee),
+		currencyFor(tx.Fee, QuoteCurrency),
+		optional(tx.Amount.Mul(tx.Price)),
+		currencyFor(tx.Amount.Mul(tx.Price), QuoteCurrency),
+		koinlyLabels[tx.Type],
+		"",
+		"",
+	}
+}
+
+// coinTrackerRow renders a row of the CoinTracker CSV import template
+func coinTrackerRow(tx models.Transaction, symbol string) []string {
+	sentAmount, sentCurrency, receivedAmount, receivedCurrency := legs(tx, symbol)
+
+	return []string{
+		tx.Timestamp.UTC().Format("01/02/2006 15:04:05"),
+		receivedAmount,
+		receivedCurrency,
+		sentAmount,
+		sentCurrency,
+		optional(tx.Fee),
+		currencyFor(tx.Fee, QuoteCurrency),
+		coinTrackerTags[tx.Type],
+	}
+}
+
+// koinlyLabels maps transaction types to Koinly labels
+var koinlyLabels = map[string]string{
+	"stake":    "stake",
+	"unstake":  "unstake",
+	"reward":   "reward",
+	"fee":      "cost",
+	"airdrop":  "airdrop",
+	"fork":     "fork",
+	"interest": "lending interest",
+}
+
+// coinTrackerTags maps transaction types to CoinTracker tags
+var coinTrackerTags = map[string]string{
+	"reward":   "staked",
+	"airdrop":  "airdrop",
+	"fork":     "fork",
+	"interest": "interest",
+}
+
+// legs splits a transaction into the sent and received sides used by
+// tax tool templates
+func legs(tx models.Transaction, symbol string) (sentAmount, sentCurrency, receivedAmount, receivedCurrency string) {
+	amount := tx.Amount.String()
+	total := tx.Amount.Mul(tx.Price).String()
+
+	switch tx.Type {
+	case "buy":
+		return total, QuoteCurrency, amount, symbol
+	case "sell":
+		return amount, symbol, total, QuoteCurrency
+	case "transfer_in", "unstake", "reward", "airdrop", "fork", "interest":
+		return "", "", amount, symbol
+	default:
+		// transfer_out, stake and fee move the asset out of the portfolio
+		return amount, symbol, "", ""
+	}
+}
+
+// optional renders zero values as empty cells
+func optional(d decimal.Decimal) string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.String()
+}
+
+// currencyFor returns currency when the amount is present
+func currencyFor(d decimal.Decimal, currency string) string {
+	if d.IsZero() {
+		return ""
+	}
+	return currency
+}
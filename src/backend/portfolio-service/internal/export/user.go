@@ -0,0 +1,26 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"bookman/portfolio-service/internal/models"
+)
+
+// UserDataContentType is the MIME type of user data exports
+const UserDataContentType = "application/json"
+
+// WriteUserData writes a user data export as an indented JSON document
+func WriteUserData(w io.Writer, data *models.UserDataExport) error {
+	if data == nil {
+		return fmt.Errorf("user data export is required")
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		return fmt.Errorf("failed to encode user data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxExactDigits is the number of significant digits a spreadsheet number
+// holds exactly; spreadsheets store numbers as IEEE 754 doubles
+const maxExactDigits = 15
+
+// Cell styles defined in xlsxStyles
+const (
+	styleDefault = 0
+	styleNumber  = 1
+	styleHeader  = 2
+)
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`%s</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles formats numbers with up to 18 decimal places, enough for token
+// amounts, and makes header rows bold
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="0.00################"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`</cellXfs></styleSheet>`
+
+// writeXLSX writes sheets as an Office Open XML workbook. Rows are streamed
+// into the archive. Numbers with more significant digits than a spreadsheet
+// holds exactly are written as text so no precision is lost.
+func writeXLSX(w io.Writer, sheets []sheet) error {
+	zw := zip.NewWriter(w)
+
+	var overrides, entries, rels strings.Builder
+	for i, s := range sheets {
+		n := i + 1
+		fmt.Fprintf(&overrides, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&entries, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeXML(s.name), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(sheets)+1)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", fmt.Sprintf(xlsxContentTypes, overrides.String())},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` +
+			entries.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + rels.String() + `</Relationships>`},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", p.name, err)
+		}
+		if _, err := io.WriteString(f, p.content); err != nil {
+			return fmt.Errorf("failed to write %s: %w", p.name, err)
+		}
+	}
+
+	for i, s := range sheets {
+		f, err := zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", s.name, err)
+		}
+		if err := writeWorksheet(f, s); err != nil {
+			return fmt.Errorf("failed to write %s: %w", s.name, err)
+		}
+	}
+
+	return zw.Close()
+}
+
+// writeWorksheet writes the XML of one sheet
+func writeWorksheet(w io.Writer, s sheet) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	bw.WriteString("<row>")
+	for _, name := range s.header {
+		writeTextCell(bw, name, styleHeader)
+	}
+	bw.WriteString("</row>")
+
+	for _, row := range s.rows {
+		bw.WriteString("<row>")
+		for _, c := range row {
+			switch {
+			case c.number && exactNumber(c.text):
+				fmt.Fprintf(bw, `<c s="%d"><v>%s</v></c>`, styleNumber, c.text)
+			case c.text == "":
+				bw.WriteString("<c/>")
+			default:
+				writeTextCell(bw, c.text, styleDefault)
+			}
+		}
+		bw.WriteString("</row>")
+	}
+
+	bw.WriteString("</sheetData></worksheet>")
+	return bw.Flush()
+}
+
+// writeTextCell writes an inline string cell
+func writeTextCell(w *bufio.Writer, s string, style int) {
+	fmt.Fprintf(w, `<c t="inlineStr" s="%d"><is><t xml:space="preserve">%s</t></is></c>`, style, escapeXML(s))
+}
+
+// exactNumber reports whether a decimal string has few enough significant
+// digits to be stored exactly as a spreadsheet number
+func exactNumber(s string) bool {
+	digits := strings.TrimLeft(strings.Replace(strings.TrimPrefix(s, "-"), ".", "", 1), "0")
+	if strings.Contains(s, ".") {
+		digits = strings.TrimRight(digits, "0")
+	}
+	return len(digits) <= maxExactDigits
+}
+
+// escapeXML escapes text for element content and attribute values
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// activityKinds maps activity kinds to their protobuf representation
+var activityKinds = map[models.ActivityKind]models.ActivityKindProto{
+    models.ActivityTransaction:  models.ActivityKindProto_ACTIVITY_KIND_TRANSACTION,
+    models.ActivityAssetAdded:   models.ActivityKindProto_ACTIVITY_KIND_ASSET_ADDED,
+    models.ActivityAssetRemoved: models.ActivityKindProto_ACTIVITY_KIND_ASSET_REMOVED,
+    models.ActivityAlert:        models.ActivityKindProto_ACTIVITY_KIND_ALERT,
+    models.ActivitySync:         models.ActivityKindProto_ACTIVITY_KIND_SYNC,
+}
+
+// GetActivityFeed handles requests for the merged activity of a portfolio
+func (h *PortfolioHandler) GetActivityFeed(ctx context.Context, req *models.GetActivityFeedRequest) (*models.GetActivityFeedResponse, error) {
+    startTime := time.Now()
+    method := "GetActivityFeed"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.PageSize < 0 {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    kinds := make([]string, len(req.Kinds))
+    for i, k := range req.Kinds {
+        kind, ok := convertFromProtoActivityKind(k)
+        if !ok {
+            metrics.Requests.WithLabelValues(method, "error").Inc()
+            return nil, invalidField("kinds", "must be specified activity kinds")
+        }
+        kinds[i] = string(kind)
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    items, nextToken, err := h.portfolioService.GetActivityFeed(ctx, portfolioID, kinds, int(req.PageSize), req.PageToken)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to get activity feed",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    result := make([]*models.ActivityItemProto, len(items))
+    for i, item := range items {
+        result[i] = convertToProtoActivityItem(item)
+    }
+
+    return &models.GetActivityFeedResponse{Items: result, NextPageToken: nextToken}, nil
+}
+
+// convertFromProtoActivityKind converts a protobuf activity kind, reporting
+// false for unspecified kinds
+func convertFromProtoActivityKind(k models.ActivityKindProto) (models.ActivityKind, bool) {
+    for kind, proto := range activityKinds {
+        if proto == k {
+            return kind, true
+        }
+    }
+    return "", false
+}
+
+// convertToProtoActivityItem converts an activity feed item to its protobuf
+// representation
+func convertToProtoActivityItem(item models.ActivityItem) *models.ActivityItemProto {
+    result := &models.ActivityItemProto{
+        Kind:       activityKinds[item.Kind],
+        Id:         item.ID.String(),
+        OccurredAt: timestamppb.New(item.OccurredAt),
+        Symbol:     item.Symbol,
+    }
+    if item.AssetID != uuid.Nil {
+        result.AssetId = item.AssetID.String()
+    }
+    if tx := item.Transaction; tx != nil {
+        result.Transaction = &models.TransactionProto{
+            TransactionId: tx.ID.String(),
+            PortfolioId:   tx.PortfolioID.String(),
+            AssetId:       tx.AssetID.String(),
+            Type:          transactionTypes[tx.Type],
+            Quantity:      tx.Amount.InexactFloat64(),
+            Price:         tx.Price.InexactFloat64(),
+            TotalAmount:   tx.Amount.Mul(tx.Price).InexactFloat64(),
+            Fee:           tx.Fee.InexactFloat64(),
+            Timestamp:     timestamppb.New(tx.Timestamp),
+        }
+    }
+    if e := item.Alert; e != nil {
+        result.Alert = &models.AlertEventProto{
+            EventId:     e.ID.String(),
+            RuleId:      e.RuleID.String(),
+            PortfolioId: e.PortfolioID.String(),
+            Kind:        alertKinds[e.Kind],
+            Threshold:   e.Threshold.InexactFloat64(),
+            Observed:    e.Observed.InexactFloat64(),
+            Symbol:      e.Symbol,
+            TriggeredAt: timestamppb.New(e.TriggeredAt),
+        }
+    }
+    if sync := item.Sync; sync != nil {
+        result.Sync = &models.SyncEventProto{
+            SyncId:   sync.ID.String(),
+            Source:   sync.Source,
+            Name:     sync.Name,
+            Imported: int32(sync.Imported),
+            Error:    sync.Error,
+            SyncedAt: timestamppb.New(sync.SyncedAt),
+        }
+    }
+    return result
+}
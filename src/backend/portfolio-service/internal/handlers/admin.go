@@ -0,0 +1,224 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/grpc/codes"                           // v1.50.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/admin"
+    "bookman/portfolio-service/internal/jobs"
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// AdminHandler implements the AdminService handlers for support and ops
+// tooling. Calls reach it only once the admin interceptor has authenticated
+// an operator holding the admin scope.
+type AdminHandler struct {
+    portfolios *PortfolioHandler
+    triggers   *jobs.Triggers
+    logger     *zap.Logger
+}
+
+// NewAdminHandler creates an admin handler serving portfolio data through
+// portfolios and running jobs through triggers
+func NewAdminHandler(portfolios *PortfolioHandler, triggers *jobs.Triggers, logger *zap.Logger) (*AdminHandler, error) {
+    if portfolios == nil || triggers == nil || logger == nil {
+        return nil, fmt.Errorf("invalid dependencies provided")
+    }
+
+    return &AdminHandler{
+        portfolios: portfolios,
+        triggers:   triggers,
+        logger:     logger.With(zap.String("component", "admin_handler")),
+    }, nil
+}
+
+// operatorField returns the log field naming the operator of a call
+func operatorField(ctx context.Context) zap.Field {
+    if operator, ok := admin.FromContext(ctx); ok {
+        return zap.String("operator", operator.Name)
+    }
+    return zap.Skip()
+}
+
+// ListUserPortfolios handles requests listing the portfolios of any user,
+// which are served as the user's own listing would be
+func (h *AdminHandler) ListUserPortfolios(ctx context.Context, req *models.ListPortfoliosRequest) (*models.ListPortfoliosResponse, error) {
+    if req != nil {
+        h.logger.Info("Listing user portfolios",
+            operatorField(ctx),
+            zap.String("user_id", req.UserId),
+        )
+    }
+    return h.portfolios.ListPortfolios(ctx, req)
+}
+
+// GetUserStats handles requests counting a user's portfolio data
+func (h *AdminHandler) GetUserStats(ctx context.Context, req *models.GetUserStatsRequest) (*models.GetUserStatsResponse, error) {
+    startTime := time.Now()
+    method := "GetUserStats"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+
+    stats, err := h.portfolios.portfolioService.GetUserStats(ctx, userID)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to get user stats",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+        )
+        return nil, h.portfolios.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+    h.logger.Info("User stats retrieved",
+        operatorField(ctx),
+        zap.String("user_id", req.UserId),
+    )
+
+    return &models.GetUserStatsResponse{
+        Stats: &models.UserStatsProto{
+            UserId:             stats.UserID.String(),
+            Portfolios:         int32(stats.Portfolios),
+            ArchivedPortfolios: int32(stats.ArchivedPortfolios),
+            Assets:             int32(stats.Assets),
+            Transactions:       int32(stats.Transactions),
+            Value:              stats.Value.InexactFloat64(),
+            GeneratedAt:        timestamppb.New(stats.GeneratedAt),
+        },
+    }, nil
+}
+
+// GetPlatformStats handles requests counting the portfolio data of all users
+func (h *AdminHandler) GetPlatformStats(ctx context.Context, req *models.GetPlatformStatsRequest) (*models.GetPlatformStatsResponse, error) {
+    startTime := time.Now()
+    method := "GetPlatformStats"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    stats, err := h.portfolios.portfolioService.GetPlatformStats(ctx)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to get platform stats", zap.Error(err))
+        return nil, h.portfolios.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.GetPlatformStatsResponse{
+        Stats: &models.PlatformStatsProto{
+            Users:                 int32(stats.Users),
+            Portfolios:            int32(stats.Portfolios),
+            Assets:                int32(stats.Assets),
+            Transactions:          int32(stats.Transactions),
+            AssetsUnderManagement: stats.AssetsUnderManagement.InexactFloat64(),
+            BaseCurrency:          stats.BaseCurrency,
+            GeneratedAt:           timestamppb.New(stats.GeneratedAt),
+        },
+    }, nil
+}
+
+// InvalidateCaches handles requests dropping the cached data of this replica
+func (h *AdminHandler) InvalidateCaches(ctx context.Context, req *models.InvalidateCachesRequest) (*models.InvalidateCachesResponse, error) {
+    startTime := time.Now()
+    method := "InvalidateCaches"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    caches, err := h.portfolios.portfolioService.InvalidateCaches(ctx, req.Symbols)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to invalidate caches", zap.Error(err))
+        return nil, h.portfolios.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+    h.logger.Info("Caches invalidated",
+        operatorField(ctx),
+        zap.Strings("caches", caches),
+    )
+
+    return &models.InvalidateCachesResponse{Caches: caches}, nil
+}
+
+// ListJobs handles requests for the jobs that can be triggered
+func (h *AdminHandler) ListJobs(ctx context.Context, req *models.ListJobsRequest) (*models.ListJobsResponse, error) {
+    metrics.Requests.WithLabelValues("ListJobs", "success").Inc()
+    return &models.ListJobsResponse{Jobs: h.triggers.Names()}, nil
+}
+
+// TriggerJob handles requests starting a background job outside its schedule
+func (h *AdminHandler) TriggerJob(ctx context.Context, req *models.TriggerJobRequest) (*models.TriggerJobResponse, error) {
+    startTime := time.Now()
+    method := "TriggerJob"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.Job == "" {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("job", "is required")
+    }
+
+    if err := h.triggers.Trigger(ctx, req.Job); err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to trigger job",
+            zap.Error(err),
+            operatorField(ctx),
+            zap.String("job", req.Job),
+        )
+        return nil, h.mapTriggerError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+    h.logger.Info("Job triggered",
+        operatorField(ctx),
+        zap.String("job", req.Job),
+    )
+
+    return &models.TriggerJobResponse{
+        Job:       req.Job,
+        StartedAt: timestamppb.New(startTime),
+    }, nil
+}
+
+// mapTriggerError maps job trigger errors to gRPC status errors
+func (h *AdminHandler) mapTriggerError(err error) error {
+    switch {
+    case errors.Is(err, jobs.ErrUnknownJob):
+        return statusError(codes.NotFound, "JOB_NOT_FOUND", err.Error())
+    case errors.Is(err, jobs.ErrJobRunning):
+        return statusError(codes.FailedPrecondition, "JOB_RUNNING", err.Error())
+    }
+    return errInternal
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "github.com/shopspring/decimal"                           // v1.3.1
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/grpc/codes"                           // v1.50.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// alertKinds maps alert kinds to their protobuf representation
+var alertKinds = map[models.AlertKind]models.AlertKindProto{
+    models.AlertValueDrop:       models.AlertKindProto_ALERT_KIND_VALUE_DROP,
+    models.AlertProfitLossAbove: models.AlertKindProto_ALERT_KIND_PNL_ABOVE,
+    models.AlertProfitLossBelow: models.AlertKindProto_ALERT_KIND_PNL_BELOW,
+    models.AlertStablecoinDepeg: models.AlertKindProto_ALERT_KIND_STABLECOIN_DEPEG,
+}
+
+// CreateAlertRule handles portfolio alert rule creation requests
+func (h *PortfolioHandler) CreateAlertRule(ctx context.Context, req *models.CreateAlertRuleRequest) (*models.CreateAlertRuleResponse, error) {
+    startTime := time.Now()
+    method := "CreateAlertRule"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.WindowSeconds < 0 {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    rule, err := h.portfolioService.CreateAlertRule(ctx, &models.AlertRule{
+        PortfolioID: portfolioID,
+        Kind:        convertFromProtoAlertKind(req.Kind),
+        Threshold:   decimal.NewFromFloat(req.Threshold),
+        Window:      time.Duration(req.WindowSeconds) * time.Second,
+    })
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to create alert rule",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapAlertError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+    h.logger.Info("Alert rule created",
+        zap.String("portfolio_id", req.PortfolioId),
+        zap.String("rule_id", rule.ID.String()),
+    )
+
+    return &models.CreateAlertRuleResponse{Rule: convertToProtoAlertRule(rule)}, nil
+}
+
+// ListAlertRules handles portfolio alert rule listing requests
+func (h *PortfolioHandler) ListAlertRules(ctx context.Context, req *models.ListAlertRulesRequest) (*models.ListAlertRulesResponse, error) {
+    startTime := time.Now()
+    method := "ListAlertRules"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    rules, err := h.portfolioService.ListAlertRules(ctx, portfolioID)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to list alert rules",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapAlertError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    result := make([]*models.AlertRuleProto, len(rules))
+    for i := range rules {
+        result[i] = convertToProtoAlertRule(&rules[i])
+    }
+
+    return &models.ListAlertRulesResponse{Rules: result}, nil
+}
+
+// DeleteAlertRule handles portfolio alert rule deletion requests
+func (h *PortfolioHandler) DeleteAlertRule(ctx context.Context, req *models.DeleteAlertRuleRequest) (*models.DeleteAlertRuleResponse, error) {
+    startTime := time.Now()
+    method := "DeleteAlertRule"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+    ruleID, err := uuid.Parse(req.RuleId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("rule_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    if err := h.portfolioService.DeleteAlertRule(ctx, portfolioID, ruleID); err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to delete alert rule",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+            zap.String("rule_id", req.RuleId),
+        )
+        return nil, h.mapAlertError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.DeleteAlertRuleResponse{Success: true}, nil
+}
+
+// ListAlertEvents handles triggered alert history requests
+func (h *PortfolioHandler) ListAlertEvents(ctx context.Context, req *models.ListAlertEventsRequest) (*models.ListAlertEventsResponse, error) {
+    startTime := time.Now()
+    method := "ListAlertEvents"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.PageSize < 0 {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    var before time.Time
+    if req.Before != nil {
+        before = req.Before.AsTime()
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    events, nextToken, err := h.portfolioService.ListAlertEvents(ctx, portfolioID, before, int(req.PageSize), req.PageToken)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to list alert events",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapAlertError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    result := make([]*models.AlertEventProto, len(events))
+    for i, e := range events {
+        result[i] = &models.AlertEventProto{
+            EventId:     e.ID.String(),
+            RuleId:      e.RuleID.String(),
+            PortfolioId: e.PortfolioID.String(),
+            Kind:        alertKinds[e.Kind],
+            Threshold:   e.Threshold.InexactFloat64(),
+            Observed:    e.Observed.InexactFloat64(),
+            Symbol:      e.Symbol,
+            TriggeredAt: timestamppb.New(e.TriggeredAt),
+        }
+    }
+
+    return &models.ListAlertEventsResponse{Events: result, NextPageToken: nextToken}, nil
+}
+
+// mapAlertError maps alert rule errors to gRPC status errors
+func (h *PortfolioHandler) mapAlertError(err error) error {
+    switch {
+    case errors.Is(err, services.ErrNotFound):
+        return statusError(codes.NotFound, "ALERT_RULE_NOT_FOUND", "alert rule not found")
+    }
+    return h.mapServiceError(err)
+}
+
+// convertToProtoAlertRule converts an alert rule to its protobuf representation
+func convertToProtoAlertRule(rule *models.AlertRule) *models.AlertRuleProto {
+    result := &models.AlertRuleProto{
+        RuleId:        rule.ID.String(),
+        PortfolioId:   rule.PortfolioID.String(),
+        Kind:          alertKinds[rule.Kind],
+        Threshold:     rule.Threshold.InexactFloat64(),
+        WindowSeconds: int64(rule.Window / time.Second),
+        Enabled:       rule.Enabled,
+        CreatedAt:     timestamppb.New(rule.CreatedAt),
+    }
+    if rule.LastTriggeredAt != nil {
+        result.LastTriggeredAt = timestamppb.New(*rule.LastTriggeredAt)
+    }
+    return result
+}
+
+// convertFromProtoAlertKind converts a protobuf alert kind; unknown values map
+// to an empty kind and fail validation
+func convertFromProtoAlertKind(k models.AlertKindProto) models.AlertKind {
+    for kind, proto := range alertKinds {
+        if proto == k {
+            return kind
+        }
+    }
+    return ""
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// GetAllocation handles portfolio allocation breakdown requests
+func (h *PortfolioHandler) GetAllocation(ctx context.Context, req *models.GetAllocationRequest) (*models.GetAllocationResponse, error) {
+    startTime := time.Now()
+    method := "GetAllocation"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    allocation, err := h.portfolioService.GetAllocation(ctx, portfolioID, req.IncludeDust, req.Tag)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to get allocation",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.GetAllocationResponse{
+        Allocation: convertToProtoAllocation(allocation),
+    }, nil
+}
+
+// convertToProtoAllocation converts an allocation to its protobuf representation
+func convertToProtoAllocation(allocation *models.Allocation) *models.AllocationProto {
+    return &models.AllocationProto{
+        PortfolioId:     allocation.PortfolioID.String(),
+        BaseCurrency:    allocation.BaseCurrency,
+        TotalValue:      allocation.TotalValue.InexactFloat64(),
+        BySymbol:        convertToProtoSlices(allocation.BySymbol),
+        ByType:          convertToProtoSlices(allocation.ByType),
+        ByCategory:      convertToProtoSlices(allocation.ByCategory),
+        DustValue:       allocation.DustValue.InexactFloat64(),
+        CalculatedAt:    timestamppb.New(allocation.CalculatedAt),
+        PricesStale:     allocation.PricesStale,
+        DelistedSymbols: allocation.DelistedSymbols,
+    }
+}
+
+// convertToProtoSlices converts allocation slices to their protobuf representation
+func convertToProtoSlices(slices []models.AllocationSlice) []*models.AllocationSliceProto {
+    result := make([]*models.AllocationSliceProto, len(slices))
+    for i, s := range slices {
+        result[i] = &models.AllocationSliceProto{
+            Key:        s.Key,
+            Value:      s.Value.InexactFloat64(),
+            Percentage: s.Percentage.InexactFloat64(),
+        }
+        if s.PriceAsOf != nil {
+            result[i].PriceAsOf = timestamppb.New(*s.PriceAsOf)
+            result[i].PriceStale = s.PriceStale
+        }
+    }
+    return result
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// SearchAssets handles requests for every holding of a symbol across a
+// user's portfolios
+func (h *PortfolioHandler) SearchAssets(ctx context.Context, req *models.SearchAssetsRequest) (*models.SearchAssetsResponse, error) {
+    startTime := time.Now()
+    method := "SearchAssets"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid user ID",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+        )
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    result, err := h.portfolioService.SearchAssets(ctx, userID, req.Symbol, req.IncludeArchived)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to search assets",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+            zap.String("symbol", req.Symbol),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    holdings := make([]*models.AssetHoldingProto, len(result.Holdings))
+    for i, holding := range result.Holdings {
+        holdings[i] = &models.AssetHoldingProto{
+            PortfolioId:   holding.PortfolioID.String(),
+            PortfolioName: holding.PortfolioName,
+            AssetId:       holding.AssetID.String(),
+            Amount:        holding.Amount.InexactFloat64(),
+            CurrentValue:  holding.CurrentValue.InexactFloat64(),
+            Archived:      holding.Archived,
+        }
+    }
+
+    return &models.SearchAssetsResponse{
+        Symbol:      result.Symbol,
+        Holdings:    holdings,
+        TotalAmount: result.TotalAmount.InexactFloat64(),
+        TotalValue:  result.TotalValue.InexactFloat64(),
+    }, nil
+}
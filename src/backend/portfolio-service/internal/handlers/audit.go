@@ -0,0 +1,69 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// ListAuditEntries handles portfolio audit trail requests
+func (h *PortfolioHandler) ListAuditEntries(ctx context.Context, req *models.ListAuditEntriesRequest) (*models.ListAuditEntriesResponse, error) {
+    startTime := time.Now()
+    method := "ListAuditEntries"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.PageSize < 0 {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    var before time.Time
+    if req.Before != nil {
+        before = req.Before.AsTime()
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    entries, nextToken, err := h.portfolioService.ListAuditEntries(ctx, portfolioID, before, int(req.PageSize), req.PageToken)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to list audit entries",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    result := make([]*models.AuditEntryProto, len(entries))
+    for i, e := range entries {
+        result[i] = &models.AuditEntryProto{
+            EntryId:   e.ID,
+            TableName: e.TableName,
+            Operation: e.Operation,
+            OldData:   string(e.OldData),
+            NewData:   string(e.NewData),
+            ChangedBy: e.ChangedBy.String(),
+            ChangedAt: timestamppb.New(e.ChangedAt),
+        }
+    }
+
+    return &models.ListAuditEntriesResponse{Entries: result, NextPageToken: nextToken}, nil
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// assetChanges maps holding changes to their protobuf representation
+var assetChanges = map[models.AssetChange]models.AssetChangeProto{
+    models.AssetChangeAdded:   models.AssetChangeProto_ASSET_CHANGE_ADDED,
+    models.AssetChangeRemoved: models.AssetChangeProto_ASSET_CHANGE_REMOVED,
+    models.AssetChangeHeld:    models.AssetChangeProto_ASSET_CHANGE_HELD,
+}
+
+// ComparePortfolio handles requests for what changed in a portfolio between
+// two times
+func (h *PortfolioHandler) ComparePortfolio(ctx context.Context, req *models.ComparePortfolioRequest) (*models.ComparePortfolioResponse, error) {
+    startTime := time.Now()
+    method := "ComparePortfolio"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.From == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    var to time.Time
+    if req.To != nil {
+        to = req.To.AsTime()
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    comparison, err := h.portfolioService.ComparePortfolio(ctx, portfolioID, req.From.AsTime(), to)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to compare portfolio",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    assets := make([]*models.AssetComparisonProto, len(comparison.Assets))
+    for i, a := range comparison.Assets {
+        assets[i] = &models.AssetComparisonProto{
+            AssetId:                a.AssetID.String(),
+            Symbol:                 a.Symbol,
+            Change:                 assetChanges[a.Change],
+            StartAmount:            a.StartAmount.InexactFloat64(),
+            EndAmount:              a.EndAmount.InexactFloat64(),
+            AmountDelta:            a.AmountDelta.InexactFloat64(),
+            StartValue:             a.StartValue.InexactFloat64(),
+            EndValue:               a.EndValue.InexactFloat64(),
+            ValueDelta:             a.ValueDelta.InexactFloat64(),
+            ProfitLossContribution: a.ProfitLossContribution.InexactFloat64(),
+            ContributionPercentage: a.ContributionPercentage.InexactFloat64(),
+        }
+    }
+
+    result := &models.PortfolioComparisonProto{
+        PortfolioId:     comparison.PortfolioID.String(),
+        From:            timestamppb.New(comparison.From),
+        To:              timestamppb.New(comparison.To),
+        EndCapturedAt:   timestamppb.New(comparison.EndCapturedAt),
+        StartValue:      comparison.StartValue.InexactFloat64(),
+        EndValue:        comparison.EndValue.InexactFloat64(),
+        ValueDelta:      comparison.ValueDelta.InexactFloat64(),
+        ProfitLossDelta: comparison.ProfitLossDelta.InexactFloat64(),
+        Assets:          assets,
+        GeneratedAt:     timestamppb.New(comparison.GeneratedAt),
+    }
+    if !comparison.StartCapturedAt.IsZero() {
+        result.StartCapturedAt = timestamppb.New(comparison.StartCapturedAt)
+    }
+
+    return &models.ComparePortfolioResponse{Comparison: result}, nil
+}
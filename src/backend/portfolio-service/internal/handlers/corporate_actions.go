@@ -0,0 +1,92 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"          // v1.3.0
+    "github.com/shopspring/decimal"   // v1.3.1
+    "go.uber.org/zap"                // v1.24.0
+    "google.golang.org/grpc/codes"   // v1.50.0
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// RecordCorporateAction handles requests to credit the asset of an airdrop
+// or chain fork as a new position
+func (h *PortfolioHandler) RecordCorporateAction(ctx context.Context, req *models.RecordCorporateActionRequest) (*models.RecordCorporateActionResponse, error) {
+    startTime := time.Now()
+    method := "RecordCorporateAction"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.Type == "" || req.Symbol == "" || req.Quantity <= 0 {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    action := &models.CorporateAction{
+        PortfolioID: portfolioID,
+        Type:        req.Type,
+        AssetType:   req.AssetType,
+        Symbol:      req.Symbol,
+        Amount:      decimal.NewFromFloat(req.Quantity),
+        Price:       decimal.NewFromFloat(req.Price),
+        ParentPrice: decimal.NewFromFloat(req.ParentPrice),
+    }
+    if req.ParentAssetId != "" {
+        action.ParentAssetID, err = uuid.Parse(req.ParentAssetId)
+        if err != nil {
+            metrics.Requests.WithLabelValues(method, "error").Inc()
+            return nil, invalidField("parent_asset_id", "must be a UUID")
+        }
+    }
+    if req.Timestamp != nil {
+        action.Timestamp = req.Timestamp.AsTime()
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    asset, t, err := h.portfolioService.RecordCorporateAction(ctx, action)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to record corporate action",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+            zap.String("type", req.Type),
+            zap.String("symbol", req.Symbol),
+        )
+        return nil, h.mapCorporateActionError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.RecordCorporateActionResponse{
+        AssetId:       asset.ID.String(),
+        TransactionId: t.ID.String(),
+        CostBasis:     asset.CostBasis.InexactFloat64(),
+    }, nil
+}
+
+// mapCorporateActionError maps corporate action errors to gRPC status errors
+func (h *PortfolioHandler) mapCorporateActionError(err error) error {
+    switch {
+    case errors.Is(err, services.ErrNotFound):
+        return statusError(codes.NotFound, "ASSET_NOT_FOUND", "parent asset not found")
+    case errors.Is(err, models.ErrPortfolioFull):
+        return statusError(codes.ResourceExhausted, "ASSET_LIMIT_EXCEEDED", "portfolio has reached its asset limit")
+    }
+    return h.mapServiceError(err)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/grpc/codes"                           // v1.50.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// GetCorrelationMatrix handles asset return correlation requests
+func (h *PortfolioHandler) GetCorrelationMatrix(ctx context.Context, req *models.GetCorrelationMatrixRequest) (*models.GetCorrelationMatrixResponse, error) {
+    startTime := time.Now()
+    method := "GetCorrelationMatrix"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.WindowDays < 0 {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    matrix, err := h.portfolioService.GetCorrelationMatrix(ctx, portfolioID, int(req.WindowDays))
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to get correlation matrix",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        switch {
+        case errors.Is(err, services.ErrInsufficientData):
+            return nil, statusError(codes.FailedPrecondition, "INSUFFICIENT_MARKET_DATA", "not enough price history to correlate assets")
+        case errors.Is(err, services.ErrFeatureDisabled):
+            return nil, statusError(codes.Unimplemented, "FEATURE_DISABLED", "correlation matrix is not enabled")
+        }
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    rows := make([]*models.CorrelationRowProto, len(matrix.Values))
+    for i, values := range matrix.Values {
+        rows[i] = &models.CorrelationRowProto{Values: values}
+    }
+
+    return &models.GetCorrelationMatrixResponse{
+        Matrix: &models.CorrelationMatrixProto{
+            PortfolioId:      matrix.PortfolioID.String(),
+            Symbols:          matrix.Symbols,
+            Rows:             rows,
+            Observations:     int32(matrix.Observations),
+            WindowStart:      timestamppb.New(matrix.WindowStart),
+            WindowEnd:        timestamppb.New(matrix.WindowEnd),
+            UncoveredSymbols: matrix.UncoveredSymbols,
+        },
+    }, nil
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"                           // v1.3.0
+    "github.com/shopspring/decimal"                    // v1.3.1
+    "go.uber.org/zap"                                 // v1.24.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// DefineCustomAsset handles requests to define an asset no market data
+// provider lists, optionally with its manual price
+func (h *PortfolioHandler) DefineCustomAsset(ctx context.Context, req *models.DefineCustomAssetRequest) (*models.DefineCustomAssetResponse, error) {
+    startTime := time.Now()
+    method := "DefineCustomAsset"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    var price *decimal.Decimal
+    if req.Price != nil {
+        p := decimal.NewFromFloat(*req.Price)
+        price = &p
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    asset, err := h.portfolioService.DefineCustomAsset(ctx, &models.CustomAsset{
+        PortfolioID: portfolioID,
+        Symbol:      req.Symbol,
+        Name:        req.Name,
+        Decimals:    req.Decimals,
+    }, price)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to define custom asset",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+            zap.String("symbol", req.Symbol),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+    h.logger.Info("Custom asset defined",
+        zap.String("portfolio_id", req.PortfolioId),
+        zap.String("symbol", asset.Symbol),
+        zap.Bool("priced", price != nil),
+    )
+
+    return &models.DefineCustomAssetResponse{
+        Asset: &models.CustomAssetProto{
+            CustomAssetId: asset.ID.String(),
+            PortfolioId:   asset.PortfolioID.String(),
+            Symbol:        asset.Symbol,
+            Name:          asset.Name,
+            Decimals:      asset.Decimals,
+            CreatedAt:     timestamppb.New(asset.CreatedAt),
+        },
+    }, nil
+}
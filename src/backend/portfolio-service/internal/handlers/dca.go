@@ -0,0 +1,232 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "github.com/shopspring/decimal"                           // v1.3.1
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/grpc/codes"                           // v1.50.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// dcaFrequencies maps DCA frequencies to their protobuf representation
+var dcaFrequencies = map[models.DCAFrequency]models.DCAFrequencyProto{
+    models.DCAWeekly:   models.DCAFrequencyProto_DCA_FREQUENCY_WEEKLY,
+    models.DCABiweekly: models.DCAFrequencyProto_DCA_FREQUENCY_BIWEEKLY,
+    models.DCAMonthly:  models.DCAFrequencyProto_DCA_FREQUENCY_MONTHLY,
+}
+
+// PlanDCA handles dollar-cost averaging plan requests
+func (h *PortfolioHandler) PlanDCA(ctx context.Context, req *models.PlanDCARequest) (*models.PlanDCAResponse, error) {
+    startTime := time.Now()
+    method := "PlanDCA"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    plan := services.DCARequest{
+        Contribution: decimal.NewFromFloat(req.Contribution).Round(2),
+        Frequency:    convertFromProtoFrequency(req.Frequency),
+        Periods:      int(req.Periods),
+        Targets:      make([]models.AllocationTarget, len(req.Targets)),
+    }
+    if req.StartDate != nil {
+        plan.Start = req.StartDate.AsTime()
+    }
+    for i, t := range req.Targets {
+        plan.Targets[i] = models.AllocationTarget{
+            Dimension:  convertFromProtoDimension(t.Dimension),
+            Key:        t.Key,
+            Percentage: decimal.NewFromFloat(t.Percentage),
+        }
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    created, err := h.portfolioService.PlanDCA(ctx, portfolioID, plan)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to plan DCA",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapDCAError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.PlanDCAResponse{Plan: convertToProtoDCAPlan(created)}, nil
+}
+
+// GetDCAPlan handles dollar-cost averaging plan retrieval requests
+func (h *PortfolioHandler) GetDCAPlan(ctx context.Context, req *models.GetDCAPlanRequest) (*models.GetDCAPlanResponse, error) {
+    startTime := time.Now()
+    method := "GetDCAPlan"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    planID, err := uuid.Parse(req.PlanId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("plan_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    plan, err := h.portfolioService.GetDCAPlan(ctx, planID)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to get DCA plan",
+            zap.Error(err),
+            zap.String("plan_id", req.PlanId),
+        )
+        return nil, h.mapDCAError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.GetDCAPlanResponse{Plan: convertToProtoDCAPlan(plan)}, nil
+}
+
+// MarkDCAExecuted handles requests marking a DCA installment as executed
+func (h *PortfolioHandler) MarkDCAExecuted(ctx context.Context, req *models.MarkDCAExecutedRequest) (*models.MarkDCAExecutedResponse, error) {
+    startTime := time.Now()
+    method := "MarkDCAExecuted"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.Sequence <= 0 {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    planID, err := uuid.Parse(req.PlanId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("plan_id", "must be a UUID")
+    }
+
+    var executedAt time.Time
+    if req.ExecutedAt != nil {
+        executedAt = req.ExecutedAt.AsTime()
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    plan, err := h.portfolioService.MarkDCAInstallmentExecuted(ctx, planID, int(req.Sequence), executedAt)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to mark DCA installment executed",
+            zap.Error(err),
+            zap.String("plan_id", req.PlanId),
+            zap.Int32("sequence", req.Sequence),
+        )
+        return nil, h.mapDCAError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+    h.logger.Info("DCA installment executed",
+        zap.String("plan_id", req.PlanId),
+        zap.Int32("sequence", req.Sequence),
+    )
+
+    return &models.MarkDCAExecutedResponse{Plan: convertToProtoDCAPlan(plan)}, nil
+}
+
+// mapDCAError maps DCA planning errors to gRPC status errors
+func (h *PortfolioHandler) mapDCAError(err error) error {
+    switch {
+    case errors.Is(err, services.ErrNotFound):
+        return statusError(codes.NotFound, "DCA_PLAN_NOT_FOUND", "DCA plan or installment not found")
+    }
+    return h.mapServiceError(err)
+}
+
+// convertToProtoDCAPlan converts a DCA plan to its protobuf representation
+func convertToProtoDCAPlan(plan *models.DCAPlan) *models.DCAPlanProto {
+    targets := make([]*models.AllocationTargetProto, len(plan.Targets))
+    for i, t := range plan.Targets {
+        targets[i] = &models.AllocationTargetProto{
+            Dimension:  targetDimensions[t.Dimension],
+            Key:        t.Key,
+            Percentage: t.Percentage.InexactFloat64(),
+        }
+    }
+
+    installments := make([]*models.DCAInstallmentProto, len(plan.Installments))
+    for i, inst := range plan.Installments {
+        purchases := make([]*models.DCAPurchaseProto, len(inst.Purchases))
+        for j, p := range inst.Purchases {
+            purchases[j] = &models.DCAPurchaseProto{
+                Symbol: p.Symbol,
+                Amount: p.Amount.InexactFloat64(),
+            }
+        }
+
+        installments[i] = &models.DCAInstallmentProto{
+            Sequence:            int32(inst.Sequence),
+            ScheduledAt:         timestamppb.New(inst.ScheduledAt),
+            Purchases:           purchases,
+            ProjectedAllocation: convertToProtoSlices(inst.ProjectedAllocation),
+        }
+        if inst.ExecutedAt != nil {
+            installments[i].ExecutedAt = timestamppb.New(*inst.ExecutedAt)
+        }
+    }
+
+    return &models.DCAPlanProto{
+        PlanId:       plan.ID.String(),
+        PortfolioId:  plan.PortfolioID.String(),
+        Contribution: plan.Contribution.InexactFloat64(),
+        Frequency:    dcaFrequencies[plan.Frequency],
+        Targets:      targets,
+        Installments: installments,
+        CreatedAt:    timestamppb.New(plan.CreatedAt),
+    }
+}
+
+// convertFromProtoFrequency converts a protobuf DCA frequency; unknown values
+// map to an empty frequency and fail validation
+func convertFromProtoFrequency(f models.DCAFrequencyProto) models.DCAFrequency {
+    for frequency, proto := range dcaFrequencies {
+        if proto == f {
+            return frequency
+        }
+    }
+    return ""
+}
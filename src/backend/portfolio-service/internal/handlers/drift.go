@@ -0,0 +1,157 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "github.com/shopspring/decimal"                           // v1.3.1
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// targetDimensions maps target dimensions to their protobuf representation
+var targetDimensions = map[models.TargetDimension]models.TargetDimensionProto{
+    models.TargetBySymbol:   models.TargetDimensionProto_TARGET_DIMENSION_SYMBOL,
+    models.TargetByCategory: models.TargetDimensionProto_TARGET_DIMENSION_CATEGORY,
+}
+
+// SetAllocationTargets handles allocation target replacement requests
+func (h *PortfolioHandler) SetAllocationTargets(ctx context.Context, req *models.SetAllocationTargetsRequest) (*models.SetAllocationTargetsResponse, error) {
+    startTime := time.Now()
+    method := "SetAllocationTargets"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    targets := make([]models.AllocationTarget, len(req.Targets))
+    for i, t := range req.Targets {
+        targets[i] = models.AllocationTarget{
+            Dimension:  convertFromProtoDimension(t.Dimension),
+            Key:        t.Key,
+            Percentage: decimal.NewFromFloat(t.Percentage),
+        }
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    stored, err := h.portfolioService.SetAllocationTargets(ctx, portfolioID, targets)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to set allocation targets",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+    h.logger.Info("Allocation targets updated",
+        zap.String("portfolio_id", req.PortfolioId),
+        zap.Int("targets", len(stored)),
+    )
+
+    result := make([]*models.AllocationTargetProto, len(stored))
+    for i, t := range stored {
+        result[i] = &models.AllocationTargetProto{
+            Dimension:  targetDimensions[t.Dimension],
+            Key:        t.Key,
+            Percentage: t.Percentage.InexactFloat64(),
+        }
+    }
+
+    return &models.SetAllocationTargetsResponse{Targets: result}, nil
+}
+
+// GetDrift handles allocation drift requests
+func (h *PortfolioHandler) GetDrift(ctx context.Context, req *models.GetDriftRequest) (*models.GetDriftResponse, error) {
+    startTime := time.Now()
+    method := "GetDrift"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    drift, err := h.portfolioService.GetDrift(ctx, portfolioID, decimal.NewFromFloat(req.Threshold))
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to get allocation drift",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    entries := make([]*models.DriftEntryProto, len(drift.Entries))
+    for i, e := range drift.Entries {
+        entries[i] = &models.DriftEntryProto{
+            Dimension: targetDimensions[e.Dimension],
+            Key:       e.Key,
+            Target:    e.Target.InexactFloat64(),
+            Actual:    e.Actual.InexactFloat64(),
+            Drift:     e.Drift.InexactFloat64(),
+            Breached:  e.Breached,
+        }
+    }
+
+    return &models.GetDriftResponse{
+        Drift: &models.DriftProto{
+            PortfolioId:  drift.PortfolioID.String(),
+            Threshold:    drift.Threshold.InexactFloat64(),
+            Entries:      entries,
+            Breached:     drift.Breached,
+            CalculatedAt: timestamppb.New(drift.CalculatedAt),
+        },
+    }, nil
+}
+
+// convertFromProtoDimension converts a protobuf target dimension; unknown
+// values map to an empty dimension and fail validation
+func convertFromProtoDimension(d models.TargetDimensionProto) models.TargetDimension {
+    for dimension, proto := range targetDimensions {
+        if proto == d {
+            return dimension
+        }
+    }
+    return ""
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+    "go.uber.org/zap"                 // v1.24.0
+    "google.golang.org/grpc/codes"    // v1.50.0
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// SetDustSettings handles dust threshold update requests
+func (h *PortfolioHandler) SetDustSettings(ctx context.Context, req *models.SetDustSettingsRequest) (*models.SetDustSettingsResponse, error) {
+    startTime := time.Now()
+    method := "SetDustSettings"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.Settings == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.Settings.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.Settings.PortfolioId),
+        )
+        return nil, invalidField("settings.portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    stored, err := h.portfolioService.SetDustSettings(ctx, &models.DustSettings{
+        PortfolioID: portfolioID,
+        Threshold:   decimal.NewFromFloat(req.Settings.Threshold),
+        Hidden:      req.Settings.HideDust,
+    })
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to set dust settings",
+            zap.Error(err),
+            zap.String("portfolio_id", req.Settings.PortfolioId),
+        )
+        return nil, h.mapDustError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+    h.logger.Info("Dust settings updated",
+        zap.String("portfolio_id", req.Settings.PortfolioId),
+        zap.String("threshold", stored.Threshold.String()),
+        zap.Bool("hide_dust", stored.Hidden),
+    )
+
+    return &models.SetDustSettingsResponse{
+        Settings: &models.DustSettingsProto{
+            PortfolioId: stored.PortfolioID.String(),
+            Threshold:   stored.Threshold.InexactFloat64(),
+            HideDust:    stored.Hidden,
+        },
+    }, nil
+}
+
+// mapDustError maps dust settings errors to gRPC status errors
+func (h *PortfolioHandler) mapDustError(err error) error {
+    switch {
+    case errors.Is(err, services.ErrNotFound):
+        return statusError(codes.NotFound, "PORTFOLIO_NOT_FOUND", "portfolio not found")
+    }
+    return h.mapServiceError(err)
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "google.golang.org/genproto/googleapis/rpc/errdetails"
+    "google.golang.org/grpc/codes"                           // v1.50.0
+    "google.golang.org/grpc/status"                          // v1.50.0
+    "google.golang.org/protobuf/runtime/protoiface"
+    "google.golang.org/protobuf/types/known/durationpb"
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+    "bookman/portfolio-service/internal/services"
+)
+
+// errorDomain identifies the service in the ErrorInfo of returned errors
+const errorDomain = "portfolio.bookman.ai"
+
+// Delays clients are advised to wait before retrying transient failures
+const (
+    conflictRetryDelay    = time.Millisecond * 200
+    unavailableRetryDelay = time.Second * 2
+    rateLimitRetryDelay   = time.Second * 30
+)
+
+// serviceError describes how a service error is returned to clients
+type serviceError struct {
+    target error
+    code   codes.Code
+    reason string
+    // message replaces the error text when set; otherwise the service error
+    // message is returned, as it describes the client's mistake
+    message string
+}
+
+// serviceErrors maps service errors to status codes and ErrorInfo reasons in
+// the order they are matched
+var serviceErrors = []serviceError{
+    {target: services.ErrConcurrentModification, code: codes.Aborted, reason: "CONCURRENT_MODIFICATION", message: "portfolio is being modified concurrently; retry the request"},
+    {target: services.ErrInvalidPageToken, code: codes.InvalidArgument, reason: "INVALID_PAGE_TOKEN", message: "invalid or expired page token"},
+    {target: services.ErrInvalidListQuery, code: codes.InvalidArgument, reason: "INVALID_LIST_QUERY"},
+    {target: services.ErrInvalidPortfolio, code: codes.InvalidArgument, reason: "INVALID_PORTFOLIO"},
+    {target: services.ErrInvalidAsset, code: codes.InvalidArgument, reason: "INVALID_ASSET"},
+    {target: services.ErrInvalidTransaction, code: codes.InvalidArgument, reason: "INVALID_TRANSACTION"},
+    {target: services.ErrInvalidTarget, code: codes.InvalidArgument, reason: "INVALID_ALLOCATION_TARGET"},
+    {target: services.ErrInvalidDCAPlan, code: codes.InvalidArgument, reason: "INVALID_DCA_PLAN"},
+    {target: services.ErrInvalidAlertRule, code: codes.InvalidArgument, reason: "INVALID_ALERT_RULE"},
+    {target: services.ErrInvalidWebhook, code: codes.InvalidArgument, reason: "INVALID_WEBHOOK"},
+    {target: services.ErrInvalidReportSchedule, code: codes.InvalidArgument, reason: "INVALID_REPORT_SCHEDULE"},
+    {target: services.ErrInvalidStakingPosition, code: codes.InvalidArgument, reason: "INVALID_STAKING_POSITION"},
+    {target: services.ErrInvalidCorporateAction, code: codes.InvalidArgument, reason: "INVALID_CORPORATE_ACTION"},
+    {target: services.ErrInvalidSymbolMigration, code: codes.InvalidArgument, reason: "INVALID_SYMBOL_MIGRATION"},
+    {target: services.ErrInvalidDustThreshold, code: codes.InvalidArgument, reason: "INVALID_DUST_THRESHOLD"},
+    {target: services.ErrInvalidTag, code: codes.InvalidArgument, reason: "INVALID_TAG"},
+    {target: services.ErrInvalidShare, code: codes.InvalidArgument, reason: "INVALID_SHARE"},
+    {target: services.ErrInvalidManualPrice, code: codes.InvalidArgument, reason: "INVALID_MANUAL_PRICE"},
+    {target: services.ErrInvalidCustomAsset, code: codes.InvalidArgument, reason: "INVALID_CUSTOM_ASSET"},
+    {target: services.ErrInvalidTransferLink, code: codes.InvalidArgument, reason: "INVALID_TRANSFER_LINK"},
+    {target: services.ErrInvalidIncomeReport, code: codes.InvalidArgument, reason: "INVALID_INCOME_REPORT"},
+    {target: services.ErrInvalidHarvestCriteria, code: codes.InvalidArgument, reason: "INVALID_HARVEST_CRITERIA"},
+    {target: services.ErrInvalidHoldingPeriod, code: codes.InvalidArgument, reason: "INVALID_HOLDING_PERIOD"},
+    {target: services.ErrInvalidTaxSettings, code: codes.InvalidArgument, reason: "INVALID_TAX_SETTINGS"},
+    {target: services.ErrInvalidComparison, code: codes.InvalidArgument, reason: "INVALID_COMPARISON"},
+    {target: services.ErrNotFound, code: codes.NotFound, reason: "NOT_FOUND"},
+    {target: services.ErrLimitExceeded, code: codes.ResourceExhausted, reason: "LIMIT_EXCEEDED"},
+    {target: services.ErrReadOnlyPortfolio, code: codes.FailedPrecondition, reason: "READ_ONLY_PORTFOLIO", message: "portfolio of a tracked wallet is read-only"},
+    {target: services.ErrWalletAlreadyTracked, code: codes.AlreadyExists, reason: "WALLET_ALREADY_TRACKED", message: "wallet already tracked"},
+    {target: services.ErrStakingPositionExists, code: codes.AlreadyExists, reason: "STAKING_POSITION_EXISTS", message: "asset already has a staking position"},
+    {target: services.ErrCustomAssetExists, code: codes.AlreadyExists, reason: "CUSTOM_ASSET_EXISTS", message: "custom asset already defined"},
+    {target: services.ErrTransferLinked, code: codes.FailedPrecondition, reason: "TRANSFER_LINKED", message: "transfer is linked; unlink it first"},
+    {target: services.ErrInsufficientData, code: codes.FailedPrecondition, reason: "INSUFFICIENT_MARKET_DATA", message: "not enough market data for the request"},
+    {target: services.ErrShareRequired, code: codes.Unauthenticated, reason: "SHARE_REQUIRED", message: "a share token is required"},
+    {target: services.ErrFeatureDisabled, code: codes.Unimplemented, reason: "FEATURE_DISABLED", message: "feature is not enabled"},
+}
+
+// mapServiceError converts a service error to a status error with ErrorInfo
+// details, adding field violations to invalid requests and retry advice to
+// transient failures. Errors of unknown cause are returned as internal
+// without their message.
+func (h *PortfolioHandler) mapServiceError(err error) error {
+    switch {
+    case errors.Is(err, context.DeadlineExceeded), repository.IsCanceled(err):
+        return statusError(codes.DeadlineExceeded, "DEADLINE_EXCEEDED", "request deadline exceeded")
+    case errors.Is(err, context.Canceled):
+        return statusError(codes.Canceled, "CANCELED", "request canceled")
+    case errors.Is(err, services.ErrRepositoryOperation) && repository.IsTransient(err):
+        return statusError(codes.Unavailable, "STORAGE_UNAVAILABLE", "storage temporarily unavailable, retry later",
+            retryAfter(unavailableRetryDelay))
+    }
+
+    for _, e := range serviceErrors {
+        if !errors.Is(err, e.target) {
+            continue
+        }
+
+        message := e.message
+        if message == "" {
+            message = err.Error()
+        }
+        var details []protoiface.MessageV1
+        switch e.code {
+        case codes.InvalidArgument:
+            if violations := fieldViolations(err); violations != nil {
+                details = append(details, violations)
+            }
+        case codes.Aborted:
+            details = append(details, retryAfter(conflictRetryDelay))
+        }
+        return statusError(e.code, e.reason, message, details...)
+    }
+
+    return errInternal
+}
+
+// statusError returns a status error carrying an ErrorInfo with the reason,
+// followed by any further details
+func statusError(code codes.Code, reason, message string, details ...protoiface.MessageV1) error {
+    st := status.New(code, message)
+    info := &errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}
+    detailed, err := st.WithDetails(append([]protoiface.MessageV1{info}, details...)...)
+    if err != nil {
+        return st.Err()
+    }
+    return detailed.Err()
+}
+
+// invalidField returns an invalid argument error with a field violation of
+// one request field
+func invalidField(field, description string) error {
+    return statusError(codes.InvalidArgument, "INVALID_REQUEST", fmt.Sprintf("invalid %s: %s", field, description),
+        &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+            {Field: field, Description: description},
+        }})
+}
+
+// unavailable returns an error of a transient failure advising clients when
+// to retry
+func unavailable(reason, message string, delay time.Duration) error {
+    return statusError(codes.Unavailable, reason, message, retryAfter(delay))
+}
+
+// fieldViolations returns the field violation of a validation error of a
+// request field, or nil when the error does not name one
+func fieldViolations(err error) *errdetails.BadRequest {
+    var fieldErr *models.FieldError
+    if !errors.As(err, &fieldErr) {
+        return nil
+    }
+    return &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+        {Field: fieldErr.Field, Description: fieldErr.Error()},
+    }}
+}
+
+// retryAfter advises clients to retry after a delay
+func retryAfter(delay time.Duration) *errdetails.RetryInfo {
+    return &errdetails.RetryInfo{RetryDelay: durationpb.New(delay)}
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"          // v1.3.0
+    "go.uber.org/zap"                // v1.24.0
+    "google.golang.org/grpc/codes"   // v1.50.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/exchanges"
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// ImportExchange handles exchange account import requests. Credentials are
+// never logged.
+func (h *PortfolioHandler) ImportExchange(ctx context.Context, req *models.ImportExchangeRequest) (*models.ImportExchangeResponse, error) {
+    startTime := time.Now()
+    method := "ImportExchange"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.Exchange == "" {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    creds := exchanges.Credentials{APIKey: req.ApiKey, APISecret: req.ApiSecret}
+    result, err := h.portfolioService.ImportExchange(ctx, portfolioID, req.Exchange, creds, req.Cursor)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to import exchange account",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+            zap.String("exchange", req.Exchange),
+        )
+        return nil, h.mapExchangeError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return convertToProtoExchangeImport(result), nil
+}
+
+// ConnectExchange handles requests to connect an exchange account for
+// background sync. Credentials are never logged.
+func (h *PortfolioHandler) ConnectExchange(ctx context.Context, req *models.ConnectExchangeRequest) (*models.ConnectExchangeResponse, error) {
+    startTime := time.Now()
+    method := "ConnectExchange"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.Exchange == "" {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    creds := exchanges.Credentials{APIKey: req.ApiKey, APISecret: req.ApiSecret}
+    account, err := h.portfolioService.ConnectExchange(ctx, portfolioID, req.Exchange, creds)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to connect exchange account",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+            zap.String("exchange", req.Exchange),
+        )
+        return nil, h.mapExchangeError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.ConnectExchangeResponse{Account: convertToProtoExchangeAccount(account)}, nil
+}
+
+// ListExchangeAccounts handles requests to list the exchange accounts
+// connected to a portfolio
+func (h *PortfolioHandler) ListExchangeAccounts(ctx context.Context, req *models.ListExchangeAccountsRequest) (*models.ListExchangeAccountsResponse, error) {
+    startTime := time.Now()
+    method := "ListExchangeAccounts"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    accounts, err := h.portfolioService.ListExchangeAccounts(ctx, portfolioID)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to list exchange accounts",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapExchangeError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    result := make([]*models.ExchangeAccountProto, len(accounts))
+    for i := range accounts {
+        result[i] = convertToProtoExchangeAccount(&accounts[i])
+    }
+    return &models.ListExchangeAccountsResponse{Accounts: result}, nil
+}
+
+// SyncExchange handles requests to sync a connected exchange account now
+func (h *PortfolioHandler) SyncExchange(ctx context.Context, req *models.SyncExchangeRequest) (*models.ImportExchangeResponse, error) {
+    startTime := time.Now()
+    method := "SyncExchange"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.Exchange == "" {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    result, err := h.portfolioService.SyncExchange(ctx, portfolioID, req.Exchange)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to sync exchange account",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+            zap.String("exchange", req.Exchange),
+        )
+        return nil, h.mapExchangeError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return convertToProtoExchangeImport(result), nil
+}
+
+// DisconnectExchange handles requests to disconnect an exchange account
+func (h *PortfolioHandler) DisconnectExchange(ctx context.Context, req *models.DisconnectExchangeRequest) (*models.DisconnectExchangeResponse, error) {
+    startTime := time.Now()
+    method := "DisconnectExchange"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.Exchange == "" {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    if err := h.portfolioService.DisconnectExchange(ctx, portfolioID, req.Exchange); err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to disconnect exchange account",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+            zap.String("exchange", req.Exchange),
+        )
+        return nil, h.mapExchangeError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.DisconnectExchangeResponse{Success: true}, nil
+}
+
+// mapExchangeError maps exchange import errors to gRPC status errors
+func (h *PortfolioHandler) mapExchangeError(err error) error {
+    switch {
+    case errors.Is(err, services.ErrFeatureDisabled):
+        return statusError(codes.Unimplemented, "FEATURE_DISABLED", "exchange import or account sync is not enabled")
+    case errors.Is(err, services.ErrNotFound):
+        return statusError(codes.NotFound, "EXCHANGE_ACCOUNT_NOT_FOUND", "exchange account not connected")
+    case errors.Is(err, exchanges.ErrUnsupportedExchange):
+        return statusError(codes.InvalidArgument, "UNSUPPORTED_EXCHANGE", "unsupported exchange")
+    case errors.Is(err, exchanges.ErrInvalidCursor):
+        return statusError(codes.InvalidArgument, "INVALID_IMPORT_CURSOR", "invalid import cursor")
+    case errors.Is(err, exchanges.ErrInvalidCredentials):
+        return statusError(codes.PermissionDenied, "EXCHANGE_CREDENTIALS_REJECTED", "exchange rejected the API credentials")
+    case errors.Is(err, exchanges.ErrCredentialPermissions):
+        return statusError(codes.FailedPrecondition, "EXCHANGE_KEY_NOT_READ_ONLY", "exchange API key must be read-only")
+    case errors.Is(err, exchanges.ErrUndecryptableCredentials):
+        return statusError(codes.FailedPrecondition, "EXCHANGE_CREDENTIALS_UNREADABLE", "stored exchange credentials are no longer valid, reconnect the account")
+    case errors.Is(err, exchanges.ErrRateLimited):
+        return unavailable("EXCHANGE_RATE_LIMITED", "exchange rate limit exceeded, retry later", rateLimitRetryDelay)
+    case errors.Is(err, exchanges.ErrTemporary):
+        return unavailable("EXCHANGE_UNAVAILABLE", "exchange temporarily unavailable, retry later", unavailableRetryDelay)
+    }
+    return h.mapServiceError(err)
+}
+
+// convertToProtoExchangeImport converts an exchange import summary to its
+// protobuf representation
+func convertToProtoExchangeImport(result *models.ExchangeImport) *models.ImportExchangeResponse {
+    return &models.ImportExchangeResponse{
+        Fills:                int32(result.Fills),
+        Conversions:          int32(result.Conversions),
+        AssetsCreated:        int32(result.AssetsCreated),
+        TransactionsRecorded: int32(result.TransactionsRecorded),
+        Duplicates:           int32(result.Duplicates),
+        SkippedSymbols:       result.SkippedSymbols,
+        Cursor:               result.Cursor,
+        Entries:              int32(result.Entries),
+    }
+}
+
+// convertToProtoExchangeAccount converts an exchange account to its protobuf
+// representation without its credentials
+func convertToProtoExchangeAccount(account *models.ExchangeAccount) *models.ExchangeAccountProto {
+    result := &models.ExchangeAccountProto{
+        AccountId:   account.ID.String(),
+        PortfolioId: account.PortfolioID.String(),
+        Exchange:    account.Exchange,
+        LastError:   account.LastError,
+        NextSyncAt:  timestamppb.New(account.NextSyncAt),
+        CreatedAt:   timestamppb.New(account.CreatedAt),
+    }
+    if account.LastSyncedAt != nil {
+        result.LastSyncedAt = timestamppb.New(*account.LastSyncedAt)
+    }
+    return result
+}
@@ -0,0 +1,315 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/grpc/codes"                           // v1.50.0
+
+    "bookman/portfolio-service/internal/export"
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// exportFormats maps protobuf export formats to export layouts
+var exportFormats = map[models.ExportFormat]export.Format{
+    models.ExportFormat_EXPORT_FORMAT_CSV:         export.FormatCSV,
+    models.ExportFormat_EXPORT_FORMAT_KOINLY:      export.FormatKoinly,
+    models.ExportFormat_EXPORT_FORMAT_COINTRACKER: export.FormatCoinTracker,
+}
+
+// portfolioExportFormats maps protobuf portfolio export formats to layouts
+var portfolioExportFormats = map[models.PortfolioExportFormat]export.PortfolioFormat{
+    models.PortfolioExportFormat_PORTFOLIO_EXPORT_FORMAT_CSV:  export.PortfolioCSV,
+    models.PortfolioExportFormat_PORTFOLIO_EXPORT_FORMAT_XLSX: export.PortfolioXLSX,
+}
+
+// exportChunkSize is the size of the data chunks of streamed exports, well
+// below the default gRPC message limit
+const exportChunkSize = 64 << 10
+
+// ExportTransactions handles transaction export requests
+func (h *PortfolioHandler) ExportTransactions(ctx context.Context, req *models.ExportTransactionsRequest) (*models.ExportTransactionsResponse, error) {
+    startTime := time.Now()
+    method := "ExportTransactions"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    format, ok := exportFormats[req.Format]
+    if !ok {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, statusError(codes.InvalidArgument, "UNSUPPORTED_EXPORT_FORMAT", "unsupported export format")
+    }
+
+    var start, end time.Time
+    if req.StartDate != nil {
+        start = req.StartDate.AsTime()
+    }
+    if req.EndDate != nil {
+        end = req.EndDate.AsTime()
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    data, err := h.portfolioService.ExportTransactions(ctx, portfolioID, format, start, end)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to export transactions",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        if errors.Is(err, export.ErrUnknownAsset) {
+            return nil, statusError(codes.FailedPrecondition, "UNKNOWN_ASSETS", "transactions reference unknown assets")
+        }
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.ExportTransactionsResponse{
+        Data:        data,
+        ContentType: format.ContentType(),
+        Filename:    fmt.Sprintf("transactions-%s-%s.%s", req.PortfolioId, format, format.Extension()),
+    }, nil
+}
+
+// ExportTransactionsStream handles transaction export requests of any size,
+// streaming the file in chunks as it is rendered
+func (h *PortfolioHandler) ExportTransactionsStream(req *models.ExportTransactionsRequest, stream models.PortfolioService_ExportTransactionsStreamServer) error {
+    startTime := time.Now()
+    method := "ExportTransactionsStream"
+
+    defer func() {
+        metrics.ObserveRequest(stream.Context(), method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return invalidField("portfolio_id", "must be a UUID")
+    }
+
+    format, ok := exportFormats[req.Format]
+    if !ok {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return statusError(codes.InvalidArgument, "UNSUPPORTED_EXPORT_FORMAT", "unsupported export format")
+    }
+
+    var start, end time.Time
+    if req.StartDate != nil {
+        start = req.StartDate.AsTime()
+    }
+    if req.EndDate != nil {
+        end = req.EndDate.AsTime()
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    w := &chunkWriter{
+        stream: stream,
+        first: &models.ExportPortfolioChunk{
+            ContentType: format.ContentType(),
+            Filename:    fmt.Sprintf("transactions-%s-%s.%s", req.PortfolioId, format, format.Extension()),
+        },
+    }
+    err = h.portfolioService.ExportTransactionsStream(stream.Context(), portfolioID, format, start, end, w)
+    if err == nil {
+        err = w.Flush()
+    }
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to export transactions",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        if errors.Is(err, export.ErrUnknownAsset) {
+            return statusError(codes.FailedPrecondition, "UNKNOWN_ASSETS", "transactions reference unknown assets")
+        }
+        return h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return nil
+}
+
+// ExportPortfolio handles full portfolio export requests, streaming the file
+// in chunks as it is rendered
+func (h *PortfolioHandler) ExportPortfolio(req *models.ExportPortfolioRequest, stream models.PortfolioService_ExportPortfolioServer) error {
+    startTime := time.Now()
+    method := "ExportPortfolio"
+
+    defer func() {
+        metrics.ObserveRequest(stream.Context(), method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return invalidField("portfolio_id", "must be a UUID")
+    }
+
+    format, ok := portfolioExportFormats[req.Format]
+    if !ok {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return statusError(codes.InvalidArgument, "UNSUPPORTED_EXPORT_FORMAT", "unsupported export format")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    w := &chunkWriter{
+        stream: stream,
+        first: &models.ExportPortfolioChunk{
+            ContentType: format.ContentType(),
+            Filename:    fmt.Sprintf("portfolio-%s.%s", req.PortfolioId, format.Extension()),
+        },
+    }
+    err = h.portfolioService.ExportPortfolio(stream.Context(), portfolioID, format, w)
+    if err == nil {
+        err = w.Flush()
+    }
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to export portfolio",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        if errors.Is(err, export.ErrUnknownAsset) {
+            return statusError(codes.FailedPrecondition, "UNKNOWN_ASSETS", "transactions reference unknown assets")
+        }
+        return h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return nil
+}
+
+// ExportUserData handles data portability requests, streaming everything
+// stored about the user as a JSON document
+func (h *PortfolioHandler) ExportUserData(req *models.ExportUserDataRequest, stream models.PortfolioService_ExportUserDataServer) error {
+    startTime := time.Now()
+    method := "ExportUserData"
+
+    defer func() {
+        metrics.ObserveRequest(stream.Context(), method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return invalidField("user_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    w := &chunkWriter{
+        stream: stream,
+        first: &models.ExportPortfolioChunk{
+            ContentType: export.UserDataContentType,
+            Filename:    fmt.Sprintf("user-data-%s.json", req.UserId),
+        },
+    }
+    err = h.portfolioService.ExportUserData(stream.Context(), userID, w)
+    if err == nil {
+        err = w.Flush()
+    }
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to export user data",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+        )
+        return h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return nil
+}
+
+// chunkSender is a server stream of export file chunks
+type chunkSender interface {
+    Send(*models.ExportPortfolioChunk) error
+}
+
+// chunkWriter sends written data to an export stream in chunks of
+// exportChunkSize
+type chunkWriter struct {
+    stream chunkSender
+    // first holds the metadata sent with the first chunk
+    first *models.ExportPortfolioChunk
+    buf   []byte
+}
+
+// Write buffers p and sends every complete chunk
+func (c *chunkWriter) Write(p []byte) (int, error) {
+    c.buf = append(c.buf, p...)
+    for len(c.buf) >= exportChunkSize {
+        if err := c.send(c.buf[:exportChunkSize]); err != nil {
+            return 0, err
+        }
+        c.buf = c.buf[exportChunkSize:]
+    }
+    return len(p), nil
+}
+
+// Flush sends the buffered remainder, and the metadata if nothing was sent
+func (c *chunkWriter) Flush() error {
+    if len(c.buf) == 0 && c.first == nil {
+        return nil
+    }
+    err := c.send(c.buf)
+    c.buf = nil
+    return err
+}
+
+// send sends one chunk, copying data as the buffer is reused
+func (c *chunkWriter) send(data []byte) error {
+    chunk := &models.ExportPortfolioChunk{}
+    if c.first != nil {
+        chunk, c.first = c.first, nil
+    }
+    chunk.Data = append([]byte(nil), data...)
+    return c.stream.Send(chunk)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "github.com/shopspring/decimal"                           // v1.3.1
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// SuggestTaxLossHarvests handles requests for sells that would realize
+// unrealized losses of a portfolio
+func (h *PortfolioHandler) SuggestTaxLossHarvests(ctx context.Context, req *models.SuggestTaxLossHarvestsRequest) (*models.SuggestTaxLossHarvestsResponse, error) {
+    startTime := time.Now()
+    method := "SuggestTaxLossHarvests"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    candidates, err := h.portfolioService.SuggestTaxLossHarvests(ctx, portfolioID,
+        decimal.NewFromFloat(req.MinLoss),
+        decimal.NewFromFloat(req.MinLossPercentage),
+        decimal.NewFromFloat(req.TaxRate),
+    )
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to suggest tax-loss harvests",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    result := make([]*models.HarvestCandidateProto, len(candidates))
+    for i, c := range candidates {
+        conflicts := make([]string, len(c.WashSaleConflicts))
+        for j, id := range c.WashSaleConflicts {
+            conflicts[j] = id.String()
+        }
+        result[i] = &models.HarvestCandidateProto{
+            AssetId:             c.AssetID.String(),
+            Symbol:              c.Symbol,
+            Quantity:            c.Quantity.InexactFloat64(),
+            Lots:                int32(c.Lots),
+            CostBasis:           c.CostBasis.InexactFloat64(),
+            Proceeds:            c.Proceeds.InexactFloat64(),
+            Loss:                c.Loss.InexactFloat64(),
+            LossPercentage:      c.LossPercentage.InexactFloat64(),
+            EstimatedTaxSaving:  c.EstimatedTaxSaving.InexactFloat64(),
+            WashSaleConflictIds: conflicts,
+            RepurchaseAfter:     timestamppb.New(c.RepurchaseAfter),
+        }
+    }
+
+    return &models.SuggestTaxLossHarvestsResponse{Candidates: result}, nil
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// historyIntervals maps protobuf history intervals to bucket widths
+var historyIntervals = map[models.HistoryIntervalProto]models.HistoryInterval{
+    models.HistoryIntervalProto_HISTORY_INTERVAL_DAILY:   models.HistoryIntervalDay,
+    models.HistoryIntervalProto_HISTORY_INTERVAL_WEEKLY:  models.HistoryIntervalWeek,
+    models.HistoryIntervalProto_HISTORY_INTERVAL_MONTHLY: models.HistoryIntervalMonth,
+}
+
+// GetValueHistory handles downsampled portfolio value history requests
+func (h *PortfolioHandler) GetValueHistory(ctx context.Context, req *models.GetValueHistoryRequest) (*models.GetValueHistoryResponse, error) {
+    startTime := time.Now()
+    method := "GetValueHistory"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.StartDate == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    interval, ok := historyIntervals[req.Interval]
+    if !ok {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    var end time.Time
+    if req.EndDate != nil {
+        end = req.EndDate.AsTime()
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    points, err := h.portfolioService.GetValueHistory(ctx, portfolioID, interval, req.StartDate.AsTime(), end)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to get value history",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    resp := &models.GetValueHistoryResponse{
+        Points: make([]*models.ValuePointProto, len(points)),
+    }
+    for i, p := range points {
+        resp.Points[i] = &models.ValuePointProto{
+            Timestamp:  timestamppb.New(p.Timestamp),
+            Value:      p.Value.InexactFloat64(),
+            ProfitLoss: p.ProfitLoss.InexactFloat64(),
+        }
+    }
+
+    return resp, nil
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// holdingTerms maps holding terms to their protobuf representation
+var holdingTerms = map[models.HoldingTerm]models.HoldingTermProto{
+    models.HoldingShortTerm: models.HoldingTermProto_HOLDING_TERM_SHORT,
+    models.HoldingLongTerm:  models.HoldingTermProto_HOLDING_TERM_LONG,
+}
+
+// GetHoldingPeriods handles requests for the short- and long-term split of a
+// portfolio's profit and loss
+func (h *PortfolioHandler) GetHoldingPeriods(ctx context.Context, req *models.GetHoldingPeriodsRequest) (*models.GetHoldingPeriodsResponse, error) {
+    startTime := time.Now()
+    method := "GetHoldingPeriods"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    var start, end time.Time
+    if req.StartDate != nil {
+        start = req.StartDate.AsTime()
+    }
+    if req.EndDate != nil {
+        end = req.EndDate.AsTime()
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    report, err := h.portfolioService.GetHoldingPeriods(ctx, portfolioID, start, end)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to classify holding periods",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    assets := make([]*models.AssetHoldingPeriodsProto, len(report.Assets))
+    for i, a := range report.Assets {
+        lots := make([]*models.HeldLotProto, len(a.Lots))
+        for j, l := range a.Lots {
+            lot := &models.HeldLotProto{
+                AcquiredAt:         timestamppb.New(l.AcquiredAt),
+                Quantity:           l.Quantity.InexactFloat64(),
+                CostBasis:          l.CostBasis.InexactFloat64(),
+                Term:               holdingTerms[l.Term],
+                LongTermAt:         timestamppb.New(l.LongTermAt),
+                Value:              l.Value.InexactFloat64(),
+                Unpriced:           l.Unpriced,
+                WashSaleAdjustment: l.WashSaleAdjustment.InexactFloat64(),
+            }
+            if l.TransactionID != uuid.Nil {
+                lot.TransactionId = l.TransactionID.String()
+            }
+            lots[j] = lot
+        }
+        assets[i] = &models.AssetHoldingPeriodsProto{
+            AssetId:    a.AssetID.String(),
+            Symbol:     a.Symbol,
+            Lots:       lots,
+            Realized:   convertToProtoTermSplit(a.Realized),
+            Unrealized: convertToProtoTermSplit(a.Unrealized),
+        }
+    }
+
+    washSales := make([]*models.WashSaleProto, len(report.WashSales))
+    for i, w := range report.WashSales {
+        replacements := make([]string, len(w.Replacements))
+        for j, id := range w.Replacements {
+            replacements[j] = id.String()
+        }
+        washSales[i] = &models.WashSaleProto{
+            AssetId:        w.AssetID.String(),
+            Symbol:         w.Symbol,
+            TransactionId:  w.TransactionID.String(),
+            DisposedAt:     timestamppb.New(w.DisposedAt),
+            Loss:           w.Loss.InexactFloat64(),
+            Disallowed:     w.Disallowed.InexactFloat64(),
+            ReplacementIds: replacements,
+        }
+    }
+
+    return &models.GetHoldingPeriodsResponse{
+        Report: &models.HoldingPeriodReportProto{
+            PortfolioId:     report.PortfolioID.String(),
+            LongTermSeconds: int64(report.LongTerm.Seconds()),
+            StartDate:       timestamppb.New(report.Start),
+            EndDate:         timestamppb.New(report.End),
+            Assets:          assets,
+            Realized:        convertToProtoTermSplit(report.Realized),
+            Unrealized:      convertToProtoTermSplit(report.Unrealized),
+            GeneratedAt:     timestamppb.New(report.GeneratedAt),
+            WashSales:       washSales,
+        },
+    }, nil
+}
+
+// convertToProtoTermSplit converts a term split to its protobuf representation
+func convertToProtoTermSplit(s models.TermSplit) *models.TermSplitProto {
+    return &models.TermSplitProto{
+        ShortTerm: s.ShortTerm.InexactFloat64(),
+        LongTerm:  s.LongTerm.InexactFloat64(),
+    }
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+    "time"
+
+    "github.com/google/uuid"          // v1.3.0
+    "go.uber.org/zap"                // v1.24.0
+    "google.golang.org/grpc/codes"   // v1.50.0
+
+    "bookman/portfolio-service/internal/importer"
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// ImportTransactions handles streamed CSV transaction imports. The stream
+// starts with a header selecting the portfolio and column mapping, followed by
+// chunks of the file.
+func (h *PortfolioHandler) ImportTransactions(stream models.PortfolioService_ImportTransactionsServer) error {
+    startTime := time.Now()
+    method := "ImportTransactions"
+
+    defer func() {
+        metrics.ObserveRequest(stream.Context(), method, startTime)
+    }()
+
+    first, err := stream.Recv()
+    if err != nil || first.GetHeader() == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return errInvalidRequest
+    }
+    header := first.GetHeader()
+
+    portfolioID, err := uuid.Parse(header.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return errInvalidRequest
+    }
+
+    mapping, err := importMapping(header)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return statusError(codes.InvalidArgument, "INVALID_IMPORT_MAPPING", err.Error())
+    }
+
+    var file bytes.Buffer
+    for {
+        req, err := stream.Recv()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            metrics.Requests.WithLabelValues(method, "error").Inc()
+            return err
+        }
+        if req.GetHeader() != nil {
+            metrics.Requests.WithLabelValues(method, "error").Inc()
+            return statusError(codes.InvalidArgument, "IMPORT_HEADER_OUT_OF_ORDER", "import header must only be sent first")
+        }
+        if file.Len()+len(req.GetChunk()) > importer.MaxFileSize {
+            metrics.Requests.WithLabelValues(method, "error").Inc()
+            return statusError(codes.InvalidArgument, "IMPORT_TOO_LARGE", fmt.Sprintf("import file exceeds %d MiB", importer.MaxFileSize>>20))
+        }
+        file.Write(req.GetChunk())
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    result, err := h.portfolioService.ImportTransactions(stream.Context(), portfolioID, &file, mapping, header.DryRun)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to import transactions",
+            zap.Error(err),
+            zap.String("portfolio_id", header.PortfolioId),
+            zap.String("template", header.Template),
+        )
+        return h.mapImportError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    resp := &models.ImportTransactionsResponse{
+        Rows:                 int32(result.Rows),
+        Valid:                int32(result.Valid),
+        Invalid:              int32(result.Invalid),
+        Skipped:              int32(result.Skipped),
+        Duplicates:           int32(result.Duplicates),
+        TransactionsRecorded: int32(result.TransactionsRecorded),
+        Committed:            result.Committed,
+        Errors:               make([]*models.ImportRowErrorProto, len(result.Errors)),
+    }
+    for i, e := range result.Errors {
+        resp.Errors[i] = &models.ImportRowErrorProto{
+            Line:    int32(e.Line),
+            Column:  e.Column,
+            Message: e.Message,
+        }
+    }
+    return stream.SendAndClose(resp)
+}
+
+// ImportTransactionsStream handles bulk CSV transaction imports. The request
+// stream has the layout of ImportTransactions; file chunks are parsed and
+// recorded in batches as they arrive and progress is streamed back after each
+// batch, so large files do not have to fit into one deadline.
+func (h *PortfolioHandler) ImportTransactionsStream(stream models.PortfolioService_ImportTransactionsStreamServer) error {
+    startTime := time.Now()
+    method := "ImportTransactionsStream"
+
+    defer func() {
+        metrics.ObserveRequest(stream.Context(), method, startTime)
+    }()
+
+    first, err := stream.Recv()
+    if err != nil || first.GetHeader() == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return errInvalidRequest
+    }
+    header := first.GetHeader()
+
+    portfolioID, err := uuid.Parse(header.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return errInvalidRequest
+    }
+
+    mapping, err := importMapping(header)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return statusError(codes.InvalidArgument, "INVALID_IMPORT_MAPPING", err.Error())
+    }
+
+    // Chunks are piped to the import while it records earlier batches
+    file, writer := io.Pipe()
+    defer file.Close()
+    go func() {
+        var size int
+        for {
+            req, err := stream.Recv()
+            if err == io.EOF {
+                writer.Close()
+                return
+            }
+            if err != nil {
+                writer.CloseWithError(err)
+                return
+            }
+            if req.GetHeader() != nil {
+                writer.CloseWithError(statusError(codes.InvalidArgument, "IMPORT_HEADER_OUT_OF_ORDER", "import header must only be sent first"))
+                return
+            }
+            size += len(req.GetChunk())
+            if size > importer.MaxStreamFileSize {
+                writer.CloseWithError(statusError(codes.InvalidArgument, "IMPORT_TOO_LARGE", fmt.Sprintf("import file exceeds %d MiB", importer.MaxStreamFileSize>>20)))
+                return
+            }
+            if _, err := writer.Write(req.GetChunk()); err != nil {
+                return
+            }
+        }
+    }()
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    result, err := h.portfolioService.ImportTransactionsStream(stream.Context(), portfolioID, file, mapping, header.DryRun,
+        func(totals *models.TransactionImport, rowErrors []models.ImportRowError) error {
+            return stream.Send(importProgress(totals, rowErrors, false))
+        })
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to bulk import transactions",
+            zap.Error(err),
+            zap.String("portfolio_id", header.PortfolioId),
+            zap.String("template", header.Template),
+        )
+        return h.mapImportError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+    return stream.Send(importProgress(result, nil, true))
+}
+
+// importProgress converts bulk import totals and the row errors of a batch
+// into a progress message
+func importProgress(totals *models.TransactionImport, rowErrors []models.ImportRowError, done bool) *models.ImportTransactionsProgress {
+    progress := &models.ImportTransactionsProgress{
+        Rows:                 int32(totals.Rows),
+        Valid:                int32(totals.Valid),
+        Invalid:              int32(totals.Invalid),
+        Skipped:              int32(totals.Skipped),
+        Duplicates:           int32(totals.Duplicates),
+        TransactionsRecorded: int32(totals.TransactionsRecorded),
+        Done:                 done,
+        Errors:               make([]*models.ImportRowErrorProto, len(rowErrors)),
+    }
+    for i, e := range rowErrors {
+        progress.Errors[i] = &models.ImportRowErrorProto{
+            Line:    int32(e.Line),
+            Column:  e.Column,
+            Message: e.Message,
+        }
+    }
+    return progress
+}
+
+// importMapping resolves the column mapping of an import from its template
+// and the fields overridden by the request
+func importMapping(header *models.ImportTransactionsHeader) (importer.Mapping, error) {
+    var mapping importer.Mapping
+    if header.Template != "" {
+        m, err := importer.TemplateMapping(importer.Template(header.Template))
+        if err != nil {
+            return importer.Mapping{}, err
+        }
+        mapping = m
+    }
+
+    if m := header.Mapping; m != nil {
+        mapping = mapping.Merge(importer.Mapping{
+            Timestamp:  m.Timestamp,
+            Type:       m.Type,
+            Symbol:     m.Symbol,
+            Amount:     m.Amount,
+            Price:      m.Price,
+            Fee:        m.Fee,
+            Reference:  m.Reference,
+            TimeLayout: m.TimeLayout,
+            TypeValues: m.TypeValues,
+            PairQuotes: m.PairQuotes,
+        })
+    }
+
+    return mapping, mapping.Validate()
+}
+
+// mapImportError maps transaction import errors to gRPC status errors
+func (h *PortfolioHandler) mapImportError(err error) error {
+    switch {
+    case errors.Is(err, services.ErrInvalidPortfolio):
+        return errInvalidRequest
+    }
+    return h.mapServiceError(err)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// GetIncomeReport handles requests for the reward, airdrop and interest
+// income of a portfolio by asset and period
+func (h *PortfolioHandler) GetIncomeReport(ctx context.Context, req *models.GetIncomeReportRequest) (*models.GetIncomeReportResponse, error) {
+    startTime := time.Now()
+    method := "GetIncomeReport"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.StartDate == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    period, ok := reportPeriods[req.Period]
+    if !ok {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("period", "must be a supported report period")
+    }
+
+    var end time.Time
+    if req.EndDate != nil {
+        end = req.EndDate.AsTime()
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    report, err := h.portfolioService.GetIncomeReport(ctx, portfolioID, period, req.StartDate.AsTime(), end)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to build income report",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    periods := make([]*models.IncomePeriodProto, len(report.Periods))
+    for i, p := range report.Periods {
+        periods[i] = &models.IncomePeriodProto{
+            PeriodStart: timestamppb.New(p.PeriodStart),
+            PeriodEnd:   timestamppb.New(p.PeriodEnd),
+            Lines:       convertToProtoIncomeLines(p.Lines),
+            Value:       p.Value.InexactFloat64(),
+        }
+    }
+
+    return &models.GetIncomeReportResponse{
+        Report: &models.IncomeReportProto{
+            PortfolioId:  report.PortfolioID.String(),
+            Period:       req.Period,
+            StartDate:    timestamppb.New(report.Start),
+            EndDate:      timestamppb.New(report.End),
+            BaseCurrency: report.BaseCurrency,
+            Periods:      periods,
+            Totals:       convertToProtoIncomeLines(report.Totals),
+            Value:        report.Value.InexactFloat64(),
+            GeneratedAt:  timestamppb.New(report.GeneratedAt),
+        },
+    }, nil
+}
+
+// convertToProtoIncomeLines converts income lines to their protobuf
+// representation
+func convertToProtoIncomeLines(lines []models.IncomeLine) []*models.IncomeLineProto {
+    result := make([]*models.IncomeLineProto, len(lines))
+    for i, l := range lines {
+        result[i] = &models.IncomeLineProto{
+            AssetId:  l.AssetID.String(),
+            Symbol:   l.Symbol,
+            Type:     transactionTypes[l.Type],
+            Amount:   l.Amount.InexactFloat64(),
+            Value:    l.Value.InexactFloat64(),
+            Receipts: int32(l.Receipts),
+            Unvalued: int32(l.Unvalued),
+        }
+    }
+    return result
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/grpc/codes"                           // v1.50.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// ListLedgerEvents handles portfolio ledger audit requests
+func (h *PortfolioHandler) ListLedgerEvents(ctx context.Context, req *models.ListLedgerEventsRequest) (*models.ListLedgerEventsResponse, error) {
+    startTime := time.Now()
+    method := "ListLedgerEvents"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.AfterSequence < 0 || req.PageSize < 0 {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    events, err := h.portfolioService.ListLedgerEvents(ctx, portfolioID, req.AfterSequence, int(req.PageSize))
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to list ledger events",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapLedgerError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    result := make([]*models.LedgerEventProto, len(events))
+    for i, e := range events {
+        result[i] = &models.LedgerEventProto{
+            PortfolioId: e.PortfolioID.String(),
+            Sequence:    e.Sequence,
+            Type:        string(e.Type),
+            Data:        string(e.Data),
+            RecordedAt:  timestamppb.New(e.RecordedAt),
+        }
+    }
+
+    return &models.ListLedgerEventsResponse{Events: result}, nil
+}
+
+// GetPortfolioAsOf handles historical portfolio reconstruction requests
+func (h *PortfolioHandler) GetPortfolioAsOf(ctx context.Context, req *models.GetPortfolioAsOfRequest) (*models.GetPortfolioAsOfResponse, error) {
+    startTime := time.Now()
+    method := "GetPortfolioAsOf"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.AsOf == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    state, err := h.portfolioService.GetPortfolioAsOf(ctx, portfolioID, req.AsOf.AsTime())
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to reconstruct portfolio",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapLedgerError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.GetPortfolioAsOfResponse{
+        Portfolio:        h.convertToProtoPortfolio(state.Portfolio),
+        Sequence:         state.Sequence,
+        TransactionCount: int32(len(state.Transactions)),
+    }, nil
+}
+
+// mapLedgerError maps portfolio ledger errors to gRPC status errors
+func (h *PortfolioHandler) mapLedgerError(err error) error {
+    switch {
+    case errors.Is(err, services.ErrFeatureDisabled):
+        return statusError(codes.Unimplemented, "FEATURE_DISABLED", "event-sourced storage is not enabled")
+    case errors.Is(err, services.ErrNotFound):
+        return statusError(codes.NotFound, "LEDGER_NOT_FOUND", "no ledger for portfolio at the requested time")
+    case errors.Is(err, services.ErrInsufficientData):
+        return statusError(codes.FailedPrecondition, "LEDGER_NOT_REPLAYABLE", "portfolio ledger cannot be replayed")
+    }
+    return h.mapServiceError(err)
+}
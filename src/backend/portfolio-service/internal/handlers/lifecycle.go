@@ -0,0 +1,112 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+    "google.golang.org/grpc/codes"    // v1.50.0
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// ArchivePortfolio handles portfolio archive requests
+func (h *PortfolioHandler) ArchivePortfolio(ctx context.Context, req *models.ArchivePortfolioRequest) (*models.ArchivePortfolioResponse, error) {
+    startTime := time.Now()
+    method := "ArchivePortfolio"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    if err := h.portfolioService.ArchivePortfolio(ctx, portfolioID); err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to archive portfolio",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapLifecycleError(err, "no active portfolio to archive")
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+    h.logger.Info("Portfolio archived",
+        zap.String("portfolio_id", req.PortfolioId),
+    )
+
+    return &models.ArchivePortfolioResponse{Success: true}, nil
+}
+
+// RestorePortfolio handles archived portfolio restore requests
+func (h *PortfolioHandler) RestorePortfolio(ctx context.Context, req *models.RestorePortfolioRequest) (*models.RestorePortfolioResponse, error) {
+    startTime := time.Now()
+    method := "RestorePortfolio"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    if err := h.portfolioService.RestorePortfolio(ctx, portfolioID); err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to restore portfolio",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapLifecycleError(err, "no archived portfolio to restore")
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+    h.logger.Info("Portfolio restored",
+        zap.String("portfolio_id", req.PortfolioId),
+    )
+
+    return &models.RestorePortfolioResponse{Success: true}, nil
+}
+
+// mapLifecycleError maps portfolio archive errors to gRPC status errors
+func (h *PortfolioHandler) mapLifecycleError(err error, notFound string) error {
+    switch {
+    case errors.Is(err, services.ErrNotFound):
+        return statusError(codes.NotFound, "NOT_FOUND", notFound)
+    }
+    return h.mapServiceError(err)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"                           // v1.3.0
+    "github.com/shopspring/decimal"                    // v1.3.1
+    "go.uber.org/zap"                                 // v1.24.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// SetManualPrice handles requests to price a symbol of a portfolio that no
+// market data provider quotes
+func (h *PortfolioHandler) SetManualPrice(ctx context.Context, req *models.SetManualPriceRequest) (*models.SetManualPriceResponse, error) {
+    startTime := time.Now()
+    method := "SetManualPrice"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.Price == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.Price.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    price := &models.ManualPrice{
+        PortfolioID: portfolioID,
+        Symbol:      req.Price.Symbol,
+        Price:       decimal.NewFromFloat(req.Price.Price),
+        Note:        req.Price.Note,
+    }
+    if req.Price.ValidFrom != nil {
+        price.ValidFrom = req.Price.ValidFrom.AsTime()
+    }
+    if req.Price.ValidUntil != nil {
+        validUntil := req.Price.ValidUntil.AsTime()
+        price.ValidUntil = &validUntil
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    stored, err := h.portfolioService.SetManualPrice(ctx, price)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to set manual price",
+            zap.Error(err),
+            zap.String("portfolio_id", req.Price.PortfolioId),
+            zap.String("symbol", req.Price.Symbol),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+    h.logger.Info("Manual price set",
+        zap.String("portfolio_id", req.Price.PortfolioId),
+        zap.String("symbol", stored.Symbol),
+        zap.Time("valid_from", stored.ValidFrom),
+    )
+
+    return &models.SetManualPriceResponse{Price: convertToProtoManualPrice(stored)}, nil
+}
+
+// convertToProtoManualPrice converts a manual price to its protobuf form
+func convertToProtoManualPrice(price *models.ManualPrice) *models.ManualPriceProto {
+    result := &models.ManualPriceProto{
+        PriceId:     price.ID.String(),
+        PortfolioId: price.PortfolioID.String(),
+        Symbol:      price.Symbol,
+        Price:       price.Price.InexactFloat64(),
+        ValidFrom:   timestamppb.New(price.ValidFrom),
+        Note:        price.Note,
+        CreatedAt:   timestamppb.New(price.CreatedAt),
+    }
+    if price.ValidUntil != nil {
+        result.ValidUntil = timestamppb.New(*price.ValidUntil)
+    }
+    return result
+}
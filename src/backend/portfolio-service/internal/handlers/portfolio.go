@@ -8,63 +8,41 @@ import (
     "time"
 
     "github.com/google/uuid"                                  // v1.3.0
-    "github.com/prometheus/client_golang/prometheus"          // v1.15.0
+    "github.com/shopspring/decimal"                           // v1.3.1
     "go.uber.org/zap"                                        // v1.24.0
     "google.golang.org/grpc/codes"                           // v1.50.0
-    "google.golang.org/grpc/status"                          // v1.50.0
+    "google.golang.org/protobuf/types/known/timestamppb"
 
+    "bookman/portfolio-service/internal/buildinfo"
+    "bookman/portfolio-service/internal/metrics"
     "bookman/portfolio-service/internal/models"
     "bookman/portfolio-service/internal/services"
 )
 
 // Define common error responses
 var (
-    errInvalidRequest = status.Error(codes.InvalidArgument, "invalid request parameters")
-    errNotFound      = status.Error(codes.NotFound, "portfolio not found")
-    errInternal      = status.Error(codes.Internal, "internal server error")
+    errInvalidRequest = statusError(codes.InvalidArgument, "INVALID_REQUEST", "invalid request parameters")
+    errNotFound      = statusError(codes.NotFound, "PORTFOLIO_NOT_FOUND", "portfolio not found")
+    errInternal      = statusError(codes.Internal, "INTERNAL", "internal server error")
 )
 
-// Define metrics collectors
-var (
-    requestMetrics = prometheus.NewCounterVec(
-        prometheus.CounterOpts{
-            Name: "portfolio_requests_total",
-            Help: "Total number of portfolio requests",
-        },
-        []string{"method", "status"},
-    )
-
-    latencyMetrics = prometheus.NewHistogramVec(
-        prometheus.HistogramOpts{
-            Name: "portfolio_request_duration_seconds",
-            Help: "Request latency in seconds",
-            Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
-        },
-        []string{"method"},
-    )
-)
-
-func init() {
-    // Register metrics with Prometheus
-    prometheus.MustRegister(requestMetrics)
-    prometheus.MustRegister(latencyMetrics)
-}
-
 // PortfolioHandler implements the gRPC server handlers with thread safety
 type PortfolioHandler struct {
     portfolioService *services.PortfolioService
+    buildInfo       buildinfo.Info
     logger          *zap.Logger
     mutex           sync.RWMutex
 }
 
 // NewPortfolioHandler creates a new portfolio handler instance
-func NewPortfolioHandler(svc *services.PortfolioService, logger *zap.Logger) (*PortfolioHandler, error) {
+func NewPortfolioHandler(svc *services.PortfolioService, info buildinfo.Info, logger *zap.Logger) (*PortfolioHandler, error) {
     if svc == nil || logger == nil {
         return nil, fmt.Errorf("invalid dependencies provided")
     }
 
     return &PortfolioHandler{
         portfolioService: svc,
+        buildInfo:       info,
         logger:          logger.With(zap.String("component", "portfolio_handler")),
     }, nil
 }
@@ -75,17 +53,17 @@ func (h *PortfolioHandler) CreatePortfolio(ctx context.Context, req *models.Crea
     method := "CreatePortfolio"
 
     defer func() {
-        latencyMetrics.WithLabelValues(method).Observe(time.Since(startTime).Seconds())
+        metrics.ObserveRequest(ctx, method, startTime)
     }()
 
     // Validate request
     if err := h.validateCreateRequest(req); err != nil {
-        requestMetrics.WithLabelValues(method, "error").Inc()
+        metrics.Requests.WithLabelValues(method, "error").Inc()
         h.logger.Error("Invalid create portfolio request",
             zap.Error(err),
             zap.Any("request", req),
         )
-        return nil, errInvalidRequest
+        return nil, err
     }
 
     h.mutex.Lock()
@@ -101,15 +79,15 @@ func (h *PortfolioHandler) CreatePortfolio(ctx context.Context, req *models.Crea
     // Call service layer
     createdPortfolio, err := h.portfolioService.CreatePortfolio(ctx, portfolio)
     if err != nil {
-        requestMetrics.WithLabelValues(method, "error").Inc()
+        metrics.Requests.WithLabelValues(method, "error").Inc()
         h.logger.Error("Failed to create portfolio",
             zap.Error(err),
             zap.String("user_id", req.UserId),
         )
-        return nil, errInternal
+        return nil, h.mapServiceError(err)
     }
 
-    requestMetrics.WithLabelValues(method, "success").Inc()
+    metrics.Requests.WithLabelValues(method, "success").Inc()
     h.logger.Info("Portfolio created successfully",
         zap.String("portfolio_id", createdPortfolio.ID.String()),
         zap.String("user_id", req.UserId),
@@ -127,18 +105,18 @@ func (h *PortfolioHandler) GetPortfolio(ctx context.Context, req *models.GetPort
     method := "GetPortfolio"
 
     defer func() {
-        latencyMetrics.WithLabelValues(method).Observe(time.Since(startTime).Seconds())
+        metrics.ObserveRequest(ctx, method, startTime)
     }()
 
     // Validate request
     portfolioID, err := uuid.Parse(req.PortfolioId)
     if err != nil {
-        requestMetrics.WithLabelValues(method, "error").Inc()
+        metrics.Requests.WithLabelValues(method, "error").Inc()
         h.logger.Error("Invalid portfolio ID",
             zap.Error(err),
             zap.String("portfolio_id", req.PortfolioId),
         )
-        return nil, errInvalidRequest
+        return nil, invalidField("portfolio_id", "must be a UUID")
     }
 
     h.mutex.RLock()
@@ -147,7 +125,7 @@ func (h *PortfolioHandler) GetPortfolio(ctx context.Context, req *models.GetPort
     // Call service layer
     portfolio, err := h.portfolioService.GetPortfolio(ctx, portfolioID)
     if err != nil {
-        requestMetrics.WithLabelValues(method, "error").Inc()
+        metrics.Requests.WithLabelValues(method, "error").Inc()
         h.logger.Error("Failed to get portfolio",
             zap.Error(err),
             zap.String("portfolio_id", req.PortfolioId),
@@ -155,13 +133,39 @@ func (h *PortfolioHandler) GetPortfolio(ctx context.Context, req *models.GetPort
         return nil, h.mapServiceError(err)
     }
 
-    requestMetrics.WithLabelValues(method, "success").Inc()
+    if !req.IncludeDust {
+        if err := h.portfolioService.HideDust(ctx, portfolio); err != nil {
+            metrics.Requests.WithLabelValues(method, "error").Inc()
+            h.logger.Error("Failed to hide dust holdings",
+                zap.Error(err),
+                zap.String("portfolio_id", req.PortfolioId),
+            )
+            return nil, h.mapServiceError(err)
+        }
+    }
+
+    tags, err := h.portfolioService.LoadAssetTags(ctx, portfolio, req.Tag)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to load asset tags",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
     h.logger.Info("Portfolio retrieved successfully",
         zap.String("portfolio_id", req.PortfolioId),
     )
 
+    result := h.convertToProtoPortfolio(portfolio)
+    for i, asset := range portfolio.Assets {
+        result.Assets[i].Tags = tags[asset.ID]
+    }
+
     return &models.GetPortfolioResponse{
-        Portfolio: h.convertToProtoPortfolio(portfolio),
+        Portfolio: result,
     }, nil
 }
 
@@ -171,17 +175,17 @@ func (h *PortfolioHandler) UpdatePortfolio(ctx context.Context, req *models.Upda
     method := "UpdatePortfolio"
 
     defer func() {
-        latencyMetrics.WithLabelValues(method).Observe(time.Since(startTime).Seconds())
+        metrics.ObserveRequest(ctx, method, startTime)
     }()
 
     // Validate request
     if err := h.validateUpdateRequest(req); err != nil {
-        requestMetrics.WithLabelValues(method, "error").Inc()
+        metrics.Requests.WithLabelValues(method, "error").Inc()
         h.logger.Error("Invalid update portfolio request",
             zap.Error(err),
             zap.Any("request", req),
         )
-        return nil, errInvalidRequest
+        return nil, err
     }
 
     h.mutex.Lock()
@@ -198,7 +202,7 @@ func (h *PortfolioHandler) UpdatePortfolio(ctx context.Context, req *models.Upda
     // Call service layer
     updatedPortfolio, err := h.portfolioService.UpdatePortfolio(ctx, portfolio)
     if err != nil {
-        requestMetrics.WithLabelValues(method, "error").Inc()
+        metrics.Requests.WithLabelValues(method, "error").Inc()
         h.logger.Error("Failed to update portfolio",
             zap.Error(err),
             zap.String("portfolio_id", req.Portfolio.Id),
@@ -206,7 +210,7 @@ func (h *PortfolioHandler) UpdatePortfolio(ctx context.Context, req *models.Upda
         return nil, h.mapServiceError(err)
     }
 
-    requestMetrics.WithLabelValues(method, "success").Inc()
+    metrics.Requests.WithLabelValues(method, "success").Inc()
     h.logger.Info("Portfolio updated successfully",
         zap.String("portfolio_id", req.Portfolio.Id),
     )
@@ -216,19 +220,90 @@ func (h *PortfolioHandler) UpdatePortfolio(ctx context.Context, req *models.Upda
     }, nil
 }
 
+// ListPortfolios handles paginated portfolio listing requests
+func (h *PortfolioHandler) ListPortfolios(ctx context.Context, req *models.ListPortfoliosRequest) (*models.ListPortfoliosResponse, error) {
+    startTime := time.Now()
+    method := "ListPortfolios"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    // Validate request
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid user ID",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+        )
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+
+    filter, err := services.ParsePortfolioFilter(req.Filter)
+    if err == nil && req.Tag != "" {
+        if filter.Tag != "" {
+            err = fmt.Errorf("%w: tag is set both as a field and in the filter", services.ErrInvalidListQuery)
+        }
+        filter.Tag = req.Tag
+    }
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, h.mapServiceError(err)
+    }
+    filter.IncludeArchived = req.IncludeArchived
+
+    order, err := services.ParsePortfolioOrder(req.OrderBy)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, h.mapServiceError(err)
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    // Call service layer
+    portfolios, nextToken, err := h.portfolioService.ListPortfolios(ctx, userID, int(req.PageSize), req.PageToken, filter, order)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to list portfolios",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    resp := &models.ListPortfoliosResponse{
+        Portfolios:    make([]*models.PortfolioProto, len(portfolios)),
+        NextPageToken: nextToken,
+    }
+    for i, p := range portfolios {
+        resp.Portfolios[i] = h.convertToProtoPortfolio(p)
+    }
+
+    return resp, nil
+}
+
 // Helper functions
 
 func (h *PortfolioHandler) validateCreateRequest(req *models.CreatePortfolioRequest) error {
     if req == nil {
-        return fmt.Errorf("nil request")
+        return errInvalidRequest
     }
 
     if _, err := uuid.Parse(req.UserId); err != nil {
-        return fmt.Errorf("invalid user ID: %v", err)
+        return invalidField("user_id", "must be a UUID")
     }
 
     if req.Name == "" {
-        return fmt.Errorf("portfolio name is required")
+        return invalidField("name", "is required")
     }
 
     return nil
@@ -236,35 +311,24 @@ func (h *PortfolioHandler) validateCreateRequest(req *models.CreatePortfolioRequ
 
 func (h *PortfolioHandler) validateUpdateRequest(req *models.UpdatePortfolioRequest) error {
     if req == nil || req.Portfolio == nil {
-        return fmt.Errorf("nil request or portfolio")
+        return errInvalidRequest
     }
 
     if _, err := uuid.Parse(req.Portfolio.Id); err != nil {
-        return fmt.Errorf("invalid portfolio ID: %v", err)
+        return invalidField("portfolio.id", "must be a UUID")
     }
 
     if _, err := uuid.Parse(req.Portfolio.UserId); err != nil {
-        return fmt.Errorf("invalid user ID: %v", err)
+        return invalidField("portfolio.user_id", "must be a UUID")
     }
 
     if req.Portfolio.Name == "" {
-        return fmt.Errorf("portfolio name is required")
+        return invalidField("portfolio.name", "is required")
     }
 
     return nil
 }
 
-func (h *PortfolioHandler) mapServiceError(err error) error {
-    switch err {
-    case services.ErrInvalidPortfolio:
-        return errInvalidRequest
-    case services.ErrRepositoryOperation:
-        return errInternal
-    default:
-        return errInternal
-    }
-}
-
 func (h *PortfolioHandler) convertToProtoPortfolio(p *models.Portfolio) *models.PortfolioProto {
     if p == nil {
         return nil
@@ -276,14 +340,20 @@ func (h *PortfolioHandler) convertToProtoPortfolio(p *models.Portfolio) *models.
             Id:           asset.ID.String(),
             Type:        asset.Type,
             Symbol:      asset.Symbol,
-            Amount:      asset.Amount.String(),
+            Amount:      models.FormatAmount(asset.Type, asset.Symbol, asset.Amount),
             CostBasis:   asset.CostBasis.String(),
             CurrentValue: asset.CurrentValue.String(),
             LastUpdated: asset.LastUpdated.Unix(),
         }
+        if asset.PriceAsOf != nil {
+            assets[i].PriceAsOf = timestamppb.New(*asset.PriceAsOf)
+            assets[i].PriceStale = asset.PriceStale
+        }
+        assets[i].PriceSource = asset.PriceSource
+        assets[i].Delisted = asset.Delisted
     }
 
-    return &models.PortfolioProto{
+    result := &models.PortfolioProto{
         Id:          p.ID.String(),
         UserId:      p.UserID.String(),
         Name:        p.Name,
@@ -293,5 +363,25 @@ func (h *PortfolioHandler) convertToProtoPortfolio(p *models.Portfolio) *models.
         ProfitLoss:  p.ProfitLoss.String(),
         CreatedAt:   p.CreatedAt.Unix(),
         LastUpdated: p.LastUpdated.Unix(),
+        DayChangePercentage:   optionalPercentage(p.Change24h),
+        WeekChangePercentage:  optionalPercentage(p.Change7d),
+        MonthChangePercentage: optionalPercentage(p.Change30d),
+    }
+    if p.ArchivedAt != nil {
+        result.ArchivedAt = timestamppb.New(*p.ArchivedAt)
+    }
+    if p.PricesAsOf != nil {
+        result.PricesAsOf = timestamppb.New(*p.PricesAsOf)
+        result.PricesStale = p.PricesStale
+    }
+    return result
+}
+
+// optionalPercentage converts a nullable percentage into an optional proto field
+func optionalPercentage(d decimal.NullDecimal) *float64 {
+    if !d.Valid {
+        return nil
     }
+    v := d.Decimal.InexactFloat64()
+    return &v
 }
\ No newline at end of file
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+    "google.golang.org/grpc/codes"    // v1.50.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/admin"
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// PurgeUserData handles admin requests to permanently erase a user's data
+func (h *PortfolioHandler) PurgeUserData(ctx context.Context, req *models.PurgeUserDataRequest) (*models.PurgeUserDataResponse, error) {
+    startTime := time.Now()
+    method := "PurgeUserData"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    // Erasure is admin only; the admin interceptor puts the operator in the
+    // context, so without admin authentication every call is refused
+    if operator, ok := admin.FromContext(ctx); !ok || !operator.HasScope(admin.ScopeAdmin) {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, statusError(codes.PermissionDenied, "ADMIN_REQUIRED", "user data purge requires an operator token with the admin scope")
+    }
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid user ID",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+        )
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    purge, err := h.portfolioService.PurgeUserData(ctx, userID)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to purge user data",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+        )
+        return nil, h.mapPurgeError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+    h.logger.Info("User data purged",
+        zap.String("user_id", req.UserId),
+        zap.String("purge_id", purge.ID.String()),
+    )
+
+    return &models.PurgeUserDataResponse{
+        PurgeId:      purge.ID.String(),
+        Portfolios:   int32(purge.Portfolios),
+        Assets:       int32(purge.Assets),
+        Transactions: int32(purge.Transactions),
+        Snapshots:    int32(purge.Snapshots),
+        Alerts:       int32(purge.Alerts),
+        AuditEntries: int32(purge.AuditEntries),
+        Archives:     int32(purge.Archives),
+        CompletedAt:  timestamppb.New(purge.CompletedAt),
+    }, nil
+}
+
+// mapPurgeError maps user data purge errors to gRPC status errors
+func (h *PortfolioHandler) mapPurgeError(err error) error {
+    switch {
+    case errors.Is(err, services.ErrFeatureDisabled):
+        return statusError(codes.Unimplemented, "FEATURE_DISABLED", "user data purge is not enabled")
+    }
+    return h.mapServiceError(err)
+}
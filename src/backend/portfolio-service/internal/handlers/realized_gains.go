@@ -0,0 +1,75 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// ListRealizedGains handles requests for the gains realized by the sells of
+// a portfolio over a period
+func (h *PortfolioHandler) ListRealizedGains(ctx context.Context, req *models.ListRealizedGainsRequest) (*models.ListRealizedGainsResponse, error) {
+    startTime := time.Now()
+    method := "ListRealizedGains"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    var start, end time.Time
+    if req.StartDate != nil {
+        start = req.StartDate.AsTime()
+    }
+    if req.EndDate != nil {
+        end = req.EndDate.AsTime()
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    gains, err := h.portfolioService.ListRealizedGains(ctx, portfolioID, start, end)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to list realized gains",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    result := make([]*models.RealizedGainProto, len(gains))
+    for i, g := range gains {
+        result[i] = &models.RealizedGainProto{
+            GainId:        g.ID.String(),
+            AssetId:       g.AssetID.String(),
+            TransactionId: g.TransactionID.String(),
+            Symbol:        g.Symbol,
+            Quantity:      g.Quantity.InexactFloat64(),
+            CostBasis:     g.CostBasis.InexactFloat64(),
+            Proceeds:      g.Proceeds.InexactFloat64(),
+            Gain:          g.Gain.InexactFloat64(),
+            DisposedAt:    timestamppb.New(g.DisposedAt),
+        }
+    }
+
+    return &models.ListRealizedGainsResponse{Gains: result}, nil
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/grpc/codes"                           // v1.50.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// reportPeriods maps protobuf report periods to calendar periods
+var reportPeriods = map[models.ReportPeriodProto]models.ReportPeriod{
+    models.ReportPeriodProto_REPORT_PERIOD_MONTHLY:   models.ReportPeriodMonth,
+    models.ReportPeriodProto_REPORT_PERIOD_QUARTERLY: models.ReportPeriodQuarter,
+    models.ReportPeriodProto_REPORT_PERIOD_WEEKLY:    models.ReportPeriodWeek,
+    models.ReportPeriodProto_REPORT_PERIOD_YEARLY:    models.ReportPeriodYear,
+}
+
+// reportFrequencies maps protobuf report frequencies to schedule frequencies
+var reportFrequencies = map[models.ReportFrequencyProto]models.ReportFrequency{
+    models.ReportFrequencyProto_REPORT_FREQUENCY_WEEKLY:  models.ReportWeekly,
+    models.ReportFrequencyProto_REPORT_FREQUENCY_MONTHLY: models.ReportMonthly,
+}
+
+// reportFormats maps protobuf report formats to file formats
+var reportFormats = map[models.ReportFormatProto]models.ReportFormat{
+    models.ReportFormatProto_REPORT_FORMAT_PDF: models.ReportFormatPDF,
+    models.ReportFormatProto_REPORT_FORMAT_CSV: models.ReportFormatCSV,
+}
+
+// GenerateReport handles PDF performance report requests
+func (h *PortfolioHandler) GenerateReport(ctx context.Context, req *models.GenerateReportRequest) (*models.GenerateReportResponse, error) {
+    startTime := time.Now()
+    method := "GenerateReport"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    period, ok := reportPeriods[req.Period]
+    if !ok {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    var at time.Time
+    if req.PeriodDate != nil {
+        at = req.PeriodDate.AsTime()
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    generated, err := h.portfolioService.GenerateReport(ctx, portfolioID, period, at)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to generate report",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapReportError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.GenerateReportResponse{
+        Report:      convertToProtoReport(generated.Report, req.Period),
+        DownloadUrl: generated.DownloadURL,
+        ExpiresAt:   timestamppb.New(generated.ExpiresAt),
+    }, nil
+}
+
+// SetReportSchedule handles requests to schedule recurring report delivery
+func (h *PortfolioHandler) SetReportSchedule(ctx context.Context, req *models.SetReportScheduleRequest) (*models.SetReportScheduleResponse, error) {
+    startTime := time.Now()
+    method := "SetReportSchedule"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.Schedule == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    schedule, err := convertFromProtoReportSchedule(req.Schedule)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, err
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    created, err := h.portfolioService.SetReportSchedule(ctx, schedule)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to set report schedule",
+            zap.Error(err),
+            zap.String("portfolio_id", req.Schedule.PortfolioId),
+        )
+        return nil, h.mapReportError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.SetReportScheduleResponse{Schedule: convertToProtoReportSchedule(created)}, nil
+}
+
+// ListReportSchedules handles requests to list a user's report schedules
+func (h *PortfolioHandler) ListReportSchedules(ctx context.Context, req *models.ListReportSchedulesRequest) (*models.ListReportSchedulesResponse, error) {
+    startTime := time.Now()
+    method := "ListReportSchedules"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    schedules, err := h.portfolioService.ListReportSchedules(ctx, userID)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to list report schedules",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+        )
+        return nil, h.mapReportError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    result := make([]*models.ReportScheduleProto, len(schedules))
+    for i := range schedules {
+        result[i] = convertToProtoReportSchedule(&schedules[i])
+    }
+
+    return &models.ListReportSchedulesResponse{Schedules: result}, nil
+}
+
+// DeleteReportSchedule handles requests to stop a scheduled report delivery
+func (h *PortfolioHandler) DeleteReportSchedule(ctx context.Context, req *models.DeleteReportScheduleRequest) (*models.DeleteReportScheduleResponse, error) {
+    startTime := time.Now()
+    method := "DeleteReportSchedule"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+    scheduleID, err := uuid.Parse(req.ScheduleId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("schedule_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    if err := h.portfolioService.DeleteReportSchedule(ctx, userID, scheduleID); err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to delete report schedule",
+            zap.Error(err),
+            zap.String("schedule_id", req.ScheduleId),
+        )
+        return nil, h.mapReportError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.DeleteReportScheduleResponse{Success: true}, nil
+}
+
+// convertFromProtoReportSchedule converts a protobuf report schedule request
+func convertFromProtoReportSchedule(s *models.ReportScheduleProto) (*models.ReportSchedule, error) {
+    portfolioID, err := uuid.Parse(s.PortfolioId)
+    if err != nil {
+        return nil, errInvalidRequest
+    }
+    userID, err := uuid.Parse(s.UserId)
+    if err != nil {
+        return nil, errInvalidRequest
+    }
+    frequency, ok := reportFrequencies[s.Frequency]
+    if !ok {
+        return nil, errInvalidRequest
+    }
+    format, ok := reportFormats[s.Format]
+    if !ok {
+        return nil, errInvalidRequest
+    }
+
+    return &models.ReportSchedule{
+        PortfolioID: portfolioID,
+        UserID:      userID,
+        Frequency:   frequency,
+        Format:      format,
+        Timezone:    s.Timezone,
+        Hour:        int(s.Hour),
+        Weekday:     int(s.Weekday),
+        DayOfMonth:  int(s.DayOfMonth),
+    }, nil
+}
+
+// convertToProtoReportSchedule converts a report schedule to its protobuf representation
+func convertToProtoReportSchedule(s *models.ReportSchedule) *models.ReportScheduleProto {
+    result := &models.ReportScheduleProto{
+        ScheduleId:  s.ID.String(),
+        PortfolioId: s.PortfolioID.String(),
+        UserId:      s.UserID.String(),
+        Timezone:    s.Timezone,
+        Hour:        int32(s.Hour),
+        Weekday:     int32(s.Weekday),
+        DayOfMonth:  int32(s.DayOfMonth),
+        NextRunAt:   timestamppb.New(s.NextRunAt),
+        LastError:   s.LastError,
+        CreatedAt:   timestamppb.New(s.CreatedAt),
+    }
+    for proto, frequency := range reportFrequencies {
+        if frequency == s.Frequency {
+            result.Frequency = proto
+        }
+    }
+    for proto, format := range reportFormats {
+        if format == s.Format {
+            result.Format = proto
+        }
+    }
+    if s.LastRunAt != nil {
+        result.LastRunAt = timestamppb.New(*s.LastRunAt)
+    }
+    return result
+}
+
+// convertToProtoReport converts a performance report to its protobuf representation
+func convertToProtoReport(r *models.PerformanceReport, period models.ReportPeriodProto) *models.PerformanceReportProto {
+    holdings := make([]*models.ReportHoldingProto, len(r.Holdings))
+    for i, h := range r.Holdings {
+        holdings[i] = &models.ReportHoldingProto{
+            Symbol:     h.Symbol,
+            Amount:     h.Amount.InexactFloat64(),
+            Value:      h.Value.InexactFloat64(),
+            CostBasis:  h.CostBasis.InexactFloat64(),
+            ProfitLoss: h.ProfitLoss.InexactFloat64(),
+            Weight:     h.Weight.InexactFloat64(),
+        }
+    }
+
+    movers := make([]*models.ReportMoverProto, len(r.TopMovers))
+    for i, m := range r.TopMovers {
+        movers[i] = &models.ReportMoverProto{
+            Symbol:           m.Symbol,
+            StartPrice:       m.StartPrice.InexactFloat64(),
+            EndPrice:         m.EndPrice.InexactFloat64(),
+            ChangePercentage: m.ChangePercent.InexactFloat64(),
+        }
+    }
+
+    return &models.PerformanceReportProto{
+        ReportId:         r.ID.String(),
+        PortfolioId:      r.PortfolioID.String(),
+        Period:           period,
+        PeriodStart:      timestamppb.New(r.PeriodStart),
+        PeriodEnd:        timestamppb.New(r.PeriodEnd),
+        BaseCurrency:     r.BaseCurrency,
+        StartValue:       r.StartValue.InexactFloat64(),
+        EndValue:         r.EndValue.InexactFloat64(),
+        PeriodProfitLoss: r.PeriodProfitLoss.InexactFloat64(),
+        EndProfitLoss:    r.EndProfitLoss.InexactFloat64(),
+        ReturnPercentage: r.ReturnPercentage.InexactFloat64(),
+        FeesPaid:         r.FeesPaid.InexactFloat64(),
+        Holdings:         holdings,
+        Allocation:       convertToProtoSlices(r.Allocation),
+        TopMovers:        movers,
+        GeneratedAt:      timestamppb.New(r.GeneratedAt),
+    }
+}
+
+// mapReportError maps performance report errors to gRPC status errors
+func (h *PortfolioHandler) mapReportError(err error) error {
+    switch {
+    case errors.Is(err, services.ErrFeatureDisabled):
+        return statusError(codes.Unimplemented, "FEATURE_DISABLED", "performance reports are not enabled")
+    case errors.Is(err, services.ErrInsufficientData):
+        return statusError(codes.FailedPrecondition, "INSUFFICIENT_MARKET_DATA", "portfolio has no valuation history for the period")
+    }
+    return h.mapServiceError(err)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/grpc/codes"                           // v1.50.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// varMethods maps VaR methods to their protobuf representation
+var varMethods = map[models.VaRMethod]models.VaRMethodProto{
+    models.VaRMethodParametric: models.VaRMethodProto_VAR_METHOD_PARAMETRIC,
+    models.VaRMethodHistorical: models.VaRMethodProto_VAR_METHOD_HISTORICAL,
+}
+
+// GetRiskMetrics handles Value-at-Risk estimation requests
+func (h *PortfolioHandler) GetRiskMetrics(ctx context.Context, req *models.GetRiskMetricsRequest) (*models.GetRiskMetricsResponse, error) {
+    startTime := time.Now()
+    method := "GetRiskMetrics"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.LookbackDays < 0 {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    risk, err := h.portfolioService.GetRiskMetrics(ctx, portfolioID, int(req.LookbackDays))
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to get risk metrics",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        switch {
+        case errors.Is(err, services.ErrInsufficientData):
+            return nil, statusError(codes.FailedPrecondition, "INSUFFICIENT_MARKET_DATA", "not enough price history to estimate risk")
+        case errors.Is(err, services.ErrFeatureDisabled):
+            return nil, statusError(codes.Unimplemented, "FEATURE_DISABLED", "risk metrics are not enabled")
+        }
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    estimates := make([]*models.VaREstimateProto, len(risk.VaR))
+    for i, v := range risk.VaR {
+        estimates[i] = &models.VaREstimateProto{
+            Method:      varMethods[v.Method],
+            Confidence:  v.Confidence,
+            HorizonDays: int32(v.HorizonDays),
+            Value:       v.Value.InexactFloat64(),
+        }
+    }
+
+    return &models.GetRiskMetricsResponse{
+        Metrics: &models.RiskMetricsProto{
+            PortfolioId:      risk.PortfolioID.String(),
+            CoveredValue:     risk.CoveredValue.InexactFloat64(),
+            Observations:     int32(risk.Observations),
+            WindowStart:      timestamppb.New(risk.WindowStart),
+            WindowEnd:        timestamppb.New(risk.WindowEnd),
+            Var:              estimates,
+            UncoveredSymbols: risk.UncoveredSymbols,
+            CalculatedAt:     timestamppb.New(risk.CalculatedAt),
+        },
+    }, nil
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// GetServerInfo returns build metadata of the running instance for client diagnostics
+func (h *PortfolioHandler) GetServerInfo(ctx context.Context, req *models.GetServerInfoRequest) (*models.GetServerInfoResponse, error) {
+    startTime := time.Now()
+    method := "GetServerInfo"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    features := make([]string, len(h.buildInfo.Features))
+    copy(features, h.buildInfo.Features)
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.GetServerInfoResponse{
+        Info: &models.ServerInfoProto{
+            Version:   h.buildInfo.Version,
+            GitSha:    h.buildInfo.GitSHA,
+            BuildTime: h.buildInfo.BuildTime,
+            GoVersion: h.buildInfo.GoVersion,
+            Features:  features,
+        },
+    }, nil
+}
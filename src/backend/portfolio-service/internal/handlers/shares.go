@@ -0,0 +1,220 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+    "google.golang.org/grpc/codes"    // v1.50.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// SharePortfolio handles requests to mint a read-only share link
+func (h *PortfolioHandler) SharePortfolio(ctx context.Context, req *models.SharePortfolioRequest) (*models.SharePortfolioResponse, error) {
+    startTime := time.Now()
+    method := "SharePortfolio"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.TtlSeconds < 0 {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    share, token, err := h.portfolioService.SharePortfolio(ctx, portfolioID, time.Duration(req.TtlSeconds)*time.Second)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to share portfolio",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapShareError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.SharePortfolioResponse{
+        Share: convertToProtoShare(share),
+        Token: token,
+    }, nil
+}
+
+// ListPortfolioShares handles requests to list the share links of a portfolio
+func (h *PortfolioHandler) ListPortfolioShares(ctx context.Context, req *models.ListPortfolioSharesRequest) (*models.ListPortfolioSharesResponse, error) {
+    startTime := time.Now()
+    method := "ListPortfolioShares"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    shares, err := h.portfolioService.ListShares(ctx, portfolioID)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to list portfolio shares",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapShareError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    result := make([]*models.PortfolioShareProto, len(shares))
+    for i := range shares {
+        result[i] = convertToProtoShare(&shares[i])
+    }
+    return &models.ListPortfolioSharesResponse{Shares: result}, nil
+}
+
+// RevokePortfolioShare handles requests to revoke a share link
+func (h *PortfolioHandler) RevokePortfolioShare(ctx context.Context, req *models.RevokePortfolioShareRequest) (*models.RevokePortfolioShareResponse, error) {
+    startTime := time.Now()
+    method := "RevokePortfolioShare"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    shareID, err := uuid.Parse(req.ShareId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid share ID",
+            zap.Error(err),
+            zap.String("share_id", req.ShareId),
+        )
+        return nil, invalidField("share_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    if err := h.portfolioService.RevokeShare(ctx, portfolioID, shareID); err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to revoke portfolio share",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+            zap.String("share_id", req.ShareId),
+        )
+        return nil, h.mapShareError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.RevokePortfolioShareResponse{Success: true}, nil
+}
+
+// GetSharedPortfolio handles reads of a portfolio through the share token
+// verified by the sharing interceptor
+func (h *PortfolioHandler) GetSharedPortfolio(ctx context.Context, req *models.GetSharedPortfolioRequest) (*models.GetSharedPortfolioResponse, error) {
+    startTime := time.Now()
+    method := "GetSharedPortfolio"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    portfolio, allocation, share, err := h.portfolioService.GetSharedPortfolio(ctx)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to get shared portfolio", zap.Error(err))
+        return nil, h.mapShareError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.GetSharedPortfolioResponse{
+        Name:                  portfolio.Name,
+        TotalValue:            portfolio.TotalValue.InexactFloat64(),
+        TotalProfitLoss:       portfolio.ProfitLoss.InexactFloat64(),
+        DayChangePercentage:   optionalPercentage(portfolio.Change24h),
+        WeekChangePercentage:  optionalPercentage(portfolio.Change7d),
+        MonthChangePercentage: optionalPercentage(portfolio.Change30d),
+        Allocation:            convertToProtoAllocation(allocation),
+        ExpiresAt:             timestamppb.New(share.ExpiresAt),
+    }, nil
+}
+
+// convertToProtoShare converts a portfolio share to its protobuf representation
+func convertToProtoShare(share *models.PortfolioShare) *models.PortfolioShareProto {
+    result := &models.PortfolioShareProto{
+        ShareId:     share.ID.String(),
+        PortfolioId: share.PortfolioID.String(),
+        CreatedAt:   timestamppb.New(share.CreatedAt),
+        ExpiresAt:   timestamppb.New(share.ExpiresAt),
+    }
+    if share.RevokedAt != nil {
+        result.RevokedAt = timestamppb.New(*share.RevokedAt)
+    }
+    return result
+}
+
+// mapShareError maps portfolio share errors to gRPC status errors
+func (h *PortfolioHandler) mapShareError(err error) error {
+    switch {
+    case errors.Is(err, services.ErrFeatureDisabled):
+        return statusError(codes.Unimplemented, "FEATURE_DISABLED", "portfolio sharing is not enabled")
+    case errors.Is(err, services.ErrShareRequired):
+        return statusError(codes.Unauthenticated, "SHARE_REQUIRED", "a share token is required")
+    case errors.Is(err, services.ErrNotFound):
+        return statusError(codes.NotFound, "SHARE_NOT_FOUND", "portfolio or share not found")
+    }
+    return h.mapServiceError(err)
+}
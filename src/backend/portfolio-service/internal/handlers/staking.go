@@ -0,0 +1,190 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"          // v1.3.0
+    "go.uber.org/zap"                // v1.24.0
+    "google.golang.org/grpc/codes"   // v1.50.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/chains"
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// AddStakingPosition handles requests to accrue the rewards of a staked
+// asset automatically. Stake account addresses are never logged.
+func (h *PortfolioHandler) AddStakingPosition(ctx context.Context, req *models.AddStakingPositionRequest) (*models.AddStakingPositionResponse, error) {
+    startTime := time.Now()
+    method := "AddStakingPosition"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.Source == "" || req.Provider == "" {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+    assetID, err := uuid.Parse(req.AssetId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("asset_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    position, err := h.portfolioService.AddStakingPosition(ctx, portfolioID, assetID, req.Source, req.Provider, req.Address)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to add staking position",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+            zap.String("asset_id", req.AssetId),
+            zap.String("provider", req.Provider),
+        )
+        return nil, h.mapStakingError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.AddStakingPositionResponse{Position: convertToProtoStakingPosition(position)}, nil
+}
+
+// ListStakingPositions handles requests to list the staking positions of a
+// portfolio
+func (h *PortfolioHandler) ListStakingPositions(ctx context.Context, req *models.ListStakingPositionsRequest) (*models.ListStakingPositionsResponse, error) {
+    startTime := time.Now()
+    method := "ListStakingPositions"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    positions, err := h.portfolioService.ListStakingPositions(ctx, portfolioID)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to list staking positions",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapStakingError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    result := make([]*models.StakingPositionProto, len(positions))
+    for i := range positions {
+        result[i] = convertToProtoStakingPosition(&positions[i])
+    }
+
+    return &models.ListStakingPositionsResponse{Positions: result}, nil
+}
+
+// RemoveStakingPosition handles requests to stop accruing the rewards of a
+// staking position
+func (h *PortfolioHandler) RemoveStakingPosition(ctx context.Context, req *models.RemoveStakingPositionRequest) (*models.RemoveStakingPositionResponse, error) {
+    startTime := time.Now()
+    method := "RemoveStakingPosition"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+    positionID, err := uuid.Parse(req.PositionId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("position_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    if err := h.portfolioService.RemoveStakingPosition(ctx, portfolioID, positionID); err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to remove staking position",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+            zap.String("position_id", req.PositionId),
+        )
+        return nil, h.mapStakingError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.RemoveStakingPositionResponse{Success: true}, nil
+}
+
+// mapStakingError maps staking reward errors to gRPC status errors
+func (h *PortfolioHandler) mapStakingError(err error) error {
+    switch {
+    case errors.Is(err, services.ErrFeatureDisabled):
+        return statusError(codes.Unimplemented, "FEATURE_DISABLED", "staking rewards are not enabled")
+    case errors.Is(err, services.ErrNotFound):
+        return statusError(codes.NotFound, "STAKING_POSITION_NOT_FOUND", "staking position or asset not found")
+    case errors.Is(err, services.ErrStakingPositionExists):
+        return statusError(codes.AlreadyExists, "STAKING_POSITION_EXISTS", "asset already has a staking position")
+    case errors.Is(err, chains.ErrUnsupportedChain):
+        return statusError(codes.InvalidArgument, "UNSUPPORTED_CHAIN", "unsupported chain")
+    case errors.Is(err, chains.ErrUnsupportedStaking):
+        return statusError(codes.InvalidArgument, "UNSUPPORTED_CHAIN", "staking rewards are not supported on the chain")
+    case errors.Is(err, chains.ErrInvalidAddress):
+        return statusError(codes.InvalidArgument, "INVALID_STAKE_ACCOUNT", "invalid stake account address")
+    }
+    return h.mapServiceError(err)
+}
+
+// convertToProtoStakingPosition converts a staking position to its protobuf
+// representation
+func convertToProtoStakingPosition(position *models.StakingPosition) *models.StakingPositionProto {
+    result := &models.StakingPositionProto{
+        PositionId:    position.ID.String(),
+        PortfolioId:   position.PortfolioID.String(),
+        AssetId:       position.AssetID.String(),
+        Source:        position.Source,
+        Provider:      position.Provider,
+        Address:       position.Address,
+        LastError:     position.LastError,
+        NextAccrualAt: timestamppb.New(position.NextAccrualAt),
+        CreatedAt:     timestamppb.New(position.CreatedAt),
+    }
+    if position.LastAccruedAt != nil {
+        result.LastAccruedAt = timestamppb.New(*position.LastAccruedAt)
+    }
+    return result
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// GetUserSummary handles requests for the aggregate of a user's portfolios
+func (h *PortfolioHandler) GetUserSummary(ctx context.Context, req *models.GetUserSummaryRequest) (*models.GetUserSummaryResponse, error) {
+    startTime := time.Now()
+    method := "GetUserSummary"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid user ID",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+        )
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    summary, err := h.portfolioService.GetUserSummary(ctx, userID)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to get user summary",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    portfolios := make([]*models.PortfolioSummaryProto, len(summary.Portfolios))
+    for i, p := range summary.Portfolios {
+        portfolios[i] = &models.PortfolioSummaryProto{
+            PortfolioId:     p.PortfolioID.String(),
+            Name:            p.Name,
+            TotalValue:      p.TotalValue.InexactFloat64(),
+            TotalProfitLoss: p.ProfitLoss.InexactFloat64(),
+            Percentage:      p.Percentage.InexactFloat64(),
+        }
+    }
+
+    return &models.GetUserSummaryResponse{
+        UserId:                    summary.UserID.String(),
+        TotalValue:                summary.TotalValue.InexactFloat64(),
+        TotalProfitLoss:           summary.ProfitLoss.InexactFloat64(),
+        TotalProfitLossPercentage: summary.ProfitLossPercentage.InexactFloat64(),
+        Portfolios:                portfolios,
+        Allocation:                convertToProtoAllocation(summary.Allocation),
+        CalculatedAt:              timestamppb.New(summary.CalculatedAt),
+    }, nil
+}
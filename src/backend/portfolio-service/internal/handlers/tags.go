@@ -0,0 +1,81 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+    "google.golang.org/grpc/codes"    // v1.50.0
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// SetAssetTags handles asset tag update requests
+func (h *PortfolioHandler) SetAssetTags(ctx context.Context, req *models.SetAssetTagsRequest) (*models.SetAssetTagsResponse, error) {
+    startTime := time.Now()
+    method := "SetAssetTags"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+    assetID, err := uuid.Parse(req.AssetId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid asset ID",
+            zap.Error(err),
+            zap.String("asset_id", req.AssetId),
+        )
+        return nil, invalidField("asset_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    stored, err := h.portfolioService.SetAssetTags(ctx, portfolioID, assetID, req.Tags)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to set asset tags",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+            zap.String("asset_id", req.AssetId),
+        )
+        return nil, h.mapTagError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+    h.logger.Info("Asset tags updated",
+        zap.String("portfolio_id", req.PortfolioId),
+        zap.String("asset_id", req.AssetId),
+        zap.Int("tags", len(stored)),
+    )
+
+    return &models.SetAssetTagsResponse{Tags: stored}, nil
+}
+
+// mapTagError maps asset tag errors to gRPC status errors
+func (h *PortfolioHandler) mapTagError(err error) error {
+    switch {
+    case errors.Is(err, services.ErrNotFound):
+        return statusError(codes.NotFound, "ASSET_NOT_FOUND", "asset not found")
+    }
+    return h.mapServiceError(err)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// GetTaxSettings handles requests for the tax settings applied to a user's
+// tax reports
+func (h *PortfolioHandler) GetTaxSettings(ctx context.Context, req *models.GetTaxSettingsRequest) (*models.GetTaxSettingsResponse, error) {
+    startTime := time.Now()
+    method := "GetTaxSettings"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    settings, err := h.portfolioService.GetTaxSettings(ctx, userID)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to get tax settings",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.GetTaxSettingsResponse{
+        Settings:  convertToProtoTaxSettings(settings),
+        IsDefault: settings.UpdatedAt.IsZero(),
+    }, nil
+}
+
+// SetTaxSettings handles tax settings update requests
+func (h *PortfolioHandler) SetTaxSettings(ctx context.Context, req *models.SetTaxSettingsRequest) (*models.SetTaxSettingsResponse, error) {
+    startTime := time.Now()
+    method := "SetTaxSettings"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.Settings == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.Settings.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("settings.user_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    stored, err := h.portfolioService.SetTaxSettings(ctx, &models.TaxSettings{
+        UserID:  userID,
+        Country: req.Settings.Country,
+        TaxYear: models.TaxYear{
+            StartMonth: time.Month(req.Settings.TaxYearStartMonth),
+            StartDay:   int(req.Settings.TaxYearStartDay),
+        },
+        LongTermDays: int(req.Settings.LongTermDays),
+        WashSales:    req.Settings.WashSales,
+    }, req.UseJurisdictionDefaults)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to set tax settings",
+            zap.Error(err),
+            zap.String("user_id", req.Settings.UserId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+    h.logger.Info("Tax settings updated",
+        zap.String("user_id", req.Settings.UserId),
+        zap.String("country", stored.Country),
+    )
+
+    return &models.SetTaxSettingsResponse{Settings: convertToProtoTaxSettings(stored)}, nil
+}
+
+// convertToProtoTaxSettings converts tax settings to their protobuf
+// representation, leaving the update time of defaults unset
+func convertToProtoTaxSettings(s *models.TaxSettings) *models.TaxSettingsProto {
+    settings := &models.TaxSettingsProto{
+        UserId:            s.UserID.String(),
+        Country:           s.Country,
+        TaxYearStartMonth: int32(s.TaxYear.StartMonth),
+        TaxYearStartDay:   int32(s.TaxYear.StartDay),
+        LongTermDays:      int32(s.LongTermDays),
+        WashSales:         s.WashSales,
+    }
+    if !s.UpdatedAt.IsZero() {
+        settings.UpdatedAt = timestamppb.New(s.UpdatedAt)
+    }
+    return settings
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "github.com/shopspring/decimal"                          // v1.3.1
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// transactionTypes maps transaction types to their protobuf representation
+var transactionTypes = map[string]models.TransactionType{
+    "buy":          models.TransactionType_TRANSACTION_TYPE_BUY,
+    "sell":         models.TransactionType_TRANSACTION_TYPE_SELL,
+    "transfer_in":  models.TransactionType_TRANSACTION_TYPE_TRANSFER_IN,
+    "transfer_out": models.TransactionType_TRANSACTION_TYPE_TRANSFER_OUT,
+    "stake":        models.TransactionType_TRANSACTION_TYPE_STAKE,
+    "unstake":      models.TransactionType_TRANSACTION_TYPE_UNSTAKE,
+    "reward":       models.TransactionType_TRANSACTION_TYPE_REWARD,
+    "fee":          models.TransactionType_TRANSACTION_TYPE_FEE,
+    "airdrop":      models.TransactionType_TRANSACTION_TYPE_AIRDROP,
+    "fork":         models.TransactionType_TRANSACTION_TYPE_FORK,
+    "interest":     models.TransactionType_TRANSACTION_TYPE_INTEREST,
+}
+
+// GetTransactions handles paginated transaction history requests
+func (h *PortfolioHandler) GetTransactions(ctx context.Context, req *models.GetTransactionsRequest) (*models.GetTransactionsResponse, error) {
+    startTime := time.Now()
+    method := "GetTransactions"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.PageSize < 0 {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+
+    var filter repository.TransactionFilter
+    if req.AssetId != "" {
+        if filter.AssetID, err = uuid.Parse(req.AssetId); err != nil {
+            metrics.Requests.WithLabelValues(method, "error").Inc()
+            return nil, invalidField("asset_id", "must be a UUID")
+        }
+    }
+    if req.StartDate != nil {
+        filter.Start = req.StartDate.AsTime()
+    }
+    if req.EndDate != nil {
+        filter.End = req.EndDate.AsTime()
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    txs, nextToken, err := h.portfolioService.ListTransactions(ctx, portfolioID, int(req.PageSize), req.PageToken, filter)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to list transactions",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    result := make([]*models.TransactionProto, len(txs))
+    for i, tx := range txs {
+        result[i] = &models.TransactionProto{
+            TransactionId: tx.ID.String(),
+            PortfolioId:   tx.PortfolioID.String(),
+            AssetId:       tx.AssetID.String(),
+            Type:          transactionTypes[tx.Type],
+            Quantity:      tx.Amount.InexactFloat64(),
+            Price:         tx.Price.InexactFloat64(),
+            TotalAmount:   tx.Amount.Mul(tx.Price).InexactFloat64(),
+            Fee:           tx.Fee.InexactFloat64(),
+            Timestamp:     timestamppb.New(tx.Timestamp),
+        }
+    }
+
+    return &models.GetTransactionsResponse{Transactions: result, NextPageToken: nextToken}, nil
+}
+
+// UpdateTransaction handles requests to edit a recorded transaction
+func (h *PortfolioHandler) UpdateTransaction(ctx context.Context, req *models.UpdateTransactionRequest) (*models.UpdateTransactionResponse, error) {
+    startTime := time.Now()
+    method := "UpdateTransaction"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.Transaction == nil || req.Transaction.Quantity <= 0 || req.Transaction.Timestamp == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.Transaction.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("transaction.portfolio_id", "must be a UUID")
+    }
+    transactionID, err := uuid.Parse(req.Transaction.TransactionId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("transaction.transaction_id", "must be a UUID")
+    }
+    transactionType := convertFromProtoTransactionType(req.Transaction.Type)
+    if transactionType == "" {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("transaction.type", "must be a supported transaction type")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    recomputation, err := h.portfolioService.UpdateTransaction(ctx, &models.Transaction{
+        ID:          transactionID,
+        PortfolioID: portfolioID,
+        Type:        transactionType,
+        Amount:      decimal.NewFromFloat(req.Transaction.Quantity),
+        Price:       decimal.NewFromFloat(req.Transaction.Price),
+        Fee:         decimal.NewFromFloat(req.Transaction.Fee),
+        Timestamp:   req.Transaction.Timestamp.AsTime(),
+    })
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to update transaction",
+            zap.Error(err),
+            zap.String("portfolio_id", req.Transaction.PortfolioId),
+            zap.String("transaction_id", req.Transaction.TransactionId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.UpdateTransactionResponse{Recomputation: lotRecomputationToProto(recomputation)}, nil
+}
+
+// DeleteTransaction handles requests to delete a recorded transaction
+func (h *PortfolioHandler) DeleteTransaction(ctx context.Context, req *models.DeleteTransactionRequest) (*models.DeleteTransactionResponse, error) {
+    startTime := time.Now()
+    method := "DeleteTransaction"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    portfolioID, err := uuid.Parse(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("portfolio_id", "must be a UUID")
+    }
+    transactionID, err := uuid.Parse(req.TransactionId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("transaction_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    recomputation, err := h.portfolioService.DeleteTransaction(ctx, portfolioID, transactionID)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to delete transaction",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+            zap.String("transaction_id", req.TransactionId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.DeleteTransactionResponse{Recomputation: lotRecomputationToProto(recomputation)}, nil
+}
+
+// convertFromProtoTransactionType converts a protobuf transaction type;
+// unknown values map to an empty type
+func convertFromProtoTransactionType(t models.TransactionType) string {
+    for name, proto := range transactionTypes {
+        if proto == t {
+            return name
+        }
+    }
+    return ""
+}
+
+// lotRecomputationToProto converts a restated asset to its protobuf representation
+func lotRecomputationToProto(r *models.LotRecomputation) *models.LotRecomputationProto {
+    lots := make([]*models.LotProto, len(r.Lots))
+    for i, lot := range r.Lots {
+        lots[i] = &models.LotProto{
+            AcquiredAt: timestamppb.New(lot.AcquiredAt),
+            Quantity:   lot.Quantity.InexactFloat64(),
+            CostBasis:  lot.CostBasis.InexactFloat64(),
+        }
+        if lot.TransactionID != uuid.Nil {
+            lots[i].TransactionId = lot.TransactionID.String()
+        }
+    }
+
+    return &models.LotRecomputationProto{
+        AssetId:     r.AssetID.String(),
+        Amount:      r.Amount.InexactFloat64(),
+        CostBasis:   r.CostBasis.InexactFloat64(),
+        RealizedPnl: r.RealizedPnL.InexactFloat64(),
+        Lots:        lots,
+    }
+}
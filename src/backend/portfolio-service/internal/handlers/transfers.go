@@ -0,0 +1,130 @@
+package handlers
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+)
+
+// LinkTransfer handles requests to link a transfer between a user's
+// portfolios by hand
+func (h *PortfolioHandler) LinkTransfer(ctx context.Context, req *models.LinkTransferRequest) (*models.LinkTransferResponse, error) {
+    startTime := time.Now()
+    method := "LinkTransfer"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+    outID, err := uuid.Parse(req.OutTransactionId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("out_transaction_id", "must be a UUID")
+    }
+    inID, err := uuid.Parse(req.InTransactionId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("in_transaction_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    link, recomputation, err := h.portfolioService.LinkTransfer(ctx, userID, outID, inID)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to link transfer",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+            zap.String("out_transaction_id", req.OutTransactionId),
+            zap.String("in_transaction_id", req.InTransactionId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.LinkTransferResponse{
+        Link:          transferLinkToProto(link),
+        Recomputation: lotRecomputationToProto(recomputation),
+    }, nil
+}
+
+// UnlinkTransfer handles requests to remove the link of a transfer
+func (h *PortfolioHandler) UnlinkTransfer(ctx context.Context, req *models.UnlinkTransferRequest) (*models.UnlinkTransferResponse, error) {
+    startTime := time.Now()
+    method := "UnlinkTransfer"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+    transactionID, err := uuid.Parse(req.TransactionId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("transaction_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    link, recomputation, err := h.portfolioService.UnlinkTransfer(ctx, userID, transactionID)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to unlink transfer",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+            zap.String("transaction_id", req.TransactionId),
+        )
+        return nil, h.mapServiceError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.UnlinkTransferResponse{
+        Link:          transferLinkToProto(link),
+        Recomputation: lotRecomputationToProto(recomputation),
+    }, nil
+}
+
+// transferLinkToProto converts a transfer link to its protobuf representation
+func transferLinkToProto(link *models.TransferLink) *models.TransferLinkProto {
+    return &models.TransferLinkProto{
+        LinkId:           link.ID.String(),
+        OutTransactionId: link.OutTransactionID.String(),
+        InTransactionId:  link.InTransactionID.String(),
+        OutPortfolioId:   link.OutPortfolioID.String(),
+        InPortfolioId:    link.InPortfolioID.String(),
+        Symbol:           link.Symbol,
+        Amount:           link.Amount.InexactFloat64(),
+        CostBasis:        link.CostBasis.InexactFloat64(),
+        MatchedBy:        link.MatchedBy,
+        CreatedAt:        timestamppb.New(link.CreatedAt),
+    }
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"          // v1.3.0
+    "go.uber.org/zap"                // v1.24.0
+    "google.golang.org/grpc/codes"   // v1.50.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/chains"
+    "bookman/portfolio-service/internal/exchanges"
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// TrackWallet handles requests to track an on-chain wallet in a new
+// read-only portfolio, or in the portfolio of another tracked wallet.
+// Addresses are never logged.
+func (h *PortfolioHandler) TrackWallet(ctx context.Context, req *models.TrackWalletRequest) (*models.TrackWalletResponse, error) {
+    startTime := time.Now()
+    method := "TrackWallet"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.Chain == "" || req.Address == "" {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+
+    var portfolioID uuid.UUID
+    if req.PortfolioId != "" {
+        portfolioID, err = uuid.Parse(req.PortfolioId)
+        if err != nil {
+            metrics.Requests.WithLabelValues(method, "error").Inc()
+            return nil, invalidField("portfolio_id", "must be a UUID")
+        }
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    wallet, err := h.portfolioService.TrackWallet(ctx, userID, portfolioID, req.Chain, req.Address, req.Name)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to track wallet",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+            zap.String("chain", req.Chain),
+        )
+        return nil, h.mapWalletError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.TrackWalletResponse{Wallet: convertToProtoTrackedWallet(wallet)}, nil
+}
+
+// ListTrackedWallets handles requests to list a user's tracked wallets
+func (h *PortfolioHandler) ListTrackedWallets(ctx context.Context, req *models.ListTrackedWalletsRequest) (*models.ListTrackedWalletsResponse, error) {
+    startTime := time.Now()
+    method := "ListTrackedWallets"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    wallets, err := h.portfolioService.ListTrackedWallets(ctx, userID)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to list tracked wallets",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+        )
+        return nil, h.mapWalletError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    result := make([]*models.TrackedWalletProto, len(wallets))
+    for i := range wallets {
+        result[i] = convertToProtoTrackedWallet(&wallets[i])
+    }
+
+    return &models.ListTrackedWalletsResponse{Wallets: result}, nil
+}
+
+// SyncWallet handles requests to sync a tracked wallet right away
+func (h *PortfolioHandler) SyncWallet(ctx context.Context, req *models.SyncWalletRequest) (*models.ImportExchangeResponse, error) {
+    startTime := time.Now()
+    method := "SyncWallet"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+    walletID, err := uuid.Parse(req.WalletId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("wallet_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    result, err := h.portfolioService.SyncWallet(ctx, userID, walletID)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to sync wallet",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+            zap.String("wallet_id", req.WalletId),
+        )
+        return nil, h.mapWalletError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return convertToProtoExchangeImport(result), nil
+}
+
+// UntrackWallet handles requests to stop syncing a tracked wallet
+func (h *PortfolioHandler) UntrackWallet(ctx context.Context, req *models.UntrackWalletRequest) (*models.UntrackWalletResponse, error) {
+    startTime := time.Now()
+    method := "UntrackWallet"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+    walletID, err := uuid.Parse(req.WalletId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("wallet_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    if err := h.portfolioService.UntrackWallet(ctx, userID, walletID); err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to untrack wallet",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+            zap.String("wallet_id", req.WalletId),
+        )
+        return nil, h.mapWalletError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.UntrackWalletResponse{Success: true}, nil
+}
+
+// mapWalletError maps wallet tracking errors to gRPC status errors
+func (h *PortfolioHandler) mapWalletError(err error) error {
+    switch {
+    case errors.Is(err, services.ErrFeatureDisabled):
+        return statusError(codes.Unimplemented, "FEATURE_DISABLED", "wallet tracking is not enabled")
+    case errors.Is(err, services.ErrNotFound):
+        return statusError(codes.NotFound, "WALLET_NOT_FOUND", "tracked wallet not found")
+    case errors.Is(err, services.ErrWalletAlreadyTracked):
+        return statusError(codes.AlreadyExists, "WALLET_ALREADY_TRACKED", "wallet already tracked")
+    case errors.Is(err, services.ErrInvalidPortfolio):
+        return statusError(codes.InvalidArgument, "WALLET_NOT_HELD", "portfolio does not hold a tracked wallet of the user")
+    case errors.Is(err, chains.ErrUnsupportedChain):
+        return statusError(codes.InvalidArgument, "UNSUPPORTED_CHAIN", "unsupported chain")
+    case errors.Is(err, chains.ErrInvalidAddress):
+        return statusError(codes.InvalidArgument, "INVALID_WALLET_ADDRESS", "invalid wallet address")
+    case errors.Is(err, chains.ErrInvalidCursor):
+        return statusError(codes.FailedPrecondition, "INVALID_WALLET_CHECKPOINT", "stored wallet checkpoint is invalid")
+    case errors.Is(err, services.ErrInsufficientData):
+        return statusError(codes.FailedPrecondition, "INSUFFICIENT_MARKET_DATA", "no price available for a wallet asset")
+    case errors.Is(err, exchanges.ErrRateLimited):
+        return unavailable("CHAIN_RATE_LIMITED", "chain backend rate limit exceeded, retry later", rateLimitRetryDelay)
+    case errors.Is(err, exchanges.ErrTemporary):
+        return unavailable("CHAIN_UNAVAILABLE", "chain backend temporarily unavailable, retry later", unavailableRetryDelay)
+    }
+    return h.mapServiceError(err)
+}
+
+// convertToProtoTrackedWallet converts a tracked wallet to its protobuf
+// representation
+func convertToProtoTrackedWallet(wallet *models.TrackedWallet) *models.TrackedWalletProto {
+    result := &models.TrackedWalletProto{
+        WalletId:    wallet.ID.String(),
+        PortfolioId: wallet.PortfolioID.String(),
+        UserId:      wallet.UserID.String(),
+        Chain:       wallet.Chain,
+        Address:     wallet.Address,
+        LastError:   wallet.LastError,
+        NextSyncAt:  timestamppb.New(wallet.NextSyncAt),
+        CreatedAt:   timestamppb.New(wallet.CreatedAt),
+    }
+    if wallet.LastSyncedAt != nil {
+        result.LastSyncedAt = timestamppb.New(*wallet.LastSyncedAt)
+    }
+    return result
+}
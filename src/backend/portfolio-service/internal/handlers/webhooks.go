@@ -0,0 +1,283 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"                                  // v1.3.0
+    "go.uber.org/zap"                                        // v1.24.0
+    "google.golang.org/grpc/codes"                           // v1.50.0
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// deliveryStatuses maps webhook delivery statuses to their protobuf representation
+var deliveryStatuses = map[models.DeliveryStatus]models.WebhookDeliveryStatus{
+    models.DeliveryPending:   models.WebhookDeliveryStatus_WEBHOOK_DELIVERY_STATUS_PENDING,
+    models.DeliverySucceeded: models.WebhookDeliveryStatus_WEBHOOK_DELIVERY_STATUS_SUCCEEDED,
+    models.DeliveryFailed:    models.WebhookDeliveryStatus_WEBHOOK_DELIVERY_STATUS_FAILED,
+}
+
+// RegisterWebhook handles webhook endpoint registration requests
+func (h *PortfolioHandler) RegisterWebhook(ctx context.Context, req *models.RegisterWebhookRequest) (*models.RegisterWebhookResponse, error) {
+    startTime := time.Now()
+    method := "RegisterWebhook"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+    portfolioID, err := parseOptionalID(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Invalid portfolio ID",
+            zap.Error(err),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, errInvalidRequest
+    }
+
+    events := make([]models.WebhookEvent, len(req.Events))
+    for i, event := range req.Events {
+        events[i] = models.WebhookEvent(event)
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    endpoint, err := h.portfolioService.RegisterWebhook(ctx, &models.WebhookEndpoint{
+        UserID:      userID,
+        PortfolioID: portfolioID,
+        URL:         req.Url,
+        Events:      events,
+    })
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to register webhook",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+            zap.String("portfolio_id", req.PortfolioId),
+        )
+        return nil, h.mapWebhookError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    result := convertToProtoWebhook(endpoint)
+    result.Secret = endpoint.Secret
+
+    return &models.RegisterWebhookResponse{Webhook: result}, nil
+}
+
+// ListWebhooks handles webhook endpoint listing requests
+func (h *PortfolioHandler) ListWebhooks(ctx context.Context, req *models.ListWebhooksRequest) (*models.ListWebhooksResponse, error) {
+    startTime := time.Now()
+    method := "ListWebhooks"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+    portfolioID, err := parseOptionalID(req.PortfolioId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    endpoints, err := h.portfolioService.ListWebhooks(ctx, userID, portfolioID)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to list webhooks",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+        )
+        return nil, h.mapWebhookError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    result := make([]*models.WebhookEndpointProto, len(endpoints))
+    for i := range endpoints {
+        result[i] = convertToProtoWebhook(&endpoints[i])
+    }
+
+    return &models.ListWebhooksResponse{Webhooks: result}, nil
+}
+
+// DeleteWebhook handles webhook endpoint deletion requests
+func (h *PortfolioHandler) DeleteWebhook(ctx context.Context, req *models.DeleteWebhookRequest) (*models.DeleteWebhookResponse, error) {
+    startTime := time.Now()
+    method := "DeleteWebhook"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+    webhookID, err := uuid.Parse(req.WebhookId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("webhook_id", "must be a UUID")
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    if err := h.portfolioService.DeleteWebhook(ctx, userID, webhookID); err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to delete webhook",
+            zap.Error(err),
+            zap.String("user_id", req.UserId),
+            zap.String("webhook_id", req.WebhookId),
+        )
+        return nil, h.mapWebhookError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    return &models.DeleteWebhookResponse{Success: true}, nil
+}
+
+// ListWebhookDeliveries handles webhook delivery status requests
+func (h *PortfolioHandler) ListWebhookDeliveries(ctx context.Context, req *models.ListWebhookDeliveriesRequest) (*models.ListWebhookDeliveriesResponse, error) {
+    startTime := time.Now()
+    method := "ListWebhookDeliveries"
+
+    defer func() {
+        metrics.ObserveRequest(ctx, method, startTime)
+    }()
+
+    if req == nil || req.PageSize < 0 {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, errInvalidRequest
+    }
+
+    userID, err := uuid.Parse(req.UserId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("user_id", "must be a UUID")
+    }
+    webhookID, err := uuid.Parse(req.WebhookId)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        return nil, invalidField("webhook_id", "must be a UUID")
+    }
+
+    var before time.Time
+    if req.Before != nil {
+        before = req.Before.AsTime()
+    }
+
+    h.mutex.RLock()
+    defer h.mutex.RUnlock()
+
+    deliveries, nextToken, err := h.portfolioService.ListWebhookDeliveries(ctx, userID, webhookID, before, int(req.PageSize), req.PageToken)
+    if err != nil {
+        metrics.Requests.WithLabelValues(method, "error").Inc()
+        h.logger.Error("Failed to list webhook deliveries",
+            zap.Error(err),
+            zap.String("webhook_id", req.WebhookId),
+        )
+        return nil, h.mapWebhookError(err)
+    }
+
+    metrics.Requests.WithLabelValues(method, "success").Inc()
+
+    result := make([]*models.WebhookDeliveryProto, len(deliveries))
+    for i, d := range deliveries {
+        result[i] = &models.WebhookDeliveryProto{
+            DeliveryId:   d.ID.String(),
+            WebhookId:    d.EndpointID.String(),
+            PortfolioId:  d.PortfolioID.String(),
+            Event:        string(d.Event),
+            Status:       deliveryStatuses[d.Status],
+            Attempts:     int32(d.Attempts),
+            ResponseCode: int32(d.ResponseCode),
+            LastError:    d.LastError,
+            CreatedAt:    timestamppb.New(d.CreatedAt),
+        }
+        if d.Status == models.DeliveryPending {
+            result[i].NextAttemptAt = timestamppb.New(d.NextAttemptAt)
+        }
+        if d.DeliveredAt != nil {
+            result[i].DeliveredAt = timestamppb.New(*d.DeliveredAt)
+        }
+    }
+
+    return &models.ListWebhookDeliveriesResponse{Deliveries: result, NextPageToken: nextToken}, nil
+}
+
+// mapWebhookError maps webhook errors to gRPC status errors
+func (h *PortfolioHandler) mapWebhookError(err error) error {
+    switch {
+    case errors.Is(err, services.ErrNotFound):
+        return statusError(codes.NotFound, "WEBHOOK_NOT_FOUND", "webhook not found")
+    case errors.Is(err, services.ErrFeatureDisabled):
+        return statusError(codes.Unimplemented, "FEATURE_DISABLED", "webhooks are not enabled")
+    }
+    return h.mapServiceError(err)
+}
+
+// convertToProtoWebhook converts a webhook endpoint to its protobuf
+// representation without its secret
+func convertToProtoWebhook(endpoint *models.WebhookEndpoint) *models.WebhookEndpointProto {
+    result := &models.WebhookEndpointProto{
+        WebhookId: endpoint.ID.String(),
+        UserId:    endpoint.UserID.String(),
+        Url:       endpoint.URL,
+        Events:    make([]string, len(endpoint.Events)),
+        Enabled:   endpoint.Enabled,
+        CreatedAt: timestamppb.New(endpoint.CreatedAt),
+    }
+    if endpoint.PortfolioID != uuid.Nil {
+        result.PortfolioId = endpoint.PortfolioID.String()
+    }
+    for i, event := range endpoint.Events {
+        result.Events[i] = string(event)
+    }
+    return result
+}
+
+// parseOptionalID parses an ID that may be left empty, returning uuid.Nil
+func parseOptionalID(id string) (uuid.UUID, error) {
+    if id == "" {
+        return uuid.Nil, nil
+    }
+    return uuid.Parse(id)
+}
@@ -0,0 +1,514 @@
+// Package importer parses transaction files exported by exchanges and by this
+// service into portfolio transactions. Columns are located by header name
+// through a Mapping, either supplied by the user or taken from a built-in
+// template.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+const (
+	// MaxFileSize bounds the size of an imported file in bytes
+	MaxFileSize = 16 << 20
+
+	// MaxRows bounds the number of data rows of an imported file
+	MaxRows = 50000
+
+	// MaxStreamFileSize and MaxStreamRows bound files of bulk imports, which
+	// are parsed and recorded in batches as they arrive
+	MaxStreamFileSize = 256 << 20
+	MaxStreamRows     = 1000000
+
+	// headerSearchRows is how many leading records may precede the header,
+	// as in exports starting with account details
+	headerSearchRows = 10
+)
+
+// Template identifies a built-in column mapping
+type Template string
+
+const (
+	// TemplateNative reads the CSV layout written by the transaction export
+	TemplateNative Template = "native"
+	// TemplateBinance reads the Binance spot trade history export
+	TemplateBinance Template = "binance"
+	// TemplateCoinbase reads the Coinbase transaction history report
+	TemplateCoinbase Template = "coinbase"
+)
+
+var (
+	// ErrUnknownTemplate is returned for templates without a built-in mapping
+	ErrUnknownTemplate = errors.New("unknown import template")
+
+	// ErrInvalidMapping is returned for mappings missing required columns
+	ErrInvalidMapping = errors.New("invalid column mapping")
+
+	// ErrInvalidFile is returned for files that cannot be read as CSV or have
+	// no header matching the mapping
+	ErrInvalidFile = errors.New("invalid import file")
+)
+
+// Mapping names the header of the column holding each transaction field.
+// Timestamp, Type, Symbol and Amount are required.
+type Mapping struct {
+	Timestamp string
+	Type      string
+	Symbol    string
+	Amount    string
+	Price     string
+	Fee       string
+	// Reference names a column of unique row IDs, such as the transaction ID
+	// of an export, making re-imports recognize rows reliably
+	Reference string
+	// TimeLayout is the Go reference layout of timestamps; when empty RFC 3339
+	// and common spreadsheet layouts are accepted
+	TimeLayout string
+	// TypeValues maps source type values, matched case-insensitively, to
+	// transaction types. Values mapped to "" mark rows that are skipped. When
+	// nil, source values are used as transaction types.
+	TypeValues map[string]string
+	// PairQuotes lists quote currencies stripped from trading pair symbols,
+	// such as USDT from BTCUSDT
+	PairQuotes []string
+}
+
+// Merge returns m with the non-empty fields of override applied
+func (m Mapping) Merge(override Mapping) Mapping {
+	for _, f := range []struct{ dst, src *string }{
+		{&m.Timestamp, &override.Timestamp},
+		{&m.Type, &override.Type},
+		{&m.Symbol, &override.Symbol},
+		{&m.Amount, &override.Amount},
+		{&m.Price, &override.Price},
+		{&m.Fee, &override.Fee},
+		{&m.Reference, &override.Reference},
+		{&m.TimeLayout, &override.TimeLayout},
+	} {
+		if *f.src != "" {
+			*f.dst = *f.src
+		}
+	}
+	if len(override.TypeValues) > 0 {
+		m.TypeValues = override.TypeValues
+	}
+	if len(override.PairQuotes) > 0 {
+		m.PairQuotes = override.PairQuotes
+	}
+	return m
+}
+
+// Validate checks the required columns are mapped
+func (m Mapping) Validate() error {
+	for name, column := range map[string]string{
+		"timestamp": m.Timestamp,
+		"type":      m.Type,
+		"symbol":    m.Symbol,
+		"amount":    m.Amount,
+	} {
+		if strings.TrimSpace(column) == "" {
+			return fmt.Errorf("%w: %s column is required", ErrInvalidMapping, name)
+		}
+	}
+	return nil
+}
+
+// stableQuotes are quote currencies valued at 1 USD in exchange exports
+var stableQuotes = []string{"USDT", "USDC", "FDUSD", "BUSD", "TUSD", "DAI", "USD"}
+
+var templates = map[Template]Mapping{
+	TemplateNative: {
+		Timestamp: "Date",
+		Type:      "Type",
+		Symbol:    "Symbol",
+		Amount:    "Amount",
+		Price:     "Price",
+		Fee:       "Fee",
+		Reference: "Transaction ID",
+	},
+	TemplateBinance: {
+		Timestamp:  "Date(UTC)",
+		Type:       "Side",
+		Symbol:     "Pair",
+		Amount:     "Executed",
+		Price:      "Price",
+		Fee:        "Fee",
+		TimeLayout: "2006-01-02 15:04:05",
+		TypeValues: map[string]string{"buy": "buy", "sell": "sell"},
+		PairQuotes: stableQuotes,
+	},
+	TemplateCoinbase: {
+		Timestamp: "Timestamp",
+		Type:      "Transaction Type",
+		Symbol:    "Asset",
+		Amount:    "Quantity Transacted",
+		Price:     "Spot Price at Transaction",
+		Fee:       "Fees and/or Spread",
+		TypeValues: map[string]string{
+			"buy":                 "buy",
+			"advanced trade buy":  "buy",
+			"sell":                "sell",
+			"advanced trade sell": "sell",
+			"receive":             "transfer_in",
+			"deposit":             "transfer_in",
+			"send":                "transfer_out",
+			"withdrawal":          "transfer_out",
+			"rewards income":      "reward",
+			"staking income":      "reward",
+			"learning reward":     "reward",
+			"inflation reward":    "reward",
+			// Conversions have no per-asset price in the report
+			"convert": "",
+		},
+	},
+}
+
+// TemplateMapping returns the column mapping of a built-in template
+func TemplateMapping(t Template) (Mapping, error) {
+	m, ok := templates[t]
+	if !ok {
+		return Mapping{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, t)
+	}
+	return m, nil
+}
+
+// Row is a parsed data row. Fee is in the price currency.
+type Row struct {
+	Line      int
+	Reference string
+	Timestamp time.Time
+	Type      string
+	Symbol    string
+	Amount    decimal.Decimal
+	Price     decimal.Decimal
+	Fee       decimal.Decimal
+}
+
+// RowError describes why a data row was rejected
+type RowError struct {
+	Line    int
+	Column  string
+	Message string
+}
+
+// Result holds the rows of a file that parsed and the errors of those that did not
+type Result struct {
+	Rows    []Row
+	Errors  []RowError
+	Skipped int
+}
+
+// ParseTransactions reads a CSV file according to a mapping. Row problems are
+// collected rather than returned so every problem of a file can be reported
+// at once; an error is only returned when the file as a whole is unreadable.
+func ParseTransactions(r io.Reader, m Mapping) (*Result, error) {
+	p, err := NewParser(r, m, MaxRows)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := p.Next(0)
+	if err == io.EOF {
+		return &Result{}, nil
+	}
+	return result, err
+}
+
+// Parser reads the data rows of a CSV file in batches, so large files can be
+// processed as they arrive
+type Parser struct {
+	cr      *csv.Reader
+	columns map[string]int
+	m       Mapping
+	maxRows int
+	rows    int
+}
+
+// NewParser validates the mapping and reads the file up to its header row.
+// Files with more than maxRows data rows are rejected once the limit is
+// passed.
+func NewParser(r io.Reader, m Mapping, maxRows int) (*Parser, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	columns, err := findHeader(cr, m)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Parser{cr: cr, columns: columns, m: m, maxRows: maxRows}, nil
+}
+
+// Next parses up to n further data rows, or all remaining rows when n is not
+// positive. It returns io.EOF once no rows are left.
+func (p *Parser) Next(n int) (*Result, error) {
+	result := &Result{}
+	for parsed := 0; n <= 0 || parsed < n; parsed++ {
+		record, err := p.cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		line, _ := p.cr.FieldPos(0)
+		if blank(record) {
+			parsed--
+			continue
+		}
+		if p.rows >= p.maxRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidFile, p.maxRows)
+		}
+		p.rows++
+
+		row, skip, rowErr := parseRow(record, p.columns, p.m)
+		switch {
+		case rowErr != nil:
+			rowErr.Line = line
+			result.Errors = append(result.Errors, *rowErr)
+		case skip:
+			result.Skipped++
+		default:
+			row.Line = line
+			result.Rows = append(result.Rows, row)
+		}
+	}
+
+	if len(result.Rows)+len(result.Errors)+result.Skipped == 0 {
+		return nil, io.EOF
+	}
+	return result, nil
+}
+
+// findHeader reads records until one contains every mapped column and returns
+// the column index of each mapped header
+func findHeader(cr *csv.Reader, m Mapping) (map[string]int, error) {
+	for i := 0; i < headerSearchRows; i++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+
+		index := make(map[string]int, len(record))
+		for i, name := range record {
+			name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+			if _, ok := index[name]; !ok {
+				index[name] = i
+			}
+		}
+
+		columns := make(map[string]int)
+		found := true
+		for _, header := range []string{m.Timestamp, m.Type, m.Symbol, m.Amount, m.Price, m.Fee, m.Reference} {
+			if header == "" {
+				continue
+			}
+			i, ok := index[strings.ToLower(strings.TrimSpace(header))]
+			if !ok {
+				found = false
+				break
+			}
+			columns[header] = i
+		}
+		if found {
+			return columns, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no header row with the mapped columns", ErrInvalidFile)
+}
+
+// parseRow converts a record into a row, reporting whether the row is skipped
+// by the type mapping
+func parseRow(record []string, columns map[string]int, m Mapping) (Row, bool, *RowError) {
+	cell := func(header string) string {
+		if header == "" {
+			return ""
+		}
+		i := columns[header]
+		if i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	fail := func(header, format string, args ...interface{}) (Row, bool, *RowError) {
+		return Row{}, false, &RowError{Column: header, Message: fmt.Sprintf(format, args...)}
+	}
+
+	var row Row
+	row.Reference = cell(m.Reference)
+
+	source := cell(m.Type)
+	row.Type = strings.ToLower(source)
+	if m.TypeValues != nil {
+		mapped, ok := lookupType(m.TypeValues, source)
+		if !ok {
+			return fail(m.Type, "unsupported type %q", source)
+		}
+		if mapped == "" {
+			return Row{}, true, nil
+		}
+		row.Type = mapped
+	}
+
+	row.Symbol = pairBase(strings.ToUpper(cell(m.Symbol)), m.PairQuotes)
+	if row.Symbol == "" {
+		return fail(m.Symbol, "symbol is required")
+	}
+
+	timestamp, err := parseTime(cell(m.Timestamp), m.TimeLayout)
+	if err != nil {
+		return fail(m.Timestamp, "invalid timestamp %q", cell(m.Timestamp))
+	}
+	row.Timestamp = timestamp
+
+	amount, _, err := parseQuantity(cell(m.Amount))
+	if err != nil {
+		return fail(m.Amount, "invalid amount %q", cell(m.Amount))
+	}
+	// Some exports sign outflows; the type carries the direction
+	row.Amount = amount.Abs()
+	if !row.Amount.IsPositive() {
+		return fail(m.Amount, "amount must be positive")
+	}
+
+	if raw := cell(m.Price); raw != "" {
+		price, _, err := parseQuantity(raw)
+		if err != nil || price.IsNegative() {
+			return fail(m.Price, "invalid price %q", raw)
+		}
+		row.Price = price
+	}
+
+	if raw := cell(m.Fee); raw != "" {
+		fee, unit, err := parseQuantity(raw)
+		if err != nil || fee.IsNegative() {
+			return fail(m.Fee, "invalid fee %q", raw)
+		}
+		// Fees charged in the traded asset are converted at the row price;
+		// fees in other assets have no price in the file
+		switch {
+		case unit == "" || strings.EqualFold(unit, "USD") || containsFold(m.PairQuotes, unit):
+		case strings.EqualFold(unit, row.Symbol):
+			fee = fee.Mul(row.Price)
+		default:
+			return fail(m.Fee, "fee in %s cannot be converted to the price currency", unit)
+		}
+		row.Fee = fee
+	}
+
+	return row, false, nil
+}
+
+// lookupType finds the transaction type of a source value case-insensitively
+func lookupType(values map[string]string, source string) (string, bool) {
+	for from, to := range values {
+		if strings.EqualFold(from, source) {
+			return to, true
+		}
+	}
+	return "", false
+}
+
+// pairBase strips a quote currency and separator from a trading pair symbol
+func pairBase(symbol string, quotes []string) string {
+	for _, sep := range []string{"/", "-", "_"} {
+		if i := strings.Index(symbol, sep); i > 0 && len(quotes) > 0 {
+			return symbol[:i]
+		}
+	}
+	for _, quote := range quotes {
+		quote = strings.ToUpper(quote)
+		if strings.HasSuffix(symbol, quote) && len(symbol) > len(quote) {
+			return strings.TrimSuffix(symbol, quote)
+		}
+	}
+	return symbol
+}
+
+// timeLayouts are tried in order when a mapping has no layout
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04 MST",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"01/02/2006 15:04:05",
+	"01/02/2006",
+}
+
+// parseTime parses a timestamp, treating values without a zone as UTC
+func parseTime(value, layout string) (time.Time, error) {
+	if layout != "" {
+		t, err := time.Parse(layout, value)
+		return t.UTC(), err
+	}
+	for _, l := range timeLayouts {
+		if t, err := time.Parse(l, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", value)
+}
+
+// parseQuantity parses a number that may carry a currency symbol, thousands
+// separators or a trailing unit, as in "$1,234.50" or "0.015BTC", and returns
+// the unit
+func parseQuantity(value string) (decimal.Decimal, string, error) {
+	value = strings.TrimLeft(value, "$€£ ")
+	value = strings.ReplaceAll(value, ",", "")
+
+	end := strings.IndexFunc(value, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != '-' && r != '+' && r != 'e' && r != 'E'
+	})
+	// An exponent marker followed by letters belongs to the unit, as in 1ETH
+	for end > 0 && (value[end-1] == 'e' || value[end-1] == 'E') {
+		end--
+	}
+	if end < 0 {
+		end = len(value)
+	}
+
+	number, unit := value[:end], strings.TrimSpace(value[end:])
+	d, err := decimal.NewFromString(number)
+	if err != nil {
+		return decimal.Zero, "", err
+	}
+	return d, unit, nil
+}
+
+// containsFold reports whether values contains s case-insensitively
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// blank reports whether every cell of a record is empty
+func blank(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
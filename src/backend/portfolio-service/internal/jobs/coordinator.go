@@ -0,0 +1,184 @@
+// Package jobs coordinates scheduled background jobs across service
+// replicas. Each job is guarded by a lock of its own, so exactly one replica
+// runs it at a time; the others wait to take over when the leader stops or
+// loses its lock.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"go.uber.org/zap"                                // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/metrics"
+)
+
+// releaseTimeout bounds releasing a lock after its job stopped
+const releaseTimeout = time.Second * 5
+
+// Leadership change events reported in metrics
+const (
+	eventAcquired = "acquired"
+	eventLost     = "lost"
+	eventReleased = "released"
+)
+
+var (
+	leader = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_job_leader",
+			Help: "Whether this instance currently runs the job (1) or not (0)",
+		},
+		[]string{"job"},
+	)
+
+	leadershipChanges = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "portfolio_job_leadership_changes_total",
+			Help: "Total number of job leadership changes of this instance by job and event",
+		},
+		[]string{"job", "event"},
+	)
+)
+
+func init() {
+	metrics.MustRegister(leader, leadershipChanges)
+}
+
+// Lock is a held job lock
+type Lock interface {
+	// Check returns an error once the lock is no longer held
+	Check(ctx context.Context) error
+	// Release gives up the lock
+	Release(ctx context.Context) error
+}
+
+// Locker takes job locks
+type Locker interface {
+	// TryLock takes the lock with key without waiting, returning a nil lock
+	// when another instance holds it
+	TryLock(ctx context.Context, key int64) (Lock, error)
+}
+
+// Job is a background job that runs until ctx is cancelled
+type Job func(ctx context.Context)
+
+// Coordinator runs each job only while this instance holds its lock
+type Coordinator struct {
+	locker Locker
+	cfg    config.JobsConfig
+	logger *zap.Logger
+}
+
+// NewCoordinator creates a job coordinator. Without leader election jobs run
+// unconditionally and locker may be nil.
+func NewCoordinator(locker Locker, cfg config.JobsConfig, logger *zap.Logger) (*Coordinator, error) {
+	if (cfg.LeaderElection && locker == nil) || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	return &Coordinator{
+		locker: locker,
+		cfg:    cfg,
+		logger: logger.With(zap.String("component", "job_coordinator")),
+	}, nil
+}
+
+// LockKey derives the advisory lock key of a job from its name
+func LockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("portfolio-service/jobs/" + name))
+	return int64(h.Sum64())
+}
+
+// Run runs job whenever this instance leads name, until ctx is cancelled.
+// The job's context is cancelled when the lock is lost; it is started again
+// once the lock is retaken.
+func (c *Coordinator) Run(ctx context.Context, name string, job Job) {
+	if !c.cfg.LeaderElection {
+		job(ctx)
+		return
+	}
+
+	key := LockKey(name)
+	leader.WithLabelValues(name).Set(0)
+	for {
+		lock, err := c.locker.TryLock(ctx, key)
+		if err != nil && ctx.Err() == nil {
+			c.logger.Warn("Failed to take job lock",
+				zap.Error(err),
+				zap.String("job", name),
+			)
+		}
+		if lock != nil {
+			c.lead(ctx, name, lock, job)
+		}
+
+		timer := time.NewTimer(c.cfg.RetryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// lead runs job while lock is held and releases the lock once the job
+// stopped
+func (c *Coordinator) lead(ctx context.Context, name string, lock Lock, job Job) {
+	leader.WithLabelValues(name).Set(1)
+	leadershipChanges.WithLabelValues(name, eventAcquired).Inc()
+	c.logger.Info("Acquired job leadership", zap.String("job", name))
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		job(jobCtx)
+	}()
+
+	ticker := time.NewTicker(c.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	event := eventReleased
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		case <-ctx.Done():
+			running = false
+		case <-ticker.C:
+			if err := lock.Check(ctx); err != nil && ctx.Err() == nil {
+				event = eventLost
+				c.logger.Warn("Lost job leadership",
+					zap.Error(err),
+					zap.String("job", name),
+				)
+				running = false
+			}
+		}
+	}
+	cancel()
+	<-done
+
+	releaseCtx, cancelRelease := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancelRelease()
+	if err := lock.Release(releaseCtx); err != nil {
+		c.logger.Warn("Failed to release job lock",
+			zap.Error(err),
+			zap.String("job", name),
+		)
+	}
+
+	leader.WithLabelValues(name).Set(0)
+	leadershipChanges.WithLabelValues(name, event).Inc()
+	if event == eventReleased {
+		c.logger.Info("Released job leadership", zap.String("job", name))
+	}
+}
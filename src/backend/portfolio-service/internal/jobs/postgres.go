@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"context"
+
+	"bookman/portfolio-service/internal/repository"
+)
+
+// postgresLocker takes job locks as Postgres session-level advisory locks
+type postgresLocker struct {
+	repo *repository.PostgresRepository
+}
+
+// NewPostgresLocker creates a locker whose locks are Postgres advisory locks.
+// A lock is lost when its database connection ends.
+func NewPostgresLocker(repo *repository.PostgresRepository) Locker {
+	return postgresLocker{repo: repo}
+}
+
+func (l postgresLocker) TryLock(ctx context.Context, key int64) (Lock, error) {
+	lock, err := l.repo.TryAdvisoryLock(ctx, key)
+	if lock == nil {
+		// A nil *AdvisoryLock must not become a non-nil Lock
+		return nil, err
+	}
+	return lock, nil
+}
@@ -0,0 +1,140 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"go.uber.org/zap"                                // v1.24.0
+
+	"bookman/portfolio-service/internal/metrics"
+)
+
+// ErrUnknownJob is returned when triggering a job that is not registered
+var ErrUnknownJob = errors.New("unknown job")
+
+// ErrJobRunning is returned when triggering a job that is already running
+var ErrJobRunning = errors.New("job is already running")
+
+var triggeredRuns = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "portfolio_job_triggered_runs_total",
+		Help: "Total number of on-demand job runs by job and status",
+	},
+	[]string{"job", "status"},
+)
+
+func init() {
+	metrics.MustRegister(triggeredRuns)
+}
+
+// triggerable is a registered job that can be run on demand
+type triggerable struct {
+	run       func(ctx context.Context) error
+	exclusive bool
+	running   atomic.Bool
+}
+
+// Triggers runs registered jobs on demand, outside their schedules, for
+// operators catching up after an outage or verifying a fix. Runs are
+// cancelled with the context the triggers were created with.
+type Triggers struct {
+	ctx    context.Context
+	locker Locker
+	logger *zap.Logger
+
+	mutex sync.RWMutex
+	jobs  map[string]*triggerable
+}
+
+// NewTriggers creates an on-demand job runner. Exclusive jobs take their
+// lock from locker for the run and are refused while another replica holds
+// it; without a locker they run on the triggering replica regardless.
+func NewTriggers(ctx context.Context, locker Locker, logger *zap.Logger) (*Triggers, error) {
+	if ctx == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	return &Triggers{
+		ctx:    ctx,
+		locker: locker,
+		logger: logger.With(zap.String("component", "job_triggers")),
+		jobs:   make(map[string]*triggerable),
+	}, nil
+}
+
+// Register makes a single run of a job available on demand
+func (t *Triggers) Register(name string, exclusive bool, run func(ctx context.Context) error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.jobs[name] = &triggerable{run: run, exclusive: exclusive}
+}
+
+// Names returns the names of the registered jobs in order
+func (t *Triggers) Names() []string {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	names := make([]string, 0, len(t.jobs))
+	for name := range t.jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Trigger starts a run of the named job in the background. It returns once
+// the run started; the run's outcome is logged and counted.
+func (t *Triggers) Trigger(ctx context.Context, name string) error {
+	t.mutex.RLock()
+	job, ok := t.jobs[name]
+	t.mutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+
+	if !job.running.CompareAndSwap(false, true) {
+		return fmt.Errorf("%w: %s", ErrJobRunning, name)
+	}
+
+	var lock Lock
+	if job.exclusive && t.locker != nil {
+		var err error
+		lock, err = t.locker.TryLock(ctx, LockKey(name))
+		if err != nil {
+			job.running.Store(false)
+			return fmt.Errorf("failed to take lock of job %s: %w", name, err)
+		}
+		if lock == nil {
+			job.running.Store(false)
+			return fmt.Errorf("%w on another replica: %s", ErrJobRunning, name)
+		}
+	}
+
+	go func() {
+		defer job.running.Store(false)
+		if lock != nil {
+			defer func() {
+				releaseCtx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+				defer cancel()
+				if err := lock.Release(releaseCtx); err != nil {
+					t.logger.Warn("Failed to release job lock", zap.Error(err), zap.String("job", name))
+				}
+			}()
+		}
+
+		t.logger.Info("Running triggered job", zap.String("job", name))
+		if err := job.run(t.ctx); err != nil {
+			triggeredRuns.WithLabelValues(name, "failed").Inc()
+			t.logger.Error("Triggered job failed", zap.Error(err), zap.String("job", name))
+			return
+		}
+		triggeredRuns.WithLabelValues(name, "succeeded").Inc()
+		t.logger.Info("Triggered job completed", zap.String("job", name))
+	}()
+	return nil
+}
@@ -0,0 +1,133 @@
+// Package ledger keeps the portfolio read model in step with the
+// event-sourced portfolio ledger. Appends are normally projected in the same
+// transaction; the projector catches up portfolios whose projection is
+// behind, for example after checkpoints were reset to replay their ledgers.
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"                         // v1.3.0
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"go.uber.org/zap"                                // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/metrics"
+	"bookman/portfolio-service/internal/repository"
+)
+
+var (
+	projectedEvents = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "portfolio_ledger_projected_events_total",
+			Help: "Total number of ledger events applied to the read model by the projector",
+		},
+	)
+	projectionFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "portfolio_ledger_projection_failures_total",
+			Help: "Total number of portfolios the projector failed to catch up",
+		},
+	)
+)
+
+func init() {
+	metrics.MustRegister(projectedEvents, projectionFailures)
+}
+
+// Projector applies unprojected ledger events on a fixed interval
+type Projector struct {
+	repo   *repository.PostgresRepository
+	cfg    config.LedgerConfig
+	logger *zap.Logger
+}
+
+// NewProjector creates a new ledger projector
+func NewProjector(repo *repository.PostgresRepository, cfg config.LedgerConfig, logger *zap.Logger) (*Projector, error) {
+	if repo == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	return &Projector{
+		repo:   repo,
+		cfg:    cfg,
+		logger: logger.With(zap.String("component", "ledger_projector")),
+	}, nil
+}
+
+// Run catches up pending projections at each interval until ctx is cancelled
+func (p *Projector) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.ProjectionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := p.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			p.logger.Error("Ledger projection pass failed", zap.Error(err))
+		}
+	}
+}
+
+// RunOnce projects every pending portfolio and returns the number of events
+// applied. A portfolio that fails is logged and retried on the next pass.
+func (p *Projector) RunOnce(ctx context.Context) (int, error) {
+	var applied int
+	failed := make(map[uuid.UUID]bool)
+
+	for {
+		ids, err := p.repo.PendingLedgerProjections(ctx, p.cfg.ProjectionBatchSize)
+		if err != nil {
+			return applied, fmt.Errorf("failed to list pending projections: %w", err)
+		}
+
+		progressed := false
+		for _, id := range ids {
+			if failed[id] {
+				continue
+			}
+			n, err := p.Project(ctx, id)
+			applied += n
+			if err != nil {
+				if ctx.Err() != nil {
+					return applied, ctx.Err()
+				}
+				failed[id] = true
+				projectionFailures.Inc()
+				p.logger.Error("Failed to project portfolio ledger",
+					zap.Error(err),
+					zap.String("portfolio_id", id.String()),
+				)
+				continue
+			}
+			progressed = true
+		}
+
+		if !progressed || len(ids) < p.cfg.ProjectionBatchSize {
+			return applied, nil
+		}
+	}
+}
+
+// Project applies all unprojected events of one portfolio
+func (p *Projector) Project(ctx context.Context, portfolioID uuid.UUID) (int, error) {
+	var applied int
+	for {
+		n, err := p.repo.ProjectLedger(ctx, portfolioID, p.cfg.ProjectionBatchSize)
+		applied += n
+		projectedEvents.Add(float64(n))
+		if err != nil {
+			return applied, err
+		}
+		if n < p.cfg.ProjectionBatchSize {
+			return applied, nil
+		}
+	}
+}
@@ -0,0 +1,334 @@
+// Package loadshed rejects calls early when the service is saturated, so the
+// calls it does accept keep their latency. Each method has a concurrency
+// limit that adapts to its latency: it grows while recent calls are about as
+// fast as calls have been over the long term, and shrinks once they slow down,
+// which is the first sign of requests queueing for the database or CPU. Calls
+// over the limit wait briefly for a slot and are rejected with
+// ResourceExhausted when none frees up.
+package loadshed
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"math"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"        // v1.50.0
+	"google.golang.org/grpc/codes"  // v1.50.0
+	"google.golang.org/grpc/status" // v1.50.0
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/metrics"
+)
+
+const (
+	// shortWindow and longWindow are the number of samples averaged by the
+	// recent and long-term latency
+	shortWindow = 10
+	longWindow  = 600
+
+	// smoothing weighs each new limit estimate against the current limit
+	smoothing = 0.2
+
+	// errorDomain identifies the service in the ErrorInfo of rejections
+	errorDomain = "portfolio.bookman.ai"
+
+	// retryDelay is the delay rejected clients are advised to wait
+	retryDelay = time.Second
+)
+
+var (
+	// ErrQueueFull is returned when a method's queue has no room for the call
+	ErrQueueFull = errors.New("method queue is full")
+
+	// ErrQueueTimeout is returned when no slot freed up within the queue timeout
+	ErrQueueTimeout = errors.New("timed out waiting for a slot")
+)
+
+var (
+	shed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "portfolio_load_shed_total",
+			Help: "Total number of calls rejected because the service was saturated",
+		},
+		[]string{"method", "reason"},
+	)
+
+	limits = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_concurrency_limit",
+			Help: "Current adaptive concurrency limit of each method",
+		},
+		[]string{"method"},
+	)
+
+	inflight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_inflight_requests",
+			Help: "Number of calls of each method currently running",
+		},
+		[]string{"method"},
+	)
+)
+
+func init() {
+	metrics.MustRegister(shed, limits, inflight)
+}
+
+// Shedder limits the concurrent calls of each method
+type Shedder struct {
+	cfg    config.LoadSheddingConfig
+	exempt map[string]bool
+
+	mutex    sync.Mutex
+	limiters map[string]*limiter
+}
+
+// NewShedder creates a shedder with the configured limits
+func NewShedder(cfg config.LoadSheddingConfig) (*Shedder, error) {
+	if cfg.MinLimit <= 0 || cfg.MaxLimit < cfg.MinLimit || cfg.InitialLimit < cfg.MinLimit || cfg.InitialLimit > cfg.MaxLimit {
+		return nil, errors.New("invalid concurrency limits")
+	}
+	if cfg.Tolerance < 1 {
+		return nil, errors.New("tolerance must be at least 1")
+	}
+
+	exempt := make(map[string]bool, len(cfg.ExemptMethods))
+	for _, method := range cfg.ExemptMethods {
+		exempt[method] = true
+	}
+
+	return &Shedder{cfg: cfg, exempt: exempt, limiters: make(map[string]*limiter)}, nil
+}
+
+// UnaryServerInterceptor admits unary calls within their method's limit
+func (s *Shedder) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if s.exempt[path.Base(info.FullMethod)] {
+			return handler(ctx, req)
+		}
+
+		release, err := s.Acquire(ctx, info.FullMethod)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, status.FromContextError(ctx.Err()).Err()
+			}
+			return nil, overloaded()
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		release(time.Since(start), status.Code(err) != codes.Canceled)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor admits streams within their method's limit. A
+// stream holds its slot until it ends, but its duration does not adapt the
+// limit: it reflects how long the client keeps the stream open rather than
+// how loaded the service is.
+func (s *Shedder) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if s.exempt[path.Base(info.FullMethod)] {
+			return handler(srv, ss)
+		}
+
+		ctx := ss.Context()
+		release, err := s.Acquire(ctx, info.FullMethod)
+		if err != nil {
+			if ctx.Err() != nil {
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			return overloaded()
+		}
+
+		start := time.Now()
+		err = handler(srv, ss)
+		release(time.Since(start), false)
+		return err
+	}
+}
+
+// Acquire waits for a slot of a method, returning a function that releases it
+// with the call's latency. Latency is only used to adapt the limit when sample
+// is set. It fails with ErrQueueFull or ErrQueueTimeout when the method is
+// saturated, or with the context's error.
+func (s *Shedder) Acquire(ctx context.Context, fullMethod string) (func(latency time.Duration, sample bool), error) {
+	l := s.limiter(fullMethod)
+	if err := l.acquire(ctx, s.cfg.QueueSize, s.cfg.QueueTimeout); err != nil {
+		switch {
+		case errors.Is(err, ErrQueueFull):
+			shed.WithLabelValues(fullMethod, "queue_full").Inc()
+		case errors.Is(err, ErrQueueTimeout):
+			shed.WithLabelValues(fullMethod, "queue_timeout").Inc()
+		}
+		return nil, err
+	}
+	inflight.WithLabelValues(fullMethod).Inc()
+
+	var once sync.Once
+	return func(latency time.Duration, sample bool) {
+		once.Do(func() {
+			inflight.WithLabelValues(fullMethod).Dec()
+			limit := l.release(latency, sample)
+			limits.WithLabelValues(fullMethod).Set(float64(limit))
+		})
+	}, nil
+}
+
+// Limit returns the current concurrency limit of a method
+func (s *Shedder) Limit(fullMethod string) int {
+	l := s.limiter(fullMethod)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return int(l.limit)
+}
+
+// limiter returns the limiter of a method, creating it on first use
+func (s *Shedder) limiter(fullMethod string) *limiter {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	l, ok := s.limiters[fullMethod]
+	if !ok {
+		l = &limiter{
+			limit:     float64(s.cfg.InitialLimit),
+			min:       float64(s.cfg.MinLimit),
+			max:       float64(s.cfg.MaxLimit),
+			tolerance: s.cfg.Tolerance,
+			queue:     list.New(),
+		}
+		s.limiters[fullMethod] = l
+		limits.WithLabelValues(fullMethod).Set(l.limit)
+	}
+	return l
+}
+
+// limiter is the adaptive concurrency limit of one method
+type limiter struct {
+	mutex     sync.Mutex
+	limit     float64
+	min       float64
+	max       float64
+	tolerance float64
+	inflight  int
+	queue     *list.List // of chan struct{}, granted a slot in order
+
+	// shortLatency and longLatency are moving averages of call latency in
+	// seconds over about shortWindow and longWindow samples
+	shortLatency float64
+	longLatency  float64
+}
+
+// acquire takes a slot, waiting in the queue while the limit is reached
+func (l *limiter) acquire(ctx context.Context, queueSize int, queueTimeout time.Duration) error {
+	l.mutex.Lock()
+	if l.inflight < int(l.limit) && l.queue.Len() == 0 {
+		l.inflight++
+		l.mutex.Unlock()
+		return nil
+	}
+	if l.queue.Len() >= queueSize {
+		l.mutex.Unlock()
+		return ErrQueueFull
+	}
+	granted := make(chan struct{}, 1)
+	waiter := l.queue.PushBack(granted)
+	l.mutex.Unlock()
+
+	timer := time.NewTimer(queueTimeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-granted:
+		return nil
+	case <-timer.C:
+		err = ErrQueueTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	select {
+	case <-granted:
+		// The slot was granted while giving up; pass it on
+		l.inflight--
+		l.grant()
+	default:
+		l.queue.Remove(waiter)
+	}
+	return err
+}
+
+// release frees a slot, adapting the limit to the call's latency, and returns
+// the new limit
+func (l *limiter) release(latency time.Duration, sample bool) int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if sample {
+		l.adapt(latency.Seconds())
+	}
+	l.inflight--
+	l.grant()
+	return int(l.limit)
+}
+
+// grant hands free slots to queued calls in order. The caller must hold the
+// mutex.
+func (l *limiter) grant() {
+	for l.inflight < int(l.limit) && l.queue.Len() > 0 {
+		granted := l.queue.Remove(l.queue.Front()).(chan struct{})
+		l.inflight++
+		granted <- struct{}{}
+	}
+}
+
+// adapt moves the limit by the gradient of long-term to recent latency. The
+// caller must hold the mutex.
+func (l *limiter) adapt(latency float64) {
+	if l.longLatency == 0 {
+		l.shortLatency, l.longLatency = latency, latency
+		return
+	}
+	l.shortLatency += (latency - l.shortLatency) * 2 / (shortWindow + 1)
+	l.longLatency += (latency - l.longLatency) * 2 / (longWindow + 1)
+
+	// Recover the long-term latency quickly once a slowdown has passed
+	if l.longLatency > l.shortLatency*2 {
+		l.longLatency *= 0.95
+	}
+
+	// Calls are not limited by concurrency, so latency says nothing about it
+	if float64(l.inflight) < l.limit/2 {
+		return
+	}
+
+	gradient := 1.0
+	if l.shortLatency > 0 {
+		gradient = math.Max(0.5, math.Min(1, l.tolerance*l.longLatency/l.shortLatency))
+	}
+	estimate := l.limit*gradient + math.Sqrt(l.limit)
+	l.limit = math.Max(l.min, math.Min(l.max, l.limit*(1-smoothing)+estimate*smoothing))
+}
+
+// overloaded returns the rejection of a call the service is too busy for
+func overloaded() error {
+	st := status.New(codes.ResourceExhausted, "service is overloaded, retry later")
+	detailed, err := st.WithDetails(
+		&errdetails.ErrorInfo{Reason: "OVERLOADED", Domain: errorDomain},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(retryDelay)},
+	)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -0,0 +1,174 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// reloadInterval is how long the fetch times of stored prices are reused
+// before they are read again
+const reloadInterval = time.Second * 15
+
+// Staleness describes how fresh the prices used for a valuation are
+type Staleness struct {
+	// AsOf is when the oldest used price was last returned by a provider,
+	// or zero when no used price has a known fetch time
+	AsOf time.Time
+	// Stale is set once AsOf is older than the staleness threshold
+	Stale bool
+	// Dropped lists the symbols whose price was older than the maximum
+	// staleness and left out, in order
+	Dropped []string
+	// Prices holds the age of each used price with a known fetch time
+	Prices map[string]PriceAge
+}
+
+// PriceAge is when a price was last returned by a provider, whether that is
+// too long ago, and which provider it was when known
+type PriceAge struct {
+	AsOf   time.Time
+	Stale  bool
+	Source string
+}
+
+// FetchLister lists when each stored price was last returned by a provider
+// and which provider returned it
+type FetchLister interface {
+	ListPriceFetches(ctx context.Context) (map[string]time.Time, map[string]string, error)
+}
+
+// Freshness keeps valuations serving the stored prices while market data
+// providers are unavailable, reporting how stale they are. Prices older than
+// the maximum staleness are too old to value assets with and are left out.
+type Freshness struct {
+	repo         FetchLister
+	staleAfter   time.Duration
+	maxStaleness time.Duration
+
+	mutex     sync.Mutex
+	fetchedAt map[string]time.Time
+	sources   map[string]string
+	loadedAt  time.Time
+}
+
+// NewFreshness creates a tracker of stored price freshness. Prices are stale
+// after staleAfter and left out after maxStaleness; a zero maxStaleness keeps
+// prices of any age.
+func NewFreshness(repo FetchLister, staleAfter, maxStaleness time.Duration) (*Freshness, error) {
+	if repo == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+	if staleAfter <= 0 || (maxStaleness != 0 && maxStaleness < staleAfter) {
+		return nil, errors.New("invalid staleness thresholds")
+	}
+	return &Freshness{
+		repo:         repo,
+		staleAfter:   staleAfter,
+		maxStaleness: maxStaleness,
+		fetchedAt:    make(map[string]time.Time),
+		sources:      make(map[string]string),
+	}, nil
+}
+
+// Apply removes the prices older than the maximum staleness from prices and
+// returns the staleness of the rest, attributing each price to the provider
+// that returned it. When the fetch times cannot be read, the last ones read
+// are used along with the error.
+func (f *Freshness) Apply(ctx context.Context, prices map[string]decimal.Decimal, now time.Time) (Staleness, error) {
+	fetchedAt, sources, err := f.load(ctx, now)
+	staleness := Assess(prices, fetchedAt, now, f.staleAfter, f.maxStaleness)
+	for _, symbol := range staleness.Dropped {
+		delete(prices, symbol)
+	}
+	for symbol, age := range staleness.Prices {
+		age.Source = sources[symbol]
+		staleness.Prices[symbol] = age
+	}
+	return staleness, err
+}
+
+// Record notes prices just returned by a provider, so this replica sees them
+// as fresh before the fetch times are read again. sources maps each symbol to
+// the provider that returned it, or to an empty name when unknown.
+func (f *Freshness) Record(sources map[string]string, at time.Time) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	// Copy on write, as readers hold the current maps without the mutex
+	fetchedAt := make(map[string]time.Time, len(f.fetchedAt)+len(sources))
+	for symbol, t := range f.fetchedAt {
+		fetchedAt[symbol] = t
+	}
+	origins := make(map[string]string, len(f.sources)+len(sources))
+	for symbol, source := range f.sources {
+		origins[symbol] = source
+	}
+	for symbol, source := range sources {
+		if !at.After(fetchedAt[symbol]) {
+			continue
+		}
+		fetchedAt[symbol] = at
+		if source != "" {
+			origins[symbol] = source
+		} else {
+			delete(origins, symbol)
+		}
+	}
+	f.fetchedAt = fetchedAt
+	f.sources = origins
+}
+
+// load returns the fetch times and sources of stored prices, reading them
+// again once they are older than the reload interval. The returned maps must
+// not be modified.
+func (f *Freshness) load(ctx context.Context, now time.Time) (map[string]time.Time, map[string]string, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if now.Sub(f.loadedAt) < reloadInterval {
+		return f.fetchedAt, f.sources, nil
+	}
+
+	// Failed reads are retried after the interval too, rather than by every
+	// valuation while the database is unavailable
+	f.loadedAt = now
+	fetchedAt, sources, err := f.repo.ListPriceFetches(ctx)
+	if err != nil {
+		return f.fetchedAt, f.sources, fmt.Errorf("failed to load price fetches: %w", err)
+	}
+	f.fetchedAt = fetchedAt
+	f.sources = sources
+	return fetchedAt, sources, nil
+}
+
+// Assess returns the staleness of prices given when each was last returned by
+// a provider. Prices older than maxStaleness are reported as dropped, and a
+// zero maxStaleness keeps prices of any age. Prices without a known fetch time
+// are taken as fresh.
+func Assess(prices map[string]decimal.Decimal, fetchedAt map[string]time.Time, now time.Time, staleAfter, maxStaleness time.Duration) Staleness {
+	staleness := Staleness{Prices: make(map[string]PriceAge, len(prices))}
+	for symbol := range prices {
+		at, ok := fetchedAt[symbol]
+		if !ok {
+			continue
+		}
+		if maxStaleness > 0 && now.Sub(at) > maxStaleness {
+			staleness.Dropped = append(staleness.Dropped, symbol)
+			continue
+		}
+		staleness.Prices[symbol] = PriceAge{AsOf: at, Stale: now.Sub(at) > staleAfter}
+		if staleness.AsOf.IsZero() || at.Before(staleness.AsOf) {
+			staleness.AsOf = at
+		}
+	}
+	sort.Strings(staleness.Dropped)
+
+	staleness.Stale = !staleness.AsOf.IsZero() && now.Sub(staleness.AsOf) > staleAfter
+	return staleness
+}
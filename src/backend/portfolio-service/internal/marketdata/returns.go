@@ -0,0 +1,119 @@
+// Package marketdata provides access to historical market prices and the
+// return series derived from them for analytics such as risk and correlation.
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"bookman/portfolio-service/internal/models"
+	"bookman/portfolio-service/internal/repository"
+)
+
+// ReturnSeries holds daily simple returns of several symbols aligned on the
+// same dates, so Returns[symbol][i] is the return ending on Dates[i]
+type ReturnSeries struct {
+	Dates   []time.Time
+	Returns map[string][]float64
+}
+
+// Len returns the number of aligned observations in the series
+func (s *ReturnSeries) Len() int {
+	return len(s.Dates)
+}
+
+// Reader loads historical prices from the market data tables
+type Reader struct {
+	repo *repository.PostgresRepository
+}
+
+// NewReader creates a new market data reader
+func NewReader(repo *repository.PostgresRepository) (*Reader, error) {
+	if repo == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+	return &Reader{repo: repo}, nil
+}
+
+// DailyReturns returns the aligned daily returns of the symbols over [start, end).
+// Symbols without any price history are absent from the result.
+func (r *Reader) DailyReturns(ctx context.Context, symbols []string, start, end time.Time) (*ReturnSeries, error) {
+	// One extra day of closes is needed to compute the first return of the window
+	closes, err := r.repo.GetDailyCloses(ctx, symbols, start.AddDate(0, 0, -1), end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load daily closes: %w", err)
+	}
+	return AlignReturns(closes), nil
+}
+
+// AlignReturns computes daily simple returns from closing prices, keeping only
+// consecutive days on which every symbol has a close and the previous close is
+// positive. Missing days break the chain rather than producing multi-day returns.
+func AlignReturns(closes map[string][]models.PricePoint) *ReturnSeries {
+	series := &ReturnSeries{Returns: make(map[string][]float64, len(closes))}
+	if len(closes) == 0 {
+		return series
+	}
+
+	byDay := make(map[string]map[time.Time]float64, len(closes))
+	var days map[time.Time]bool
+	for symbol, points := range closes {
+		prices := make(map[time.Time]float64, len(points))
+		for _, p := range points {
+			prices[p.Timestamp.UTC().Truncate(24*time.Hour)] = p.Close.InexactFloat64()
+		}
+		byDay[symbol] = prices
+
+		// Intersect the trading days of all symbols
+		if days == nil {
+			days = make(map[time.Time]bool, len(prices))
+			for day := range prices {
+				days[day] = true
+			}
+			continue
+		}
+		for day := range days {
+			if _, ok := prices[day]; !ok {
+				delete(days, day)
+			}
+		}
+	}
+
+	ordered := make([]time.Time, 0, len(days))
+	for day := range days {
+		ordered = append(ordered, day)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Before(ordered[j]) })
+
+	for symbol := range closes {
+		series.Returns[symbol] = make([]float64, 0, len(ordered))
+	}
+
+	for i := 1; i < len(ordered); i++ {
+		prev, day := ordered[i-1], ordered[i]
+		if !day.Equal(prev.Add(24 * time.Hour)) {
+			continue
+		}
+
+		valid := true
+		for symbol := range closes {
+			if byDay[symbol][prev] <= 0 {
+				valid = false
+				break
+			}
+		}
+		if !valid {
+			continue
+		}
+
+		series.Dates = append(series.Dates, day)
+		for symbol := range closes {
+			series.Returns[symbol] = append(series.Returns[symbol], byDay[symbol][day]/byDay[symbol][prev]-1)
+		}
+	}
+
+	return series
+}
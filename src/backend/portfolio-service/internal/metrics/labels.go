@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.15.0
+	dto "github.com/prometheus/client_model/go"      // v0.3.0
+	"google.golang.org/protobuf/proto"               // v1.30.0
+)
+
+// labelledGatherer adds constant labels to every metric of a gatherer
+type labelledGatherer struct {
+	gatherer prometheus.Gatherer
+	labels   []*dto.LabelPair
+}
+
+// WithLabels returns a gatherer adding labels to every metric gathered by
+// gatherer, so deployment-wide labels such as the environment, region or
+// cluster reach collectors registered before configuration was loaded. A
+// metric's own label takes precedence over a deployment label of the same
+// name. Without labels gatherer is returned unchanged.
+func WithLabels(gatherer prometheus.Gatherer, labels map[string]string) prometheus.Gatherer {
+	if len(labels) == 0 {
+		return gatherer
+	}
+
+	pairs := make([]*dto.LabelPair, 0, len(labels))
+	for name, value := range labels {
+		pairs = append(pairs, &dto.LabelPair{Name: proto.String(name), Value: proto.String(value)})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].GetName() < pairs[j].GetName() })
+
+	return &labelledGatherer{gatherer: gatherer, labels: pairs}
+}
+
+// Gather gathers the metric families of the wrapped gatherer and labels
+// each of their metrics
+func (g *labelledGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.gatherer.Gather()
+	for _, family := range families {
+		for _, metric := range family.Metric {
+			metric.Label = g.addLabels(metric.Label)
+		}
+	}
+	return families, err
+}
+
+// addLabels returns own with the deployment labels it lacks, in name order
+func (g *labelledGatherer) addLabels(own []*dto.LabelPair) []*dto.LabelPair {
+	names := make(map[string]bool, len(own))
+	for _, pair := range own {
+		names[pair.GetName()] = true
+	}
+
+	labels := append(make([]*dto.LabelPair, 0, len(own)+len(g.labels)), own...)
+	for _, pair := range g.labels {
+		if !names[pair.GetName()] {
+			labels = append(labels, pair)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].GetName() < labels[j].GetName() })
+	return labels
+}
@@ -0,0 +1,155 @@
+// Package metrics holds the registry every exported metric is registered
+// with and defines the request metrics shared by the gRPC server and its
+// handlers. The dedicated registry replaces the global default one, so the
+// metrics endpoint serves exactly what the service registers; the Go runtime
+// and process collectors are added only when configured. The request latency
+// histogram takes its buckets from configuration and can additionally be
+// exposed as a Prometheus native histogram, so it is built by Register once
+// configuration is loaded. Feature packages keep defining their own metrics.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"            // v1.15.0
+	"github.com/prometheus/client_golang/prometheus/collectors" // v1.15.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/exemplars"
+)
+
+// Registry is the registry of every metric the service exports
+var Registry = prometheus.NewRegistry()
+
+// MustRegister registers collectors with Registry and panics on failure,
+// such as a metric name registered twice
+func MustRegister(cs ...prometheus.Collector) {
+	Registry.MustRegister(cs...)
+}
+
+// DefaultLatencyBuckets are the request latency buckets used until Register
+// applies the configured ones
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// States of the configuration reported by ConfigInfo
+const (
+	ConfigActive = "active"
+	ConfigLatest = "latest"
+)
+
+// nativeMinResetDuration is the minimum time between resets of a native
+// histogram that reached its maximum bucket count
+const nativeMinResetDuration = time.Hour
+
+// Define request metrics
+var (
+	// Requests counts handled requests by method and status
+	Requests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "portfolio_requests_total",
+			Help: "Total number of portfolio requests",
+		},
+		[]string{"method", "status"},
+	)
+
+	// ActiveConnections is the number of open gRPC connections
+	ActiveConnections = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "portfolio_active_connections",
+			Help: "Number of active gRPC connections",
+		},
+	)
+
+	// Errors counts service errors by type
+	Errors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "portfolio_errors_total",
+			Help: "Total number of portfolio service errors",
+		},
+		[]string{"type"},
+	)
+
+	// ConfigInfo reports the version and source of the configuration in use
+	// as state "active", and that of the latest remote configuration seen
+	// while watching as state "latest", so a drift can be alerted on
+	ConfigInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_config_info",
+			Help: "Configuration version by state and source",
+		},
+		[]string{"state", "source", "version"},
+	)
+
+	// requestDuration is replaced by Register before requests are served
+	requestDuration = newRequestDuration(DefaultLatencyBuckets, config.NativeHistogramConfig{})
+
+	registered bool
+)
+
+// newRequestDuration creates the request latency histogram
+func newRequestDuration(buckets []float64, native config.NativeHistogramConfig) *prometheus.HistogramVec {
+	opts := prometheus.HistogramOpts{
+		Name:    "portfolio_request_duration_seconds",
+		Help:    "Request latency in seconds",
+		Buckets: buckets,
+	}
+	if native.Enabled {
+		opts.NativeHistogramBucketFactor = native.BucketFactor
+		opts.NativeHistogramMaxBucketNumber = native.MaxBuckets
+		opts.NativeHistogramMinResetDuration = nativeMinResetDuration
+	}
+	return prometheus.NewHistogramVec(opts, []string{"method"})
+}
+
+// Register builds the request latency histogram from cfg and registers the
+// request metrics, along with the configured runtime collectors, with
+// Registry. It must be called once, before the server starts handling
+// requests. When a collector cannot be registered, those already registered
+// are removed again, so a failed call can be retried.
+func Register(cfg config.MetricsConfig) error {
+	if registered {
+		return errors.New("request metrics are already registered")
+	}
+
+	buckets := cfg.LatencyBuckets
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	histogram := newRequestDuration(buckets, cfg.NativeHistograms)
+
+	toRegister := []prometheus.Collector{Requests, ActiveConnections, Errors, ConfigInfo, histogram}
+	if cfg.GoCollector {
+		toRegister = append(toRegister, collectors.NewGoCollector())
+	}
+	if cfg.ProcessCollector {
+		toRegister = append(toRegister, collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	}
+	for i, collector := range toRegister {
+		if err := Registry.Register(collector); err != nil {
+			for _, done := range toRegister[:i] {
+				Registry.Unregister(done)
+			}
+			return err
+		}
+	}
+
+	requestDuration = histogram
+	registered = true
+	return nil
+}
+
+// ObserveRequest records the latency of a request to method started at
+// startTime, linking the observation to the request's trace when it is
+// sampled
+func ObserveRequest(ctx context.Context, method string, startTime time.Time) {
+	exemplars.Observe(ctx, requestDuration.WithLabelValues(method), time.Since(startTime).Seconds())
+}
+
+// SetConfigVersion reports the configuration of state, replacing the one
+// previously reported for it
+func SetConfigVersion(state, source, version string) {
+	ConfigInfo.DeletePartialMatch(prometheus.Labels{"state": state})
+	ConfigInfo.WithLabelValues(state, source, version).Set(1)
+}
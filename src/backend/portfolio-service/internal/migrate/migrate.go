@@ -0,0 +1,207 @@
+// Package migrate applies the schema migrations in db/migrations during the
+// warm-up phase, for deployments where the service owns its schema instead
+// of a deploy pipeline applying them. Migrations are applied in version order
+// from the latest recorded in schema_migrations; every migration from
+// 046_schema_migrations on records itself there. Instances starting together
+// serialize on an advisory lock, so each migration is applied once.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrLocked reports migrations being applied by another instance
+var ErrLocked = errors.New("migrations are being applied by another instance")
+
+// ErrUnrecorded reports a migrated database predating schema_migrations,
+// whose applied migrations are unknown
+var ErrUnrecorded = errors.New("database schema predates schema_migrations")
+
+// lockKey is the advisory lock serializing instances applying migrations
+var lockKey = func() int64 {
+	h := fnv.New64a()
+	h.Write([]byte("portfolio-service/migrations"))
+	return int64(h.Sum64())
+}()
+
+// Migration is a migration script split into its statements
+type Migration struct {
+	Version    int
+	Name       string
+	Statements []string
+}
+
+// Concurrent reports whether the migration builds indexes concurrently,
+// which PostgreSQL refuses inside a transaction
+func (m Migration) Concurrent() bool {
+	for _, statement := range m.Statements {
+		if strings.Contains(strings.ToUpper(statement), "CONCURRENTLY") {
+			return true
+		}
+	}
+	return false
+}
+
+// Load reads the migrations of dir, named NNN_name.sql, in version order
+func Load(dir string) ([]Migration, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no migrations found in %s", dir)
+	}
+
+	migrations := make([]Migration, 0, len(paths))
+	versions := make(map[int]string, len(paths))
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".sql")
+		prefix, _, ok := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s is not named NNN_name.sql", filepath.Base(path))
+		}
+		if other, ok := versions[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, name, version)
+		}
+		versions[version] = name
+
+		script, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, Statements: Split(string(script))})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Split splits a migration script into its statements, skipping comments
+// between statements and keeping dollar-quoted function bodies whole
+func Split(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		quoted     bool
+	)
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if current.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if strings.Count(line, "$$")%2 == 1 {
+			quoted = !quoted
+		}
+		if !quoted && strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, current.String())
+			current.Reset()
+		}
+	}
+	return statements
+}
+
+// Apply applies the migrations newer than the database's schema version and
+// returns those it applied. Each migration runs in a transaction, except
+// those building indexes concurrently, whose statements run one at a time.
+// It fails with ErrLocked while another instance is applying migrations.
+func Apply(ctx context.Context, db *sql.DB, migrations []Migration) ([]Migration, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve migration connection: %w", err)
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockKey).Scan(&locked); err != nil {
+		return nil, fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	if !locked {
+		return nil, ErrLocked
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+
+	// Index builds may outlast the statement timeout of the service's
+	// queries; the warm-up timeout still bounds them through ctx
+	if _, err := conn.ExecContext(ctx, "SET statement_timeout = 0"); err != nil {
+		return nil, fmt.Errorf("failed to disable statement timeout: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "RESET statement_timeout")
+
+	version, err := schemaVersion(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		if m.Version <= version {
+			continue
+		}
+		if err := apply(ctx, conn, m); err != nil {
+			return applied, fmt.Errorf("failed to apply migration %s: %w", m.Name, err)
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+// schemaVersion returns the latest migration recorded in schema_migrations,
+// or zero for an empty database
+func schemaVersion(ctx context.Context, conn *sql.Conn) (int, error) {
+	var recorded, migrated bool
+	err := conn.QueryRowContext(ctx, `
+		SELECT to_regclass('schema_migrations') IS NOT NULL,
+		       to_regclass('audit_trail') IS NOT NULL`).Scan(&recorded, &migrated)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	if !recorded {
+		if migrated {
+			return 0, fmt.Errorf("%w: apply migrations up to 046_schema_migrations before enabling startup migrations", ErrUnrecorded)
+		}
+		return 0, nil
+	}
+
+	var version int
+	if err := conn.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// apply runs the statements of a migration
+func apply(ctx context.Context, conn *sql.Conn, m Migration) error {
+	if m.Concurrent() {
+		for _, statement := range m.Statements {
+			if _, err := conn.ExecContext(ctx, statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, statement := range m.Statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
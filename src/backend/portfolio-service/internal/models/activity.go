@@ -0,0 +1,103 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// ActivityKind is the kind of change an activity feed item records
+type ActivityKind string
+
+const (
+	// ActivityTransaction is a recorded transaction
+	ActivityTransaction ActivityKind = "transaction"
+	// ActivityAssetAdded is an asset added to the portfolio
+	ActivityAssetAdded ActivityKind = "asset_added"
+	// ActivityAssetRemoved is an asset removed from the portfolio
+	ActivityAssetRemoved ActivityKind = "asset_removed"
+	// ActivityAlert is an alert rule that triggered
+	ActivityAlert ActivityKind = "alert"
+	// ActivitySync is a sync of a connected exchange account or tracked wallet
+	ActivitySync ActivityKind = "sync"
+)
+
+// ACTIVITY_KINDS lists the kinds of activity feed items
+var ACTIVITY_KINDS = []ActivityKind{
+	ActivityTransaction,
+	ActivityAssetAdded,
+	ActivityAssetRemoved,
+	ActivityAlert,
+	ActivitySync,
+}
+
+// ErrInvalidActivityKind is returned for activity kinds the feed does not list
+var ErrInvalidActivityKind = errors.New("invalid activity kind")
+
+// ParseActivityKinds validates the kinds an activity feed is filtered to,
+// dropping duplicates. No kinds lists all of them.
+func ParseActivityKinds(kinds []string) ([]ActivityKind, error) {
+	if len(kinds) == 0 {
+		return append([]ActivityKind(nil), ACTIVITY_KINDS...), nil
+	}
+
+	seen := make(map[ActivityKind]bool, len(kinds))
+	parsed := make([]ActivityKind, 0, len(kinds))
+	for _, k := range kinds {
+		kind := ActivityKind(k)
+		valid := false
+		for _, known := range ACTIVITY_KINDS {
+			if kind == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidActivityKind, k)
+		}
+		if !seen[kind] {
+			seen[kind] = true
+			parsed = append(parsed, kind)
+		}
+	}
+	return parsed, nil
+}
+
+// Sync sources of a portfolio
+const (
+	SyncSourceExchange = "exchange"
+	SyncSourceWallet   = "wallet"
+)
+
+// SyncEvent is one sync of a connected exchange account or tracked wallet
+// into a portfolio
+type SyncEvent struct {
+	ID          uuid.UUID `json:"id"`
+	PortfolioID uuid.UUID `json:"portfolio_id"`
+	Source      string    `json:"source"`
+	// Name is the exchange of an exchange account or chain of a wallet
+	Name string `json:"name"`
+	// Imported counts the transactions the sync recorded
+	Imported int `json:"imported"`
+	// Error is why the sync failed; empty when it succeeded
+	Error    string    `json:"error,omitempty"`
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+// ActivityItem is one entry of a portfolio's activity feed. Transaction is
+// set for transactions, Alert for triggered alerts and Sync for syncs;
+// asset changes only carry the asset.
+type ActivityItem struct {
+	Kind ActivityKind `json:"kind"`
+	// ID is the ID of the transaction, asset, alert event or sync
+	ID         uuid.UUID `json:"id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	// AssetID is nil for syncs and portfolio-wide alerts
+	AssetID     uuid.UUID    `json:"asset_id"`
+	Symbol      string       `json:"symbol,omitempty"`
+	Transaction *Transaction `json:"transaction,omitempty"`
+	Alert       *AlertEvent  `json:"alert,omitempty"`
+	Sync        *SyncEvent   `json:"sync,omitempty"`
+}
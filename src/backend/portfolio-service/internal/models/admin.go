@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// UserStats counts a user's portfolio data for support tooling. Value is
+// the sum of the portfolios' last recorded valuations.
+type UserStats struct {
+	UserID             uuid.UUID       `json:"user_id"`
+	Portfolios         int             `json:"portfolios"`
+	ArchivedPortfolios int             `json:"archived_portfolios"`
+	Assets             int             `json:"assets"`
+	Transactions       int             `json:"transactions"`
+	Value              decimal.Decimal `json:"value"`
+	GeneratedAt        time.Time       `json:"generated_at"`
+}
+
+// PlatformStats counts the portfolio data of all users for ops tooling.
+// AssetsUnderManagement is the sum of every portfolio's last recorded
+// valuation in the base currency.
+type PlatformStats struct {
+	Users                 int             `json:"users"`
+	Portfolios            int             `json:"portfolios"`
+	Assets                int             `json:"assets"`
+	Transactions          int             `json:"transactions"`
+	AssetsUnderManagement decimal.Decimal `json:"assets_under_management"`
+	BaseCurrency          string          `json:"base_currency"`
+	GeneratedAt           time.Time       `json:"generated_at"`
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// AlertKind identifies the portfolio aggregate an alert rule watches
+type AlertKind string
+
+const (
+	// AlertValueDrop triggers when total value falls Threshold percent below
+	// its peak within Window
+	AlertValueDrop AlertKind = "value_drop"
+	// AlertProfitLossAbove triggers when unrealized P&L rises above Threshold
+	AlertProfitLossAbove AlertKind = "pnl_above"
+	// AlertProfitLossBelow triggers when unrealized P&L falls below Threshold
+	AlertProfitLossBelow AlertKind = "pnl_below"
+	// AlertStablecoinDepeg triggers when the price of a held stablecoin
+	// deviates Threshold percent or more from its peg
+	AlertStablecoinDepeg AlertKind = "stablecoin_depeg"
+)
+
+var (
+	// DEFAULT_ALERT_WINDOW is the lookback of value drop rules, and the quiet
+	// period of depeg rules, without a window
+	DEFAULT_ALERT_WINDOW = 24 * time.Hour
+
+	// MAX_ALERT_WINDOW bounds the window of value drop and depeg rules
+	MAX_ALERT_WINDOW = 30 * 24 * time.Hour
+
+	// MAX_ALERT_RULES_PER_PORTFOLIO limits the number of rules per portfolio
+	MAX_ALERT_RULES_PER_PORTFOLIO = 20
+
+	// ErrInvalidAlertRule is returned for malformed alert rules
+	ErrInvalidAlertRule = errors.New("invalid alert rule")
+)
+
+// AlertRule is a user-defined condition on portfolio value, P&L or the pegs
+// of held stablecoins
+type AlertRule struct {
+	ID              uuid.UUID       `json:"id"`
+	PortfolioID     uuid.UUID       `json:"portfolio_id"`
+	Kind            AlertKind       `json:"kind"`
+	Threshold       decimal.Decimal `json:"threshold"`
+	Window          time.Duration   `json:"window"`
+	Enabled         bool            `json:"enabled"`
+	LastTriggeredAt *time.Time      `json:"last_triggered_at,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// AlertEvent records a rule triggering on a snapshot
+type AlertEvent struct {
+	ID          uuid.UUID       `json:"id"`
+	RuleID      uuid.UUID       `json:"rule_id"`
+	PortfolioID uuid.UUID       `json:"portfolio_id"`
+	Kind        AlertKind       `json:"kind"`
+	Threshold   decimal.Decimal `json:"threshold"`
+	Observed    decimal.Decimal `json:"observed"`
+	// Symbol is the stablecoin that lost its peg, for depeg rules
+	Symbol      string    `json:"symbol,omitempty"`
+	TriggeredAt time.Time `json:"triggered_at"`
+}
+
+// AlertObservation is the snapshot data an alert rule is evaluated against
+type AlertObservation struct {
+	// Current is the snapshot just captured
+	Current ValuePoint
+	// Previous is the snapshot before Current, if any
+	Previous *ValuePoint
+	// WindowPeak is the highest total value within the rule's window, if any
+	WindowPeak decimal.NullDecimal
+	// Assets are the holdings valued in Current
+	Assets []AssetSnapshot
+}
+
+// Validate checks the rule and fills in the default window
+func (r *AlertRule) Validate() error {
+	switch r.Kind {
+	case AlertValueDrop, AlertStablecoinDepeg:
+		if !r.Threshold.IsPositive() || r.Threshold.GreaterThan(decimal.NewFromInt(100)) {
+			return InvalidField("threshold", fmt.Errorf("%w: %s threshold must be a percentage in (0, 100]", ErrInvalidAlertRule, r.Kind))
+		}
+		if r.Window == 0 {
+			r.Window = DEFAULT_ALERT_WINDOW
+		}
+		if r.Window < time.Hour || r.Window > MAX_ALERT_WINDOW {
+			return InvalidField("window", fmt.Errorf("%w: window must be between 1h and %s", ErrInvalidAlertRule, MAX_ALERT_WINDOW))
+		}
+	case AlertProfitLossAbove, AlertProfitLossBelow:
+		if r.Window != 0 {
+			return InvalidField("window", fmt.Errorf("%w: P&L rules do not take a window", ErrInvalidAlertRule))
+		}
+	default:
+		return InvalidField("kind", fmt.Errorf("%w: unknown kind %q", ErrInvalidAlertRule, r.Kind))
+	}
+	return nil
+}
+
+// Evaluate reports whether the rule triggers on the observation and returns
+// the observed value: the drop percentage from the window peak for value drop
+// rules, the largest peg deviation percentage of held stablecoins for depeg
+// rules, or the current P&L. Value drop and depeg rules stay quiet for one
+// window after triggering; P&L rules trigger only when the level is crossed. Rules never
+// trigger twice for the same snapshot.
+func (r *AlertRule) Evaluate(obs AlertObservation) (decimal.Decimal, bool) {
+	if !r.Enabled {
+		return decimal.Zero, false
+	}
+	// Re-evaluating an already alerted snapshot must not alert again
+	if r.LastTriggeredAt != nil && !obs.Current.Timestamp.After(*r.LastTriggeredAt) {
+		return decimal.Zero, false
+	}
+
+	switch r.Kind {
+	case AlertValueDrop:
+		if !obs.WindowPeak.Valid || !obs.WindowPeak.Decimal.IsPositive() {
+			return decimal.Zero, false
+		}
+		if r.LastTriggeredAt != nil && obs.Current.Timestamp.Sub(*r.LastTriggeredAt) < r.Window {
+			return decimal.Zero, false
+		}
+		peak := obs.WindowPeak.Decimal
+		drop := peak.Sub(obs.Current.Value).Div(peak).Mul(decimal.NewFromInt(100)).Round(2)
+		return drop, drop.GreaterThanOrEqual(r.Threshold)
+
+	case AlertStablecoinDepeg:
+		if r.LastTriggeredAt != nil && obs.Current.Timestamp.Sub(*r.LastTriggeredAt) < r.Window {
+			return decimal.Zero, false
+		}
+		_, deviation, ok := LargestPegDeviation(obs.Assets)
+		return deviation, ok && deviation.GreaterThanOrEqual(r.Threshold)
+
+	case AlertProfitLossAbove:
+		if obs.Previous == nil {
+			return obs.Current.ProfitLoss, false
+		}
+		crossed := obs.Previous.ProfitLoss.LessThanOrEqual(r.Threshold) && obs.Current.ProfitLoss.GreaterThan(r.Threshold)
+		return obs.Current.ProfitLoss, crossed
+
+	case AlertProfitLossBelow:
+		if obs.Previous == nil {
+			return obs.Current.ProfitLoss, false
+		}
+		crossed := obs.Previous.ProfitLoss.GreaterThanOrEqual(r.Threshold) && obs.Current.ProfitLoss.LessThan(r.Threshold)
+		return obs.Current.ProfitLoss, crossed
+	}
+
+	return decimal.Zero, false
+}
+
+// PegDeviation returns how far a stablecoin price is from STABLECOIN_PEG, in
+// percent rounded to two decimals
+func PegDeviation(price decimal.Decimal) decimal.Decimal {
+	return price.Sub(STABLECOIN_PEG).Abs().Div(STABLECOIN_PEG).Mul(decimal.NewFromInt(100)).Round(2)
+}
+
+// LargestPegDeviation returns the stablecoin among assets whose price deviates
+// most from its peg, and the deviation in percent. Holdings without an amount
+// or value are skipped since their price is unknown; ok is false when no
+// stablecoin is priced.
+func LargestPegDeviation(assets []AssetSnapshot) (symbol string, deviation decimal.Decimal, ok bool) {
+	for _, asset := range assets {
+		if !IsStablecoin(asset.Symbol) || !asset.Amount.IsPositive() || !asset.Value.IsPositive() {
+			continue
+		}
+		d := PegDeviation(asset.Value.Div(asset.Amount))
+		if !ok || d.GreaterThan(deviation) {
+			symbol, deviation, ok = asset.Symbol, d, true
+		}
+	}
+	return symbol, deviation, ok
+}
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// BASE_CURRENCY is the currency all portfolio values are denominated in
+const BASE_CURRENCY = "USD"
+
+// Asset categories used for allocation breakdowns
+const (
+	CategoryLayer1     = "l1"
+	CategoryLayer2     = "l2"
+	CategoryDeFi       = "defi"
+	CategoryStablecoin = "stablecoin"
+	CategoryNFT        = "nft"
+	CategoryCash       = "cash"
+	CategoryOther      = "other"
+)
+
+// SYMBOL_CATEGORIES maps well-known symbols to their asset category
+var SYMBOL_CATEGORIES = map[string]string{
+	"BTC":   CategoryLayer1,
+	"ETH":   CategoryLayer1,
+	"SOL":   CategoryLayer1,
+	"ADA":   CategoryLayer1,
+	"AVAX":  CategoryLayer1,
+	"DOT":   CategoryLayer1,
+	"ATOM":  CategoryLayer1,
+	"NEAR":  CategoryLayer1,
+	"BNB":   CategoryLayer1,
+	"TRX":   CategoryLayer1,
+	"XRP":   CategoryLayer1,
+	"LTC":   CategoryLayer1,
+	"MATIC": CategoryLayer2,
+	"ARB":   CategoryLayer2,
+	"OP":    CategoryLayer2,
+	"IMX":   CategoryLayer2,
+	"UNI":   CategoryDeFi,
+	"AAVE":  CategoryDeFi,
+	"MKR":   CategoryDeFi,
+	"COMP":  CategoryDeFi,
+	"CRV":   CategoryDeFi,
+	"LDO":   CategoryDeFi,
+	"SNX":   CategoryDeFi,
+	"SUSHI": CategoryDeFi,
+	"USDT":  CategoryStablecoin,
+	"USDC":  CategoryStablecoin,
+	"DAI":   CategoryStablecoin,
+	"BUSD":  CategoryStablecoin,
+	"TUSD":  CategoryStablecoin,
+	"USDP":  CategoryStablecoin,
+	"FRAX":  CategoryStablecoin,
+	"PYUSD": CategoryStablecoin,
+	"FDUSD": CategoryStablecoin,
+	"GUSD":  CategoryStablecoin,
+	"LUSD":  CategoryStablecoin,
+	"USDE":  CategoryStablecoin,
+}
+
+// STABLECOIN_PEG is the base currency price stablecoins are pegged to; every
+// stablecoin in SYMBOL_CATEGORIES tracks the base currency
+var STABLECOIN_PEG = decimal.NewFromInt(1)
+
+// IsStablecoin reports whether a symbol is classified as a stablecoin
+func IsStablecoin(symbol string) bool {
+	return SYMBOL_CATEGORIES[strings.ToUpper(symbol)] == CategoryStablecoin
+}
+
+// AllocationSlice is the share of portfolio value held in one group
+type AllocationSlice struct {
+	Key        string          `json:"key"`
+	Value      decimal.Decimal `json:"value"`
+	Percentage decimal.Decimal `json:"percentage"`
+
+	// PriceAsOf is the oldest price time of the slice's assets, and
+	// PriceStale is set when any of their prices is stale
+	PriceAsOf  *time.Time `json:"price_as_of,omitempty"`
+	PriceStale bool       `json:"price_stale,omitempty"`
+}
+
+// Allocation breaks portfolio value down by symbol, asset type and category.
+// DustValue is the value of dust holdings left out of the breakdown.
+type Allocation struct {
+	PortfolioID  uuid.UUID         `json:"portfolio_id"`
+	BaseCurrency string            `json:"base_currency"`
+	TotalValue   decimal.Decimal   `json:"total_value"`
+	BySymbol     []AllocationSlice `json:"by_symbol"`
+	ByType       []AllocationSlice `json:"by_type"`
+	ByCategory   []AllocationSlice `json:"by_category"`
+	DustValue    decimal.Decimal   `json:"dust_value"`
+	CalculatedAt time.Time         `json:"calculated_at"`
+	PricesStale  bool              `json:"prices_stale,omitempty"`
+	// DelistedSymbols lists the held symbols no provider quotes anymore, in
+	// order, including those valued at zero
+	DelistedSymbols []string `json:"delisted_symbols,omitempty"`
+}
+
+// AssetCategory returns the category of an asset, derived from its type for
+// NFTs and fiat cash and from its symbol otherwise
+func AssetCategory(asset Asset) string {
+	switch asset.Type {
+	case "nft":
+		return CategoryNFT
+	case FIAT_ASSET_TYPE:
+		return CategoryCash
+	}
+	if category, ok := SYMBOL_CATEGORIES[strings.ToUpper(asset.Symbol)]; ok {
+		return category
+	}
+	return CategoryOther
+}
+
+// BuildAllocation groups the current values of assets into allocation slices.
+// Holdings without value are ignored; percentages are rounded to two decimals.
+func BuildAllocation(portfolioID uuid.UUID, assets []Asset) *Allocation {
+	bySymbol := make(map[string]decimal.Decimal)
+	byType := make(map[string]decimal.Decimal)
+	byCategory := make(map[string]decimal.Decimal)
+	total := decimal.Zero
+
+	for _, asset := range assets {
+		if !asset.CurrentValue.IsPositive() {
+			continue
+		}
+		bySymbol[asset.Symbol] = bySymbol[asset.Symbol].Add(asset.CurrentValue)
+		byType[asset.Type] = byType[asset.Type].Add(asset.CurrentValue)
+		category := AssetCategory(asset)
+		byCategory[category] = byCategory[category].Add(asset.CurrentValue)
+		total = total.Add(asset.CurrentValue)
+	}
+
+	allocation := &Allocation{
+		PortfolioID:  portfolioID,
+		BaseCurrency: BASE_CURRENCY,
+		TotalValue:   total,
+		BySymbol:     allocationSlices(bySymbol, total),
+		ByType:       allocationSlices(byType, total),
+		ByCategory:   allocationSlices(byCategory, total),
+		CalculatedAt: time.Now().UTC(),
+	}
+	markPriceFreshness(allocation.BySymbol, assets, func(a Asset) string { return a.Symbol })
+	markPriceFreshness(allocation.ByType, assets, func(a Asset) string { return a.Type })
+	markPriceFreshness(allocation.ByCategory, assets, AssetCategory)
+	for _, slice := range allocation.BySymbol {
+		allocation.PricesStale = allocation.PricesStale || slice.PriceStale
+	}
+
+	delisted := make(map[string]bool)
+	for _, asset := range assets {
+		if asset.Delisted && !delisted[asset.Symbol] {
+			delisted[asset.Symbol] = true
+			allocation.DelistedSymbols = append(allocation.DelistedSymbols, asset.Symbol)
+		}
+	}
+	sort.Strings(allocation.DelistedSymbols)
+	return allocation
+}
+
+// markPriceFreshness sets the price freshness of each slice from the valued
+// assets grouped into it by key
+func markPriceFreshness(slices []AllocationSlice, assets []Asset, key func(Asset) string) {
+	index := make(map[string]int, len(slices))
+	for i := range slices {
+		index[slices[i].Key] = i
+	}
+
+	for _, asset := range assets {
+		i, ok := index[key(asset)]
+		if !ok || asset.PriceAsOf == nil || !asset.CurrentValue.IsPositive() {
+			continue
+		}
+		slice := &slices[i]
+		if slice.PriceAsOf == nil || asset.PriceAsOf.Before(*slice.PriceAsOf) {
+			asOf := *asset.PriceAsOf
+			slice.PriceAsOf = &asOf
+		}
+		slice.PriceStale = slice.PriceStale || asset.PriceStale
+	}
+}
+
+// allocationSlices converts grouped values into slices ordered by value, largest first
+func allocationSlices(groups map[string]decimal.Decimal, total decimal.Decimal) []AllocationSlice {
+	slices := make([]AllocationSlice, 0, len(groups))
+	for key, value := range groups {
+		slices = append(slices, AllocationSlice{
+			Key:        key,
+			Value:      value,
+			Percentage: value.Div(total).Mul(decimal.NewFromInt(100)).Round(2),
+		})
+	}
+
+	sort.Slice(slices, func(i, j int) bool {
+		if c := slices[i].Value.Cmp(slices[j].Value); c != 0 {
+			return c > 0
+		}
+		return slices[i].Key < slices[j].Key
+	})
+	return slices
+}
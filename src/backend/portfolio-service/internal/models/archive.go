@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// TransactionArchive records a batch of transactions moved to cold storage
+type TransactionArchive struct {
+	ID               uuid.UUID `json:"id"`
+	PortfolioID      uuid.UUID `json:"portfolio_id"`
+	ObjectKey        string    `json:"object_key"`
+	PeriodStart      time.Time `json:"period_start"`
+	PeriodEnd        time.Time `json:"period_end"`
+	TransactionCount int       `json:"transaction_count"`
+	SizeBytes        int64     `json:"size_bytes"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ArchivedLot summarizes the position in an asset carried over from archived
+// transactions, so holdings and cost basis stay computable from Postgres alone
+type ArchivedLot struct {
+	ArchiveID        uuid.UUID       `json:"archive_id"`
+	PortfolioID      uuid.UUID       `json:"portfolio_id"`
+	AssetID          uuid.UUID       `json:"asset_id"`
+	Quantity         decimal.Decimal `json:"quantity"`
+	CostBasis        decimal.Decimal `json:"cost_basis"`
+	TotalFees        decimal.Decimal `json:"total_fees"`
+	TransactionCount int             `json:"transaction_count"`
+	AcquiredBefore   time.Time       `json:"acquired_before"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"sort"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// AssetHolding is one holding of a searched symbol within a portfolio,
+// valued at the latest stored price
+type AssetHolding struct {
+	PortfolioID   uuid.UUID       `json:"portfolio_id"`
+	PortfolioName string          `json:"portfolio_name"`
+	AssetID       uuid.UUID       `json:"asset_id"`
+	Symbol        string          `json:"symbol"`
+	Amount        decimal.Decimal `json:"amount"`
+	CurrentValue  decimal.Decimal `json:"current_value"`
+	// Archived is set for holdings of archived portfolios
+	Archived bool `json:"archived"`
+}
+
+// AssetSearchResult lists every holding of a symbol across a user's
+// portfolios with their totals
+type AssetSearchResult struct {
+	Symbol      string          `json:"symbol"`
+	Holdings    []AssetHolding  `json:"holdings"`
+	TotalAmount decimal.Decimal `json:"total_amount"`
+	TotalValue  decimal.Decimal `json:"total_value"`
+}
+
+// NewAssetSearchResult totals holdings of symbol and orders them by value,
+// largest first, breaking ties by portfolio ID
+func NewAssetSearchResult(symbol string, holdings []AssetHolding) *AssetSearchResult {
+	result := &AssetSearchResult{
+		Symbol:   symbol,
+		Holdings: make([]AssetHolding, len(holdings)),
+	}
+	copy(result.Holdings, holdings)
+
+	for _, h := range result.Holdings {
+		result.TotalAmount = result.TotalAmount.Add(h.Amount)
+		result.TotalValue = result.TotalValue.Add(h.CurrentValue)
+	}
+
+	sort.SliceStable(result.Holdings, func(i, j int) bool {
+		a, b := result.Holdings[i], result.Holdings[j]
+		if c := a.CurrentValue.Cmp(b.CurrentValue); c != 0 {
+			return c > 0
+		}
+		return a.PortfolioID.String() < b.PortfolioID.String()
+	})
+
+	return result
+}
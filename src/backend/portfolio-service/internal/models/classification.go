@@ -0,0 +1,1079 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Classification is the data sensitivity level of a model field
+type Classification string
+
+const (
+	// ClassPublic data may be shown to anyone, e.g. market prices
+	ClassPublic Classification = "public"
+	// ClassInternal data is owned by a user but carries no risk if logged
+	ClassInternal Classification = "internal"
+	// ClassSensitive data is user-entered or identifying and must be
+	// encrypted at rest or kept out of logs and exports
+	ClassSensitive Classification = "sensitive"
+	// ClassSecret data must be encrypted at rest and never logged or exported
+	ClassSecret Classification = "secret"
+)
+
+// FieldPolicy records how a model field is classified and where it may flow
+type FieldPolicy struct {
+	Class Classification
+	// Encrypted is set when the backing column is encrypted at rest
+	Encrypted bool
+	// Logged is set when the field may appear in structured log fields
+	Logged bool
+	// Exported is set when the field may be written to file exports
+	Exported bool
+}
+
+var (
+	publicField    = FieldPolicy{Class: ClassPublic, Logged: true, Exported: true}
+	identifier     = FieldPolicy{Class: ClassInternal, Logged: true, Exported: true}
+	holding        = FieldPolicy{Class: ClassInternal, Exported: true}
+	userText       = FieldPolicy{Class: ClassSensitive}
+	storageDetails = FieldPolicy{Class: ClassInternal, Logged: true}
+	credential     = FieldPolicy{Class: ClassSecret, Encrypted: true}
+	// signedURL grants access to stored files without further authentication
+	signedURL = FieldPolicy{Class: ClassSensitive}
+	// walletAddress links a user to the public history of their wallet
+	walletAddress = FieldPolicy{Class: ClassSensitive}
+)
+
+// CLASSIFIED_MODELS lists the model types covered by DATA_CLASSIFICATION.
+// Every exported field of these types must have an entry.
+var CLASSIFIED_MODELS = []reflect.Type{
+	reflect.TypeOf((*Portfolio)(nil)).Elem(),
+	reflect.TypeOf((*Asset)(nil)).Elem(),
+	reflect.TypeOf((*Transaction)(nil)).Elem(),
+	reflect.TypeOf((*PortfolioSnapshot)(nil)).Elem(),
+	reflect.TypeOf((*AssetSnapshot)(nil)).Elem(),
+	reflect.TypeOf((*ValuePoint)(nil)).Elem(),
+	reflect.TypeOf((*TransactionArchive)(nil)).Elem(),
+	reflect.TypeOf((*ArchivedLot)(nil)).Elem(),
+	reflect.TypeOf((*PricePoint)(nil)).Elem(),
+	reflect.TypeOf((*PriceBar)(nil)).Elem(),
+	reflect.TypeOf((*VaREstimate)(nil)).Elem(),
+	reflect.TypeOf((*RiskMetrics)(nil)).Elem(),
+	reflect.TypeOf((*CorrelationMatrix)(nil)).Elem(),
+	reflect.TypeOf((*AllocationSlice)(nil)).Elem(),
+	reflect.TypeOf((*Allocation)(nil)).Elem(),
+	reflect.TypeOf((*AllocationTarget)(nil)).Elem(),
+	reflect.TypeOf((*DustSettings)(nil)).Elem(),
+	reflect.TypeOf((*AssetTags)(nil)).Elem(),
+	reflect.TypeOf((*ManualPrice)(nil)).Elem(),
+	reflect.TypeOf((*CustomAsset)(nil)).Elem(),
+	reflect.TypeOf((*DelistedSymbol)(nil)).Elem(),
+	reflect.TypeOf((*UserDataPurge)(nil)).Elem(),
+	reflect.TypeOf((*UserDataExport)(nil)).Elem(),
+	reflect.TypeOf((*PortfolioDataExport)(nil)).Elem(),
+	reflect.TypeOf((*AuditEntry)(nil)).Elem(),
+	reflect.TypeOf((*PortfolioShare)(nil)).Elem(),
+	reflect.TypeOf((*PortfolioHoldings)(nil)).Elem(),
+	reflect.TypeOf((*PortfolioSummary)(nil)).Elem(),
+	reflect.TypeOf((*UserSummary)(nil)).Elem(),
+	reflect.TypeOf((*AssetHolding)(nil)).Elem(),
+	reflect.TypeOf((*AssetSearchResult)(nil)).Elem(),
+	reflect.TypeOf((*DriftEntry)(nil)).Elem(),
+	reflect.TypeOf((*Drift)(nil)).Elem(),
+	reflect.TypeOf((*DCAPurchase)(nil)).Elem(),
+	reflect.TypeOf((*DCAInstallment)(nil)).Elem(),
+	reflect.TypeOf((*DCAPlan)(nil)).Elem(),
+	reflect.TypeOf((*AlertRule)(nil)).Elem(),
+	reflect.TypeOf((*AlertEvent)(nil)).Elem(),
+	reflect.TypeOf((*AlertObservation)(nil)).Elem(),
+	reflect.TypeOf((*Notification)(nil)).Elem(),
+	reflect.TypeOf((*NotificationDeadLetter)(nil)).Elem(),
+	reflect.TypeOf((*WebhookEndpoint)(nil)).Elem(),
+	reflect.TypeOf((*WebhookDelivery)(nil)).Elem(),
+	reflect.TypeOf((*OutboxEvent)(nil)).Elem(),
+	reflect.TypeOf((*LedgerEvent)(nil)).Elem(),
+	reflect.TypeOf((*LedgerPortfolioDetails)(nil)).Elem(),
+	reflect.TypeOf((*LedgerAssetRef)(nil)).Elem(),
+	reflect.TypeOf((*LedgerCostBasis)(nil)).Elem(),
+	reflect.TypeOf((*LedgerState)(nil)).Elem(),
+	reflect.TypeOf((*ExchangeBalance)(nil)).Elem(),
+	reflect.TypeOf((*ExchangeFill)(nil)).Elem(),
+	reflect.TypeOf((*ExchangeConversion)(nil)).Elem(),
+	reflect.TypeOf((*ExchangeEntry)(nil)).Elem(),
+	reflect.TypeOf((*ExchangeActivity)(nil)).Elem(),
+	reflect.TypeOf((*ExchangeLeg)(nil)).Elem(),
+	reflect.TypeOf((*ExchangeImport)(nil)).Elem(),
+	reflect.TypeOf((*ExchangeAccount)(nil)).Elem(),
+	reflect.TypeOf((*ImportRowError)(nil)).Elem(),
+	reflect.TypeOf((*TransactionImport)(nil)).Elem(),
+	reflect.TypeOf((*ReportHolding)(nil)).Elem(),
+	reflect.TypeOf((*ReportMover)(nil)).Elem(),
+	reflect.TypeOf((*PerformanceReport)(nil)).Elem(),
+	reflect.TypeOf((*GeneratedReport)(nil)).Elem(),
+	reflect.TypeOf((*ReportSchedule)(nil)).Elem(),
+	reflect.TypeOf((*TrackedWallet)(nil)).Elem(),
+	reflect.TypeOf((*StakingPosition)(nil)).Elem(),
+	reflect.TypeOf((*CorporateAction)(nil)).Elem(),
+	reflect.TypeOf((*SymbolMigration)(nil)).Elem(),
+	reflect.TypeOf((*SymbolMigrationAudit)(nil)).Elem(),
+	reflect.TypeOf((*LedgerAssetMigration)(nil)).Elem(),
+	reflect.TypeOf((*Lot)(nil)).Elem(),
+	reflect.TypeOf((*LotDisposal)(nil)).Elem(),
+	reflect.TypeOf((*LotReplay)(nil)).Elem(),
+	reflect.TypeOf((*LotRecomputation)(nil)).Elem(),
+	reflect.TypeOf((*TransferCandidate)(nil)).Elem(),
+	reflect.TypeOf((*TransferLink)(nil)).Elem(),
+	reflect.TypeOf((*RealizedGain)(nil)).Elem(),
+	reflect.TypeOf((*IncomeLine)(nil)).Elem(),
+	reflect.TypeOf((*IncomePeriod)(nil)).Elem(),
+	reflect.TypeOf((*IncomeReport)(nil)).Elem(),
+	reflect.TypeOf((*HarvestCandidate)(nil)).Elem(),
+	reflect.TypeOf((*TermSplit)(nil)).Elem(),
+	reflect.TypeOf((*HeldLot)(nil)).Elem(),
+	reflect.TypeOf((*AssetHoldingPeriods)(nil)).Elem(),
+	reflect.TypeOf((*HoldingPeriodReport)(nil)).Elem(),
+	reflect.TypeOf((*WashSale)(nil)).Elem(),
+	reflect.TypeOf((*TaxYear)(nil)).Elem(),
+	reflect.TypeOf((*TaxSettings)(nil)).Elem(),
+	reflect.TypeOf((*AssetComparison)(nil)).Elem(),
+	reflect.TypeOf((*PortfolioComparison)(nil)).Elem(),
+	reflect.TypeOf((*SyncEvent)(nil)).Elem(),
+	reflect.TypeOf((*ActivityItem)(nil)).Elem(),
+	reflect.TypeOf((*UserStats)(nil)).Elem(),
+	reflect.TypeOf((*PlatformStats)(nil)).Elem(),
+}
+
+// DATA_CLASSIFICATION maps model type and field names to their policy
+var DATA_CLASSIFICATION = map[string]map[string]FieldPolicy{
+	"Portfolio": {
+		"ID":          identifier,
+		"UserID":      identifier,
+		"Name":        userText,
+		"Description": userText,
+		"Assets":      holding,
+		"TotalValue":  holding,
+		"ProfitLoss":  holding,
+		"LastUpdated": identifier,
+		"CreatedAt":   identifier,
+		"Change24h":   holding,
+		"Change7d":    holding,
+		"Change30d":   holding,
+		"ArchivedAt":  identifier,
+		"PricesAsOf":  publicField,
+		"PricesStale": publicField,
+	},
+	"Asset": {
+		"ID":           identifier,
+		"Type":         identifier,
+		"Symbol":       publicField,
+		"Amount":       holding,
+		"CostBasis":    holding,
+		"CurrentValue": holding,
+		"LastUpdated":  identifier,
+		"PriceAsOf":    publicField,
+		"PriceStale":   publicField,
+		"PriceSource":  publicField,
+		"Delisted":     publicField,
+	},
+	"Transaction": {
+		"ID":          identifier,
+		"PortfolioID": identifier,
+		"AssetID":     identifier,
+		"Type":        identifier,
+		"Amount":      holding,
+		"Price":       publicField,
+		"Timestamp":   identifier,
+		"Fee":         holding,
+	},
+	"PortfolioSnapshot": {
+		"ID":          identifier,
+		"PortfolioID": identifier,
+		"TotalValue":  holding,
+		"ProfitLoss":  holding,
+		"Assets":      holding,
+		"CapturedAt":  identifier,
+	},
+	"AssetSnapshot": {
+		"AssetID":     identifier,
+		"Symbol":      publicField,
+		"Amount":      holding,
+		"Value":       holding,
+		"CostBasis":   holding,
+		"PriceSource": publicField,
+		"PriceAsOf":   publicField,
+	},
+	"ValuePoint": {
+		"Timestamp":  identifier,
+		"Value":      holding,
+		"ProfitLoss": holding,
+	},
+	"TransactionArchive": {
+		"ID":               identifier,
+		"PortfolioID":      identifier,
+		"ObjectKey":        storageDetails,
+		"PeriodStart":      identifier,
+		"PeriodEnd":        identifier,
+		"TransactionCount": identifier,
+		"SizeBytes":        storageDetails,
+		"CreatedAt":        identifier,
+	},
+	"ArchivedLot": {
+		"ArchiveID":        identifier,
+		"PortfolioID":      identifier,
+		"AssetID":          identifier,
+		"Quantity":         holding,
+		"CostBasis":        holding,
+		"TotalFees":        holding,
+		"TransactionCount": identifier,
+		"AcquiredBefore":   identifier,
+	},
+	"PricePoint": {
+		"Symbol":    publicField,
+		"Timestamp": publicField,
+		"Close":     publicField,
+	},
+	"PriceBar": {
+		"Symbol": publicField,
+		"Day":    publicField,
+		"Open":   publicField,
+		"High":   publicField,
+		"Low":    publicField,
+		"Close":  publicField,
+		"Volume": publicField,
+	},
+	"VaREstimate": {
+		"Method":      publicField,
+		"Confidence":  publicField,
+		"HorizonDays": publicField,
+		"Value":       holding,
+	},
+	"RiskMetrics": {
+		"PortfolioID":      identifier,
+		"CoveredValue":     holding,
+		"Observations":     identifier,
+		"WindowStart":      identifier,
+		"WindowEnd":        identifier,
+		"VaR":              holding,
+		"UncoveredSymbols": publicField,
+		"CalculatedAt":     identifier,
+	},
+	"CorrelationMatrix": {
+		"PortfolioID":      identifier,
+		"Symbols":          publicField,
+		"Values":           publicField,
+		"Observations":     identifier,
+		"WindowStart":      identifier,
+		"WindowEnd":        identifier,
+		"UncoveredSymbols": publicField,
+	},
+	"AllocationSlice": {
+		"Key":        publicField,
+		"Value":      holding,
+		"Percentage": holding,
+		"PriceAsOf":  publicField,
+		"PriceStale": publicField,
+	},
+	"Allocation": {
+		"PortfolioID":     identifier,
+		"BaseCurrency":    publicField,
+		"TotalValue":      holding,
+		"BySymbol":        holding,
+		"ByType":          holding,
+		"ByCategory":      holding,
+		"DustValue":       holding,
+		"CalculatedAt":    identifier,
+		"PricesStale":     publicField,
+		"DelistedSymbols": holding,
+	},
+	"AllocationTarget": {
+		"Dimension":  identifier,
+		"Key":        publicField,
+		"Percentage": holding,
+	},
+	"DustSettings": {
+		"PortfolioID": identifier,
+		"Threshold":   holding,
+		"Hidden":      identifier,
+	},
+	"AssetTags": {
+		"PortfolioID": identifier,
+		"AssetID":     identifier,
+		"Tags":        userText,
+	},
+	"ManualPrice": {
+		"ID":          identifier,
+		"PortfolioID": identifier,
+		"Symbol":      publicField,
+		"Price":       holding,
+		"ValidFrom":   identifier,
+		"ValidUntil":  identifier,
+		"Note":        userText,
+		"CreatedAt":   identifier,
+	},
+	"CustomAsset": {
+		"ID":          identifier,
+		"PortfolioID": identifier,
+		"Symbol":      publicField,
+		"Name":        userText,
+		"Decimals":    publicField,
+		"CreatedAt":   identifier,
+	},
+	"DelistedSymbol": {
+		"Symbol":       publicField,
+		"LastPrice":    publicField,
+		"LastQuotedAt": publicField,
+		"DelistedAt":   publicField,
+	},
+	"UserDataPurge": {
+		"ID":           identifier,
+		"UserID":       identifier,
+		"Portfolios":   identifier,
+		"Assets":       identifier,
+		"Transactions": identifier,
+		"Snapshots":    identifier,
+		"Alerts":       identifier,
+		"AuditEntries": identifier,
+		"Archives":     identifier,
+		"CompletedAt":  identifier,
+		"ArchiveKeys":  identifier,
+	},
+	"UserDataExport": {
+		"Version":         publicField,
+		"UserID":          identifier,
+		"GeneratedAt":     identifier,
+		"Portfolios":      userText,
+		"Webhooks":        userText,
+		"ReportSchedules": holding,
+		"TrackedWallets":  walletAddress,
+		"AuditTrail":      userText,
+	},
+	"PortfolioDataExport": {
+		"Portfolio":         userText,
+		"Transactions":      holding,
+		"AssetTags":         userText,
+		"Dust":              holding,
+		"AllocationTargets": holding,
+		"DCAPlans":          holding,
+		"AlertRules":        holding,
+		"AlertEvents":       holding,
+		"Webhooks":          userText,
+		"ExchangeAccounts":  holding,
+		"StakingPositions":  walletAddress,
+		"Shares":            identifier,
+	},
+	"AuditEntry": {
+		"ID":        identifier,
+		"TableName": identifier,
+		"Operation": identifier,
+		"OldData":   userText,
+		"NewData":   userText,
+		"ChangedBy": identifier,
+		"ChangedAt": identifier,
+		"ClientIP":  userText,
+		"UserAgent": userText,
+	},
+	"PortfolioShare": {
+		"ID":          identifier,
+		"PortfolioID": identifier,
+		"TokenHash":   credential,
+		"CreatedAt":   identifier,
+		"ExpiresAt":   identifier,
+		"RevokedAt":   identifier,
+	},
+	"PortfolioHoldings": {
+		"PortfolioID": identifier,
+		"Name":        userText,
+		"Dust":        holding,
+		"Assets":      holding,
+	},
+	"PortfolioSummary": {
+		"PortfolioID": identifier,
+		"Name":        userText,
+		"TotalValue":  holding,
+		"CostBasis":   holding,
+		"ProfitLoss":  holding,
+		"Percentage":  holding,
+	},
+	"UserSummary": {
+		"UserID":               identifier,
+		"TotalValue":           holding,
+		"CostBasis":            holding,
+		"ProfitLoss":           holding,
+		"ProfitLossPercentage": holding,
+		"Portfolios":           userText,
+		"Allocation":           holding,
+		"CalculatedAt":         identifier,
+	},
+	"AssetHolding": {
+		"PortfolioID":   identifier,
+		"PortfolioName": userText,
+		"AssetID":       identifier,
+		"Symbol":        publicField,
+		"Amount":        holding,
+		"CurrentValue":  holding,
+		"Archived":      identifier,
+	},
+	"AssetSearchResult": {
+		"Symbol":      publicField,
+		"Holdings":    userText,
+		"TotalAmount": holding,
+		"TotalValue":  holding,
+	},
+	"DriftEntry": {
+		"Dimension": identifier,
+		"Key":       publicField,
+		"Target":    holding,
+		"Actual":    holding,
+		"Drift":     holding,
+		"Breached":  identifier,
+	},
+	"Drift": {
+		"PortfolioID":  identifier,
+		"Threshold":    identifier,
+		"Entries":      holding,
+		"Breached":     identifier,
+		"CalculatedAt": identifier,
+	},
+	"DCAPurchase": {
+		"Symbol": publicField,
+		"Amount": holding,
+	},
+	"DCAInstallment": {
+		"Sequence":            identifier,
+		"ScheduledAt":         identifier,
+		"Purchases":           holding,
+		"ProjectedAllocation": holding,
+		"ExecutedAt":          identifier,
+	},
+	"DCAPlan": {
+		"ID":           identifier,
+		"PortfolioID":  identifier,
+		"Contribution": holding,
+		"Frequency":    identifier,
+		"Targets":      holding,
+		"Installments": holding,
+		"CreatedAt":    identifier,
+	},
+	"AlertRule": {
+		"ID":              identifier,
+		"PortfolioID":     identifier,
+		"Kind":            identifier,
+		"Threshold":       holding,
+		"Window":          identifier,
+		"Enabled":         identifier,
+		"LastTriggeredAt": identifier,
+		"CreatedAt":       identifier,
+	},
+	"AlertEvent": {
+		"ID":          identifier,
+		"RuleID":      identifier,
+		"PortfolioID": identifier,
+		"Kind":        identifier,
+		"Threshold":   holding,
+		"Observed":    holding,
+		"Symbol":      publicField,
+		"TriggeredAt": identifier,
+	},
+	"AlertObservation": {
+		"Current":    holding,
+		"Previous":   holding,
+		"WindowPeak": holding,
+		"Assets":     holding,
+	},
+	"Notification": {
+		"ID":          identifier,
+		"Event":       identifier,
+		"PortfolioID": identifier,
+		"UserID":      identifier,
+		"Subject":     holding,
+		"Body":        holding,
+		"Attributes":  holding,
+		"CreatedAt":   identifier,
+	},
+	"NotificationDeadLetter": {
+		"ID":           identifier,
+		"Sender":       identifier,
+		"Notification": holding,
+		"Error":        storageDetails,
+		"Attempts":     identifier,
+		"FailedAt":     identifier,
+	},
+	"WebhookEndpoint": {
+		"ID":          identifier,
+		"UserID":      identifier,
+		"PortfolioID": identifier,
+		"URL":         userText,
+		"Secret":      credential,
+		"Events":      identifier,
+		"Enabled":     identifier,
+		"CreatedAt":   identifier,
+	},
+	"WebhookDelivery": {
+		"ID":            identifier,
+		"EndpointID":    identifier,
+		"PortfolioID":   identifier,
+		"Event":         identifier,
+		"Payload":       holding,
+		"Status":        identifier,
+		"Attempts":      identifier,
+		"ResponseCode":  identifier,
+		"LastError":     storageDetails,
+		"NextAttemptAt": identifier,
+		"CreatedAt":     identifier,
+		"DeliveredAt":   identifier,
+	},
+	"OutboxEvent": {
+		"ID":          identifier,
+		"PortfolioID": identifier,
+		"Type":        identifier,
+		"Payload":     holding,
+		"CreatedAt":   identifier,
+		"PublishedAt": identifier,
+	},
+	"LedgerEvent": {
+		"PortfolioID": identifier,
+		"Sequence":    identifier,
+		"Type":        identifier,
+		"Data":        holding,
+		"RecordedAt":  identifier,
+	},
+	"LedgerPortfolioDetails": {
+		"UserID":      identifier,
+		"Name":        userText,
+		"Description": userText,
+	},
+	"LedgerAssetRef": {
+		"AssetID": identifier,
+	},
+	"LedgerCostBasis": {
+		"AssetID":   identifier,
+		"CostBasis": holding,
+	},
+	"LedgerState": {
+		"Portfolio":    holding,
+		"Transactions": holding,
+		"Sequence":     identifier,
+	},
+	"ExchangeBalance": {
+		"Asset":  publicField,
+		"Free":   holding,
+		"Locked": holding,
+	},
+	"ExchangeFill": {
+		"ID":              identifier,
+		"Base":            publicField,
+		"Quote":           publicField,
+		"Side":            identifier,
+		"Quantity":        holding,
+		"Price":           publicField,
+		"QuoteQuantity":   holding,
+		"Commission":      holding,
+		"CommissionAsset": publicField,
+		"ExecutedAt":      identifier,
+	},
+	"ExchangeConversion": {
+		"ID":         identifier,
+		"FromAsset":  publicField,
+		"FromAmount": holding,
+		"ToAsset":    publicField,
+		"ToAmount":   holding,
+		"Fee":        holding,
+		"ExecutedAt": identifier,
+	},
+	"ExchangeEntry": {
+		"ID":         identifier,
+		"Asset":      publicField,
+		"Type":       identifier,
+		"Amount":     holding,
+		"Fee":        holding,
+		"ExecutedAt": identifier,
+	},
+	"ExchangeActivity": {
+		"Fills":       holding,
+		"Conversions": holding,
+		"Entries":     holding,
+	},
+	"ExchangeLeg": {
+		"Ref":       identifier,
+		"Symbol":    publicField,
+		"Type":      identifier,
+		"Amount":    holding,
+		"Price":     publicField,
+		"Fee":       holding,
+		"Timestamp": identifier,
+	},
+	"ExchangeImport": {
+		"PortfolioID":          identifier,
+		"Exchange":             identifier,
+		"Fills":                identifier,
+		"Conversions":          identifier,
+		"Entries":              identifier,
+		"AssetsCreated":        identifier,
+		"TransactionsRecorded": identifier,
+		"Duplicates":           identifier,
+		"SkippedSymbols":       publicField,
+		"Cursor":               storageDetails,
+		"ImportedAt":           identifier,
+	},
+	"ExchangeAccount": {
+		"ID":           identifier,
+		"PortfolioID":  identifier,
+		"Exchange":     identifier,
+		"Credentials":  credential,
+		"Cursor":       storageDetails,
+		"LastSyncedAt": identifier,
+		"LastError":    storageDetails,
+		"NextSyncAt":   identifier,
+		"CreatedAt":    identifier,
+	},
+	"ImportRowError": {
+		"Line":    identifier,
+		"Column":  identifier,
+		"Message": userText,
+	},
+	"TransactionImport": {
+		"PortfolioID":          identifier,
+		"Rows":                 identifier,
+		"Valid":                identifier,
+		"Invalid":              identifier,
+		"Skipped":              identifier,
+		"Duplicates":           identifier,
+		"TransactionsRecorded": identifier,
+		"Committed":            identifier,
+		"Errors":               userText,
+		"ImportedAt":           identifier,
+	},
+	"ReportHolding": {
+		"Symbol":     publicField,
+		"Amount":     holding,
+		"Value":      holding,
+		"CostBasis":  holding,
+		"ProfitLoss": holding,
+		"Weight":     holding,
+	},
+	"ReportMover": {
+		"Symbol":        publicField,
+		"StartPrice":    publicField,
+		"EndPrice":      publicField,
+		"ChangePercent": publicField,
+	},
+	"PerformanceReport": {
+		"ID":               identifier,
+		"PortfolioID":      identifier,
+		"PortfolioName":    userText,
+		"Period":           identifier,
+		"PeriodStart":      identifier,
+		"PeriodEnd":        identifier,
+		"BaseCurrency":     publicField,
+		"StartValue":       holding,
+		"EndValue":         holding,
+		"PeriodProfitLoss": holding,
+		"EndProfitLoss":    holding,
+		"ReturnPercentage": holding,
+		"FeesPaid":         holding,
+		"Holdings":         holding,
+		"Allocation":       holding,
+		"TopMovers":        publicField,
+		"GeneratedAt":      identifier,
+	},
+	"GeneratedReport": {
+		"Report":      holding,
+		"ObjectKey":   storageDetails,
+		"DownloadURL": signedURL,
+		"ExpiresAt":   identifier,
+	},
+	"ReportSchedule": {
+		"ID":          identifier,
+		"PortfolioID": identifier,
+		"UserID":      identifier,
+		"Frequency":   identifier,
+		"Format":      identifier,
+		"Timezone":    identifier,
+		"Hour":        identifier,
+		"Weekday":     identifier,
+		"DayOfMonth":  identifier,
+		"NextRunAt":   identifier,
+		"LastRunAt":   identifier,
+		"LastError":   storageDetails,
+		"CreatedAt":   identifier,
+	},
+	"TrackedWallet": {
+		"ID":           identifier,
+		"PortfolioID":  identifier,
+		"UserID":       identifier,
+		"Chain":        identifier,
+		"Address":      walletAddress,
+		"Cursor":       storageDetails,
+		"LastSyncedAt": identifier,
+		"LastError":    storageDetails,
+		"NextSyncAt":   identifier,
+		"CreatedAt":    identifier,
+	},
+	"StakingPosition": {
+		"ID":            identifier,
+		"PortfolioID":   identifier,
+		"AssetID":       identifier,
+		"Source":        identifier,
+		"Provider":      identifier,
+		"Address":       walletAddress,
+		"Cursor":        storageDetails,
+		"LastAccruedAt": identifier,
+		"LastError":     storageDetails,
+		"NextAccrualAt": identifier,
+		"CreatedAt":     identifier,
+	},
+	"CorporateAction": {
+		"PortfolioID":   identifier,
+		"Type":          identifier,
+		"AssetType":     identifier,
+		"Symbol":        publicField,
+		"Amount":        holding,
+		"Price":         publicField,
+		"ParentAssetID": identifier,
+		"ParentPrice":   publicField,
+		"Timestamp":     identifier,
+	},
+	"SymbolMigration": {
+		"ID":             identifier,
+		"FromSymbol":     publicField,
+		"ToSymbol":       publicField,
+		"Ratio":          publicField,
+		"EffectiveAt":    publicField,
+		"AssetsMigrated": identifier,
+		"AppliedAt":      identifier,
+		"CreatedAt":      identifier,
+	},
+	"SymbolMigrationAudit": {
+		"MigrationID":          identifier,
+		"PortfolioID":          identifier,
+		"AssetID":              identifier,
+		"FromSymbol":           publicField,
+		"ToSymbol":             publicField,
+		"Ratio":                publicField,
+		"AmountBefore":         holding,
+		"AmountAfter":          holding,
+		"TransactionsRelinked": identifier,
+		"LotsRelinked":         identifier,
+		"MigratedAt":           identifier,
+	},
+	"LedgerAssetMigration": {
+		"MigrationID": identifier,
+		"AssetID":     identifier,
+		"FromSymbol":  publicField,
+		"ToSymbol":    publicField,
+		"Ratio":       publicField,
+	},
+	"Lot": {
+		"AssetID":            identifier,
+		"TransactionID":      identifier,
+		"AcquiredAt":         identifier,
+		"Quantity":           holding,
+		"CostBasis":          holding,
+		"WashSaleAdjustment": holding,
+	},
+	"LotDisposal": {
+		"TransactionID": identifier,
+		"Type":          identifier,
+		"DisposedAt":    identifier,
+		"Quantity":      holding,
+		"CostBasis":     holding,
+		"Proceeds":      holding,
+		"Lots":          holding,
+		"Disallowed":    holding,
+		"Replacements":  identifier,
+	},
+	"LotReplay": {
+		"Lots":        holding,
+		"Disposals":   holding,
+		"Quantity":    holding,
+		"CostBasis":   holding,
+		"RealizedPnL": holding,
+		"Shortfall":   holding,
+	},
+	"LotRecomputation": {
+		"AssetID":     identifier,
+		"Amount":      holding,
+		"CostBasis":   holding,
+		"RealizedPnL": holding,
+		"Lots":        holding,
+		"Disposals":   holding,
+	},
+	"TransferCandidate": {
+		"Transaction": holding,
+		"Symbol":      publicField,
+	},
+	"TransferLink": {
+		"ID":               identifier,
+		"UserID":           identifier,
+		"OutTransactionID": identifier,
+		"InTransactionID":  identifier,
+		"OutPortfolioID":   identifier,
+		"InPortfolioID":    identifier,
+		"Symbol":           publicField,
+		"Amount":           holding,
+		"CostBasis":        holding,
+		"InPriceBefore":    holding,
+		"MatchedBy":        identifier,
+		"CreatedAt":        identifier,
+	},
+	"RealizedGain": {
+		"ID":            identifier,
+		"PortfolioID":   identifier,
+		"AssetID":       identifier,
+		"TransactionID": identifier,
+		"Symbol":        publicField,
+		"Quantity":      holding,
+		"CostBasis":     holding,
+		"Proceeds":      holding,
+		"Gain":          holding,
+		"DisposedAt":    identifier,
+		"RecordedAt":    identifier,
+	},
+	"IncomeLine": {
+		"AssetID":  identifier,
+		"Symbol":   publicField,
+		"Type":     identifier,
+		"Amount":   holding,
+		"Value":    holding,
+		"Receipts": holding,
+		"Unvalued": holding,
+	},
+	"IncomePeriod": {
+		"PeriodStart": identifier,
+		"PeriodEnd":   identifier,
+		"Lines":       holding,
+		"Value":       holding,
+	},
+	"IncomeReport": {
+		"PortfolioID":  identifier,
+		"Period":       identifier,
+		"Start":        identifier,
+		"End":          identifier,
+		"BaseCurrency": publicField,
+		"Periods":      holding,
+		"Totals":       holding,
+		"Value":        holding,
+		"GeneratedAt":  identifier,
+	},
+	"HarvestCandidate": {
+		"AssetID":            identifier,
+		"Symbol":             publicField,
+		"Quantity":           holding,
+		"Lots":               holding,
+		"CostBasis":          holding,
+		"Proceeds":           holding,
+		"Loss":               holding,
+		"LossPercentage":     holding,
+		"EstimatedTaxSaving": holding,
+		"WashSaleConflicts":  identifier,
+		"RepurchaseAfter":    identifier,
+	},
+	"TermSplit": {
+		"ShortTerm": holding,
+		"LongTerm":  holding,
+	},
+	"HeldLot": {
+		"TransactionID":      identifier,
+		"AcquiredAt":         identifier,
+		"Quantity":           holding,
+		"CostBasis":          holding,
+		"Term":               identifier,
+		"WashSaleAdjustment": holding,
+		"LongTermAt":         identifier,
+		"Value":              holding,
+		"Unpriced":           identifier,
+	},
+	"AssetHoldingPeriods": {
+		"AssetID":    identifier,
+		"Symbol":     publicField,
+		"Lots":       holding,
+		"Realized":   holding,
+		"Unrealized": holding,
+	},
+	"HoldingPeriodReport": {
+		"PortfolioID": identifier,
+		"LongTerm":    publicField,
+		"Start":       identifier,
+		"End":         identifier,
+		"Assets":      holding,
+		"Realized":    holding,
+		"Unrealized":  holding,
+		"WashSales":   holding,
+		"GeneratedAt": identifier,
+	},
+	"WashSale": {
+		"AssetID":       identifier,
+		"Symbol":        publicField,
+		"TransactionID": identifier,
+		"DisposedAt":    identifier,
+		"Loss":          holding,
+		"Disallowed":    holding,
+		"Replacements":  identifier,
+	},
+	"TaxYear": {
+		"StartMonth": publicField,
+		"StartDay":   publicField,
+	},
+	"TaxSettings": {
+		"UserID":       identifier,
+		"Country":      publicField,
+		"TaxYear":      publicField,
+		"LongTermDays": publicField,
+		"WashSales":    publicField,
+		"UpdatedAt":    identifier,
+	},
+	"AssetComparison": {
+		"AssetID":                identifier,
+		"Symbol":                 publicField,
+		"Change":                 identifier,
+		"StartAmount":            holding,
+		"EndAmount":              holding,
+		"AmountDelta":            holding,
+		"StartValue":             holding,
+		"EndValue":               holding,
+		"ValueDelta":             holding,
+		"ProfitLossContribution": holding,
+		"ContributionPercentage": holding,
+	},
+	"PortfolioComparison": {
+		"PortfolioID":     identifier,
+		"From":            identifier,
+		"To":              identifier,
+		"StartCapturedAt": identifier,
+		"EndCapturedAt":   identifier,
+		"StartValue":      holding,
+		"EndValue":        holding,
+		"ValueDelta":      holding,
+		"ProfitLossDelta": holding,
+		"Assets":          holding,
+		"GeneratedAt":     identifier,
+	},
+	"SyncEvent": {
+		"ID":          identifier,
+		"PortfolioID": identifier,
+		"Source":      identifier,
+		"Name":        identifier,
+		"Imported":    holding,
+		"Error":       storageDetails,
+		"SyncedAt":    identifier,
+	},
+	"ActivityItem": {
+		"Kind":        identifier,
+		"ID":          identifier,
+		"OccurredAt":  identifier,
+		"AssetID":     identifier,
+		"Symbol":      publicField,
+		"Transaction": holding,
+		"Alert":       holding,
+		"Sync":        holding,
+	},
+	"UserStats": {
+		"UserID":             identifier,
+		"Portfolios":         identifier,
+		"ArchivedPortfolios": identifier,
+		"Assets":             identifier,
+		"Transactions":       identifier,
+		"Value":              holding,
+		"GeneratedAt":        identifier,
+	},
+	"PlatformStats": {
+		"Users":                 publicField,
+		"Portfolios":            publicField,
+		"Assets":                publicField,
+		"Transactions":          publicField,
+		"AssetsUnderManagement": publicField,
+		"BaseCurrency":          publicField,
+		"GeneratedAt":           publicField,
+	},
+}
+
+// FieldClassification returns the policy of a model field
+func FieldClassification(model, field string) (FieldPolicy, bool) {
+	policy, ok := DATA_CLASSIFICATION[model][field]
+	return policy, ok
+}
+
+// Loggable reports whether a model field may appear in log output.
+// Unclassified fields are never loggable.
+func Loggable(model, field string) bool {
+	policy, ok := FieldClassification(model, field)
+	return ok && policy.Logged
+}
+
+// Exportable reports whether a model field may be written to file exports.
+// Unclassified fields are never exportable.
+func Exportable(model, field string) bool {
+	policy, ok := FieldClassification(model, field)
+	return ok && policy.Exported
+}
+
+// Validate checks that the policy is permitted for its classification
+func (p FieldPolicy) Validate() error {
+	switch p.Class {
+	case ClassPublic, ClassInternal:
+		return nil
+	case ClassSensitive:
+		if !p.Encrypted && (p.Logged || p.Exported) {
+			return fmt.Errorf("sensitive field must be encrypted at rest or excluded from logs and exports")
+		}
+		return nil
+	case ClassSecret:
+		if !p.Encrypted || p.Logged || p.Exported {
+			return fmt.Errorf("secret field must be encrypted at rest and excluded from logs and exports")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown classification %q", p.Class)
+	}
+}
+
+// ValidateClassifications checks that every exported field of CLASSIFIED_MODELS
+// is classified, that the registry has no stale entries, and that every policy
+// is permitted for its classification
+func ValidateClassifications() error {
+	var violations []string
+
+	covered := make(map[string]bool, len(CLASSIFIED_MODELS))
+	for _, t := range CLASSIFIED_MODELS {
+		covered[t.Name()] = true
+		fields := DATA_CLASSIFICATION[t.Name()]
+
+		exported := make(map[string]bool, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			exported[f.Name] = true
+
+			policy, ok := fields[f.Name]
+			if !ok {
+				violations = append(violations, fmt.Sprintf("%s.%s is not classified", t.Name(), f.Name))
+				continue
+			}
+			if err := policy.Validate(); err != nil {
+				violations = append(violations, fmt.Sprintf("%s.%s: %v", t.Name(), f.Name, err))
+			}
+		}
+
+		for name := range fields {
+			if !exported[name] {
+				violations = append(violations, fmt.Sprintf("%s.%s is classified but does not exist", t.Name(), name))
+			}
+		}
+	}
+
+	for model := range DATA_CLASSIFICATION {
+		if !covered[model] {
+			violations = append(violations, fmt.Sprintf("%s is classified but not listed in CLASSIFIED_MODELS", model))
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	sort.Strings(violations)
+	return fmt.Errorf("data classification violations:\n%s", strings.Join(violations, "\n"))
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// ErrInvalidComparison is returned for comparison windows that cannot be
+// compared
+var ErrInvalidComparison = errors.New("invalid portfolio comparison")
+
+// AssetChange is how a holding changed between two valuations
+type AssetChange string
+
+const (
+	// AssetChangeAdded is a holding held at the end of the window only
+	AssetChangeAdded AssetChange = "added"
+	// AssetChangeRemoved is a holding held at the start of the window only
+	AssetChangeRemoved AssetChange = "removed"
+	// AssetChangeHeld is a holding held at both ends of the window
+	AssetChangeHeld AssetChange = "held"
+)
+
+// AssetComparison is the change of one holding between two valuations
+type AssetComparison struct {
+	AssetID     uuid.UUID       `json:"asset_id"`
+	Symbol      string          `json:"symbol"`
+	Change      AssetChange     `json:"change"`
+	StartAmount decimal.Decimal `json:"start_amount"`
+	EndAmount   decimal.Decimal `json:"end_amount"`
+	AmountDelta decimal.Decimal `json:"amount_delta"`
+	StartValue  decimal.Decimal `json:"start_value"`
+	EndValue    decimal.Decimal `json:"end_value"`
+	ValueDelta  decimal.Decimal `json:"value_delta"`
+	// ProfitLossContribution is the change in the holding's unrealized
+	// profit and loss, which the portfolio's change is the sum of
+	ProfitLossContribution decimal.Decimal `json:"profit_loss_contribution"`
+	// ContributionPercentage is ProfitLossContribution relative to the size
+	// of the portfolio's change, rounded to two decimals; zero when the
+	// portfolio's profit and loss did not change
+	ContributionPercentage decimal.Decimal `json:"contribution_percentage"`
+}
+
+// PortfolioComparison is what changed in a portfolio between the latest
+// valuations at or before two times
+type PortfolioComparison struct {
+	PortfolioID uuid.UUID `json:"portfolio_id"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	// StartCapturedAt and EndCapturedAt are when the compared valuations
+	// were taken; StartCapturedAt is zero when the portfolio had none by
+	// From
+	StartCapturedAt time.Time         `json:"start_captured_at"`
+	EndCapturedAt   time.Time         `json:"end_captured_at"`
+	StartValue      decimal.Decimal   `json:"start_value"`
+	EndValue        decimal.Decimal   `json:"end_value"`
+	ValueDelta      decimal.Decimal   `json:"value_delta"`
+	ProfitLossDelta decimal.Decimal   `json:"profit_loss_delta"`
+	Assets          []AssetComparison `json:"assets"`
+	GeneratedAt     time.Time         `json:"generated_at"`
+}
+
+// ComparePortfolioSnapshots compares the valuation start, taken by from,
+// with end, taken by to. start is nil when the portfolio has no valuation
+// by from, in which case it is treated as empty. Holdings are matched by
+// asset and ordered by the size of their contribution, largest first.
+func ComparePortfolioSnapshots(portfolioID uuid.UUID, from, to time.Time, start, end *PortfolioSnapshot, at time.Time) (*PortfolioComparison, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidComparison)
+	}
+	if end == nil {
+		return nil, fmt.Errorf("%w: no valuation at the end of the window", ErrInvalidComparison)
+	}
+	if start == nil {
+		start = &PortfolioSnapshot{}
+	}
+
+	comparison := &PortfolioComparison{
+		PortfolioID:     portfolioID,
+		From:            from,
+		To:              to,
+		StartCapturedAt: start.CapturedAt,
+		EndCapturedAt:   end.CapturedAt,
+		StartValue:      start.TotalValue,
+		EndValue:        end.TotalValue,
+		ValueDelta:      end.TotalValue.Sub(start.TotalValue),
+		ProfitLossDelta: end.ProfitLoss.Sub(start.ProfitLoss),
+		Assets:          []AssetComparison{},
+		GeneratedAt:     at,
+	}
+
+	byAsset := make(map[uuid.UUID]*AssetComparison)
+	holding := func(asset AssetSnapshot) *AssetComparison {
+		c, ok := byAsset[asset.AssetID]
+		if !ok {
+			c = &AssetComparison{
+				AssetID:                asset.AssetID,
+				StartAmount:            decimal.Zero,
+				EndAmount:              decimal.Zero,
+				StartValue:             decimal.Zero,
+				EndValue:               decimal.Zero,
+				ProfitLossContribution: decimal.Zero,
+			}
+			byAsset[asset.AssetID] = c
+		}
+		c.Symbol = asset.Symbol
+		return c
+	}
+	for _, asset := range start.Assets {
+		c := holding(asset)
+		c.Change = AssetChangeRemoved
+		c.StartAmount = asset.Amount
+		c.StartValue = asset.Value
+		c.ProfitLossContribution = c.ProfitLossContribution.Sub(asset.Value.Sub(asset.CostBasis))
+	}
+	for _, asset := range end.Assets {
+		c := holding(asset)
+		if c.Change == AssetChangeRemoved {
+			c.Change = AssetChangeHeld
+		} else {
+			c.Change = AssetChangeAdded
+		}
+		c.EndAmount = asset.Amount
+		c.EndValue = asset.Value
+		c.ProfitLossContribution = c.ProfitLossContribution.Add(asset.Value.Sub(asset.CostBasis))
+	}
+
+	scale := comparison.ProfitLossDelta.Abs()
+	for _, c := range byAsset {
+		c.AmountDelta = c.EndAmount.Sub(c.StartAmount)
+		c.ValueDelta = c.EndValue.Sub(c.StartValue)
+		c.ContributionPercentage = decimal.Zero
+		if scale.IsPositive() {
+			c.ContributionPercentage = c.ProfitLossContribution.Div(scale).Mul(decimal.NewFromInt(100)).Round(2)
+		}
+		comparison.Assets = append(comparison.Assets, *c)
+	}
+	sort.SliceStable(comparison.Assets, func(i, j int) bool {
+		a, b := comparison.Assets[i], comparison.Assets[j]
+		if c := a.ProfitLossContribution.Abs().Cmp(b.ProfitLossContribution.Abs()); c != 0 {
+			return c > 0
+		}
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		return a.AssetID.String() < b.AssetID.String()
+	})
+	return comparison, nil
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+var (
+	// SUPPORTED_CORPORATE_ACTIONS are the events crediting a new position
+	// without a purchase; each is recorded as a transaction of its type
+	SUPPORTED_CORPORATE_ACTIONS = []string{"airdrop", "fork"}
+
+	// ErrInvalidCorporateAction is returned for actions that cannot be applied
+	ErrInvalidCorporateAction = errors.New("invalid corporate action")
+)
+
+// CorporateAction is an airdrop or chain fork crediting Amount of Symbol to a
+// portfolio. Price is the market price of the credited asset when received.
+// A fork splits off from the parent asset held at the fork, which is valued
+// at ParentPrice then.
+type CorporateAction struct {
+	PortfolioID   uuid.UUID       `json:"portfolio_id"`
+	Type          string          `json:"type"`
+	AssetType     string          `json:"asset_type"`
+	Symbol        string          `json:"symbol"`
+	Amount        decimal.Decimal `json:"amount"`
+	Price         decimal.Decimal `json:"price"`
+	ParentAssetID uuid.UUID       `json:"parent_asset_id,omitempty"`
+	ParentPrice   decimal.Decimal `json:"parent_price"`
+	Timestamp     time.Time       `json:"timestamp"`
+}
+
+// Validate checks the action, defaulting the asset type of airdrops to
+// token and of forks to cryptocurrency
+func (a *CorporateAction) Validate() error {
+	switch a.Type {
+	case "airdrop":
+		if a.ParentAssetID != uuid.Nil {
+			return fmt.Errorf("%w: airdrops have no parent asset", ErrInvalidCorporateAction)
+		}
+		if a.AssetType == "" {
+			a.AssetType = "token"
+		}
+	case "fork":
+		if a.ParentAssetID == uuid.Nil {
+			return fmt.Errorf("%w: forks need the parent asset", ErrInvalidCorporateAction)
+		}
+		if a.AssetType == "" {
+			a.AssetType = "cryptocurrency"
+		}
+	default:
+		return fmt.Errorf("%w: type %q", ErrInvalidCorporateAction, a.Type)
+	}
+
+	if err := ValidateAssetType(a.AssetType); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCorporateAction, err)
+	}
+	if a.AssetType == "staked_asset" {
+		return fmt.Errorf("%w: credited positions cannot be staked assets", ErrInvalidCorporateAction)
+	}
+	a.Symbol = strings.ToUpper(strings.TrimSpace(a.Symbol))
+	if a.Symbol == "" {
+		return fmt.Errorf("%w: symbol is required", ErrInvalidCorporateAction)
+	}
+	if !a.Amount.IsPositive() {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidCorporateAction)
+	}
+	if err := ValidateAmountPrecision(a.AssetType, a.Symbol, a.Amount); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCorporateAction, err)
+	}
+	if a.Price.IsNegative() || a.ParentPrice.IsNegative() {
+		return fmt.Errorf("%w: prices cannot be negative", ErrInvalidCorporateAction)
+	}
+	return nil
+}
+
+// CorporateActionCostBasis returns the cost basis of the position credited
+// by a under policy, and the cost basis the parent keeps for forks. Under
+// the allocated policy an airdrop costs its market value, as it is taxed
+// as income when received, and a fork takes the share of its parent's cost
+// basis matching its part of their combined market value; nothing is
+// allocated when neither has a price.
+func CorporateActionCostBasis(a CorporateAction, policy string, parent *Asset) (cost, parentCost decimal.Decimal, err error) {
+	if parent != nil {
+		parentCost = parent.CostBasis
+	}
+
+	switch policy {
+	case "zero":
+		return decimal.Zero, parentCost, nil
+	case "allocated":
+	default:
+		return decimal.Zero, parentCost, fmt.Errorf("%w: cost basis policy %q", ErrInvalidCorporateAction, policy)
+	}
+
+	value := a.Amount.Mul(a.Price)
+	if a.Type != "fork" {
+		return value, parentCost, nil
+	}
+	if parent == nil {
+		return decimal.Zero, parentCost, fmt.Errorf("%w: forks need the parent asset", ErrInvalidCorporateAction)
+	}
+
+	total := value.Add(parent.Amount.Mul(a.ParentPrice))
+	if !total.IsPositive() {
+		return decimal.Zero, parentCost, nil
+	}
+	cost = parent.CostBasis.Mul(value).Div(total).Round(8)
+	return cost, parent.CostBasis.Sub(cost), nil
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// CorrelationMatrix holds pairwise daily return correlations of a portfolio's
+// assets, where Values[i][j] correlates Symbols[i] with Symbols[j]
+type CorrelationMatrix struct {
+	PortfolioID      uuid.UUID   `json:"portfolio_id"`
+	Symbols          []string    `json:"symbols"`
+	Values           [][]float64 `json:"values"`
+	Observations     int         `json:"observations"`
+	WindowStart      time.Time   `json:"window_start"`
+	WindowEnd        time.Time   `json:"window_end"`
+	UncoveredSymbols []string    `json:"uncovered_symbols"`
+}
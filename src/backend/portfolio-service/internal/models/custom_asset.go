@@ -0,0 +1,84 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// CUSTOM_ASSET_TYPE is the asset type of holdings of custom assets. It is
+// not one of SUPPORTED_ASSET_TYPES: custom holdings are validated against
+// the portfolio's custom asset definitions instead.
+const CUSTOM_ASSET_TYPE = "custom"
+
+var (
+	// MAX_CUSTOM_ASSET_DECIMALS limits the fractional digits of custom asset
+	// amounts
+	MAX_CUSTOM_ASSET_DECIMALS int32 = 18
+
+	// MAX_CUSTOM_ASSET_NAME_LENGTH limits the length of a custom asset name in
+	// characters
+	MAX_CUSTOM_ASSET_NAME_LENGTH = 100
+
+	// ErrInvalidCustomAsset is returned for malformed custom assets and for
+	// holdings that do not match their definition
+	ErrInvalidCustomAsset = errors.New("invalid custom asset")
+
+	// customAssetSymbol matches the symbols portfolio assets may be stored with
+	customAssetSymbol = regexp.MustCompile(`^[A-Z0-9]{2,10}$`)
+)
+
+// CustomAsset is an asset a user defined for a portfolio because no market
+// data provider lists it, such as a small-cap token or a private holding.
+// Holdings of it have the custom asset type, amounts with at most Decimals
+// fractional digits, and are valued at manual prices.
+type CustomAsset struct {
+	ID          uuid.UUID `json:"id"`
+	PortfolioID uuid.UUID `json:"portfolio_id"`
+	Symbol      string    `json:"symbol"`
+	Name        string    `json:"name"`
+	Decimals    int32     `json:"decimals"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Validate normalizes the symbol and name and checks the definition
+func (a *CustomAsset) Validate() error {
+	a.Symbol = strings.ToUpper(strings.TrimSpace(a.Symbol))
+	a.Name = strings.TrimSpace(a.Name)
+	if !customAssetSymbol.MatchString(a.Symbol) {
+		return InvalidField("symbol", fmt.Errorf("%w: symbol must be 2 to 10 letters or digits", ErrInvalidCustomAsset))
+	}
+	if a.Name == "" {
+		return InvalidField("name", fmt.Errorf("%w: name is required", ErrInvalidCustomAsset))
+	}
+	if len([]rune(a.Name)) > MAX_CUSTOM_ASSET_NAME_LENGTH {
+		return InvalidField("name", fmt.Errorf("%w: name exceeds %d characters", ErrInvalidCustomAsset, MAX_CUSTOM_ASSET_NAME_LENGTH))
+	}
+	if a.Decimals < 0 || a.Decimals > MAX_CUSTOM_ASSET_DECIMALS {
+		return InvalidField("decimals", fmt.Errorf("%w: decimals must be between 0 and %d", ErrInvalidCustomAsset, MAX_CUSTOM_ASSET_DECIMALS))
+	}
+	return nil
+}
+
+// ValidateAmount checks that amount carries no more fractional digits than
+// the custom asset allows
+func (a CustomAsset) ValidateAmount(amount decimal.Decimal) error {
+	if !amount.Equal(amount.Truncate(a.Decimals)) {
+		return fmt.Errorf("%w: %s amounts have at most %d decimals", ErrInvalidCustomAsset, a.Symbol, a.Decimals)
+	}
+	return nil
+}
+
+// ValidateHoldingType checks that an asset type may be held in a portfolio:
+// one of SUPPORTED_ASSET_TYPES or the custom asset type
+func ValidateHoldingType(assetType string) error {
+	if assetType == CUSTOM_ASSET_TYPE {
+		return nil
+	}
+	return ValidateAssetType(assetType)
+}
@@ -0,0 +1,188 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// DCAFrequency is the interval between dollar-cost averaging contributions
+type DCAFrequency string
+
+const (
+	DCAWeekly   DCAFrequency = "weekly"
+	DCABiweekly DCAFrequency = "biweekly"
+	DCAMonthly  DCAFrequency = "monthly"
+)
+
+var (
+	// MAX_DCA_PERIODS limits the length of a dollar-cost averaging schedule
+	MAX_DCA_PERIODS = 520
+
+	// ErrInvalidDCAPlan is returned for malformed dollar-cost averaging plans
+	ErrInvalidDCAPlan = errors.New("invalid DCA plan")
+)
+
+// DCAPurchase is the amount of base currency to spend on one symbol
+type DCAPurchase struct {
+	Symbol string          `json:"symbol"`
+	Amount decimal.Decimal `json:"amount"`
+}
+
+// DCAInstallment is one scheduled contribution of a plan and the allocation
+// projected after it is executed
+type DCAInstallment struct {
+	Sequence            int               `json:"sequence"`
+	ScheduledAt         time.Time         `json:"scheduled_at"`
+	Purchases           []DCAPurchase     `json:"purchases"`
+	ProjectedAllocation []AllocationSlice `json:"projected_allocation"`
+	ExecutedAt          *time.Time        `json:"executed_at,omitempty"`
+}
+
+// DCAPlan is a persisted dollar-cost averaging schedule toward target weights
+type DCAPlan struct {
+	ID           uuid.UUID          `json:"id"`
+	PortfolioID  uuid.UUID          `json:"portfolio_id"`
+	Contribution decimal.Decimal    `json:"contribution"`
+	Frequency    DCAFrequency       `json:"frequency"`
+	Targets      []AllocationTarget `json:"targets"`
+	Installments []DCAInstallment   `json:"installments"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+// At returns the date of the n-th contribution after start. Months are added
+// from start rather than chained so month-end schedules do not drift.
+func (f DCAFrequency) At(start time.Time, n int) time.Time {
+	switch f {
+	case DCAWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case DCABiweekly:
+		return start.AddDate(0, 0, 14*n)
+	default:
+		return start.AddDate(0, n, 0)
+	}
+}
+
+// Valid reports whether f is a supported frequency
+func (f DCAFrequency) Valid() bool {
+	return f == DCAWeekly || f == DCABiweekly || f == DCAMonthly
+}
+
+// BuildDCASchedule splits each contribution across the target symbols so the
+// portfolio converges on its targets: underweight symbols are topped up first
+// and any remainder is spread by target weight. holdings maps symbols to their
+// current value; projections assume prices stay constant. Targets must be
+// normalized symbol targets summing to 100 percent.
+func BuildDCASchedule(holdings map[string]decimal.Decimal, contribution decimal.Decimal, targets []AllocationTarget, frequency DCAFrequency, start time.Time, periods int) ([]DCAInstallment, error) {
+	if !contribution.IsPositive() {
+		return nil, fmt.Errorf("%w: contribution must be positive", ErrInvalidDCAPlan)
+	}
+	if !frequency.Valid() {
+		return nil, fmt.Errorf("%w: unknown frequency %q", ErrInvalidDCAPlan, frequency)
+	}
+	if periods <= 0 || periods > MAX_DCA_PERIODS {
+		return nil, fmt.Errorf("%w: periods must be within [1, %d]", ErrInvalidDCAPlan, MAX_DCA_PERIODS)
+	}
+
+	hundred := decimal.NewFromInt(100)
+	total := decimal.Zero
+	for _, target := range targets {
+		if target.Dimension != TargetBySymbol {
+			return nil, fmt.Errorf("%w: only symbol targets are supported", ErrInvalidDCAPlan)
+		}
+		total = total.Add(target.Percentage)
+	}
+	if !total.Equal(hundred) {
+		return nil, fmt.Errorf("%w: symbol targets must sum to 100 percent", ErrInvalidDCAPlan)
+	}
+
+	values := make(map[string]decimal.Decimal, len(holdings)+len(targets))
+	for symbol, value := range holdings {
+		if value.IsPositive() {
+			values[symbol] = value
+		}
+	}
+
+	installments := make([]DCAInstallment, 0, periods)
+	for seq := 1; seq <= periods; seq++ {
+		purchases := allocateContribution(values, contribution, targets)
+
+		portfolioValue := decimal.Zero
+		for _, purchase := range purchases {
+			values[purchase.Symbol] = values[purchase.Symbol].Add(purchase.Amount)
+		}
+		for _, value := range values {
+			portfolioValue = portfolioValue.Add(value)
+		}
+
+		installments = append(installments, DCAInstallment{
+			Sequence:            seq,
+			ScheduledAt:         frequency.At(start.UTC(), seq-1),
+			Purchases:           purchases,
+			ProjectedAllocation: allocationSlices(values, portfolioValue),
+		})
+	}
+
+	return installments, nil
+}
+
+// allocateContribution splits one contribution across targets, rounded to
+// cents, with rounding differences assigned to the largest purchase
+func allocateContribution(values map[string]decimal.Decimal, contribution decimal.Decimal, targets []AllocationTarget) []DCAPurchase {
+	hundred := decimal.NewFromInt(100)
+
+	after := contribution
+	for _, value := range values {
+		after = after.Add(value)
+	}
+
+	deficits := make(map[string]decimal.Decimal, len(targets))
+	totalDeficit := decimal.Zero
+	for _, target := range targets {
+		wanted := after.Mul(target.Percentage).Div(hundred)
+		if deficit := wanted.Sub(values[target.Key]); deficit.IsPositive() {
+			deficits[target.Key] = deficit
+			totalDeficit = totalDeficit.Add(deficit)
+		}
+	}
+
+	amounts := make(map[string]decimal.Decimal, len(targets))
+	if totalDeficit.GreaterThanOrEqual(contribution) {
+		// Not enough to close every gap; share by size of the gap
+		for symbol, deficit := range deficits {
+			amounts[symbol] = contribution.Mul(deficit).Div(totalDeficit)
+		}
+	} else {
+		// Close every gap and spread the rest by target weight
+		remainder := contribution.Sub(totalDeficit)
+		for _, target := range targets {
+			amounts[target.Key] = deficits[target.Key].Add(remainder.Mul(target.Percentage).Div(hundred))
+		}
+	}
+
+	purchases := make([]DCAPurchase, 0, len(amounts))
+	spent := decimal.Zero
+	for symbol, amount := range amounts {
+		amount = amount.Round(2)
+		if !amount.IsPositive() {
+			continue
+		}
+		purchases = append(purchases, DCAPurchase{Symbol: symbol, Amount: amount})
+		spent = spent.Add(amount)
+	}
+
+	sort.Slice(purchases, func(i, j int) bool {
+		if c := purchases[i].Amount.Cmp(purchases[j].Amount); c != 0 {
+			return c > 0
+		}
+		return purchases[i].Symbol < purchases[j].Symbol
+	})
+	if len(purchases) > 0 {
+		purchases[0].Amount = purchases[0].Amount.Add(contribution.Round(2).Sub(spent))
+	}
+	return purchases
+}
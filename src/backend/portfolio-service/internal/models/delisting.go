@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// Policies valuing the assets of delisted symbols
+const (
+	// DelistedPriceLast freezes delisted assets at their last known price
+	DelistedPriceLast = "last_price"
+	// DelistedPriceZero values delisted assets at zero
+	DelistedPriceZero = "zero"
+)
+
+// DELISTED_PRICE_POLICIES lists the supported delisted asset valuation policies
+var DELISTED_PRICE_POLICIES = []string{DelistedPriceLast, DelistedPriceZero}
+
+// DelistedSymbol is a held symbol no market data provider has quoted for too
+// long, such as a token removed from every exchange. Its assets are valued by
+// policy instead of being left out of valuations, until a provider quotes the
+// symbol again.
+type DelistedSymbol struct {
+	Symbol       string          `json:"symbol"`
+	LastPrice    decimal.Decimal `json:"last_price"`
+	LastQuotedAt time.Time       `json:"last_quoted_at"`
+	DelistedAt   time.Time       `json:"delisted_at"`
+}
+
+// Price returns the price valuing assets of the symbol under policy
+func (d DelistedSymbol) Price(policy string) decimal.Decimal {
+	if policy == DelistedPriceZero {
+		return decimal.Zero
+	}
+	return d.LastPrice
+}
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// TargetDimension identifies the allocation breakdown a target applies to
+type TargetDimension string
+
+const (
+	TargetBySymbol   TargetDimension = "symbol"
+	TargetByCategory TargetDimension = "category"
+)
+
+var (
+	// DEFAULT_DRIFT_THRESHOLD is the deviation in percentage points beyond
+	// which an allocation is considered to have drifted from its target
+	DEFAULT_DRIFT_THRESHOLD = decimal.NewFromInt(5)
+
+	// MAX_ALLOCATION_TARGETS limits the number of targets per portfolio
+	MAX_ALLOCATION_TARGETS = 100
+
+	// ErrInvalidTarget is returned for malformed allocation targets
+	ErrInvalidTarget = errors.New("invalid allocation target")
+)
+
+// AllocationTarget is the desired share of portfolio value for one symbol or category
+type AllocationTarget struct {
+	Dimension  TargetDimension `json:"dimension"`
+	Key        string          `json:"key"`
+	Percentage decimal.Decimal `json:"percentage"`
+}
+
+// DriftEntry compares the actual weight of one symbol or category to its target
+type DriftEntry struct {
+	Dimension TargetDimension `json:"dimension"`
+	Key       string          `json:"key"`
+	Target    decimal.Decimal `json:"target"`
+	Actual    decimal.Decimal `json:"actual"`
+	Drift     decimal.Decimal `json:"drift"`
+	Breached  bool            `json:"breached"`
+}
+
+// Drift reports how far a portfolio's allocation deviates from its targets
+type Drift struct {
+	PortfolioID  uuid.UUID       `json:"portfolio_id"`
+	Threshold    decimal.Decimal `json:"threshold"`
+	Entries      []DriftEntry    `json:"entries"`
+	Breached     bool            `json:"breached"`
+	CalculatedAt time.Time       `json:"calculated_at"`
+}
+
+// NormalizeTargets validates allocation targets and returns them with symbols
+// upper-cased and categories lower-cased. Targets of each dimension may not
+// exceed 100 percent in total.
+func NormalizeTargets(targets []AllocationTarget) ([]AllocationTarget, error) {
+	if len(targets) > MAX_ALLOCATION_TARGETS {
+		return nil, fmt.Errorf("%w: at most %d targets are allowed", ErrInvalidTarget, MAX_ALLOCATION_TARGETS)
+	}
+
+	hundred := decimal.NewFromInt(100)
+	totals := make(map[TargetDimension]decimal.Decimal)
+	seen := make(map[TargetDimension]map[string]bool)
+	normalized := make([]AllocationTarget, 0, len(targets))
+
+	for _, target := range targets {
+		key := strings.TrimSpace(target.Key)
+		switch target.Dimension {
+		case TargetBySymbol:
+			key = strings.ToUpper(key)
+		case TargetByCategory:
+			key = strings.ToLower(key)
+		default:
+			return nil, fmt.Errorf("%w: unknown dimension %q", ErrInvalidTarget, target.Dimension)
+		}
+		if key == "" {
+			return nil, fmt.Errorf("%w: empty %s key", ErrInvalidTarget, target.Dimension)
+		}
+		if !target.Percentage.IsPositive() || target.Percentage.GreaterThan(hundred) {
+			return nil, fmt.Errorf("%w: percentage for %s must be in (0, 100]", ErrInvalidTarget, key)
+		}
+
+		if seen[target.Dimension] == nil {
+			seen[target.Dimension] = make(map[string]bool)
+		}
+		if seen[target.Dimension][key] {
+			return nil, fmt.Errorf("%w: duplicate %s target %s", ErrInvalidTarget, target.Dimension, key)
+		}
+		seen[target.Dimension][key] = true
+
+		totals[target.Dimension] = totals[target.Dimension].Add(target.Percentage)
+		if totals[target.Dimension].GreaterThan(hundred) {
+			return nil, fmt.Errorf("%w: %s targets exceed 100 percent", ErrInvalidTarget, target.Dimension)
+		}
+
+		normalized = append(normalized, AllocationTarget{
+			Dimension:  target.Dimension,
+			Key:        key,
+			Percentage: target.Percentage,
+		})
+	}
+
+	return normalized, nil
+}
+
+// ComputeDrift compares actual allocation weights to targets. Every dimension
+// with at least one target is compared in full, so holdings without a target
+// are treated as a target of zero. Entries are ordered by absolute drift,
+// largest first.
+func ComputeDrift(allocation *Allocation, targets []AllocationTarget, threshold decimal.Decimal) *Drift {
+	actual := map[TargetDimension]map[string]decimal.Decimal{
+		TargetBySymbol:   sliceWeights(allocation.BySymbol, strings.ToUpper),
+		TargetByCategory: sliceWeights(allocation.ByCategory, strings.ToLower),
+	}
+
+	expected := make(map[TargetDimension]map[string]decimal.Decimal)
+	for _, target := range targets {
+		if expected[target.Dimension] == nil {
+			expected[target.Dimension] = make(map[string]decimal.Decimal)
+		}
+		expected[target.Dimension][target.Key] = target.Percentage
+	}
+
+	drift := &Drift{
+		PortfolioID:  allocation.PortfolioID,
+		Threshold:    threshold,
+		Entries:      make([]DriftEntry, 0, len(targets)),
+		CalculatedAt: time.Now().UTC(),
+	}
+
+	for dimension, wanted := range expected {
+		keys := make(map[string]bool, len(wanted))
+		for key := range wanted {
+			keys[key] = true
+		}
+		for key := range actual[dimension] {
+			keys[key] = true
+		}
+
+		for key := range keys {
+			entry := DriftEntry{
+				Dimension: dimension,
+				Key:       key,
+				Target:    wanted[key],
+				Actual:    actual[dimension][key],
+			}
+			entry.Drift = entry.Actual.Sub(entry.Target)
+			entry.Breached = entry.Drift.Abs().GreaterThan(threshold)
+			drift.Breached = drift.Breached || entry.Breached
+			drift.Entries = append(drift.Entries, entry)
+		}
+	}
+
+	sort.Slice(drift.Entries, func(i, j int) bool {
+		a, b := drift.Entries[i], drift.Entries[j]
+		if c := a.Drift.Abs().Cmp(b.Drift.Abs()); c != 0 {
+			return c > 0
+		}
+		if a.Dimension != b.Dimension {
+			return a.Dimension < b.Dimension
+		}
+		return a.Key < b.Key
+	})
+	return drift
+}
+
+// sliceWeights indexes allocation percentages by normalized key
+func sliceWeights(slices []AllocationSlice, normalize func(string) string) map[string]decimal.Decimal {
+	weights := make(map[string]decimal.Decimal, len(slices))
+	for _, s := range slices {
+		key := normalize(s.Key)
+		weights[key] = weights[key].Add(s.Percentage)
+	}
+	return weights
+}
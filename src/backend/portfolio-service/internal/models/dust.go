@@ -0,0 +1,53 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// ErrInvalidDustThreshold is returned for negative dust thresholds
+var ErrInvalidDustThreshold = errors.New("invalid dust threshold")
+
+// DustSettings marks the holdings of a portfolio worth less than Threshold
+// units of the base currency as dust. Dust is left out of allocation
+// percentages and, when Hidden, of the portfolio's holdings in responses.
+// A zero threshold disables both.
+type DustSettings struct {
+	PortfolioID uuid.UUID       `json:"portfolio_id"`
+	Threshold   decimal.Decimal `json:"threshold"`
+	Hidden      bool            `json:"hidden"`
+}
+
+// Validate checks the dust threshold
+func (d DustSettings) Validate() error {
+	if d.Threshold.IsNegative() {
+		return fmt.Errorf("%w: threshold cannot be negative", ErrInvalidDustThreshold)
+	}
+	return nil
+}
+
+// IsDust reports whether asset is worth less than the threshold
+func (d DustSettings) IsDust(asset Asset) bool {
+	return d.Threshold.IsPositive() && asset.CurrentValue.LessThan(d.Threshold)
+}
+
+// SplitDust separates assets into holdings and dust, keeping their order,
+// and returns the combined value of the dust
+func SplitDust(assets []Asset, settings DustSettings) (kept, dust []Asset, dustValue decimal.Decimal) {
+	kept = make([]Asset, 0, len(assets))
+	dustValue = decimal.Zero
+	for _, asset := range assets {
+		if !settings.IsDust(asset) {
+			kept = append(kept, asset)
+			continue
+		}
+		dust = append(dust, asset)
+		if asset.CurrentValue.IsPositive() {
+			dustValue = dustValue.Add(asset.CurrentValue)
+		}
+	}
+	return kept, dust, dustValue
+}
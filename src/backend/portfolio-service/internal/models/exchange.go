@@ -0,0 +1,393 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// ExchangeSide is the direction of an exchange fill from the account's view
+type ExchangeSide string
+
+const (
+	ExchangeBuy  ExchangeSide = "buy"
+	ExchangeSell ExchangeSide = "sell"
+)
+
+// ErrInvalidExchangeActivity is returned for exchange activity that cannot be
+// mapped to transactions
+var ErrInvalidExchangeActivity = errors.New("invalid exchange activity")
+
+// exchangeTransactionNamespace derives transaction IDs of imported activity so
+// that re-importing the same fills is idempotent
+var exchangeTransactionNamespace = uuid.MustParse("5b0c3f4e-8d7a-4e62-9f0b-2a6c1d8e4b71")
+
+// ExchangeBalance is the spot balance of one asset in an exchange account
+type ExchangeBalance struct {
+	Asset  string          `json:"asset"`
+	Free   decimal.Decimal `json:"free"`
+	Locked decimal.Decimal `json:"locked"`
+}
+
+// Total returns the free and locked balance
+func (b ExchangeBalance) Total() decimal.Decimal {
+	return b.Free.Add(b.Locked)
+}
+
+// ExchangeFill is a single executed trade of an exchange account. Price is
+// quoted in the Quote asset and the commission is charged in CommissionAsset,
+// which may be the base, the quote or a third asset.
+type ExchangeFill struct {
+	ID              string          `json:"id"`
+	Base            string          `json:"base"`
+	Quote           string          `json:"quote"`
+	Side            ExchangeSide    `json:"side"`
+	Quantity        decimal.Decimal `json:"quantity"`
+	Price           decimal.Decimal `json:"price"`
+	QuoteQuantity   decimal.Decimal `json:"quote_quantity"`
+	Commission      decimal.Decimal `json:"commission"`
+	CommissionAsset string          `json:"commission_asset"`
+	ExecutedAt      time.Time       `json:"executed_at"`
+}
+
+// ExchangeConversion is a small-balance ("dust") conversion of FromAmount of
+// FromAsset into ToAmount of ToAsset. Fee is charged in ToAsset on top of
+// ToAmount, which is the amount actually credited.
+type ExchangeConversion struct {
+	ID         string          `json:"id"`
+	FromAsset  string          `json:"from_asset"`
+	FromAmount decimal.Decimal `json:"from_amount"`
+	ToAsset    string          `json:"to_asset"`
+	ToAmount   decimal.Decimal `json:"to_amount"`
+	Fee        decimal.Decimal `json:"fee"`
+	ExecutedAt time.Time       `json:"executed_at"`
+}
+
+// ExchangeEntry is a single-asset account movement: a staking reward, a
+// deposit or withdrawal, or a fee paid on its own such as network gas, with
+// Type the transaction type it maps to. Amount is the gross amount credited
+// or debited and Fee is charged in the same asset.
+type ExchangeEntry struct {
+	ID         string          `json:"id"`
+	Asset      string          `json:"asset"`
+	Type       string          `json:"type"`
+	Amount     decimal.Decimal `json:"amount"`
+	Fee        decimal.Decimal `json:"fee"`
+	ExecutedAt time.Time       `json:"executed_at"`
+}
+
+// ExchangeActivity is the account history returned by one import
+type ExchangeActivity struct {
+	Fills       []ExchangeFill       `json:"fills"`
+	Conversions []ExchangeConversion `json:"conversions"`
+	Entries     []ExchangeEntry      `json:"entries"`
+}
+
+// ExchangeLeg is one portfolio transaction derived from exchange activity,
+// keyed by asset symbol until it is matched to a portfolio asset. Price and
+// Fee are in USD.
+type ExchangeLeg struct {
+	Ref       string          `json:"ref"`
+	Symbol    string          `json:"symbol"`
+	Type      string          `json:"type"`
+	Amount    decimal.Decimal `json:"amount"`
+	Price     decimal.Decimal `json:"price"`
+	Fee       decimal.Decimal `json:"fee"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// TransactionID returns the deterministic ID of the leg's transaction in a
+// portfolio
+func (l ExchangeLeg) TransactionID(portfolioID uuid.UUID, exchange string) uuid.UUID {
+	return uuid.NewSHA1(exchangeTransactionNamespace, []byte(portfolioID.String()+"/"+exchange+"/"+l.Ref))
+}
+
+// ExchangeImport summarizes the result of importing an exchange account or
+// tracked wallet, whose chain is given as Exchange
+type ExchangeImport struct {
+	PortfolioID          uuid.UUID `json:"portfolio_id"`
+	Exchange             string    `json:"exchange"`
+	Fills                int       `json:"fills"`
+	Conversions          int       `json:"conversions"`
+	Entries              int       `json:"entries"`
+	AssetsCreated        int       `json:"assets_created"`
+	TransactionsRecorded int       `json:"transactions_recorded"`
+	Duplicates           int       `json:"duplicates"`
+	SkippedSymbols       []string  `json:"skipped_symbols"`
+	Cursor               string    `json:"cursor"`
+	ImportedAt           time.Time `json:"imported_at"`
+}
+
+// ExchangeAccount is an exchange account connected to a portfolio and synced
+// in the background. Credentials are sealed by the service and never leave
+// it; Cursor is the checkpoint the next sync resumes from.
+type ExchangeAccount struct {
+	ID           uuid.UUID  `json:"id"`
+	PortfolioID  uuid.UUID  `json:"portfolio_id"`
+	Exchange     string     `json:"exchange"`
+	Credentials  []byte     `json:"-"`
+	Cursor       string     `json:"cursor"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	NextSyncAt   time.Time  `json:"next_sync_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// USDPriceFunc returns the USD price of one unit of an asset at a time
+type USDPriceFunc func(asset string, at time.Time) (decimal.Decimal, error)
+
+// ExchangeMapper maps exchange activity onto USD-denominated portfolio
+// transactions. Cash assets (stablecoins and fiat) are valued at 1 USD and
+// are not tracked as holdings, so trades quoted in them produce a single leg.
+type ExchangeMapper struct {
+	cash  map[string]bool
+	price USDPriceFunc
+}
+
+// NewExchangeMapper creates a mapper valuing non-cash assets with price
+func NewExchangeMapper(cashAssets []string, price USDPriceFunc) *ExchangeMapper {
+	cash := make(map[string]bool, len(cashAssets))
+	for _, asset := range cashAssets {
+		cash[strings.ToUpper(asset)] = true
+	}
+	return &ExchangeMapper{cash: cash, price: price}
+}
+
+// IsCash reports whether the asset is valued at 1 USD and not tracked
+func (m *ExchangeMapper) IsCash(asset string) bool {
+	return m.cash[strings.ToUpper(asset)]
+}
+
+// usd returns the USD price of one unit of asset at a time
+func (m *ExchangeMapper) usd(asset string, at time.Time) (decimal.Decimal, error) {
+	if m.IsCash(asset) {
+		return decimal.NewFromInt(1), nil
+	}
+	price, err := m.price(asset, at)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to price %s: %w", asset, err)
+	}
+	if !price.IsPositive() {
+		return decimal.Zero, fmt.Errorf("%w: no USD price for %s", ErrInvalidExchangeActivity, asset)
+	}
+	return price, nil
+}
+
+// MapFill maps a fill onto transaction legs. The base leg carries the whole
+// commission as its fee, so cost basis includes it on buys and proceeds are
+// net of it on sells:
+//
+//   - commission in the base asset reduces the amount received on a buy and
+//     increases the amount disposed of on a sell
+//   - commission in the quote asset changes the quote leg by the same amount
+//   - commission in a third asset is disposed of by a separate fee leg
+//
+// Non-cash quote assets get an opposite leg priced at their USD value.
+func (m *ExchangeMapper) MapFill(f ExchangeFill) ([]ExchangeLeg, error) {
+	if f.ID == "" || f.Base == "" || f.Quote == "" {
+		return nil, fmt.Errorf("%w: fill without id or symbol", ErrInvalidExchangeActivity)
+	}
+	if !f.Quantity.IsPositive() || !f.Price.IsPositive() || f.Commission.IsNegative() {
+		return nil, fmt.Errorf("%w: fill %s has non-positive quantity or price", ErrInvalidExchangeActivity, f.ID)
+	}
+	if f.Side != ExchangeBuy && f.Side != ExchangeSell {
+		return nil, fmt.Errorf("%w: fill %s has side %q", ErrInvalidExchangeActivity, f.ID, f.Side)
+	}
+
+	quoteQuantity := f.QuoteQuantity
+	if !quoteQuantity.IsPositive() {
+		quoteQuantity = f.Quantity.Mul(f.Price)
+	}
+
+	quoteUSD, err := m.usd(f.Quote, f.ExecutedAt)
+	if err != nil {
+		return nil, err
+	}
+	baseUSD := f.Price.Mul(quoteUSD)
+
+	var baseCommission, quoteCommission decimal.Decimal
+	var feeUSD decimal.Decimal
+	var feeLeg *ExchangeLeg
+	if f.Commission.IsPositive() {
+		switch strings.ToUpper(f.CommissionAsset) {
+		case strings.ToUpper(f.Base):
+			baseCommission = f.Commission
+			feeUSD = f.Commission.Mul(baseUSD)
+		case strings.ToUpper(f.Quote):
+			quoteCommission = f.Commission
+			feeUSD = f.Commission.Mul(quoteUSD)
+		default:
+			commissionUSD, err := m.usd(f.CommissionAsset, f.ExecutedAt)
+			if err != nil {
+				return nil, err
+			}
+			feeUSD = f.Commission.Mul(commissionUSD)
+			if !m.IsCash(f.CommissionAsset) {
+				feeLeg = &ExchangeLeg{
+					Ref:       f.ID + "/fee",
+					Symbol:    f.CommissionAsset,
+					Type:      "fee",
+					Amount:    f.Commission,
+					Price:     commissionUSD,
+					Fee:       decimal.Zero,
+					Timestamp: f.ExecutedAt,
+				}
+			}
+		}
+	}
+
+	base := ExchangeLeg{
+		Ref:       f.ID + "/base",
+		Symbol:    f.Base,
+		Price:     baseUSD,
+		Fee:       feeUSD,
+		Timestamp: f.ExecutedAt,
+	}
+	quote := ExchangeLeg{
+		Ref:       f.ID + "/quote",
+		Symbol:    f.Quote,
+		Price:     quoteUSD,
+		Fee:       decimal.Zero,
+		Timestamp: f.ExecutedAt,
+	}
+	if f.Side == ExchangeBuy {
+		base.Type, base.Amount = "buy", f.Quantity.Sub(baseCommission)
+		quote.Type, quote.Amount = "sell", quoteQuantity.Add(quoteCommission)
+	} else {
+		base.Type, base.Amount = "sell", f.Quantity.Add(baseCommission)
+		quote.Type, quote.Amount = "buy", quoteQuantity.Sub(quoteCommission)
+	}
+	if !base.Amount.IsPositive() || !quote.Amount.IsPositive() {
+		return nil, fmt.Errorf("%w: fill %s commission exceeds the traded amount", ErrInvalidExchangeActivity, f.ID)
+	}
+
+	legs := []ExchangeLeg{base}
+	if !m.IsCash(f.Quote) {
+		legs = append(legs, quote)
+	}
+	if feeLeg != nil {
+		legs = append(legs, *feeLeg)
+	}
+	return legs, nil
+}
+
+// MapConversion maps a dust conversion onto a sale of the converted asset
+// and a purchase of the credited asset. The sale is valued at the gross
+// credited amount with the conversion fee deducted from its proceeds, so the
+// purchase cost basis equals the net proceeds.
+func (m *ExchangeMapper) MapConversion(c ExchangeConversion) ([]ExchangeLeg, error) {
+	if c.ID == "" || c.FromAsset == "" || c.ToAsset == "" {
+		return nil, fmt.Errorf("%w: conversion without id or asset", ErrInvalidExchangeActivity)
+	}
+	if !c.FromAmount.IsPositive() || !c.ToAmount.IsPositive() || c.Fee.IsNegative() {
+		return nil, fmt.Errorf("%w: conversion %s has non-positive amounts", ErrInvalidExchangeActivity, c.ID)
+	}
+
+	toUSD, err := m.usd(c.ToAsset, c.ExecutedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	gross := c.ToAmount.Add(c.Fee)
+	legs := make([]ExchangeLeg, 0, 2)
+	if !m.IsCash(c.FromAsset) {
+		legs = append(legs, ExchangeLeg{
+			Ref:       c.ID + "/from",
+			Symbol:    c.FromAsset,
+			Type:      "sell",
+			Amount:    c.FromAmount,
+			Price:     gross.Mul(toUSD).Div(c.FromAmount),
+			Fee:       c.Fee.Mul(toUSD),
+			Timestamp: c.ExecutedAt,
+		})
+	}
+	if !m.IsCash(c.ToAsset) {
+		legs = append(legs, ExchangeLeg{
+			Ref:       c.ID + "/to",
+			Symbol:    c.ToAsset,
+			Type:      "buy",
+			Amount:    c.ToAmount,
+			Price:     toUSD,
+			Fee:       decimal.Zero,
+			Timestamp: c.ExecutedAt,
+		})
+	}
+	return legs, nil
+}
+
+// MapEntry maps a reward, deposit, withdrawal or fee onto a single leg valued
+// at the asset's USD price. Fees reduce the amount credited and add to the
+// amount debited; cash entries produce no leg.
+func (m *ExchangeMapper) MapEntry(e ExchangeEntry) ([]ExchangeLeg, error) {
+	if e.ID == "" || e.Asset == "" {
+		return nil, fmt.Errorf("%w: entry without id or asset", ErrInvalidExchangeActivity)
+	}
+	if !e.Amount.IsPositive() || e.Fee.IsNegative() {
+		return nil, fmt.Errorf("%w: entry %s has non-positive amount", ErrInvalidExchangeActivity, e.ID)
+	}
+	if m.IsCash(e.Asset) {
+		return nil, nil
+	}
+
+	var amount decimal.Decimal
+	switch e.Type {
+	case "reward", "transfer_in":
+		amount = e.Amount.Sub(e.Fee)
+	case "transfer_out", "fee":
+		amount = e.Amount.Add(e.Fee)
+	default:
+		return nil, fmt.Errorf("%w: entry %s has type %q", ErrInvalidExchangeActivity, e.ID, e.Type)
+	}
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: entry %s fee exceeds its amount", ErrInvalidExchangeActivity, e.ID)
+	}
+
+	price, err := m.usd(e.Asset, e.ExecutedAt)
+	if err != nil {
+		return nil, err
+	}
+	return []ExchangeLeg{{
+		Ref:       e.ID,
+		Symbol:    e.Asset,
+		Type:      e.Type,
+		Amount:    amount,
+		Price:     price,
+		Fee:       e.Fee.Mul(price),
+		Timestamp: e.ExecutedAt,
+	}}, nil
+}
+
+// MapActivity maps all fills, conversions and entries onto legs ordered by time
+func (m *ExchangeMapper) MapActivity(activity ExchangeActivity) ([]ExchangeLeg, error) {
+	var legs []ExchangeLeg
+	for _, f := range activity.Fills {
+		mapped, err := m.MapFill(f)
+		if err != nil {
+			return nil, err
+		}
+		legs = append(legs, mapped...)
+	}
+	for _, c := range activity.Conversions {
+		mapped, err := m.MapConversion(c)
+		if err != nil {
+			return nil, err
+		}
+		legs = append(legs, mapped...)
+	}
+	for _, e := range activity.Entries {
+		mapped, err := m.MapEntry(e)
+		if err != nil {
+			return nil, err
+		}
+		legs = append(legs, mapped...)
+	}
+	sort.SliceStable(legs, func(i, j int) bool {
+		return legs[i].Timestamp.Before(legs[j].Timestamp)
+	})
+	return legs, nil
+}
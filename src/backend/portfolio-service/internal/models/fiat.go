@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// FIAT_ASSET_TYPE is the asset type of cash balances, such as fiat held on
+// an exchange. Fiat holdings are valued at foreign exchange rates rather than
+// crypto market prices, and are allocated to the cash category.
+const FIAT_ASSET_TYPE = "fiat"
+
+// FIAT_TRANSACTION_TYPES lists the transaction types of fiat holdings; cash
+// earns interest but cannot be staked, earn rewards or be airdropped
+var FIAT_TRANSACTION_TYPES = []string{
+	"buy",
+	"sell",
+	"transfer_in",
+	"transfer_out",
+	"fee",
+	"interest",
+}
+
+// fiatCurrencyCode matches ISO 4217 currency codes
+var fiatCurrencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ValidateFiatCurrency checks that a fiat holding's symbol is an ISO 4217
+// currency code
+func ValidateFiatCurrency(symbol string) error {
+	if !fiatCurrencyCode.MatchString(strings.ToUpper(strings.TrimSpace(symbol))) {
+		return fmt.Errorf("%w: fiat symbol %q is not an ISO 4217 currency code", ErrInvalidAssetType, symbol)
+	}
+	return nil
+}
+
+// BaseCurrencyPrice returns the price of a fiat holding in the base
+// currency, which is known without an exchange rate
+func BaseCurrencyPrice(asset Asset) (decimal.Decimal, bool) {
+	if asset.Type == FIAT_ASSET_TYPE && strings.EqualFold(asset.Symbol, BASE_CURRENCY) {
+		return decimal.NewFromInt(1), true
+	}
+	return decimal.Zero, false
+}
@@ -0,0 +1,25 @@
+package models
+
+// FieldError is a validation error of one request field. It wraps the
+// underlying error, so matching validation sentinels keeps working, and
+// reports the field to clients as a field violation.
+type FieldError struct {
+	// Field is the request field name, such as "url" or "threshold"
+	Field string
+	Err   error
+}
+
+// InvalidField returns a validation error of a request field
+func InvalidField(field string, err error) error {
+	return &FieldError{Field: field, Err: err}
+}
+
+// Error returns the message of the underlying error
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
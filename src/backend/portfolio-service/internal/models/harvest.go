@@ -0,0 +1,128 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// ErrInvalidHarvestCriteria is returned for tax-loss harvesting thresholds
+// or tax rates out of range
+var ErrInvalidHarvestCriteria = errors.New("invalid harvest criteria")
+
+// HarvestCriteria selects the disposals worth suggesting for tax-loss
+// harvesting and how their tax impact is estimated
+type HarvestCriteria struct {
+	// MinLoss is the smallest loss, in the base currency, worth harvesting
+	MinLoss decimal.Decimal
+	// MinLossPercentage is the smallest loss as a percentage of cost basis
+	MinLossPercentage decimal.Decimal
+	// TaxRate is the rate, between 0 and 1, the loss offsets gains at
+	TaxRate decimal.Decimal
+	// WashSaleWindow is how long before and after a loss disposal a
+	// purchase of the same asset may disallow the loss
+	WashSaleWindow time.Duration
+}
+
+// Validate checks the thresholds are not negative and the tax rate is a
+// fraction
+func (c HarvestCriteria) Validate() error {
+	if c.MinLoss.IsNegative() || c.MinLossPercentage.IsNegative() || c.WashSaleWindow < 0 {
+		return ErrInvalidHarvestCriteria
+	}
+	if c.TaxRate.IsNegative() || c.TaxRate.GreaterThan(decimal.NewFromInt(1)) {
+		return ErrInvalidHarvestCriteria
+	}
+	return nil
+}
+
+// HarvestCandidate is a suggested sell of an asset realizing an unrealized
+// loss. Lots are disposed of oldest first, so the sell takes the oldest lots
+// up to the quantity realizing the largest loss.
+type HarvestCandidate struct {
+	AssetID   uuid.UUID       `json:"asset_id"`
+	Symbol    string          `json:"symbol"`
+	Quantity  decimal.Decimal `json:"quantity"`
+	Lots      int             `json:"lots"`
+	CostBasis decimal.Decimal `json:"cost_basis"`
+	// Proceeds is the quantity valued at the current price
+	Proceeds decimal.Decimal `json:"proceeds"`
+	// Loss is CostBasis less Proceeds, always positive
+	Loss           decimal.Decimal `json:"loss"`
+	LossPercentage decimal.Decimal `json:"loss_percentage"`
+	// EstimatedTaxSaving is the loss at the criteria's tax rate
+	EstimatedTaxSaving decimal.Decimal `json:"estimated_tax_saving"`
+	// WashSaleConflicts lists purchases of the asset within the wash-sale
+	// window that the sell would not dispose of
+	WashSaleConflicts []uuid.UUID `json:"wash_sale_conflicts"`
+	// RepurchaseAfter is the earliest time the asset can be bought back
+	// without a wash sale
+	RepurchaseAfter time.Time `json:"repurchase_after"`
+}
+
+// SuggestHarvest returns the sell of an asset at price that realizes the
+// largest loss against its open lots, if that loss meets the criteria.
+// recent holds the portfolio's transactions within the wash-sale window
+// before at.
+func SuggestHarvest(asset Asset, replay LotReplay, price decimal.Decimal, recent []Transaction, criteria HarvestCriteria, at time.Time) (HarvestCandidate, bool) {
+	if !price.IsPositive() {
+		return HarvestCandidate{}, false
+	}
+
+	best := -1
+	bestLoss := decimal.Zero
+	quantity, cost := decimal.Zero, decimal.Zero
+	for i, lot := range replay.Lots {
+		quantity = quantity.Add(lot.Quantity)
+		cost = cost.Add(lot.CostBasis)
+		if loss := cost.Sub(quantity.Mul(price)); loss.GreaterThan(bestLoss) {
+			best, bestLoss = i, loss
+		}
+	}
+	if best < 0 {
+		return HarvestCandidate{}, false
+	}
+
+	candidate := HarvestCandidate{
+		AssetID:           asset.ID,
+		Symbol:            asset.Symbol,
+		Lots:              best + 1,
+		Quantity:          decimal.Zero,
+		CostBasis:         decimal.Zero,
+		Loss:              bestLoss,
+		WashSaleConflicts: []uuid.UUID{},
+		RepurchaseAfter:   at.Add(criteria.WashSaleWindow),
+	}
+	sold := make(map[uuid.UUID]bool, best+1)
+	for _, lot := range replay.Lots[:best+1] {
+		candidate.Quantity = candidate.Quantity.Add(lot.Quantity)
+		candidate.CostBasis = candidate.CostBasis.Add(lot.CostBasis)
+		sold[lot.TransactionID] = true
+	}
+	candidate.Proceeds = candidate.Quantity.Mul(price)
+	candidate.LossPercentage = bestLoss.Div(candidate.CostBasis).Mul(decimal.NewFromInt(100))
+
+	if bestLoss.LessThan(criteria.MinLoss) || candidate.LossPercentage.LessThan(criteria.MinLossPercentage) {
+		return HarvestCandidate{}, false
+	}
+	candidate.EstimatedTaxSaving = bestLoss.Mul(criteria.TaxRate)
+
+	since := at.Add(-criteria.WashSaleWindow)
+	for _, tx := range recent {
+		if tx.AssetID != asset.ID || tx.Type != "buy" || sold[tx.ID] || tx.Timestamp.Before(since) || tx.Timestamp.After(at) {
+			continue
+		}
+		candidate.WashSaleConflicts = append(candidate.WashSaleConflicts, tx.ID)
+	}
+	return candidate, true
+}
+
+// SortHarvestCandidates orders candidates by loss, largest first
+func SortHarvestCandidates(candidates []HarvestCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Loss.GreaterThan(candidates[j].Loss)
+	})
+}
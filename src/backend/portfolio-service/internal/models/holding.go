@@ -0,0 +1,202 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// HoldingTerm classifies how long a lot was held, which decides the rate
+// many jurisdictions tax its gain at
+type HoldingTerm string
+
+const (
+	// HoldingShortTerm is a lot held no longer than the long-term period
+	HoldingShortTerm HoldingTerm = "short"
+	// HoldingLongTerm is a lot held longer than the long-term period
+	HoldingLongTerm HoldingTerm = "long"
+)
+
+// DEFAULT_LONG_TERM_HOLDING_PERIOD is the holding period lots must exceed
+// to be long-term when none is configured
+const DEFAULT_LONG_TERM_HOLDING_PERIOD = 365 * 24 * time.Hour
+
+// ErrInvalidHoldingPeriod is returned for holding period ranges that cannot
+// be reported on
+var ErrInvalidHoldingPeriod = errors.New("invalid holding period")
+
+// ClassifyHolding returns the term of a lot acquired at acquiredAt and held
+// until at. Lots held longer than longTerm are long-term; with a zero
+// longTerm, for jurisdictions taxing gains alike however long lots were
+// held, none are.
+func ClassifyHolding(acquiredAt, at time.Time, longTerm time.Duration) HoldingTerm {
+	if longTerm > 0 && at.Sub(acquiredAt) > longTerm {
+		return HoldingLongTerm
+	}
+	return HoldingShortTerm
+}
+
+// TermSplit divides an amount of profit and loss by holding term
+type TermSplit struct {
+	ShortTerm decimal.Decimal `json:"short_term"`
+	LongTerm  decimal.Decimal `json:"long_term"`
+}
+
+// add adds amount to the part of the split of term
+func (s TermSplit) add(term HoldingTerm, amount decimal.Decimal) TermSplit {
+	if term == HoldingLongTerm {
+		s.LongTerm = s.LongTerm.Add(amount)
+	} else {
+		s.ShortTerm = s.ShortTerm.Add(amount)
+	}
+	return s
+}
+
+// plus returns the sum of two splits
+func (s TermSplit) plus(other TermSplit) TermSplit {
+	return TermSplit{ShortTerm: s.ShortTerm.Add(other.ShortTerm), LongTerm: s.LongTerm.Add(other.LongTerm)}
+}
+
+// zeroSplit returns a split of nothing
+func zeroSplit() TermSplit {
+	return TermSplit{ShortTerm: decimal.Zero, LongTerm: decimal.Zero}
+}
+
+// SplitRealized divides the gain a sell realized by the holding term of the
+// lots it consumed, sharing its proceeds and any loss disallowed as a wash
+// sale among them by quantity. Other disposals realize nothing.
+func SplitRealized(disposal LotDisposal, longTerm time.Duration) TermSplit {
+	split := zeroSplit()
+	if disposal.Type != "sell" || !disposal.Quantity.IsPositive() {
+		return split
+	}
+	for _, lot := range disposal.Lots {
+		proceeds := disposal.Proceeds.Mul(lot.Quantity).Div(disposal.Quantity)
+		disallowed := disposal.Disallowed.Mul(lot.Quantity).Div(disposal.Quantity)
+		split = split.add(ClassifyHolding(lot.AcquiredAt, disposal.DisposedAt, longTerm), proceeds.Sub(lot.CostBasis).Add(disallowed))
+	}
+	return split
+}
+
+// HeldLot is an open lot classified by how long it has been held and valued
+// at the current price
+type HeldLot struct {
+	TransactionID uuid.UUID       `json:"transaction_id"`
+	AcquiredAt    time.Time       `json:"acquired_at"`
+	Quantity      decimal.Decimal `json:"quantity"`
+	CostBasis     decimal.Decimal `json:"cost_basis"`
+	Term          HoldingTerm     `json:"term"`
+	// WashSaleAdjustment is the disallowed loss of wash sales included in
+	// CostBasis
+	WashSaleAdjustment decimal.Decimal `json:"wash_sale_adjustment"`
+	// LongTermAt is when the lot becomes long-term; zero when no lot does
+	LongTermAt time.Time       `json:"long_term_at"`
+	Value      decimal.Decimal `json:"value"`
+	// Unpriced is set when the asset has no current price, leaving Value
+	// and the lot's unrealized profit and loss out
+	Unpriced bool `json:"unpriced"`
+}
+
+// AssetHoldingPeriods splits the profit and loss of one asset by holding term
+type AssetHoldingPeriods struct {
+	AssetID    uuid.UUID `json:"asset_id"`
+	Symbol     string    `json:"symbol"`
+	Lots       []HeldLot `json:"lots"`
+	Realized   TermSplit `json:"realized"`
+	Unrealized TermSplit `json:"unrealized"`
+}
+
+// HoldingPeriodReport splits a portfolio's realized profit and loss within
+// a range and its unrealized profit and loss at a time by holding term
+type HoldingPeriodReport struct {
+	PortfolioID uuid.UUID             `json:"portfolio_id"`
+	LongTerm    time.Duration         `json:"long_term"`
+	Start       time.Time             `json:"start"`
+	End         time.Time             `json:"end"`
+	Assets      []AssetHoldingPeriods `json:"assets"`
+	Realized    TermSplit             `json:"realized"`
+	Unrealized  TermSplit             `json:"unrealized"`
+	// WashSales are the sells within the range whose loss was disallowed,
+	// when the replays applied a wash-sale rule
+	WashSales   []WashSale `json:"wash_sales"`
+	GeneratedAt time.Time  `json:"generated_at"`
+}
+
+// BuildHoldingPeriodReport classifies the open lots of each asset's replay
+// at at, valued at prices by symbol, and splits the gains of the sells
+// disposed of within [start, end) by the term of the lots they consumed
+func BuildHoldingPeriodReport(portfolioID uuid.UUID, assets []Asset, replays map[uuid.UUID]LotReplay, prices map[string]decimal.Decimal, longTerm time.Duration, start, end, at time.Time) (*HoldingPeriodReport, error) {
+	if longTerm < 0 {
+		return nil, fmt.Errorf("%w: long-term holding period cannot be negative", ErrInvalidHoldingPeriod)
+	}
+	if !start.Before(end) {
+		return nil, fmt.Errorf("%w: start must be before end", ErrInvalidHoldingPeriod)
+	}
+
+	report := &HoldingPeriodReport{
+		PortfolioID: portfolioID,
+		LongTerm:    longTerm,
+		Start:       start,
+		End:         end,
+		Assets:      make([]AssetHoldingPeriods, 0, len(assets)),
+		Realized:    zeroSplit(),
+		Unrealized:  zeroSplit(),
+		WashSales:   []WashSale{},
+		GeneratedAt: at,
+	}
+	for _, asset := range assets {
+		replay := replays[asset.ID]
+		price, priced := prices[asset.Symbol]
+		summary := AssetHoldingPeriods{
+			AssetID:    asset.ID,
+			Symbol:     asset.Symbol,
+			Lots:       make([]HeldLot, len(replay.Lots)),
+			Realized:   zeroSplit(),
+			Unrealized: zeroSplit(),
+		}
+
+		for i, lot := range replay.Lots {
+			held := HeldLot{
+				TransactionID:      lot.TransactionID,
+				AcquiredAt:         lot.AcquiredAt,
+				Quantity:           lot.Quantity,
+				CostBasis:          lot.CostBasis,
+				Term:               ClassifyHolding(lot.AcquiredAt, at, longTerm),
+				WashSaleAdjustment: lot.WashSaleAdjustment,
+				Value:              decimal.Zero,
+				Unpriced:           !priced,
+			}
+			if longTerm > 0 {
+				held.LongTermAt = lot.AcquiredAt.Add(longTerm)
+			}
+			if priced {
+				held.Value = lot.Quantity.Mul(price)
+				summary.Unrealized = summary.Unrealized.add(held.Term, held.Value.Sub(lot.CostBasis))
+			}
+			summary.Lots[i] = held
+		}
+
+		var disposals []LotDisposal
+		for _, disposal := range replay.Disposals {
+			if disposal.DisposedAt.Before(start) || !disposal.DisposedAt.Before(end) {
+				continue
+			}
+			disposals = append(disposals, disposal)
+			summary.Realized = summary.Realized.plus(SplitRealized(disposal, longTerm))
+		}
+		report.WashSales = append(report.WashSales, WashSales(asset, disposals)...)
+
+		report.Realized = report.Realized.plus(summary.Realized)
+		report.Unrealized = report.Unrealized.plus(summary.Unrealized)
+		report.Assets = append(report.Assets, summary)
+	}
+
+	sort.SliceStable(report.Assets, func(i, j int) bool {
+		return report.Assets[i].Symbol < report.Assets[j].Symbol
+	})
+	return report, nil
+}
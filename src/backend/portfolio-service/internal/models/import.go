@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// importTransactionNamespace derives transaction IDs of imported file rows so
+// that importing the same file twice records nothing twice
+var importTransactionNamespace = uuid.MustParse("9e4d2a71-3c58-4f0e-b6a1-7d2f8c5e0b93")
+
+// ImportedTransactionID derives the ID of a transaction imported from a file
+// row. key is the row's reference or, for files without one, its content
+// with the number of identical rows before it.
+func ImportedTransactionID(portfolioID uuid.UUID, key string) uuid.UUID {
+	return uuid.NewSHA1(importTransactionNamespace, []byte(portfolioID.String()+"/file/"+key))
+}
+
+// ImportRowError describes why a row of an imported file was rejected
+type ImportRowError struct {
+	Line    int    `json:"line"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// TransactionImport summarizes the import of a transaction file. Nothing is
+// recorded unless every row is valid; Committed reports whether it was.
+type TransactionImport struct {
+	PortfolioID          uuid.UUID        `json:"portfolio_id"`
+	Rows                 int              `json:"rows"`
+	Valid                int              `json:"valid"`
+	Invalid              int              `json:"invalid"`
+	Skipped              int              `json:"skipped"`
+	Duplicates           int              `json:"duplicates"`
+	TransactionsRecorded int              `json:"transactions_recorded"`
+	Committed            bool             `json:"committed"`
+	Errors               []ImportRowError `json:"errors"`
+	ImportedAt           time.Time        `json:"imported_at"`
+}
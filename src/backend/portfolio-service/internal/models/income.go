@@ -0,0 +1,198 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// INCOME_TRANSACTION_TYPES lists the transaction types received as income,
+// which many jurisdictions tax as ordinary income at their value when
+// received
+var INCOME_TRANSACTION_TYPES = []string{
+	"reward",
+	"airdrop",
+	"interest",
+}
+
+// MAX_INCOME_REPORT_PERIODS bounds the number of periods an income report
+// breaks its range into
+const MAX_INCOME_REPORT_PERIODS = 120
+
+// ErrInvalidIncomeReport is returned for income report ranges that cannot
+// be reported on
+var ErrInvalidIncomeReport = errors.New("invalid income report")
+
+// IncomeLine totals the income of one type received in one asset
+type IncomeLine struct {
+	AssetID uuid.UUID `json:"asset_id"`
+	// Symbol is empty for assets removed since
+	Symbol   string          `json:"symbol"`
+	Type     string          `json:"type"`
+	Amount   decimal.Decimal `json:"amount"`
+	Value    decimal.Decimal `json:"value"`
+	Receipts int             `json:"receipts"`
+	// Unvalued counts receipts with neither a price recorded nor a daily
+	// close for their day, which add nothing to Value
+	Unvalued int `json:"unvalued"`
+}
+
+// IncomePeriod is the income received within one calendar period
+type IncomePeriod struct {
+	PeriodStart time.Time       `json:"period_start"`
+	PeriodEnd   time.Time       `json:"period_end"`
+	Lines       []IncomeLine    `json:"lines"`
+	Value       decimal.Decimal `json:"value"`
+}
+
+// IncomeReport summarizes the rewards, airdrops and interest a portfolio
+// received over a range, by asset and calendar period, valued in the base
+// currency when received
+type IncomeReport struct {
+	PortfolioID  uuid.UUID       `json:"portfolio_id"`
+	Period       ReportPeriod    `json:"period"`
+	Start        time.Time       `json:"start"`
+	End          time.Time       `json:"end"`
+	BaseCurrency string          `json:"base_currency"`
+	Periods      []IncomePeriod  `json:"periods"`
+	Totals       []IncomeLine    `json:"totals"`
+	Value        decimal.Decimal `json:"value"`
+	GeneratedAt  time.Time       `json:"generated_at"`
+}
+
+// IsIncome reports whether a transaction type is received as income
+func IsIncome(transactionType string) bool {
+	for _, income := range INCOME_TRANSACTION_TYPES {
+		if transactionType == income {
+			return true
+		}
+	}
+	return false
+}
+
+// IncomeValue returns the value of an income receipt when received: its
+// recorded price, or else the daily close of its symbol on the day it was
+// received. ok is false when neither is known.
+func IncomeValue(tx Transaction, closes []PricePoint) (value decimal.Decimal, ok bool) {
+	if tx.Price.IsPositive() {
+		return tx.Amount.Mul(tx.Price), true
+	}
+	day := tx.Timestamp.UTC().Truncate(24 * time.Hour)
+	for _, point := range closes {
+		if point.Timestamp.UTC().Truncate(24*time.Hour).Equal(day) && point.Close.IsPositive() {
+			return tx.Amount.Mul(point.Close), true
+		}
+	}
+	return decimal.Zero, false
+}
+
+// incomeKey identifies the income line of an asset and type
+type incomeKey struct {
+	assetID uuid.UUID
+	txType  string
+}
+
+// BuildIncomeReport groups the income transactions among txs received within
+// [start, end) by the calendar periods of the range, in the location of
+// start, and by asset and type. Yearly periods are tax years starting as
+// taxYear does. symbols names the portfolio's assets and closes holds the
+// daily closes of each symbol over the range.
+func BuildIncomeReport(portfolioID uuid.UUID, period ReportPeriod, taxYear TaxYear, start, end time.Time, txs []Transaction, symbols map[uuid.UUID]string, closes map[string][]PricePoint, at time.Time) (*IncomeReport, error) {
+	if !start.Before(end) {
+		return nil, fmt.Errorf("%w: start must be before end", ErrInvalidIncomeReport)
+	}
+
+	report := &IncomeReport{
+		PortfolioID:  portfolioID,
+		Period:       period,
+		Start:        start,
+		End:          end,
+		BaseCurrency: BASE_CURRENCY,
+		Periods:      []IncomePeriod{},
+		Value:        decimal.Zero,
+		GeneratedAt:  at,
+	}
+	for cursor := start; cursor.Before(end); {
+		var periodEnd time.Time
+		if period == ReportPeriodYear {
+			_, periodEnd = taxYear.Bounds(cursor)
+		} else {
+			var err error
+			if _, periodEnd, err = ReportPeriodBounds(period, cursor); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidIncomeReport, err)
+			}
+		}
+		if len(report.Periods) == MAX_INCOME_REPORT_PERIODS {
+			return nil, fmt.Errorf("%w: range spans more than %d periods", ErrInvalidIncomeReport, MAX_INCOME_REPORT_PERIODS)
+		}
+		if periodEnd.After(end) {
+			periodEnd = end
+		}
+		report.Periods = append(report.Periods, IncomePeriod{PeriodStart: cursor, PeriodEnd: periodEnd, Value: decimal.Zero})
+		cursor = periodEnd
+	}
+
+	periodLines := make([]map[incomeKey]*IncomeLine, len(report.Periods))
+	totals := make(map[incomeKey]*IncomeLine)
+	add := func(lines map[incomeKey]*IncomeLine, key incomeKey, tx Transaction, value decimal.Decimal, valued bool) {
+		line, ok := lines[key]
+		if !ok {
+			line = &IncomeLine{AssetID: key.assetID, Symbol: symbols[key.assetID], Type: key.txType, Amount: decimal.Zero, Value: decimal.Zero}
+			lines[key] = line
+		}
+		line.Amount = line.Amount.Add(tx.Amount)
+		line.Value = line.Value.Add(value)
+		line.Receipts++
+		if !valued {
+			line.Unvalued++
+		}
+	}
+
+	for _, tx := range txs {
+		if !IsIncome(tx.Type) || tx.Timestamp.Before(start) || !tx.Timestamp.Before(end) {
+			continue
+		}
+		i := sort.Search(len(report.Periods), func(i int) bool {
+			return report.Periods[i].PeriodEnd.After(tx.Timestamp)
+		})
+		value, valued := IncomeValue(tx, closes[symbols[tx.AssetID]])
+		key := incomeKey{assetID: tx.AssetID, txType: tx.Type}
+
+		if periodLines[i] == nil {
+			periodLines[i] = make(map[incomeKey]*IncomeLine)
+		}
+		add(periodLines[i], key, tx, value, valued)
+		add(totals, key, tx, value, valued)
+		report.Periods[i].Value = report.Periods[i].Value.Add(value)
+		report.Value = report.Value.Add(value)
+	}
+
+	for i := range report.Periods {
+		report.Periods[i].Lines = sortedIncomeLines(periodLines[i])
+	}
+	report.Totals = sortedIncomeLines(totals)
+	return report, nil
+}
+
+// sortedIncomeLines returns income lines by symbol, asset and type
+func sortedIncomeLines(lines map[incomeKey]*IncomeLine) []IncomeLine {
+	sorted := make([]IncomeLine, 0, len(lines))
+	for _, line := range lines {
+		sorted = append(sorted, *line)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		if a.AssetID != b.AssetID {
+			return a.AssetID.String() < b.AssetID.String()
+		}
+		return a.Type < b.Type
+	})
+	return sorted
+}
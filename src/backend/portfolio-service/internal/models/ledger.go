@@ -0,0 +1,258 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// LedgerEventType identifies a state change recorded in a portfolio ledger
+type LedgerEventType string
+
+const (
+	// LedgerPortfolioCreated opens a ledger; its data is LedgerPortfolioDetails
+	LedgerPortfolioCreated LedgerEventType = "PortfolioCreated"
+	// LedgerPortfolioUpdated changes the name or description; its data is
+	// LedgerPortfolioDetails without a user ID
+	LedgerPortfolioUpdated LedgerEventType = "PortfolioUpdated"
+	// LedgerPortfolioArchived hides the portfolio from default listings; it
+	// has no data
+	LedgerPortfolioArchived LedgerEventType = "PortfolioArchived"
+	// LedgerPortfolioRestored undoes an archive; it has no data
+	LedgerPortfolioRestored LedgerEventType = "PortfolioRestored"
+	// LedgerAssetAdded adds a holding; its data is an Asset
+	LedgerAssetAdded LedgerEventType = "AssetAdded"
+	// LedgerAssetRemoved removes a holding; its data is LedgerAssetRef
+	LedgerAssetRemoved LedgerEventType = "AssetRemoved"
+	// LedgerTransactionRecorded records a transaction; its data is a Transaction
+	LedgerTransactionRecorded LedgerEventType = "TransactionRecorded"
+	// LedgerCostBasisAdjusted sets the cost basis of a holding, as when a
+	// fork takes a share of it; its data is LedgerCostBasis
+	LedgerCostBasisAdjusted LedgerEventType = "CostBasisAdjusted"
+	// LedgerAssetMigrated moves a holding and its transactions to the new
+	// symbol of a migrated token; its data is LedgerAssetMigration
+	LedgerAssetMigrated LedgerEventType = "AssetMigrated"
+)
+
+var (
+	// ErrLedgerSequence is returned when events are replayed out of order
+	ErrLedgerSequence = errors.New("ledger events out of sequence")
+
+	// ErrInvalidLedgerEvent is returned for events that cannot be applied
+	ErrInvalidLedgerEvent = errors.New("invalid ledger event")
+)
+
+// LedgerEvent is one entry of a portfolio's append-only event stream.
+// Sequence numbers start at 1 and have no gaps within a portfolio.
+type LedgerEvent struct {
+	PortfolioID uuid.UUID       `json:"portfolio_id"`
+	Sequence    int64           `json:"sequence"`
+	Type        LedgerEventType `json:"type"`
+	Data        json.RawMessage `json:"data"`
+	RecordedAt  time.Time       `json:"recorded_at"`
+}
+
+// LedgerPortfolioDetails is the data of portfolio created and updated events
+type LedgerPortfolioDetails struct {
+	UserID      uuid.UUID `json:"user_id,omitempty"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+}
+
+// LedgerAssetRef is the data of asset removed events
+type LedgerAssetRef struct {
+	AssetID uuid.UUID `json:"asset_id"`
+}
+
+// LedgerCostBasis is the data of cost basis adjusted events
+type LedgerCostBasis struct {
+	AssetID   uuid.UUID       `json:"asset_id"`
+	CostBasis decimal.Decimal `json:"cost_basis"`
+}
+
+// LedgerState is a portfolio read model rebuilt by folding its ledger.
+// Valuations depend on market prices and are not part of the ledger, so
+// assets keep the values they were recorded with.
+type LedgerState struct {
+	Portfolio    *Portfolio    `json:"portfolio"`
+	Transactions []Transaction `json:"transactions"`
+	Sequence     int64         `json:"sequence"`
+}
+
+// NewLedgerEvent builds an unsequenced event with data encoded as JSON. The
+// repository assigns the sequence number when appending.
+func NewLedgerEvent(portfolioID uuid.UUID, eventType LedgerEventType, data interface{}, at time.Time) (LedgerEvent, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return LedgerEvent{}, fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	return LedgerEvent{
+		PortfolioID: portfolioID,
+		Type:        eventType,
+		Data:        encoded,
+		RecordedAt:  at.UTC(),
+	}, nil
+}
+
+// Decode unmarshals the event data into v
+func (e LedgerEvent) Decode(v interface{}) error {
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return fmt.Errorf("%w: %s #%d: %v", ErrInvalidLedgerEvent, e.Type, e.Sequence, err)
+	}
+	return nil
+}
+
+// Apply folds the next event of the stream into the state
+func (s *LedgerState) Apply(e LedgerEvent) error {
+	if e.Sequence != s.Sequence+1 {
+		return fmt.Errorf("%w: expected #%d, got #%d", ErrLedgerSequence, s.Sequence+1, e.Sequence)
+	}
+	if s.Portfolio == nil && e.Type != LedgerPortfolioCreated {
+		return fmt.Errorf("%w: %s before PortfolioCreated", ErrInvalidLedgerEvent, e.Type)
+	}
+
+	switch e.Type {
+	case LedgerPortfolioCreated:
+		if s.Portfolio != nil {
+			return fmt.Errorf("%w: portfolio created twice", ErrInvalidLedgerEvent)
+		}
+		var details LedgerPortfolioDetails
+		if err := e.Decode(&details); err != nil {
+			return err
+		}
+		s.Portfolio = &Portfolio{
+			ID:          e.PortfolioID,
+			UserID:      details.UserID,
+			Name:        details.Name,
+			Description: details.Description,
+			Assets:      []Asset{},
+			CreatedAt:   e.RecordedAt,
+		}
+
+	case LedgerPortfolioUpdated:
+		var details LedgerPortfolioDetails
+		if err := e.Decode(&details); err != nil {
+			return err
+		}
+		s.Portfolio.Name = details.Name
+		s.Portfolio.Description = details.Description
+
+	case LedgerPortfolioArchived:
+		if s.Portfolio.ArchivedAt != nil {
+			return fmt.Errorf("%w: portfolio archived twice", ErrInvalidLedgerEvent)
+		}
+		archivedAt := e.RecordedAt
+		s.Portfolio.ArchivedAt = &archivedAt
+
+	case LedgerPortfolioRestored:
+		if s.Portfolio.ArchivedAt == nil {
+			return fmt.Errorf("%w: portfolio restored while not archived", ErrInvalidLedgerEvent)
+		}
+		s.Portfolio.ArchivedAt = nil
+
+	case LedgerAssetAdded:
+		var asset Asset
+		if err := e.Decode(&asset); err != nil {
+			return err
+		}
+		for _, existing := range s.Portfolio.Assets {
+			if existing.ID == asset.ID {
+				return fmt.Errorf("%w: asset %s added twice", ErrInvalidLedgerEvent, asset.ID)
+			}
+		}
+		s.Portfolio.Assets = append(s.Portfolio.Assets, asset)
+
+	case LedgerAssetRemoved:
+		var ref LedgerAssetRef
+		if err := e.Decode(&ref); err != nil {
+			return err
+		}
+		removed := false
+		for i := range s.Portfolio.Assets {
+			if s.Portfolio.Assets[i].ID == ref.AssetID {
+				s.Portfolio.Assets = append(s.Portfolio.Assets[:i], s.Portfolio.Assets[i+1:]...)
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			return fmt.Errorf("%w: asset %s not held", ErrInvalidLedgerEvent, ref.AssetID)
+		}
+
+	case LedgerTransactionRecorded:
+		var t Transaction
+		if err := e.Decode(&t); err != nil {
+			return err
+		}
+		s.Transactions = append(s.Transactions, t)
+
+	case LedgerCostBasisAdjusted:
+		var adjustment LedgerCostBasis
+		if err := e.Decode(&adjustment); err != nil {
+			return err
+		}
+		adjusted := false
+		for i := range s.Portfolio.Assets {
+			if s.Portfolio.Assets[i].ID == adjustment.AssetID {
+				s.Portfolio.Assets[i].CostBasis = adjustment.CostBasis
+				adjusted = true
+				break
+			}
+		}
+		if !adjusted {
+			return fmt.Errorf("%w: asset %s not held", ErrInvalidLedgerEvent, adjustment.AssetID)
+		}
+
+	case LedgerAssetMigrated:
+		var migration LedgerAssetMigration
+		if err := e.Decode(&migration); err != nil {
+			return err
+		}
+		migrated := false
+		for i := range s.Portfolio.Assets {
+			if s.Portfolio.Assets[i].ID == migration.AssetID {
+				s.Portfolio.Assets[i].Symbol = migration.ToSymbol
+				s.Portfolio.Assets[i].Amount = s.Portfolio.Assets[i].Amount.Mul(migration.Ratio)
+				migrated = true
+				break
+			}
+		}
+		if !migrated {
+			return fmt.Errorf("%w: asset %s not held", ErrInvalidLedgerEvent, migration.AssetID)
+		}
+		for i := range s.Transactions {
+			if s.Transactions[i].AssetID == migration.AssetID {
+				s.Transactions[i] = MigrateTransaction(s.Transactions[i], migration.Ratio)
+			}
+		}
+
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidLedgerEvent, e.Type)
+	}
+
+	s.Portfolio.LastUpdated = e.RecordedAt
+	s.Sequence = e.Sequence
+	return nil
+}
+
+// ReplayLedger folds events recorded at or before asOf into a new state.
+// Events must be in sequence order starting at 1; a zero asOf replays all.
+func ReplayLedger(events []LedgerEvent, asOf time.Time) (*LedgerState, error) {
+	state := &LedgerState{}
+	for _, e := range events {
+		if !asOf.IsZero() && e.RecordedAt.After(asOf) {
+			break
+		}
+		if err := state.Apply(e); err != nil {
+			return nil, err
+		}
+	}
+	if state.Portfolio == nil {
+		return nil, fmt.Errorf("%w: no events as of %s", ErrInvalidLedgerEvent, asOf.Format(time.RFC3339))
+	}
+	return state, nil
+}
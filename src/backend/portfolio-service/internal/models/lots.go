@@ -0,0 +1,267 @@
+package models
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// LOT_ACQUISITION_TYPES lists the transaction types that add to a holding,
+// each opening a lot costed at the transaction price. The other supported
+// types dispose of the oldest lots first.
+var LOT_ACQUISITION_TYPES = []string{
+	"buy",
+	"transfer_in",
+	"unstake",
+	"reward",
+	"airdrop",
+	"fork",
+	"interest",
+}
+
+// FeePolicy decides how transaction fees enter cost basis and proceeds
+type FeePolicy string
+
+const (
+	// FeePolicyCapitalize adds the fee of a buy to the cost of the lot it
+	// opens and deducts the fee of a sell from its proceeds
+	FeePolicyCapitalize FeePolicy = "capitalize"
+	// FeePolicyExpense keeps fees out of cost basis and proceeds; they are
+	// only reported as fees paid
+	FeePolicyExpense FeePolicy = "expense"
+)
+
+// Lot is a quantity of an asset acquired by one transaction and not yet
+// disposed of. Lots carried over from archived history have no transaction.
+type Lot struct {
+	AssetID       uuid.UUID       `json:"asset_id"`
+	TransactionID uuid.UUID       `json:"transaction_id"`
+	AcquiredAt    time.Time       `json:"acquired_at"`
+	Quantity      decimal.Decimal `json:"quantity"`
+	CostBasis     decimal.Decimal `json:"cost_basis"`
+	// WashSaleAdjustment is the disallowed loss of wash sales added to
+	// CostBasis, set only by replays applying a wash-sale rule
+	WashSaleAdjustment decimal.Decimal `json:"wash_sale_adjustment"`
+}
+
+// LotDisposal is the part of a disposal taken from open lots: the quantity
+// consumed and its cost basis. Proceeds are set for sells only, net of the
+// share of the fee capitalized with them.
+type LotDisposal struct {
+	TransactionID uuid.UUID       `json:"transaction_id"`
+	Type          string          `json:"type"`
+	DisposedAt    time.Time       `json:"disposed_at"`
+	Quantity      decimal.Decimal `json:"quantity"`
+	CostBasis     decimal.Decimal `json:"cost_basis"`
+	Proceeds      decimal.Decimal `json:"proceeds"`
+	// Lots are the parts of the lots consumed, oldest first
+	Lots []Lot `json:"lots"`
+	// Disallowed is the part of a sell's loss a wash-sale rule disallowed
+	// and added to the cost of Replacements; the sell realizes its
+	// proceeds less its cost basis plus Disallowed
+	Disallowed   decimal.Decimal `json:"disallowed"`
+	Replacements []uuid.UUID     `json:"replacements"`
+}
+
+// LotReplay is the state of an asset's lots after folding its transactions
+type LotReplay struct {
+	Lots        []Lot           `json:"lots"`
+	Disposals   []LotDisposal   `json:"disposals"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	CostBasis   decimal.Decimal `json:"cost_basis"`
+	RealizedPnL decimal.Decimal `json:"realized_pnl"`
+	// Shortfall is the quantity disposed of beyond the open lots, which
+	// realizes no gain or loss
+	Shortfall decimal.Decimal `json:"shortfall"`
+}
+
+// LotRecomputation is the outcome of recomputing an asset after one of its
+// transactions was edited or deleted
+type LotRecomputation struct {
+	AssetID     uuid.UUID       `json:"asset_id"`
+	Amount      decimal.Decimal `json:"amount"`
+	CostBasis   decimal.Decimal `json:"cost_basis"`
+	RealizedPnL decimal.Decimal `json:"realized_pnl"`
+	Lots        []Lot           `json:"lots"`
+	Disposals   []LotDisposal   `json:"disposals"`
+}
+
+// Disposal returns the disposal of the replay made by a transaction
+func (r LotReplay) Disposal(transactionID uuid.UUID) (LotDisposal, bool) {
+	for _, disposal := range r.Disposals {
+		if disposal.TransactionID == transactionID {
+			return disposal, true
+		}
+	}
+	return LotDisposal{}, false
+}
+
+// IsLotAcquisition reports whether a transaction type adds to a holding
+func IsLotAcquisition(transactionType string) bool {
+	for _, acquisition := range LOT_ACQUISITION_TYPES {
+		if transactionType == acquisition {
+			return true
+		}
+	}
+	return false
+}
+
+// NetQuantity returns the quantity acquired by transactions less the
+// quantity they disposed of
+func NetQuantity(txs []Transaction) decimal.Decimal {
+	net := decimal.Zero
+	for _, tx := range txs {
+		if IsLotAcquisition(tx.Type) {
+			net = net.Add(tx.Amount)
+		} else {
+			net = net.Sub(tx.Amount)
+		}
+	}
+	return net
+}
+
+// SortTransactions orders transactions by timestamp, breaking ties by ID so
+// replays of the same transactions always agree
+func SortTransactions(txs []Transaction) {
+	sort.SliceStable(txs, func(i, j int) bool {
+		if !txs[i].Timestamp.Equal(txs[j].Timestamp) {
+			return txs[i].Timestamp.Before(txs[j].Timestamp)
+		}
+		return bytes.Compare(txs[i].ID[:], txs[j].ID[:]) < 0
+	})
+}
+
+// ReplayLots folds the transactions of one asset into FIFO lots, starting
+// from the opening lots carried over from archived history. Acquisitions
+// open a lot at the transaction price; disposals consume the oldest lots
+// first, and sells realize their proceeds less the cost consumed. Fees of
+// buys and sells are capitalized or left out by policy.
+func ReplayLots(opening []Lot, txs []Transaction, policy FeePolicy) LotReplay {
+	return ReplayLotsWithWashSales(opening, txs, policy, nil)
+}
+
+// ReplayLotsWithWashSales replays lots like ReplayLots, and when rule is set
+// disallows the losses of sells replaced by purchases within its window,
+// adding them to the cost of the replacement lots
+func ReplayLotsWithWashSales(opening []Lot, txs []Transaction, policy FeePolicy, rule *WashSaleRule) LotReplay {
+	lots := make([]Lot, 0, len(opening)+len(txs))
+	for _, lot := range opening {
+		if lot.Quantity.IsPositive() {
+			lots = append(lots, lot)
+		}
+	}
+
+	ordered := make([]Transaction, len(txs))
+	copy(ordered, txs)
+	SortTransactions(ordered)
+
+	replay := LotReplay{
+		RealizedPnL: decimal.Zero,
+		Shortfall:   decimal.Zero,
+	}
+	replacements := newWashSaleReplacements(ordered)
+	for i, tx := range ordered {
+		capitalized := policy == FeePolicyCapitalize && tx.Fee.IsPositive()
+		if IsLotAcquisition(tx.Type) {
+			cost := tx.Amount.Mul(tx.Price)
+			if capitalized && tx.Type == "buy" {
+				cost = cost.Add(tx.Fee)
+			}
+			adjustment := replacements.pending[tx.ID]
+			lots = append(lots, Lot{
+				AssetID:            tx.AssetID,
+				TransactionID:      tx.ID,
+				AcquiredAt:         tx.Timestamp,
+				Quantity:           tx.Amount,
+				CostBasis:          cost.Add(adjustment),
+				WashSaleAdjustment: adjustment,
+			})
+			continue
+		}
+
+		remaining := tx.Amount
+		consumedQuantity, consumedCost := decimal.Zero, decimal.Zero
+		var consumed []Lot
+		for len(lots) > 0 && remaining.IsPositive() {
+			lot := &lots[0]
+			if remaining.LessThan(lot.Quantity) {
+				cost := lot.CostBasis.Mul(remaining).Div(lot.Quantity)
+				part := *lot
+				part.Quantity, part.CostBasis = remaining, cost
+				consumed = append(consumed, part)
+				lot.Quantity = lot.Quantity.Sub(remaining)
+				lot.CostBasis = lot.CostBasis.Sub(cost)
+				consumedQuantity = consumedQuantity.Add(remaining)
+				consumedCost = consumedCost.Add(cost)
+				remaining = decimal.Zero
+				break
+			}
+			consumed = append(consumed, *lot)
+			remaining = remaining.Sub(lot.Quantity)
+			consumedQuantity = consumedQuantity.Add(lot.Quantity)
+			consumedCost = consumedCost.Add(lot.CostBasis)
+			lots = lots[1:]
+		}
+		replay.Shortfall = replay.Shortfall.Add(remaining)
+
+		disposal := LotDisposal{
+			TransactionID: tx.ID,
+			Type:          tx.Type,
+			DisposedAt:    tx.Timestamp,
+			Quantity:      consumedQuantity,
+			CostBasis:     consumedCost,
+			Proceeds:      decimal.Zero,
+			Lots:          consumed,
+			Disallowed:    decimal.Zero,
+		}
+		if tx.Type == "sell" {
+			disposal.Proceeds = consumedQuantity.Mul(tx.Price)
+			if capitalized && consumedQuantity.IsPositive() {
+				// The share of the fee for the quantity sold beyond the lots
+				// realizes nothing, like that quantity
+				disposal.Proceeds = disposal.Proceeds.Sub(tx.Fee.Mul(consumedQuantity).Div(tx.Amount))
+			}
+			if rule != nil {
+				rule.disallow(&disposal, lots, ordered[i+1:], replacements)
+			}
+			replay.RealizedPnL = replay.RealizedPnL.Add(disposal.Proceeds.Sub(consumedCost).Add(disposal.Disallowed))
+		}
+		replay.Disposals = append(replay.Disposals, disposal)
+	}
+
+	replay.Lots = lots
+	replay.Quantity, replay.CostBasis = decimal.Zero, decimal.Zero
+	for _, lot := range lots {
+		replay.Quantity = replay.Quantity.Add(lot.Quantity)
+		replay.CostBasis = replay.CostBasis.Add(lot.CostBasis)
+	}
+	return replay
+}
+
+// RecomputeLots restates an asset after its transactions changed from
+// before to after, with fees treated by policy. Its cost basis moves by the
+// difference between the two replays and its amount by the change in net
+// quantity, so holdings not derived from transactions, such as a share of
+// cost basis taken by a fork, are kept. Transactions earlier than the change
+// replay identically, so only the lots from that point forward differ.
+func RecomputeLots(asset Asset, opening []Lot, before, after []Transaction, policy FeePolicy) LotRecomputation {
+	previous := ReplayLots(opening, before, policy)
+	current := ReplayLots(opening, after, policy)
+
+	costBasis := asset.CostBasis.Add(current.CostBasis.Sub(previous.CostBasis))
+	if costBasis.IsNegative() {
+		costBasis = decimal.Zero
+	}
+
+	return LotRecomputation{
+		AssetID:     asset.ID,
+		Amount:      asset.Amount.Add(NetQuantity(after).Sub(NetQuantity(before))),
+		CostBasis:   costBasis,
+		RealizedPnL: current.RealizedPnL,
+		Lots:        current.Lots,
+		Disposals:   current.Disposals,
+	}
+}
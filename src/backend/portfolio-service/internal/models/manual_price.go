@@ -0,0 +1,92 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// MANUAL_PRICE_SOURCE is the price source reported for assets valued at a
+// manual price
+const MANUAL_PRICE_SOURCE = "manual"
+
+// MAX_MANUAL_PRICE_NOTE_LENGTH limits the length of a manual price note in
+// characters
+var MAX_MANUAL_PRICE_NOTE_LENGTH = 500
+
+// ErrInvalidManualPrice is returned for malformed manual prices
+var ErrInvalidManualPrice = errors.New("invalid manual price")
+
+// ManualPrice is a user-supplied price of a symbol held in a portfolio, for
+// assets such as illiquid tokens or private placements that no market data
+// provider quotes. It is valid from ValidFrom until ValidUntil, or
+// indefinitely when ValidUntil is nil.
+type ManualPrice struct {
+	ID          uuid.UUID       `json:"id"`
+	PortfolioID uuid.UUID       `json:"portfolio_id"`
+	Symbol      string          `json:"symbol"`
+	Price       decimal.Decimal `json:"price"`
+	ValidFrom   time.Time       `json:"valid_from"`
+	ValidUntil  *time.Time      `json:"valid_until,omitempty"`
+	Note        string          `json:"note,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// Validate normalizes the symbol and checks the price and its validity window
+func (p *ManualPrice) Validate() error {
+	p.Symbol = strings.ToUpper(strings.TrimSpace(p.Symbol))
+	if p.Symbol == "" {
+		return InvalidField("symbol", fmt.Errorf("%w: symbol is required", ErrInvalidManualPrice))
+	}
+	if p.Price.IsNegative() {
+		return InvalidField("price", fmt.Errorf("%w: price cannot be negative", ErrInvalidManualPrice))
+	}
+	if p.ValidFrom.IsZero() {
+		return InvalidField("valid_from", fmt.Errorf("%w: valid_from is required", ErrInvalidManualPrice))
+	}
+	if p.ValidUntil != nil && !p.ValidUntil.After(p.ValidFrom) {
+		return InvalidField("valid_until", fmt.Errorf("%w: valid_until must be after valid_from", ErrInvalidManualPrice))
+	}
+	if len([]rune(p.Note)) > MAX_MANUAL_PRICE_NOTE_LENGTH {
+		return InvalidField("note", fmt.Errorf("%w: note exceeds %d characters", ErrInvalidManualPrice, MAX_MANUAL_PRICE_NOTE_LENGTH))
+	}
+	return nil
+}
+
+// ValidAt reports whether the price is valid at t
+func (p ManualPrice) ValidAt(t time.Time) bool {
+	return !t.Before(p.ValidFrom) && (p.ValidUntil == nil || t.Before(*p.ValidUntil))
+}
+
+// ApplyManualPrices adds to prices the manual price valid at t of each symbol
+// without one, preferring the latest ValidFrom when several are valid.
+// Symbols that already have a price keep it. It returns the symbols priced
+// manually, in order of first appearance in manual.
+func ApplyManualPrices(prices map[string]decimal.Decimal, manual []ManualPrice, t time.Time) []string {
+	chosen := make(map[string]ManualPrice)
+	var symbols []string
+	for _, price := range manual {
+		if !price.ValidAt(t) {
+			continue
+		}
+		if _, quoted := prices[price.Symbol]; quoted {
+			continue
+		}
+		current, ok := chosen[price.Symbol]
+		if !ok {
+			symbols = append(symbols, price.Symbol)
+		}
+		if !ok || price.ValidFrom.After(current.ValidFrom) {
+			chosen[price.Symbol] = price
+		}
+	}
+
+	for _, symbol := range symbols {
+		prices[symbol] = chosen[symbol].Price
+	}
+	return symbols
+}
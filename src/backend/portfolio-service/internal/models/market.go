@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// PricePoint is the closing market price of a symbol for one period
+type PricePoint struct {
+	Symbol    string          `json:"symbol"`
+	Timestamp time.Time       `json:"timestamp"`
+	Close     decimal.Decimal `json:"close"`
+}
+
+// PriceBar is the daily OHLCV bar of a symbol as reported by a market data
+// provider, starting at Day (UTC midnight)
+type PriceBar struct {
+	Symbol string          `json:"symbol"`
+	Day    time.Time       `json:"day"`
+	Open   decimal.Decimal `json:"open"`
+	High   decimal.Decimal `json:"high"`
+	Low    decimal.Decimal `json:"low"`
+	Close  decimal.Decimal `json:"close"`
+	Volume decimal.Decimal `json:"volume"` // quote volume in USD
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// NotificationEvent identifies what a notification reports
+type NotificationEvent string
+
+const (
+	// EventAlertTriggered reports a portfolio alert rule triggering
+	EventAlertTriggered NotificationEvent = "alert.triggered"
+	// EventDriftBreached reports an allocation drifting beyond its threshold
+	EventDriftBreached NotificationEvent = "drift.breached"
+	// EventPortfolioCreated reports a new portfolio
+	EventPortfolioCreated NotificationEvent = "portfolio.created"
+	// EventReportReady reports a scheduled performance report ready for download
+	EventReportReady NotificationEvent = "report.ready"
+)
+
+// Notification is a message about a portfolio delivered to external channels.
+// Attributes carry event specific values such as the alert kind.
+type Notification struct {
+	ID          uuid.UUID         `json:"id"`
+	Event       NotificationEvent `json:"event"`
+	PortfolioID uuid.UUID         `json:"portfolio_id"`
+	UserID      uuid.UUID         `json:"user_id"`
+	Subject     string            `json:"subject"`
+	Body        string            `json:"body"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// NotificationDeadLetter is a notification a sender failed to deliver after
+// all retries, kept for inspection and redelivery
+type NotificationDeadLetter struct {
+	ID           uuid.UUID    `json:"id"`
+	Sender       string       `json:"sender"`
+	Notification Notification `json:"notification"`
+	Error        string       `json:"error"`
+	Attempts     int          `json:"attempts"`
+	FailedAt     time.Time    `json:"failed_at"`
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// OutboxEventType identifies a domain event published to other services
+type OutboxEventType string
+
+const (
+	// OutboxPortfolioCreated is published when a portfolio is created
+	OutboxPortfolioCreated OutboxEventType = "PortfolioCreated"
+	// OutboxAssetAdded is published when an asset is added to a portfolio
+	OutboxAssetAdded OutboxEventType = "AssetAdded"
+	// OutboxTransactionRecorded is published when a transaction is recorded
+	OutboxTransactionRecorded OutboxEventType = "TransactionRecorded"
+	// OutboxUserDataPurged is published to the compliance pipeline once all
+	// portfolio data of a user was erased; it has no portfolio ID
+	OutboxUserDataPurged OutboxEventType = "UserDataPurged"
+)
+
+// OutboxEvent is a domain event stored in the same database transaction as
+// the mutation it describes and published asynchronously. Payload is the
+// JSON envelope sent to consumers; ID lets them drop redelivered events.
+type OutboxEvent struct {
+	ID          uuid.UUID       `json:"id"`
+	PortfolioID uuid.UUID       `json:"portfolio_id"`
+	Type        OutboxEventType `json:"type"`
+	Payload     []byte          `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+	PublishedAt *time.Time      `json:"published_at,omitempty"`
+}
+
+// outboxEnvelope is the published representation of an outbox event
+type outboxEnvelope struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        OutboxEventType `json:"type"`
+	PortfolioID uuid.UUID       `json:"portfolio_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Data        interface{}     `json:"data"`
+}
+
+// NewOutboxEvent builds an event with its envelope encoded as the payload
+func NewOutboxEvent(eventType OutboxEventType, portfolioID uuid.UUID, data interface{}, at time.Time) (*OutboxEvent, error) {
+	event := &OutboxEvent{
+		ID:          uuid.New(),
+		PortfolioID: portfolioID,
+		Type:        eventType,
+		CreatedAt:   at.UTC(),
+	}
+
+	payload, err := json.Marshal(outboxEnvelope{
+		ID:          event.ID,
+		Type:        eventType,
+		PortfolioID: portfolioID,
+		OccurredAt:  event.CreatedAt,
+		Data:        data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	event.Payload = payload
+	return event, nil
+}
+
+// PortfolioCreatedEvent describes a new portfolio. Names and descriptions are
+// left out; consumers needing them read the portfolio.
+func PortfolioCreatedEvent(p *Portfolio) (*OutboxEvent, error) {
+	return NewOutboxEvent(OutboxPortfolioCreated, p.ID, map[string]interface{}{
+		"user_id":     p.UserID,
+		"asset_count": len(p.Assets),
+		"created_at":  p.CreatedAt,
+	}, p.CreatedAt)
+}
+
+// AssetAddedEvent describes an asset added to a portfolio
+func AssetAddedEvent(portfolioID uuid.UUID, a *Asset) (*OutboxEvent, error) {
+	return NewOutboxEvent(OutboxAssetAdded, portfolioID, map[string]interface{}{
+		"asset_id":   a.ID,
+		"type":       a.Type,
+		"symbol":     a.Symbol,
+		"amount":     a.Amount,
+		"cost_basis": a.CostBasis,
+	}, time.Now())
+}
+
+// TransactionRecordedEvent describes a recorded portfolio transaction
+func TransactionRecordedEvent(t *Transaction) (*OutboxEvent, error) {
+	return NewOutboxEvent(OutboxTransactionRecorded, t.PortfolioID, map[string]interface{}{
+		"transaction_id": t.ID,
+		"asset_id":       t.AssetID,
+		"type":           t.Type,
+		"amount":         t.Amount,
+		"price":          t.Price,
+		"fee":            t.Fee,
+		"timestamp":      t.Timestamp,
+	}, time.Now())
+}
+
+// UserDataPurgedEvent describes a completed erasure of a user's data
+func UserDataPurgedEvent(p *UserDataPurge) (*OutboxEvent, error) {
+	return NewOutboxEvent(OutboxUserDataPurged, uuid.Nil, map[string]interface{}{
+		"purge_id":      p.ID,
+		"user_id":       p.UserID,
+		"portfolios":    p.Portfolios,
+		"assets":        p.Assets,
+		"transactions":  p.Transactions,
+		"snapshots":     p.Snapshots,
+		"alerts":        p.Alerts,
+		"audit_entries": p.AuditEntries,
+		"archives":      p.Archives,
+		"completed_at":  p.CompletedAt,
+	}, p.CompletedAt)
+}
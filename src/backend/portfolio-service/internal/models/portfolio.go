@@ -19,6 +19,7 @@ var (
 		"nft",
 		"defi_lp",
 		"staked_asset",
+		FIAT_ASSET_TYPE,
 	}
 
 	// SUPPORTED_TRANSACTION_TYPES defines valid transaction operations
@@ -31,11 +32,11 @@ var (
 		"unstake",
 		"reward",
 		"fee",
+		"airdrop",
+		"fork",
+		"interest",
 	}
 
-	// MIN_TRANSACTION_AMOUNT defines the smallest allowed transaction value
-	MIN_TRANSACTION_AMOUNT = decimal.NewFromFloat(0.000000001)
-
 	// MAX_ASSETS_PER_PORTFOLIO defines the maximum number of assets per portfolio
 	MAX_ASSETS_PER_PORTFOLIO = 1000
 
@@ -43,7 +44,7 @@ var (
 	ErrInvalidAssetType      = errors.New("invalid asset type")
 	ErrInvalidTransactionType = errors.New("invalid transaction type")
 	ErrPortfolioFull         = errors.New("portfolio has reached maximum asset limit")
-	ErrInvalidAmount         = errors.New("transaction amount below minimum threshold")
+	ErrInvalidAmount         = errors.New("amount below minimum or beyond asset precision")
 )
 
 // Asset represents a single asset holding within a portfolio
@@ -55,6 +56,18 @@ type Asset struct {
 	CostBasis     decimal.Decimal `json:"cost_basis"`
 	CurrentValue  decimal.Decimal `json:"current_value"`
 	LastUpdated   time.Time      `json:"last_updated"`
+
+	// PriceAsOf is when the price valuing the asset was last returned by a
+	// market data provider, and PriceStale is set once that is too long
+	// ago. Unset when price freshness is not tracked.
+	PriceAsOf  *time.Time `json:"price_as_of,omitempty"`
+	PriceStale bool       `json:"price_stale,omitempty"`
+	// PriceSource names the market data provider that supplied the price,
+	// MANUAL_PRICE_SOURCE for a manual price, empty when unknown
+	PriceSource string `json:"price_source,omitempty"`
+	// Delisted is set once no provider quotes the symbol anymore; the asset
+	// is then valued by the delisted price policy as of PriceAsOf
+	Delisted bool `json:"delisted,omitempty"`
 }
 
 // Transaction represents a portfolio transaction
@@ -81,6 +94,22 @@ type Portfolio struct {
 	ProfitLoss  decimal.Decimal `json:"profit_loss"`
 	LastUpdated time.Time      `json:"last_updated"`
 	CreatedAt   time.Time      `json:"created_at"`
+
+	// Materialized value change percentages, maintained on snapshot writes.
+	// Invalid when not enough snapshot history exists for the window.
+	Change24h decimal.NullDecimal `json:"change_24h"`
+	Change7d  decimal.NullDecimal `json:"change_7d"`
+	Change30d decimal.NullDecimal `json:"change_30d"`
+
+	// ArchivedAt is set while the portfolio is archived and hidden from
+	// default listings
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+
+	// PricesAsOf is when the oldest price valuing the portfolio was last
+	// returned by a market data provider, and PricesStale is set once that
+	// is too long ago. Unset when price freshness is not tracked.
+	PricesAsOf  *time.Time `json:"prices_as_of,omitempty"`
+	PricesStale bool       `json:"prices_stale,omitempty"`
 }
 
 // NewPortfolio creates a new portfolio instance with initialized values
@@ -132,6 +161,13 @@ func ValidateTransactionType(transactionType, assetType string) error {
 		if transactionType != "stake" && transactionType != "unstake" && transactionType != "reward" {
 			return fmt.Errorf("invalid transaction type %s for staked assets", transactionType)
 		}
+	case FIAT_ASSET_TYPE:
+		for _, supported := range FIAT_TRANSACTION_TYPES {
+			if transactionType == supported {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid transaction type %s for fiat balances", transactionType)
 	}
 
 	return nil
@@ -146,7 +182,7 @@ func (p *Portfolio) AddAsset(asset Asset) error {
 		return ErrPortfolioFull
 	}
 
-	if err := ValidateAssetType(asset.Type); err != nil {
+	if err := ValidateHoldingType(asset.Type); err != nil {
 		return err
 	}
 
@@ -155,6 +191,22 @@ func (p *Portfolio) AddAsset(asset Asset) error {
 	return nil
 }
 
+// RemoveAsset removes an asset from the portfolio and returns it
+func (p *Portfolio) RemoveAsset(assetID uuid.UUID) (*Asset, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for i := range p.Assets {
+		if p.Assets[i].ID == assetID {
+			removed := p.Assets[i]
+			p.Assets = append(p.Assets[:i], p.Assets[i+1:]...)
+			p.LastUpdated = time.Now().UTC()
+			return &removed, nil
+		}
+	}
+	return nil, fmt.Errorf("asset not found: %s", assetID)
+}
+
 // CalculateTotalValue computes the total portfolio value
 func (p *Portfolio) CalculateTotalValue(currentPrices map[string]decimal.Decimal) decimal.Decimal {
 	p.mutex.Lock()
@@ -209,9 +261,12 @@ func (p *Portfolio) UpdateAsset(assetID uuid.UUID, amount decimal.Decimal) error
 
 	for i := range p.Assets {
 		if p.Assets[i].ID == assetID {
-			if amount.LessThan(MIN_TRANSACTION_AMOUNT) && !amount.IsZero() {
+			if amount.IsNegative() {
 				return ErrInvalidAmount
 			}
+			if err := ValidateAmountPrecision(p.Assets[i].Type, p.Assets[i].Symbol, amount); err != nil {
+				return err
+			}
 			p.Assets[i].Amount = amount
 			p.Assets[i].LastUpdated = time.Now().UTC()
 			p.LastUpdated = time.Now().UTC()
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+var (
+	// DEFAULT_AMOUNT_DECIMALS is the precision of amounts of assets without
+	// precision metadata, matching the 18 decimals of most tokens
+	DEFAULT_AMOUNT_DECIMALS int32 = 18
+
+	// ASSET_TYPE_DECIMALS sets the precision of asset types whose amounts
+	// are counted the same way whatever the symbol
+	ASSET_TYPE_DECIMALS = map[string]int32{
+		"nft":           0,
+		FIAT_ASSET_TYPE: 2,
+	}
+
+	// SYMBOL_DECIMALS sets the precision of well-known symbols, the smallest
+	// unit their native chain or issuer divides them into
+	SYMBOL_DECIMALS = map[string]int32{
+		"BTC":  8,
+		"LTC":  8,
+		"BCH":  8,
+		"DOGE": 8,
+		"ETH":  18,
+		"SOL":  9,
+		"ADA":  6,
+		"XRP":  6,
+		"TRX":  6,
+		"ATOM": 6,
+		"DOT":  10,
+		"USDT": 6,
+		"USDC": 6,
+		"SHIB": 18,
+		"PEPE": 18,
+	}
+)
+
+// AmountDecimals returns how many fractional digits amounts of an asset may
+// carry: set by the asset type for NFTs and fiat, and by the symbol otherwise
+func AmountDecimals(assetType, symbol string) int32 {
+	if decimals, ok := ASSET_TYPE_DECIMALS[assetType]; ok {
+		return decimals
+	}
+	if decimals, ok := SYMBOL_DECIMALS[strings.ToUpper(symbol)]; ok {
+		return decimals
+	}
+	return DEFAULT_AMOUNT_DECIMALS
+}
+
+// MinAmount returns the smallest nonzero amount of an asset, one unit of its
+// last fractional digit
+func MinAmount(assetType, symbol string) decimal.Decimal {
+	return decimal.New(1, -AmountDecimals(assetType, symbol))
+}
+
+// ValidateAmountPrecision checks that a nonzero amount is at least the
+// asset's minimum amount and carries no more fractional digits than its
+// precision
+func ValidateAmountPrecision(assetType, symbol string, amount decimal.Decimal) error {
+	if amount.IsZero() {
+		return nil
+	}
+	decimals := AmountDecimals(assetType, symbol)
+	if amount.Abs().LessThan(MinAmount(assetType, symbol)) {
+		return fmt.Errorf("%w: %s amounts must be at least %s", ErrInvalidAmount, symbol, MinAmount(assetType, symbol))
+	}
+	if !amount.Equal(amount.Truncate(decimals)) {
+		return fmt.Errorf("%w: %s amounts have at most %d decimals", ErrInvalidAmount, symbol, decimals)
+	}
+	return nil
+}
+
+// FormatAmount serializes an amount of an asset rounded to its precision
+func FormatAmount(assetType, symbol string, amount decimal.Decimal) string {
+	return amount.Round(AmountDecimals(assetType, symbol)).String()
+}
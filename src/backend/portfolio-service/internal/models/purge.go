@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// UserDataPurge records the permanent erasure of a user's portfolio data,
+// with the number of records removed of each kind. It holds no personal
+// data besides the user ID, so it is kept as proof of the erasure.
+type UserDataPurge struct {
+	ID           uuid.UUID `json:"id"`
+	UserID       uuid.UUID `json:"user_id"`
+	Portfolios   int       `json:"portfolios"`
+	Assets       int       `json:"assets"`
+	Transactions int       `json:"transactions"`
+	Snapshots    int       `json:"snapshots"`
+	Alerts       int       `json:"alerts"`
+	AuditEntries int       `json:"audit_entries"`
+	Archives     int       `json:"archives"`
+	CompletedAt  time.Time `json:"completed_at"`
+
+	// ArchiveKeys are the object keys of the removed archives, whose objects
+	// are deleted once the purge committed
+	ArchiveKeys []string `json:"-"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// RealizedGain is the gain or loss a sell realized against the lots it
+// disposed of, stored when the sell is recorded so reports read it rather
+// than replaying lots. Quantity sold beyond the lots is not part of it.
+type RealizedGain struct {
+	ID            uuid.UUID       `json:"id"`
+	PortfolioID   uuid.UUID       `json:"portfolio_id"`
+	AssetID       uuid.UUID       `json:"asset_id"`
+	TransactionID uuid.UUID       `json:"transaction_id"`
+	Symbol        string          `json:"symbol"`
+	Quantity      decimal.Decimal `json:"quantity"`
+	CostBasis     decimal.Decimal `json:"cost_basis"`
+	Proceeds      decimal.Decimal `json:"proceeds"`
+	// Gain is Proceeds less CostBasis; negative for a loss
+	Gain       decimal.Decimal `json:"gain"`
+	DisposedAt time.Time       `json:"disposed_at"`
+	RecordedAt time.Time       `json:"recorded_at"`
+}
+
+// RealizedGainsSince returns the gains realized by the sells among the
+// disposals of a replay of an asset's lots made at or after from
+func RealizedGainsSince(portfolioID, assetID uuid.UUID, symbol string, disposals []LotDisposal, from, at time.Time) []RealizedGain {
+	var gains []RealizedGain
+	for _, disposal := range disposals {
+		if disposal.Type != "sell" || disposal.DisposedAt.Before(from) {
+			continue
+		}
+		gains = append(gains, RealizedGain{
+			ID:            uuid.New(),
+			PortfolioID:   portfolioID,
+			AssetID:       assetID,
+			TransactionID: disposal.TransactionID,
+			Symbol:        symbol,
+			Quantity:      disposal.Quantity,
+			CostBasis:     disposal.CostBasis,
+			Proceeds:      disposal.Proceeds,
+			Gain:          disposal.Proceeds.Sub(disposal.CostBasis),
+			DisposedAt:    disposal.DisposedAt,
+			RecordedAt:    at,
+		})
+	}
+	return gains
+}
@@ -0,0 +1,340 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// ReportPeriod is the calendar period covered by a performance report
+type ReportPeriod string
+
+const (
+	ReportPeriodWeek    ReportPeriod = "week"
+	ReportPeriodMonth   ReportPeriod = "month"
+	ReportPeriodQuarter ReportPeriod = "quarter"
+	ReportPeriodYear    ReportPeriod = "year"
+)
+
+// REPORT_TOP_MOVERS bounds the number of top movers listed in a report
+const REPORT_TOP_MOVERS = 5
+
+// ReportPeriodBounds returns the start and end of the week, calendar month,
+// quarter or year containing t, in the location of t. Weeks start on Monday.
+func ReportPeriodBounds(period ReportPeriod, t time.Time) (time.Time, time.Time, error) {
+	loc := t.Location()
+	switch period {
+	case ReportPeriodWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		start := time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 0, 7), nil
+	case ReportPeriodMonth:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 1, 0), nil
+	case ReportPeriodQuarter:
+		month := time.Month((int(t.Month())-1)/3*3 + 1)
+		start := time.Date(t.Year(), month, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 3, 0), nil
+	case ReportPeriodYear:
+		start := time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(1, 0, 0), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("unsupported report period %q", period)
+}
+
+// LastCompletedReportPeriod returns the bounds of the latest period that
+// ended at or before t, in the location of t
+func LastCompletedReportPeriod(period ReportPeriod, t time.Time) (time.Time, time.Time, error) {
+	current, _, err := ReportPeriodBounds(period, t)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return ReportPeriodBounds(period, current.Add(-time.Nanosecond))
+}
+
+// ReportHolding is a position held at the end of a report period
+type ReportHolding struct {
+	Symbol     string          `json:"symbol"`
+	Amount     decimal.Decimal `json:"amount"`
+	Value      decimal.Decimal `json:"value"`
+	CostBasis  decimal.Decimal `json:"cost_basis"`
+	ProfitLoss decimal.Decimal `json:"profit_loss"`
+	// Weight is the percentage of the portfolio value, rounded to two decimals
+	Weight decimal.Decimal `json:"weight"`
+}
+
+// ReportMover is the price change over a report period of an asset held at
+// both its start and end
+type ReportMover struct {
+	Symbol        string          `json:"symbol"`
+	StartPrice    decimal.Decimal `json:"start_price"`
+	EndPrice      decimal.Decimal `json:"end_price"`
+	ChangePercent decimal.Decimal `json:"change_percent"`
+}
+
+// PerformanceReport summarizes a portfolio's performance over a calendar
+// period: the P&L summary, the holdings and allocation at the end of the
+// period and the assets whose prices moved the most
+type PerformanceReport struct {
+	ID            uuid.UUID       `json:"id"`
+	PortfolioID   uuid.UUID       `json:"portfolio_id"`
+	PortfolioName string          `json:"portfolio_name"`
+	Period        ReportPeriod    `json:"period"`
+	PeriodStart   time.Time       `json:"period_start"`
+	PeriodEnd     time.Time       `json:"period_end"`
+	BaseCurrency  string          `json:"base_currency"`
+	StartValue    decimal.Decimal `json:"start_value"`
+	EndValue      decimal.Decimal `json:"end_value"`
+	// PeriodProfitLoss is the change in unrealized profit and loss, which
+	// unlike the value change excludes deposits and withdrawals
+	PeriodProfitLoss decimal.Decimal `json:"period_profit_loss"`
+	EndProfitLoss    decimal.Decimal `json:"end_profit_loss"`
+	// ReturnPercentage is PeriodProfitLoss relative to the start value; zero
+	// when the portfolio had no value at the start
+	ReturnPercentage decimal.Decimal `json:"return_percentage"`
+	// FeesPaid totals the fees of the transactions recorded in the period
+	FeesPaid    decimal.Decimal   `json:"fees_paid"`
+	Holdings    []ReportHolding   `json:"holdings"`
+	Allocation  []AllocationSlice `json:"allocation"`
+	TopMovers   []ReportMover     `json:"top_movers"`
+	GeneratedAt time.Time         `json:"generated_at"`
+}
+
+// ReportFormat is the file format of a generated report
+type ReportFormat string
+
+const (
+	ReportFormatPDF ReportFormat = "pdf"
+	ReportFormatCSV ReportFormat = "csv"
+)
+
+// GeneratedReport is a rendered performance report stored for download
+type GeneratedReport struct {
+	Report *PerformanceReport `json:"report"`
+	// ObjectKey locates the rendered file in object storage
+	ObjectKey string `json:"-"`
+	// DownloadURL is a signed URL granting access until ExpiresAt
+	DownloadURL string    `json:"download_url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// BuildPerformanceReport compares the valuation at the start of a period
+// with the one at its end. start is nil when the portfolio has no valuation
+// before the period, in which case it is treated as empty.
+func BuildPerformanceReport(portfolio *Portfolio, period ReportPeriod, periodStart, periodEnd time.Time, start, end *PortfolioSnapshot) *PerformanceReport {
+	if start == nil {
+		start = &PortfolioSnapshot{}
+	}
+
+	report := &PerformanceReport{
+		ID:               uuid.New(),
+		PortfolioID:      portfolio.ID,
+		PortfolioName:    portfolio.Name,
+		Period:           period,
+		PeriodStart:      periodStart,
+		PeriodEnd:        periodEnd,
+		BaseCurrency:     BASE_CURRENCY,
+		StartValue:       start.TotalValue,
+		EndValue:         end.TotalValue,
+		PeriodProfitLoss: end.ProfitLoss.Sub(start.ProfitLoss),
+		EndProfitLoss:    end.ProfitLoss,
+		ReturnPercentage: decimal.Zero,
+		FeesPaid:         decimal.Zero,
+		Holdings:         []ReportHolding{},
+		TopMovers:        []ReportMover{},
+		GeneratedAt:      time.Now().UTC(),
+	}
+	if start.TotalValue.IsPositive() {
+		report.ReturnPercentage = report.PeriodProfitLoss.Div(start.TotalValue).Mul(decimal.NewFromInt(100)).Round(2)
+	}
+
+	total := decimal.Zero
+	for _, asset := range end.Assets {
+		if asset.Value.IsPositive() {
+			total = total.Add(asset.Value)
+		}
+	}
+
+	bySymbol := make(map[string]decimal.Decimal)
+	for _, asset := range end.Assets {
+		if !asset.Amount.IsPositive() {
+			continue
+		}
+		holding := ReportHolding{
+			Symbol:     asset.Symbol,
+			Amount:     asset.Amount,
+			Value:      asset.Value,
+			CostBasis:  asset.CostBasis,
+			ProfitLoss: asset.Value.Sub(asset.CostBasis),
+			Weight:     decimal.Zero,
+		}
+		if asset.Value.IsPositive() {
+			holding.Weight = asset.Value.Div(total).Mul(decimal.NewFromInt(100)).Round(2)
+			bySymbol[asset.Symbol] = bySymbol[asset.Symbol].Add(asset.Value)
+		}
+		report.Holdings = append(report.Holdings, holding)
+	}
+	sort.SliceStable(report.Holdings, func(i, j int) bool {
+		if c := report.Holdings[i].Value.Cmp(report.Holdings[j].Value); c != 0 {
+			return c > 0
+		}
+		return report.Holdings[i].Symbol < report.Holdings[j].Symbol
+	})
+	report.Allocation = allocationSlices(bySymbol, total)
+
+	report.TopMovers = topMovers(start.Assets, end.Assets)
+	return report
+}
+
+// topMovers returns the assets with the largest absolute price change between
+// two valuations. Prices are derived from value and amount.
+func topMovers(start, end []AssetSnapshot) []ReportMover {
+	startPrices := make(map[uuid.UUID]decimal.Decimal, len(start))
+	for _, asset := range start {
+		if asset.Amount.IsPositive() && asset.Value.IsPositive() {
+			startPrices[asset.AssetID] = asset.Value.Div(asset.Amount)
+		}
+	}
+
+	movers := []ReportMover{}
+	for _, asset := range end {
+		startPrice, ok := startPrices[asset.AssetID]
+		if !ok || !asset.Amount.IsPositive() {
+			continue
+		}
+		endPrice := asset.Value.Div(asset.Amount)
+		movers = append(movers, ReportMover{
+			Symbol:        asset.Symbol,
+			StartPrice:    startPrice.Round(8),
+			EndPrice:      endPrice.Round(8),
+			ChangePercent: endPrice.Sub(startPrice).Div(startPrice).Mul(decimal.NewFromInt(100)).Round(2),
+		})
+	}
+
+	sort.SliceStable(movers, func(i, j int) bool {
+		if c := movers[i].ChangePercent.Abs().Cmp(movers[j].ChangePercent.Abs()); c != 0 {
+			return c > 0
+		}
+		return movers[i].Symbol < movers[j].Symbol
+	})
+	if len(movers) > REPORT_TOP_MOVERS {
+		movers = movers[:REPORT_TOP_MOVERS]
+	}
+	return movers
+}
+
+// ErrInvalidReportSchedule is returned for report schedules failing validation
+var ErrInvalidReportSchedule = errors.New("invalid report schedule")
+
+// ReportFrequency is how often a scheduled report is delivered
+type ReportFrequency string
+
+const (
+	ReportWeekly  ReportFrequency = "weekly"
+	ReportMonthly ReportFrequency = "monthly"
+)
+
+// Period returns the report period delivered at the frequency
+func (f ReportFrequency) Period() ReportPeriod {
+	if f == ReportWeekly {
+		return ReportPeriodWeek
+	}
+	return ReportPeriodMonth
+}
+
+// ReportSchedule delivers the performance report of the period just completed
+// to the portfolio owner every week or month. Reports are generated at Hour
+// in the user's Timezone, on Weekday for weekly schedules and on DayOfMonth
+// for monthly ones; periods are also taken in that timezone.
+type ReportSchedule struct {
+	ID          uuid.UUID       `json:"id"`
+	PortfolioID uuid.UUID       `json:"portfolio_id"`
+	UserID      uuid.UUID       `json:"user_id"`
+	Frequency   ReportFrequency `json:"frequency"`
+	Format      ReportFormat    `json:"format"`
+	// Timezone is an IANA time zone name such as "Europe/Berlin"
+	Timezone string `json:"timezone"`
+	Hour     int    `json:"hour"`
+	// Weekday is the delivery day of weekly schedules, 0 being Sunday
+	Weekday int `json:"weekday"`
+	// DayOfMonth is the delivery day of monthly schedules, at most 28 so
+	// every month has it
+	DayOfMonth int        `json:"day_of_month"`
+	NextRunAt  time.Time  `json:"next_run_at"`
+	LastRunAt  *time.Time `json:"last_run_at,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Validate checks the schedule and clears the day field its frequency ignores
+func (r *ReportSchedule) Validate() error {
+	switch r.Frequency {
+	case ReportWeekly:
+		if r.Weekday < 0 || r.Weekday > 6 {
+			return fmt.Errorf("%w: weekday must be between 0 and 6", ErrInvalidReportSchedule)
+		}
+		r.DayOfMonth = 0
+	case ReportMonthly:
+		if r.DayOfMonth < 1 || r.DayOfMonth > 28 {
+			return fmt.Errorf("%w: day of month must be between 1 and 28", ErrInvalidReportSchedule)
+		}
+		r.Weekday = 0
+	default:
+		return fmt.Errorf("%w: unsupported frequency %q", ErrInvalidReportSchedule, r.Frequency)
+	}
+
+	if r.Format != ReportFormatPDF && r.Format != ReportFormatCSV {
+		return fmt.Errorf("%w: unsupported format %q", ErrInvalidReportSchedule, r.Format)
+	}
+	if r.Hour < 0 || r.Hour > 23 {
+		return fmt.Errorf("%w: hour must be between 0 and 23", ErrInvalidReportSchedule)
+	}
+	if _, err := r.Location(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Location returns the time zone of the schedule
+func (r *ReportSchedule) Location() (*time.Location, error) {
+	// LoadLocation treats an empty name as UTC and "Local" as the server zone
+	if r.Timezone == "" || r.Timezone == "Local" {
+		return nil, fmt.Errorf("%w: timezone is required", ErrInvalidReportSchedule)
+	}
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidReportSchedule, r.Timezone)
+	}
+	return loc, nil
+}
+
+// NextRun returns the first delivery time of the schedule after t. Delivery
+// times falling into a daylight saving gap move forward by the gap.
+func (r *ReportSchedule) NextRun(t time.Time) (time.Time, error) {
+	loc, err := r.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	local := t.In(loc)
+
+	switch r.Frequency {
+	case ReportWeekly:
+		days := (r.Weekday - int(local.Weekday()) + 7) % 7
+		next := time.Date(local.Year(), local.Month(), local.Day()+days, r.Hour, 0, 0, 0, loc)
+		if !next.After(t) {
+			next = time.Date(local.Year(), local.Month(), local.Day()+days+7, r.Hour, 0, 0, 0, loc)
+		}
+		return next.UTC(), nil
+	case ReportMonthly:
+		next := time.Date(local.Year(), local.Month(), r.DayOfMonth, r.Hour, 0, 0, 0, loc)
+		if !next.After(t) {
+			next = time.Date(local.Year(), local.Month()+1, r.DayOfMonth, r.Hour, 0, 0, 0, loc)
+		}
+		return next.UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: unsupported frequency %q", ErrInvalidReportSchedule, r.Frequency)
+}
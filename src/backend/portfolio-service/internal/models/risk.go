@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// VaRMethod identifies how a Value-at-Risk estimate was computed
+type VaRMethod string
+
+const (
+	VaRMethodParametric VaRMethod = "parametric"
+	VaRMethodHistorical VaRMethod = "historical"
+)
+
+// VaREstimate is the loss not expected to be exceeded with the given
+// confidence over the horizon, expressed in portfolio currency
+type VaREstimate struct {
+	Method      VaRMethod       `json:"method"`
+	Confidence  float64         `json:"confidence"`
+	HorizonDays int             `json:"horizon_days"`
+	Value       decimal.Decimal `json:"value"`
+}
+
+// RiskMetrics summarizes the market risk of a portfolio's current holdings
+type RiskMetrics struct {
+	PortfolioID      uuid.UUID       `json:"portfolio_id"`
+	CoveredValue     decimal.Decimal `json:"covered_value"`
+	Observations     int             `json:"observations"`
+	WindowStart      time.Time       `json:"window_start"`
+	WindowEnd        time.Time       `json:"window_end"`
+	VaR              []VaREstimate   `json:"var"`
+	UncoveredSymbols []string        `json:"uncovered_symbols"`
+	CalculatedAt     time.Time       `json:"calculated_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// PortfolioShare grants whoever holds its token read-only access to the
+// valuation and allocation of a portfolio until it expires or is revoked.
+// Only a hash of the token is stored.
+type PortfolioShare struct {
+	ID          uuid.UUID  `json:"id"`
+	PortfolioID uuid.UUID  `json:"portfolio_id"`
+	TokenHash   []byte     `json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active reports whether the share grants access at the given time
+func (s PortfolioShare) Active(at time.Time) bool {
+	return s.RevokedAt == nil && at.Before(s.ExpiresAt)
+}
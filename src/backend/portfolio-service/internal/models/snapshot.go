@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// PortfolioSnapshot captures the valuation of a portfolio at a point in time
+type PortfolioSnapshot struct {
+	ID          uuid.UUID       `json:"id"`
+	PortfolioID uuid.UUID       `json:"portfolio_id"`
+	TotalValue  decimal.Decimal `json:"total_value"`
+	ProfitLoss  decimal.Decimal `json:"profit_loss"`
+	Assets      []AssetSnapshot `json:"assets"`
+	CapturedAt  time.Time       `json:"captured_at"`
+}
+
+// AssetSnapshot captures the valuation of a single holding within a snapshot
+type AssetSnapshot struct {
+	AssetID   uuid.UUID       `json:"asset_id"`
+	Symbol    string          `json:"symbol"`
+	Amount    decimal.Decimal `json:"amount"`
+	Value     decimal.Decimal `json:"value"`
+	CostBasis decimal.Decimal `json:"cost_basis"`
+
+	// PriceSource and PriceAsOf record which market data provider supplied
+	// the price behind Value and when, so later discrepancies can be traced
+	PriceSource string     `json:"price_source,omitempty"`
+	PriceAsOf   *time.Time `json:"price_as_of,omitempty"`
+}
+
+// HistoryInterval is the bucket width of a downsampled value history
+type HistoryInterval string
+
+const (
+	HistoryIntervalDay   HistoryInterval = "day"
+	HistoryIntervalWeek  HistoryInterval = "week"
+	HistoryIntervalMonth HistoryInterval = "month"
+)
+
+// ValuePoint is a single bucket of a portfolio value history series,
+// holding the closing valuation of the bucket
+type ValuePoint struct {
+	Timestamp  time.Time       `json:"timestamp"`
+	Value      decimal.Decimal `json:"value"`
+	ProfitLoss decimal.Decimal `json:"profit_loss"`
+}
+
+// BucketStart returns the start of the interval bucket holding t: its UTC
+// day, its ISO week starting on Monday, or its calendar month
+func (i HistoryInterval) BucketStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch i {
+	case HistoryIntervalWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case HistoryIntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// DownsampleValues keeps the closing value of each interval bucket of
+// points, which must be in chronological order, stamped with the start of
+// its bucket
+func DownsampleValues(points []ValuePoint, interval HistoryInterval) []ValuePoint {
+	buckets := make([]ValuePoint, 0, len(points))
+	for _, p := range points {
+		p.Timestamp = interval.BucketStart(p.Timestamp)
+		if n := len(buckets); n > 0 && buckets[n-1].Timestamp.Equal(p.Timestamp) {
+			buckets[n-1] = p
+			continue
+		}
+		buckets = append(buckets, p)
+	}
+	return buckets
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// SUPPORTED_STAKING_SOURCES are where staking rewards are read from: chain
+// data of a stake account, or the staking history of a connected exchange
+// account
+var SUPPORTED_STAKING_SOURCES = []string{"chain", "exchange"}
+
+// StakingPosition links a staked_asset holding to where its rewards are
+// read from, so they are recorded as reward transactions without manual
+// entry. Provider is the chain or exchange name; Address is the stake
+// account or validator on the chain and empty for exchange positions,
+// whose rewards are recorded by the exchange account sync. Cursor is the
+// checkpoint the next accrual of a chain position resumes from.
+type StakingPosition struct {
+	ID            uuid.UUID  `json:"id"`
+	PortfolioID   uuid.UUID  `json:"portfolio_id"`
+	AssetID       uuid.UUID  `json:"asset_id"`
+	Source        string     `json:"source"`
+	Provider      string     `json:"provider"`
+	Address       string     `json:"address,omitempty"`
+	Cursor        string     `json:"cursor"`
+	LastAccruedAt *time.Time `json:"last_accrued_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAccrualAt time.Time  `json:"next_accrual_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ValidateStakingSource checks if the given staking source is supported
+func ValidateStakingSource(source string) error {
+	for _, supported := range SUPPORTED_STAKING_SOURCES {
+		if source == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported staking source: %s", source)
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// PortfolioHoldings are the holdings of a portfolio valued at the latest
+// stored prices, read together with its dust settings for user summaries
+type PortfolioHoldings struct {
+	PortfolioID uuid.UUID
+	Name        string
+	Dust        DustSettings
+	Assets      []Asset
+}
+
+// PortfolioSummary is the valuation of one portfolio within a user summary
+type PortfolioSummary struct {
+	PortfolioID uuid.UUID       `json:"portfolio_id"`
+	Name        string          `json:"name"`
+	TotalValue  decimal.Decimal `json:"total_value"`
+	CostBasis   decimal.Decimal `json:"cost_basis"`
+	ProfitLoss  decimal.Decimal `json:"profit_loss"`
+	// Percentage is the portfolio's share of the user's total value
+	Percentage decimal.Decimal `json:"percentage"`
+}
+
+// UserSummary aggregates the valuation and allocation of all active
+// portfolios of a user
+type UserSummary struct {
+	UserID     uuid.UUID       `json:"user_id"`
+	TotalValue decimal.Decimal `json:"total_value"`
+	CostBasis  decimal.Decimal `json:"cost_basis"`
+	ProfitLoss decimal.Decimal `json:"profit_loss"`
+	// ProfitLossPercentage is relative to the cost basis, zero without one
+	ProfitLossPercentage decimal.Decimal    `json:"profit_loss_percentage"`
+	Portfolios           []PortfolioSummary `json:"portfolios"`
+	// Allocation spans all portfolios; each portfolio's dust is left out of
+	// it as in that portfolio's own allocation
+	Allocation   *Allocation `json:"allocation"`
+	CalculatedAt time.Time   `json:"calculated_at"`
+}
+
+// BuildUserSummary aggregates the holdings of a user's portfolios. Portfolios
+// are ordered by value, largest first.
+func BuildUserSummary(userID uuid.UUID, holdings []PortfolioHoldings) *UserSummary {
+	hundred := decimal.NewFromInt(100)
+	summary := &UserSummary{
+		UserID:     userID,
+		TotalValue: decimal.Zero,
+		CostBasis:  decimal.Zero,
+		ProfitLoss: decimal.Zero,
+		Portfolios: make([]PortfolioSummary, 0, len(holdings)),
+	}
+
+	var kept []Asset
+	dustValue := decimal.Zero
+	for _, h := range holdings {
+		entry := PortfolioSummary{
+			PortfolioID: h.PortfolioID,
+			Name:        h.Name,
+			TotalValue:  decimal.Zero,
+			CostBasis:   decimal.Zero,
+		}
+		for _, asset := range h.Assets {
+			entry.TotalValue = entry.TotalValue.Add(asset.CurrentValue)
+			entry.CostBasis = entry.CostBasis.Add(asset.CostBasis)
+		}
+		entry.ProfitLoss = entry.TotalValue.Sub(entry.CostBasis)
+		summary.Portfolios = append(summary.Portfolios, entry)
+
+		summary.TotalValue = summary.TotalValue.Add(entry.TotalValue)
+		summary.CostBasis = summary.CostBasis.Add(entry.CostBasis)
+
+		portfolioKept, _, portfolioDust := SplitDust(h.Assets, h.Dust)
+		kept = append(kept, portfolioKept...)
+		dustValue = dustValue.Add(portfolioDust)
+	}
+
+	summary.ProfitLoss = summary.TotalValue.Sub(summary.CostBasis)
+	if summary.CostBasis.IsPositive() {
+		summary.ProfitLossPercentage = summary.ProfitLoss.Div(summary.CostBasis).Mul(hundred).Round(2)
+	}
+
+	for i := range summary.Portfolios {
+		if summary.TotalValue.IsPositive() {
+			summary.Portfolios[i].Percentage = summary.Portfolios[i].TotalValue.Div(summary.TotalValue).Mul(hundred).Round(2)
+		}
+	}
+	sort.SliceStable(summary.Portfolios, func(i, j int) bool {
+		return summary.Portfolios[i].TotalValue.Cmp(summary.Portfolios[j].TotalValue) > 0
+	})
+
+	summary.Allocation = BuildAllocation(uuid.Nil, kept)
+	summary.Allocation.DustValue = dustValue
+	summary.CalculatedAt = summary.Allocation.CalculatedAt
+
+	return summary
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// ErrInvalidSymbolMigration is returned for migrations that cannot be applied
+var ErrInvalidSymbolMigration = errors.New("invalid symbol migration")
+
+// SymbolMigration maps a token that migrated contracts or rebranded to its
+// new symbol. Each unit of FromSymbol became Ratio units of ToSymbol at
+// EffectiveAt; rebrands such as MATIC to POL have a ratio of 1.
+type SymbolMigration struct {
+	ID             uuid.UUID       `json:"id"`
+	FromSymbol     string          `json:"from_symbol"`
+	ToSymbol       string          `json:"to_symbol"`
+	Ratio          decimal.Decimal `json:"ratio"`
+	EffectiveAt    time.Time       `json:"effective_at"`
+	AssetsMigrated int             `json:"assets_migrated"`
+	AppliedAt      *time.Time      `json:"applied_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// Validate checks the migration, normalizing its symbols to upper case
+func (m *SymbolMigration) Validate() error {
+	m.FromSymbol = strings.ToUpper(strings.TrimSpace(m.FromSymbol))
+	m.ToSymbol = strings.ToUpper(strings.TrimSpace(m.ToSymbol))
+	if m.FromSymbol == "" || m.ToSymbol == "" {
+		return fmt.Errorf("%w: both symbols are required", ErrInvalidSymbolMigration)
+	}
+	if m.FromSymbol == m.ToSymbol {
+		return fmt.Errorf("%w: %s maps to itself", ErrInvalidSymbolMigration, m.FromSymbol)
+	}
+	if !m.Ratio.IsPositive() {
+		return fmt.Errorf("%w: ratio must be positive", ErrInvalidSymbolMigration)
+	}
+	return nil
+}
+
+// Conflicts reports whether other maps the same symbol differently
+func (m SymbolMigration) Conflicts(other SymbolMigration) bool {
+	return m.FromSymbol == other.FromSymbol && (m.ToSymbol != other.ToSymbol || !m.Ratio.Equal(other.Ratio))
+}
+
+// SymbolMigrationAudit records a holding moved to the new symbol of a
+// migration, with the transactions and archived lots re-linked with it
+type SymbolMigrationAudit struct {
+	MigrationID          uuid.UUID       `json:"migration_id"`
+	PortfolioID          uuid.UUID       `json:"portfolio_id"`
+	AssetID              uuid.UUID       `json:"asset_id"`
+	FromSymbol           string          `json:"from_symbol"`
+	ToSymbol             string          `json:"to_symbol"`
+	Ratio                decimal.Decimal `json:"ratio"`
+	AmountBefore         decimal.Decimal `json:"amount_before"`
+	AmountAfter          decimal.Decimal `json:"amount_after"`
+	TransactionsRelinked int             `json:"transactions_relinked"`
+	LotsRelinked         int             `json:"lots_relinked"`
+	MigratedAt           time.Time       `json:"migrated_at"`
+}
+
+// LedgerAssetMigration is the data of asset migrated events
+type LedgerAssetMigration struct {
+	MigrationID uuid.UUID       `json:"migration_id"`
+	AssetID     uuid.UUID       `json:"asset_id"`
+	FromSymbol  string          `json:"from_symbol"`
+	ToSymbol    string          `json:"to_symbol"`
+	Ratio       decimal.Decimal `json:"ratio"`
+}
+
+// MigrateTransaction returns t restated in units of a migrated token: its
+// amount scaled by ratio and its price divided by it, keeping its value
+func MigrateTransaction(t Transaction, ratio decimal.Decimal) Transaction {
+	t.Amount = t.Amount.Mul(ratio)
+	t.Price = t.Price.Div(ratio)
+	return t
+}
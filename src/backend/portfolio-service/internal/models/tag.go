@@ -0,0 +1,82 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+var (
+	// MAX_ASSET_TAGS limits the number of tags per asset
+	MAX_ASSET_TAGS = 20
+
+	// MAX_TAG_LENGTH limits the length of a tag in characters
+	MAX_TAG_LENGTH = 32
+
+	// ErrInvalidTag is returned for malformed asset tags
+	ErrInvalidTag = errors.New("invalid asset tag")
+)
+
+// AssetTags are the free-form labels a user attached to an asset, such as
+// "long-term" or "experiment"
+type AssetTags struct {
+	PortfolioID uuid.UUID `json:"portfolio_id"`
+	AssetID     uuid.UUID `json:"asset_id"`
+	Tags        []string  `json:"tags"`
+}
+
+// NormalizeTag trims and lower-cases a tag so that tags differing only in
+// case or surrounding space match
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// NormalizeTags validates tags and returns them normalized, deduplicated
+// and sorted
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" {
+			return nil, fmt.Errorf("%w: empty tag", ErrInvalidTag)
+		}
+		if len([]rune(tag)) > MAX_TAG_LENGTH {
+			return nil, fmt.Errorf("%w: %q exceeds %d characters", ErrInvalidTag, tag, MAX_TAG_LENGTH)
+		}
+		if strings.IndexFunc(tag, unicode.IsControl) >= 0 {
+			return nil, fmt.Errorf("%w: %q contains control characters", ErrInvalidTag, tag)
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > MAX_ASSET_TAGS {
+		return nil, fmt.Errorf("%w: at most %d tags are allowed", ErrInvalidTag, MAX_ASSET_TAGS)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// FilterAssetsByTag returns the assets carrying tag, keeping their order.
+// tags maps asset IDs to their normalized tags.
+func FilterAssetsByTag(assets []Asset, tags map[uuid.UUID][]string, tag string) []Asset {
+	tag = NormalizeTag(tag)
+	filtered := make([]Asset, 0, len(assets))
+	for _, asset := range assets {
+		for _, t := range tags[asset.ID] {
+			if t == tag {
+				filtered = append(filtered, asset)
+				break
+			}
+		}
+	}
+	return filtered
+}
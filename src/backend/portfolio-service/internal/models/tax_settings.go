@@ -0,0 +1,89 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// ErrInvalidTaxSettings is returned for tax settings that cannot be applied
+var ErrInvalidTaxSettings = errors.New("invalid tax settings")
+
+// TaxYear is the day of the year a tax year starts on. Days are capped at
+// 28 so every year has them.
+type TaxYear struct {
+	StartMonth time.Month `json:"start_month"`
+	StartDay   int        `json:"start_day"`
+}
+
+// CalendarTaxYear is the tax year starting on the 1st of January
+var CalendarTaxYear = TaxYear{StartMonth: time.January, StartDay: 1}
+
+// Bounds returns the start and end of the tax year containing t, in the
+// location of t
+func (y TaxYear) Bounds(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), y.StartMonth, y.StartDay, 0, 0, 0, 0, t.Location())
+	if t.Before(start) {
+		start = start.AddDate(-1, 0, 0)
+	}
+	return start, start.AddDate(1, 0, 0)
+}
+
+// TaxSettings are the tax rules of a user's jurisdiction applied by tax
+// reports: when the tax year starts, how many days a lot must be held
+// beyond to be long-term, and whether wash sale losses are disallowed
+type TaxSettings struct {
+	UserID uuid.UUID `json:"user_id"`
+	// Country is the ISO 3166-1 alpha-2 code of the jurisdiction
+	Country string  `json:"country"`
+	TaxYear TaxYear `json:"tax_year"`
+	// LongTermDays is zero where gains are taxed alike however long the
+	// lot was held
+	LongTermDays int       `json:"long_term_days"`
+	WashSales    bool      `json:"wash_sales"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TAX_JURISDICTIONS holds the rules of the jurisdictions with known
+// defaults. The UK tax year starts on the 6th of April and does not
+// distinguish long-term gains; German private sales of lots held beyond a
+// year are tax-free, so they are long-term there.
+var TAX_JURISDICTIONS = map[string]TaxSettings{
+	"US": {Country: "US", TaxYear: CalendarTaxYear, LongTermDays: 365, WashSales: true},
+	"GB": {Country: "GB", TaxYear: TaxYear{StartMonth: time.April, StartDay: 6}, LongTermDays: 0, WashSales: false},
+	"DE": {Country: "DE", TaxYear: CalendarTaxYear, LongTermDays: 365, WashSales: false},
+}
+
+// JurisdictionTaxSettings returns the default tax settings of a country
+// for a user, and false when the country has no known defaults
+func JurisdictionTaxSettings(userID uuid.UUID, country string) (TaxSettings, bool) {
+	settings, ok := TAX_JURISDICTIONS[country]
+	settings.UserID = userID
+	return settings, ok
+}
+
+// Validate checks the country code, the start of the tax year and the
+// long-term holding period
+func (t TaxSettings) Validate() error {
+	if len(t.Country) != 2 || t.Country[0] < 'A' || t.Country[0] > 'Z' || t.Country[1] < 'A' || t.Country[1] > 'Z' {
+		return fmt.Errorf("%w: country must be an ISO 3166-1 alpha-2 code", ErrInvalidTaxSettings)
+	}
+	if t.TaxYear.StartMonth < time.January || t.TaxYear.StartMonth > time.December {
+		return fmt.Errorf("%w: tax year start month must be 1 to 12", ErrInvalidTaxSettings)
+	}
+	if t.TaxYear.StartDay < 1 || t.TaxYear.StartDay > 28 {
+		return fmt.Errorf("%w: tax year start day must be 1 to 28", ErrInvalidTaxSettings)
+	}
+	if t.LongTermDays < 0 {
+		return fmt.Errorf("%w: long-term days cannot be negative", ErrInvalidTaxSettings)
+	}
+	return nil
+}
+
+// LongTermHoldingPeriod returns the holding period lots must exceed to be
+// long-term, zero when the jurisdiction does not distinguish them
+func (t TaxSettings) LongTermHoldingPeriod() time.Duration {
+	return time.Duration(t.LongTermDays) * 24 * time.Hour
+}
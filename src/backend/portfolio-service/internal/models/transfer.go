@@ -0,0 +1,175 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// Ways a transfer link is made
+const (
+	// TransferMatchedAuto links transfers paired by MatchTransfers
+	TransferMatchedAuto = "auto"
+	// TransferMatchedManual links transfers paired by the user
+	TransferMatchedManual = "manual"
+)
+
+var (
+	// TRANSFER_MATCH_WINDOW is how long after a transfer_out the matching
+	// transfer_in may be recorded
+	TRANSFER_MATCH_WINDOW = 72 * time.Hour
+
+	// TRANSFER_CLOCK_SKEW is how long before a transfer_out the matching
+	// transfer_in may be recorded, as sources timestamp the two legs
+	// independently
+	TRANSFER_CLOCK_SKEW = 10 * time.Minute
+
+	// ErrInvalidTransferLink is returned for transfers that cannot be linked
+	ErrInvalidTransferLink = errors.New("invalid transfer link")
+)
+
+// TransferCandidate is an unlinked transfer_in or transfer_out of one of a
+// user's portfolios, with the symbol of its asset
+type TransferCandidate struct {
+	Transaction Transaction `json:"transaction"`
+	Symbol      string      `json:"symbol"`
+}
+
+// TransferLink pairs a transfer_out from one of a user's portfolios with the
+// transfer_in it arrived as in another. The transfer_in is costed at the
+// cost basis the transfer_out took from its lots, so the move carries cost
+// basis over instead of counting the asset as a fresh acquisition.
+type TransferLink struct {
+	ID               uuid.UUID       `json:"id"`
+	UserID           uuid.UUID       `json:"user_id"`
+	OutTransactionID uuid.UUID       `json:"out_transaction_id"`
+	InTransactionID  uuid.UUID       `json:"in_transaction_id"`
+	OutPortfolioID   uuid.UUID       `json:"out_portfolio_id"`
+	InPortfolioID    uuid.UUID       `json:"in_portfolio_id"`
+	Symbol           string          `json:"symbol"`
+	Amount           decimal.Decimal `json:"amount"`
+	CostBasis        decimal.Decimal `json:"cost_basis"`
+	InPriceBefore    decimal.Decimal `json:"in_price_before"`
+	MatchedBy        string          `json:"matched_by"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
+
+// ValidateTransferPair checks that a transfer_out and transfer_in may be
+// linked: both of the same symbol, in different portfolios, and the
+// transfer_in no larger than the transfer_out
+func ValidateTransferPair(out, in TransferCandidate) error {
+	if out.Transaction.Type != "transfer_out" {
+		return fmt.Errorf("%w: %s is not a transfer_out", ErrInvalidTransferLink, out.Transaction.ID)
+	}
+	if in.Transaction.Type != "transfer_in" {
+		return fmt.Errorf("%w: %s is not a transfer_in", ErrInvalidTransferLink, in.Transaction.ID)
+	}
+	if out.Transaction.PortfolioID == in.Transaction.PortfolioID {
+		return fmt.Errorf("%w: transfers are in the same portfolio", ErrInvalidTransferLink)
+	}
+	if !strings.EqualFold(out.Symbol, in.Symbol) {
+		return fmt.Errorf("%w: %s sent but %s received", ErrInvalidTransferLink, out.Symbol, in.Symbol)
+	}
+	if in.Transaction.Amount.GreaterThan(out.Transaction.Amount) {
+		return fmt.Errorf("%w: more received than sent", ErrInvalidTransferLink)
+	}
+	return nil
+}
+
+// transfersMatch reports whether a transfer_in is the arrival of a
+// transfer_out: the same amount, or the amount less the transfer_out's fee
+// when the network deducted it, received within the match window
+func transfersMatch(out, in TransferCandidate) bool {
+	if ValidateTransferPair(out, in) != nil {
+		return false
+	}
+	sent := out.Transaction.Amount
+	if !in.Transaction.Amount.Equal(sent) && !in.Transaction.Amount.Equal(sent.Sub(out.Transaction.Fee)) {
+		return false
+	}
+	earliest := out.Transaction.Timestamp.Add(-TRANSFER_CLOCK_SKEW)
+	latest := out.Transaction.Timestamp.Add(TRANSFER_MATCH_WINDOW)
+	return !in.Transaction.Timestamp.Before(earliest) && !in.Transaction.Timestamp.After(latest)
+}
+
+// MatchTransfers pairs unlinked transfer_outs with the transfer_ins they
+// arrived as. Transfer_outs are matched oldest first, each to the closest
+// matching transfer_in in time not already taken, so the pairing is the
+// same however the candidates are ordered. Pairs are returned as
+// automatically matched links without cost basis.
+func MatchTransfers(userID uuid.UUID, candidates []TransferCandidate, at time.Time) []TransferLink {
+	var outs, ins []TransferCandidate
+	for _, c := range candidates {
+		switch c.Transaction.Type {
+		case "transfer_out":
+			outs = append(outs, c)
+		case "transfer_in":
+			ins = append(ins, c)
+		}
+	}
+	byTime := func(list []TransferCandidate) {
+		sort.SliceStable(list, func(i, j int) bool {
+			a, b := list[i].Transaction, list[j].Transaction
+			if !a.Timestamp.Equal(b.Timestamp) {
+				return a.Timestamp.Before(b.Timestamp)
+			}
+			return bytes.Compare(a.ID[:], b.ID[:]) < 0
+		})
+	}
+	byTime(outs)
+	byTime(ins)
+
+	taken := make(map[uuid.UUID]bool)
+	var links []TransferLink
+	for _, out := range outs {
+		best := -1
+		var bestGap time.Duration
+		for i, in := range ins {
+			if taken[in.Transaction.ID] || !transfersMatch(out, in) {
+				continue
+			}
+			gap := in.Transaction.Timestamp.Sub(out.Transaction.Timestamp)
+			if gap < 0 {
+				gap = -gap
+			}
+			if best < 0 || gap < bestGap {
+				best, bestGap = i, gap
+			}
+		}
+		if best < 0 {
+			continue
+		}
+
+		in := ins[best]
+		taken[in.Transaction.ID] = true
+		links = append(links, TransferLink{
+			ID:               uuid.New(),
+			UserID:           userID,
+			OutTransactionID: out.Transaction.ID,
+			InTransactionID:  in.Transaction.ID,
+			OutPortfolioID:   out.Transaction.PortfolioID,
+			InPortfolioID:    in.Transaction.PortfolioID,
+			Symbol:           strings.ToUpper(out.Symbol),
+			Amount:           in.Transaction.Amount,
+			MatchedBy:        TransferMatchedAuto,
+			CreatedAt:        at,
+		})
+	}
+	return links
+}
+
+// CarriedPrice returns the price of a linked transfer_in that carries over
+// the cost basis its transfer_out took from its lots, spread over the
+// amount received
+func CarriedPrice(disposal LotDisposal, received decimal.Decimal) decimal.Decimal {
+	if !received.IsPositive() {
+		return decimal.Zero
+	}
+	return disposal.CostBasis.Div(received)
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// USER_DATA_EXPORT_VERSION is the layout version of user data exports,
+// raised when fields are removed or change meaning
+const USER_DATA_EXPORT_VERSION = 1
+
+// UserDataExport is everything the service stores about a user, produced
+// for data portability requests. It holds the user's own sensitive text and
+// is only delivered to that user; exchange credentials and webhook signing
+// secrets are never included.
+type UserDataExport struct {
+	Version     int       `json:"version"`
+	UserID      uuid.UUID `json:"user_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	// Portfolios include archived ones
+	Portfolios []PortfolioDataExport `json:"portfolios"`
+	// Webhooks are the user-wide endpoints; portfolio endpoints are listed
+	// with their portfolio
+	Webhooks        []WebhookEndpoint `json:"webhooks"`
+	ReportSchedules []ReportSchedule  `json:"report_schedules"`
+	TrackedWallets  []TrackedWallet   `json:"tracked_wallets"`
+	AuditTrail      []AuditEntry      `json:"audit_trail"`
+}
+
+// PortfolioDataExport is a portfolio of a user data export with its full
+// transaction history, settings and alerts
+type PortfolioDataExport struct {
+	Portfolio         *Portfolio             `json:"portfolio"`
+	Transactions      []Transaction          `json:"transactions"`
+	AssetTags         map[uuid.UUID][]string `json:"asset_tags"`
+	Dust              *DustSettings          `json:"dust,omitempty"`
+	AllocationTargets []AllocationTarget     `json:"allocation_targets"`
+	DCAPlans          []DCAPlan              `json:"dca_plans"`
+	AlertRules        []AlertRule            `json:"alert_rules"`
+	AlertEvents       []AlertEvent           `json:"alert_events"`
+	Webhooks          []WebhookEndpoint      `json:"webhooks"`
+	ExchangeAccounts  []ExchangeAccount      `json:"exchange_accounts"`
+	StakingPositions  []StakingPosition      `json:"staking_positions"`
+	Shares            []PortfolioShare       `json:"shares"`
+}
+
+// AuditEntry is a recorded change to a user's portfolio rows, holding the
+// row as it was before a delete or after an insert or update
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	TableName string          `json:"table_name"`
+	Operation string          `json:"operation"`
+	OldData   json.RawMessage `json:"old_data,omitempty"`
+	NewData   json.RawMessage `json:"new_data,omitempty"`
+	ChangedBy uuid.UUID       `json:"changed_by"`
+	ChangedAt time.Time       `json:"changed_at"`
+	ClientIP  string          `json:"client_ip,omitempty"`
+	UserAgent string          `json:"user_agent,omitempty"`
+}
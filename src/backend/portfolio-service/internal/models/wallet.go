@@ -0,0 +1,56 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// TrackedWallet is an on-chain wallet address materialized in a read-only
+// portfolio of its owner, possibly shared with the owner's wallets on other
+// chains, and refreshed in the background. Address is in the
+// chain's canonical form, or the account extended public key of an HD wallet;
+// Cursor is the checkpoint the next sync resumes from.
+type TrackedWallet struct {
+	ID           uuid.UUID  `json:"id"`
+	PortfolioID  uuid.UUID  `json:"portfolio_id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	Chain        string     `json:"chain"`
+	Address      string     `json:"address"`
+	Cursor       string     `json:"cursor"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	NextSyncAt   time.Time  `json:"next_sync_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// WalletPortfolioName returns the default name of a tracked wallet's
+// portfolio, with the address shortened
+func WalletPortfolioName(chain, address string) string {
+	if len(address) > 14 {
+		address = address[:8] + "..." + address[len(address)-6:]
+	}
+	if chain == "" {
+		return "Wallet " + address
+	}
+	return strings.ToUpper(chain[:1]) + chain[1:] + " wallet " + address
+}
+
+// PriceAt returns the close of the latest point at or before at from closes
+// ordered by time, or the earliest close when at predates them all. It
+// reports false for an empty series.
+func PriceAt(closes []PricePoint, at time.Time) (decimal.Decimal, bool) {
+	if len(closes) == 0 {
+		return decimal.Zero, false
+	}
+	i := sort.Search(len(closes), func(i int) bool {
+		return closes[i].Timestamp.After(at)
+	})
+	if i == 0 {
+		return closes[0].Close, true
+	}
+	return closes[i-1].Close, true
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"        // v1.3.0
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// WashSaleRule disallows the loss of a sell when the same asset is bought
+// within Window before or after it. The disallowed loss is added to the cost
+// of the replacement lots, up to the quantity sold, so it is realized when
+// they are sold in turn.
+type WashSaleRule struct {
+	Window time.Duration
+}
+
+// WashSale is a sell whose loss a wash-sale rule disallowed
+type WashSale struct {
+	AssetID       uuid.UUID `json:"asset_id"`
+	Symbol        string    `json:"symbol"`
+	TransactionID uuid.UUID `json:"transaction_id"`
+	DisposedAt    time.Time `json:"disposed_at"`
+	// Loss is the loss of the sell before the rule
+	Loss         decimal.Decimal `json:"loss"`
+	Disallowed   decimal.Decimal `json:"disallowed"`
+	Replacements []uuid.UUID     `json:"replacements"`
+}
+
+// WashSales returns the sells among the disposals of a replay of an asset's
+// lots whose loss was disallowed
+func WashSales(asset Asset, disposals []LotDisposal) []WashSale {
+	var sales []WashSale
+	for _, disposal := range disposals {
+		if !disposal.Disallowed.IsPositive() {
+			continue
+		}
+		sales = append(sales, WashSale{
+			AssetID:       asset.ID,
+			Symbol:        asset.Symbol,
+			TransactionID: disposal.TransactionID,
+			DisposedAt:    disposal.DisposedAt,
+			Loss:          disposal.CostBasis.Sub(disposal.Proceeds),
+			Disallowed:    disposal.Disallowed,
+			Replacements:  disposal.Replacements,
+		})
+	}
+	return sales
+}
+
+// washSaleReplacements tracks the purchases of a replay that may replace a
+// loss sell: the quantity of each already matched to a sell and the
+// disallowed loss owed to purchases not yet replayed
+type washSaleReplacements struct {
+	purchases map[uuid.UUID]decimal.Decimal
+	matched   map[uuid.UUID]decimal.Decimal
+	pending   map[uuid.UUID]decimal.Decimal
+}
+
+// newWashSaleReplacements returns the replacements of ordered transactions
+func newWashSaleReplacements(ordered []Transaction) *washSaleReplacements {
+	r := &washSaleReplacements{
+		purchases: make(map[uuid.UUID]decimal.Decimal),
+		matched:   make(map[uuid.UUID]decimal.Decimal),
+		pending:   make(map[uuid.UUID]decimal.Decimal),
+	}
+	for _, tx := range ordered {
+		if tx.Type == "buy" {
+			r.purchases[tx.ID] = tx.Amount
+		}
+	}
+	return r
+}
+
+// match matches up to quantity of a purchase to a sell, returning the
+// quantity matched
+func (r *washSaleReplacements) match(id uuid.UUID, held, quantity decimal.Decimal) decimal.Decimal {
+	bought, ok := r.purchases[id]
+	if !ok {
+		return decimal.Zero
+	}
+	available := decimal.Min(held, bought.Sub(r.matched[id]))
+	if !available.IsPositive() {
+		return decimal.Zero
+	}
+	matched := decimal.Min(available, quantity)
+	r.matched[id] = r.matched[id].Add(matched)
+	return matched
+}
+
+// disallow disallows the loss of a sell disposal in proportion to the
+// quantity replaced by purchases within the window, oldest first: open lots
+// bought before it and purchases of later, not yet replayed
+func (rule WashSaleRule) disallow(disposal *LotDisposal, lots []Lot, later []Transaction, r *washSaleReplacements) {
+	loss := disposal.CostBasis.Sub(disposal.Proceeds)
+	if !loss.IsPositive() || !disposal.Quantity.IsPositive() {
+		return
+	}
+
+	unmatched := disposal.Quantity
+	share := func(quantity decimal.Decimal) decimal.Decimal {
+		unmatched = unmatched.Sub(quantity)
+		disallowed := loss.Mul(quantity).Div(disposal.Quantity)
+		disposal.Disallowed = disposal.Disallowed.Add(disallowed)
+		return disallowed
+	}
+
+	since := disposal.DisposedAt.Add(-rule.Window)
+	for i := range lots {
+		if !unmatched.IsPositive() {
+			break
+		}
+		lot := &lots[i]
+		if lot.AcquiredAt.Before(since) {
+			continue
+		}
+		if quantity := r.match(lot.TransactionID, lot.Quantity, unmatched); quantity.IsPositive() {
+			disallowed := share(quantity)
+			lot.CostBasis = lot.CostBasis.Add(disallowed)
+			lot.WashSaleAdjustment = lot.WashSaleAdjustment.Add(disallowed)
+			disposal.Replacements = append(disposal.Replacements, lot.TransactionID)
+		}
+	}
+
+	until := disposal.DisposedAt.Add(rule.Window)
+	for _, tx := range later {
+		if !unmatched.IsPositive() || tx.Timestamp.After(until) {
+			break
+		}
+		if quantity := r.match(tx.ID, tx.Amount, unmatched); quantity.IsPositive() {
+			r.pending[tx.ID] = r.pending[tx.ID].Add(share(quantity))
+			disposal.Replacements = append(disposal.Replacements, tx.ID)
+		}
+	}
+}
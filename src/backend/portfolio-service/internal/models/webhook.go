@@ -0,0 +1,168 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+// WebhookEvent identifies the portfolio mutation a webhook delivery reports
+type WebhookEvent string
+
+const (
+	WebhookPortfolioCreated    WebhookEvent = "portfolio.created"
+	WebhookPortfolioUpdated    WebhookEvent = "portfolio.updated"
+	WebhookPortfolioArchived   WebhookEvent = "portfolio.archived"
+	WebhookPortfolioRestored   WebhookEvent = "portfolio.restored"
+	WebhookAssetAdded          WebhookEvent = "asset.added"
+	WebhookAssetRemoved        WebhookEvent = "asset.removed"
+	WebhookTransactionRecorded WebhookEvent = "transaction.recorded"
+)
+
+// WEBHOOK_EVENTS lists the events webhook endpoints may subscribe to
+var WEBHOOK_EVENTS = []WebhookEvent{
+	WebhookPortfolioCreated,
+	WebhookPortfolioUpdated,
+	WebhookPortfolioArchived,
+	WebhookPortfolioRestored,
+	WebhookAssetAdded,
+	WebhookAssetRemoved,
+	WebhookTransactionRecorded,
+}
+
+// DeliveryStatus is the state of a webhook delivery
+type DeliveryStatus string
+
+const (
+	// DeliveryPending deliveries are waiting for their next attempt
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliverySucceeded deliveries were acknowledged with a 2xx response
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryFailed deliveries were rejected or exhausted their attempts
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+var (
+	// MAX_WEBHOOKS_PER_PORTFOLIO limits the number of endpoints per portfolio
+	MAX_WEBHOOKS_PER_PORTFOLIO = 10
+
+	// ErrInvalidWebhook is returned for malformed webhook endpoints
+	ErrInvalidWebhook = errors.New("invalid webhook endpoint")
+)
+
+// WebhookEndpoint is a user-registered URL receiving signed events for one
+// portfolio. Endpoints without a portfolio receive events for every portfolio
+// of the user, including newly created ones.
+type WebhookEndpoint struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	PortfolioID uuid.UUID `json:"portfolio_id"`
+	URL         string    `json:"url"`
+	// Secret signs deliveries; it is only returned when the endpoint is registered
+	Secret    string         `json:"-"`
+	Events    []WebhookEvent `json:"events"`
+	Enabled   bool           `json:"enabled"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// WebhookSecretScope is the additional authenticated data binding a sealed
+// signing secret to its endpoint
+func WebhookSecretScope(endpointID uuid.UUID) []byte {
+	return []byte("webhook-secret\x00" + endpointID.String())
+}
+
+// WebhookDelivery is one event queued for delivery to an endpoint
+type WebhookDelivery struct {
+	ID            uuid.UUID      `json:"id"`
+	EndpointID    uuid.UUID      `json:"endpoint_id"`
+	PortfolioID   uuid.UUID      `json:"portfolio_id"`
+	Event         WebhookEvent   `json:"event"`
+	Payload       []byte         `json:"payload"`
+	Status        DeliveryStatus `json:"status"`
+	Attempts      int            `json:"attempts"`
+	ResponseCode  int            `json:"response_code"`
+	LastError     string         `json:"last_error,omitempty"`
+	NextAttemptAt time.Time      `json:"next_attempt_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	DeliveredAt   *time.Time     `json:"delivered_at,omitempty"`
+}
+
+// Validate checks the endpoint and normalizes its events. Plain HTTP is only
+// accepted when allowInsecure is set.
+func (e *WebhookEndpoint) Validate(allowInsecure bool) error {
+	u, err := url.Parse(strings.TrimSpace(e.URL))
+	if err != nil || u.Host == "" || u.User != nil {
+		return InvalidField("url", fmt.Errorf("%w: invalid url", ErrInvalidWebhook))
+	}
+	switch {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && allowInsecure:
+	default:
+		return InvalidField("url", fmt.Errorf("%w: url must use https", ErrInvalidWebhook))
+	}
+	e.URL = u.String()
+
+	seen := make(map[WebhookEvent]bool, len(e.Events))
+	events := make([]WebhookEvent, 0, len(e.Events))
+	for _, event := range e.Events {
+		event = WebhookEvent(strings.ToLower(strings.TrimSpace(string(event))))
+		if !event.Valid() {
+			return InvalidField("events", fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event))
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	if e.PortfolioID != uuid.Nil && seen[WebhookPortfolioCreated] {
+		return InvalidField("events", fmt.Errorf("%w: portfolio.created requires an endpoint without a portfolio", ErrInvalidWebhook))
+	}
+
+	// Subscribe to every event the endpoint can receive by default
+	if len(events) == 0 {
+		for _, event := range WEBHOOK_EVENTS {
+			if event != WebhookPortfolioCreated || e.PortfolioID == uuid.Nil {
+				events = append(events, event)
+			}
+		}
+	}
+	e.Events = events
+	return nil
+}
+
+// Subscribes reports whether the endpoint receives the event
+func (e *WebhookEndpoint) Subscribes(event WebhookEvent) bool {
+	for _, ev := range e.Events {
+		if ev == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid reports whether e is a known webhook event
+func (e WebhookEvent) Valid() bool {
+	for _, event := range WEBHOOK_EVENTS {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookBackoff returns the delay before retrying a delivery that failed for
+// the attempt-th time: initial doubled per attempt, capped at max
+func WebhookBackoff(attempt int, initial, max time.Duration) time.Duration {
+	delay := initial
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
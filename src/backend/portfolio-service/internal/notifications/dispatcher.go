@@ -0,0 +1,381 @@
+// Package notifications delivers alert and lifecycle notifications through
+// pluggable senders such as webhooks, email and the bookman notification
+// service. Delivery is asynchronous with retries and exponential backoff;
+// notifications a sender cannot deliver are stored as dead letters and can be
+// redelivered later.
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"                         // v1.3.0
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"go.uber.org/zap"                                // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/metrics"
+	"bookman/portfolio-service/internal/models"
+)
+
+// Define notification metrics
+var (
+	deliveries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "portfolio_notifications_total",
+			Help: "Total number of notification deliveries by sender, event and result",
+		},
+		[]string{"sender", "event", "result"},
+	)
+
+	queueDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "portfolio_notification_queue_depth",
+			Help: "Number of notifications waiting for delivery",
+		},
+	)
+)
+
+func init() {
+	metrics.MustRegister(deliveries)
+	metrics.MustRegister(queueDepth)
+}
+
+// Delivery results recorded in metrics
+const (
+	resultSent       = "sent"
+	resultRetried    = "retried"
+	resultDeadLetter = "dead_letter"
+	resultDropped    = "dropped"
+)
+
+// Sender delivers notifications to one channel
+type Sender interface {
+	// Name identifies the sender in metrics and dead letters
+	Name() string
+	// Send delivers the notification; errors wrapped with Permanent are not retried
+	Send(ctx context.Context, n *models.Notification) error
+}
+
+// DeadLetterStore persists notifications that could not be delivered
+type DeadLetterStore interface {
+	SaveDeadLetter(ctx context.Context, letter *models.NotificationDeadLetter) error
+	ListDeadLetters(ctx context.Context, limit int) ([]models.NotificationDeadLetter, error)
+	DeleteDeadLetter(ctx context.Context, id uuid.UUID) error
+	RecordDeadLetterRetry(ctx context.Context, id uuid.UUID, cause string, at time.Time) error
+}
+
+// permanentError marks a delivery failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the dispatcher dead-letters the notification without
+// further retries
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked as permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// route is a sender and the events it receives; a nil event set receives all
+type route struct {
+	sender Sender
+	events map[models.NotificationEvent]bool
+}
+
+// Dispatcher queues notifications and delivers them to every subscribed sender
+type Dispatcher struct {
+	routes []route
+	store  DeadLetterStore
+	cfg    config.NotificationsConfig
+	queue  chan *models.Notification
+	logger *zap.Logger
+}
+
+// NewDispatcher creates a dispatcher storing undeliverable notifications in store
+func NewDispatcher(store DeadLetterStore, cfg config.NotificationsConfig, logger *zap.Logger) (*Dispatcher, error) {
+	if store == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+	if cfg.QueueSize <= 0 || cfg.Workers <= 0 || cfg.MaxAttempts <= 0 {
+		return nil, errors.New("invalid notifications configuration")
+	}
+
+	return &Dispatcher{
+		store:  store,
+		cfg:    cfg,
+		queue:  make(chan *models.Notification, cfg.QueueSize),
+		logger: logger.With(zap.String("component", "notifications")),
+	}, nil
+}
+
+// AddSender subscribes a sender to the given events, or to all events when none
+// are given. Senders must be added before Run.
+func (d *Dispatcher) AddSender(sender Sender, events []string) {
+	r := route{sender: sender}
+	if len(events) > 0 {
+		r.events = make(map[models.NotificationEvent]bool, len(events))
+		for _, event := range events {
+			r.events[models.NotificationEvent(event)] = true
+		}
+	}
+	d.routes = append(d.routes, r)
+}
+
+// Notify queues a notification for delivery without blocking. A nil
+// dispatcher discards notifications, so callers need not check whether
+// notifications are enabled. When the queue is full the notification is
+// dead-lettered immediately.
+func (d *Dispatcher) Notify(n models.Notification) {
+	if d == nil {
+		return
+	}
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now().UTC()
+	}
+
+	select {
+	case d.queue <- &n:
+		queueDepth.Inc()
+	default:
+		ctx, cancel := context.WithTimeout(context.Background(), d.cfg.SendTimeout)
+		defer cancel()
+		d.deadLetterAll(ctx, &n, errors.New("notification queue full"))
+	}
+}
+
+// Run delivers queued notifications until ctx is cancelled. Notifications
+// still queued at shutdown are dead-lettered so they can be redelivered.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < d.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case n := <-d.queue:
+					queueDepth.Dec()
+					d.dispatch(ctx, n)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), d.cfg.SendTimeout)
+	defer cancel()
+	for {
+		select {
+		case n := <-d.queue:
+			queueDepth.Dec()
+			d.deadLetterAll(drainCtx, n, errors.New("service shutting down"))
+		default:
+			return
+		}
+	}
+}
+
+// Close releases resources held by senders
+func (d *Dispatcher) Close() error {
+	var errs []error
+	for _, r := range d.routes {
+		if c, ok := r.sender.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("failed to close %s sender: %w", r.sender.Name(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Redrive redelivers up to limit dead letters, oldest first, and returns the
+// number delivered. Delivered letters are deleted; failed ones stay stored
+// with their attempt count increased.
+func (d *Dispatcher) Redrive(ctx context.Context, limit int) (int, error) {
+	letters, err := d.store.ListDeadLetters(ctx, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	senders := make(map[string]Sender, len(d.routes))
+	for _, r := range d.routes {
+		senders[r.sender.Name()] = r.sender
+	}
+
+	delivered := 0
+	for i := range letters {
+		letter := &letters[i]
+		sender, ok := senders[letter.Sender]
+		if !ok {
+			d.logger.Warn("Skipping dead letter of unconfigured sender",
+				zap.String("dead_letter_id", letter.ID.String()),
+				zap.String("sender", letter.Sender),
+			)
+			continue
+		}
+
+		if _, err := d.deliver(ctx, sender, &letter.Notification); err != nil {
+			if ctx.Err() != nil {
+				return delivered, ctx.Err()
+			}
+			if err := d.store.RecordDeadLetterRetry(ctx, letter.ID, err.Error(), time.Now().UTC()); err != nil {
+				return delivered, fmt.Errorf("failed to update dead letter: %w", err)
+			}
+			continue
+		}
+
+		if err := d.store.DeleteDeadLetter(ctx, letter.ID); err != nil {
+			return delivered, fmt.Errorf("failed to delete dead letter: %w", err)
+		}
+		delivered++
+	}
+
+	return delivered, nil
+}
+
+// dispatch delivers a notification to every subscribed sender, dead-lettering
+// it per sender on failure
+func (d *Dispatcher) dispatch(ctx context.Context, n *models.Notification) {
+	for _, r := range d.routes {
+		if r.events != nil && !r.events[n.Event] {
+			continue
+		}
+
+		attempts, err := d.deliver(ctx, r.sender, n)
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			// Interrupted by shutdown; keep the notification for redelivery
+			ctx = context.Background()
+		}
+
+		storeCtx, cancel := context.WithTimeout(ctx, d.cfg.SendTimeout)
+		d.deadLetter(storeCtx, r.sender.Name(), n, err, attempts)
+		cancel()
+	}
+}
+
+// deliver sends a notification with retries and returns the number of
+// attempts made and the last error
+func (d *Dispatcher) deliver(ctx context.Context, sender Sender, n *models.Notification) (int, error) {
+	var err error
+	for attempt := 1; attempt <= d.cfg.MaxAttempts; attempt++ {
+		sendCtx, cancel := context.WithTimeout(ctx, d.cfg.SendTimeout)
+		err = sender.Send(sendCtx, n)
+		cancel()
+
+		if err == nil {
+			deliveries.WithLabelValues(sender.Name(), string(n.Event), resultSent).Inc()
+			return attempt, nil
+		}
+		if IsPermanent(err) || attempt == d.cfg.MaxAttempts {
+			return attempt, err
+		}
+
+		deliveries.WithLabelValues(sender.Name(), string(n.Event), resultRetried).Inc()
+		d.logger.Debug("Notification delivery failed, retrying",
+			zap.Error(err),
+			zap.String("sender", sender.Name()),
+			zap.String("notification_id", n.ID.String()),
+			zap.Int("attempt", attempt),
+		)
+		if err := sleepContext(ctx, d.backoff(attempt)); err != nil {
+			return attempt, err
+		}
+	}
+	return d.cfg.MaxAttempts, err
+}
+
+// deadLetterAll dead-letters a notification for every subscribed sender
+func (d *Dispatcher) deadLetterAll(ctx context.Context, n *models.Notification, cause error) {
+	for _, r := range d.routes {
+		if r.events != nil && !r.events[n.Event] {
+			continue
+		}
+		deliveries.WithLabelValues(r.sender.Name(), string(n.Event), resultDropped).Inc()
+		d.deadLetter(ctx, r.sender.Name(), n, cause, 1)
+	}
+}
+
+// deadLetter stores an undelivered notification
+func (d *Dispatcher) deadLetter(ctx context.Context, sender string, n *models.Notification, cause error, attempts int) {
+	deliveries.WithLabelValues(sender, string(n.Event), resultDeadLetter).Inc()
+
+	letter := &models.NotificationDeadLetter{
+		ID:           uuid.New(),
+		Sender:       sender,
+		Notification: *n,
+		Error:        cause.Error(),
+		Attempts:     attempts,
+		FailedAt:     time.Now().UTC(),
+	}
+	if err := d.store.SaveDeadLetter(ctx, letter); err != nil {
+		d.logger.Error("Failed to store notification dead letter",
+			zap.Error(err),
+			zap.String("sender", sender),
+			zap.String("notification_id", n.ID.String()),
+			zap.String("event", string(n.Event)),
+		)
+		return
+	}
+
+	d.logger.Warn("Notification dead-lettered",
+		zap.String("sender", sender),
+		zap.String("notification_id", n.ID.String()),
+		zap.String("event", string(n.Event)),
+		zap.Int("attempts", attempts),
+		zap.String("error", cause.Error()),
+	)
+}
+
+// backoff returns the delay before the retry following attempt: exponential
+// from the initial backoff, capped, with up to 20% jitter
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.cfg.InitialBackoff
+	for i := 1; i < attempt && delay < d.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.cfg.MaxBackoff {
+		delay = d.cfg.MaxBackoff
+	}
+	if jitter := int64(delay) / 5; jitter > 0 {
+		delay += time.Duration(rand.Int63n(jitter))
+	}
+	return delay
+}
+
+// sleepContext waits for d or until ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
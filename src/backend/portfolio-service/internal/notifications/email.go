@@ -0,0 +1,127 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/models"
+)
+
+// EmailSender mails notifications over SMTP to a fixed list of recipients,
+// using STARTTLS when the server offers it
+type EmailSender struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// NewEmailSender creates an SMTP sender; password is used only with a username
+func NewEmailSender(cfg config.EmailSenderConfig, password string) *EmailSender {
+	return &EmailSender{
+		addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		host:     cfg.Host,
+		username: cfg.Username,
+		password: password,
+		from:     cfg.From,
+		to:       cfg.To,
+	}
+}
+
+// Name identifies the sender
+func (e *EmailSender) Name() string {
+	return "email"
+}
+
+// Send mails the notification. Rejections with permanent SMTP reply codes are
+// not retried.
+func (e *EmailSender) Send(ctx context.Context, n *models.Notification) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", e.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: e.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("SMTP STARTTLS failed: %w", err)
+		}
+	}
+	if e.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.username, e.password, e.host)); err != nil {
+			return smtpError("SMTP authentication failed", err)
+		}
+	}
+
+	if err := client.Mail(e.from); err != nil {
+		return smtpError("SMTP MAIL rejected", err)
+	}
+	for _, rcpt := range e.to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return smtpError("SMTP RCPT rejected", err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return smtpError("SMTP DATA rejected", err)
+	}
+	if _, err := w.Write(e.message(n)); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpError("SMTP message rejected", err)
+	}
+
+	return client.Quit()
+}
+
+// message renders the notification as a plain text email
+func (e *EmailSender) message(n *models.Notification) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", n.CreatedAt.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: <%s@bookman>\r\n", n.ID)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(n.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+// smtpError wraps err, marking 5xx replies as permanent
+func smtpError(msg string, err error) error {
+	wrapped := fmt.Errorf("%s: %w", msg, err)
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return Permanent(wrapped)
+	}
+	return wrapped
+}
@@ -0,0 +1,83 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"                          // v1.50.0
+	"google.golang.org/grpc/codes"                    // v1.50.0
+	"google.golang.org/grpc/credentials"              // v1.50.0
+	"google.golang.org/grpc/credentials/insecure"     // v1.50.0
+	"google.golang.org/grpc/status"                   // v1.50.0
+	"google.golang.org/protobuf/types/known/emptypb"  // v1.30.0
+	"google.golang.org/protobuf/types/known/structpb" // v1.30.0
+
+	"bookman/portfolio-service/internal/models"
+)
+
+// notifyMethod is the notification service RPC, see shared/proto/notification.proto
+const notifyMethod = "/bookman.notification.NotificationService/Notify"
+
+// ServiceSender forwards notifications to the bookman notification service,
+// which resolves the user's channels and preferences
+type ServiceSender struct {
+	conn *grpc.ClientConn
+}
+
+// NewServiceSender connects to the notification service at address
+func NewServiceSender(address string, tlsEnabled bool) (*ServiceSender, error) {
+	creds := insecure.NewCredentials()
+	if tlsEnabled {
+		creds = credentials.NewClientTLSFromCert(nil, "")
+	}
+
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial notification service: %w", err)
+	}
+
+	return &ServiceSender{conn: conn}, nil
+}
+
+// Name identifies the sender
+func (s *ServiceSender) Name() string {
+	return "service"
+}
+
+// Send calls the notification service. Requests it rejects as invalid or
+// unauthorized are not retried.
+func (s *ServiceSender) Send(ctx context.Context, n *models.Notification) error {
+	attributes := make(map[string]interface{}, len(n.Attributes))
+	for k, v := range n.Attributes {
+		attributes[k] = v
+	}
+
+	req, err := structpb.NewStruct(map[string]interface{}{
+		"id":           n.ID.String(),
+		"event":        string(n.Event),
+		"portfolio_id": n.PortfolioID.String(),
+		"user_id":      n.UserID.String(),
+		"subject":      n.Subject,
+		"body":         n.Body,
+		"attributes":   attributes,
+		"created_at":   n.CreatedAt.UTC().Format("2006-01-02T15:04:05Z07:00"),
+	})
+	if err != nil {
+		return Permanent(fmt.Errorf("failed to encode notification: %w", err))
+	}
+
+	err = s.conn.Invoke(ctx, notifyMethod, req, &emptypb.Empty{})
+	switch status.Code(err) {
+	case codes.OK:
+		return nil
+	case codes.InvalidArgument, codes.NotFound, codes.PermissionDenied, codes.Unauthenticated, codes.Unimplemented:
+		return Permanent(fmt.Errorf("notification service rejected notification: %w", err))
+	default:
+		return fmt.Errorf("notification service call failed: %w", err)
+	}
+}
+
+// Close closes the connection to the notification service
+func (s *ServiceSender) Close() error {
+	return s.conn.Close()
+}
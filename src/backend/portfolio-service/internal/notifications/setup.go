@@ -0,0 +1,46 @@
+package notifications
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"go.uber.org/zap" // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+)
+
+// NewConfiguredDispatcher creates a dispatcher with the senders enabled in cfg
+func NewConfiguredDispatcher(store DeadLetterStore, cfg config.NotificationsConfig, logger *zap.Logger) (*Dispatcher, error) {
+	d, err := NewDispatcher(store, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Webhook.Enabled {
+		var secret []byte
+		if cfg.Webhook.SecretEnv != "" {
+			secret = []byte(os.Getenv(cfg.Webhook.SecretEnv))
+		}
+		d.AddSender(NewWebhookSender(cfg.Webhook.URL, secret, &http.Client{Timeout: cfg.SendTimeout}), cfg.Webhook.Events)
+	}
+
+	if cfg.Email.Enabled {
+		var password string
+		if cfg.Email.PasswordEnv != "" {
+			password = os.Getenv(cfg.Email.PasswordEnv)
+		}
+		d.AddSender(NewEmailSender(cfg.Email, password), cfg.Email.Events)
+	}
+
+	if cfg.Service.Enabled {
+		sender, err := NewServiceSender(cfg.Service.Address, cfg.Service.TLSEnabled)
+		if err != nil {
+			d.Close()
+			return nil, fmt.Errorf("failed to create notification service sender: %w", err)
+		}
+		d.AddSender(sender, cfg.Service.Events)
+	}
+
+	return d, nil
+}
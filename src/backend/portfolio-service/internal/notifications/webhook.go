@@ -0,0 +1,91 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"bookman/portfolio-service/internal/models"
+)
+
+// Webhook request headers
+const (
+	// EventHeader carries the notification event
+	EventHeader = "X-Bookman-Event"
+	// DeliveryHeader carries the notification ID so receivers can drop duplicates
+	DeliveryHeader = "X-Bookman-Delivery"
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body
+	SignatureHeader = "X-Bookman-Signature"
+)
+
+// WebhookSender posts notifications as JSON to an HTTP endpoint
+type WebhookSender struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhookSender creates a sender posting to url, signing payloads with
+// secret when it is not empty
+func NewWebhookSender(url string, secret []byte, client *http.Client) *WebhookSender {
+	if client == nil {
+		client = &http.Client{}
+	}
+	return &WebhookSender{url: url, secret: secret, client: client}
+}
+
+// Name identifies the sender
+func (w *WebhookSender) Name() string {
+	return "webhook"
+}
+
+// Send posts the notification. Client errors other than timeouts and rate
+// limiting are permanent.
+func (w *WebhookSender) Send(ctx context.Context, n *models.Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return Permanent(fmt.Errorf("failed to encode notification: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(fmt.Errorf("failed to create webhook request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(n.Event))
+	req.Header.Set(DeliveryHeader, n.ID.String())
+	if len(w.secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return Permanent(fmt.Errorf("webhook rejected notification with status %d", resp.StatusCode))
+	default:
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of body under secret, as sent in SignatureHeader
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,52 @@
+package ops
+
+import (
+	"context"
+	"sync/atomic"
+
+	"google.golang.org/grpc/codes"                 // v1.54.0
+	"google.golang.org/grpc/health/grpc_health_v1" // v1.54.0
+	"google.golang.org/grpc/status"                // v1.54.0
+)
+
+// Health implements the gRPC health check service. It reports NOT_SERVING
+// until the service is marked started, whether or not the ops server runs,
+// and then the same readiness as /readyz when it does.
+type Health struct {
+	probes  *Server
+	started atomic.Bool
+}
+
+// NewHealth creates a gRPC health service consulting the readiness checks of
+// probes, which may be nil when the ops server is disabled
+func NewHealth(probes *Server) *Health {
+	return &Health{probes: probes}
+}
+
+// MarkStarted reports the service finished warming up and its listeners are
+// serving
+func (h *Health) MarkStarted() {
+	h.started.Store(true)
+}
+
+func (h *Health) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if !h.started.Load() {
+		return &grpc_health_v1.HealthCheckResponse{
+			Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+		}, nil
+	}
+	if h.probes != nil {
+		if err := h.probes.Ready(ctx); err != nil {
+			return &grpc_health_v1.HealthCheckResponse{
+				Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+			}, nil
+		}
+	}
+	return &grpc_health_v1.HealthCheckResponse{
+		Status: grpc_health_v1.HealthCheckResponse_SERVING,
+	}, nil
+}
+
+func (h *Health) Watch(*grpc_health_v1.HealthCheckRequest, grpc_health_v1.Health_WatchServer) error {
+	return status.Error(codes.Unimplemented, "health check watching is not implemented")
+}
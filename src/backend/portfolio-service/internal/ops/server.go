@@ -0,0 +1,293 @@
+// Package ops provides the operational HTTP server exposing metrics, health,
+// readiness and build information endpoints for the portfolio service. The
+// probe endpoints follow Kubernetes: /healthz reports the process is alive,
+// /startupz that it finished starting and /readyz that it can serve traffic.
+package ops
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp" // v1.14.0
+	"go.uber.org/zap"                                         // v1.24.0
+
+	"bookman/portfolio-service/internal/buildinfo"
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/metrics"
+)
+
+const (
+	// readinessTimeout bounds the total time spent running readiness checks
+	readinessTimeout = 5 * time.Second
+
+	statusOK       = "ok"
+	statusFail     = "unavailable"
+	statusDegraded = "degraded"
+	statusStarting = "starting"
+)
+
+// ErrStarting is returned by Ready until the service has finished starting
+var ErrStarting = errors.New("service is starting")
+
+// ReadinessCheck reports whether a dependency is ready to serve traffic
+type ReadinessCheck func(ctx context.Context) error
+
+// DependencyStatus is the state of a dependency the service keeps serving
+// without, with details for operators
+type DependencyStatus struct {
+	Degraded bool
+	Details  interface{}
+}
+
+// DependencyCheck reports the state of a dependency whose loss degrades the
+// service without making it unready, such as a market data provider
+type DependencyCheck func(ctx context.Context) DependencyStatus
+
+// Server serves operational endpoints on the metrics listener
+type Server struct {
+	cfg    *config.MetricsConfig
+	info   buildinfo.Info
+	logger *zap.Logger
+	mux    *http.ServeMux
+	http   *http.Server
+
+	checks       map[string]ReadinessCheck
+	dependencies map[string]DependencyCheck
+	checkMutex   sync.RWMutex
+	started      atomic.Bool
+}
+
+// NewServer creates a new operational HTTP server instance
+func NewServer(cfg *config.MetricsConfig, info buildinfo.Info, logger *zap.Logger) (*Server, error) {
+	if cfg == nil || logger == nil {
+		return nil, fmt.Errorf("invalid dependencies provided")
+	}
+
+	s := &Server{
+		cfg:          cfg,
+		info:         info,
+		logger:       logger.With(zap.String("component", "ops_server")),
+		mux:          http.NewServeMux(),
+		checks:       make(map[string]ReadinessCheck),
+		dependencies: make(map[string]DependencyCheck),
+	}
+
+	// Deployment labels are added to every metric, and OpenMetrics is
+	// negotiated so scrapers receive latency exemplars
+	gatherer := metrics.WithLabels(metrics.Registry, cfg.Labels)
+	s.mux.Handle(cfg.Path, promhttp.InstrumentMetricHandler(
+		metrics.Registry,
+		promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	))
+	s.mux.HandleFunc("/healthz", s.handleHealthz)
+	s.mux.HandleFunc("/startupz", s.handleStartupz)
+	s.mux.HandleFunc("/readyz", s.handleReadyz)
+	s.mux.HandleFunc("/buildinfo", s.handleBuildInfo)
+
+	s.http = &http.Server{
+		Addr:         fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Handler:      s.mux,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+
+	if cfg.TLSEnabled {
+		tlsConfig, err := serverTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		s.http.TLSConfig = tlsConfig
+	}
+
+	return s, nil
+}
+
+// serverTLSConfig returns the TLS settings of the listener, requiring client
+// certificates signed by the configured CA when one is set
+func serverTLSConfig(cfg *config.MetricsConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSClientCA == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(cfg.TLSClientCA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("client CA contains no certificates")
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	return tlsConfig, nil
+}
+
+// AddReadinessCheck registers a named dependency check consulted by /readyz
+func (s *Server) AddReadinessCheck(name string, check ReadinessCheck) {
+	s.checkMutex.Lock()
+	defer s.checkMutex.Unlock()
+	s.checks[name] = check
+}
+
+// AddDependencyCheck registers a named dependency check reported by /readyz.
+// A degraded dependency is reported without failing readiness.
+func (s *Server) AddDependencyCheck(name string, check DependencyCheck) {
+	s.checkMutex.Lock()
+	defer s.checkMutex.Unlock()
+	s.dependencies[name] = check
+}
+
+// MarkStarted reports the service finished starting: its dependencies are
+// connected, the database schema was verified by preparing statements and
+// its listeners are serving. /startupz and /readyz fail until then.
+func (s *Server) MarkStarted() {
+	s.started.Store(true)
+}
+
+// Ready reports whether the service has started and every readiness check
+// passes, returning the first failure. It backs the gRPC health service so
+// both agree with /readyz.
+func (s *Server) Ready(ctx context.Context) error {
+	if !s.started.Load() {
+		return ErrStarting
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	s.checkMutex.RLock()
+	defer s.checkMutex.RUnlock()
+
+	for name, check := range s.checks {
+		if err := check(ctx); err != nil {
+			return fmt.Errorf("readiness check %s failed: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Handle mounts an additional handler on the ops server, e.g. developer tooling
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Handler returns the HTTP handler serving all operational endpoints
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// ListenAndServe starts serving operational endpoints until Shutdown is called
+func (s *Server) ListenAndServe() error {
+	s.logger.Info("Ops server listening",
+		zap.String("address", s.http.Addr),
+		zap.Bool("tls", s.cfg.TLSEnabled),
+	)
+
+	var err error
+	if s.cfg.TLSEnabled {
+		err = s.http.ListenAndServeTLS(s.cfg.TLSCert, s.cfg.TLSKey)
+	} else {
+		err = s.http.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown gracefully stops the server, waiting for active requests until ctx expires
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.http.Shutdown(ctx)
+}
+
+// handleHealthz reports process liveness
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": statusOK})
+}
+
+// handleStartupz reports whether the service finished starting
+func (s *Server) handleStartupz(w http.ResponseWriter, r *http.Request) {
+	if !s.started.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": statusStarting})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": statusOK})
+}
+
+// handleReadyz runs all registered readiness checks, and reports the state of
+// dependencies the service can run degraded without. It fails until the
+// service finished starting.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if !s.started.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": statusStarting})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	s.checkMutex.RLock()
+	defer s.checkMutex.RUnlock()
+
+	code := http.StatusOK
+	overall := statusOK
+	results := make(map[string]string, len(s.checks))
+
+	for name, check := range s.checks {
+		if err := check(ctx); err != nil {
+			s.logger.Warn("Readiness check failed",
+				zap.String("check", name),
+				zap.Error(err),
+			)
+			results[name] = statusFail
+			overall = statusFail
+			code = http.StatusServiceUnavailable
+			continue
+		}
+		results[name] = statusOK
+	}
+
+	payload := map[string]interface{}{"checks": results}
+	if len(s.dependencies) > 0 {
+		dependencies := make(map[string]interface{}, len(s.dependencies))
+		for name, check := range s.dependencies {
+			dependency := check(ctx)
+			state := statusOK
+			if dependency.Degraded {
+				state = statusDegraded
+				if overall == statusOK {
+					overall = statusDegraded
+				}
+			}
+			dependencies[name] = map[string]interface{}{"status": state, "details": dependency.Details}
+		}
+		payload["dependencies"] = dependencies
+	}
+	payload["status"] = overall
+
+	writeJSON(w, code, payload)
+}
+
+// handleBuildInfo reports build metadata for the running binary
+func (s *Server) handleBuildInfo(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.info)
+}
+
+// writeJSON encodes the payload as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(payload)
+}
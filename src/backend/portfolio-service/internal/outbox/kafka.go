@@ -0,0 +1,91 @@
+package outbox
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/segmentio/kafka-go"            // v0.4.42
+	"github.com/segmentio/kafka-go/sasl/plain" // v0.4.42
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/models"
+)
+
+// Kafka message headers set on every published event
+const (
+	// EventTypeHeader carries the event type, e.g. "AssetAdded"
+	EventTypeHeader = "event-type"
+	// EventIDHeader carries the event ID consumers deduplicate by
+	EventIDHeader = "event-id"
+)
+
+// KafkaPublisher publishes outbox events to a Kafka topic. Messages are keyed
+// by portfolio ID so events of one portfolio keep their order within a partition.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaPublisher creates a publisher for the configured brokers and topic
+func NewKafkaPublisher(cfg config.OutboxConfig) (*KafkaPublisher, error) {
+	transport := &kafka.Transport{
+		DialTimeout: cfg.WriteTimeout,
+	}
+	if cfg.TLSEnabled {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if cfg.SASLUsername != "" {
+		password := os.Getenv(cfg.SASLPasswordEnv)
+		if password == "" {
+			return nil, fmt.Errorf("kafka password variable %s is not set", cfg.SASLPasswordEnv)
+		}
+		transport.SASL = plain.Mechanism{Username: cfg.SASLUsername, Password: password}
+	}
+
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Topic:        cfg.Topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			MaxAttempts:  1, // the relay retries whole batches
+			BatchSize:    cfg.BatchSize,
+			BatchTimeout: 10 * time.Millisecond,
+			WriteTimeout: cfg.WriteTimeout,
+			Transport:    transport,
+		},
+	}, nil
+}
+
+// Publish writes the events synchronously and waits for all in-sync replicas
+// to acknowledge them
+func (p *KafkaPublisher) Publish(ctx context.Context, events []models.OutboxEvent) error {
+	messages := make([]kafka.Message, len(events))
+	for i, e := range events {
+		messages[i] = kafka.Message{
+			Key:   []byte(e.PortfolioID.String()),
+			Value: e.Payload,
+			Time:  e.CreatedAt,
+			Headers: []kafka.Header{
+				{Key: EventTypeHeader, Value: []byte(e.Type)},
+				{Key: EventIDHeader, Value: []byte(e.ID.String())},
+			},
+		}
+	}
+
+	if err := p.writer.WriteMessages(ctx, messages...); err != nil {
+		return fmt.Errorf("failed to write kafka messages: %w", err)
+	}
+
+	for _, e := range events {
+		publishedEvents.WithLabelValues(string(e.Type)).Inc()
+	}
+	return nil
+}
+
+// Close flushes and closes the underlying writer
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
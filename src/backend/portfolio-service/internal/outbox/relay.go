@@ -0,0 +1,131 @@
+// Package outbox publishes portfolio domain events recorded in the
+// transactional outbox to Kafka. Events are written in the same database
+// transaction as the mutation they describe and marked published only after
+// the broker acknowledges them, so every committed mutation is published at
+// least once. Consumers deduplicate by the event ID. A single relay preserves
+// the order of each portfolio's events; with several replicas relaying, order
+// is only guaranteed within a batch.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"go.uber.org/zap"                                // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/metrics"
+	"bookman/portfolio-service/internal/models"
+	"bookman/portfolio-service/internal/repository"
+)
+
+// purgeInterval is how often published events past retention are deleted
+const purgeInterval = time.Hour
+
+var (
+	publishedEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "portfolio_outbox_published_total",
+			Help: "Total number of outbox events published by event type",
+		},
+		[]string{"type"},
+	)
+	publishFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "portfolio_outbox_publish_failures_total",
+			Help: "Total number of failed outbox relay batches",
+		},
+	)
+)
+
+func init() {
+	metrics.MustRegister(publishedEvents, publishFailures)
+}
+
+// Publisher sends a batch of events to the message broker. It returns only
+// after every event is acknowledged, or an error if any may not have been.
+type Publisher interface {
+	Publish(ctx context.Context, events []models.OutboxEvent) error
+	Close() error
+}
+
+// Relay moves outbox events to a publisher on a fixed interval
+type Relay struct {
+	repo      *repository.PostgresRepository
+	publisher Publisher
+	cfg       config.OutboxConfig
+	logger    *zap.Logger
+	lastPurge time.Time
+}
+
+// NewRelay creates a new outbox relay
+func NewRelay(repo *repository.PostgresRepository, publisher Publisher, cfg config.OutboxConfig, logger *zap.Logger) (*Relay, error) {
+	if repo == nil || publisher == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	return &Relay{
+		repo:      repo,
+		publisher: publisher,
+		cfg:       cfg,
+		logger:    logger.With(zap.String("component", "outbox_relay")),
+	}, nil
+}
+
+// Run relays events at each interval until ctx is cancelled. Failed batches
+// stay in the outbox and are retried on the next tick.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			publishFailures.Inc()
+			r.logger.Error("Outbox relay pass failed", zap.Error(err))
+		}
+
+		if time.Since(r.lastPurge) >= purgeInterval {
+			r.purge(ctx)
+		}
+	}
+}
+
+// RunOnce publishes batches of pending events until the outbox is drained
+func (r *Relay) RunOnce(ctx context.Context) error {
+	for {
+		published, err := r.repo.RelayOutbox(ctx, r.cfg.BatchSize, func(events []models.OutboxEvent) error {
+			publishCtx, cancel := context.WithTimeout(ctx, r.cfg.WriteTimeout)
+			defer cancel()
+			return r.publisher.Publish(publishCtx, events)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to relay outbox events: %w", err)
+		}
+		if published < r.cfg.BatchSize {
+			return nil
+		}
+	}
+}
+
+// purge deletes published events older than the retention period
+func (r *Relay) purge(ctx context.Context) {
+	r.lastPurge = time.Now()
+
+	deleted, err := r.repo.PurgeOutbox(ctx, r.lastPurge.Add(-r.cfg.Retention).UTC())
+	if err != nil {
+		r.logger.Error("Failed to purge outbox", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		r.logger.Info("Purged published outbox events", zap.Int64("deleted", deleted))
+	}
+}
@@ -0,0 +1,75 @@
+package pagination
+
+import (
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+)
+
+const (
+	// DefaultPageSize is the page size of list requests that leave it unset
+	DefaultPageSize = 50
+
+	// MaxPageSize is the largest page size of list endpoints that do not set
+	// their own limit
+	MaxPageSize = 100
+)
+
+// Size returns the page size to serve for a requested size: the default for
+// an unset size, bounded by max
+func Size(requested, max int) int {
+	if requested <= 0 {
+		requested = DefaultPageSize
+	}
+	if requested > max {
+		requested = max
+	}
+	return requested
+}
+
+// Position is the keyset position of the last row of a page in a list ordered
+// by a sort key and row ID. The ID breaks ties between rows with equal sort
+// keys, so every row is returned exactly once however many rows are inserted
+// while a client pages through the list.
+type Position struct {
+	// At is the sort key of lists ordered by time
+	At time.Time
+	// Key is the sort key of lists ordered by other values
+	Key string
+	ID  uuid.UUID
+}
+
+// IsZero reports whether the position is the start of a list
+func (p Position) IsZero() bool {
+	return p.At.IsZero() && p.Key == "" && p.ID == uuid.Nil
+}
+
+// EncodePosition issues the token of the page following the position
+func (c *Codec) EncodePosition(scope string, pos Position, filters string) (string, error) {
+	return c.Encode(scope, Cursor{
+		Keys:    []string{pos.At.UTC().Format(time.RFC3339Nano), pos.Key, pos.ID.String()},
+		Filters: filters,
+	})
+}
+
+// DecodePosition returns the position a token issued by EncodePosition
+// continues from. An empty token yields the zero position.
+func (c *Codec) DecodePosition(scope, token, filters string) (Position, error) {
+	cursor, err := c.Decode(scope, token, filters)
+	if err != nil || len(cursor.Keys) == 0 {
+		return Position{}, err
+	}
+	if len(cursor.Keys) != 3 {
+		return Position{}, ErrInvalidToken
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, cursor.Keys[0])
+	if err != nil {
+		return Position{}, ErrInvalidToken
+	}
+	id, err := uuid.Parse(cursor.Keys[2])
+	if err != nil {
+		return Position{}, ErrInvalidToken
+	}
+	return Position{At: at, Key: cursor.Keys[1], ID: id}, nil
+}
@@ -0,0 +1,167 @@
+// Package pagination implements opaque page tokens shared by all list
+// endpoints. Tokens are encrypted and authenticated cursors that carry the
+// sort keys of the last returned row together with a fingerprint of the
+// request filters, so clients can neither forge positions nor replay a token
+// against a different query. The versioned payload keeps the format evolvable.
+package pagination
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// currentVersion is the cursor payload format written by this package
+const currentVersion = 1
+
+// MinSecretLength is the shortest accepted token secret in bytes
+const MinSecretLength = 32
+
+var (
+	// ErrInvalidToken is returned for malformed, forged or mismatched tokens
+	ErrInvalidToken = errors.New("invalid page token")
+
+	// ErrExpiredToken is returned for tokens older than the codec TTL
+	ErrExpiredToken = errors.New("page token expired")
+)
+
+// Cursor is the position of a page within a list query
+type Cursor struct {
+	// Keys holds the sort key values of the last row of the previous page
+	Keys []string
+	// Filters is the fingerprint of the query the cursor belongs to
+	Filters string
+}
+
+// payload is the serialized form of a cursor
+type payload struct {
+	Version  int      `json:"v"`
+	Keys     []string `json:"k"`
+	Filters  string   `json:"f,omitempty"`
+	IssuedAt int64    `json:"t"`
+}
+
+// Codec encodes and decodes page tokens
+type Codec struct {
+	keys []cipher.AEAD
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// NewCodec creates a token codec. Tokens are sealed with the first secret and
+// opened with any of them, allowing secrets to be rotated without invalidating
+// tokens in flight. A zero ttl disables expiry.
+func NewCodec(secrets [][]byte, ttl time.Duration) (*Codec, error) {
+	if len(secrets) == 0 {
+		return nil, errors.New("at least one token secret is required")
+	}
+
+	c := &Codec{ttl: ttl, now: time.Now}
+	for i, secret := range secrets {
+		if len(secret) < MinSecretLength {
+			return nil, fmt.Errorf("token secret %d is shorter than %d bytes", i, MinSecretLength)
+		}
+
+		key := sha256.Sum256(secret)
+		block, err := aes.NewCipher(key[:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher: %w", err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AEAD: %w", err)
+		}
+		c.keys = append(c.keys, aead)
+	}
+
+	return c, nil
+}
+
+// NewEphemeralCodec creates a codec with a random secret. Its tokens are only
+// valid within the current process.
+func NewEphemeralCodec(ttl time.Duration) (*Codec, error) {
+	secret := make([]byte, MinSecretLength)
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+		return nil, fmt.Errorf("failed to generate token secret: %w", err)
+	}
+	return NewCodec([][]byte{secret}, ttl)
+}
+
+// Encode seals the cursor into an opaque token bound to the list scope
+func (c *Codec) Encode(scope string, cursor Cursor) (string, error) {
+	data, err := json.Marshal(payload{
+		Version:  currentVersion,
+		Keys:     cursor.Keys,
+		Filters:  cursor.Filters,
+		IssuedAt: c.now().Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	aead := c.keys[0]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, data, []byte(scope))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decode opens a token issued for the scope and checks that it belongs to a
+// query with the given filter fingerprint. An empty token yields a zero cursor.
+func (c *Codec) Decode(scope, token, filters string) (Cursor, error) {
+	if token == "" {
+		return Cursor{}, nil
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, ErrInvalidToken
+	}
+
+	var data []byte
+	for _, aead := range c.keys {
+		if len(sealed) < aead.NonceSize() {
+			return Cursor{}, ErrInvalidToken
+		}
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		if data, err = aead.Open(nil, nonce, ciphertext, []byte(scope)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return Cursor{}, ErrInvalidToken
+	}
+
+	var p payload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Cursor{}, ErrInvalidToken
+	}
+
+	if p.Version != currentVersion || p.Filters != filters {
+		return Cursor{}, ErrInvalidToken
+	}
+
+	if c.ttl > 0 && c.now().Sub(time.Unix(p.IssuedAt, 0)) > c.ttl {
+		return Cursor{}, ErrExpiredToken
+	}
+
+	return Cursor{Keys: p.Keys, Filters: p.Filters}, nil
+}
+
+// Fingerprint derives a compact, order-sensitive fingerprint of filter values
+func Fingerprint(values ...string) string {
+	h := sha256.Sum256([]byte(strings.Join(values, "\x00")))
+	return hex.EncodeToString(h[:8])
+}
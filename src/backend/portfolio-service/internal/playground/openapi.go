@@ -0,0 +1,204 @@
+package playground
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/codes"                    // v1.50.0
+	"google.golang.org/protobuf/reflect/protoreflect" // v1.30.0
+)
+
+// buildSpec generates an OpenAPI 3 description of the unary methods of a
+// service, exposed as JSON POST operations under basePath
+func buildSpec(service protoreflect.ServiceDescriptor, basePath string, examples map[string]json.RawMessage) map[string]interface{} {
+	schemas := make(map[string]interface{})
+	paths := make(map[string]interface{})
+
+	methods := service.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.Get(i)
+		if method.IsStreamingClient() || method.IsStreamingServer() {
+			continue
+		}
+
+		addMessageSchema(schemas, method.Input())
+		addMessageSchema(schemas, method.Output())
+
+		media := map[string]interface{}{
+			"schema": schemaRef(method.Input()),
+		}
+		if example, ok := examples[string(method.Name())]; ok {
+			media["example"] = example
+		}
+
+		paths[basePath+"/"+string(service.FullName())+"/"+string(method.Name())] = map[string]interface{}{
+			"post": map[string]interface{}{
+				"operationId": string(method.Name()),
+				"tags":        []string{string(service.Name())},
+				"requestBody": map[string]interface{}{
+					"required": true,
+					"content":  map[string]interface{}{"application/json": media},
+				},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "Successful response",
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{"schema": schemaRef(method.Output())},
+						},
+					},
+					"default": map[string]interface{}{
+						"description": "gRPC error status",
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/Status"}},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	schemas["Status"] = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"code":    map[string]interface{}{"type": "string"},
+			"message": map[string]interface{}{"type": "string"},
+		},
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       string(service.FullName()),
+			"description": "Generated from the protobuf service definition. Requests are bridged to the local gRPC server.",
+			"version":     "1.0",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+}
+
+// schemaRef returns a reference to the component schema of a message
+func schemaRef(md protoreflect.MessageDescriptor) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + string(md.FullName())}
+}
+
+// addMessageSchema registers the schema of a message and the messages it references
+func addMessageSchema(schemas map[string]interface{}, md protoreflect.MessageDescriptor) {
+	name := string(md.FullName())
+	if _, ok := schemas[name]; ok || isWellKnown(md) {
+		return
+	}
+
+	properties := make(map[string]interface{})
+	schemas[name] = map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		properties[fd.JSONName()] = fieldSchema(schemas, fd)
+	}
+}
+
+// fieldSchema returns the schema of a field following protojson encoding rules
+func fieldSchema(schemas map[string]interface{}, fd protoreflect.FieldDescriptor) map[string]interface{} {
+	if fd.IsMap() {
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": singularSchema(schemas, fd.MapValue()),
+		}
+	}
+	if fd.IsList() {
+		return map[string]interface{}{
+			"type":  "array",
+			"items": singularSchema(schemas, fd),
+		}
+	}
+	return singularSchema(schemas, fd)
+}
+
+// singularSchema returns the schema of a single value of a field
+func singularSchema(schemas map[string]interface{}, fd protoreflect.FieldDescriptor) map[string]interface{} {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return map[string]interface{}{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// protojson encodes 64-bit integers as strings
+		return map[string]interface{}{"type": "string", "format": "int64"}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return map[string]interface{}{"type": "number"}
+	case protoreflect.BytesKind:
+		return map[string]interface{}{"type": "string", "format": "byte"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]string, values.Len())
+		for i := range names {
+			names[i] = string(values.Get(i).Name())
+		}
+		return map[string]interface{}{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if isWellKnown(fd.Message()) {
+			return wellKnownSchema(fd.Message())
+		}
+		addMessageSchema(schemas, fd.Message())
+		return schemaRef(fd.Message())
+	default:
+		return map[string]interface{}{"type": "string"}
+	}
+}
+
+// isWellKnown reports whether a message is a google.protobuf well-known type
+func isWellKnown(md protoreflect.MessageDescriptor) bool {
+	return strings.HasPrefix(string(md.FullName()), "google.protobuf.")
+}
+
+// wellKnownSchema returns the schema of the protojson encoding of a well-known type
+func wellKnownSchema(md protoreflect.MessageDescriptor) map[string]interface{} {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case "google.protobuf.Duration":
+		return map[string]interface{}{"type": "string", "example": "1.5s"}
+	case "google.protobuf.Empty":
+		return map[string]interface{}{"type": "object"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// httpStatus maps a gRPC status code to the closest HTTP status
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled:
+		return 499
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
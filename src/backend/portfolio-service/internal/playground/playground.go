@@ -0,0 +1,217 @@
+// Package playground serves a developer-facing API explorer on the ops server.
+// It renders an OpenAPI description generated from the registered proto
+// service descriptors and bridges JSON requests to the local gRPC server, so
+// the API can be explored from a browser without installing grpcurl.
+package playground
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"                                  // v1.24.0
+	"google.golang.org/grpc"                           // v1.50.0
+	"google.golang.org/grpc/metadata"                  // v1.50.0
+	"google.golang.org/grpc/status"                    // v1.50.0
+	"google.golang.org/protobuf/encoding/protojson"    // v1.30.0
+	"google.golang.org/protobuf/reflect/protoreflect"  // v1.30.0
+	"google.golang.org/protobuf/reflect/protoregistry" // v1.30.0
+	"google.golang.org/protobuf/types/dynamicpb"       // v1.30.0
+)
+
+const (
+	// maxRequestBytes bounds the size of a bridged JSON request body
+	maxRequestBytes = 1 << 20 // 1MB
+
+	// invokeTimeout bounds a single bridged RPC
+	invokeTimeout = 30 * time.Second
+)
+
+//go:embed assets
+var assets embed.FS
+
+// forwardedHeaders lists HTTP headers passed to the gRPC server as metadata
+var forwardedHeaders = []string{"authorization", "x-request-id", "traceparent"}
+
+// Playground serves the API explorer UI, its OpenAPI description and the JSON bridge
+type Playground struct {
+	conn    *grpc.ClientConn
+	service protoreflect.ServiceDescriptor
+	prefix  string
+	spec    []byte
+	logger  *zap.Logger
+}
+
+// New creates a playground for the named gRPC service mounted under prefix.
+// Requests are invoked on conn, which should target the local gRPC server.
+func New(conn *grpc.ClientConn, serviceName, prefix string, logger *zap.Logger) (*Playground, error) {
+	if conn == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("service %s is not registered: %w", serviceName, err)
+	}
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", serviceName)
+	}
+
+	examples, err := loadExamples()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix = strings.TrimSuffix(prefix, "/")
+	spec, err := json.Marshal(buildSpec(service, prefix+"/rpc", examples))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI spec: %w", err)
+	}
+
+	return &Playground{
+		conn:    conn,
+		service: service,
+		prefix:  prefix,
+		spec:    spec,
+		logger:  logger.With(zap.String("component", "playground")),
+	}, nil
+}
+
+// Prefix returns the URL path under which the playground is served
+func (p *Playground) Prefix() string {
+	return p.prefix + "/"
+}
+
+// ServeHTTP routes playground requests
+func (p *Playground) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, p.prefix)
+
+	switch {
+	case path == "" || path == "/":
+		if path == "" {
+			http.Redirect(w, r, p.prefix+"/", http.StatusMovedPermanently)
+			return
+		}
+		p.serveAsset(w, "assets/index.html", "text/html; charset=utf-8")
+	case path == "/openapi.json":
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(p.spec)
+	case strings.HasPrefix(path, "/rpc/"):
+		p.handleInvoke(w, r, strings.TrimPrefix(path, "/rpc/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveAsset writes an embedded static file
+func (p *Playground) serveAsset(w http.ResponseWriter, name, contentType string) {
+	data, err := fs.ReadFile(assets, name)
+	if err != nil {
+		http.Error(w, "asset not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(data)
+}
+
+// handleInvoke decodes a JSON request, invokes the unary RPC named by the path
+// and encodes the response as JSON
+func (p *Playground) handleInvoke(w http.ResponseWriter, r *http.Request, fullMethod string) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	serviceName, methodName, ok := strings.Cut(fullMethod, "/")
+	if !ok || serviceName != string(p.service.FullName()) {
+		writeError(w, http.StatusNotFound, "unknown service")
+		return
+	}
+
+	method := p.service.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil || method.IsStreamingClient() || method.IsStreamingServer() {
+		writeError(w, http.StatusNotFound, "unknown or streaming method")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
+
+	req := dynamicpb.NewMessage(method.Input())
+	if len(body) > 0 {
+		if err := protojson.Unmarshal(body, req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), invokeTimeout)
+	defer cancel()
+
+	for _, header := range forwardedHeaders {
+		if v := r.Header.Get(header); v != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, header, v)
+		}
+	}
+
+	resp := dynamicpb.NewMessage(method.Output())
+	rpc := fmt.Sprintf("/%s/%s", p.service.FullName(), method.Name())
+	if err := p.conn.Invoke(ctx, rpc, req, resp); err != nil {
+		st := status.Convert(err)
+		p.logger.Debug("Playground call failed",
+			zap.String("method", rpc),
+			zap.String("code", st.Code().String()),
+		)
+		writeJSON(w, httpStatus(st.Code()), map[string]string{
+			"code":    st.Code().String(),
+			"message": st.Message(),
+		})
+		return
+	}
+
+	data, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(resp)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to encode response")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// loadExamples reads the embedded example requests keyed by method name
+func loadExamples() (map[string]json.RawMessage, error) {
+	data, err := fs.ReadFile(assets, "assets/examples.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read examples: %w", err)
+	}
+
+	var examples map[string]json.RawMessage
+	if err := json.Unmarshal(data, &examples); err != nil {
+		return nil, fmt.Errorf("failed to decode examples: %w", err)
+	}
+	return examples, nil
+}
+
+// writeError writes an error message as a JSON response
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"message": message})
+}
+
+// writeJSON encodes the payload as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,99 @@
+package pricecache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal" // v1.3.1
+)
+
+// lruEntry is a cached price with its expiry
+type lruEntry struct {
+	symbol    string
+	price     decimal.Decimal
+	expiresAt time.Time
+}
+
+// lru is a size-bounded, expiring in-process price cache. The least recently
+// used symbol is evicted when it is full.
+type lru struct {
+	mutex   sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+}
+
+func newLRU(size int, ttl time.Duration) *lru {
+	return &lru{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+// get returns the unexpired price of symbol
+func (c *lru) get(symbol string, now time.Time) (decimal.Decimal, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	elem, ok := c.entries[symbol]
+	if !ok {
+		return decimal.Decimal{}, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !now.Before(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, symbol)
+		return decimal.Decimal{}, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.price, true
+}
+
+// put stores the price of symbol for the cache TTL
+func (c *lru) put(symbol string, price decimal.Decimal, now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem, ok := c.entries[symbol]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.price = price
+		entry.expiresAt = now.Add(c.ttl)
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	for c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).symbol)
+	}
+	c.entries[symbol] = c.order.PushFront(&lruEntry{
+		symbol:    symbol,
+		price:     price,
+		expiresAt: now.Add(c.ttl),
+	})
+}
+
+// remove drops the price of symbol
+func (c *lru) remove(symbol string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem, ok := c.entries[symbol]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, symbol)
+	}
+}
+
+// clear drops every price
+func (c *lru) clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[string]*list.Element, c.size)
+}
@@ -0,0 +1,229 @@
+// Package pricecache keeps valuation latency flat under load with a two-tier
+// cache of current prices: a short-lived in-process LRU of hot symbols in
+// front of a shared store such as Redis, in front of the price source.
+//
+// Concurrent misses for the same symbols are collapsed into a single source
+// fetch. Symbols without a price are not cached, so they are looked up again
+// on every request.
+package pricecache
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"github.com/shopspring/decimal"                  // v1.3.1
+	"golang.org/x/sync/singleflight"                 // v0.3.0
+
+	"bookman/portfolio-service/internal/metrics"
+)
+
+// Cache tiers reported in metrics
+const (
+	tierLocal  = "local"
+	tierStore  = "store"
+	tierSource = "source"
+)
+
+var lookups = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "portfolio_price_cache_lookups_total",
+		Help: "Total number of symbol price lookups, by cache tier and result",
+	},
+	[]string{"tier", "result"},
+)
+
+func init() {
+	metrics.MustRegister(lookups)
+}
+
+// Source returns the current price of each requested symbol, omitting
+// symbols it has no price for
+type Source interface {
+	GetCurrentPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error)
+}
+
+// Store is the shared cache tier between the in-process cache and the source
+type Store interface {
+	// GetPrices returns the cached prices of the symbols that are present
+	GetPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error)
+	// SetPrices caches prices for ttl
+	SetPrices(ctx context.Context, prices map[string]decimal.Decimal, ttl time.Duration) error
+	// DeletePrices removes the cached prices of the symbols
+	DeletePrices(ctx context.Context, symbols []string) error
+}
+
+// Cache serves current prices from the in-process cache, then the shared
+// store, then the source. Failures of the shared store are counted and
+// otherwise treated as misses.
+type Cache struct {
+	source   Source
+	store    Store
+	storeTTL time.Duration
+	local    *lru
+	flights  singleflight.Group
+	now      func() time.Time
+}
+
+// New creates a price cache over source. store may be nil to cache in
+// process only. Prices are kept in process for localTTL, up to localSize
+// symbols, and in the store for storeTTL.
+func New(source Source, store Store, localSize int, localTTL, storeTTL time.Duration) (*Cache, error) {
+	if source == nil {
+		return nil, errors.New("price source is required")
+	}
+	if localSize <= 0 || localTTL <= 0 {
+		return nil, errors.New("local cache size and TTL must be positive")
+	}
+	if store != nil && storeTTL <= 0 {
+		return nil, errors.New("store TTL must be positive")
+	}
+
+	return &Cache{
+		source:   source,
+		store:    store,
+		storeTTL: storeTTL,
+		local:    newLRU(localSize, localTTL),
+		now:      time.Now,
+	}, nil
+}
+
+// GetCurrentPrices returns the current price of each requested symbol.
+// Symbols without a price are omitted from the result. Cache implements
+// Source, so caches can be stacked.
+func (c *Cache) GetCurrentPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error) {
+	prices := make(map[string]decimal.Decimal, len(symbols))
+	now := c.now()
+
+	var missing []string
+	seen := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		if seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+
+		if price, ok := c.local.get(symbol, now); ok {
+			prices[symbol] = price
+			continue
+		}
+		missing = append(missing, symbol)
+	}
+	lookups.WithLabelValues(tierLocal, "hit").Add(float64(len(prices)))
+	lookups.WithLabelValues(tierLocal, "miss").Add(float64(len(missing)))
+	if len(missing) == 0 {
+		return prices, nil
+	}
+
+	missing = c.fromStore(ctx, missing, prices, now)
+	if len(missing) == 0 {
+		return prices, nil
+	}
+
+	fetched, err := c.fetch(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, symbol := range missing {
+		if price, ok := fetched[symbol]; ok {
+			prices[symbol] = price
+		}
+	}
+	return prices, nil
+}
+
+// SetPrices writes freshly recorded prices through both cache tiers, so
+// this replica serves them at once and others after their local TTL
+func (c *Cache) SetPrices(ctx context.Context, prices map[string]decimal.Decimal) error {
+	now := c.now()
+	for symbol, price := range prices {
+		c.local.put(symbol, price, now)
+	}
+	if c.store == nil || len(prices) == 0 {
+		return nil
+	}
+	return c.store.SetPrices(ctx, prices, c.storeTTL)
+}
+
+// Invalidate drops the cached prices of symbols from both cache tiers, so
+// they are fetched from the source again. Without symbols it empties the
+// in-process cache only; other replicas keep serving their own until their
+// local TTL passes.
+func (c *Cache) Invalidate(ctx context.Context, symbols []string) error {
+	if len(symbols) == 0 {
+		c.local.clear()
+		return nil
+	}
+	for _, symbol := range symbols {
+		c.local.remove(symbol)
+	}
+	if c.store == nil {
+		return nil
+	}
+	return c.store.DeletePrices(ctx, symbols)
+}
+
+// fromStore adds the prices of symbols found in the shared store to prices
+// and returns the symbols still missing
+func (c *Cache) fromStore(ctx context.Context, symbols []string, prices map[string]decimal.Decimal, now time.Time) []string {
+	if c.store == nil {
+		return symbols
+	}
+
+	stored, err := c.store.GetPrices(ctx, symbols)
+	if err != nil {
+		lookups.WithLabelValues(tierStore, "error").Add(float64(len(symbols)))
+		return symbols
+	}
+
+	missing := symbols[:0:0]
+	for _, symbol := range symbols {
+		price, ok := stored[symbol]
+		if !ok {
+			missing = append(missing, symbol)
+			continue
+		}
+		prices[symbol] = price
+		c.local.put(symbol, price, now)
+	}
+	lookups.WithLabelValues(tierStore, "hit").Add(float64(len(symbols) - len(missing)))
+	lookups.WithLabelValues(tierStore, "miss").Add(float64(len(missing)))
+	return missing
+}
+
+// fetch loads prices from the source and fills both cache tiers. Concurrent
+// fetches of the same symbol set share one source call; the returned map is
+// shared between callers and must not be modified.
+func (c *Cache) fetch(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error) {
+	key := make([]string, len(symbols))
+	copy(key, symbols)
+	sort.Strings(key)
+
+	result, err, _ := c.flights.Do(strings.Join(key, ","), func() (interface{}, error) {
+		prices, err := c.source.GetCurrentPrices(ctx, key)
+		if err != nil {
+			lookups.WithLabelValues(tierSource, "error").Add(float64(len(key)))
+			return nil, err
+		}
+		lookups.WithLabelValues(tierSource, "hit").Add(float64(len(prices)))
+		lookups.WithLabelValues(tierSource, "miss").Add(float64(len(key) - len(prices)))
+
+		now := c.now()
+		for symbol, price := range prices {
+			c.local.put(symbol, price, now)
+		}
+		if c.store != nil && len(prices) > 0 {
+			if err := c.store.SetPrices(ctx, prices, c.storeTTL); err != nil {
+				lookups.WithLabelValues(tierStore, "error").Add(float64(len(prices)))
+			}
+		}
+		return prices, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]decimal.Decimal), nil
+}
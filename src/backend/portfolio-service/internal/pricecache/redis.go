@@ -0,0 +1,127 @@
+package pricecache
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"  // v9.0.5
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"bookman/portfolio-service/internal/config"
+)
+
+// redisKeyPrefix namespaces price keys in a Redis instance shared with other
+// services
+const redisKeyPrefix = "portfolio:price:"
+
+// RedisStore implements Store on Redis, storing each price as a decimal
+// string under its own expiring key
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore connects to the configured Redis instance
+func NewRedisStore(ctx context.Context, cfg config.CacheConfig) (*RedisStore, error) {
+	opts := &redis.Options{
+		Addr:         net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		PoolSize:     cfg.PoolSize,
+		MinIdleConns: cfg.MinIdleConns,
+		MaxRetries:   cfg.MaxRetries,
+	}
+	if cfg.TLSEnabled {
+		pem, err := os.ReadFile(cfg.TLSCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cache TLS certificate: %w", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in cache TLS certificate file")
+		}
+		opts.TLSConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &RedisStore{client: client}, nil
+}
+
+// GetPrices reads the prices of all symbols in one round trip
+func (s *RedisStore) GetPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error) {
+	keys := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		keys[i] = redisKeyPrefix + symbol
+	}
+
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached prices: %w", err)
+	}
+
+	prices := make(map[string]decimal.Decimal, len(symbols))
+	for i, value := range values {
+		raw, ok := value.(string)
+		if !ok {
+			continue
+		}
+		// Unparsable entries are treated as misses and overwritten on refetch
+		price, err := decimal.NewFromString(raw)
+		if err != nil {
+			continue
+		}
+		prices[symbols[i]] = price
+	}
+	return prices, nil
+}
+
+// SetPrices writes all prices in one pipelined round trip
+func (s *RedisStore) SetPrices(ctx context.Context, prices map[string]decimal.Decimal, ttl time.Duration) error {
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for symbol, price := range prices {
+			pipe.Set(ctx, redisKeyPrefix+symbol, price.String(), ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cache prices: %w", err)
+	}
+	return nil
+}
+
+// DeletePrices removes the prices of all symbols in one round trip
+func (s *RedisStore) DeletePrices(ctx context.Context, symbols []string) error {
+	keys := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		keys[i] = redisKeyPrefix + symbol
+	}
+
+	if err := s.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to delete cached prices: %w", err)
+	}
+	return nil
+}
+
+// Ping checks the Redis instance is reachable
+func (s *RedisStore) Ping(ctx context.Context) error {
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return nil
+}
+
+// Close closes the Redis connection pool
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,148 @@
+package pricefeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap" // v1.24.0
+
+	"bookman/portfolio-service/internal/models"
+	"bookman/portfolio-service/internal/repository"
+)
+
+// BackfillResult summarizes a price history backfill
+type BackfillResult struct {
+	Symbols  int      // symbols with history from a provider
+	Inserted int64    // daily bars stored; days already stored are kept
+	Missing  []string // symbols no provider had history of
+}
+
+// Backfiller stores daily price history of symbols so analytics based on
+// daily closes, such as returns and risk, work without waiting for history
+// to accumulate
+type Backfiller struct {
+	repo      *repository.PostgresRepository
+	providers []HistoryProvider
+	logger    *zap.Logger
+}
+
+// NewBackfiller creates a backfiller consulting the providers that serve
+// price history, in the given order
+func NewBackfiller(repo *repository.PostgresRepository, providers []Provider, logger *zap.Logger) (*Backfiller, error) {
+	if repo == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	var history []HistoryProvider
+	for _, provider := range providers {
+		if h, ok := provider.(HistoryProvider); ok {
+			history = append(history, h)
+		}
+	}
+	if len(history) == 0 {
+		return nil, errors.New("no configured provider serves price history")
+	}
+
+	return &Backfiller{
+		repo:      repo,
+		providers: history,
+		logger:    logger.With(zap.String("component", "price_backfill")),
+	}, nil
+}
+
+// Backfill stores the daily bars of the symbols over the given number of
+// complete days before today, or of all held symbols when symbols is empty.
+// Each symbol's history is taken from the first provider that has it.
+func (b *Backfiller) Backfill(ctx context.Context, symbols []string, days int) (*BackfillResult, error) {
+	if days <= 0 {
+		return nil, errors.New("days must be positive")
+	}
+
+	if len(symbols) == 0 {
+		held, err := b.repo.ListHeldSymbols(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list held symbols: %w", err)
+		}
+		symbols = held
+	}
+
+	// Today's bar is still forming
+	end := time.Now().UTC().Truncate(day)
+	start := end.AddDate(0, 0, -days)
+
+	result := &BackfillResult{}
+	for _, symbol := range unique(symbols) {
+		bars, err := b.fetch(ctx, symbol, start, end)
+		if err != nil {
+			return result, err
+		}
+		if len(bars) == 0 {
+			result.Missing = append(result.Missing, symbol)
+			continue
+		}
+
+		inserted, err := b.repo.InsertDailyBars(ctx, symbol, bars)
+		if err != nil {
+			return result, fmt.Errorf("failed to store history of %s: %w", symbol, err)
+		}
+		result.Symbols++
+		result.Inserted += inserted
+
+		b.logger.Info("Backfilled price history",
+			zap.String("symbol", symbol),
+			zap.Int("days", len(bars)),
+			zap.Int64("inserted", inserted),
+		)
+	}
+
+	return result, nil
+}
+
+// fetch returns the valid bars of symbol from the first provider that has
+// any. An error is only returned when ctx is cancelled.
+func (b *Backfiller) fetch(ctx context.Context, symbol string, start, end time.Time) ([]models.PriceBar, error) {
+	for _, provider := range b.providers {
+		bars, err := provider.FetchDailyBars(ctx, symbol, start, end)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			providerRequests.WithLabelValues(provider.Name(), "error").Inc()
+			b.logger.Warn("Price history request failed",
+				zap.Error(err),
+				zap.String("provider", provider.Name()),
+				zap.String("symbol", symbol),
+			)
+			continue
+		}
+		providerRequests.WithLabelValues(provider.Name(), "success").Inc()
+
+		if valid := ValidBars(bars, start, end); len(valid) > 0 {
+			return valid, nil
+		}
+	}
+	return nil, nil
+}
+
+// ValidBars keeps the bars starting at a UTC midnight within [start, end)
+// with a positive close, dropping the placeholder bars some providers return
+// for days before a symbol was listed
+func ValidBars(bars []models.PriceBar, start, end time.Time) []models.PriceBar {
+	valid := make([]models.PriceBar, 0, len(bars))
+	seen := make(map[time.Time]bool, len(bars))
+	for _, bar := range bars {
+		d := bar.Day.UTC()
+		if !d.Equal(d.Truncate(day)) || d.Before(start) || !d.Before(end) || seen[d] {
+			continue
+		}
+		if !bar.Close.IsPositive() || bar.Open.IsNegative() || bar.High.IsNegative() || bar.Low.IsNegative() || bar.Volume.IsNegative() {
+			continue
+		}
+		seen[d] = true
+		bar.Day = d
+		valid = append(valid, bar)
+	}
+	return valid
+}
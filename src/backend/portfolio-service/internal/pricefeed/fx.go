@@ -0,0 +1,62 @@
+package pricefeed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/exchanges"
+	"bookman/portfolio-service/internal/models"
+)
+
+// NewFXProviders creates clients for the configured foreign exchange rate
+// providers, keeping their order. They implement Provider, pricing currency
+// codes in the base currency, so fiat balances are fetched like any symbol.
+func NewFXProviders(cfgs []config.ProviderConfig, httpClient *http.Client, retry exchanges.RetryPolicy) ([]Provider, error) {
+	providers := make([]Provider, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		// Rate limits are configured per minute
+		client := exchanges.NewClient(cfg.Name, httpClient, float64(cfg.RateLimit)/60, 1, retry)
+		base := httpProvider{name: cfg.Name, baseURL: cfg.BaseURL, apiKey: cfg.APIKey(), client: client}
+
+		switch cfg.Type {
+		case "frankfurter":
+			providers = append(providers, &frankfurter{base})
+		default:
+			return nil, fmt.Errorf("fx provider %q has unsupported type %q", cfg.Name, cfg.Type)
+		}
+	}
+	return providers, nil
+}
+
+// frankfurter reads the latest European Central Bank reference rates. Asking
+// for an unknown currency fails the whole request, so all rates are read at
+// once and filtered.
+type frankfurter struct{ httpProvider }
+
+func (p *frankfurter) MaxBatch() int { return 0 }
+
+func (p *frankfurter) FetchPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error) {
+	params := url.Values{"from": {models.BASE_CURRENCY}}
+
+	var body struct {
+		Rates map[string]decimal.Decimal `json:"rates"`
+	}
+	if err := p.get(ctx, "/latest", params, nil, &body); err != nil {
+		return nil, err
+	}
+
+	// Rates are units of each currency per unit of the base currency
+	one := decimal.NewFromInt(1)
+	prices := make(map[string]decimal.Decimal, len(symbols))
+	for _, symbol := range symbols {
+		if rate, ok := body.Rates[symbol]; ok && rate.IsPositive() {
+			prices[symbol] = one.Div(rate)
+		}
+	}
+	return prices, nil
+}
@@ -0,0 +1,146 @@
+package pricefeed
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+
+	"bookman/portfolio-service/internal/metrics"
+)
+
+var (
+	providerFailures = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_price_provider_consecutive_failures",
+			Help: "Number of consecutive failed requests of each price provider",
+		},
+		[]string{"provider"},
+	)
+
+	providerLastSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_price_provider_last_success_timestamp_seconds",
+			Help: "Unix time of the last successful request of each price provider",
+		},
+		[]string{"provider"},
+	)
+)
+
+func init() {
+	metrics.MustRegister(providerFailures, providerLastSuccess)
+}
+
+// ProviderHealth is the availability of one price provider
+type ProviderHealth struct {
+	Name                string    `json:"name"`
+	LastSuccess         time.Time `json:"last_success,omitempty"`
+	LastFailure         time.Time `json:"last_failure,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+}
+
+// HealthStatus is the availability of market data. The service keeps serving
+// stored prices while degraded, so it is reported apart from readiness.
+type HealthStatus struct {
+	Degraded    bool             `json:"degraded"`
+	Reason      string           `json:"reason,omitempty"`
+	LastSuccess time.Time        `json:"last_success,omitempty"`
+	Providers   []ProviderHealth `json:"providers"`
+}
+
+// Health tracks the outcome of price provider requests. Market data is
+// degraded once no provider has succeeded for staleAfter, or a provider has
+// failed failureThreshold requests in a row. Only replicas fetching prices
+// record requests, so others report healthy market data.
+type Health struct {
+	staleAfter       time.Duration
+	failureThreshold int
+
+	mutex       sync.Mutex
+	lastSuccess time.Time
+	providers   map[string]*ProviderHealth
+}
+
+// NewHealth creates a tracker of price provider availability
+func NewHealth(staleAfter time.Duration, failureThreshold int) *Health {
+	return &Health{
+		staleAfter:       staleAfter,
+		failureThreshold: failureThreshold,
+		providers:        make(map[string]*ProviderHealth),
+	}
+}
+
+// RecordSuccess records a successful request of a provider
+func (h *Health) RecordSuccess(provider string, at time.Time) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	p := h.provider(provider)
+	p.LastSuccess = at
+	p.ConsecutiveFailures = 0
+	if at.After(h.lastSuccess) {
+		h.lastSuccess = at
+	}
+
+	providerFailures.WithLabelValues(provider).Set(0)
+	providerLastSuccess.WithLabelValues(provider).Set(float64(at.Unix()))
+}
+
+// RecordFailure records a failed request of a provider
+func (h *Health) RecordFailure(provider string, at time.Time, err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	p := h.provider(provider)
+	p.LastFailure = at
+	p.LastError = err.Error()
+	p.ConsecutiveFailures++
+
+	providerFailures.WithLabelValues(provider).Set(float64(p.ConsecutiveFailures))
+}
+
+// Status returns the availability of market data at now
+func (h *Health) Status(now time.Time) HealthStatus {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	status := HealthStatus{LastSuccess: h.lastSuccess, Providers: make([]ProviderHealth, 0, len(h.providers))}
+	for _, p := range h.providers {
+		status.Providers = append(status.Providers, *p)
+	}
+	sort.Slice(status.Providers, func(i, j int) bool {
+		return status.Providers[i].Name < status.Providers[j].Name
+	})
+
+	switch {
+	case h.lastSuccess.IsZero() && len(h.providers) > 0:
+		status.Degraded, status.Reason = true, "no successful price fetch yet"
+	case !h.lastSuccess.IsZero() && now.Sub(h.lastSuccess) > h.staleAfter:
+		status.Degraded = true
+		status.Reason = fmt.Sprintf("prices are stale, last fetched %s ago", now.Sub(h.lastSuccess).Truncate(time.Second))
+	default:
+		for _, p := range status.Providers {
+			if p.ConsecutiveFailures >= h.failureThreshold {
+				status.Degraded = true
+				status.Reason = fmt.Sprintf("provider %s failed %d requests in a row", p.Name, p.ConsecutiveFailures)
+				break
+			}
+		}
+	}
+
+	return status
+}
+
+// provider returns the health of a provider, creating it on first use. The
+// caller must hold the mutex.
+func (h *Health) provider(name string) *ProviderHealth {
+	p, ok := h.providers[name]
+	if !ok {
+		p = &ProviderHealth{Name: name}
+		h.providers[name] = p
+	}
+	return p
+}
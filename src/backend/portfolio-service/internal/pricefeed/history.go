@@ -0,0 +1,148 @@
+package pricefeed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"bookman/portfolio-service/internal/models"
+)
+
+const day = 24 * time.Hour
+
+// HistoryProvider is implemented by providers that serve daily price history
+// by symbol
+type HistoryProvider interface {
+	Provider
+	// FetchDailyBars returns the USD daily bars of the upper-case symbol
+	// starting within [start, end), or none when the provider does not know
+	// the symbol
+	FetchDailyBars(ctx context.Context, symbol string, start, end time.Time) ([]models.PriceBar, error)
+}
+
+// cryptocompareMaxDays is the most days one histoday request returns
+const cryptocompareMaxDays = 2000
+
+func (p *cryptocompare) FetchDailyBars(ctx context.Context, symbol string, start, end time.Time) ([]models.PriceBar, error) {
+	header := http.Header{}
+	if p.apiKey != "" {
+		header.Set("Authorization", "Apikey "+p.apiKey)
+	}
+
+	var bars []models.PriceBar
+	// Pages are read backwards from the last day
+	for to := end.Add(-day); !to.Before(start); {
+		days := int(to.Sub(start)/day) + 1
+		if days > cryptocompareMaxDays {
+			days = cryptocompareMaxDays
+		}
+		params := url.Values{
+			"fsym":  {symbol},
+			"tsym":  {"USD"},
+			"limit": {strconv.Itoa(days - 1)},
+			"toTs":  {strconv.FormatInt(to.Unix(), 10)},
+		}
+
+		var body struct {
+			Response string `json:"Response"`
+			Message  string `json:"Message"`
+			Data     struct {
+				Data []struct {
+					Time     int64           `json:"time"`
+					Open     decimal.Decimal `json:"open"`
+					High     decimal.Decimal `json:"high"`
+					Low      decimal.Decimal `json:"low"`
+					Close    decimal.Decimal `json:"close"`
+					VolumeTo decimal.Decimal `json:"volumeto"`
+				} `json:"Data"`
+			} `json:"Data"`
+		}
+		if err := p.get(ctx, "/data/v2/histoday", params, header, &body); err != nil {
+			return nil, err
+		}
+		if body.Response == "Error" {
+			return nil, p.responseError(body.Message)
+		}
+
+		for _, point := range body.Data.Data {
+			bars = append(bars, models.PriceBar{
+				Symbol: symbol,
+				Day:    time.Unix(point.Time, 0).UTC(),
+				Open:   point.Open,
+				High:   point.High,
+				Low:    point.Low,
+				Close:  point.Close,
+				Volume: point.VolumeTo,
+			})
+		}
+		to = to.AddDate(0, 0, -days)
+	}
+
+	return bars, nil
+}
+
+// binanceMaxKlines is the most klines one request returns
+const binanceMaxKlines = 1000
+
+func (p *binance) FetchDailyBars(ctx context.Context, symbol string, start, end time.Time) ([]models.PriceBar, error) {
+	var bars []models.PriceBar
+	for from := start; from.Before(end); {
+		params := url.Values{
+			"symbol":    {symbol + "USDT"},
+			"interval":  {"1d"},
+			"startTime": {strconv.FormatInt(from.UnixMilli(), 10)},
+			"endTime":   {strconv.FormatInt(end.UnixMilli()-1, 10)},
+			"limit":     {strconv.Itoa(binanceMaxKlines)},
+		}
+
+		// Klines are arrays of open time, open, high, low, close, volume,
+		// close time and quote volume followed by fields not needed here
+		var klines [][]json.RawMessage
+		if err := p.get(ctx, "/klines", params, nil, &klines); err != nil {
+			return nil, err
+		}
+		if len(klines) == 0 {
+			break
+		}
+
+		for _, kline := range klines {
+			bar, err := parseKline(symbol, kline)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode %s kline: %w", p.name, err)
+			}
+			bars = append(bars, bar)
+		}
+		if len(klines) < binanceMaxKlines {
+			break
+		}
+		from = bars[len(bars)-1].Day.Add(day)
+	}
+
+	return bars, nil
+}
+
+// parseKline decodes the fields of a Binance kline into a bar
+func parseKline(symbol string, kline []json.RawMessage) (models.PriceBar, error) {
+	if len(kline) < 8 {
+		return models.PriceBar{}, fmt.Errorf("expected at least 8 fields, got %d", len(kline))
+	}
+
+	var openTime int64
+	if err := json.Unmarshal(kline[0], &openTime); err != nil {
+		return models.PriceBar{}, err
+	}
+
+	bar := models.PriceBar{Symbol: symbol, Day: time.UnixMilli(openTime).UTC()}
+	for i, dest := range map[int]*decimal.Decimal{1: &bar.Open, 2: &bar.High, 3: &bar.Low, 4: &bar.Close, 7: &bar.Volume} {
+		if err := json.Unmarshal(kline[i], dest); err != nil {
+			return models.PriceBar{}, err
+		}
+	}
+	return bar, nil
+}
@@ -0,0 +1,185 @@
+// Package pricefeed refreshes the current prices of held symbols from the
+// configured market data providers in the background, so valuations read
+// stored prices and never wait on provider APIs.
+//
+// Providers are consulted in priority order; symbols a provider fails to
+// price are asked of the next one.
+package pricefeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"github.com/shopspring/decimal"                  // v1.3.1
+	"go.uber.org/zap"                                // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/exchanges"
+	"bookman/portfolio-service/internal/metrics"
+)
+
+var providerRequests = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "portfolio_price_provider_requests_total",
+		Help: "Total number of price provider batch requests by provider and status",
+	},
+	[]string{"provider", "status"},
+)
+
+func init() {
+	metrics.MustRegister(providerRequests)
+}
+
+// Provider fetches current USD prices from one market data API
+type Provider interface {
+	// Name identifies the provider in logs and metrics
+	Name() string
+	// MaxBatch is the number of symbols one request may ask for, or 0 when
+	// unbounded
+	MaxBatch() int
+	// FetchPrices returns the prices of the upper-case symbols, omitting
+	// those the provider does not know
+	FetchPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error)
+}
+
+// NewProviders creates clients for the configured providers, keeping their
+// order. Each provider has its own rate limit.
+func NewProviders(cfgs []config.ProviderConfig, httpClient *http.Client, retry exchanges.RetryPolicy) ([]Provider, error) {
+	providers := make([]Provider, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		// Rate limits are configured per minute
+		client := exchanges.NewClient(cfg.Name, httpClient, float64(cfg.RateLimit)/60, 1, retry)
+		base := httpProvider{name: cfg.Name, baseURL: cfg.BaseURL, apiKey: cfg.APIKey(), client: client}
+
+		switch cfg.Type {
+		case "coingecko":
+			providers = append(providers, &coingecko{base})
+		case "coinmarketcap":
+			providers = append(providers, &coinmarketcap{base})
+		case "cryptocompare":
+			providers = append(providers, &cryptocompare{base})
+		case "binance":
+			providers = append(providers, &binance{base})
+		default:
+			return nil, fmt.Errorf("provider %q has unsupported type %q", cfg.Name, cfg.Type)
+		}
+	}
+	return providers, nil
+}
+
+// Fetcher prices symbols through a list of providers in priority order
+type Fetcher struct {
+	providers []Provider
+	health    *Health
+	logger    *zap.Logger
+}
+
+// NewFetcher creates a fetcher consulting providers in the given order and
+// recording the outcome of their requests in health
+func NewFetcher(providers []Provider, health *Health, logger *zap.Logger) (*Fetcher, error) {
+	if len(providers) == 0 || health == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+	return &Fetcher{providers: providers, health: health, logger: logger}, nil
+}
+
+// FetchPrices returns the positive prices of as many symbols as the
+// providers know, along with the name of the provider that supplied each.
+// Symbols are requested in batches of each provider's maximum; a failed batch
+// is retried with the next provider. An error is only returned when ctx is
+// cancelled.
+func (f *Fetcher) FetchPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, map[string]string, error) {
+	prices := make(map[string]decimal.Decimal, len(symbols))
+	sources := make(map[string]string, len(symbols))
+	remaining := unique(symbols)
+
+	for _, provider := range f.providers {
+		if len(remaining) == 0 {
+			break
+		}
+
+		for _, batch := range batches(remaining, provider.MaxBatch()) {
+			fetched, err := provider.FetchPrices(ctx, batch)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, nil, ctx.Err()
+				}
+				providerRequests.WithLabelValues(provider.Name(), "error").Inc()
+				f.health.RecordFailure(provider.Name(), time.Now(), err)
+				f.logger.Warn("Price provider request failed",
+					zap.Error(err),
+					zap.String("provider", provider.Name()),
+					zap.Int("symbols", len(batch)),
+				)
+				continue
+			}
+			providerRequests.WithLabelValues(provider.Name(), "success").Inc()
+			f.health.RecordSuccess(provider.Name(), time.Now())
+
+			for _, symbol := range batch {
+				if price, ok := fetched[symbol]; ok && price.IsPositive() {
+					prices[symbol] = price
+					sources[symbol] = provider.Name()
+				}
+			}
+		}
+
+		unpriced := remaining[:0:0]
+		for _, symbol := range remaining {
+			if _, ok := prices[symbol]; !ok {
+				unpriced = append(unpriced, symbol)
+			}
+		}
+		remaining = unpriced
+	}
+
+	return prices, sources, nil
+}
+
+// unique returns symbols without duplicates, in first-seen order
+func unique(symbols []string) []string {
+	seen := make(map[string]bool, len(symbols))
+	result := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		if !seen[symbol] {
+			seen[symbol] = true
+			result = append(result, symbol)
+		}
+	}
+	return result
+}
+
+// batches splits symbols into slices of at most size symbols; a size of 0
+// keeps them in one batch
+func batches(symbols []string, size int) [][]string {
+	if size <= 0 || len(symbols) <= size {
+		return [][]string{symbols}
+	}
+
+	result := make([][]string, 0, (len(symbols)+size-1)/size)
+	for start := 0; start < len(symbols); start += size {
+		end := start + size
+		if end > len(symbols) {
+			end = len(symbols)
+		}
+		result = append(result, symbols[start:end])
+	}
+	return result
+}
+
+// checkStatus classifies unsuccessful HTTP responses of a provider
+func checkStatus(provider string, resp *exchanges.Response) error {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %s returned status %d", exchanges.ErrRateLimited, provider, resp.StatusCode)
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("%w: %s returned status %d", exchanges.ErrTemporary, provider, resp.StatusCode)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("%s returned status %d", provider, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package pricefeed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"bookman/portfolio-service/internal/exchanges"
+)
+
+// httpProvider holds what all provider clients share
+type httpProvider struct {
+	name    string
+	baseURL string
+	apiKey  string
+	client  *exchanges.Client
+}
+
+func (p *httpProvider) Name() string {
+	return p.name
+}
+
+// get sends a GET request of path and decodes the JSON response into out
+func (p *httpProvider) get(ctx context.Context, path string, params url.Values, header http.Header, out interface{}) error {
+	target := strings.TrimRight(p.baseURL, "/") + path
+	if len(params) > 0 {
+		target += "?" + params.Encode()
+	}
+
+	build := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		req.Header.Set("Accept", "application/json")
+		return req, nil
+	}
+	return p.client.Do(ctx, build, func(resp *exchanges.Response) error {
+		if err := checkStatus(p.name, resp); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(resp.Body, out); err != nil {
+			return fmt.Errorf("failed to decode %s response: %w", p.name, err)
+		}
+		return nil
+	})
+}
+
+// coingecko reads the simple price endpoint, which resolves a symbol shared
+// by several coins to the one with the largest market cap
+type coingecko struct{ httpProvider }
+
+func (p *coingecko) MaxBatch() int { return 50 }
+
+func (p *coingecko) FetchPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error) {
+	params := url.Values{
+		"symbols":       {strings.ToLower(strings.Join(symbols, ","))},
+		"vs_currencies": {"usd"},
+	}
+	header := http.Header{}
+	if p.apiKey != "" {
+		// Pro and demo keys are sent in different headers
+		if strings.Contains(p.baseURL, "pro-api") {
+			header.Set("x-cg-pro-api-key", p.apiKey)
+		} else {
+			header.Set("x-cg-demo-api-key", p.apiKey)
+		}
+	}
+
+	var body map[string]map[string]decimal.Decimal
+	if err := p.get(ctx, "/simple/price", params, header, &body); err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]decimal.Decimal, len(body))
+	for symbol, quote := range body {
+		if price, ok := quote["usd"]; ok {
+			prices[strings.ToUpper(symbol)] = price
+		}
+	}
+	return prices, nil
+}
+
+// coinmarketcap reads the latest quotes endpoint, skipping unknown symbols
+// instead of failing the batch
+type coinmarketcap struct{ httpProvider }
+
+func (p *coinmarketcap) MaxBatch() int { return 100 }
+
+func (p *coinmarketcap) FetchPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error) {
+	params := url.Values{
+		"symbol":       {strings.Join(symbols, ",")},
+		"convert":      {"USD"},
+		"skip_invalid": {"true"},
+	}
+	header := http.Header{}
+	if p.apiKey != "" {
+		header.Set("X-CMC_PRO_API_KEY", p.apiKey)
+	}
+
+	var body struct {
+		Data map[string][]struct {
+			Quote map[string]struct {
+				Price decimal.NullDecimal `json:"price"`
+			} `json:"quote"`
+		} `json:"data"`
+	}
+	if err := p.get(ctx, "/v2/cryptocurrency/quotes/latest", params, header, &body); err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]decimal.Decimal, len(body.Data))
+	for symbol, listings := range body.Data {
+		// Listings sharing a symbol are ordered by rank
+		if len(listings) == 0 {
+			continue
+		}
+		if quote, ok := listings[0].Quote["USD"]; ok && quote.Price.Valid {
+			prices[strings.ToUpper(symbol)] = quote.Price.Decimal
+		}
+	}
+	return prices, nil
+}
+
+// cryptocompare reads the multiple symbols price endpoint
+type cryptocompare struct{ httpProvider }
+
+func (p *cryptocompare) MaxBatch() int { return 50 }
+
+func (p *cryptocompare) FetchPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error) {
+	params := url.Values{
+		"fsyms": {strings.Join(symbols, ",")},
+		"tsyms": {"USD"},
+	}
+	header := http.Header{}
+	if p.apiKey != "" {
+		header.Set("Authorization", "Apikey "+p.apiKey)
+	}
+
+	var body map[string]json.RawMessage
+	if err := p.get(ctx, "/data/pricemulti", params, header, &body); err != nil {
+		return nil, err
+	}
+	// Errors are reported in the body of a successful response
+	if raw, ok := body["Response"]; ok {
+		var message string
+		_ = json.Unmarshal(body["Message"], &message)
+		var response string
+		if json.Unmarshal(raw, &response) == nil && response == "Error" {
+			return nil, p.responseError(message)
+		}
+	}
+
+	prices := make(map[string]decimal.Decimal, len(body))
+	for symbol, raw := range body {
+		var quote map[string]decimal.Decimal
+		if err := json.Unmarshal(raw, &quote); err != nil {
+			continue
+		}
+		if price, ok := quote["USD"]; ok {
+			prices[strings.ToUpper(symbol)] = price
+		}
+	}
+	return prices, nil
+}
+
+// responseError classifies an error reported in a response body
+func (p *cryptocompare) responseError(message string) error {
+	if strings.Contains(strings.ToLower(message), "rate limit") {
+		return fmt.Errorf("%w: %s: %s", exchanges.ErrRateLimited, p.name, message)
+	}
+	return fmt.Errorf("%s request failed: %s", p.name, message)
+}
+
+// binance prices symbols by their USDT pairs. Asking for an unlisted pair
+// fails the whole request, so all tickers are read at once and filtered.
+type binance struct{ httpProvider }
+
+func (p *binance) MaxBatch() int { return 0 }
+
+func (p *binance) FetchPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error) {
+	var tickers []struct {
+		Symbol string          `json:"symbol"`
+		Price  decimal.Decimal `json:"price"`
+	}
+	if err := p.get(ctx, "/ticker/price", nil, nil, &tickers); err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]string, len(symbols))
+	for _, symbol := range symbols {
+		wanted[symbol+"USDT"] = symbol
+	}
+
+	prices := make(map[string]decimal.Decimal, len(symbols))
+	for _, ticker := range tickers {
+		if symbol, ok := wanted[ticker.Symbol]; ok {
+			prices[symbol] = ticker.Price
+		}
+	}
+	return prices, nil
+}
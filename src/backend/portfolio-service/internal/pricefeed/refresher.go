@@ -0,0 +1,179 @@
+package pricefeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"go.uber.org/zap"                                // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/metrics"
+	"bookman/portfolio-service/internal/models"
+	"bookman/portfolio-service/internal/repository"
+	"bookman/portfolio-service/internal/services"
+)
+
+var (
+	refreshRuns = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "portfolio_price_refresh_runs_total",
+			Help: "Total number of background price refresh runs by status",
+		},
+		[]string{"status"},
+	)
+
+	unpricedSymbols = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "portfolio_price_refresh_unpriced_symbols",
+			Help: "Number of held symbols no provider returned a price for in the last refresh",
+		},
+	)
+)
+
+func init() {
+	metrics.MustRegister(refreshRuns, unpricedSymbols)
+}
+
+// Refresher periodically refreshes the current prices of all held symbols,
+// and the exchange rates of held fiat currencies when an FX fetcher is set
+type Refresher struct {
+	repo    *repository.PostgresRepository
+	svc     *services.PortfolioService
+	fetcher *Fetcher
+	fx      *Fetcher
+	cfg     config.PriceRefreshConfig
+	logger  *zap.Logger
+}
+
+// NewRefresher creates a new background price refresh job. fx may be nil
+// when no FX providers are configured.
+func NewRefresher(repo *repository.PostgresRepository, svc *services.PortfolioService, fetcher, fx *Fetcher, cfg config.PriceRefreshConfig, logger *zap.Logger) (*Refresher, error) {
+	if repo == nil || svc == nil || fetcher == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	return &Refresher{
+		repo:    repo,
+		svc:     svc,
+		fetcher: fetcher,
+		fx:      fx,
+		cfg:     cfg,
+		logger:  logger.With(zap.String("component", "price_refresher")),
+	}, nil
+}
+
+// Run refreshes prices at start and then on every interval until ctx is
+// cancelled
+func (r *Refresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			refreshRuns.WithLabelValues("error").Inc()
+			r.logger.Error("Price refresh failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce fetches the prices of all held symbols, and the rates of held fiat
+// currencies other than the base currency, in batches and stores each batch
+// as soon as it is priced. Held symbols no provider quoted for the delisting
+// period are then marked delisted.
+func (r *Refresher) RunOnce(ctx context.Context) error {
+	symbols, err := r.repo.ListHeldSymbols(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list held symbols: %w", err)
+	}
+
+	stored, unpriced, err := r.refresh(ctx, r.fetcher, symbols)
+	if err != nil {
+		return err
+	}
+
+	var currencies []string
+	if r.fx != nil {
+		held, err := r.repo.ListHeldFiatCurrencies(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list held fiat currencies: %w", err)
+		}
+		for _, currency := range held {
+			// The base currency is always valued at 1
+			if currency != models.BASE_CURRENCY {
+				currencies = append(currencies, currency)
+			}
+		}
+
+		fxStored, fxUnpriced, err := r.refresh(ctx, r.fx, currencies)
+		if err != nil {
+			return err
+		}
+		stored += fxStored
+		unpriced += fxUnpriced
+	}
+
+	// Only mark symbols delisted while providers answer, so an outage does
+	// not delist everything
+	if r.cfg.DelistAfter > 0 && stored > 0 {
+		now := time.Now().UTC()
+		delisted, err := r.repo.MarkDelistedSymbols(ctx, now.Add(-r.cfg.DelistAfter), now)
+		if err != nil {
+			return fmt.Errorf("failed to mark delisted symbols: %w", err)
+		}
+		if len(delisted) > 0 {
+			r.logger.Warn("Symbols delisted after going unquoted",
+				zap.Strings("symbols", delisted),
+				zap.Duration("unquoted_for", r.cfg.DelistAfter),
+			)
+		}
+	}
+
+	refreshRuns.WithLabelValues("success").Inc()
+	unpricedSymbols.Set(float64(unpriced))
+	r.logger.Debug("Prices refreshed",
+		zap.Int("symbols", len(symbols)),
+		zap.Int("currencies", len(currencies)),
+		zap.Int("stored", stored),
+		zap.Int("unpriced", unpriced),
+	)
+
+	return nil
+}
+
+// refresh fetches the prices of symbols with fetcher in batches and stores
+// each batch, returning how many prices were stored and how many symbols no
+// provider priced
+func (r *Refresher) refresh(ctx context.Context, fetcher *Fetcher, symbols []string) (int, int, error) {
+	stored, unpriced := 0, 0
+	for start := 0; start < len(symbols); start += r.cfg.BatchSize {
+		end := start + r.cfg.BatchSize
+		if end > len(symbols) {
+			end = len(symbols)
+		}
+		batch := symbols[start:end]
+
+		prices, sources, err := fetcher.FetchPrices(ctx, batch)
+		if err != nil {
+			return stored, unpriced, err
+		}
+		unpriced += len(batch) - len(prices)
+		if len(prices) == 0 {
+			continue
+		}
+
+		if err := r.svc.RefreshPrices(ctx, prices, sources); err != nil {
+			return stored, unpriced, fmt.Errorf("failed to store prices: %w", err)
+		}
+		stored += len(prices)
+	}
+	return stored, unpriced, nil
+}
@@ -0,0 +1,415 @@
+// Package reports renders portfolio performance reports and stores them for
+// download
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal" // v1.3.1
+
+	"bookman/portfolio-service/internal/models"
+)
+
+// A4 page geometry in points
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	margin     = 50.0
+	// footerSpace keeps content clear of the page footer
+	footerSpace = 24.0
+	rowHeight   = 16.0
+	fontSize    = 9.0
+)
+
+// Fonts are the standard Helvetica faces every PDF reader provides
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+// maxChartSlices bounds the bars of the allocation chart; smaller slices are
+// combined into one
+const maxChartSlices = 10
+
+// column is a table column; numeric columns are right-aligned
+type column struct {
+	title string
+	width float64
+	right bool
+}
+
+// document lays out content top to bottom over as many pages as needed
+type document struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+// RenderPDF renders a performance report as a PDF document with a P&L
+// summary, the holdings at the end of the period, an allocation chart and the
+// top movers
+func RenderPDF(report *models.PerformanceReport) ([]byte, error) {
+	if report == nil {
+		return nil, fmt.Errorf("no report to render")
+	}
+
+	d := &document{}
+	d.newPage()
+
+	d.text(margin, d.y-18, fontBold, 18, "Portfolio Performance Report")
+	d.y -= 30
+	d.text(margin, d.y-12, fontRegular, 11, truncate(report.PortfolioName, 11, pageWidth-2*margin))
+	d.y -= 16
+	d.text(margin, d.y-12, fontRegular, 11, PeriodLabel(report))
+	d.y -= 30
+
+	d.heading("Summary")
+	summary := [][2]string{
+		{"Start value", formatMoney(report.StartValue, report.BaseCurrency)},
+		{"End value", formatMoney(report.EndValue, report.BaseCurrency)},
+		{"Value change", formatMoney(report.EndValue.Sub(report.StartValue), report.BaseCurrency)},
+		{"Profit and loss for the period", formatMoney(report.PeriodProfitLoss, report.BaseCurrency)},
+		{"Return", formatPercent(report.ReturnPercentage, true)},
+		{"Unrealized profit and loss", formatMoney(report.EndProfitLoss, report.BaseCurrency)},
+		{"Fees paid", formatMoney(report.FeesPaid, report.BaseCurrency)},
+	}
+	for _, line := range summary {
+		d.ensure(rowHeight)
+		d.text(margin, d.y-12, fontRegular, fontSize, line[0])
+		d.textRight(margin+320, d.y-12, fontRegular, fontSize, line[1])
+		d.y -= rowHeight
+	}
+	d.y -= rowHeight
+
+	holdings := make([][]string, len(report.Holdings))
+	for i, h := range report.Holdings {
+		holdings[i] = []string{
+			h.Symbol,
+			formatAmount(h.Amount),
+			formatMoney(h.Value, ""),
+			formatMoney(h.CostBasis, ""),
+			formatMoney(h.ProfitLoss, ""),
+			formatPercent(h.Weight, false),
+		}
+	}
+	d.table("Holdings", []column{
+		{title: "Asset", width: 75},
+		{title: "Amount", width: 110, right: true},
+		{title: "Value", width: 85, right: true},
+		{title: "Cost Basis", width: 85, right: true},
+		{title: "P&L", width: 85, right: true},
+		{title: "Weight", width: 55, right: true},
+	}, holdings)
+
+	d.allocationChart(report.Allocation)
+
+	movers := make([][]string, len(report.TopMovers))
+	for i, m := range report.TopMovers {
+		movers[i] = []string{
+			m.Symbol,
+			formatMoney(m.StartPrice, ""),
+			formatMoney(m.EndPrice, ""),
+			formatPercent(m.ChangePercent, true),
+		}
+	}
+	d.table("Top Movers", []column{
+		{title: "Asset", width: 75},
+		{title: "Start Price", width: 110, right: true},
+		{title: "End Price", width: 110, right: true},
+		{title: "Change", width: 80, right: true},
+	}, movers)
+
+	generated := "Generated " + report.GeneratedAt.UTC().Format("2006-01-02 15:04 MST")
+	for i, page := range d.pages {
+		fmt.Fprintf(page, "BT /%s 8 Tf %.2f %.2f Td (%s) Tj ET\n", fontRegular, margin, margin-10, escapeText(generated))
+		label := fmt.Sprintf("Page %d of %d", i+1, len(d.pages))
+		fmt.Fprintf(page, "BT /%s 8 Tf %.2f %.2f Td (%s) Tj ET\n", fontRegular, pageWidth-margin-textWidth(label, 8), margin-10, label)
+	}
+
+	return d.bytes(), nil
+}
+
+// PeriodLabel describes the period of a report, e.g. "March 2024", "Q1 2024"
+// or "2024", followed by its dates in the timezone it was taken in
+func PeriodLabel(report *models.PerformanceReport) string {
+	start := report.PeriodStart
+	end := report.PeriodEnd.Add(-1)
+	var label string
+	switch report.Period {
+	case models.ReportPeriodWeek:
+		label = "Week of " + start.Format("January 2, 2006")
+	case models.ReportPeriodQuarter:
+		label = fmt.Sprintf("Q%d %d", (int(start.Month())-1)/3+1, start.Year())
+	case models.ReportPeriodYear:
+		label = start.Format("2006")
+	default:
+		label = start.Format("January 2006")
+	}
+	return fmt.Sprintf("%s (%s to %s)", label, start.Format("2006-01-02"), end.Format("2006-01-02"))
+}
+
+// newPage starts a new page with the cursor below the top margin
+func (d *document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pageHeight - margin
+}
+
+// page returns the content stream of the current page
+func (d *document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// ensure starts a new page unless height points remain above the footer
+func (d *document) ensure(height float64) {
+	if d.y-height < margin+footerSpace {
+		d.newPage()
+	}
+}
+
+// text draws s with its baseline starting at x, y
+func (d *document) text(x, y float64, font string, size float64, s string) {
+	fmt.Fprintf(d.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escapeText(s))
+}
+
+// textRight draws s ending at right
+func (d *document) textRight(right, y float64, font string, size float64, s string) {
+	d.text(right-textWidth(s, size), y, font, size, s)
+}
+
+// rect fills a rectangle in the given gray level, 0 being black
+func (d *document) rect(x, y, w, h, gray float64) {
+	fmt.Fprintf(d.page(), "%.2f g %.2f %.2f %.2f %.2f re f 0 g\n", gray, x, y, w, h)
+}
+
+// rule draws a horizontal line across the content width at y
+func (d *document) rule(y float64) {
+	fmt.Fprintf(d.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", margin, y, pageWidth-margin, y)
+}
+
+// heading draws a section title, keeping it on the page of the first rows
+func (d *document) heading(title string) {
+	d.ensure(rowHeight * 3)
+	d.text(margin, d.y-13, fontBold, 13, title)
+	d.y -= 22
+}
+
+// table draws a section of rows, repeating the header on each page
+func (d *document) table(title string, columns []column, rows [][]string) {
+	d.heading(title)
+
+	header := func() {
+		x := margin
+		for _, c := range columns {
+			if c.right {
+				d.textRight(x+c.width, d.y-12, fontBold, fontSize, c.title)
+			} else {
+				d.text(x, d.y-12, fontBold, fontSize, c.title)
+			}
+			x += c.width
+		}
+		d.rule(d.y - rowHeight)
+		d.y -= rowHeight
+	}
+	header()
+
+	if len(rows) == 0 {
+		d.text(margin, d.y-12, fontRegular, fontSize, "None")
+		d.y -= rowHeight
+	}
+	for _, row := range rows {
+		if d.y-rowHeight < margin+footerSpace {
+			d.newPage()
+			header()
+		}
+		x := margin
+		for i, c := range columns {
+			value := truncate(row[i], fontSize, c.width-6)
+			if c.right {
+				d.textRight(x+c.width, d.y-12, fontRegular, fontSize, value)
+			} else {
+				d.text(x, d.y-12, fontRegular, fontSize, value)
+			}
+			x += c.width
+		}
+		d.y -= rowHeight
+	}
+	d.y -= rowHeight
+}
+
+// allocationChart draws the allocation by symbol as horizontal bars scaled to
+// the largest slice
+func (d *document) allocationChart(slices []models.AllocationSlice) {
+	d.heading("Allocation")
+	if len(slices) == 0 {
+		d.text(margin, d.y-12, fontRegular, fontSize, "None")
+		d.y -= rowHeight * 2
+		return
+	}
+
+	if len(slices) > maxChartSlices {
+		other := models.AllocationSlice{Key: "Other"}
+		for _, s := range slices[maxChartSlices-1:] {
+			other.Value = other.Value.Add(s.Value)
+			other.Percentage = other.Percentage.Add(s.Percentage)
+		}
+		slices = append(slices[:maxChartSlices-1:maxChartSlices-1], other)
+	}
+
+	const (
+		labelWidth = 75.0
+		barWidth   = 320.0
+	)
+	largest := decimal.Zero
+	for _, s := range slices {
+		if s.Percentage.GreaterThan(largest) {
+			largest = s.Percentage
+		}
+	}
+
+	for i, s := range slices {
+		d.ensure(rowHeight)
+		d.text(margin, d.y-12, fontRegular, fontSize, truncate(s.Key, fontSize, labelWidth-6))
+		width := 0.0
+		if largest.IsPositive() {
+			width = s.Percentage.Div(largest).InexactFloat64() * barWidth
+		}
+		d.rect(margin+labelWidth, d.y-13, width, 10, 0.35+0.3*float64(i%2))
+		d.text(margin+labelWidth+width+6, d.y-12, fontRegular, fontSize, formatPercent(s.Percentage, false))
+		d.y -= rowHeight
+	}
+	d.y -= rowHeight
+}
+
+// bytes assembles the pages into a PDF file
+func (d *document) bytes() []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 to 4 are shared; each page adds a page and a content object
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, fontRegular, fontBold, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", page.Len(), page.Bytes()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+// escapeText escapes a string literal of a content stream. The fonts use
+// WinAnsiEncoding, so characters outside printable ASCII are replaced.
+func escapeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// glyphWidths holds Helvetica advance widths, in thousandths of the font size,
+// of the characters that differ from the default
+var glyphWidths = map[rune]float64{
+	' ': 278, '.': 278, ',': 278, '-': 333, '%': 889, '+': 584, '(': 333, ')': 333,
+	'&': 667, 'I': 278, 'i': 222, 'l': 222, 'j': 222, 't': 278, 'f': 278, 'r': 333,
+	'M': 833, 'W': 944, 'm': 833, 'w': 722,
+}
+
+// textWidth approximates the rendered width of s. Digits, which all numbers
+// consist of, are exact so right-aligned columns line up.
+func textWidth(s string, size float64) float64 {
+	width := 0.0
+	for _, r := range s {
+		w, ok := glyphWidths[r]
+		if !ok {
+			w = 556
+		}
+		width += w
+	}
+	return width * size / 1000
+}
+
+// truncate shortens s with an ellipsis to fit within width
+func truncate(s string, size, width float64) string {
+	if textWidth(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && textWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// formatMoney formats a value with two decimals and thousands separators,
+// followed by the currency when given
+func formatMoney(d decimal.Decimal, currency string) string {
+	s := groupThousands(d.StringFixed(2))
+	if currency != "" {
+		s += " " + currency
+	}
+	return s
+}
+
+// formatAmount formats a holding amount with up to eight decimals
+func formatAmount(d decimal.Decimal) string {
+	return groupThousands(d.Round(8).String())
+}
+
+// formatPercent formats a percentage with two decimals, signed when requested
+func formatPercent(d decimal.Decimal, signed bool) string {
+	s := d.StringFixed(2) + "%"
+	if signed && d.IsPositive() {
+		s = "+" + s
+	}
+	return s
+}
+
+// groupThousands inserts separators into the integer part of a decimal string
+func groupThousands(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	integer, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		integer, fraction = s[:i], s[i:]
+	}
+
+	var b strings.Builder
+	for i, r := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return sign + b.String() + fraction
+}
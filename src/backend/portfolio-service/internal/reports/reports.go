@@ -0,0 +1,76 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path"
+
+	"bookman/portfolio-service/internal/models"
+)
+
+// Render renders a performance report in the given format
+func Render(report *models.PerformanceReport, format models.ReportFormat) ([]byte, error) {
+	switch format {
+	case models.ReportFormatPDF:
+		return RenderPDF(report)
+	case models.ReportFormatCSV:
+		return RenderCSV(report)
+	}
+	return nil, fmt.Errorf("unsupported report format %q", format)
+}
+
+// ContentType returns the MIME type of reports rendered in format
+func ContentType(format models.ReportFormat) string {
+	if format == models.ReportFormatCSV {
+		return "text/csv"
+	}
+	return "application/pdf"
+}
+
+// ObjectKey returns the storage key of a rendered report under prefix
+func ObjectKey(prefix string, report *models.PerformanceReport, format models.ReportFormat) string {
+	return path.Join(prefix, report.PortfolioID.String(), report.ID.String()+"."+string(format))
+}
+
+// Filename returns the download file name of a rendered report
+func Filename(report *models.PerformanceReport, format models.ReportFormat) string {
+	return fmt.Sprintf("portfolio-report-%s-%s.%s", report.Period, report.PeriodStart.Format("2006-01-02"), format)
+}
+
+// RenderCSV renders the holdings of a performance report as CSV, one row per
+// holding with its price change when it is a top mover. Amounts keep their
+// full precision.
+func RenderCSV(report *models.PerformanceReport) ([]byte, error) {
+	if report == nil {
+		return nil, fmt.Errorf("no report to render")
+	}
+
+	changes := make(map[string]string, len(report.TopMovers))
+	for _, m := range report.TopMovers {
+		changes[m.Symbol] = m.ChangePercent.String()
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"Period Start", "Period End", "Symbol", "Amount", "Value", "Cost Basis", "Profit Loss", "Weight %", "Price Change %", "Currency"})
+	for _, h := range report.Holdings {
+		w.Write([]string{
+			report.PeriodStart.Format("2006-01-02T15:04:05Z07:00"),
+			report.PeriodEnd.Format("2006-01-02T15:04:05Z07:00"),
+			h.Symbol,
+			h.Amount.String(),
+			h.Value.String(),
+			h.CostBasis.String(),
+			h.ProfitLoss.String(),
+			h.Weight.String(),
+			changes[h.Symbol],
+			report.BaseCurrency,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write report CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,84 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"              // v1.21.2
+	awsconfig "github.com/aws/aws-sdk-go-v2/config" // v1.18.45
+	"github.com/aws/aws-sdk-go-v2/service/s3"       // v1.40.0
+	"github.com/aws/aws-sdk-go-v2/service/s3/types" // v1.40.0
+
+	"bookman/portfolio-service/internal/config"
+)
+
+// Store persists rendered reports and issues time-limited download URLs
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType, filename string) error
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// S3Store implements Store on top of Amazon S3 or an S3-compatible service
+type S3Store struct {
+	client  *s3.Client
+	presign *s3.PresignClient
+	bucket  string
+}
+
+// NewS3Store creates an S3-backed report store from reports configuration
+func NewS3Store(ctx context.Context, cfg *config.ReportsConfig) (*S3Store, error) {
+	if cfg == nil {
+		return nil, errors.New("reports configuration is required")
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(cfg.Region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return &S3Store{
+		client:  client,
+		presign: s3.NewPresignClient(client),
+		bucket:  cfg.Bucket,
+	}, nil
+}
+
+// Put uploads a report encrypted at rest with the bucket's KMS key; browsers
+// save it under filename
+func (s *S3Store) Put(ctx context.Context, key string, data []byte, contentType, filename string) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(key),
+		Body:                 bytes.NewReader(data),
+		ContentLength:        int64(len(data)),
+		ContentType:          aws.String(contentType),
+		ContentDisposition:   aws.String(fmt.Sprintf("attachment; filename=%q", filename)),
+		ServerSideEncryption: types.ServerSideEncryptionAwsKms,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload report %s: %w", key, err)
+	}
+	return nil
+}
+
+// SignedURL returns a presigned GET URL of a report valid for expiry
+func (s *S3Store) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	req, err := s.presign.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign report URL %s: %w", key, err)
+	}
+	return req.URL, nil
+}
@@ -0,0 +1,126 @@
+// Package reportschedule delivers scheduled performance reports in the
+// background. Due schedules are leased so several service instances can
+// deliver concurrently without sending a report twice.
+package reportschedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"go.uber.org/zap"                                // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/metrics"
+	"bookman/portfolio-service/internal/repository"
+	"bookman/portfolio-service/internal/services"
+)
+
+const (
+	// pollInterval is how often the scheduler looks for due schedules
+	pollInterval = time.Minute
+	// leaseDuration bounds the time a claimed schedule is held by one
+	// instance; a crashed instance's claims are delivered after it expires
+	leaseDuration = 15 * time.Minute
+)
+
+var reportDeliveries = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "portfolio_report_deliveries_total",
+		Help: "Total number of scheduled report deliveries by frequency, format and status",
+	},
+	[]string{"frequency", "format", "status"},
+)
+
+func init() {
+	metrics.MustRegister(reportDeliveries)
+}
+
+// Scheduler delivers due report schedules on a fixed interval
+type Scheduler struct {
+	repo   *repository.PostgresRepository
+	svc    *services.PortfolioService
+	cfg    config.ReportsConfig
+	logger *zap.Logger
+}
+
+// NewScheduler creates a new scheduled report delivery job
+func NewScheduler(repo *repository.PostgresRepository, svc *services.PortfolioService, cfg config.ReportsConfig, logger *zap.Logger) (*Scheduler, error) {
+	if repo == nil || svc == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	return &Scheduler{
+		repo:   repo,
+		svc:    svc,
+		cfg:    cfg,
+		logger: logger.With(zap.String("component", "report_scheduler")),
+	}, nil
+}
+
+// Run delivers due reports at each poll until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("Report delivery pass failed", zap.Error(err))
+		}
+	}
+}
+
+// RunOnce claims and delivers batches of due schedules until none are left. A
+// failed delivery is recorded on its schedule and retried later.
+func (s *Scheduler) RunOnce(ctx context.Context) error {
+	failed := 0
+	for {
+		now := time.Now().UTC()
+		schedules, err := s.repo.ClaimDueReportSchedules(ctx, now, now.Add(leaseDuration), s.cfg.ScheduleBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to claim report schedules: %w", err)
+		}
+
+		for i := range schedules {
+			schedule := &schedules[i]
+			generated, err := s.svc.RunReportSchedule(ctx, schedule)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				failed++
+				reportDeliveries.WithLabelValues(string(schedule.Frequency), string(schedule.Format), "error").Inc()
+				s.logger.Warn("Scheduled report delivery failed",
+					zap.Error(err),
+					zap.String("schedule_id", schedule.ID.String()),
+					zap.String("portfolio_id", schedule.PortfolioID.String()),
+				)
+				continue
+			}
+
+			reportDeliveries.WithLabelValues(string(schedule.Frequency), string(schedule.Format), "success").Inc()
+			s.logger.Debug("Scheduled report delivered",
+				zap.String("schedule_id", schedule.ID.String()),
+				zap.String("report_id", generated.Report.ID.String()),
+				zap.Time("next_run_at", schedule.NextRunAt),
+			)
+		}
+
+		if len(schedules) < s.cfg.ScheduleBatchSize {
+			break
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d scheduled reports failed to deliver", failed)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/lib/pq"                // v1.10.9
+    "github.com/shopspring/decimal"    // v1.3.1
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/pagination"
+)
+
+// ListActivity returns up to limit activity feed items of a portfolio of the
+// given kinds before the position, newest first. Items are ordered by time,
+// kind and ID.
+func (r *PostgresRepository) ListActivity(ctx context.Context, portfolioID uuid.UUID, kinds []models.ActivityKind, before pagination.Position, limit int) ([]models.ActivityItem, error) {
+    names := make([]string, len(kinds))
+    for i, kind := range kinds {
+        names[i] = string(kind)
+    }
+
+    var items []models.ActivityItem
+
+    err := r.withStatementRecovery(ctx, "listActivity", func() error {
+        rows, err := r.queryContext(ctx, "listActivity",
+            portfolioID,
+            portfolioID.String(),
+            pq.Array(names),
+            before.At,
+            before.Key,
+            before.ID,
+            limit,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to query activity: %w", err)
+        }
+        defer rows.Close()
+
+        items = items[:0]
+        for rows.Next() {
+            var (
+                item       models.ActivityItem
+                kind       string
+                assetID    uuid.NullUUID
+                symbol     sql.NullString
+                txType     sql.NullString
+                amount     decimal.NullDecimal
+                price      decimal.NullDecimal
+                fee        decimal.NullDecimal
+                ruleID     uuid.NullUUID
+                alertKind  sql.NullString
+                threshold  decimal.NullDecimal
+                observed   decimal.NullDecimal
+                syncSource sql.NullString
+                syncName   sql.NullString
+                imported   sql.NullInt64
+                syncError  sql.NullString
+            )
+            err := rows.Scan(
+                &kind,
+                &item.ID,
+                &item.OccurredAt,
+                &assetID,
+                &symbol,
+                &txType,
+                &amount,
+                &price,
+                &fee,
+                &ruleID,
+                &alertKind,
+                &threshold,
+                &observed,
+                &syncSource,
+                &syncName,
+                &imported,
+                &syncError,
+            )
+            if err != nil {
+                return fmt.Errorf("failed to scan activity: %w", err)
+            }
+            item.Kind = models.ActivityKind(kind)
+            item.OccurredAt = item.OccurredAt.UTC()
+            item.AssetID = assetID.UUID
+            item.Symbol = symbol.String
+
+            switch item.Kind {
+            case models.ActivityTransaction:
+                item.Transaction = &models.Transaction{
+                    ID:          item.ID,
+                    PortfolioID: portfolioID,
+                    AssetID:     item.AssetID,
+                    Type:        txType.String,
+                    Amount:      amount.Decimal,
+                    Price:       price.Decimal,
+                    Fee:         fee.Decimal,
+                    Timestamp:   item.OccurredAt,
+                }
+            case models.ActivityAlert:
+                item.Alert = &models.AlertEvent{
+                    ID:          item.ID,
+                    RuleID:      ruleID.UUID,
+                    PortfolioID: portfolioID,
+                    Kind:        models.AlertKind(alertKind.String),
+                    Threshold:   threshold.Decimal,
+                    Observed:    observed.Decimal,
+                    Symbol:      item.Symbol,
+                    TriggeredAt: item.OccurredAt,
+                }
+            case models.ActivitySync:
+                item.Sync = &models.SyncEvent{
+                    ID:          item.ID,
+                    PortfolioID: portfolioID,
+                    Source:      syncSource.String,
+                    Name:        syncName.String,
+                    Imported:    int(imported.Int64),
+                    Error:       syncError.String,
+                    SyncedAt:    item.OccurredAt,
+                }
+            }
+            items = append(items, item)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return items, nil
+}
+
+// RecordSyncEvent stores a sync of an exchange account or tracked wallet for
+// the activity feed
+func (r *PostgresRepository) RecordSyncEvent(ctx context.Context, event *models.SyncEvent) error {
+    if event == nil || event.PortfolioID == uuid.Nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "recordSyncEvent", func() error {
+        var syncError sql.NullString
+        if event.Error != "" {
+            syncError = sql.NullString{String: event.Error, Valid: true}
+        }
+        _, err := r.statement("recordSyncEvent").ExecContext(ctx,
+            event.ID,
+            event.PortfolioID,
+            event.Source,
+            event.Name,
+            event.Imported,
+            syncError,
+            event.SyncedAt,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to record sync event: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// GetUserStats counts the portfolios of a user, archived ones apart, with
+// their assets and transactions, and sums the last valuations of the active
+// ones
+func (r *PostgresRepository) GetUserStats(ctx context.Context, userID uuid.UUID) (*models.UserStats, error) {
+    stats := &models.UserStats{UserID: userID}
+
+    err := r.withStatementRecovery(ctx, "getUserStats", func() error {
+        err := r.statement("getUserStats").QueryRowContext(ctx, userID).Scan(
+            &stats.Portfolios,
+            &stats.ArchivedPortfolios,
+            &stats.Assets,
+            &stats.Transactions,
+            &stats.Value,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to get user stats: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return stats, nil
+}
+
+// GetPlatformStats counts the active portfolios of all users with their
+// assets and transactions, and sums their last valuations
+func (r *PostgresRepository) GetPlatformStats(ctx context.Context) (*models.PlatformStats, error) {
+    stats := &models.PlatformStats{}
+
+    err := r.withStatementRecovery(ctx, "getPlatformStats", func() error {
+        err := r.statement("getPlatformStats").QueryRowContext(ctx).Scan(
+            &stats.Users,
+            &stats.Portfolios,
+            &stats.Assets,
+            &stats.Transactions,
+            &stats.AssetsUnderManagement,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to get platform stats: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return stats, nil
+}
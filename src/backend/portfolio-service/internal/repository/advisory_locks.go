@@ -0,0 +1,81 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "database/sql/driver"
+    "errors"
+    "fmt"
+)
+
+// ErrAdvisoryLockLost is returned when a held advisory lock is no longer
+// held, because its connection was closed or reset
+var ErrAdvisoryLockLost = errors.New("advisory lock lost")
+
+// AdvisoryLock is a session-level advisory lock held on a dedicated connection.
+// It is held until released or until the connection ends, so it requires a
+// direct connection rather than a transaction-pooling proxy.
+type AdvisoryLock struct {
+    conn *sql.Conn
+    key  int64
+}
+
+// TryAdvisoryLock takes the advisory lock with key without waiting. It
+// returns a nil lock when another session holds it.
+func (r *PostgresRepository) TryAdvisoryLock(ctx context.Context, key int64) (*AdvisoryLock, error) {
+    var acquired bool
+    lock, err := r.advisoryLock(ctx, "tryAdvisoryLock", key, &acquired)
+    if err != nil || acquired {
+        return lock, err
+    }
+    lock.conn.Close()
+    return nil, nil
+}
+
+// advisoryLock runs the locking statement on a reserved connection, scanning
+// its result into dest
+func (r *PostgresRepository) advisoryLock(ctx context.Context, statement string, key int64, dest interface{}) (*AdvisoryLock, error) {
+    conn, err := r.db.Conn(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("failed to reserve advisory lock connection: %w", err)
+    }
+
+    if err := conn.QueryRowContext(ctx, preparedStatements[statement], key).Scan(dest); err != nil {
+        // A cancelled attempt may leave the lock granted just after giving
+        // up, so the session is ended rather than reused
+        conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+        conn.Close()
+        return nil, fmt.Errorf("failed to take advisory lock: %w", err)
+    }
+
+    return &AdvisoryLock{conn: conn, key: key}, nil
+}
+
+// Check verifies the lock is still held by its session
+func (l *AdvisoryLock) Check(ctx context.Context) error {
+    var held bool
+    if err := l.conn.QueryRowContext(ctx, preparedStatements["checkAdvisoryLock"], l.key).Scan(&held); err != nil {
+        return fmt.Errorf("%w: %v", ErrAdvisoryLockLost, err)
+    }
+    if !held {
+        return ErrAdvisoryLockLost
+    }
+    return nil
+}
+
+// Release unlocks the lock and returns its connection to the pool. When
+// unlocking fails the connection is discarded instead, which ends its session
+// and so releases the lock on the server.
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+    var released bool
+    err := l.conn.QueryRowContext(ctx, preparedStatements["releaseAdvisoryLock"], l.key).Scan(&released)
+    if err != nil {
+        l.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+    }
+    l.conn.Close()
+
+    if err != nil {
+        return fmt.Errorf("failed to release advisory lock: %w", err)
+    }
+    return nil
+}
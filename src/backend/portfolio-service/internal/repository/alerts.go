@@ -0,0 +1,234 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/shopspring/decimal" // v1.3.1
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/pagination"
+)
+
+// CreateAlertRule stores an alert rule unless the portfolio already has
+// models.MAX_ALERT_RULES_PER_PORTFOLIO rules
+func (r *PostgresRepository) CreateAlertRule(ctx context.Context, rule *models.AlertRule) error {
+    if rule == nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "createAlertRule", func() error {
+        res, err := r.statement("createAlertRule").ExecContext(ctx,
+            rule.ID,
+            rule.PortfolioID,
+            string(rule.Kind),
+            rule.Threshold,
+            int64(rule.Window/time.Second),
+            rule.Enabled,
+            rule.CreatedAt,
+            models.MAX_ALERT_RULES_PER_PORTFOLIO,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to create alert rule: %w", err)
+        }
+        n, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        if n == 0 {
+            return ErrAlertRuleLimit
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// GetAlertRules returns all alert rules of a portfolio, oldest first
+func (r *PostgresRepository) GetAlertRules(ctx context.Context, portfolioID uuid.UUID) ([]models.AlertRule, error) {
+    var rules []models.AlertRule
+
+    err := r.withStatementRecovery(ctx, "getAlertRules", func() error {
+        rows, err := r.queryContext(ctx, "getAlertRules", portfolioID)
+        if err != nil {
+            return fmt.Errorf("failed to query alert rules: %w", err)
+        }
+        defer rows.Close()
+
+        rules = rules[:0]
+        for rows.Next() {
+            var (
+                rule          models.AlertRule
+                kind          string
+                windowSeconds int64
+                lastTriggered sql.NullTime
+            )
+            if err := rows.Scan(&rule.ID, &kind, &rule.Threshold, &windowSeconds, &rule.Enabled, &lastTriggered, &rule.CreatedAt); err != nil {
+                return fmt.Errorf("failed to scan alert rule: %w", err)
+            }
+            rule.PortfolioID = portfolioID
+            rule.Kind = models.AlertKind(kind)
+            rule.Window = time.Duration(windowSeconds) * time.Second
+            if lastTriggered.Valid {
+                rule.LastTriggeredAt = &lastTriggered.Time
+            }
+            rules = append(rules, rule)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return rules, nil
+}
+
+// DeleteAlertRule removes an alert rule and its event history
+func (r *PostgresRepository) DeleteAlertRule(ctx context.Context, portfolioID, ruleID uuid.UUID) error {
+    err := r.withStatementRecovery(ctx, "deleteAlertRule", func() error {
+        res, err := r.statement("deleteAlertRule").ExecContext(ctx, ruleID, portfolioID)
+        if err != nil {
+            return fmt.Errorf("failed to delete alert rule: %w", err)
+        }
+        n, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        if n == 0 {
+            return ErrAlertRuleNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// RecordAlertEvent stores a triggered alert and marks its rule as triggered
+func (r *PostgresRepository) RecordAlertEvent(ctx context.Context, event *models.AlertEvent) error {
+    if event == nil {
+        return ErrInvalidPortfolio
+    }
+
+    return r.withStatementRecovery(ctx, "createAlertEvent", func() error {
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        _, err = tx.StmtContext(ctx, r.statement("createAlertEvent")).ExecContext(ctx,
+            event.ID,
+            event.RuleID,
+            event.PortfolioID,
+            string(event.Kind),
+            event.Threshold,
+            event.Observed,
+            sql.NullString{String: event.Symbol, Valid: event.Symbol != ""},
+            event.TriggeredAt,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to create alert event: %w", err)
+        }
+
+        if _, err := tx.StmtContext(ctx, r.statement("markAlertRuleTriggered")).ExecContext(ctx, event.RuleID, event.TriggeredAt); err != nil {
+            return fmt.Errorf("failed to mark alert rule triggered: %w", err)
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        return nil
+    })
+}
+
+// ListAlertEvents returns up to limit alert events of a portfolio ordered
+// before the given position, newest first. Events are ordered by trigger time
+// and ID.
+func (r *PostgresRepository) ListAlertEvents(ctx context.Context, portfolioID uuid.UUID, before pagination.Position, limit int) ([]models.AlertEvent, error) {
+    var events []models.AlertEvent
+
+    err := r.withStatementRecovery(ctx, "listAlertEvents", func() error {
+        rows, err := r.queryContext(ctx, "listAlertEvents", portfolioID, before.At, before.ID, limit)
+        if err != nil {
+            return fmt.Errorf("failed to query alert events: %w", err)
+        }
+        defer rows.Close()
+
+        events = events[:0]
+        for rows.Next() {
+            var (
+                event  models.AlertEvent
+                kind   string
+                symbol sql.NullString
+            )
+            if err := rows.Scan(&event.ID, &event.RuleID, &kind, &event.Threshold, &event.Observed, &symbol, &event.TriggeredAt); err != nil {
+                return fmt.Errorf("failed to scan alert event: %w", err)
+            }
+            event.PortfolioID = portfolioID
+            event.Kind = models.AlertKind(kind)
+            event.Symbol = symbol.String
+            events = append(events, event)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return events, nil
+}
+
+// GetPreviousSnapshot returns the value of the latest snapshot captured before
+// the given time, or nil if there is none
+func (r *PostgresRepository) GetPreviousSnapshot(ctx context.Context, portfolioID uuid.UUID, before time.Time) (*models.ValuePoint, error) {
+    var point *models.ValuePoint
+
+    err := r.withStatementRecovery(ctx, "getPreviousSnapshot", func() error {
+        var p models.ValuePoint
+        err := r.statement("getPreviousSnapshot").QueryRowContext(ctx, portfolioID, before).Scan(&p.Timestamp, &p.Value, &p.ProfitLoss)
+        if errors.Is(err, sql.ErrNoRows) {
+            point = nil
+            return nil
+        }
+        if err != nil {
+            return fmt.Errorf("failed to get previous snapshot: %w", err)
+        }
+        point = &p
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return point, nil
+}
+
+// GetSnapshotPeak returns the highest total value among snapshots captured
+// within [start, end]; the result is invalid when there are none
+func (r *PostgresRepository) GetSnapshotPeak(ctx context.Context, portfolioID uuid.UUID, start, end time.Time) (decimal.NullDecimal, error) {
+    var peak decimal.NullDecimal
+
+    err := r.withStatementRecovery(ctx, "getSnapshotPeak", func() error {
+        if err := r.statement("getSnapshotPeak").QueryRowContext(ctx, portfolioID, start, end).Scan(&peak); err != nil {
+            return fmt.Errorf("failed to get snapshot peak: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return decimal.NullDecimal{}, err
+    }
+
+    return peak, nil
+}
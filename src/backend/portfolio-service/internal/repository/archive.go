@@ -0,0 +1,167 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/lib/pq"           // v1.10.9
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// PortfoliosWithTransactionsBefore lists up to limit portfolios holding transactions older than cutoff
+func (r *PostgresRepository) PortfoliosWithTransactionsBefore(ctx context.Context, cutoff time.Time, limit int) ([]uuid.UUID, error) {
+    var ids []uuid.UUID
+
+    err := r.withStatementRecovery(ctx, "portfoliosWithTransactionsBefore", func() error {
+        rows, err := r.statement("portfoliosWithTransactionsBefore").QueryContext(ctx, cutoff, limit)
+        if err != nil {
+            return fmt.Errorf("failed to query archivable portfolios: %w", err)
+        }
+        defer rows.Close()
+
+        ids = ids[:0]
+        for rows.Next() {
+            var id uuid.UUID
+            if err := rows.Scan(&id); err != nil {
+                return fmt.Errorf("failed to scan portfolio id: %w", err)
+            }
+            ids = append(ids, id)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return ids, nil
+}
+
+// CommitTransactionArchive records an uploaded archive with its summary lots and
+// removes the archived transactions in a single transaction
+func (r *PostgresRepository) CommitTransactionArchive(ctx context.Context, archive *models.TransactionArchive, lots []models.ArchivedLot, transactionIDs []uuid.UUID) error {
+    return r.withStatementRecovery(ctx, "commitTransactionArchive", func() error {
+        tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        _, err = tx.StmtContext(ctx, r.statement("createTransactionArchive")).ExecContext(ctx,
+            archive.ID,
+            archive.PortfolioID,
+            archive.ObjectKey,
+            archive.PeriodStart,
+            archive.PeriodEnd,
+            archive.TransactionCount,
+            archive.SizeBytes,
+            archive.CreatedAt,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to create archive record: %w", err)
+        }
+
+        lotStmt := tx.StmtContext(ctx, r.statement("createArchivedLot"))
+        for _, lot := range lots {
+            _, err = lotStmt.ExecContext(ctx,
+                lot.ArchiveID,
+                lot.PortfolioID,
+                lot.AssetID,
+                lot.Quantity,
+                lot.CostBasis,
+                lot.TotalFees,
+                lot.TransactionCount,
+                lot.AcquiredBefore,
+            )
+            if err != nil {
+                return fmt.Errorf("failed to create archived lot: %w", err)
+            }
+        }
+
+        ids := make([]string, len(transactionIDs))
+        for i, id := range transactionIDs {
+            ids[i] = id.String()
+        }
+
+        res, err := tx.StmtContext(ctx, r.statement("deleteArchivedTransactions")).ExecContext(ctx,
+            archive.PortfolioID,
+            pq.Array(ids),
+        )
+        if err != nil {
+            return fmt.Errorf("failed to delete archived transactions: %w", err)
+        }
+
+        if n, err := res.RowsAffected(); err == nil && n != int64(len(transactionIDs)) {
+            return fmt.Errorf("%w: expected to archive %d transactions, removed %d",
+                ErrTransactionFailed, len(transactionIDs), n)
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        return nil
+    })
+}
+
+// GetFirstTransactionTime returns the time of the oldest transaction of a
+// portfolio, archived or not, or the zero time when it has none. For archived
+// history the start of the oldest archive period is returned.
+func (r *PostgresRepository) GetFirstTransactionTime(ctx context.Context, portfolioID uuid.UUID) (time.Time, error) {
+    var first sql.NullTime
+
+    err := r.withStatementRecovery(ctx, "getFirstTransactionTime", func() error {
+        err := r.statement("getFirstTransactionTime").QueryRowContext(ctx, portfolioID).Scan(&first)
+        if err != nil {
+            return fmt.Errorf("failed to query first transaction time: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return time.Time{}, err
+    }
+
+    if !first.Valid {
+        return time.Time{}, nil
+    }
+    return first.Time.UTC(), nil
+}
+
+// GetTransactionArchives returns the archives of a portfolio overlapping [start, end)
+func (r *PostgresRepository) GetTransactionArchives(ctx context.Context, portfolioID uuid.UUID, start, end time.Time) ([]models.TransactionArchive, error) {
+    var archives []models.TransactionArchive
+
+    err := r.withStatementRecovery(ctx, "getTransactionArchives", func() error {
+        rows, err := r.statement("getTransactionArchives").QueryContext(ctx, portfolioID, start, end)
+        if err != nil {
+            return fmt.Errorf("failed to query transaction archives: %w", err)
+        }
+        defer rows.Close()
+
+        archives = archives[:0]
+        for rows.Next() {
+            var a models.TransactionArchive
+            if err := rows.Scan(
+                &a.ID,
+                &a.PortfolioID,
+                &a.ObjectKey,
+                &a.PeriodStart,
+                &a.PeriodEnd,
+                &a.TransactionCount,
+                &a.SizeBytes,
+                &a.CreatedAt,
+            ); err != nil {
+                return fmt.Errorf("failed to scan transaction archive: %w", err)
+            }
+            archives = append(archives, a)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return archives, nil
+}
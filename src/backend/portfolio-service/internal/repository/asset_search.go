@@ -0,0 +1,48 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// SearchUserAssets returns every holding of symbol across the portfolios of
+// a user, valued at the latest stored prices. Holdings of archived
+// portfolios are only returned with includeArchived.
+func (r *PostgresRepository) SearchUserAssets(ctx context.Context, userID uuid.UUID, symbol string, includeArchived bool) ([]models.AssetHolding, error) {
+    var holdings []models.AssetHolding
+
+    err := r.withStatementRecovery(ctx, "searchUserAssets", func() error {
+        rows, err := r.queryContext(ctx, "searchUserAssets", userID, symbol, includeArchived)
+        if err != nil {
+            return fmt.Errorf("failed to search user assets: %w", err)
+        }
+        defer rows.Close()
+
+        holdings = holdings[:0]
+        for rows.Next() {
+            var h models.AssetHolding
+            if err := rows.Scan(
+                &h.PortfolioID,
+                &h.PortfolioName,
+                &h.Archived,
+                &h.AssetID,
+                &h.Symbol,
+                &h.Amount,
+                &h.CurrentValue,
+            ); err != nil {
+                return fmt.Errorf("failed to scan asset holding: %w", err)
+            }
+            holdings = append(holdings, h)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return holdings, nil
+}
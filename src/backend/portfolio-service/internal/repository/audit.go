@@ -0,0 +1,89 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "strconv"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/pagination"
+)
+
+// AuditPosition returns the keyset position of an audit entry. Audit entries
+// are identified by a sequence rather than a UUID, so the position carries
+// the entry ID as its key.
+func AuditPosition(e *models.AuditEntry) pagination.Position {
+    return pagination.Position{At: e.ChangedAt, Key: strconv.FormatInt(e.ID, 10)}
+}
+
+// ListAuditEntries returns up to limit audit entries of the rows of a
+// portfolio before the given position, newest first. Entries are ordered by
+// change time and ID; a position without a key lists entries changed before
+// its time.
+func (r *PostgresRepository) ListAuditEntries(ctx context.Context, portfolioID uuid.UUID, before pagination.Position, limit int) ([]models.AuditEntry, error) {
+    var beforeID int64
+    if before.Key != "" {
+        id, err := strconv.ParseInt(before.Key, 10, 64)
+        if err != nil {
+            return nil, fmt.Errorf("invalid audit entry position %q: %w", before.Key, err)
+        }
+        beforeID = id
+    }
+
+    var entries []models.AuditEntry
+
+    err := r.withStatementRecovery(ctx, "listAuditEntries", func() error {
+        rows, err := r.queryContext(ctx, "listAuditEntries", portfolioID.String(), before.At, beforeID, limit)
+        if err != nil {
+            return fmt.Errorf("failed to query audit trail: %w", err)
+        }
+        defer rows.Close()
+
+        entries = entries[:0]
+        for rows.Next() {
+            e, err := scanAuditEntry(rows)
+            if err != nil {
+                return fmt.Errorf("failed to scan audit entry: %w", err)
+            }
+            entries = append(entries, e)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return entries, nil
+}
+
+// scanAuditEntry reads an audit trail row
+func scanAuditEntry(row rowScanner) (models.AuditEntry, error) {
+    var (
+        e         models.AuditEntry
+        oldData   []byte
+        newData   []byte
+        clientIP  sql.NullString
+        userAgent sql.NullString
+    )
+    if err := row.Scan(
+        &e.ID,
+        &e.TableName,
+        &e.Operation,
+        &oldData,
+        &newData,
+        &e.ChangedBy,
+        &e.ChangedAt,
+        &clientIP,
+        &userAgent,
+    ); err != nil {
+        return models.AuditEntry{}, err
+    }
+    e.OldData = oldData
+    e.NewData = newData
+    e.ClientIP = clientIP.String
+    e.UserAgent = userAgent.String
+    return e, nil
+}
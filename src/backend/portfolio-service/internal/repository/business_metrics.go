@@ -0,0 +1,158 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/lib/pq"                // v1.10.9
+    "github.com/shopspring/decimal"    // v1.3.1
+)
+
+// PortfolioValueBucket counts the active portfolios valued within a bucket
+// and sums their last valuations
+type PortfolioValueBucket struct {
+    Portfolios int64
+    Value      decimal.Decimal
+}
+
+// ConnectorSyncs counts the syncs of one exchange or chain
+type ConnectorSyncs struct {
+    Source   string
+    Name     string
+    Syncs    int64
+    Failures int64
+}
+
+// CountPortfoliosByValue counts the active portfolios by their last valuation
+// under ascending bounds. The first bucket holds portfolios valued below the
+// first bound and bucket i those valued at least bound i-1 and below bound i,
+// if any.
+func (r *PostgresRepository) CountPortfoliosByValue(ctx context.Context, bounds []decimal.Decimal) ([]PortfolioValueBucket, error) {
+    thresholds := make([]string, len(bounds))
+    for i, bound := range bounds {
+        thresholds[i] = bound.String()
+    }
+
+    var buckets []PortfolioValueBucket
+
+    err := r.withStatementRecovery(ctx, "countPortfoliosByValue", func() error {
+        rows, err := r.queryContext(ctx, "countPortfoliosByValue", pq.Array(thresholds))
+        if err != nil {
+            return fmt.Errorf("failed to count portfolios by value: %w", err)
+        }
+        defer rows.Close()
+
+        buckets = make([]PortfolioValueBucket, len(bounds)+1)
+        for i := range buckets {
+            buckets[i].Value = decimal.Zero
+        }
+        for rows.Next() {
+            var (
+                bucket int
+                count  int64
+                value  decimal.Decimal
+            )
+            if err := rows.Scan(&bucket, &count, &value); err != nil {
+                return fmt.Errorf("failed to scan portfolio value count: %w", err)
+            }
+            if bucket < 0 || bucket >= len(buckets) {
+                continue
+            }
+            buckets[bucket].Portfolios += count
+            buckets[bucket].Value = buckets[bucket].Value.Add(value)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return buckets, nil
+}
+
+// CountPortfolioOwners counts the users with an active portfolio
+func (r *PostgresRepository) CountPortfolioOwners(ctx context.Context) (int64, error) {
+    var owners int64
+
+    err := r.withStatementRecovery(ctx, "countPortfolioOwners", func() error {
+        if err := r.statement("countPortfolioOwners").QueryRowContext(ctx).Scan(&owners); err != nil {
+            return fmt.Errorf("failed to count portfolio owners: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return 0, err
+    }
+
+    return owners, nil
+}
+
+// CountActiveUsers counts the users whose holdings changed since a time,
+// through transactions, imports or syncs
+func (r *PostgresRepository) CountActiveUsers(ctx context.Context, since time.Time) (int64, error) {
+    var users int64
+
+    err := r.withStatementRecovery(ctx, "countActiveUsers", func() error {
+        if err := r.statement("countActiveUsers").QueryRowContext(ctx, since).Scan(&users); err != nil {
+            return fmt.Errorf("failed to count active users: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return 0, err
+    }
+
+    return users, nil
+}
+
+// GetLatestSnapshotTime returns when the latest valuation snapshot of any
+// portfolio was captured, or the zero time when there is none
+func (r *PostgresRepository) GetLatestSnapshotTime(ctx context.Context) (time.Time, error) {
+    var latest sql.NullTime
+
+    err := r.withStatementRecovery(ctx, "getLatestSnapshotTime", func() error {
+        if err := r.statement("getLatestSnapshotTime").QueryRowContext(ctx).Scan(&latest); err != nil {
+            return fmt.Errorf("failed to get latest snapshot time: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return time.Time{}, err
+    }
+
+    if !latest.Valid {
+        return time.Time{}, nil
+    }
+    return latest.Time.UTC(), nil
+}
+
+// CountSyncsByConnector counts the syncs of each exchange and chain since a
+// time, and how many of them failed
+func (r *PostgresRepository) CountSyncsByConnector(ctx context.Context, since time.Time) ([]ConnectorSyncs, error) {
+    var syncs []ConnectorSyncs
+
+    err := r.withStatementRecovery(ctx, "countSyncsByConnector", func() error {
+        rows, err := r.queryContext(ctx, "countSyncsByConnector", since)
+        if err != nil {
+            return fmt.Errorf("failed to count syncs: %w", err)
+        }
+        defer rows.Close()
+
+        syncs = syncs[:0]
+        for rows.Next() {
+            var s ConnectorSyncs
+            if err := rows.Scan(&s.Source, &s.Name, &s.Syncs, &s.Failures); err != nil {
+                return fmt.Errorf("failed to scan sync count: %w", err)
+            }
+            syncs = append(syncs, s)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return syncs, nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// RecordCorporateAction adds the asset credited by an airdrop or fork with
+// the transaction crediting it in a single transaction. The cost basis of
+// parent, the asset a fork split off from, is saved as given; a nil parent
+// is left as is. AssetAdded and TransactionRecorded events are recorded when
+// the outbox is enabled.
+func (r *PostgresRepository) RecordCorporateAction(ctx context.Context, p *models.Portfolio, asset *models.Asset, t *models.Transaction, parent *models.Asset) error {
+    if p == nil || asset == nil || t == nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "recordCorporateAction", func() error {
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        _, err = tx.StmtContext(ctx, r.statement("createAsset")).ExecContext(ctx,
+            asset.ID,
+            p.ID,
+            asset.Type,
+            asset.Symbol,
+            asset.Amount,
+            asset.CostBasis,
+            asset.CurrentValue,
+            asset.LastUpdated,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to create asset: %w", err)
+        }
+
+        if parent != nil {
+            result, err := tx.StmtContext(ctx, r.statement("updateAssetCostBasis")).ExecContext(ctx,
+                parent.ID,
+                p.ID,
+                parent.CostBasis,
+                time.Now().UTC(),
+            )
+            if err != nil {
+                return fmt.Errorf("failed to update parent cost basis: %w", err)
+            }
+            if n, err := result.RowsAffected(); err == nil && n == 0 {
+                return ErrPortfolioNotFound
+            }
+        }
+
+        _, err = tx.StmtContext(ctx, r.statement("createTransaction")).ExecContext(ctx,
+            t.ID,
+            t.PortfolioID,
+            t.AssetID,
+            t.Type,
+            t.Amount,
+            t.Price,
+            t.Fee,
+            t.Timestamp,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to create transaction: %w", err)
+        }
+
+        if err := r.appendOutbox(ctx, tx, func() (*models.OutboxEvent, error) {
+            return models.AssetAddedEvent(p.ID, asset)
+        }); err != nil {
+            return err
+        }
+        if err := r.appendOutbox(ctx, tx, func() (*models.OutboxEvent, error) {
+            return models.TransactionRecordedEvent(t)
+        }); err != nil {
+            return err
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/lib/pq"                // v1.10.9
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// InsertCustomAsset stores a custom asset definition along with its initial
+// manual price, when given, in one transaction. It fails with
+// ErrCustomAssetExists when the portfolio already defines the symbol and
+// with ErrPortfolioNotFound when the portfolio does not exist.
+func (r *PostgresRepository) InsertCustomAsset(ctx context.Context, asset *models.CustomAsset, price *models.ManualPrice) error {
+    if asset == nil || asset.PortfolioID == uuid.Nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "insertCustomAsset", func() error {
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        result, err := tx.StmtContext(ctx, r.statement("insertCustomAsset")).ExecContext(ctx,
+            asset.ID,
+            asset.PortfolioID,
+            asset.Symbol,
+            asset.Name,
+            asset.Decimals,
+            asset.CreatedAt,
+        )
+        var pqErr *pq.Error
+        if errors.As(err, &pqErr) && pqErr.Code == pgCodeUniqueViolation {
+            return ErrCustomAssetExists
+        }
+        if err != nil {
+            return fmt.Errorf("failed to insert custom asset: %w", err)
+        }
+        if n, err := result.RowsAffected(); err == nil && n == 0 {
+            return ErrPortfolioNotFound
+        }
+
+        if price != nil {
+            _, err = tx.StmtContext(ctx, r.statement("insertManualPrice")).ExecContext(ctx,
+                price.ID,
+                price.PortfolioID,
+                price.Symbol,
+                price.Price,
+                price.ValidFrom,
+                price.ValidUntil,
+                sql.NullString{String: price.Note, Valid: price.Note != ""},
+                price.CreatedAt,
+            )
+            if err != nil {
+                return fmt.Errorf("failed to insert manual price: %w", err)
+            }
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// GetCustomAsset returns the custom asset a portfolio defines for symbol
+func (r *PostgresRepository) GetCustomAsset(ctx context.Context, portfolioID uuid.UUID, symbol string) (*models.CustomAsset, error) {
+    asset := &models.CustomAsset{}
+
+    err := r.withStatementRecovery(ctx, "getCustomAsset", func() error {
+        err := r.statement("getCustomAsset").QueryRowContext(ctx, portfolioID, symbol).Scan(
+            &asset.ID,
+            &asset.PortfolioID,
+            &asset.Symbol,
+            &asset.Name,
+            &asset.Decimals,
+            &asset.CreatedAt,
+        )
+        if errors.Is(err, sql.ErrNoRows) {
+            return ErrCustomAssetNotFound
+        }
+        if err != nil {
+            return fmt.Errorf("failed to get custom asset: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return asset, nil
+}
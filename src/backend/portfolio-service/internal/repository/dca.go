@@ -0,0 +1,220 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/lib/pq"      // v1.10.9
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// CreateDCAPlan stores a dollar-cost averaging plan with all its installments
+func (r *PostgresRepository) CreateDCAPlan(ctx context.Context, plan *models.DCAPlan) error {
+    if plan == nil {
+        return ErrInvalidPortfolio
+    }
+
+    targets, err := json.Marshal(plan.Targets)
+    if err != nil {
+        return fmt.Errorf("failed to encode plan targets: %w", err)
+    }
+
+    sequences := make([]int64, len(plan.Installments))
+    scheduled := make([]string, len(plan.Installments))
+    purchases := make([]string, len(plan.Installments))
+    projections := make([]string, len(plan.Installments))
+    for i, installment := range plan.Installments {
+        p, err := json.Marshal(installment.Purchases)
+        if err != nil {
+            return fmt.Errorf("failed to encode installment purchases: %w", err)
+        }
+        a, err := json.Marshal(installment.ProjectedAllocation)
+        if err != nil {
+            return fmt.Errorf("failed to encode projected allocation: %w", err)
+        }
+        sequences[i] = int64(installment.Sequence)
+        scheduled[i] = installment.ScheduledAt.UTC().Format(time.RFC3339Nano)
+        purchases[i] = string(p)
+        projections[i] = string(a)
+    }
+
+    err = r.withStatementRecovery(ctx, "createDCAPlan", func() error {
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        _, err = tx.StmtContext(ctx, r.statement("createDCAPlan")).ExecContext(ctx,
+            plan.ID,
+            plan.PortfolioID,
+            plan.Contribution,
+            string(plan.Frequency),
+            targets,
+            plan.CreatedAt,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to create DCA plan: %w", err)
+        }
+
+        _, err = tx.StmtContext(ctx, r.statement("createDCAInstallments")).ExecContext(ctx,
+            plan.ID,
+            pq.Array(sequences),
+            pq.Array(scheduled),
+            pq.Array(purchases),
+            pq.Array(projections),
+        )
+        if err != nil {
+            return fmt.Errorf("failed to create DCA installments: %w", err)
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// GetDCAPlan returns a dollar-cost averaging plan with its installments
+func (r *PostgresRepository) GetDCAPlan(ctx context.Context, planID uuid.UUID) (*models.DCAPlan, error) {
+    plan := &models.DCAPlan{ID: planID}
+
+    err := r.withStatementRecovery(ctx, "getDCAPlan", func() error {
+        var (
+            frequency string
+            targets   []byte
+        )
+        err := r.statement("getDCAPlan").QueryRowContext(ctx, planID).Scan(
+            &plan.PortfolioID,
+            &plan.Contribution,
+            &frequency,
+            &targets,
+            &plan.CreatedAt,
+        )
+        if errors.Is(err, sql.ErrNoRows) {
+            return ErrDCAPlanNotFound
+        }
+        if err != nil {
+            return fmt.Errorf("failed to get DCA plan: %w", err)
+        }
+        plan.Frequency = models.DCAFrequency(frequency)
+        if err := json.Unmarshal(targets, &plan.Targets); err != nil {
+            return fmt.Errorf("failed to decode plan targets: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    err = r.withStatementRecovery(ctx, "getDCAInstallments", func() error {
+        rows, err := r.statement("getDCAInstallments").QueryContext(ctx, planID)
+        if err != nil {
+            return fmt.Errorf("failed to query DCA installments: %w", err)
+        }
+        defer rows.Close()
+
+        plan.Installments = plan.Installments[:0]
+        for rows.Next() {
+            var (
+                installment models.DCAInstallment
+                purchases   []byte
+                projection  []byte
+                executedAt  sql.NullTime
+            )
+            if err := rows.Scan(&installment.Sequence, &installment.ScheduledAt, &purchases, &projection, &executedAt); err != nil {
+                return fmt.Errorf("failed to scan DCA installment: %w", err)
+            }
+            if err := json.Unmarshal(purchases, &installment.Purchases); err != nil {
+                return fmt.Errorf("failed to decode installment purchases: %w", err)
+            }
+            if err := json.Unmarshal(projection, &installment.ProjectedAllocation); err != nil {
+                return fmt.Errorf("failed to decode projected allocation: %w", err)
+            }
+            if executedAt.Valid {
+                installment.ExecutedAt = &executedAt.Time
+            }
+            plan.Installments = append(plan.Installments, installment)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return plan, nil
+}
+
+// ListDCAPlans returns the dollar-cost averaging plans of a portfolio with
+// their installments, oldest first
+func (r *PostgresRepository) ListDCAPlans(ctx context.Context, portfolioID uuid.UUID) ([]models.DCAPlan, error) {
+    var ids []uuid.UUID
+
+    err := r.withStatementRecovery(ctx, "listDCAPlanIDs", func() error {
+        rows, err := r.statement("listDCAPlanIDs").QueryContext(ctx, portfolioID)
+        if err != nil {
+            return fmt.Errorf("failed to query DCA plans: %w", err)
+        }
+        defer rows.Close()
+
+        ids = ids[:0]
+        for rows.Next() {
+            var id uuid.UUID
+            if err := rows.Scan(&id); err != nil {
+                return fmt.Errorf("failed to scan DCA plan id: %w", err)
+            }
+            ids = append(ids, id)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    plans := make([]models.DCAPlan, 0, len(ids))
+    for _, id := range ids {
+        plan, err := r.GetDCAPlan(ctx, id)
+        if err != nil {
+            return nil, err
+        }
+        plans = append(plans, *plan)
+    }
+    return plans, nil
+}
+
+// MarkDCAInstallmentExecuted records that a scheduled contribution was made.
+// Installments can be marked executed only once.
+func (r *PostgresRepository) MarkDCAInstallmentExecuted(ctx context.Context, planID uuid.UUID, sequence int, at time.Time) error {
+    err := r.withStatementRecovery(ctx, "markDCAInstallmentExecuted", func() error {
+        res, err := r.statement("markDCAInstallmentExecuted").ExecContext(ctx, planID, sequence, at)
+        if err != nil {
+            return fmt.Errorf("failed to mark DCA installment executed: %w", err)
+        }
+        n, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        if n == 0 {
+            return ErrDCAInstallmentNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/lib/pq" // v1.10.9
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// MarkDelistedSymbols marks the held symbols whose stored price was last
+// returned by a provider before the cutoff as delisted at the given time,
+// keeping their last price. Symbols already delisted are left as they are. It
+// returns the newly delisted symbols.
+func (r *PostgresRepository) MarkDelistedSymbols(ctx context.Context, cutoff, at time.Time) ([]string, error) {
+    var symbols []string
+
+    err := r.withStatementRecovery(ctx, "markDelistedSymbols", func() error {
+        rows, err := r.statement("markDelistedSymbols").QueryContext(ctx, cutoff, at)
+        if err != nil {
+            return fmt.Errorf("failed to mark delisted symbols: %w", err)
+        }
+        defer rows.Close()
+
+        symbols = symbols[:0]
+        for rows.Next() {
+            var symbol string
+            if err := rows.Scan(&symbol); err != nil {
+                return fmt.Errorf("failed to scan delisted symbol: %w", err)
+            }
+            symbols = append(symbols, symbol)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return symbols, nil
+}
+
+// ClearDelistedSymbols removes the delisted mark of symbols a provider quoted
+// again and returns how many were delisted
+func (r *PostgresRepository) ClearDelistedSymbols(ctx context.Context, symbols []string) (int64, error) {
+    if len(symbols) == 0 {
+        return 0, nil
+    }
+
+    var cleared int64
+    err := r.withStatementRecovery(ctx, "clearDelistedSymbols", func() error {
+        res, err := r.statement("clearDelistedSymbols").ExecContext(ctx, pq.Array(symbols))
+        if err != nil {
+            return fmt.Errorf("failed to clear delisted symbols: %w", err)
+        }
+        cleared, err = res.RowsAffected()
+        return err
+    })
+    if err != nil {
+        return 0, err
+    }
+
+    return cleared, nil
+}
+
+// ListDelistedSymbols returns the delisted symbols among symbols, keyed by
+// symbol
+func (r *PostgresRepository) ListDelistedSymbols(ctx context.Context, symbols []string) (map[string]models.DelistedSymbol, error) {
+    delisted := make(map[string]models.DelistedSymbol)
+    if len(symbols) == 0 {
+        return delisted, nil
+    }
+
+    err := r.withStatementRecovery(ctx, "listDelistedSymbols", func() error {
+        rows, err := r.queryContext(ctx, "listDelistedSymbols", pq.Array(symbols))
+        if err != nil {
+            return fmt.Errorf("failed to query delisted symbols: %w", err)
+        }
+        defer rows.Close()
+
+        for rows.Next() {
+            var d models.DelistedSymbol
+            if err := rows.Scan(&d.Symbol, &d.LastPrice, &d.LastQuotedAt, &d.DelistedAt); err != nil {
+                return fmt.Errorf("failed to scan delisted symbol: %w", err)
+            }
+            delisted[d.Symbol] = d
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return delisted, nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// GetDustSettings returns the dust threshold of a portfolio
+func (r *PostgresRepository) GetDustSettings(ctx context.Context, portfolioID uuid.UUID) (*models.DustSettings, error) {
+    settings := models.DustSettings{PortfolioID: portfolioID}
+
+    err := r.withStatementRecovery(ctx, "getDustSettings", func() error {
+        err := r.statement("getDustSettings").QueryRowContext(ctx, portfolioID).Scan(&settings.Threshold, &settings.Hidden)
+        if errors.Is(err, sql.ErrNoRows) {
+            return ErrPortfolioNotFound
+        }
+        if err != nil {
+            return fmt.Errorf("failed to get dust settings: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return &settings, nil
+}
+
+// SetDustSettings replaces the dust threshold of a portfolio
+func (r *PostgresRepository) SetDustSettings(ctx context.Context, settings *models.DustSettings) error {
+    if settings == nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "updateDustSettings", func() error {
+        result, err := r.statement("updateDustSettings").ExecContext(ctx,
+            settings.PortfolioID,
+            settings.Threshold,
+            settings.Hidden,
+            time.Now().UTC(),
+        )
+        if err != nil {
+            return fmt.Errorf("failed to update dust settings: %w", err)
+        }
+        if n, err := result.RowsAffected(); err == nil && n == 0 {
+            return ErrPortfolioNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// UpsertExchangeAccount connects an exchange account to a portfolio. Connecting
+// an already connected exchange replaces its credentials and restarts the sync
+// from the beginning, since the new key may belong to another account;
+// activity imported before is recognized as duplicates.
+func (r *PostgresRepository) UpsertExchangeAccount(ctx context.Context, account *models.ExchangeAccount) error {
+    if account == nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "upsertExchangeAccount", func() error {
+        var lastSyncedAt sql.NullTime
+        err := r.statement("upsertExchangeAccount").QueryRowContext(ctx,
+            account.ID,
+            account.PortfolioID,
+            account.Exchange,
+            account.Credentials,
+            account.NextSyncAt,
+            account.CreatedAt,
+        ).Scan(&account.ID, &lastSyncedAt, &account.CreatedAt)
+        if err != nil {
+            return fmt.Errorf("failed to upsert exchange account: %w", err)
+        }
+        account.Cursor = ""
+        account.LastError = ""
+        account.LastSyncedAt = nil
+        if lastSyncedAt.Valid {
+            account.LastSyncedAt = &lastSyncedAt.Time
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// GetExchangeAccount returns an exchange account of a portfolio with its
+// sealed credentials
+func (r *PostgresRepository) GetExchangeAccount(ctx context.Context, portfolioID uuid.UUID, exchange string) (*models.ExchangeAccount, error) {
+    var account *models.ExchangeAccount
+
+    err := r.withStatementRecovery(ctx, "getExchangeAccount", func() error {
+        row := r.statement("getExchangeAccount").QueryRowContext(ctx, portfolioID, exchange)
+        var credentials []byte
+        a, err := scanExchangeAccount(row, &credentials)
+        if errors.Is(err, sql.ErrNoRows) {
+            return ErrExchangeAccountNotFound
+        }
+        if err != nil {
+            return fmt.Errorf("failed to get exchange account: %w", err)
+        }
+        a.Credentials = credentials
+        account = a
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return account, nil
+}
+
+// ListExchangeAccounts returns the exchange accounts of a portfolio without
+// their credentials
+func (r *PostgresRepository) ListExchangeAccounts(ctx context.Context, portfolioID uuid.UUID) ([]models.ExchangeAccount, error) {
+    var accounts []models.ExchangeAccount
+
+    err := r.withStatementRecovery(ctx, "listExchangeAccounts", func() error {
+        rows, err := r.queryContext(ctx, "listExchangeAccounts", portfolioID)
+        if err != nil {
+            return fmt.Errorf("failed to query exchange accounts: %w", err)
+        }
+        defer rows.Close()
+
+        accounts = accounts[:0]
+        for rows.Next() {
+            a, err := scanExchangeAccount(rows)
+            if err != nil {
+                return fmt.Errorf("failed to scan exchange account: %w", err)
+            }
+            accounts = append(accounts, *a)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return accounts, nil
+}
+
+// DeleteExchangeAccount disconnects an exchange account and discards its
+// credentials; imported transactions are kept
+func (r *PostgresRepository) DeleteExchangeAccount(ctx context.Context, portfolioID uuid.UUID, exchange string) error {
+    err := r.withStatementRecovery(ctx, "deleteExchangeAccount", func() error {
+        res, err := r.statement("deleteExchangeAccount").ExecContext(ctx, portfolioID, exchange)
+        if err != nil {
+            return fmt.Errorf("failed to delete exchange account: %w", err)
+        }
+        n, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        if n == 0 {
+            return ErrExchangeAccountNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// ClaimDueExchangeAccounts leases up to limit accounts due for sync at now
+// until leaseUntil, so concurrent workers do not sync them twice, and returns
+// them with their sealed credentials
+func (r *PostgresRepository) ClaimDueExchangeAccounts(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.ExchangeAccount, error) {
+    var accounts []models.ExchangeAccount
+
+    err := r.withStatementRecovery(ctx, "claimDueExchangeAccounts", func() error {
+        rows, err := r.statement("claimDueExchangeAccounts").QueryContext(ctx, now, leaseUntil, limit)
+        if err != nil {
+            return fmt.Errorf("failed to claim exchange accounts: %w", err)
+        }
+        defer rows.Close()
+
+        accounts = accounts[:0]
+        for rows.Next() {
+            var credentials []byte
+            a, err := scanExchangeAccount(rows, &credentials)
+            if err != nil {
+                return fmt.Errorf("failed to scan exchange account: %w", err)
+            }
+            a.Credentials = credentials
+            accounts = append(accounts, *a)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+    if len(accounts) > 0 {
+        r.recordWrite(ctx)
+    }
+
+    return accounts, nil
+}
+
+// SaveExchangeCheckpoint stores the cursor reached by a successful sync and
+// schedules the next one
+func (r *PostgresRepository) SaveExchangeCheckpoint(ctx context.Context, accountID uuid.UUID, cursor string, syncedAt, nextSyncAt time.Time) error {
+    err := r.withStatementRecovery(ctx, "saveExchangeCheckpoint", func() error {
+        res, err := r.statement("saveExchangeCheckpoint").ExecContext(ctx, accountID, cursor, syncedAt, nextSyncAt)
+        if err != nil {
+            return fmt.Errorf("failed to save exchange checkpoint: %w", err)
+        }
+        n, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        if n == 0 {
+            return ErrExchangeAccountNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// RecordExchangeSyncError stores why a sync failed and when to retry, keeping
+// the last checkpoint
+func (r *PostgresRepository) RecordExchangeSyncError(ctx context.Context, accountID uuid.UUID, message string, nextSyncAt time.Time) error {
+    err := r.withStatementRecovery(ctx, "recordExchangeSyncError", func() error {
+        _, err := r.statement("recordExchangeSyncError").ExecContext(ctx, accountID, message, nextSyncAt)
+        if err != nil {
+            return fmt.Errorf("failed to record exchange sync error: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// scanExchangeAccount reads an exchange account row followed by any extra
+// selected columns
+func scanExchangeAccount(row rowScanner, extra ...interface{}) (*models.ExchangeAccount, error) {
+    var (
+        a            models.ExchangeAccount
+        lastSyncedAt sql.NullTime
+    )
+    dest := append([]interface{}{
+        &a.ID, &a.PortfolioID, &a.Exchange, &a.Cursor, &lastSyncedAt, &a.LastError, &a.NextSyncAt, &a.CreatedAt,
+    }, extra...)
+    if err := row.Scan(dest...); err != nil {
+        return nil, err
+    }
+    if lastSyncedAt.Valid {
+        a.LastSyncedAt = &lastSyncedAt.Time
+    }
+    return &a, nil
+}
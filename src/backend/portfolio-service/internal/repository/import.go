@@ -0,0 +1,73 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/lib/pq"           // v1.10.9
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// CopyTransactions stores a batch of transactions in one database transaction
+// through COPY, with their outbox events. The batch must not contain IDs that
+// are already recorded; it is stored entirely or not at all.
+func (r *PostgresRepository) CopyTransactions(ctx context.Context, txs []*models.Transaction) error {
+    if len(txs) == 0 {
+        return nil
+    }
+
+    err := r.withStatementRecovery(ctx, "copyTransactions", func() error {
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        stmt, err := tx.PrepareContext(ctx, pq.CopyIn("portfolio_transactions",
+            "id", "portfolio_id", "asset_id", "type", "amount", "price", "fee", "timestamp"))
+        if err != nil {
+            return fmt.Errorf("failed to start transaction copy: %w", err)
+        }
+        defer stmt.Close()
+
+        for _, t := range txs {
+            _, err := stmt.ExecContext(ctx,
+                t.ID,
+                t.PortfolioID,
+                t.AssetID,
+                t.Type,
+                t.Amount.String(),
+                t.Price.String(),
+                t.Fee.String(),
+                t.Timestamp,
+            )
+            if err != nil {
+                return fmt.Errorf("failed to copy transaction: %w", err)
+            }
+        }
+        if _, err := stmt.ExecContext(ctx); err != nil {
+            return fmt.Errorf("failed to copy transactions: %w", err)
+        }
+
+        for _, t := range txs {
+            t := t
+            if err := r.appendOutbox(ctx, tx, func() (*models.OutboxEvent, error) {
+                return models.TransactionRecordedEvent(t)
+            }); err != nil {
+                return err
+            }
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
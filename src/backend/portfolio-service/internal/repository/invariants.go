@@ -0,0 +1,50 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+)
+
+// Ledger invariants checked by the correctness verifier
+const (
+    InvariantNegativeBalance  = "negative_balance"
+    InvariantUnbalancedLedger = "unbalanced_ledger"
+    InvariantOrphanedAsset    = "orphaned_asset"
+)
+
+// invariantStatements maps each invariant to the statement counting its violations
+var invariantStatements = map[string]string{
+    InvariantNegativeBalance:  "countNegativeBalances",
+    InvariantUnbalancedLedger: "countUnbalancedLedgers",
+    InvariantOrphanedAsset:    "countOrphanedAssets",
+}
+
+// Invariants returns the names of all ledger invariants known to the repository
+func Invariants() []string {
+    return []string{
+        InvariantNegativeBalance,
+        InvariantUnbalancedLedger,
+        InvariantOrphanedAsset,
+    }
+}
+
+// CountInvariantViolations returns the number of rows currently violating the named invariant
+func (r *PostgresRepository) CountInvariantViolations(ctx context.Context, invariant string) (int64, error) {
+    name, ok := invariantStatements[invariant]
+    if !ok {
+        return 0, fmt.Errorf("unknown invariant %q", invariant)
+    }
+
+    var count int64
+    err := r.withStatementRecovery(ctx, name, func() error {
+        if err := r.statement(name).QueryRowContext(ctx).Scan(&count); err != nil {
+            return fmt.Errorf("failed to count %s violations: %w", invariant, err)
+        }
+        return nil
+    })
+    if err != nil {
+        return 0, err
+    }
+
+    return count, nil
+}
@@ -0,0 +1,402 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/lib/pq" // v1.10.9
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// pgCodeUniqueViolation is the SQLSTATE raised when an insert conflicts with a
+// unique constraint
+const pgCodeUniqueViolation = "23505"
+
+// AppendLedgerEvents appends events to a portfolio ledger in one transaction,
+// assigning the next sequence numbers. When the read model is caught up the
+// events are projected in the same transaction; otherwise the projector
+// applies them in order. Returns ErrLedgerConflict if another writer appended
+// concurrently.
+func (r *PostgresRepository) AppendLedgerEvents(ctx context.Context, portfolioID uuid.UUID, events []models.LedgerEvent) error {
+    if portfolioID == uuid.Nil || len(events) == 0 {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "appendLedgerEvent", func() error {
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        var last int64
+        if err := tx.StmtContext(ctx, r.statement("lastLedgerSequence")).QueryRowContext(ctx, portfolioID).Scan(&last); err != nil {
+            return fmt.Errorf("failed to read ledger sequence: %w", err)
+        }
+
+        stmt := tx.StmtContext(ctx, r.statement("appendLedgerEvent"))
+        for i := range events {
+            events[i].PortfolioID = portfolioID
+            events[i].Sequence = last + int64(i) + 1
+            _, err := stmt.ExecContext(ctx,
+                portfolioID,
+                events[i].Sequence,
+                string(events[i].Type),
+                []byte(events[i].Data),
+                events[i].RecordedAt,
+            )
+            var pqErr *pq.Error
+            if errors.As(err, &pqErr) && pqErr.Code == pgCodeUniqueViolation {
+                return ErrLedgerConflict
+            }
+            if err != nil {
+                return fmt.Errorf("failed to append ledger event: %w", err)
+            }
+
+            if err := r.appendOutbox(ctx, tx, func() (*models.OutboxEvent, error) {
+                return outboxEventFor(events[i])
+            }); err != nil {
+                return err
+            }
+        }
+
+        checkpoint, err := r.lockLedgerCheckpoint(ctx, tx, portfolioID)
+        if err != nil {
+            return err
+        }
+        if checkpoint == last {
+            for _, e := range events {
+                if err := r.projectLedgerEvent(ctx, tx, e); err != nil {
+                    return err
+                }
+            }
+            if _, err := tx.StmtContext(ctx, r.statement("saveLedgerCheckpoint")).ExecContext(ctx, portfolioID, events[len(events)-1].Sequence, time.Now().UTC()); err != nil {
+                return fmt.Errorf("failed to save ledger checkpoint: %w", err)
+            }
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// ListLedgerEvents returns up to limit events of a portfolio after the given
+// sequence number and recorded at or before until, in sequence order
+func (r *PostgresRepository) ListLedgerEvents(ctx context.Context, portfolioID uuid.UUID, after int64, until time.Time, limit int) ([]models.LedgerEvent, error) {
+    var events []models.LedgerEvent
+
+    err := r.withStatementRecovery(ctx, "listLedgerEvents", func() error {
+        rows, err := r.queryContext(ctx, "listLedgerEvents", portfolioID, after, until, limit)
+        if err != nil {
+            return fmt.Errorf("failed to list ledger events: %w", err)
+        }
+        defer rows.Close()
+
+        events, err = scanLedgerEvents(rows)
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return events, nil
+}
+
+// ProjectLedger applies up to limit unprojected events of a portfolio to the
+// read model and advances its checkpoint. Returns the number applied.
+func (r *PostgresRepository) ProjectLedger(ctx context.Context, portfolioID uuid.UUID, limit int) (int, error) {
+    var applied int
+
+    err := r.withStatementRecovery(ctx, "listLedgerEvents", func() error {
+        applied = 0
+
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        checkpoint, err := r.lockLedgerCheckpoint(ctx, tx, portfolioID)
+        if err != nil {
+            return err
+        }
+
+        rows, err := tx.StmtContext(ctx, r.statement("listLedgerEvents")).QueryContext(ctx, portfolioID, checkpoint, time.Now().UTC(), limit)
+        if err != nil {
+            return fmt.Errorf("failed to list ledger events: %w", err)
+        }
+        events, err := scanLedgerEvents(rows)
+        rows.Close()
+        if err != nil {
+            return err
+        }
+        if len(events) == 0 {
+            return nil
+        }
+
+        for _, e := range events {
+            if e.Sequence != checkpoint+1 {
+                return fmt.Errorf("%w: expected #%d, got #%d", models.ErrLedgerSequence, checkpoint+1, e.Sequence)
+            }
+            if err := r.projectLedgerEvent(ctx, tx, e); err != nil {
+                return err
+            }
+            checkpoint = e.Sequence
+        }
+
+        if _, err := tx.StmtContext(ctx, r.statement("saveLedgerCheckpoint")).ExecContext(ctx, portfolioID, checkpoint, time.Now().UTC()); err != nil {
+            return fmt.Errorf("failed to save ledger checkpoint: %w", err)
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        applied = len(events)
+        return nil
+    })
+    if err != nil {
+        return 0, err
+    }
+    if applied > 0 {
+        r.recordWrite(ctx)
+    }
+
+    return applied, nil
+}
+
+// PendingLedgerProjections returns up to limit portfolios whose read model is
+// behind their ledger
+func (r *PostgresRepository) PendingLedgerProjections(ctx context.Context, limit int) ([]uuid.UUID, error) {
+    var ids []uuid.UUID
+
+    err := r.withStatementRecovery(ctx, "pendingLedgerProjections", func() error {
+        rows, err := r.statement("pendingLedgerProjections").QueryContext(ctx, limit)
+        if err != nil {
+            return fmt.Errorf("failed to list pending projections: %w", err)
+        }
+        defer rows.Close()
+
+        ids = ids[:0]
+        for rows.Next() {
+            var id uuid.UUID
+            if err := rows.Scan(&id); err != nil {
+                return fmt.Errorf("failed to scan portfolio ID: %w", err)
+            }
+            ids = append(ids, id)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return ids, nil
+}
+
+// ResetLedgerProjections rewinds projection checkpoints so the read model is
+// rebuilt by replaying ledgers from the start. uuid.Nil resets every portfolio.
+func (r *PostgresRepository) ResetLedgerProjections(ctx context.Context, portfolioID uuid.UUID) (int64, error) {
+    var reset int64
+
+    err := r.withStatementRecovery(ctx, "resetLedgerCheckpoints", func() error {
+        result, err := r.statement("resetLedgerCheckpoints").ExecContext(ctx, nullableUUID(portfolioID), time.Now().UTC())
+        if err != nil {
+            return fmt.Errorf("failed to reset ledger checkpoints: %w", err)
+        }
+        reset, err = result.RowsAffected()
+        return err
+    })
+    if err != nil {
+        return 0, err
+    }
+    r.recordWrite(ctx)
+
+    return reset, nil
+}
+
+// lockLedgerCheckpoint returns the projected sequence of a portfolio, holding
+// its checkpoint row lock until tx ends so projections are applied serially
+func (r *PostgresRepository) lockLedgerCheckpoint(ctx context.Context, tx *sql.Tx, portfolioID uuid.UUID) (int64, error) {
+    if _, err := tx.StmtContext(ctx, r.statement("initLedgerCheckpoint")).ExecContext(ctx, portfolioID, time.Now().UTC()); err != nil {
+        return 0, fmt.Errorf("failed to create ledger checkpoint: %w", err)
+    }
+
+    var checkpoint int64
+    if err := tx.StmtContext(ctx, r.statement("lockLedgerCheckpoint")).QueryRowContext(ctx, portfolioID).Scan(&checkpoint); err != nil {
+        return 0, fmt.Errorf("failed to lock ledger checkpoint: %w", err)
+    }
+    return checkpoint, nil
+}
+
+// projectLedgerEvent applies one event to the read model tables. Every write
+// is idempotent so ledgers can be replayed over an existing read model.
+func (r *PostgresRepository) projectLedgerEvent(ctx context.Context, tx *sql.Tx, e models.LedgerEvent) error {
+    var err error
+
+    switch e.Type {
+    case models.LedgerPortfolioCreated:
+        var details models.LedgerPortfolioDetails
+        if err := e.Decode(&details); err != nil {
+            return err
+        }
+        _, err = tx.StmtContext(ctx, r.statement("projectPortfolio")).ExecContext(ctx,
+            e.PortfolioID,
+            details.UserID,
+            details.Name,
+            details.Description,
+            e.RecordedAt,
+        )
+
+    case models.LedgerPortfolioUpdated:
+        var details models.LedgerPortfolioDetails
+        if err := e.Decode(&details); err != nil {
+            return err
+        }
+        _, err = tx.StmtContext(ctx, r.statement("projectPortfolioDetails")).ExecContext(ctx,
+            e.PortfolioID,
+            details.Name,
+            details.Description,
+            e.RecordedAt,
+        )
+
+    case models.LedgerPortfolioArchived:
+        _, err = tx.StmtContext(ctx, r.statement("deletePortfolio")).ExecContext(ctx, e.PortfolioID, e.RecordedAt)
+
+    case models.LedgerPortfolioRestored:
+        _, err = tx.StmtContext(ctx, r.statement("restorePortfolio")).ExecContext(ctx, e.PortfolioID, e.RecordedAt)
+
+    case models.LedgerAssetAdded:
+        var asset models.Asset
+        if err := e.Decode(&asset); err != nil {
+            return err
+        }
+        _, err = tx.StmtContext(ctx, r.statement("projectAsset")).ExecContext(ctx,
+            asset.ID,
+            e.PortfolioID,
+            asset.Type,
+            asset.Symbol,
+            asset.Amount,
+            asset.CostBasis,
+            asset.CurrentValue,
+            e.RecordedAt,
+        )
+
+    case models.LedgerAssetRemoved:
+        var ref models.LedgerAssetRef
+        if err := e.Decode(&ref); err != nil {
+            return err
+        }
+        _, err = tx.StmtContext(ctx, r.statement("projectAssetRemoved")).ExecContext(ctx, e.PortfolioID, ref.AssetID, e.RecordedAt)
+
+    case models.LedgerTransactionRecorded:
+        var t models.Transaction
+        if err := e.Decode(&t); err != nil {
+            return err
+        }
+        _, err = tx.StmtContext(ctx, r.statement("projectTransaction")).ExecContext(ctx,
+            t.ID,
+            e.PortfolioID,
+            t.AssetID,
+            t.Type,
+            t.Amount,
+            t.Price,
+            t.Fee,
+            t.Timestamp,
+        )
+        if err == nil {
+            err = r.recordRealizedGains(ctx, tx, e.PortfolioID, &t)
+        }
+
+    case models.LedgerCostBasisAdjusted:
+        var adjustment models.LedgerCostBasis
+        if err := e.Decode(&adjustment); err != nil {
+            return err
+        }
+        _, err = tx.StmtContext(ctx, r.statement("updateAssetCostBasis")).ExecContext(ctx,
+            adjustment.AssetID,
+            e.PortfolioID,
+            adjustment.CostBasis,
+            e.RecordedAt,
+        )
+
+    case models.LedgerAssetMigrated:
+        var migration models.LedgerAssetMigration
+        if err := e.Decode(&migration); err != nil {
+            return err
+        }
+        _, err = r.migrateAsset(ctx, tx, e.PortfolioID, migration, e.RecordedAt)
+
+    default:
+        return fmt.Errorf("%w: unknown type %q", models.ErrInvalidLedgerEvent, e.Type)
+    }
+
+    if err != nil {
+        return fmt.Errorf("failed to project %s #%d: %w", e.Type, e.Sequence, err)
+    }
+    return nil
+}
+
+// outboxEventFor returns the published event for a ledger event, or nil for
+// event types that are not published
+func outboxEventFor(e models.LedgerEvent) (*models.OutboxEvent, error) {
+    switch e.Type {
+    case models.LedgerPortfolioCreated:
+        var details models.LedgerPortfolioDetails
+        if err := e.Decode(&details); err != nil {
+            return nil, err
+        }
+        return models.PortfolioCreatedEvent(&models.Portfolio{
+            ID:        e.PortfolioID,
+            UserID:    details.UserID,
+            CreatedAt: e.RecordedAt,
+        })
+    case models.LedgerAssetAdded:
+        var asset models.Asset
+        if err := e.Decode(&asset); err != nil {
+            return nil, err
+        }
+        return models.AssetAddedEvent(e.PortfolioID, &asset)
+    case models.LedgerTransactionRecorded:
+        var t models.Transaction
+        if err := e.Decode(&t); err != nil {
+            return nil, err
+        }
+        return models.TransactionRecordedEvent(&t)
+    }
+    return nil, nil
+}
+
+// scanLedgerEvents reads ledger event rows
+func scanLedgerEvents(rows *sql.Rows) ([]models.LedgerEvent, error) {
+    var events []models.LedgerEvent
+    for rows.Next() {
+        var (
+            e         models.LedgerEvent
+            eventType string
+            data      []byte
+        )
+        if err := rows.Scan(&e.PortfolioID, &e.Sequence, &eventType, &data, &e.RecordedAt); err != nil {
+            return nil, fmt.Errorf("failed to scan ledger event: %w", err)
+        }
+        e.Type = models.LedgerEventType(eventType)
+        e.Data = data
+        events = append(events, e)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("failed to iterate ledger events: %w", err)
+    }
+    return events, nil
+}
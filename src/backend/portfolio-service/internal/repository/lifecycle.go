@@ -0,0 +1,77 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+)
+
+// ArchivePortfolio hides an active portfolio from reads and default listings
+// as of at
+func (r *PostgresRepository) ArchivePortfolio(ctx context.Context, portfolioID uuid.UUID, at time.Time) error {
+    err := r.withStatementRecovery(ctx, "deletePortfolio", func() error {
+        result, err := r.statement("deletePortfolio").ExecContext(ctx, portfolioID, at)
+        if err != nil {
+            return fmt.Errorf("failed to archive portfolio: %w", err)
+        }
+        if n, err := result.RowsAffected(); err == nil && n == 0 {
+            return ErrPortfolioNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// RestorePortfolio makes an archived portfolio active again
+func (r *PostgresRepository) RestorePortfolio(ctx context.Context, portfolioID uuid.UUID, at time.Time) error {
+    err := r.withStatementRecovery(ctx, "restorePortfolio", func() error {
+        result, err := r.statement("restorePortfolio").ExecContext(ctx, portfolioID, at)
+        if err != nil {
+            return fmt.Errorf("failed to restore portfolio: %w", err)
+        }
+        if n, err := result.RowsAffected(); err == nil && n == 0 {
+            return ErrPortfolioNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// PortfolioArchivedAt returns when a portfolio was archived, or nil while it
+// is active
+func (r *PostgresRepository) PortfolioArchivedAt(ctx context.Context, portfolioID uuid.UUID) (*time.Time, error) {
+    var archivedAt sql.NullTime
+
+    err := r.withStatementRecovery(ctx, "getPortfolioArchivedAt", func() error {
+        err := r.statement("getPortfolioArchivedAt").QueryRowContext(ctx, portfolioID).Scan(&archivedAt)
+        if errors.Is(err, sql.ErrNoRows) {
+            return ErrPortfolioNotFound
+        }
+        if err != nil {
+            return fmt.Errorf("failed to get portfolio archive time: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    if !archivedAt.Valid {
+        return nil, nil
+    }
+    return &archivedAt.Time, nil
+}
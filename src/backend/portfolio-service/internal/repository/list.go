@@ -0,0 +1,248 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/shopspring/decimal" // v1.3.1
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/pagination"
+)
+
+// PortfolioFilter narrows portfolio listings
+type PortfolioFilter struct {
+    // Tag limits the listing to portfolios holding an asset with the tag
+    Tag string
+    // AssetType limits the listing to portfolios holding an asset of the type
+    AssetType string
+    // MinValue limits the listing to portfolios worth at least the value
+    MinValue decimal.NullDecimal
+    // IncludeArchived lists archived portfolios along with active ones
+    IncludeArchived bool
+}
+
+// Portfolio listing sort fields
+const (
+    OrderCreatedAt  = "created_at"
+    OrderTotalValue = "total_value"
+    OrderName       = "name"
+)
+
+// PortfolioOrder is the sort order of portfolio listings. The zero order
+// lists oldest portfolios first.
+type PortfolioOrder struct {
+    // Field is OrderCreatedAt, OrderTotalValue or OrderName
+    Field      string
+    Descending bool
+}
+
+// portfolioOrderTypes maps the portfolio sort fields, which are also their
+// column names, to the column types. Only these identifiers are interpolated
+// into listing queries; all values are bound as parameters.
+var portfolioOrderTypes = map[string]string{
+    OrderCreatedAt:  "timestamptz",
+    OrderTotalValue: "numeric",
+    OrderName:       "text",
+}
+
+// listPortfoliosQuery lists portfolios ordered by a sort column and ID,
+// resuming after the sort key $2 and ID $3 when $2 is set. Unset filters are
+// bound as NULL.
+const listPortfoliosQuery = `
+        SELECT p.id, p.user_id, p.name, p.description, p.total_value, p.profit_loss, p.created_at, p.updated_at,
+               p.change_24h, p.change_7d, p.change_30d, p.deleted_at
+        FROM portfolios p
+        WHERE p.user_id = $1 AND ($5 OR p.deleted_at IS NULL)
+          AND ($2::text IS NULL OR (p.%[1]s, p.id) %[2]s ($2::text::%[3]s, $3))
+          AND ($6::text IS NULL OR EXISTS (
+              SELECT 1
+              FROM asset_tags t
+              JOIN portfolio_assets a ON a.id = t.asset_id AND a.deleted_at IS NULL
+              WHERE t.portfolio_id = p.id AND t.tag = $6
+          ))
+          AND ($7::text IS NULL OR EXISTS (
+              SELECT 1
+              FROM portfolio_assets a
+              WHERE a.portfolio_id = p.id AND a.deleted_at IS NULL AND a.type = $7
+          ))
+          AND ($8::numeric IS NULL OR p.total_value >= $8)
+        ORDER BY p.%[1]s %[4]s, p.id %[4]s
+        LIMIT $4`
+
+// One listing statement is prepared per sort order
+func init() {
+    for field, columnType := range portfolioOrderTypes {
+        for _, descending := range []bool{false, true} {
+            cmp, direction := ">", "ASC"
+            if descending {
+                cmp, direction = "<", "DESC"
+            }
+            order := PortfolioOrder{Field: field, Descending: descending}
+            preparedStatements[order.statement()] = fmt.Sprintf(listPortfoliosQuery, field, cmp, columnType, direction)
+        }
+    }
+}
+
+// Valid reports whether the order sorts by a known field
+func (o PortfolioOrder) Valid() bool {
+    _, ok := portfolioOrderTypes[o.field()]
+    return ok
+}
+
+// Position returns the keyset position of a portfolio in listings in this
+// order
+func (o PortfolioOrder) Position(p *models.Portfolio) pagination.Position {
+    pos := pagination.Position{At: p.CreatedAt, ID: p.ID}
+    switch o.field() {
+    case OrderTotalValue:
+        pos.Key = p.TotalValue.String()
+    case OrderName:
+        pos.Key = p.Name
+    }
+    return pos
+}
+
+func (o PortfolioOrder) field() string {
+    if o.Field == "" {
+        return OrderCreatedAt
+    }
+    return o.Field
+}
+
+// statement returns the name of the listing statement of the order
+func (o PortfolioOrder) statement() string {
+    name := "listPortfoliosBy_" + o.field()
+    if o.Descending {
+        name += "_desc"
+    }
+    return name
+}
+
+// sortKey returns the sort key bound to the listing statement of the order
+// for a position, which is NULL at the start of the listing
+func (o PortfolioOrder) sortKey(after pagination.Position) sql.NullString {
+    if after.IsZero() {
+        return sql.NullString{}
+    }
+    if o.field() == OrderCreatedAt {
+        return sql.NullString{String: after.At.UTC().Format(time.RFC3339Nano), Valid: true}
+    }
+    return sql.NullString{String: after.Key, Valid: true}
+}
+
+// TransactionFilter narrows transaction listings
+type TransactionFilter struct {
+    // AssetID limits the listing to one asset when set
+    AssetID uuid.UUID
+    // Start and End bound the transaction timestamps to [Start, End)
+    Start time.Time
+    End   time.Time
+}
+
+// ListPortfolios returns up to limit portfolios of a user matching filter in
+// the given order, starting after the given position. Ties are ordered by ID.
+// Assets are not loaded.
+func (r *PostgresRepository) ListPortfolios(ctx context.Context, userID uuid.UUID, after pagination.Position, limit int, filter PortfolioFilter, order PortfolioOrder) ([]*models.Portfolio, error) {
+    if !order.Valid() {
+        return nil, fmt.Errorf("unknown portfolio sort field %q", order.Field)
+    }
+
+    var portfolios []*models.Portfolio
+
+    name := order.statement()
+    args := []interface{}{
+        userID,
+        order.sortKey(after),
+        after.ID,
+        limit,
+        filter.IncludeArchived,
+        sql.NullString{String: filter.Tag, Valid: filter.Tag != ""},
+        sql.NullString{String: filter.AssetType, Valid: filter.AssetType != ""},
+        filter.MinValue,
+    }
+
+    err := r.withStatementRecovery(ctx, name, func() error {
+        rows, err := r.queryContext(ctx, name, args...)
+        if err != nil {
+            return fmt.Errorf("failed to query portfolios: %w", err)
+        }
+        defer rows.Close()
+
+        portfolios = portfolios[:0]
+        for rows.Next() {
+            var archivedAt sql.NullTime
+            p := &models.Portfolio{}
+            if err := rows.Scan(
+                &p.ID,
+                &p.UserID,
+                &p.Name,
+                &p.Description,
+                &p.TotalValue,
+                &p.ProfitLoss,
+                &p.CreatedAt,
+                &p.LastUpdated,
+                &p.Change24h,
+                &p.Change7d,
+                &p.Change30d,
+                &archivedAt,
+            ); err != nil {
+                return fmt.Errorf("failed to scan portfolio: %w", err)
+            }
+            if archivedAt.Valid {
+                p.ArchivedAt = &archivedAt.Time
+            }
+            portfolios = append(portfolios, p)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return portfolios, nil
+}
+
+// ListTransactions returns up to limit live transactions of a portfolio
+// matching filter ordered by timestamp and ID, starting after the given
+// position. Archived transactions are not listed.
+func (r *PostgresRepository) ListTransactions(ctx context.Context, portfolioID uuid.UUID, after pagination.Position, limit int, filter TransactionFilter) ([]models.Transaction, error) {
+    var txs []models.Transaction
+
+    assetID := uuid.NullUUID{UUID: filter.AssetID, Valid: filter.AssetID != uuid.Nil}
+
+    err := r.withStatementRecovery(ctx, "listTransactions", func() error {
+        rows, err := r.queryContext(ctx, "listTransactions", portfolioID, filter.Start, filter.End, after.At, after.ID, assetID, limit)
+        if err != nil {
+            return fmt.Errorf("failed to query transactions: %w", err)
+        }
+        defer rows.Close()
+
+        txs = txs[:0]
+        for rows.Next() {
+            var tx models.Transaction
+            if err := rows.Scan(
+                &tx.ID,
+                &tx.PortfolioID,
+                &tx.AssetID,
+                &tx.Type,
+                &tx.Amount,
+                &tx.Price,
+                &tx.Fee,
+                &tx.Timestamp,
+            ); err != nil {
+                return fmt.Errorf("failed to scan transaction: %w", err)
+            }
+            txs = append(txs, tx)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return txs, nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// GetLotReplays replays the lots of each of a portfolio's assets from its
+// transactions and archived lots, with fees treated by the configured
+// policy and wash sales disallowed when washSales is set. All assets are
+// read from one snapshot so their lots agree.
+func (r *PostgresRepository) GetLotReplays(ctx context.Context, portfolioID uuid.UUID, assetIDs []uuid.UUID, washSales *models.WashSaleRule) (map[uuid.UUID]models.LotReplay, error) {
+    replays := make(map[uuid.UUID]models.LotReplay, len(assetIDs))
+    if len(assetIDs) == 0 {
+        return replays, nil
+    }
+
+    err := r.withStatementRecovery(ctx, "listAssetTransactions", func() error {
+        tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        for _, assetID := range assetIDs {
+            txs, err := r.assetTransactions(ctx, tx, portfolioID, assetID)
+            if err != nil {
+                return err
+            }
+            opening, err := r.assetArchivedLots(ctx, tx, portfolioID, assetID)
+            if err != nil {
+                return err
+            }
+            replays[assetID] = models.ReplayLotsWithWashSales(opening, txs, r.feePolicy, washSales)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return replays, nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// InsertManualPrice stores a user-supplied price of a symbol of a portfolio.
+// It returns ErrPortfolioNotFound when the portfolio does not exist.
+func (r *PostgresRepository) InsertManualPrice(ctx context.Context, price *models.ManualPrice) error {
+    if price == nil || price.PortfolioID == uuid.Nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "insertManualPrice", func() error {
+        result, err := r.statement("insertManualPrice").ExecContext(ctx,
+            price.ID,
+            price.PortfolioID,
+            price.Symbol,
+            price.Price,
+            price.ValidFrom,
+            price.ValidUntil,
+            sql.NullString{String: price.Note, Valid: price.Note != ""},
+            price.CreatedAt,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to insert manual price: %w", err)
+        }
+        if n, err := result.RowsAffected(); err == nil && n == 0 {
+            return ErrPortfolioNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// ListManualPrices returns the manual prices of a portfolio valid at the
+// given time, by symbol and latest valid_from first
+func (r *PostgresRepository) ListManualPrices(ctx context.Context, portfolioID uuid.UUID, at time.Time) ([]models.ManualPrice, error) {
+    var prices []models.ManualPrice
+
+    err := r.withStatementRecovery(ctx, "listManualPrices", func() error {
+        rows, err := r.queryContext(ctx, "listManualPrices", portfolioID, at)
+        if err != nil {
+            return fmt.Errorf("failed to query manual prices: %w", err)
+        }
+        defer rows.Close()
+
+        prices = prices[:0]
+        for rows.Next() {
+            var (
+                price      models.ManualPrice
+                validUntil sql.NullTime
+                note       sql.NullString
+            )
+            err := rows.Scan(
+                &price.ID,
+                &price.PortfolioID,
+                &price.Symbol,
+                &price.Price,
+                &price.ValidFrom,
+                &validUntil,
+                &note,
+                &price.CreatedAt,
+            )
+            if err != nil {
+                return fmt.Errorf("failed to scan manual price: %w", err)
+            }
+            if validUntil.Valid {
+                until := validUntil.Time
+                price.ValidUntil = &until
+            }
+            price.Note = note.String
+            prices = append(prices, price)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return prices, nil
+}
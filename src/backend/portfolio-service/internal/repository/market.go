@@ -0,0 +1,93 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/lib/pq"           // v1.10.9
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// GetDailyCloses returns the daily closing prices of each symbol within
+// [start, end), ordered by time. Symbols without history are omitted.
+func (r *PostgresRepository) GetDailyCloses(ctx context.Context, symbols []string, start, end time.Time) (map[string][]models.PricePoint, error) {
+    closes := make(map[string][]models.PricePoint, len(symbols))
+    if len(symbols) == 0 {
+        return closes, nil
+    }
+
+    err := r.withStatementRecovery(ctx, "getDailyCloses", func() error {
+        rows, err := r.statement("getDailyCloses").QueryContext(ctx, pq.Array(symbols), start, end)
+        if err != nil {
+            return fmt.Errorf("failed to query daily closes: %w", err)
+        }
+        defer rows.Close()
+
+        for k := range closes {
+            delete(closes, k)
+        }
+        for rows.Next() {
+            var p models.PricePoint
+            if err := rows.Scan(&p.Symbol, &p.Timestamp, &p.Close); err != nil {
+                return fmt.Errorf("failed to scan daily close: %w", err)
+            }
+            p.Timestamp = p.Timestamp.UTC()
+            closes[p.Symbol] = append(closes[p.Symbol], p)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return closes, nil
+}
+
+// InsertDailyBars stores the daily bars of one symbol, skipping days that
+// already have a bar so stored history is never overwritten. It returns the
+// number of bars inserted.
+func (r *PostgresRepository) InsertDailyBars(ctx context.Context, symbol string, bars []models.PriceBar) (int64, error) {
+    if len(bars) == 0 {
+        return 0, nil
+    }
+
+    days := make([]string, len(bars))
+    opens := make([]string, len(bars))
+    highs := make([]string, len(bars))
+    lows := make([]string, len(bars))
+    closes := make([]string, len(bars))
+    volumes := make([]string, len(bars))
+    for i, bar := range bars {
+        days[i] = bar.Day.UTC().Format(time.RFC3339Nano)
+        opens[i] = bar.Open.String()
+        highs[i] = bar.High.String()
+        lows[i] = bar.Low.String()
+        closes[i] = bar.Close.String()
+        volumes[i] = bar.Volume.String()
+    }
+
+    var inserted int64
+    err := r.withStatementRecovery(ctx, "insertDailyBars", func() error {
+        res, err := r.statement("insertDailyBars").ExecContext(ctx,
+            symbol,
+            pq.Array(days),
+            pq.Array(opens),
+            pq.Array(highs),
+            pq.Array(lows),
+            pq.Array(closes),
+            pq.Array(volumes),
+        )
+        if err != nil {
+            return fmt.Errorf("failed to insert daily bars: %w", err)
+        }
+        inserted, err = res.RowsAffected()
+        return err
+    })
+    if err != nil {
+        return 0, err
+    }
+
+    return inserted, nil
+}
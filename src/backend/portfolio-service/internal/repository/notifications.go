@@ -0,0 +1,119 @@
+package repository
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// SaveDeadLetter stores a notification that could not be delivered
+func (r *PostgresRepository) SaveDeadLetter(ctx context.Context, letter *models.NotificationDeadLetter) error {
+    if letter == nil {
+        return ErrInvalidPortfolio
+    }
+
+    payload, err := json.Marshal(letter.Notification)
+    if err != nil {
+        return fmt.Errorf("failed to encode notification: %w", err)
+    }
+
+    // Notifications not tied to a portfolio are stored without one
+    var portfolioID *uuid.UUID
+    if letter.Notification.PortfolioID != uuid.Nil {
+        portfolioID = &letter.Notification.PortfolioID
+    }
+
+    err = r.withStatementRecovery(ctx, "createDeadLetter", func() error {
+        _, err := r.statement("createDeadLetter").ExecContext(ctx,
+            letter.ID,
+            portfolioID,
+            letter.Sender,
+            string(letter.Notification.Event),
+            payload,
+            letter.Error,
+            letter.Attempts,
+            letter.FailedAt,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to create dead letter: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// ListDeadLetters returns up to limit dead letters, oldest first
+func (r *PostgresRepository) ListDeadLetters(ctx context.Context, limit int) ([]models.NotificationDeadLetter, error) {
+    var letters []models.NotificationDeadLetter
+
+    err := r.withStatementRecovery(ctx, "listDeadLetters", func() error {
+        rows, err := r.statement("listDeadLetters").QueryContext(ctx, limit)
+        if err != nil {
+            return fmt.Errorf("failed to query dead letters: %w", err)
+        }
+        defer rows.Close()
+
+        letters = letters[:0]
+        for rows.Next() {
+            var (
+                letter  models.NotificationDeadLetter
+                payload []byte
+            )
+            if err := rows.Scan(&letter.ID, &letter.Sender, &payload, &letter.Error, &letter.Attempts, &letter.FailedAt); err != nil {
+                return fmt.Errorf("failed to scan dead letter: %w", err)
+            }
+            if err := json.Unmarshal(payload, &letter.Notification); err != nil {
+                return fmt.Errorf("failed to decode dead letter %s: %w", letter.ID, err)
+            }
+            letters = append(letters, letter)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return letters, nil
+}
+
+// DeleteDeadLetter removes a dead letter after successful redelivery
+func (r *PostgresRepository) DeleteDeadLetter(ctx context.Context, id uuid.UUID) error {
+    err := r.withStatementRecovery(ctx, "deleteDeadLetter", func() error {
+        if _, err := r.statement("deleteDeadLetter").ExecContext(ctx, id); err != nil {
+            return fmt.Errorf("failed to delete dead letter: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// RecordDeadLetterRetry records another failed redelivery of a dead letter
+func (r *PostgresRepository) RecordDeadLetterRetry(ctx context.Context, id uuid.UUID, cause string, at time.Time) error {
+    err := r.withStatementRecovery(ctx, "retryDeadLetter", func() error {
+        if _, err := r.statement("retryDeadLetter").ExecContext(ctx, id, cause, at); err != nil {
+            return fmt.Errorf("failed to update dead letter: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/lib/pq" // v1.10.9
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// AddAsset inserts an asset and updates the portfolio totals in a single
+// transaction, recording an AssetAdded event when the outbox is enabled
+func (r *PostgresRepository) AddAsset(ctx context.Context, p *models.Portfolio, asset *models.Asset) error {
+    if p == nil || asset == nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "createAsset", func() error {
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        _, err = tx.StmtContext(ctx, r.statement("createAsset")).ExecContext(ctx,
+            asset.ID,
+            p.ID,
+            asset.Type,
+            asset.Symbol,
+            asset.Amount,
+            asset.CostBasis,
+            asset.CurrentValue,
+            asset.LastUpdated,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to create asset: %w", err)
+        }
+
+        result, err := tx.StmtContext(ctx, r.statement("updatePortfolio")).ExecContext(ctx,
+            p.ID,
+            p.Name,
+            p.Description,
+            p.TotalValue,
+            p.ProfitLoss,
+            p.LastUpdated,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to update portfolio: %w", err)
+        }
+        if n, err := result.RowsAffected(); err == nil && n == 0 {
+            return ErrPortfolioNotFound
+        }
+
+        if err := r.appendOutbox(ctx, tx, func() (*models.OutboxEvent, error) {
+            return models.AssetAddedEvent(p.ID, asset)
+        }); err != nil {
+            return err
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// RelayOutbox locks up to limit unpublished events in creation order, passes
+// them to publish and marks them published if it succeeds. Events are locked
+// until publish returns so concurrent relays skip them; a crash after
+// publishing leaves them unpublished, so events are delivered at least once.
+// Returns the number of events published.
+func (r *PostgresRepository) RelayOutbox(ctx context.Context, limit int, publish func([]models.OutboxEvent) error) (int, error) {
+    var published int
+
+    err := r.withStatementRecovery(ctx, "claimOutboxEvents", func() error {
+        published = 0
+
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        rows, err := tx.StmtContext(ctx, r.statement("claimOutboxEvents")).QueryContext(ctx, limit)
+        if err != nil {
+            return fmt.Errorf("failed to claim outbox events: %w", err)
+        }
+
+        var events []models.OutboxEvent
+        for rows.Next() {
+            var (
+                e         models.OutboxEvent
+                eventType string
+            )
+            if err := rows.Scan(&e.ID, &e.PortfolioID, &eventType, &e.Payload, &e.CreatedAt); err != nil {
+                rows.Close()
+                return fmt.Errorf("failed to scan outbox event: %w", err)
+            }
+            e.Type = models.OutboxEventType(eventType)
+            events = append(events, e)
+        }
+        rows.Close()
+        if err := rows.Err(); err != nil {
+            return fmt.Errorf("failed to iterate outbox events: %w", err)
+        }
+        if len(events) == 0 {
+            return nil
+        }
+
+        if err := publish(events); err != nil {
+            return fmt.Errorf("failed to publish outbox events: %w", err)
+        }
+
+        ids := make([]uuid.UUID, len(events))
+        for i, e := range events {
+            ids[i] = e.ID
+        }
+        if _, err := tx.StmtContext(ctx, r.statement("markOutboxPublished")).ExecContext(ctx, pq.Array(ids), time.Now().UTC()); err != nil {
+            return fmt.Errorf("failed to mark outbox events published: %w", err)
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        published = len(events)
+        return nil
+    })
+    if err != nil {
+        return 0, err
+    }
+
+    return published, nil
+}
+
+// PurgeOutbox deletes events published before the given time
+func (r *PostgresRepository) PurgeOutbox(ctx context.Context, before time.Time) (int64, error) {
+    var deleted int64
+
+    err := r.withStatementRecovery(ctx, "purgeOutbox", func() error {
+        result, err := r.statement("purgeOutbox").ExecContext(ctx, before)
+        if err != nil {
+            return fmt.Errorf("failed to purge outbox: %w", err)
+        }
+        deleted, err = result.RowsAffected()
+        return err
+    })
+    if err != nil {
+        return 0, err
+    }
+
+    return deleted, nil
+}
+
+// appendOutbox records the event built by build within tx. It does nothing
+// when the outbox is disabled or build returns no event.
+func (r *PostgresRepository) appendOutbox(ctx context.Context, tx *sql.Tx, build func() (*models.OutboxEvent, error)) error {
+    if !r.outbox {
+        return nil
+    }
+
+    event, err := build()
+    if err != nil || event == nil {
+        return err
+    }
+
+    _, err = tx.StmtContext(ctx, r.statement("createOutboxEvent")).ExecContext(ctx,
+        event.ID,
+        event.PortfolioID,
+        string(event.Type),
+        event.Payload,
+        event.CreatedAt,
+    )
+    if err != nil {
+        return fmt.Errorf("failed to record %s event: %w", event.Type, err)
+    }
+    return nil
+}
@@ -6,6 +6,7 @@ import (
     "database/sql"
     "errors"
     "fmt"
+    "strings"
     "sync"
     "time"
 
@@ -16,16 +17,38 @@ import (
     "github.com/google/uuid"
     
     "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/metrics"
     "bookman/portfolio-service/internal/models"
 )
 
 // Common errors returned by the repository
 var (
-    ErrPortfolioNotFound  = errors.New("portfolio not found")
-    ErrAssetNotFound      = errors.New("asset not found")
-    ErrTransactionFailed  = errors.New("transaction failed")
-    ErrInvalidPortfolio   = errors.New("invalid portfolio data")
-    ErrDatabaseConnection = errors.New("database connection error")
+    ErrPortfolioNotFound       = errors.New("portfolio not found")
+    ErrAssetNotFound           = errors.New("asset not found")
+    ErrTransactionFailed       = errors.New("transaction failed")
+    ErrInvalidPortfolio        = errors.New("invalid portfolio data")
+    ErrDatabaseConnection      = errors.New("database connection error")
+    ErrDCAPlanNotFound         = errors.New("DCA plan not found")
+    ErrDCAInstallmentNotFound  = errors.New("DCA installment not found or already executed")
+    ErrAlertRuleNotFound       = errors.New("alert rule not found")
+    ErrAlertRuleLimit          = errors.New("alert rule limit reached")
+    ErrWebhookNotFound         = errors.New("webhook not found")
+    ErrWebhookLimit            = errors.New("webhook limit reached")
+    ErrLedgerConflict          = errors.New("concurrent ledger append")
+    ErrExchangeAccountNotFound = errors.New("exchange account not found")
+    ErrReportScheduleNotFound  = errors.New("report schedule not found")
+    ErrTrackedWalletNotFound   = errors.New("tracked wallet not found")
+    ErrTrackedWalletExists     = errors.New("wallet already tracked")
+    ErrStakingPositionNotFound = errors.New("staking position not found")
+    ErrStakingPositionExists   = errors.New("asset already has a staking position")
+    ErrSymbolMigrationNotFound = errors.New("symbol migration not found")
+    ErrShareNotFound           = errors.New("portfolio share not found")
+    ErrCustomAssetNotFound     = errors.New("custom asset not found")
+    ErrCustomAssetExists       = errors.New("custom asset already defined")
+    ErrTransactionNotFound     = errors.New("transaction not found")
+    ErrNegativeBalance         = errors.New("change would leave a negative balance")
+    ErrTransferLinked          = errors.New("transfer already linked")
+    ErrTransferLinkNotFound    = errors.New("transfer link not found")
 )
 
 // Metrics keys for monitoring database operations
@@ -34,15 +57,52 @@ const (
     metricQueryTotal    = "portfolio_db_query_total"
     metricQueryErrors   = "portfolio_db_query_errors_total"
     metricConnections   = "portfolio_db_connections"
+    metricStmtRecovered = "portfolio_db_prepared_statement_recoveries_total"
+)
+
+// startupTimeout bounds preparing statements and verifying the connection
+// when the repository is created
+const startupTimeout = time.Second * 30
+
+// pgCodeInvalidStatementName is the SQLSTATE raised when a prepared statement
+// no longer exists on the server, e.g. after a failover or pooler reset
+const pgCodeInvalidStatementName = "26000"
+
+// pgCodeFeatureNotSupported is the SQLSTATE raised, among other failures,
+// when a schema change altered the result type of a prepared statement, which
+// is then identified by cachedPlanMessage
+const pgCodeFeatureNotSupported = "0A000"
+
+// cachedPlanMessage is the server message of a prepared statement whose
+// result type changed since it was prepared
+const cachedPlanMessage = "cached plan must not change result type"
+
+// stmtClosedMessage is the database/sql error of a statement closed by a
+// concurrent re-prepare after it was looked up
+const stmtClosedMessage = "sql: statement is closed"
+
+// metricsOnce registers the repository metrics with the first repository
+// created, as startup may retry creating it until the database is available
+var metricsOnce sync.Once
+
+// stmtRecoveries counts re-prepare and retry cycles triggered by invalidated statements
+var stmtRecoveries = prometheus.NewCounterVec(
+    prometheus.CounterOpts{
+        Name: metricStmtRecovered,
+        Help: "Total number of operations retried after re-preparing invalidated statements",
+    },
+    []string{"operation"},
 )
 
 // PostgresRepository implements thread-safe database operations for portfolios
 type PostgresRepository struct {
     db        *sql.DB
+    replica   *sql.DB
     logger    *zap.Logger
     metrics   *prometheus.Registry
-    stmts     map[string]*sql.Stmt
-    stmtMutex sync.RWMutex
+    stmts     *StatementCache // shared by copies of the repository
+    outbox    bool // record domain events with mutations
+    feePolicy models.FeePolicy
 }
 
 // preparedStatements contains all SQL prepared statement queries
@@ -64,13 +124,957 @@ var preparedStatements = map[string]string{
         UPDATE portfolios
         SET deleted_at = $2
         WHERE id = $1 AND deleted_at IS NULL`,
+    "restorePortfolio": `
+        UPDATE portfolios
+        SET deleted_at = NULL, updated_at = $2
+        WHERE id = $1 AND deleted_at IS NOT NULL`,
+    "getPortfolioArchivedAt": `
+        SELECT deleted_at
+        FROM portfolios
+        WHERE id = $1`,
     "createAsset": `
         INSERT INTO portfolio_assets (id, portfolio_id, type, symbol, amount, cost_basis, current_value, last_updated)
         VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
     "getAssets": `
-        SELECT id, type, symbol, amount, cost_basis, current_value, last_updated
+        SELECT a.id, a.type, a.symbol, a.amount, a.cost_basis,
+               COALESCE(a.amount * p.price, a.current_value),
+               GREATEST(a.last_updated, p.updated_at)
+        FROM portfolio_assets a
+        LEFT JOIN asset_prices_current p ON p.symbol = a.symbol AND a.type <> 'custom'
+        WHERE a.portfolio_id = $1 AND a.deleted_at IS NULL`,
+    "getTransactions": `
+        SELECT id, portfolio_id, asset_id, type, amount, price, fee, timestamp
+        FROM portfolio_transactions
+        WHERE portfolio_id = $1 AND timestamp >= $2 AND timestamp < $3
+        ORDER BY timestamp ASC`,
+    "listTransactions": `
+        SELECT id, portfolio_id, asset_id, type, amount, price, fee, timestamp
+        FROM portfolio_transactions
+        WHERE portfolio_id = $1 AND timestamp >= $2 AND timestamp < $3
+          AND (timestamp, id) > ($4, $5) AND ($6::uuid IS NULL OR asset_id = $6)
+        ORDER BY timestamp, id
+        LIMIT $7`,
+    "portfoliosWithTransactionsBefore": `
+        SELECT DISTINCT portfolio_id
+        FROM portfolio_transactions
+        WHERE timestamp < $1
+        LIMIT $2`,
+    "createTransactionArchive": `
+        INSERT INTO transaction_archives (id, portfolio_id, object_key, period_start, period_end, transaction_count, size_bytes, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+    "createArchivedLot": `
+        INSERT INTO archived_transaction_lots (archive_id, portfolio_id, asset_id, quantity, cost_basis, total_fees, transaction_count, acquired_before)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+    "deleteArchivedTransactions": `
+        DELETE FROM portfolio_transactions
+        WHERE portfolio_id = $1 AND id = ANY($2)`,
+    "getFirstTransactionTime": `
+        SELECT LEAST(
+            (SELECT MIN(period_start) FROM transaction_archives WHERE portfolio_id = $1),
+            (SELECT MIN(timestamp) FROM portfolio_transactions WHERE portfolio_id = $1)
+        )`,
+    "getTransactionArchives": `
+        SELECT id, portfolio_id, object_key, period_start, period_end, transaction_count, size_bytes, created_at
+        FROM transaction_archives
+        WHERE portfolio_id = $1 AND period_start < $3 AND period_end > $2
+        ORDER BY period_start ASC`,
+    "listPortfolioIDs": `
+        SELECT id
+        FROM portfolios
+        WHERE deleted_at IS NULL AND id > $1
+        ORDER BY id
+        LIMIT $2`,
+    "createSnapshot": `
+        INSERT INTO portfolio_snapshots (id, portfolio_id, total_value, profit_loss, assets, captured_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (portfolio_id, captured_at) DO NOTHING`,
+    "refreshPortfolioChanges": `
+        SELECT refresh_portfolio_changes($1, $2)`,
+    "getValueHistory": `
+        SELECT DISTINCT ON (date_trunc('day', captured_at AT TIME ZONE 'UTC'))
+               captured_at, total_value, profit_loss
+        FROM portfolio_snapshots
+        WHERE portfolio_id = $1 AND captured_at >= $2 AND captured_at < $3
+        ORDER BY date_trunc('day', captured_at AT TIME ZONE 'UTC'), captured_at DESC`,
+    "upsertCurrentPrices": `
+        INSERT INTO asset_prices_current (symbol, price, source, updated_at, fetched_at)
+        SELECT symbol, price, NULLIF(source, ''), $4, $4
+        FROM unnest($1::text[], $2::numeric[], $3::text[]) AS t(symbol, price, source)
+        ON CONFLICT (symbol) DO UPDATE
+        SET price = EXCLUDED.price,
+            source = EXCLUDED.source,
+            updated_at = CASE WHEN asset_prices_current.price IS DISTINCT FROM EXCLUDED.price
+                              THEN EXCLUDED.updated_at ELSE asset_prices_current.updated_at END,
+            fetched_at = EXCLUDED.fetched_at
+        WHERE asset_prices_current.fetched_at < EXCLUDED.fetched_at`,
+    "getCurrentPrices": `
+        SELECT symbol, price
+        FROM asset_prices_current
+        WHERE symbol = ANY($1)`,
+    "listPriceFetches": `
+        SELECT symbol, fetched_at, COALESCE(source, '')
+        FROM asset_prices_current`,
+    "markDelistedSymbols": `
+        INSERT INTO delisted_symbols (symbol, last_price, last_quoted_at, delisted_at)
+        SELECT p.symbol, p.price, p.fetched_at, $2
+        FROM asset_prices_current p
+        WHERE p.fetched_at < $1
+            AND EXISTS (
+                SELECT 1 FROM portfolio_assets a
+                WHERE a.symbol = p.symbol AND a.deleted_at IS NULL AND a.amount > 0
+                    AND a.type NOT IN ('custom', 'fiat')
+            )
+        ON CONFLICT (symbol) DO NOTHING
+        RETURNING symbol`,
+    "clearDelistedSymbols": `
+        DELETE FROM delisted_symbols
+        WHERE symbol = ANY($1)`,
+    "listDelistedSymbols": `
+        SELECT symbol, last_price, last_quoted_at, delisted_at
+        FROM delisted_symbols
+        WHERE symbol = ANY($1)`,
+    "insertManualPrice": `
+        INSERT INTO manual_prices (id, portfolio_id, symbol, price, valid_from, valid_until, note, created_at)
+        SELECT $1, id, $3, $4, $5, $6, $7, $8
+        FROM portfolios
+        WHERE id = $2 AND deleted_at IS NULL`,
+    "listManualPrices": `
+        SELECT id, portfolio_id, symbol, price, valid_from, valid_until, note, created_at
+        FROM manual_prices
+        WHERE portfolio_id = $1 AND valid_from <= $2
+            AND (valid_until IS NULL OR valid_until > $2)
+        ORDER BY symbol, valid_from DESC`,
+    "insertCustomAsset": `
+        INSERT INTO custom_assets (id, portfolio_id, symbol, name, decimals, created_at)
+        SELECT $1, id, $3, $4, $5, $6
+        FROM portfolios
+        WHERE id = $2 AND deleted_at IS NULL`,
+    "getCustomAsset": `
+        SELECT id, portfolio_id, symbol, name, decimals, created_at
+        FROM custom_assets
+        WHERE portfolio_id = $1 AND symbol = $2`,
+    "listHeldSymbols": `
+        SELECT DISTINCT symbol
+        FROM portfolio_assets
+        WHERE deleted_at IS NULL AND amount > 0 AND type NOT IN ('custom', 'fiat')
+        ORDER BY symbol`,
+    "listHeldFiatCurrencies": `
+        SELECT DISTINCT symbol
+        FROM portfolio_assets
+        WHERE deleted_at IS NULL AND amount > 0 AND type = 'fiat'
+        ORDER BY symbol`,
+    "tryAdvisoryLock": `
+        SELECT pg_try_advisory_lock($1)`,
+    "checkAdvisoryLock": `
+        SELECT EXISTS (
+            SELECT 1
+            FROM pg_locks
+            WHERE locktype = 'advisory' AND granted AND pid = pg_backend_pid()
+              AND ((classid::bigint << 32) | objid::bigint) = $1 AND objsubid = 1
+        )`,
+    "releaseAdvisoryLock": `
+        SELECT pg_advisory_unlock($1)`,
+    "getDailyCloses": `
+        SELECT symbol, timestamp, close
+        FROM market_historical_data
+        WHERE symbol = ANY($1) AND interval = '1d' AND timestamp >= $2 AND timestamp < $3
+        ORDER BY symbol, timestamp`,
+    "insertDailyBars": `
+        INSERT INTO market_historical_data (symbol, interval, open, high, low, close, volume, trades_count, vwap, timestamp)
+        SELECT $1, '1d', t.open, t.high, t.low, t.close, t.volume, 0, t.close, t.day
+        FROM unnest($2::timestamptz[], $3::numeric[], $4::numeric[], $5::numeric[], $6::numeric[], $7::numeric[])
+             AS t(day, open, high, low, close, volume)
+        WHERE NOT EXISTS (
+            SELECT 1
+            FROM market_historical_data h
+            WHERE h.symbol = $1 AND h.interval = '1d' AND h.timestamp = t.day
+        )`,
+    "countNegativeBalances": `
+        SELECT COUNT(*)
+        FROM portfolio_assets
+        WHERE deleted_at IS NULL AND amount < 0`,
+    "countUnbalancedLedgers": `
+        SELECT COUNT(*)
+        FROM portfolio_assets a
+        LEFT JOIN (
+            SELECT asset_id, SUM(CASE
+                       WHEN type IN ('buy', 'transfer_in', 'reward', 'unstake', 'airdrop', 'fork') THEN amount
+                       WHEN type IN ('sell', 'transfer_out', 'stake', 'fee') THEN -amount
+                       ELSE 0
+                   END) AS net
+            FROM portfolio_transactions
+            GROUP BY asset_id
+        ) t ON t.asset_id = a.id
+        LEFT JOIN (
+            SELECT asset_id, SUM(quantity) AS net
+            FROM archived_transaction_lots
+            GROUP BY asset_id
+        ) l ON l.asset_id = a.id
+        WHERE a.deleted_at IS NULL
+          AND a.type <> 'staked_asset'
+          AND (t.asset_id IS NOT NULL OR l.asset_id IS NOT NULL)
+          AND a.amount <> COALESCE(t.net, 0) + COALESCE(l.net, 0)`,
+    "countOrphanedAssets": `
+        SELECT COUNT(*)
+        FROM portfolio_assets a
+        LEFT JOIN portfolios p ON p.id = a.portfolio_id AND p.deleted_at IS NULL
+        WHERE a.deleted_at IS NULL AND p.id IS NULL`,
+    "deleteAllocationTargets": `
+        DELETE FROM portfolio_allocation_targets
+        WHERE portfolio_id = $1`,
+    "insertAllocationTargets": `
+        INSERT INTO portfolio_allocation_targets (portfolio_id, dimension, target_key, percentage, updated_at)
+        SELECT $1, dimension, target_key, percentage, $5
+        FROM unnest($2::text[], $3::text[], $4::numeric[]) AS t(dimension, target_key, percentage)`,
+    "getAllocationTargets": `
+        SELECT dimension, target_key, percentage
+        FROM portfolio_allocation_targets
+        WHERE portfolio_id = $1
+        ORDER BY dimension, target_key`,
+    "getDustSettings": `
+        SELECT dust_threshold, hide_dust
+        FROM portfolios
+        WHERE id = $1 AND deleted_at IS NULL`,
+    "updateDustSettings": `
+        UPDATE portfolios
+        SET dust_threshold = $2, hide_dust = $3, updated_at = $4
+        WHERE id = $1 AND deleted_at IS NULL`,
+    "deleteAssetTags": `
+        DELETE FROM asset_tags
+        WHERE asset_id = $1 AND portfolio_id = $2`,
+    "insertAssetTags": `
+        INSERT INTO asset_tags (asset_id, portfolio_id, tag, created_at)
+        SELECT $1, $2, tag, $4
+        FROM unnest($3::text[]) AS t(tag)`,
+    "getAssetTags": `
+        SELECT asset_id, tag
+        FROM asset_tags
+        WHERE portfolio_id = $1
+        ORDER BY asset_id, tag`,
+    "listPortfoliosWithTargets": `
+        SELECT DISTINCT portfolio_id
+        FROM portfolio_allocation_targets
+        WHERE portfolio_id > $1
+        ORDER BY portfolio_id
+        LIMIT $2`,
+    "createDCAPlan": `
+        INSERT INTO dca_plans (id, portfolio_id, contribution, frequency, targets, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)`,
+    "createDCAInstallments": `
+        INSERT INTO dca_installments (plan_id, sequence, scheduled_at, purchases, projected_allocation)
+        SELECT $1, sequence, scheduled_at, purchases, projected_allocation
+        FROM unnest($2::int[], $3::timestamptz[], $4::jsonb[], $5::jsonb[])
+            AS t(sequence, scheduled_at, purchases, projected_allocation)`,
+    "getDCAPlan": `
+        SELECT portfolio_id, contribution, frequency, targets, created_at
+        FROM dca_plans
+        WHERE id = $1`,
+    "getDCAInstallments": `
+        SELECT sequence, scheduled_at, purchases, projected_allocation, executed_at
+        FROM dca_installments
+        WHERE plan_id = $1
+        ORDER BY sequence`,
+    "listDCAPlanIDs": `
+        SELECT id
+        FROM dca_plans
+        WHERE portfolio_id = $1
+        ORDER BY created_at, id`,
+    "markDCAInstallmentExecuted": `
+        UPDATE dca_installments
+        SET executed_at = $3
+        WHERE plan_id = $1 AND sequence = $2 AND executed_at IS NULL`,
+    "createAlertRule": `
+        INSERT INTO portfolio_alert_rules (id, portfolio_id, kind, threshold, window_seconds, enabled, created_at)
+        SELECT $1, $2, $3, $4, $5, $6, $7
+        WHERE (SELECT COUNT(*) FROM portfolio_alert_rules WHERE portfolio_id = $2) < $8`,
+    "getAlertRules": `
+        SELECT id, kind, threshold, window_seconds, enabled, last_triggered_at, created_at
+        FROM portfolio_alert_rules
+        WHERE portfolio_id = $1
+        ORDER BY created_at, id`,
+    "deleteAlertRule": `
+        DELETE FROM portfolio_alert_rules
+        WHERE id = $1 AND portfolio_id = $2`,
+    "createAlertEvent": `
+        INSERT INTO portfolio_alert_events (id, rule_id, portfolio_id, kind, threshold, observed, symbol, triggered_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+    "markAlertRuleTriggered": `
+        UPDATE portfolio_alert_rules
+        SET last_triggered_at = $2
+        WHERE id = $1`,
+    "listAlertEvents": `
+        SELECT id, rule_id, kind, threshold, observed, symbol, triggered_at
+        FROM portfolio_alert_events
+        WHERE portfolio_id = $1 AND (triggered_at, id) < ($2, $3)
+        ORDER BY triggered_at DESC, id DESC
+        LIMIT $4`,
+    "listActivity": `
+        SELECT kind, id, occurred_at, asset_id, symbol, tx_type, amount, price, fee,
+               rule_id, alert_kind, threshold, observed, sync_source, sync_name, imported, sync_error
+        FROM (
+            SELECT 'transaction'::text AS kind, t.id, t.timestamp AS occurred_at, t.asset_id, a.symbol::text,
+                   t.type::text AS tx_type, t.amount, t.price, t.fee,
+                   NULL::uuid AS rule_id, NULL::text AS alert_kind, NULL::numeric AS threshold,
+                   NULL::numeric AS observed, NULL::text AS sync_source, NULL::text AS sync_name,
+                   NULL::integer AS imported, NULL::text AS sync_error
+            FROM portfolio_transactions t
+            LEFT JOIN portfolio_assets a ON a.id = t.asset_id
+            WHERE t.portfolio_id = $1
+            UNION ALL
+            SELECT CASE WHEN operation = 'INSERT' THEN 'asset_added' ELSE 'asset_removed' END,
+                   (COALESCE(new_data, old_data)->>'id')::uuid, changed_at,
+                   (COALESCE(new_data, old_data)->>'id')::uuid, COALESCE(new_data, old_data)->>'symbol',
+                   NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL
+            FROM audit_trail
+            WHERE table_name = 'portfolio_assets'
+              AND COALESCE(new_data, old_data)->>'portfolio_id' = $2
+              AND (operation IN ('INSERT', 'DELETE')
+                   OR (operation = 'UPDATE' AND old_data->>'deleted_at' IS NULL
+                       AND new_data->>'deleted_at' IS NOT NULL))
+            UNION ALL
+            SELECT 'alert', e.id, e.triggered_at, NULL, e.symbol,
+                   NULL, NULL, NULL, NULL, e.rule_id, e.kind, e.threshold, e.observed, NULL, NULL, NULL, NULL
+            FROM portfolio_alert_events e
+            WHERE e.portfolio_id = $1
+            UNION ALL
+            SELECT 'sync', s.id, s.synced_at, NULL, NULL,
+                   NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, s.source, s.name, s.imported, s.error
+            FROM portfolio_sync_events s
+            WHERE s.portfolio_id = $1
+        ) activity
+        WHERE kind = ANY($3::text[]) AND (occurred_at, kind, id) < ($4, $5, $6)
+        ORDER BY occurred_at DESC, kind DESC, id DESC
+        LIMIT $7`,
+    "recordSyncEvent": `
+        INSERT INTO portfolio_sync_events (id, portfolio_id, source, name, imported, error, synced_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+    "getPreviousSnapshot": `
+        SELECT captured_at, total_value, profit_loss
+        FROM portfolio_snapshots
+        WHERE portfolio_id = $1 AND captured_at < $2
+        ORDER BY captured_at DESC
+        LIMIT 1`,
+    "getSnapshotAt": `
+        SELECT id, total_value, profit_loss, assets, captured_at
+        FROM portfolio_snapshots
+        WHERE portfolio_id = $1 AND captured_at <= $2
+        ORDER BY captured_at DESC
+        LIMIT 1`,
+    "getSnapshotPeak": `
+        SELECT MAX(total_value)
+        FROM portfolio_snapshots
+        WHERE portfolio_id = $1 AND captured_at >= $2 AND captured_at <= $3`,
+    "createDeadLetter": `
+        INSERT INTO notification_dead_letters (id, portfolio_id, sender, event, notification, error, attempts, failed_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+    "listDeadLetters": `
+        SELECT id, sender, notification, error, attempts, failed_at
+        FROM notification_dead_letters
+        ORDER BY failed_at, id
+        LIMIT $1`,
+    "deleteDeadLetter": `
+        DELETE FROM notification_dead_letters
+        WHERE id = $1`,
+    "retryDeadLetter": `
+        UPDATE notification_dead_letters
+        SET attempts = attempts + 1, error = $2, failed_at = $3
+        WHERE id = $1`,
+    "updateAssetCostBasis": `
+        UPDATE portfolio_assets
+        SET cost_basis = $3, last_updated = $4
+        WHERE id = $1 AND portfolio_id = $2 AND deleted_at IS NULL`,
+    "createTransaction": `
+        INSERT INTO portfolio_transactions (id, portfolio_id, asset_id, type, amount, price, fee, timestamp)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+    "getTransaction": `
+        SELECT id, portfolio_id, asset_id, type, amount, price, fee, timestamp
+        FROM portfolio_transactions
+        WHERE id = $1 AND portfolio_id = $2`,
+    "lockTransaction": `
+        SELECT id, portfolio_id, asset_id, type, amount, price, fee, timestamp
+        FROM portfolio_transactions
+        WHERE id = $1 AND portfolio_id = $2
+        FOR UPDATE`,
+    "lockAsset": `
+        SELECT id, type, symbol, amount, cost_basis
+        FROM portfolio_assets
+        WHERE id = $1 AND portfolio_id = $2 AND deleted_at IS NULL
+        FOR UPDATE`,
+    "listAssetTransactions": `
+        SELECT id, portfolio_id, asset_id, type, amount, price, fee, timestamp
+        FROM portfolio_transactions
+        WHERE portfolio_id = $1 AND asset_id = $2
+        ORDER BY timestamp, id`,
+    "sumTransactionFees": `
+        SELECT COALESCE(SUM(fee), 0)
+        FROM portfolio_transactions
+        WHERE portfolio_id = $1 AND timestamp >= $2 AND timestamp < $3`,
+    "listAssetArchivedLots": `
+        SELECT asset_id, quantity, cost_basis, acquired_before
+        FROM archived_transaction_lots
+        WHERE portfolio_id = $1 AND asset_id = $2
+        ORDER BY acquired_before, archive_id`,
+    "updateTransaction": `
+        UPDATE portfolio_transactions
+        SET type = $3, amount = $4, price = $5, fee = $6, timestamp = $7
+        WHERE id = $1 AND portfolio_id = $2`,
+    "deleteTransaction": `
+        DELETE FROM portfolio_transactions
+        WHERE id = $1 AND portfolio_id = $2`,
+    "restateAsset": `
+        UPDATE portfolio_assets
+        SET amount = $3, cost_basis = $4, last_updated = $5
+        WHERE id = $1 AND portfolio_id = $2 AND deleted_at IS NULL`,
+    "transferLinked": `
+        SELECT EXISTS (SELECT 1 FROM transfer_links WHERE out_transaction_id = $1 OR in_transaction_id = $1)`,
+    "listTransferCandidates": `
+        SELECT t.id, t.portfolio_id, t.asset_id, t.type, t.amount, t.price, t.fee, t.timestamp, a.symbol
+        FROM portfolio_transactions t
+        JOIN portfolios p ON p.id = t.portfolio_id AND p.deleted_at IS NULL
+        JOIN portfolio_assets a ON a.id = t.asset_id
+        WHERE p.user_id = $1 AND a.symbol = $2
+          AND t.type IN ('transfer_in', 'transfer_out')
+          AND t.timestamp >= $3 AND t.timestamp < $4
+          AND NOT EXISTS (
+              SELECT 1 FROM transfer_links l
+              WHERE l.out_transaction_id = t.id OR l.in_transaction_id = t.id)
+        ORDER BY t.timestamp, t.id`,
+    "lockTransferCandidate": `
+        SELECT t.id, t.portfolio_id, t.asset_id, t.type, t.amount, t.price, t.fee, t.timestamp, a.symbol
+        FROM portfolio_transactions t
+        JOIN portfolios p ON p.id = t.portfolio_id AND p.deleted_at IS NULL
+        JOIN portfolio_assets a ON a.id = t.asset_id
+        WHERE t.id = $1 AND p.user_id = $2
+        FOR UPDATE OF t`,
+    "insertTransferLink": `
+        INSERT INTO transfer_links (id, user_id, out_transaction_id, in_transaction_id, out_portfolio_id, in_portfolio_id,
+                                    symbol, amount, cost_basis, in_price_before, matched_by, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+    "deleteTransferLink": `
+        DELETE FROM transfer_links
+        WHERE user_id = $1 AND (out_transaction_id = $2 OR in_transaction_id = $2)
+        RETURNING id, user_id, out_transaction_id, in_transaction_id, out_portfolio_id, in_portfolio_id,
+                  symbol, amount, cost_basis, in_price_before, matched_by, created_at`,
+    "realizedGainsSince": `
+        SELECT EXISTS (
+            SELECT 1 FROM realized_gains
+            WHERE portfolio_id = $1 AND asset_id = $2 AND disposed_at >= $3
+        )`,
+    "deleteRealizedGainsSince": `
+        DELETE FROM realized_gains
+        WHERE portfolio_id = $1 AND asset_id = $2 AND disposed_at >= $3`,
+    "insertRealizedGain": `
+        INSERT INTO realized_gains (id, portfolio_id, asset_id, transaction_id, symbol, quantity, cost_basis,
+                                    proceeds, gain, disposed_at, recorded_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+    "listRealizedGains": `
+        SELECT id, portfolio_id, asset_id, transaction_id, symbol, quantity, cost_basis, proceeds, gain,
+               disposed_at, recorded_at
+        FROM realized_gains
+        WHERE portfolio_id = $1 AND disposed_at >= $2 AND disposed_at < $3
+        ORDER BY disposed_at, transaction_id`,
+    "getTaxSettings": `
+        SELECT country, tax_year_start_month, tax_year_start_day, long_term_days, wash_sales, updated_at
+        FROM user_tax_settings
+        WHERE user_id = $1`,
+    "upsertTaxSettings": `
+        INSERT INTO user_tax_settings (user_id, country, tax_year_start_month, tax_year_start_day,
+                                       long_term_days, wash_sales, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (user_id) DO UPDATE
+        SET country = EXCLUDED.country,
+            tax_year_start_month = EXCLUDED.tax_year_start_month,
+            tax_year_start_day = EXCLUDED.tax_year_start_day,
+            long_term_days = EXCLUDED.long_term_days,
+            wash_sales = EXCLUDED.wash_sales,
+            updated_at = EXCLUDED.updated_at`,
+    "createWebhook": `
+        INSERT INTO portfolio_webhooks (id, user_id, portfolio_id, url, secret, events, enabled, created_at)
+        SELECT $1, $2, $3, $4, $5, $6, $7, $8
+        WHERE (SELECT COUNT(*) FROM portfolio_webhooks
+               WHERE user_id = $2 AND portfolio_id IS NOT DISTINCT FROM $3) < $9`,
+    "getWebhook": `
+        SELECT id, user_id, portfolio_id, url, events, enabled, created_at
+        FROM portfolio_webhooks
+        WHERE id = $1 AND user_id = $2`,
+    "listWebhooks": `
+        SELECT id, user_id, portfolio_id, url, events, enabled, created_at
+        FROM portfolio_webhooks
+        WHERE user_id = $1 AND portfolio_id IS NOT DISTINCT FROM $2
+        ORDER BY created_at, id`,
+    "deleteWebhook": `
+        DELETE FROM portfolio_webhooks
+        WHERE id = $1 AND user_id = $2`,
+    "enqueueWebhookDeliveries": `
+        INSERT INTO portfolio_webhook_deliveries (id, webhook_id, portfolio_id, event, payload, next_attempt_at, created_at)
+        SELECT gen_random_uuid(), w.id, $1, $2, $3, $4, $4
+        FROM portfolio_webhooks w
+        WHERE w.enabled AND $2 = ANY(w.events)
+          AND (w.portfolio_id = $1 OR (w.portfolio_id IS NULL
+               AND w.user_id = (SELECT user_id FROM portfolios WHERE id = $1)))`,
+    "claimWebhookDeliveries": `
+        UPDATE portfolio_webhook_deliveries d
+        SET next_attempt_at = $2
+        FROM portfolio_webhooks w
+        WHERE w.id = d.webhook_id AND d.id IN (
+            SELECT id FROM portfolio_webhook_deliveries
+            WHERE status = 'pending' AND next_attempt_at <= $1
+            ORDER BY next_attempt_at
+            LIMIT $3
+            FOR UPDATE SKIP LOCKED)
+        RETURNING d.id, d.webhook_id, d.portfolio_id, d.event, d.payload, d.attempts, d.created_at, w.url, w.secret`,
+    "recordWebhookAttempt": `
+        UPDATE portfolio_webhook_deliveries
+        SET status = $2, attempts = $3, response_code = $4, last_error = $5, next_attempt_at = $6, delivered_at = $7
+        WHERE id = $1`,
+    "listWebhookDeliveries": `
+        SELECT id, portfolio_id, event, payload, status, attempts, response_code, COALESCE(last_error, ''),
+               next_attempt_at, created_at, delivered_at
+        FROM portfolio_webhook_deliveries
+        WHERE webhook_id = $1 AND (created_at, id) < ($2, $3)
+        ORDER BY created_at DESC, id DESC
+        LIMIT $4`,
+    "createPortfolioShare": `
+        INSERT INTO portfolio_shares (id, portfolio_id, token_hash, created_at, expires_at)
+        SELECT $1, id, $3, $4, $5
+        FROM portfolios
+        WHERE id = $2 AND deleted_at IS NULL`,
+    "getActivePortfolioShare": `
+        SELECT s.id, s.portfolio_id, s.created_at, s.expires_at
+        FROM portfolio_shares s
+        JOIN portfolios p ON p.id = s.portfolio_id
+        WHERE s.token_hash = $1 AND s.revoked_at IS NULL AND s.expires_at > $2
+          AND p.deleted_at IS NULL`,
+    "listPortfolioShares": `
+        SELECT id, created_at, expires_at, revoked_at
+        FROM portfolio_shares
+        WHERE portfolio_id = $1
+        ORDER BY created_at, id`,
+    "revokePortfolioShare": `
+        UPDATE portfolio_shares
+        SET revoked_at = $3
+        WHERE portfolio_id = $1 AND id = $2 AND revoked_at IS NULL`,
+    "listUserHoldings": `
+        SELECT p.id, p.name, p.dust_threshold, p.hide_dust,
+               a.id, a.type, a.symbol, a.amount, a.cost_basis,
+               COALESCE(a.amount * c.price, a.current_value),
+               GREATEST(a.last_updated, c.updated_at)
+        FROM portfolios p
+        LEFT JOIN portfolio_assets a ON a.portfolio_id = p.id AND a.deleted_at IS NULL
+        LEFT JOIN asset_prices_current c ON c.symbol = a.symbol AND a.type <> 'custom'
+        WHERE p.user_id = $1 AND p.deleted_at IS NULL
+        ORDER BY p.id`,
+    "searchUserAssets": `
+        SELECT p.id, p.name, p.deleted_at IS NOT NULL,
+               a.id, a.symbol, a.amount,
+               COALESCE(a.amount * c.price, a.current_value)
+        FROM portfolios p
+        JOIN portfolio_assets a ON a.portfolio_id = p.id AND a.deleted_at IS NULL
+        LEFT JOIN asset_prices_current c ON c.symbol = a.symbol AND a.type <> 'custom'
+        WHERE p.user_id = $1 AND a.symbol = $2 AND ($3 OR p.deleted_at IS NULL)
+        ORDER BY p.id, a.id`,
+    "listUserPortfolioIDs": `
+        SELECT id
+        FROM portfolios
+        WHERE user_id = $1
+        ORDER BY id
+        FOR UPDATE`,
+    "purgeTransactions": `
+        DELETE FROM portfolio_transactions
+        WHERE portfolio_id = ANY($1::uuid[])`,
+    "purgeArchivedLots": `
+        DELETE FROM archived_transaction_lots
+        WHERE portfolio_id = ANY($1::uuid[])`,
+    "purgeRealizedGains": `
+        DELETE FROM realized_gains
+        WHERE portfolio_id = ANY($1::uuid[])`,
+    "purgeArchives": `
+        DELETE FROM transaction_archives
+        WHERE portfolio_id = ANY($1::uuid[])
+        RETURNING object_key`,
+    "createArchiveObjectDeletions": `
+        INSERT INTO archive_object_deletions (object_key)
+        SELECT unnest($1::text[])
+        ON CONFLICT (object_key) DO NOTHING`,
+    "listArchiveObjectDeletions": `
+        SELECT object_key
+        FROM archive_object_deletions
+        ORDER BY requested_at, object_key
+        LIMIT $1`,
+    "deleteArchiveObjectDeletions": `
+        DELETE FROM archive_object_deletions
+        WHERE object_key = ANY($1::text[])`,
+    "purgeSnapshots": `
+        DELETE FROM portfolio_snapshots
+        WHERE portfolio_id = ANY($1::uuid[])`,
+    "purgeAlertRules": `
+        DELETE FROM portfolio_alert_rules
+        WHERE portfolio_id = ANY($1::uuid[])`,
+    "purgeSyncEvents": `
+        DELETE FROM portfolio_sync_events
+        WHERE portfolio_id = ANY($1::uuid[])`,
+    "purgeAssets": `
+        DELETE FROM portfolio_assets
+        WHERE portfolio_id = ANY($1::uuid[])`,
+    "purgeLedgerEvents": `
+        DELETE FROM portfolio_ledger_events
+        WHERE portfolio_id = ANY($1::uuid[])`,
+    "purgeLedgerProjections": `
+        DELETE FROM portfolio_ledger_projections
+        WHERE portfolio_id = ANY($1::uuid[])`,
+    "purgeOutboxEvents": `
+        DELETE FROM portfolio_outbox
+        WHERE portfolio_id = ANY($1::uuid[])`,
+    "purgeWebhooks": `
+        DELETE FROM portfolio_webhooks
+        WHERE user_id = $1`,
+    "purgeTaxSettings": `
+        DELETE FROM user_tax_settings
+        WHERE user_id = $1`,
+    "purgePortfolios": `
+        DELETE FROM portfolios
+        WHERE user_id = $1`,
+    "purgeAuditTrail": `
+        DELETE FROM audit_trail
+        WHERE changed_by = $1
+           OR (table_name IN ('portfolios', 'portfolio_assets', 'portfolio_transactions')
+               AND COALESCE(new_data, old_data)->>'portfolio_id' = ANY($2::text[]))`,
+    "listUserAuditEntries": `
+        SELECT id, table_name, operation, old_data, new_data, changed_by, changed_at,
+               host(client_ip), user_agent
+        FROM audit_trail
+        WHERE changed_by = $1
+           OR (table_name IN ('portfolios', 'portfolio_assets', 'portfolio_transactions')
+               AND COALESCE(new_data, old_data)->>'portfolio_id' = ANY($2::text[]))
+        ORDER BY id`,
+    "listAuditEntries": `
+        SELECT id, table_name, operation, old_data, new_data, changed_by, changed_at,
+               host(client_ip), user_agent
+        FROM audit_trail
+        WHERE table_name IN ('portfolios', 'portfolio_assets', 'portfolio_transactions')
+          AND COALESCE(new_data, old_data)->>'portfolio_id' = $1
+          AND (changed_at, id) < ($2, $3)
+        ORDER BY changed_at DESC, id DESC
+        LIMIT $4`,
+    "createUserDataPurge": `
+        INSERT INTO user_data_purges (id, user_id, portfolios, assets, transactions, snapshots, alerts, audit_entries, archives, completed_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+    "createOutboxEvent": `
+        INSERT INTO portfolio_outbox (id, portfolio_id, event_type, payload, created_at)
+        VALUES ($1, $2, $3, $4, $5)`,
+    "claimOutboxEvents": `
+        SELECT id, portfolio_id, event_type, payload, created_at
+        FROM portfolio_outbox
+        WHERE published_at IS NULL
+        ORDER BY created_at
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED`,
+    "markOutboxPublished": `
+        UPDATE portfolio_outbox
+        SET published_at = $2
+        WHERE id = ANY($1)`,
+    "purgeOutbox": `
+        DELETE FROM portfolio_outbox
+        WHERE published_at < $1`,
+    "lastLedgerSequence": `
+        SELECT COALESCE(MAX(sequence), 0)
+        FROM portfolio_ledger_events
+        WHERE portfolio_id = $1`,
+    "appendLedgerEvent": `
+        INSERT INTO portfolio_ledger_events (portfolio_id, sequence, event_type, data, recorded_at)
+        VALUES ($1, $2, $3, $4, $5)`,
+    "listLedgerEvents": `
+        SELECT portfolio_id, sequence, event_type, data, recorded_at
+        FROM portfolio_ledger_events
+        WHERE portfolio_id = $1 AND sequence > $2 AND recorded_at <= $3
+        ORDER BY sequence
+        LIMIT $4`,
+    "initLedgerCheckpoint": `
+        INSERT INTO portfolio_ledger_projections (portfolio_id, sequence, projected_at)
+        VALUES ($1, 0, $2)
+        ON CONFLICT (portfolio_id) DO NOTHING`,
+    "lockLedgerCheckpoint": `
+        SELECT sequence
+        FROM portfolio_ledger_projections
+        WHERE portfolio_id = $1
+        FOR UPDATE`,
+    "saveLedgerCheckpoint": `
+        UPDATE portfolio_ledger_projections
+        SET sequence = $2, projected_at = $3
+        WHERE portfolio_id = $1`,
+    "resetLedgerCheckpoints": `
+        UPDATE portfolio_ledger_projections
+        SET sequence = 0, projected_at = $2
+        WHERE $1::uuid IS NULL OR portfolio_id = $1`,
+    "pendingLedgerProjections": `
+        SELECT e.portfolio_id
+        FROM portfolio_ledger_events e
+        LEFT JOIN portfolio_ledger_projections p ON p.portfolio_id = e.portfolio_id
+        GROUP BY e.portfolio_id, p.sequence
+        HAVING MAX(e.sequence) > COALESCE(p.sequence, 0)
+        LIMIT $1`,
+    "projectPortfolio": `
+        INSERT INTO portfolios (id, user_id, name, description, total_value, profit_loss, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, 0, 0, $5, $5)
+        ON CONFLICT (id) DO UPDATE
+        SET user_id = EXCLUDED.user_id, name = EXCLUDED.name, description = EXCLUDED.description,
+            created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at, deleted_at = NULL`,
+    "projectPortfolioDetails": `
+        UPDATE portfolios
+        SET name = $2, description = $3, updated_at = $4
+        WHERE id = $1`,
+    "projectAsset": `
+        INSERT INTO portfolio_assets (id, portfolio_id, type, symbol, amount, cost_basis, current_value, last_updated)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (id) DO UPDATE
+        SET type = EXCLUDED.type, symbol = EXCLUDED.symbol, amount = EXCLUDED.amount,
+            cost_basis = EXCLUDED.cost_basis, deleted_at = NULL`,
+    "projectAssetRemoved": `
+        UPDATE portfolio_assets
+        SET deleted_at = $3
+        WHERE id = $2 AND portfolio_id = $1`,
+    "projectTransaction": `
+        INSERT INTO portfolio_transactions (id, portfolio_id, asset_id, type, amount, price, fee, timestamp)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (id) DO NOTHING`,
+    "upsertExchangeAccount": `
+        INSERT INTO exchange_accounts (id, portfolio_id, exchange, credentials, next_sync_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (portfolio_id, exchange) DO UPDATE
+        SET credentials = EXCLUDED.credentials, cursor = '', last_error = NULL, next_sync_at = EXCLUDED.next_sync_at
+        RETURNING id, last_synced_at, created_at`,
+    "getExchangeAccount": `
+        SELECT id, portfolio_id, exchange, cursor, last_synced_at, COALESCE(last_error, ''), next_sync_at, created_at, credentials
+        FROM exchange_accounts
+        WHERE portfolio_id = $1 AND exchange = $2`,
+    "listExchangeAccounts": `
+        SELECT id, portfolio_id, exchange, cursor, last_synced_at, COALESCE(last_error, ''), next_sync_at, created_at
+        FROM exchange_accounts
+        WHERE portfolio_id = $1
+        ORDER BY exchange`,
+    "deleteExchangeAccount": `
+        DELETE FROM exchange_accounts
+        WHERE portfolio_id = $1 AND exchange = $2`,
+    "claimDueExchangeAccounts": `
+        UPDATE exchange_accounts
+        SET next_sync_at = $2
+        WHERE id IN (
+            SELECT id FROM exchange_accounts
+            WHERE next_sync_at <= $1
+            ORDER BY next_sync_at
+            LIMIT $3
+            FOR UPDATE SKIP LOCKED)
+        RETURNING id, portfolio_id, exchange, cursor, last_synced_at, COALESCE(last_error, ''), next_sync_at, created_at, credentials`,
+    "saveExchangeCheckpoint": `
+        UPDATE exchange_accounts
+        SET cursor = $2, last_synced_at = $3, last_error = NULL, next_sync_at = $4
+        WHERE id = $1`,
+    "recordExchangeSyncError": `
+        UPDATE exchange_accounts
+        SET last_error = $2, next_sync_at = $3
+        WHERE id = $1`,
+    "listSealedExchangeCredentials": `
+        SELECT id, portfolio_id, exchange, credentials
+        FROM exchange_accounts
+        WHERE id > $1
+        ORDER BY id
+        LIMIT $2`,
+    "replaceExchangeCredentials": `
+        UPDATE exchange_accounts
+        SET credentials = $3
+        WHERE id = $1 AND credentials = $2`,
+    "listWebhookSecrets": `
+        SELECT id, secret
+        FROM portfolio_webhooks
+        WHERE id > $1
+        ORDER BY id
+        LIMIT $2`,
+    "replaceWebhookSecret": `
+        UPDATE portfolio_webhooks
+        SET secret = $3
+        WHERE id = $1 AND secret = $2`,
+    "upsertReportSchedule": `
+        INSERT INTO report_schedules (id, portfolio_id, user_id, frequency, format, timezone, hour, weekday, day_of_month, next_run_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        ON CONFLICT (portfolio_id, frequency) DO UPDATE
+        SET format = EXCLUDED.format, timezone = EXCLUDED.timezone, hour = EXCLUDED.hour, weekday = EXCLUDED.weekday,
+            day_of_month = EXCLUDED.day_of_month, next_run_at = EXCLUDED.next_run_at, last_error = NULL
+        RETURNING id, last_run_at, created_at`,
+    "listReportSchedules": `
+        SELECT id, portfolio_id, user_id, frequency, format, timezone, hour, weekday, day_of_month, next_run_at, last_run_at, COALESCE(last_error, ''), created_at
+        FROM report_schedules
+        WHERE user_id = $1
+        ORDER BY created_at`,
+    "deleteReportSchedule": `
+        DELETE FROM report_schedules
+        WHERE id = $2 AND user_id = $1`,
+    "claimDueReportSchedules": `
+        UPDATE report_schedules s
+        SET next_run_at = $2
+        FROM (
+            SELECT id, next_run_at FROM report_schedules
+            WHERE next_run_at <= $1
+            ORDER BY next_run_at
+            LIMIT $3
+            FOR UPDATE SKIP LOCKED) due
+        WHERE s.id = due.id
+        RETURNING s.id, s.portfolio_id, s.user_id, s.frequency, s.format, s.timezone, s.hour, s.weekday, s.day_of_month, due.next_run_at, s.last_run_at, COALESCE(s.last_error, ''), s.created_at`,
+    "completeReportRun": `
+        UPDATE report_schedules
+        SET last_run_at = $2, last_error = NULL, next_run_at = $3
+        WHERE id = $1`,
+    "recordReportScheduleError": `
+        UPDATE report_schedules
+        SET last_error = $2, next_run_at = $3
+        WHERE id = $1`,
+    "insertTrackedWallet": `
+        INSERT INTO tracked_wallets (id, portfolio_id, user_id, chain, address, next_sync_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+    "getTrackedWallet": `
+        SELECT id, portfolio_id, user_id, chain, address, cursor, last_synced_at, COALESCE(last_error, ''), next_sync_at, created_at
+        FROM tracked_wallets
+        WHERE user_id = $1 AND id = $2`,
+    "isTrackedWallet": `
+        SELECT EXISTS (SELECT 1 FROM tracked_wallets WHERE portfolio_id = $1)`,
+    "listTrackedWallets": `
+        SELECT id, portfolio_id, user_id, chain, address, cursor, last_synced_at, COALESCE(last_error, ''), next_sync_at, created_at
+        FROM tracked_wallets
+        WHERE user_id = $1
+        ORDER BY created_at`,
+    "deleteTrackedWallet": `
+        DELETE FROM tracked_wallets
+        WHERE user_id = $1 AND id = $2`,
+    "claimDueTrackedWallets": `
+        UPDATE tracked_wallets
+        SET next_sync_at = $2
+        WHERE id IN (
+            SELECT id FROM tracked_wallets
+            WHERE next_sync_at <= $1
+            ORDER BY next_sync_at
+            LIMIT $3
+            FOR UPDATE SKIP LOCKED)
+        RETURNING id, portfolio_id, user_id, chain, address, cursor, last_synced_at, COALESCE(last_error, ''), next_sync_at, created_at`,
+    "saveWalletCheckpoint": `
+        UPDATE tracked_wallets
+        SET cursor = $2, last_synced_at = $3, last_error = NULL, next_sync_at = $4
+        WHERE id = $1`,
+    "recordWalletSyncError": `
+        UPDATE tracked_wallets
+        SET last_error = $2, next_sync_at = $3
+        WHERE id = $1`,
+    "insertStakingPosition": `
+        INSERT INTO staking_positions (id, portfolio_id, asset_id, source, provider, address, next_accrual_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+    "listStakingPositions": `
+        SELECT id, portfolio_id, asset_id, source, provider, address, cursor, last_accrued_at, COALESCE(last_error, ''), next_accrual_at, created_at
+        FROM staking_positions
+        WHERE portfolio_id = $1
+        ORDER BY created_at`,
+    "deleteStakingPosition": `
+        DELETE FROM staking_positions
+        WHERE portfolio_id = $1 AND id = $2`,
+    "claimDueStakingPositions": `
+        UPDATE staking_positions
+        SET next_accrual_at = $2
+        WHERE id IN (
+            SELECT id FROM staking_positions
+            WHERE source = 'chain' AND next_accrual_at <= $1
+            ORDER BY next_accrual_at
+            LIMIT $3
+            FOR UPDATE SKIP LOCKED)
+        RETURNING id, portfolio_id, asset_id, source, provider, address, cursor, last_accrued_at, COALESCE(last_error, ''), next_accrual_at, created_at`,
+    "saveStakingCheckpoint": `
+        UPDATE staking_positions
+        SET cursor = $2, last_accrued_at = $3, last_error = NULL, next_accrual_at = $4
+        WHERE id = $1`,
+    "recordStakingError": `
+        UPDATE staking_positions
+        SET last_error = $2, next_accrual_at = $3
+        WHERE id = $1`,
+    "insertSymbolMigration": `
+        INSERT INTO symbol_migrations (id, from_symbol, to_symbol, ratio, effective_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (from_symbol) DO NOTHING`,
+    "getSymbolMigration": `
+        SELECT id, from_symbol, to_symbol, ratio, effective_at, assets_migrated, applied_at, created_at
+        FROM symbol_migrations
+        WHERE from_symbol = $1`,
+    "listSymbolHoldings": `
+        SELECT id, portfolio_id, amount
         FROM portfolio_assets
-        WHERE portfolio_id = $1 AND deleted_at IS NULL`,
+        WHERE symbol = $1 AND deleted_at IS NULL AND id > $2
+        ORDER BY id
+        LIMIT $3`,
+    "hasArchivedLots": `
+        SELECT EXISTS (SELECT 1 FROM archived_transaction_lots WHERE asset_id = $1)`,
+    "symbolMigrationAudited": `
+        SELECT EXISTS (SELECT 1 FROM symbol_migration_audit WHERE migration_id = $1 AND asset_id = $2)`,
+    "migrateAssetSymbol": `
+        UPDATE portfolio_assets
+        SET symbol = $4, amount = amount * $5, last_updated = $6
+        WHERE id = $1 AND portfolio_id = $2 AND symbol = $3 AND deleted_at IS NULL
+        RETURNING amount`,
+    "migrateAssetTransactions": `
+        UPDATE portfolio_transactions
+        SET amount = amount * $3, price = price / $3
+        WHERE asset_id = $1 AND portfolio_id = $2`,
+    "migrateAssetLots": `
+        UPDATE archived_transaction_lots
+        SET quantity = quantity * $3
+        WHERE asset_id = $1 AND portfolio_id = $2`,
+    "insertSymbolMigrationAudit": `
+        INSERT INTO symbol_migration_audit (migration_id, portfolio_id, asset_id, from_symbol, to_symbol, ratio,
+                                            amount_before, amount_after, transactions_relinked, lots_relinked, migrated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+    "completeSymbolMigration": `
+        UPDATE symbol_migrations
+        SET assets_migrated = assets_migrated + $2, applied_at = $3
+        WHERE id = $1`,
+    "countPortfoliosByValue": `
+        SELECT width_bucket(total_value, $1::numeric[]), COUNT(*), COALESCE(SUM(total_value), 0)
+        FROM portfolios
+        WHERE deleted_at IS NULL
+        GROUP BY 1`,
+    "countPortfolioOwners": `
+        SELECT COUNT(DISTINCT user_id)
+        FROM portfolios
+        WHERE deleted_at IS NULL`,
+    "countActiveUsers": `
+        SELECT COUNT(DISTINCT p.user_id)
+        FROM audit_trail a
+        JOIN portfolios p ON p.id::text = COALESCE(a.new_data, a.old_data)->>'portfolio_id'
+        WHERE a.table_name = 'portfolio_assets' AND a.changed_at >= $1`,
+    "getLatestSnapshotTime": `
+        SELECT MAX(captured_at)
+        FROM portfolio_snapshots`,
+    "countSyncsByConnector": `
+        SELECT source, name, COUNT(*), COUNT(*) FILTER (WHERE error IS NOT NULL)
+        FROM portfolio_sync_events
+        WHERE synced_at >= $1
+        GROUP BY source, name`,
+    "getUserStats": `
+        SELECT COUNT(*) FILTER (WHERE p.deleted_at IS NULL),
+               COUNT(*) FILTER (WHERE p.deleted_at IS NOT NULL),
+               COALESCE(SUM((SELECT COUNT(*) FROM portfolio_assets a
+                             WHERE a.portfolio_id = p.id AND a.deleted_at IS NULL)), 0),
+               COALESCE(SUM((SELECT COUNT(*) FROM portfolio_transactions t
+                             WHERE t.portfolio_id = p.id)), 0),
+               COALESCE(SUM(p.total_value) FILTER (WHERE p.deleted_at IS NULL), 0)
+        FROM portfolios p
+        WHERE p.user_id = $1`,
+    "getSchemaVersion": `
+        SELECT COALESCE(MAX(version), 0)
+        FROM schema_migrations`,
+    "getPlatformStats": `
+        SELECT COUNT(DISTINCT p.user_id),
+               COUNT(*),
+               COALESCE(SUM((SELECT COUNT(*) FROM portfolio_assets a
+                             WHERE a.portfolio_id = p.id AND a.deleted_at IS NULL)), 0),
+               COALESCE(SUM((SELECT COUNT(*) FROM portfolio_transactions t
+                             WHERE t.portfolio_id = p.id)), 0),
+               COALESCE(SUM(p.total_value), 0)
+        FROM portfolios p
+        WHERE p.deleted_at IS NULL`,
 }
 
 // NewPostgresRepository creates a new PostgreSQL repository instance
@@ -79,68 +1083,126 @@ func NewPostgresRepository(cfg *config.Config, logger *zap.Logger) (*PostgresRep
         return nil, errors.New("invalid configuration or logger")
     }
 
-    // Construct connection string with SSL settings
-    connStr := fmt.Sprintf(
-        "host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-        cfg.Database.Host,
-        cfg.Database.Port,
-        cfg.Database.User,
-        cfg.Database.Password,
-        cfg.Database.Database,
-        cfg.Database.SSLMode,
-    )
+    // Initialize database connection
+    db, err := openDB(&cfg.Database, cfg.Database.Host, cfg.Database.Port)
+    if err != nil {
+        return nil, fmt.Errorf("failed to connect to database: %w", err)
+    }
 
-    // Add SSL certificate configuration if provided
-    if cfg.Database.SSLCert != "" {
-        connStr += fmt.Sprintf(" sslcert=%s sslkey=%s sslrootcert=%s",
-            cfg.Database.SSLCert,
-            cfg.Database.SSLKey,
-            cfg.Database.SSLRootCert,
-        )
+    // Optional read replica for reads without read-your-writes requirements
+    var replica *sql.DB
+    if cfg.Database.ReplicaHost != "" {
+        replica, err = openDB(&cfg.Database, cfg.Database.ReplicaHost, cfg.Database.ReplicaPort)
+        if err != nil {
+            db.Close()
+            return nil, fmt.Errorf("failed to connect to read replica: %w", err)
+        }
     }
 
-    // Initialize database connection
-    db, err := sql.Open("postgres", connStr)
+    ctx, cancel := context.WithTimeout(context.Background(), startupTimeout)
+    defer cancel()
+
+    repo, err := NewPostgresRepositoryWithDB(ctx, db, cfg, logger)
     if err != nil {
-        return nil, fmt.Errorf("failed to connect to database: %w", err)
+        if replica != nil {
+            replica.Close()
+        }
+        return nil, err
     }
+    repo.replica = replica
 
-    // Configure connection pool
-    db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
-    db.SetMaxIdleConns(cfg.Database.MaxIdleConns)
-    db.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
-    db.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
+    return repo, nil
+}
+
+// NewPostgresRepositoryWithDB creates a repository over an open connection
+// pool and prepares its statements. The pool is closed when creation fails
+// and by Close otherwise.
+func NewPostgresRepositoryWithDB(ctx context.Context, db *sql.DB, cfg *config.Config, logger *zap.Logger) (*PostgresRepository, error) {
+    if db == nil || cfg == nil || logger == nil {
+        return nil, errors.New("invalid database, configuration or logger")
+    }
 
     // Initialize repository instance
     repo := &PostgresRepository{
-        db:      db,
-        logger:  logger,
-        metrics: prometheus.NewRegistry(),
-        stmts:   make(map[string]*sql.Stmt),
+        db:        db,
+        logger:    logger,
+        metrics:   prometheus.NewRegistry(),
+        stmts:     NewStatementCache(db, preparedStatements),
+        outbox:    cfg.Outbox.Enabled,
+        feePolicy: models.FeePolicy(cfg.CostBasis.FeePolicy),
     }
 
     // Initialize metrics collectors
-    repo.initMetrics()
+    metricsOnce.Do(repo.initMetrics)
 
     // Prepare statements
-    if err := repo.prepareStatements(); err != nil {
-        db.Close()
+    if err := repo.prepareStatements(ctx); err != nil {
+        repo.Close()
         return nil, fmt.Errorf("failed to prepare statements: %w", err)
     }
 
     // Verify connection
-    if err := db.Ping(); err != nil {
-        db.Close()
+    if err := db.PingContext(ctx); err != nil {
+        repo.Close()
         return nil, fmt.Errorf("failed to ping database: %w", err)
     }
 
     return repo, nil
 }
 
+// OpenDB opens a connection pool to the primary database without preparing
+// statements, for work that must precede the repository, such as applying
+// the migrations its statements depend on
+func OpenDB(cfg *config.DatabaseConfig) (*sql.DB, error) {
+    return openDB(cfg, cfg.Host, cfg.Port)
+}
+
+// openDB opens a connection pool to the database server at host:port
+func openDB(cfg *config.DatabaseConfig, host string, port int) (*sql.DB, error) {
+    // Construct connection string with SSL settings
+    connStr := fmt.Sprintf(
+        "host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+        host,
+        port,
+        cfg.User,
+        cfg.Password,
+        cfg.Database,
+        cfg.SSLMode,
+    )
+
+    // Bound statements server-side as well, covering work whose context
+    // carries no deadline
+    if cfg.StatementTimeout > 0 {
+        connStr += fmt.Sprintf(" statement_timeout=%d", cfg.StatementTimeout.Milliseconds())
+    }
+
+    // Add SSL certificate configuration if provided
+    if cfg.SSLCert != "" {
+        connStr += fmt.Sprintf(" sslcert=%s sslkey=%s sslrootcert=%s",
+            cfg.SSLCert,
+            cfg.SSLKey,
+            cfg.SSLRootCert,
+        )
+    }
+
+    db, err := sql.Open("postgres", connStr)
+    if err != nil {
+        return nil, err
+    }
+
+    // Configure connection pool
+    db.SetMaxOpenConns(cfg.MaxOpenConns)
+    db.SetMaxIdleConns(cfg.MaxIdleConns)
+    db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+    db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+    return db, nil
+}
+
 // initMetrics initializes Prometheus metrics collectors
 func (r *PostgresRepository) initMetrics() {
     // Query duration histogram
-    prometheus.MustRegister(prometheus.NewHistogramVec(
+    metrics.MustRegister(prometheus.NewHistogramVec(
         prometheus.HistogramOpts{
             Name: metricQueryDuration,
             Help: "Duration of database queries in seconds",
@@ -149,7 +1211,7 @@ func (r *PostgresRepository) initMetrics() {
     ))
 
     // Query counter
-    prometheus.MustRegister(prometheus.NewCounterVec(
+    metrics.MustRegister(prometheus.NewCounterVec(
         prometheus.CounterOpts{
             Name: metricQueryTotal,
             Help: "Total number of database queries",
@@ -158,7 +1220,7 @@ func (r *PostgresRepository) initMetrics() {
     ))
 
     // Error counter
-    prometheus.MustRegister(prometheus.NewCounterVec(
+    metrics.MustRegister(prometheus.NewCounterVec(
         prometheus.CounterOpts{
             Name: metricQueryErrors,
             Help: "Total number of database query errors",
@@ -167,27 +1229,83 @@ func (r *PostgresRepository) initMetrics() {
     ))
 
     // Connection gauge
-    prometheus.MustRegister(prometheus.NewGauge(
+    metrics.MustRegister(prometheus.NewGauge(
         prometheus.GaugeOpts{
             Name: metricConnections,
             Help: "Current number of database connections",
         },
     ))
+
+    // Prepared statement recovery counter
+    metrics.MustRegister(stmtRecoveries)
 }
 
 // prepareStatements prepares all SQL statements
-func (r *PostgresRepository) prepareStatements() error {
-    r.stmtMutex.Lock()
-    defer r.stmtMutex.Unlock()
+func (r *PostgresRepository) prepareStatements(ctx context.Context) error {
+    return r.stmts.Prepare(ctx)
+}
 
-    for name, query := range preparedStatements {
-        stmt, err := r.db.Prepare(query)
-        if err != nil {
-            return fmt.Errorf("failed to prepare statement %s: %w", name, err)
+// statement returns the prepared statement registered under name
+func (r *PostgresRepository) statement(name string) *sql.Stmt {
+    return r.stmts.Get(name)
+}
+
+// withStatementRecovery runs fn and, when it fails because a prepared statement
+// was invalidated on the server, re-prepares all statements and retries once.
+// fn must be safe to re-run, i.e. it should own its transaction.
+func (r *PostgresRepository) withStatementRecovery(ctx context.Context, operation string, fn func() error) error {
+    generation := r.stmts.Generation()
+    return RetryInvalidStatement(ctx, fn, func(ctx context.Context, cause error) error {
+        stmtRecoveries.WithLabelValues(operation).Inc()
+        r.logger.Warn("Prepared statement invalidated, re-preparing and retrying",
+            zap.String("operation", operation),
+            zap.Error(cause),
+        )
+        return r.stmts.Reprepare(ctx, generation)
+    })
+}
+
+// RetryInvalidStatement runs fn and, when it fails because a prepared
+// statement was invalidated, calls reprepare with the failure and runs fn
+// once more. The retry's error is returned as is, so a statement that keeps
+// failing is never re-prepared in a loop. Nothing is retried once ctx is done.
+func RetryInvalidStatement(ctx context.Context, fn func() error, reprepare func(ctx context.Context, cause error) error) error {
+    err := fn()
+    if err == nil || !IsInvalidStatement(err) || ctx.Err() != nil {
+        return err
+    }
+
+    if perr := reprepare(ctx, err); perr != nil {
+        return fmt.Errorf("failed to re-prepare statements: %w", perr)
+    }
+
+    return fn()
+}
+
+// IsInvalidStatement reports whether err indicates a prepared statement that
+// must be prepared again: one missing on the server, e.g. after a failover or
+// pooler reset, one whose result type a schema change altered, or one closed
+// by a concurrent re-prepare
+func IsInvalidStatement(err error) bool {
+    if err == nil {
+        return false
+    }
+
+    var pqErr *pq.Error
+    if errors.As(err, &pqErr) {
+        switch pqErr.Code {
+        case pgCodeInvalidStatementName:
+            return true
+        case pgCodeFeatureNotSupported:
+            return strings.Contains(pqErr.Message, cachedPlanMessage)
         }
-        r.stmts[name] = stmt
+        return false
     }
-    return nil
+
+    msg := err.Error()
+    return strings.Contains(msg, "prepared statement") && strings.Contains(msg, "does not exist") ||
+        strings.Contains(msg, cachedPlanMessage) ||
+        strings.Contains(msg, stmtClosedMessage)
 }
 
 // CreatePortfolio creates a new portfolio with transaction support
@@ -196,6 +1314,28 @@ func (r *PostgresRepository) CreatePortfolio(ctx context.Context, p *models.Port
         return ErrInvalidPortfolio
     }
 
+    err := r.withStatementRecovery(ctx, "createPortfolio", func() error {
+        return r.createPortfolio(ctx, p)
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    prometheus.NewCounter(prometheus.CounterOpts{
+        Name: metricQueryTotal,
+    }).Inc()
+
+    r.logger.Info("Portfolio created successfully",
+        zap.String("portfolio_id", p.ID.String()),
+        zap.String("user_id", p.UserID.String()),
+    )
+
+    return nil
+}
+
+// createPortfolio inserts the portfolio and its assets in a single transaction
+func (r *PostgresRepository) createPortfolio(ctx context.Context, p *models.Portfolio) error {
     // Start transaction
     tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
     if err != nil {
@@ -205,7 +1345,7 @@ func (r *PostgresRepository) CreatePortfolio(ctx context.Context, p *models.Port
 
     // Execute prepared statement within transaction
     start := time.Now()
-    _, err = tx.StmtContext(ctx, r.stmts["createPortfolio"]).ExecContext(ctx,
+    _, err = tx.StmtContext(ctx, r.statement("createPortfolio")).ExecContext(ctx,
         p.ID,
         p.UserID,
         p.Name,
@@ -231,7 +1371,7 @@ func (r *PostgresRepository) CreatePortfolio(ctx context.Context, p *models.Port
 
     // Create assets in batch if any exist
     if len(p.Assets) > 0 {
-        stmt := r.stmts["createAsset"]
+        stmt := r.statement("createAsset")
         for _, asset := range p.Assets {
             _, err = tx.StmtContext(ctx, stmt).ExecContext(ctx,
                 asset.ID,
@@ -249,31 +1389,74 @@ func (r *PostgresRepository) CreatePortfolio(ctx context.Context, p *models.Port
         }
     }
 
+    if err := r.appendOutbox(ctx, tx, func() (*models.OutboxEvent, error) {
+        return models.PortfolioCreatedEvent(p)
+    }); err != nil {
+        return err
+    }
+
     // Commit transaction
     if err := tx.Commit(); err != nil {
         return fmt.Errorf("failed to commit transaction: %w", err)
     }
 
-    prometheus.NewCounter(prometheus.CounterOpts{
-        Name: metricQueryTotal,
-    }).Inc()
+    return nil
+}
 
-    r.logger.Info("Portfolio created successfully",
-        zap.String("portfolio_id", p.ID.String()),
-        zap.String("user_id", p.UserID.String()),
-    )
+// GetTransactions returns the portfolio transactions recorded within [start, end)
+func (r *PostgresRepository) GetTransactions(ctx context.Context, portfolioID uuid.UUID, start, end time.Time) ([]models.Transaction, error) {
+    var txs []models.Transaction
 
+    err := r.withStatementRecovery(ctx, "getTransactions", func() error {
+        rows, err := r.queryContext(ctx, "getTransactions", portfolioID, start, end)
+        if err != nil {
+            return fmt.Errorf("failed to query transactions: %w", err)
+        }
+        defer rows.Close()
+
+        txs = txs[:0]
+        for rows.Next() {
+            var tx models.Transaction
+            if err := rows.Scan(
+                &tx.ID,
+                &tx.PortfolioID,
+                &tx.AssetID,
+                &tx.Type,
+                &tx.Amount,
+                &tx.Price,
+                &tx.Fee,
+                &tx.Timestamp,
+            ); err != nil {
+                return fmt.Errorf("failed to scan transaction: %w", err)
+            }
+            txs = append(txs, tx)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return txs, nil
+}
+
+// Ping verifies the database connection is alive
+func (r *PostgresRepository) Ping(ctx context.Context) error {
+    if err := r.db.PingContext(ctx); err != nil {
+        return fmt.Errorf("%w: %v", ErrDatabaseConnection, err)
+    }
     return nil
 }
 
 // Close closes the database connection and prepared statements
 func (r *PostgresRepository) Close() error {
-    r.stmtMutex.Lock()
-    defer r.stmtMutex.Unlock()
+    if err := r.stmts.Close(); err != nil {
+        r.logger.Error("Failed to close prepared statement", zap.Error(err))
+    }
 
-    for _, stmt := range r.stmts {
-        if err := stmt.Close(); err != nil {
-            r.logger.Error("Failed to close prepared statement", zap.Error(err))
+    if r.replica != nil {
+        if err := r.replica.Close(); err != nil {
+            r.logger.Error("Failed to close read replica", zap.Error(err))
         }
     }
 
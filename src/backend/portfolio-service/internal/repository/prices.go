@@ -0,0 +1,190 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "time"
+
+    "github.com/lib/pq"             // v1.10.9
+    "github.com/shopspring/decimal" // v1.3.1
+)
+
+// UpsertCurrentPrices stores the latest price of each symbol in a single bulk
+// statement. Portfolio and asset rows are never touched; asset values are
+// derived at read time. Symbols whose price is unchanged only have their fetch
+// time advanced, keeping their updated_at. sources names the provider of each
+// price; prices without one are stored with no source. It returns the number
+// of symbols stored.
+func (r *PostgresRepository) UpsertCurrentPrices(ctx context.Context, prices map[string]decimal.Decimal, sources map[string]string, at time.Time) (int64, error) {
+    if len(prices) == 0 {
+        return 0, nil
+    }
+
+    // Sort symbols so concurrent refreshes lock rows in the same order
+    symbols := make([]string, 0, len(prices))
+    for symbol := range prices {
+        symbols = append(symbols, symbol)
+    }
+    sort.Strings(symbols)
+
+    values := make([]string, len(symbols))
+    origins := make([]string, len(symbols))
+    for i, symbol := range symbols {
+        values[i] = prices[symbol].String()
+        origins[i] = sources[symbol]
+    }
+
+    var updated int64
+    err := r.withStatementRecovery(ctx, "upsertCurrentPrices", func() error {
+        res, err := r.statement("upsertCurrentPrices").ExecContext(ctx,
+            pq.Array(symbols),
+            pq.Array(values),
+            pq.Array(origins),
+            at,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to upsert current prices: %w", err)
+        }
+        updated, err = res.RowsAffected()
+        return err
+    })
+    if err != nil {
+        return 0, err
+    }
+
+    return updated, nil
+}
+
+// GetCurrentPrices returns the latest stored price of each requested symbol.
+// Symbols without a stored price are omitted from the result.
+func (r *PostgresRepository) GetCurrentPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error) {
+    prices := make(map[string]decimal.Decimal, len(symbols))
+    if len(symbols) == 0 {
+        return prices, nil
+    }
+
+    err := r.withStatementRecovery(ctx, "getCurrentPrices", func() error {
+        rows, err := r.statement("getCurrentPrices").QueryContext(ctx, pq.Array(symbols))
+        if err != nil {
+            return fmt.Errorf("failed to query current prices: %w", err)
+        }
+        defer rows.Close()
+
+        for rows.Next() {
+            var (
+                symbol string
+                price  decimal.Decimal
+            )
+            if err := rows.Scan(&symbol, &price); err != nil {
+                return fmt.Errorf("failed to scan current price: %w", err)
+            }
+            prices[symbol] = price
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return prices, nil
+}
+
+// ListPriceFetches returns when a provider last returned the stored price of
+// each symbol, and the name of that provider for the prices stored with one
+func (r *PostgresRepository) ListPriceFetches(ctx context.Context) (map[string]time.Time, map[string]string, error) {
+    var (
+        fetchedAt map[string]time.Time
+        sources   map[string]string
+    )
+
+    err := r.withStatementRecovery(ctx, "listPriceFetches", func() error {
+        rows, err := r.queryContext(ctx, "listPriceFetches")
+        if err != nil {
+            return fmt.Errorf("failed to query price fetches: %w", err)
+        }
+        defer rows.Close()
+
+        fetchedAt = make(map[string]time.Time)
+        sources = make(map[string]string)
+        for rows.Next() {
+            var (
+                symbol string
+                at     time.Time
+                source string
+            )
+            if err := rows.Scan(&symbol, &at, &source); err != nil {
+                return fmt.Errorf("failed to scan price fetch: %w", err)
+            }
+            fetchedAt[symbol] = at
+            if source != "" {
+                sources[symbol] = source
+            }
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return fetchedAt, sources, nil
+}
+
+// ListHeldSymbols returns the distinct symbols held in any portfolio, in
+// order. Custom assets are left out, as no provider quotes them, and so are
+// fiat balances, which are priced at exchange rates.
+func (r *PostgresRepository) ListHeldSymbols(ctx context.Context) ([]string, error) {
+    var symbols []string
+
+    err := r.withStatementRecovery(ctx, "listHeldSymbols", func() error {
+        rows, err := r.queryContext(ctx, "listHeldSymbols")
+        if err != nil {
+            return fmt.Errorf("failed to query held symbols: %w", err)
+        }
+        defer rows.Close()
+
+        symbols = symbols[:0]
+        for rows.Next() {
+            var symbol string
+            if err := rows.Scan(&symbol); err != nil {
+                return fmt.Errorf("failed to scan held symbol: %w", err)
+            }
+            symbols = append(symbols, symbol)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return symbols, nil
+}
+
+// ListHeldFiatCurrencies returns the distinct currency codes of fiat balances
+// held in any portfolio, in order
+func (r *PostgresRepository) ListHeldFiatCurrencies(ctx context.Context) ([]string, error) {
+    var currencies []string
+
+    err := r.withStatementRecovery(ctx, "listHeldFiatCurrencies", func() error {
+        rows, err := r.queryContext(ctx, "listHeldFiatCurrencies")
+        if err != nil {
+            return fmt.Errorf("failed to query held fiat currencies: %w", err)
+        }
+        defer rows.Close()
+
+        currencies = currencies[:0]
+        for rows.Next() {
+            var currency string
+            if err := rows.Scan(&currency); err != nil {
+                return fmt.Errorf("failed to scan held fiat currency: %w", err)
+            }
+            currencies = append(currencies, currency)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return currencies, nil
+}
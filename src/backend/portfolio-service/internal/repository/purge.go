@@ -0,0 +1,205 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/lib/pq"                // v1.10.9
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// ListArchiveObjectDeletions returns up to limit keys of archive objects
+// whose records were purged but which are still to be deleted, oldest first
+func (r *PostgresRepository) ListArchiveObjectDeletions(ctx context.Context, limit int) ([]string, error) {
+    var keys []string
+
+    err := r.withStatementRecovery(ctx, "listArchiveObjectDeletions", func() error {
+        rows, err := r.statement("listArchiveObjectDeletions").QueryContext(ctx, limit)
+        if err != nil {
+            return fmt.Errorf("failed to query archive object deletions: %w", err)
+        }
+        defer rows.Close()
+
+        keys = keys[:0]
+        for rows.Next() {
+            var key string
+            if err := rows.Scan(&key); err != nil {
+                return fmt.Errorf("failed to scan archive object key: %w", err)
+            }
+            keys = append(keys, key)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return keys, nil
+}
+
+// DeleteArchiveObjectDeletions clears the pending deletions of archive
+// objects that were deleted
+func (r *PostgresRepository) DeleteArchiveObjectDeletions(ctx context.Context, keys []string) error {
+    if len(keys) == 0 {
+        return nil
+    }
+
+    return r.withStatementRecovery(ctx, "deleteArchiveObjectDeletions", func() error {
+        if _, err := r.statement("deleteArchiveObjectDeletions").ExecContext(ctx, pq.Array(keys)); err != nil {
+            return fmt.Errorf("failed to clear archive object deletions: %w", err)
+        }
+        return nil
+    })
+}
+
+// PurgeUserData permanently deletes all portfolios of a user, archived ones
+// included, with their holdings, history, alerts, webhooks, ledgers, pending
+// events, tax settings and audit entries in a single transaction. The
+// removed record counts are filled into purge, which is stored as the record
+// of the erasure together with its completion event. The objects of removed
+// archives are recorded as pending deletions in the same transaction and
+// their keys filled into purge, so they are only deleted once the purge
+// committed and a failed deletion can be retried.
+func (r *PostgresRepository) PurgeUserData(ctx context.Context, purge *models.UserDataPurge) error {
+    if purge == nil || purge.UserID == uuid.Nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "purgePortfolios", func() error {
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        rows, err := tx.StmtContext(ctx, r.statement("listUserPortfolioIDs")).QueryContext(ctx, purge.UserID)
+        if err != nil {
+            return fmt.Errorf("failed to lock user portfolios: %w", err)
+        }
+        var ids []string
+        for rows.Next() {
+            var id uuid.UUID
+            if err := rows.Scan(&id); err != nil {
+                rows.Close()
+                return fmt.Errorf("failed to scan portfolio id: %w", err)
+            }
+            ids = append(ids, id.String())
+        }
+        rows.Close()
+        if err := rows.Err(); err != nil {
+            return fmt.Errorf("failed to list user portfolios: %w", err)
+        }
+
+        // Dependent rows go first so the counts are exact rather than
+        // hidden in cascades; audit entries go last as the deletes above
+        // write new ones.
+        portfolios := pq.Array(ids)
+        var skipped int
+        var archiveKeys []string
+        steps := []struct {
+            statement string
+            args      []interface{}
+            count     *int
+            returning *[]string
+        }{
+            {"purgeTransactions", []interface{}{portfolios}, &purge.Transactions, nil},
+            {"purgeArchivedLots", []interface{}{portfolios}, &skipped, nil},
+            {"purgeRealizedGains", []interface{}{portfolios}, &skipped, nil},
+            {"purgeArchives", []interface{}{portfolios}, &purge.Archives, &archiveKeys},
+            {"purgeSnapshots", []interface{}{portfolios}, &purge.Snapshots, nil},
+            {"purgeAlertRules", []interface{}{portfolios}, &purge.Alerts, nil},
+            {"purgeSyncEvents", []interface{}{portfolios}, &skipped, nil},
+            {"purgeAssets", []interface{}{portfolios}, &purge.Assets, nil},
+            {"purgeLedgerEvents", []interface{}{portfolios}, &skipped, nil},
+            {"purgeLedgerProjections", []interface{}{portfolios}, &skipped, nil},
+            {"purgeOutboxEvents", []interface{}{portfolios}, &skipped, nil},
+            {"purgeWebhooks", []interface{}{purge.UserID}, &skipped, nil},
+            {"purgeTaxSettings", []interface{}{purge.UserID}, &skipped, nil},
+            {"purgePortfolios", []interface{}{purge.UserID}, &purge.Portfolios, nil},
+            {"purgeAuditTrail", []interface{}{purge.UserID, portfolios}, &purge.AuditEntries, nil},
+        }
+        for _, step := range steps {
+            if step.returning != nil {
+                returned, err := queryStrings(ctx, tx.StmtContext(ctx, r.statement(step.statement)), step.args...)
+                if err != nil {
+                    return fmt.Errorf("failed to run %s: %w", step.statement, err)
+                }
+                *step.returning = returned
+                *step.count = len(returned)
+                continue
+            }
+
+            result, err := tx.StmtContext(ctx, r.statement(step.statement)).ExecContext(ctx, step.args...)
+            if err != nil {
+                return fmt.Errorf("failed to run %s: %w", step.statement, err)
+            }
+            if n, err := result.RowsAffected(); err == nil {
+                *step.count = int(n)
+            }
+        }
+
+        if len(archiveKeys) > 0 {
+            _, err := tx.StmtContext(ctx, r.statement("createArchiveObjectDeletions")).ExecContext(ctx, pq.Array(archiveKeys))
+            if err != nil {
+                return fmt.Errorf("failed to record archive object deletions: %w", err)
+            }
+        }
+
+        _, err = tx.StmtContext(ctx, r.statement("createUserDataPurge")).ExecContext(ctx,
+            purge.ID,
+            purge.UserID,
+            purge.Portfolios,
+            purge.Assets,
+            purge.Transactions,
+            purge.Snapshots,
+            purge.Alerts,
+            purge.AuditEntries,
+            purge.Archives,
+            purge.CompletedAt,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to record user data purge: %w", err)
+        }
+
+        if err := r.appendOutbox(ctx, tx, func() (*models.OutboxEvent, error) {
+            return models.UserDataPurgedEvent(purge)
+        }); err != nil {
+            return err
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        purge.ArchiveKeys = archiveKeys
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// queryStrings runs a statement returning a single text column and collects
+// its values
+func queryStrings(ctx context.Context, stmt *sql.Stmt, args ...interface{}) ([]string, error) {
+    rows, err := stmt.QueryContext(ctx, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var values []string
+    for rows.Next() {
+        var value string
+        if err := rows.Scan(&value); err != nil {
+            return nil, err
+        }
+        values = append(values, value)
+    }
+    return values, rows.Err()
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// ListRealizedGains returns the gains realized by the sells of a portfolio
+// within [start, end), oldest first
+func (r *PostgresRepository) ListRealizedGains(ctx context.Context, portfolioID uuid.UUID, start, end time.Time) ([]models.RealizedGain, error) {
+    var gains []models.RealizedGain
+
+    err := r.withStatementRecovery(ctx, "listRealizedGains", func() error {
+        rows, err := r.queryContext(ctx, "listRealizedGains", portfolioID, start, end)
+        if err != nil {
+            return fmt.Errorf("failed to query realized gains: %w", err)
+        }
+        defer rows.Close()
+
+        gains = gains[:0]
+        for rows.Next() {
+            var g models.RealizedGain
+            err := rows.Scan(
+                &g.ID,
+                &g.PortfolioID,
+                &g.AssetID,
+                &g.TransactionID,
+                &g.Symbol,
+                &g.Quantity,
+                &g.CostBasis,
+                &g.Proceeds,
+                &g.Gain,
+                &g.DisposedAt,
+                &g.RecordedAt,
+            )
+            if err != nil {
+                return fmt.Errorf("failed to scan realized gain: %w", err)
+            }
+            g.DisposedAt = g.DisposedAt.UTC()
+            g.RecordedAt = g.RecordedAt.UTC()
+            gains = append(gains, g)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return gains, nil
+}
+
+// recordRealizedGains stores the gains realized by a transaction just
+// recorded within tx. A sell, or any transaction recorded before a sell of
+// its asset already stored, replays the asset's lots and restates the gains
+// of its sells from the transaction's timestamp forward; an acquisition
+// after the last sell changes none.
+func (r *PostgresRepository) recordRealizedGains(ctx context.Context, tx *sql.Tx, portfolioID uuid.UUID, t *models.Transaction) error {
+    if models.IsLotAcquisition(t.Type) {
+        var later bool
+        err := tx.StmtContext(ctx, r.statement("realizedGainsSince")).QueryRowContext(ctx, portfolioID, t.AssetID, t.Timestamp).Scan(&later)
+        if err != nil {
+            return fmt.Errorf("failed to check realized gains: %w", err)
+        }
+        if !later {
+            return nil
+        }
+    }
+
+    var asset models.Asset
+    err := tx.StmtContext(ctx, r.statement("lockAsset")).QueryRowContext(ctx, t.AssetID, portfolioID).Scan(
+        &asset.ID,
+        &asset.Type,
+        &asset.Symbol,
+        &asset.Amount,
+        &asset.CostBasis,
+    )
+    if errors.Is(err, sql.ErrNoRows) {
+        // Projections replayed from the ledger may reach transactions of
+        // assets removed since, whose gains are no longer listed
+        return nil
+    }
+    if err != nil {
+        return fmt.Errorf("failed to lock asset: %w", err)
+    }
+
+    txs, err := r.assetTransactions(ctx, tx, portfolioID, asset.ID)
+    if err != nil {
+        return err
+    }
+    opening, err := r.assetArchivedLots(ctx, tx, portfolioID, asset.ID)
+    if err != nil {
+        return err
+    }
+
+    replay := models.ReplayLots(opening, txs, r.feePolicy)
+    return r.restateRealizedGains(ctx, tx, portfolioID, asset, replay.Disposals, t.Timestamp)
+}
+
+// restateRealizedGains replaces the stored gains of an asset's sells made at
+// or after from with those of its disposals, within tx
+func (r *PostgresRepository) restateRealizedGains(ctx context.Context, tx *sql.Tx, portfolioID uuid.UUID, asset models.Asset, disposals []models.LotDisposal, from time.Time) error {
+    if _, err := tx.StmtContext(ctx, r.statement("deleteRealizedGainsSince")).ExecContext(ctx, portfolioID, asset.ID, from); err != nil {
+        return fmt.Errorf("failed to delete realized gains: %w", err)
+    }
+
+    for _, gain := range models.RealizedGainsSince(portfolioID, asset.ID, asset.Symbol, disposals, from, time.Now().UTC()) {
+        _, err := tx.StmtContext(ctx, r.statement("insertRealizedGain")).ExecContext(ctx,
+            gain.ID,
+            gain.PortfolioID,
+            gain.AssetID,
+            gain.TransactionID,
+            gain.Symbol,
+            gain.Quantity,
+            gain.CostBasis,
+            gain.Proceeds,
+            gain.Gain,
+            gain.DisposedAt,
+            gain.RecordedAt,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to insert realized gain: %w", err)
+        }
+    }
+    return nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+
+    "go.uber.org/zap"            // v1.24.0
+
+    "bookman/portfolio-service/internal/consistency"
+)
+
+// queryContext runs a read-only prepared query on the read replica when one is
+// configured and has replayed every write the caller must observe, and on the
+// primary otherwise
+func (r *PostgresRepository) queryContext(ctx context.Context, name string, args ...interface{}) (*sql.Rows, error) {
+    if replica := r.readReplica(ctx); replica != nil {
+        return replica.QueryContext(ctx, preparedStatements[name], args...)
+    }
+    return r.statement(name).QueryContext(ctx, args...)
+}
+
+// readReplica returns the replica if it may serve reads in ctx
+func (r *PostgresRepository) readReplica(ctx context.Context) *sql.DB {
+    if r.replica == nil {
+        return nil
+    }
+
+    required, ok := consistency.Requirement(ctx)
+    if !ok {
+        return r.replica
+    }
+
+    var text sql.NullString
+    if err := r.replica.QueryRowContext(ctx, "SELECT pg_last_wal_replay_lsn()::text").Scan(&text); err != nil {
+        r.logger.Warn("Failed to read replica replay position", zap.Error(err))
+        return nil
+    }
+    if !text.Valid {
+        return nil
+    }
+
+    replayed, err := consistency.ParseLSN(text.String)
+    if err != nil || replayed < required {
+        return nil
+    }
+    return r.replica
+}
+
+// recordWrite notes the primary's WAL position after a committed write so the
+// client receives a read-your-writes token
+func (r *PostgresRepository) recordWrite(ctx context.Context) {
+    if !consistency.Tracking(ctx) {
+        return
+    }
+
+    var text string
+    if err := r.db.QueryRowContext(ctx, "SELECT pg_current_wal_lsn()::text").Scan(&text); err != nil {
+        r.logger.Warn("Failed to read primary WAL position", zap.Error(err))
+        return
+    }
+
+    lsn, err := consistency.ParseLSN(text)
+    if err != nil {
+        r.logger.Warn("Failed to parse primary WAL position", zap.Error(err))
+        return
+    }
+    consistency.RecordWrite(ctx, lsn)
+}
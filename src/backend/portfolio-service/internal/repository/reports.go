@@ -0,0 +1,197 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// UpsertReportSchedule creates the report schedule of a portfolio or replaces
+// the one with the same frequency, clearing its last error
+func (r *PostgresRepository) UpsertReportSchedule(ctx context.Context, schedule *models.ReportSchedule) error {
+    if schedule == nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "upsertReportSchedule", func() error {
+        var lastRunAt sql.NullTime
+        err := r.statement("upsertReportSchedule").QueryRowContext(ctx,
+            schedule.ID,
+            schedule.PortfolioID,
+            schedule.UserID,
+            schedule.Frequency,
+            schedule.Format,
+            schedule.Timezone,
+            schedule.Hour,
+            schedule.Weekday,
+            schedule.DayOfMonth,
+            schedule.NextRunAt,
+            schedule.CreatedAt,
+        ).Scan(&schedule.ID, &lastRunAt, &schedule.CreatedAt)
+        if err != nil {
+            return fmt.Errorf("failed to upsert report schedule: %w", err)
+        }
+        schedule.LastError = ""
+        schedule.LastRunAt = nil
+        if lastRunAt.Valid {
+            schedule.LastRunAt = &lastRunAt.Time
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// ListReportSchedules returns the report schedules of a user
+func (r *PostgresRepository) ListReportSchedules(ctx context.Context, userID uuid.UUID) ([]models.ReportSchedule, error) {
+    var schedules []models.ReportSchedule
+
+    err := r.withStatementRecovery(ctx, "listReportSchedules", func() error {
+        rows, err := r.queryContext(ctx, "listReportSchedules", userID)
+        if err != nil {
+            return fmt.Errorf("failed to query report schedules: %w", err)
+        }
+        defer rows.Close()
+
+        schedules = schedules[:0]
+        for rows.Next() {
+            s, err := scanReportSchedule(rows)
+            if err != nil {
+                return fmt.Errorf("failed to scan report schedule: %w", err)
+            }
+            schedules = append(schedules, *s)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return schedules, nil
+}
+
+// DeleteReportSchedule removes a report schedule of a user
+func (r *PostgresRepository) DeleteReportSchedule(ctx context.Context, userID, scheduleID uuid.UUID) error {
+    err := r.withStatementRecovery(ctx, "deleteReportSchedule", func() error {
+        res, err := r.statement("deleteReportSchedule").ExecContext(ctx, userID, scheduleID)
+        if err != nil {
+            return fmt.Errorf("failed to delete report schedule: %w", err)
+        }
+        n, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        if n == 0 {
+            return ErrReportScheduleNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// ClaimDueReportSchedules leases up to limit schedules due at now until
+// leaseUntil, so concurrent workers do not deliver a report twice. The
+// returned schedules keep the delivery time they were due at in NextRunAt.
+func (r *PostgresRepository) ClaimDueReportSchedules(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.ReportSchedule, error) {
+    var schedules []models.ReportSchedule
+
+    err := r.withStatementRecovery(ctx, "claimDueReportSchedules", func() error {
+        rows, err := r.statement("claimDueReportSchedules").QueryContext(ctx, now, leaseUntil, limit)
+        if err != nil {
+            return fmt.Errorf("failed to claim report schedules: %w", err)
+        }
+        defer rows.Close()
+
+        schedules = schedules[:0]
+        for rows.Next() {
+            s, err := scanReportSchedule(rows)
+            if err != nil {
+                return fmt.Errorf("failed to scan report schedule: %w", err)
+            }
+            schedules = append(schedules, *s)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+    if len(schedules) > 0 {
+        r.recordWrite(ctx)
+    }
+
+    return schedules, nil
+}
+
+// CompleteReportRun records a delivered report and schedules the next one
+func (r *PostgresRepository) CompleteReportRun(ctx context.Context, scheduleID uuid.UUID, runAt, nextRunAt time.Time) error {
+    err := r.withStatementRecovery(ctx, "completeReportRun", func() error {
+        res, err := r.statement("completeReportRun").ExecContext(ctx, scheduleID, runAt, nextRunAt)
+        if err != nil {
+            return fmt.Errorf("failed to complete report run: %w", err)
+        }
+        n, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        if n == 0 {
+            return ErrReportScheduleNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// RecordReportScheduleError stores why a delivery failed and when to retry
+func (r *PostgresRepository) RecordReportScheduleError(ctx context.Context, scheduleID uuid.UUID, message string, nextRunAt time.Time) error {
+    err := r.withStatementRecovery(ctx, "recordReportScheduleError", func() error {
+        _, err := r.statement("recordReportScheduleError").ExecContext(ctx, scheduleID, message, nextRunAt)
+        if err != nil {
+            return fmt.Errorf("failed to record report schedule error: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// scanReportSchedule reads a report schedule row
+func scanReportSchedule(row rowScanner) (*models.ReportSchedule, error) {
+    var (
+        s         models.ReportSchedule
+        lastRunAt sql.NullTime
+    )
+    err := row.Scan(
+        &s.ID, &s.PortfolioID, &s.UserID, &s.Frequency, &s.Format, &s.Timezone, &s.Hour, &s.Weekday,
+        &s.DayOfMonth, &s.NextRunAt, &lastRunAt, &s.LastError, &s.CreatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    if lastRunAt.Valid {
+        s.LastRunAt = &lastRunAt.Time
+    }
+    return &s, nil
+}
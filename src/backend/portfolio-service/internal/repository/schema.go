@@ -0,0 +1,40 @@
+package repository
+
+import (
+    "context"
+    "errors"
+    "fmt"
+)
+
+// SchemaVersion is the latest schema migration this build depends on. The
+// deploy pipeline, or the service itself when startup migrations are
+// enabled, applies migrations, each of which records itself in
+// schema_migrations; bump it with every migration the service relies on.
+const SchemaVersion = 46
+
+// ErrSchemaOutdated reports a database schema older than SchemaVersion
+var ErrSchemaOutdated = errors.New("database schema is outdated")
+
+// CheckSchemaVersion returns an error wrapping ErrSchemaOutdated unless the
+// migrations up to SchemaVersion were applied to the database
+func (r *PostgresRepository) CheckSchemaVersion(ctx context.Context) error {
+    var version int
+
+    err := r.withStatementRecovery(ctx, "getSchemaVersion", func() error {
+        return r.statement("getSchemaVersion").QueryRowContext(ctx).Scan(&version)
+    })
+    if err != nil {
+        return fmt.Errorf("failed to read schema version: %w", err)
+    }
+
+    return ValidateSchemaVersion(version)
+}
+
+// ValidateSchemaVersion returns an error wrapping ErrSchemaOutdated when
+// version, the latest applied migration, is older than SchemaVersion
+func ValidateSchemaVersion(version int) error {
+    if version < SchemaVersion {
+        return fmt.Errorf("%w: migration %d applied, %d required", ErrSchemaOutdated, version, SchemaVersion)
+    }
+    return nil
+}
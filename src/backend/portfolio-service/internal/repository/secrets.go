@@ -0,0 +1,124 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// ListSealedExchangeCredentials returns up to limit exchange accounts ordered
+// by ID after the given one, with only their identity and sealed credentials
+// set. It reads the primary so key rotation sees the latest credentials.
+func (r *PostgresRepository) ListSealedExchangeCredentials(ctx context.Context, after uuid.UUID, limit int) ([]models.ExchangeAccount, error) {
+    var accounts []models.ExchangeAccount
+
+    err := r.withStatementRecovery(ctx, "listSealedExchangeCredentials", func() error {
+        rows, err := r.statement("listSealedExchangeCredentials").QueryContext(ctx, after, limit)
+        if err != nil {
+            return fmt.Errorf("failed to list exchange credentials: %w", err)
+        }
+        defer rows.Close()
+
+        accounts = accounts[:0]
+        for rows.Next() {
+            var a models.ExchangeAccount
+            if err := rows.Scan(&a.ID, &a.PortfolioID, &a.Exchange, &a.Credentials); err != nil {
+                return fmt.Errorf("failed to scan exchange credentials: %w", err)
+            }
+            accounts = append(accounts, a)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return accounts, nil
+}
+
+// ReplaceExchangeCredentials swaps the sealed credentials of an account if
+// they are still old, reporting whether they were replaced. Credentials
+// changed by a reconnect in the meantime are left alone.
+func (r *PostgresRepository) ReplaceExchangeCredentials(ctx context.Context, accountID uuid.UUID, old, sealed []byte) (bool, error) {
+    var replaced bool
+
+    err := r.withStatementRecovery(ctx, "replaceExchangeCredentials", func() error {
+        res, err := r.statement("replaceExchangeCredentials").ExecContext(ctx, accountID, old, sealed)
+        if err != nil {
+            return fmt.Errorf("failed to replace exchange credentials: %w", err)
+        }
+        n, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        replaced = n > 0
+        return nil
+    })
+    if err != nil {
+        return false, err
+    }
+    if replaced {
+        r.recordWrite(ctx)
+    }
+
+    return replaced, nil
+}
+
+// ListWebhookSecrets returns up to limit webhook endpoints ordered by ID after
+// the given one, with only their ID and stored secret set
+func (r *PostgresRepository) ListWebhookSecrets(ctx context.Context, after uuid.UUID, limit int) ([]models.WebhookEndpoint, error) {
+    var endpoints []models.WebhookEndpoint
+
+    err := r.withStatementRecovery(ctx, "listWebhookSecrets", func() error {
+        rows, err := r.statement("listWebhookSecrets").QueryContext(ctx, after, limit)
+        if err != nil {
+            return fmt.Errorf("failed to list webhook secrets: %w", err)
+        }
+        defer rows.Close()
+
+        endpoints = endpoints[:0]
+        for rows.Next() {
+            var e models.WebhookEndpoint
+            if err := rows.Scan(&e.ID, &e.Secret); err != nil {
+                return fmt.Errorf("failed to scan webhook secret: %w", err)
+            }
+            endpoints = append(endpoints, e)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return endpoints, nil
+}
+
+// ReplaceWebhookSecret swaps the stored secret of an endpoint if it is still
+// old, reporting whether it was replaced
+func (r *PostgresRepository) ReplaceWebhookSecret(ctx context.Context, webhookID uuid.UUID, old, secret string) (bool, error) {
+    var replaced bool
+
+    err := r.withStatementRecovery(ctx, "replaceWebhookSecret", func() error {
+        res, err := r.statement("replaceWebhookSecret").ExecContext(ctx, webhookID, old, secret)
+        if err != nil {
+            return fmt.Errorf("failed to replace webhook secret: %w", err)
+        }
+        n, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        replaced = n > 0
+        return nil
+    })
+    if err != nil {
+        return false, err
+    }
+    if replaced {
+        r.recordWrite(ctx)
+    }
+
+    return replaced, nil
+}
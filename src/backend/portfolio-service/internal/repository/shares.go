@@ -0,0 +1,124 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// CreatePortfolioShare stores a share of an active portfolio
+func (r *PostgresRepository) CreatePortfolioShare(ctx context.Context, share *models.PortfolioShare) error {
+    if share == nil || share.PortfolioID == uuid.Nil || len(share.TokenHash) == 0 {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "createPortfolioShare", func() error {
+        result, err := r.statement("createPortfolioShare").ExecContext(ctx,
+            share.ID,
+            share.PortfolioID,
+            share.TokenHash,
+            share.CreatedAt,
+            share.ExpiresAt,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to create portfolio share: %w", err)
+        }
+        if n, err := result.RowsAffected(); err == nil && n == 0 {
+            return ErrPortfolioNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// GetActivePortfolioShare returns the share stored under a token hash that
+// is neither revoked nor expired at the given time and whose portfolio is
+// active
+func (r *PostgresRepository) GetActivePortfolioShare(ctx context.Context, tokenHash []byte, at time.Time) (*models.PortfolioShare, error) {
+    share := &models.PortfolioShare{}
+
+    err := r.withStatementRecovery(ctx, "getActivePortfolioShare", func() error {
+        err := r.statement("getActivePortfolioShare").QueryRowContext(ctx, tokenHash, at).Scan(
+            &share.ID,
+            &share.PortfolioID,
+            &share.CreatedAt,
+            &share.ExpiresAt,
+        )
+        if errors.Is(err, sql.ErrNoRows) {
+            return ErrShareNotFound
+        }
+        if err != nil {
+            return fmt.Errorf("failed to get portfolio share: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return share, nil
+}
+
+// ListPortfolioShares returns all shares of a portfolio, revoked and expired
+// ones included, oldest first
+func (r *PostgresRepository) ListPortfolioShares(ctx context.Context, portfolioID uuid.UUID) ([]models.PortfolioShare, error) {
+    var shares []models.PortfolioShare
+
+    err := r.withStatementRecovery(ctx, "listPortfolioShares", func() error {
+        rows, err := r.queryContext(ctx, "listPortfolioShares", portfolioID)
+        if err != nil {
+            return fmt.Errorf("failed to query portfolio shares: %w", err)
+        }
+        defer rows.Close()
+
+        shares = shares[:0]
+        for rows.Next() {
+            var revokedAt sql.NullTime
+            share := models.PortfolioShare{PortfolioID: portfolioID}
+            if err := rows.Scan(&share.ID, &share.CreatedAt, &share.ExpiresAt, &revokedAt); err != nil {
+                return fmt.Errorf("failed to scan portfolio share: %w", err)
+            }
+            if revokedAt.Valid {
+                share.RevokedAt = &revokedAt.Time
+            }
+            shares = append(shares, share)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return shares, nil
+}
+
+// RevokePortfolioShare revokes an unrevoked share of a portfolio as of at
+func (r *PostgresRepository) RevokePortfolioShare(ctx context.Context, portfolioID, shareID uuid.UUID, at time.Time) error {
+    err := r.withStatementRecovery(ctx, "revokePortfolioShare", func() error {
+        result, err := r.statement("revokePortfolioShare").ExecContext(ctx, portfolioID, shareID, at)
+        if err != nil {
+            return fmt.Errorf("failed to revoke portfolio share: %w", err)
+        }
+        if n, err := result.RowsAffected(); err == nil && n == 0 {
+            return ErrShareNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// ListPortfolioIDs returns up to limit active portfolio IDs ordered after the given ID,
+// allowing callers to page through all portfolios with keyset pagination
+func (r *PostgresRepository) ListPortfolioIDs(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+    var ids []uuid.UUID
+
+    err := r.withStatementRecovery(ctx, "listPortfolioIDs", func() error {
+        rows, err := r.statement("listPortfolioIDs").QueryContext(ctx, after, limit)
+        if err != nil {
+            return fmt.Errorf("failed to query portfolio ids: %w", err)
+        }
+        defer rows.Close()
+
+        ids = ids[:0]
+        for rows.Next() {
+            var id uuid.UUID
+            if err := rows.Scan(&id); err != nil {
+                return fmt.Errorf("failed to scan portfolio id: %w", err)
+            }
+            ids = append(ids, id)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return ids, nil
+}
+
+// CreateSnapshot stores a valuation snapshot. Snapshots are unique per portfolio
+// and capture time, so concurrent writers for the same slot are ignored.
+func (r *PostgresRepository) CreateSnapshot(ctx context.Context, snapshot *models.PortfolioSnapshot) error {
+    if snapshot == nil {
+        return ErrInvalidPortfolio
+    }
+
+    assets, err := json.Marshal(snapshot.Assets)
+    if err != nil {
+        return fmt.Errorf("failed to encode snapshot assets: %w", err)
+    }
+
+    return r.withStatementRecovery(ctx, "createSnapshot", func() error {
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        res, err := tx.StmtContext(ctx, r.statement("createSnapshot")).ExecContext(ctx,
+            snapshot.ID,
+            snapshot.PortfolioID,
+            snapshot.TotalValue,
+            snapshot.ProfitLoss,
+            assets,
+            snapshot.CapturedAt,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to create snapshot: %w", err)
+        }
+
+        // Maintain materialized change columns only for newly written snapshots
+        if n, err := res.RowsAffected(); err == nil && n > 0 {
+            _, err = tx.StmtContext(ctx, r.statement("refreshPortfolioChanges")).ExecContext(ctx,
+                snapshot.PortfolioID,
+                snapshot.CapturedAt,
+            )
+            if err != nil {
+                return fmt.Errorf("failed to refresh portfolio changes: %w", err)
+            }
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        return nil
+    })
+}
+
+// RefreshPortfolioChanges recomputes the materialized change columns of a
+// portfolio from snapshots captured up to asOf
+func (r *PostgresRepository) RefreshPortfolioChanges(ctx context.Context, portfolioID uuid.UUID, asOf time.Time) error {
+    return r.withStatementRecovery(ctx, "refreshPortfolioChanges", func() error {
+        _, err := r.statement("refreshPortfolioChanges").ExecContext(ctx, portfolioID, asOf)
+        if err != nil {
+            return fmt.Errorf("failed to refresh portfolio changes: %w", err)
+        }
+        return nil
+    })
+}
+
+// GetValueHistory returns the closing portfolio value of each interval bucket
+// within [start, end). The database reduces snapshots to daily closes, which
+// are then downsampled to the interval.
+func (r *PostgresRepository) GetValueHistory(ctx context.Context, portfolioID uuid.UUID, interval models.HistoryInterval, start, end time.Time) ([]models.ValuePoint, error) {
+    var points []models.ValuePoint
+
+    err := r.withStatementRecovery(ctx, "getValueHistory", func() error {
+        rows, err := r.queryContext(ctx, "getValueHistory", portfolioID, start, end)
+        if err != nil {
+            return fmt.Errorf("failed to query value history: %w", err)
+        }
+        defer rows.Close()
+
+        points = points[:0]
+        for rows.Next() {
+            var p models.ValuePoint
+            if err := rows.Scan(&p.Timestamp, &p.Value, &p.ProfitLoss); err != nil {
+                return fmt.Errorf("failed to scan value point: %w", err)
+            }
+            p.Timestamp = p.Timestamp.UTC()
+            points = append(points, p)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return models.DownsampleValues(points, interval), nil
+}
+
+// GetSnapshotAt returns the latest snapshot, including its holdings, captured
+// at or before the given time, or nil if there is none
+func (r *PostgresRepository) GetSnapshotAt(ctx context.Context, portfolioID uuid.UUID, at time.Time) (*models.PortfolioSnapshot, error) {
+    var snapshot *models.PortfolioSnapshot
+
+    err := r.withStatementRecovery(ctx, "getSnapshotAt", func() error {
+        s := models.PortfolioSnapshot{PortfolioID: portfolioID}
+        var assets []byte
+        err := r.statement("getSnapshotAt").QueryRowContext(ctx, portfolioID, at).Scan(
+            &s.ID,
+            &s.TotalValue,
+            &s.ProfitLoss,
+            &assets,
+            &s.CapturedAt,
+        )
+        if errors.Is(err, sql.ErrNoRows) {
+            snapshot = nil
+            return nil
+        }
+        if err != nil {
+            return fmt.Errorf("failed to get snapshot: %w", err)
+        }
+        if err := json.Unmarshal(assets, &s.Assets); err != nil {
+            return fmt.Errorf("failed to decode snapshot assets: %w", err)
+        }
+        s.CapturedAt = s.CapturedAt.UTC()
+        snapshot = &s
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return snapshot, nil
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/lib/pq"           // v1.10.9
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// CreateStakingPosition stores a staking position, failing with
+// ErrStakingPositionExists when its asset already has one
+func (r *PostgresRepository) CreateStakingPosition(ctx context.Context, position *models.StakingPosition) error {
+    if position == nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "insertStakingPosition", func() error {
+        _, err := r.statement("insertStakingPosition").ExecContext(ctx,
+            position.ID,
+            position.PortfolioID,
+            position.AssetID,
+            position.Source,
+            position.Provider,
+            position.Address,
+            position.NextAccrualAt,
+            position.CreatedAt,
+        )
+        var pqErr *pq.Error
+        if errors.As(err, &pqErr) && pqErr.Code == pgCodeUniqueViolation {
+            return ErrStakingPositionExists
+        }
+        if err != nil {
+            return fmt.Errorf("failed to insert staking position: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// ListStakingPositions returns the staking positions of a portfolio
+func (r *PostgresRepository) ListStakingPositions(ctx context.Context, portfolioID uuid.UUID) ([]models.StakingPosition, error) {
+    var positions []models.StakingPosition
+
+    err := r.withStatementRecovery(ctx, "listStakingPositions", func() error {
+        rows, err := r.queryContext(ctx, "listStakingPositions", portfolioID)
+        if err != nil {
+            return fmt.Errorf("failed to query staking positions: %w", err)
+        }
+        defer rows.Close()
+
+        positions = positions[:0]
+        for rows.Next() {
+            p, err := scanStakingPosition(rows)
+            if err != nil {
+                return fmt.Errorf("failed to scan staking position: %w", err)
+            }
+            positions = append(positions, *p)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return positions, nil
+}
+
+// DeleteStakingPosition stops accruing the rewards of a position; recorded
+// rewards are kept
+func (r *PostgresRepository) DeleteStakingPosition(ctx context.Context, portfolioID, positionID uuid.UUID) error {
+    err := r.withStatementRecovery(ctx, "deleteStakingPosition", func() error {
+        res, err := r.statement("deleteStakingPosition").ExecContext(ctx, portfolioID, positionID)
+        if err != nil {
+            return fmt.Errorf("failed to delete staking position: %w", err)
+        }
+        n, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        if n == 0 {
+            return ErrStakingPositionNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// ClaimDueStakingPositions leases up to limit chain positions due for
+// accrual at now until leaseUntil, so concurrent workers do not accrue them
+// twice
+func (r *PostgresRepository) ClaimDueStakingPositions(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.StakingPosition, error) {
+    var positions []models.StakingPosition
+
+    err := r.withStatementRecovery(ctx, "claimDueStakingPositions", func() error {
+        rows, err := r.statement("claimDueStakingPositions").QueryContext(ctx, now, leaseUntil, limit)
+        if err != nil {
+            return fmt.Errorf("failed to claim staking positions: %w", err)
+        }
+        defer rows.Close()
+
+        positions = positions[:0]
+        for rows.Next() {
+            p, err := scanStakingPosition(rows)
+            if err != nil {
+                return fmt.Errorf("failed to scan staking position: %w", err)
+            }
+            positions = append(positions, *p)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+    if len(positions) > 0 {
+        r.recordWrite(ctx)
+    }
+
+    return positions, nil
+}
+
+// SaveStakingCheckpoint stores the cursor reached by a successful accrual
+// and schedules the next one
+func (r *PostgresRepository) SaveStakingCheckpoint(ctx context.Context, positionID uuid.UUID, cursor string, accruedAt, nextAccrualAt time.Time) error {
+    err := r.withStatementRecovery(ctx, "saveStakingCheckpoint", func() error {
+        res, err := r.statement("saveStakingCheckpoint").ExecContext(ctx, positionID, cursor, accruedAt, nextAccrualAt)
+        if err != nil {
+            return fmt.Errorf("failed to save staking checkpoint: %w", err)
+        }
+        n, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        if n == 0 {
+            return ErrStakingPositionNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// RecordStakingError stores why an accrual failed and when to retry,
+// keeping the last checkpoint
+func (r *PostgresRepository) RecordStakingError(ctx context.Context, positionID uuid.UUID, message string, nextAccrualAt time.Time) error {
+    err := r.withStatementRecovery(ctx, "recordStakingError", func() error {
+        _, err := r.statement("recordStakingError").ExecContext(ctx, positionID, message, nextAccrualAt)
+        if err != nil {
+            return fmt.Errorf("failed to record staking error: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// scanStakingPosition reads a staking position row
+func scanStakingPosition(row rowScanner) (*models.StakingPosition, error) {
+    var (
+        p             models.StakingPosition
+        lastAccruedAt sql.NullTime
+    )
+    err := row.Scan(
+        &p.ID, &p.PortfolioID, &p.AssetID, &p.Source, &p.Provider, &p.Address, &p.Cursor, &lastAccruedAt,
+        &p.LastError, &p.NextAccrualAt, &p.CreatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    if lastAccruedAt.Valid {
+        p.LastAccruedAt = &lastAccruedAt.Time
+    }
+    return &p, nil
+}
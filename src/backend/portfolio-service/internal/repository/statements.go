@@ -0,0 +1,94 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "sync"
+)
+
+// StatementCache holds the prepared statements of a repository. Copies of a
+// repository share one cache, so a re-prepare through any of them is seen by
+// all under the same lock and none keeps using statements another closed.
+type StatementCache struct {
+    db      *sql.DB
+    queries map[string]string
+    stmts   map[string]*sql.Stmt
+    mutex   sync.RWMutex
+    gen     uint64 // incremented each time the statements are prepared
+}
+
+// NewStatementCache creates a cache preparing queries, keyed by statement
+// name, on db. Statements are prepared by Prepare.
+func NewStatementCache(db *sql.DB, queries map[string]string) *StatementCache {
+    return &StatementCache{
+        db:      db,
+        queries: queries,
+        stmts:   make(map[string]*sql.Stmt, len(queries)),
+    }
+}
+
+// Prepare prepares all statements, replacing and closing the previous ones
+func (c *StatementCache) Prepare(ctx context.Context) error {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+    return c.prepareLocked(ctx)
+}
+
+// Reprepare prepares all statements again unless another caller already did
+// since they were at generation, so operations failing together on the same
+// invalidation re-prepare once
+func (c *StatementCache) Reprepare(ctx context.Context, generation uint64) error {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    if c.gen != generation {
+        return nil
+    }
+    return c.prepareLocked(ctx)
+}
+
+// prepareLocked prepares all statements. The caller must hold mutex.
+func (c *StatementCache) prepareLocked(ctx context.Context) error {
+    for name, query := range c.queries {
+        stmt, err := c.db.PrepareContext(ctx, query)
+        if err != nil {
+            return fmt.Errorf("failed to prepare statement %s: %w", name, err)
+        }
+        if old, ok := c.stmts[name]; ok {
+            old.Close()
+        }
+        c.stmts[name] = stmt
+    }
+    c.gen++
+    return nil
+}
+
+// Generation returns the generation of the prepared statements
+func (c *StatementCache) Generation() uint64 {
+    c.mutex.RLock()
+    defer c.mutex.RUnlock()
+    return c.gen
+}
+
+// Get returns the prepared statement registered under name
+func (c *StatementCache) Get(name string) *sql.Stmt {
+    c.mutex.RLock()
+    defer c.mutex.RUnlock()
+    return c.stmts[name]
+}
+
+// Close closes all prepared statements, returning the first failure
+func (c *StatementCache) Close() error {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    var firstErr error
+    for name, stmt := range c.stmts {
+        if err := stmt.Close(); err != nil && firstErr == nil {
+            firstErr = fmt.Errorf("failed to close statement %s: %w", name, err)
+        }
+        delete(c.stmts, name)
+    }
+    return firstErr
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// ListUserHoldings returns the holdings of all active portfolios of a user,
+// valued at the latest stored prices, with their dust settings, in a single
+// query. Portfolios without holdings are included.
+func (r *PostgresRepository) ListUserHoldings(ctx context.Context, userID uuid.UUID) ([]models.PortfolioHoldings, error) {
+    var holdings []models.PortfolioHoldings
+
+    err := r.withStatementRecovery(ctx, "listUserHoldings", func() error {
+        rows, err := r.queryContext(ctx, "listUserHoldings", userID)
+        if err != nil {
+            return fmt.Errorf("failed to query user holdings: %w", err)
+        }
+        defer rows.Close()
+
+        holdings = holdings[:0]
+        for rows.Next() {
+            var (
+                portfolioID uuid.UUID
+                name        string
+                dust        models.DustSettings
+                assetID     uuid.NullUUID
+                assetType   sql.NullString
+                symbol      sql.NullString
+                amount      decimal.NullDecimal
+                costBasis   decimal.NullDecimal
+                value       decimal.NullDecimal
+                lastUpdated sql.NullTime
+            )
+            if err := rows.Scan(
+                &portfolioID,
+                &name,
+                &dust.Threshold,
+                &dust.Hidden,
+                &assetID,
+                &assetType,
+                &symbol,
+                &amount,
+                &costBasis,
+                &value,
+                &lastUpdated,
+            ); err != nil {
+                return fmt.Errorf("failed to scan user holding: %w", err)
+            }
+
+            // Rows are ordered by portfolio, so a new ID starts its holdings
+            if len(holdings) == 0 || holdings[len(holdings)-1].PortfolioID != portfolioID {
+                dust.PortfolioID = portfolioID
+                holdings = append(holdings, models.PortfolioHoldings{
+                    PortfolioID: portfolioID,
+                    Name:        name,
+                    Dust:        dust,
+                })
+            }
+            if !assetID.Valid {
+                continue
+            }
+
+            h := &holdings[len(holdings)-1]
+            h.Assets = append(h.Assets, models.Asset{
+                ID:           assetID.UUID,
+                Type:         assetType.String,
+                Symbol:       symbol.String,
+                Amount:       amount.Decimal,
+                CostBasis:    costBasis.Decimal,
+                CurrentValue: value.Decimal,
+                LastUpdated:  lastUpdated.Time,
+            })
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return holdings, nil
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// EnsureSymbolMigration stores a symbol migration unless its symbol was
+// already mapped, and returns the stored migration of the symbol
+func (r *PostgresRepository) EnsureSymbolMigration(ctx context.Context, m *models.SymbolMigration) (*models.SymbolMigration, error) {
+    if m == nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    var stored models.SymbolMigration
+    err := r.withStatementRecovery(ctx, "insertSymbolMigration", func() error {
+        _, err := r.statement("insertSymbolMigration").ExecContext(ctx,
+            m.ID,
+            m.FromSymbol,
+            m.ToSymbol,
+            m.Ratio,
+            m.EffectiveAt,
+            m.CreatedAt,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to insert symbol migration: %w", err)
+        }
+
+        var appliedAt sql.NullTime
+        err = r.statement("getSymbolMigration").QueryRowContext(ctx, m.FromSymbol).Scan(
+            &stored.ID, &stored.FromSymbol, &stored.ToSymbol, &stored.Ratio, &stored.EffectiveAt,
+            &stored.AssetsMigrated, &appliedAt, &stored.CreatedAt,
+        )
+        if errors.Is(err, sql.ErrNoRows) {
+            return ErrSymbolMigrationNotFound
+        }
+        if err != nil {
+            return fmt.Errorf("failed to get symbol migration: %w", err)
+        }
+        if appliedAt.Valid {
+            stored.AppliedAt = &appliedAt.Time
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    r.recordWrite(ctx)
+
+    return &stored, nil
+}
+
+// ListSymbolHoldings returns up to limit holdings of symbol with asset IDs
+// after after, in ID order, as audit records with the portfolio, asset and
+// amount filled in
+func (r *PostgresRepository) ListSymbolHoldings(ctx context.Context, symbol string, after uuid.UUID, limit int) ([]models.SymbolMigrationAudit, error) {
+    var holdings []models.SymbolMigrationAudit
+
+    err := r.withStatementRecovery(ctx, "listSymbolHoldings", func() error {
+        rows, err := r.statement("listSymbolHoldings").QueryContext(ctx, symbol, after, limit)
+        if err != nil {
+            return fmt.Errorf("failed to list symbol holdings: %w", err)
+        }
+        defer rows.Close()
+
+        holdings = holdings[:0]
+        for rows.Next() {
+            h := models.SymbolMigrationAudit{FromSymbol: symbol}
+            if err := rows.Scan(&h.AssetID, &h.PortfolioID, &h.AmountBefore); err != nil {
+                return fmt.Errorf("failed to scan symbol holding: %w", err)
+            }
+            holdings = append(holdings, h)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return holdings, nil
+}
+
+// HasArchivedLots reports whether part of an asset's history was moved to
+// the cold archive
+func (r *PostgresRepository) HasArchivedLots(ctx context.Context, assetID uuid.UUID) (bool, error) {
+    var archived bool
+
+    err := r.withStatementRecovery(ctx, "hasArchivedLots", func() error {
+        if err := r.statement("hasArchivedLots").QueryRowContext(ctx, assetID).Scan(&archived); err != nil {
+            return fmt.Errorf("failed to check archived lots: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return false, err
+    }
+
+    return archived, nil
+}
+
+// MigrateAsset moves a holding of a portfolio to the new symbol of a
+// migration in a single transaction, re-linking its transactions and
+// archived lots and recording the audit trail. It returns nil when the
+// asset is no longer held under the old symbol.
+func (r *PostgresRepository) MigrateAsset(ctx context.Context, portfolioID uuid.UUID, migration models.LedgerAssetMigration) (*models.SymbolMigrationAudit, error) {
+    var audit *models.SymbolMigrationAudit
+
+    err := r.withStatementRecovery(ctx, "migrateAssetSymbol", func() error {
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        audit, err = r.migrateAsset(ctx, tx, portfolioID, migration, time.Now().UTC())
+        if err != nil {
+            return err
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    if audit != nil {
+        r.recordWrite(ctx)
+    }
+
+    return audit, nil
+}
+
+// CompleteSymbolMigration adds migrated to the holdings moved by a migration
+// and marks it applied at
+func (r *PostgresRepository) CompleteSymbolMigration(ctx context.Context, migrationID uuid.UUID, migrated int, at time.Time) error {
+    err := r.withStatementRecovery(ctx, "completeSymbolMigration", func() error {
+        result, err := r.statement("completeSymbolMigration").ExecContext(ctx, migrationID, migrated, at)
+        if err != nil {
+            return fmt.Errorf("failed to complete symbol migration: %w", err)
+        }
+        if n, err := result.RowsAffected(); err == nil && n == 0 {
+            return ErrSymbolMigrationNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// migrateAsset renames and restates a holding within tx. Transactions and
+// lots are only restated the first time, as replayed ledger projections
+// keep the transactions already restated but restore the holding's original
+// amount.
+func (r *PostgresRepository) migrateAsset(ctx context.Context, tx *sql.Tx, portfolioID uuid.UUID, m models.LedgerAssetMigration, at time.Time) (*models.SymbolMigrationAudit, error) {
+    var amount decimal.Decimal
+    err := tx.StmtContext(ctx, r.statement("migrateAssetSymbol")).QueryRowContext(ctx,
+        m.AssetID,
+        portfolioID,
+        m.FromSymbol,
+        m.ToSymbol,
+        m.Ratio,
+        at,
+    ).Scan(&amount)
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to migrate asset symbol: %w", err)
+    }
+
+    audit := &models.SymbolMigrationAudit{
+        MigrationID:  m.MigrationID,
+        PortfolioID:  portfolioID,
+        AssetID:      m.AssetID,
+        FromSymbol:   m.FromSymbol,
+        ToSymbol:     m.ToSymbol,
+        Ratio:        m.Ratio,
+        AmountBefore: amount.Div(m.Ratio),
+        AmountAfter:  amount,
+        MigratedAt:   at,
+    }
+
+    var audited bool
+    if err := tx.StmtContext(ctx, r.statement("symbolMigrationAudited")).QueryRowContext(ctx, m.MigrationID, m.AssetID).Scan(&audited); err != nil {
+        return nil, fmt.Errorf("failed to check symbol migration audit: %w", err)
+    }
+    if audited {
+        return audit, nil
+    }
+
+    result, err := tx.StmtContext(ctx, r.statement("migrateAssetTransactions")).ExecContext(ctx, m.AssetID, portfolioID, m.Ratio)
+    if err != nil {
+        return nil, fmt.Errorf("failed to re-link transactions: %w", err)
+    }
+    if n, err := result.RowsAffected(); err == nil {
+        audit.TransactionsRelinked = int(n)
+    }
+
+    result, err = tx.StmtContext(ctx, r.statement("migrateAssetLots")).ExecContext(ctx, m.AssetID, portfolioID, m.Ratio)
+    if err != nil {
+        return nil, fmt.Errorf("failed to re-link archived lots: %w", err)
+    }
+    if n, err := result.RowsAffected(); err == nil {
+        audit.LotsRelinked = int(n)
+    }
+
+    _, err = tx.StmtContext(ctx, r.statement("insertSymbolMigrationAudit")).ExecContext(ctx,
+        audit.MigrationID,
+        audit.PortfolioID,
+        audit.AssetID,
+        audit.FromSymbol,
+        audit.ToSymbol,
+        audit.Ratio,
+        audit.AmountBefore,
+        audit.AmountAfter,
+        audit.TransactionsRelinked,
+        audit.LotsRelinked,
+        audit.MigratedAt,
+    )
+    if err != nil {
+        return nil, fmt.Errorf("failed to record symbol migration audit: %w", err)
+    }
+
+    return audit, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/lib/pq"                // v1.10.9
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// SetAssetTags replaces the tags of an asset. An empty set removes all tags.
+func (r *PostgresRepository) SetAssetTags(ctx context.Context, tags *models.AssetTags) error {
+    if tags == nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "insertAssetTags", func() error {
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        if _, err := tx.StmtContext(ctx, r.statement("deleteAssetTags")).ExecContext(ctx, tags.AssetID, tags.PortfolioID); err != nil {
+            return fmt.Errorf("failed to delete asset tags: %w", err)
+        }
+
+        if len(tags.Tags) > 0 {
+            _, err = tx.StmtContext(ctx, r.statement("insertAssetTags")).ExecContext(ctx,
+                tags.AssetID,
+                tags.PortfolioID,
+                pq.Array(tags.Tags),
+                time.Now().UTC(),
+            )
+            if err != nil {
+                return fmt.Errorf("failed to insert asset tags: %w", err)
+            }
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// GetAssetTags returns the tags of a portfolio's assets keyed by asset ID.
+// Assets without tags are absent.
+func (r *PostgresRepository) GetAssetTags(ctx context.Context, portfolioID uuid.UUID) (map[uuid.UUID][]string, error) {
+    var tags map[uuid.UUID][]string
+
+    err := r.withStatementRecovery(ctx, "getAssetTags", func() error {
+        rows, err := r.queryContext(ctx, "getAssetTags", portfolioID)
+        if err != nil {
+            return fmt.Errorf("failed to query asset tags: %w", err)
+        }
+        defer rows.Close()
+
+        tags = make(map[uuid.UUID][]string)
+        for rows.Next() {
+            var (
+                assetID uuid.UUID
+                tag     string
+            )
+            if err := rows.Scan(&assetID, &tag); err != nil {
+                return fmt.Errorf("failed to scan asset tag: %w", err)
+            }
+            tags[assetID] = append(tags[assetID], tag)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return tags, nil
+}
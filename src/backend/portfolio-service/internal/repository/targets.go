@@ -0,0 +1,122 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/lib/pq"      // v1.10.9
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// SetAllocationTargets replaces the allocation targets of a portfolio.
+// An empty set removes all targets.
+func (r *PostgresRepository) SetAllocationTargets(ctx context.Context, portfolioID uuid.UUID, targets []models.AllocationTarget) error {
+    dimensions := make([]string, len(targets))
+    keys := make([]string, len(targets))
+    percentages := make([]string, len(targets))
+    for i, target := range targets {
+        dimensions[i] = string(target.Dimension)
+        keys[i] = target.Key
+        percentages[i] = target.Percentage.String()
+    }
+
+    err := r.withStatementRecovery(ctx, "insertAllocationTargets", func() error {
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        if _, err := tx.StmtContext(ctx, r.statement("deleteAllocationTargets")).ExecContext(ctx, portfolioID); err != nil {
+            return fmt.Errorf("failed to delete allocation targets: %w", err)
+        }
+
+        if len(targets) > 0 {
+            _, err = tx.StmtContext(ctx, r.statement("insertAllocationTargets")).ExecContext(ctx,
+                portfolioID,
+                pq.Array(dimensions),
+                pq.Array(keys),
+                pq.Array(percentages),
+                time.Now().UTC(),
+            )
+            if err != nil {
+                return fmt.Errorf("failed to insert allocation targets: %w", err)
+            }
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// GetAllocationTargets returns the allocation targets of a portfolio
+func (r *PostgresRepository) GetAllocationTargets(ctx context.Context, portfolioID uuid.UUID) ([]models.AllocationTarget, error) {
+    var targets []models.AllocationTarget
+
+    err := r.withStatementRecovery(ctx, "getAllocationTargets", func() error {
+        rows, err := r.queryContext(ctx, "getAllocationTargets", portfolioID)
+        if err != nil {
+            return fmt.Errorf("failed to query allocation targets: %w", err)
+        }
+        defer rows.Close()
+
+        targets = targets[:0]
+        for rows.Next() {
+            var (
+                dimension string
+                target    models.AllocationTarget
+            )
+            if err := rows.Scan(&dimension, &target.Key, &target.Percentage); err != nil {
+                return fmt.Errorf("failed to scan allocation target: %w", err)
+            }
+            target.Dimension = models.TargetDimension(dimension)
+            targets = append(targets, target)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return targets, nil
+}
+
+// ListPortfoliosWithTargets returns up to limit IDs of portfolios that have
+// allocation targets, ordered after the given ID
+func (r *PostgresRepository) ListPortfoliosWithTargets(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+    var ids []uuid.UUID
+
+    err := r.withStatementRecovery(ctx, "listPortfoliosWithTargets", func() error {
+        rows, err := r.statement("listPortfoliosWithTargets").QueryContext(ctx, after, limit)
+        if err != nil {
+            return fmt.Errorf("failed to query portfolios with targets: %w", err)
+        }
+        defer rows.Close()
+
+        ids = ids[:0]
+        for rows.Next() {
+            var id uuid.UUID
+            if err := rows.Scan(&id); err != nil {
+                return fmt.Errorf("failed to scan portfolio id: %w", err)
+            }
+            ids = append(ids, id)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return ids, nil
+}
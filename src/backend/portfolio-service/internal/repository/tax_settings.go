@@ -0,0 +1,78 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// GetTaxSettings returns the tax settings of a user, or nil when they have
+// stored none
+func (r *PostgresRepository) GetTaxSettings(ctx context.Context, userID uuid.UUID) (*models.TaxSettings, error) {
+    var settings *models.TaxSettings
+
+    err := r.withStatementRecovery(ctx, "getTaxSettings", func() error {
+        s := models.TaxSettings{UserID: userID}
+        var month, day int
+        err := r.statement("getTaxSettings").QueryRowContext(ctx, userID).Scan(
+            &s.Country,
+            &month,
+            &day,
+            &s.LongTermDays,
+            &s.WashSales,
+            &s.UpdatedAt,
+        )
+        if errors.Is(err, sql.ErrNoRows) {
+            settings = nil
+            return nil
+        }
+        if err != nil {
+            return fmt.Errorf("failed to get tax settings: %w", err)
+        }
+        s.TaxYear = models.TaxYear{StartMonth: time.Month(month), StartDay: day}
+        s.UpdatedAt = s.UpdatedAt.UTC()
+        settings = &s
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return settings, nil
+}
+
+// SetTaxSettings stores the tax settings of a user, replacing any stored
+// before
+func (r *PostgresRepository) SetTaxSettings(ctx context.Context, settings *models.TaxSettings) error {
+    if settings == nil || settings.UserID == uuid.Nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "upsertTaxSettings", func() error {
+        _, err := r.statement("upsertTaxSettings").ExecContext(ctx,
+            settings.UserID,
+            settings.Country,
+            int(settings.TaxYear.StartMonth),
+            settings.TaxYear.StartDay,
+            settings.LongTermDays,
+            settings.WashSales,
+            settings.UpdatedAt,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to upsert tax settings: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
@@ -0,0 +1,251 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// GetTransaction returns a transaction of a portfolio still held in Postgres
+func (r *PostgresRepository) GetTransaction(ctx context.Context, portfolioID, transactionID uuid.UUID) (*models.Transaction, error) {
+    var t *models.Transaction
+
+    err := r.withStatementRecovery(ctx, "getTransaction", func() error {
+        var err error
+        t, err = scanTransaction(r.statement("getTransaction").QueryRowContext(ctx, transactionID, portfolioID))
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return t, nil
+}
+
+// SumTransactionFees returns the fees paid by the transactions of a portfolio
+// still held in Postgres recorded within [start, end)
+func (r *PostgresRepository) SumTransactionFees(ctx context.Context, portfolioID uuid.UUID, start, end time.Time) (decimal.Decimal, error) {
+    fees := decimal.Zero
+
+    err := r.withStatementRecovery(ctx, "sumTransactionFees", func() error {
+        rows, err := r.queryContext(ctx, "sumTransactionFees", portfolioID, start, end)
+        if err != nil {
+            return fmt.Errorf("failed to sum transaction fees: %w", err)
+        }
+        defer rows.Close()
+
+        for rows.Next() {
+            if err := rows.Scan(&fees); err != nil {
+                return fmt.Errorf("failed to scan transaction fees: %w", err)
+            }
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return decimal.Zero, err
+    }
+
+    return fees, nil
+}
+
+// AmendTransaction replaces a transaction of a portfolio with updated, or
+// deletes it when updated is nil, and restates its asset from a replay of
+// the asset's lots before and after the change, all in one transaction.
+// The asset's archived lots open the replay. ErrNegativeBalance is returned,
+// and nothing changed, when the change would leave the asset's amount
+// negative, and ErrTransferLinked when the transaction is a linked transfer.
+func (r *PostgresRepository) AmendTransaction(ctx context.Context, portfolioID, transactionID uuid.UUID, updated *models.Transaction) (*models.LotRecomputation, error) {
+    var recomputation *models.LotRecomputation
+
+    err := r.withStatementRecovery(ctx, "lockTransaction", func() error {
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        var linked bool
+        if err := tx.StmtContext(ctx, r.statement("transferLinked")).QueryRowContext(ctx, transactionID).Scan(&linked); err != nil {
+            return fmt.Errorf("failed to check transfer link: %w", err)
+        }
+        if linked {
+            return ErrTransferLinked
+        }
+
+        recomputation, err = r.amendTransaction(ctx, tx, portfolioID, transactionID, func(models.Transaction) *models.Transaction {
+            return updated
+        })
+        if err != nil {
+            return err
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    r.recordWrite(ctx)
+
+    return recomputation, nil
+}
+
+// amendTransaction locks a transaction and its asset within tx, replaces the
+// transaction with what amend returns for it, or deletes it when amend
+// returns nil, and restates the asset and its realized gains by the change
+// in its lots
+func (r *PostgresRepository) amendTransaction(ctx context.Context, tx *sql.Tx, portfolioID, transactionID uuid.UUID, amend func(current models.Transaction) *models.Transaction) (*models.LotRecomputation, error) {
+    current, err := scanTransaction(tx.StmtContext(ctx, r.statement("lockTransaction")).QueryRowContext(ctx, transactionID, portfolioID))
+    if err != nil {
+        return nil, err
+    }
+
+    var asset models.Asset
+    err = tx.StmtContext(ctx, r.statement("lockAsset")).QueryRowContext(ctx, current.AssetID, portfolioID).Scan(
+        &asset.ID,
+        &asset.Type,
+        &asset.Symbol,
+        &asset.Amount,
+        &asset.CostBasis,
+    )
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, ErrAssetNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to lock asset: %w", err)
+    }
+
+    before, err := r.assetTransactions(ctx, tx, portfolioID, asset.ID)
+    if err != nil {
+        return nil, err
+    }
+    opening, err := r.assetArchivedLots(ctx, tx, portfolioID, asset.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    updated := amend(*current)
+    after := make([]models.Transaction, 0, len(before))
+    for _, t := range before {
+        if t.ID != transactionID {
+            after = append(after, t)
+        } else if updated != nil {
+            after = append(after, *updated)
+        }
+    }
+
+    recomputation := models.RecomputeLots(asset, opening, before, after, r.feePolicy)
+    if recomputation.Amount.IsNegative() {
+        return nil, fmt.Errorf("%w: %s amount would be %s", ErrNegativeBalance, asset.Symbol, recomputation.Amount)
+    }
+
+    if updated != nil {
+        _, err = tx.StmtContext(ctx, r.statement("updateTransaction")).ExecContext(ctx,
+            transactionID,
+            portfolioID,
+            updated.Type,
+            updated.Amount,
+            updated.Price,
+            updated.Fee,
+            updated.Timestamp,
+        )
+    } else {
+        _, err = tx.StmtContext(ctx, r.statement("deleteTransaction")).ExecContext(ctx, transactionID, portfolioID)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to amend transaction: %w", err)
+    }
+
+    _, err = tx.StmtContext(ctx, r.statement("restateAsset")).ExecContext(ctx,
+        asset.ID,
+        portfolioID,
+        recomputation.Amount,
+        recomputation.CostBasis,
+        time.Now().UTC(),
+    )
+    if err != nil {
+        return nil, fmt.Errorf("failed to restate asset: %w", err)
+    }
+
+    // Sells before the change realized the same gains
+    from := current.Timestamp
+    if updated != nil && updated.Timestamp.Before(from) {
+        from = updated.Timestamp
+    }
+    if err := r.restateRealizedGains(ctx, tx, portfolioID, asset, recomputation.Disposals, from); err != nil {
+        return nil, err
+    }
+
+    return &recomputation, nil
+}
+
+// assetTransactions returns the transactions of an asset within tx, oldest first
+func (r *PostgresRepository) assetTransactions(ctx context.Context, tx *sql.Tx, portfolioID, assetID uuid.UUID) ([]models.Transaction, error) {
+    rows, err := tx.StmtContext(ctx, r.statement("listAssetTransactions")).QueryContext(ctx, portfolioID, assetID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to query asset transactions: %w", err)
+    }
+    defer rows.Close()
+
+    var txs []models.Transaction
+    for rows.Next() {
+        t, err := scanTransaction(rows)
+        if err != nil {
+            return nil, err
+        }
+        txs = append(txs, *t)
+    }
+    return txs, rows.Err()
+}
+
+// assetArchivedLots returns the summary lots of an asset's archived history
+// within tx, as the opening lots of a replay
+func (r *PostgresRepository) assetArchivedLots(ctx context.Context, tx *sql.Tx, portfolioID, assetID uuid.UUID) ([]models.Lot, error) {
+    rows, err := tx.StmtContext(ctx, r.statement("listAssetArchivedLots")).QueryContext(ctx, portfolioID, assetID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to query archived lots: %w", err)
+    }
+    defer rows.Close()
+
+    var lots []models.Lot
+    for rows.Next() {
+        var lot models.Lot
+        if err := rows.Scan(&lot.AssetID, &lot.Quantity, &lot.CostBasis, &lot.AcquiredAt); err != nil {
+            return nil, fmt.Errorf("failed to scan archived lot: %w", err)
+        }
+        lots = append(lots, lot)
+    }
+    return lots, rows.Err()
+}
+
+// scanTransaction scans a transaction row, returning ErrTransactionNotFound
+// when there is none
+func scanTransaction(row rowScanner) (*models.Transaction, error) {
+    var t models.Transaction
+    err := row.Scan(
+        &t.ID,
+        &t.PortfolioID,
+        &t.AssetID,
+        &t.Type,
+        &t.Amount,
+        &t.Price,
+        &t.Fee,
+        &t.Timestamp,
+    )
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, ErrTransactionNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to scan transaction: %w", err)
+    }
+    return &t, nil
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/lib/pq"                // v1.10.9
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// ListTransferCandidates returns the unlinked transfer_ins and transfer_outs
+// of symbol across a user's portfolios recorded within [start, end), oldest
+// first
+func (r *PostgresRepository) ListTransferCandidates(ctx context.Context, userID uuid.UUID, symbol string, start, end time.Time) ([]models.TransferCandidate, error) {
+    var candidates []models.TransferCandidate
+
+    err := r.withStatementRecovery(ctx, "listTransferCandidates", func() error {
+        rows, err := r.statement("listTransferCandidates").QueryContext(ctx, userID, symbol, start, end)
+        if err != nil {
+            return fmt.Errorf("failed to query transfer candidates: %w", err)
+        }
+        defer rows.Close()
+
+        candidates = candidates[:0]
+        for rows.Next() {
+            c, err := scanTransferCandidate(rows)
+            if err != nil {
+                return err
+            }
+            candidates = append(candidates, *c)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return candidates, nil
+}
+
+// LinkTransfer links the transfer_out and transfer_in of link in one
+// transaction: the transfer_in is repriced to carry over the cost basis the
+// transfer_out took from its lots, its asset restated, and the link stored
+// with the cost basis, amount and portfolios filled in. Both transactions
+// must belong to the link's user. ErrTransferLinked is returned when either
+// is already linked.
+func (r *PostgresRepository) LinkTransfer(ctx context.Context, link *models.TransferLink) (*models.LotRecomputation, error) {
+    if link == nil || link.UserID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    var recomputation *models.LotRecomputation
+    err := r.withStatementRecovery(ctx, "insertTransferLink", func() error {
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        out, err := scanTransferCandidate(tx.StmtContext(ctx, r.statement("lockTransferCandidate")).QueryRowContext(ctx, link.OutTransactionID, link.UserID))
+        if err != nil {
+            return err
+        }
+        in, err := scanTransferCandidate(tx.StmtContext(ctx, r.statement("lockTransferCandidate")).QueryRowContext(ctx, link.InTransactionID, link.UserID))
+        if err != nil {
+            return err
+        }
+        if err := models.ValidateTransferPair(*out, *in); err != nil {
+            return err
+        }
+
+        sent, err := r.assetTransactions(ctx, tx, out.Transaction.PortfolioID, out.Transaction.AssetID)
+        if err != nil {
+            return err
+        }
+        opening, err := r.assetArchivedLots(ctx, tx, out.Transaction.PortfolioID, out.Transaction.AssetID)
+        if err != nil {
+            return err
+        }
+        disposal, _ := models.ReplayLots(opening, sent, r.feePolicy).Disposal(out.Transaction.ID)
+
+        link.OutPortfolioID = out.Transaction.PortfolioID
+        link.InPortfolioID = in.Transaction.PortfolioID
+        link.Symbol = out.Symbol
+        link.Amount = in.Transaction.Amount
+        link.CostBasis = disposal.CostBasis
+        link.InPriceBefore = in.Transaction.Price
+
+        _, err = tx.StmtContext(ctx, r.statement("insertTransferLink")).ExecContext(ctx,
+            link.ID,
+            link.UserID,
+            link.OutTransactionID,
+            link.InTransactionID,
+            link.OutPortfolioID,
+            link.InPortfolioID,
+            link.Symbol,
+            link.Amount,
+            link.CostBasis,
+            link.InPriceBefore,
+            link.MatchedBy,
+            link.CreatedAt,
+        )
+        var pqErr *pq.Error
+        if errors.As(err, &pqErr) && pqErr.Code == pgCodeUniqueViolation {
+            return ErrTransferLinked
+        }
+        if err != nil {
+            return fmt.Errorf("failed to insert transfer link: %w", err)
+        }
+
+        price := models.CarriedPrice(disposal, in.Transaction.Amount)
+        recomputation, err = r.amendTransaction(ctx, tx, in.Transaction.PortfolioID, in.Transaction.ID, func(current models.Transaction) *models.Transaction {
+            current.Price = price
+            return &current
+        })
+        if err != nil {
+            return err
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    r.recordWrite(ctx)
+
+    return recomputation, nil
+}
+
+// UnlinkTransfer removes the link of a user's transfer, given either of its
+// transactions, restoring the transfer_in's own price and restating its
+// asset in the same transaction
+func (r *PostgresRepository) UnlinkTransfer(ctx context.Context, userID, transactionID uuid.UUID) (*models.TransferLink, *models.LotRecomputation, error) {
+    var (
+        link          models.TransferLink
+        recomputation *models.LotRecomputation
+    )
+
+    err := r.withStatementRecovery(ctx, "deleteTransferLink", func() error {
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        err = tx.StmtContext(ctx, r.statement("deleteTransferLink")).QueryRowContext(ctx, userID, transactionID).Scan(
+            &link.ID,
+            &link.UserID,
+            &link.OutTransactionID,
+            &link.InTransactionID,
+            &link.OutPortfolioID,
+            &link.InPortfolioID,
+            &link.Symbol,
+            &link.Amount,
+            &link.CostBasis,
+            &link.InPriceBefore,
+            &link.MatchedBy,
+            &link.CreatedAt,
+        )
+        if errors.Is(err, sql.ErrNoRows) {
+            return ErrTransferLinkNotFound
+        }
+        if err != nil {
+            return fmt.Errorf("failed to delete transfer link: %w", err)
+        }
+
+        recomputation, err = r.amendTransaction(ctx, tx, link.InPortfolioID, link.InTransactionID, func(current models.Transaction) *models.Transaction {
+            current.Price = link.InPriceBefore
+            return &current
+        })
+        if err != nil {
+            return err
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, nil, err
+    }
+    r.recordWrite(ctx)
+
+    return &link, recomputation, nil
+}
+
+// scanTransferCandidate scans a transaction row followed by its asset's
+// symbol, returning ErrTransactionNotFound when there is none
+func scanTransferCandidate(row rowScanner) (*models.TransferCandidate, error) {
+    var c models.TransferCandidate
+    err := row.Scan(
+        &c.Transaction.ID,
+        &c.Transaction.PortfolioID,
+        &c.Transaction.AssetID,
+        &c.Transaction.Type,
+        &c.Transaction.Amount,
+        &c.Transaction.Price,
+        &c.Transaction.Fee,
+        &c.Transaction.Timestamp,
+        &c.Symbol,
+    )
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, ErrTransactionNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to scan transfer: %w", err)
+    }
+    return &c, nil
+}
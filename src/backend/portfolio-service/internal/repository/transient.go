@@ -0,0 +1,61 @@
+package repository
+
+import (
+    "database/sql"
+    "database/sql/driver"
+    "errors"
+    "net"
+
+    "github.com/lib/pq"           // v1.10.9
+)
+
+// pgCodeQueryCanceled is the SQLSTATE of statements cancelled because the
+// request context ended or the statement timeout elapsed
+const pgCodeQueryCanceled = "57014"
+
+// transientClasses are the SQLSTATE classes of failures that succeed when
+// retried: connection exceptions, insufficient resources and operator
+// intervention such as a server shutting down
+var transientClasses = map[pq.ErrorClass]bool{
+    "08": true,
+    "53": true,
+    "57": true,
+}
+
+// transientCodes are SQLSTATE codes of transactions aborted by a concurrent
+// one: serialization failures and deadlocks
+var transientCodes = map[pq.ErrorCode]bool{
+    "40001": true,
+    "40P01": true,
+}
+
+// IsTransient reports whether a repository error is a temporary database
+// failure that may succeed when the operation is retried
+func IsTransient(err error) bool {
+    if err == nil {
+        return false
+    }
+    if errors.Is(err, ErrDatabaseConnection) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+        return true
+    }
+
+    var pqErr *pq.Error
+    if errors.As(err, &pqErr) {
+        // Cancelled statements follow the caller's own deadline
+        if pqErr.Code == pgCodeQueryCanceled {
+            return false
+        }
+        return transientClasses[pqErr.Code.Class()] || transientCodes[pqErr.Code]
+    }
+
+    var netErr net.Error
+    return errors.As(err, &netErr)
+}
+
+// IsCanceled reports whether a repository error is a statement cancelled on
+// the server because the request context ended or it ran past the statement
+// timeout
+func IsCanceled(err error) bool {
+    var pqErr *pq.Error
+    return errors.As(err, &pqErr) && pqErr.Code == pgCodeQueryCanceled
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/lib/pq"                // v1.10.9
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// GetPortfolioAssets returns the holdings of a portfolio valued at current
+// prices, whether or not the portfolio is archived
+func (r *PostgresRepository) GetPortfolioAssets(ctx context.Context, portfolioID uuid.UUID) ([]models.Asset, error) {
+    var assets []models.Asset
+
+    err := r.withStatementRecovery(ctx, "getAssets", func() error {
+        rows, err := r.queryContext(ctx, "getAssets", portfolioID)
+        if err != nil {
+            return fmt.Errorf("failed to query assets: %w", err)
+        }
+        defer rows.Close()
+
+        assets = assets[:0]
+        for rows.Next() {
+            var a models.Asset
+            if err := rows.Scan(
+                &a.ID,
+                &a.Type,
+                &a.Symbol,
+                &a.Amount,
+                &a.CostBasis,
+                &a.CurrentValue,
+                &a.LastUpdated,
+            ); err != nil {
+                return fmt.Errorf("failed to scan asset: %w", err)
+            }
+            assets = append(assets, a)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return assets, nil
+}
+
+// ListUserAuditEntries returns the audit trail of changes made by a user and
+// of the rows of the given portfolios, oldest first
+func (r *PostgresRepository) ListUserAuditEntries(ctx context.Context, userID uuid.UUID, portfolioIDs []uuid.UUID) ([]models.AuditEntry, error) {
+    ids := make([]string, len(portfolioIDs))
+    for i, id := range portfolioIDs {
+        ids[i] = id.String()
+    }
+
+    var entries []models.AuditEntry
+
+    err := r.withStatementRecovery(ctx, "listUserAuditEntries", func() error {
+        rows, err := r.queryContext(ctx, "listUserAuditEntries", userID, pq.Array(ids))
+        if err != nil {
+            return fmt.Errorf("failed to query audit trail: %w", err)
+        }
+        defer rows.Close()
+
+        entries = entries[:0]
+        for rows.Next() {
+            e, err := scanAuditEntry(rows)
+            if err != nil {
+                return fmt.Errorf("failed to scan audit entry: %w", err)
+            }
+            entries = append(entries, e)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return entries, nil
+}
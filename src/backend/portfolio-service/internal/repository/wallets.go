@@ -0,0 +1,235 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/lib/pq"           // v1.10.9
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// CreateTrackedWallet stores a tracked wallet, failing with
+// ErrTrackedWalletExists when its owner already tracks the address
+func (r *PostgresRepository) CreateTrackedWallet(ctx context.Context, wallet *models.TrackedWallet) error {
+    if wallet == nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "insertTrackedWallet", func() error {
+        _, err := r.statement("insertTrackedWallet").ExecContext(ctx,
+            wallet.ID,
+            wallet.PortfolioID,
+            wallet.UserID,
+            wallet.Chain,
+            wallet.Address,
+            wallet.NextSyncAt,
+            wallet.CreatedAt,
+        )
+        var pqErr *pq.Error
+        if errors.As(err, &pqErr) && pqErr.Code == pgCodeUniqueViolation {
+            return ErrTrackedWalletExists
+        }
+        if err != nil {
+            return fmt.Errorf("failed to insert tracked wallet: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// GetTrackedWallet returns a tracked wallet of a user
+func (r *PostgresRepository) GetTrackedWallet(ctx context.Context, userID, walletID uuid.UUID) (*models.TrackedWallet, error) {
+    var wallet *models.TrackedWallet
+
+    err := r.withStatementRecovery(ctx, "getTrackedWallet", func() error {
+        w, err := scanTrackedWallet(r.statement("getTrackedWallet").QueryRowContext(ctx, userID, walletID))
+        if errors.Is(err, sql.ErrNoRows) {
+            return ErrTrackedWalletNotFound
+        }
+        if err != nil {
+            return fmt.Errorf("failed to get tracked wallet: %w", err)
+        }
+        wallet = w
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return wallet, nil
+}
+
+// IsTrackedWallet reports whether a portfolio is the portfolio of a tracked
+// wallet
+func (r *PostgresRepository) IsTrackedWallet(ctx context.Context, portfolioID uuid.UUID) (bool, error) {
+    var tracked bool
+
+    err := r.withStatementRecovery(ctx, "isTrackedWallet", func() error {
+        if err := r.statement("isTrackedWallet").QueryRowContext(ctx, portfolioID).Scan(&tracked); err != nil {
+            return fmt.Errorf("failed to check tracked wallet: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return false, err
+    }
+
+    return tracked, nil
+}
+
+// ListTrackedWallets returns the tracked wallets of a user
+func (r *PostgresRepository) ListTrackedWallets(ctx context.Context, userID uuid.UUID) ([]models.TrackedWallet, error) {
+    var wallets []models.TrackedWallet
+
+    err := r.withStatementRecovery(ctx, "listTrackedWallets", func() error {
+        rows, err := r.queryContext(ctx, "listTrackedWallets", userID)
+        if err != nil {
+            return fmt.Errorf("failed to query tracked wallets: %w", err)
+        }
+        defer rows.Close()
+
+        wallets = wallets[:0]
+        for rows.Next() {
+            w, err := scanTrackedWallet(rows)
+            if err != nil {
+                return fmt.Errorf("failed to scan tracked wallet: %w", err)
+            }
+            wallets = append(wallets, *w)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return wallets, nil
+}
+
+// DeleteTrackedWallet stops tracking a wallet of a user; its portfolio and
+// synced transactions are kept
+func (r *PostgresRepository) DeleteTrackedWallet(ctx context.Context, userID, walletID uuid.UUID) error {
+    err := r.withStatementRecovery(ctx, "deleteTrackedWallet", func() error {
+        res, err := r.statement("deleteTrackedWallet").ExecContext(ctx, userID, walletID)
+        if err != nil {
+            return fmt.Errorf("failed to delete tracked wallet: %w", err)
+        }
+        n, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        if n == 0 {
+            return ErrTrackedWalletNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// ClaimDueTrackedWallets leases up to limit wallets due for sync at now until
+// leaseUntil, so concurrent workers do not sync them twice
+func (r *PostgresRepository) ClaimDueTrackedWallets(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.TrackedWallet, error) {
+    var wallets []models.TrackedWallet
+
+    err := r.withStatementRecovery(ctx, "claimDueTrackedWallets", func() error {
+        rows, err := r.statement("claimDueTrackedWallets").QueryContext(ctx, now, leaseUntil, limit)
+        if err != nil {
+            return fmt.Errorf("failed to claim tracked wallets: %w", err)
+        }
+        defer rows.Close()
+
+        wallets = wallets[:0]
+        for rows.Next() {
+            w, err := scanTrackedWallet(rows)
+            if err != nil {
+                return fmt.Errorf("failed to scan tracked wallet: %w", err)
+            }
+            wallets = append(wallets, *w)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+    if len(wallets) > 0 {
+        r.recordWrite(ctx)
+    }
+
+    return wallets, nil
+}
+
+// SaveWalletCheckpoint stores the cursor reached by a successful sync and
+// schedules the next one
+func (r *PostgresRepository) SaveWalletCheckpoint(ctx context.Context, walletID uuid.UUID, cursor string, syncedAt, nextSyncAt time.Time) error {
+    err := r.withStatementRecovery(ctx, "saveWalletCheckpoint", func() error {
+        res, err := r.statement("saveWalletCheckpoint").ExecContext(ctx, walletID, cursor, syncedAt, nextSyncAt)
+        if err != nil {
+            return fmt.Errorf("failed to save wallet checkpoint: %w", err)
+        }
+        n, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        if n == 0 {
+            return ErrTrackedWalletNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// RecordWalletSyncError stores why a sync failed and when to retry, keeping
+// the last checkpoint
+func (r *PostgresRepository) RecordWalletSyncError(ctx context.Context, walletID uuid.UUID, message string, nextSyncAt time.Time) error {
+    err := r.withStatementRecovery(ctx, "recordWalletSyncError", func() error {
+        _, err := r.statement("recordWalletSyncError").ExecContext(ctx, walletID, message, nextSyncAt)
+        if err != nil {
+            return fmt.Errorf("failed to record wallet sync error: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// scanTrackedWallet reads a tracked wallet row
+func scanTrackedWallet(row rowScanner) (*models.TrackedWallet, error) {
+    var (
+        w            models.TrackedWallet
+        lastSyncedAt sql.NullTime
+    )
+    err := row.Scan(
+        &w.ID, &w.PortfolioID, &w.UserID, &w.Chain, &w.Address, &w.Cursor, &lastSyncedAt, &w.LastError,
+        &w.NextSyncAt, &w.CreatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    if lastSyncedAt.Valid {
+        w.LastSyncedAt = &lastSyncedAt.Time
+    }
+    return &w, nil
+}
@@ -0,0 +1,363 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/lib/pq" // v1.10.9
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/pagination"
+)
+
+// CreateTransaction records a portfolio transaction and the gains it realized,
+// with a TransactionRecorded event when the outbox is enabled
+func (r *PostgresRepository) CreateTransaction(ctx context.Context, t *models.Transaction) error {
+    if t == nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "createTransaction", func() error {
+        tx, err := r.db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin transaction: %w", err)
+        }
+        defer tx.Rollback()
+
+        _, err = tx.StmtContext(ctx, r.statement("createTransaction")).ExecContext(ctx,
+            t.ID,
+            t.PortfolioID,
+            t.AssetID,
+            t.Type,
+            t.Amount,
+            t.Price,
+            t.Fee,
+            t.Timestamp,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to create transaction: %w", err)
+        }
+        if err := r.recordRealizedGains(ctx, tx, t.PortfolioID, t); err != nil {
+            return err
+        }
+
+        if err := r.appendOutbox(ctx, tx, func() (*models.OutboxEvent, error) {
+            return models.TransactionRecordedEvent(t)
+        }); err != nil {
+            return err
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit transaction: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// CreateWebhook stores a webhook endpoint unless its scope already has
+// models.MAX_WEBHOOKS_PER_PORTFOLIO endpoints
+func (r *PostgresRepository) CreateWebhook(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+    if endpoint == nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := r.withStatementRecovery(ctx, "createWebhook", func() error {
+        res, err := r.statement("createWebhook").ExecContext(ctx,
+            endpoint.ID,
+            endpoint.UserID,
+            nullableUUID(endpoint.PortfolioID),
+            endpoint.URL,
+            endpoint.Secret,
+            pq.Array(webhookEventStrings(endpoint.Events)),
+            endpoint.Enabled,
+            endpoint.CreatedAt,
+            models.MAX_WEBHOOKS_PER_PORTFOLIO,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to create webhook: %w", err)
+        }
+        n, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        if n == 0 {
+            return ErrWebhookLimit
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// GetWebhook returns a webhook endpoint of a user without its secret
+func (r *PostgresRepository) GetWebhook(ctx context.Context, userID, webhookID uuid.UUID) (*models.WebhookEndpoint, error) {
+    var endpoint *models.WebhookEndpoint
+
+    err := r.withStatementRecovery(ctx, "getWebhook", func() error {
+        row := r.statement("getWebhook").QueryRowContext(ctx, webhookID, userID)
+        e, err := scanWebhook(row)
+        if errors.Is(err, sql.ErrNoRows) {
+            return ErrWebhookNotFound
+        }
+        if err != nil {
+            return fmt.Errorf("failed to get webhook: %w", err)
+        }
+        endpoint = e
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return endpoint, nil
+}
+
+// ListWebhooks returns the webhook endpoints of a portfolio, or the user-wide
+// endpoints when portfolioID is uuid.Nil, without their secrets
+func (r *PostgresRepository) ListWebhooks(ctx context.Context, userID, portfolioID uuid.UUID) ([]models.WebhookEndpoint, error) {
+    var endpoints []models.WebhookEndpoint
+
+    err := r.withStatementRecovery(ctx, "listWebhooks", func() error {
+        rows, err := r.queryContext(ctx, "listWebhooks", userID, nullableUUID(portfolioID))
+        if err != nil {
+            return fmt.Errorf("failed to query webhooks: %w", err)
+        }
+        defer rows.Close()
+
+        endpoints = endpoints[:0]
+        for rows.Next() {
+            e, err := scanWebhook(rows)
+            if err != nil {
+                return fmt.Errorf("failed to scan webhook: %w", err)
+            }
+            endpoints = append(endpoints, *e)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return endpoints, nil
+}
+
+// DeleteWebhook removes a webhook endpoint and its delivery history
+func (r *PostgresRepository) DeleteWebhook(ctx context.Context, userID, webhookID uuid.UUID) error {
+    err := r.withStatementRecovery(ctx, "deleteWebhook", func() error {
+        res, err := r.statement("deleteWebhook").ExecContext(ctx, webhookID, userID)
+        if err != nil {
+            return fmt.Errorf("failed to delete webhook: %w", err)
+        }
+        n, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        if n == 0 {
+            return ErrWebhookNotFound
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// EnqueueWebhookDeliveries queues an event for every enabled endpoint of the
+// portfolio or its owner subscribed to it and returns the number queued
+func (r *PostgresRepository) EnqueueWebhookDeliveries(ctx context.Context, portfolioID uuid.UUID, event models.WebhookEvent, payload []byte, at time.Time) (int64, error) {
+    var queued int64
+
+    err := r.withStatementRecovery(ctx, "enqueueWebhookDeliveries", func() error {
+        res, err := r.statement("enqueueWebhookDeliveries").ExecContext(ctx, portfolioID, string(event), payload, at)
+        if err != nil {
+            return fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+        }
+        queued, err = res.RowsAffected()
+        return err
+    })
+    if err != nil {
+        return 0, err
+    }
+    if queued > 0 {
+        r.recordWrite(ctx)
+    }
+
+    return queued, nil
+}
+
+// ClaimWebhookDeliveries leases up to limit pending deliveries due at now
+// until leaseUntil, so concurrent workers do not send them twice, and returns
+// them with their endpoints including secrets
+func (r *PostgresRepository) ClaimWebhookDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, map[uuid.UUID]models.WebhookEndpoint, error) {
+    var (
+        deliveries []models.WebhookDelivery
+        endpoints  map[uuid.UUID]models.WebhookEndpoint
+    )
+
+    err := r.withStatementRecovery(ctx, "claimWebhookDeliveries", func() error {
+        rows, err := r.statement("claimWebhookDeliveries").QueryContext(ctx, now, leaseUntil, limit)
+        if err != nil {
+            return fmt.Errorf("failed to claim webhook deliveries: %w", err)
+        }
+        defer rows.Close()
+
+        deliveries = deliveries[:0]
+        endpoints = make(map[uuid.UUID]models.WebhookEndpoint)
+        for rows.Next() {
+            var (
+                d        models.WebhookDelivery
+                event    string
+                endpoint models.WebhookEndpoint
+            )
+            if err := rows.Scan(&d.ID, &d.EndpointID, &d.PortfolioID, &event, &d.Payload, &d.Attempts, &d.CreatedAt, &endpoint.URL, &endpoint.Secret); err != nil {
+                return fmt.Errorf("failed to scan webhook delivery: %w", err)
+            }
+            d.Event = models.WebhookEvent(event)
+            d.Status = models.DeliveryPending
+            d.NextAttemptAt = leaseUntil
+            endpoint.ID = d.EndpointID
+            endpoints[d.EndpointID] = endpoint
+            deliveries = append(deliveries, d)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, nil, err
+    }
+    if len(deliveries) > 0 {
+        r.recordWrite(ctx)
+    }
+
+    return deliveries, endpoints, nil
+}
+
+// RecordWebhookAttempt stores the outcome of a delivery attempt
+func (r *PostgresRepository) RecordWebhookAttempt(ctx context.Context, d *models.WebhookDelivery) error {
+    if d == nil {
+        return ErrInvalidPortfolio
+    }
+
+    var lastError sql.NullString
+    if d.LastError != "" {
+        lastError = sql.NullString{String: d.LastError, Valid: true}
+    }
+
+    err := r.withStatementRecovery(ctx, "recordWebhookAttempt", func() error {
+        _, err := r.statement("recordWebhookAttempt").ExecContext(ctx,
+            d.ID,
+            string(d.Status),
+            d.Attempts,
+            d.ResponseCode,
+            lastError,
+            d.NextAttemptAt,
+            d.DeliveredAt,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to record webhook attempt: %w", err)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    r.recordWrite(ctx)
+
+    return nil
+}
+
+// ListWebhookDeliveries returns up to limit deliveries of an endpoint ordered
+// before the given position, newest first. Deliveries are ordered by creation
+// time and ID.
+func (r *PostgresRepository) ListWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, before pagination.Position, limit int) ([]models.WebhookDelivery, error) {
+    var deliveries []models.WebhookDelivery
+
+    err := r.withStatementRecovery(ctx, "listWebhookDeliveries", func() error {
+        rows, err := r.queryContext(ctx, "listWebhookDeliveries", webhookID, before.At, before.ID, limit)
+        if err != nil {
+            return fmt.Errorf("failed to query webhook deliveries: %w", err)
+        }
+        defer rows.Close()
+
+        deliveries = deliveries[:0]
+        for rows.Next() {
+            var (
+                d         models.WebhookDelivery
+                event     string
+                status    string
+                delivered sql.NullTime
+            )
+            if err := rows.Scan(&d.ID, &d.PortfolioID, &event, &d.Payload, &status, &d.Attempts, &d.ResponseCode, &d.LastError, &d.NextAttemptAt, &d.CreatedAt, &delivered); err != nil {
+                return fmt.Errorf("failed to scan webhook delivery: %w", err)
+            }
+            d.EndpointID = webhookID
+            d.Event = models.WebhookEvent(event)
+            d.Status = models.DeliveryStatus(status)
+            if delivered.Valid {
+                d.DeliveredAt = &delivered.Time
+            }
+            deliveries = append(deliveries, d)
+        }
+        return rows.Err()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return deliveries, nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+    Scan(dest ...interface{}) error
+}
+
+// scanWebhook reads a webhook endpoint row without its secret
+func scanWebhook(row rowScanner) (*models.WebhookEndpoint, error) {
+    var (
+        e           models.WebhookEndpoint
+        portfolioID uuid.NullUUID
+        events      []string
+    )
+    if err := row.Scan(&e.ID, &e.UserID, &portfolioID, &e.URL, pq.Array(&events), &e.Enabled, &e.CreatedAt); err != nil {
+        return nil, err
+    }
+    if portfolioID.Valid {
+        e.PortfolioID = portfolioID.UUID
+    }
+    e.Events = make([]models.WebhookEvent, len(events))
+    for i, event := range events {
+        e.Events[i] = models.WebhookEvent(event)
+    }
+    return &e, nil
+}
+
+// webhookEventStrings converts events for storage in a text array
+func webhookEventStrings(events []models.WebhookEvent) []string {
+    result := make([]string, len(events))
+    for i, event := range events {
+        result[i] = string(event)
+    }
+    return result
+}
+
+// nullableUUID stores uuid.Nil as NULL
+func nullableUUID(id uuid.UUID) uuid.NullUUID {
+    return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
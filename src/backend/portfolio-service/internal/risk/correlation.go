@@ -0,0 +1,66 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// MinCorrelationObservations is the fewest aligned returns accepted for a correlation estimate
+const MinCorrelationObservations = 10
+
+// CorrelationMatrix returns the Pearson correlation of every pair of return
+// series, in the order of symbols. Series with no variance are uncorrelated
+// with everything but themselves.
+func CorrelationMatrix(symbols []string, returns map[string][]float64) ([][]float64, error) {
+	n := -1
+	for _, symbol := range symbols {
+		series, ok := returns[symbol]
+		if !ok {
+			return nil, fmt.Errorf("no returns for %s", symbol)
+		}
+		if n >= 0 && len(series) != n {
+			return nil, errors.New("return series are not aligned")
+		}
+		n = len(series)
+	}
+	if len(symbols) > 0 && n < MinCorrelationObservations {
+		return nil, ErrInsufficientHistory
+	}
+
+	// Center each series once and keep its norm for the pairwise products
+	centered := make([][]float64, len(symbols))
+	norms := make([]float64, len(symbols))
+	for i, symbol := range symbols {
+		mean, _ := meanStdDev(returns[symbol])
+		centered[i] = make([]float64, n)
+		var sq float64
+		for k, r := range returns[symbol] {
+			centered[i][k] = r - mean
+			sq += centered[i][k] * centered[i][k]
+		}
+		norms[i] = math.Sqrt(sq)
+	}
+
+	matrix := make([][]float64, len(symbols))
+	for i := range matrix {
+		matrix[i] = make([]float64, len(symbols))
+		matrix[i][i] = 1
+	}
+
+	for i := 0; i < len(symbols); i++ {
+		for j := i + 1; j < len(symbols); j++ {
+			if norms[i] == 0 || norms[j] == 0 {
+				continue
+			}
+			var dot float64
+			for k := 0; k < n; k++ {
+				dot += centered[i][k] * centered[j][k]
+			}
+			c := math.Max(-1, math.Min(1, dot/(norms[i]*norms[j])))
+			matrix[i][j], matrix[j][i] = c, c
+		}
+	}
+
+	return matrix, nil
+}
@@ -0,0 +1,124 @@
+// Package risk implements market risk estimates over portfolio return series.
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MinObservations is the fewest daily returns accepted for a VaR estimate
+const MinObservations = 30
+
+// zScores holds the one-sided standard normal quantiles of supported confidence levels
+var zScores = map[float64]float64{
+	0.95: 1.6448536269514722,
+	0.99: 2.3263478740408408,
+}
+
+// ErrInsufficientHistory is returned when too few returns are available
+var ErrInsufficientHistory = errors.New("insufficient return history")
+
+// PortfolioReturns combines per-asset return series into portfolio returns
+// using the given value weights. Weights are normalized to sum to one.
+func PortfolioReturns(returns map[string][]float64, weights map[string]float64) ([]float64, error) {
+	// Sum in a fixed order so results are reproducible
+	symbols := make([]string, 0, len(weights))
+	for symbol := range weights {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	var total float64
+	n := -1
+	for _, symbol := range symbols {
+		series, ok := returns[symbol]
+		if !ok {
+			return nil, fmt.Errorf("no returns for %s", symbol)
+		}
+		if n >= 0 && len(series) != n {
+			return nil, errors.New("return series are not aligned")
+		}
+		n = len(series)
+		total += weights[symbol]
+	}
+	if total <= 0 || n < 0 {
+		return nil, errors.New("portfolio has no weighted holdings")
+	}
+
+	combined := make([]float64, n)
+	for _, symbol := range symbols {
+		w := weights[symbol] / total
+		for i, r := range returns[symbol] {
+			combined[i] += r * w
+		}
+	}
+	return combined, nil
+}
+
+// ParametricVaR estimates VaR assuming normally distributed returns, scaling
+// the daily mean and volatility to the horizon. The result is a fraction of
+// portfolio value and never negative.
+func ParametricVaR(returns []float64, confidence float64, horizonDays int) (float64, error) {
+	z, ok := zScores[confidence]
+	if !ok {
+		return 0, fmt.Errorf("unsupported confidence level %v", confidence)
+	}
+	if horizonDays <= 0 {
+		return 0, fmt.Errorf("invalid horizon of %d days", horizonDays)
+	}
+	if len(returns) < MinObservations {
+		return 0, ErrInsufficientHistory
+	}
+
+	mean, stddev := meanStdDev(returns)
+	h := float64(horizonDays)
+	return math.Max(0, z*stddev*math.Sqrt(h)-mean*h), nil
+}
+
+// HistoricalVaR estimates VaR as the empirical loss quantile of daily returns,
+// scaled to the horizon with the square-root-of-time rule. The result is a
+// fraction of portfolio value and never negative.
+func HistoricalVaR(returns []float64, confidence float64, horizonDays int) (float64, error) {
+	if confidence <= 0 || confidence >= 1 {
+		return 0, fmt.Errorf("unsupported confidence level %v", confidence)
+	}
+	if horizonDays <= 0 {
+		return 0, fmt.Errorf("invalid horizon of %d days", horizonDays)
+	}
+	if len(returns) < MinObservations {
+		return 0, ErrInsufficientHistory
+	}
+
+	sorted := make([]float64, len(returns))
+	copy(sorted, returns)
+	sort.Float64s(sorted)
+
+	// Index of the worst return not exceeded with the given confidence; the
+	// epsilon absorbs floating point error in e.g. (1-0.95)*100
+	idx := int(math.Ceil((1-confidence)*float64(len(sorted))-1e-9)) - 1
+	if idx < 0 {
+		idx = 0
+	}
+
+	return math.Max(0, -sorted[idx]*math.Sqrt(float64(horizonDays))), nil
+}
+
+// meanStdDev returns the mean and sample standard deviation of values
+func meanStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	if len(values) < 2 {
+		return mean, 0
+	}
+	return mean, math.Sqrt(sq / float64(len(values)-1))
+}
@@ -0,0 +1,212 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearch bounds the search for the next activation; expressions that never
+// match within it, such as February 30th, have no next activation
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// ErrInvalidSchedule is returned for cron expressions that cannot be parsed
+var ErrInvalidSchedule = errors.New("invalid cron expression")
+
+// descriptors are the supported shorthands for common expressions
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	weekdayNames = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+)
+
+// field describes the accepted values of one cron field
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var fields = [5]field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: monthNames},
+	{name: "day of week", min: 0, max: 7, names: weekdayNames}, // 7 is Sunday as well
+}
+
+// Schedule is a parsed cron expression. Each field is a bit set of the values
+// it matches.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// restrictedDays is set when both day fields are restricted, in which
+	// case a day matching either of them matches, as in classic cron
+	restrictedDays bool
+}
+
+// Parse parses a standard five field cron expression (minute, hour, day of
+// month, month, day of week) or one of the @yearly, @monthly, @weekly,
+// @daily and @hourly descriptors. Fields accept *, values, ranges, steps and
+// comma separated lists; months and weekdays also accept three letter names.
+func Parse(expression string) (*Schedule, error) {
+	expression = strings.TrimSpace(expression)
+	if strings.HasPrefix(expression, "@") {
+		spec, ok := descriptors[strings.ToLower(expression)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown descriptor %q", ErrInvalidSchedule, expression)
+		}
+		expression = spec
+	}
+
+	parts := strings.Fields(expression)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("%w: expected %d fields, got %d", ErrInvalidSchedule, len(fields), len(parts))
+	}
+
+	var sets [5]uint64
+	for i, part := range parts {
+		set, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+
+	// Fold Sunday as 7 into 0
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+
+	return &Schedule{
+		minute:         sets[0],
+		hour:           sets[1],
+		dom:            sets[2],
+		month:          sets[3],
+		dow:            sets[4],
+		restrictedDays: !strings.HasPrefix(parts[2], "*") && !strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// parseField parses one comma separated cron field into a bit set
+func parseField(expression string, f field) (uint64, error) {
+	var set uint64
+	for _, term := range strings.Split(expression, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(term, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("%w: invalid %s step %q", ErrInvalidSchedule, f.name, stepPart)
+			}
+		}
+
+		var low, high int
+		switch {
+		case rangePart == "*":
+			low, high = f.min, f.max
+		case strings.Contains(rangePart, "-"):
+			lowPart, highPart, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = parseValue(lowPart, f); err != nil {
+				return 0, err
+			}
+			if high, err = parseValue(highPart, f); err != nil {
+				return 0, err
+			}
+		default:
+			var err error
+			if low, err = parseValue(rangePart, f); err != nil {
+				return 0, err
+			}
+			high = low
+			if hasStep {
+				high = f.max
+			}
+		}
+
+		if low > high {
+			return 0, fmt.Errorf("%w: empty %s range %q", ErrInvalidSchedule, f.name, rangePart)
+		}
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+// parseValue parses a single number or name of a cron field
+func parseValue(value string, f field) (int, error) {
+	if n, ok := f.names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("%w: invalid %s %q", ErrInvalidSchedule, f.name, value)
+	}
+	return n, nil
+}
+
+// Next returns the first activation strictly after t, in t's location, or
+// the zero time when there is none
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	limit := t.Add(maxSearch)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = advance(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+		case !s.matchesDay(t):
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// matchesDay reports whether the date of t matches the day fields
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.restrictedDays {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// advance moves t to next, or by a minute when a daylight saving transition
+// normalized next to a time not after t
+func advance(t, next time.Time) time.Time {
+	if !next.After(t) {
+		return t.Add(time.Minute)
+	}
+	return next
+}
@@ -0,0 +1,274 @@
+// Package scheduler runs background jobs in-process on cron expressions from
+// configuration. A job never overlaps with its own previous run, exclusive
+// jobs run on one replica per activation, and running jobs are drained on
+// shutdown.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"go.uber.org/zap"                                // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/jobs"
+	"bookman/portfolio-service/internal/metrics"
+)
+
+// releaseTimeout bounds releasing the lock of an exclusive run
+const releaseTimeout = time.Second * 5
+
+// Run outcomes reported in metrics
+const (
+	statusSuccess       = "success"
+	statusFailed        = "failed"
+	statusOverlapped    = "overlapped"     // the previous run was still in progress
+	statusHeldElsewhere = "held_elsewhere" // another replica runs the activation
+)
+
+var (
+	runs = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "portfolio_scheduler_runs_total",
+			Help: "Total number of scheduled job activations by job and status",
+		},
+		[]string{"job", "status"},
+	)
+
+	runDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "portfolio_scheduler_run_duration_seconds",
+			Help:    "Duration of scheduled job runs",
+			Buckets: []float64{.1, .5, 1, 5, 15, 30, 60, 120, 300, 600},
+		},
+		[]string{"job"},
+	)
+
+	running = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_scheduler_running",
+			Help: "Whether a run of the job is in progress (1) or not (0)",
+		},
+		[]string{"job"},
+	)
+
+	lastSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_scheduler_last_success_timestamp_seconds",
+			Help: "Unix time of the last successful run of the job",
+		},
+		[]string{"job"},
+	)
+
+	nextRun = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_scheduler_next_run_timestamp_seconds",
+			Help: "Unix time of the next activation of the job",
+		},
+		[]string{"job"},
+	)
+)
+
+func init() {
+	metrics.MustRegister(runs, runDuration, running, lastSuccess, nextRun)
+}
+
+// RunFunc performs a single run of a scheduled job
+type RunFunc func(ctx context.Context) error
+
+// entry is a registered job
+type entry struct {
+	name      string
+	schedule  *Schedule
+	run       RunFunc
+	exclusive bool
+	running   atomic.Bool
+}
+
+// Scheduler runs registered jobs on their cron schedules
+type Scheduler struct {
+	cfg      config.SchedulerConfig
+	location *time.Location
+	locker   jobs.Locker
+	logger   *zap.Logger
+
+	entries []*entry
+	names   map[string]bool
+	active  sync.WaitGroup
+}
+
+// NewScheduler creates a cron scheduler. Without a locker exclusive jobs run
+// on every replica.
+func NewScheduler(cfg config.SchedulerConfig, locker jobs.Locker, logger *zap.Logger) (*Scheduler, error) {
+	if logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	return &Scheduler{
+		cfg:      cfg,
+		location: location,
+		locker:   locker,
+		logger:   logger.With(zap.String("component", "scheduler")),
+		names:    make(map[string]bool),
+	}, nil
+}
+
+// Register schedules a job that runs on every replica, for jobs that lease
+// their work themselves. Jobs must be registered before Run is called.
+func (s *Scheduler) Register(name string, run RunFunc) error {
+	return s.register(name, run, false)
+}
+
+// RegisterExclusive schedules a job of which each activation runs on only
+// one replica, the one that takes the job's lock
+func (s *Scheduler) RegisterExclusive(name string, run RunFunc) error {
+	return s.register(name, run, true)
+}
+
+// register parses the configured expression of the job and adds it
+func (s *Scheduler) register(name string, run RunFunc, exclusive bool) error {
+	if s.names[name] {
+		return fmt.Errorf("job %s is already registered", name)
+	}
+
+	expression, ok := s.cfg.Jobs[name]
+	if !ok || expression == "" {
+		return fmt.Errorf("no schedule configured for job %s", name)
+	}
+
+	schedule, err := Parse(expression)
+	if err != nil {
+		return fmt.Errorf("invalid schedule of job %s: %w", name, err)
+	}
+
+	s.names[name] = true
+	s.entries = append(s.entries, &entry{
+		name:      name,
+		schedule:  schedule,
+		run:       run,
+		exclusive: exclusive,
+	})
+	return nil
+}
+
+// Run starts jobs at their activations until ctx is cancelled, then waits up
+// to the drain timeout for running jobs to finish before cancelling them
+func (s *Scheduler) Run(ctx context.Context) {
+	// Runs outlive ctx so that they can be drained
+	runCtx, cancelRuns := context.WithCancel(context.Background())
+	defer cancelRuns()
+
+	var loops sync.WaitGroup
+	for _, e := range s.entries {
+		loops.Add(1)
+		go func(e *entry) {
+			defer loops.Done()
+			s.loop(ctx, runCtx, e)
+		}(e)
+	}
+	loops.Wait()
+
+	drained := make(chan struct{})
+	go func() {
+		s.active.Wait()
+		close(drained)
+	}()
+
+	timer := time.NewTimer(s.cfg.DrainTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+		s.logger.Info("Scheduled jobs drained")
+	case <-timer.C:
+		s.logger.Warn("Drain timeout exceeded, cancelling running jobs")
+		cancelRuns()
+		<-drained
+	}
+}
+
+// loop starts runs of e at each activation until ctx is cancelled
+func (s *Scheduler) loop(ctx, runCtx context.Context, e *entry) {
+	for {
+		next := e.schedule.Next(time.Now().In(s.location))
+		if next.IsZero() {
+			s.logger.Warn("Job has no further activations", zap.String("job", e.name))
+			return
+		}
+		nextRun.WithLabelValues(e.name).Set(float64(next.Unix()))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !e.running.CompareAndSwap(false, true) {
+			runs.WithLabelValues(e.name, statusOverlapped).Inc()
+			s.logger.Warn("Skipping activation, previous run still in progress", zap.String("job", e.name))
+			continue
+		}
+
+		s.active.Add(1)
+		go func() {
+			defer s.active.Done()
+			defer e.running.Store(false)
+			s.execute(runCtx, e)
+		}()
+	}
+}
+
+// execute performs one run of e, under the job's lock when it is exclusive
+func (s *Scheduler) execute(ctx context.Context, e *entry) {
+	if e.exclusive && s.locker != nil {
+		lock, err := s.locker.TryLock(ctx, jobs.LockKey(e.name))
+		if err != nil {
+			runs.WithLabelValues(e.name, statusFailed).Inc()
+			s.logger.Error("Failed to take job lock", zap.Error(err), zap.String("job", e.name))
+			return
+		}
+		if lock == nil {
+			runs.WithLabelValues(e.name, statusHeldElsewhere).Inc()
+			return
+		}
+		defer func() {
+			releaseCtx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+			defer cancel()
+			if err := lock.Release(releaseCtx); err != nil {
+				s.logger.Warn("Failed to release job lock", zap.Error(err), zap.String("job", e.name))
+			}
+		}()
+	}
+
+	running.WithLabelValues(e.name).Set(1)
+	defer running.WithLabelValues(e.name).Set(0)
+
+	start := time.Now()
+	err := e.run(ctx)
+	runDuration.WithLabelValues(e.name).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		runs.WithLabelValues(e.name, statusFailed).Inc()
+		s.logger.Error("Scheduled job failed",
+			zap.Error(err),
+			zap.String("job", e.name),
+			zap.Duration("duration", time.Since(start)),
+		)
+		return
+	}
+
+	runs.WithLabelValues(e.name, statusSuccess).Inc()
+	lastSuccess.WithLabelValues(e.name).SetToCurrentTime()
+}
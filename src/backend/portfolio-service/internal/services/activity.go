@@ -0,0 +1,86 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/pagination"
+)
+
+// GetActivityFeed returns a page of a portfolio's transactions, asset
+// additions and removals, triggered alerts and syncs of the given kinds,
+// newest first, and the token of the next page if any. No kinds lists all of
+// them.
+func (s *PortfolioService) GetActivityFeed(ctx context.Context, portfolioID uuid.UUID, kinds []string, pageSize int, pageToken string) ([]models.ActivityItem, string, error) {
+    if portfolioID == uuid.Nil {
+        return nil, "", ErrInvalidPortfolio
+    }
+    parsed, err := models.ParseActivityKinds(kinds)
+    if err != nil {
+        return nil, "", fmt.Errorf("%w: %w", ErrInvalidListQuery, err)
+    }
+    pageSize = pagination.Size(pageSize, pagination.MaxPageSize)
+
+    names := make([]string, len(parsed))
+    for i, kind := range parsed {
+        names[i] = string(kind)
+    }
+    filters := pagination.Fingerprint(portfolioID.String(), strings.Join(names, ","))
+    pos, err := s.decodePageToken(activityListScope, pageToken, filters)
+    if err != nil {
+        return nil, "", err
+    }
+    if pos.IsZero() {
+        pos = pagination.Position{At: time.Now().UTC()}
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    items, err := s.repo.ListActivity(ctx, portfolioID, parsed, pos, pageSize+1)
+    if err != nil {
+        return nil, "", fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    var nextToken string
+    if len(items) > pageSize {
+        items = items[:pageSize]
+        last := items[pageSize-1]
+        nextToken, err = s.encodePageToken(activityListScope, pagination.Position{At: last.OccurredAt, Key: string(last.Kind), ID: last.ID}, filters)
+        if err != nil {
+            return nil, "", err
+        }
+    }
+
+    return items, nextToken, nil
+}
+
+// recordSync stores a sync of an exchange account or tracked wallet in the
+// activity feed. The feed is informational, so failures are only logged.
+func (s *PortfolioService) recordSync(ctx context.Context, portfolioID uuid.UUID, source, name string, result *models.ExchangeImport, message string, at time.Time) {
+    event := &models.SyncEvent{
+        ID:          uuid.New(),
+        PortfolioID: portfolioID,
+        Source:      source,
+        Name:        name,
+        Error:       message,
+        SyncedAt:    at,
+    }
+    if result != nil {
+        event.Imported = result.TransactionsRecorded
+    }
+
+    if err := s.repo.RecordSyncEvent(ctx, event); err != nil {
+        s.logger.Warn("Failed to record sync event",
+            zap.Error(err),
+            zap.String("portfolio_id", portfolioID.String()),
+            zap.String("source", source),
+        )
+    }
+}
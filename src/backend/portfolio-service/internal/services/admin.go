@@ -0,0 +1,84 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// Caches reported as invalidated
+const (
+    cachePrices       = "prices"
+    cacheCorrelations = "correlations"
+)
+
+// GetUserStats counts a user's portfolios, assets and transactions and sums
+// the value of their active portfolios, for support tooling
+func (s *PortfolioService) GetUserStats(ctx context.Context, userID uuid.UUID) (*models.UserStats, error) {
+    if userID == uuid.Nil {
+        return nil, fmt.Errorf("%w: user ID is required", ErrInvalidPortfolio)
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    stats, err := s.repo.GetUserStats(ctx, userID)
+    if err != nil {
+        return nil, repositoryError(err)
+    }
+    stats.GeneratedAt = time.Now().UTC()
+    return stats, nil
+}
+
+// GetPlatformStats counts the active portfolios of all users and sums their
+// value as the assets under management, for ops tooling
+func (s *PortfolioService) GetPlatformStats(ctx context.Context) (*models.PlatformStats, error) {
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    stats, err := s.repo.GetPlatformStats(ctx)
+    if err != nil {
+        return nil, repositoryError(err)
+    }
+    stats.BaseCurrency = models.BASE_CURRENCY
+    stats.GeneratedAt = time.Now().UTC()
+    return stats, nil
+}
+
+// InvalidateCaches drops cached current prices of symbols, or the whole
+// in-process price cache without symbols, along with all cached correlation
+// matrices, and returns the names of the caches invalidated. Only this
+// replica's in-process caches are affected.
+func (s *PortfolioService) InvalidateCaches(ctx context.Context, symbols []string) ([]string, error) {
+    normalized := make([]string, 0, len(symbols))
+    for _, symbol := range symbols {
+        symbol = strings.ToUpper(strings.TrimSpace(symbol))
+        if symbol == "" {
+            return nil, fmt.Errorf("%w: symbols cannot be empty", ErrInvalidAsset)
+        }
+        normalized = append(normalized, symbol)
+    }
+
+    var invalidated []string
+    if s.prices != nil {
+        if err := s.prices.Invalidate(ctx, normalized); err != nil {
+            return nil, fmt.Errorf("failed to invalidate cached prices: %w", err)
+        }
+        invalidated = append(invalidated, cachePrices)
+    }
+    dropped := s.correlations.clear()
+    invalidated = append(invalidated, cacheCorrelations)
+
+    s.logger.Info("Invalidated caches",
+        zap.Strings("caches", invalidated),
+        zap.Strings("symbols", normalized),
+        zap.Int("correlations", dropped))
+
+    return invalidated, nil
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"                         // v1.3.0
+    "github.com/prometheus/client_golang/prometheus" // v1.14.0
+    "go.uber.org/zap"                               // v1.24.0
+
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/pagination"
+    "bookman/portfolio-service/internal/repository"
+)
+
+var alertsTriggered = prometheus.NewCounterVec(
+    prometheus.CounterOpts{
+        Name: "portfolio_alerts_triggered_total",
+        Help: "Total number of portfolio alert rules triggered, by kind",
+    },
+    []string{"kind"},
+)
+
+func init() {
+    metrics.MustRegister(alertsTriggered)
+}
+
+// CreateAlertRule validates and stores an alert rule on portfolio value, P&L
+// or stablecoin pegs
+func (s *PortfolioService) CreateAlertRule(ctx context.Context, rule *models.AlertRule) (*models.AlertRule, error) {
+    if rule == nil || rule.PortfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+    if err := rule.Validate(); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidAlertRule, err)
+    }
+
+    rule.ID = uuid.New()
+    rule.Enabled = true
+    rule.LastTriggeredAt = nil
+    rule.CreatedAt = time.Now().UTC()
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    if _, err := s.repo.GetPortfolio(ctx, rule.PortfolioID); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    err := s.repo.CreateAlertRule(ctx, rule)
+    if errors.Is(err, repository.ErrAlertRuleLimit) {
+        return nil, fmt.Errorf("%w: at most %d alert rules per portfolio", ErrLimitExceeded, models.MAX_ALERT_RULES_PER_PORTFOLIO)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    return rule, nil
+}
+
+// ListAlertRules returns the alert rules of a portfolio
+func (s *PortfolioService) ListAlertRules(ctx context.Context, portfolioID uuid.UUID) ([]models.AlertRule, error) {
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    rules, err := s.repo.GetAlertRules(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    return rules, nil
+}
+
+// DeleteAlertRule removes an alert rule of a portfolio
+func (s *PortfolioService) DeleteAlertRule(ctx context.Context, portfolioID, ruleID uuid.UUID) error {
+    if portfolioID == uuid.Nil || ruleID == uuid.Nil {
+        return ErrInvalidPortfolio
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    err := s.repo.DeleteAlertRule(ctx, portfolioID, ruleID)
+    if errors.Is(err, repository.ErrAlertRuleNotFound) {
+        return fmt.Errorf("%w: %v", ErrNotFound, err)
+    }
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    return nil
+}
+
+// ListAlertEvents returns a page of the triggered alerts of a portfolio before
+// the given time, newest first, and the token of the next page if any. A zero
+// time lists the latest events.
+func (s *PortfolioService) ListAlertEvents(ctx context.Context, portfolioID uuid.UUID, before time.Time, pageSize int, pageToken string) ([]models.AlertEvent, string, error) {
+    if portfolioID == uuid.Nil {
+        return nil, "", ErrInvalidPortfolio
+    }
+    pageSize = pagination.Size(pageSize, pagination.MaxPageSize)
+
+    filters := pagination.Fingerprint(portfolioID.String(), before.UTC().Format(time.RFC3339Nano))
+    pos, err := s.decodePageToken(alertEventListScope, pageToken, filters)
+    if err != nil {
+        return nil, "", err
+    }
+    if pos.IsZero() {
+        if before.IsZero() {
+            before = time.Now().UTC()
+        }
+        pos = pagination.Position{At: before}
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    events, err := s.repo.ListAlertEvents(ctx, portfolioID, pos, pageSize+1)
+    if err != nil {
+        return nil, "", fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    var nextToken string
+    if len(events) > pageSize {
+        events = events[:pageSize]
+        last := events[pageSize-1]
+        nextToken, err = s.encodePageToken(alertEventListScope, pagination.Position{At: last.TriggeredAt, ID: last.ID}, filters)
+        if err != nil {
+            return nil, "", err
+        }
+    }
+
+    return events, nextToken, nil
+}
+
+// evaluateAlerts checks the portfolio's alert rules against a newly captured
+// snapshot, records an event for each rule that triggers and notifies the
+// owner. Callers hold the service read lock.
+func (s *PortfolioService) evaluateAlerts(ctx context.Context, portfolio *models.Portfolio, snapshot *models.PortfolioSnapshot) error {
+    rules, err := s.repo.GetAlertRules(ctx, snapshot.PortfolioID)
+    if err != nil || len(rules) == 0 {
+        return err
+    }
+
+    obs := models.AlertObservation{
+        Current: models.ValuePoint{
+            Timestamp:  snapshot.CapturedAt,
+            Value:      snapshot.TotalValue,
+            ProfitLoss: snapshot.ProfitLoss,
+        },
+        Assets: snapshot.Assets,
+    }
+
+    // Load the previous snapshot only when an enabled P&L rule needs it
+    var needPrevious bool
+    for _, rule := range rules {
+        if rule.Enabled && (rule.Kind == models.AlertProfitLossAbove || rule.Kind == models.AlertProfitLossBelow) {
+            needPrevious = true
+        }
+    }
+
+    if needPrevious {
+        if obs.Previous, err = s.repo.GetPreviousSnapshot(ctx, snapshot.PortfolioID, snapshot.CapturedAt); err != nil {
+            return err
+        }
+    }
+
+    for _, rule := range rules {
+        if rule.Kind == models.AlertValueDrop && rule.Enabled {
+            peak, err := s.repo.GetSnapshotPeak(ctx, snapshot.PortfolioID, snapshot.CapturedAt.Add(-rule.Window), snapshot.CapturedAt)
+            if err != nil {
+                return err
+            }
+            obs.WindowPeak = peak
+        }
+
+        observed, triggered := rule.Evaluate(obs)
+        if !triggered {
+            continue
+        }
+
+        event := &models.AlertEvent{
+            ID:          uuid.New(),
+            RuleID:      rule.ID,
+            PortfolioID: snapshot.PortfolioID,
+            Kind:        rule.Kind,
+            Threshold:   rule.Threshold,
+            Observed:    observed,
+            TriggeredAt: snapshot.CapturedAt,
+        }
+        if rule.Kind == models.AlertStablecoinDepeg {
+            event.Symbol, _, _ = models.LargestPegDeviation(obs.Assets)
+        }
+        if err := s.repo.RecordAlertEvent(ctx, event); err != nil {
+            return err
+        }
+
+        alertsTriggered.WithLabelValues(string(rule.Kind)).Inc()
+        s.logger.Info("Portfolio alert triggered",
+            zap.String("portfolio_id", snapshot.PortfolioID.String()),
+            zap.String("rule_id", rule.ID.String()),
+            zap.String("kind", string(rule.Kind)),
+        )
+
+        s.notifier.Notify(alertNotification(portfolio.UserID, event))
+    }
+
+    return nil
+}
+
+// alertNotification describes a triggered alert for its portfolio owner
+func alertNotification(userID uuid.UUID, event *models.AlertEvent) models.Notification {
+    var subject, body string
+    switch event.Kind {
+    case models.AlertValueDrop:
+        subject = "Portfolio value dropped"
+        body = fmt.Sprintf("Portfolio value fell %s%% from its recent peak, beyond your %s%% alert.", event.Observed, event.Threshold)
+    case models.AlertStablecoinDepeg:
+        subject = fmt.Sprintf("%s lost its peg", event.Symbol)
+        body = fmt.Sprintf("%s trades %s%% away from its peg, beyond your %s%% alert.", event.Symbol, event.Observed, event.Threshold)
+    case models.AlertProfitLossAbove:
+        subject = "Portfolio P&L above alert level"
+        body = fmt.Sprintf("Unrealized P&L rose to %s, above your alert level of %s.", event.Observed, event.Threshold)
+    default:
+        subject = "Portfolio P&L below alert level"
+        body = fmt.Sprintf("Unrealized P&L fell to %s, below your alert level of %s.", event.Observed, event.Threshold)
+    }
+
+    return models.Notification{
+        Event:       models.EventAlertTriggered,
+        PortfolioID: event.PortfolioID,
+        UserID:      userID,
+        Subject:     subject,
+        Body:        body,
+        Attributes: map[string]string{
+            "rule_id":   event.RuleID.String(),
+            "kind":      string(event.Kind),
+            "threshold": event.Threshold.String(),
+            "observed":  event.Observed.String(),
+        },
+        CreatedAt: event.TriggeredAt,
+    }
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// GetAllocation returns the portfolio's value breakdown by symbol, asset type
+// and category, valued at the latest stored prices in the base currency.
+// Dust holdings are left out of the breakdown unless includeDust is set. A
+// non-empty tag limits the breakdown to the assets carrying it.
+func (s *PortfolioService) GetAllocation(ctx context.Context, portfolioID uuid.UUID, includeDust bool, tag string) (*models.Allocation, error) {
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    portfolio.CalculateTotalValue(s.getCurrentPrices(ctx, portfolio))
+
+    if tag != "" {
+        if _, err := s.LoadAssetTags(ctx, portfolio, tag); err != nil {
+            return nil, err
+        }
+    }
+
+    return s.buildAllocation(ctx, portfolio, includeDust)
+}
+
+// buildAllocation builds the allocation of a valued portfolio, leaving out
+// holdings below its dust threshold unless includeDust is set
+func (s *PortfolioService) buildAllocation(ctx context.Context, portfolio *models.Portfolio, includeDust bool) (*models.Allocation, error) {
+    if includeDust {
+        return models.BuildAllocation(portfolio.ID, portfolio.Assets), nil
+    }
+
+    settings, err := s.repo.GetDustSettings(ctx, portfolio.ID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    kept, _, dustValue := models.SplitDust(portfolio.Assets, *settings)
+    allocation := models.BuildAllocation(portfolio.ID, kept)
+    allocation.DustValue = dustValue
+    return allocation, nil
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "strings"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// SearchAssets finds every holding of symbol across the portfolios of a
+// user, so where an asset is held is answered in one call. Symbols match
+// case-insensitively.
+func (s *PortfolioService) SearchAssets(ctx context.Context, userID uuid.UUID, symbol string, includeArchived bool) (*models.AssetSearchResult, error) {
+    if userID == uuid.Nil {
+        return nil, fmt.Errorf("%w: user ID is required", ErrInvalidPortfolio)
+    }
+    symbol = strings.ToUpper(strings.TrimSpace(symbol))
+    if symbol == "" {
+        return nil, fmt.Errorf("%w: symbol is required", ErrInvalidAsset)
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    holdings, err := s.repo.SearchUserAssets(ctx, userID, symbol, includeArchived)
+    if err != nil {
+        return nil, repositoryError(err)
+    }
+
+    return models.NewAssetSearchResult(symbol, holdings), nil
+}
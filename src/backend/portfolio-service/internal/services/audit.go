@@ -0,0 +1,54 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/pagination"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// ListAuditEntries returns a page of the audit trail of a portfolio's rows
+// changed before the given time, newest first, and the token of the next page
+// if any. A zero time lists the latest changes.
+func (s *PortfolioService) ListAuditEntries(ctx context.Context, portfolioID uuid.UUID, before time.Time, pageSize int, pageToken string) ([]models.AuditEntry, string, error) {
+    if portfolioID == uuid.Nil {
+        return nil, "", ErrInvalidPortfolio
+    }
+    pageSize = pagination.Size(pageSize, pagination.MaxPageSize)
+
+    filters := pagination.Fingerprint(portfolioID.String(), before.UTC().Format(time.RFC3339Nano))
+    pos, err := s.decodePageToken(auditEntryListScope, pageToken, filters)
+    if err != nil {
+        return nil, "", err
+    }
+    if pos.IsZero() {
+        if before.IsZero() {
+            before = time.Now().UTC()
+        }
+        pos = pagination.Position{At: before}
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    entries, err := s.repo.ListAuditEntries(ctx, portfolioID, pos, pageSize+1)
+    if err != nil {
+        return nil, "", fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    var nextToken string
+    if len(entries) > pageSize {
+        entries = entries[:pageSize]
+        nextToken, err = s.encodePageToken(auditEntryListScope, repository.AuditPosition(&entries[pageSize-1]), filters)
+        if err != nil {
+            return nil, "", err
+        }
+    }
+
+    return entries, nextToken, nil
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// ComparePortfolio compares the latest snapshot of a portfolio at or before
+// from with the one at or before to, or with the current valuation when to
+// is unset or not yet past
+func (s *PortfolioService) ComparePortfolio(ctx context.Context, portfolioID uuid.UUID, from, to time.Time) (*models.PortfolioComparison, error) {
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+    now := time.Now().UTC()
+    if to.IsZero() || to.After(now) {
+        to = now
+    }
+    if !from.Before(to) {
+        return nil, fmt.Errorf("%w: from must be before to", ErrInvalidComparison)
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    start, err := s.repo.GetSnapshotAt(ctx, portfolioID, from)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    var end *models.PortfolioSnapshot
+    if to.Equal(now) {
+        end = s.valuePortfolio(ctx, portfolio, now)
+    } else {
+        end, err = s.repo.GetSnapshotAt(ctx, portfolioID, to)
+        if err != nil {
+            return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+        }
+        if end == nil {
+            return nil, fmt.Errorf("%w: no valuation recorded by the end of the window", ErrInsufficientData)
+        }
+    }
+
+    comparison, err := models.ComparePortfolioSnapshots(portfolioID, from, to, start, end, now)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidComparison, err)
+    }
+    return comparison, nil
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// costBasisPolicies holds the cost basis policies of credited positions
+type costBasisPolicies struct {
+    airdrop string
+    fork    string
+}
+
+// policy returns the cost basis policy of an action type
+func (p costBasisPolicies) policy(actionType string) string {
+    policy := p.airdrop
+    if actionType == "fork" {
+        policy = p.fork
+    }
+    if policy == "" {
+        return "zero"
+    }
+    return policy
+}
+
+// RecordCorporateAction credits the asset of an airdrop or fork as a new
+// position of the portfolio, costed by the configured policy, and records
+// the crediting transaction. The transaction price is the cost basis per
+// unit, so folding the transactions gives back the position's cost basis.
+// A fork allocating cost basis moves it from the parent asset.
+func (s *PortfolioService) RecordCorporateAction(ctx context.Context, action *models.CorporateAction) (*models.Asset, *models.Transaction, error) {
+    if action == nil || action.PortfolioID == uuid.Nil {
+        return nil, nil, ErrInvalidPortfolio
+    }
+    if err := action.Validate(); err != nil {
+        return nil, nil, fmt.Errorf("%w: %w", ErrInvalidCorporateAction, err)
+    }
+    if err := s.checkWritable(ctx, action.PortfolioID); err != nil {
+        return nil, nil, err
+    }
+
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, action.PortfolioID)
+    if err != nil {
+        return nil, nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    // A held symbol grows by transfers; credited positions are always new
+    for _, held := range portfolio.Assets {
+        if held.Type != "staked_asset" && strings.EqualFold(held.Symbol, action.Symbol) {
+            return nil, nil, fmt.Errorf("%w: %s is already held", ErrInvalidCorporateAction, action.Symbol)
+        }
+    }
+
+    var parent *models.Asset
+    if action.Type == "fork" {
+        held, err := portfolio.GetAsset(action.ParentAssetID)
+        if err != nil {
+            return nil, nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+        }
+        copied := *held
+        parent = &copied
+    }
+
+    policy := s.costBasis.policy(action.Type)
+    cost, parentCost, err := models.CorporateActionCostBasis(*action, policy, parent)
+    if err != nil {
+        return nil, nil, fmt.Errorf("%w: %w", ErrInvalidCorporateAction, err)
+    }
+
+    now := time.Now().UTC()
+    if action.Timestamp.IsZero() {
+        action.Timestamp = now
+    }
+
+    asset := &models.Asset{
+        ID:           uuid.New(),
+        Type:         action.AssetType,
+        Symbol:       action.Symbol,
+        Amount:       action.Amount,
+        CostBasis:    cost,
+        CurrentValue: action.Amount.Mul(action.Price),
+        LastUpdated:  now,
+    }
+    if err := portfolio.AddAsset(*asset); err != nil {
+        return nil, nil, fmt.Errorf("failed to add asset: %w", err)
+    }
+
+    t := &models.Transaction{
+        ID:          uuid.New(),
+        PortfolioID: action.PortfolioID,
+        AssetID:     asset.ID,
+        Type:        action.Type,
+        Amount:      action.Amount,
+        Price:       cost.Div(action.Amount),
+        Timestamp:   action.Timestamp,
+    }
+
+    // The parent is only written when the fork took some of its cost basis
+    if parent != nil && parentCost.Equal(parent.CostBasis) {
+        parent = nil
+    }
+    if parent != nil {
+        parent.CostBasis = parentCost
+    }
+
+    if s.eventSourced {
+        changes := []ledgerChange{{eventType: models.LedgerAssetAdded, data: asset}}
+        if parent != nil {
+            changes = append(changes, ledgerChange{
+                eventType: models.LedgerCostBasisAdjusted,
+                data:      models.LedgerCostBasis{AssetID: parent.ID, CostBasis: parent.CostBasis},
+            })
+        }
+        changes = append(changes, ledgerChange{eventType: models.LedgerTransactionRecorded, data: t})
+        err = s.appendLedger(ctx, action.PortfolioID, now, changes...)
+    } else {
+        err = s.repo.RecordCorporateAction(ctx, portfolio, asset, t, parent)
+    }
+
+    if err != nil {
+        s.logger.Error("Failed to record corporate action",
+            zap.Error(err),
+            zap.String("portfolio_id", action.PortfolioID.String()),
+            zap.String("type", action.Type),
+            zap.String("asset_symbol", action.Symbol),
+        )
+        return nil, nil, repositoryError(err)
+    }
+
+    s.logger.Info("Corporate action recorded",
+        zap.String("portfolio_id", action.PortfolioID.String()),
+        zap.String("type", action.Type),
+        zap.String("asset_symbol", action.Symbol),
+        zap.String("cost_basis_policy", policy),
+    )
+    s.emitWebhook(ctx, action.PortfolioID, models.WebhookAssetAdded, assetEventData(asset))
+    s.emitWebhook(ctx, action.PortfolioID, models.WebhookTransactionRecorded, transactionEventData(t, asset.Symbol))
+
+    return asset, t, nil
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/risk"
+)
+
+const (
+    defaultCorrelationWindowDays = 90
+    maxCorrelationWindowDays     = 3 * 365
+    maxCorrelationAssets         = 50
+
+    // Correlations are computed from daily closes, so results stay valid
+    // until the next close; the key also includes the window end day
+    correlationCacheTTL     = time.Hour
+    correlationCacheEntries = 1024
+)
+
+// correlationResult is a cached correlation computation for a set of symbols
+type correlationResult struct {
+    symbols      []string
+    values       [][]float64
+    observations int
+    uncovered    []string
+    expiresAt    time.Time
+}
+
+// correlationCache memoizes correlation matrices by symbol set and window,
+// so portfolios holding the same assets share one computation
+type correlationCache struct {
+    mutex   sync.Mutex
+    entries map[string]*correlationResult
+}
+
+// get returns an unexpired cached result
+func (c *correlationCache) get(key string, now time.Time) (*correlationResult, bool) {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    entry, ok := c.entries[key]
+    if !ok || now.After(entry.expiresAt) {
+        return nil, false
+    }
+    return entry, true
+}
+
+// put stores a result, evicting expired entries when the cache is full
+func (c *correlationCache) put(key string, result *correlationResult, now time.Time) {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    if c.entries == nil {
+        c.entries = make(map[string]*correlationResult)
+    }
+    if len(c.entries) >= correlationCacheEntries {
+        for k, entry := range c.entries {
+            if now.After(entry.expiresAt) {
+                delete(c.entries, k)
+            }
+        }
+        // Still full of live entries; drop an arbitrary one
+        for k := range c.entries {
+            if len(c.entries) < correlationCacheEntries {
+                break
+            }
+            delete(c.entries, k)
+        }
+    }
+    c.entries[key] = result
+}
+
+// clear drops every result and returns how many there were
+func (c *correlationCache) clear() int {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    dropped := len(c.entries)
+    c.entries = nil
+    return dropped
+}
+
+// GetCorrelationMatrix returns pairwise daily return correlations of the
+// portfolio's assets over the trailing window. Assets without price history
+// are excluded from the matrix and reported as uncovered.
+func (s *PortfolioService) GetCorrelationMatrix(ctx context.Context, portfolioID uuid.UUID, windowDays int) (*models.CorrelationMatrix, error) {
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+    if s.market == nil {
+        return nil, fmt.Errorf("%w: market data", ErrFeatureDisabled)
+    }
+
+    if windowDays <= 0 {
+        windowDays = defaultCorrelationWindowDays
+    }
+    if windowDays > maxCorrelationWindowDays {
+        return nil, fmt.Errorf("%w: window exceeds %d days", ErrInvalidPortfolio, maxCorrelationWindowDays)
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    seen := make(map[string]bool, len(portfolio.Assets))
+    symbols := make([]string, 0, len(portfolio.Assets))
+    for _, asset := range portfolio.Assets {
+        if !seen[asset.Symbol] && asset.Amount.IsPositive() {
+            seen[asset.Symbol] = true
+            symbols = append(symbols, asset.Symbol)
+        }
+    }
+    sort.Strings(symbols)
+
+    if len(symbols) > maxCorrelationAssets {
+        return nil, fmt.Errorf("%w: correlation supports at most %d assets", ErrInvalidPortfolio, maxCorrelationAssets)
+    }
+
+    now := time.Now().UTC()
+    end := now.Truncate(24 * time.Hour)
+    start := end.AddDate(0, 0, -windowDays)
+    key := fmt.Sprintf("%s|%d|%s", end.Format("2006-01-02"), windowDays, strings.Join(symbols, ","))
+
+    result, ok := s.correlations.get(key, now)
+    if !ok {
+        result, err = s.computeCorrelations(ctx, symbols, start, end)
+        if err != nil {
+            return nil, err
+        }
+        result.expiresAt = now.Add(correlationCacheTTL)
+        s.correlations.put(key, result, now)
+    }
+
+    return &models.CorrelationMatrix{
+        PortfolioID:      portfolioID,
+        Symbols:          result.symbols,
+        Values:           result.values,
+        Observations:     result.observations,
+        WindowStart:      start,
+        WindowEnd:        end,
+        UncoveredSymbols: result.uncovered,
+    }, nil
+}
+
+// computeCorrelations loads aligned returns of the symbols and correlates them
+func (s *PortfolioService) computeCorrelations(ctx context.Context, symbols []string, start, end time.Time) (*correlationResult, error) {
+    result := &correlationResult{}
+    if len(symbols) == 0 {
+        return result, nil
+    }
+
+    series, err := s.market.DailyReturns(ctx, symbols, start, end)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    for _, symbol := range symbols {
+        if _, ok := series.Returns[symbol]; ok {
+            result.symbols = append(result.symbols, symbol)
+        } else {
+            result.uncovered = append(result.uncovered, symbol)
+        }
+    }
+
+    values, err := risk.CorrelationMatrix(result.symbols, series.Returns)
+    if err != nil {
+        if errors.Is(err, risk.ErrInsufficientHistory) {
+            return nil, fmt.Errorf("%w: %d aligned daily returns, need %d", ErrInsufficientData, series.Len(), risk.MinCorrelationObservations)
+        }
+        return nil, fmt.Errorf("failed to compute correlations: %w", err)
+    }
+
+    result.values = values
+    result.observations = series.Len()
+    return result, nil
+}
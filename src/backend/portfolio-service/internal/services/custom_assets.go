@@ -0,0 +1,83 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// DefineCustomAsset validates and stores the definition of an asset no market
+// data provider lists, so holdings of it can be added to the portfolio with
+// the custom asset type. A non-nil price is stored as the asset's manual
+// price, valid from now.
+func (s *PortfolioService) DefineCustomAsset(ctx context.Context, asset *models.CustomAsset, price *decimal.Decimal) (*models.CustomAsset, error) {
+    if asset == nil || asset.PortfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+    if err := asset.Validate(); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidCustomAsset, err)
+    }
+
+    now := time.Now().UTC()
+    asset.ID = uuid.New()
+    asset.CreatedAt = now
+
+    var manual *models.ManualPrice
+    if price != nil {
+        manual = &models.ManualPrice{
+            ID:          uuid.New(),
+            PortfolioID: asset.PortfolioID,
+            Symbol:      asset.Symbol,
+            Price:       *price,
+            ValidFrom:   now,
+            CreatedAt:   now,
+        }
+        if err := manual.Validate(); err != nil {
+            return nil, fmt.Errorf("%w: %w", ErrInvalidManualPrice, err)
+        }
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    err := s.repo.InsertCustomAsset(ctx, asset, manual)
+    switch {
+    case errors.Is(err, repository.ErrPortfolioNotFound):
+        return nil, fmt.Errorf("%w: portfolio %s", ErrNotFound, asset.PortfolioID)
+    case errors.Is(err, repository.ErrCustomAssetExists):
+        return nil, ErrCustomAssetExists
+    case err != nil:
+        s.logger.Error("Failed to define custom asset",
+            zap.Error(err),
+            zap.String("portfolio_id", asset.PortfolioID.String()),
+            zap.String("symbol", asset.Symbol),
+        )
+        return nil, repositoryError(err)
+    }
+
+    return asset, nil
+}
+
+// checkCustomHolding verifies that a holding of the custom asset type is of a
+// custom asset the portfolio defines, with an amount of its precision
+func (s *PortfolioService) checkCustomHolding(ctx context.Context, portfolioID uuid.UUID, asset *models.Asset) error {
+    definition, err := s.repo.GetCustomAsset(ctx, portfolioID, asset.Symbol)
+    if errors.Is(err, repository.ErrCustomAssetNotFound) {
+        return fmt.Errorf("%w: %w: %s is not a custom asset of the portfolio", ErrInvalidAsset, models.ErrInvalidCustomAsset, asset.Symbol)
+    }
+    if err != nil {
+        return repositoryError(err)
+    }
+    if err := definition.ValidateAmount(asset.Amount); err != nil {
+        return fmt.Errorf("%w: %w", ErrInvalidAsset, err)
+    }
+    return nil
+}
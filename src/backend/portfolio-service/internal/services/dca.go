@@ -0,0 +1,150 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// DCARequest describes a dollar-cost averaging plan to generate. Without
+// targets, the portfolio's stored symbol targets are used.
+type DCARequest struct {
+    Contribution decimal.Decimal
+    Frequency    models.DCAFrequency
+    Start        time.Time
+    Periods      int
+    Targets      []models.AllocationTarget
+}
+
+// PlanDCA generates and stores a purchase schedule that invests a recurring
+// contribution toward target symbol weights, projecting the allocation after
+// each installment at current prices
+func (s *PortfolioService) PlanDCA(ctx context.Context, portfolioID uuid.UUID, req DCARequest) (*models.DCAPlan, error) {
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    targets := req.Targets
+    if len(targets) == 0 {
+        stored, err := s.repo.GetAllocationTargets(ctx, portfolioID)
+        if err != nil {
+            return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+        }
+        for _, target := range stored {
+            if target.Dimension == models.TargetBySymbol {
+                targets = append(targets, target)
+            }
+        }
+    }
+    if len(targets) == 0 {
+        return nil, fmt.Errorf("%w: no symbol targets given or stored", ErrInvalidDCAPlan)
+    }
+
+    targets, err := models.NormalizeTargets(targets)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidDCAPlan, err)
+    }
+
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    portfolio.CalculateTotalValue(s.getCurrentPrices(ctx, portfolio))
+
+    holdings := make(map[string]decimal.Decimal, len(portfolio.Assets))
+    for _, asset := range portfolio.Assets {
+        holdings[asset.Symbol] = holdings[asset.Symbol].Add(asset.CurrentValue)
+    }
+
+    start := req.Start
+    if start.IsZero() {
+        start = time.Now().UTC().Truncate(24 * time.Hour)
+    }
+
+    installments, err := models.BuildDCASchedule(holdings, req.Contribution, targets, req.Frequency, start, req.Periods)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidDCAPlan, err)
+    }
+
+    plan := &models.DCAPlan{
+        ID:           uuid.New(),
+        PortfolioID:  portfolioID,
+        Contribution: req.Contribution,
+        Frequency:    req.Frequency,
+        Targets:      targets,
+        Installments: installments,
+        CreatedAt:    time.Now().UTC(),
+    }
+
+    if err := s.repo.CreateDCAPlan(ctx, plan); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    s.logger.Info("DCA plan created",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.String("plan_id", plan.ID.String()),
+        zap.Int("installments", len(installments)),
+    )
+
+    return plan, nil
+}
+
+// GetDCAPlan returns a stored dollar-cost averaging plan
+func (s *PortfolioService) GetDCAPlan(ctx context.Context, planID uuid.UUID) (*models.DCAPlan, error) {
+    if planID == uuid.Nil {
+        return nil, ErrInvalidDCAPlan
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    plan, err := s.repo.GetDCAPlan(ctx, planID)
+    if errors.Is(err, repository.ErrDCAPlanNotFound) {
+        return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    return plan, nil
+}
+
+// MarkDCAInstallmentExecuted records that a scheduled contribution was made
+// and returns the updated plan
+func (s *PortfolioService) MarkDCAInstallmentExecuted(ctx context.Context, planID uuid.UUID, sequence int, at time.Time) (*models.DCAPlan, error) {
+    if planID == uuid.Nil || sequence <= 0 {
+        return nil, ErrInvalidDCAPlan
+    }
+    if at.IsZero() {
+        at = time.Now().UTC()
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    err := s.repo.MarkDCAInstallmentExecuted(ctx, planID, sequence, at)
+    if errors.Is(err, repository.ErrDCAInstallmentNotFound) {
+        return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    plan, err := s.repo.GetDCAPlan(ctx, planID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    return plan, nil
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+    "context"
+
+    "github.com/shopspring/decimal" // v1.3.1
+    "go.uber.org/zap"               // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// delistedSymbols returns the delisted symbols among those of the portfolio,
+// or none when delisting is not tracked or cannot be read
+func (s *PortfolioService) delistedSymbols(ctx context.Context, p *models.Portfolio, symbols []string) map[string]models.DelistedSymbol {
+    if s.delisting == "" {
+        return nil
+    }
+
+    delisted, err := s.repo.ListDelistedSymbols(ctx, symbols)
+    if err != nil {
+        s.logger.Warn("Failed to load delisted symbols",
+            zap.Error(err),
+            zap.String("portfolio_id", p.ID.String()),
+        )
+        return nil
+    }
+    return delisted
+}
+
+// applyDelisting marks the portfolio's assets of delisted symbols and values
+// those without a manual price by the delisted price policy, as of when the
+// symbol was last quoted
+func (s *PortfolioService) applyDelisting(p *models.Portfolio, prices map[string]decimal.Decimal, delisted map[string]models.DelistedSymbol) {
+    for symbol, d := range delisted {
+        if _, ok := prices[symbol]; !ok {
+            prices[symbol] = d.Price(s.delisting)
+        }
+    }
+
+    for i := range p.Assets {
+        d, ok := delisted[p.Assets[i].Symbol]
+        if !ok {
+            continue
+        }
+        p.Assets[i].Delisted = true
+        p.Assets[i].PriceStale = false
+        if p.Assets[i].PriceSource != models.MANUAL_PRICE_SOURCE {
+            asOf := d.LastQuotedAt.UTC()
+            p.Assets[i].PriceAsOf = &asOf
+        }
+    }
+}
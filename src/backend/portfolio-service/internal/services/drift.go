@@ -0,0 +1,72 @@
+package services
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// SetAllocationTargets validates and replaces the allocation targets of a
+// portfolio, returning the stored targets. An empty set clears all targets.
+func (s *PortfolioService) SetAllocationTargets(ctx context.Context, portfolioID uuid.UUID, targets []models.AllocationTarget) ([]models.AllocationTarget, error) {
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    normalized, err := models.NormalizeTargets(targets)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidTarget, err)
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    if _, err := s.repo.GetPortfolio(ctx, portfolioID); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    if err := s.repo.SetAllocationTargets(ctx, portfolioID, normalized); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    return normalized, nil
+}
+
+// GetDrift compares the portfolio's current allocation to its targets and
+// flags entries deviating by more than threshold percentage points. A zero
+// threshold selects models.DEFAULT_DRIFT_THRESHOLD.
+func (s *PortfolioService) GetDrift(ctx context.Context, portfolioID uuid.UUID, threshold decimal.Decimal) (*models.Drift, error) {
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+    if threshold.IsNegative() || threshold.GreaterThan(decimal.NewFromInt(100)) {
+        return nil, fmt.Errorf("%w: threshold must be within [0, 100]", ErrInvalidTarget)
+    }
+    if threshold.IsZero() {
+        threshold = models.DEFAULT_DRIFT_THRESHOLD
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    targets, err := s.repo.GetAllocationTargets(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    portfolio.CalculateTotalValue(s.getCurrentPrices(ctx, portfolio))
+
+    allocation, err := s.buildAllocation(ctx, portfolio, false)
+    if err != nil {
+        return nil, err
+    }
+    return models.ComputeDrift(allocation, targets, threshold), nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// SetDustSettings validates and stores the dust threshold of a portfolio.
+// Dust settings only shape responses, so they can be changed on read-only
+// portfolios too.
+func (s *PortfolioService) SetDustSettings(ctx context.Context, settings *models.DustSettings) (*models.DustSettings, error) {
+    if settings == nil || settings.PortfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+    if err := settings.Validate(); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidDustThreshold, err)
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    err := s.repo.SetDustSettings(ctx, settings)
+    if errors.Is(err, repository.ErrPortfolioNotFound) {
+        return nil, fmt.Errorf("%w: portfolio %s", ErrNotFound, settings.PortfolioID)
+    }
+    if err != nil {
+        s.logger.Error("Failed to set dust settings",
+            zap.Error(err),
+            zap.String("portfolio_id", settings.PortfolioID.String()),
+        )
+        return nil, repositoryError(err)
+    }
+
+    return settings, nil
+}
+
+// HideDust removes the dust holdings from a portfolio read for a response
+// when the portfolio hides dust. Holdings are judged by their stored
+// current values.
+func (s *PortfolioService) HideDust(ctx context.Context, portfolio *models.Portfolio) error {
+    if portfolio == nil {
+        return ErrInvalidPortfolio
+    }
+
+    settings, err := s.repo.GetDustSettings(ctx, portfolio.ID)
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    if !settings.Hidden {
+        return nil
+    }
+
+    kept, dust, _ := models.SplitDust(portfolio.Assets, *settings)
+    if len(dust) > 0 {
+        portfolio.Assets = kept
+    }
+    return nil
+}
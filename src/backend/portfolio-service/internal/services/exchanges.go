@@ -0,0 +1,433 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/exchanges"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// maxSyncErrorLength bounds the stored error of a failed sync
+const maxSyncErrorLength = 512
+
+// exchangeSettings holds the exchange import options of the service
+type exchangeSettings struct {
+    registry     *exchanges.Registry
+    cashAssets   []string
+    cipher       *exchanges.CredentialCipher
+    syncInterval time.Duration
+}
+
+// ImportExchange imports the balances, trades, rewards and transfers of an
+// exchange account after cursor into a portfolio. Held assets missing from the
+// portfolio are added with their exchange balance; activity is recorded as
+// transactions with IDs derived from the exchange references, so repeating an
+// import after a failure records nothing twice. The returned cursor resumes
+// the next import.
+func (s *PortfolioService) ImportExchange(ctx context.Context, portfolioID uuid.UUID, exchange string, creds exchanges.Credentials, cursor string) (*models.ExchangeImport, error) {
+    if s.exchanges == nil {
+        return nil, fmt.Errorf("%w: exchange import", ErrFeatureDisabled)
+    }
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+    if err := s.checkWritable(ctx, portfolioID); err != nil {
+        return nil, err
+    }
+
+    conn, err := s.exchanges.registry.Connect(exchange, creds)
+    if err != nil {
+        return nil, err
+    }
+
+    return s.importExchange(ctx, portfolioID, exchange, conn, cursor)
+}
+
+// ConnectExchange verifies read-only credentials of an exchange account and
+// stores them encrypted for background sync, which starts right away.
+// Reconnecting an exchange replaces its credentials.
+func (s *PortfolioService) ConnectExchange(ctx context.Context, portfolioID uuid.UUID, exchange string, creds exchanges.Credentials) (*models.ExchangeAccount, error) {
+    if s.exchanges == nil || s.exchanges.cipher == nil {
+        return nil, fmt.Errorf("%w: exchange account sync", ErrFeatureDisabled)
+    }
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+    if _, err := s.GetPortfolio(ctx, portfolioID); err != nil {
+        return nil, err
+    }
+    if err := s.checkWritable(ctx, portfolioID); err != nil {
+        return nil, err
+    }
+
+    conn, err := s.exchanges.registry.Connect(exchange, creds)
+    if err != nil {
+        return nil, err
+    }
+    if err := conn.Authenticate(ctx); err != nil {
+        return nil, fmt.Errorf("failed to authenticate with %s: %w", exchange, err)
+    }
+
+    sealed, err := s.exchanges.cipher.Seal(ctx, portfolioID, exchange, creds)
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now().UTC()
+    account := &models.ExchangeAccount{
+        ID:          uuid.New(),
+        PortfolioID: portfolioID,
+        Exchange:    exchange,
+        Credentials: sealed,
+        NextSyncAt:  now,
+        CreatedAt:   now,
+    }
+    if err := s.repo.UpsertExchangeAccount(ctx, account); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    account.Credentials = nil
+
+    s.logger.Info("Exchange account connected",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.String("exchange", exchange),
+        zap.String("account_id", account.ID.String()),
+    )
+
+    return account, nil
+}
+
+// ListExchangeAccounts returns the exchange accounts connected to a portfolio
+// with their sync status
+func (s *PortfolioService) ListExchangeAccounts(ctx context.Context, portfolioID uuid.UUID) ([]models.ExchangeAccount, error) {
+    if s.exchanges == nil || s.exchanges.cipher == nil {
+        return nil, fmt.Errorf("%w: exchange account sync", ErrFeatureDisabled)
+    }
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    accounts, err := s.repo.ListExchangeAccounts(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    return accounts, nil
+}
+
+// DisconnectExchange stops syncing an exchange account and discards its
+// credentials. Imported transactions are kept.
+func (s *PortfolioService) DisconnectExchange(ctx context.Context, portfolioID uuid.UUID, exchange string) error {
+    if s.exchanges == nil || s.exchanges.cipher == nil {
+        return fmt.Errorf("%w: exchange account sync", ErrFeatureDisabled)
+    }
+    if portfolioID == uuid.Nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := s.repo.DeleteExchangeAccount(ctx, portfolioID, exchange)
+    if errors.Is(err, repository.ErrExchangeAccountNotFound) {
+        return fmt.Errorf("%w: %v", ErrNotFound, err)
+    }
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    s.logger.Info("Exchange account disconnected",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.String("exchange", exchange),
+    )
+
+    return nil
+}
+
+// SyncExchange imports the activity of a connected exchange account since its
+// last checkpoint without waiting for the background sync. Like the sync
+// worker, it is allowed to decrypt the account's stored credentials.
+func (s *PortfolioService) SyncExchange(ctx context.Context, portfolioID uuid.UUID, exchange string) (*models.ExchangeImport, error) {
+    if s.exchanges == nil || s.exchanges.cipher == nil {
+        return nil, fmt.Errorf("%w: exchange account sync", ErrFeatureDisabled)
+    }
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    account, err := s.repo.GetExchangeAccount(ctx, portfolioID, exchange)
+    if errors.Is(err, repository.ErrExchangeAccountNotFound) {
+        return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    return s.SyncExchangeAccount(exchanges.WithSyncAccess(ctx), account)
+}
+
+// SyncExchangeAccount imports the activity of a stored exchange account from
+// its checkpoint. On success the new checkpoint is saved and the next sync is
+// scheduled after the sync interval; on failure the error is recorded and the
+// checkpoint kept, so the next sync retries the same range.
+func (s *PortfolioService) SyncExchangeAccount(ctx context.Context, account *models.ExchangeAccount) (*models.ExchangeImport, error) {
+    if s.exchanges == nil || s.exchanges.cipher == nil {
+        return nil, fmt.Errorf("%w: exchange account sync", ErrFeatureDisabled)
+    }
+    if account == nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    result, err := s.syncExchangeAccount(ctx, account)
+    now := time.Now().UTC()
+    next := now.Add(s.exchanges.syncInterval)
+    if err != nil {
+        message := err.Error()
+        if len(message) > maxSyncErrorLength {
+            message = message[:maxSyncErrorLength]
+        }
+        if recordErr := s.repo.RecordExchangeSyncError(ctx, account.ID, message, next); recordErr != nil {
+            s.logger.Error("Failed to record exchange sync error",
+                zap.Error(recordErr),
+                zap.String("account_id", account.ID.String()),
+            )
+        }
+        s.recordSync(ctx, account.PortfolioID, models.SyncSourceExchange, account.Exchange, nil, message, now)
+        return nil, err
+    }
+
+    if err := s.repo.SaveExchangeCheckpoint(ctx, account.ID, result.Cursor, now, next); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    s.recordSync(ctx, account.PortfolioID, models.SyncSourceExchange, account.Exchange, result, "", now)
+    account.Cursor = result.Cursor
+    account.LastSyncedAt = &now
+    account.LastError = ""
+    account.NextSyncAt = next
+
+    return result, nil
+}
+
+// syncExchangeAccount opens the credentials of an account and imports from its
+// checkpoint. Credentials only open for contexts granted sync access.
+func (s *PortfolioService) syncExchangeAccount(ctx context.Context, account *models.ExchangeAccount) (*models.ExchangeImport, error) {
+    creds, err := s.exchanges.cipher.Open(ctx, account.PortfolioID, account.Exchange, account.Credentials)
+    if err != nil {
+        return nil, err
+    }
+
+    conn, err := s.exchanges.registry.Connect(account.Exchange, creds)
+    if err != nil {
+        return nil, err
+    }
+
+    return s.importExchange(ctx, account.PortfolioID, account.Exchange, conn, account.Cursor)
+}
+
+// importExchange imports exchange activity after cursor through a connector
+func (s *PortfolioService) importExchange(ctx context.Context, portfolioID uuid.UUID, exchange string, conn exchanges.Connector, cursor string) (*models.ExchangeImport, error) {
+    // Exchange calls are slow and paginated, so they run before any lock is held
+    balances, err := conn.FetchBalances(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch %s balances: %w", exchange, err)
+    }
+    activity, next, err := conn.FetchTrades(ctx, cursor)
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch %s activity: %w", exchange, err)
+    }
+
+    mapper := models.NewExchangeMapper(s.exchanges.cashAssets, func(asset string, at time.Time) (decimal.Decimal, error) {
+        return conn.PriceUSD(ctx, asset, at)
+    })
+    legs, err := mapper.MapActivity(activity)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidTransaction, err)
+    }
+
+    result := &models.ExchangeImport{
+        PortfolioID:    portfolioID,
+        Exchange:       exchange,
+        Fills:          len(activity.Fills),
+        Conversions:    len(activity.Conversions),
+        Entries:        len(activity.Entries),
+        SkippedSymbols: []string{},
+        Cursor:         next,
+    }
+
+    rewards, err := s.exchangeStakingAssets(ctx, portfolioID, exchange)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := s.recordLegs(ctx, portfolioID, exchange, mapper, balances, legs, []string{"buy"}, rewards, result); err != nil {
+        return nil, err
+    }
+
+    s.logger.Info("Exchange account imported",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.String("exchange", exchange),
+        zap.Int("fills", result.Fills),
+        zap.Int("conversions", result.Conversions),
+        zap.Int("entries", result.Entries),
+        zap.Int("transactions", result.TransactionsRecorded),
+        zap.Int("duplicates", result.Duplicates),
+        zap.Strings("skipped_symbols", result.SkippedSymbols),
+    )
+
+    return result, nil
+}
+
+// recordLegs adds the held assets missing from a portfolio and records the
+// legs not recorded yet as transactions, updating result. It holds the
+// portfolio lock throughout, so concurrent syncs of one portfolio neither
+// add an asset twice nor record a leg twice. source namespaces
+// the transaction IDs; legs of the acquisition types make up the cost basis of
+// added assets. Reward legs of the symbols in rewards are recorded on the
+// staked assets given there.
+func (s *PortfolioService) recordLegs(ctx context.Context, portfolioID uuid.UUID, source string, mapper *models.ExchangeMapper, balances []models.ExchangeBalance, legs []models.ExchangeLeg, acquisitions []string, rewards map[string]uuid.UUID, result *models.ExchangeImport) error {
+    unlock, err := s.lockPortfolio(ctx, portfolioID)
+    if err != nil {
+        return err
+    }
+    defer unlock()
+
+    assets, created, err := s.importExchangeAssets(ctx, portfolioID, mapper, balances, legs, acquisitions)
+    if err != nil {
+        return err
+    }
+    result.AssetsCreated = created
+
+    recorded := make(map[uuid.UUID]bool)
+    if len(legs) > 0 {
+        // Legs are ordered by time; the end of the range is exclusive
+        end := legs[len(legs)-1].Timestamp.Add(time.Nanosecond)
+        recorded, err = s.existingTransactionIDs(ctx, portfolioID, legs[0].Timestamp, end)
+        if err != nil {
+            return err
+        }
+    }
+
+    skipped := make(map[string]bool)
+    for _, leg := range legs {
+        assetID, ok := assets[strings.ToUpper(leg.Symbol)]
+        if staked, routed := rewards[strings.ToUpper(leg.Symbol)]; routed && leg.Type == "reward" {
+            assetID, ok = staked, true
+        }
+        if !ok {
+            skipped[leg.Symbol] = true
+            continue
+        }
+
+        id := leg.TransactionID(portfolioID, source)
+        if recorded[id] {
+            result.Duplicates++
+            continue
+        }
+
+        _, err := s.RecordTransaction(ctx, &models.Transaction{
+            ID:          id,
+            PortfolioID: portfolioID,
+            AssetID:     assetID,
+            Type:        leg.Type,
+            Amount:      leg.Amount,
+            Price:       leg.Price,
+            Fee:         leg.Fee,
+            Timestamp:   leg.Timestamp,
+        })
+        if err != nil {
+            return fmt.Errorf("failed to record %s %s: %w", source, leg.Ref, err)
+        }
+        recorded[id] = true
+        result.TransactionsRecorded++
+    }
+
+    for symbol := range skipped {
+        result.SkippedSymbols = append(result.SkippedSymbols, symbol)
+    }
+    sort.Strings(result.SkippedSymbols)
+    result.ImportedAt = time.Now().UTC()
+
+    return nil
+}
+
+// importExchangeAssets returns portfolio asset IDs by upper-case symbol after
+// adding held exchange assets the portfolio does not track yet. Assets that
+// only appear in activity and are no longer held cannot be added, as assets
+// need a positive amount; their legs are skipped. The cost basis of an added
+// asset is the value of its legs of the acquisition types. Staked assets
+// only take reward legs routed to them, so held balances of their symbol
+// are added as separate assets.
+func (s *PortfolioService) importExchangeAssets(ctx context.Context, portfolioID uuid.UUID, mapper *models.ExchangeMapper, balances []models.ExchangeBalance, legs []models.ExchangeLeg, acquisitions []string) (map[string]uuid.UUID, int, error) {
+    portfolio, err := s.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, 0, err
+    }
+
+    assets := make(map[string]uuid.UUID, len(portfolio.Assets))
+    for _, asset := range portfolio.Assets {
+        if asset.Type != "staked_asset" {
+            assets[strings.ToUpper(asset.Symbol)] = asset.ID
+        }
+    }
+
+    acquired := make(map[string]bool, len(acquisitions))
+    for _, t := range acquisitions {
+        acquired[t] = true
+    }
+
+    created := 0
+    for _, balance := range balances {
+        symbol := strings.ToUpper(balance.Asset)
+        if _, ok := assets[symbol]; ok || mapper.IsCash(symbol) {
+            continue
+        }
+
+        // Exchanges may report more digits than the asset is divided into;
+        // balances below its smallest unit are dust left unlisted
+        amount := balance.Total().Truncate(models.AmountDecimals("cryptocurrency", symbol))
+        if !amount.IsPositive() {
+            continue
+        }
+
+        var cost decimal.Decimal
+        for _, leg := range legs {
+            if strings.EqualFold(leg.Symbol, symbol) && acquired[leg.Type] {
+                cost = cost.Add(leg.Amount.Mul(leg.Price)).Add(leg.Fee)
+            }
+        }
+
+        asset := &models.Asset{
+            Type:      "cryptocurrency",
+            Symbol:    symbol,
+            Amount:    amount,
+            CostBasis: cost,
+        }
+        if err := s.AddAsset(ctx, portfolioID, asset); err != nil {
+            return nil, 0, fmt.Errorf("failed to add %s: %w", symbol, err)
+        }
+        assets[symbol] = asset.ID
+        created++
+    }
+
+    return assets, created, nil
+}
+
+// existingTransactionIDs returns the IDs of transactions recorded within
+// [start, end)
+func (s *PortfolioService) existingTransactionIDs(ctx context.Context, portfolioID uuid.UUID, start, end time.Time) (map[uuid.UUID]bool, error) {
+    txs, err := s.transactions(ctx, portfolioID, start, end)
+    if err != nil {
+        return nil, err
+    }
+
+    ids := make(map[uuid.UUID]bool, len(txs))
+    for _, tx := range txs {
+        ids[tx.ID] = true
+    }
+    return ids, nil
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "io"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/export"
+)
+
+// ExportTransactions renders the portfolio's transactions recorded within
+// [start, end) in the requested export format. A zero end defaults to now.
+func (s *PortfolioService) ExportTransactions(ctx context.Context, portfolioID uuid.UUID, format export.Format, start, end time.Time) ([]byte, error) {
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    if end.IsZero() {
+        end = time.Now().UTC()
+    }
+    if !start.Before(end) {
+        return nil, fmt.Errorf("%w: export start must be before end", ErrInvalidTransaction)
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    txs, err := s.transactions(ctx, portfolioID, start, end)
+    if err != nil {
+        return nil, err
+    }
+
+    symbols := make(map[uuid.UUID]string, len(portfolio.Assets))
+    for _, asset := range portfolio.Assets {
+        symbols[asset.ID] = asset.Symbol
+    }
+
+    var buf bytes.Buffer
+    if err := export.WriteTransactions(&buf, format, txs, symbols); err != nil {
+        s.logger.Error("Failed to export transactions",
+            zap.Error(err),
+            zap.String("portfolio_id", portfolioID.String()),
+            zap.String("format", string(format)),
+        )
+        return nil, fmt.Errorf("failed to export transactions: %w", err)
+    }
+
+    s.logger.Info("Transactions exported",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.String("format", string(format)),
+        zap.Int("count", len(txs)),
+    )
+
+    return buf.Bytes(), nil
+}
+
+// ExportTransactionsStream writes the portfolio's transactions recorded
+// within [start, end) to w in the requested export format, reading and
+// rendering them one calendar month at a time so multi-year histories are
+// never held in memory at once. A zero start exports from the first
+// transaction and a zero end defaults to now. Output is written as it is
+// rendered, so w sees partial output when rendering fails.
+func (s *PortfolioService) ExportTransactionsStream(ctx context.Context, portfolioID uuid.UUID, format export.Format, start, end time.Time, w io.Writer) error {
+    if portfolioID == uuid.Nil || w == nil {
+        return ErrInvalidPortfolio
+    }
+
+    if end.IsZero() {
+        end = time.Now().UTC()
+    }
+    if !start.Before(end) {
+        return fmt.Errorf("%w: export start must be before end", ErrInvalidTransaction)
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    symbols := make(map[uuid.UUID]string, len(portfolio.Assets))
+    for _, asset := range portfolio.Assets {
+        symbols[asset.ID] = asset.Symbol
+    }
+
+    first, err := s.repo.GetFirstTransactionTime(ctx, portfolioID)
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    if first.After(start) {
+        start = first
+    }
+
+    tw, err := export.NewTransactionWriter(w, format, symbols)
+    if err != nil {
+        return fmt.Errorf("failed to export transactions: %w", err)
+    }
+
+    var count int
+    for from := start; !first.IsZero() && from.Before(end); {
+        to := time.Date(from.Year(), from.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+        if to.After(end) {
+            to = end
+        }
+
+        txs, err := s.transactions(ctx, portfolioID, from, to)
+        if err != nil {
+            return err
+        }
+        if err := tw.Write(txs); err != nil {
+            s.logger.Error("Failed to export transactions",
+                zap.Error(err),
+                zap.String("portfolio_id", portfolioID.String()),
+                zap.String("format", string(format)),
+            )
+            return fmt.Errorf("failed to export transactions: %w", err)
+        }
+        // Hand each month to w as it is rendered
+        if err := tw.Flush(); err != nil {
+            return fmt.Errorf("failed to export transactions: %w", err)
+        }
+        count += len(txs)
+        from = to
+    }
+    if err := tw.Flush(); err != nil {
+        return fmt.Errorf("failed to export transactions: %w", err)
+    }
+
+    s.logger.Info("Transactions exported",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.String("format", string(format)),
+        zap.Int("count", count),
+        zap.Bool("streamed", true),
+    )
+
+    return nil
+}
+
+// ExportPortfolio writes the portfolio's current holdings and its full
+// transaction history, including archived history, to w in the requested
+// format. Output is written as it is rendered, so w sees partial output when
+// rendering fails.
+func (s *PortfolioService) ExportPortfolio(ctx context.Context, portfolioID uuid.UUID, format export.PortfolioFormat, w io.Writer) error {
+    if portfolioID == uuid.Nil || w == nil {
+        return ErrInvalidPortfolio
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    txs, err := s.transactions(ctx, portfolioID, time.Time{}, time.Now().UTC())
+    if err != nil {
+        return err
+    }
+
+    if err := export.WritePortfolio(w, format, portfolio, txs); err != nil {
+        s.logger.Error("Failed to export portfolio",
+            zap.Error(err),
+            zap.String("portfolio_id", portfolioID.String()),
+            zap.String("format", string(format)),
+        )
+        return fmt.Errorf("failed to export portfolio: %w", err)
+    }
+
+    s.logger.Info("Portfolio exported",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.String("format", string(format)),
+        zap.Int("assets", len(portfolio.Assets)),
+        zap.Int("transactions", len(txs)),
+    )
+
+    return nil
+}
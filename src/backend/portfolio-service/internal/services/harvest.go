@@ -0,0 +1,79 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// SuggestTaxLossHarvests returns the sells of a portfolio's assets that
+// would realize a loss of at least minLoss and minLossPercentage of its
+// cost at current prices, largest loss first, with the tax they would save
+// at taxRate and, where the owner's jurisdiction has a wash-sale rule,
+// purchases that could make them wash sales. A zero taxRate uses the
+// configured estimate.
+func (s *PortfolioService) SuggestTaxLossHarvests(ctx context.Context, portfolioID uuid.UUID, minLoss, minLossPercentage, taxRate decimal.Decimal) ([]models.HarvestCandidate, error) {
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+    if taxRate.IsZero() {
+        taxRate = decimal.NewFromFloat(s.tax.estimatedRate)
+    }
+    criteria := models.HarvestCriteria{
+        MinLoss:           minLoss,
+        MinLossPercentage: minLossPercentage,
+        TaxRate:           taxRate,
+        WashSaleWindow:    s.tax.washSaleWindow,
+    }
+    if err := criteria.Validate(); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidHarvestCriteria, err)
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    settings, err := s.userTaxSettings(ctx, portfolio.UserID)
+    if err != nil {
+        return nil, err
+    }
+    rule := s.tax.washSaleRule(settings)
+    if rule == nil {
+        criteria.WashSaleWindow = 0
+    }
+
+    assetIDs := make([]uuid.UUID, len(portfolio.Assets))
+    for i, asset := range portfolio.Assets {
+        assetIDs[i] = asset.ID
+    }
+    replays, err := s.repo.GetLotReplays(ctx, portfolioID, assetIDs, rule)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    now := time.Now().UTC()
+    recent, err := s.transactions(ctx, portfolioID, now.Add(-criteria.WashSaleWindow), now)
+    if err != nil {
+        return nil, err
+    }
+
+    prices := s.getCurrentPrices(ctx, portfolio)
+    candidates := []models.HarvestCandidate{}
+    for _, asset := range portfolio.Assets {
+        candidate, ok := models.SuggestHarvest(asset, replays[asset.ID], prices[asset.Symbol], recent, criteria, now)
+        if ok {
+            candidates = append(candidates, candidate)
+        }
+    }
+    models.SortHarvestCandidates(candidates)
+
+    return candidates, nil
+}
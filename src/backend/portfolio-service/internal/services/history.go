@@ -0,0 +1,54 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// maxHistoryPoints bounds the number of buckets a single history query may return
+const maxHistoryPoints = 2000
+
+// bucketWidth approximates the duration of one bucket of each interval
+var bucketWidth = map[models.HistoryInterval]time.Duration{
+    models.HistoryIntervalDay:   24 * time.Hour,
+    models.HistoryIntervalWeek:  7 * 24 * time.Hour,
+    models.HistoryIntervalMonth: 30 * 24 * time.Hour,
+}
+
+// GetValueHistory returns the downsampled portfolio value series between start
+// and end, one closing value per interval bucket, for charting
+func (s *PortfolioService) GetValueHistory(ctx context.Context, portfolioID uuid.UUID, interval models.HistoryInterval, start, end time.Time) ([]models.ValuePoint, error) {
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    width, ok := bucketWidth[interval]
+    if !ok {
+        return nil, fmt.Errorf("%w: unsupported history interval %q", ErrInvalidPortfolio, interval)
+    }
+
+    if end.IsZero() {
+        end = time.Now().UTC()
+    }
+    if !start.Before(end) {
+        return nil, fmt.Errorf("%w: history start must be before end", ErrInvalidPortfolio)
+    }
+    if end.Sub(start)/width > maxHistoryPoints {
+        return nil, fmt.Errorf("%w: range exceeds %d %s buckets", ErrInvalidPortfolio, maxHistoryPoints, interval)
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    points, err := s.repo.GetValueHistory(ctx, portfolioID, interval, start, end)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    return points, nil
+}
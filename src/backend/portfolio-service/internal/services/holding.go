@@ -0,0 +1,57 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// GetHoldingPeriods classifies the open lots of a portfolio as short- or
+// long-term and splits its unrealized profit and loss at current prices,
+// and the gains of its sells within [start, end), by holding term, under
+// the tax settings of its owner. Where their jurisdiction has a wash-sale
+// rule, the losses of sells replaced within its window are disallowed and
+// flagged. Sells whose history was
+// archived are summarized into their lots and not split. An unset end
+// reports up to now.
+func (s *PortfolioService) GetHoldingPeriods(ctx context.Context, portfolioID uuid.UUID, start, end time.Time) (*models.HoldingPeriodReport, error) {
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+    now := time.Now().UTC()
+    if end.IsZero() {
+        end = now
+    }
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    settings, err := s.userTaxSettings(ctx, portfolio.UserID)
+    if err != nil {
+        return nil, err
+    }
+
+    assetIDs := make([]uuid.UUID, len(portfolio.Assets))
+    for i, asset := range portfolio.Assets {
+        assetIDs[i] = asset.ID
+    }
+    replays, err := s.repo.GetLotReplays(ctx, portfolioID, assetIDs, s.tax.washSaleRule(settings))
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    prices := s.getCurrentPrices(ctx, portfolio)
+    report, err := models.BuildHoldingPeriodReport(portfolioID, portfolio.Assets, replays, prices, settings.LongTermHoldingPeriod(), start, end, now)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidHoldingPeriod, err)
+    }
+
+    return report, nil
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/importer"
+    "bookman/portfolio-service/internal/models"
+)
+
+// maxImportRowErrors bounds the row errors reported for one file
+const maxImportRowErrors = 1000
+
+// ImportTransactions validates every row of a CSV transaction file against
+// the portfolio and records them as transactions when all rows are valid and
+// dryRun is not set. Rows must reference assets held in the portfolio. Each
+// row's transaction ID is derived from its reference column or content, so
+// importing the same file again reports its rows as duplicates.
+func (s *PortfolioService) ImportTransactions(ctx context.Context, portfolioID uuid.UUID, file io.Reader, mapping importer.Mapping, dryRun bool) (*models.TransactionImport, error) {
+    if portfolioID == uuid.Nil || file == nil {
+        return nil, ErrInvalidPortfolio
+    }
+    if err := s.checkWritable(ctx, portfolioID); err != nil {
+        return nil, err
+    }
+
+    parsed, err := importer.ParseTransactions(file, mapping)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidTransaction, err)
+    }
+
+    portfolio, err := s.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, err
+    }
+    assets := make(map[string]*models.Asset, len(portfolio.Assets))
+    for i := range portfolio.Assets {
+        assets[strings.ToUpper(portfolio.Assets[i].Symbol)] = &portfolio.Assets[i]
+    }
+
+    result := &models.TransactionImport{
+        PortfolioID: portfolioID,
+        Rows:        len(parsed.Rows) + len(parsed.Errors) + parsed.Skipped,
+        Skipped:     parsed.Skipped,
+        Errors:      []models.ImportRowError{},
+    }
+    reject := func(line int, column, message string) {
+        result.Invalid++
+        if len(result.Errors) < maxImportRowErrors {
+            result.Errors = append(result.Errors, models.ImportRowError{Line: line, Column: column, Message: message})
+        }
+    }
+    for _, e := range parsed.Errors {
+        reject(e.Line, e.Column, e.Message)
+    }
+
+    txs := make([]*models.Transaction, 0, len(parsed.Rows))
+    occurrences := make(map[string]int)
+    for _, row := range parsed.Rows {
+        tx, rowErr := importedTransaction(portfolioID, row, assets, mapping, occurrences)
+        if rowErr != nil {
+            reject(rowErr.Line, rowErr.Column, rowErr.Message)
+            continue
+        }
+        txs = append(txs, tx)
+    }
+    result.Valid = len(txs)
+    sort.Slice(result.Errors, func(i, j int) bool {
+        return result.Errors[i].Line < result.Errors[j].Line
+    })
+
+    if dryRun || result.Invalid > 0 || len(txs) == 0 {
+        result.ImportedAt = time.Now().UTC()
+        return result, nil
+    }
+
+    sort.SliceStable(txs, func(i, j int) bool {
+        return txs[i].Timestamp.Before(txs[j].Timestamp)
+    })
+
+    // Duplicates are detected and recorded under one lock, so concurrent
+    // imports of the same file do not both record its rows
+    unlock, err := s.lockPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, err
+    }
+    defer unlock()
+
+    recorded, err := s.existingTransactionIDs(ctx, portfolioID, txs[0].Timestamp, txs[len(txs)-1].Timestamp.Add(time.Nanosecond))
+    if err != nil {
+        return nil, err
+    }
+
+    for _, tx := range txs {
+        if recorded[tx.ID] {
+            result.Duplicates++
+            continue
+        }
+        if _, err := s.RecordTransaction(ctx, tx); err != nil {
+            return nil, fmt.Errorf("failed to record imported transaction %s: %w", tx.ID, err)
+        }
+        recorded[tx.ID] = true
+        result.TransactionsRecorded++
+    }
+    result.Committed = true
+    result.ImportedAt = time.Now().UTC()
+
+    s.logger.Info("Transactions imported",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.Int("rows", result.Rows),
+        zap.Int("skipped", result.Skipped),
+        zap.Int("duplicates", result.Duplicates),
+        zap.Int("transactions", result.TransactionsRecorded),
+    )
+
+    return result, nil
+}
+
+// importedTransaction converts a parsed row into a transaction of the
+// portfolio asset of its symbol. The transaction ID is derived from the row's
+// reference, or from its content and the number of identical rows seen so far
+// in occurrences.
+func importedTransaction(portfolioID uuid.UUID, row importer.Row, assets map[string]*models.Asset, mapping importer.Mapping, occurrences map[string]int) (*models.Transaction, *models.ImportRowError) {
+    asset, ok := assets[row.Symbol]
+    if !ok {
+        return nil, &models.ImportRowError{Line: row.Line, Column: mapping.Symbol, Message: fmt.Sprintf("asset %s is not held in the portfolio", row.Symbol)}
+    }
+    if err := models.ValidateTransactionType(row.Type, asset.Type); err != nil {
+        return nil, &models.ImportRowError{Line: row.Line, Column: mapping.Type, Message: err.Error()}
+    }
+
+    key := row.Reference
+    if key == "" {
+        // Identical rows are distinct transactions, such as two equal fills
+        key = strings.Join([]string{
+            row.Timestamp.Format(time.RFC3339Nano), row.Type, row.Symbol,
+            row.Amount.String(), row.Price.String(), row.Fee.String(),
+        }, "|")
+        occurrences[key]++
+        key = fmt.Sprintf("%s#%d", key, occurrences[key])
+    }
+
+    return &models.Transaction{
+        ID:          models.ImportedTransactionID(portfolioID, key),
+        PortfolioID: portfolioID,
+        AssetID:     asset.ID,
+        Type:        row.Type,
+        Amount:      row.Amount,
+        Price:       row.Price,
+        Fee:         row.Fee,
+        Timestamp:   row.Timestamp,
+    }, nil
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/importer"
+    "bookman/portfolio-service/internal/models"
+)
+
+// importBatchSize is the number of rows parsed and recorded per batch of a
+// bulk import
+const importBatchSize = 2000
+
+// ImportProgress receives the running totals of a bulk import after each
+// batch together with the row errors found in that batch
+type ImportProgress func(totals *models.TransactionImport, errors []models.ImportRowError) error
+
+// ImportTransactionsStream imports a large CSV transaction file in batches as
+// it is read. Unlike ImportTransactions, valid rows are recorded even when
+// other rows are invalid, so a failed row does not block the rest of the file;
+// each batch is stored in one bulk copy and reported to progress. Re-importing
+// a file records only the rows missing from earlier imports. Bulk imports do
+// not emit a webhook per transaction.
+func (s *PortfolioService) ImportTransactionsStream(ctx context.Context, portfolioID uuid.UUID, file io.Reader, mapping importer.Mapping, dryRun bool, progress ImportProgress) (*models.TransactionImport, error) {
+    if portfolioID == uuid.Nil || file == nil || progress == nil {
+        return nil, ErrInvalidPortfolio
+    }
+    if err := s.checkWritable(ctx, portfolioID); err != nil {
+        return nil, err
+    }
+
+    parser, err := importer.NewParser(file, mapping, importer.MaxStreamRows)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidTransaction, err)
+    }
+
+    portfolio, err := s.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, err
+    }
+    assets := make(map[string]*models.Asset, len(portfolio.Assets))
+    for i := range portfolio.Assets {
+        assets[strings.ToUpper(portfolio.Assets[i].Symbol)] = &portfolio.Assets[i]
+    }
+
+    // The whole file is recorded under one lock, so concurrent imports of the
+    // same file do not both record its rows
+    if !dryRun {
+        unlock, err := s.lockPortfolio(ctx, portfolioID)
+        if err != nil {
+            return nil, err
+        }
+        defer unlock()
+    }
+
+    totals := &models.TransactionImport{
+        PortfolioID: portfolioID,
+        Committed:   !dryRun,
+        Errors:      []models.ImportRowError{},
+    }
+    occurrences := make(map[string]int)
+    seen := make(map[uuid.UUID]bool)
+
+    for {
+        batch, err := parser.Next(importBatchSize)
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, fmt.Errorf("%w: %w", ErrInvalidTransaction, err)
+        }
+
+        totals.Rows += len(batch.Rows) + len(batch.Errors) + batch.Skipped
+        totals.Skipped += batch.Skipped
+
+        rowErrors := make([]models.ImportRowError, 0, len(batch.Errors))
+        for _, e := range batch.Errors {
+            rowErrors = append(rowErrors, models.ImportRowError{Line: e.Line, Column: e.Column, Message: e.Message})
+        }
+
+        txs := make([]*models.Transaction, 0, len(batch.Rows))
+        for _, row := range batch.Rows {
+            tx, rowErr := importedTransaction(portfolioID, row, assets, mapping, occurrences)
+            if rowErr != nil {
+                rowErrors = append(rowErrors, *rowErr)
+                continue
+            }
+            txs = append(txs, tx)
+        }
+        totals.Valid += len(txs)
+        totals.Invalid += len(rowErrors)
+        sort.Slice(rowErrors, func(i, j int) bool {
+            return rowErrors[i].Line < rowErrors[j].Line
+        })
+        for _, e := range rowErrors {
+            if len(totals.Errors) >= maxImportRowErrors {
+                break
+            }
+            totals.Errors = append(totals.Errors, e)
+        }
+
+        fresh, err := s.newImportedTransactions(ctx, portfolioID, txs, seen)
+        if err != nil {
+            return nil, err
+        }
+        totals.Duplicates += len(txs) - len(fresh)
+
+        if !dryRun && len(fresh) > 0 {
+            if err := s.recordImportBatch(ctx, portfolioID, fresh); err != nil {
+                return nil, err
+            }
+            totals.TransactionsRecorded += len(fresh)
+        }
+
+        if err := progress(totals, rowErrors); err != nil {
+            return nil, err
+        }
+    }
+    totals.ImportedAt = time.Now().UTC()
+
+    s.logger.Info("Transactions bulk imported",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.Int("rows", totals.Rows),
+        zap.Int("invalid", totals.Invalid),
+        zap.Int("duplicates", totals.Duplicates),
+        zap.Int("transactions", totals.TransactionsRecorded),
+        zap.Bool("dry_run", dryRun),
+    )
+
+    return totals, nil
+}
+
+// newImportedTransactions returns the transactions of a batch that are
+// neither recorded nor part of an earlier batch of the same file, adding them
+// to seen
+func (s *PortfolioService) newImportedTransactions(ctx context.Context, portfolioID uuid.UUID, txs []*models.Transaction, seen map[uuid.UUID]bool) ([]*models.Transaction, error) {
+    if len(txs) == 0 {
+        return nil, nil
+    }
+
+    start, end := txs[0].Timestamp, txs[0].Timestamp
+    for _, tx := range txs[1:] {
+        if tx.Timestamp.Before(start) {
+            start = tx.Timestamp
+        }
+        if tx.Timestamp.After(end) {
+            end = tx.Timestamp
+        }
+    }
+    // The end of the range is exclusive
+    recorded, err := s.existingTransactionIDs(ctx, portfolioID, start, end.Add(time.Nanosecond))
+    if err != nil {
+        return nil, err
+    }
+
+    fresh := make([]*models.Transaction, 0, len(txs))
+    for _, tx := range txs {
+        if recorded[tx.ID] || seen[tx.ID] {
+            continue
+        }
+        seen[tx.ID] = true
+        fresh = append(fresh, tx)
+    }
+    return fresh, nil
+}
+
+// recordImportBatch stores a batch of imported transactions at once, in the
+// ledger of event-sourced portfolios and by bulk copy otherwise
+func (s *PortfolioService) recordImportBatch(ctx context.Context, portfolioID uuid.UUID, txs []*models.Transaction) error {
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    var err error
+    if s.eventSourced {
+        changes := make([]ledgerChange, len(txs))
+        for i, tx := range txs {
+            changes[i] = ledgerChange{eventType: models.LedgerTransactionRecorded, data: tx}
+        }
+        err = s.appendLedger(ctx, portfolioID, time.Now().UTC(), changes...)
+    } else {
+        err = s.repo.CopyTransactions(ctx, txs)
+    }
+
+    if err != nil {
+        s.logger.Error("Failed to record imported transactions",
+            zap.Error(err),
+            zap.String("portfolio_id", portfolioID.String()),
+            zap.Int("transactions", len(txs)),
+        )
+        return repositoryError(err)
+    }
+    return nil
+}
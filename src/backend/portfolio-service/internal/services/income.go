@@ -0,0 +1,72 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// GetIncomeReport summarizes the rewards, airdrops and interest a portfolio
+// received within [start, end) by asset and calendar period, valued when
+// received. Yearly periods are the tax years of the owner's jurisdiction.
+// An unset end reports up to now.
+func (s *PortfolioService) GetIncomeReport(ctx context.Context, portfolioID uuid.UUID, period models.ReportPeriod, start, end time.Time) (*models.IncomeReport, error) {
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+    now := time.Now().UTC()
+    if end.IsZero() || end.After(now) {
+        end = now
+    }
+    if !start.Before(end) {
+        return nil, fmt.Errorf("%w: report start must be before end", ErrInvalidIncomeReport)
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    symbols := make(map[uuid.UUID]string, len(portfolio.Assets))
+    for _, asset := range portfolio.Assets {
+        symbols[asset.ID] = asset.Symbol
+    }
+    settings, err := s.userTaxSettings(ctx, portfolio.UserID)
+    if err != nil {
+        return nil, err
+    }
+
+    txs, err := s.transactions(ctx, portfolioID, start, end)
+    if err != nil {
+        return nil, err
+    }
+
+    // Only receipts recorded without a price are valued at the daily close
+    var unpriced []string
+    seen := make(map[string]bool)
+    for _, tx := range txs {
+        symbol := symbols[tx.AssetID]
+        if !models.IsIncome(tx.Type) || tx.Price.IsPositive() || symbol == "" || seen[symbol] {
+            continue
+        }
+        seen[symbol] = true
+        unpriced = append(unpriced, symbol)
+    }
+    closes, err := s.repo.GetDailyCloses(ctx, unpriced, start.UTC().Truncate(24*time.Hour), end)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    report, err := models.BuildIncomeReport(portfolioID, period, settings.TaxYear, start, end, txs, symbols, closes, now)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidIncomeReport, err)
+    }
+
+    return report, nil
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// Ledger read limits
+const (
+    maxLedgerPageSize    = 500
+    ledgerReplayPageSize = 1000
+)
+
+// ledgerChange is one state change to append to a portfolio ledger
+type ledgerChange struct {
+    eventType models.LedgerEventType
+    data      interface{}
+}
+
+// ListLedgerEvents returns up to limit ledger events of a portfolio after the
+// given sequence number, in sequence order
+func (s *PortfolioService) ListLedgerEvents(ctx context.Context, portfolioID uuid.UUID, after int64, limit int) ([]models.LedgerEvent, error) {
+    if !s.eventSourced {
+        return nil, fmt.Errorf("%w: portfolio ledger", ErrFeatureDisabled)
+    }
+    if portfolioID == uuid.Nil || after < 0 {
+        return nil, ErrInvalidPortfolio
+    }
+    if limit <= 0 || limit > maxLedgerPageSize {
+        limit = maxLedgerPageSize
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    events, err := s.repo.ListLedgerEvents(ctx, portfolioID, after, time.Now().UTC(), limit)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    return events, nil
+}
+
+// GetPortfolioAsOf reconstructs a portfolio and its transactions as they were
+// at the given time by replaying its ledger
+func (s *PortfolioService) GetPortfolioAsOf(ctx context.Context, portfolioID uuid.UUID, asOf time.Time) (*models.LedgerState, error) {
+    if !s.eventSourced {
+        return nil, fmt.Errorf("%w: portfolio ledger", ErrFeatureDisabled)
+    }
+    if portfolioID == uuid.Nil || asOf.IsZero() {
+        return nil, ErrInvalidPortfolio
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    var (
+        events []models.LedgerEvent
+        after  int64
+    )
+    for {
+        page, err := s.repo.ListLedgerEvents(ctx, portfolioID, after, asOf, ledgerReplayPageSize)
+        if err != nil {
+            return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+        }
+        events = append(events, page...)
+        if len(page) < ledgerReplayPageSize {
+            break
+        }
+        after = page[len(page)-1].Sequence
+    }
+    if len(events) == 0 {
+        return nil, fmt.Errorf("%w: no ledger events for portfolio as of %s", ErrNotFound, asOf.Format(time.RFC3339))
+    }
+
+    state, err := models.ReplayLedger(events, asOf)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInsufficientData, err)
+    }
+
+    return state, nil
+}
+
+// appendLedger records changes to a portfolio ledger as events at the given time
+func (s *PortfolioService) appendLedger(ctx context.Context, portfolioID uuid.UUID, at time.Time, changes ...ledgerChange) error {
+    events := make([]models.LedgerEvent, len(changes))
+    for i, change := range changes {
+        event, err := models.NewLedgerEvent(portfolioID, change.eventType, change.data, at)
+        if err != nil {
+            return err
+        }
+        events[i] = event
+    }
+
+    err := s.repo.AppendLedgerEvents(ctx, portfolioID, events)
+    if errors.Is(err, repository.ErrLedgerConflict) {
+        return fmt.Errorf("%w: %v", ErrConcurrentModification, err)
+    }
+    return err
+}
+
+// portfolioCreatedChanges returns the ledger changes opening a portfolio with
+// its initial assets
+func portfolioCreatedChanges(p *models.Portfolio) []ledgerChange {
+    changes := []ledgerChange{{
+        eventType: models.LedgerPortfolioCreated,
+        data: models.LedgerPortfolioDetails{
+            UserID:      p.UserID,
+            Name:        p.Name,
+            Description: p.Description,
+        },
+    }}
+    for _, asset := range p.Assets {
+        changes = append(changes, ledgerChange{eventType: models.LedgerAssetAdded, data: asset})
+    }
+    return changes
+}
+
+// repositoryError wraps a persistence error, keeping concurrent modification
+// errors distinguishable
+func repositoryError(err error) error {
+    if errors.Is(err, ErrConcurrentModification) {
+        return err
+    }
+    return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// ArchivePortfolio hides an active portfolio from reads and default
+// listings. Its holdings and history are kept so it can be restored.
+func (s *PortfolioService) ArchivePortfolio(ctx context.Context, portfolioID uuid.UUID) error {
+    return s.setArchived(ctx, portfolioID, true)
+}
+
+// RestorePortfolio makes an archived portfolio active again
+func (s *PortfolioService) RestorePortfolio(ctx context.Context, portfolioID uuid.UUID) error {
+    return s.setArchived(ctx, portfolioID, false)
+}
+
+// setArchived archives or restores a portfolio, returning ErrNotFound when
+// it does not exist or already is in the requested state
+func (s *PortfolioService) setArchived(ctx context.Context, portfolioID uuid.UUID, archive bool) error {
+    if portfolioID == uuid.Nil {
+        return ErrInvalidPortfolio
+    }
+
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+
+    now := time.Now().UTC()
+    event, webhook := models.LedgerPortfolioRestored, models.WebhookPortfolioRestored
+    if archive {
+        event, webhook = models.LedgerPortfolioArchived, models.WebhookPortfolioArchived
+    }
+
+    var err error
+    if s.eventSourced {
+        var archivedAt *time.Time
+        archivedAt, err = s.repo.PortfolioArchivedAt(ctx, portfolioID)
+        if err == nil && (archivedAt != nil) == archive {
+            err = repository.ErrPortfolioNotFound
+        }
+        if err == nil {
+            err = s.appendLedger(ctx, portfolioID, now, ledgerChange{eventType: event})
+        }
+    } else if archive {
+        err = s.repo.ArchivePortfolio(ctx, portfolioID, now)
+    } else {
+        err = s.repo.RestorePortfolio(ctx, portfolioID, now)
+    }
+
+    if errors.Is(err, repository.ErrPortfolioNotFound) {
+        return fmt.Errorf("%w: portfolio %s", ErrNotFound, portfolioID)
+    }
+    if err != nil {
+        s.logger.Error("Failed to change portfolio archive state",
+            zap.Error(err),
+            zap.String("portfolio_id", portfolioID.String()),
+            zap.Bool("archive", archive),
+        )
+        return repositoryError(err)
+    }
+
+    s.logger.Info("Portfolio archive state changed",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.Bool("archived", archive),
+    )
+    s.emitWebhook(ctx, portfolioID, webhook, map[string]interface{}{
+        "portfolio_id": portfolioID,
+        "updated_at":   now,
+    })
+
+    return nil
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "sort"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/pagination"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// Page token scopes bind tokens to the listing that issued them
+const (
+    portfolioListScope       = "portfolios"
+    transactionListScope     = "transactions"
+    alertEventListScope      = "alert_events"
+    webhookDeliveryListScope = "webhook_deliveries"
+    activityListScope        = "activity"
+    auditEntryListScope      = "audit_entries"
+)
+
+// ListPortfolios returns a page of the user's portfolios including their
+// materialized value changes in the given order, and the token of the next
+// page if any. Archived portfolios are only listed when the filter includes
+// them.
+func (s *PortfolioService) ListPortfolios(ctx context.Context, userID uuid.UUID, pageSize int, pageToken string, filter repository.PortfolioFilter, order repository.PortfolioOrder) ([]*models.Portfolio, string, error) {
+    if userID == uuid.Nil {
+        return nil, "", fmt.Errorf("%w: user ID is required", ErrInvalidPortfolio)
+    }
+    if !order.Valid() {
+        return nil, "", fmt.Errorf("%w: cannot order by %q", ErrInvalidListQuery, order.Field)
+    }
+    filter.Tag = models.NormalizeTag(filter.Tag)
+    pageSize = pagination.Size(pageSize, pagination.MaxPageSize)
+
+    // Filters are only fingerprinted when set, keeping unfiltered tokens valid
+    scope := []string{userID.String()}
+    if filter.Tag != "" {
+        scope = append(scope, "tag="+filter.Tag)
+    }
+    if filter.AssetType != "" {
+        scope = append(scope, "asset_type="+filter.AssetType)
+    }
+    if filter.MinValue.Valid {
+        scope = append(scope, "min_value="+filter.MinValue.Decimal.String())
+    }
+    if filter.IncludeArchived {
+        scope = append(scope, "archived")
+    }
+    if order != (repository.PortfolioOrder{}) {
+        scope = append(scope, fmt.Sprintf("order=%s,%t", order.Field, order.Descending))
+    }
+    filters := pagination.Fingerprint(scope...)
+    after, err := s.decodePageToken(portfolioListScope, pageToken, filters)
+    if err != nil {
+        return nil, "", err
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    // Fetch one extra row to detect whether another page exists
+    portfolios, err := s.repo.ListPortfolios(ctx, userID, after, pageSize+1, filter, order)
+    if err != nil {
+        return nil, "", fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    var nextToken string
+    if len(portfolios) > pageSize {
+        portfolios = portfolios[:pageSize]
+        nextToken, err = s.encodePageToken(portfolioListScope, order.Position(portfolios[pageSize-1]), filters)
+        if err != nil {
+            return nil, "", err
+        }
+    }
+
+    return portfolios, nextToken, nil
+}
+
+// ListTransactions returns a page of a portfolio's transactions matching
+// filter, including archived ones, oldest first, and the token of the next
+// page if any. A zero filter end lists transactions up to now.
+func (s *PortfolioService) ListTransactions(ctx context.Context, portfolioID uuid.UUID, pageSize int, pageToken string, filter repository.TransactionFilter) ([]models.Transaction, string, error) {
+    if portfolioID == uuid.Nil {
+        return nil, "", ErrInvalidPortfolio
+    }
+    pageSize = pagination.Size(pageSize, pagination.MaxPageSize)
+
+    // An unset end is fingerprinted as such, so each page lists up to the
+    // time it is requested
+    filters := pagination.Fingerprint(portfolioID.String(), filter.AssetID.String(),
+        filter.Start.UTC().Format(time.RFC3339Nano), filter.End.UTC().Format(time.RFC3339Nano))
+    if filter.End.IsZero() {
+        filter.End = time.Now().UTC()
+    }
+    if !filter.Start.Before(filter.End) {
+        return nil, "", fmt.Errorf("%w: list start must be before end", ErrInvalidTransaction)
+    }
+
+    after, err := s.decodePageToken(transactionListScope, pageToken, filters)
+    if err != nil {
+        return nil, "", err
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    txs, err := s.repo.ListTransactions(ctx, portfolioID, after, pageSize+1, filter)
+    if err != nil {
+        return nil, "", fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    if s.archive != nil {
+        start := filter.Start
+        if after.At.After(start) {
+            start = after.At
+        }
+        archived, err := s.archive.Transactions(ctx, portfolioID, start, filter.End)
+        if err != nil {
+            return nil, "", fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+        }
+        for _, tx := range archived {
+            if (filter.AssetID == uuid.Nil || tx.AssetID == filter.AssetID) && afterPosition(tx.Timestamp, tx.ID, after) {
+                txs = append(txs, tx)
+            }
+        }
+        sort.Slice(txs, func(i, j int) bool {
+            return afterPosition(txs[j].Timestamp, txs[j].ID, pagination.Position{At: txs[i].Timestamp, ID: txs[i].ID})
+        })
+    }
+
+    var nextToken string
+    if len(txs) > pageSize {
+        txs = txs[:pageSize]
+        last := txs[pageSize-1]
+        nextToken, err = s.encodePageToken(transactionListScope, pagination.Position{At: last.Timestamp, ID: last.ID}, filters)
+        if err != nil {
+            return nil, "", err
+        }
+    }
+
+    return txs, nextToken, nil
+}
+
+// afterPosition reports whether a row sorts after the position in a list
+// ordered by time and ID
+func afterPosition(at time.Time, id uuid.UUID, pos pagination.Position) bool {
+    if !at.Equal(pos.At) {
+        return at.After(pos.At)
+    }
+    return bytes.Compare(id[:], pos.ID[:]) > 0
+}
+
+// decodePageToken returns the position a page token of the listing continues
+// from
+func (s *PortfolioService) decodePageToken(scope, token, filters string) (pagination.Position, error) {
+    pos, err := s.pages.DecodePosition(scope, token, filters)
+    if err != nil {
+        return pagination.Position{}, fmt.Errorf("%w: %w", ErrInvalidPageToken, err)
+    }
+    return pos, nil
+}
+
+// encodePageToken issues the token of the page after the position
+func (s *PortfolioService) encodePageToken(scope string, pos pagination.Position, filters string) (string, error) {
+    token, err := s.pages.EncodePosition(scope, pos, filters)
+    if err != nil {
+        return "", fmt.Errorf("failed to issue page token: %w", err)
+    }
+    return token, nil
+}
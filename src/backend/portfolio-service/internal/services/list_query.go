@@ -0,0 +1,91 @@
+package services
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+
+    "github.com/shopspring/decimal"    // v1.3.1
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// filterCondition matches one condition of a list filter expression at the
+// start of the remaining input: a field, an operator and a plain or
+// double-quoted value, followed by AND or the end of the expression
+var filterCondition = regexp.MustCompile(`^\s*([a-z_]+)\s*(>=|=)\s*(?:"([^"]*)"|([^\s"]+))\s*(?:(?i:AND)\s+|$)`)
+
+// ParsePortfolioOrder parses a portfolio listing order: created_at,
+// total_value or name, optionally followed by asc or desc. An empty order
+// lists oldest portfolios first.
+func ParsePortfolioOrder(orderBy string) (repository.PortfolioOrder, error) {
+    fields := strings.Fields(strings.ToLower(orderBy))
+    if len(fields) == 0 {
+        return repository.PortfolioOrder{}, nil
+    }
+    if len(fields) > 2 {
+        return repository.PortfolioOrder{}, fmt.Errorf("%w: order %q", ErrInvalidListQuery, orderBy)
+    }
+
+    order := repository.PortfolioOrder{Field: fields[0]}
+    if !order.Valid() {
+        return repository.PortfolioOrder{}, fmt.Errorf("%w: cannot order by %q", ErrInvalidListQuery, fields[0])
+    }
+    if len(fields) == 2 {
+        switch fields[1] {
+        case "asc":
+        case "desc":
+            order.Descending = true
+        default:
+            return repository.PortfolioOrder{}, fmt.Errorf("%w: order direction %q", ErrInvalidListQuery, fields[1])
+        }
+    }
+    return order, nil
+}
+
+// ParsePortfolioFilter parses a portfolio listing filter expression of
+// conditions joined by AND. The conditions are asset_type = <type>,
+// tag = <tag> and total_value >= <amount>, each given at most once; values
+// may be double-quoted.
+func ParsePortfolioFilter(expr string) (repository.PortfolioFilter, error) {
+    var filter repository.PortfolioFilter
+
+    seen := make(map[string]bool)
+    for rest := strings.TrimSpace(expr); rest != ""; {
+        m := filterCondition.FindStringSubmatch(rest)
+        if m == nil {
+            return filter, fmt.Errorf("%w: malformed filter near %q", ErrInvalidListQuery, rest)
+        }
+        rest = rest[len(m[0]):]
+
+        field, op, value := m[1], m[2], m[3]+m[4]
+        if seen[field] {
+            return filter, fmt.Errorf("%w: %s is filtered more than once", ErrInvalidListQuery, field)
+        }
+        seen[field] = true
+
+        switch {
+        case field == "asset_type" && op == "=":
+            if err := models.ValidateHoldingType(value); err != nil {
+                return filter, fmt.Errorf("%w: %w", ErrInvalidListQuery, err)
+            }
+            filter.AssetType = value
+        case field == "tag" && op == "=":
+            filter.Tag = models.NormalizeTag(value)
+            if filter.Tag == "" {
+                return filter, fmt.Errorf("%w: empty tag", ErrInvalidListQuery)
+            }
+        case field == "total_value" && op == ">=":
+            minValue, err := decimal.NewFromString(value)
+            if err != nil {
+                return filter, fmt.Errorf("%w: total value %q", ErrInvalidListQuery, value)
+            }
+            filter.MinValue = decimal.NullDecimal{Decimal: minValue, Valid: true}
+        default:
+            return filter, fmt.Errorf("%w: cannot filter by %s %s", ErrInvalidListQuery, field, op)
+        }
+    }
+
+    return filter, nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "hash/fnv"
+    "time"
+
+    "github.com/google/uuid" // v1.3.0
+    "go.uber.org/zap"        // v1.24.0
+
+    "bookman/portfolio-service/internal/repository"
+)
+
+const (
+    // lockReleaseTimeout bounds releasing a portfolio lock after a mutation
+    lockReleaseTimeout = time.Second * 5
+
+    // lockMinPoll and lockMaxPoll bound the backoff between attempts to take
+    // a locked portfolio's lock
+    lockMinPoll = time.Millisecond * 10
+    lockMaxPoll = time.Millisecond * 500
+)
+
+// PortfolioLock is a held cross-replica portfolio lock
+type PortfolioLock interface {
+    // Release gives up the lock
+    Release(ctx context.Context) error
+}
+
+// PortfolioLocker takes cross-replica portfolio locks
+type PortfolioLocker interface {
+    // TryLock takes the lock with key without waiting, returning a nil lock
+    // when another replica holds it
+    TryLock(ctx context.Context, key int64) (PortfolioLock, error)
+}
+
+// postgresPortfolioLocker takes portfolio locks as Postgres session-level
+// advisory locks
+type postgresPortfolioLocker struct {
+    repo *repository.PostgresRepository
+}
+
+func (l postgresPortfolioLocker) TryLock(ctx context.Context, key int64) (PortfolioLock, error) {
+    lock, err := l.repo.TryAdvisoryLock(ctx, key)
+    if lock == nil {
+        // A nil *AdvisoryLock must not become a non-nil PortfolioLock
+        return nil, err
+    }
+    return lock, nil
+}
+
+// PortfolioLocks serializes multi-step mutations of a portfolio across
+// replicas. A waiting mutation polls for the lock with backoff rather than
+// blocking on it, so it holds no database connection while it waits.
+type PortfolioLocks struct {
+    locker PortfolioLocker
+    wait   time.Duration
+    logger *zap.Logger
+}
+
+// NewPortfolioLocks creates portfolio locks taken through locker, where a
+// mutation waits up to wait for a locked portfolio
+func NewPortfolioLocks(locker PortfolioLocker, wait time.Duration, logger *zap.Logger) *PortfolioLocks {
+    return &PortfolioLocks{locker: locker, wait: wait, logger: logger}
+}
+
+// portfolioLockKey derives the advisory lock key of a portfolio
+func portfolioLockKey(portfolioID uuid.UUID) int64 {
+    h := fnv.New64a()
+    h.Write([]byte("portfolio-service/portfolios/"))
+    h.Write(portfolioID[:])
+    return int64(h.Sum64())
+}
+
+// Lock takes the lock of a portfolio and returns the function releasing it.
+// Nil locks return at once; the in-process mutex then only guards this
+// replica. When another mutation holds the lock for longer than the
+// configured wait, ErrConcurrentModification is returned; when ctx ends
+// first, its error is.
+func (l *PortfolioLocks) Lock(ctx context.Context, portfolioID uuid.UUID) (func(), error) {
+    if l == nil {
+        return func() {}, nil
+    }
+
+    key := portfolioLockKey(portfolioID)
+    deadline := time.Now().Add(l.wait)
+    poll := lockMinPoll
+    for {
+        lock, err := l.locker.TryLock(ctx, key)
+        if err != nil {
+            if ctx.Err() != nil {
+                return nil, fmt.Errorf("waiting for portfolio %s lock: %w", portfolioID, ctx.Err())
+            }
+            return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+        }
+        if lock != nil {
+            return l.releaser(lock, portfolioID), nil
+        }
+
+        remaining := time.Until(deadline)
+        if remaining <= 0 {
+            return nil, fmt.Errorf("%w: portfolio %s is locked by another mutation", ErrConcurrentModification, portfolioID)
+        }
+
+        timer := time.NewTimer(min(poll, remaining))
+        select {
+        case <-ctx.Done():
+            timer.Stop()
+            return nil, fmt.Errorf("waiting for portfolio %s lock: %w", portfolioID, ctx.Err())
+        case <-timer.C:
+        }
+        poll = min(poll*2, lockMaxPoll)
+    }
+}
+
+// releaser returns the function releasing a held portfolio lock
+func (l *PortfolioLocks) releaser(lock PortfolioLock, portfolioID uuid.UUID) func() {
+    return func() {
+        releaseCtx, cancel := context.WithTimeout(context.Background(), lockReleaseTimeout)
+        defer cancel()
+        if err := lock.Release(releaseCtx); err != nil {
+            l.logger.Warn("Failed to release portfolio lock",
+                zap.Error(err),
+                zap.String("portfolio_id", portfolioID.String()),
+            )
+        }
+    }
+}
+
+// lockPortfolio serializes a multi-step mutation of a portfolio across
+// replicas and returns the function ending it
+func (s *PortfolioService) lockPortfolio(ctx context.Context, portfolioID uuid.UUID) (func(), error) {
+    return s.locks.Lock(ctx, portfolioID)
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// SetManualPrice validates and stores a user-supplied price of a symbol of a
+// portfolio. Prices without a start of validity are valid from now. Manual
+// prices only value holdings, so they can be set on read-only portfolios too.
+func (s *PortfolioService) SetManualPrice(ctx context.Context, price *models.ManualPrice) (*models.ManualPrice, error) {
+    if price == nil || price.PortfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    now := time.Now().UTC()
+    if price.ValidFrom.IsZero() {
+        price.ValidFrom = now
+    }
+    if err := price.Validate(); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidManualPrice, err)
+    }
+    price.ID = uuid.New()
+    price.CreatedAt = now
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    err := s.repo.InsertManualPrice(ctx, price)
+    if errors.Is(err, repository.ErrPortfolioNotFound) {
+        return nil, fmt.Errorf("%w: portfolio %s", ErrNotFound, price.PortfolioID)
+    }
+    if err != nil {
+        s.logger.Error("Failed to set manual price",
+            zap.Error(err),
+            zap.String("portfolio_id", price.PortfolioID.String()),
+            zap.String("symbol", price.Symbol),
+        )
+        return nil, repositoryError(err)
+    }
+
+    return price, nil
+}
+
+// applyManualPrices prices the portfolio's assets that have no provider quote
+// at the manual prices valid now, marking them as manually priced. Failures
+// are logged and leave those assets at their last known value.
+func (s *PortfolioService) applyManualPrices(ctx context.Context, p *models.Portfolio, prices map[string]decimal.Decimal) {
+    unquoted := false
+    for _, asset := range p.Assets {
+        if _, ok := prices[asset.Symbol]; !ok {
+            unquoted = true
+            break
+        }
+    }
+    if !unquoted {
+        return
+    }
+
+    now := time.Now()
+    manual, err := s.repo.ListManualPrices(ctx, p.ID, now)
+    if err != nil {
+        s.logger.Warn("Failed to load manual prices",
+            zap.Error(err),
+            zap.String("portfolio_id", p.ID.String()),
+        )
+        return
+    }
+
+    symbols := models.ApplyManualPrices(prices, manual, now)
+    if len(symbols) == 0 {
+        return
+    }
+    manuallyPriced := make(map[string]bool, len(symbols))
+    for _, symbol := range symbols {
+        manuallyPriced[symbol] = true
+    }
+    for i := range p.Assets {
+        if manuallyPriced[p.Assets[i].Symbol] {
+            p.Assets[i].PriceSource = models.MANUAL_PRICE_SOURCE
+        }
+    }
+}
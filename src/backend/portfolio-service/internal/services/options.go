@@ -0,0 +1,201 @@
+package services
+
+import (
+    "time"
+
+    "bookman/portfolio-service/internal/archive"
+    "bookman/portfolio-service/internal/chains"
+    "bookman/portfolio-service/internal/envelope"
+    "bookman/portfolio-service/internal/exchanges"
+    "bookman/portfolio-service/internal/marketdata"
+    "bookman/portfolio-service/internal/notifications"
+    "bookman/portfolio-service/internal/pagination"
+    "bookman/portfolio-service/internal/pricecache"
+    "bookman/portfolio-service/internal/reports"
+)
+
+// Option configures optional dependencies of the portfolio service
+type Option func(*PortfolioService)
+
+// WithArchive enables transparent reads of transaction history moved to cold storage
+func WithArchive(reader *archive.Reader) Option {
+    return func(s *PortfolioService) {
+        s.archive = reader
+    }
+}
+
+// WithMarketData enables analytics computed from historical market prices
+func WithMarketData(reader *marketdata.Reader) Option {
+    return func(s *PortfolioService) {
+        s.market = reader
+    }
+}
+
+// WithNotifications enables notifications for triggered alerts and portfolio
+// lifecycle events
+func WithNotifications(dispatcher *notifications.Dispatcher) Option {
+    return func(s *PortfolioService) {
+        s.notifier = dispatcher
+    }
+}
+
+// WithWebhooks enables user-registered webhooks on portfolio mutations.
+// allowInsecure permits plain HTTP endpoint URLs. A non-nil encryptor seals
+// signing secrets before they are stored.
+func WithWebhooks(allowInsecure bool, encryptor *envelope.Encryptor) Option {
+    return func(s *PortfolioService) {
+        s.webhooks = webhookSettings{enabled: true, allowInsecure: allowInsecure, encryptor: encryptor}
+    }
+}
+
+// WithExchanges enables importing exchange accounts through the registered
+// connectors. cashAssets are valued at 1 USD and not tracked as holdings. A
+// non-nil cipher also enables connecting accounts whose encrypted credentials
+// are stored and synced every syncInterval.
+func WithExchanges(registry *exchanges.Registry, cipher *exchanges.CredentialCipher, cashAssets []string, syncInterval time.Duration) Option {
+    return func(s *PortfolioService) {
+        s.exchanges = &exchangeSettings{
+            registry:     registry,
+            cashAssets:   cashAssets,
+            cipher:       cipher,
+            syncInterval: syncInterval,
+        }
+    }
+}
+
+// WithEventSourcing stores portfolio changes as ledger events projected into
+// the portfolio tables, enabling ledger audit and as-of reconstruction
+func WithEventSourcing() Option {
+    return func(s *PortfolioService) {
+        s.eventSourced = true
+    }
+}
+
+// WithPageTokens sets the codec used to issue and verify list page tokens
+func WithPageTokens(codec *pagination.Codec) Option {
+    return func(s *PortfolioService) {
+        s.pages = codec
+    }
+}
+
+// WithReports enables performance reports stored in store under prefix,
+// downloadable through URLs valid for urlExpiry, or deliveryExpiry for
+// scheduled reports
+func WithReports(store reports.Store, prefix string, urlExpiry, deliveryExpiry time.Duration) Option {
+    return func(s *PortfolioService) {
+        s.reports = &reportSettings{
+            store:          store,
+            prefix:         prefix,
+            urlExpiry:      urlExpiry,
+            deliveryExpiry: deliveryExpiry,
+        }
+    }
+}
+
+// WithWallets enables tracking on-chain wallets through the registered chain
+// adapters, synced every syncInterval. peggedAssets are valued at 1 USD.
+func WithWallets(registry *chains.Registry, peggedAssets []string, syncInterval time.Duration) Option {
+    return func(s *PortfolioService) {
+        s.wallets = &walletSettings{
+            registry:     registry,
+            peggedAssets: peggedAssets,
+            syncInterval: syncInterval,
+        }
+    }
+}
+
+// WithCostBasisPolicies sets the cost basis policies, zero or allocated, of
+// positions credited by airdrops and forks. Without it they are zero.
+func WithCostBasisPolicies(airdrop, fork string) Option {
+    return func(s *PortfolioService) {
+        s.costBasis = costBasisPolicies{airdrop: airdrop, fork: fork}
+    }
+}
+
+// WithStaking enables staking reward accrual; rewards of chain positions are
+// read every accrualInterval
+func WithStaking(accrualInterval time.Duration) Option {
+    return func(s *PortfolioService) {
+        s.staking = &stakingSettings{accrualInterval: accrualInterval}
+    }
+}
+
+// WithUserDataPurge enables permanent erasure of all data of a user
+func WithUserDataPurge() Option {
+    return func(s *PortfolioService) {
+        s.purgeEnabled = true
+    }
+}
+
+// WithSharing enables read-only share links of portfolios, valid for
+// defaultTTL unless the owner picks a lifetime of at most maxTTL
+func WithSharing(defaultTTL, maxTTL time.Duration) Option {
+    return func(s *PortfolioService) {
+        s.sharing = &shareSettings{defaultTTL: defaultTTL, maxTTL: maxTTL}
+    }
+}
+
+// WithPriceCache serves current prices for valuations from a layered cache
+// instead of reading them from the database on every request
+func WithPriceCache(cache *pricecache.Cache) Option {
+    return func(s *PortfolioService) {
+        s.prices = cache
+    }
+}
+
+// WithPriceFreshness flags valuations using stale prices and leaves out
+// prices too old to value assets with
+func WithPriceFreshness(freshness *marketdata.Freshness) Option {
+    return func(s *PortfolioService) {
+        s.freshness = freshness
+    }
+}
+
+// WithDelisting marks the assets of delisted symbols in valuations and
+// values them by policy, at their last known price or at zero
+func WithDelisting(policy string) Option {
+    return func(s *PortfolioService) {
+        s.delisting = policy
+    }
+}
+
+// WithPortfolioLocks serializes multi-step mutations of a portfolio, such as
+// imports and syncs, across replicas. A mutation waits up to wait for the
+// lock before failing with ErrConcurrentModification. The locks are Postgres
+// advisory locks, so they require a direct database connection.
+func WithPortfolioLocks(wait time.Duration) Option {
+    return func(s *PortfolioService) {
+        s.locks = NewPortfolioLocks(postgresPortfolioLocker{repo: s.repo}, wait, s.logger)
+    }
+}
+
+// WithTaxEstimates sets the tax rate, a fraction, that values the saving of
+// harvesting a loss when a request sets none, and the window around a loss
+// disposal in which purchases flag a wash sale. Without it both are zero.
+func WithTaxEstimates(estimatedRate float64, washSaleWindow time.Duration) Option {
+    return func(s *PortfolioService) {
+        s.tax.estimatedRate = estimatedRate
+        s.tax.washSaleWindow = washSaleWindow
+    }
+}
+
+// WithTaxJurisdiction sets the jurisdiction, an ISO 3166-1 alpha-2 country
+// code, of tax reports and the jurisdictions whose reports disallow the
+// losses of wash sales. Without it no wash-sale rule applies.
+func WithTaxJurisdiction(jurisdiction string, washSaleJurisdictions []string) Option {
+    return func(s *PortfolioService) {
+        s.tax.jurisdiction = jurisdiction
+        s.tax.washSaleJurisdictions = make(map[string]bool, len(washSaleJurisdictions))
+        for _, code := range washSaleJurisdictions {
+            s.tax.washSaleJurisdictions[code] = true
+        }
+    }
+}
+
+// WithLongTermHoldingPeriod sets the holding period lots must exceed to be
+// long-term. Without it lots are long-term after a year.
+func WithLongTermHoldingPeriod(longTerm time.Duration) Option {
+    return func(s *PortfolioService) {
+        s.tax.longTerm = longTerm
+    }
+}
@@ -14,7 +14,12 @@ import (
     "google.golang.org/grpc/codes"    // v1.50.0
     "google.golang.org/grpc/status"   // v1.50.0
 
+    "bookman/portfolio-service/internal/archive"
+    "bookman/portfolio-service/internal/marketdata"
     "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/notifications"
+    "bookman/portfolio-service/internal/pagination"
+    "bookman/portfolio-service/internal/pricecache"
     "bookman/portfolio-service/internal/repository"
 )
 
@@ -25,31 +30,96 @@ var (
     ErrInvalidTransaction = errors.New("invalid transaction data")
     ErrConcurrentModification = errors.New("concurrent modification detected")
     ErrRepositoryOperation = errors.New("repository operation failed")
+    ErrInsufficientData = errors.New("insufficient market data")
+    ErrFeatureDisabled = errors.New("feature not enabled")
+    ErrInvalidPageToken = errors.New("invalid page token")
+    ErrInvalidListQuery = errors.New("invalid list query")
+    ErrInvalidTarget = errors.New("invalid allocation target")
+    ErrInvalidDCAPlan = errors.New("invalid DCA plan")
+    ErrNotFound = errors.New("resource not found")
+    ErrInvalidAlertRule = errors.New("invalid alert rule")
+    ErrLimitExceeded = errors.New("limit exceeded")
+    ErrInvalidWebhook = errors.New("invalid webhook endpoint")
+    ErrInvalidReportSchedule = errors.New("invalid report schedule")
+    ErrReadOnlyPortfolio = errors.New("portfolio is read-only")
+    ErrWalletAlreadyTracked = errors.New("wallet already tracked")
+    ErrInvalidStakingPosition = errors.New("invalid staking position")
+    ErrStakingPositionExists = errors.New("asset already has a staking position")
+    ErrInvalidCorporateAction = errors.New("invalid corporate action")
+    ErrInvalidSymbolMigration = errors.New("invalid symbol migration")
+    ErrInvalidDustThreshold = errors.New("invalid dust threshold")
+    ErrInvalidTag = errors.New("invalid asset tag")
+    ErrInvalidShare = errors.New("invalid portfolio share")
+    ErrShareRequired = errors.New("share token required")
+    ErrInvalidManualPrice = errors.New("invalid manual price")
+    ErrInvalidCustomAsset = errors.New("invalid custom asset")
+    ErrCustomAssetExists = errors.New("custom asset already defined")
+    ErrInvalidTransferLink = errors.New("invalid transfer link")
+    ErrTransferLinked = errors.New("transfer already linked")
+    ErrInvalidIncomeReport = errors.New("invalid income report")
+    ErrInvalidHarvestCriteria = errors.New("invalid harvest criteria")
+    ErrInvalidHoldingPeriod = errors.New("invalid holding period")
+    ErrInvalidTaxSettings = errors.New("invalid tax settings")
+    ErrInvalidComparison = errors.New("invalid portfolio comparison")
 )
 
 // PortfolioService implements thread-safe portfolio management operations
 type PortfolioService struct {
-    repo    repository.PostgresRepository
-    logger  *zap.Logger
-    mutex   sync.RWMutex
+    repo     *repository.PostgresRepository
+    archive  *archive.Reader
+    market   *marketdata.Reader
+    pages    *pagination.Codec
+    prices   *pricecache.Cache
+    notifier *notifications.Dispatcher
+    logger   *zap.Logger
+    mutex    sync.RWMutex
+
+    webhooks     webhookSettings
+    exchanges    *exchangeSettings
+    reports      *reportSettings
+    wallets      *walletSettings
+    staking      *stakingSettings
+    sharing      *shareSettings
+    locks        *PortfolioLocks
+    costBasis    costBasisPolicies
+    tax          taxSettings
+    correlations correlationCache
+    freshness    *marketdata.Freshness
+    delisting    string // delisted price policy, empty when not tracked
+    eventSourced bool // store changes as portfolio ledger events
+    purgeEnabled bool // allow permanent erasure of user data
 }
 
 // NewPortfolioService creates a new instance of the portfolio service
-func NewPortfolioService(repo *repository.PostgresRepository, logger *zap.Logger) (*PortfolioService, error) {
+func NewPortfolioService(repo *repository.PostgresRepository, logger *zap.Logger, opts ...Option) (*PortfolioService, error) {
     if repo == nil || logger == nil {
         return nil, errors.New("invalid dependencies provided")
     }
 
-    return &PortfolioService{
-        repo:   *repo,
+    s := &PortfolioService{
+        repo:   repo,
         logger: logger.With(zap.String("service", "portfolio")),
-    }, nil
+    }
+    for _, opt := range opts {
+        opt(s)
+    }
+
+    // Without a configured codec, page tokens are only valid within this process
+    if s.pages == nil {
+        codec, err := pagination.NewEphemeralCodec(0)
+        if err != nil {
+            return nil, fmt.Errorf("failed to create page token codec: %w", err)
+        }
+        s.pages = codec
+    }
+
+    return s, nil
 }
 
 // CreatePortfolio creates a new portfolio with validation
 func (s *PortfolioService) CreatePortfolio(ctx context.Context, portfolio *models.Portfolio) (*models.Portfolio, error) {
     if err := s.validatePortfolio(portfolio); err != nil {
-        return nil, fmt.Errorf("%w: %v", ErrInvalidPortfolio, err)
+        return nil, fmt.Errorf("%w: %w", ErrInvalidPortfolio, err)
     }
 
     s.mutex.Lock()
@@ -65,20 +135,25 @@ func (s *PortfolioService) CreatePortfolio(ctx context.Context, portfolio *model
     portfolio.CreatedAt = now
     portfolio.LastUpdated = now
 
-    // Begin transaction
-    err := s.repo.WithTransaction(ctx, func(tx repository.PostgresRepository) error {
-        if err := tx.CreatePortfolio(ctx, portfolio); err != nil {
-            return fmt.Errorf("failed to create portfolio: %w", err)
-        }
-        return nil
-    })
+    var err error
+    if s.eventSourced {
+        err = s.appendLedger(ctx, portfolio.ID, now, portfolioCreatedChanges(portfolio)...)
+    } else {
+        // Begin transaction
+        err = s.repo.WithTransaction(ctx, func(tx repository.PostgresRepository) error {
+            if err := tx.CreatePortfolio(ctx, portfolio); err != nil {
+                return fmt.Errorf("failed to create portfolio: %w", err)
+            }
+            return nil
+        })
+    }
 
     if err != nil {
         s.logger.Error("Failed to create portfolio",
             zap.Error(err),
             zap.String("user_id", portfolio.UserID.String()),
         )
-        return nil, fmt.Errorf("%w: %v", ErrRepositoryOperation, err)
+        return nil, repositoryError(err)
     }
 
     s.logger.Info("Portfolio created successfully",
@@ -86,6 +161,16 @@ func (s *PortfolioService) CreatePortfolio(ctx context.Context, portfolio *model
         zap.String("user_id", portfolio.UserID.String()),
     )
 
+    s.notifier.Notify(models.Notification{
+        Event:       models.EventPortfolioCreated,
+        PortfolioID: portfolio.ID,
+        UserID:      portfolio.UserID,
+        Subject:     "Portfolio created",
+        Body:        "A new portfolio was created.",
+        CreatedAt:   now,
+    })
+    s.emitWebhook(ctx, portfolio.ID, models.WebhookPortfolioCreated, portfolioEventData(portfolio))
+
     return portfolio, nil
 }
 
@@ -104,7 +189,7 @@ func (s *PortfolioService) GetPortfolio(ctx context.Context, id uuid.UUID) (*mod
             zap.Error(err),
             zap.String("portfolio_id", id.String()),
         )
-        return nil, fmt.Errorf("%w: %v", ErrRepositoryOperation, err)
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
     }
 
     return portfolio, nil
@@ -113,7 +198,7 @@ func (s *PortfolioService) GetPortfolio(ctx context.Context, id uuid.UUID) (*mod
 // UpdatePortfolio updates an existing portfolio
 func (s *PortfolioService) UpdatePortfolio(ctx context.Context, portfolio *models.Portfolio) (*models.Portfolio, error) {
     if err := s.validatePortfolio(portfolio); err != nil {
-        return nil, fmt.Errorf("%w: %v", ErrInvalidPortfolio, err)
+        return nil, fmt.Errorf("%w: %w", ErrInvalidPortfolio, err)
     }
 
     s.mutex.Lock()
@@ -121,24 +206,33 @@ func (s *PortfolioService) UpdatePortfolio(ctx context.Context, portfolio *model
 
     portfolio.LastUpdated = time.Now().UTC()
 
-    err := s.repo.WithTransaction(ctx, func(tx repository.PostgresRepository) error {
-        if err := tx.UpdatePortfolio(ctx, portfolio); err != nil {
-            return fmt.Errorf("failed to update portfolio: %w", err)
-        }
-        return nil
-    })
+    var err error
+    if s.eventSourced {
+        err = s.appendLedger(ctx, portfolio.ID, portfolio.LastUpdated, ledgerChange{
+            eventType: models.LedgerPortfolioUpdated,
+            data:      models.LedgerPortfolioDetails{Name: portfolio.Name, Description: portfolio.Description},
+        })
+    } else {
+        err = s.repo.WithTransaction(ctx, func(tx repository.PostgresRepository) error {
+            if err := tx.UpdatePortfolio(ctx, portfolio); err != nil {
+                return fmt.Errorf("failed to update portfolio: %w", err)
+            }
+            return nil
+        })
+    }
 
     if err != nil {
         s.logger.Error("Failed to update portfolio",
             zap.Error(err),
             zap.String("portfolio_id", portfolio.ID.String()),
         )
-        return nil, fmt.Errorf("%w: %v", ErrRepositoryOperation, err)
+        return nil, repositoryError(err)
     }
 
     s.logger.Info("Portfolio updated successfully",
         zap.String("portfolio_id", portfolio.ID.String()),
     )
+    s.emitWebhook(ctx, portfolio.ID, models.WebhookPortfolioUpdated, portfolioEventData(portfolio))
 
     return portfolio, nil
 }
@@ -146,7 +240,15 @@ func (s *PortfolioService) UpdatePortfolio(ctx context.Context, portfolio *model
 // AddAsset adds a new asset to a portfolio
 func (s *PortfolioService) AddAsset(ctx context.Context, portfolioID uuid.UUID, asset *models.Asset) error {
     if err := s.validateAsset(asset); err != nil {
-        return fmt.Errorf("%w: %v", ErrInvalidAsset, err)
+        return fmt.Errorf("%w: %w", ErrInvalidAsset, err)
+    }
+    if err := s.checkWritable(ctx, portfolioID); err != nil {
+        return err
+    }
+    if asset.Type == models.CUSTOM_ASSET_TYPE {
+        if err := s.checkCustomHolding(ctx, portfolioID, asset); err != nil {
+            return err
+        }
     }
 
     s.mutex.Lock()
@@ -154,19 +256,24 @@ func (s *PortfolioService) AddAsset(ctx context.Context, portfolioID uuid.UUID,
 
     portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
     if err != nil {
-        return fmt.Errorf("%w: %v", ErrRepositoryOperation, err)
+        return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    if asset.ID == uuid.Nil {
+        asset.ID = uuid.New()
     }
+    asset.LastUpdated = time.Now().UTC()
 
     if err := portfolio.AddAsset(*asset); err != nil {
         return fmt.Errorf("failed to add asset: %w", err)
     }
 
-    err = s.repo.WithTransaction(ctx, func(tx repository.PostgresRepository) error {
-        if err := tx.UpdatePortfolio(ctx, portfolio); err != nil {
-            return fmt.Errorf("failed to update portfolio with new asset: %w", err)
-        }
-        return nil
-    })
+    if s.eventSourced {
+        err = s.appendLedger(ctx, portfolioID, asset.LastUpdated, ledgerChange{eventType: models.LedgerAssetAdded, data: asset})
+    } else {
+        // The asset, portfolio totals and AssetAdded event are written atomically
+        err = s.repo.AddAsset(ctx, portfolio, asset)
+    }
 
     if err != nil {
         s.logger.Error("Failed to add asset",
@@ -174,13 +281,68 @@ func (s *PortfolioService) AddAsset(ctx context.Context, portfolioID uuid.UUID,
             zap.String("portfolio_id", portfolioID.String()),
             zap.String("asset_symbol", asset.Symbol),
         )
-        return fmt.Errorf("%w: %v", ErrRepositoryOperation, err)
+        return repositoryError(err)
     }
 
     s.logger.Info("Asset added successfully",
         zap.String("portfolio_id", portfolioID.String()),
         zap.String("asset_symbol", asset.Symbol),
     )
+    s.emitWebhook(ctx, portfolioID, models.WebhookAssetAdded, assetEventData(asset))
+
+    return nil
+}
+
+// RemoveAsset removes an asset from a portfolio
+func (s *PortfolioService) RemoveAsset(ctx context.Context, portfolioID, assetID uuid.UUID) error {
+    if portfolioID == uuid.Nil || assetID == uuid.Nil {
+        return ErrInvalidAsset
+    }
+    if err := s.checkWritable(ctx, portfolioID); err != nil {
+        return err
+    }
+
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    asset, err := portfolio.RemoveAsset(assetID)
+    if err != nil {
+        return fmt.Errorf("%w: %v", ErrNotFound, err)
+    }
+
+    if s.eventSourced {
+        err = s.appendLedger(ctx, portfolioID, time.Now().UTC(), ledgerChange{
+            eventType: models.LedgerAssetRemoved,
+            data:      models.LedgerAssetRef{AssetID: assetID},
+        })
+    } else {
+        err = s.repo.WithTransaction(ctx, func(tx repository.PostgresRepository) error {
+            if err := tx.UpdatePortfolio(ctx, portfolio); err != nil {
+                return fmt.Errorf("failed to update portfolio without asset: %w", err)
+            }
+            return nil
+        })
+    }
+
+    if err != nil {
+        s.logger.Error("Failed to remove asset",
+            zap.Error(err),
+            zap.String("portfolio_id", portfolioID.String()),
+            zap.String("asset_id", assetID.String()),
+        )
+        return repositoryError(err)
+    }
+
+    s.logger.Info("Asset removed successfully",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.String("asset_symbol", asset.Symbol),
+    )
+    s.emitWebhook(ctx, portfolioID, models.WebhookAssetRemoved, assetEventData(asset))
 
     return nil
 }
@@ -192,11 +354,11 @@ func (s *PortfolioService) GetPerformanceMetrics(ctx context.Context, portfolioI
 
     portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
     if err != nil {
-        return nil, fmt.Errorf("%w: %v", ErrRepositoryOperation, err)
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
     }
 
     // Calculate total value and profit/loss
-    totalValue := portfolio.CalculateTotalValue(s.getCurrentPrices())
+    totalValue := portfolio.CalculateTotalValue(s.getCurrentPrices(ctx, portfolio))
     profitLoss := portfolio.CalculateProfitLoss()
 
     s.logger.Info("Performance metrics calculated",
@@ -215,11 +377,11 @@ func (s *PortfolioService) validatePortfolio(p *models.Portfolio) error {
     }
 
     if p.UserID == uuid.Nil {
-        return errors.New("user ID is required")
+        return models.InvalidField("user_id", errors.New("user ID is required"))
     }
 
     if p.Name == "" {
-        return errors.New("portfolio name is required")
+        return models.InvalidField("name", errors.New("portfolio name is required"))
     }
 
     if len(p.Assets) > models.MAX_ASSETS_PER_PORTFOLIO {
@@ -235,24 +397,98 @@ func (s *PortfolioService) validateAsset(a *models.Asset) error {
         return errors.New("asset cannot be nil")
     }
 
-    if err := models.ValidateAssetType(a.Type); err != nil {
+    if err := models.ValidateHoldingType(a.Type); err != nil {
         return err
     }
 
+    if a.Type == models.FIAT_ASSET_TYPE {
+        if err := models.ValidateFiatCurrency(a.Symbol); err != nil {
+            return err
+        }
+    }
+
     if a.Symbol == "" {
         return errors.New("asset symbol is required")
     }
 
-    if a.Amount.LessThan(models.MIN_TRANSACTION_AMOUNT) {
-        return fmt.Errorf("asset amount must be greater than %v", models.MIN_TRANSACTION_AMOUNT)
+    if !a.Amount.IsPositive() {
+        return errors.New("asset amount must be positive")
+    }
+
+    if err := models.ValidateAmountPrecision(a.Type, a.Symbol, a.Amount); err != nil {
+        return err
     }
 
     return nil
 }
 
-// getCurrentPrices fetches current market prices for assets
-// This is a placeholder - implement actual market data integration
-func (s *PortfolioService) getCurrentPrices() map[string]decimal.Decimal {
-    // TODO: Implement market data integration
-    return make(map[string]decimal.Decimal)
+// getCurrentPrices fetches the latest stored market prices of the portfolio's assets.
+// Assets without a stored price keep their last known value. Stored prices are
+// used however long providers have been unavailable, up to the maximum
+// staleness, and the portfolio records how fresh they are and which provider
+// supplied each. Assets without a provider price, and custom assets, which
+// no provider quotes, are valued at the portfolio's manual price of their
+// symbol when one is valid. Fiat balances are valued at the stored exchange
+// rate of their currency, and at one in the base currency. Assets of delisted
+// symbols are marked and valued by the delisted price policy unless a manual
+// price is valid.
+func (s *PortfolioService) getCurrentPrices(ctx context.Context, p *models.Portfolio) map[string]decimal.Decimal {
+    symbols := make([]string, 0, len(p.Assets))
+    for _, asset := range p.Assets {
+        if asset.Type == models.CUSTOM_ASSET_TYPE {
+            continue
+        }
+        symbols = append(symbols, asset.Symbol)
+    }
+
+    prices, err := s.currentPrices(ctx, symbols)
+    if err != nil {
+        s.logger.Warn("Failed to load current prices",
+            zap.Error(err),
+            zap.String("portfolio_id", p.ID.String()),
+        )
+        return make(map[string]decimal.Decimal)
+    }
+
+    // Delisted prices are frozen, so they neither count as stale nor expire
+    delisted := s.delistedSymbols(ctx, p, symbols)
+    for symbol := range delisted {
+        delete(prices, symbol)
+    }
+
+    if s.freshness != nil {
+        staleness, err := s.freshness.Apply(ctx, prices, time.Now())
+        if err != nil {
+            s.logger.Warn("Failed to load price freshness", zap.Error(err))
+        }
+        if len(staleness.Dropped) > 0 {
+            s.logger.Debug("Left out prices past the maximum staleness",
+                zap.Strings("symbols", staleness.Dropped),
+                zap.String("portfolio_id", p.ID.String()),
+            )
+        }
+        if !staleness.AsOf.IsZero() {
+            asOf := staleness.AsOf.UTC()
+            p.PricesAsOf = &asOf
+        }
+        p.PricesStale = staleness.Stale
+        for i := range p.Assets {
+            if age, ok := staleness.Prices[p.Assets[i].Symbol]; ok {
+                asOf := age.AsOf.UTC()
+                p.Assets[i].PriceAsOf = &asOf
+                p.Assets[i].PriceStale = age.Stale
+                p.Assets[i].PriceSource = age.Source
+            }
+        }
+    }
+
+    for _, asset := range p.Assets {
+        if price, ok := models.BaseCurrencyPrice(asset); ok {
+            prices[asset.Symbol] = price
+        }
+    }
+
+    s.applyManualPrices(ctx, p, prices)
+    s.applyDelisting(p, prices, delisted)
+    return prices
 }
\ No newline at end of file
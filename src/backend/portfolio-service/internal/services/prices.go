@@ -0,0 +1,75 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/shopspring/decimal"    // v1.3.1
+    "go.uber.org/zap"                 // v1.24.0
+)
+
+// RefreshPrices records the latest market price of each symbol. Prices are
+// stored once per symbol rather than per asset, so refreshing a popular symbol
+// costs a single row write however many portfolios hold it. sources names the
+// provider that supplied each price and may be nil when they are not known.
+func (s *PortfolioService) RefreshPrices(ctx context.Context, prices map[string]decimal.Decimal, sources map[string]string) error {
+    normalized := make(map[string]decimal.Decimal, len(prices))
+    origins := make(map[string]string, len(prices))
+    for symbol, price := range prices {
+        source := strings.TrimSpace(sources[symbol])
+        symbol = strings.ToUpper(strings.TrimSpace(symbol))
+        if symbol == "" || price.IsNegative() {
+            return fmt.Errorf("%w: invalid price for symbol %q", ErrInvalidAsset, symbol)
+        }
+        normalized[symbol] = price
+        origins[symbol] = source
+    }
+
+    now := time.Now().UTC()
+    updated, err := s.repo.UpsertCurrentPrices(ctx, normalized, origins, now)
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    if s.freshness != nil {
+        s.freshness.Record(origins, now)
+    }
+
+    // Symbols quoted again are no longer delisted
+    quoted := make([]string, 0, len(normalized))
+    for symbol := range normalized {
+        quoted = append(quoted, symbol)
+    }
+    relisted, err := s.repo.ClearDelistedSymbols(ctx, quoted)
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    if relisted > 0 {
+        s.logger.Info("Delisted symbols quoted again", zap.Int64("symbols", relisted))
+    }
+
+    // Write through so valuations on this replica see the new prices at once
+    if s.prices != nil {
+        if err := s.prices.SetPrices(ctx, normalized); err != nil {
+            s.logger.Warn("Failed to cache refreshed prices", zap.Error(err))
+        }
+    }
+
+    s.logger.Debug("Current prices refreshed",
+        zap.Int("symbols", len(normalized)),
+        zap.Int64("updated", updated),
+    )
+
+    return nil
+}
+
+// currentPrices returns the latest stored prices of symbols, through the
+// price cache when one is configured
+func (s *PortfolioService) currentPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error) {
+    if s.prices != nil {
+        return s.prices.GetCurrentPrices(ctx, symbols)
+    }
+    return s.repo.GetCurrentPrices(ctx, symbols)
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/archive"
+    "bookman/portfolio-service/internal/models"
+)
+
+// PurgeUserData permanently erases all portfolios of a user with their
+// holdings, history, alerts and audit entries. Archived transaction objects
+// cannot take part in the database transaction, so they are deleted only
+// once it committed; a failed purge leaves everything in place and can be
+// retried, and objects that fail to delete are retried by the archiver.
+func (s *PortfolioService) PurgeUserData(ctx context.Context, userID uuid.UUID) (*models.UserDataPurge, error) {
+    if !s.purgeEnabled {
+        return nil, ErrFeatureDisabled
+    }
+    if userID == uuid.Nil {
+        return nil, fmt.Errorf("%w: user ID is required", ErrInvalidPortfolio)
+    }
+
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+
+    purge := &models.UserDataPurge{
+        ID:          uuid.New(),
+        UserID:      userID,
+        CompletedAt: time.Now().UTC(),
+    }
+
+    erase := func(ctx context.Context) ([]string, error) {
+        if err := s.repo.PurgeUserData(ctx, purge); err != nil {
+            return nil, err
+        }
+        return purge.ArchiveKeys, nil
+    }
+
+    var err error
+    if s.archive != nil {
+        _, err = s.archive.PurgeUser(ctx, erase)
+    } else {
+        _, err = erase(ctx)
+    }
+    if errors.Is(err, archive.ErrDeletionPending) {
+        s.logger.Warn("Archived transaction objects left for the archiver to delete",
+            zap.String("purge_id", purge.ID.String()),
+            zap.Error(err))
+    } else if err != nil {
+        s.logger.Error("Failed to purge user data",
+            zap.String("user_id", userID.String()),
+            zap.Error(err))
+        return nil, repositoryError(err)
+    }
+
+    s.logger.Info("Purged user data",
+        zap.String("purge_id", purge.ID.String()),
+        zap.String("user_id", userID.String()),
+        zap.Int("portfolios", purge.Portfolios))
+
+    return purge, nil
+}
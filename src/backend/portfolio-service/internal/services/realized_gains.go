@@ -0,0 +1,36 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// ListRealizedGains returns the gains realized by the sells of a portfolio
+// within [start, end), as stored when the sells were recorded. An unset end
+// lists up to now.
+func (s *PortfolioService) ListRealizedGains(ctx context.Context, portfolioID uuid.UUID, start, end time.Time) ([]models.RealizedGain, error) {
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+    if end.IsZero() {
+        end = time.Now().UTC()
+    }
+    if !start.Before(end) {
+        return nil, fmt.Errorf("%w: list start must be before end", ErrInvalidTransaction)
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    gains, err := s.repo.ListRealizedGains(ctx, portfolioID, start, end)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    return gains, nil
+}
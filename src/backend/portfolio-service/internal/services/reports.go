@@ -0,0 +1,327 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/reports"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// reportRetryDelay is how long a failed scheduled delivery waits before it is
+// attempted again
+const reportRetryDelay = time.Hour
+
+// reportSettings configures performance report generation
+type reportSettings struct {
+    store          reports.Store
+    prefix         string
+    urlExpiry      time.Duration
+    deliveryExpiry time.Duration
+}
+
+// GenerateReport renders the performance report of the calendar week, month
+// or quarter containing at as a PDF, stores it and returns it with a signed
+// download URL. A zero at selects the last completed period. Reports on the
+// current period value the portfolio at current prices.
+func (s *PortfolioService) GenerateReport(ctx context.Context, portfolioID uuid.UUID, period models.ReportPeriod, at time.Time) (*models.GeneratedReport, error) {
+    if s.reports == nil {
+        return nil, ErrFeatureDisabled
+    }
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    now := time.Now().UTC()
+    var (
+        periodStart, periodEnd time.Time
+        err                    error
+    )
+    if at.IsZero() {
+        periodStart, periodEnd, err = models.LastCompletedReportPeriod(period, now)
+    } else {
+        periodStart, periodEnd, err = models.ReportPeriodBounds(period, at)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidPortfolio, err)
+    }
+    if periodStart.After(now) {
+        return nil, fmt.Errorf("%w: report period has not started", ErrInvalidPortfolio)
+    }
+
+    return s.renderReport(ctx, portfolioID, period, periodStart, periodEnd, models.ReportFormatPDF, s.reports.urlExpiry, now)
+}
+
+// renderReport builds the report of a period, renders it in format, stores it
+// and signs a download URL valid for expiry
+func (s *PortfolioService) renderReport(ctx context.Context, portfolioID uuid.UUID, period models.ReportPeriod, periodStart, periodEnd time.Time, format models.ReportFormat, expiry time.Duration, now time.Time) (*models.GeneratedReport, error) {
+    report, err := s.buildReport(ctx, portfolioID, period, periodStart, periodEnd, now)
+    if err != nil {
+        return nil, err
+    }
+
+    data, err := reports.Render(report, format)
+    if err != nil {
+        return nil, fmt.Errorf("failed to render report: %w", err)
+    }
+
+    key := reports.ObjectKey(s.reports.prefix, report, format)
+    if err := s.reports.store.Put(ctx, key, data, reports.ContentType(format), reports.Filename(report, format)); err != nil {
+        s.logger.Error("Failed to store report",
+            zap.Error(err),
+            zap.String("portfolio_id", portfolioID.String()),
+        )
+        return nil, fmt.Errorf("failed to store report: %w", err)
+    }
+
+    url, err := s.reports.store.SignedURL(ctx, key, expiry)
+    if err != nil {
+        return nil, fmt.Errorf("failed to sign report URL: %w", err)
+    }
+
+    s.logger.Info("Report generated",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.String("report_id", report.ID.String()),
+        zap.String("period", string(period)),
+        zap.String("format", string(format)),
+        zap.Time("period_start", periodStart),
+        zap.Int("bytes", len(data)),
+    )
+
+    return &models.GeneratedReport{
+        Report:      report,
+        ObjectKey:   key,
+        DownloadURL: url,
+        ExpiresAt:   now.Add(expiry),
+    }, nil
+}
+
+// buildReport compares the latest snapshot at the start of a period with the
+// one at its end, or with the current valuation while the period is running,
+// and totals the fees paid in the period
+func (s *PortfolioService) buildReport(ctx context.Context, portfolioID uuid.UUID, period models.ReportPeriod, periodStart, periodEnd, now time.Time) (*models.PerformanceReport, error) {
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    start, err := s.repo.GetSnapshotAt(ctx, portfolioID, periodStart)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    var end *models.PortfolioSnapshot
+    if periodEnd.After(now) {
+        periodEnd = now
+        end = s.valuePortfolio(ctx, portfolio, now)
+    } else {
+        end, err = s.repo.GetSnapshotAt(ctx, portfolioID, periodEnd)
+        if err != nil {
+            return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+        }
+        if end == nil {
+            return nil, fmt.Errorf("%w: no valuation recorded by the end of the period", ErrInsufficientData)
+        }
+    }
+
+    fees, err := s.repo.SumTransactionFees(ctx, portfolioID, periodStart, periodEnd)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    report := models.BuildPerformanceReport(portfolio, period, periodStart, periodEnd, start, end)
+    report.FeesPaid = fees
+    return report, nil
+}
+
+// SetReportSchedule validates and stores the weekly or monthly report schedule
+// of a portfolio, replacing any schedule of the same frequency. Scheduled
+// reports are delivered through notifications, so both must be enabled.
+func (s *PortfolioService) SetReportSchedule(ctx context.Context, schedule *models.ReportSchedule) (*models.ReportSchedule, error) {
+    if s.reports == nil || s.notifier == nil {
+        return nil, fmt.Errorf("%w: report schedules", ErrFeatureDisabled)
+    }
+    if schedule == nil || schedule.UserID == uuid.Nil || schedule.PortfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+    if err := schedule.Validate(); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidReportSchedule, err)
+    }
+
+    now := time.Now().UTC()
+    next, err := schedule.NextRun(now)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidReportSchedule, err)
+    }
+    schedule.ID = uuid.New()
+    schedule.NextRunAt = next
+    schedule.CreatedAt = now
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    if err := s.checkPortfolioOwner(ctx, schedule.UserID, schedule.PortfolioID); err != nil {
+        return nil, err
+    }
+
+    if err := s.repo.UpsertReportSchedule(ctx, schedule); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    s.logger.Info("Report schedule set",
+        zap.String("schedule_id", schedule.ID.String()),
+        zap.String("portfolio_id", schedule.PortfolioID.String()),
+        zap.String("frequency", string(schedule.Frequency)),
+        zap.Time("next_run_at", schedule.NextRunAt),
+    )
+
+    return schedule, nil
+}
+
+// ListReportSchedules returns the report schedules of a user
+func (s *PortfolioService) ListReportSchedules(ctx context.Context, userID uuid.UUID) ([]models.ReportSchedule, error) {
+    if userID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    schedules, err := s.repo.ListReportSchedules(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    return schedules, nil
+}
+
+// DeleteReportSchedule removes a report schedule of a user
+func (s *PortfolioService) DeleteReportSchedule(ctx context.Context, userID, scheduleID uuid.UUID) error {
+    if userID == uuid.Nil || scheduleID == uuid.Nil {
+        return ErrInvalidPortfolio
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    err := s.repo.DeleteReportSchedule(ctx, userID, scheduleID)
+    if errors.Is(err, repository.ErrReportScheduleNotFound) {
+        return fmt.Errorf("%w: %v", ErrNotFound, err)
+    }
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    return nil
+}
+
+// RunReportSchedule delivers the report of the period completed by the time a
+// claimed schedule was due, taken in the schedule's timezone, and schedules
+// the next delivery. A failed delivery is recorded on the schedule and
+// retried after reportRetryDelay.
+func (s *PortfolioService) RunReportSchedule(ctx context.Context, schedule *models.ReportSchedule) (*models.GeneratedReport, error) {
+    if s.reports == nil {
+        return nil, fmt.Errorf("%w: report schedules", ErrFeatureDisabled)
+    }
+    if schedule == nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    generated, err := s.deliverReport(ctx, schedule)
+    now := time.Now().UTC()
+    if err != nil {
+        message := err.Error()
+        if len(message) > maxSyncErrorLength {
+            message = message[:maxSyncErrorLength]
+        }
+        if recordErr := s.repo.RecordReportScheduleError(ctx, schedule.ID, message, now.Add(reportRetryDelay)); recordErr != nil {
+            s.logger.Error("Failed to record report schedule error",
+                zap.Error(recordErr),
+                zap.String("schedule_id", schedule.ID.String()),
+            )
+        }
+        return nil, err
+    }
+
+    next, err := schedule.NextRun(now)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidReportSchedule, err)
+    }
+    if err := s.repo.CompleteReportRun(ctx, schedule.ID, now, next); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    schedule.LastRunAt = &now
+    schedule.LastError = ""
+    schedule.NextRunAt = next
+
+    return generated, nil
+}
+
+// deliverReport generates the report of a schedule and notifies its owner
+func (s *PortfolioService) deliverReport(ctx context.Context, schedule *models.ReportSchedule) (*models.GeneratedReport, error) {
+    loc, err := schedule.Location()
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidReportSchedule, err)
+    }
+
+    period := schedule.Frequency.Period()
+    periodStart, periodEnd, err := models.LastCompletedReportPeriod(period, schedule.NextRunAt.In(loc))
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidReportSchedule, err)
+    }
+
+    now := time.Now().UTC()
+    generated, err := s.renderReport(ctx, schedule.PortfolioID, period, periodStart, periodEnd, schedule.Format, s.reports.deliveryExpiry, now)
+    if err != nil {
+        return nil, err
+    }
+
+    s.notifier.Notify(reportNotification(schedule, generated, loc))
+
+    return generated, nil
+}
+
+// reportNotification describes a delivered scheduled report for its owner
+func reportNotification(schedule *models.ReportSchedule, generated *models.GeneratedReport, loc *time.Location) models.Notification {
+    report := generated.Report
+    frequency := string(schedule.Frequency)
+    label := reports.PeriodLabel(report)
+
+    body := fmt.Sprintf("Your %s portfolio report for %s is ready.\n\n"+
+        "Value: %s %s (%s%%)\nProfit and loss over the period: %s %s\n\n"+
+        "Download the %s report until %s:\n%s",
+        frequency, label,
+        report.EndValue.StringFixed(2), report.BaseCurrency, report.ReturnPercentage.StringFixed(2),
+        report.PeriodProfitLoss.StringFixed(2), report.BaseCurrency,
+        strings.ToUpper(string(schedule.Format)), generated.ExpiresAt.In(loc).Format("January 2, 2006 15:04 MST"),
+        generated.DownloadURL,
+    )
+
+    return models.Notification{
+        Event:       models.EventReportReady,
+        PortfolioID: schedule.PortfolioID,
+        UserID:      schedule.UserID,
+        Subject:     fmt.Sprintf("%s%s portfolio report: %s", strings.ToUpper(frequency[:1]), frequency[1:], label),
+        Body:        body,
+        Attributes: map[string]string{
+            "report_id":    report.ID.String(),
+            "schedule_id":  schedule.ID.String(),
+            "format":       string(schedule.Format),
+            "period_start": report.PeriodStart.Format(time.RFC3339),
+            "period_end":   report.PeriodEnd.Format(time.RFC3339),
+            "download_url": generated.DownloadURL,
+            "expires_at":   generated.ExpiresAt.Format(time.RFC3339),
+        },
+        CreatedAt: generated.Report.GeneratedAt,
+    }
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/risk"
+)
+
+const (
+    defaultRiskLookbackDays = 365
+    maxRiskLookbackDays     = 5 * 365
+)
+
+// varConfidences and varHorizons define the VaR estimates reported per method
+var (
+    varConfidences = []float64{0.95, 0.99}
+    varHorizons    = []int{1, 10}
+)
+
+// GetRiskMetrics estimates parametric and historical-simulation Value-at-Risk
+// of the portfolio's current holdings from daily asset returns over the
+// lookback window. Holdings without price history are excluded and reported.
+func (s *PortfolioService) GetRiskMetrics(ctx context.Context, portfolioID uuid.UUID, lookbackDays int) (*models.RiskMetrics, error) {
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+    if s.market == nil {
+        return nil, fmt.Errorf("%w: market data", ErrFeatureDisabled)
+    }
+
+    if lookbackDays <= 0 {
+        lookbackDays = defaultRiskLookbackDays
+    }
+    if lookbackDays > maxRiskLookbackDays {
+        return nil, fmt.Errorf("%w: lookback exceeds %d days", ErrInvalidPortfolio, maxRiskLookbackDays)
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    portfolio.CalculateTotalValue(s.getCurrentPrices(ctx, portfolio))
+
+    values := make(map[string]decimal.Decimal)
+    for _, asset := range portfolio.Assets {
+        if asset.CurrentValue.IsPositive() {
+            values[asset.Symbol] = values[asset.Symbol].Add(asset.CurrentValue)
+        }
+    }
+
+    symbols := make([]string, 0, len(values))
+    for symbol := range values {
+        symbols = append(symbols, symbol)
+    }
+    sort.Strings(symbols)
+
+    end := time.Now().UTC().Truncate(24 * time.Hour)
+    start := end.AddDate(0, 0, -lookbackDays)
+
+    series, err := s.market.DailyReturns(ctx, symbols, start, end)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    metrics := &models.RiskMetrics{
+        PortfolioID:  portfolioID,
+        CoveredValue: decimal.Zero,
+        Observations: series.Len(),
+        WindowStart:  start,
+        WindowEnd:    end,
+        CalculatedAt: time.Now().UTC(),
+    }
+
+    weights := make(map[string]float64, len(symbols))
+    for _, symbol := range symbols {
+        if _, ok := series.Returns[symbol]; !ok {
+            metrics.UncoveredSymbols = append(metrics.UncoveredSymbols, symbol)
+            continue
+        }
+        weights[symbol] = values[symbol].InexactFloat64()
+        metrics.CoveredValue = metrics.CoveredValue.Add(values[symbol])
+    }
+
+    if len(weights) == 0 || series.Len() < risk.MinObservations {
+        return nil, fmt.Errorf("%w: %d aligned daily returns, need %d", ErrInsufficientData, series.Len(), risk.MinObservations)
+    }
+
+    returns, err := risk.PortfolioReturns(series.Returns, weights)
+    if err != nil {
+        return nil, fmt.Errorf("failed to combine returns: %w", err)
+    }
+
+    for _, method := range []models.VaRMethod{models.VaRMethodParametric, models.VaRMethodHistorical} {
+        for _, confidence := range varConfidences {
+            for _, horizon := range varHorizons {
+                var fraction float64
+                if method == models.VaRMethodParametric {
+                    fraction, err = risk.ParametricVaR(returns, confidence, horizon)
+                } else {
+                    fraction, err = risk.HistoricalVaR(returns, confidence, horizon)
+                }
+                if err != nil {
+                    if errors.Is(err, risk.ErrInsufficientHistory) {
+                        return nil, fmt.Errorf("%w: %v", ErrInsufficientData, err)
+                    }
+                    return nil, fmt.Errorf("failed to estimate VaR: %w", err)
+                }
+
+                metrics.VaR = append(metrics.VaR, models.VaREstimate{
+                    Method:      method,
+                    Confidence:  confidence,
+                    HorizonDays: horizon,
+                    Value:       metrics.CoveredValue.Mul(decimal.NewFromFloat(fraction)).Round(2),
+                })
+            }
+        }
+    }
+
+    s.logger.Info("Risk metrics calculated",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.Int("observations", metrics.Observations),
+        zap.Strings("uncovered_symbols", metrics.UncoveredSymbols),
+    )
+
+    return metrics, nil
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+    "bookman/portfolio-service/internal/sharing"
+)
+
+// shareSettings configures read-only portfolio share links
+type shareSettings struct {
+    defaultTTL time.Duration
+    maxTTL     time.Duration
+}
+
+// SharePortfolio mints a share token granting read-only access to the
+// valuation and allocation of an active portfolio for ttl, or the configured
+// default lifetime when ttl is zero. The token is only returned here.
+func (s *PortfolioService) SharePortfolio(ctx context.Context, portfolioID uuid.UUID, ttl time.Duration) (*models.PortfolioShare, string, error) {
+    if s.sharing == nil {
+        return nil, "", ErrFeatureDisabled
+    }
+    if portfolioID == uuid.Nil {
+        return nil, "", ErrInvalidPortfolio
+    }
+    if ttl == 0 {
+        ttl = s.sharing.defaultTTL
+    }
+    if ttl < 0 || ttl > s.sharing.maxTTL {
+        return nil, "", fmt.Errorf("%w: lifetime must be positive and at most %s", ErrInvalidShare, s.sharing.maxTTL)
+    }
+
+    token, hash, err := sharing.NewToken()
+    if err != nil {
+        return nil, "", err
+    }
+
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+
+    now := time.Now().UTC()
+    share := &models.PortfolioShare{
+        ID:          uuid.New(),
+        PortfolioID: portfolioID,
+        TokenHash:   hash,
+        CreatedAt:   now,
+        ExpiresAt:   now.Add(ttl),
+    }
+    if err := s.repo.CreatePortfolioShare(ctx, share); err != nil {
+        if errors.Is(err, repository.ErrPortfolioNotFound) {
+            return nil, "", fmt.Errorf("%w: portfolio %s", ErrNotFound, portfolioID)
+        }
+        return nil, "", repositoryError(err)
+    }
+
+    s.logger.Info("Portfolio shared",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.String("share_id", share.ID.String()),
+        zap.Time("expires_at", share.ExpiresAt),
+    )
+
+    return share, token, nil
+}
+
+// ListShares returns the shares of a portfolio, revoked and expired ones
+// included
+func (s *PortfolioService) ListShares(ctx context.Context, portfolioID uuid.UUID) ([]models.PortfolioShare, error) {
+    if s.sharing == nil {
+        return nil, ErrFeatureDisabled
+    }
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    shares, err := s.repo.ListPortfolioShares(ctx, portfolioID)
+    if err != nil {
+        return nil, repositoryError(err)
+    }
+    return shares, nil
+}
+
+// RevokeShare immediately ends the access granted by a share
+func (s *PortfolioService) RevokeShare(ctx context.Context, portfolioID, shareID uuid.UUID) error {
+    if s.sharing == nil {
+        return ErrFeatureDisabled
+    }
+    if portfolioID == uuid.Nil || shareID == uuid.Nil {
+        return ErrInvalidPortfolio
+    }
+
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+
+    err := s.repo.RevokePortfolioShare(ctx, portfolioID, shareID, time.Now().UTC())
+    if errors.Is(err, repository.ErrShareNotFound) {
+        return fmt.Errorf("%w: share %s", ErrNotFound, shareID)
+    }
+    if err != nil {
+        return repositoryError(err)
+    }
+
+    s.logger.Info("Portfolio share revoked",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.String("share_id", shareID.String()),
+    )
+
+    return nil
+}
+
+// ResolveShare returns the active share a token grants, or nil when the
+// token is malformed, unknown, expired or revoked
+func (s *PortfolioService) ResolveShare(ctx context.Context, token string) (*models.PortfolioShare, error) {
+    if s.sharing == nil {
+        return nil, nil
+    }
+
+    hash, err := sharing.HashToken(token)
+    if err != nil {
+        return nil, nil
+    }
+
+    share, err := s.repo.GetActivePortfolioShare(ctx, hash, time.Now().UTC())
+    if errors.Is(err, repository.ErrShareNotFound) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, repositoryError(err)
+    }
+    return share, nil
+}
+
+// GetSharedPortfolio returns the valued portfolio and allocation granted by
+// the share authorizing ctx, along with that share. Dust is left out of the
+// allocation as it is for the owner.
+func (s *PortfolioService) GetSharedPortfolio(ctx context.Context) (*models.Portfolio, *models.Allocation, *models.PortfolioShare, error) {
+    share, ok := sharing.FromContext(ctx)
+    if !ok {
+        return nil, nil, nil, ErrShareRequired
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, share.PortfolioID)
+    if err != nil {
+        return nil, nil, nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    portfolio.CalculateTotalValue(s.getCurrentPrices(ctx, portfolio))
+
+    allocation, err := s.buildAllocation(ctx, portfolio, false)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+
+    return portfolio, allocation, share, nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// CaptureSnapshot values a portfolio at current prices and records the result
+// as a historical snapshot taken at capturedAt
+func (s *PortfolioService) CaptureSnapshot(ctx context.Context, portfolioID uuid.UUID, capturedAt time.Time) (*models.PortfolioSnapshot, error) {
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    snapshot := s.valuePortfolio(ctx, portfolio, capturedAt)
+
+    if err := s.repo.CreateSnapshot(ctx, snapshot); err != nil {
+        s.logger.Error("Failed to record snapshot",
+            zap.Error(err),
+            zap.String("portfolio_id", portfolioID.String()),
+        )
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    // Alerts must not fail the snapshot; they are retried on the next capture
+    if err := s.evaluateAlerts(ctx, portfolio, snapshot); err != nil {
+        s.logger.Warn("Failed to evaluate portfolio alerts",
+            zap.Error(err),
+            zap.String("portfolio_id", portfolioID.String()),
+        )
+    }
+
+    return snapshot, nil
+}
+
+// valuePortfolio values a portfolio at current prices as a snapshot taken at
+// capturedAt, without recording it
+func (s *PortfolioService) valuePortfolio(ctx context.Context, portfolio *models.Portfolio, capturedAt time.Time) *models.PortfolioSnapshot {
+    totalValue := portfolio.CalculateTotalValue(s.getCurrentPrices(ctx, portfolio))
+    profitLoss := portfolio.CalculateProfitLoss()
+
+    assets := make([]models.AssetSnapshot, len(portfolio.Assets))
+    for i, asset := range portfolio.Assets {
+        assets[i] = models.AssetSnapshot{
+            AssetID:     asset.ID,
+            Symbol:      asset.Symbol,
+            Amount:      asset.Amount,
+            Value:       asset.CurrentValue,
+            CostBasis:   asset.CostBasis,
+            PriceSource: asset.PriceSource,
+            PriceAsOf:   asset.PriceAsOf,
+        }
+    }
+
+    return &models.PortfolioSnapshot{
+        ID:          uuid.New(),
+        PortfolioID: portfolio.ID,
+        TotalValue:  totalValue,
+        ProfitLoss:  profitLoss,
+        Assets:      assets,
+        CapturedAt:  capturedAt.UTC(),
+    }
+}
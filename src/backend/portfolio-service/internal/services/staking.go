@@ -0,0 +1,287 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// stakingSettings holds the staking reward options of the service
+type stakingSettings struct {
+    accrualInterval time.Duration
+}
+
+// AddStakingPosition links a staked_asset holding of a portfolio to where
+// its rewards are read from. Chain positions name the chain and the stake
+// account whose rewards the staking worker accrues; exchange positions name
+// an exchange account connected to the portfolio, whose sync records the
+// rewards of the asset's symbol on the staked asset.
+func (s *PortfolioService) AddStakingPosition(ctx context.Context, portfolioID, assetID uuid.UUID, source, provider, address string) (*models.StakingPosition, error) {
+    if s.staking == nil {
+        return nil, fmt.Errorf("%w: staking rewards", ErrFeatureDisabled)
+    }
+    if portfolioID == uuid.Nil || assetID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+    if err := models.ValidateStakingSource(source); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidStakingPosition, err)
+    }
+    if err := s.checkWritable(ctx, portfolioID); err != nil {
+        return nil, err
+    }
+
+    portfolio, err := s.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, err
+    }
+    asset, err := portfolio.GetAsset(assetID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+    }
+    if asset.Type != "staked_asset" {
+        return nil, fmt.Errorf("%w: %s is not a staked asset", ErrInvalidStakingPosition, asset.Symbol)
+    }
+
+    switch source {
+    case "chain":
+        if s.wallets == nil {
+            return nil, fmt.Errorf("%w: wallet tracking", ErrFeatureDisabled)
+        }
+        if _, err := s.wallets.registry.Rewards(provider); err != nil {
+            return nil, err
+        }
+        adapter, err := s.wallets.registry.Adapter(provider)
+        if err != nil {
+            return nil, err
+        }
+        address, err = adapter.NormalizeAddress(address)
+        if err != nil {
+            return nil, err
+        }
+    case "exchange":
+        if address != "" {
+            return nil, fmt.Errorf("%w: exchange positions have no address", ErrInvalidStakingPosition)
+        }
+        accounts, err := s.ListExchangeAccounts(ctx, portfolioID)
+        if err != nil {
+            return nil, err
+        }
+        connected := false
+        for _, account := range accounts {
+            connected = connected || account.Exchange == provider
+        }
+        if !connected {
+            return nil, fmt.Errorf("%w: no %s account is connected to the portfolio", ErrInvalidStakingPosition, provider)
+        }
+    }
+
+    now := time.Now().UTC()
+    position := &models.StakingPosition{
+        ID:            uuid.New(),
+        PortfolioID:   portfolioID,
+        AssetID:       assetID,
+        Source:        source,
+        Provider:      provider,
+        Address:       address,
+        NextAccrualAt: now,
+        CreatedAt:     now,
+    }
+    err = s.repo.CreateStakingPosition(ctx, position)
+    if errors.Is(err, repository.ErrStakingPositionExists) {
+        return nil, ErrStakingPositionExists
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    s.logger.Info("Staking position added",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.String("position_id", position.ID.String()),
+        zap.String("source", source),
+        zap.String("provider", provider),
+    )
+
+    return position, nil
+}
+
+// ListStakingPositions returns the staking positions of a portfolio with
+// their accrual status
+func (s *PortfolioService) ListStakingPositions(ctx context.Context, portfolioID uuid.UUID) ([]models.StakingPosition, error) {
+    if s.staking == nil {
+        return nil, fmt.Errorf("%w: staking rewards", ErrFeatureDisabled)
+    }
+    if portfolioID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    positions, err := s.repo.ListStakingPositions(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    return positions, nil
+}
+
+// RemoveStakingPosition stops accruing the rewards of a position. Recorded
+// rewards are kept.
+func (s *PortfolioService) RemoveStakingPosition(ctx context.Context, portfolioID, positionID uuid.UUID) error {
+    if s.staking == nil {
+        return fmt.Errorf("%w: staking rewards", ErrFeatureDisabled)
+    }
+    if portfolioID == uuid.Nil || positionID == uuid.Nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := s.repo.DeleteStakingPosition(ctx, portfolioID, positionID)
+    if errors.Is(err, repository.ErrStakingPositionNotFound) {
+        return fmt.Errorf("%w: %v", ErrNotFound, err)
+    }
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    s.logger.Info("Staking position removed",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.String("position_id", positionID.String()),
+    )
+
+    return nil
+}
+
+// AccrueStakingRewards records the rewards of a chain staking position from
+// its checkpoint as reward transactions on its staked asset. On success the
+// new checkpoint is saved and the next accrual is scheduled after the
+// accrual interval; on failure the error is recorded and the checkpoint
+// kept, so the next accrual retries the same range.
+func (s *PortfolioService) AccrueStakingRewards(ctx context.Context, position *models.StakingPosition) (*models.ExchangeImport, error) {
+    if s.staking == nil {
+        return nil, fmt.Errorf("%w: staking rewards", ErrFeatureDisabled)
+    }
+    if position == nil || position.Source != "chain" {
+        return nil, ErrInvalidStakingPosition
+    }
+
+    result, err := s.accrueRewards(ctx, position)
+    now := time.Now().UTC()
+    next := now.Add(s.staking.accrualInterval)
+    if err != nil {
+        message := err.Error()
+        if len(message) > maxSyncErrorLength {
+            message = message[:maxSyncErrorLength]
+        }
+        if recordErr := s.repo.RecordStakingError(ctx, position.ID, message, next); recordErr != nil {
+            s.logger.Error("Failed to record staking accrual error",
+                zap.Error(recordErr),
+                zap.String("position_id", position.ID.String()),
+            )
+        }
+        return nil, err
+    }
+
+    if err := s.repo.SaveStakingCheckpoint(ctx, position.ID, result.Cursor, now, next); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    position.Cursor = result.Cursor
+    position.LastAccruedAt = &now
+    position.LastError = ""
+    position.NextAccrualAt = next
+
+    return result, nil
+}
+
+// accrueRewards imports the rewards of a chain position after its cursor.
+// Rewards are valued at the daily close of the day they were credited, so
+// staking income is recorded at its value when received.
+func (s *PortfolioService) accrueRewards(ctx context.Context, position *models.StakingPosition) (*models.ExchangeImport, error) {
+    if s.wallets == nil {
+        return nil, fmt.Errorf("%w: wallet tracking", ErrFeatureDisabled)
+    }
+    reader, err := s.wallets.registry.Rewards(position.Provider)
+    if err != nil {
+        return nil, err
+    }
+
+    activity, next, err := reader.FetchRewards(ctx, position.Address, position.Cursor)
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch %s staking rewards: %w", position.Provider, err)
+    }
+
+    price, err := s.walletPrices(ctx, activity, nil)
+    if err != nil {
+        return nil, err
+    }
+    mapper := models.NewExchangeMapper(nil, price)
+    legs, err := mapper.MapActivity(activity)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidTransaction, err)
+    }
+
+    // Every reward of the stake account belongs to the position, whatever
+    // symbol its staked asset is tracked under
+    rewards := make(map[string]uuid.UUID)
+    for _, leg := range legs {
+        rewards[strings.ToUpper(leg.Symbol)] = position.AssetID
+    }
+
+    result := &models.ExchangeImport{
+        PortfolioID:    position.PortfolioID,
+        Exchange:       position.Provider,
+        Entries:        len(activity.Entries),
+        SkippedSymbols: []string{},
+        Cursor:         next,
+    }
+    source := "staking/" + position.Provider + "/" + position.Address
+    if err := s.recordLegs(ctx, position.PortfolioID, source, mapper, nil, legs, nil, rewards, result); err != nil {
+        return nil, err
+    }
+
+    s.logger.Info("Staking rewards accrued",
+        zap.String("position_id", position.ID.String()),
+        zap.String("portfolio_id", position.PortfolioID.String()),
+        zap.String("provider", position.Provider),
+        zap.Int("transactions", result.TransactionsRecorded),
+        zap.Int("duplicates", result.Duplicates),
+    )
+
+    return result, nil
+}
+
+// exchangeStakingAssets returns the staked assets of a portfolio by
+// upper-case symbol whose rewards are read from its account on exchange
+func (s *PortfolioService) exchangeStakingAssets(ctx context.Context, portfolioID uuid.UUID, exchange string) (map[string]uuid.UUID, error) {
+    if s.staking == nil {
+        return nil, nil
+    }
+
+    positions, err := s.repo.ListStakingPositions(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    if len(positions) == 0 {
+        return nil, nil
+    }
+
+    portfolio, err := s.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, err
+    }
+    assets := make(map[string]uuid.UUID)
+    for _, position := range positions {
+        if position.Source != "exchange" || position.Provider != exchange {
+            continue
+        }
+        asset, err := portfolio.GetAsset(position.AssetID)
+        if err != nil {
+            continue
+        }
+        assets[strings.ToUpper(asset.Symbol)] = asset.ID
+    }
+    return assets, nil
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/google/uuid"           // v1.3.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// GetUserSummary returns the total value, profit and loss and allocation of
+// all active portfolios of a user, valued at the latest stored prices. The
+// holdings of every portfolio are read in one query.
+func (s *PortfolioService) GetUserSummary(ctx context.Context, userID uuid.UUID) (*models.UserSummary, error) {
+    if userID == uuid.Nil {
+        return nil, fmt.Errorf("%w: user ID is required", ErrInvalidPortfolio)
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    holdings, err := s.repo.ListUserHoldings(ctx, userID)
+    if err != nil {
+        return nil, repositoryError(err)
+    }
+
+    return models.BuildUserSummary(userID, holdings), nil
+}
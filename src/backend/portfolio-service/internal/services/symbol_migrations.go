@@ -0,0 +1,137 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// MigrateSymbol maps a migrated or rebranded token to its new symbol and
+// moves every holding of the old symbol to it, batchSize holdings at a time.
+// Holdings keep their transactions and archived lots, restated in new units
+// when the ratio is not 1. Holdings with history in the cold archive are
+// skipped in that case, as archived transactions cannot be restated; the
+// IDs of holdings not moved are returned. Running a migration again moves
+// holdings added since; a symbol cannot be mapped differently once mapped.
+func (s *PortfolioService) MigrateSymbol(ctx context.Context, migration *models.SymbolMigration, batchSize int) (*models.SymbolMigration, []uuid.UUID, error) {
+    if migration == nil || batchSize <= 0 {
+        return nil, nil, ErrInvalidSymbolMigration
+    }
+    if err := migration.Validate(); err != nil {
+        return nil, nil, fmt.Errorf("%w: %w", ErrInvalidSymbolMigration, err)
+    }
+
+    now := time.Now().UTC()
+    if migration.ID == uuid.Nil {
+        migration.ID = uuid.New()
+    }
+    if migration.EffectiveAt.IsZero() {
+        migration.EffectiveAt = now
+    }
+    migration.CreatedAt = now
+
+    stored, err := s.repo.EnsureSymbolMigration(ctx, migration)
+    if err != nil {
+        return nil, nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    if stored.Conflicts(*migration) {
+        return nil, nil, fmt.Errorf("%w: %s already maps to %s at ratio %s", ErrInvalidSymbolMigration, stored.FromSymbol, stored.ToSymbol, stored.Ratio)
+    }
+
+    var skipped []uuid.UUID
+    after := uuid.Nil
+    for {
+        holdings, err := s.repo.ListSymbolHoldings(ctx, stored.FromSymbol, after, batchSize)
+        if err != nil {
+            return nil, nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+        }
+
+        migrated := 0
+        for _, holding := range holdings {
+            after = holding.AssetID
+
+            moved, err := s.migrateHolding(ctx, stored, holding)
+            if err != nil {
+                return nil, nil, err
+            }
+            if !moved {
+                skipped = append(skipped, holding.AssetID)
+                continue
+            }
+            migrated++
+        }
+
+        if migrated > 0 {
+            at := time.Now().UTC()
+            if err := s.repo.CompleteSymbolMigration(ctx, stored.ID, migrated, at); err != nil {
+                return nil, nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+            }
+            stored.AssetsMigrated += migrated
+            stored.AppliedAt = &at
+        }
+
+        if len(holdings) < batchSize {
+            break
+        }
+    }
+
+    s.logger.Info("Symbol migration applied",
+        zap.String("from_symbol", stored.FromSymbol),
+        zap.String("to_symbol", stored.ToSymbol),
+        zap.String("ratio", stored.Ratio.String()),
+        zap.Int("assets_migrated", stored.AssetsMigrated),
+        zap.Int("assets_skipped", len(skipped)),
+    )
+
+    return stored, skipped, nil
+}
+
+// migrateHolding moves one holding to the new symbol of a migration,
+// reporting false when it was skipped or is no longer held
+func (s *PortfolioService) migrateHolding(ctx context.Context, migration *models.SymbolMigration, holding models.SymbolMigrationAudit) (bool, error) {
+    if !migration.Ratio.Equal(decimal.NewFromInt(1)) {
+        archived, err := s.repo.HasArchivedLots(ctx, holding.AssetID)
+        if err != nil {
+            return false, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+        }
+        if archived {
+            s.logger.Warn("Holding with archived history not migrated",
+                zap.String("portfolio_id", holding.PortfolioID.String()),
+                zap.String("asset_id", holding.AssetID.String()),
+                zap.String("from_symbol", migration.FromSymbol),
+            )
+            return false, nil
+        }
+    }
+
+    change := models.LedgerAssetMigration{
+        MigrationID: migration.ID,
+        AssetID:     holding.AssetID,
+        FromSymbol:  migration.FromSymbol,
+        ToSymbol:    migration.ToSymbol,
+        Ratio:       migration.Ratio,
+    }
+
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+
+    if s.eventSourced {
+        err := s.appendLedger(ctx, holding.PortfolioID, time.Now().UTC(), ledgerChange{eventType: models.LedgerAssetMigrated, data: change})
+        if err != nil {
+            return false, repositoryError(err)
+        }
+        return true, nil
+    }
+
+    audit, err := s.repo.MigrateAsset(ctx, holding.PortfolioID, change)
+    if err != nil {
+        return false, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    return audit != nil, nil
+}
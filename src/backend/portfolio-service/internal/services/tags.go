@@ -0,0 +1,71 @@
+package services
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// SetAssetTags validates and replaces the tags of an asset of a portfolio,
+// returning the stored tags. An empty set clears all tags. Tags only label
+// holdings, so they can be changed on read-only portfolios too.
+func (s *PortfolioService) SetAssetTags(ctx context.Context, portfolioID, assetID uuid.UUID, tags []string) ([]string, error) {
+    if portfolioID == uuid.Nil || assetID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    normalized, err := models.NormalizeTags(tags)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidTag, err)
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    if _, err := portfolio.GetAsset(assetID); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+    }
+
+    err = s.repo.SetAssetTags(ctx, &models.AssetTags{
+        PortfolioID: portfolioID,
+        AssetID:     assetID,
+        Tags:        normalized,
+    })
+    if err != nil {
+        s.logger.Error("Failed to set asset tags",
+            zap.Error(err),
+            zap.String("portfolio_id", portfolioID.String()),
+            zap.String("asset_id", assetID.String()),
+        )
+        return nil, repositoryError(err)
+    }
+
+    return normalized, nil
+}
+
+// LoadAssetTags returns the tags of a portfolio's assets keyed by asset ID.
+// A non-empty tag removes the assets not carrying it from the portfolio,
+// which must have been read for this request.
+func (s *PortfolioService) LoadAssetTags(ctx context.Context, portfolio *models.Portfolio, tag string) (map[uuid.UUID][]string, error) {
+    if portfolio == nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    tags, err := s.repo.GetAssetTags(ctx, portfolio.ID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    if tag != "" {
+        portfolio.Assets = models.FilterAssetsByTag(portfolio.Assets, tags, tag)
+    }
+    return tags, nil
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// taxSettings holds the assumptions of tax estimates and the tax rules of
+// users without settings of their own
+type taxSettings struct {
+    estimatedRate  float64
+    washSaleWindow time.Duration
+    longTerm       time.Duration // zero for the default period
+    jurisdiction   string
+    // jurisdictions whose tax reports disallow wash sale losses
+    washSaleJurisdictions map[string]bool
+}
+
+// defaults returns the tax settings of a user without settings of their
+// own: the configured jurisdiction, the start of its tax year when known,
+// the configured long-term period and its wash-sale applicability
+func (t taxSettings) defaults(userID uuid.UUID) models.TaxSettings {
+    settings, ok := models.JurisdictionTaxSettings(userID, t.jurisdiction)
+    if !ok {
+        settings.Country = t.jurisdiction
+        settings.TaxYear = models.CalendarTaxYear
+    }
+    longTerm := t.longTerm
+    if longTerm == 0 {
+        longTerm = models.DEFAULT_LONG_TERM_HOLDING_PERIOD
+    }
+    settings.LongTermDays = int(longTerm / (24 * time.Hour))
+    settings.WashSales = t.washSaleJurisdictions[t.jurisdiction]
+    return settings
+}
+
+// washSaleRule returns the wash-sale rule of tax reports under settings, or
+// nil when their jurisdiction has none
+func (t taxSettings) washSaleRule(settings models.TaxSettings) *models.WashSaleRule {
+    if !settings.WashSales || t.washSaleWindow <= 0 {
+        return nil
+    }
+    return &models.WashSaleRule{Window: t.washSaleWindow}
+}
+
+// userTaxSettings returns the tax settings of a user, or the configured
+// defaults when they have none
+func (s *PortfolioService) userTaxSettings(ctx context.Context, userID uuid.UUID) (models.TaxSettings, error) {
+    settings, err := s.repo.GetTaxSettings(ctx, userID)
+    if err != nil {
+        return models.TaxSettings{}, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    if settings == nil {
+        return s.tax.defaults(userID), nil
+    }
+    return *settings, nil
+}
+
+// GetTaxSettings returns the tax settings applied to the tax reports of a
+// user. Settings never stored are the configured defaults and have no
+// update time.
+func (s *PortfolioService) GetTaxSettings(ctx context.Context, userID uuid.UUID) (*models.TaxSettings, error) {
+    if userID == uuid.Nil {
+        return nil, ErrInvalidTaxSettings
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    settings, err := s.userTaxSettings(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    return &settings, nil
+}
+
+// SetTaxSettings validates and stores the tax settings of a user. With
+// jurisdictionDefaults the rules of the settings' country replace the
+// others given, for countries with known defaults.
+func (s *PortfolioService) SetTaxSettings(ctx context.Context, settings *models.TaxSettings, jurisdictionDefaults bool) (*models.TaxSettings, error) {
+    if settings == nil || settings.UserID == uuid.Nil {
+        return nil, ErrInvalidTaxSettings
+    }
+    if jurisdictionDefaults {
+        defaults, ok := models.JurisdictionTaxSettings(settings.UserID, settings.Country)
+        if !ok {
+            return nil, fmt.Errorf("%w: no defaults for country %q", ErrInvalidTaxSettings, settings.Country)
+        }
+        settings = &defaults
+    }
+    if err := settings.Validate(); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidTaxSettings, err)
+    }
+    settings.UpdatedAt = time.Now().UTC()
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    if err := s.repo.SetTaxSettings(ctx, settings); err != nil {
+        s.logger.Error("Failed to set tax settings",
+            zap.Error(err),
+            zap.String("user_id", settings.UserID.String()),
+        )
+        return nil, repositoryError(err)
+    }
+
+    return settings, nil
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// RecordTransaction validates and records a transaction on an asset of a portfolio
+func (s *PortfolioService) RecordTransaction(ctx context.Context, t *models.Transaction) (*models.Transaction, error) {
+    if t == nil || t.PortfolioID == uuid.Nil || t.AssetID == uuid.Nil {
+        return nil, ErrInvalidTransaction
+    }
+    if !t.Amount.IsPositive() || t.Price.IsNegative() || t.Fee.IsNegative() {
+        return nil, fmt.Errorf("%w: amount must be positive and price and fee non-negative", ErrInvalidTransaction)
+    }
+    if err := s.checkWritable(ctx, t.PortfolioID); err != nil {
+        return nil, err
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    portfolio, err := s.repo.GetPortfolio(ctx, t.PortfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    asset, err := portfolio.GetAsset(t.AssetID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidTransaction, err)
+    }
+    if err := models.ValidateTransactionType(t.Type, asset.Type); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidTransaction, err)
+    }
+    if err := models.ValidateAmountPrecision(asset.Type, asset.Symbol, t.Amount); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidTransaction, err)
+    }
+
+    if t.ID == uuid.Nil {
+        t.ID = uuid.New()
+    }
+    if t.Timestamp.IsZero() {
+        t.Timestamp = time.Now().UTC()
+    }
+
+    if s.eventSourced {
+        err = s.appendLedger(ctx, t.PortfolioID, time.Now().UTC(), ledgerChange{eventType: models.LedgerTransactionRecorded, data: t})
+    } else {
+        err = s.repo.CreateTransaction(ctx, t)
+    }
+
+    if err != nil {
+        s.logger.Error("Failed to record transaction",
+            zap.Error(err),
+            zap.String("portfolio_id", t.PortfolioID.String()),
+        )
+        return nil, repositoryError(err)
+    }
+
+    s.emitWebhook(ctx, t.PortfolioID, models.WebhookTransactionRecorded, transactionEventData(t, asset.Symbol))
+
+    // A transfer may be the other leg of one recorded in another portfolio;
+    // failing to link it leaves it to be linked manually
+    if !s.eventSourced && (t.Type == "transfer_in" || t.Type == "transfer_out") {
+        if _, err := s.matchTransfers(ctx, portfolio.UserID, asset.Symbol, t.Timestamp); err != nil {
+            s.logger.Warn("Failed to match transfer",
+                zap.Error(err),
+                zap.String("portfolio_id", t.PortfolioID.String()),
+                zap.String("transaction_id", t.ID.String()),
+            )
+        }
+    }
+
+    return t, nil
+}
+
+// UpdateTransaction replaces the type, amount, price, fee and timestamp of a
+// recorded transaction and recomputes its asset's lots, cost basis and
+// realized P&L from the changed transaction forward
+func (s *PortfolioService) UpdateTransaction(ctx context.Context, t *models.Transaction) (*models.LotRecomputation, error) {
+    if t == nil || t.PortfolioID == uuid.Nil || t.ID == uuid.Nil {
+        return nil, ErrInvalidTransaction
+    }
+    if !t.Amount.IsPositive() || t.Price.IsNegative() || t.Fee.IsNegative() {
+        return nil, fmt.Errorf("%w: amount must be positive and price and fee non-negative", ErrInvalidTransaction)
+    }
+    if t.Timestamp.IsZero() {
+        return nil, fmt.Errorf("%w: timestamp is required", ErrInvalidTransaction)
+    }
+    if err := s.checkAmendable(ctx, t.PortfolioID); err != nil {
+        return nil, err
+    }
+
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+
+    current, err := s.repo.GetTransaction(ctx, t.PortfolioID, t.ID)
+    if err != nil {
+        return nil, transactionError(err)
+    }
+    portfolio, err := s.repo.GetPortfolio(ctx, t.PortfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    asset, err := portfolio.GetAsset(current.AssetID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+    }
+    if err := models.ValidateTransactionType(t.Type, asset.Type); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidTransaction, err)
+    }
+    if err := models.ValidateAmountPrecision(asset.Type, asset.Symbol, t.Amount); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidTransaction, err)
+    }
+    t.AssetID = current.AssetID
+    t.Timestamp = t.Timestamp.UTC()
+
+    recomputation, err := s.repo.AmendTransaction(ctx, t.PortfolioID, t.ID, t)
+    if err != nil {
+        s.logger.Error("Failed to update transaction",
+            zap.Error(err),
+            zap.String("portfolio_id", t.PortfolioID.String()),
+            zap.String("transaction_id", t.ID.String()),
+        )
+        return nil, transactionError(err)
+    }
+
+    s.logger.Info("Transaction updated",
+        zap.String("portfolio_id", t.PortfolioID.String()),
+        zap.String("transaction_id", t.ID.String()),
+        zap.String("asset_symbol", asset.Symbol),
+    )
+    return recomputation, nil
+}
+
+// DeleteTransaction deletes a recorded transaction and recomputes its
+// asset's lots, cost basis and realized P&L without it
+func (s *PortfolioService) DeleteTransaction(ctx context.Context, portfolioID, transactionID uuid.UUID) (*models.LotRecomputation, error) {
+    if portfolioID == uuid.Nil || transactionID == uuid.Nil {
+        return nil, ErrInvalidTransaction
+    }
+    if err := s.checkAmendable(ctx, portfolioID); err != nil {
+        return nil, err
+    }
+
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+
+    recomputation, err := s.repo.AmendTransaction(ctx, portfolioID, transactionID, nil)
+    if err != nil {
+        s.logger.Error("Failed to delete transaction",
+            zap.Error(err),
+            zap.String("portfolio_id", portfolioID.String()),
+            zap.String("transaction_id", transactionID.String()),
+        )
+        return nil, transactionError(err)
+    }
+
+    s.logger.Info("Transaction deleted",
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.String("transaction_id", transactionID.String()),
+    )
+    return recomputation, nil
+}
+
+// checkAmendable fails unless recorded transactions of a portfolio may be
+// changed. Ledger events are immutable, so event-sourced portfolios never
+// allow it.
+func (s *PortfolioService) checkAmendable(ctx context.Context, portfolioID uuid.UUID) error {
+    if s.eventSourced {
+        return fmt.Errorf("%w: changing recorded transactions of event-sourced portfolios", ErrFeatureDisabled)
+    }
+    return s.checkWritable(ctx, portfolioID)
+}
+
+// transactionError maps repository errors of transaction changes to
+// service errors
+func transactionError(err error) error {
+    switch {
+    case errors.Is(err, repository.ErrTransactionNotFound), errors.Is(err, repository.ErrAssetNotFound):
+        return fmt.Errorf("%w: %v", ErrNotFound, err)
+    case errors.Is(err, repository.ErrNegativeBalance):
+        return fmt.Errorf("%w: %w", ErrInvalidTransaction, err)
+    case errors.Is(err, repository.ErrTransferLinked):
+        return fmt.Errorf("%w: %v", ErrTransferLinked, err)
+    }
+    return repositoryError(err)
+}
+
+// transactions returns the portfolio transactions recorded within [start, end),
+// merging rows still in Postgres with history moved to the cold archive
+func (s *PortfolioService) transactions(ctx context.Context, portfolioID uuid.UUID, start, end time.Time) ([]models.Transaction, error) {
+    txs, err := s.repo.GetTransactions(ctx, portfolioID, start, end)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    if s.archive == nil {
+        return txs, nil
+    }
+
+    archived, err := s.archive.Transactions(ctx, portfolioID, start, end)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    if len(archived) == 0 {
+        return txs, nil
+    }
+
+    merged := append(archived, txs...)
+    sort.SliceStable(merged, func(i, j int) bool {
+        return merged[i].Timestamp.Before(merged[j].Timestamp)
+    })
+    return merged, nil
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// LinkTransfer links a transfer_out from one of a user's portfolios to the
+// transfer_in it arrived as in another, for transfers automatic matching
+// missed. The transfer_in is repriced to carry over the transfer_out's cost
+// basis and its asset recomputed.
+func (s *PortfolioService) LinkTransfer(ctx context.Context, userID, outTransactionID, inTransactionID uuid.UUID) (*models.TransferLink, *models.LotRecomputation, error) {
+    if userID == uuid.Nil || outTransactionID == uuid.Nil || inTransactionID == uuid.Nil {
+        return nil, nil, ErrInvalidTransferLink
+    }
+    if s.eventSourced {
+        return nil, nil, fmt.Errorf("%w: linking transfers of event-sourced portfolios", ErrFeatureDisabled)
+    }
+
+    link := &models.TransferLink{
+        ID:               uuid.New(),
+        UserID:           userID,
+        OutTransactionID: outTransactionID,
+        InTransactionID:  inTransactionID,
+        MatchedBy:        models.TransferMatchedManual,
+        CreatedAt:        time.Now().UTC(),
+    }
+
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+
+    recomputation, err := s.repo.LinkTransfer(ctx, link)
+    if err != nil {
+        s.logger.Error("Failed to link transfer",
+            zap.Error(err),
+            zap.String("user_id", userID.String()),
+            zap.String("out_transaction_id", outTransactionID.String()),
+            zap.String("in_transaction_id", inTransactionID.String()),
+        )
+        return nil, nil, transferError(err)
+    }
+
+    s.logger.Info("Transfer linked",
+        zap.String("link_id", link.ID.String()),
+        zap.String("asset_symbol", link.Symbol),
+        zap.String("matched_by", link.MatchedBy),
+    )
+    return link, recomputation, nil
+}
+
+// UnlinkTransfer removes the link of a user's transfer, given either of its
+// transactions, restoring the transfer_in's own price
+func (s *PortfolioService) UnlinkTransfer(ctx context.Context, userID, transactionID uuid.UUID) (*models.TransferLink, *models.LotRecomputation, error) {
+    if userID == uuid.Nil || transactionID == uuid.Nil {
+        return nil, nil, ErrInvalidTransferLink
+    }
+    if s.eventSourced {
+        return nil, nil, fmt.Errorf("%w: linking transfers of event-sourced portfolios", ErrFeatureDisabled)
+    }
+
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+
+    link, recomputation, err := s.repo.UnlinkTransfer(ctx, userID, transactionID)
+    if err != nil {
+        s.logger.Error("Failed to unlink transfer",
+            zap.Error(err),
+            zap.String("user_id", userID.String()),
+            zap.String("transaction_id", transactionID.String()),
+        )
+        return nil, nil, transferError(err)
+    }
+
+    s.logger.Info("Transfer unlinked",
+        zap.String("link_id", link.ID.String()),
+        zap.String("asset_symbol", link.Symbol),
+    )
+    return link, recomputation, nil
+}
+
+// matchTransfers links the unlinked transfers of symbol across a user's
+// portfolios that MatchTransfers pairs around a transfer recorded at at.
+// It returns the number of links made; a pair linked concurrently is
+// skipped.
+func (s *PortfolioService) matchTransfers(ctx context.Context, userID uuid.UUID, symbol string, at time.Time) (int, error) {
+    reach := models.TRANSFER_MATCH_WINDOW + models.TRANSFER_CLOCK_SKEW
+    candidates, err := s.repo.ListTransferCandidates(ctx, userID, strings.ToUpper(symbol), at.Add(-reach), at.Add(reach))
+    if err != nil {
+        return 0, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    linked := 0
+    for _, link := range models.MatchTransfers(userID, candidates, time.Now().UTC()) {
+        link := link
+        _, err := s.repo.LinkTransfer(ctx, &link)
+        if errors.Is(err, repository.ErrTransferLinked) {
+            continue
+        }
+        if err != nil {
+            return linked, transferError(err)
+        }
+        linked++
+
+        s.logger.Info("Transfer linked",
+            zap.String("link_id", link.ID.String()),
+            zap.String("asset_symbol", link.Symbol),
+            zap.String("matched_by", link.MatchedBy),
+        )
+    }
+    return linked, nil
+}
+
+// transferError maps repository errors of transfer links to service errors
+func transferError(err error) error {
+    switch {
+    case errors.Is(err, models.ErrInvalidTransferLink):
+        return fmt.Errorf("%w: %w", ErrInvalidTransferLink, err)
+    case errors.Is(err, repository.ErrTransferLinkNotFound):
+        return fmt.Errorf("%w: %v", ErrNotFound, err)
+    }
+    return transactionError(err)
+}
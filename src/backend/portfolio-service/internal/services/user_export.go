@@ -0,0 +1,149 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/export"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/pagination"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// userExportPageSize bounds the portfolios and alert events read per query
+// while assembling a user data export
+const userExportPageSize = 500
+
+// ExportUserData writes everything stored about a user, archived portfolios
+// and archived transaction history included, to w as a JSON document. The
+// export is assembled before anything is written, so w sees no output when
+// it fails.
+func (s *PortfolioService) ExportUserData(ctx context.Context, userID uuid.UUID, w io.Writer) error {
+    if userID == uuid.Nil || w == nil {
+        return fmt.Errorf("%w: user ID is required", ErrInvalidPortfolio)
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    data := &models.UserDataExport{
+        Version:     models.USER_DATA_EXPORT_VERSION,
+        UserID:      userID,
+        GeneratedAt: time.Now().UTC(),
+    }
+
+    var portfolioIDs []uuid.UUID
+    var after pagination.Position
+    for {
+        page, err := s.repo.ListPortfolios(ctx, userID, after, userExportPageSize, repository.PortfolioFilter{IncludeArchived: true}, repository.PortfolioOrder{})
+        if err != nil {
+            return repositoryError(err)
+        }
+        for _, portfolio := range page {
+            entry, err := s.exportPortfolioData(ctx, portfolio, data.GeneratedAt)
+            if err != nil {
+                return err
+            }
+            data.Portfolios = append(data.Portfolios, *entry)
+            portfolioIDs = append(portfolioIDs, portfolio.ID)
+        }
+        if len(page) < userExportPageSize {
+            break
+        }
+        after = repository.PortfolioOrder{}.Position(page[len(page)-1])
+    }
+
+    var err error
+    if data.Webhooks, err = s.repo.ListWebhooks(ctx, userID, uuid.Nil); err != nil {
+        return repositoryError(err)
+    }
+    if data.ReportSchedules, err = s.repo.ListReportSchedules(ctx, userID); err != nil {
+        return repositoryError(err)
+    }
+    if data.TrackedWallets, err = s.repo.ListTrackedWallets(ctx, userID); err != nil {
+        return repositoryError(err)
+    }
+    if data.AuditTrail, err = s.repo.ListUserAuditEntries(ctx, userID, portfolioIDs); err != nil {
+        return repositoryError(err)
+    }
+
+    if err := export.WriteUserData(w, data); err != nil {
+        s.logger.Error("Failed to export user data",
+            zap.Error(err),
+            zap.String("user_id", userID.String()),
+        )
+        return fmt.Errorf("failed to export user data: %w", err)
+    }
+
+    s.logger.Info("User data exported",
+        zap.String("user_id", userID.String()),
+        zap.Int("portfolios", len(data.Portfolios)),
+        zap.Int("audit_entries", len(data.AuditTrail)),
+    )
+
+    return nil
+}
+
+// exportPortfolioData loads a listed portfolio's holdings, full transaction
+// history, settings and alerts
+func (s *PortfolioService) exportPortfolioData(ctx context.Context, portfolio *models.Portfolio, now time.Time) (*models.PortfolioDataExport, error) {
+    var err error
+    entry := &models.PortfolioDataExport{Portfolio: portfolio}
+
+    if portfolio.Assets, err = s.repo.GetPortfolioAssets(ctx, portfolio.ID); err != nil {
+        return nil, repositoryError(err)
+    }
+    if entry.Transactions, err = s.transactions(ctx, portfolio.ID, time.Time{}, now); err != nil {
+        return nil, err
+    }
+    if entry.AssetTags, err = s.repo.GetAssetTags(ctx, portfolio.ID); err != nil {
+        return nil, repositoryError(err)
+    }
+    // Dust settings are only readable on active portfolios
+    entry.Dust, err = s.repo.GetDustSettings(ctx, portfolio.ID)
+    if err != nil && !errors.Is(err, repository.ErrPortfolioNotFound) {
+        return nil, repositoryError(err)
+    }
+    if entry.AllocationTargets, err = s.repo.GetAllocationTargets(ctx, portfolio.ID); err != nil {
+        return nil, repositoryError(err)
+    }
+    if entry.DCAPlans, err = s.repo.ListDCAPlans(ctx, portfolio.ID); err != nil {
+        return nil, repositoryError(err)
+    }
+    if entry.AlertRules, err = s.repo.GetAlertRules(ctx, portfolio.ID); err != nil {
+        return nil, repositoryError(err)
+    }
+    before := pagination.Position{At: now}
+    for {
+        events, err := s.repo.ListAlertEvents(ctx, portfolio.ID, before, userExportPageSize)
+        if err != nil {
+            return nil, repositoryError(err)
+        }
+        entry.AlertEvents = append(entry.AlertEvents, events...)
+        if len(events) < userExportPageSize {
+            break
+        }
+        last := events[len(events)-1]
+        before = pagination.Position{At: last.TriggeredAt, ID: last.ID}
+    }
+    if entry.Webhooks, err = s.repo.ListWebhooks(ctx, portfolio.UserID, portfolio.ID); err != nil {
+        return nil, repositoryError(err)
+    }
+    if entry.ExchangeAccounts, err = s.repo.ListExchangeAccounts(ctx, portfolio.ID); err != nil {
+        return nil, repositoryError(err)
+    }
+    if entry.StakingPositions, err = s.repo.ListStakingPositions(ctx, portfolio.ID); err != nil {
+        return nil, repositoryError(err)
+    }
+    if entry.Shares, err = s.repo.ListPortfolioShares(ctx, portfolio.ID); err != nil {
+        return nil, repositoryError(err)
+    }
+
+    return entry, nil
+}
@@ -0,0 +1,363 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/chains"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// walletSettings holds the wallet tracking options of the service
+type walletSettings struct {
+    registry     *chains.Registry
+    peggedAssets []string
+    syncInterval time.Duration
+}
+
+// walletSyncKey marks the context of a wallet sync, the only writer allowed
+// on tracked wallet portfolios
+type walletSyncKey struct{}
+
+// TrackWallet starts tracking an on-chain wallet address of a user. The
+// wallet is added to portfolioID, which must be the portfolio of another
+// tracked wallet of the user, so wallets across chains form one portfolio.
+// Without portfolioID a dedicated read-only portfolio named name, or after
+// the address when empty, is created for the wallet. Its history is synced
+// right away by the background sync.
+func (s *PortfolioService) TrackWallet(ctx context.Context, userID, portfolioID uuid.UUID, chain, address, name string) (*models.TrackedWallet, error) {
+    if s.wallets == nil {
+        return nil, fmt.Errorf("%w: wallet tracking", ErrFeatureDisabled)
+    }
+    if userID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    adapter, err := s.wallets.registry.Adapter(chain)
+    if err != nil {
+        return nil, err
+    }
+    address, err = adapter.NormalizeAddress(address)
+    if err != nil {
+        return nil, err
+    }
+
+    wallets, err := s.repo.ListTrackedWallets(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    shared := false
+    for _, w := range wallets {
+        if w.Chain == chain && w.Address == address {
+            return nil, ErrWalletAlreadyTracked
+        }
+        if portfolioID != uuid.Nil && w.PortfolioID == portfolioID {
+            shared = true
+        }
+    }
+    // Only wallet portfolios are read-only, so wallets cannot join a
+    // portfolio with manual entries
+    if portfolioID != uuid.Nil && !shared {
+        return nil, ErrInvalidPortfolio
+    }
+
+    if portfolioID == uuid.Nil {
+        if name == "" {
+            name = models.WalletPortfolioName(chain, address)
+        }
+        portfolio, err := s.CreatePortfolio(ctx, &models.Portfolio{
+            UserID:      userID,
+            Name:        name,
+            Description: fmt.Sprintf("Tracked %s wallet %s", chain, address),
+        })
+        if err != nil {
+            return nil, err
+        }
+        portfolioID = portfolio.ID
+    }
+
+    now := time.Now().UTC()
+    wallet := &models.TrackedWallet{
+        ID:          uuid.New(),
+        PortfolioID: portfolioID,
+        UserID:      userID,
+        Chain:       chain,
+        Address:     address,
+        NextSyncAt:  now,
+        CreatedAt:   now,
+    }
+    err = s.repo.CreateTrackedWallet(ctx, wallet)
+    if errors.Is(err, repository.ErrTrackedWalletExists) {
+        // A concurrent request tracked the address first; an empty
+        // portfolio created here is left to the user
+        s.logger.Warn("Wallet tracked concurrently",
+            zap.String("user_id", userID.String()),
+            zap.String("portfolio_id", portfolioID.String()),
+            zap.String("chain", chain),
+        )
+        return nil, ErrWalletAlreadyTracked
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    s.logger.Info("Wallet tracked",
+        zap.String("user_id", userID.String()),
+        zap.String("wallet_id", wallet.ID.String()),
+        zap.String("portfolio_id", portfolioID.String()),
+        zap.String("chain", chain),
+    )
+
+    return wallet, nil
+}
+
+// ListTrackedWallets returns the wallets tracked by a user with their sync
+// status
+func (s *PortfolioService) ListTrackedWallets(ctx context.Context, userID uuid.UUID) ([]models.TrackedWallet, error) {
+    if s.wallets == nil {
+        return nil, fmt.Errorf("%w: wallet tracking", ErrFeatureDisabled)
+    }
+    if userID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    wallets, err := s.repo.ListTrackedWallets(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    return wallets, nil
+}
+
+// UntrackWallet stops syncing a tracked wallet of a user. Its portfolio is
+// kept with the synced history and becomes writable once no other tracked
+// wallet shares it.
+func (s *PortfolioService) UntrackWallet(ctx context.Context, userID, walletID uuid.UUID) error {
+    if s.wallets == nil {
+        return fmt.Errorf("%w: wallet tracking", ErrFeatureDisabled)
+    }
+    if userID == uuid.Nil || walletID == uuid.Nil {
+        return ErrInvalidPortfolio
+    }
+
+    err := s.repo.DeleteTrackedWallet(ctx, userID, walletID)
+    if errors.Is(err, repository.ErrTrackedWalletNotFound) {
+        return fmt.Errorf("%w: %v", ErrNotFound, err)
+    }
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    s.logger.Info("Wallet untracked",
+        zap.String("user_id", userID.String()),
+        zap.String("wallet_id", walletID.String()),
+    )
+
+    return nil
+}
+
+// SyncWallet syncs a tracked wallet of a user from its checkpoint without
+// waiting for the background sync
+func (s *PortfolioService) SyncWallet(ctx context.Context, userID, walletID uuid.UUID) (*models.ExchangeImport, error) {
+    if s.wallets == nil {
+        return nil, fmt.Errorf("%w: wallet tracking", ErrFeatureDisabled)
+    }
+    if userID == uuid.Nil || walletID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    wallet, err := s.repo.GetTrackedWallet(ctx, userID, walletID)
+    if errors.Is(err, repository.ErrTrackedWalletNotFound) {
+        return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    return s.SyncTrackedWallet(ctx, wallet)
+}
+
+// SyncTrackedWallet imports the transfers of a tracked wallet from its
+// checkpoint into its portfolio. On success the new checkpoint is saved and
+// the next sync is scheduled after the sync interval; on failure the error is
+// recorded and the checkpoint kept, so the next sync retries the same range.
+func (s *PortfolioService) SyncTrackedWallet(ctx context.Context, wallet *models.TrackedWallet) (*models.ExchangeImport, error) {
+    if s.wallets == nil {
+        return nil, fmt.Errorf("%w: wallet tracking", ErrFeatureDisabled)
+    }
+    if wallet == nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    result, err := s.importWallet(context.WithValue(ctx, walletSyncKey{}, true), wallet)
+    now := time.Now().UTC()
+    next := now.Add(s.wallets.syncInterval)
+    if err != nil {
+        message := err.Error()
+        if len(message) > maxSyncErrorLength {
+            message = message[:maxSyncErrorLength]
+        }
+        if recordErr := s.repo.RecordWalletSyncError(ctx, wallet.ID, message, next); recordErr != nil {
+            s.logger.Error("Failed to record wallet sync error",
+                zap.Error(recordErr),
+                zap.String("wallet_id", wallet.ID.String()),
+            )
+        }
+        s.recordSync(ctx, wallet.PortfolioID, models.SyncSourceWallet, wallet.Chain, nil, message, now)
+        return nil, err
+    }
+
+    if err := s.repo.SaveWalletCheckpoint(ctx, wallet.ID, result.Cursor, now, next); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    s.recordSync(ctx, wallet.PortfolioID, models.SyncSourceWallet, wallet.Chain, result, "", now)
+    wallet.Cursor = result.Cursor
+    wallet.LastSyncedAt = &now
+    wallet.LastError = ""
+    wallet.NextSyncAt = next
+
+    return result, nil
+}
+
+// importWallet imports the transfers of a wallet after its cursor. Transfers
+// are valued at the daily close of their day, pegged assets at 1 USD, so
+// received assets carry their cost basis at the time they arrived.
+func (s *PortfolioService) importWallet(ctx context.Context, wallet *models.TrackedWallet) (*models.ExchangeImport, error) {
+    adapter, err := s.wallets.registry.Adapter(wallet.Chain)
+    if err != nil {
+        return nil, err
+    }
+
+    // Chain calls are slow and paginated, so they run before any lock is held
+    activity, next, err := adapter.FetchTransfers(ctx, wallet.Address, wallet.Cursor)
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch %s transfers: %w", wallet.Chain, err)
+    }
+    balances, err := adapter.FetchBalances(ctx, wallet.Address)
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch %s balances: %w", wallet.Chain, err)
+    }
+
+    price, err := s.walletPrices(ctx, activity, balances)
+    if err != nil {
+        return nil, err
+    }
+    mapper := models.NewExchangeMapper(nil, price)
+    legs, err := mapper.MapActivity(activity)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidTransaction, err)
+    }
+
+    result := &models.ExchangeImport{
+        PortfolioID:    wallet.PortfolioID,
+        Exchange:       wallet.Chain,
+        Entries:        len(activity.Entries),
+        SkippedSymbols: []string{},
+        Cursor:         next,
+    }
+
+    // Wallets sharing a portfolio may both take part in a transaction, so
+    // transaction IDs are derived per wallet
+    source := wallet.Chain + "/" + wallet.Address
+    if err := s.recordLegs(ctx, wallet.PortfolioID, source, mapper, balances, legs, []string{"transfer_in"}, nil, result); err != nil {
+        return nil, err
+    }
+
+    s.logger.Info("Wallet synced",
+        zap.String("wallet_id", wallet.ID.String()),
+        zap.String("portfolio_id", wallet.PortfolioID.String()),
+        zap.String("chain", wallet.Chain),
+        zap.Int("entries", result.Entries),
+        zap.Int("transactions", result.TransactionsRecorded),
+        zap.Int("duplicates", result.Duplicates),
+        zap.Strings("skipped_symbols", result.SkippedSymbols),
+    )
+
+    return result, nil
+}
+
+// walletPrices returns a USD price function for the assets of a wallet
+// import. Historical prices are the stored daily closes over the range of the
+// activity, falling back to the current price for assets without history.
+func (s *PortfolioService) walletPrices(ctx context.Context, activity models.ExchangeActivity, balances []models.ExchangeBalance) (models.USDPriceFunc, error) {
+    pegged := make(map[string]bool, len(s.wallets.peggedAssets))
+    for _, asset := range s.wallets.peggedAssets {
+        pegged[strings.ToUpper(asset)] = true
+    }
+
+    seen := make(map[string]bool)
+    var symbols []string
+    addSymbol := func(asset string) {
+        symbol := strings.ToUpper(asset)
+        if !seen[symbol] && !pegged[symbol] {
+            seen[symbol] = true
+            symbols = append(symbols, symbol)
+        }
+    }
+    var start, end time.Time
+    for _, e := range activity.Entries {
+        addSymbol(e.Asset)
+        if start.IsZero() || e.ExecutedAt.Before(start) {
+            start = e.ExecutedAt
+        }
+        if e.ExecutedAt.After(end) {
+            end = e.ExecutedAt
+        }
+    }
+    for _, b := range balances {
+        addSymbol(b.Asset)
+    }
+
+    closes := make(map[string][]models.PricePoint)
+    if len(activity.Entries) > 0 && len(symbols) > 0 {
+        // Closes are daily, so the range is widened by a day on each side
+        var err error
+        closes, err = s.repo.GetDailyCloses(ctx, symbols, start.AddDate(0, 0, -1), end.AddDate(0, 0, 1))
+        if err != nil {
+            return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+        }
+    }
+    current, err := s.currentPrices(ctx, symbols)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    return func(asset string, at time.Time) (decimal.Decimal, error) {
+        symbol := strings.ToUpper(asset)
+        if pegged[symbol] {
+            return decimal.NewFromInt(1), nil
+        }
+        if price, ok := models.PriceAt(closes[symbol], at); ok {
+            return price, nil
+        }
+        if price, ok := current[symbol]; ok {
+            return price, nil
+        }
+        return decimal.Zero, fmt.Errorf("%w: %s", ErrInsufficientData, symbol)
+    }, nil
+}
+
+// checkWritable returns ErrReadOnlyPortfolio for the portfolio of a tracked
+// wallet outside its sync. Portfolios are writable while wallet tracking is
+// disabled.
+func (s *PortfolioService) checkWritable(ctx context.Context, portfolioID uuid.UUID) error {
+    if s.wallets == nil || ctx.Value(walletSyncKey{}) != nil {
+        return nil
+    }
+
+    tracked, err := s.repo.IsTrackedWallet(ctx, portfolioID)
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    if tracked {
+        return ErrReadOnlyPortfolio
+    }
+    return nil
+}
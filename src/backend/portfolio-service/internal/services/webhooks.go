@@ -0,0 +1,261 @@
+package services
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "go.uber.org/zap"                 // v1.24.0
+
+    "bookman/portfolio-service/internal/envelope"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/pagination"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// webhookSettings holds the webhook options of the service
+type webhookSettings struct {
+    enabled       bool
+    allowInsecure bool
+    encryptor     *envelope.Encryptor
+}
+
+// webhookPayload is the JSON body delivered to webhook endpoints
+type webhookPayload struct {
+    ID          uuid.UUID           `json:"id"`
+    Event       models.WebhookEvent `json:"event"`
+    PortfolioID uuid.UUID           `json:"portfolio_id"`
+    OccurredAt  time.Time           `json:"occurred_at"`
+    Data        interface{}         `json:"data"`
+}
+
+// RegisterWebhook validates and stores a webhook endpoint with a generated
+// signing secret, sealed when encryption is configured. The returned endpoint
+// is the only place the secret is shown.
+func (s *PortfolioService) RegisterWebhook(ctx context.Context, endpoint *models.WebhookEndpoint) (*models.WebhookEndpoint, error) {
+    if !s.webhooks.enabled {
+        return nil, fmt.Errorf("%w: webhooks", ErrFeatureDisabled)
+    }
+    if endpoint == nil || endpoint.UserID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+    if err := endpoint.Validate(s.webhooks.allowInsecure); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrInvalidWebhook, err)
+    }
+
+    secret := make([]byte, 32)
+    if _, err := rand.Read(secret); err != nil {
+        return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+    }
+
+    endpoint.ID = uuid.New()
+    endpoint.Secret = "whsec_" + hex.EncodeToString(secret)
+    endpoint.Enabled = true
+    endpoint.CreatedAt = time.Now().UTC()
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    if endpoint.PortfolioID != uuid.Nil {
+        if err := s.checkPortfolioOwner(ctx, endpoint.UserID, endpoint.PortfolioID); err != nil {
+            return nil, err
+        }
+    }
+
+    stored := *endpoint
+    if s.webhooks.encryptor != nil {
+        sealed, err := s.webhooks.encryptor.SealString(ctx, endpoint.Secret, models.WebhookSecretScope(endpoint.ID))
+        if err != nil {
+            return nil, fmt.Errorf("failed to seal webhook secret: %w", err)
+        }
+        stored.Secret = sealed
+    }
+
+    err := s.repo.CreateWebhook(ctx, &stored)
+    if errors.Is(err, repository.ErrWebhookLimit) {
+        return nil, fmt.Errorf("%w: at most %d webhooks per portfolio", ErrLimitExceeded, models.MAX_WEBHOOKS_PER_PORTFOLIO)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    s.logger.Info("Webhook registered",
+        zap.String("webhook_id", endpoint.ID.String()),
+        zap.String("user_id", endpoint.UserID.String()),
+        zap.String("portfolio_id", endpoint.PortfolioID.String()),
+    )
+
+    return endpoint, nil
+}
+
+// ListWebhooks returns the webhook endpoints of a portfolio, or the user-wide
+// endpoints when portfolioID is uuid.Nil. Secrets are not returned.
+func (s *PortfolioService) ListWebhooks(ctx context.Context, userID, portfolioID uuid.UUID) ([]models.WebhookEndpoint, error) {
+    if userID == uuid.Nil {
+        return nil, ErrInvalidPortfolio
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    endpoints, err := s.repo.ListWebhooks(ctx, userID, portfolioID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    return endpoints, nil
+}
+
+// DeleteWebhook removes a webhook endpoint of a user
+func (s *PortfolioService) DeleteWebhook(ctx context.Context, userID, webhookID uuid.UUID) error {
+    if userID == uuid.Nil || webhookID == uuid.Nil {
+        return ErrInvalidPortfolio
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    err := s.repo.DeleteWebhook(ctx, userID, webhookID)
+    if errors.Is(err, repository.ErrWebhookNotFound) {
+        return fmt.Errorf("%w: %v", ErrNotFound, err)
+    }
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    return nil
+}
+
+// ListWebhookDeliveries returns a page of the deliveries of a user's webhook
+// created before the given time, newest first, and the token of the next page
+// if any. A zero time lists the latest.
+func (s *PortfolioService) ListWebhookDeliveries(ctx context.Context, userID, webhookID uuid.UUID, before time.Time, pageSize int, pageToken string) ([]models.WebhookDelivery, string, error) {
+    if userID == uuid.Nil || webhookID == uuid.Nil {
+        return nil, "", ErrInvalidPortfolio
+    }
+    pageSize = pagination.Size(pageSize, pagination.MaxPageSize)
+
+    filters := pagination.Fingerprint(userID.String(), webhookID.String(), before.UTC().Format(time.RFC3339Nano))
+    pos, err := s.decodePageToken(webhookDeliveryListScope, pageToken, filters)
+    if err != nil {
+        return nil, "", err
+    }
+    if pos.IsZero() {
+        if before.IsZero() {
+            before = time.Now().UTC()
+        }
+        pos = pagination.Position{At: before}
+    }
+
+    s.mutex.RLock()
+    defer s.mutex.RUnlock()
+
+    _, err = s.repo.GetWebhook(ctx, userID, webhookID)
+    if errors.Is(err, repository.ErrWebhookNotFound) {
+        return nil, "", fmt.Errorf("%w: %v", ErrNotFound, err)
+    }
+    if err != nil {
+        return nil, "", fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    deliveries, err := s.repo.ListWebhookDeliveries(ctx, webhookID, pos, pageSize+1)
+    if err != nil {
+        return nil, "", fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+
+    var nextToken string
+    if len(deliveries) > pageSize {
+        deliveries = deliveries[:pageSize]
+        last := deliveries[pageSize-1]
+        nextToken, err = s.encodePageToken(webhookDeliveryListScope, pagination.Position{At: last.CreatedAt, ID: last.ID}, filters)
+        if err != nil {
+            return nil, "", err
+        }
+    }
+
+    return deliveries, nextToken, nil
+}
+
+// checkPortfolioOwner verifies that the portfolio exists and belongs to the user
+func (s *PortfolioService) checkPortfolioOwner(ctx context.Context, userID, portfolioID uuid.UUID) error {
+    portfolio, err := s.repo.GetPortfolio(ctx, portfolioID)
+    if errors.Is(err, repository.ErrPortfolioNotFound) {
+        return fmt.Errorf("%w: portfolio %s", ErrNotFound, portfolioID)
+    }
+    if err != nil {
+        return fmt.Errorf("%w: %w", ErrRepositoryOperation, err)
+    }
+    if portfolio.UserID != userID {
+        return fmt.Errorf("%w: portfolio %s", ErrNotFound, portfolioID)
+    }
+    return nil
+}
+
+// emitWebhook queues a mutation event for the subscribed webhook endpoints of
+// the portfolio and its owner. Failures are logged and do not fail the
+// mutation, which has already been committed.
+func (s *PortfolioService) emitWebhook(ctx context.Context, portfolioID uuid.UUID, event models.WebhookEvent, data interface{}) {
+    if !s.webhooks.enabled {
+        return
+    }
+
+    now := time.Now().UTC()
+    payload, err := json.Marshal(webhookPayload{
+        ID:          uuid.New(),
+        Event:       event,
+        PortfolioID: portfolioID,
+        OccurredAt:  now,
+        Data:        data,
+    })
+    if err == nil {
+        _, err = s.repo.EnqueueWebhookDeliveries(ctx, portfolioID, event, payload, now)
+    }
+    if err != nil {
+        s.logger.Warn("Failed to queue webhook event",
+            zap.Error(err),
+            zap.String("portfolio_id", portfolioID.String()),
+            zap.String("event", string(event)),
+        )
+    }
+}
+
+// portfolioEventData describes a portfolio in webhook events. Names and
+// descriptions are user text and are not sent to external endpoints.
+func portfolioEventData(p *models.Portfolio) map[string]interface{} {
+    return map[string]interface{}{
+        "portfolio_id": p.ID,
+        "asset_count":  len(p.Assets),
+        "updated_at":   p.LastUpdated,
+    }
+}
+
+// assetEventData describes an asset in webhook events
+func assetEventData(a *models.Asset) map[string]interface{} {
+    return map[string]interface{}{
+        "asset_id":   a.ID,
+        "type":       a.Type,
+        "symbol":     a.Symbol,
+        "amount":     a.Amount,
+        "cost_basis": a.CostBasis,
+    }
+}
+
+// transactionEventData describes a transaction on the asset with symbol in
+// webhook events
+func transactionEventData(t *models.Transaction, symbol string) map[string]interface{} {
+    return map[string]interface{}{
+        "transaction_id": t.ID,
+        "asset_id":       t.AssetID,
+        "symbol":         symbol,
+        "type":           t.Type,
+        "amount":         t.Amount,
+        "price":          t.Price,
+        "fee":            t.Fee,
+        "timestamp":      t.Timestamp,
+    }
+}
@@ -0,0 +1,126 @@
+// Package sharing implements read-only portfolio share links. A share token
+// is a random bearer secret sent as request metadata; only its hash is
+// stored. The interceptor resolves tokens to their share and confines them to
+// the methods serving shared portfolios.
+package sharing
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc"          // v1.50.0
+	"google.golang.org/grpc/codes"    // v1.50.0
+	"google.golang.org/grpc/metadata" // v1.50.0
+	"google.golang.org/grpc/status"   // v1.50.0
+
+	"bookman/portfolio-service/internal/models"
+)
+
+// MetadataKey is the gRPC metadata key carrying share tokens
+const MetadataKey = "x-share-token"
+
+// tokenPrefix versions the token format
+const tokenPrefix = "sh1."
+
+// tokenBytes is the number of random bytes in a token
+const tokenBytes = 32
+
+// ErrInvalidToken is returned for malformed share tokens
+var ErrInvalidToken = errors.New("invalid share token")
+
+type shareKey struct{}
+
+// Resolver looks up the active share a token grants. It returns a nil share
+// when the token grants none, and an error only when the lookup failed.
+type Resolver interface {
+	ResolveShare(ctx context.Context, token string) (*models.PortfolioShare, error)
+}
+
+// NewToken returns a new random share token and its hash
+func NewToken() (string, []byte, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate share token: %w", err)
+	}
+	token := tokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	hash, _ := HashToken(token)
+	return token, hash, nil
+}
+
+// HashToken returns the hash a token is stored and looked up by
+func HashToken(token string) ([]byte, error) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+	buf, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token, tokenPrefix))
+	if err != nil || len(buf) != tokenBytes {
+		return nil, ErrInvalidToken
+	}
+	sum := sha256.Sum256([]byte(token))
+	return sum[:], nil
+}
+
+// WithShare returns a context authorized by share
+func WithShare(ctx context.Context, share *models.PortfolioShare) context.Context {
+	return context.WithValue(ctx, shareKey{}, share)
+}
+
+// FromContext returns the share that authorized the request in ctx, if any
+func FromContext(ctx context.Context) (*models.PortfolioShare, bool) {
+	share, ok := ctx.Value(shareKey{}).(*models.PortfolioShare)
+	return share, ok && share != nil
+}
+
+// UnaryServerInterceptor resolves share tokens from request metadata. Calls
+// bearing a token may only reach the given full method names, and are
+// rejected when the token does not grant an active share.
+func UnaryServerInterceptor(resolver Resolver, methods ...string) grpc.UnaryServerInterceptor {
+	allowed := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		allowed[method] = true
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return handler(ctx, req)
+		}
+		values := md.Get(MetadataKey)
+		if len(values) == 0 || values[0] == "" {
+			return handler(ctx, req)
+		}
+
+		if !allowed[info.FullMethod] {
+			return nil, status.Error(codes.PermissionDenied, "share tokens only grant access to shared portfolios")
+		}
+
+		share, err := resolver.ResolveShare(ctx, values[0])
+		if err != nil {
+			return nil, status.Error(codes.Internal, "failed to verify share token")
+		}
+		if share == nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired share token")
+		}
+
+		return handler(WithShare(ctx, share), req)
+	}
+}
+
+// StreamServerInterceptor rejects streaming calls bearing share tokens, as no
+// streaming method serves shared portfolios
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if md, ok := metadata.FromIncomingContext(ss.Context()); ok {
+			if values := md.Get(MetadataKey); len(values) > 0 && values[0] != "" {
+				return status.Error(codes.PermissionDenied, "share tokens only grant access to shared portfolios")
+			}
+		}
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,428 @@
+// Package slo measures gRPC calls against the configured service level
+// objectives. Every call is an availability event, good unless it failed with
+// a server error, and every good unary call is a latency event, good when it
+// completed within the objective's threshold. Event counts are exported per
+// method, and error budget burn rates are recorded over several windows so
+// alerts can page on fast burns and ticket on slow ones.
+package slo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"google.golang.org/grpc"                         // v1.50.0
+	"google.golang.org/grpc/codes"                   // v1.50.0
+	"google.golang.org/grpc/status"                  // v1.50.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/metrics"
+)
+
+// Objective is the kind of service level objective an event counts towards
+type Objective string
+
+const (
+	// Availability counts calls that did not fail with a server error
+	Availability Objective = "availability"
+	// Latency counts calls that completed within the latency threshold
+	Latency Objective = "latency"
+)
+
+var (
+	events = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "portfolio_slo_events_total",
+			Help: "Total number of SLO events by objective, method and outcome",
+		},
+		[]string{"slo", "objective", "method", "outcome"},
+	)
+
+	targets = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_slo_target_ratio",
+			Help: "Target ratio of good events of each objective",
+		},
+		[]string{"slo", "objective"},
+	)
+
+	successRatios = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_slo_success_ratio",
+			Help: "Ratio of good events of each method over a window",
+		},
+		[]string{"slo", "objective", "method", "window"},
+	)
+
+	burnRates = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_slo_burn_rate",
+			Help: "Rate the error budget is spent at over a window, where 1 spends it exactly over the compliance period",
+		},
+		[]string{"slo", "objective", "window"},
+	)
+
+	budgetRemaining = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_slo_error_budget_remaining_ratio",
+			Help: "Ratio of the error budget left over the compliance period",
+		},
+		[]string{"slo", "objective"},
+	)
+)
+
+func init() {
+	metrics.MustRegister(events, targets, successRatios, burnRates, budgetRemaining)
+}
+
+// BurnRate is the error budget burn of an objective over a window
+type BurnRate struct {
+	SLO       string
+	Objective Objective
+	Window    time.Duration
+	// Events is the number of events in the window
+	Events uint64
+	// Ratio is the ratio of good events, 1 when there were none
+	Ratio float64
+	// Rate is the ratio of bad events relative to the error budget
+	Rate float64
+}
+
+// objective is a configured service level objective
+type objective struct {
+	cfg      config.ServiceObjective
+	methods  map[string]bool
+	catchAll bool
+}
+
+// target returns the target ratio of good events of a kind
+func (o *objective) target(kind Objective) float64 {
+	if kind == Latency {
+		return o.cfg.LatencyTarget
+	}
+	return o.cfg.Availability
+}
+
+// measuresLatency reports whether the objective has a latency target
+func (o *objective) measuresLatency() bool {
+	return o.cfg.LatencyThreshold > 0
+}
+
+// bucket counts the events of one evaluation interval
+type bucket struct {
+	slot  int64
+	good  uint64
+	total uint64
+}
+
+// series counts the events of a method over the longest window, one bucket
+// per evaluation interval
+type series struct {
+	buckets []bucket
+}
+
+func newSeries(slots int) *series {
+	return &series{buckets: make([]bucket, slots)}
+}
+
+// add counts an event in a slot
+func (s *series) add(slot int64, good bool) {
+	b := &s.buckets[slot%int64(len(s.buckets))]
+	if b.slot != slot {
+		*b = bucket{slot: slot}
+	}
+	b.total++
+	if good {
+		b.good++
+	}
+}
+
+// sum returns the event counts of the slots after from, up to and including to
+func (s *series) sum(from, to int64) (good, total uint64) {
+	for i := range s.buckets {
+		b := &s.buckets[i]
+		if b.slot > from && b.slot <= to {
+			good += b.good
+			total += b.total
+		}
+	}
+	return good, total
+}
+
+// method holds the events of a method against its objective
+type method struct {
+	objective    *objective
+	availability *series
+	latency      *series
+}
+
+// Recorder measures calls against the configured objectives and records their
+// error budget burn rates
+type Recorder struct {
+	objectives []*objective
+	windows    []time.Duration
+	interval   time.Duration
+	slots      int
+
+	mutex   sync.Mutex
+	methods map[string]*method // nil for methods without an objective
+}
+
+// NewRecorder creates a recorder of the configured objectives
+func NewRecorder(cfg config.SLOConfig) (*Recorder, error) {
+	if len(cfg.Objectives) == 0 {
+		return nil, errors.New("at least one objective is required")
+	}
+	if cfg.EvaluationInterval <= 0 || len(cfg.BurnRateWindows) == 0 {
+		return nil, errors.New("an evaluation interval and burn rate windows are required")
+	}
+
+	r := &Recorder{
+		windows:  append([]time.Duration(nil), cfg.BurnRateWindows...),
+		interval: cfg.EvaluationInterval,
+		methods:  make(map[string]*method),
+	}
+	for _, window := range r.windows {
+		if window < r.interval {
+			return nil, fmt.Errorf("burn rate window %s is shorter than the evaluation interval", window)
+		}
+		if slots := int((window + r.interval - 1) / r.interval); slots > r.slots {
+			r.slots = slots
+		}
+	}
+
+	for _, oc := range cfg.Objectives {
+		o := &objective{cfg: oc, methods: make(map[string]bool, len(oc.Methods)), catchAll: len(oc.Methods) == 0}
+		for _, name := range oc.Methods {
+			o.methods[name] = true
+		}
+		r.objectives = append(r.objectives, o)
+
+		targets.WithLabelValues(oc.Name, string(Availability)).Set(oc.Availability)
+		if o.measuresLatency() {
+			targets.WithLabelValues(oc.Name, string(Latency)).Set(oc.LatencyTarget)
+		}
+	}
+
+	return r, nil
+}
+
+// UnaryServerInterceptor measures unary calls for both objectives
+func (r *Recorder) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		r.observe(time.Now(), info.FullMethod, status.Code(err), time.Since(start), true)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor measures streaming calls for availability only, as
+// their duration depends on the client
+func (r *Recorder) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		r.observe(time.Now(), info.FullMethod, status.Code(err), time.Since(start), false)
+		return err
+	}
+}
+
+// Observe records a unary call that completed at a time with a status code
+// after running for elapsed
+func (r *Recorder) Observe(at time.Time, fullMethod string, code codes.Code, elapsed time.Duration) {
+	r.observe(at, fullMethod, code, elapsed, true)
+}
+
+// observe records a call, measuring its latency if timed
+func (r *Recorder) observe(at time.Time, fullMethod string, code codes.Code, elapsed time.Duration, timed bool) {
+	// Calls abandoned by the client are neither good nor bad
+	if code == codes.Canceled {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m := r.method(fullMethod)
+	if m == nil {
+		return
+	}
+	slot := at.UnixNano() / int64(r.interval)
+
+	available := !serverError(code)
+	m.availability.add(slot, available)
+	events.WithLabelValues(m.objective.cfg.Name, string(Availability), fullMethod, outcome(available)).Inc()
+
+	if timed && available && m.latency != nil {
+		fast := elapsed <= m.objective.cfg.LatencyThreshold
+		m.latency.add(slot, fast)
+		events.WithLabelValues(m.objective.cfg.Name, string(Latency), fullMethod, outcome(fast)).Inc()
+	}
+}
+
+// method returns the events of a method, or nil when no objective covers it.
+// The caller must hold the mutex.
+func (r *Recorder) method(fullMethod string) *method {
+	if m, ok := r.methods[fullMethod]; ok {
+		return m
+	}
+
+	var m *method
+	if o := r.objectiveOf(fullMethod); o != nil {
+		m = &method{objective: o, availability: newSeries(r.slots)}
+		if o.measuresLatency() {
+			m.latency = newSeries(r.slots)
+		}
+	}
+	r.methods[fullMethod] = m
+	return m
+}
+
+// objectiveOf returns the first objective listing a method, by full or bare
+// name, falling back to the first objective listing no methods
+func (r *Recorder) objectiveOf(fullMethod string) *objective {
+	name := path.Base(fullMethod)
+	var fallback *objective
+	for _, o := range r.objectives {
+		if o.methods[fullMethod] || o.methods[name] {
+			return o
+		}
+		if o.catchAll && fallback == nil {
+			fallback = o
+		}
+	}
+	return fallback
+}
+
+// Run evaluates burn rates every evaluation interval until ctx is cancelled
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			r.Evaluate(now)
+		}
+	}
+}
+
+// Evaluate updates the success ratio and burn rate metrics as of now and
+// returns the burn rates of each objective over each window
+func (r *Recorder) Evaluate(now time.Time) []BurnRate {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	current := now.UnixNano() / int64(r.interval)
+	type key struct {
+		objective *objective
+		kind      Objective
+		window    int
+	}
+	totals := make(map[key][2]uint64)
+
+	for name, m := range r.methods {
+		if m == nil {
+			continue
+		}
+		for _, kind := range []Objective{Availability, Latency} {
+			s := m.availability
+			if kind == Latency {
+				s = m.latency
+			}
+			if s == nil {
+				continue
+			}
+
+			for i, window := range r.windows {
+				good, total := s.sum(current-r.windowSlots(window), current)
+				k := key{m.objective, kind, i}
+				sums := totals[k]
+				totals[k] = [2]uint64{sums[0] + good, sums[1] + total}
+
+				labels := []string{m.objective.cfg.Name, string(kind), name, formatWindow(window)}
+				if total == 0 {
+					successRatios.DeleteLabelValues(labels...)
+					continue
+				}
+				successRatios.WithLabelValues(labels...).Set(float64(good) / float64(total))
+			}
+		}
+	}
+
+	var rates []BurnRate
+	for _, o := range r.objectives {
+		for _, kind := range []Objective{Availability, Latency} {
+			if kind == Latency && !o.measuresLatency() {
+				continue
+			}
+
+			var longest BurnRate
+			for i, window := range r.windows {
+				sums := totals[key{o, kind, i}]
+				rate := burnRate(o.cfg.Name, kind, window, sums[0], sums[1], o.target(kind))
+				burnRates.WithLabelValues(o.cfg.Name, string(kind), formatWindow(window)).Set(rate.Rate)
+				rates = append(rates, rate)
+				if window >= longest.Window {
+					longest = rate
+				}
+			}
+			budgetRemaining.WithLabelValues(o.cfg.Name, string(kind)).Set(1 - longest.Rate)
+		}
+	}
+
+	return rates
+}
+
+// windowSlots returns the number of evaluation intervals a window spans
+func (r *Recorder) windowSlots(window time.Duration) int64 {
+	return int64((window + r.interval - 1) / r.interval)
+}
+
+// burnRate computes the burn rate of an objective from its event counts
+func burnRate(name string, kind Objective, window time.Duration, good, total uint64, target float64) BurnRate {
+	rate := BurnRate{SLO: name, Objective: kind, Window: window, Events: total, Ratio: 1}
+	if total > 0 {
+		rate.Ratio = float64(good) / float64(total)
+		rate.Rate = (1 - rate.Ratio) / (1 - target)
+	}
+	return rate
+}
+
+// serverError reports whether a status code is the service's fault rather
+// than the client's, and so spends the availability error budget
+func serverError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+// outcome labels an event as good or bad
+func outcome(good bool) string {
+	if good {
+		return "good"
+	}
+	return "bad"
+}
+
+// formatWindow labels a window in the largest whole unit, e.g. "30m" or "3d"
+func formatWindow(window time.Duration) string {
+	switch {
+	case window%(time.Hour*24) == 0:
+		return fmt.Sprintf("%dd", window/(time.Hour*24))
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%dh", window/time.Hour)
+	case window%time.Minute == 0:
+		return fmt.Sprintf("%dm", window/time.Minute)
+	}
+	return window.String()
+}
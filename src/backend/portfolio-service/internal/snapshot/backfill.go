@@ -0,0 +1,55 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+	"go.uber.org/zap"        // v1.24.0
+)
+
+// ChangeRefresher lists portfolios and recomputes their materialized change
+// columns from snapshots
+type ChangeRefresher interface {
+	Portfolios
+	RefreshPortfolioChanges(ctx context.Context, portfolioID uuid.UUID, asOf time.Time) error
+}
+
+// BackfillChanges recomputes the materialized 24h/7d/30d change columns of
+// every active portfolio from existing snapshots. It is intended to be run
+// once after the change columns are introduced and is safe to re-run.
+func BackfillChanges(ctx context.Context, repo ChangeRefresher, batchSize int, logger *zap.Logger) error {
+	asOf := time.Now().UTC()
+
+	var (
+		after     uuid.UUID
+		refreshed int
+	)
+
+	for {
+		ids, err := repo.ListPortfolioIDs(ctx, after, batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list portfolios: %w", err)
+		}
+
+		for _, id := range ids {
+			if err := repo.RefreshPortfolioChanges(ctx, id, asOf); err != nil {
+				return fmt.Errorf("failed to backfill portfolio %s: %w", id, err)
+			}
+			refreshed++
+		}
+
+		if len(ids) < batchSize {
+			break
+		}
+		after = ids[len(ids)-1]
+	}
+
+	logger.Info("Portfolio change backfill completed",
+		zap.Int("portfolios", refreshed),
+		zap.Time("as_of", asOf),
+	)
+
+	return nil
+}
@@ -0,0 +1,119 @@
+// Package snapshot records scheduled historical valuation snapshots of all
+// portfolios so charts and period returns are backed by real history.
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid" // v1.3.0
+	"go.uber.org/zap"        // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/models"
+)
+
+// Portfolios pages through the IDs of active portfolios in order
+type Portfolios interface {
+	ListPortfolioIDs(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error)
+}
+
+// Capturer values a portfolio and records the result as a snapshot
+type Capturer interface {
+	CaptureSnapshot(ctx context.Context, portfolioID uuid.UUID, capturedAt time.Time) (*models.PortfolioSnapshot, error)
+}
+
+// Recorder captures valuation snapshots of every active portfolio on a fixed interval
+type Recorder struct {
+	repo   Portfolios
+	svc    Capturer
+	cfg    config.SnapshotConfig
+	logger *zap.Logger
+}
+
+// NewRecorder creates a new snapshot recording job
+func NewRecorder(repo Portfolios, svc Capturer, cfg config.SnapshotConfig, logger *zap.Logger) (*Recorder, error) {
+	if repo == nil || svc == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	return &Recorder{
+		repo:   repo,
+		svc:    svc,
+		cfg:    cfg,
+		logger: logger.With(zap.String("component", "snapshot_recorder")),
+	}, nil
+}
+
+// Run records snapshots at each interval boundary until ctx is cancelled
+func (r *Recorder) Run(ctx context.Context) {
+	for {
+		next := time.Now().UTC().Truncate(r.cfg.Interval).Add(r.cfg.Interval)
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := r.RunOnce(ctx, next); err != nil && ctx.Err() == nil {
+			r.logger.Error("Snapshot pass failed", zap.Error(err))
+		}
+	}
+}
+
+// RunOnce records a snapshot of every active portfolio for the given slot.
+// Slots are aligned to the interval so repeated runs for the same slot are idempotent.
+func (r *Recorder) RunOnce(ctx context.Context, slot time.Time) error {
+	capturedAt := slot.UTC().Truncate(r.cfg.Interval)
+	start := time.Now()
+
+	var (
+		after    uuid.UUID
+		recorded int
+		failed   int
+	)
+
+	for {
+		ids, err := r.repo.ListPortfolioIDs(ctx, after, r.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list portfolios: %w", err)
+		}
+
+		for _, id := range ids {
+			if _, err := r.svc.CaptureSnapshot(ctx, id, capturedAt); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				failed++
+				r.logger.Warn("Failed to capture snapshot",
+					zap.Error(err),
+					zap.String("portfolio_id", id.String()),
+				)
+				continue
+			}
+			recorded++
+		}
+
+		if len(ids) < r.cfg.BatchSize {
+			break
+		}
+		after = ids[len(ids)-1]
+	}
+
+	r.logger.Info("Snapshot pass completed",
+		zap.Time("captured_at", capturedAt),
+		zap.Int("recorded", recorded),
+		zap.Int("failed", failed),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	if failed > 0 {
+		return fmt.Errorf("%d portfolios failed to snapshot", failed)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// Package staking accrues the rewards of chain staking positions into their
+// portfolios in the background. Due positions are leased so several service
+// instances can accrue concurrently without recording a reward twice.
+package staking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"go.uber.org/zap"                                // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/metrics"
+	"bookman/portfolio-service/internal/repository"
+	"bookman/portfolio-service/internal/services"
+)
+
+// pollInterval is how often the accruer looks for due positions
+const pollInterval = time.Minute * 5
+
+var rewardAccruals = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "portfolio_staking_accruals_total",
+		Help: "Total number of staking reward accruals by provider and status",
+	},
+	[]string{"provider", "status"},
+)
+
+func init() {
+	metrics.MustRegister(rewardAccruals)
+}
+
+// Accruer accrues the rewards of due chain staking positions on a fixed
+// interval
+type Accruer struct {
+	repo   *repository.PostgresRepository
+	svc    *services.PortfolioService
+	cfg    config.StakingConfig
+	logger *zap.Logger
+}
+
+// NewAccruer creates a new staking reward accrual job
+func NewAccruer(repo *repository.PostgresRepository, svc *services.PortfolioService, cfg config.StakingConfig, logger *zap.Logger) (*Accruer, error) {
+	if repo == nil || svc == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	return &Accruer{
+		repo:   repo,
+		svc:    svc,
+		cfg:    cfg,
+		logger: logger.With(zap.String("component", "staking_accruer")),
+	}, nil
+}
+
+// Run accrues due positions at each poll until ctx is cancelled
+func (a *Accruer) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := a.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			a.logger.Error("Staking accrual pass failed", zap.Error(err))
+		}
+	}
+}
+
+// RunOnce claims and accrues batches of due positions until none are left.
+// A failed accrual is recorded on its position and retried at its next
+// accrual.
+func (a *Accruer) RunOnce(ctx context.Context) error {
+	failed := 0
+	for {
+		now := time.Now().UTC()
+		// The lease outlives an accrual so a crashed worker's claims are
+		// retried after one interval
+		positions, err := a.repo.ClaimDueStakingPositions(ctx, now, now.Add(a.cfg.AccrualInterval), a.cfg.AccrualBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to claim staking positions: %w", err)
+		}
+
+		for i := range positions {
+			position := &positions[i]
+			result, err := a.svc.AccrueStakingRewards(ctx, position)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				failed++
+				rewardAccruals.WithLabelValues(position.Provider, "error").Inc()
+				a.logger.Warn("Staking reward accrual failed",
+					zap.Error(err),
+					zap.String("position_id", position.ID.String()),
+					zap.String("portfolio_id", position.PortfolioID.String()),
+					zap.String("provider", position.Provider),
+				)
+				continue
+			}
+
+			rewardAccruals.WithLabelValues(position.Provider, "success").Inc()
+			a.logger.Debug("Staking rewards accrued",
+				zap.String("position_id", position.ID.String()),
+				zap.Int("transactions", result.TransactionsRecorded),
+			)
+		}
+
+		if len(positions) < a.cfg.AccrualBatchSize {
+			break
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d staking positions failed to accrue", failed)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Package transport applies the connection and message limits of the server
+// configuration to the gRPC server, so every listener serving the API shares
+// the same keepalive, message size, stream and compression settings.
+package transport
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc"               // v1.50.0
+	"google.golang.org/grpc/encoding/gzip" // v1.50.0
+	"google.golang.org/grpc/keepalive"     // v1.50.0
+	"google.golang.org/grpc/reflection"    // v1.50.0
+
+	"bookman/portfolio-service/internal/config"
+)
+
+// ServerOptions returns the gRPC server options enforcing the keepalive
+// policy, message sizes and concurrent stream limit of cfg. It also sets the
+// level of the gzip compressor, which compresses responses of clients that
+// request gzip; the compressor is process-wide and registered on import.
+func ServerOptions(cfg *config.ServerConfig) ([]grpc.ServerOption, error) {
+	if err := gzip.SetLevel(cfg.GzipLevel); err != nil {
+		return nil, fmt.Errorf("failed to set gzip level: %w", err)
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     cfg.Keepalive.MaxConnectionIdle,
+			MaxConnectionAge:      cfg.Keepalive.MaxConnectionAge,
+			MaxConnectionAgeGrace: cfg.Keepalive.MaxConnectionAgeGrace,
+			Time:                  cfg.Keepalive.Time,
+			Timeout:               cfg.Keepalive.Timeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.Keepalive.MinTime,
+			PermitWithoutStream: cfg.Keepalive.PermitWithoutStream,
+		}),
+		grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize),
+		grpc.MaxSendMsgSize(cfg.MaxSendMsgSize),
+	}
+
+	// Zero leaves streams unlimited
+	if cfg.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(cfg.MaxConcurrentStreams))
+	}
+
+	return opts, nil
+}
+
+// RegisterReflection registers the gRPC reflection service on server when
+// cfg enables it
+func RegisterReflection(server *grpc.Server, cfg *config.ServerConfig) {
+	if cfg.Reflection {
+		reflection.Register(server)
+	}
+}
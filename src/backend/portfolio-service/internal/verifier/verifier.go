@@ -0,0 +1,131 @@
+// Package verifier periodically checks ledger invariants and exports the number
+// of violations as gauges, so data-correctness regressions can page the on-call.
+package verifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"go.uber.org/zap"                                // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/metrics"
+	"bookman/portfolio-service/internal/repository"
+)
+
+// Define verifier metrics
+var (
+	violations = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "portfolio_ledger_invariant_violations",
+			Help: "Number of rows violating each ledger invariant at the last verification pass",
+		},
+		[]string{"invariant"},
+	)
+
+	checkErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "portfolio_ledger_invariant_check_errors_total",
+			Help: "Total number of ledger invariant checks that failed to run",
+		},
+		[]string{"invariant"},
+	)
+
+	lastSuccess = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "portfolio_ledger_verification_last_success_timestamp_seconds",
+			Help: "Unix time of the last verification pass in which every check ran",
+		},
+	)
+)
+
+func init() {
+	metrics.MustRegister(violations)
+	metrics.MustRegister(checkErrors)
+	metrics.MustRegister(lastSuccess)
+}
+
+// Repository counts the rows violating ledger invariants
+type Repository interface {
+	CountInvariantViolations(ctx context.Context, invariant string) (int64, error)
+}
+
+// Verifier checks ledger invariants on a fixed interval
+type Verifier struct {
+	repo   Repository
+	cfg    config.VerifierConfig
+	logger *zap.Logger
+}
+
+// NewVerifier creates a new ledger invariant verification job
+func NewVerifier(repo Repository, cfg config.VerifierConfig, logger *zap.Logger) (*Verifier, error) {
+	if repo == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	return &Verifier{
+		repo:   repo,
+		cfg:    cfg,
+		logger: logger.With(zap.String("component", "ledger_verifier")),
+	}, nil
+}
+
+// Run verifies invariants immediately and then at each interval until ctx is cancelled
+func (v *Verifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(v.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := v.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			v.logger.Error("Verification pass failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce checks every invariant and updates the violation gauges. A failed
+// check leaves its gauge at the previous value so alerts do not flap.
+func (v *Verifier) RunOnce(ctx context.Context) error {
+	var failed int
+
+	for _, invariant := range repository.Invariants() {
+		checkCtx, cancel := context.WithTimeout(ctx, v.cfg.CheckTimeout)
+		count, err := v.repo.CountInvariantViolations(checkCtx, invariant)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed++
+			checkErrors.WithLabelValues(invariant).Inc()
+			v.logger.Warn("Invariant check failed",
+				zap.String("invariant", invariant),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		violations.WithLabelValues(invariant).Set(float64(count))
+		if count > 0 {
+			v.logger.Error("Ledger invariant violated",
+				zap.String("invariant", invariant),
+				zap.Int64("violations", count),
+			)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d invariant checks failed", failed)
+	}
+
+	lastSuccess.SetToCurrentTime()
+	return nil
+}
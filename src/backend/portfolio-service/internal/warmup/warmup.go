@@ -0,0 +1,79 @@
+// Package warmup runs the bounded warm-up phase before the service starts
+// serving: waiting for dependencies that are still coming up and priming
+// caches, so the first requests after a deploy are neither failed nor slowed
+// down. Schema migrations are applied by the deploy pipeline, or by the
+// service itself when startup migrations are enabled, and recorded in
+// schema_migrations; the service waits for them, bounded by the warm-up
+// timeout, and fails startup unless the schema reaches the version it was
+// built against.
+package warmup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal" // v1.3.1
+	"go.uber.org/zap"               // v1.24.0
+)
+
+// Wait calls attempt until it succeeds, waiting interval between attempts,
+// and returns the last error once ctx is done
+func Wait(ctx context.Context, name string, interval time.Duration, logger *zap.Logger, attempt func(ctx context.Context) error) error {
+	for attempts := 1; ; attempts++ {
+		err := attempt(ctx)
+		if err == nil {
+			if attempts > 1 {
+				logger.Info("Dependency available", zap.String("dependency", name), zap.Int("attempts", attempts))
+			}
+			return nil
+		}
+
+		logger.Warn("Dependency not available yet",
+			zap.String("dependency", name),
+			zap.Int("attempt", attempts),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s not available within the warm-up timeout: %w", name, err)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// SymbolLister lists the symbols whose prices are worth priming
+type SymbolLister interface {
+	ListHeldSymbols(ctx context.Context) ([]string, error)
+}
+
+// PriceSource loads current prices, filling its cache tiers as it goes
+type PriceSource interface {
+	GetCurrentPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error)
+}
+
+// PrimePrices loads the prices of every held symbol through prices in
+// batches of batchSize, so they are cached before the first valuation. It
+// returns the number of symbols primed.
+func PrimePrices(ctx context.Context, symbols SymbolLister, prices PriceSource, batchSize int) (int, error) {
+	held, err := symbols.ListHeldSymbols(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list held symbols: %w", err)
+	}
+
+	primed := 0
+	for start := 0; start < len(held); start += batchSize {
+		end := start + batchSize
+		if end > len(held) {
+			end = len(held)
+		}
+
+		loaded, err := prices.GetCurrentPrices(ctx, held[start:end])
+		if err != nil {
+			return primed, fmt.Errorf("failed to load prices: %w", err)
+		}
+		primed += len(loaded)
+	}
+	return primed, nil
+}
@@ -0,0 +1,143 @@
+// Package web serves the gRPC services to browser clients using the gRPC-Web
+// protocol, wrapping the in-process gRPC server so no Envoy proxy is needed.
+// Plain gRPC clients can use the same listener over HTTP/2.
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/improbable-eng/grpc-web/go/grpcweb" // v0.15.0
+	"go.uber.org/zap"                               // v1.24.0
+	"golang.org/x/net/http2"                        // v0.9.0
+	"golang.org/x/net/http2/h2c"                    // v0.9.0
+	"google.golang.org/grpc"                        // v1.50.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/consistency"
+	"bookman/portfolio-service/internal/deprecation"
+	"bookman/portfolio-service/internal/exemplars"
+)
+
+// readHeaderTimeout bounds how long a client may take to send request headers
+const readHeaderTimeout = 10 * time.Second
+
+// allowedRequestHeaders lists the headers browsers may send besides the
+// gRPC-Web protocol headers; they are forwarded to handlers as metadata
+var allowedRequestHeaders = []string{
+	"authorization",
+	"x-request-id",
+	"x-user-agent",
+	"x-grpc-web",
+	"grpc-timeout",
+	consistency.MetadataKey,
+	deprecation.ClientVersionKey,
+	exemplars.MetadataKey,
+}
+
+// Server exposes a gRPC server over HTTP/1.1 and HTTP/2 using gRPC-Web
+type Server struct {
+	cfg     *config.ServerConfig
+	grpc    *grpc.Server
+	wrapped *grpcweb.WrappedGrpcServer
+	http    *http.Server
+	logger  *zap.Logger
+}
+
+// NewServer wraps the gRPC server for gRPC-Web clients. The listener is taken
+// from cfg.GRPCWeb and TLS settings are shared with the gRPC server.
+func NewServer(cfg *config.ServerConfig, grpcServer *grpc.Server, logger *zap.Logger) (*Server, error) {
+	if cfg == nil || grpcServer == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	s := &Server{
+		cfg:    cfg,
+		grpc:   grpcServer,
+		logger: logger.With(zap.String("component", "grpc_web")),
+	}
+
+	web := cfg.GRPCWeb
+	s.wrapped = grpcweb.WrapServer(grpcServer,
+		grpcweb.WithOriginFunc(s.allowOrigin),
+		grpcweb.WithAllowedRequestHeaders(allowedRequestHeaders),
+		grpcweb.WithCorsForRegisteredEndpointsOnly(true),
+		grpcweb.WithWebsockets(web.Websockets),
+		grpcweb.WithWebsocketOriginFunc(func(r *http.Request) bool {
+			return s.allowOrigin(r.Header.Get("Origin"))
+		}),
+		grpcweb.WithWebsocketPingInterval(web.WebsocketPingInterval),
+	)
+
+	// Without TLS, plain gRPC clients reach the listener over cleartext HTTP/2
+	var handler http.Handler = http.HandlerFunc(s.serveHTTP)
+	if !cfg.TLSEnabled {
+		handler = h2c.NewHandler(handler, &http2.Server{})
+	}
+
+	s.http = &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", web.Host, web.Port),
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		IdleTimeout:       web.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+
+	return s, nil
+}
+
+// Handler returns the handler serving the listener's requests
+func (s *Server) Handler() http.Handler {
+	return s.http.Handler
+}
+
+// serveHTTP routes gRPC-Web, CORS preflight and websocket requests to the
+// wrapped server and passes plain gRPC requests through to the gRPC server.
+// Write timeouts are not set so server streams stay open.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case s.wrapped.IsGrpcWebRequest(r),
+		s.wrapped.IsAcceptableGrpcCorsRequest(r),
+		s.wrapped.IsGrpcWebSocketRequest(r):
+		s.wrapped.ServeHTTP(w, r)
+	case r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc"):
+		s.grpc.ServeHTTP(w, r)
+	default:
+		http.Error(w, "unsupported request", http.StatusUnsupportedMediaType)
+	}
+}
+
+// allowOrigin reports whether browsers from the origin may call the API
+func (s *Server) allowOrigin(origin string) bool {
+	for _, allowed := range s.cfg.GRPCWeb.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListenAndServe starts serving gRPC-Web requests until Shutdown is called
+func (s *Server) ListenAndServe() error {
+	s.logger.Info("gRPC-Web server listening", zap.String("address", s.http.Addr))
+
+	var err error
+	if s.cfg.TLSEnabled {
+		err = s.http.ListenAndServeTLS(s.cfg.TLSCert, s.cfg.TLSKey)
+	} else {
+		err = s.http.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown gracefully stops the server, waiting for active requests until ctx expires
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.http.Shutdown(ctx)
+}
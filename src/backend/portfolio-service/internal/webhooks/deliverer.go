@@ -0,0 +1,248 @@
+// Package webhooks delivers queued portfolio mutation events to user-registered
+// endpoints. Deliveries are signed with the endpoint secret and retried with
+// exponential backoff; their status is stored for users to query.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // v1.14.0
+	"go.uber.org/zap"                                // v1.24.0
+
+	"bookman/portfolio-service/internal/config"
+	"bookman/portfolio-service/internal/envelope"
+	"bookman/portfolio-service/internal/metrics"
+	"bookman/portfolio-service/internal/models"
+	"bookman/portfolio-service/internal/notifications"
+	"bookman/portfolio-service/internal/repository"
+)
+
+// maxErrorLength bounds the stored error of a failed attempt
+const maxErrorLength = 512
+
+var deliveryAttempts = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "portfolio_webhook_delivery_attempts_total",
+		Help: "Total number of webhook delivery attempts by event and resulting status",
+	},
+	[]string{"event", "status"},
+)
+
+func init() {
+	metrics.MustRegister(deliveryAttempts)
+}
+
+// errPrivateTarget is returned when an endpoint resolves to a private address
+var errPrivateTarget = errors.New("webhook target resolves to a private address")
+
+// Deliverer sends due webhook deliveries on a fixed interval
+type Deliverer struct {
+	repo      *repository.PostgresRepository
+	cfg       config.WebhooksConfig
+	encryptor *envelope.Encryptor
+	client    *http.Client
+	logger    *zap.Logger
+}
+
+// NewDeliverer creates a new webhook delivery job. The encryptor opens sealed
+// signing secrets; secrets stored before encryption was enabled are used as is.
+func NewDeliverer(repo *repository.PostgresRepository, cfg config.WebhooksConfig, encryptor *envelope.Encryptor, logger *zap.Logger) (*Deliverer, error) {
+	if repo == nil || logger == nil {
+		return nil, errors.New("invalid dependencies provided")
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateTargets {
+		dialer.Control = rejectPrivateTargets
+	}
+
+	client := &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: cfg.Timeout,
+			MaxIdleConnsPerHost: 2,
+		},
+		// Redirects could point deliveries at unvalidated targets
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return &Deliverer{
+		repo:      repo,
+		cfg:       cfg,
+		encryptor: encryptor,
+		client:    client,
+		logger:    logger.With(zap.String("component", "webhook_deliverer")),
+	}, nil
+}
+
+// Run delivers due webhooks at each interval until ctx is cancelled
+func (d *Deliverer) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := d.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			d.logger.Error("Webhook delivery pass failed", zap.Error(err))
+		}
+	}
+}
+
+// RunOnce claims and sends batches of due deliveries until none are left
+func (d *Deliverer) RunOnce(ctx context.Context) error {
+	for {
+		now := time.Now().UTC()
+		// The lease outlives one attempt so a crashed worker's claims are retried
+		deliveries, endpoints, err := d.repo.ClaimWebhookDeliveries(ctx, now, now.Add(2*d.cfg.Timeout), d.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to claim webhook deliveries: %w", err)
+		}
+
+		for i := range deliveries {
+			delivery := &deliveries[i]
+			endpoint := endpoints[delivery.EndpointID]
+			d.attempt(ctx, delivery, &endpoint)
+
+			if err := d.repo.RecordWebhookAttempt(ctx, delivery); err != nil {
+				return fmt.Errorf("failed to record webhook attempt: %w", err)
+			}
+		}
+
+		if len(deliveries) < d.cfg.BatchSize {
+			return nil
+		}
+	}
+}
+
+// attempt sends one delivery and updates its status, attempt count and next
+// attempt time
+func (d *Deliverer) attempt(ctx context.Context, delivery *models.WebhookDelivery, endpoint *models.WebhookEndpoint) {
+	code, err := d.send(ctx, delivery, endpoint)
+
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.ResponseCode = code
+	delivery.LastError = ""
+
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliverySucceeded
+		delivery.DeliveredAt = &now
+	case notifications.IsPermanent(err) || delivery.Attempts >= d.cfg.MaxAttempts:
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = truncate(err.Error())
+	default:
+		delivery.Status = models.DeliveryPending
+		delivery.LastError = truncate(err.Error())
+		delivery.NextAttemptAt = now.Add(models.WebhookBackoff(delivery.Attempts, d.cfg.InitialBackoff, d.cfg.MaxBackoff))
+	}
+
+	deliveryAttempts.WithLabelValues(string(delivery.Event), string(delivery.Status)).Inc()
+	if delivery.Status == models.DeliveryFailed {
+		d.logger.Warn("Webhook delivery failed",
+			zap.String("delivery_id", delivery.ID.String()),
+			zap.String("webhook_id", delivery.EndpointID.String()),
+			zap.String("event", string(delivery.Event)),
+			zap.Int("attempts", delivery.Attempts),
+			zap.Int("response_code", code),
+		)
+	}
+}
+
+// send posts the signed payload and returns the response status code. Client
+// errors other than timeouts and rate limiting are permanent.
+func (d *Deliverer) send(ctx context.Context, delivery *models.WebhookDelivery, endpoint *models.WebhookEndpoint) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, notifications.Permanent(fmt.Errorf("invalid webhook request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(notifications.EventHeader, string(delivery.Event))
+	req.Header.Set(notifications.DeliveryHeader, delivery.ID.String())
+	secret, err := d.signingSecret(ctx, delivery, endpoint)
+	if err != nil {
+		// Key service outages are retried; secrets that cannot be opened are not
+		if d.encryptor == nil || errors.Is(err, envelope.ErrUndecryptable) || errors.Is(err, envelope.ErrUnknownKey) {
+			return 0, notifications.Permanent(err)
+		}
+		return 0, err
+	}
+	req.Header.Set(notifications.SignatureHeader, "sha256="+notifications.Sign([]byte(secret), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		if errors.Is(err, errPrivateTarget) {
+			return 0, notifications.Permanent(err)
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return resp.StatusCode, nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests:
+		return resp.StatusCode, fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return resp.StatusCode, notifications.Permanent(fmt.Errorf("endpoint rejected delivery with status %d", resp.StatusCode))
+	default:
+		return resp.StatusCode, fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+}
+
+// signingSecret returns the signing secret of an endpoint, opening it when
+// sealed
+func (d *Deliverer) signingSecret(ctx context.Context, delivery *models.WebhookDelivery, endpoint *models.WebhookEndpoint) (string, error) {
+	if !envelope.IsSealedString(endpoint.Secret) {
+		return endpoint.Secret, nil
+	}
+	if d.encryptor == nil {
+		return "", errors.New("webhook secret is sealed but encryption is not configured")
+	}
+	secret, err := d.encryptor.OpenString(ctx, endpoint.Secret, models.WebhookSecretScope(delivery.EndpointID))
+	if err != nil {
+		return "", fmt.Errorf("failed to open webhook secret: %w", err)
+	}
+	return secret, nil
+}
+
+// rejectPrivateTargets refuses connections to loopback, private, link-local
+// and unspecified addresses after DNS resolution
+func rejectPrivateTargets(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return errPrivateTarget
+	}
+	return nil
+}
+
+// truncate bounds error messages stored with deliveries
+func truncate(s string) string {
+	if len(s) > maxErrorLength {
+		return s[:maxErrorLength]
+	}
+	return s
+}
@@ -0,0 +1,27 @@
+package tests
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestParseActivityKinds verifies an unfiltered feed lists every kind and
+// filters drop duplicates and reject unknown kinds
+func TestParseActivityKinds(t *testing.T) {
+    t.Parallel()
+
+    all, err := models.ParseActivityKinds(nil)
+    require.NoError(t, err)
+    assert.Equal(t, models.ACTIVITY_KINDS, all)
+
+    kinds, err := models.ParseActivityKinds([]string{"sync", "transaction", "sync"})
+    require.NoError(t, err)
+    assert.Equal(t, []models.ActivityKind{models.ActivitySync, models.ActivityTransaction}, kinds)
+
+    _, err = models.ParseActivityKinds([]string{"transaction", "price"})
+    assert.ErrorIs(t, err, models.ErrInvalidActivityKind)
+}
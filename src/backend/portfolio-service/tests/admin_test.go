@@ -0,0 +1,86 @@
+package tests
+
+import (
+    "context"
+    "testing"
+
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+    "google.golang.org/grpc"                // v1.50.0
+    "google.golang.org/grpc/codes"          // v1.50.0
+    "google.golang.org/grpc/metadata"       // v1.50.0
+    "google.golang.org/grpc/status"         // v1.50.0
+
+    "bookman/portfolio-service/internal/admin"
+    "bookman/portfolio-service/internal/config"
+)
+
+// TestAdminInterceptor verifies AdminService and other admin-only methods
+// require an operator token granting the admin scope while other methods are
+// left alone
+func TestAdminInterceptor(t *testing.T) {
+    t.Parallel()
+
+    authenticator, err := admin.NewAuthenticator(config.AdminConfig{
+        Enabled: true,
+        Tokens: []config.AdminToken{
+            {Name: "support", Hash: admin.HashToken("support-token"), Scopes: []string{admin.ScopeAdmin}},
+            {Name: "dashboards", Hash: admin.HashToken("read-token"), Scopes: []string{"metrics"}},
+        },
+    })
+    require.NoError(t, err)
+    interceptor := authenticator.UnaryServerInterceptor()
+
+    handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+        operator, ok := admin.FromContext(ctx)
+        if !ok {
+            return "", nil
+        }
+        return operator.Name, nil
+    }
+    call := func(fullMethod, authorization string) (interface{}, error) {
+        ctx := context.Background()
+        if authorization != "" {
+            ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(admin.MetadataKey, authorization))
+        }
+        return interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: fullMethod}, handler)
+    }
+
+    got, err := call(admin.ServicePrefix+"GetPlatformStats", "Bearer support-token")
+    require.NoError(t, err)
+    assert.Equal(t, "support", got)
+
+    _, err = call(admin.ServicePrefix+"GetPlatformStats", "")
+    assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+    _, err = call(admin.ServicePrefix+"GetPlatformStats", "support-token")
+    assert.Equal(t, codes.Unauthenticated, status.Code(err), "tokens must be bearer credentials")
+
+    _, err = call(admin.ServicePrefix+"TriggerJob", "Bearer unknown-token")
+    assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+    _, err = call(admin.ServicePrefix+"TriggerJob", "Bearer read-token")
+    assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+    // Admin-only methods of other services are gated the same way
+    _, err = call(admin.PurgeUserDataMethod, "")
+    assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+    _, err = call(admin.PurgeUserDataMethod, "Bearer read-token")
+    assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+    got, err = call(admin.PurgeUserDataMethod, "Bearer support-token")
+    require.NoError(t, err)
+    assert.Equal(t, "support", got)
+
+    // Operator tokens grant nothing on other methods
+    got, err = call("/portfolio.PortfolioService/ListPortfolios", "Bearer support-token")
+    require.NoError(t, err)
+    assert.Equal(t, "", got)
+    assert.False(t, admin.RequiresAdmin("/portfolio.PortfolioService/ListPortfolios"))
+
+    _, err = admin.NewAuthenticator(config.AdminConfig{Tokens: []config.AdminToken{{Name: "bad", Hash: "abc", Scopes: []string{admin.ScopeAdmin}}}})
+    assert.Error(t, err)
+    _, err = admin.NewAuthenticator(config.AdminConfig{})
+    assert.Error(t, err)
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/shopspring/decimal"    // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestValueDropAlert verifies drawdowns are measured from the window peak and
+// do not re-trigger within the window
+func TestValueDropAlert(t *testing.T) {
+    t.Parallel()
+
+    rule := &models.AlertRule{Kind: models.AlertValueDrop, Threshold: decimal.NewFromInt(10), Enabled: true}
+    require.NoError(t, rule.Validate())
+    assert.Equal(t, models.DEFAULT_ALERT_WINDOW, rule.Window)
+
+    now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+    obs := models.AlertObservation{
+        Current:    models.ValuePoint{Timestamp: now, Value: decimal.NewFromInt(880)},
+        WindowPeak: decimal.NewNullDecimal(decimal.NewFromInt(1000)),
+    }
+
+    observed, triggered := rule.Evaluate(obs)
+    assert.True(t, triggered)
+    assert.True(t, observed.Equal(decimal.NewFromInt(12)))
+
+    rule.LastTriggeredAt = &now
+    obs.Current.Timestamp = now.Add(time.Hour)
+    _, triggered = rule.Evaluate(obs)
+    assert.False(t, triggered, "cooldown within window")
+
+    obs.Current.Timestamp = now.Add(25 * time.Hour)
+    _, triggered = rule.Evaluate(obs)
+    assert.True(t, triggered)
+
+    obs.Current.Value = decimal.NewFromInt(950)
+    _, triggered = rule.Evaluate(obs)
+    assert.False(t, triggered)
+}
+
+// TestProfitLossCrossingAlert verifies P&L rules trigger only when the level is crossed
+func TestProfitLossCrossingAlert(t *testing.T) {
+    t.Parallel()
+
+    rule := &models.AlertRule{Kind: models.AlertProfitLossBelow, Threshold: decimal.NewFromInt(-500), Enabled: true}
+    require.NoError(t, rule.Validate())
+
+    now := time.Now().UTC()
+    previous := models.ValuePoint{Timestamp: now.Add(-time.Hour), ProfitLoss: decimal.NewFromInt(-100)}
+    obs := models.AlertObservation{
+        Current:  models.ValuePoint{Timestamp: now, ProfitLoss: decimal.NewFromInt(-600)},
+        Previous: &previous,
+    }
+
+    _, triggered := rule.Evaluate(obs)
+    assert.True(t, triggered)
+
+    previous.ProfitLoss = decimal.NewFromInt(-550)
+    _, triggered = rule.Evaluate(obs)
+    assert.False(t, triggered, "already below the level")
+
+    obs.Previous = nil
+    _, triggered = rule.Evaluate(obs)
+    assert.False(t, triggered, "no history")
+
+    invalid := []models.AlertRule{
+        {Kind: models.AlertValueDrop, Threshold: decimal.NewFromInt(150)},
+        {Kind: models.AlertValueDrop, Threshold: decimal.NewFromInt(5), Window: time.Minute},
+        {Kind: models.AlertProfitLossAbove, Threshold: decimal.NewFromInt(5), Window: time.Hour},
+        {Kind: "price_above", Threshold: decimal.NewFromInt(5)},
+    }
+    fields := []string{"threshold", "window", "window", "kind"}
+    for i := range invalid {
+        err := invalid[i].Validate()
+        assert.ErrorIs(t, err, models.ErrInvalidAlertRule)
+        var fieldErr *models.FieldError
+        if assert.ErrorAs(t, err, &fieldErr) {
+            assert.Equal(t, fields[i], fieldErr.Field)
+        }
+    }
+}
+
+// TestStablecoinDepegAlert verifies depeg rules watch the most deviated held
+// stablecoin, skip unpriced holdings and stay quiet within the window
+func TestStablecoinDepegAlert(t *testing.T) {
+    t.Parallel()
+
+    assert.True(t, models.IsStablecoin("usdc"))
+    assert.False(t, models.IsStablecoin("BTC"))
+
+    rule := &models.AlertRule{Kind: models.AlertStablecoinDepeg, Threshold: decimal.NewFromInt(2), Enabled: true}
+    require.NoError(t, rule.Validate())
+    assert.Equal(t, models.DEFAULT_ALERT_WINDOW, rule.Window)
+
+    now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+    obs := models.AlertObservation{
+        Current: models.ValuePoint{Timestamp: now},
+        Assets: []models.AssetSnapshot{
+            {Symbol: "BTC", Amount: decimal.NewFromInt(1), Value: decimal.NewFromInt(30000)},
+            {Symbol: "USDC", Amount: decimal.NewFromInt(1000), Value: decimal.NewFromInt(995)},
+            {Symbol: "DAI", Amount: decimal.NewFromInt(200), Value: decimal.NewFromInt(194)},
+            {Symbol: "USDT", Amount: decimal.NewFromInt(500), Value: decimal.Zero},
+        },
+    }
+
+    symbol, deviation, ok := models.LargestPegDeviation(obs.Assets)
+    require.True(t, ok)
+    assert.Equal(t, "DAI", symbol)
+    assert.Equal(t, "3", deviation.String())
+
+    observed, triggered := rule.Evaluate(obs)
+    assert.True(t, triggered)
+    assert.Equal(t, "3", observed.String())
+
+    rule.LastTriggeredAt = &now
+    obs.Current.Timestamp = now.Add(time.Hour)
+    _, triggered = rule.Evaluate(obs)
+    assert.False(t, triggered, "quiet within window")
+
+    obs.Current.Timestamp = now.Add(25 * time.Hour)
+    obs.Assets[2].Value = decimal.NewFromInt(199)
+    observed, triggered = rule.Evaluate(obs)
+    assert.False(t, triggered, "back within the threshold")
+    assert.Equal(t, "0.5", observed.String())
+
+    _, _, ok = models.LargestPegDeviation(obs.Assets[:1])
+    assert.False(t, ok, "no stablecoin held")
+}
@@ -0,0 +1,229 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestBuildAllocation verifies value grouping by symbol, type and category
+func TestBuildAllocation(t *testing.T) {
+    t.Parallel()
+
+    asset := func(assetType, symbol, value string) models.Asset {
+        return models.Asset{
+            ID:           uuid.New(),
+            Type:         assetType,
+            Symbol:       symbol,
+            Amount:       decimal.NewFromInt(1),
+            CurrentValue: decimal.RequireFromString(value),
+        }
+    }
+
+    portfolioID := uuid.New()
+    allocation := models.BuildAllocation(portfolioID, []models.Asset{
+        asset("cryptocurrency", "BTC", "500"),
+        asset("staked_asset", "ETH", "150"),
+        asset("cryptocurrency", "ETH", "100"),
+        asset("token", "USDC", "200"),
+        asset("nft", "PUNK", "50"),
+        asset("token", "DOGE", "0"),
+    })
+
+    assert.Equal(t, portfolioID, allocation.PortfolioID)
+    assert.Equal(t, models.BASE_CURRENCY, allocation.BaseCurrency)
+    assert.True(t, decimal.NewFromInt(1000).Equal(allocation.TotalValue))
+
+    require.Len(t, allocation.BySymbol, 4)
+    assert.Equal(t, "BTC", allocation.BySymbol[0].Key)
+    assert.Equal(t, "ETH", allocation.BySymbol[1].Key)
+    assert.True(t, decimal.NewFromInt(25).Equal(allocation.BySymbol[1].Percentage))
+
+    require.Len(t, allocation.ByType, 4)
+    assert.Equal(t, "cryptocurrency", allocation.ByType[0].Key)
+    assert.True(t, decimal.NewFromInt(60).Equal(allocation.ByType[0].Percentage))
+
+    categories := make(map[string]string)
+    for _, s := range allocation.ByCategory {
+        categories[s.Key] = s.Percentage.String()
+    }
+    assert.Equal(t, map[string]string{
+        models.CategoryLayer1:     "75",
+        models.CategoryStablecoin: "20",
+        models.CategoryNFT:        "5",
+    }, categories)
+    assert.False(t, allocation.PricesStale, "freshness not tracked")
+    assert.Nil(t, allocation.BySymbol[0].PriceAsOf)
+}
+
+// TestAllocationPriceFreshness verifies slices report the oldest price of
+// their assets and whether any is stale
+func TestAllocationPriceFreshness(t *testing.T) {
+    t.Parallel()
+
+    fresh := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+    old := fresh.Add(-time.Hour)
+    asset := func(assetType, symbol string, asOf time.Time, stale bool) models.Asset {
+        return models.Asset{
+            Type:         assetType,
+            Symbol:       symbol,
+            CurrentValue: decimal.NewFromInt(100),
+            PriceAsOf:    &asOf,
+            PriceStale:   stale,
+        }
+    }
+
+    allocation := models.BuildAllocation(uuid.New(), []models.Asset{
+        asset("cryptocurrency", "BTC", fresh, false),
+        asset("cryptocurrency", "SOL", old, true),
+        asset("token", "USDC", fresh, false),
+    })
+
+    assert.True(t, allocation.PricesStale)
+    for _, slice := range allocation.BySymbol {
+        require.NotNil(t, slice.PriceAsOf)
+        assert.Equal(t, slice.Key == "SOL", slice.PriceStale, slice.Key)
+    }
+    for _, slice := range allocation.ByType {
+        if slice.Key == "cryptocurrency" {
+            assert.Equal(t, old, *slice.PriceAsOf, "oldest price of the group")
+            assert.True(t, slice.PriceStale)
+        } else {
+            assert.Equal(t, fresh, *slice.PriceAsOf)
+            assert.False(t, slice.PriceStale)
+        }
+    }
+}
+
+// TestSplitDust verifies that holdings below the dust threshold are split off
+func TestSplitDust(t *testing.T) {
+    t.Parallel()
+
+    asset := func(symbol, value string) models.Asset {
+        return models.Asset{
+            ID:           uuid.New(),
+            Type:         "cryptocurrency",
+            Symbol:       symbol,
+            Amount:       decimal.NewFromInt(1),
+            CurrentValue: decimal.RequireFromString(value),
+        }
+    }
+    assets := []models.Asset{
+        asset("BTC", "500"),
+        asset("SHIB", "0.40"),
+        asset("ETH", "1"),
+        asset("DOGE", "0"),
+    }
+
+    kept, dust, value := models.SplitDust(assets, models.DustSettings{})
+    assert.Len(t, kept, 4)
+    assert.Empty(t, dust)
+    assert.True(t, value.IsZero())
+
+    settings := models.DustSettings{Threshold: decimal.NewFromInt(1)}
+    require.NoError(t, settings.Validate())
+    kept, dust, value = models.SplitDust(assets, settings)
+    require.Len(t, kept, 2)
+    assert.Equal(t, "BTC", kept[0].Symbol)
+    assert.Equal(t, "ETH", kept[1].Symbol)
+    require.Len(t, dust, 2)
+    assert.True(t, decimal.RequireFromString("0.4").Equal(value))
+
+    allocation := models.BuildAllocation(uuid.New(), kept)
+    require.Len(t, allocation.BySymbol, 2)
+    assert.True(t, decimal.NewFromInt(501).Equal(allocation.TotalValue))
+
+    settings.Threshold = decimal.NewFromInt(-1)
+    assert.ErrorIs(t, settings.Validate(), models.ErrInvalidDustThreshold)
+}
+
+// TestBuildUserSummary verifies portfolios are aggregated into one valuation
+// and allocation, leaving out each portfolio's own dust
+func TestBuildUserSummary(t *testing.T) {
+    t.Parallel()
+
+    asset := func(symbol, value, costBasis string) models.Asset {
+        return models.Asset{
+            ID:           uuid.New(),
+            Type:         "cryptocurrency",
+            Symbol:       symbol,
+            Amount:       decimal.NewFromInt(1),
+            CostBasis:    decimal.RequireFromString(costBasis),
+            CurrentValue: decimal.RequireFromString(value),
+        }
+    }
+
+    userID := uuid.New()
+    trading := models.PortfolioHoldings{
+        PortfolioID: uuid.New(),
+        Name:        "Trading",
+        Dust:        models.DustSettings{Threshold: decimal.NewFromInt(5)},
+        Assets:      []models.Asset{asset("ETH", "200", "250"), asset("SHIB", "2", "10")},
+    }
+    longTerm := models.PortfolioHoldings{
+        PortfolioID: uuid.New(),
+        Name:        "Long term",
+        Assets:      []models.Asset{asset("BTC", "600", "300"), asset("ETH", "198", "140")},
+    }
+    empty := models.PortfolioHoldings{PortfolioID: uuid.New(), Name: "Empty"}
+
+    summary := models.BuildUserSummary(userID, []models.PortfolioHoldings{trading, empty, longTerm})
+
+    assert.Equal(t, userID, summary.UserID)
+    assert.True(t, decimal.NewFromInt(1000).Equal(summary.TotalValue))
+    assert.True(t, decimal.NewFromInt(700).Equal(summary.CostBasis))
+    assert.True(t, decimal.NewFromInt(300).Equal(summary.ProfitLoss))
+    assert.Equal(t, "42.86", summary.ProfitLossPercentage.String())
+
+    require.Len(t, summary.Portfolios, 3)
+    assert.Equal(t, longTerm.PortfolioID, summary.Portfolios[0].PortfolioID)
+    assert.True(t, decimal.NewFromInt(358).Equal(summary.Portfolios[0].ProfitLoss))
+    assert.Equal(t, "79.8", summary.Portfolios[0].Percentage.String())
+    assert.Equal(t, "Trading", summary.Portfolios[1].Name)
+    assert.True(t, decimal.NewFromInt(-58).Equal(summary.Portfolios[1].ProfitLoss))
+    assert.True(t, summary.Portfolios[2].TotalValue.IsZero())
+
+    require.NotNil(t, summary.Allocation)
+    assert.Equal(t, uuid.Nil, summary.Allocation.PortfolioID)
+    assert.True(t, decimal.NewFromInt(998).Equal(summary.Allocation.TotalValue))
+    assert.True(t, decimal.NewFromInt(2).Equal(summary.Allocation.DustValue))
+    require.Len(t, summary.Allocation.BySymbol, 2)
+    assert.Equal(t, "BTC", summary.Allocation.BySymbol[0].Key)
+    assert.Equal(t, "ETH", summary.Allocation.BySymbol[1].Key)
+    assert.True(t, decimal.NewFromInt(398).Equal(summary.Allocation.BySymbol[1].Value))
+}
+
+// TestNewAssetSearchResult verifies holdings of a symbol are totalled and
+// ordered by value
+func TestNewAssetSearchResult(t *testing.T) {
+    t.Parallel()
+
+    low, _ := uuid.Parse("00000000-0000-0000-0000-000000000001")
+    high, _ := uuid.Parse("00000000-0000-0000-0000-000000000002")
+    holdings := []models.AssetHolding{
+        {PortfolioID: high, Symbol: "ETH", Amount: decimal.NewFromInt(1), CurrentValue: decimal.NewFromInt(2000)},
+        {PortfolioID: uuid.New(), Symbol: "ETH", Amount: decimal.NewFromInt(3), CurrentValue: decimal.NewFromInt(6000), Archived: true},
+        {PortfolioID: low, Symbol: "ETH", Amount: decimal.RequireFromString("0.5"), CurrentValue: decimal.NewFromInt(2000)},
+    }
+
+    result := models.NewAssetSearchResult("ETH", holdings)
+
+    assert.Equal(t, "ETH", result.Symbol)
+    assert.True(t, decimal.RequireFromString("4.5").Equal(result.TotalAmount))
+    assert.True(t, decimal.NewFromInt(10000).Equal(result.TotalValue))
+    require.Len(t, result.Holdings, 3)
+    assert.True(t, result.Holdings[0].Archived)
+    assert.Equal(t, low, result.Holdings[1].PortfolioID)
+    assert.Equal(t, high, result.Holdings[2].PortfolioID)
+    assert.Equal(t, high, holdings[0].PortfolioID, "input must not be reordered")
+
+    empty := models.NewAssetSearchResult("BTC", nil)
+    assert.Empty(t, empty.Holdings)
+    assert.True(t, empty.TotalValue.IsZero())
+}
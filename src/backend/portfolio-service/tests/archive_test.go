@@ -0,0 +1,215 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "sort"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/archive"
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestArchiveParquetRoundTrip verifies archived transactions decode losslessly
+func TestArchiveParquetRoundTrip(t *testing.T) {
+    t.Parallel()
+
+    portfolioID := uuid.New()
+    txs := []models.Transaction{
+        {
+            ID:          uuid.New(),
+            PortfolioID: portfolioID,
+            AssetID:     uuid.New(),
+            Type:        "buy",
+            Amount:      decimal.RequireFromString("1.123456789"),
+            Price:       decimal.RequireFromString("30123.45"),
+            Fee:         decimal.RequireFromString("0.5"),
+            Timestamp:   time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC),
+        },
+        {
+            ID:          uuid.New(),
+            PortfolioID: portfolioID,
+            AssetID:     uuid.New(),
+            Type:        "reward",
+            Amount:      decimal.RequireFromString("0.0001"),
+            Price:       decimal.Zero,
+            Fee:         decimal.Zero,
+            Timestamp:   time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC),
+        },
+    }
+
+    data, err := archive.EncodeTransactions(txs)
+    require.NoError(t, err)
+
+    decoded, err := archive.DecodeTransactions(data)
+    require.NoError(t, err)
+    require.Len(t, decoded, len(txs))
+
+    for i := range txs {
+        assert.Equal(t, txs[i].ID, decoded[i].ID)
+        assert.Equal(t, txs[i].Type, decoded[i].Type)
+        assert.True(t, txs[i].Amount.Equal(decoded[i].Amount))
+        assert.True(t, txs[i].Price.Equal(decoded[i].Price))
+        assert.True(t, txs[i].Timestamp.Equal(decoded[i].Timestamp))
+    }
+}
+
+// TestSummarizeLots verifies average cost summary lots of archived history
+func TestSummarizeLots(t *testing.T) {
+    t.Parallel()
+
+    archiveID, portfolioID, assetID := uuid.New(), uuid.New(), uuid.New()
+    base := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+    cutoff := base.AddDate(1, 0, 0)
+
+    txs := []models.Transaction{
+        {AssetID: assetID, Type: "sell", Amount: decimal.NewFromInt(1), Price: decimal.NewFromInt(150), Timestamp: base.Add(48 * time.Hour)},
+        {AssetID: assetID, Type: "buy", Amount: decimal.NewFromInt(2), Price: decimal.NewFromInt(100), Fee: decimal.NewFromInt(10), Timestamp: base},
+        {AssetID: assetID, Type: "reward", Amount: decimal.NewFromInt(1), Price: decimal.NewFromInt(90), Timestamp: base.Add(24 * time.Hour)},
+    }
+
+    lots := archive.SummarizeLots(archiveID, portfolioID, txs, cutoff)
+    require.Len(t, lots, 1)
+
+    lot := lots[0]
+    assert.Equal(t, archiveID, lot.ArchiveID)
+    assert.Equal(t, 3, lot.TransactionCount)
+    assert.True(t, lot.Quantity.Equal(decimal.NewFromInt(2)), lot.Quantity.String())
+    // (2*100 + 10 + 90) cost for 3 units, one unit sold at average cost
+    assert.True(t, lot.CostBasis.Equal(decimal.NewFromInt(200)), lot.CostBasis.String())
+    assert.True(t, lot.TotalFees.Equal(decimal.NewFromInt(10)))
+    assert.Equal(t, cutoff, lot.AcquiredBefore)
+}
+
+// fakeObjectStore keeps archive objects in memory and fails deletions of the
+// keys in failDelete
+type fakeObjectStore struct {
+    mutex      sync.Mutex
+    objects    map[string][]byte
+    failDelete map[string]bool
+}
+
+func (s *fakeObjectStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+    s.objects[key] = data
+    return nil
+}
+
+func (s *fakeObjectStore) Get(ctx context.Context, key string) ([]byte, error) {
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+    return s.objects[key], nil
+}
+
+func (s *fakeObjectStore) Delete(ctx context.Context, key string) error {
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+    if s.failDelete[key] {
+        return errors.New("object store unavailable")
+    }
+    delete(s.objects, key)
+    return nil
+}
+
+// keys returns the keys of the stored objects in order
+func (s *fakeObjectStore) keys() []string {
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+    keys := make([]string, 0, len(s.objects))
+    for key := range s.objects {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    return keys
+}
+
+// fakePendingDeletions keeps pending archive object deletions in memory
+type fakePendingDeletions struct {
+    mutex sync.Mutex
+    keys  map[string]bool
+}
+
+func (p *fakePendingDeletions) ListArchiveObjectDeletions(ctx context.Context, limit int) ([]string, error) {
+    p.mutex.Lock()
+    defer p.mutex.Unlock()
+    keys := make([]string, 0, len(p.keys))
+    for key := range p.keys {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    if len(keys) > limit {
+        keys = keys[:limit]
+    }
+    return keys, nil
+}
+
+func (p *fakePendingDeletions) DeleteArchiveObjectDeletions(ctx context.Context, keys []string) error {
+    p.mutex.Lock()
+    defer p.mutex.Unlock()
+    for _, key := range keys {
+        delete(p.keys, key)
+    }
+    return nil
+}
+
+// TestArchivePurgeOrdering verifies archive objects are only deleted once the
+// purge transaction committed, and that objects failing to delete stay
+// pending until a retry deletes them
+func TestArchivePurgeOrdering(t *testing.T) {
+    t.Parallel()
+
+    ctx := context.Background()
+    store := &fakeObjectStore{
+        objects: map[string][]byte{
+            "archive/p1/a.parquet": []byte("a"),
+            "archive/p1/b.parquet": []byte("b"),
+        },
+        failDelete: map[string]bool{},
+    }
+    pending := &fakePendingDeletions{keys: map[string]bool{}}
+    keys := store.keys()
+
+    // A failed transaction leaves every object in place, so the purge can
+    // be retried
+    erased, err := archive.PurgeObjects(ctx, store, pending, func(ctx context.Context) ([]string, error) {
+        assert.Len(t, store.keys(), 2, "objects must not be deleted before the transaction commits")
+        return nil, errors.New("serialization failure")
+    })
+    require.Error(t, err)
+    assert.NotErrorIs(t, err, archive.ErrDeletionPending)
+    assert.Zero(t, erased)
+    assert.Equal(t, keys, store.keys())
+
+    // A committed transaction records the pending deletions; an object that
+    // fails to delete stays pending
+    store.failDelete["archive/p1/b.parquet"] = true
+    erased, err = archive.PurgeObjects(ctx, store, pending, func(ctx context.Context) ([]string, error) {
+        assert.Len(t, store.keys(), 2, "objects must not be deleted before the transaction commits")
+        for _, key := range keys {
+            pending.keys[key] = true
+        }
+        return keys, nil
+    })
+    assert.ErrorIs(t, err, archive.ErrDeletionPending)
+    assert.Equal(t, 2, erased)
+    assert.Equal(t, []string{"archive/p1/b.parquet"}, store.keys())
+    retry, err := pending.ListArchiveObjectDeletions(ctx, 10)
+    require.NoError(t, err)
+    assert.Equal(t, []string{"archive/p1/b.parquet"}, retry)
+
+    // The archiver's retry deletes it once the store recovers
+    store.failDelete = map[string]bool{}
+    deleted, err := archive.DeleteObjects(ctx, store, pending, retry)
+    require.NoError(t, err)
+    assert.Equal(t, 1, deleted)
+    assert.Empty(t, store.keys())
+    assert.Empty(t, pending.keys)
+}
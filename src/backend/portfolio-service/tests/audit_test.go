@@ -0,0 +1,112 @@
+package tests
+
+import (
+    "context"
+    "database/sql/driver"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"              // v1.3.0
+    "github.com/stretchr/testify/assert"  // v1.8.0
+    "github.com/stretchr/testify/require" // v1.8.0
+    "go.uber.org/zap"                     // v1.24.0
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    "bookman/portfolio-service/internal/buildinfo"
+    "bookman/portfolio-service/internal/handlers"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/services"
+)
+
+// TestListAuditEntriesPaging verifies the audit trail of a portfolio is paged
+// newest first with page tokens resuming after the last entry's change time
+// and ID, and tokens are bound to the listing's filters
+func TestListAuditEntriesPaging(t *testing.T) {
+    t.Parallel()
+
+    ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+    defer cancel()
+
+    portfolioID, userID := uuid.New(), uuid.New()
+    changed := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+    row := func(id int64, at time.Time, operation string) []driver.Value {
+        return []driver.Value{
+            id, "portfolio_assets", operation, nil, []byte(`{"symbol": "BTC"}`),
+            userID.String(), at, nil, nil,
+        }
+    }
+
+    repo, db := newScriptedRepository(t, map[string]scriptedResult{
+        "FROM audit_trail": {
+            columns: []string{
+                "id", "table_name", "operation", "old_data", "new_data",
+                "changed_by", "changed_at", "host", "user_agent",
+            },
+            rows: [][]driver.Value{
+                row(30, changed, "UPDATE"),
+                row(29, changed, "INSERT"),
+                row(12, changed.Add(-time.Hour), "INSERT"),
+            },
+        },
+    })
+    svc, err := services.NewPortfolioService(repo, zap.NewNop())
+    require.NoError(t, err)
+    handler, err := handlers.NewPortfolioHandler(svc, buildinfo.Info{}, zap.NewNop())
+    require.NoError(t, err)
+
+    // auditQueries returns the arguments of the audit trail queries so far
+    auditQueries := func() [][]driver.Value {
+        var args [][]driver.Value
+        for _, call := range db.recorded() {
+            if strings.Contains(call.query, "FROM audit_trail") {
+                args = append(args, call.args)
+            }
+        }
+        return args
+    }
+
+    resp, err := handler.ListAuditEntries(ctx, &models.ListAuditEntriesRequest{PortfolioId: portfolioID.String(), PageSize: 2})
+    require.NoError(t, err)
+    require.Len(t, resp.Entries, 2)
+    assert.Equal(t, int64(30), resp.Entries[0].EntryId)
+    assert.Equal(t, "UPDATE", resp.Entries[0].Operation)
+    assert.Equal(t, `{"symbol": "BTC"}`, resp.Entries[0].NewData)
+    assert.Empty(t, resp.Entries[0].OldData)
+    assert.Equal(t, userID.String(), resp.Entries[0].ChangedBy)
+    assert.Equal(t, int64(29), resp.Entries[1].EntryId)
+    require.NotEmpty(t, resp.NextPageToken)
+
+    queries := auditQueries()
+    require.Len(t, queries, 1)
+    assert.Equal(t, portfolioID.String(), queries[0][0])
+    assert.WithinDuration(t, time.Now(), queries[0][1].(time.Time), time.Minute, "starts from now")
+    assert.Equal(t, int64(0), queries[0][2])
+    assert.Equal(t, int64(3), queries[0][3], "reads one entry past the page")
+
+    _, err = handler.ListAuditEntries(ctx, &models.ListAuditEntriesRequest{
+        PortfolioId: portfolioID.String(), PageSize: 2, PageToken: resp.NextPageToken,
+    })
+    require.NoError(t, err)
+    queries = auditQueries()
+    require.Len(t, queries, 2)
+    assert.True(t, changed.Equal(queries[1][1].(time.Time)), "resumes at the last entry's change time")
+    assert.Equal(t, int64(29), queries[1][2], "and ID")
+
+    _, err = handler.ListAuditEntries(ctx, &models.ListAuditEntriesRequest{
+        PortfolioId: portfolioID.String(), PageSize: 2, PageToken: resp.NextPageToken,
+        Before: timestamppb.New(changed),
+    })
+    assert.Equal(t, codes.InvalidArgument, status.Code(err), "token issued for other filters")
+
+    _, err = handler.ListAuditEntries(ctx, &models.ListAuditEntriesRequest{
+        PortfolioId: uuid.New().String(), PageToken: resp.NextPageToken,
+    })
+    assert.Equal(t, codes.InvalidArgument, status.Code(err), "token issued for another portfolio")
+
+    _, err = handler.ListAuditEntries(ctx, &models.ListAuditEntriesRequest{PortfolioId: "not-a-uuid"})
+    assert.Equal(t, codes.InvalidArgument, status.Code(err))
+    assert.Len(t, auditQueries(), 2)
+}
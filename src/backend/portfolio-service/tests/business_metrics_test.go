@@ -0,0 +1,35 @@
+package tests
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"    // v1.8.0
+
+    "bookman/portfolio-service/internal/businessmetrics"
+)
+
+// TestValueBucketLabels verifies value buckets are labelled by their lower
+// bound, starting at zero
+func TestValueBucketLabels(t *testing.T) {
+    t.Parallel()
+
+    assert.Equal(t, []string{"0", "100", "1000", "2500.5"}, businessmetrics.ValueBucketLabels([]float64{100, 1000, 2500.5}))
+    assert.Equal(t, []string{"0"}, businessmetrics.ValueBucketLabels(nil))
+}
+
+// TestConnectorLabels verifies syncs of connectors that are not registered
+// share one label, bounding the cardinality of sync metrics
+func TestConnectorLabels(t *testing.T) {
+    t.Parallel()
+
+    connectors := businessmetrics.NewConnectors(map[string][]string{
+        "exchange": {"binance", "kraken"},
+        "wallet":   {"ethereum"},
+    })
+
+    assert.Equal(t, "binance", connectors.Label("exchange", "binance"))
+    assert.Equal(t, "ethereum", connectors.Label("wallet", "ethereum"))
+    assert.Equal(t, businessmetrics.OtherConnector, connectors.Label("exchange", "coinbase"))
+    assert.Equal(t, businessmetrics.OtherConnector, connectors.Label("wallet", "binance"), "connectors are registered per source")
+    assert.Equal(t, businessmetrics.OtherConnector, connectors.Label("staking", "ethereum"))
+}
@@ -0,0 +1,351 @@
+package tests
+
+import (
+    "context"
+    "database/sql"
+    "database/sql/driver"
+    "errors"
+    "fmt"
+    "os"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"              // v1.3.0
+    "github.com/shopspring/decimal"       // v1.3.1
+    "github.com/stretchr/testify/assert"  // v1.8.0
+    "github.com/stretchr/testify/require" // v1.8.0
+    "go.uber.org/zap"                     // v1.24.0
+
+    "bookman/portfolio-service/internal/buildinfo"
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/handlers"
+    "bookman/portfolio-service/internal/migrate"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/pagination"
+    "bookman/portfolio-service/internal/repository"
+    "bookman/portfolio-service/internal/services"
+    "bookman/portfolio-service/internal/snapshot"
+)
+
+// changesMigration is the migration defining the portfolio change columns
+// and the functions maintaining them
+const changesMigration = "../../db/migrations/010_portfolio_changes.sql"
+
+// newScriptedRepository creates a repository over a scriptedDB answering
+// from script
+func newScriptedRepository(t *testing.T, script map[string]scriptedResult) (*repository.PostgresRepository, *scriptedDB) {
+    t.Helper()
+
+    ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+    defer cancel()
+
+    db := &scriptedDB{script: script}
+    repo, err := repository.NewPostgresRepositoryWithDB(ctx, sql.OpenDB(db), &config.Config{}, zap.NewNop())
+    require.NoError(t, err)
+    t.Cleanup(func() { repo.Close() })
+    return repo, db
+}
+
+// fakeChangeRefresher pages through portfolios and records the time each
+// was refreshed as of, failing the portfolios in failing
+type fakeChangeRefresher struct {
+    *fakePortfolioPages
+    mutex     sync.Mutex
+    failing   map[uuid.UUID]bool
+    refreshed map[uuid.UUID][]time.Time
+}
+
+func (r *fakeChangeRefresher) RefreshPortfolioChanges(ctx context.Context, portfolioID uuid.UUID, asOf time.Time) error {
+    r.mutex.Lock()
+    defer r.mutex.Unlock()
+
+    if r.failing[portfolioID] {
+        return errors.New("statement timeout")
+    }
+    r.refreshed[portfolioID] = append(r.refreshed[portfolioID], asOf)
+    return nil
+}
+
+// TestBackfillChanges verifies the backfill refreshes every portfolio page
+// by page as of one time, can be run again, and stops at the first failure
+func TestBackfillChanges(t *testing.T) {
+    t.Parallel()
+
+    ids := sortedPortfolioIDs(5)
+    repo := &fakeChangeRefresher{
+        fakePortfolioPages: &fakePortfolioPages{ids: ids},
+        refreshed:          make(map[uuid.UUID][]time.Time),
+    }
+
+    require.NoError(t, snapshot.BackfillChanges(context.Background(), repo, 2, zap.NewNop()))
+    require.Len(t, repo.refreshed, len(ids))
+    asOf := repo.refreshed[ids[0]][0]
+    for _, id := range ids {
+        assert.Equal(t, []time.Time{asOf}, repo.refreshed[id], "refreshed once as of the backfill start")
+    }
+    assert.Equal(t, []uuid.UUID{uuid.Nil, ids[1], ids[3]}, repo.cursors)
+
+    require.NoError(t, snapshot.BackfillChanges(context.Background(), repo, 2, zap.NewNop()))
+    for _, id := range ids {
+        require.Len(t, repo.refreshed[id], 2, "a second run refreshes every portfolio again")
+        assert.False(t, repo.refreshed[id][1].Before(asOf))
+    }
+
+    failing := &fakeChangeRefresher{
+        fakePortfolioPages: &fakePortfolioPages{ids: ids},
+        failing:            map[uuid.UUID]bool{ids[1]: true},
+        refreshed:          make(map[uuid.UUID][]time.Time),
+    }
+    err := snapshot.BackfillChanges(context.Background(), failing, 2, zap.NewNop())
+    require.Error(t, err)
+    assert.Contains(t, err.Error(), ids[1].String())
+    assert.Len(t, failing.refreshed, 1, "portfolios after the failure are not refreshed")
+
+    listErr := errors.New("connection refused")
+    err = snapshot.BackfillChanges(context.Background(), &fakeChangeRefresher{
+        fakePortfolioPages: &fakePortfolioPages{err: listErr},
+    }, 2, zap.NewNop())
+    assert.ErrorIs(t, err, listErr)
+}
+
+// TestCreateSnapshotRefreshesChanges verifies change columns are refreshed in
+// the snapshot's transaction for the snapshot just inserted, and not when the
+// slot already held a snapshot
+func TestCreateSnapshotRefreshesChanges(t *testing.T) {
+    t.Parallel()
+
+    ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+    defer cancel()
+
+    snap := &models.PortfolioSnapshot{
+        ID:          uuid.New(),
+        PortfolioID: uuid.New(),
+        TotalValue:  decimal.RequireFromString("1250.50"),
+        ProfitLoss:  decimal.RequireFromString("250.50"),
+        CapturedAt:  time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC),
+    }
+
+    repo, db := newScriptedRepository(t, map[string]scriptedResult{
+        "INSERT INTO portfolio_snapshots": {rowsAffected: 1},
+    })
+    require.NoError(t, repo.CreateSnapshot(ctx, snap))
+
+    calls := db.recorded()
+    require.Len(t, calls, 4)
+    assert.Equal(t, "BEGIN", calls[0].query)
+    assert.Contains(t, calls[1].query, "INSERT INTO portfolio_snapshots")
+    assert.Contains(t, calls[2].query, "refresh_portfolio_changes")
+    assert.Equal(t, []driver.Value{snap.PortfolioID.String(), snap.CapturedAt}, calls[2].args)
+    assert.Equal(t, "COMMIT", calls[3].query)
+
+    // A duplicate of an existing slot inserts nothing and refreshes nothing
+    repo, db = newScriptedRepository(t, map[string]scriptedResult{
+        "INSERT INTO portfolio_snapshots": {rowsAffected: 0},
+    })
+    require.NoError(t, repo.CreateSnapshot(ctx, snap))
+
+    calls = db.recorded()
+    require.Len(t, calls, 3)
+    assert.Contains(t, calls[1].query, "INSERT INTO portfolio_snapshots")
+    assert.Equal(t, "COMMIT", calls[2].query)
+}
+
+// TestListPortfoliosChanges verifies listed portfolios carry stored changes,
+// and changes without a baseline stay unset in responses rather than 0
+func TestListPortfoliosChanges(t *testing.T) {
+    t.Parallel()
+
+    ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+    defer cancel()
+
+    userID := uuid.New()
+    created := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+    row := func(id uuid.UUID, day, week, month interface{}) []driver.Value {
+        return []driver.Value{
+            id.String(), userID.String(), "Core", "", "1000.00", "100.00", created, created,
+            day, week, month, nil,
+        }
+    }
+    tracked, fresh, flat := uuid.New(), uuid.New(), uuid.New()
+
+    repo, _ := newScriptedRepository(t, map[string]scriptedResult{
+        "FROM portfolios p": {
+            columns: []string{
+                "id", "user_id", "name", "description", "total_value", "profit_loss", "created_at", "updated_at",
+                "change_24h", "change_7d", "change_30d", "deleted_at",
+            },
+            rows: [][]driver.Value{
+                row(tracked, "12.5000", "-3.2500", "40.0000"),
+                row(fresh, nil, nil, nil),
+                row(flat, "0.0000", nil, nil),
+            },
+        },
+    })
+
+    portfolios, err := repo.ListPortfolios(ctx, userID, pagination.Position{}, 10, repository.PortfolioFilter{}, repository.PortfolioOrder{})
+    require.NoError(t, err)
+    require.Len(t, portfolios, 3)
+    assert.True(t, portfolios[0].Change24h.Valid)
+    assert.True(t, decimal.RequireFromString("12.5").Equal(portfolios[0].Change24h.Decimal))
+    assert.True(t, decimal.RequireFromString("-3.25").Equal(portfolios[0].Change7d.Decimal))
+    assert.True(t, decimal.RequireFromString("40").Equal(portfolios[0].Change30d.Decimal))
+    assert.False(t, portfolios[1].Change24h.Valid)
+    assert.False(t, portfolios[1].Change7d.Valid)
+    assert.False(t, portfolios[1].Change30d.Valid)
+    assert.Nil(t, portfolios[0].ArchivedAt)
+
+    svc, err := services.NewPortfolioService(repo, zap.NewNop())
+    require.NoError(t, err)
+    handler, err := handlers.NewPortfolioHandler(svc, buildinfo.Info{}, zap.NewNop())
+    require.NoError(t, err)
+
+    resp, err := handler.ListPortfolios(ctx, &models.ListPortfoliosRequest{UserId: userID.String(), PageSize: 10})
+    require.NoError(t, err)
+    require.Len(t, resp.Portfolios, 3)
+
+    require.NotNil(t, resp.Portfolios[0].DayChangePercentage)
+    assert.Equal(t, 12.5, *resp.Portfolios[0].DayChangePercentage)
+    require.NotNil(t, resp.Portfolios[0].WeekChangePercentage)
+    assert.Equal(t, -3.25, *resp.Portfolios[0].WeekChangePercentage)
+
+    assert.Nil(t, resp.Portfolios[1].DayChangePercentage, "no baseline leaves the change unset")
+    assert.Nil(t, resp.Portfolios[1].WeekChangePercentage)
+    assert.Nil(t, resp.Portfolios[1].MonthChangePercentage)
+
+    require.NotNil(t, resp.Portfolios[2].DayChangePercentage, "an unchanged value is 0, not unset")
+    assert.Zero(t, *resp.Portfolios[2].DayChangePercentage)
+    assert.Nil(t, resp.Portfolios[2].WeekChangePercentage)
+}
+
+// TestPortfolioChangeRefresh runs the change functions of the migration
+// against PostgreSQL in a scratch schema. It verifies changes are computed
+// from the latest snapshot at or before each baseline, missing or zero
+// baselines are NULL, older refreshes never overwrite newer changes, and
+// refreshing again is safe. It is skipped unless PORTFOLIO_TEST_DATABASE_URL
+// points at a database the test may create schemas in.
+func TestPortfolioChangeRefresh(t *testing.T) {
+    url := os.Getenv("PORTFOLIO_TEST_DATABASE_URL")
+    if url == "" {
+        t.Skip("PORTFOLIO_TEST_DATABASE_URL is not set")
+    }
+    t.Parallel()
+
+    ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+    defer cancel()
+
+    db, err := sql.Open("postgres", url)
+    require.NoError(t, err)
+    defer db.Close()
+
+    // Pin one connection so the scratch schema stays on its search path
+    conn, err := db.Conn(ctx)
+    require.NoError(t, err)
+    defer conn.Close()
+
+    exec := func(query string, args ...interface{}) {
+        t.Helper()
+        _, err := conn.ExecContext(ctx, query, args...)
+        require.NoError(t, err, query)
+    }
+
+    schema := fmt.Sprintf("portfolio_changes_test_%d", time.Now().UnixNano())
+    exec("CREATE SCHEMA " + schema)
+    defer func() {
+        _, _ = conn.ExecContext(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+    }()
+    exec("SET search_path TO " + schema)
+    exec(`CREATE TABLE portfolios (portfolio_id UUID PRIMARY KEY)`)
+    exec(`CREATE TABLE portfolio_snapshots (
+        portfolio_id UUID NOT NULL REFERENCES portfolios(portfolio_id) ON DELETE CASCADE,
+        total_value DECIMAL(24,8) NOT NULL,
+        captured_at TIMESTAMPTZ NOT NULL,
+        UNIQUE (portfolio_id, captured_at)
+    )`)
+
+    migration, err := os.ReadFile(changesMigration)
+    require.NoError(t, err)
+    for _, statement := range migrate.Split(string(migration)) {
+        exec(statement)
+    }
+
+    portfolio := func(values map[time.Time]string) uuid.UUID {
+        id := uuid.New()
+        exec("INSERT INTO portfolios (portfolio_id) VALUES ($1)", id)
+        for at, value := range values {
+            exec("INSERT INTO portfolio_snapshots (portfolio_id, total_value, captured_at) VALUES ($1, $2, $3)", id, value, at)
+        }
+        return id
+    }
+    refresh := func(id uuid.UUID, asOf time.Time) {
+        exec("SELECT refresh_portfolio_changes($1, $2)", id, asOf)
+    }
+    changes := func(id uuid.UUID) (day, week, month decimal.NullDecimal, asOf sql.NullTime) {
+        err := conn.QueryRowContext(ctx,
+            "SELECT change_24h, change_7d, change_30d, changes_as_of FROM portfolios WHERE portfolio_id = $1", id,
+        ).Scan(&day, &week, &month, &asOf)
+        require.NoError(t, err)
+        return day, week, month, asOf
+    }
+    assertChange := func(want string, got decimal.NullDecimal, msg string) {
+        t.Helper()
+        if assert.True(t, got.Valid, msg) {
+            assert.True(t, decimal.RequireFromString(want).Equal(got.Decimal), "%s: got %s", msg, got.Decimal)
+        }
+    }
+
+    now := time.Now().UTC().Truncate(time.Second)
+    day, week, month := 24*time.Hour, 7*24*time.Hour, 30*24*time.Hour
+
+    tracked := portfolio(map[time.Time]string{
+        now.Add(-month - time.Hour): "100",
+        now.Add(-week):              "200",
+        now.Add(-day - time.Minute): "300",
+        now:                         "400",
+    })
+    refresh(tracked, now)
+    d, w, m, asOf := changes(tracked)
+    assertChange("33.3333", d, "24h against the latest snapshot before the baseline")
+    assertChange("100", w, "7d")
+    assertChange("300", m, "30d")
+    require.True(t, asOf.Valid)
+    assert.True(t, now.Equal(asOf.Time))
+
+    // A backdated snapshot and its refresh leave the newer changes in place
+    exec("INSERT INTO portfolio_snapshots (portfolio_id, total_value, captured_at) VALUES ($1, $2, $3)",
+        tracked, "50", now.Add(-2*day))
+    refresh(tracked, now.Add(-2*day))
+    refresh(tracked, now.Add(-week))
+    d, w, m, asOf = changes(tracked)
+    assertChange("33.3333", d, "24h after a backdated refresh")
+    assertChange("100", w, "7d after a backdated refresh")
+    assertChange("300", m, "30d after a backdated refresh")
+    assert.True(t, now.Equal(asOf.Time))
+
+    // Refreshing as of the same time again, as a re-run backfill does, is
+    // idempotent
+    refresh(tracked, now)
+    d, _, _, asOf = changes(tracked)
+    assertChange("33.3333", d, "24h after a repeated refresh")
+    assert.True(t, now.Equal(asOf.Time))
+
+    // Without a baseline, or with a zero one, the change is NULL
+    fresh := portfolio(map[time.Time]string{now: "500"})
+    refresh(fresh, now)
+    d, w, m, asOf = changes(fresh)
+    assert.False(t, d.Valid)
+    assert.False(t, w.Valid)
+    assert.False(t, m.Valid)
+    assert.True(t, asOf.Valid, "changes are stamped even without baselines")
+
+    funded := portfolio(map[time.Time]string{now.Add(-day): "0", now: "10"})
+    refresh(funded, now)
+    d, _, _, _ = changes(funded)
+    assert.False(t, d.Valid, "a zero baseline has no percentage change")
+
+    // A portfolio without snapshots is left untouched
+    empty := portfolio(nil)
+    refresh(empty, now)
+    _, _, _, asOf = changes(empty)
+    assert.False(t, asOf.Valid)
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"    // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestDataClassificationRegistry verifies every model field is classified and
+// that sensitive fields are encrypted or kept out of logs and exports
+func TestDataClassificationRegistry(t *testing.T) {
+    t.Parallel()
+
+    assert.NoError(t, models.ValidateClassifications())
+}
+
+// TestClassifiedFieldUsage verifies fields written to logs and exports are
+// permitted there by their classification
+func TestClassifiedFieldUsage(t *testing.T) {
+    t.Parallel()
+
+    logged := map[string][]string{
+        "Portfolio": {"ID", "UserID"},
+        "Asset":     {"Symbol"},
+    }
+    for model, fields := range logged {
+        for _, field := range fields {
+            assert.True(t, models.Loggable(model, field), "%s.%s is logged", model, field)
+        }
+    }
+
+    for _, field := range []string{"ID", "Type", "Amount", "Price", "Fee", "Timestamp"} {
+        assert.True(t, models.Exportable("Transaction", field), "Transaction.%s is exported", field)
+    }
+
+    assert.False(t, models.Loggable("Portfolio", "Name"))
+    assert.False(t, models.Exportable("Portfolio", "Description"))
+    assert.False(t, models.Loggable("Portfolio", "Unknown"))
+}
+
+// TestFieldPolicyValidation verifies the rules applied per classification
+func TestFieldPolicyValidation(t *testing.T) {
+    t.Parallel()
+
+    assert.NoError(t, models.FieldPolicy{Class: models.ClassSensitive, Encrypted: true, Exported: true}.Validate())
+    assert.Error(t, models.FieldPolicy{Class: models.ClassSensitive, Logged: true}.Validate())
+    assert.NoError(t, models.FieldPolicy{Class: models.ClassSecret, Encrypted: true}.Validate())
+    assert.Error(t, models.FieldPolicy{Class: models.ClassSecret, Encrypted: true, Exported: true}.Validate())
+    assert.Error(t, models.FieldPolicy{Class: "confidential"}.Validate())
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"                // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestComparePortfolioSnapshots verifies holdings added, removed and held are
+// matched by asset and each contributes its change in unrealized P&L to the
+// portfolio's
+func TestComparePortfolioSnapshots(t *testing.T) {
+    t.Parallel()
+
+    btc, eth, sol := uuid.New(), uuid.New(), uuid.New()
+    from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    to := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+    start := &models.PortfolioSnapshot{
+        TotalValue: decimal.NewFromInt(50000),
+        ProfitLoss: decimal.NewFromInt(10000),
+        Assets: []models.AssetSnapshot{
+            assetSnapshot(btc, "BTC", "1", "30000", "25000"),
+            assetSnapshot(eth, "ETH", "10", "2000", "15000"),
+        },
+        CapturedAt: from.Add(-time.Hour),
+    }
+    end := &models.PortfolioSnapshot{
+        TotalValue: decimal.NewFromInt(70000),
+        ProfitLoss: decimal.NewFromInt(18000),
+        Assets: []models.AssetSnapshot{
+            assetSnapshot(sol, "SOL", "100", "100", "12000"),
+            assetSnapshot(btc, "BTC", "1.5", "40000", "40000"),
+        },
+        CapturedAt: to,
+    }
+
+    comparison, err := models.ComparePortfolioSnapshots(uuid.New(), from, to, start, end, to)
+    require.NoError(t, err)
+
+    assert.Equal(t, from.Add(-time.Hour), comparison.StartCapturedAt)
+    assert.True(t, comparison.ValueDelta.Equal(decimal.NewFromInt(20000)))
+    assert.True(t, comparison.ProfitLossDelta.Equal(decimal.NewFromInt(8000)))
+
+    require.Len(t, comparison.Assets, 3)
+    held, removed, added := comparison.Assets[0], comparison.Assets[1], comparison.Assets[2]
+
+    assert.Equal(t, "BTC", held.Symbol)
+    assert.Equal(t, models.AssetChangeHeld, held.Change)
+    assert.True(t, held.AmountDelta.Equal(decimal.RequireFromString("0.5")))
+    assert.True(t, held.ValueDelta.Equal(decimal.NewFromInt(30000)))
+    assert.True(t, held.ProfitLossContribution.Equal(decimal.NewFromInt(15000)))
+    assert.True(t, held.ContributionPercentage.Equal(decimal.RequireFromString("187.5")))
+
+    assert.Equal(t, "ETH", removed.Symbol)
+    assert.Equal(t, models.AssetChangeRemoved, removed.Change)
+    assert.True(t, removed.AmountDelta.Equal(decimal.NewFromInt(-10)))
+    assert.True(t, removed.EndValue.IsZero())
+    assert.True(t, removed.ProfitLossContribution.Equal(decimal.NewFromInt(-5000)))
+
+    assert.Equal(t, "SOL", added.Symbol)
+    assert.Equal(t, models.AssetChangeAdded, added.Change)
+    assert.True(t, added.StartAmount.IsZero())
+    assert.True(t, added.ProfitLossContribution.Equal(decimal.NewFromInt(-2000)))
+    assert.True(t, added.ContributionPercentage.Equal(decimal.NewFromInt(-25)))
+
+    total := decimal.Zero
+    for _, a := range comparison.Assets {
+        total = total.Add(a.ProfitLossContribution)
+    }
+    assert.True(t, total.Equal(comparison.ProfitLossDelta))
+}
+
+// TestComparePortfolioSnapshotsWithoutStart verifies a portfolio without a
+// valuation by the start of the window compares as empty, and that windows
+// must run forward and end with a valuation
+func TestComparePortfolioSnapshotsWithoutStart(t *testing.T) {
+    t.Parallel()
+
+    btc := uuid.New()
+    from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    to := from.AddDate(0, 1, 0)
+    end := &models.PortfolioSnapshot{
+        TotalValue: decimal.NewFromInt(300),
+        ProfitLoss: decimal.NewFromInt(100),
+        Assets:     []models.AssetSnapshot{assetSnapshot(btc, "BTC", "3", "100", "200")},
+        CapturedAt: to,
+    }
+
+    comparison, err := models.ComparePortfolioSnapshots(uuid.New(), from, to, nil, end, to)
+    require.NoError(t, err)
+    assert.True(t, comparison.StartCapturedAt.IsZero())
+    require.Len(t, comparison.Assets, 1)
+    assert.Equal(t, models.AssetChangeAdded, comparison.Assets[0].Change)
+    assert.True(t, comparison.Assets[0].ContributionPercentage.Equal(decimal.NewFromInt(100)))
+
+    _, err = models.ComparePortfolioSnapshots(uuid.New(), to, from, nil, end, to)
+    assert.ErrorIs(t, err, models.ErrInvalidComparison)
+
+    _, err = models.ComparePortfolioSnapshots(uuid.New(), from, to, nil, nil, to)
+    assert.ErrorIs(t, err, models.ErrInvalidComparison)
+}
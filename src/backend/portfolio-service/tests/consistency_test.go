@@ -0,0 +1,43 @@
+package tests
+
+import (
+    "context"
+    "testing"
+
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/consistency"
+)
+
+// TestConsistencyTokenRoundTrip verifies WAL positions survive token encoding
+func TestConsistencyTokenRoundTrip(t *testing.T) {
+    t.Parallel()
+
+    lsn, err := consistency.ParseLSN("16/B374D848")
+    require.NoError(t, err)
+    assert.Equal(t, uint64(0x16B374D848), lsn)
+
+    decoded, err := consistency.Decode(consistency.Encode(lsn))
+    require.NoError(t, err)
+    assert.Equal(t, lsn, decoded)
+
+    _, err = consistency.Decode("not-a-token")
+    assert.ErrorIs(t, err, consistency.ErrInvalidToken)
+
+    _, err = consistency.ParseLSN("16B374D848")
+    assert.Error(t, err)
+}
+
+// TestConsistencyRequirement verifies requirements are carried on the context
+func TestConsistencyRequirement(t *testing.T) {
+    t.Parallel()
+
+    _, ok := consistency.Requirement(context.Background())
+    assert.False(t, ok)
+    assert.False(t, consistency.Tracking(context.Background()))
+
+    lsn, ok := consistency.Requirement(consistency.WithRequirement(context.Background(), 42))
+    assert.True(t, ok)
+    assert.Equal(t, uint64(42), lsn)
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"               // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestCorporateActionValidation verifies actions are checked and their asset
+// types defaulted
+func TestCorporateActionValidation(t *testing.T) {
+    t.Parallel()
+
+    airdrop := models.CorporateAction{Type: "airdrop", Symbol: " arb ", Amount: decimal.NewFromInt(625)}
+    require.NoError(t, airdrop.Validate())
+    assert.Equal(t, "ARB", airdrop.Symbol)
+    assert.Equal(t, "token", airdrop.AssetType)
+
+    fork := models.CorporateAction{Type: "fork", Symbol: "BCH", Amount: decimal.NewFromInt(2), ParentAssetID: uuid.New()}
+    require.NoError(t, fork.Validate())
+    assert.Equal(t, "cryptocurrency", fork.AssetType)
+
+    invalid := []models.CorporateAction{
+        {Type: "split", Symbol: "BCH", Amount: decimal.NewFromInt(1)},
+        {Type: "fork", Symbol: "BCH", Amount: decimal.NewFromInt(1)},
+        {Type: "airdrop", Symbol: "ARB", Amount: decimal.NewFromInt(1), ParentAssetID: uuid.New()},
+        {Type: "airdrop", Symbol: "ARB", Amount: decimal.Zero},
+        {Type: "airdrop", Symbol: "", Amount: decimal.NewFromInt(1)},
+        {Type: "airdrop", Symbol: "ARB", Amount: decimal.NewFromInt(1), Price: decimal.NewFromInt(-1)},
+        {Type: "airdrop", AssetType: "staked_asset", Symbol: "ARB", Amount: decimal.NewFromInt(1)},
+        {Type: "fork", Symbol: "BCH", Amount: decimal.RequireFromString("0.000000001"), ParentAssetID: uuid.New()},
+    }
+    for _, action := range invalid {
+        assert.ErrorIs(t, action.Validate(), models.ErrInvalidCorporateAction, "%+v", action)
+    }
+}
+
+// TestCorporateActionCostBasis verifies the zero and allocated policies
+func TestCorporateActionCostBasis(t *testing.T) {
+    t.Parallel()
+
+    airdrop := models.CorporateAction{Type: "airdrop", Symbol: "ARB", Amount: decimal.NewFromInt(625), Price: decimal.RequireFromString("1.2")}
+
+    cost, _, err := models.CorporateActionCostBasis(airdrop, "zero", nil)
+    require.NoError(t, err)
+    assert.True(t, cost.IsZero())
+
+    cost, _, err = models.CorporateActionCostBasis(airdrop, "allocated", nil)
+    require.NoError(t, err)
+    assert.Equal(t, "750", cost.String(), "airdrops cost their value when received")
+
+    // 2 BTC bought for 30000 fork into 2 BCH; BCH is worth 10% of the pair
+    parent := &models.Asset{ID: uuid.New(), Symbol: "BTC", Amount: decimal.NewFromInt(2), CostBasis: decimal.NewFromInt(30000)}
+    fork := models.CorporateAction{
+        Type:          "fork",
+        Symbol:        "BCH",
+        Amount:        decimal.NewFromInt(2),
+        Price:         decimal.NewFromInt(300),
+        ParentAssetID: parent.ID,
+        ParentPrice:   decimal.NewFromInt(2700),
+    }
+
+    cost, parentCost, err := models.CorporateActionCostBasis(fork, "allocated", parent)
+    require.NoError(t, err)
+    assert.Equal(t, "3000", cost.String())
+    assert.Equal(t, "27000", parentCost.String())
+    assert.True(t, parent.CostBasis.Equal(decimal.NewFromInt(30000)), "the parent is not modified")
+
+    cost, parentCost, err = models.CorporateActionCostBasis(fork, "zero", parent)
+    require.NoError(t, err)
+    assert.True(t, cost.IsZero())
+    assert.Equal(t, "30000", parentCost.String())
+
+    unpriced := fork
+    unpriced.Price, unpriced.ParentPrice = decimal.Zero, decimal.Zero
+    cost, parentCost, err = models.CorporateActionCostBasis(unpriced, "allocated", parent)
+    require.NoError(t, err)
+    assert.True(t, cost.IsZero(), "nothing is allocated without prices")
+    assert.Equal(t, "30000", parentCost.String())
+
+    _, _, err = models.CorporateActionCostBasis(fork, "allocated", nil)
+    assert.ErrorIs(t, err, models.ErrInvalidCorporateAction)
+    _, _, err = models.CorporateActionCostBasis(airdrop, "market", nil)
+    assert.ErrorIs(t, err, models.ErrInvalidCorporateAction)
+}
+
+// TestLedgerCostBasisAdjusted verifies fork allocations replay onto the parent
+func TestLedgerCostBasisAdjusted(t *testing.T) {
+    t.Parallel()
+
+    portfolioID := uuid.New()
+    btc := models.Asset{ID: uuid.New(), Type: "cryptocurrency", Symbol: "BTC", Amount: decimal.NewFromInt(2), CostBasis: decimal.NewFromInt(30000)}
+    bch := models.Asset{ID: uuid.New(), Type: "cryptocurrency", Symbol: "BCH", Amount: decimal.NewFromInt(2), CostBasis: decimal.NewFromInt(3000)}
+    start := time.Date(2017, 8, 1, 0, 0, 0, 0, time.UTC)
+
+    events := ledgerStream(t, portfolioID, start,
+        models.LedgerPortfolioCreated, models.LedgerPortfolioDetails{Name: "Main"},
+        models.LedgerAssetAdded, btc,
+        models.LedgerAssetAdded, bch,
+        models.LedgerCostBasisAdjusted, models.LedgerCostBasis{AssetID: btc.ID, CostBasis: decimal.NewFromInt(27000)},
+        models.LedgerTransactionRecorded, models.Transaction{ID: uuid.New(), PortfolioID: portfolioID, AssetID: bch.ID, Type: "fork", Amount: bch.Amount, Price: decimal.NewFromInt(1500)},
+    )
+
+    state, err := models.ReplayLedger(events, time.Time{})
+    require.NoError(t, err)
+    require.Len(t, state.Portfolio.Assets, 2)
+    assert.Equal(t, "27000", state.Portfolio.Assets[0].CostBasis.String())
+    assert.Equal(t, "3000", state.Portfolio.Assets[1].CostBasis.String())
+
+    missing := ledgerStream(t, portfolioID, start,
+        models.LedgerPortfolioCreated, models.LedgerPortfolioDetails{Name: "Main"},
+        models.LedgerCostBasisAdjusted, models.LedgerCostBasis{AssetID: uuid.New(), CostBasis: decimal.Zero},
+    )
+    _, err = models.ReplayLedger(missing, time.Time{})
+    assert.ErrorIs(t, err, models.ErrInvalidLedgerEvent)
+}
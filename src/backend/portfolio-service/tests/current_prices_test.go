@@ -0,0 +1,73 @@
+package tests
+
+import (
+    "context"
+    "testing"
+
+    "github.com/shopspring/decimal"       // v1.3.1
+    "github.com/stretchr/testify/assert"  // v1.8.0
+    "github.com/stretchr/testify/require" // v1.8.0
+    "go.uber.org/zap"                     // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+    "bookman/portfolio-service/internal/services"
+)
+
+// TestCurrentPriceValuation verifies asset values are derived at read time
+// from one price per symbol, shared by every portfolio holding it
+func TestCurrentPriceValuation(t *testing.T) {
+    t.Parallel()
+
+    prices := map[string]decimal.Decimal{
+        "BTC": decimal.NewFromInt(60000),
+        "ETH": decimal.NewFromInt(3000),
+    }
+
+    first := &models.Portfolio{Assets: []models.Asset{
+        {Symbol: "BTC", Amount: decimal.RequireFromString("0.5"), CostBasis: decimal.NewFromInt(20000)},
+        {Symbol: "ETH", Amount: decimal.NewFromInt(2), CostBasis: decimal.NewFromInt(5000)},
+    }}
+    second := &models.Portfolio{Assets: []models.Asset{
+        {Symbol: "BTC", Amount: decimal.NewFromInt(2), CostBasis: decimal.NewFromInt(50000)},
+        {Symbol: "XYZ", Amount: decimal.NewFromInt(10), CurrentValue: decimal.NewFromInt(70)},
+    }}
+
+    assert.True(t, decimal.NewFromInt(36000).Equal(first.CalculateTotalValue(prices)))
+    assert.True(t, decimal.NewFromInt(30000).Equal(first.Assets[0].CurrentValue))
+    assert.True(t, decimal.NewFromInt(6000).Equal(first.Assets[1].CurrentValue))
+    assert.True(t, decimal.NewFromInt(11000).Equal(first.CalculateProfitLoss()))
+
+    assert.True(t, decimal.NewFromInt(120000).Equal(second.CalculateTotalValue(prices)))
+    assert.True(t, decimal.NewFromInt(120000).Equal(second.Assets[0].CurrentValue), "same symbol, same price")
+    assert.True(t, decimal.NewFromInt(70).Equal(second.Assets[1].CurrentValue), "unpriced assets keep their last value")
+    assert.False(t, second.Assets[0].LastUpdated.IsZero())
+    assert.True(t, second.Assets[1].LastUpdated.IsZero())
+
+    // A price change revalues every holder without rewriting assets
+    prices["BTC"] = decimal.NewFromInt(50000)
+    assert.True(t, decimal.NewFromInt(31000).Equal(first.CalculateTotalValue(prices)))
+    assert.True(t, decimal.NewFromInt(100000).Equal(second.CalculateTotalValue(prices)))
+}
+
+// TestRefreshPricesValidation verifies invalid symbols and negative prices
+// are rejected before any price is stored
+func TestRefreshPricesValidation(t *testing.T) {
+    t.Parallel()
+
+    service, err := services.NewPortfolioService(&repository.PostgresRepository{}, zap.NewNop())
+    require.NoError(t, err)
+
+    tests := []struct {
+        name   string
+        prices map[string]decimal.Decimal
+    }{
+        {"blank symbol", map[string]decimal.Decimal{"  ": decimal.NewFromInt(1)}},
+        {"negative price", map[string]decimal.Decimal{"BTC": decimal.NewFromInt(-1)}},
+    }
+
+    for _, tt := range tests {
+        err := service.RefreshPrices(context.Background(), tt.prices, nil)
+        assert.ErrorIs(t, err, services.ErrInvalidAsset, tt.name)
+    }
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+    "testing"
+
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestCustomAssetValidation verifies custom asset definitions are normalized
+// and checked independently of the supported asset types
+func TestCustomAssetValidation(t *testing.T) {
+    t.Parallel()
+
+    asset := models.CustomAsset{Symbol: " pvt1 ", Name: "  Seed round SAFT ", Decimals: 2}
+    require.NoError(t, asset.Validate())
+    assert.Equal(t, "PVT1", asset.Symbol)
+    assert.Equal(t, "Seed round SAFT", asset.Name)
+
+    invalid := []models.CustomAsset{
+        {Symbol: "X", Name: "Too short", Decimals: 0},
+        {Symbol: "PVT-1", Name: "Punctuation", Decimals: 0},
+        {Symbol: "PVT", Name: " ", Decimals: 0},
+        {Symbol: "PVT", Name: "Negative", Decimals: -1},
+        {Symbol: "PVT", Name: "Too precise", Decimals: models.MAX_CUSTOM_ASSET_DECIMALS + 1},
+    }
+    for _, asset := range invalid {
+        assert.ErrorIs(t, asset.Validate(), models.ErrInvalidCustomAsset)
+    }
+
+    assert.NoError(t, models.ValidateHoldingType(models.CUSTOM_ASSET_TYPE))
+    assert.NoError(t, models.ValidateHoldingType("token"))
+    assert.ErrorIs(t, models.ValidateAssetType(models.CUSTOM_ASSET_TYPE), models.ErrInvalidAssetType,
+        "custom is not a supported provider-listed asset type")
+}
+
+// TestCustomAssetAmountPrecision verifies holdings carry no more decimals
+// than their custom asset allows
+func TestCustomAssetAmountPrecision(t *testing.T) {
+    t.Parallel()
+
+    asset := models.CustomAsset{Symbol: "PVT", Name: "Private placement", Decimals: 2}
+    assert.NoError(t, asset.ValidateAmount(decimal.RequireFromString("10.25")))
+    assert.NoError(t, asset.ValidateAmount(decimal.RequireFromString("10.50000")))
+    assert.ErrorIs(t, asset.ValidateAmount(decimal.RequireFromString("10.255")), models.ErrInvalidCustomAsset)
+
+    whole := models.CustomAsset{Symbol: "SHARE", Name: "Shares", Decimals: 0}
+    assert.ErrorIs(t, whole.ValidateAmount(decimal.RequireFromString("1.5")), models.ErrInvalidCustomAsset)
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/shopspring/decimal"    // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestBuildDCASchedule verifies contributions go to underweight symbols first
+// and the projected allocation converges on the targets
+func TestBuildDCASchedule(t *testing.T) {
+    t.Parallel()
+
+    holdings := map[string]decimal.Decimal{
+        "BTC": decimal.NewFromInt(900),
+        "ETH": decimal.NewFromInt(100),
+    }
+    targets := []models.AllocationTarget{
+        {Dimension: models.TargetBySymbol, Key: "BTC", Percentage: decimal.NewFromInt(50)},
+        {Dimension: models.TargetBySymbol, Key: "ETH", Percentage: decimal.NewFromInt(50)},
+    }
+    start := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+    schedule, err := models.BuildDCASchedule(holdings, decimal.NewFromInt(100), targets, models.DCAMonthly, start, 12)
+    require.NoError(t, err)
+    require.Len(t, schedule, 12)
+
+    // First installment is entirely spent on the underweight symbol
+    require.Len(t, schedule[0].Purchases, 1)
+    assert.Equal(t, "ETH", schedule[0].Purchases[0].Symbol)
+    assert.True(t, schedule[0].Purchases[0].Amount.Equal(decimal.NewFromInt(100)))
+    assert.Equal(t, start, schedule[0].ScheduledAt)
+    assert.Equal(t, 2, schedule[1].Sequence)
+
+    for _, installment := range schedule {
+        spent := decimal.Zero
+        for _, p := range installment.Purchases {
+            spent = spent.Add(p.Amount)
+        }
+        assert.True(t, spent.Equal(decimal.NewFromInt(100)), "installment %d spends the contribution", installment.Sequence)
+    }
+
+    // Gap of 800 is closed after eight installments, then split evenly
+    last := schedule[len(schedule)-1]
+    for _, slice := range last.ProjectedAllocation {
+        assert.True(t, slice.Percentage.Equal(decimal.NewFromInt(50)), "%s projected at target", slice.Key)
+    }
+}
+
+// TestBuildDCAScheduleValidation verifies malformed plans are rejected
+func TestBuildDCAScheduleValidation(t *testing.T) {
+    t.Parallel()
+
+    partial := []models.AllocationTarget{
+        {Dimension: models.TargetBySymbol, Key: "BTC", Percentage: decimal.NewFromInt(60)},
+    }
+    full := []models.AllocationTarget{
+        {Dimension: models.TargetBySymbol, Key: "BTC", Percentage: decimal.NewFromInt(100)},
+    }
+    now := time.Now()
+
+    _, err := models.BuildDCASchedule(nil, decimal.NewFromInt(100), partial, models.DCAWeekly, now, 4)
+    assert.ErrorIs(t, err, models.ErrInvalidDCAPlan)
+
+    _, err = models.BuildDCASchedule(nil, decimal.Zero, full, models.DCAWeekly, now, 4)
+    assert.ErrorIs(t, err, models.ErrInvalidDCAPlan)
+
+    _, err = models.BuildDCASchedule(nil, decimal.NewFromInt(100), full, "daily", now, 4)
+    assert.ErrorIs(t, err, models.ErrInvalidDCAPlan)
+
+    _, err = models.BuildDCASchedule(nil, decimal.NewFromInt(100), full, models.DCAWeekly, now, 0)
+    assert.ErrorIs(t, err, models.ErrInvalidDCAPlan)
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/deadline"
+)
+
+// TestDefaultDeadlines verifies calls without a client deadline get the
+// deadline of their method, and client deadlines are kept
+func TestDefaultDeadlines(t *testing.T) {
+    t.Parallel()
+
+    enforcer, err := deadline.NewEnforcer(config.DeadlineConfig{
+        Enabled: true,
+        Default: time.Second * 10,
+        Methods: map[string]time.Duration{
+            "generatereport":     time.Minute,
+            "ImportTransactions": time.Minute * 5,
+        },
+    })
+    require.NoError(t, err)
+
+    timeout, ok := enforcer.Timeout("/portfolio.PortfolioService/GetPortfolio", false)
+    assert.True(t, ok)
+    assert.Equal(t, time.Second*10, timeout)
+    timeout, ok = enforcer.Timeout("/portfolio.PortfolioService/GenerateReport", false)
+    assert.True(t, ok)
+    assert.Equal(t, time.Minute, timeout, "matched case-insensitively")
+    timeout, ok = enforcer.Timeout("/portfolio.PortfolioService/ImportTransactions", true)
+    assert.True(t, ok)
+    assert.Equal(t, time.Minute*5, timeout)
+    _, ok = enforcer.Timeout("/portfolio.PortfolioService/StreamPortfolioUpdates", true)
+    assert.False(t, ok, "streams only get listed deadlines")
+
+    start := time.Now()
+    ctx, cancel := enforcer.Apply(context.Background(), "/portfolio.PortfolioService/GetPortfolio", false)
+    defer cancel()
+    applied, ok := ctx.Deadline()
+    require.True(t, ok)
+    assert.WithinDuration(t, start.Add(time.Second*10), applied, time.Second)
+
+    clientCtx, clientCancel := context.WithTimeout(context.Background(), time.Hour)
+    defer clientCancel()
+    clientDeadline, _ := clientCtx.Deadline()
+    ctx, cancel = enforcer.Apply(clientCtx, "/portfolio.PortfolioService/GetPortfolio", false)
+    defer cancel()
+    kept, _ := ctx.Deadline()
+    assert.Equal(t, clientDeadline, kept, "client deadlines are not shortened")
+
+    ctx, cancel = enforcer.Apply(context.Background(), "/portfolio.PortfolioService/StreamPortfolioUpdates", true)
+    defer cancel()
+    _, ok = ctx.Deadline()
+    assert.False(t, ok)
+
+    _, err = deadline.NewEnforcer(config.DeadlineConfig{Enabled: true})
+    assert.Error(t, err, "missing default")
+}
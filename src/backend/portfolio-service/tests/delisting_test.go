@@ -0,0 +1,49 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"                // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestDelistedSymbolPrice verifies delisted assets are frozen at their last
+// known price or valued at zero depending on the policy
+func TestDelistedSymbolPrice(t *testing.T) {
+    t.Parallel()
+
+    delisted := models.DelistedSymbol{
+        Symbol:       "LUNA",
+        LastPrice:    decimal.RequireFromString("0.00012"),
+        LastQuotedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+    }
+
+    assert.Equal(t, "0.00012", delisted.Price(models.DelistedPriceLast).String())
+    assert.True(t, delisted.Price(models.DelistedPriceZero).IsZero())
+    assert.ElementsMatch(t, []string{models.DelistedPriceLast, models.DelistedPriceZero}, models.DELISTED_PRICE_POLICIES)
+}
+
+// TestAllocationDelistedSymbols verifies allocations list delisted holdings,
+// including those valued at zero and left out of the breakdown
+func TestAllocationDelistedSymbols(t *testing.T) {
+    t.Parallel()
+
+    allocation := models.BuildAllocation(uuid.New(), []models.Asset{
+        {ID: uuid.New(), Type: "cryptocurrency", Symbol: "BTC", CurrentValue: decimal.NewFromInt(900)},
+        {ID: uuid.New(), Type: "token", Symbol: "LUNA", CurrentValue: decimal.NewFromInt(100), Delisted: true},
+        {ID: uuid.New(), Type: "token", Symbol: "FTT", CurrentValue: decimal.Zero, Delisted: true},
+        {ID: uuid.New(), Type: "staked_asset", Symbol: "LUNA", CurrentValue: decimal.NewFromInt(10), Delisted: true},
+    })
+
+    assert.Equal(t, []string{"FTT", "LUNA"}, allocation.DelistedSymbols)
+    assert.Len(t, allocation.BySymbol, 2, "zero valued holdings stay out of the breakdown")
+
+    allocation = models.BuildAllocation(uuid.New(), []models.Asset{
+        {ID: uuid.New(), Type: "cryptocurrency", Symbol: "BTC", CurrentValue: decimal.NewFromInt(900)},
+    })
+    assert.Empty(t, allocation.DelistedSymbols)
+}
@@ -0,0 +1,295 @@
+package tests
+
+import (
+    "context"
+    "io"
+    "net/http"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"               // v1.8.0
+    "github.com/stretchr/testify/require"              // v1.8.0
+    "google.golang.org/grpc"                           // v1.50.0
+    "google.golang.org/grpc/metadata"                  // v1.50.0
+    "google.golang.org/protobuf/proto"                 // v1.30.0
+    "google.golang.org/protobuf/reflect/protodesc"     // v1.30.0
+    "google.golang.org/protobuf/reflect/protoreflect"  // v1.30.0
+    "google.golang.org/protobuf/reflect/protoregistry" // v1.30.0
+    "google.golang.org/protobuf/types/descriptorpb"    // v1.30.0
+    "google.golang.org/protobuf/types/dynamicpb"       // v1.30.0
+
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/deprecation"
+)
+
+var (
+    deprecationFileOnce sync.Once
+    deprecationFile     protoreflect.FileDescriptor
+    deprecationFileErr  error
+)
+
+// deprecationTestFile registers a proto file with a deprecated method, a
+// deprecated request field and a deprecated field of a repeated nested message
+func deprecationTestFile(t *testing.T) protoreflect.FileDescriptor {
+    t.Helper()
+
+    deprecationFileOnce.Do(func() {
+        str := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+        optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+        deprecated := func() *descriptorpb.FieldOptions {
+            return &descriptorpb.FieldOptions{Deprecated: proto.Bool(true)}
+        }
+
+        file := &descriptorpb.FileDescriptorProto{
+            Name:    proto.String("deprecation_test.proto"),
+            Package: proto.String("deprecationtest"),
+            Syntax:  proto.String("proto3"),
+            MessageType: []*descriptorpb.DescriptorProto{
+                {
+                    Name: proto.String("Filter"),
+                    Field: []*descriptorpb.FieldDescriptorProto{
+                        {Name: proto.String("code"), Number: proto.Int32(1), Type: str, Label: optional},
+                        {Name: proto.String("legacy_code"), Number: proto.Int32(2), Type: str, Label: optional, Options: deprecated()},
+                    },
+                },
+                {
+                    Name: proto.String("Request"),
+                    Field: []*descriptorpb.FieldDescriptorProto{
+                        {Name: proto.String("id"), Number: proto.Int32(1), Type: str, Label: optional},
+                        {Name: proto.String("period"), Number: proto.Int32(2), Type: str, Label: optional, Options: deprecated()},
+                        {
+                            Name:     proto.String("filters"),
+                            Number:   proto.Int32(3),
+                            Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+                            Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+                            TypeName: proto.String(".deprecationtest.Filter"),
+                        },
+                    },
+                },
+            },
+            Service: []*descriptorpb.ServiceDescriptorProto{{
+                Name: proto.String("Legacy"),
+                Method: []*descriptorpb.MethodDescriptorProto{
+                    {
+                        Name:       proto.String("Old"),
+                        InputType:  proto.String(".deprecationtest.Request"),
+                        OutputType: proto.String(".deprecationtest.Request"),
+                        Options:    &descriptorpb.MethodOptions{Deprecated: proto.Bool(true)},
+                    },
+                    {
+                        Name:       proto.String("Current"),
+                        InputType:  proto.String(".deprecationtest.Request"),
+                        OutputType: proto.String(".deprecationtest.Request"),
+                    },
+                },
+            }},
+        }
+
+        deprecationFile, deprecationFileErr = protodesc.NewFile(file, protoregistry.GlobalFiles)
+        if deprecationFileErr == nil {
+            deprecationFileErr = protoregistry.GlobalFiles.RegisterFile(deprecationFile)
+        }
+    })
+    require.NoError(t, deprecationFileErr)
+    return deprecationFile
+}
+
+// newDeprecationRequest builds a request setting period when set and one
+// filter with the given legacy code
+func newDeprecationRequest(file protoreflect.FileDescriptor, period, legacyCode string) *dynamicpb.Message {
+    request := file.Messages().ByName("Request")
+    filter := file.Messages().ByName("Filter")
+
+    msg := dynamicpb.NewMessage(request)
+    msg.Set(request.Fields().ByName("id"), protoreflect.ValueOfString("p-1"))
+    if period != "" {
+        msg.Set(request.Fields().ByName("period"), protoreflect.ValueOfString(period))
+    }
+
+    f := dynamicpb.NewMessage(filter)
+    f.Set(filter.Fields().ByName("code"), protoreflect.ValueOfString("BTC"))
+    if legacyCode != "" {
+        f.Set(filter.Fields().ByName("legacy_code"), protoreflect.ValueOfString(legacyCode))
+    }
+    msg.Mutable(request.Fields().ByName("filters")).List().Append(protoreflect.ValueOfMessage(f))
+    return msg
+}
+
+// fakeTransportStream captures the trailer set through grpc.SetTrailer
+type fakeTransportStream struct {
+    method  string
+    trailer metadata.MD
+}
+
+func (s *fakeTransportStream) Method() string                  { return s.method }
+func (s *fakeTransportStream) SetHeader(md metadata.MD) error  { return nil }
+func (s *fakeTransportStream) SendHeader(md metadata.MD) error { return nil }
+func (s *fakeTransportStream) SetTrailer(md metadata.MD) error {
+    s.trailer = metadata.Join(s.trailer, md)
+    return nil
+}
+
+// fakeServerStream delivers queued messages to RecvMsg and captures the
+// trailer
+type fakeServerStream struct {
+    ctx      context.Context
+    messages []proto.Message
+    trailer  metadata.MD
+}
+
+func (s *fakeServerStream) SetHeader(md metadata.MD) error  { return nil }
+func (s *fakeServerStream) SendHeader(md metadata.MD) error { return nil }
+func (s *fakeServerStream) SetTrailer(md metadata.MD)       { s.trailer = metadata.Join(s.trailer, md) }
+func (s *fakeServerStream) Context() context.Context        { return s.ctx }
+func (s *fakeServerStream) SendMsg(m interface{}) error     { return nil }
+
+func (s *fakeServerStream) RecvMsg(m interface{}) error {
+    if len(s.messages) == 0 {
+        return io.EOF
+    }
+    proto.Merge(m.(proto.Message), s.messages[0])
+    s.messages = s.messages[1:]
+    return nil
+}
+
+// TestDeprecationInspect verifies deprecated methods and fields, nested ones
+// included, are found while current usage is not reported
+func TestDeprecationInspect(t *testing.T) {
+    t.Parallel()
+
+    file := deprecationTestFile(t)
+    signaler, err := deprecation.NewSignaler(config.DeprecationConfig{})
+    require.NoError(t, err)
+
+    assert.Empty(t, signaler.Inspect("/deprecationtest.Legacy/Current", newDeprecationRequest(file, "", "")))
+
+    assert.Equal(t,
+        []string{"deprecationtest.Legacy.Old"},
+        signaler.Inspect("/deprecationtest.Legacy/Old", newDeprecationRequest(file, "", "")),
+    )
+
+    assert.Equal(t,
+        []string{"deprecationtest.Filter.legacy_code", "deprecationtest.Request.period"},
+        signaler.Inspect("/deprecationtest.Legacy/Current", newDeprecationRequest(file, "1M", "XBT")),
+    )
+
+    // Unknown methods and non-proto requests are not deprecated
+    assert.Empty(t, signaler.Inspect("/deprecationtest.Legacy/Missing", "not a message"))
+}
+
+// TestDeprecationTrailers verifies calls using deprecated elements receive
+// the deprecated trailer with the earliest configured sunset date
+func TestDeprecationTrailers(t *testing.T) {
+    t.Parallel()
+
+    file := deprecationTestFile(t)
+    signaler, err := deprecation.NewSignaler(config.DeprecationConfig{
+        Sunsets: map[string]string{
+            "deprecationtest.Request.period":     "2025-06-30",
+            "deprecationtest.Filter.legacy_code": "2025-03-31",
+        },
+    })
+    require.NoError(t, err)
+    interceptor := signaler.UnaryServerInterceptor()
+
+    call := func(fullMethod string, req interface{}) metadata.MD {
+        transport := &fakeTransportStream{method: fullMethod}
+        ctx := grpc.NewContextWithServerTransportStream(context.Background(), transport)
+        _, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: fullMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+            return nil, nil
+        })
+        require.NoError(t, err)
+        return transport.trailer
+    }
+
+    trailer := call("/deprecationtest.Legacy/Current", newDeprecationRequest(file, "1M", "XBT"))
+    assert.Equal(t, []string{"deprecationtest.Filter.legacy_code,deprecationtest.Request.period"}, trailer.Get(deprecation.DeprecatedKey))
+    sunset := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+    assert.Equal(t, []string{sunset}, trailer.Get(deprecation.SunsetKey))
+
+    // Without a configured sunset only the deprecation is signaled
+    trailer = call("/deprecationtest.Legacy/Old", newDeprecationRequest(file, "", ""))
+    assert.Equal(t, []string{"deprecationtest.Legacy.Old"}, trailer.Get(deprecation.DeprecatedKey))
+    assert.Empty(t, trailer.Get(deprecation.SunsetKey))
+
+    // Current usage gets no trailer
+    assert.Empty(t, call("/deprecationtest.Legacy/Current", newDeprecationRequest(file, "", "")))
+}
+
+// TestDeprecationStreamTrailers verifies streaming calls are signaled for
+// deprecated fields set in any received message
+func TestDeprecationStreamTrailers(t *testing.T) {
+    t.Parallel()
+
+    file := deprecationTestFile(t)
+    signaler, err := deprecation.NewSignaler(config.DeprecationConfig{
+        Sunsets: map[string]string{"deprecationtest.Request.period": "2025-06-30"},
+    })
+    require.NoError(t, err)
+    interceptor := signaler.StreamServerInterceptor()
+
+    stream := &fakeServerStream{
+        ctx: context.Background(),
+        messages: []proto.Message{
+            newDeprecationRequest(file, "", ""),
+            newDeprecationRequest(file, "1M", ""),
+        },
+    }
+    received := 0
+    err = interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/deprecationtest.Legacy/Current"}, func(srv interface{}, ss grpc.ServerStream) error {
+        for {
+            msg := dynamicpb.NewMessage(file.Messages().ByName("Request"))
+            if err := ss.RecvMsg(msg); err == io.EOF {
+                return nil
+            } else if err != nil {
+                return err
+            }
+            received++
+        }
+    })
+    require.NoError(t, err)
+    assert.Equal(t, 2, received)
+    assert.Equal(t, []string{"deprecationtest.Request.period"}, stream.trailer.Get(deprecation.DeprecatedKey))
+    assert.Equal(t, []string{time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)}, stream.trailer.Get(deprecation.SunsetKey))
+}
+
+// TestDeprecationClientVersion verifies client versions are bucketed to
+// major.minor and bounded by the allowlist when one is configured
+func TestDeprecationClientVersion(t *testing.T) {
+    t.Parallel()
+
+    open, err := deprecation.NewSignaler(config.DeprecationConfig{})
+    require.NoError(t, err)
+    allowlisted, err := deprecation.NewSignaler(config.DeprecationConfig{ClientVersions: []string{"2.4", "3.0"}})
+    require.NoError(t, err)
+
+    versionContext := func(version string) context.Context {
+        return metadata.NewIncomingContext(context.Background(), metadata.Pairs(deprecation.ClientVersionKey, version))
+    }
+
+    testCases := []struct {
+        version     string
+        want        string
+        allowlisted string
+    }{
+        {"2.4.17", "2.4", "2.4"},
+        {"v2.4.3", "2.4", "2.4"},
+        {"02.04", "2.4", "2.4"},
+        {"3", "3.0", "3.0"},
+        {"2.5.0", "2.5", "other"},
+        {"1.0.0-beta", "unknown", "unknown"},
+        {"2.4.17.1", "unknown", "unknown"},
+        {"12345.0", "unknown", "unknown"},
+        {"", "unknown", "unknown"},
+    }
+    for _, tc := range testCases {
+        assert.Equal(t, tc.want, open.ClientVersion(versionContext(tc.version)), tc.version)
+        assert.Equal(t, tc.allowlisted, allowlisted.ClientVersion(versionContext(tc.version)), tc.version)
+    }
+
+    assert.Equal(t, "unknown", open.ClientVersion(context.Background()))
+
+    _, err = deprecation.NewSignaler(config.DeprecationConfig{ClientVersions: []string{"latest"}})
+    assert.Error(t, err)
+}
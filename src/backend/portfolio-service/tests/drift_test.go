@@ -0,0 +1,78 @@
+package tests
+
+import (
+    "testing"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestNormalizeTargets verifies target validation and key normalization
+func TestNormalizeTargets(t *testing.T) {
+    t.Parallel()
+
+    targets, err := models.NormalizeTargets([]models.AllocationTarget{
+        {Dimension: models.TargetBySymbol, Key: " btc ", Percentage: decimal.NewFromInt(60)},
+        {Dimension: models.TargetByCategory, Key: "DeFi", Percentage: decimal.NewFromInt(100)},
+    })
+    require.NoError(t, err)
+    assert.Equal(t, "BTC", targets[0].Key)
+    assert.Equal(t, "defi", targets[1].Key)
+
+    invalid := [][]models.AllocationTarget{
+        {{Dimension: "sector", Key: "BTC", Percentage: decimal.NewFromInt(10)}},
+        {{Dimension: models.TargetBySymbol, Key: "BTC", Percentage: decimal.Zero}},
+        {
+            {Dimension: models.TargetBySymbol, Key: "BTC", Percentage: decimal.NewFromInt(10)},
+            {Dimension: models.TargetBySymbol, Key: "btc", Percentage: decimal.NewFromInt(10)},
+        },
+        {
+            {Dimension: models.TargetBySymbol, Key: "BTC", Percentage: decimal.NewFromInt(70)},
+            {Dimension: models.TargetBySymbol, Key: "ETH", Percentage: decimal.NewFromInt(40)},
+        },
+    }
+    for _, targets := range invalid {
+        _, err := models.NormalizeTargets(targets)
+        assert.ErrorIs(t, err, models.ErrInvalidTarget)
+    }
+}
+
+// TestComputeDrift verifies deviations are measured against targets, with
+// untargeted holdings counted against a zero target
+func TestComputeDrift(t *testing.T) {
+    t.Parallel()
+
+    allocation := &models.Allocation{
+        PortfolioID: uuid.New(),
+        BySymbol: []models.AllocationSlice{
+            {Key: "BTC", Percentage: decimal.NewFromInt(70)},
+            {Key: "ETH", Percentage: decimal.NewFromInt(27)},
+            {Key: "DOGE", Percentage: decimal.NewFromInt(3)},
+        },
+    }
+    targets := []models.AllocationTarget{
+        {Dimension: models.TargetBySymbol, Key: "BTC", Percentage: decimal.NewFromInt(60)},
+        {Dimension: models.TargetBySymbol, Key: "ETH", Percentage: decimal.NewFromInt(30)},
+        {Dimension: models.TargetBySymbol, Key: "SOL", Percentage: decimal.NewFromInt(10)},
+    }
+
+    drift := models.ComputeDrift(allocation, targets, decimal.NewFromInt(5))
+    require.Len(t, drift.Entries, 4)
+    assert.True(t, drift.Breached)
+
+    byKey := make(map[string]models.DriftEntry)
+    for _, e := range drift.Entries {
+        byKey[e.Key] = e
+    }
+    assert.True(t, byKey["BTC"].Drift.Equal(decimal.NewFromInt(10)))
+    assert.True(t, byKey["BTC"].Breached)
+    assert.True(t, byKey["SOL"].Drift.Equal(decimal.NewFromInt(-10)))
+    assert.False(t, byKey["ETH"].Breached)
+    assert.True(t, byKey["DOGE"].Target.IsZero())
+    assert.False(t, byKey["DOGE"].Breached)
+    assert.Equal(t, "BTC", drift.Entries[0].Key)
+}
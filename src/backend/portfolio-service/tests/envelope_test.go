@@ -0,0 +1,214 @@
+package tests
+
+import (
+    "context"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/envelope"
+)
+
+// localKEK creates a local key encryption key from a repeated character
+func localKEK(t *testing.T, c string) *envelope.LocalKey {
+    t.Helper()
+    kek, err := envelope.NewLocalKey([]byte(strings.Repeat(c, envelope.MinLocalKeyLength)))
+    require.NoError(t, err)
+    return kek
+}
+
+// TestEnvelopeSealOpen verifies sealed values only open with the additional
+// data and key encryption key they were sealed with
+func TestEnvelopeSealOpen(t *testing.T) {
+    t.Parallel()
+
+    ctx := context.Background()
+    encryptor, err := envelope.NewEncryptor(localKEK(t, "a"))
+    require.NoError(t, err)
+
+    sealed, err := encryptor.Seal(ctx, []byte("api-secret"), []byte("scope"))
+    require.NoError(t, err)
+    assert.True(t, envelope.IsSealed(sealed))
+    assert.NotContains(t, string(sealed), "api-secret")
+
+    again, err := encryptor.Seal(ctx, []byte("api-secret"), []byte("scope"))
+    require.NoError(t, err)
+    assert.NotEqual(t, sealed, again, "each value has its own data key and nonce")
+
+    opened, err := encryptor.Open(ctx, sealed, []byte("scope"))
+    require.NoError(t, err)
+    assert.Equal(t, "api-secret", string(opened))
+
+    _, err = encryptor.Open(ctx, sealed, []byte("other"))
+    assert.ErrorIs(t, err, envelope.ErrUndecryptable, "bound to its additional data")
+
+    tampered := append([]byte(nil), sealed...)
+    tampered[len(tampered)-1] ^= 1
+    _, err = encryptor.Open(ctx, tampered, []byte("scope"))
+    assert.ErrorIs(t, err, envelope.ErrUndecryptable)
+
+    _, err = encryptor.Open(ctx, sealed[:8], []byte("scope"))
+    assert.ErrorIs(t, err, envelope.ErrUndecryptable, "truncated")
+
+    other, err := envelope.NewEncryptor(localKEK(t, "b"))
+    require.NoError(t, err)
+    _, err = other.Open(ctx, sealed, []byte("scope"))
+    assert.ErrorIs(t, err, envelope.ErrUnknownKey)
+
+    _, err = envelope.NewLocalKey([]byte("short"))
+    assert.Error(t, err)
+    _, err = envelope.NewEncryptor(localKEK(t, "a"), localKEK(t, "a"))
+    assert.Error(t, err, "duplicate keys")
+}
+
+// TestEnvelopeRotation verifies values sealed under a previous key open until
+// rewrapped under the current key, without re-encrypting them
+func TestEnvelopeRotation(t *testing.T) {
+    t.Parallel()
+
+    ctx := context.Background()
+    oldKEK, newKEK := localKEK(t, "o"), localKEK(t, "n")
+    assert.NotEqual(t, oldKEK.ID(), newKEK.ID())
+
+    before, err := envelope.NewEncryptor(oldKEK)
+    require.NoError(t, err)
+    sealed, err := before.SealString(ctx, "whsec_123", []byte("webhook"))
+    require.NoError(t, err)
+    assert.True(t, envelope.IsSealedString(sealed))
+    assert.False(t, envelope.IsSealedString("whsec_123"))
+
+    rotated, err := envelope.NewEncryptor(newKEK, oldKEK)
+    require.NoError(t, err)
+    opened, err := rotated.OpenString(ctx, sealed, []byte("webhook"))
+    require.NoError(t, err)
+    assert.Equal(t, "whsec_123", opened)
+
+    rewrapped, changed, err := rotated.RewrapString(ctx, sealed)
+    require.NoError(t, err)
+    assert.True(t, changed)
+    _, changed, err = rotated.RewrapString(ctx, rewrapped)
+    require.NoError(t, err)
+    assert.False(t, changed)
+
+    after, err := envelope.NewEncryptor(newKEK)
+    require.NoError(t, err)
+    _, err = after.OpenString(ctx, sealed, []byte("webhook"))
+    assert.ErrorIs(t, err, envelope.ErrUnknownKey)
+    opened, err = after.OpenString(ctx, rewrapped, []byte("webhook"))
+    require.NoError(t, err)
+    assert.Equal(t, "whsec_123", opened)
+
+    _, err = after.OpenString(ctx, "whsec_123", []byte("webhook"))
+    assert.ErrorIs(t, err, envelope.ErrUndecryptable)
+}
+
+// fakeTransit is a minimal Vault transit engine keeping data keys in memory
+type fakeTransit struct {
+    mu      sync.Mutex
+    version int
+    keys    map[string]string
+}
+
+func (f *fakeTransit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if r.Header.Get("X-Vault-Token") != "token" {
+        w.WriteHeader(http.StatusForbidden)
+        return
+    }
+    var body map[string]string
+    if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        return
+    }
+
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    data := map[string]string{}
+    switch r.URL.Path {
+    case "/v1/transit/encrypt/portfolio":
+        ciphertext := fmt.Sprintf("vault:v%d:%d", f.version, len(f.keys))
+        f.keys[ciphertext] = body["plaintext"]
+        data["ciphertext"] = ciphertext
+    case "/v1/transit/decrypt/portfolio":
+        plaintext, ok := f.keys[body["ciphertext"]]
+        if !ok {
+            w.WriteHeader(http.StatusBadRequest)
+            return
+        }
+        data["plaintext"] = plaintext
+    case "/v1/transit/rewrap/portfolio":
+        plaintext, ok := f.keys[body["ciphertext"]]
+        if !ok {
+            w.WriteHeader(http.StatusBadRequest)
+            return
+        }
+        ciphertext := fmt.Sprintf("vault:v%d:%d", f.version, len(f.keys))
+        f.keys[ciphertext] = plaintext
+        data["ciphertext"] = ciphertext
+    default:
+        w.WriteHeader(http.StatusNotFound)
+        return
+    }
+    json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+// TestVaultTransitKey verifies data keys are wrapped by Vault and rewrapped
+// after the transit key is rotated
+func TestVaultTransitKey(t *testing.T) {
+    t.Parallel()
+
+    ctx := context.Background()
+    transit := &fakeTransit{version: 1, keys: map[string]string{}}
+    server := httptest.NewServer(transit)
+    defer server.Close()
+
+    kek, err := envelope.NewVaultTransitKey(server.Client(), server.URL, "transit", "portfolio", "token")
+    require.NoError(t, err)
+    assert.Equal(t, "vault:transit/portfolio", kek.ID())
+    encryptor, err := envelope.NewEncryptor(kek)
+    require.NoError(t, err)
+
+    sealed, err := encryptor.Seal(ctx, []byte("api-secret"), []byte("scope"))
+    require.NoError(t, err)
+    opened, err := encryptor.Open(ctx, sealed, []byte("scope"))
+    require.NoError(t, err)
+    assert.Equal(t, "api-secret", string(opened))
+    for _, dataKey := range transit.keys {
+        raw, err := base64.StdEncoding.DecodeString(dataKey)
+        require.NoError(t, err)
+        assert.NotContains(t, string(sealed), string(raw), "only the wrapped data key is stored")
+    }
+
+    _, changed, err := encryptor.Rewrap(ctx, sealed)
+    require.NoError(t, err)
+    assert.False(t, changed, "already under the latest version")
+
+    transit.mu.Lock()
+    transit.version = 2
+    transit.mu.Unlock()
+    rewrapped, changed, err := encryptor.Rewrap(ctx, sealed)
+    require.NoError(t, err)
+    assert.True(t, changed)
+    assert.Contains(t, string(rewrapped), "vault:v2:")
+    opened, err = encryptor.Open(ctx, rewrapped, []byte("scope"))
+    require.NoError(t, err)
+    assert.Equal(t, "api-secret", string(opened))
+
+    unauthorized, err := envelope.NewVaultTransitKey(server.Client(), server.URL, "transit", "portfolio", "wrong")
+    require.NoError(t, err)
+    _, err = unauthorized.Wrap(ctx, make([]byte, 32))
+    assert.Error(t, err)
+
+    _, err = envelope.NewVaultTransitKey(server.Client(), "vault.internal", "transit", "portfolio", "token")
+    assert.Error(t, err, "address without scheme")
+    _, err = envelope.NewVaultTransitKey(server.Client(), server.URL, "transit", "portfolio", "")
+    assert.Error(t, err, "missing token")
+}
@@ -0,0 +1,381 @@
+package tests
+
+import (
+    "context"
+    "crypto/aes"
+    gocipher "crypto/cipher"
+    "crypto/sha256"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"               // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/envelope"
+    "bookman/portfolio-service/internal/exchanges"
+    "bookman/portfolio-service/internal/models"
+)
+
+var exchangeCash = []string{"USDT", "USDC"}
+
+// exchangePrices returns a fixed USD price source for the mapper
+func exchangePrices(prices map[string]string) models.USDPriceFunc {
+    return func(asset string, at time.Time) (decimal.Decimal, error) {
+        price, ok := prices[asset]
+        if !ok {
+            return decimal.Zero, errors.New("no price")
+        }
+        return decimal.RequireFromString(price), nil
+    }
+}
+
+// legBySymbol returns the mapped leg of an asset
+func legBySymbol(t *testing.T, legs []models.ExchangeLeg, symbol string) models.ExchangeLeg {
+    t.Helper()
+    for _, leg := range legs {
+        if leg.Symbol == symbol {
+            return leg
+        }
+    }
+    t.Fatalf("no leg for %s", symbol)
+    return models.ExchangeLeg{}
+}
+
+// TestExchangeFillFeeAccounting verifies commissions end up in cost basis on
+// buys and reduce proceeds on sells whichever asset they are charged in
+func TestExchangeFillFeeAccounting(t *testing.T) {
+    t.Parallel()
+
+    mapper := models.NewExchangeMapper(exchangeCash, exchangePrices(map[string]string{
+        "BTC": "60000",
+        "BNB": "500",
+    }))
+    at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+    t.Run("buy with commission in base", func(t *testing.T) {
+        legs, err := mapper.MapFill(models.ExchangeFill{
+            ID: "ETHUSDT:1", Base: "ETH", Quote: "USDT", Side: models.ExchangeBuy,
+            Quantity: decimal.RequireFromString("2"), Price: decimal.RequireFromString("3000"),
+            QuoteQuantity: decimal.RequireFromString("6000"),
+            Commission: decimal.RequireFromString("0.002"), CommissionAsset: "ETH",
+            ExecutedAt: at,
+        })
+        require.NoError(t, err)
+        require.Len(t, legs, 1, "cash quote is not tracked")
+
+        eth := legs[0]
+        assert.Equal(t, "buy", eth.Type)
+        assert.True(t, eth.Amount.Equal(decimal.RequireFromString("1.998")))
+        assert.True(t, eth.Fee.Equal(decimal.RequireFromString("6")))
+        cost := eth.Amount.Mul(eth.Price).Add(eth.Fee)
+        assert.True(t, cost.Equal(decimal.RequireFromString("6000")), "cost basis is the quote spent")
+    })
+
+    t.Run("sell with commission in quote", func(t *testing.T) {
+        legs, err := mapper.MapFill(models.ExchangeFill{
+            ID: "ETHUSDT:2", Base: "ETH", Quote: "USDT", Side: models.ExchangeSell,
+            Quantity: decimal.RequireFromString("1"), Price: decimal.RequireFromString("3200"),
+            Commission: decimal.RequireFromString("3.2"), CommissionAsset: "USDT",
+            ExecutedAt: at,
+        })
+        require.NoError(t, err)
+        require.Len(t, legs, 1)
+
+        eth := legs[0]
+        assert.Equal(t, "sell", eth.Type)
+        assert.True(t, eth.Amount.Equal(decimal.NewFromInt(1)))
+        proceeds := eth.Amount.Mul(eth.Price).Sub(eth.Fee)
+        assert.True(t, proceeds.Equal(decimal.RequireFromString("3196.8")))
+    })
+
+    t.Run("crypto quote with commission in third asset", func(t *testing.T) {
+        legs, err := mapper.MapFill(models.ExchangeFill{
+            ID: "ETHBTC:3", Base: "ETH", Quote: "BTC", Side: models.ExchangeBuy,
+            Quantity: decimal.RequireFromString("1"), Price: decimal.RequireFromString("0.05"),
+            QuoteQuantity: decimal.RequireFromString("0.05"),
+            Commission: decimal.RequireFromString("0.006"), CommissionAsset: "BNB",
+            ExecutedAt: at,
+        })
+        require.NoError(t, err)
+        require.Len(t, legs, 3)
+
+        eth := legBySymbol(t, legs, "ETH")
+        assert.Equal(t, "buy", eth.Type)
+        assert.True(t, eth.Price.Equal(decimal.NewFromInt(3000)), "priced through the quote")
+        assert.True(t, eth.Fee.Equal(decimal.NewFromInt(3)))
+
+        btc := legBySymbol(t, legs, "BTC")
+        assert.Equal(t, "sell", btc.Type)
+        assert.True(t, btc.Amount.Equal(decimal.RequireFromString("0.05")))
+        assert.True(t, btc.Fee.IsZero(), "the commission is only counted once")
+
+        bnb := legBySymbol(t, legs, "BNB")
+        assert.Equal(t, "fee", bnb.Type)
+        assert.True(t, bnb.Amount.Equal(decimal.RequireFromString("0.006")))
+    })
+
+    t.Run("commission exceeding the fill is rejected", func(t *testing.T) {
+        _, err := mapper.MapFill(models.ExchangeFill{
+            ID: "ETHUSDT:4", Base: "ETH", Quote: "USDT", Side: models.ExchangeBuy,
+            Quantity: decimal.RequireFromString("0.001"), Price: decimal.RequireFromString("3000"),
+            Commission: decimal.RequireFromString("0.001"), CommissionAsset: "ETH",
+            ExecutedAt: at,
+        })
+        assert.ErrorIs(t, err, models.ErrInvalidExchangeActivity)
+    })
+}
+
+// TestExchangeDustConversion verifies the conversion fee is deducted from the
+// sale and the credited asset's cost basis equals the net proceeds
+func TestExchangeDustConversion(t *testing.T) {
+    t.Parallel()
+
+    mapper := models.NewExchangeMapper(exchangeCash, exchangePrices(map[string]string{"BNB": "500"}))
+    legs, err := mapper.MapConversion(models.ExchangeConversion{
+        ID: "dust:9", FromAsset: "DOGE", FromAmount: decimal.NewFromInt(100),
+        ToAsset: "BNB", ToAmount: decimal.RequireFromString("0.0196"), Fee: decimal.RequireFromString("0.0004"),
+        ExecutedAt: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+    })
+    require.NoError(t, err)
+    require.Len(t, legs, 2)
+
+    doge := legBySymbol(t, legs, "DOGE")
+    bnb := legBySymbol(t, legs, "BNB")
+    assert.Equal(t, "sell", doge.Type)
+    assert.Equal(t, "buy", bnb.Type)
+
+    proceeds := doge.Amount.Mul(doge.Price).Sub(doge.Fee)
+    assert.True(t, proceeds.Equal(decimal.RequireFromString("9.8")))
+    assert.True(t, bnb.Amount.Mul(bnb.Price).Equal(proceeds))
+}
+
+// TestExchangeEntryMapping verifies staking rewards and transfers map to a
+// single leg with fees reducing credits and adding to debits
+func TestExchangeEntryMapping(t *testing.T) {
+    t.Parallel()
+
+    mapper := models.NewExchangeMapper(exchangeCash, exchangePrices(map[string]string{"DOT": "7"}))
+    at := time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)
+
+    legs, err := mapper.MapEntry(models.ExchangeEntry{
+        ID: "L1", Asset: "DOT", Type: "reward",
+        Amount: decimal.RequireFromString("0.5"), Fee: decimal.RequireFromString("0.01"), ExecutedAt: at,
+    })
+    require.NoError(t, err)
+    require.Len(t, legs, 1)
+    assert.Equal(t, "reward", legs[0].Type)
+    assert.True(t, legs[0].Amount.Equal(decimal.RequireFromString("0.49")))
+    assert.True(t, legs[0].Amount.Mul(legs[0].Price).Add(legs[0].Fee).Equal(decimal.RequireFromString("3.5")), "income is the gross reward")
+
+    legs, err = mapper.MapEntry(models.ExchangeEntry{
+        ID: "L2", Asset: "DOT", Type: "transfer_out",
+        Amount: decimal.NewFromInt(10), Fee: decimal.RequireFromString("0.05"), ExecutedAt: at,
+    })
+    require.NoError(t, err)
+    assert.True(t, legs[0].Amount.Equal(decimal.RequireFromString("10.05")))
+
+    legs, err = mapper.MapEntry(models.ExchangeEntry{
+        ID: "L3", Asset: "USDT", Type: "transfer_in", Amount: decimal.NewFromInt(100), ExecutedAt: at,
+    })
+    require.NoError(t, err)
+    assert.Empty(t, legs, "cash is not tracked")
+
+    _, err = mapper.MapEntry(models.ExchangeEntry{
+        ID: "L4", Asset: "DOT", Type: "margin", Amount: decimal.NewFromInt(1), ExecutedAt: at,
+    })
+    assert.ErrorIs(t, err, models.ErrInvalidExchangeActivity)
+}
+
+// TestExchangeLegTransactionID verifies re-imports derive the same IDs
+func TestExchangeLegTransactionID(t *testing.T) {
+    t.Parallel()
+
+    portfolioID := uuid.New()
+    leg := models.ExchangeLeg{Ref: "ETHUSDT:1/base"}
+
+    assert.Equal(t, leg.TransactionID(portfolioID, "binance"), leg.TransactionID(portfolioID, "binance"))
+    assert.NotEqual(t, leg.TransactionID(portfolioID, "binance"), leg.TransactionID(uuid.New(), "binance"))
+    assert.NotEqual(t, leg.TransactionID(portfolioID, "binance"), leg.TransactionID(portfolioID, "kraken"))
+}
+
+// TestCredentialCipher verifies sealed credentials only open for their account
+// during a sync and remain readable after the key is rotated
+func TestCredentialCipher(t *testing.T) {
+    t.Parallel()
+
+    ctx := exchanges.WithSyncAccess(context.Background())
+    oldKey := []byte(strings.Repeat("o", exchanges.MinCredentialKeyLength))
+    newKey := []byte(strings.Repeat("n", exchanges.MinCredentialKeyLength))
+    creds := exchanges.Credentials{APIKey: "key", APISecret: "secret"}
+    portfolioID := uuid.New()
+
+    oldKEK, err := envelope.NewLocalKey(oldKey)
+    require.NoError(t, err)
+    newKEK, err := envelope.NewLocalKey(newKey)
+    require.NoError(t, err)
+    encryptor, err := envelope.NewEncryptor(oldKEK)
+    require.NoError(t, err)
+
+    cipher, err := exchanges.NewCredentialCipher(encryptor, nil)
+    require.NoError(t, err)
+    sealed, err := cipher.Seal(ctx, portfolioID, "kraken", creds)
+    require.NoError(t, err)
+    assert.NotContains(t, string(sealed), "secret")
+    assert.True(t, envelope.IsSealed(sealed))
+
+    opened, err := cipher.Open(ctx, portfolioID, "kraken", sealed)
+    require.NoError(t, err)
+    assert.Equal(t, creds, opened)
+
+    _, err = cipher.Open(context.Background(), portfolioID, "kraken", sealed)
+    assert.ErrorIs(t, err, exchanges.ErrCredentialAccessDenied, "only syncs decrypt")
+    _, err = cipher.Open(ctx, uuid.New(), "kraken", sealed)
+    assert.ErrorIs(t, err, exchanges.ErrUndecryptableCredentials, "bound to the portfolio")
+    _, err = cipher.Open(ctx, portfolioID, "binance", sealed)
+    assert.ErrorIs(t, err, exchanges.ErrUndecryptableCredentials, "bound to the exchange")
+
+    rotatedEncryptor, err := envelope.NewEncryptor(newKEK, oldKEK)
+    require.NoError(t, err)
+    rotated, err := exchanges.NewCredentialCipher(rotatedEncryptor, nil)
+    require.NoError(t, err)
+    opened, err = rotated.Open(ctx, portfolioID, "kraken", sealed)
+    require.NoError(t, err)
+    assert.Equal(t, creds, opened)
+
+    rewrapped, changed, err := rotated.Rewrap(context.Background(), portfolioID, "kraken", sealed)
+    require.NoError(t, err)
+    assert.True(t, changed)
+    _, changed, err = rotated.Rewrap(context.Background(), portfolioID, "kraken", rewrapped)
+    require.NoError(t, err)
+    assert.False(t, changed, "already under the current key")
+
+    retiredEncryptor, err := envelope.NewEncryptor(newKEK)
+    require.NoError(t, err)
+    retired, err := exchanges.NewCredentialCipher(retiredEncryptor, nil)
+    require.NoError(t, err)
+    _, err = retired.Open(ctx, portfolioID, "kraken", sealed)
+    assert.ErrorIs(t, err, exchanges.ErrUndecryptableCredentials)
+    opened, err = retired.Open(ctx, portfolioID, "kraken", rewrapped)
+    require.NoError(t, err)
+    assert.Equal(t, creds, opened)
+
+    _, err = exchanges.NewCredentialCipher(encryptor, [][]byte{[]byte("short")})
+    assert.Error(t, err)
+}
+
+// TestCredentialCipherLegacy verifies credentials sealed directly with a
+// credential key still open and are upgraded to envelope encryption
+func TestCredentialCipherLegacy(t *testing.T) {
+    t.Parallel()
+
+    ctx := exchanges.WithSyncAccess(context.Background())
+    legacyKey := []byte(strings.Repeat("l", exchanges.MinCredentialKeyLength))
+    portfolioID := uuid.New()
+
+    key := sha256.Sum256(legacyKey)
+    block, err := aes.NewCipher(key[:])
+    require.NoError(t, err)
+    aead, err := gocipher.NewGCM(block)
+    require.NoError(t, err)
+    nonce := make([]byte, aead.NonceSize())
+    scope := []byte("exchange-credentials\x00" + portfolioID.String() + "\x00kraken")
+    legacy := aead.Seal(nonce, nonce, []byte(`{"api_key":"key","api_secret":"secret"}`), scope)
+
+    kek, err := envelope.NewLocalKey([]byte(strings.Repeat("k", envelope.MinLocalKeyLength)))
+    require.NoError(t, err)
+    encryptor, err := envelope.NewEncryptor(kek)
+    require.NoError(t, err)
+    cipher, err := exchanges.NewCredentialCipher(encryptor, [][]byte{legacyKey})
+    require.NoError(t, err)
+
+    opened, err := cipher.Open(ctx, portfolioID, "kraken", legacy)
+    require.NoError(t, err)
+    assert.Equal(t, "secret", opened.APISecret)
+
+    upgraded, changed, err := cipher.Rewrap(context.Background(), portfolioID, "kraken", legacy)
+    require.NoError(t, err)
+    assert.True(t, changed)
+    assert.True(t, envelope.IsSealed(upgraded))
+    opened, err = cipher.Open(ctx, portfolioID, "kraken", upgraded)
+    require.NoError(t, err)
+    assert.Equal(t, "secret", opened.APISecret)
+
+    _, _, err = cipher.Rewrap(context.Background(), uuid.New(), "kraken", legacy)
+    assert.ErrorIs(t, err, exchanges.ErrUndecryptableCredentials)
+}
+
+// probeConnector exposes the client handed to a connector
+type probeConnector struct {
+    exchanges.Connector
+    client *exchanges.Client
+}
+
+// TestExchangeClientRetries verifies throttled and failing requests are
+// retried while rejected credentials are not
+func TestExchangeClientRetries(t *testing.T) {
+    t.Parallel()
+
+    var calls int32
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch n := atomic.AddInt32(&calls, 1); {
+        case r.URL.Path == "/auth":
+            w.WriteHeader(http.StatusUnauthorized)
+        case n == 1:
+            w.WriteHeader(http.StatusTooManyRequests)
+        case n == 2:
+            w.WriteHeader(http.StatusServiceUnavailable)
+        default:
+            w.WriteHeader(http.StatusOK)
+        }
+    }))
+    defer server.Close()
+
+    registry := exchanges.NewRegistry(server.Client(), exchanges.RetryPolicy{
+        MaxAttempts: 3,
+        BaseDelay:   time.Millisecond,
+        MaxDelay:    time.Millisecond,
+    })
+    registry.Register(exchanges.Exchange{
+        Name: "probe",
+        New: func(creds exchanges.Credentials, client *exchanges.Client) (exchanges.Connector, error) {
+            return &probeConnector{client: client}, nil
+        },
+    })
+    conn, err := registry.Connect("probe", exchanges.Credentials{APIKey: "key", APISecret: "secret"})
+    require.NoError(t, err)
+    client := conn.(*probeConnector).client
+
+    check := func(resp *exchanges.Response) error {
+        switch {
+        case resp.StatusCode == http.StatusTooManyRequests:
+            return exchanges.ErrRateLimited
+        case resp.StatusCode == http.StatusUnauthorized:
+            return exchanges.ErrInvalidCredentials
+        case resp.StatusCode >= 500:
+            return exchanges.ErrTemporary
+        }
+        return nil
+    }
+    get := func(path string) func() (*http.Request, error) {
+        return func() (*http.Request, error) {
+            return http.NewRequest(http.MethodGet, server.URL+path, nil)
+        }
+    }
+
+    require.NoError(t, client.Do(context.Background(), get("/data"), check))
+    assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+    err = client.Do(context.Background(), get("/auth"), check)
+    assert.ErrorIs(t, err, exchanges.ErrInvalidCredentials)
+    assert.Equal(t, int32(4), atomic.LoadInt32(&calls), "rejected credentials are not retried")
+
+    _, err = registry.Connect("unknown", exchanges.Credentials{APIKey: "key", APISecret: "secret"})
+    assert.ErrorIs(t, err, exchanges.ErrUnsupportedExchange)
+}
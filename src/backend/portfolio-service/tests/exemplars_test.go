@@ -0,0 +1,70 @@
+package tests
+
+import (
+    "context"
+    "testing"
+
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "google.golang.org/grpc/metadata"       // v1.50.0
+
+    "bookman/portfolio-service/internal/exemplars"
+)
+
+// TestParseTraceparent verifies trace IDs are read from well-formed trace
+// context and malformed or invalid values are rejected
+func TestParseTraceparent(t *testing.T) {
+    t.Parallel()
+
+    tests := []struct {
+        name    string
+        value   string
+        traceID string
+        sampled bool
+        ok      bool
+    }{
+        {"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736", true, true},
+        {"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "4bf92f3577b34da6a3ce929d0e0e4736", false, true},
+        {"future version with extra fields", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", "4bf92f3577b34da6a3ce929d0e0e4736", true, true},
+        {"zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", false, false},
+        {"zero span ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", "", false, false},
+        {"forbidden version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", false, false},
+        {"extra fields in version 00", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", "", false, false},
+        {"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", "", false, false},
+        {"short trace ID", "00-4bf92f3577b34da6-00f067aa0ba902b7-01", "", false, false},
+        {"empty", "", "", false, false},
+    }
+
+    for _, tt := range tests {
+        tt := tt
+        t.Run(tt.name, func(t *testing.T) {
+            t.Parallel()
+
+            traceID, sampled, ok := exemplars.ParseTraceparent(tt.value)
+            assert.Equal(t, tt.ok, ok)
+            assert.Equal(t, tt.traceID, traceID)
+            assert.Equal(t, tt.sampled, sampled)
+        })
+    }
+}
+
+// TestExemplarTraceID verifies only sampled traces propagated in incoming
+// metadata are attached to observations
+func TestExemplarTraceID(t *testing.T) {
+    t.Parallel()
+
+    _, ok := exemplars.TraceID(context.Background())
+    assert.False(t, ok)
+
+    sampled := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+        exemplars.MetadataKey, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+    ))
+    traceID, ok := exemplars.TraceID(sampled)
+    assert.True(t, ok)
+    assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+
+    unsampled := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+        exemplars.MetadataKey, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+    ))
+    _, ok = exemplars.TraceID(unsampled)
+    assert.False(t, ok)
+}
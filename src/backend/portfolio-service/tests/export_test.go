@@ -0,0 +1,139 @@
+package tests
+
+import (
+    "bytes"
+    "encoding/csv"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/shopspring/decimal"    // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/export"
+    "bookman/portfolio-service/internal/models"
+)
+
+// exportFixture returns a buy and a reward transaction for a single BTC asset
+func exportFixture() ([]models.Transaction, map[uuid.UUID]string) {
+    assetID := uuid.New()
+    base := time.Date(2023, 3, 14, 9, 30, 0, 0, time.UTC)
+
+    txs := []models.Transaction{
+        {
+            ID:        uuid.New(),
+            AssetID:   assetID,
+            Type:      "reward",
+            Amount:    decimal.RequireFromString("0.01"),
+            Price:     decimal.RequireFromString("25000"),
+            Timestamp: base.Add(time.Hour),
+        },
+        {
+            ID:        uuid.New(),
+            AssetID:   assetID,
+            Type:      "buy",
+            Amount:    decimal.RequireFromString("0.5"),
+            Price:     decimal.RequireFromString("24000"),
+            Fee:       decimal.RequireFromString("12.5"),
+            Timestamp: base,
+        },
+    }
+
+    return txs, map[uuid.UUID]string{assetID: "BTC"}
+}
+
+func readExport(t *testing.T, format export.Format) [][]string {
+    t.Helper()
+
+    txs, symbols := exportFixture()
+    var buf bytes.Buffer
+    require.NoError(t, export.WriteTransactions(&buf, format, txs, symbols))
+
+    records, err := csv.NewReader(&buf).ReadAll()
+    require.NoError(t, err)
+    require.Len(t, records, 3)
+    return records
+}
+
+// TestKoinlyExport verifies the Koinly universal template layout
+func TestKoinlyExport(t *testing.T) {
+    t.Parallel()
+
+    records := readExport(t, export.FormatKoinly)
+
+    assert.Equal(t, "Date", records[0][0])
+    assert.Equal(t, "TxHash", records[0][11])
+
+    // Buy is written first as transactions are ordered oldest first
+    assert.Equal(t, []string{
+        "2023-03-14 09:30 UTC", "12000", "USD", "0.5", "BTC",
+        "12.5", "USD", "12000", "USD", "", "", "",
+    }, records[1])
+
+    assert.Equal(t, "", records[2][1])
+    assert.Equal(t, "0.01", records[2][3])
+    assert.Equal(t, "BTC", records[2][4])
+    assert.Equal(t, "reward", records[2][9])
+}
+
+// TestCoinTrackerExport verifies the CoinTracker import template layout
+func TestCoinTrackerExport(t *testing.T) {
+    t.Parallel()
+
+    records := readExport(t, export.FormatCoinTracker)
+
+    assert.Equal(t, []string{
+        "Date", "Received Quantity", "Received Currency", "Sent Quantity", "Sent Currency",
+        "Fee Amount", "Fee Currency", "Tag",
+    }, records[0])
+    assert.Equal(t, []string{
+        "03/14/2023 09:30:00", "0.5", "BTC", "12000", "USD", "12.5", "USD", "",
+    }, records[1])
+    assert.Equal(t, "staked", records[2][7])
+}
+
+// TestExportErrors verifies unsupported formats and unknown assets are rejected
+func TestExportErrors(t *testing.T) {
+    t.Parallel()
+
+    txs, symbols := exportFixture()
+    var buf bytes.Buffer
+
+    err := export.WriteTransactions(&buf, export.Format("quickbooks"), txs, symbols)
+    assert.ErrorIs(t, err, export.ErrUnsupportedFormat)
+
+    err = export.WriteTransactions(&buf, export.FormatKoinly, txs, map[uuid.UUID]string{})
+    assert.ErrorIs(t, err, export.ErrUnknownAsset)
+}
+
+// TestTransactionWriterBatches verifies batched exports match one-shot exports
+func TestTransactionWriterBatches(t *testing.T) {
+    t.Parallel()
+
+    txs, symbols := exportFixture()
+    var whole bytes.Buffer
+    require.NoError(t, export.WriteTransactions(&whole, export.FormatCSV, txs, symbols))
+
+    var batched bytes.Buffer
+    w, err := export.NewTransactionWriter(&batched, export.FormatCSV, symbols)
+    require.NoError(t, err)
+    require.NoError(t, w.Write(txs[1:]))
+    require.NoError(t, w.Flush())
+    require.NoError(t, w.Write(txs[:1]))
+    require.NoError(t, w.Flush())
+
+    assert.Equal(t, whole.String(), batched.String())
+
+    // Only the header is written for an empty export
+    var empty bytes.Buffer
+    w, err = export.NewTransactionWriter(&empty, export.FormatCSV, symbols)
+    require.NoError(t, err)
+    require.NoError(t, w.Flush())
+    records, err := csv.NewReader(&empty).ReadAll()
+    require.NoError(t, err)
+    assert.Len(t, records, 1)
+
+    _, err = export.NewTransactionWriter(&empty, export.Format("quickbooks"), symbols)
+    assert.ErrorIs(t, err, export.ErrUnsupportedFormat)
+}
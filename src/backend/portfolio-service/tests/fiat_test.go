@@ -0,0 +1,67 @@
+package tests
+
+import (
+    "testing"
+
+    "github.com/google/uuid"                // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestFiatAssetValidation verifies fiat holdings are symbolized by currency
+// codes and only support cash movements
+func TestFiatAssetValidation(t *testing.T) {
+    t.Parallel()
+
+    assert.NoError(t, models.ValidateAssetType(models.FIAT_ASSET_TYPE))
+    assert.NoError(t, models.ValidateFiatCurrency("EUR"))
+    assert.NoError(t, models.ValidateFiatCurrency(" usd "))
+    for _, symbol := range []string{"EURO", "E1R", "", "BTC1"} {
+        assert.ErrorIs(t, models.ValidateFiatCurrency(symbol), models.ErrInvalidAssetType, symbol)
+    }
+
+    for _, transactionType := range models.FIAT_TRANSACTION_TYPES {
+        assert.NoError(t, models.ValidateTransactionType(transactionType, models.FIAT_ASSET_TYPE), transactionType)
+    }
+    assert.Error(t, models.ValidateTransactionType("stake", models.FIAT_ASSET_TYPE))
+    assert.Error(t, models.ValidateTransactionType("reward", models.FIAT_ASSET_TYPE))
+}
+
+// TestFiatValuation verifies base currency balances are valued at 1 without
+// an exchange rate and fiat is allocated to the cash category
+func TestFiatValuation(t *testing.T) {
+    t.Parallel()
+
+    price, ok := models.BaseCurrencyPrice(models.Asset{Type: models.FIAT_ASSET_TYPE, Symbol: models.BASE_CURRENCY})
+    require.True(t, ok)
+    assert.True(t, decimal.NewFromInt(1).Equal(price))
+
+    _, ok = models.BaseCurrencyPrice(models.Asset{Type: models.FIAT_ASSET_TYPE, Symbol: "EUR"})
+    assert.False(t, ok, "other currencies need an exchange rate")
+    _, ok = models.BaseCurrencyPrice(models.Asset{Type: "token", Symbol: models.BASE_CURRENCY})
+    assert.False(t, ok, "only fiat holdings are cash")
+
+    allocation := models.BuildAllocation(uuid.New(), []models.Asset{
+        {ID: uuid.New(), Type: "cryptocurrency", Symbol: "BTC", CurrentValue: decimal.NewFromInt(600)},
+        {ID: uuid.New(), Type: models.FIAT_ASSET_TYPE, Symbol: "USD", CurrentValue: decimal.NewFromInt(300)},
+        {ID: uuid.New(), Type: models.FIAT_ASSET_TYPE, Symbol: "EUR", CurrentValue: decimal.NewFromInt(100)},
+    })
+
+    categories := make(map[string]string)
+    for _, s := range allocation.ByCategory {
+        categories[s.Key] = s.Percentage.String()
+    }
+    assert.Equal(t, map[string]string{
+        models.CategoryLayer1: "60",
+        models.CategoryCash:   "40",
+    }, categories)
+
+    types := make(map[string]string)
+    for _, s := range allocation.ByType {
+        types[s.Key] = s.Percentage.String()
+    }
+    assert.Equal(t, "40", types[models.FIAT_ASSET_TYPE])
+}
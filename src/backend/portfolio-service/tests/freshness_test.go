@@ -0,0 +1,138 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/shopspring/decimal"       // v1.3.1
+    "github.com/stretchr/testify/assert"  // v1.8.0
+    "github.com/stretchr/testify/require" // v1.8.0
+
+    "bookman/portfolio-service/internal/marketdata"
+)
+
+// TestPriceStaleness verifies valuations report the age of their oldest
+// price, flag it once stale and leave out prices past the maximum staleness
+func TestPriceStaleness(t *testing.T) {
+    t.Parallel()
+
+    now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+    prices := map[string]decimal.Decimal{
+        "BTC":  decimal.NewFromInt(60000),
+        "ETH":  decimal.NewFromInt(3000),
+        "USDC": decimal.NewFromInt(1),
+        "NEW":  decimal.NewFromInt(5),
+    }
+    fetchedAt := map[string]time.Time{
+        "BTC":  now.Add(-time.Minute),
+        "ETH":  now.Add(-time.Minute * 3),
+        "USDC": now.Add(-time.Hour * 30),
+    }
+
+    staleness := marketdata.Assess(prices, fetchedAt, now, time.Minute*5, time.Hour*24)
+    assert.Equal(t, now.Add(-time.Minute*3), staleness.AsOf, "oldest used price")
+    assert.False(t, staleness.Stale)
+    assert.Equal(t, []string{"USDC"}, staleness.Dropped)
+    assert.Equal(t, map[string]marketdata.PriceAge{
+        "BTC": {AsOf: now.Add(-time.Minute)},
+        "ETH": {AsOf: now.Add(-time.Minute * 3)},
+    }, staleness.Prices, "ages of used prices with known fetch times")
+
+    // Providers unavailable for 10 minutes: prices are still used but stale
+    later := now.Add(time.Minute * 10)
+    staleness = marketdata.Assess(prices, fetchedAt, later, time.Minute*5, 0)
+    assert.True(t, staleness.Stale)
+    assert.Equal(t, now.Add(-time.Hour*30), staleness.AsOf, "no cutoff keeps every price")
+    assert.Empty(t, staleness.Dropped)
+    assert.True(t, staleness.Prices["BTC"].Stale)
+
+    staleness = marketdata.Assess(map[string]decimal.Decimal{"NEW": decimal.NewFromInt(5)}, fetchedAt, now, time.Minute*5, time.Hour)
+    assert.True(t, staleness.AsOf.IsZero(), "unknown fetch times are taken as fresh")
+    assert.False(t, staleness.Stale)
+}
+
+// fakeFetchLister returns seeded price fetch times and sources, or err
+type fakeFetchLister struct {
+    mutex     sync.Mutex
+    fetchedAt map[string]time.Time
+    sources   map[string]string
+    err       error
+}
+
+func (l *fakeFetchLister) ListPriceFetches(ctx context.Context) (map[string]time.Time, map[string]string, error) {
+    l.mutex.Lock()
+    defer l.mutex.Unlock()
+    if l.err != nil {
+        return nil, nil, l.err
+    }
+    return l.fetchedAt, l.sources, nil
+}
+
+func (l *fakeFetchLister) fail(err error) {
+    l.mutex.Lock()
+    defer l.mutex.Unlock()
+    l.err = err
+}
+
+// TestPriceSourceAttribution verifies valuations attribute each price to the
+// provider that last returned it, and keep the last known sources while they
+// cannot be read
+func TestPriceSourceAttribution(t *testing.T) {
+    t.Parallel()
+
+    ctx := context.Background()
+    now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+    prices := func() map[string]decimal.Decimal {
+        return map[string]decimal.Decimal{
+            "BTC": decimal.NewFromInt(60000),
+            "ETH": decimal.NewFromInt(3000),
+        }
+    }
+
+    lister := &fakeFetchLister{
+        fetchedAt: map[string]time.Time{"BTC": now.Add(-time.Minute), "ETH": now.Add(-time.Minute * 2)},
+        sources:   map[string]string{"BTC": "coingecko"},
+    }
+    freshness, err := marketdata.NewFreshness(lister, time.Minute*5, 0)
+    require.NoError(t, err)
+
+    staleness, err := freshness.Apply(ctx, prices(), now)
+    require.NoError(t, err)
+    assert.Equal(t, "coingecko", staleness.Prices["BTC"].Source)
+    assert.Empty(t, staleness.Prices["ETH"].Source, "prices stored before attribution have no source")
+
+    // Newer fetches replace the source, clearing it when unknown
+    freshness.Record(map[string]string{"ETH": "binance", "BTC": ""}, now.Add(time.Second))
+    staleness, err = freshness.Apply(ctx, prices(), now.Add(time.Second*2))
+    require.NoError(t, err)
+    assert.Equal(t, "binance", staleness.Prices["ETH"].Source)
+    assert.Empty(t, staleness.Prices["BTC"].Source)
+    assert.Equal(t, now.Add(time.Second), staleness.Prices["ETH"].AsOf)
+
+    // Fetches older than the recorded one do not change it
+    freshness.Record(map[string]string{"ETH": "kraken"}, now.Add(-time.Hour))
+    staleness, err = freshness.Apply(ctx, prices(), now.Add(time.Second*3))
+    require.NoError(t, err)
+    assert.Equal(t, "binance", staleness.Prices["ETH"].Source)
+
+    // Failed reloads keep the last sources along with the error
+    lister.fail(errors.New("connection refused"))
+    staleness, err = freshness.Apply(ctx, prices(), now.Add(time.Minute))
+    assert.ErrorContains(t, err, "connection refused")
+    assert.Equal(t, "binance", staleness.Prices["ETH"].Source)
+
+    // Without any fetch times read, prices are kept unattributed
+    failing, err := marketdata.NewFreshness(&fakeFetchLister{err: errors.New("connection refused")}, time.Minute*5, time.Hour)
+    require.NoError(t, err)
+    valued := prices()
+    staleness, err = failing.Apply(ctx, valued, now)
+    assert.Error(t, err)
+    assert.Empty(t, staleness.Prices)
+    assert.Len(t, valued, 2)
+
+    _, err = marketdata.NewFreshness(nil, time.Minute*5, 0)
+    assert.Error(t, err)
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"                // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestSuggestHarvest verifies the suggested sell takes the oldest lots up to
+// the largest loss and flags purchases left unsold within the wash-sale window
+func TestSuggestHarvest(t *testing.T) {
+    t.Parallel()
+
+    asset := models.Asset{ID: uuid.New(), Symbol: "ETH"}
+    txs := []models.Transaction{
+        lotTransaction(asset.ID, 1, "buy", "1", "100"),
+        lotTransaction(asset.ID, 2, "buy", "1", "300"),
+        lotTransaction(asset.ID, 3, "buy", "1", "50"),
+    }
+    replay := models.ReplayLots(nil, txs, models.FeePolicyCapitalize)
+    criteria := models.HarvestCriteria{
+        MinLoss:        decimal.NewFromInt(50),
+        TaxRate:        decimal.RequireFromString("0.3"),
+        WashSaleWindow: 30 * 24 * time.Hour,
+    }
+    at := time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)
+
+    candidate, ok := models.SuggestHarvest(asset, replay, decimal.NewFromInt(150), txs, criteria, at)
+    require.True(t, ok)
+    assert.Equal(t, 2, candidate.Lots, "selling the third lot gives the loss back")
+    assert.True(t, candidate.Quantity.Equal(decimal.NewFromInt(2)), candidate.Quantity.String())
+    assert.True(t, candidate.Loss.Equal(decimal.NewFromInt(100)), candidate.Loss.String())
+    assert.True(t, candidate.LossPercentage.Equal(decimal.NewFromInt(25)), candidate.LossPercentage.String())
+    assert.True(t, candidate.EstimatedTaxSaving.Equal(decimal.NewFromInt(30)), candidate.EstimatedTaxSaving.String())
+    assert.Equal(t, []uuid.UUID{txs[2].ID}, candidate.WashSaleConflicts)
+    assert.Equal(t, at.Add(criteria.WashSaleWindow), candidate.RepurchaseAfter)
+
+    criteria.MinLoss = decimal.NewFromInt(150)
+    _, ok = models.SuggestHarvest(asset, replay, decimal.NewFromInt(150), txs, criteria, at)
+    assert.False(t, ok, "loss below the threshold")
+
+    _, ok = models.SuggestHarvest(asset, replay, decimal.Zero, txs, models.HarvestCriteria{}, at)
+    assert.False(t, ok, "no price to value the lots at")
+
+    assert.ErrorIs(t, models.HarvestCriteria{TaxRate: decimal.NewFromInt(2)}.Validate(), models.ErrInvalidHarvestCriteria)
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"              // v1.3.0
+    "github.com/shopspring/decimal"       // v1.3.1
+    "github.com/stretchr/testify/assert"  // v1.8.0
+    "github.com/stretchr/testify/require" // v1.8.0
+    "go.uber.org/zap"                     // v1.24.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/repository"
+    "bookman/portfolio-service/internal/services"
+)
+
+// TestValueHistoryBucketBoundaries verifies buckets start at UTC midnight, on
+// Mondays and on the first of the month
+func TestValueHistoryBucketBoundaries(t *testing.T) {
+    t.Parallel()
+
+    eastern := time.FixedZone("EST", -5*60*60)
+    utc := func(value string) time.Time {
+        parsed, err := time.Parse(time.RFC3339Nano, value)
+        require.NoError(t, err)
+        return parsed.UTC()
+    }
+
+    tests := []struct {
+        interval models.HistoryInterval
+        at       time.Time
+        want     string
+    }{
+        {models.HistoryIntervalDay, utc("2024-03-10T23:59:59.999Z"), "2024-03-10T00:00:00Z"},
+        {models.HistoryIntervalDay, utc("2024-03-11T00:00:00Z"), "2024-03-11T00:00:00Z"},
+        {models.HistoryIntervalDay, time.Date(2024, 3, 10, 20, 0, 0, 0, eastern), "2024-03-11T00:00:00Z"},
+        {models.HistoryIntervalWeek, utc("2024-03-10T23:59:59Z"), "2024-03-04T00:00:00Z"},
+        {models.HistoryIntervalWeek, utc("2024-03-11T00:00:00Z"), "2024-03-11T00:00:00Z"},
+        {models.HistoryIntervalWeek, utc("2024-03-13T12:00:00Z"), "2024-03-11T00:00:00Z"},
+        {models.HistoryIntervalWeek, utc("2025-01-01T08:00:00Z"), "2024-12-30T00:00:00Z"},
+        {models.HistoryIntervalMonth, utc("2024-02-29T23:59:59Z"), "2024-02-01T00:00:00Z"},
+        {models.HistoryIntervalMonth, utc("2024-03-01T00:00:00Z"), "2024-03-01T00:00:00Z"},
+        {models.HistoryIntervalMonth, time.Date(2024, 12, 31, 22, 0, 0, 0, eastern), "2025-01-01T00:00:00Z"},
+    }
+
+    for _, tt := range tests {
+        assert.Equal(t, utc(tt.want), tt.interval.BucketStart(tt.at), "%s bucket of %s", tt.interval, tt.at)
+    }
+}
+
+// TestDownsampleValues verifies each bucket keeps its closing value, stamped
+// with the bucket start, and that no values yield no buckets
+func TestDownsampleValues(t *testing.T) {
+    t.Parallel()
+
+    point := func(at string, value int64) models.ValuePoint {
+        timestamp, err := time.Parse(time.RFC3339, at)
+        require.NoError(t, err)
+        return models.ValuePoint{Timestamp: timestamp, Value: decimal.NewFromInt(value), ProfitLoss: decimal.NewFromInt(value - 100)}
+    }
+    closes := []models.ValuePoint{
+        point("2024-02-28T23:00:00Z", 100),
+        point("2024-02-29T23:59:59Z", 110),
+        point("2024-03-01T00:00:00Z", 120),
+        point("2024-03-03T12:00:00Z", 130),
+        point("2024-03-04T00:00:00Z", 140),
+    }
+
+    daily := models.DownsampleValues(closes, models.HistoryIntervalDay)
+    require.Len(t, daily, 5)
+    assert.Equal(t, point("2024-02-29T00:00:00Z", 110), daily[1])
+
+    weekly := models.DownsampleValues(closes, models.HistoryIntervalWeek)
+    require.Len(t, weekly, 2)
+    assert.Equal(t, point("2024-02-26T00:00:00Z", 130), weekly[0])
+    assert.Equal(t, point("2024-03-04T00:00:00Z", 140), weekly[1])
+
+    monthly := models.DownsampleValues(closes, models.HistoryIntervalMonth)
+    require.Len(t, monthly, 2)
+    assert.Equal(t, point("2024-02-01T00:00:00Z", 110), monthly[0])
+    assert.Equal(t, point("2024-03-01T00:00:00Z", 140), monthly[1])
+
+    empty := models.DownsampleValues(nil, models.HistoryIntervalWeek)
+    assert.NotNil(t, empty)
+    assert.Empty(t, empty)
+}
+
+// TestValueHistoryValidation verifies invalid intervals and ranges are
+// rejected before the repository is queried
+func TestValueHistoryValidation(t *testing.T) {
+    t.Parallel()
+
+    service, err := services.NewPortfolioService(&repository.PostgresRepository{}, zap.NewNop())
+    require.NoError(t, err)
+
+    ctx := context.Background()
+    portfolioID := uuid.New()
+    end := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+    start := end.AddDate(0, -1, 0)
+
+    tests := []struct {
+        name        string
+        portfolioID uuid.UUID
+        interval    models.HistoryInterval
+        start, end  time.Time
+    }{
+        {"missing portfolio", uuid.Nil, models.HistoryIntervalDay, start, end},
+        {"invalid interval", portfolioID, models.HistoryInterval("hour"), start, end},
+        {"empty interval", portfolioID, models.HistoryInterval(""), start, end},
+        {"empty range", portfolioID, models.HistoryIntervalDay, end, end},
+        {"reversed range", portfolioID, models.HistoryIntervalDay, end, start},
+        {"too many buckets", portfolioID, models.HistoryIntervalDay, end.AddDate(-10, 0, 0), end},
+    }
+
+    for _, tt := range tests {
+        points, err := service.GetValueHistory(ctx, tt.portfolioID, tt.interval, tt.start, tt.end)
+        assert.ErrorIs(t, err, services.ErrInvalidPortfolio, tt.name)
+        assert.Nil(t, points, tt.name)
+    }
+}
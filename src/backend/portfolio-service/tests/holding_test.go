@@ -0,0 +1,63 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"                // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestBuildHoldingPeriodReport verifies sells split their gain by the term of
+// each lot consumed and open lots split unrealized profit and loss by how
+// long they have been held
+func TestBuildHoldingPeriodReport(t *testing.T) {
+    t.Parallel()
+
+    eth := models.Asset{ID: uuid.New(), Symbol: "ETH"}
+    dot := models.Asset{ID: uuid.New(), Symbol: "DOT"}
+    replays := map[uuid.UUID]models.LotReplay{
+        eth.ID: models.ReplayLots(nil, []models.Transaction{
+            lotTransaction(eth.ID, 1, "buy", "2", "100"),
+            lotTransaction(eth.ID, 40, "buy", "1", "200"),
+            lotTransaction(eth.ID, 45, "sell", "2.5", "300"),
+            lotTransaction(eth.ID, 75, "buy", "1", "500"),
+        }, models.FeePolicyCapitalize),
+        dot.ID: models.ReplayLots(nil, []models.Transaction{
+            lotTransaction(dot.ID, 1, "buy", "10", "5"),
+        }, models.FeePolicyCapitalize),
+    }
+    prices := map[string]decimal.Decimal{"ETH": decimal.NewFromInt(400)}
+    longTerm := 30 * 24 * time.Hour
+    start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    at := time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)
+
+    report, err := models.BuildHoldingPeriodReport(uuid.New(), []models.Asset{eth, dot}, replays, prices, longTerm, start, at, at)
+    require.NoError(t, err)
+    require.Len(t, report.Assets, 2)
+    assert.Equal(t, "DOT", report.Assets[0].Symbol)
+    assert.True(t, report.Assets[0].Lots[0].Unpriced, "no current price")
+
+    assert.True(t, report.Realized.LongTerm.Equal(decimal.NewFromInt(400)), report.Realized.LongTerm.String())
+    assert.True(t, report.Realized.ShortTerm.Equal(decimal.NewFromInt(50)), report.Realized.ShortTerm.String())
+    assert.True(t, report.Unrealized.LongTerm.Equal(decimal.NewFromInt(100)), report.Unrealized.LongTerm.String())
+    assert.True(t, report.Unrealized.ShortTerm.Equal(decimal.NewFromInt(-100)), report.Unrealized.ShortTerm.String())
+
+    lots := report.Assets[1].Lots
+    require.Len(t, lots, 2)
+    assert.Equal(t, models.HoldingLongTerm, lots[0].Term)
+    assert.Equal(t, models.HoldingShortTerm, lots[1].Term)
+    assert.Equal(t, lots[1].AcquiredAt.Add(longTerm), lots[1].LongTermAt)
+
+    report, err = models.BuildHoldingPeriodReport(uuid.New(), []models.Asset{eth}, replays, prices, 0, start, at, at)
+    require.NoError(t, err)
+    assert.True(t, report.Realized.LongTerm.IsZero(), "no lot is long-term without a long-term period")
+    assert.True(t, report.Assets[0].Lots[0].LongTermAt.IsZero())
+
+    _, err = models.BuildHoldingPeriodReport(uuid.New(), nil, nil, nil, -time.Hour, start, at, at)
+    assert.ErrorIs(t, err, models.ErrInvalidHoldingPeriod)
+}
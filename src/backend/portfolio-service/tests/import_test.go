@@ -0,0 +1,203 @@
+package tests
+
+import (
+    "bytes"
+    "fmt"
+    "io"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"               // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/export"
+    "bookman/portfolio-service/internal/importer"
+    "bookman/portfolio-service/internal/models"
+)
+
+// templateMapping returns a built-in mapping or fails the test
+func templateMapping(t *testing.T, template importer.Template) importer.Mapping {
+    t.Helper()
+    m, err := importer.TemplateMapping(template)
+    require.NoError(t, err)
+    return m
+}
+
+// TestImportNativeRoundTrip verifies the native template reads the CSV export
+func TestImportNativeRoundTrip(t *testing.T) {
+    t.Parallel()
+
+    assetID := uuid.New()
+    tx := models.Transaction{
+        ID:        uuid.New(),
+        AssetID:   assetID,
+        Type:      "buy",
+        Amount:    decimal.RequireFromString("1.5"),
+        Price:     decimal.RequireFromString("2000"),
+        Fee:       decimal.RequireFromString("3"),
+        Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+    }
+
+    var buf bytes.Buffer
+    require.NoError(t, export.WriteTransactions(&buf, export.FormatCSV, []models.Transaction{tx}, map[uuid.UUID]string{assetID: "ETH"}))
+
+    result, err := importer.ParseTransactions(&buf, templateMapping(t, importer.TemplateNative))
+    require.NoError(t, err)
+    require.Empty(t, result.Errors)
+    require.Len(t, result.Rows, 1)
+
+    row := result.Rows[0]
+    assert.Equal(t, 2, row.Line)
+    assert.Equal(t, tx.ID.String(), row.Reference)
+    assert.Equal(t, "ETH", row.Symbol)
+    assert.Equal(t, "buy", row.Type)
+    assert.True(t, row.Timestamp.Equal(tx.Timestamp))
+    assert.True(t, row.Amount.Equal(tx.Amount))
+    assert.True(t, row.Price.Equal(tx.Price))
+    assert.True(t, row.Fee.Equal(tx.Fee))
+}
+
+// TestImportBinanceTemplate verifies pair symbols and unit-suffixed amounts
+// of the Binance trade history export
+func TestImportBinanceTemplate(t *testing.T) {
+    t.Parallel()
+
+    file := "Date(UTC),Pair,Side,Price,Executed,Amount,Fee\n" +
+        "2024-03-01 12:00:00,BTCUSDT,BUY,60000,0.01BTC,600USDT,0.00001BTC\n" +
+        "2024-03-01 13:00:00,ETHUSDT,SELL,\"3,000\",1ETH,3000USDT,3USDT\n" +
+        "2024-03-01 14:00:00,SOLUSDT,BUY,100,2SOL,200USDT,0.001BNB\n"
+
+    result, err := importer.ParseTransactions(strings.NewReader(file), templateMapping(t, importer.TemplateBinance))
+    require.NoError(t, err)
+    require.Len(t, result.Rows, 2)
+
+    btc := result.Rows[0]
+    assert.Equal(t, "BTC", btc.Symbol)
+    assert.Equal(t, "buy", btc.Type)
+    assert.True(t, btc.Amount.Equal(decimal.RequireFromString("0.01")))
+    assert.True(t, btc.Fee.Equal(decimal.RequireFromString("0.6")), "base fee converted at the row price")
+
+    eth := result.Rows[1]
+    assert.Equal(t, "ETH", eth.Symbol)
+    assert.Equal(t, "sell", eth.Type)
+    assert.True(t, eth.Price.Equal(decimal.NewFromInt(3000)))
+    assert.True(t, eth.Fee.Equal(decimal.NewFromInt(3)))
+
+    require.Len(t, result.Errors, 1)
+    assert.Equal(t, 4, result.Errors[0].Line)
+    assert.Equal(t, "Fee", result.Errors[0].Column)
+}
+
+// TestImportCoinbaseTemplate verifies leading report lines are skipped, types
+// are mapped and unmapped types are reported
+func TestImportCoinbaseTemplate(t *testing.T) {
+    t.Parallel()
+
+    file := "Transactions\n" +
+        "User,someone,id\n" +
+        "\n" +
+        "Timestamp,Transaction Type,Asset,Quantity Transacted,Spot Price Currency,Spot Price at Transaction,Subtotal,Total (inclusive of fees and/or spread),Fees and/or Spread,Notes\n" +
+        "2024-02-01 10:00:00 UTC,Buy,BTC,0.002,USD,\"$50,000.00\",$100.00,$101.99,$1.99,Bought\n" +
+        "2024-02-02T10:00:00Z,Staking Income,ETH,0.01,USD,$2300,,,,\n" +
+        "2024-02-03 10:00:00 UTC,Convert,ETH,1,USD,$2300,,,,Converted\n" +
+        "2024-02-04 10:00:00 UTC,Margin Call,ETH,1,USD,$2300,,,,\n" +
+        "yesterday,Send,ETH,-0.5,USD,$2300,,,,\n"
+
+    result, err := importer.ParseTransactions(strings.NewReader(file), templateMapping(t, importer.TemplateCoinbase))
+    require.NoError(t, err)
+    require.Len(t, result.Rows, 2)
+    assert.Equal(t, 1, result.Skipped)
+
+    buy := result.Rows[0]
+    assert.Equal(t, "buy", buy.Type)
+    assert.True(t, buy.Price.Equal(decimal.NewFromInt(50000)))
+    assert.True(t, buy.Fee.Equal(decimal.RequireFromString("1.99")))
+    assert.Equal(t, "reward", result.Rows[1].Type)
+
+    require.Len(t, result.Errors, 2)
+    assert.Equal(t, 8, result.Errors[0].Line)
+    assert.Equal(t, "Transaction Type", result.Errors[0].Column)
+    assert.Equal(t, 9, result.Errors[1].Line)
+    assert.Equal(t, "Timestamp", result.Errors[1].Column)
+}
+
+// TestImportCustomMapping verifies user mappings override templates and are
+// validated
+func TestImportCustomMapping(t *testing.T) {
+    t.Parallel()
+
+    _, err := importer.TemplateMapping("unknown")
+    assert.ErrorIs(t, err, importer.ErrUnknownTemplate)
+
+    _, err = importer.ParseTransactions(strings.NewReader("a,b\n"), importer.Mapping{Timestamp: "a", Type: "b"})
+    assert.ErrorIs(t, err, importer.ErrInvalidMapping)
+
+    mapping := templateMapping(t, importer.TemplateNative).Merge(importer.Mapping{
+        Timestamp:  "When",
+        Symbol:     "Coin",
+        TimeLayout: "02.01.2006",
+    })
+    file := "When,Type,Coin,Amount\n15.03.2024,transfer_in,dot,10\n"
+
+    _, err = importer.ParseTransactions(strings.NewReader(file), mapping)
+    assert.ErrorIs(t, err, importer.ErrInvalidFile, "price, fee and reference columns are still mapped")
+
+    mapping.Price, mapping.Fee, mapping.Reference = "", "", ""
+    result, err := importer.ParseTransactions(strings.NewReader(file), mapping)
+    require.NoError(t, err)
+    require.Len(t, result.Rows, 1)
+    assert.Equal(t, "DOT", result.Rows[0].Symbol)
+    assert.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), result.Rows[0].Timestamp)
+}
+
+// TestImportedTransactionID verifies re-imports derive the same IDs per portfolio
+func TestImportedTransactionID(t *testing.T) {
+    t.Parallel()
+
+    portfolioID := uuid.New()
+    assert.Equal(t, models.ImportedTransactionID(portfolioID, "row#1"), models.ImportedTransactionID(portfolioID, "row#1"))
+    assert.NotEqual(t, models.ImportedTransactionID(portfolioID, "row#1"), models.ImportedTransactionID(portfolioID, "row#2"))
+    assert.NotEqual(t, models.ImportedTransactionID(portfolioID, "row#1"), models.ImportedTransactionID(uuid.New(), "row#1"))
+}
+
+// TestImportParserBatches verifies bulk import files are read in batches of
+// data rows, keeping line numbers and enforcing the row limit across batches
+func TestImportParserBatches(t *testing.T) {
+    t.Parallel()
+
+    var file strings.Builder
+    file.WriteString("Date,Type,Symbol,Amount,Price,Fee,Transaction ID\n")
+    for i := 0; i < 5; i++ {
+        fmt.Fprintf(&file, "2024-01-0%d,buy,BTC,1,100,0,tx-%d\n", i+1, i)
+        if i == 2 {
+            file.WriteString("\n")
+            file.WriteString("2024-01-09,buy,BTC,-x,100,0,bad\n")
+        }
+    }
+
+    parser, err := importer.NewParser(strings.NewReader(file.String()), templateMapping(t, importer.TemplateNative), importer.MaxStreamRows)
+    require.NoError(t, err)
+
+    first, err := parser.Next(3)
+    require.NoError(t, err)
+    require.Len(t, first.Rows, 3)
+    assert.Equal(t, 2, first.Rows[0].Line)
+
+    second, err := parser.Next(3)
+    require.NoError(t, err)
+    require.Len(t, second.Errors, 1)
+    assert.Equal(t, 6, second.Errors[0].Line, "blank lines are not counted as rows")
+    require.Len(t, second.Rows, 2)
+    assert.Equal(t, "tx-4", second.Rows[1].Reference)
+
+    _, err = parser.Next(3)
+    assert.Equal(t, io.EOF, err)
+
+    limited, err := importer.NewParser(strings.NewReader(file.String()), templateMapping(t, importer.TemplateNative), 4)
+    require.NoError(t, err)
+    _, err = limited.Next(0)
+    assert.ErrorIs(t, err, importer.ErrInvalidFile)
+}
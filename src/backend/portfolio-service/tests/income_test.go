@@ -0,0 +1,58 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"                // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestBuildIncomeReport verifies income receipts are grouped by period, asset
+// and type, valued at their recorded price or else the day's close
+func TestBuildIncomeReport(t *testing.T) {
+    t.Parallel()
+
+    eth, dot := uuid.New(), uuid.New()
+    symbols := map[uuid.UUID]string{eth: "ETH", dot: "DOT"}
+    txs := []models.Transaction{
+        lotTransaction(eth, 3, "buy", "5", "90"),
+        lotTransaction(eth, 5, "reward", "1", "100"),
+        lotTransaction(dot, 10, "interest", "10", "0"),
+        lotTransaction(eth, 40, "reward", "2", "0"),
+        lotTransaction(eth, 70, "airdrop", "1", "50"),
+    }
+    closes := map[string][]models.PricePoint{
+        "ETH": {{Symbol: "ETH", Timestamp: time.Date(2024, 2, 9, 0, 0, 0, 0, time.UTC), Close: decimal.NewFromInt(120)}},
+    }
+    start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    end := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+    report, err := models.BuildIncomeReport(uuid.New(), models.ReportPeriodMonth, models.CalendarTaxYear, start, end, txs, symbols, closes, end)
+    require.NoError(t, err)
+    require.Len(t, report.Periods, 2)
+    assert.True(t, report.Value.Equal(decimal.NewFromInt(340)), report.Value.String())
+
+    january := report.Periods[0]
+    require.Len(t, january.Lines, 2, "buys are not income")
+    assert.Equal(t, "DOT", january.Lines[0].Symbol)
+    assert.Equal(t, 1, january.Lines[0].Unvalued, "no price or close to value it at")
+    assert.True(t, january.Value.Equal(decimal.NewFromInt(100)), january.Value.String())
+
+    february := report.Periods[1]
+    require.Len(t, february.Lines, 1, "receipts after the range are left out")
+    assert.True(t, february.Lines[0].Value.Equal(decimal.NewFromInt(240)), "valued at the day's close")
+
+    require.Len(t, report.Totals, 2)
+    total := report.Totals[1]
+    assert.Equal(t, "reward", total.Type)
+    assert.Equal(t, 2, total.Receipts)
+    assert.True(t, total.Amount.Equal(decimal.NewFromInt(3)), total.Amount.String())
+
+    _, err = models.BuildIncomeReport(uuid.New(), models.ReportPeriodMonth, models.CalendarTaxYear, end, end, txs, symbols, closes, end)
+    assert.ErrorIs(t, err, models.ErrInvalidIncomeReport)
+}
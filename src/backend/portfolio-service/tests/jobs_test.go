@@ -0,0 +1,185 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+    "go.uber.org/zap"                       // v1.24.0
+
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/jobs"
+)
+
+// memoryLocker grants each key to one holder at a time
+type memoryLocker struct {
+    mutex sync.Mutex
+    held  map[int64]*memoryLock
+}
+
+type memoryLock struct {
+    locker *memoryLocker
+    key    int64
+    lost   atomic.Bool
+}
+
+func (l *memoryLocker) TryLock(ctx context.Context, key int64) (jobs.Lock, error) {
+    l.mutex.Lock()
+    defer l.mutex.Unlock()
+
+    if l.held == nil {
+        l.held = make(map[int64]*memoryLock)
+    }
+    if _, ok := l.held[key]; ok {
+        return nil, nil
+    }
+    lock := &memoryLock{locker: l, key: key}
+    l.held[key] = lock
+    return lock, nil
+}
+
+func (l *memoryLocker) holder(key int64) *memoryLock {
+    l.mutex.Lock()
+    defer l.mutex.Unlock()
+    return l.held[key]
+}
+
+func (l *memoryLock) Check(ctx context.Context) error {
+    if l.lost.Load() {
+        return errors.New("connection closed")
+    }
+    return nil
+}
+
+func (l *memoryLock) Release(ctx context.Context) error {
+    l.locker.mutex.Lock()
+    defer l.locker.mutex.Unlock()
+    if l.locker.held[l.key] == l {
+        delete(l.locker.held, l.key)
+    }
+    return nil
+}
+
+// TestJobCoordinatorLeaderElection verifies a job runs on one instance at a
+// time and moves to another when the leader loses its lock or stops
+func TestJobCoordinatorLeaderElection(t *testing.T) {
+    t.Parallel()
+
+    cfg := config.JobsConfig{
+        LeaderElection: true,
+        RetryInterval:  10 * time.Millisecond,
+        CheckInterval:  5 * time.Millisecond,
+    }
+    locker := &memoryLocker{}
+    key := jobs.LockKey("snapshots")
+
+    var running, starts, overlaps atomic.Int32
+    job := func(ctx context.Context) {
+        if running.Add(1) > 1 {
+            overlaps.Add(1)
+        }
+        starts.Add(1)
+        <-ctx.Done()
+        running.Add(-1)
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    var wg sync.WaitGroup
+    for i := 0; i < 3; i++ {
+        coordinator, err := jobs.NewCoordinator(locker, cfg, zap.NewNop())
+        require.NoError(t, err)
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            coordinator.Run(ctx, "snapshots", job)
+        }()
+    }
+
+    require.Eventually(t, func() bool { return starts.Load() == 1 }, time.Second, time.Millisecond)
+    first := locker.holder(key)
+    require.NotNil(t, first)
+
+    // Losing the lock stops the job and another instance takes over
+    first.lost.Store(true)
+    require.Eventually(t, func() bool {
+        holder := locker.holder(key)
+        return starts.Load() == 2 && running.Load() == 1 && holder != nil && holder != first
+    }, time.Second, time.Millisecond)
+
+    cancel()
+    wg.Wait()
+    assert.Zero(t, running.Load())
+    assert.Zero(t, overlaps.Load())
+    assert.Nil(t, locker.holder(key), "stopping releases the lock")
+}
+
+// TestJobCoordinatorWithoutElection verifies jobs run unconditionally when
+// leader election is disabled
+func TestJobCoordinatorWithoutElection(t *testing.T) {
+    t.Parallel()
+
+    coordinator, err := jobs.NewCoordinator(nil, config.JobsConfig{}, zap.NewNop())
+    require.NoError(t, err)
+
+    ran := false
+    coordinator.Run(context.Background(), "verifier", func(ctx context.Context) { ran = true })
+    assert.True(t, ran)
+
+    _, err = jobs.NewCoordinator(nil, config.JobsConfig{LeaderElection: true}, zap.NewNop())
+    assert.Error(t, err)
+
+    assert.Equal(t, jobs.LockKey("snapshots"), jobs.LockKey("snapshots"))
+    assert.NotEqual(t, jobs.LockKey("snapshots"), jobs.LockKey("price_refresh"))
+}
+
+// TestJobTriggers verifies triggered jobs run once in the background, are
+// refused while running and take the lock of exclusive jobs
+func TestJobTriggers(t *testing.T) {
+    t.Parallel()
+
+    locker := &memoryLocker{}
+    triggers, err := jobs.NewTriggers(context.Background(), locker, zap.NewNop())
+    require.NoError(t, err)
+
+    release := make(chan struct{})
+    var runs atomic.Int32
+    triggers.Register("snapshots", true, func(ctx context.Context) error {
+        runs.Add(1)
+        <-release
+        return nil
+    })
+    triggers.Register("exchange_syncs", false, func(ctx context.Context) error {
+        return errors.New("exchange unavailable")
+    })
+    assert.Equal(t, []string{"exchange_syncs", "snapshots"}, triggers.Names())
+
+    require.NoError(t, triggers.Trigger(context.Background(), "snapshots"))
+    require.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, time.Millisecond)
+    assert.NotNil(t, locker.holder(jobs.LockKey("snapshots")), "exclusive runs hold the job lock")
+    assert.ErrorIs(t, triggers.Trigger(context.Background(), "snapshots"), jobs.ErrJobRunning)
+
+    close(release)
+    require.Eventually(t, func() bool { return locker.holder(jobs.LockKey("snapshots")) == nil }, time.Second, time.Millisecond)
+    require.Eventually(t, func() bool {
+        return triggers.Trigger(context.Background(), "snapshots") == nil
+    }, time.Second, time.Millisecond)
+    require.Eventually(t, func() bool { return runs.Load() == 2 }, time.Second, time.Millisecond)
+
+    // A job held by another replica is refused
+    require.Eventually(t, func() bool { return locker.holder(jobs.LockKey("snapshots")) == nil }, time.Second, time.Millisecond)
+    other, err := locker.TryLock(context.Background(), jobs.LockKey("snapshots"))
+    require.NoError(t, err)
+    require.Eventually(t, func() bool {
+        return errors.Is(triggers.Trigger(context.Background(), "snapshots"), jobs.ErrJobRunning)
+    }, time.Second, time.Millisecond)
+    require.NoError(t, other.Release(context.Background()))
+
+    // Failed runs are logged without affecting the caller
+    assert.NoError(t, triggers.Trigger(context.Background(), "exchange_syncs"))
+    assert.ErrorIs(t, triggers.Trigger(context.Background(), "verifier"), jobs.ErrUnknownJob)
+}
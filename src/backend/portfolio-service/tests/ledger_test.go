@@ -0,0 +1,132 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"               // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// ledgerStream builds a sequenced event stream for a portfolio, one hour apart
+func ledgerStream(t *testing.T, portfolioID uuid.UUID, start time.Time, changes ...interface{}) []models.LedgerEvent {
+    t.Helper()
+
+    events := make([]models.LedgerEvent, 0, len(changes)/2)
+    for i := 0; i < len(changes); i += 2 {
+        e, err := models.NewLedgerEvent(portfolioID, changes[i].(models.LedgerEventType), changes[i+1], start.Add(time.Duration(i/2)*time.Hour))
+        require.NoError(t, err)
+        e.Sequence = int64(len(events) + 1)
+        events = append(events, e)
+    }
+    return events
+}
+
+// TestLedgerReplay verifies the read model is rebuilt from the event stream
+// and can be reconstructed as of an earlier time
+func TestLedgerReplay(t *testing.T) {
+    t.Parallel()
+
+    portfolioID, userID := uuid.New(), uuid.New()
+    btc := models.Asset{ID: uuid.New(), Type: "crypto", Symbol: "BTC", Amount: decimal.NewFromInt(1)}
+    eth := models.Asset{ID: uuid.New(), Type: "crypto", Symbol: "ETH", Amount: decimal.NewFromInt(10)}
+    start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+    events := ledgerStream(t, portfolioID, start,
+        models.LedgerPortfolioCreated, models.LedgerPortfolioDetails{UserID: userID, Name: "Main"},
+        models.LedgerAssetAdded, btc,
+        models.LedgerAssetAdded, eth,
+        models.LedgerTransactionRecorded, models.Transaction{ID: uuid.New(), PortfolioID: portfolioID, AssetID: btc.ID, Type: "buy", Amount: decimal.NewFromInt(1)},
+        models.LedgerAssetRemoved, models.LedgerAssetRef{AssetID: btc.ID},
+        models.LedgerPortfolioUpdated, models.LedgerPortfolioDetails{Name: "Renamed"},
+    )
+
+    current, err := models.ReplayLedger(events, time.Time{})
+    require.NoError(t, err)
+    assert.Equal(t, int64(6), current.Sequence)
+    assert.Equal(t, userID, current.Portfolio.UserID)
+    assert.Equal(t, "Renamed", current.Portfolio.Name)
+    require.Len(t, current.Portfolio.Assets, 1)
+    assert.Equal(t, "ETH", current.Portfolio.Assets[0].Symbol)
+    assert.Len(t, current.Transactions, 1)
+    assert.Equal(t, start, current.Portfolio.CreatedAt)
+    assert.Equal(t, start.Add(5*time.Hour), current.Portfolio.LastUpdated)
+
+    past, err := models.ReplayLedger(events, start.Add(3*time.Hour+30*time.Minute))
+    require.NoError(t, err)
+    assert.Equal(t, int64(4), past.Sequence)
+    assert.Equal(t, "Main", past.Portfolio.Name)
+    assert.Len(t, past.Portfolio.Assets, 2)
+
+    _, err = models.ReplayLedger(events, start.Add(-time.Minute))
+    assert.ErrorIs(t, err, models.ErrInvalidLedgerEvent)
+}
+
+// TestLedgerApplyRejectsInvalidStreams verifies gaps and impossible changes fail
+func TestLedgerApplyRejectsInvalidStreams(t *testing.T) {
+    t.Parallel()
+
+    portfolioID := uuid.New()
+    start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+    created := ledgerStream(t, portfolioID, start, models.LedgerPortfolioCreated, models.LedgerPortfolioDetails{Name: "Main"})
+
+    gap := ledgerStream(t, portfolioID, start, models.LedgerAssetRemoved, models.LedgerAssetRef{AssetID: uuid.New()})
+    gap[0].Sequence = 3
+    _, err := models.ReplayLedger(append(created, gap...), time.Time{})
+    assert.ErrorIs(t, err, models.ErrLedgerSequence)
+
+    missing := ledgerStream(t, portfolioID, start,
+        models.LedgerPortfolioCreated, models.LedgerPortfolioDetails{Name: "Main"},
+        models.LedgerAssetRemoved, models.LedgerAssetRef{AssetID: uuid.New()},
+    )
+    _, err = models.ReplayLedger(missing, time.Time{})
+    assert.ErrorIs(t, err, models.ErrInvalidLedgerEvent)
+
+    orphan := ledgerStream(t, portfolioID, start, models.LedgerAssetAdded, models.Asset{ID: uuid.New()})
+    _, err = models.ReplayLedger(orphan, time.Time{})
+    assert.ErrorIs(t, err, models.ErrInvalidLedgerEvent)
+}
+
+// TestLedgerArchiveRestore verifies archiving replays onto the portfolio and
+// rejects repeated archives and restores
+func TestLedgerArchiveRestore(t *testing.T) {
+    t.Parallel()
+
+    portfolioID := uuid.New()
+    start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+    events := ledgerStream(t, portfolioID, start,
+        models.LedgerPortfolioCreated, models.LedgerPortfolioDetails{Name: "Main"},
+        models.LedgerPortfolioArchived, nil,
+        models.LedgerPortfolioRestored, nil,
+        models.LedgerPortfolioArchived, nil,
+    )
+
+    archived, err := models.ReplayLedger(events, time.Time{})
+    require.NoError(t, err)
+    require.NotNil(t, archived.Portfolio.ArchivedAt)
+    assert.Equal(t, start.Add(3*time.Hour), *archived.Portfolio.ArchivedAt)
+
+    restored, err := models.ReplayLedger(events, start.Add(2*time.Hour))
+    require.NoError(t, err)
+    assert.Nil(t, restored.Portfolio.ArchivedAt)
+
+    twice := ledgerStream(t, portfolioID, start,
+        models.LedgerPortfolioCreated, models.LedgerPortfolioDetails{Name: "Main"},
+        models.LedgerPortfolioArchived, nil,
+        models.LedgerPortfolioArchived, nil,
+    )
+    _, err = models.ReplayLedger(twice, time.Time{})
+    assert.ErrorIs(t, err, models.ErrInvalidLedgerEvent)
+
+    unarchived := ledgerStream(t, portfolioID, start,
+        models.LedgerPortfolioCreated, models.LedgerPortfolioDetails{Name: "Main"},
+        models.LedgerPortfolioRestored, nil,
+    )
+    _, err = models.ReplayLedger(unarchived, time.Time{})
+    assert.ErrorIs(t, err, models.ErrInvalidLedgerEvent)
+}
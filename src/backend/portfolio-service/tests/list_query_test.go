@@ -0,0 +1,75 @@
+package tests
+
+import (
+    "testing"
+
+    "github.com/shopspring/decimal"    // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/repository"
+    "bookman/portfolio-service/internal/services"
+)
+
+// TestParsePortfolioOrder verifies sort orders are limited to known fields
+func TestParsePortfolioOrder(t *testing.T) {
+    t.Parallel()
+
+    testCases := []struct {
+        orderBy  string
+        expected repository.PortfolioOrder
+        valid    bool
+    }{
+        {orderBy: "", expected: repository.PortfolioOrder{}, valid: true},
+        {orderBy: "name", expected: repository.PortfolioOrder{Field: repository.OrderName}, valid: true},
+        {orderBy: "total_value DESC", expected: repository.PortfolioOrder{Field: repository.OrderTotalValue, Descending: true}, valid: true},
+        {orderBy: " created_at  asc ", expected: repository.PortfolioOrder{Field: repository.OrderCreatedAt}, valid: true},
+        {orderBy: "profit_loss"},
+        {orderBy: "name sideways"},
+        {orderBy: "name; DROP TABLE portfolios"},
+    }
+
+    for _, tc := range testCases {
+        tc := tc
+        t.Run(tc.orderBy, func(t *testing.T) {
+            order, err := services.ParsePortfolioOrder(tc.orderBy)
+            if !tc.valid {
+                assert.ErrorIs(t, err, services.ErrInvalidListQuery)
+                return
+            }
+            require.NoError(t, err)
+            assert.Equal(t, tc.expected, order)
+        })
+    }
+}
+
+// TestParsePortfolioFilter verifies filter expressions and their rejection
+func TestParsePortfolioFilter(t *testing.T) {
+    t.Parallel()
+
+    filter, err := services.ParsePortfolioFilter(`asset_type = token and total_value>=1000.5 AND tag = "Long Term"`)
+    require.NoError(t, err)
+    assert.Equal(t, "token", filter.AssetType)
+    assert.Equal(t, "long term", filter.Tag)
+    require.True(t, filter.MinValue.Valid)
+    assert.True(t, decimal.RequireFromString("1000.5").Equal(filter.MinValue.Decimal))
+
+    empty, err := services.ParsePortfolioFilter("  ")
+    require.NoError(t, err)
+    assert.Equal(t, repository.PortfolioFilter{}, empty)
+
+    for _, expr := range []string{
+        "asset_type = stock",
+        "total_value = 1000",
+        "total_value >= lots",
+        "name = savings",
+        "tag = a AND",
+        "tag = a tag = b",
+        "tag = a AND tag = b",
+        `tag = "unterminated`,
+        "tag = x' OR '1'='1",
+    } {
+        _, err := services.ParsePortfolioFilter(expr)
+        assert.ErrorIs(t, err, services.ErrInvalidListQuery, expr)
+    }
+}
@@ -0,0 +1,191 @@
+package tests
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"  // v1.8.0
+    "github.com/stretchr/testify/require" // v1.8.0
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/health/grpc_health_v1"
+    "google.golang.org/grpc/status"
+
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/loadshed"
+)
+
+// TestLoadShedQueue verifies calls over the limit wait for a slot and are
+// rejected when the queue is full or no slot frees up in time
+func TestLoadShedQueue(t *testing.T) {
+    t.Parallel()
+
+    shedder, err := loadshed.NewShedder(config.LoadSheddingConfig{
+        Enabled:      true,
+        InitialLimit: 2,
+        MinLimit:     2,
+        MaxLimit:     2,
+        Tolerance:    1.5,
+        QueueSize:    1,
+        QueueTimeout: time.Second,
+    })
+    require.NoError(t, err)
+
+    ctx := context.Background()
+    method := "/portfolio.PortfolioService/GetPortfolio"
+    first, err := shedder.Acquire(ctx, method)
+    require.NoError(t, err)
+    _, err = shedder.Acquire(ctx, method)
+    require.NoError(t, err)
+
+    queued := make(chan error, 1)
+    go func() {
+        release, err := shedder.Acquire(ctx, method)
+        if err == nil {
+            defer release(time.Millisecond, true)
+        }
+        queued <- err
+    }()
+
+    // Wait until the call is queued, which fills the queue
+    require.Eventually(t, func() bool {
+        _, err := shedder.Acquire(ctx, method)
+        return assert.ObjectsAreEqual(loadshed.ErrQueueFull, err)
+    }, time.Second, time.Millisecond)
+
+    first(time.Millisecond, true)
+    select {
+    case err := <-queued:
+        assert.NoError(t, err, "granted the released slot")
+    case <-time.After(time.Second):
+        t.Fatal("queued call was not granted a slot")
+    }
+
+    other := "/portfolio.PortfolioService/ListPortfolios"
+    short, err := loadshed.NewShedder(config.LoadSheddingConfig{
+        InitialLimit: 1, MinLimit: 1, MaxLimit: 1, Tolerance: 1.5, QueueSize: 1, QueueTimeout: time.Millisecond * 10,
+    })
+    require.NoError(t, err)
+    _, err = short.Acquire(ctx, other)
+    require.NoError(t, err)
+    _, err = short.Acquire(ctx, other)
+    assert.ErrorIs(t, err, loadshed.ErrQueueTimeout)
+
+    cancelled, cancel := context.WithCancel(ctx)
+    cancel()
+    _, err = short.Acquire(cancelled, other)
+    assert.ErrorIs(t, err, context.Canceled)
+}
+
+// TestLoadShedAdaptiveLimit verifies the limit grows while saturated calls
+// keep their latency and shrinks once they slow down
+func TestLoadShedAdaptiveLimit(t *testing.T) {
+    t.Parallel()
+
+    shedder, err := loadshed.NewShedder(config.LoadSheddingConfig{
+        Enabled:      true,
+        InitialLimit: 10,
+        MinLimit:     4,
+        MaxLimit:     50,
+        Tolerance:    1.5,
+    })
+    require.NoError(t, err)
+
+    ctx := context.Background()
+    method := "/portfolio.PortfolioService/GetPortfolio"
+
+    // saturate runs rounds of as many concurrent calls as the limit allows
+    saturate := func(rounds int, latency time.Duration) {
+        for i := 0; i < rounds; i++ {
+            var releases []func(time.Duration, bool)
+            for j := shedder.Limit(method); j > 0; j-- {
+                release, err := shedder.Acquire(ctx, method)
+                require.NoError(t, err)
+                releases = append(releases, release)
+            }
+            for _, release := range releases {
+                release(latency, true)
+            }
+        }
+    }
+
+    saturate(20, time.Millisecond*10)
+    grown := shedder.Limit(method)
+    assert.Greater(t, grown, 10, "steady latency")
+    assert.LessOrEqual(t, grown, 50)
+
+    saturate(20, time.Millisecond*200)
+    assert.Less(t, shedder.Limit(method), grown, "latency rose")
+    assert.GreaterOrEqual(t, shedder.Limit(method), 4)
+
+    _, err = loadshed.NewShedder(config.LoadSheddingConfig{InitialLimit: 1, MinLimit: 2, MaxLimit: 4, Tolerance: 1.5})
+    assert.Error(t, err, "initial limit below the minimum")
+}
+
+// TestLoadShedStreams verifies streams hold their method's slot until they
+// end, are rejected with ResourceExhausted while it is taken, and do not
+// adapt the limit. Exempt streams are never held back.
+func TestLoadShedStreams(t *testing.T) {
+    t.Parallel()
+
+    ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+    defer cancel()
+
+    // open starts a stream on conn and reports the status it ends with or
+    // nil once the server has started it
+    open := func(ctx context.Context, conn *grpc.ClientConn, started <-chan struct{}) error {
+        stream, err := conn.NewStream(ctx, &grpc.StreamDesc{StreamName: "Hold", ServerStreams: true}, holdMethod)
+        if err != nil {
+            return err
+        }
+        failed := make(chan error, 1)
+        go func() { failed <- stream.RecvMsg(&grpc_health_v1.HealthCheckResponse{}) }()
+        select {
+        case <-started:
+            return nil
+        case err := <-failed:
+            return err
+        case <-ctx.Done():
+            return ctx.Err()
+        }
+    }
+
+    // serve serves holdService behind a shedder admitting one stream at once
+    serve := func(exempt ...string) (*loadshed.Shedder, *grpc.ClientConn, chan struct{}) {
+        shedder, err := loadshed.NewShedder(config.LoadSheddingConfig{
+            Enabled:       true,
+            InitialLimit:  1,
+            MinLimit:      1,
+            MaxLimit:      2,
+            Tolerance:     1.5,
+            QueueTimeout:  10 * time.Millisecond,
+            ExemptMethods: exempt,
+        })
+        require.NoError(t, err)
+        started := make(chan struct{}, 2)
+        server := grpc.NewServer(grpc.StreamInterceptor(shedder.StreamServerInterceptor()))
+        server.RegisterService(holdService(started), struct{}{})
+        return shedder, serveBufconn(t, server), started
+    }
+
+    shedder, conn, started := serve()
+    firstCtx, cancelFirst := context.WithCancel(ctx)
+    defer cancelFirst()
+    require.NoError(t, open(firstCtx, conn, started))
+
+    err := open(ctx, conn, started)
+    assert.Equal(t, codes.ResourceExhausted, status.Code(err), "slot held by the open stream")
+
+    cancelFirst()
+    require.Eventually(t, func() bool {
+        streamCtx, cancelStream := context.WithCancel(ctx)
+        defer cancelStream()
+        return open(streamCtx, conn, started) == nil
+    }, time.Second, 10*time.Millisecond, "slot released when the stream ended")
+    assert.Equal(t, 1, shedder.Limit(holdMethod), "stream durations do not adapt the limit")
+
+    _, conn, started = serve("Hold")
+    require.NoError(t, open(ctx, conn, started))
+    assert.NoError(t, open(ctx, conn, started), "exempt streams are not limited")
+}
@@ -0,0 +1,163 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"              // v1.3.0
+    "github.com/stretchr/testify/assert"  // v1.8.0
+    "github.com/stretchr/testify/require" // v1.8.0
+    "go.uber.org/zap"                     // v1.24.0
+
+    "bookman/portfolio-service/internal/services"
+)
+
+// fakePortfolioLocker grants each key to one holder at a time, counting the
+// attempts to take it
+type fakePortfolioLocker struct {
+    mutex    sync.Mutex
+    held     map[int64]bool
+    attempts int
+    err      error
+}
+
+type fakePortfolioLock struct {
+    locker *fakePortfolioLocker
+    key    int64
+}
+
+func (l *fakePortfolioLocker) TryLock(ctx context.Context, key int64) (services.PortfolioLock, error) {
+    l.mutex.Lock()
+    defer l.mutex.Unlock()
+
+    l.attempts++
+    if l.err != nil {
+        return nil, l.err
+    }
+    if l.held == nil {
+        l.held = make(map[int64]bool)
+    }
+    if l.held[key] {
+        return nil, nil
+    }
+    l.held[key] = true
+    return &fakePortfolioLock{locker: l, key: key}, nil
+}
+
+func (l *fakePortfolioLocker) heldCount() int {
+    l.mutex.Lock()
+    defer l.mutex.Unlock()
+    return len(l.held)
+}
+
+func (l *fakePortfolioLocker) attemptCount() int {
+    l.mutex.Lock()
+    defer l.mutex.Unlock()
+    return l.attempts
+}
+
+func (l *fakePortfolioLock) Release(ctx context.Context) error {
+    l.locker.mutex.Lock()
+    defer l.locker.mutex.Unlock()
+    delete(l.locker.held, l.key)
+    return nil
+}
+
+// TestPortfolioLocksAcquireRelease verifies a mutation waits for the lock of
+// its portfolio until the holder releases it, without blocking others
+func TestPortfolioLocksAcquireRelease(t *testing.T) {
+    t.Parallel()
+
+    locker := &fakePortfolioLocker{}
+    locks := services.NewPortfolioLocks(locker, time.Second, zap.NewNop())
+    portfolioID := uuid.New()
+
+    unlock, err := locks.Lock(context.Background(), portfolioID)
+    require.NoError(t, err)
+
+    unlockOther, err := locks.Lock(context.Background(), uuid.New())
+    require.NoError(t, err)
+    assert.Equal(t, 2, locker.heldCount())
+    unlockOther()
+
+    time.AfterFunc(30*time.Millisecond, unlock)
+    start := time.Now()
+    unlockNext, err := locks.Lock(context.Background(), portfolioID)
+    require.NoError(t, err)
+    assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+    assert.Greater(t, locker.attemptCount(), 3)
+
+    unlockNext()
+    assert.Zero(t, locker.heldCount())
+}
+
+// TestPortfolioLocksWaitTimeout verifies a mutation gives up with
+// ErrConcurrentModification once the lock stays held past the wait
+func TestPortfolioLocksWaitTimeout(t *testing.T) {
+    t.Parallel()
+
+    locker := &fakePortfolioLocker{}
+    locks := services.NewPortfolioLocks(locker, 50*time.Millisecond, zap.NewNop())
+    portfolioID := uuid.New()
+
+    unlock, err := locks.Lock(context.Background(), portfolioID)
+    require.NoError(t, err)
+    defer unlock()
+
+    start := time.Now()
+    _, err = locks.Lock(context.Background(), portfolioID)
+    require.Error(t, err)
+    assert.ErrorIs(t, err, services.ErrConcurrentModification)
+    assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+    assert.Equal(t, 1, locker.heldCount())
+}
+
+// TestPortfolioLocksCancellation verifies a caller cancelling its wait gets
+// its context error rather than ErrConcurrentModification, and that locker
+// failures are repository errors
+func TestPortfolioLocksCancellation(t *testing.T) {
+    t.Parallel()
+
+    locker := &fakePortfolioLocker{}
+    locks := services.NewPortfolioLocks(locker, time.Hour, zap.NewNop())
+    portfolioID := uuid.New()
+
+    unlock, err := locks.Lock(context.Background(), portfolioID)
+    require.NoError(t, err)
+    defer unlock()
+
+    ctx, cancel := context.WithCancel(context.Background())
+    time.AfterFunc(20*time.Millisecond, cancel)
+    _, err = locks.Lock(ctx, portfolioID)
+    require.Error(t, err)
+    assert.ErrorIs(t, err, context.Canceled)
+    assert.NotErrorIs(t, err, services.ErrConcurrentModification)
+
+    ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+    defer cancel()
+    _, err = locks.Lock(ctx, portfolioID)
+    assert.ErrorIs(t, err, context.DeadlineExceeded)
+    assert.NotErrorIs(t, err, services.ErrConcurrentModification)
+
+    failing := services.NewPortfolioLocks(&fakePortfolioLocker{err: errors.New("connection refused")}, time.Second, zap.NewNop())
+    _, err = failing.Lock(context.Background(), portfolioID)
+    assert.ErrorIs(t, err, services.ErrRepositoryOperation)
+}
+
+// TestPortfolioLocksDisabled verifies nil portfolio locks never block
+func TestPortfolioLocksDisabled(t *testing.T) {
+    t.Parallel()
+
+    var locks *services.PortfolioLocks
+    portfolioID := uuid.New()
+
+    unlock, err := locks.Lock(context.Background(), portfolioID)
+    require.NoError(t, err)
+    unlockAgain, err := locks.Lock(context.Background(), portfolioID)
+    require.NoError(t, err)
+    unlock()
+    unlockAgain()
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/logging"
+)
+
+// readLogLines returns the JSON entries written to a log file
+func readLogLines(t *testing.T, path string) []map[string]interface{} {
+    t.Helper()
+
+    data, err := os.ReadFile(path)
+    require.NoError(t, err)
+
+    var entries []map[string]interface{}
+    for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+        if line == "" {
+            continue
+        }
+        var entry map[string]interface{}
+        require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+        entries = append(entries, entry)
+    }
+    return entries
+}
+
+// TestLoggingConfig verifies the logger honours the configured level, outputs,
+// stack trace level and sampling
+func TestLoggingConfig(t *testing.T) {
+    t.Parallel()
+
+    path := filepath.Join(t.TempDir(), "service.log")
+    logger, err := logging.New(config.LoggingConfig{
+        Level:            "warn",
+        Encoding:         "json",
+        Sampling:         config.LoggingSamplingConfig{Enabled: true, Initial: 2, Thereafter: 1000},
+        OutputPaths:      []string{path},
+        ErrorOutputPaths: []string{"stderr"},
+        DisableCaller:    true,
+        StacktraceLevel:  "error",
+    })
+    require.NoError(t, err)
+
+    logger.Info("below the level")
+    logger.Warn("without stack")
+    logger.Error("with stack")
+    for i := 0; i < 10; i++ {
+        logger.Warn("repeated")
+    }
+    require.NoError(t, logger.Sync())
+
+    entries := readLogLines(t, path)
+    require.Len(t, entries, 4, "info dropped and repeats sampled")
+    assert.Equal(t, "without stack", entries[0]["msg"])
+    assert.Equal(t, "warn", entries[0]["level"])
+    assert.NotContains(t, entries[0], "stacktrace")
+    assert.NotContains(t, entries[0], "caller")
+    assert.Contains(t, entries[1], "stacktrace")
+    assert.Equal(t, "repeated", entries[3]["msg"])
+
+    _, err = logging.New(config.LoggingConfig{Level: "verbose"})
+    assert.Error(t, err)
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"                // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// lotTransaction builds a transaction of asset at the given day of January 2024
+func lotTransaction(asset uuid.UUID, day int, txType, amount, price string) models.Transaction {
+    return models.Transaction{
+        ID:        uuid.New(),
+        AssetID:   asset,
+        Type:      txType,
+        Amount:    decimal.RequireFromString(amount),
+        Price:     decimal.RequireFromString(price),
+        Timestamp: time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC),
+    }
+}
+
+// TestReplayLots verifies disposals consume the oldest lots first and sells
+// realize their proceeds less the cost of the lots consumed
+func TestReplayLots(t *testing.T) {
+    t.Parallel()
+
+    asset := uuid.New()
+    buyLow := lotTransaction(asset, 1, "buy", "1", "100")
+    buyHigh := lotTransaction(asset, 2, "buy", "1", "200")
+    sell := lotTransaction(asset, 3, "sell", "1.5", "300")
+
+    // Out of order on purpose: the replay sorts by timestamp
+    replay := models.ReplayLots(nil, []models.Transaction{sell, buyHigh, buyLow}, models.FeePolicyCapitalize)
+
+    require.Len(t, replay.Lots, 1)
+    assert.Equal(t, buyHigh.ID, replay.Lots[0].TransactionID)
+    assert.True(t, replay.Quantity.Equal(decimal.RequireFromString("0.5")))
+    assert.True(t, replay.CostBasis.Equal(decimal.NewFromInt(100)))
+    // 1.5 sold at 300 against 100 + 0.5 * 200 of cost
+    assert.True(t, replay.RealizedPnL.Equal(decimal.NewFromInt(250)), replay.RealizedPnL.String())
+    assert.True(t, replay.Shortfall.IsZero())
+
+    opening := []models.Lot{{AssetID: asset, Quantity: decimal.NewFromInt(2), CostBasis: decimal.NewFromInt(50)}}
+    transfer := lotTransaction(asset, 4, "transfer_out", "3", "0")
+    replay = models.ReplayLots(opening, []models.Transaction{transfer}, models.FeePolicyCapitalize)
+    assert.Empty(t, replay.Lots)
+    assert.True(t, replay.RealizedPnL.IsZero(), "only sells realize P&L")
+    assert.True(t, replay.Shortfall.Equal(decimal.NewFromInt(1)))
+}
+
+// TestReplayLotsFeePolicy verifies capitalized fees add to the cost of buys
+// and reduce the proceeds of sells, and expensed fees do neither
+func TestReplayLotsFeePolicy(t *testing.T) {
+    t.Parallel()
+
+    asset := uuid.New()
+    buy := lotTransaction(asset, 1, "buy", "2", "100")
+    buy.Fee = decimal.NewFromInt(4)
+    sell := lotTransaction(asset, 2, "sell", "1", "150")
+    sell.Fee = decimal.NewFromInt(3)
+
+    replay := models.ReplayLots(nil, []models.Transaction{buy, sell}, models.FeePolicyCapitalize)
+    // Cost of 204 for two, half of it sold for 150 less the fee of 3
+    assert.True(t, replay.CostBasis.Equal(decimal.NewFromInt(102)), replay.CostBasis.String())
+    assert.True(t, replay.RealizedPnL.Equal(decimal.NewFromInt(45)), replay.RealizedPnL.String())
+    disposal, ok := replay.Disposal(sell.ID)
+    require.True(t, ok)
+    assert.True(t, disposal.Proceeds.Equal(decimal.NewFromInt(147)), disposal.Proceeds.String())
+
+    replay = models.ReplayLots(nil, []models.Transaction{buy, sell}, models.FeePolicyExpense)
+    assert.True(t, replay.CostBasis.Equal(decimal.NewFromInt(100)), replay.CostBasis.String())
+    assert.True(t, replay.RealizedPnL.Equal(decimal.NewFromInt(50)), replay.RealizedPnL.String())
+
+    // Only the share of the fee for the quantity taken from lots is deducted
+    oversell := lotTransaction(asset, 3, "sell", "4", "150")
+    oversell.Fee = decimal.NewFromInt(8)
+    replay = models.ReplayLots(nil, []models.Transaction{buy, oversell}, models.FeePolicyCapitalize)
+    assert.True(t, replay.Shortfall.Equal(decimal.NewFromInt(2)))
+    assert.True(t, replay.RealizedPnL.Equal(decimal.NewFromInt(92)), replay.RealizedPnL.String())
+}
+
+// TestRecomputeLots verifies editing or deleting a transaction restates the
+// asset by the difference it makes, keeping holdings not derived from
+// transactions
+func TestRecomputeLots(t *testing.T) {
+    t.Parallel()
+
+    assetID := uuid.New()
+    buy := lotTransaction(assetID, 1, "buy", "2", "100")
+    sell := lotTransaction(assetID, 5, "sell", "1", "150")
+    before := []models.Transaction{buy, sell}
+
+    // Cost basis 100 from the replay plus 40 not derived from transactions
+    asset := models.Asset{ID: assetID, Amount: decimal.NewFromInt(1), CostBasis: decimal.NewFromInt(140)}
+
+    edited := buy
+    edited.Price = decimal.NewFromInt(120)
+    result := models.RecomputeLots(asset, nil, before, []models.Transaction{edited, sell}, models.FeePolicyCapitalize)
+    assert.Equal(t, assetID, result.AssetID)
+    assert.True(t, result.Amount.Equal(decimal.NewFromInt(1)))
+    assert.True(t, result.CostBasis.Equal(decimal.NewFromInt(160)), result.CostBasis.String())
+    assert.True(t, result.RealizedPnL.Equal(decimal.NewFromInt(30)), result.RealizedPnL.String())
+
+    result = models.RecomputeLots(asset, nil, before, []models.Transaction{buy}, models.FeePolicyCapitalize)
+    assert.True(t, result.Amount.Equal(decimal.NewFromInt(2)), "deleting a sell restores its amount")
+    assert.True(t, result.CostBasis.Equal(decimal.NewFromInt(240)), result.CostBasis.String())
+    assert.True(t, result.RealizedPnL.IsZero())
+    require.Len(t, result.Lots, 1)
+    assert.Equal(t, buy.ID, result.Lots[0].TransactionID)
+
+    result = models.RecomputeLots(asset, nil, before, []models.Transaction{sell}, models.FeePolicyCapitalize)
+    assert.True(t, result.Amount.IsNegative(), "deleting the buy oversells the asset")
+    assert.True(t, result.CostBasis.Equal(decimal.NewFromInt(40)), result.CostBasis.String())
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestManualPriceValidation verifies manual prices are normalized and
+// rejected with negative prices or empty validity windows
+func TestManualPriceValidation(t *testing.T) {
+    t.Parallel()
+
+    from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+    price := models.ManualPrice{Symbol: " pvt ", Price: decimal.NewFromInt(12), ValidFrom: from}
+    require.NoError(t, price.Validate())
+    assert.Equal(t, "PVT", price.Symbol)
+
+    until := from
+    invalid := []models.ManualPrice{
+        {Symbol: " ", Price: decimal.NewFromInt(1), ValidFrom: from},
+        {Symbol: "PVT", Price: decimal.NewFromInt(-1), ValidFrom: from},
+        {Symbol: "PVT", Price: decimal.NewFromInt(1)},
+        {Symbol: "PVT", Price: decimal.NewFromInt(1), ValidFrom: from, ValidUntil: &until},
+    }
+    for _, price := range invalid {
+        assert.ErrorIs(t, price.Validate(), models.ErrInvalidManualPrice)
+    }
+}
+
+// TestApplyManualPrices verifies manual prices only value symbols without a
+// provider quote, within their validity window, latest first
+func TestApplyManualPrices(t *testing.T) {
+    t.Parallel()
+
+    now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+    expired := now.Add(-time.Hour)
+    manual := []models.ManualPrice{
+        {Symbol: "BTC", Price: decimal.NewFromInt(1), ValidFrom: now.Add(-time.Hour * 48)},
+        {Symbol: "PVT", Price: decimal.NewFromInt(10), ValidFrom: now.Add(-time.Hour * 48)},
+        {Symbol: "PVT", Price: decimal.NewFromInt(12), ValidFrom: now.Add(-time.Hour * 24)},
+        {Symbol: "PVT", Price: decimal.NewFromInt(15), ValidFrom: now.Add(time.Hour)},
+        {Symbol: "OLD", Price: decimal.NewFromInt(3), ValidFrom: now.Add(-time.Hour * 48), ValidUntil: &expired},
+    }
+    prices := map[string]decimal.Decimal{"BTC": decimal.NewFromInt(60000)}
+
+    symbols := models.ApplyManualPrices(prices, manual, now)
+    assert.Equal(t, []string{"PVT"}, symbols)
+    assert.True(t, prices["BTC"].Equal(decimal.NewFromInt(60000)), "provider prices take precedence")
+    assert.True(t, prices["PVT"].Equal(decimal.NewFromInt(12)), "latest valid manual price")
+    assert.NotContains(t, prices, "OLD", "expired manual prices are ignored")
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+    "context"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus" // v1.15.0
+    "github.com/stretchr/testify/assert"             // v1.8.0
+    "github.com/stretchr/testify/require"            // v1.8.0
+
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/metrics"
+)
+
+// TestMetricsWithLabels verifies deployment labels are added to every
+// gathered metric without overriding a metric's own labels
+func TestMetricsWithLabels(t *testing.T) {
+    t.Parallel()
+
+    registry := prometheus.NewRegistry()
+    requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+        Name: "test_requests_total",
+        Help: "Test requests",
+    }, []string{"method", "region"})
+    registry.MustRegister(requests)
+    requests.WithLabelValues("GetPortfolio", "us-east-1").Inc()
+
+    assert.Same(t, registry, metrics.WithLabels(registry, nil), "no labels leaves the gatherer unchanged")
+
+    families, err := metrics.WithLabels(registry, map[string]string{
+        "env":    "production",
+        "region": "eu-west-1",
+    }).Gather()
+    require.NoError(t, err)
+    require.Len(t, families, 1)
+    require.Len(t, families[0].Metric, 1)
+
+    labels := make(map[string]string)
+    var names []string
+    for _, pair := range families[0].Metric[0].Label {
+        labels[pair.GetName()] = pair.GetValue()
+        names = append(names, pair.GetName())
+    }
+    assert.Equal(t, map[string]string{
+        "env":    "production",
+        "method": "GetPortfolio",
+        "region": "us-east-1",
+    }, labels)
+    assert.Equal(t, []string{"env", "method", "region"}, names, "labels are sorted by name")
+}
+
+// gatheredNames returns the names of the metric families of gatherer
+func gatheredNames(t *testing.T, gatherer prometheus.Gatherer) []string {
+    t.Helper()
+
+    families, err := gatherer.Gather()
+    require.NoError(t, err)
+    names := make([]string, 0, len(families))
+    for _, family := range families {
+        names = append(names, family.GetName())
+    }
+    return names
+}
+
+// TestMetricsRegistry verifies service metrics are exported from the
+// dedicated registry only, that registering a metric twice fails, and that a
+// failed Register leaves nothing registered so it can be retried. It mutates
+// the shared registry, so it does not run in parallel.
+func TestMetricsRegistry(t *testing.T) {
+    opts := prometheus.CounterOpts{Name: "test_registry_duplicate_total", Help: "Test duplicate"}
+    duplicate := prometheus.NewCounter(opts)
+    metrics.MustRegister(duplicate)
+    defer metrics.Registry.Unregister(duplicate)
+    assert.Panics(t, func() { metrics.MustRegister(prometheus.NewCounter(opts)) })
+
+    // A collector conflicting with a request metric fails registration
+    conflicting := prometheus.NewGauge(prometheus.GaugeOpts{Name: "portfolio_errors_total", Help: "Conflicting"})
+    metrics.MustRegister(conflicting)
+    cfg := config.MetricsConfig{GoCollector: true}
+    assert.Error(t, metrics.Register(cfg))
+    assert.NotContains(t, gatheredNames(t, metrics.Registry), "go_goroutines")
+    require.True(t, metrics.Registry.Unregister(conflicting))
+
+    require.NoError(t, metrics.Register(cfg), "a failed registration can be retried")
+    assert.ErrorContains(t, metrics.Register(cfg), "already registered")
+
+    metrics.Requests.WithLabelValues("TestMetricsRegistry", "success").Inc()
+    metrics.ObserveRequest(context.Background(), "TestMetricsRegistry", time.Now())
+
+    names := gatheredNames(t, metrics.Registry)
+    assert.Contains(t, names, "portfolio_requests_total")
+    assert.Contains(t, names, "portfolio_request_duration_seconds")
+    assert.Contains(t, names, "go_goroutines", "configured Go collector")
+    assert.NotContains(t, names, "process_cpu_seconds_total", "process collector not configured")
+
+    for _, name := range gatheredNames(t, prometheus.DefaultGatherer) {
+        assert.False(t, strings.HasPrefix(name, "portfolio_"), "%s is registered with the default registry", name)
+    }
+}
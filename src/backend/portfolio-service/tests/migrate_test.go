@@ -0,0 +1,155 @@
+package tests
+
+import (
+    "context"
+    "database/sql"
+    "database/sql/driver"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/stretchr/testify/assert"  // v1.8.0
+    "github.com/stretchr/testify/require" // v1.8.0
+
+    "bookman/portfolio-service/internal/migrate"
+    "bookman/portfolio-service/internal/repository"
+)
+
+// migrationsDir is the directory holding the schema migrations
+const migrationsDir = "../../db/migrations"
+
+// writeMigrations writes migration scripts keyed by file name to a temporary
+// directory
+func writeMigrations(t *testing.T, scripts map[string]string) string {
+    t.Helper()
+
+    dir := t.TempDir()
+    for name, script := range scripts {
+        require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0o600))
+    }
+    return dir
+}
+
+// TestMigrationLoad verifies migrations are loaded in version order and split
+// into statements, keeping function bodies whole, and misnamed or duplicate
+// migrations are rejected
+func TestMigrationLoad(t *testing.T) {
+    t.Parallel()
+
+    dir := writeMigrations(t, map[string]string{
+        "010_functions.sql": `-- Description: functions
+CREATE FUNCTION touch() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Trailing comment
+`,
+        "002_tables.sql": `CREATE TABLE a (id INT);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_a ON a(id);
+`,
+    })
+
+    migrations, err := migrate.Load(dir)
+    require.NoError(t, err)
+    require.Len(t, migrations, 2)
+    assert.Equal(t, 2, migrations[0].Version)
+    assert.Equal(t, "002_tables", migrations[0].Name)
+    assert.Len(t, migrations[0].Statements, 2)
+    assert.True(t, migrations[0].Concurrent())
+    assert.Equal(t, 10, migrations[1].Version)
+    require.Len(t, migrations[1].Statements, 1, "function body kept whole")
+    assert.Contains(t, migrations[1].Statements[0], "LANGUAGE plpgsql;")
+    assert.False(t, migrations[1].Concurrent())
+
+    _, err = migrate.Load(writeMigrations(t, map[string]string{"init.sql": "SELECT 1;"}))
+    require.Error(t, err)
+    assert.Contains(t, err.Error(), "NNN_name.sql")
+
+    _, err = migrate.Load(writeMigrations(t, map[string]string{"001_a.sql": "SELECT 1;", "01_b.sql": "SELECT 1;"}))
+    require.Error(t, err)
+    assert.Contains(t, err.Error(), "share version 1")
+
+    _, err = migrate.Load(t.TempDir())
+    assert.Error(t, err)
+
+    // The shipped migrations are numbered without gaps up to the version
+    // the service requires
+    shipped, err := migrate.Load(migrationsDir)
+    require.NoError(t, err)
+    for i, m := range shipped {
+        assert.Equal(t, i+1, m.Version, m.Name)
+        assert.NotEmpty(t, m.Statements, m.Name)
+    }
+    assert.Equal(t, repository.SchemaVersion, shipped[len(shipped)-1].Version)
+}
+
+// TestMigrationApply verifies only migrations newer than the recorded schema
+// version are applied, in order and under the migration lock, in a
+// transaction unless they build indexes concurrently
+func TestMigrationApply(t *testing.T) {
+    t.Parallel()
+
+    ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+    defer cancel()
+
+    migrations := []migrate.Migration{
+        {Version: 44, Name: "044_applied", Statements: []string{"CREATE TABLE applied (id INT);"}},
+        {Version: 45, Name: "045_plain", Statements: []string{"CREATE TABLE plain (id INT);", "INSERT INTO plain VALUES (1);"}},
+        {Version: 46, Name: "046_concurrent", Statements: []string{"CREATE INDEX CONCURRENTLY idx_plain ON plain(id);"}},
+    }
+    // script answers the lock, schema inspection and version queries
+    script := func(locked, recorded, migrated bool, version int64) map[string]scriptedResult {
+        return map[string]scriptedResult{
+            "pg_try_advisory_lock":   {columns: []string{"locked"}, rows: [][]driver.Value{{locked}}},
+            "to_regclass":            {columns: []string{"recorded", "migrated"}, rows: [][]driver.Value{{recorded, migrated}}},
+            "FROM schema_migrations": {columns: []string{"version"}, rows: [][]driver.Value{{version}}},
+        }
+    }
+    // queries returns the statements executed against db
+    queries := func(db *scriptedDB) []string {
+        var executed []string
+        for _, call := range db.recorded() {
+            executed = append(executed, call.query)
+        }
+        return executed
+    }
+
+    db := &scriptedDB{script: script(true, true, true, 44)}
+    applied, err := migrate.Apply(ctx, sql.OpenDB(db), migrations)
+    require.NoError(t, err)
+    require.Len(t, applied, 2)
+    assert.Equal(t, "045_plain", applied[0].Name)
+    assert.Equal(t, "046_concurrent", applied[1].Name)
+
+    executed := queries(db)
+    require.Len(t, executed, 11)
+    assert.Contains(t, executed[0], "pg_try_advisory_lock")
+    assert.Contains(t, executed[1], "statement_timeout")
+    assert.Contains(t, executed[2], "to_regclass")
+    assert.Contains(t, executed[3], "FROM schema_migrations")
+    assert.Equal(t, []string{
+        "BEGIN", "CREATE TABLE plain (id INT);", "INSERT INTO plain VALUES (1);", "COMMIT",
+        "CREATE INDEX CONCURRENTLY idx_plain ON plain(id);",
+    }, executed[4:9], "concurrent index builds run outside a transaction")
+    assert.Contains(t, executed[9], "RESET statement_timeout")
+    assert.Contains(t, executed[10], "pg_advisory_unlock")
+
+    // An empty database is migrated from the first migration
+    db = &scriptedDB{script: script(true, false, false, 0)}
+    applied, err = migrate.Apply(ctx, sql.OpenDB(db), migrations)
+    require.NoError(t, err)
+    assert.Len(t, applied, 3)
+
+    db = &scriptedDB{script: script(true, false, true, 0)}
+    _, err = migrate.Apply(ctx, sql.OpenDB(db), migrations)
+    assert.ErrorIs(t, err, migrate.ErrUnrecorded, "migrated before schema_migrations existed")
+    assert.NotContains(t, queries(db), "BEGIN")
+
+    db = &scriptedDB{script: script(false, true, true, 44)}
+    _, err = migrate.Apply(ctx, sql.OpenDB(db), migrations)
+    assert.ErrorIs(t, err, migrate.ErrLocked)
+    assert.Len(t, queries(db), 1, "nothing runs without the lock")
+}
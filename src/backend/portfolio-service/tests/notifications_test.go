@@ -0,0 +1,209 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"               // v1.3.0
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+    "go.uber.org/zap"                      // v1.24.0
+
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/notifications"
+)
+
+// fakeSender fails the first failures sends with err
+type fakeSender struct {
+    name     string
+    err      error
+    failures int
+
+    mu   sync.Mutex
+    sent []models.Notification
+    hits int
+}
+
+func (f *fakeSender) Name() string { return f.name }
+
+func (f *fakeSender) Send(ctx context.Context, n *models.Notification) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.hits++
+    if f.hits <= f.failures {
+        return f.err
+    }
+    f.sent = append(f.sent, *n)
+    return nil
+}
+
+// memoryDeadLetters is an in-memory notifications.DeadLetterStore
+type memoryDeadLetters struct {
+    mu      sync.Mutex
+    letters []models.NotificationDeadLetter
+    retries int
+}
+
+func (m *memoryDeadLetters) SaveDeadLetter(ctx context.Context, letter *models.NotificationDeadLetter) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.letters = append(m.letters, *letter)
+    return nil
+}
+
+func (m *memoryDeadLetters) ListDeadLetters(ctx context.Context, limit int) ([]models.NotificationDeadLetter, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if limit > len(m.letters) {
+        limit = len(m.letters)
+    }
+    return append([]models.NotificationDeadLetter(nil), m.letters[:limit]...), nil
+}
+
+func (m *memoryDeadLetters) DeleteDeadLetter(ctx context.Context, id uuid.UUID) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for i, letter := range m.letters {
+        if letter.ID == id {
+            m.letters = append(m.letters[:i], m.letters[i+1:]...)
+            break
+        }
+    }
+    return nil
+}
+
+func (m *memoryDeadLetters) RecordDeadLetterRetry(ctx context.Context, id uuid.UUID, cause string, at time.Time) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.retries++
+    return nil
+}
+
+func (m *memoryDeadLetters) count() int {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    return len(m.letters)
+}
+
+func testNotificationsConfig() config.NotificationsConfig {
+    return config.NotificationsConfig{
+        QueueSize:      10,
+        Workers:        1,
+        MaxAttempts:    3,
+        InitialBackoff: time.Millisecond,
+        MaxBackoff:     2 * time.Millisecond,
+        SendTimeout:    time.Second,
+    }
+}
+
+// runDispatcher delivers the given notifications and stops the dispatcher
+func runDispatcher(t *testing.T, d *notifications.Dispatcher, ns ...models.Notification) {
+    t.Helper()
+    ctx, cancel := context.WithCancel(context.Background())
+    done := make(chan struct{})
+    go func() {
+        d.Run(ctx)
+        close(done)
+    }()
+    for _, n := range ns {
+        d.Notify(n)
+    }
+    // Allow the worker to finish delivery before stopping
+    time.Sleep(100 * time.Millisecond)
+    cancel()
+    <-done
+}
+
+// TestDispatcherRetriesAndRoutes verifies transient failures are retried and
+// senders only receive subscribed events
+func TestDispatcherRetriesAndRoutes(t *testing.T) {
+    t.Parallel()
+
+    store := &memoryDeadLetters{}
+    d, err := notifications.NewDispatcher(store, testNotificationsConfig(), zap.NewNop())
+    require.NoError(t, err)
+
+    flaky := &fakeSender{name: "flaky", err: errors.New("unavailable"), failures: 2}
+    alertsOnly := &fakeSender{name: "alerts"}
+    d.AddSender(flaky, nil)
+    d.AddSender(alertsOnly, []string{string(models.EventAlertTriggered)})
+
+    runDispatcher(t, d,
+        models.Notification{Event: models.EventAlertTriggered},
+        models.Notification{Event: models.EventPortfolioCreated},
+    )
+
+    assert.Len(t, flaky.sent, 2)
+    require.Len(t, alertsOnly.sent, 1)
+    assert.Equal(t, models.EventAlertTriggered, alertsOnly.sent[0].Event)
+    assert.NotEqual(t, uuid.Nil, alertsOnly.sent[0].ID)
+    assert.Zero(t, store.count())
+}
+
+// TestDispatcherDeadLetters verifies exhausted and permanent failures are
+// dead-lettered and can be redriven
+func TestDispatcherDeadLetters(t *testing.T) {
+    t.Parallel()
+
+    store := &memoryDeadLetters{}
+    d, err := notifications.NewDispatcher(store, testNotificationsConfig(), zap.NewNop())
+    require.NoError(t, err)
+
+    down := &fakeSender{name: "down", err: errors.New("unavailable"), failures: 3}
+    rejecting := &fakeSender{name: "rejecting", err: notifications.Permanent(errors.New("bad request")), failures: 1}
+    d.AddSender(down, nil)
+    d.AddSender(rejecting, nil)
+
+    runDispatcher(t, d, models.Notification{Event: models.EventDriftBreached})
+
+    require.Equal(t, 2, store.count())
+    attempts := map[string]int{}
+    for _, letter := range store.letters {
+        attempts[letter.Sender] = letter.Attempts
+    }
+    assert.Equal(t, map[string]int{"down": 3, "rejecting": 1}, attempts)
+
+    delivered, err := d.Redrive(context.Background(), 10)
+    require.NoError(t, err)
+    assert.Equal(t, 2, delivered)
+    assert.Zero(t, store.count())
+    assert.Len(t, down.sent, 1)
+    assert.Len(t, rejecting.sent, 1)
+}
+
+// TestWebhookSender verifies payload signing and permanent client errors
+func TestWebhookSender(t *testing.T) {
+    t.Parallel()
+
+    secret := []byte("webhook-secret")
+    var status = http.StatusNoContent
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ := io.ReadAll(r.Body)
+        assert.Equal(t, "sha256="+notifications.Sign(secret, body), r.Header.Get(notifications.SignatureHeader))
+        assert.Equal(t, string(models.EventAlertTriggered), r.Header.Get(notifications.EventHeader))
+        w.WriteHeader(status)
+    }))
+    defer server.Close()
+
+    sender := notifications.NewWebhookSender(server.URL, secret, server.Client())
+    n := &models.Notification{ID: uuid.New(), Event: models.EventAlertTriggered}
+
+    require.NoError(t, sender.Send(context.Background(), n))
+
+    status = http.StatusBadRequest
+    err := sender.Send(context.Background(), n)
+    require.Error(t, err)
+    assert.True(t, notifications.IsPermanent(err))
+
+    status = http.StatusServiceUnavailable
+    err = sender.Send(context.Background(), n)
+    require.Error(t, err)
+    assert.False(t, notifications.IsPermanent(err))
+}
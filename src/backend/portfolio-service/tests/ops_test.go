@@ -0,0 +1,200 @@
+package tests
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "runtime"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"  // v1.8.0
+    "github.com/stretchr/testify/require" // v1.8.0
+    "go.uber.org/zap"                     // v1.24.0
+    "google.golang.org/grpc/health/grpc_health_v1"
+
+    "bookman/portfolio-service/internal/buildinfo"
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/ops"
+)
+
+// TestOpsProbes verifies liveness always passes while startup and readiness
+// fail until the service started, and readiness follows its checks
+func TestOpsProbes(t *testing.T) {
+    t.Parallel()
+
+    server, err := ops.NewServer(&config.MetricsConfig{
+        Enabled:      true,
+        Port:         9090,
+        Path:         "/metrics",
+        ReadTimeout:  time.Second * 10,
+        WriteTimeout: time.Second * 30,
+    }, buildinfo.Info{}, zap.NewNop())
+    require.NoError(t, err)
+
+    var cacheErr error
+    server.AddReadinessCheck("cache", func(ctx context.Context) error { return cacheErr })
+
+    probe := func(path string) int {
+        recorder := httptest.NewRecorder()
+        server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+        return recorder.Code
+    }
+
+    assert.Equal(t, http.StatusOK, probe("/healthz"))
+    assert.Equal(t, http.StatusServiceUnavailable, probe("/startupz"))
+    assert.Equal(t, http.StatusServiceUnavailable, probe("/readyz"))
+    assert.ErrorIs(t, server.Ready(context.Background()), ops.ErrStarting)
+
+    server.MarkStarted()
+    assert.Equal(t, http.StatusOK, probe("/startupz"))
+    assert.Equal(t, http.StatusOK, probe("/readyz"))
+    assert.NoError(t, server.Ready(context.Background()))
+
+    cacheErr = errors.New("connection refused")
+    assert.Equal(t, http.StatusOK, probe("/healthz"))
+    assert.Equal(t, http.StatusOK, probe("/startupz"))
+    assert.Equal(t, http.StatusServiceUnavailable, probe("/readyz"))
+    assert.ErrorContains(t, server.Ready(context.Background()), "cache")
+}
+
+// TestOpsGRPCHealth verifies the gRPC health service reports NOT_SERVING
+// until startup completes, with or without the ops server, and then follows
+// its readiness checks
+func TestOpsGRPCHealth(t *testing.T) {
+    t.Parallel()
+
+    check := func(health *ops.Health) grpc_health_v1.HealthCheckResponse_ServingStatus {
+        resp, err := health.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+        require.NoError(t, err)
+        return resp.Status
+    }
+
+    standalone := ops.NewHealth(nil)
+    assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, check(standalone))
+    standalone.MarkStarted()
+    assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, check(standalone))
+
+    server, err := ops.NewServer(&config.MetricsConfig{
+        Enabled:      true,
+        Port:         9090,
+        Path:         "/metrics",
+        ReadTimeout:  time.Second * 10,
+        WriteTimeout: time.Second * 30,
+    }, buildinfo.Info{}, zap.NewNop())
+    require.NoError(t, err)
+
+    var cacheErr error
+    server.AddReadinessCheck("cache", func(ctx context.Context) error { return cacheErr })
+
+    health := ops.NewHealth(server)
+    assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, check(health))
+
+    server.MarkStarted()
+    assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, check(health))
+
+    health.MarkStarted()
+    assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, check(health))
+
+    cacheErr = errors.New("connection refused")
+    assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, check(health))
+}
+
+// newTestOpsServer creates an ops server reporting info, without listening
+func newTestOpsServer(t *testing.T, info buildinfo.Info) *ops.Server {
+    t.Helper()
+
+    server, err := ops.NewServer(&config.MetricsConfig{
+        Enabled:      true,
+        Port:         9090,
+        Path:         "/metrics",
+        ReadTimeout:  time.Second * 10,
+        WriteTimeout: time.Second * 30,
+    }, info, zap.NewNop())
+    require.NoError(t, err)
+    return server
+}
+
+// getJSON serves a GET request for path and decodes its JSON body into dest,
+// returning the status code
+func getJSON(t *testing.T, handler http.Handler, path string, dest interface{}) int {
+    t.Helper()
+
+    recorder := httptest.NewRecorder()
+    handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+    assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+    assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+    require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), dest))
+    return recorder.Code
+}
+
+// TestOpsBuildInfo verifies build metadata lists enabled features in order
+// without aliasing the caller's slice, and is served on /buildinfo
+func TestOpsBuildInfo(t *testing.T) {
+    t.Parallel()
+
+    features := []string{"wallet_tracking", "alerts", "grpc_web"}
+    info := buildinfo.Get(features...)
+    assert.Equal(t, []string{"alerts", "grpc_web", "wallet_tracking"}, info.Features)
+    assert.Equal(t, []string{"wallet_tracking", "alerts", "grpc_web"}, features, "caller's features are not reordered")
+    assert.Equal(t, runtime.Version(), info.GoVersion)
+    assert.Equal(t, buildinfo.Version, info.Version)
+    assert.Equal(t, buildinfo.GitSHA, info.GitSHA)
+    assert.Empty(t, buildinfo.Get().Features)
+
+    var served buildinfo.Info
+    code := getJSON(t, newTestOpsServer(t, info).Handler(), "/buildinfo", &served)
+    assert.Equal(t, http.StatusOK, code)
+    assert.Equal(t, info, served)
+}
+
+// TestOpsReadinessReport verifies /readyz names failing checks and reports
+// degraded dependencies without failing readiness
+func TestOpsReadinessReport(t *testing.T) {
+    t.Parallel()
+
+    server := newTestOpsServer(t, buildinfo.Info{})
+    server.MarkStarted()
+
+    var databaseErr error
+    server.AddReadinessCheck("database", func(ctx context.Context) error { return databaseErr })
+    server.AddReadinessCheck("cache", func(ctx context.Context) error { return nil })
+    degraded := false
+    server.AddDependencyCheck("market_data", func(ctx context.Context) ops.DependencyStatus {
+        return ops.DependencyStatus{Degraded: degraded, Details: map[string]interface{}{"provider": "coingecko"}}
+    })
+
+    var report struct {
+        Status       string            `json:"status"`
+        Checks       map[string]string `json:"checks"`
+        Dependencies map[string]struct {
+            Status  string                 `json:"status"`
+            Details map[string]interface{} `json:"details"`
+        } `json:"dependencies"`
+    }
+
+    code := getJSON(t, server.Handler(), "/readyz", &report)
+    assert.Equal(t, http.StatusOK, code)
+    assert.Equal(t, "ok", report.Status)
+    assert.Equal(t, map[string]string{"database": "ok", "cache": "ok"}, report.Checks)
+    assert.Equal(t, "ok", report.Dependencies["market_data"].Status)
+    assert.Equal(t, "coingecko", report.Dependencies["market_data"].Details["provider"])
+
+    degraded = true
+    code = getJSON(t, server.Handler(), "/readyz", &report)
+    assert.Equal(t, http.StatusOK, code, "degraded dependencies keep the service ready")
+    assert.Equal(t, "degraded", report.Status)
+    assert.Equal(t, "degraded", report.Dependencies["market_data"].Status)
+
+    databaseErr = errors.New("connection refused")
+    code = getJSON(t, server.Handler(), "/readyz", &report)
+    assert.Equal(t, http.StatusServiceUnavailable, code)
+    assert.Equal(t, "unavailable", report.Status)
+    assert.Equal(t, map[string]string{"database": "unavailable", "cache": "ok"}, report.Checks)
+
+    var health map[string]string
+    assert.Equal(t, http.StatusOK, getJSON(t, server.Handler(), "/healthz", &health))
+    assert.Equal(t, "ok", health["status"])
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+    "encoding/json"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"               // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestOutboxEventEnvelope verifies published payloads carry the event
+// identity consumers deduplicate by and omit user-entered text
+func TestOutboxEventEnvelope(t *testing.T) {
+    t.Parallel()
+
+    portfolio := &models.Portfolio{
+        ID:          uuid.New(),
+        UserID:      uuid.New(),
+        Name:        "Retirement",
+        Description: "Long term holdings",
+        CreatedAt:   time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC),
+    }
+
+    event, err := models.PortfolioCreatedEvent(portfolio)
+    require.NoError(t, err)
+    assert.Equal(t, models.OutboxPortfolioCreated, event.Type)
+    assert.Equal(t, portfolio.ID, event.PortfolioID)
+    assert.Equal(t, portfolio.CreatedAt, event.CreatedAt)
+    assert.NotContains(t, string(event.Payload), "Retirement")
+    assert.NotContains(t, string(event.Payload), "Long term")
+
+    var envelope struct {
+        ID          uuid.UUID              `json:"id"`
+        Type        string                 `json:"type"`
+        PortfolioID uuid.UUID              `json:"portfolio_id"`
+        OccurredAt  time.Time              `json:"occurred_at"`
+        Data        map[string]interface{} `json:"data"`
+    }
+    require.NoError(t, json.Unmarshal(event.Payload, &envelope))
+    assert.Equal(t, event.ID, envelope.ID)
+    assert.Equal(t, "PortfolioCreated", envelope.Type)
+    assert.Equal(t, portfolio.ID, envelope.PortfolioID)
+    assert.True(t, portfolio.CreatedAt.Equal(envelope.OccurredAt))
+    assert.Equal(t, portfolio.UserID.String(), envelope.Data["user_id"])
+}
+
+// TestOutboxMutationEvents verifies asset and transaction events are keyed by
+// their portfolio and get distinct IDs
+func TestOutboxMutationEvents(t *testing.T) {
+    t.Parallel()
+
+    portfolioID := uuid.New()
+    asset := &models.Asset{ID: uuid.New(), Type: "crypto", Symbol: "BTC", Amount: decimal.NewFromFloat(0.5)}
+    added, err := models.AssetAddedEvent(portfolioID, asset)
+    require.NoError(t, err)
+    assert.Equal(t, models.OutboxAssetAdded, added.Type)
+    assert.Equal(t, portfolioID, added.PortfolioID)
+    assert.Contains(t, string(added.Payload), `"symbol":"BTC"`)
+
+    tx := &models.Transaction{ID: uuid.New(), PortfolioID: portfolioID, AssetID: asset.ID, Type: "buy", Amount: decimal.NewFromInt(1)}
+    recorded, err := models.TransactionRecordedEvent(tx)
+    require.NoError(t, err)
+    assert.Equal(t, models.OutboxTransactionRecorded, recorded.Type)
+    assert.Equal(t, portfolioID, recorded.PortfolioID)
+    assert.Contains(t, string(recorded.Payload), tx.ID.String())
+    assert.NotEqual(t, added.ID, recorded.ID)
+}
+
+// TestOutboxUserDataPurgedEvent verifies purge completion events carry the
+// erased user and record counts without being keyed to a portfolio
+func TestOutboxUserDataPurgedEvent(t *testing.T) {
+    t.Parallel()
+
+    purge := &models.UserDataPurge{
+        ID:           uuid.New(),
+        UserID:       uuid.New(),
+        Portfolios:   2,
+        Assets:       5,
+        Transactions: 40,
+        AuditEntries: 61,
+        CompletedAt:  time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC),
+    }
+
+    event, err := models.UserDataPurgedEvent(purge)
+    require.NoError(t, err)
+    assert.Equal(t, models.OutboxUserDataPurged, event.Type)
+    assert.Equal(t, uuid.Nil, event.PortfolioID)
+    assert.Equal(t, purge.CompletedAt, event.CreatedAt)
+
+    var envelope struct {
+        Data map[string]interface{} `json:"data"`
+    }
+    require.NoError(t, json.Unmarshal(event.Payload, &envelope))
+    assert.Equal(t, purge.UserID.String(), envelope.Data["user_id"])
+    assert.Equal(t, purge.ID.String(), envelope.Data["purge_id"])
+    assert.EqualValues(t, 40, envelope.Data["transactions"])
+    assert.EqualValues(t, 61, envelope.Data["audit_entries"])
+}
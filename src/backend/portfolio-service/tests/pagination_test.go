@@ -0,0 +1,150 @@
+package tests
+
+import (
+    "bytes"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/pagination"
+)
+
+var (
+    testSecret     = bytes.Repeat([]byte("a"), pagination.MinSecretLength)
+    testSecretNext = bytes.Repeat([]byte("b"), pagination.MinSecretLength)
+)
+
+// TestPageTokenRoundTrip verifies cursors survive encoding and stay opaque
+func TestPageTokenRoundTrip(t *testing.T) {
+    t.Parallel()
+
+    codec, err := pagination.NewCodec([][]byte{testSecret}, time.Hour)
+    require.NoError(t, err)
+
+    filters := pagination.Fingerprint("user-1")
+    token, err := codec.Encode("portfolios", pagination.Cursor{Keys: []string{"last-id"}, Filters: filters})
+    require.NoError(t, err)
+    assert.NotContains(t, token, "last-id")
+
+    cursor, err := codec.Decode("portfolios", token, filters)
+    require.NoError(t, err)
+    assert.Equal(t, []string{"last-id"}, cursor.Keys)
+
+    empty, err := codec.Decode("portfolios", "", filters)
+    require.NoError(t, err)
+    assert.Empty(t, empty.Keys)
+}
+
+// TestPageTokenRejection verifies forged, replayed and expired tokens are rejected
+func TestPageTokenRejection(t *testing.T) {
+    t.Parallel()
+
+    codec, err := pagination.NewCodec([][]byte{testSecret}, time.Hour)
+    require.NoError(t, err)
+
+    filters := pagination.Fingerprint("user-1")
+    token, err := codec.Encode("portfolios", pagination.Cursor{Keys: []string{"last-id"}, Filters: filters})
+    require.NoError(t, err)
+
+    tampered := []byte(token)
+    tampered[len(tampered)/2] ^= 1
+
+    testCases := []struct {
+        name    string
+        scope   string
+        token   string
+        filters string
+    }{
+        {name: "Forged", scope: "portfolios", token: "00000000-0000-0000-0000-000000000000", filters: filters},
+        {name: "Tampered", scope: "portfolios", token: string(tampered), filters: filters},
+        {name: "Other Scope", scope: "transactions", token: token, filters: filters},
+        {name: "Other Filters", scope: "portfolios", token: token, filters: pagination.Fingerprint("user-2")},
+    }
+
+    for _, tc := range testCases {
+        tc := tc
+        t.Run(tc.name, func(t *testing.T) {
+            _, err := codec.Decode(tc.scope, tc.token, tc.filters)
+            assert.ErrorIs(t, err, pagination.ErrInvalidToken)
+        })
+    }
+
+    shortLived, err := pagination.NewCodec([][]byte{testSecret}, time.Nanosecond)
+    require.NoError(t, err)
+    expired, err := shortLived.Encode("portfolios", pagination.Cursor{Keys: []string{"id"}})
+    require.NoError(t, err)
+    time.Sleep(time.Millisecond)
+    _, err = shortLived.Decode("portfolios", expired, "")
+    assert.ErrorIs(t, err, pagination.ErrExpiredToken)
+
+    _, err = pagination.NewCodec([][]byte{[]byte(strings.Repeat("x", 8))}, 0)
+    assert.Error(t, err)
+}
+
+// TestPageTokenRotation verifies tokens sealed with a previous secret stay valid
+func TestPageTokenRotation(t *testing.T) {
+    t.Parallel()
+
+    old, err := pagination.NewCodec([][]byte{testSecret}, 0)
+    require.NoError(t, err)
+    token, err := old.Encode("portfolios", pagination.Cursor{Keys: []string{"id"}})
+    require.NoError(t, err)
+
+    rotated, err := pagination.NewCodec([][]byte{testSecretNext, testSecret}, 0)
+    require.NoError(t, err)
+    cursor, err := rotated.Decode("portfolios", token, "")
+    require.NoError(t, err)
+    assert.Equal(t, []string{"id"}, cursor.Keys)
+
+    retired, err := pagination.NewCodec([][]byte{testSecretNext}, 0)
+    require.NoError(t, err)
+    _, err = retired.Decode("portfolios", token, "")
+    assert.ErrorIs(t, err, pagination.ErrInvalidToken)
+}
+
+// TestPagePosition verifies keyset positions survive encoding exactly
+func TestPagePosition(t *testing.T) {
+    t.Parallel()
+
+    codec, err := pagination.NewCodec([][]byte{testSecret}, 0)
+    require.NoError(t, err)
+
+    pos := pagination.Position{
+        At: time.Date(2024, 2, 29, 13, 45, 10, 123456000, time.FixedZone("CET", 3600)),
+        Key: "1250.5",
+        ID:  uuid.New(),
+    }
+    token, err := codec.EncodePosition("transactions", pos, "filters")
+    require.NoError(t, err)
+
+    decoded, err := codec.DecodePosition("transactions", token, "filters")
+    require.NoError(t, err)
+    assert.True(t, pos.At.Equal(decoded.At))
+    assert.Equal(t, pos.Key, decoded.Key)
+    assert.Equal(t, pos.ID, decoded.ID)
+
+    start, err := codec.DecodePosition("transactions", "", "filters")
+    require.NoError(t, err)
+    assert.True(t, start.IsZero())
+
+    // Cursors that do not hold a position are rejected
+    legacy, err := codec.Encode("transactions", pagination.Cursor{Keys: []string{pos.ID.String()}, Filters: "filters"})
+    require.NoError(t, err)
+    _, err = codec.DecodePosition("transactions", legacy, "filters")
+    assert.ErrorIs(t, err, pagination.ErrInvalidToken)
+}
+
+// TestPageSize verifies unset page sizes default and large ones are capped
+func TestPageSize(t *testing.T) {
+    t.Parallel()
+
+    assert.Equal(t, pagination.DefaultPageSize, pagination.Size(0, pagination.MaxPageSize))
+    assert.Equal(t, pagination.DefaultPageSize, pagination.Size(-3, pagination.MaxPageSize))
+    assert.Equal(t, 20, pagination.Size(20, pagination.MaxPageSize))
+    assert.Equal(t, pagination.MaxPageSize, pagination.Size(1000, pagination.MaxPageSize))
+    assert.Equal(t, 10, pagination.Size(0, 10))
+}
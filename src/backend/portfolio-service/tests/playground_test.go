@@ -0,0 +1,247 @@
+package tests
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/stretchr/testify/assert"  // v1.8.0
+    "github.com/stretchr/testify/require" // v1.8.0
+    "go.uber.org/zap"                     // v1.24.0
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/health/grpc_health_v1"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/status"
+
+    "bookman/portfolio-service/internal/buildinfo"
+    "bookman/portfolio-service/internal/ops"
+    "bookman/portfolio-service/internal/playground"
+)
+
+const (
+    // playgroundToken is the bearer token the playground test server accepts
+    playgroundToken = "Bearer playground-token"
+
+    // playgroundCheckPath is the bridged path of the health Check method
+    playgroundCheckPath = "/playground/rpc/grpc.health.v1.Health/Check"
+)
+
+// newTestPlayground mounts a playground for the health service on an ops
+// server. The bridged gRPC server serves over an in-memory listener and
+// rejects calls without playgroundToken, so tests can observe header
+// forwarding and error mapping.
+func newTestPlayground(t *testing.T) http.Handler {
+    t.Helper()
+
+    authorize := func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+        md, _ := metadata.FromIncomingContext(ctx)
+        if values := md.Get("authorization"); len(values) == 0 || values[0] != playgroundToken {
+            return nil, status.Error(codes.Unauthenticated, "missing credentials")
+        }
+        return handler(ctx, req)
+    }
+
+    health := ops.NewHealth(nil)
+    health.MarkStarted()
+    grpcServer := grpc.NewServer(grpc.UnaryInterceptor(authorize))
+    grpc_health_v1.RegisterHealthServer(grpcServer, health)
+
+    conn := serveBufconn(t, grpcServer)
+
+    pg, err := playground.New(conn, "grpc.health.v1.Health", "/playground/", zap.NewNop())
+    require.NoError(t, err)
+    assert.Equal(t, "/playground/", pg.Prefix())
+
+    server := newTestOpsServer(t, buildinfo.Info{})
+    server.Handle(pg.Prefix(), pg)
+    return server.Handler()
+}
+
+// invokePlayground posts body to a bridged RPC path with an optional
+// authorization header and decodes the JSON response
+func invokePlayground(t *testing.T, handler http.Handler, method, path, body, authorization string) (int, map[string]interface{}) {
+    t.Helper()
+
+    req := httptest.NewRequest(method, path, strings.NewReader(body))
+    req.Header.Set("Content-Type", "application/json")
+    if authorization != "" {
+        req.Header.Set("Authorization", authorization)
+    }
+    recorder := httptest.NewRecorder()
+    handler.ServeHTTP(recorder, req)
+
+    assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+    var payload map[string]interface{}
+    require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &payload))
+    return recorder.Code, payload
+}
+
+// TestPlaygroundUnknownService verifies a playground cannot be created for a
+// service missing from the proto registry
+func TestPlaygroundUnknownService(t *testing.T) {
+    t.Parallel()
+
+    conn, err := grpc.Dial("bufnet", grpc.WithTransportCredentials(insecure.NewCredentials()))
+    require.NoError(t, err)
+    defer conn.Close()
+
+    _, err = playground.New(conn, "portfolio.MissingService", "/playground", zap.NewNop())
+    require.Error(t, err)
+    assert.Contains(t, err.Error(), "not registered")
+
+    _, err = playground.New(conn, "grpc.health.v1.HealthCheckRequest", "/playground", zap.NewNop())
+    require.Error(t, err)
+    assert.Contains(t, err.Error(), "is not a service")
+
+    _, err = playground.New(nil, "grpc.health.v1.Health", "/playground", zap.NewNop())
+    assert.Error(t, err)
+}
+
+// TestPlaygroundUI verifies the explorer page is served under the prefix and
+// the bare prefix redirects to it
+func TestPlaygroundUI(t *testing.T) {
+    t.Parallel()
+
+    handler := newTestPlayground(t)
+
+    recorder := httptest.NewRecorder()
+    handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/playground/", nil))
+    assert.Equal(t, http.StatusOK, recorder.Code)
+    assert.Equal(t, "text/html; charset=utf-8", recorder.Header().Get("Content-Type"))
+    assert.NotEmpty(t, recorder.Body.String())
+
+    recorder = httptest.NewRecorder()
+    handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/playground/missing.js", nil))
+    assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+// TestPlaygroundOpenAPI verifies the generated spec documents unary methods
+// as JSON POST operations under the bridge path and omits streaming methods
+func TestPlaygroundOpenAPI(t *testing.T) {
+    t.Parallel()
+
+    handler := newTestPlayground(t)
+
+    recorder := httptest.NewRecorder()
+    handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/playground/openapi.json", nil))
+    require.Equal(t, http.StatusOK, recorder.Code)
+    assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+    var spec struct {
+        OpenAPI string `json:"openapi"`
+        Info    struct {
+            Title string `json:"title"`
+        } `json:"info"`
+        Paths map[string]map[string]struct {
+            OperationID string `json:"operationId"`
+            RequestBody struct {
+                Content map[string]struct {
+                    Schema map[string]string `json:"schema"`
+                } `json:"content"`
+            } `json:"requestBody"`
+        } `json:"paths"`
+        Components struct {
+            Schemas map[string]struct {
+                Properties map[string]map[string]interface{} `json:"properties"`
+            } `json:"schemas"`
+        } `json:"components"`
+    }
+    require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &spec))
+
+    assert.Equal(t, "3.0.3", spec.OpenAPI)
+    assert.Equal(t, "grpc.health.v1.Health", spec.Info.Title)
+    require.Len(t, spec.Paths, 1, "streaming Watch is not documented")
+
+    check, ok := spec.Paths[playgroundCheckPath]["post"]
+    require.True(t, ok)
+    assert.Equal(t, "Check", check.OperationID)
+    assert.Equal(t, "#/components/schemas/grpc.health.v1.HealthCheckRequest",
+        check.RequestBody.Content["application/json"].Schema["$ref"])
+
+    response, ok := spec.Components.Schemas["grpc.health.v1.HealthCheckResponse"]
+    require.True(t, ok)
+    assert.Equal(t, "string", response.Properties["status"]["type"])
+    assert.Contains(t, response.Properties["status"]["enum"], "SERVING")
+    assert.Contains(t, spec.Components.Schemas, "Status")
+}
+
+// TestPlaygroundBridge verifies JSON requests are invoked on the gRPC server
+// with forwarded headers, and gRPC errors map to HTTP statuses
+func TestPlaygroundBridge(t *testing.T) {
+    t.Parallel()
+
+    handler := newTestPlayground(t)
+
+    code, payload := invokePlayground(t, handler, http.MethodPost, playgroundCheckPath, `{"service": ""}`, playgroundToken)
+    assert.Equal(t, http.StatusOK, code)
+    assert.Equal(t, "SERVING", payload["status"])
+
+    code, payload = invokePlayground(t, handler, http.MethodPost, playgroundCheckPath, "", playgroundToken)
+    assert.Equal(t, http.StatusOK, code, "an empty body sends an empty request")
+    assert.Equal(t, "SERVING", payload["status"])
+
+    code, payload = invokePlayground(t, handler, http.MethodPost, playgroundCheckPath, "{}", "")
+    assert.Equal(t, http.StatusUnauthorized, code)
+    assert.Equal(t, codes.Unauthenticated.String(), payload["code"])
+    assert.Equal(t, "missing credentials", payload["message"])
+
+    code, payload = invokePlayground(t, handler, http.MethodPost, playgroundCheckPath, `{"unknown": 1}`, playgroundToken)
+    assert.Equal(t, http.StatusBadRequest, code)
+    assert.Contains(t, payload["message"], "invalid request")
+
+    code, _ = invokePlayground(t, handler, http.MethodGet, playgroundCheckPath, "", playgroundToken)
+    assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+    code, _ = invokePlayground(t, handler, http.MethodPost, "/playground/rpc/grpc.health.v1.Health/Watch", "{}", playgroundToken)
+    assert.Equal(t, http.StatusNotFound, code, "streaming methods are not bridged")
+
+    code, _ = invokePlayground(t, handler, http.MethodPost, "/playground/rpc/portfolio.PortfolioService/GetPortfolio", "{}", playgroundToken)
+    assert.Equal(t, http.StatusNotFound, code, "only the configured service is bridged")
+}
+
+// TestPlaygroundConfig tests defaults and validation of the API playground
+func TestPlaygroundConfig(t *testing.T) {
+    cfg, err := loadTestConfig(t, "")
+    require.NoError(t, err)
+    assert.False(t, cfg.Playground.Enabled)
+    assert.Equal(t, "/playground", cfg.Playground.Path)
+
+    cfg, err = loadTestConfig(t, `
+playground:
+  enabled: true
+  path: /explorer
+`)
+    require.NoError(t, err)
+    assert.True(t, cfg.Playground.Enabled)
+    assert.Equal(t, "/explorer", cfg.Playground.Path)
+
+    _, err = loadTestConfig(t, `
+metrics:
+  enabled: false
+playground:
+  enabled: true
+`)
+    require.Error(t, err)
+    assert.Contains(t, err.Error(), "requires the metrics server")
+
+    _, err = loadTestConfig(t, `
+playground:
+  enabled: true
+  path: /readyz
+`)
+    require.Error(t, err)
+    assert.Contains(t, err.Error(), "conflicts with an ops endpoint")
+
+    _, err = loadTestConfig(t, `
+playground:
+  enabled: true
+  path: playground
+`)
+    require.Error(t, err)
+    assert.Contains(t, err.Error(), "absolute, non-root path")
+}
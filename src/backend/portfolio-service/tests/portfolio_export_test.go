@@ -0,0 +1,126 @@
+package tests
+
+import (
+    "archive/zip"
+    "bytes"
+    "encoding/csv"
+    "io"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"               // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/export"
+    "bookman/portfolio-service/internal/importer"
+    "bookman/portfolio-service/internal/models"
+)
+
+// portfolioExportFixture returns a portfolio holding a high-precision ETH
+// position and a buy transaction of it
+func portfolioExportFixture() (*models.Portfolio, []models.Transaction) {
+    assetID := uuid.New()
+    portfolio := &models.Portfolio{
+        ID: uuid.New(),
+        Assets: []models.Asset{{
+            ID:           assetID,
+            Symbol:       "ETH",
+            Type:         "crypto",
+            Amount:       decimal.RequireFromString("1.123456789012345678"),
+            CostBasis:    decimal.RequireFromString("2500.5"),
+            CurrentValue: decimal.RequireFromString("3000"),
+            LastUpdated:  time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+        }},
+    }
+    txs := []models.Transaction{{
+        ID:        uuid.New(),
+        AssetID:   assetID,
+        Type:      "buy",
+        Amount:    decimal.RequireFromString("1.123456789012345678"),
+        Price:     decimal.RequireFromString("2225.75"),
+        Fee:       decimal.RequireFromString("1.25"),
+        Timestamp: time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC),
+    }}
+    return portfolio, txs
+}
+
+// readZip returns the files of a zip archive by name
+func readZip(t *testing.T, data []byte) map[string]string {
+    t.Helper()
+    zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+    require.NoError(t, err)
+
+    files := make(map[string]string, len(zr.File))
+    for _, f := range zr.File {
+        rc, err := f.Open()
+        require.NoError(t, err)
+        content, err := io.ReadAll(rc)
+        rc.Close()
+        require.NoError(t, err)
+        files[f.Name] = string(content)
+    }
+    return files
+}
+
+// TestPortfolioCSVExport verifies the archive holds both tables at full
+// precision and its transactions re-import with the native template
+func TestPortfolioCSVExport(t *testing.T) {
+    t.Parallel()
+
+    portfolio, txs := portfolioExportFixture()
+    var buf bytes.Buffer
+    require.NoError(t, export.WritePortfolio(&buf, export.PortfolioCSV, portfolio, txs))
+
+    files := readZip(t, buf.Bytes())
+    require.Contains(t, files, "holdings.csv")
+    require.Contains(t, files, "transactions.csv")
+
+    holdings, err := csv.NewReader(strings.NewReader(files["holdings.csv"])).ReadAll()
+    require.NoError(t, err)
+    require.Len(t, holdings, 2)
+    assert.Equal(t, "Symbol", holdings[0][0])
+    assert.Equal(t, []string{"ETH", "crypto", "1.123456789012345678", "2500.5", "3000"}, holdings[1][:5])
+
+    result, err := importer.ParseTransactions(strings.NewReader(files["transactions.csv"]), templateMapping(t, importer.TemplateNative))
+    require.NoError(t, err)
+    require.Empty(t, result.Errors)
+    require.Len(t, result.Rows, 1)
+    assert.Equal(t, txs[0].ID.String(), result.Rows[0].Reference)
+    assert.True(t, result.Rows[0].Amount.Equal(txs[0].Amount))
+}
+
+// TestPortfolioXLSXExport verifies the workbook parts and that numbers a
+// spreadsheet can't hold exactly are written as text
+func TestPortfolioXLSXExport(t *testing.T) {
+    t.Parallel()
+
+    portfolio, txs := portfolioExportFixture()
+    var buf bytes.Buffer
+    require.NoError(t, export.WritePortfolio(&buf, export.PortfolioXLSX, portfolio, txs))
+
+    files := readZip(t, buf.Bytes())
+    for _, name := range []string{"[Content_Types].xml", "xl/workbook.xml", "xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+        assert.Contains(t, files, name)
+    }
+    assert.Contains(t, files["xl/workbook.xml"], `<sheet name="Holdings"`)
+
+    holdings := files["xl/worksheets/sheet1.xml"]
+    assert.Contains(t, holdings, `<t xml:space="preserve">1.123456789012345678</t>`)
+    assert.Contains(t, holdings, `<v>2500.5</v>`)
+    assert.Contains(t, files["xl/worksheets/sheet2.xml"], `<v>2225.75</v>`)
+}
+
+// TestPortfolioExportErrors verifies unknown assets and formats are rejected
+func TestPortfolioExportErrors(t *testing.T) {
+    t.Parallel()
+
+    portfolio, txs := portfolioExportFixture()
+    txs[0].AssetID = uuid.New()
+    assert.ErrorIs(t, export.WritePortfolio(io.Discard, export.PortfolioCSV, portfolio, txs), export.ErrUnknownAsset)
+
+    portfolio, txs = portfolioExportFixture()
+    assert.ErrorIs(t, export.WritePortfolio(io.Discard, "ods", portfolio, txs), export.ErrUnsupportedFormat)
+}
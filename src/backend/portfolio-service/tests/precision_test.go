@@ -0,0 +1,65 @@
+package tests
+
+import (
+    "testing"
+
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestAmountPrecision verifies amounts are validated against the precision
+// of their symbol or asset type rather than a global minimum
+func TestAmountPrecision(t *testing.T) {
+    t.Parallel()
+
+    assert.Equal(t, int32(8), models.AmountDecimals("cryptocurrency", "btc"))
+    assert.Equal(t, int32(18), models.AmountDecimals("cryptocurrency", "ETH"))
+    assert.Equal(t, models.DEFAULT_AMOUNT_DECIMALS, models.AmountDecimals("token", "WIF"))
+    assert.Equal(t, int32(0), models.AmountDecimals("nft", "BTC"), "type takes precedence")
+    assert.Equal(t, int32(2), models.AmountDecimals(models.FIAT_ASSET_TYPE, "EUR"))
+
+    assert.Equal(t, "0.00000001", models.MinAmount("cryptocurrency", "BTC").String())
+    assert.Equal(t, "1", models.MinAmount("nft", "PUNK").String())
+
+    valid := []struct {
+        assetType, symbol, amount string
+    }{
+        {"cryptocurrency", "BTC", "0.00000001"},
+        {"cryptocurrency", "BTC", "1.500000000"},
+        {"token", "ETH", "0.000000000000000001"},
+        {"token", "PEPE", "123456789.123456789012345678"},
+        {"nft", "PUNK", "3"},
+        {models.FIAT_ASSET_TYPE, "USD", "10.25"},
+        {"cryptocurrency", "BTC", "0"},
+    }
+    for _, tc := range valid {
+        assert.NoError(t, models.ValidateAmountPrecision(tc.assetType, tc.symbol, decimal.RequireFromString(tc.amount)), "%+v", tc)
+    }
+
+    invalid := []struct {
+        assetType, symbol, amount string
+    }{
+        {"cryptocurrency", "BTC", "0.000000001"},
+        {"cryptocurrency", "BTC", "1.123456789"},
+        {"token", "USDC", "0.0000001"},
+        {"nft", "PUNK", "0.5"},
+        {models.FIAT_ASSET_TYPE, "USD", "10.255"},
+    }
+    for _, tc := range invalid {
+        assert.ErrorIs(t, models.ValidateAmountPrecision(tc.assetType, tc.symbol, decimal.RequireFromString(tc.amount)), models.ErrInvalidAmount, "%+v", tc)
+    }
+}
+
+// TestFormatAmount verifies serialized amounts are rounded to the precision
+// of their asset
+func TestFormatAmount(t *testing.T) {
+    t.Parallel()
+
+    third := decimal.NewFromInt(1).Div(decimal.NewFromInt(3))
+    assert.Equal(t, "0.33333333", models.FormatAmount("cryptocurrency", "BTC", third))
+    assert.Equal(t, "0.33", models.FormatAmount(models.FIAT_ASSET_TYPE, "EUR", third))
+    assert.Equal(t, "2", models.FormatAmount("nft", "PUNK", decimal.NewFromInt(2)))
+    assert.Equal(t, "1.5", models.FormatAmount("token", "ETH", decimal.RequireFromString("1.500")))
+}
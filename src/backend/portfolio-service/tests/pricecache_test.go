@@ -0,0 +1,293 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/pricecache"
+)
+
+// priceSource serves fixed prices and records the symbols of every fetch
+type priceSource struct {
+    mutex   sync.Mutex
+    prices  map[string]decimal.Decimal
+    fetches [][]string
+    release chan struct{} // when set, fetches block until it is closed
+}
+
+func (s *priceSource) GetCurrentPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error) {
+    s.mutex.Lock()
+    s.fetches = append(s.fetches, symbols)
+    s.mutex.Unlock()
+
+    if s.release != nil {
+        <-s.release
+    }
+
+    prices := make(map[string]decimal.Decimal)
+    for _, symbol := range symbols {
+        if price, ok := s.prices[symbol]; ok {
+            prices[symbol] = price
+        }
+    }
+    return prices, nil
+}
+
+func (s *priceSource) fetchCount() int {
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+    return len(s.fetches)
+}
+
+// priceStore is an in-memory shared tier that can be made to fail
+type priceStore struct {
+    mutex  sync.Mutex
+    prices map[string]decimal.Decimal
+    reads  int
+    fail   bool
+}
+
+func (s *priceStore) GetPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error) {
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+
+    s.reads++
+    if s.fail {
+        return nil, errors.New("store unavailable")
+    }
+    prices := make(map[string]decimal.Decimal)
+    for _, symbol := range symbols {
+        if price, ok := s.prices[symbol]; ok {
+            prices[symbol] = price
+        }
+    }
+    return prices, nil
+}
+
+func (s *priceStore) SetPrices(ctx context.Context, prices map[string]decimal.Decimal, ttl time.Duration) error {
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+
+    if s.fail {
+        return errors.New("store unavailable")
+    }
+    if s.prices == nil {
+        s.prices = make(map[string]decimal.Decimal)
+    }
+    for symbol, price := range prices {
+        s.prices[symbol] = price
+    }
+    return nil
+}
+
+func (s *priceStore) DeletePrices(ctx context.Context, symbols []string) error {
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+
+    if s.fail {
+        return errors.New("store unavailable")
+    }
+    for _, symbol := range symbols {
+        delete(s.prices, symbol)
+    }
+    return nil
+}
+
+// TestPriceCacheTiers verifies prices are served from the in-process cache,
+// then the shared store, then the source, filling the faster tiers
+func TestPriceCacheTiers(t *testing.T) {
+    t.Parallel()
+
+    ctx := context.Background()
+    source := &priceSource{prices: map[string]decimal.Decimal{
+        "BTC": decimal.NewFromInt(60000),
+        "ETH": decimal.NewFromInt(3000),
+    }}
+    store := &priceStore{prices: map[string]decimal.Decimal{
+        "SOL": decimal.NewFromInt(150),
+    }}
+
+    cache, err := pricecache.New(source, store, 100, time.Minute, time.Hour)
+    require.NoError(t, err)
+
+    prices, err := cache.GetCurrentPrices(ctx, []string{"BTC", "SOL", "DOGE", "BTC"})
+    require.NoError(t, err)
+    assert.Len(t, prices, 2)
+    assert.True(t, decimal.NewFromInt(60000).Equal(prices["BTC"]))
+    assert.True(t, decimal.NewFromInt(150).Equal(prices["SOL"]))
+    require.Equal(t, 1, source.fetchCount())
+    assert.ElementsMatch(t, []string{"BTC", "DOGE"}, source.fetches[0])
+    assert.True(t, decimal.NewFromInt(60000).Equal(store.prices["BTC"]), "fetched prices fill the store")
+
+    // Cached symbols are served in process; unpriced ones are looked up again
+    prices, err = cache.GetCurrentPrices(ctx, []string{"BTC", "SOL", "DOGE"})
+    require.NoError(t, err)
+    assert.Len(t, prices, 2)
+    assert.Equal(t, 2, source.fetchCount())
+    assert.Equal(t, []string{"DOGE"}, source.fetches[1])
+    assert.Equal(t, 2, store.reads)
+
+    prices, err = cache.GetCurrentPrices(ctx, []string{"BTC", "SOL"})
+    require.NoError(t, err)
+    assert.Len(t, prices, 2)
+    assert.Equal(t, 2, source.fetchCount())
+    assert.Equal(t, 2, store.reads, "local hits skip the store")
+
+    _, err = pricecache.New(nil, store, 100, time.Minute, time.Hour)
+    assert.Error(t, err)
+    _, err = pricecache.New(source, nil, 0, time.Minute, 0)
+    assert.Error(t, err)
+}
+
+// TestPriceCacheStoreFailure verifies an unavailable store degrades to the
+// source instead of failing valuations
+func TestPriceCacheStoreFailure(t *testing.T) {
+    t.Parallel()
+
+    source := &priceSource{prices: map[string]decimal.Decimal{"ETH": decimal.NewFromInt(3000)}}
+    cache, err := pricecache.New(source, &priceStore{fail: true}, 100, time.Minute, time.Hour)
+    require.NoError(t, err)
+
+    prices, err := cache.GetCurrentPrices(context.Background(), []string{"ETH"})
+    require.NoError(t, err)
+    assert.True(t, decimal.NewFromInt(3000).Equal(prices["ETH"]))
+    assert.Equal(t, 1, source.fetchCount())
+}
+
+// TestPriceCacheSingleflight verifies concurrent misses of the same symbols
+// share one source fetch
+func TestPriceCacheSingleflight(t *testing.T) {
+    t.Parallel()
+
+    source := &priceSource{
+        prices:  map[string]decimal.Decimal{"BTC": decimal.NewFromInt(60000), "ETH": decimal.NewFromInt(3000)},
+        release: make(chan struct{}),
+    }
+    cache, err := pricecache.New(source, nil, 100, time.Minute, 0)
+    require.NoError(t, err)
+
+    const callers = 8
+    var wg sync.WaitGroup
+    results := make([]map[string]decimal.Decimal, callers)
+    for i := 0; i < callers; i++ {
+        wg.Add(1)
+        go func(i int) {
+            defer wg.Done()
+            // Symbol order differs between callers but the set is the same
+            symbols := []string{"BTC", "ETH"}
+            if i%2 == 1 {
+                symbols = []string{"ETH", "BTC"}
+            }
+            prices, err := cache.GetCurrentPrices(context.Background(), symbols)
+            assert.NoError(t, err)
+            results[i] = prices
+        }(i)
+    }
+
+    require.Eventually(t, func() bool { return source.fetchCount() == 1 }, time.Second, time.Millisecond)
+    // Give the remaining callers time to join the in-flight fetch
+    time.Sleep(20 * time.Millisecond)
+    close(source.release)
+    wg.Wait()
+
+    assert.Equal(t, 1, source.fetchCount())
+    for _, prices := range results {
+        assert.Len(t, prices, 2)
+    }
+}
+
+// TestPriceCacheEvictionAndExpiry verifies the in-process cache is bounded
+// in size and age
+func TestPriceCacheEvictionAndExpiry(t *testing.T) {
+    t.Parallel()
+
+    ctx := context.Background()
+    source := &priceSource{prices: map[string]decimal.Decimal{
+        "BTC": decimal.NewFromInt(60000),
+        "ETH": decimal.NewFromInt(3000),
+        "SOL": decimal.NewFromInt(150),
+    }}
+    cache, err := pricecache.New(source, nil, 2, 50*time.Millisecond, 0)
+    require.NoError(t, err)
+
+    for _, symbol := range []string{"BTC", "ETH", "BTC", "SOL"} {
+        _, err := cache.GetCurrentPrices(ctx, []string{symbol})
+        require.NoError(t, err)
+    }
+    assert.Equal(t, 3, source.fetchCount())
+
+    // ETH was least recently used when SOL was added
+    _, err = cache.GetCurrentPrices(ctx, []string{"BTC"})
+    require.NoError(t, err)
+    assert.Equal(t, 3, source.fetchCount())
+    _, err = cache.GetCurrentPrices(ctx, []string{"ETH"})
+    require.NoError(t, err)
+    assert.Equal(t, 4, source.fetchCount())
+
+    time.Sleep(60 * time.Millisecond)
+    _, err = cache.GetCurrentPrices(ctx, []string{"ETH"})
+    require.NoError(t, err)
+    assert.Equal(t, 5, source.fetchCount())
+}
+
+// TestPriceCacheWriteThrough verifies refreshed prices replace cached ones in
+// both tiers
+func TestPriceCacheWriteThrough(t *testing.T) {
+    t.Parallel()
+
+    ctx := context.Background()
+    source := &priceSource{prices: map[string]decimal.Decimal{"BTC": decimal.NewFromInt(60000)}}
+    store := &priceStore{}
+    cache, err := pricecache.New(source, store, 100, time.Minute, time.Hour)
+    require.NoError(t, err)
+
+    _, err = cache.GetCurrentPrices(ctx, []string{"BTC"})
+    require.NoError(t, err)
+
+    require.NoError(t, cache.SetPrices(ctx, map[string]decimal.Decimal{"BTC": decimal.NewFromInt(61000)}))
+    prices, err := cache.GetCurrentPrices(ctx, []string{"BTC"})
+    require.NoError(t, err)
+    assert.True(t, decimal.NewFromInt(61000).Equal(prices["BTC"]))
+    assert.True(t, decimal.NewFromInt(61000).Equal(store.prices["BTC"]))
+    assert.Equal(t, 1, source.fetchCount())
+}
+
+// TestPriceCacheInvalidate verifies invalidated symbols are fetched from the
+// source again and emptying the cache leaves the shared store alone
+func TestPriceCacheInvalidate(t *testing.T) {
+    t.Parallel()
+
+    ctx := context.Background()
+    source := &priceSource{prices: map[string]decimal.Decimal{
+        "BTC": decimal.NewFromInt(60000),
+        "ETH": decimal.NewFromInt(3000),
+    }}
+    store := &priceStore{}
+    cache, err := pricecache.New(source, store, 100, time.Minute, time.Hour)
+    require.NoError(t, err)
+
+    _, err = cache.GetCurrentPrices(ctx, []string{"BTC", "ETH"})
+    require.NoError(t, err)
+    require.Equal(t, 1, source.fetchCount())
+
+    require.NoError(t, cache.Invalidate(ctx, []string{"BTC"}))
+    assert.NotContains(t, store.prices, "BTC")
+    assert.Contains(t, store.prices, "ETH")
+    _, err = cache.GetCurrentPrices(ctx, []string{"BTC", "ETH"})
+    require.NoError(t, err)
+    assert.Equal(t, 2, source.fetchCount())
+    assert.Equal(t, []string{"BTC"}, source.fetches[1])
+
+    // Emptying the in-process cache falls back to the store
+    require.NoError(t, cache.Invalidate(ctx, nil))
+    _, err = cache.GetCurrentPrices(ctx, []string{"BTC", "ETH"})
+    require.NoError(t, err)
+    assert.Equal(t, 2, source.fetchCount())
+}
@@ -0,0 +1,342 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+    "go.uber.org/zap"                       // v1.24.0
+
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/exchanges"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/pricefeed"
+)
+
+// stubProvider prices a fixed set of symbols and records its batches
+type stubProvider struct {
+    name     string
+    maxBatch int
+    prices   map[string]decimal.Decimal
+    fail     bool
+
+    mutex   sync.Mutex
+    batches [][]string
+}
+
+func (p *stubProvider) Name() string  { return p.name }
+func (p *stubProvider) MaxBatch() int { return p.maxBatch }
+
+func (p *stubProvider) FetchPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error) {
+    p.mutex.Lock()
+    p.batches = append(p.batches, append([]string(nil), symbols...))
+    p.mutex.Unlock()
+
+    if p.fail {
+        return nil, errors.New("provider unavailable")
+    }
+    prices := make(map[string]decimal.Decimal)
+    for _, symbol := range symbols {
+        if price, ok := p.prices[symbol]; ok {
+            prices[symbol] = price
+        }
+    }
+    return prices, nil
+}
+
+// TestPriceFetcherFallback verifies symbols are batched per provider and
+// those a provider cannot price are asked of the next one
+func TestPriceFetcherFallback(t *testing.T) {
+    t.Parallel()
+
+    primary := &stubProvider{
+        name:     "primary",
+        maxBatch: 2,
+        prices: map[string]decimal.Decimal{
+            "BTC": decimal.NewFromInt(60000),
+            "ETH": decimal.NewFromInt(3000),
+            "BAD": decimal.Zero,
+        },
+    }
+    secondary := &stubProvider{
+        name: "secondary",
+        prices: map[string]decimal.Decimal{
+            "SOL": decimal.NewFromInt(150),
+            "BTC": decimal.NewFromInt(1),
+        },
+    }
+    fetcher, err := pricefeed.NewFetcher([]pricefeed.Provider{primary, secondary}, pricefeed.NewHealth(time.Minute, 3), zap.NewNop())
+    require.NoError(t, err)
+
+    prices, sources, err := fetcher.FetchPrices(context.Background(), []string{"BTC", "ETH", "SOL", "BAD", "BTC", "XYZ"})
+    require.NoError(t, err)
+
+    assert.Len(t, prices, 3)
+    assert.True(t, decimal.NewFromInt(60000).Equal(prices["BTC"]), "earlier providers win")
+    assert.True(t, decimal.NewFromInt(150).Equal(prices["SOL"]))
+    assert.NotContains(t, prices, "BAD", "non-positive prices are dropped")
+    assert.Equal(t, map[string]string{"BTC": "primary", "ETH": "primary", "SOL": "secondary"}, sources,
+        "each price is attributed to the provider that supplied it")
+    assert.Equal(t, [][]string{{"BTC", "ETH"}, {"SOL", "BAD"}, {"XYZ"}}, primary.batches)
+    assert.Equal(t, [][]string{{"SOL", "BAD", "XYZ"}}, secondary.batches)
+
+    // A failing provider hands all its symbols to the next
+    failing := &stubProvider{name: "failing", fail: true}
+    health := pricefeed.NewHealth(time.Minute, 3)
+    fetcher, err = pricefeed.NewFetcher([]pricefeed.Provider{failing, secondary}, health, zap.NewNop())
+    require.NoError(t, err)
+    prices, sources, err = fetcher.FetchPrices(context.Background(), []string{"SOL"})
+    require.NoError(t, err)
+    assert.True(t, decimal.NewFromInt(150).Equal(prices["SOL"]))
+    assert.Equal(t, "secondary", sources["SOL"])
+
+    status := health.Status(time.Now())
+    require.Len(t, status.Providers, 2)
+    assert.Equal(t, 1, status.Providers[0].ConsecutiveFailures, "failures recorded")
+    assert.False(t, status.Degraded)
+
+    // Symbols no provider prices are left unpriced and unattributed
+    fetcher, err = pricefeed.NewFetcher([]pricefeed.Provider{failing, &stubProvider{name: "empty"}}, health, zap.NewNop())
+    require.NoError(t, err)
+    prices, sources, err = fetcher.FetchPrices(context.Background(), []string{"SOL", "XYZ"})
+    require.NoError(t, err)
+    assert.Empty(t, prices)
+    assert.Empty(t, sources)
+
+    // Cancellation fails the fetch instead of falling back
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    fetcher, err = pricefeed.NewFetcher([]pricefeed.Provider{failing, secondary}, health, zap.NewNop())
+    require.NoError(t, err)
+    prices, sources, err = fetcher.FetchPrices(ctx, []string{"SOL"})
+    assert.ErrorIs(t, err, context.Canceled)
+    assert.Nil(t, prices)
+    assert.Nil(t, sources)
+
+    _, err = pricefeed.NewFetcher(nil, health, zap.NewNop())
+    assert.Error(t, err)
+}
+
+// TestPriceProviderHealth verifies market data is degraded by stale prices
+// and repeatedly failing providers, and recovers on success
+func TestPriceProviderHealth(t *testing.T) {
+    t.Parallel()
+
+    start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+    health := pricefeed.NewHealth(time.Minute*5, 3)
+    assert.False(t, health.Status(start).Degraded, "nothing fetched yet")
+
+    failure := errors.New("status 503")
+    health.RecordFailure("primary", start, failure)
+    status := health.Status(start)
+    assert.True(t, status.Degraded)
+    assert.Contains(t, status.Reason, "no successful price fetch")
+
+    health.RecordSuccess("secondary", start)
+    health.RecordFailure("primary", start, failure)
+    assert.False(t, health.Status(start).Degraded, "another provider succeeds")
+
+    health.RecordFailure("primary", start, failure)
+    status = health.Status(start)
+    assert.True(t, status.Degraded)
+    assert.Contains(t, status.Reason, "provider primary failed 3 requests in a row")
+    assert.Equal(t, "status 503", status.Providers[0].LastError)
+
+    health.RecordSuccess("primary", start.Add(time.Minute))
+    assert.False(t, health.Status(start.Add(time.Minute)).Degraded, "recovered")
+
+    status = health.Status(start.Add(time.Minute * 7))
+    assert.True(t, status.Degraded)
+    assert.Contains(t, status.Reason, "prices are stale")
+    assert.Equal(t, start.Add(time.Minute), status.LastSuccess)
+}
+
+// TestPriceProviders verifies each provider type requests and decodes its
+// API's price format
+func TestPriceProviders(t *testing.T) {
+    t.Setenv("TEST_PRICE_KEY", "secret")
+
+    testCases := []struct {
+        providerType string
+        path         string
+        query        string
+        keyHeader    string
+        body         string
+    }{
+        {
+            providerType: "coingecko",
+            path:         "/simple/price",
+            query:        "symbols=btc%2Ceth%2Cxyz&vs_currencies=usd",
+            keyHeader:    "x-cg-demo-api-key",
+            body:         `{"btc":{"usd":60000.5},"eth":{"usd":3000}}`,
+        },
+        {
+            providerType: "coinmarketcap",
+            path:         "/v2/cryptocurrency/quotes/latest",
+            query:        "convert=USD&skip_invalid=true&symbol=BTC%2CETH%2CXYZ",
+            keyHeader:    "X-CMC_PRO_API_KEY",
+            body:         `{"data":{"BTC":[{"quote":{"USD":{"price":60000.5}}},{"quote":{"USD":{"price":1}}}],"ETH":[{"quote":{"USD":{"price":3000}}}]}}`,
+        },
+        {
+            providerType: "cryptocompare",
+            path:         "/data/pricemulti",
+            query:        "fsyms=BTC%2CETH%2CXYZ&tsyms=USD",
+            keyHeader:    "Authorization",
+            body:         `{"BTC":{"USD":60000.5},"ETH":{"USD":3000}}`,
+        },
+        {
+            providerType: "binance",
+            path:         "/ticker/price",
+            body:         `[{"symbol":"BTCUSDT","price":"60000.50"},{"symbol":"ETHUSDT","price":"3000.00"},{"symbol":"ETHBTC","price":"0.05"}]`,
+        },
+    }
+
+    for _, tc := range testCases {
+        tc := tc // Capture range variable
+        t.Run(tc.providerType, func(t *testing.T) {
+            server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                assert.Equal(t, tc.path, r.URL.Path)
+                assert.Equal(t, tc.query, r.URL.RawQuery)
+                if tc.keyHeader != "" {
+                    assert.Contains(t, r.Header.Get(tc.keyHeader), "secret")
+                }
+                w.Write([]byte(tc.body))
+            }))
+            defer server.Close()
+
+            providers, err := pricefeed.NewProviders([]config.ProviderConfig{{
+                Name:      "test-" + tc.providerType,
+                Type:      tc.providerType,
+                BaseURL:   server.URL,
+                APIKeyEnv: "TEST_PRICE_KEY",
+                RateLimit: 6000,
+            }}, server.Client(), exchanges.RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+            require.NoError(t, err)
+            require.Len(t, providers, 1)
+
+            prices, err := providers[0].FetchPrices(context.Background(), []string{"BTC", "ETH", "XYZ"})
+            require.NoError(t, err)
+            assert.Len(t, prices, 2)
+            assert.Equal(t, "60000.5", prices["BTC"].String())
+            assert.Equal(t, "3000", prices["ETH"].String())
+        })
+    }
+
+    _, err := pricefeed.NewProviders([]config.ProviderConfig{{Name: "x", Type: "yahoo"}}, http.DefaultClient, exchanges.RetryPolicy{MaxAttempts: 1})
+    assert.Error(t, err)
+}
+
+// TestFXProviders verifies exchange rates are inverted into base currency
+// prices of each currency
+func TestFXProviders(t *testing.T) {
+    t.Parallel()
+
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        assert.Equal(t, "/latest", r.URL.Path)
+        assert.Equal(t, "from=USD", r.URL.RawQuery)
+        w.Write([]byte(`{"amount":1.0,"base":"USD","date":"2024-05-01","rates":{"EUR":0.8,"GBP":0.5,"JPY":150}}`))
+    }))
+    defer server.Close()
+
+    providers, err := pricefeed.NewFXProviders([]config.ProviderConfig{{
+        Name:      "test-frankfurter",
+        Type:      "frankfurter",
+        BaseURL:   server.URL,
+        RateLimit: 6000,
+    }}, server.Client(), exchanges.RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+    require.NoError(t, err)
+    require.Len(t, providers, 1)
+
+    prices, err := providers[0].FetchPrices(context.Background(), []string{"EUR", "GBP", "XYZ"})
+    require.NoError(t, err)
+    assert.Len(t, prices, 2)
+    assert.Equal(t, "1.25", prices["EUR"].String())
+    assert.Equal(t, "2", prices["GBP"].String())
+
+    _, err = pricefeed.NewFXProviders([]config.ProviderConfig{{Name: "x", Type: "coingecko"}}, http.DefaultClient, exchanges.RetryPolicy{MaxAttempts: 1})
+    assert.Error(t, err)
+}
+
+// TestPriceHistoryProviders checks the providers serving daily history by
+// symbol decode their bar formats
+func TestPriceHistoryProviders(t *testing.T) {
+    start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+    end := start.AddDate(0, 0, 2)
+
+    testCases := []struct {
+        providerType string
+        path         string
+        body         string
+    }{
+        {
+            providerType: "cryptocompare",
+            path:         "/data/v2/histoday",
+            body:         `{"Response":"Success","Data":{"Data":[{"time":1709251200,"open":61000,"high":63000,"low":60000,"close":62000.5,"volumeto":1000},{"time":1709337600,"open":62000.5,"high":62500,"low":61000,"close":62100,"volumeto":900}]}}`,
+        },
+        {
+            providerType: "binance",
+            path:         "/klines",
+            body:         `[[1709251200000,"61000","63000","60000","62000.5","10",1709337599999,"1000",5,"1","1","0"],[1709337600000,"62000.5","62500","61000","62100","9",1709423999999,"900",4,"1","1","0"]]`,
+        },
+    }
+
+    for _, tc := range testCases {
+        tc := tc // Capture range variable
+        t.Run(tc.providerType, func(t *testing.T) {
+            server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                assert.Equal(t, tc.path, r.URL.Path)
+                w.Write([]byte(tc.body))
+            }))
+            defer server.Close()
+
+            providers, err := pricefeed.NewProviders([]config.ProviderConfig{{
+                Name:      "test-" + tc.providerType,
+                Type:      tc.providerType,
+                BaseURL:   server.URL,
+                RateLimit: 6000,
+            }}, server.Client(), exchanges.RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+            require.NoError(t, err)
+
+            history, ok := providers[0].(pricefeed.HistoryProvider)
+            require.True(t, ok)
+
+            bars, err := history.FetchDailyBars(context.Background(), "BTC", start, end)
+            require.NoError(t, err)
+            require.Len(t, bars, 2)
+            assert.Equal(t, start, bars[0].Day)
+            assert.Equal(t, "62000.5", bars[0].Close.String())
+            assert.Equal(t, "63000", bars[0].High.String())
+            assert.Equal(t, "1000", bars[0].Volume.String())
+            assert.Equal(t, start.AddDate(0, 0, 1), bars[1].Day)
+        })
+    }
+}
+
+func TestValidBars(t *testing.T) {
+    start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+    end := start.AddDate(0, 0, 3)
+    bar := func(day time.Time, close string) models.PriceBar {
+        return models.PriceBar{Symbol: "BTC", Day: day, Close: decimal.RequireFromString(close)}
+    }
+
+    valid := pricefeed.ValidBars([]models.PriceBar{
+        bar(start.AddDate(0, 0, -1), "100"),                // before the range
+        bar(start, "0"),                                    // placeholder before listing
+        bar(start.AddDate(0, 0, 1), "101"),
+        bar(start.AddDate(0, 0, 1), "102"),                 // duplicate day
+        bar(start.AddDate(0, 0, 2).Add(time.Hour), "103"),  // not a day boundary
+        bar(start.AddDate(0, 0, 2), "104"),
+        bar(end, "105"),                                    // today, still forming
+    }, start, end)
+
+    require.Len(t, valid, 2)
+    assert.Equal(t, "101", valid[0].Close.String())
+    assert.Equal(t, "104", valid[1].Close.String())
+}
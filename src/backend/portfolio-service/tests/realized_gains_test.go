@@ -0,0 +1,45 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"                // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestRealizedGainsSince verifies only sells at or after the restated point
+// record gains, priced against the lots they disposed of
+func TestRealizedGainsSince(t *testing.T) {
+    t.Parallel()
+
+    portfolioID, assetID := uuid.New(), uuid.New()
+    buy := lotTransaction(assetID, 1, "buy", "2", "100")
+    sell := lotTransaction(assetID, 2, "sell", "1", "150")
+    transfer := lotTransaction(assetID, 3, "transfer_out", "0.5", "0")
+    loss := lotTransaction(assetID, 4, "sell", "0.5", "80")
+
+    replay := models.ReplayLots(nil, []models.Transaction{buy, sell, transfer, loss}, models.FeePolicyCapitalize)
+    at := time.Now().UTC()
+
+    gains := models.RealizedGainsSince(portfolioID, assetID, "ETH", replay.Disposals, time.Time{}, at)
+    require.Len(t, gains, 2, "transfers realize nothing")
+    assert.Equal(t, sell.ID, gains[0].TransactionID)
+    assert.True(t, gains[0].Gain.Equal(decimal.NewFromInt(50)), gains[0].Gain.String())
+
+    gains = models.RealizedGainsSince(portfolioID, assetID, "ETH", replay.Disposals, transfer.Timestamp, at)
+    require.Len(t, gains, 1)
+    gain := gains[0]
+    assert.Equal(t, loss.ID, gain.TransactionID)
+    assert.Equal(t, portfolioID, gain.PortfolioID)
+    assert.Equal(t, "ETH", gain.Symbol)
+    assert.Equal(t, loss.Timestamp, gain.DisposedAt)
+    assert.Equal(t, at, gain.RecordedAt)
+    assert.True(t, gain.CostBasis.Equal(decimal.NewFromInt(50)), gain.CostBasis.String())
+    assert.True(t, gain.Proceeds.Equal(decimal.NewFromInt(40)), gain.Proceeds.String())
+    assert.True(t, gain.Gain.Equal(decimal.NewFromInt(-10)), gain.Gain.String())
+}
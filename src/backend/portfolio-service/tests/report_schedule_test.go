@@ -0,0 +1,152 @@
+package tests
+
+import (
+    "bytes"
+    "encoding/csv"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"  // v1.8.0
+    "github.com/stretchr/testify/require" // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/reports"
+)
+
+// TestReportScheduleValidate verifies schedule validation and that the day
+// field unused by the frequency is cleared
+func TestReportScheduleValidate(t *testing.T) {
+    t.Parallel()
+
+    weekly := &models.ReportSchedule{
+        Frequency:  models.ReportWeekly,
+        Format:     models.ReportFormatPDF,
+        Timezone:   "Europe/Berlin",
+        Hour:       8,
+        Weekday:    1,
+        DayOfMonth: 15,
+    }
+    require.NoError(t, weekly.Validate())
+    assert.Equal(t, 0, weekly.DayOfMonth)
+
+    tests := []struct {
+        name   string
+        mutate func(s *models.ReportSchedule)
+    }{
+        {"unknown frequency", func(s *models.ReportSchedule) { s.Frequency = "daily" }},
+        {"unknown format", func(s *models.ReportSchedule) { s.Format = "xlsx" }},
+        {"hour out of range", func(s *models.ReportSchedule) { s.Hour = 24 }},
+        {"weekday out of range", func(s *models.ReportSchedule) { s.Weekday = 7 }},
+        {"day of month missing", func(s *models.ReportSchedule) { s.Frequency = models.ReportMonthly; s.DayOfMonth = 0 }},
+        {"day of month past 28", func(s *models.ReportSchedule) { s.Frequency = models.ReportMonthly; s.DayOfMonth = 31 }},
+        {"missing timezone", func(s *models.ReportSchedule) { s.Timezone = "" }},
+        {"server timezone", func(s *models.ReportSchedule) { s.Timezone = "Local" }},
+        {"unknown timezone", func(s *models.ReportSchedule) { s.Timezone = "Mars/Olympus" }},
+    }
+
+    for _, tt := range tests {
+        tt := tt
+        t.Run(tt.name, func(t *testing.T) {
+            s := &models.ReportSchedule{
+                Frequency: models.ReportWeekly,
+                Format:    models.ReportFormatCSV,
+                Timezone:  "UTC",
+                Hour:      8,
+            }
+            tt.mutate(s)
+            assert.ErrorIs(t, s.Validate(), models.ErrInvalidReportSchedule)
+        })
+    }
+}
+
+// TestReportScheduleNextRun verifies delivery times are taken in the schedule's
+// timezone, across daylight saving changes and month ends
+func TestReportScheduleNextRun(t *testing.T) {
+    t.Parallel()
+
+    berlin, err := time.LoadLocation("Europe/Berlin")
+    require.NoError(t, err)
+
+    weekly := &models.ReportSchedule{Frequency: models.ReportWeekly, Timezone: "Europe/Berlin", Hour: 8, Weekday: int(time.Monday)}
+
+    // Wednesday, March 20, 2024: next Monday at 08:00 CET
+    next, err := weekly.NextRun(time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC))
+    require.NoError(t, err)
+    assert.Equal(t, time.Date(2024, 3, 25, 8, 0, 0, 0, berlin).UTC(), next)
+    assert.Equal(t, time.Date(2024, 3, 25, 7, 0, 0, 0, time.UTC), next, "summer time starts on March 31")
+    assert.Equal(t, time.UTC, next.Location())
+
+    // Exactly at a delivery time the next one is a week later, an hour
+    // earlier in UTC after the switch to summer time
+    next, err = weekly.NextRun(next)
+    require.NoError(t, err)
+    assert.Equal(t, time.Date(2024, 4, 1, 6, 0, 0, 0, time.UTC), next)
+
+    // Monday before 08:00 local delivers the same day
+    next, err = weekly.NextRun(time.Date(2024, 4, 8, 5, 0, 0, 0, time.UTC))
+    require.NoError(t, err)
+    assert.Equal(t, time.Date(2024, 4, 8, 6, 0, 0, 0, time.UTC), next)
+
+    monthly := &models.ReportSchedule{Frequency: models.ReportMonthly, Timezone: "America/New_York", Hour: 23, DayOfMonth: 1}
+
+    // 23:00 on December 1 in New York is already December 2 in UTC
+    next, err = monthly.NextRun(time.Date(2024, 11, 15, 0, 0, 0, 0, time.UTC))
+    require.NoError(t, err)
+    assert.Equal(t, time.Date(2024, 12, 2, 4, 0, 0, 0, time.UTC), next)
+
+    next, err = monthly.NextRun(next)
+    require.NoError(t, err)
+    assert.Equal(t, time.Date(2025, 1, 2, 4, 0, 0, 0, time.UTC), next, "rolls over into the next year")
+
+    // The delivery period is the local month completed at the due time
+    start, end, err := models.LastCompletedReportPeriod(monthly.Frequency.Period(), next.In(mustLoadLocation(t, "America/New_York")))
+    require.NoError(t, err)
+    assert.Equal(t, 12, int(start.Month()))
+    assert.Equal(t, 1, int(end.Month()))
+    assert.Equal(t, 0, end.Hour())
+
+    _, err = (&models.ReportSchedule{Frequency: "daily", Timezone: "UTC"}).NextRun(time.Now())
+    assert.ErrorIs(t, err, models.ErrInvalidReportSchedule)
+}
+
+// TestRenderCSV verifies the CSV rendering of a report's holdings
+func TestRenderCSV(t *testing.T) {
+    t.Parallel()
+
+    report := reportFixture(t)
+
+    data, err := reports.Render(report, models.ReportFormatCSV)
+    require.NoError(t, err)
+
+    records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+    require.NoError(t, err)
+    require.Len(t, records, len(report.Holdings)+1)
+    assert.Equal(t, "Symbol", records[0][2])
+
+    bySymbol := make(map[string][]string)
+    for _, r := range records[1:] {
+        bySymbol[r[2]] = r
+    }
+    assert.Equal(t, "0.1", bySymbol["BTC"][3])
+    assert.Equal(t, "6600", bySymbol["BTC"][4])
+    assert.Equal(t, "10", bySymbol["BTC"][8], "top movers carry their price change")
+    assert.Equal(t, "", bySymbol["SOL"][8], "assets bought during the period have no price change")
+    assert.True(t, strings.HasPrefix(bySymbol["ETH"][0], "2024-04-01"))
+
+    assert.Equal(t, "text/csv", reports.ContentType(models.ReportFormatCSV))
+    assert.Equal(t, "application/pdf", reports.ContentType(models.ReportFormatPDF))
+    assert.Equal(t, "portfolio-report-quarter-2024-04-01.csv", reports.Filename(report, models.ReportFormatCSV))
+    assert.True(t, strings.HasSuffix(reports.ObjectKey("reports", report, models.ReportFormatPDF), report.ID.String()+".pdf"))
+
+    _, err = reports.Render(report, "xlsx")
+    assert.Error(t, err)
+}
+
+// mustLoadLocation loads a time zone or fails the test
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+    t.Helper()
+    loc, err := time.LoadLocation(name)
+    require.NoError(t, err)
+    return loc
+}
@@ -0,0 +1,174 @@
+package tests
+
+import (
+    "bytes"
+    "fmt"
+    "regexp"
+    "strconv"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"               // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/reports"
+)
+
+// assetSnapshot returns a snapshot holding of amount units valued at price
+func assetSnapshot(id uuid.UUID, symbol, amount, price, costBasis string) models.AssetSnapshot {
+    a := decimal.RequireFromString(amount)
+    return models.AssetSnapshot{
+        AssetID:   id,
+        Symbol:    symbol,
+        Amount:    a,
+        Value:     a.Mul(decimal.RequireFromString(price)),
+        CostBasis: decimal.RequireFromString(costBasis),
+    }
+}
+
+// reportFixture returns a quarterly report of a portfolio holding BTC, ETH and
+// SOL bought during the quarter
+func reportFixture(t *testing.T) *models.PerformanceReport {
+    t.Helper()
+
+    btc, eth, sol := uuid.New(), uuid.New(), uuid.New()
+    portfolio := &models.Portfolio{ID: uuid.New(), Name: "Long (term) \\ holdings"}
+    start, end, err := models.ReportPeriodBounds(models.ReportPeriodQuarter, time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC))
+    require.NoError(t, err)
+
+    first := &models.PortfolioSnapshot{
+        TotalValue: decimal.NewFromInt(12000),
+        ProfitLoss: decimal.NewFromInt(2000),
+        Assets: []models.AssetSnapshot{
+            assetSnapshot(btc, "BTC", "0.1", "60000", "5000"),
+            assetSnapshot(eth, "ETH", "2", "3000", "5000"),
+        },
+    }
+    last := &models.PortfolioSnapshot{
+        TotalValue: decimal.NewFromInt(13850),
+        ProfitLoss: decimal.NewFromInt(3000),
+        Assets: []models.AssetSnapshot{
+            assetSnapshot(btc, "BTC", "0.1", "66000", "5000"),
+            assetSnapshot(eth, "ETH", "2", "2625", "5000"),
+            assetSnapshot(sol, "SOL", "20", "100", "2000"),
+        },
+    }
+    return models.BuildPerformanceReport(portfolio, models.ReportPeriodQuarter, start, end, first, last)
+}
+
+// TestReportPeriodBounds verifies weeks, months and quarters align to the
+// calendar in the location of the given time
+func TestReportPeriodBounds(t *testing.T) {
+    t.Parallel()
+
+    zone := time.FixedZone("UTC-5", -5*3600)
+    at := time.Date(2024, 11, 30, 23, 0, 0, 0, zone)
+
+    start, end, err := models.ReportPeriodBounds(models.ReportPeriodMonth, at)
+    require.NoError(t, err)
+    assert.Equal(t, time.Date(2024, 11, 1, 0, 0, 0, 0, zone), start, "bounds are taken in the location of at")
+    assert.Equal(t, time.Date(2024, 12, 1, 0, 0, 0, 0, zone), end)
+
+    start, end, err = models.ReportPeriodBounds(models.ReportPeriodMonth, at.UTC())
+    require.NoError(t, err)
+    assert.Equal(t, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), start)
+    assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), end)
+
+    start, end, err = models.ReportPeriodBounds(models.ReportPeriodQuarter, at)
+    require.NoError(t, err)
+    assert.Equal(t, time.Date(2024, 10, 1, 0, 0, 0, 0, zone), start)
+    assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, zone), end)
+
+    // Saturday, November 30 falls in the week starting Monday, November 25
+    start, end, err = models.ReportPeriodBounds(models.ReportPeriodWeek, at)
+    require.NoError(t, err)
+    assert.Equal(t, time.Date(2024, 11, 25, 0, 0, 0, 0, zone), start)
+    assert.Equal(t, time.Date(2024, 12, 2, 0, 0, 0, 0, zone), end)
+
+    sunday := time.Date(2024, 12, 1, 12, 0, 0, 0, time.UTC)
+    start, _, err = models.ReportPeriodBounds(models.ReportPeriodWeek, sunday)
+    require.NoError(t, err)
+    assert.Equal(t, time.Date(2024, 11, 25, 0, 0, 0, 0, time.UTC), start, "Sunday closes the week")
+
+    start, end, err = models.LastCompletedReportPeriod(models.ReportPeriodMonth, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC))
+    require.NoError(t, err)
+    assert.Equal(t, time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC), start)
+    assert.Equal(t, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), end)
+
+    start, end, err = models.LastCompletedReportPeriod(models.ReportPeriodWeek, sunday)
+    require.NoError(t, err)
+    assert.Equal(t, time.Date(2024, 11, 18, 0, 0, 0, 0, time.UTC), start)
+    assert.Equal(t, time.Date(2024, 11, 25, 0, 0, 0, 0, time.UTC), end)
+
+    _, _, err = models.ReportPeriodBounds("year", at)
+    assert.Error(t, err)
+}
+
+// TestBuildPerformanceReport verifies the P&L summary, holdings, allocation
+// and top movers
+func TestBuildPerformanceReport(t *testing.T) {
+    t.Parallel()
+
+    report := reportFixture(t)
+    assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), report.PeriodStart)
+    assert.True(t, report.PeriodProfitLoss.Equal(decimal.NewFromInt(1000)))
+    assert.True(t, report.ReturnPercentage.Equal(decimal.RequireFromString("8.33")))
+
+    require.Len(t, report.Holdings, 3)
+    assert.Equal(t, "BTC", report.Holdings[0].Symbol, "holdings are ordered by value")
+    assert.True(t, report.Holdings[0].ProfitLoss.Equal(decimal.NewFromInt(1600)))
+    assert.True(t, report.Holdings[0].Weight.Equal(decimal.RequireFromString("47.65")))
+
+    require.Len(t, report.Allocation, 3)
+    assert.Equal(t, "BTC", report.Allocation[0].Key)
+
+    require.Len(t, report.TopMovers, 2, "assets bought during the period have no start price")
+    assert.Equal(t, "ETH", report.TopMovers[0].Symbol)
+    assert.True(t, report.TopMovers[0].ChangePercent.Equal(decimal.RequireFromString("-12.5")))
+    assert.True(t, report.TopMovers[1].ChangePercent.Equal(decimal.NewFromInt(10)))
+
+    empty := models.BuildPerformanceReport(&models.Portfolio{}, models.ReportPeriodMonth, time.Time{}, time.Time{}, nil, &models.PortfolioSnapshot{})
+    assert.True(t, empty.ReturnPercentage.IsZero())
+    assert.Empty(t, empty.Holdings)
+    assert.Empty(t, empty.TopMovers)
+}
+
+// TestRenderPDF verifies the rendered document structure and that text is escaped
+func TestRenderPDF(t *testing.T) {
+    t.Parallel()
+
+    report := reportFixture(t)
+    for i := 0; i < 80; i++ {
+        report.Holdings = append(report.Holdings, models.ReportHolding{Symbol: fmt.Sprintf("TOKEN%d", i)})
+    }
+
+    pdf, err := reports.RenderPDF(report)
+    require.NoError(t, err)
+
+    assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+    assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+    assert.Contains(t, string(pdf), `(Long \(term\) \\ holdings) Tj`)
+    assert.Contains(t, string(pdf), `(Q2 2024 \(2024-04-01 to 2024-06-30\)) Tj`)
+    assert.Contains(t, string(pdf), "(66,000.00) Tj")
+
+    count := regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`).FindSubmatch(pdf)
+    require.NotNil(t, count)
+    pages, err := strconv.Atoi(string(count[1]))
+    require.NoError(t, err)
+    assert.Greater(t, pages, 1, "long holding tables continue on further pages")
+    assert.Contains(t, string(pdf), fmt.Sprintf("(Page %d of %d) Tj", pages, pages))
+
+    // The cross-reference table must point at each object
+    xref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+    require.NotNil(t, xref)
+    offset, err := strconv.Atoi(string(xref[1]))
+    require.NoError(t, err)
+    assert.True(t, bytes.HasPrefix(pdf[offset:], []byte("xref\n")))
+    for i, m := range regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf, -1) {
+        offset, _ := strconv.Atoi(string(m[1]))
+        assert.True(t, bytes.HasPrefix(pdf[offset:], []byte(fmt.Sprintf("%d 0 obj", i+1))))
+    }
+}
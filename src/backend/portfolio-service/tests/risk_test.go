@@ -0,0 +1,152 @@
+package tests
+
+import (
+    "math"
+    "testing"
+    "time"
+
+    "github.com/shopspring/decimal"    // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/marketdata"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/risk"
+)
+
+// alternatingReturns returns n daily returns alternating between +r and -r
+func alternatingReturns(n int, r float64) []float64 {
+    returns := make([]float64, n)
+    for i := range returns {
+        if i%2 == 0 {
+            returns[i] = r
+        } else {
+            returns[i] = -r
+        }
+    }
+    return returns
+}
+
+// TestParametricVaR verifies normal VaR scaling across confidence and horizon
+func TestParametricVaR(t *testing.T) {
+    t.Parallel()
+
+    returns := alternatingReturns(100, 0.02)
+
+    var95, err := risk.ParametricVaR(returns, 0.95, 1)
+    require.NoError(t, err)
+    var99, err := risk.ParametricVaR(returns, 0.99, 1)
+    require.NoError(t, err)
+    var95x10, err := risk.ParametricVaR(returns, 0.95, 10)
+    require.NoError(t, err)
+
+    stddev := 0.02 * math.Sqrt(100.0/99.0)
+    assert.InDelta(t, 1.6448536269514722*stddev, var95, 1e-9)
+    assert.Greater(t, var99, var95)
+    assert.InDelta(t, var95*math.Sqrt(10), var95x10, 1e-9)
+
+    _, err = risk.ParametricVaR(returns, 0.9, 1)
+    assert.Error(t, err)
+    _, err = risk.ParametricVaR(returns[:10], 0.95, 1)
+    assert.ErrorIs(t, err, risk.ErrInsufficientHistory)
+}
+
+// TestHistoricalVaR verifies the empirical loss quantile of returns
+func TestHistoricalVaR(t *testing.T) {
+    t.Parallel()
+
+    // Returns of -1%..-100% in 1% steps
+    returns := make([]float64, 100)
+    for i := range returns {
+        returns[i] = -float64(i+1) / 100
+    }
+
+    var95, err := risk.HistoricalVaR(returns, 0.95, 1)
+    require.NoError(t, err)
+    assert.InDelta(t, 0.96, var95, 1e-9)
+
+    var99, err := risk.HistoricalVaR(returns, 0.99, 10)
+    require.NoError(t, err)
+    assert.InDelta(t, 1.0*math.Sqrt(10), var99, 1e-9)
+
+    gains := alternatingReturns(100, 0.01)
+    for i := range gains {
+        gains[i] = math.Abs(gains[i])
+    }
+    noLoss, err := risk.HistoricalVaR(gains, 0.99, 1)
+    require.NoError(t, err)
+    assert.Zero(t, noLoss)
+}
+
+// TestPortfolioReturns verifies value weighting of aligned asset returns
+func TestPortfolioReturns(t *testing.T) {
+    t.Parallel()
+
+    combined, err := risk.PortfolioReturns(
+        map[string][]float64{
+            "BTC": {0.10, -0.10},
+            "ETH": {0.20, 0.00},
+        },
+        map[string]float64{"BTC": 3000, "ETH": 1000},
+    )
+    require.NoError(t, err)
+    require.Len(t, combined, 2)
+    assert.InDelta(t, 0.125, combined[0], 1e-12)
+    assert.InDelta(t, -0.075, combined[1], 1e-12)
+
+    _, err = risk.PortfolioReturns(map[string][]float64{"BTC": {0.1}}, map[string]float64{"SOL": 1})
+    assert.Error(t, err)
+}
+
+// TestAlignReturns verifies returns are only computed across consecutive shared days
+func TestAlignReturns(t *testing.T) {
+    t.Parallel()
+
+    day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+    point := func(symbol string, d int, close string) models.PricePoint {
+        return models.PricePoint{Symbol: symbol, Timestamp: day(d), Close: decimal.RequireFromString(close)}
+    }
+
+    series := marketdata.AlignReturns(map[string][]models.PricePoint{
+        "BTC": {point("BTC", 1, "100"), point("BTC", 2, "110"), point("BTC", 3, "99"), point("BTC", 5, "120"), point("BTC", 6, "132")},
+        "ETH": {point("ETH", 1, "10"), point("ETH", 2, "10"), point("ETH", 3, "12"), point("ETH", 4, "11"), point("ETH", 5, "12"), point("ETH", 6, "15")},
+    })
+
+    // Day 4 is missing for BTC, so returns ending on days 4 and 5 are skipped
+    require.Equal(t, []time.Time{day(2), day(3), day(6)}, series.Dates)
+    assert.InDeltaSlice(t, []float64{0.10, -0.10, 0.10}, series.Returns["BTC"], 1e-12)
+    assert.InDeltaSlice(t, []float64{0.0, 0.20, 0.25}, series.Returns["ETH"], 1e-12)
+}
+
+// TestCorrelationMatrix verifies pairwise correlations of aligned returns
+func TestCorrelationMatrix(t *testing.T) {
+    t.Parallel()
+
+    base := alternatingReturns(20, 0.01)
+    inverse := make([]float64, len(base))
+    scaled := make([]float64, len(base))
+    flat := make([]float64, len(base))
+    for i, r := range base {
+        inverse[i] = -r
+        scaled[i] = 3*r + 0.001
+    }
+
+    symbols := []string{"BTC", "ETH", "SOL", "USDC"}
+    matrix, err := risk.CorrelationMatrix(symbols, map[string][]float64{
+        "BTC":  base,
+        "ETH":  scaled,
+        "SOL":  inverse,
+        "USDC": flat,
+    })
+    require.NoError(t, err)
+    require.Len(t, matrix, len(symbols))
+
+    assert.InDelta(t, 1.0, matrix[0][1], 1e-9)
+    assert.InDelta(t, -1.0, matrix[0][2], 1e-9)
+    assert.Equal(t, matrix[2][0], matrix[0][2])
+    assert.Zero(t, matrix[0][3])
+    assert.Equal(t, 1.0, matrix[3][3])
+
+    _, err = risk.CorrelationMatrix([]string{"BTC"}, map[string][]float64{"BTC": base[:5]})
+    assert.ErrorIs(t, err, risk.ErrInsufficientHistory)
+}
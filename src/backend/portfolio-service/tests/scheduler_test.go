@@ -0,0 +1,122 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+    "go.uber.org/zap"                       // v1.24.0
+
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/scheduler"
+)
+
+func TestCronNext(t *testing.T) {
+    from := time.Date(2024, 1, 31, 10, 17, 30, 0, time.UTC) // a Wednesday
+
+    tests := []struct {
+        name       string
+        expression string
+        expected   time.Time
+    }{
+        {"every minute", "* * * * *", time.Date(2024, 1, 31, 10, 18, 0, 0, time.UTC)},
+        {"hourly descriptor", "@hourly", time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC)},
+        {"step", "*/15 * * * *", time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC)},
+        {"step from offset", "5/20 * * * *", time.Date(2024, 1, 31, 10, 25, 0, 0, time.UTC)},
+        {"list and range", "0 8-9,18 * * *", time.Date(2024, 1, 31, 18, 0, 0, 0, time.UTC)},
+        {"month rollover", "0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+        {"leap day", "0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+        {"weekday name", "30 9 * * mon", time.Date(2024, 2, 5, 9, 30, 0, 0, time.UTC)},
+        {"sunday as seven", "0 0 * * 7", time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+        {"either day field", "0 0 15 * fri", time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)},
+        {"month name", "0 0 1 jul *", time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            schedule, err := scheduler.Parse(tt.expression)
+            require.NoError(t, err)
+            assert.Equal(t, tt.expected, schedule.Next(from))
+        })
+    }
+}
+
+func TestCronNextIsStrictlyAfter(t *testing.T) {
+    schedule, err := scheduler.Parse("0 * * * *")
+    require.NoError(t, err)
+
+    at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+    assert.Equal(t, at.Add(time.Hour), schedule.Next(at))
+}
+
+func TestCronNextInLocation(t *testing.T) {
+    location, err := time.LoadLocation("America/New_York")
+    require.NoError(t, err)
+
+    schedule, err := scheduler.Parse("30 2 * * *")
+    require.NoError(t, err)
+
+    // 02:30 does not exist on the day clocks spring forward
+    next := schedule.Next(time.Date(2024, 3, 9, 12, 0, 0, 0, location))
+    assert.Equal(t, time.Date(2024, 3, 11, 2, 30, 0, 0, location), next)
+}
+
+func TestCronNextWithoutActivation(t *testing.T) {
+    schedule, err := scheduler.Parse("0 0 30 2 *")
+    require.NoError(t, err)
+    assert.True(t, schedule.Next(time.Now()).IsZero())
+}
+
+func TestCronParseInvalid(t *testing.T) {
+    for _, expression := range []string{
+        "",
+        "* * * *",
+        "60 * * * *",
+        "* 24 * * *",
+        "* * 0 * *",
+        "* * * 13 *",
+        "*/0 * * * *",
+        "10-5 * * * *",
+        "* * * * funday",
+        "@often",
+    } {
+        _, err := scheduler.Parse(expression)
+        assert.True(t, errors.Is(err, scheduler.ErrInvalidSchedule), expression)
+    }
+}
+
+func TestSchedulerRegister(t *testing.T) {
+    cfg := config.SchedulerConfig{
+        Timezone:     "UTC",
+        DrainTimeout: time.Second,
+        Jobs: map[string]string{
+            "snapshots": "0 * * * *",
+            "broken":    "* * *",
+        },
+    }
+    sched, err := scheduler.NewScheduler(cfg, nil, zap.NewNop())
+    require.NoError(t, err)
+
+    run := func(ctx context.Context) error { return nil }
+    require.NoError(t, sched.RegisterExclusive("snapshots", run))
+    assert.Error(t, sched.Register("snapshots", run))
+    assert.Error(t, sched.Register("unconfigured", run))
+    assert.ErrorIs(t, sched.Register("broken", run), scheduler.ErrInvalidSchedule)
+
+    // Run returns once cancelled when no job is running
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    done := make(chan struct{})
+    go func() {
+        sched.Run(ctx)
+        close(done)
+    }()
+    select {
+    case <-done:
+    case <-time.After(time.Second):
+        t.Fatal("scheduler did not stop")
+    }
+}
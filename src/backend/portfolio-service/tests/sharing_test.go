@@ -0,0 +1,111 @@
+package tests
+
+import (
+    "context"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"               // v1.3.0
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+    "google.golang.org/grpc"                // v1.50.0
+    "google.golang.org/grpc/codes"          // v1.50.0
+    "google.golang.org/grpc/metadata"       // v1.50.0
+    "google.golang.org/grpc/status"         // v1.50.0
+
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/sharing"
+)
+
+// shareResolver resolves a single known token
+type shareResolver struct {
+    token string
+    share *models.PortfolioShare
+}
+
+func (r shareResolver) ResolveShare(ctx context.Context, token string) (*models.PortfolioShare, error) {
+    if token == r.token {
+        return r.share, nil
+    }
+    return nil, nil
+}
+
+// TestShareTokens verifies tokens are random, hash deterministically and are
+// rejected when malformed
+func TestShareTokens(t *testing.T) {
+    t.Parallel()
+
+    token, hash, err := sharing.NewToken()
+    require.NoError(t, err)
+    assert.True(t, strings.HasPrefix(token, "sh1."))
+    assert.Len(t, hash, 32)
+
+    again, err := sharing.HashToken(token)
+    require.NoError(t, err)
+    assert.Equal(t, hash, again)
+
+    other, otherHash, err := sharing.NewToken()
+    require.NoError(t, err)
+    assert.NotEqual(t, token, other)
+    assert.NotEqual(t, hash, otherHash)
+
+    for _, malformed := range []string{"", "sh1.", "sh1.short", "pg1." + strings.TrimPrefix(token, "sh1."), token + "x"} {
+        _, err := sharing.HashToken(malformed)
+        assert.ErrorIs(t, err, sharing.ErrInvalidToken, malformed)
+    }
+}
+
+// TestPortfolioShareActive verifies shares stop granting access once expired
+// or revoked
+func TestPortfolioShareActive(t *testing.T) {
+    t.Parallel()
+
+    now := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+    share := models.PortfolioShare{CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+    assert.True(t, share.Active(now))
+    assert.False(t, share.Active(now.Add(time.Hour)))
+
+    revokedAt := now.Add(time.Minute)
+    share.RevokedAt = &revokedAt
+    assert.False(t, share.Active(now.Add(2*time.Minute)))
+}
+
+// TestShareInterceptor verifies share tokens authorize only the shared
+// portfolio method and leave calls without a token untouched
+func TestShareInterceptor(t *testing.T) {
+    t.Parallel()
+
+    const method = "/portfolio.PortfolioService/GetSharedPortfolio"
+    share := &models.PortfolioShare{ID: uuid.New(), PortfolioID: uuid.New()}
+    interceptor := sharing.UnaryServerInterceptor(shareResolver{token: "valid", share: share}, method)
+
+    handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+        got, ok := sharing.FromContext(ctx)
+        if !ok {
+            return nil, nil
+        }
+        return got, nil
+    }
+    call := func(fullMethod, token string) (interface{}, error) {
+        ctx := context.Background()
+        if token != "" {
+            ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(sharing.MetadataKey, token))
+        }
+        return interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: fullMethod}, handler)
+    }
+
+    got, err := call(method, "valid")
+    require.NoError(t, err)
+    assert.Equal(t, share, got)
+
+    got, err = call("/portfolio.PortfolioService/GetTransactions", "")
+    require.NoError(t, err)
+    assert.Nil(t, got)
+
+    _, err = call("/portfolio.PortfolioService/GetTransactions", "valid")
+    assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+    _, err = call(method, "revoked")
+    assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+    "google.golang.org/grpc/codes"          // v1.50.0
+
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/slo"
+)
+
+// findBurnRate returns the burn rate of an objective over a window
+func findBurnRate(t *testing.T, rates []slo.BurnRate, name string, objective slo.Objective, window time.Duration) slo.BurnRate {
+    t.Helper()
+    for _, rate := range rates {
+        if rate.SLO == name && rate.Objective == objective && rate.Window == window {
+            return rate
+        }
+    }
+    require.Failf(t, "burn rate not found", "%s %s %s", name, objective, window)
+    return slo.BurnRate{}
+}
+
+// TestSLOBurnRates verifies calls are measured against the objective of their
+// method and burn rates are computed over each window
+func TestSLOBurnRates(t *testing.T) {
+    recorder, err := slo.NewRecorder(config.SLOConfig{
+        Enabled: true,
+        Objectives: []config.ServiceObjective{
+            {Name: "reads", Methods: []string{"GetPortfolio"}, Availability: 0.99, LatencyThreshold: time.Millisecond * 100, LatencyTarget: 0.9},
+            {Name: "api", Availability: 0.999},
+        },
+        BurnRateWindows:    []time.Duration{time.Minute * 5, time.Hour},
+        EvaluationInterval: time.Minute,
+    })
+    require.NoError(t, err)
+
+    now := time.Date(2024, 3, 1, 12, 0, 30, 0, time.UTC)
+    get := "/portfolio.PortfolioService/GetPortfolio"
+    for i := 0; i < 98; i++ {
+        elapsed := time.Millisecond * 10
+        if i < 20 {
+            elapsed = time.Second
+        }
+        recorder.Observe(now, get, codes.OK, elapsed)
+    }
+    recorder.Observe(now, get, codes.InvalidArgument, time.Millisecond)
+    recorder.Observe(now, get, codes.Internal, time.Millisecond)
+    recorder.Observe(now, get, codes.Canceled, time.Millisecond)
+    recorder.Observe(now.Add(-time.Minute*30), "/portfolio.PortfolioService/CreatePortfolio", codes.Unavailable, time.Millisecond)
+
+    rates := recorder.Evaluate(now)
+    availability := findBurnRate(t, rates, "reads", slo.Availability, time.Minute*5)
+    assert.Equal(t, uint64(100), availability.Events, "canceled calls are not counted")
+    assert.InDelta(t, 0.99, availability.Ratio, 1e-9, "client errors are good events")
+    assert.InDelta(t, 1, availability.Rate, 1e-9)
+
+    latency := findBurnRate(t, rates, "reads", slo.Latency, time.Minute*5)
+    assert.Equal(t, uint64(99), latency.Events, "failed calls are not timed")
+    assert.InDelta(t, 79.0/99, latency.Ratio, 1e-9)
+    assert.InDelta(t, (20.0/99)/0.1, latency.Rate, 1e-9)
+
+    api := findBurnRate(t, rates, "api", slo.Availability, time.Minute*5)
+    assert.Zero(t, api.Events, "outside the window")
+    api = findBurnRate(t, rates, "api", slo.Availability, time.Hour)
+    assert.Equal(t, uint64(1), api.Events)
+    assert.InDelta(t, 1000, api.Rate, 1e-6)
+    for _, rate := range rates {
+        assert.False(t, rate.SLO == "api" && rate.Objective == slo.Latency, "no latency objective")
+    }
+
+    rates = recorder.Evaluate(now.Add(time.Minute * 10))
+    availability = findBurnRate(t, rates, "reads", slo.Availability, time.Minute*5)
+    assert.Zero(t, availability.Events)
+    assert.Equal(t, float64(1), availability.Ratio)
+    assert.Zero(t, availability.Rate)
+    assert.Equal(t, uint64(100), findBurnRate(t, rates, "reads", slo.Availability, time.Hour).Events)
+
+    rates = recorder.Evaluate(now.Add(time.Hour * 3))
+    assert.Zero(t, findBurnRate(t, rates, "reads", slo.Availability, time.Hour).Events)
+
+    _, err = slo.NewRecorder(config.SLOConfig{
+        Objectives:         []config.ServiceObjective{{Name: "api", Availability: 0.999}},
+        BurnRateWindows:    []time.Duration{time.Second * 10},
+        EvaluationInterval: time.Minute,
+    })
+    assert.Error(t, err, "window shorter than the interval")
+}
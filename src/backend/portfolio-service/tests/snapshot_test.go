@@ -0,0 +1,138 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "sort"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"              // v1.3.0
+    "github.com/stretchr/testify/assert"  // v1.8.0
+    "github.com/stretchr/testify/require" // v1.8.0
+    "go.uber.org/zap"                     // v1.24.0
+
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/models"
+    "bookman/portfolio-service/internal/snapshot"
+)
+
+// fakePortfolioPages pages through a fixed set of portfolio IDs, recording
+// the cursor of each page
+type fakePortfolioPages struct {
+    ids     []uuid.UUID
+    err     error
+    cursors []uuid.UUID
+}
+
+func (p *fakePortfolioPages) ListPortfolioIDs(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+    p.cursors = append(p.cursors, after)
+    if p.err != nil {
+        return nil, p.err
+    }
+    page := make([]uuid.UUID, 0, limit)
+    for _, id := range p.ids {
+        if bytes.Compare(id[:], after[:]) > 0 && len(page) < limit {
+            page = append(page, id)
+        }
+    }
+    return page, nil
+}
+
+// fakeCapturer records the snapshots it is asked to capture, failing those
+// of the portfolios in failing
+type fakeCapturer struct {
+    mutex    sync.Mutex
+    failing  map[uuid.UUID]bool
+    captured map[uuid.UUID]time.Time
+}
+
+func (c *fakeCapturer) CaptureSnapshot(ctx context.Context, portfolioID uuid.UUID, capturedAt time.Time) (*models.PortfolioSnapshot, error) {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    if err := ctx.Err(); err != nil {
+        return nil, err
+    }
+    if c.failing[portfolioID] {
+        return nil, errors.New("price lookup failed")
+    }
+    if c.captured == nil {
+        c.captured = make(map[uuid.UUID]time.Time)
+    }
+    c.captured[portfolioID] = capturedAt
+    return &models.PortfolioSnapshot{PortfolioID: portfolioID, CapturedAt: capturedAt}, nil
+}
+
+// sortedPortfolioIDs returns n random portfolio IDs in ascending order
+func sortedPortfolioIDs(n int) []uuid.UUID {
+    ids := make([]uuid.UUID, n)
+    for i := range ids {
+        ids[i] = uuid.New()
+    }
+    sort.Slice(ids, func(i, j int) bool { return bytes.Compare(ids[i][:], ids[j][:]) < 0 })
+    return ids
+}
+
+// TestSnapshotRecorder verifies every portfolio is snapshotted page by page
+// at the start of its interval slot, and that failed captures are reported
+// without stopping the pass
+func TestSnapshotRecorder(t *testing.T) {
+    t.Parallel()
+
+    cfg := config.SnapshotConfig{Enabled: true, Interval: time.Hour, BatchSize: 2}
+    ids := sortedPortfolioIDs(5)
+    pages := &fakePortfolioPages{ids: ids}
+    capturer := &fakeCapturer{failing: map[uuid.UUID]bool{ids[2]: true}}
+    recorder, err := snapshot.NewRecorder(pages, capturer, cfg, zap.NewNop())
+    require.NoError(t, err)
+
+    slot := time.Date(2024, 5, 1, 9, 34, 56, 0, time.FixedZone("CEST", 2*60*60))
+    err = recorder.RunOnce(context.Background(), slot)
+    assert.ErrorContains(t, err, "1 portfolios failed to snapshot")
+
+    assert.Equal(t, []uuid.UUID{uuid.Nil, ids[1], ids[3]}, pages.cursors, "pages follow the last ID")
+    slotStart := time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC)
+    assert.Len(t, capturer.captured, 4)
+    for i, id := range ids {
+        if i == 2 {
+            assert.NotContains(t, capturer.captured, id)
+            continue
+        }
+        assert.Equal(t, slotStart, capturer.captured[id])
+    }
+
+    // A full last page is followed by an empty one
+    pages = &fakePortfolioPages{ids: ids[:4]}
+    capturer = &fakeCapturer{}
+    recorder, err = snapshot.NewRecorder(pages, capturer, cfg, zap.NewNop())
+    require.NoError(t, err)
+    require.NoError(t, recorder.RunOnce(context.Background(), slot))
+    assert.Len(t, pages.cursors, 3)
+    assert.Len(t, capturer.captured, 4)
+}
+
+// TestSnapshotRecorderErrors verifies listing failures and cancellation end
+// the pass
+func TestSnapshotRecorderErrors(t *testing.T) {
+    t.Parallel()
+
+    cfg := config.SnapshotConfig{Enabled: true, Interval: time.Hour, BatchSize: 10}
+    listErr := errors.New("connection refused")
+    recorder, err := snapshot.NewRecorder(&fakePortfolioPages{err: listErr}, &fakeCapturer{}, cfg, zap.NewNop())
+    require.NoError(t, err)
+    assert.ErrorIs(t, recorder.RunOnce(context.Background(), time.Now()), listErr)
+
+    capturer := &fakeCapturer{}
+    recorder, err = snapshot.NewRecorder(&fakePortfolioPages{ids: sortedPortfolioIDs(3)}, capturer, cfg, zap.NewNop())
+    require.NoError(t, err)
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    assert.ErrorIs(t, recorder.RunOnce(ctx, time.Now()), context.Canceled)
+    assert.Empty(t, capturer.captured)
+
+    _, err = snapshot.NewRecorder(nil, capturer, cfg, zap.NewNop())
+    assert.Error(t, err)
+}
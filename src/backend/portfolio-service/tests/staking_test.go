@@ -0,0 +1,98 @@
+package tests
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/chains"
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/exchanges"
+    "bookman/portfolio-service/internal/models"
+)
+
+func TestValidateStakingSource(t *testing.T) {
+    assert.NoError(t, models.ValidateStakingSource("chain"))
+    assert.NoError(t, models.ValidateStakingSource("exchange"))
+    assert.Error(t, models.ValidateStakingSource("validator"))
+    assert.Error(t, models.ValidateStakingSource(""))
+}
+
+func TestSolanaStakingRewards(t *testing.T) {
+    // The stake was activated in epoch 500; epoch 503 is running
+    rewards := map[float64]interface{}{
+        500: nil,
+        501: map[string]interface{}{"epoch": 501, "effectiveSlot": 216432000, "amount": 2500000, "postBalance": 1002500000},
+        502: map[string]interface{}{"epoch": 502, "effectiveSlot": 216864000, "amount": 2600000, "postBalance": 1005100000},
+    }
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var calls []struct {
+            ID     int             `json:"id"`
+            Method string          `json:"method"`
+            Params json.RawMessage `json:"params"`
+        }
+        require.NoError(t, json.NewDecoder(r.Body).Decode(&calls))
+
+        results := make([]map[string]interface{}, len(calls))
+        for i, call := range calls {
+            var params []interface{}
+            require.NoError(t, json.Unmarshal(call.Params, &params))
+            var result interface{}
+            switch call.Method {
+            case "getEpochInfo":
+                result = map[string]interface{}{"epoch": 503}
+            case "getAccountInfo":
+                result = map[string]interface{}{"value": map[string]interface{}{"data": map[string]interface{}{"parsed": map[string]interface{}{
+                    "type": "delegated",
+                    "info": map[string]interface{}{"stake": map[string]interface{}{"delegation": map[string]interface{}{"activationEpoch": "500"}}},
+                }}}}
+            case "getInflationReward":
+                epoch := params[1].(map[string]interface{})["epoch"].(float64)
+                result = []interface{}{rewards[epoch]}
+            case "getBlockTime":
+                result = 1700000000 + int64(params[0].(float64)-216432000)/2
+            }
+            results[i] = map[string]interface{}{"jsonrpc": "2.0", "id": call.ID, "result": result}
+        }
+        require.NoError(t, json.NewEncoder(w).Encode(results))
+    }))
+    defer server.Close()
+
+    registry := chains.NewRegistry(server.Client(), exchanges.RetryPolicy{MaxAttempts: 1})
+    require.NoError(t, registry.Register(chains.Solana(config.SolanaConfig{RPCURL: server.URL, RequestsPerSecond: 100})))
+    require.NoError(t, registry.Register(chains.Bitcoin(config.BitcoinConfig{EsploraURL: server.URL, RequestsPerSecond: 100})))
+
+    _, err := registry.Rewards("bitcoin")
+    assert.ErrorIs(t, err, chains.ErrUnsupportedStaking)
+    _, err = registry.Rewards("cardano")
+    assert.ErrorIs(t, err, chains.ErrUnsupportedChain)
+
+    reader, err := registry.Rewards("solana")
+    require.NoError(t, err)
+
+    ctx := context.Background()
+    activity, cursor, err := reader.FetchRewards(ctx, walletSolana, "")
+    require.NoError(t, err)
+    require.Len(t, activity.Entries, 2, "epochs without rewards are skipped")
+
+    first := activity.Entries[0]
+    assert.Equal(t, "epoch/501", first.ID)
+    assert.Equal(t, "reward", first.Type)
+    assert.Equal(t, "SOL", first.Asset)
+    assert.True(t, first.Amount.Equal(decimal.RequireFromString("0.0025")))
+    assert.Equal(t, time.Unix(1700000000, 0).UTC(), first.ExecutedAt)
+    assert.Equal(t, "epoch/502", activity.Entries[1].ID)
+
+    // The running epoch is read once it completes
+    activity, next, err := reader.FetchRewards(ctx, walletSolana, cursor)
+    require.NoError(t, err)
+    assert.Empty(t, activity.Entries)
+    assert.Equal(t, cursor, next)
+}
@@ -0,0 +1,284 @@
+package tests
+
+import (
+    "context"
+    "database/sql"
+    "database/sql/driver"
+    "errors"
+    "fmt"
+    "io"
+    "strings"
+    "sync"
+    "testing"
+
+    "github.com/lib/pq"                   // v1.10.9
+    "github.com/stretchr/testify/assert"  // v1.8.0
+    "github.com/stretchr/testify/require" // v1.8.0
+
+    "bookman/portfolio-service/internal/repository"
+)
+
+// TestIsInvalidStatement verifies which failures re-prepare statements
+func TestIsInvalidStatement(t *testing.T) {
+    t.Parallel()
+
+    tests := []struct {
+        name string
+        err  error
+        want bool
+    }{
+        {"nil", nil, false},
+        {"missing statement", &pq.Error{Code: "26000", Message: `prepared statement "1" does not exist`}, true},
+        {"wrapped missing statement", fmt.Errorf("failed to insert portfolio: %w", &pq.Error{Code: "26000"}), true},
+        {"cached plan", &pq.Error{Code: "0A000", Message: "cached plan must not change result type"}, true},
+        {"other unsupported feature", &pq.Error{Code: "0A000", Message: "cannot use RETURNING with multiple rules"}, false},
+        {"unique violation", &pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"}, false},
+        {"unwrapped missing statement", errors.New(`pq: prepared statement "1" does not exist`), true},
+        {"unwrapped cached plan", errors.New("pq: cached plan must not change result type"), true},
+        {"closed statement", fmt.Errorf("failed to query: %w", errors.New("sql: statement is closed")), true},
+        {"connection refused", errors.New("dial tcp: connection refused"), false},
+    }
+
+    for _, tt := range tests {
+        assert.Equal(t, tt.want, repository.IsInvalidStatement(tt.err), tt.name)
+    }
+}
+
+// TestRetryInvalidStatement verifies an invalidated statement is re-prepared
+// and retried once, and other failures are returned without retrying
+func TestRetryInvalidStatement(t *testing.T) {
+    t.Parallel()
+
+    invalid := &pq.Error{Code: "26000", Message: `prepared statement "1" does not exist`}
+
+    // run returns the errors of successive calls of fn, counting the calls
+    // of fn and reprepare
+    run := func(ctx context.Context, prepareErr error, errs ...error) (calls, prepares int, err error) {
+        err = repository.RetryInvalidStatement(ctx, func() error {
+            calls++
+            if calls > len(errs) {
+                return nil
+            }
+            return errs[calls-1]
+        }, func(ctx context.Context, cause error) error {
+            prepares++
+            assert.ErrorIs(t, cause, invalid)
+            return prepareErr
+        })
+        return calls, prepares, err
+    }
+
+    calls, prepares, err := run(context.Background(), nil)
+    assert.NoError(t, err)
+    assert.Equal(t, 1, calls)
+    assert.Zero(t, prepares)
+
+    calls, prepares, err = run(context.Background(), nil, invalid)
+    assert.NoError(t, err)
+    assert.Equal(t, 2, calls)
+    assert.Equal(t, 1, prepares)
+
+    // A statement still invalid after re-preparing is not retried again
+    calls, prepares, err = run(context.Background(), nil, invalid, invalid, invalid)
+    assert.ErrorIs(t, err, invalid)
+    assert.Equal(t, 2, calls)
+    assert.Equal(t, 1, prepares)
+
+    unique := &pq.Error{Code: "23505"}
+    calls, prepares, err = run(context.Background(), nil, unique)
+    assert.ErrorIs(t, err, unique)
+    assert.Equal(t, 1, calls)
+    assert.Zero(t, prepares)
+
+    prepareErr := errors.New("connection reset by peer")
+    calls, prepares, err = run(context.Background(), prepareErr, invalid)
+    assert.ErrorIs(t, err, prepareErr)
+    assert.Equal(t, 1, calls)
+    assert.Equal(t, 1, prepares)
+
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    calls, prepares, err = run(ctx, nil, invalid)
+    assert.ErrorIs(t, err, invalid)
+    assert.Equal(t, 1, calls)
+    assert.Zero(t, prepares)
+}
+
+// scriptedResult is the answer of a scripted statement: the rows a query
+// returns or the rows an execution affects
+type scriptedResult struct {
+    rowsAffected int64
+    columns      []string
+    rows         [][]driver.Value
+}
+
+// scriptedCall is a statement executed against a scriptedDB, or a
+// transaction boundary recorded as BEGIN, COMMIT or ROLLBACK
+type scriptedCall struct {
+    query string
+    args  []driver.Value
+}
+
+// scriptedDB is a database/sql connector answering statements from a script
+// keyed by a fragment of their query, recording the statements executed.
+// Statements without a scripted answer affect and return no rows.
+type scriptedDB struct {
+    script map[string]scriptedResult
+    mutex  sync.Mutex
+    calls  []scriptedCall
+}
+
+func (d *scriptedDB) Connect(context.Context) (driver.Conn, error) { return scriptedConn{db: d}, nil }
+func (d *scriptedDB) Driver() driver.Driver                        { return d }
+func (d *scriptedDB) Open(string) (driver.Conn, error)             { return scriptedConn{db: d}, nil }
+
+// record appends a call and returns the scripted answer of its query
+func (d *scriptedDB) record(query string, args []driver.Value) scriptedResult {
+    d.mutex.Lock()
+    defer d.mutex.Unlock()
+
+    d.calls = append(d.calls, scriptedCall{query: query, args: args})
+    for fragment, result := range d.script {
+        if strings.Contains(query, fragment) {
+            return result
+        }
+    }
+    return scriptedResult{}
+}
+
+// recorded returns the calls executed so far
+func (d *scriptedDB) recorded() []scriptedCall {
+    d.mutex.Lock()
+    defer d.mutex.Unlock()
+    return append([]scriptedCall(nil), d.calls...)
+}
+
+// scriptedConn is a connection of scriptedDB
+type scriptedConn struct {
+    db *scriptedDB
+}
+
+func (c scriptedConn) Prepare(query string) (driver.Stmt, error) {
+    return scriptedStmt{db: c.db, query: query}, nil
+}
+
+func (c scriptedConn) Close() error { return nil }
+
+func (c scriptedConn) Begin() (driver.Tx, error) {
+    c.db.record("BEGIN", nil)
+    return scriptedTx{db: c.db}, nil
+}
+
+// scriptedTx is a transaction of scriptedConn
+type scriptedTx struct {
+    db *scriptedDB
+}
+
+func (tx scriptedTx) Commit() error {
+    tx.db.record("COMMIT", nil)
+    return nil
+}
+
+func (tx scriptedTx) Rollback() error {
+    tx.db.record("ROLLBACK", nil)
+    return nil
+}
+
+// scriptedStmt is a statement prepared by scriptedConn
+type scriptedStmt struct {
+    db    *scriptedDB
+    query string
+}
+
+func (s scriptedStmt) Close() error  { return nil }
+func (s scriptedStmt) NumInput() int { return -1 }
+
+func (s scriptedStmt) Exec(args []driver.Value) (driver.Result, error) {
+    return driver.RowsAffected(s.db.record(s.query, args).rowsAffected), nil
+}
+
+func (s scriptedStmt) Query(args []driver.Value) (driver.Rows, error) {
+    result := s.db.record(s.query, args)
+    return &scriptedRows{columns: result.columns, rows: result.rows}, nil
+}
+
+// scriptedRows iterates over the rows of a scripted query
+type scriptedRows struct {
+    columns []string
+    rows    [][]driver.Value
+}
+
+func (r *scriptedRows) Columns() []string { return r.columns }
+func (r *scriptedRows) Close() error      { return nil }
+
+func (r *scriptedRows) Next(dest []driver.Value) error {
+    if len(r.rows) == 0 {
+        return io.EOF
+    }
+    copy(dest, r.rows[0])
+    r.rows = r.rows[1:]
+    return nil
+}
+
+// TestStatementCacheConcurrentReprepare verifies operations failing together
+// re-prepare once, and statements can be read and executed while another
+// handle re-prepares them. Run with -race to check the shared cache.
+func TestStatementCacheConcurrentReprepare(t *testing.T) {
+    t.Parallel()
+
+    ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+    defer cancel()
+
+    db := sql.OpenDB(&scriptedDB{})
+    defer db.Close()
+    cache := repository.NewStatementCache(db, map[string]string{
+        "getPortfolio":    "SELECT 1",
+        "updatePortfolio": "SELECT 2",
+    })
+    require.NoError(t, cache.Prepare(ctx))
+    require.Equal(t, uint64(1), cache.Generation())
+
+    generation := cache.Generation()
+    var wg sync.WaitGroup
+    for i := 0; i < 8; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            assert.NoError(t, cache.Reprepare(ctx, generation))
+        }()
+    }
+    wg.Wait()
+    assert.Equal(t, generation+1, cache.Generation(), "one re-prepare per invalidation")
+
+    stop := make(chan struct{})
+    for i := 0; i < 4; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for {
+                select {
+                case <-stop:
+                    return
+                default:
+                }
+
+                stmt := cache.Get("getPortfolio")
+                if !assert.NotNil(t, stmt) {
+                    return
+                }
+                if _, err := stmt.ExecContext(ctx); err != nil {
+                    assert.True(t, repository.IsInvalidStatement(err), "statement closed by the re-prepare: %v", err)
+                }
+            }
+        }()
+    }
+    for i := 0; i < 50; i++ {
+        require.NoError(t, cache.Reprepare(ctx, cache.Generation()))
+    }
+    close(stop)
+    wg.Wait()
+    assert.Equal(t, generation+51, cache.Generation())
+
+    assert.NoError(t, cache.Close())
+    assert.Nil(t, cache.Get("getPortfolio"))
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"               // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestSymbolMigrationValidation verifies migrations are normalized and that
+// a symbol cannot be remapped
+func TestSymbolMigrationValidation(t *testing.T) {
+    t.Parallel()
+
+    m := models.SymbolMigration{FromSymbol: " matic", ToSymbol: "pol ", Ratio: decimal.NewFromInt(1)}
+    require.NoError(t, m.Validate())
+    assert.Equal(t, "MATIC", m.FromSymbol)
+    assert.Equal(t, "POL", m.ToSymbol)
+
+    invalid := []models.SymbolMigration{
+        {FromSymbol: "MATIC", ToSymbol: "matic", Ratio: decimal.NewFromInt(1)},
+        {FromSymbol: "", ToSymbol: "POL", Ratio: decimal.NewFromInt(1)},
+        {FromSymbol: "LEND", ToSymbol: "AAVE", Ratio: decimal.Zero},
+    }
+    for _, migration := range invalid {
+        assert.ErrorIs(t, migration.Validate(), models.ErrInvalidSymbolMigration, "%+v", migration)
+    }
+
+    same := m
+    same.ID = uuid.New()
+    assert.False(t, m.Conflicts(same), "re-running a migration does not conflict")
+    other := m
+    other.ToSymbol = "POLY"
+    assert.True(t, m.Conflicts(other))
+    redenominated := m
+    redenominated.Ratio = decimal.NewFromInt(100)
+    assert.True(t, m.Conflicts(redenominated))
+}
+
+// TestMigrateTransaction verifies transactions keep their value in new units
+func TestMigrateTransaction(t *testing.T) {
+    t.Parallel()
+
+    // 100 LEND became 1 AAVE
+    tx := models.Transaction{Type: "buy", Amount: decimal.NewFromInt(500), Price: decimal.RequireFromString("0.5"), Fee: decimal.NewFromInt(1)}
+    migrated := models.MigrateTransaction(tx, decimal.RequireFromString("0.01"))
+    assert.Equal(t, "5", migrated.Amount.String())
+    assert.Equal(t, "50", migrated.Price.String())
+    assert.True(t, migrated.Amount.Mul(migrated.Price).Equal(tx.Amount.Mul(tx.Price)))
+    assert.Equal(t, "1", migrated.Fee.String())
+    assert.Equal(t, "500", tx.Amount.String(), "the original is not modified")
+}
+
+// TestLedgerAssetMigrated verifies migrations replay onto the holding and
+// its transactions only
+func TestLedgerAssetMigrated(t *testing.T) {
+    t.Parallel()
+
+    portfolioID := uuid.New()
+    lend := models.Asset{ID: uuid.New(), Type: "token", Symbol: "LEND", Amount: decimal.NewFromInt(500)}
+    eth := models.Asset{ID: uuid.New(), Type: "cryptocurrency", Symbol: "ETH", Amount: decimal.NewFromInt(2)}
+    start := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+
+    events := ledgerStream(t, portfolioID, start,
+        models.LedgerPortfolioCreated, models.LedgerPortfolioDetails{Name: "Main"},
+        models.LedgerAssetAdded, lend,
+        models.LedgerAssetAdded, eth,
+        models.LedgerTransactionRecorded, models.Transaction{ID: uuid.New(), AssetID: lend.ID, Type: "buy", Amount: decimal.NewFromInt(500), Price: decimal.RequireFromString("0.5")},
+        models.LedgerTransactionRecorded, models.Transaction{ID: uuid.New(), AssetID: eth.ID, Type: "buy", Amount: decimal.NewFromInt(2), Price: decimal.NewFromInt(400)},
+        models.LedgerAssetMigrated, models.LedgerAssetMigration{MigrationID: uuid.New(), AssetID: lend.ID, FromSymbol: "LEND", ToSymbol: "AAVE", Ratio: decimal.RequireFromString("0.01")},
+    )
+
+    state, err := models.ReplayLedger(events, time.Time{})
+    require.NoError(t, err)
+    assert.Equal(t, "AAVE", state.Portfolio.Assets[0].Symbol)
+    assert.Equal(t, "5", state.Portfolio.Assets[0].Amount.String())
+    assert.Equal(t, "ETH", state.Portfolio.Assets[1].Symbol)
+    assert.Equal(t, "5", state.Transactions[0].Amount.String())
+    assert.Equal(t, "50", state.Transactions[0].Price.String())
+    assert.Equal(t, "2", state.Transactions[1].Amount.String())
+
+    before, err := models.ReplayLedger(events, start.Add(4*time.Hour))
+    require.NoError(t, err)
+    assert.Equal(t, "LEND", before.Portfolio.Assets[0].Symbol, "history as of before the migration keeps the old symbol")
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+    "strings"
+    "testing"
+
+    "github.com/google/uuid"           // v1.3.0
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestNormalizeTags verifies tag normalization and validation
+func TestNormalizeTags(t *testing.T) {
+    t.Parallel()
+
+    tags, err := models.NormalizeTags([]string{" Long-Term", "airdrop", "long-term", "AIRDROP"})
+    require.NoError(t, err)
+    assert.Equal(t, []string{"airdrop", "long-term"}, tags)
+
+    tags, err = models.NormalizeTags(nil)
+    require.NoError(t, err)
+    assert.Empty(t, tags)
+
+    invalid := [][]string{
+        {"  "},
+        {strings.Repeat("x", models.MAX_TAG_LENGTH+1)},
+        {"bad\ttag"},
+    }
+    many := make([]string, models.MAX_ASSET_TAGS+1)
+    for i := range many {
+        many[i] = strings.Repeat("t", i+1)
+    }
+    invalid = append(invalid, many)
+
+    for _, tags := range invalid {
+        _, err := models.NormalizeTags(tags)
+        assert.ErrorIs(t, err, models.ErrInvalidTag)
+    }
+}
+
+// TestFilterAssetsByTag verifies that only assets carrying the tag are kept
+func TestFilterAssetsByTag(t *testing.T) {
+    t.Parallel()
+
+    btc := models.Asset{ID: uuid.New(), Symbol: "BTC"}
+    eth := models.Asset{ID: uuid.New(), Symbol: "ETH"}
+    arb := models.Asset{ID: uuid.New(), Symbol: "ARB"}
+    tags := map[uuid.UUID][]string{
+        btc.ID: {"long-term"},
+        arb.ID: {"airdrop", "long-term"},
+    }
+
+    filtered := models.FilterAssetsByTag([]models.Asset{btc, eth, arb}, tags, " Long-Term ")
+    require.Len(t, filtered, 2)
+    assert.Equal(t, "BTC", filtered[0].Symbol)
+    assert.Equal(t, "ARB", filtered[1].Symbol)
+
+    assert.Empty(t, models.FilterAssetsByTag([]models.Asset{btc, eth, arb}, tags, "experiment"))
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"                // v1.3.0
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestTaxYearBounds verifies tax years starting mid-year contain the days
+// before their start in the previous year's tax year
+func TestTaxYearBounds(t *testing.T) {
+    t.Parallel()
+
+    uk := models.TaxYear{StartMonth: time.April, StartDay: 6}
+
+    start, end := uk.Bounds(time.Date(2024, 4, 5, 23, 0, 0, 0, time.UTC))
+    assert.Equal(t, time.Date(2023, 4, 6, 0, 0, 0, 0, time.UTC), start)
+    assert.Equal(t, time.Date(2024, 4, 6, 0, 0, 0, 0, time.UTC), end)
+
+    start, end = uk.Bounds(time.Date(2024, 4, 6, 0, 0, 0, 0, time.UTC))
+    assert.Equal(t, time.Date(2024, 4, 6, 0, 0, 0, 0, time.UTC), start)
+    assert.Equal(t, time.Date(2025, 4, 6, 0, 0, 0, 0, time.UTC), end)
+
+    start, end = models.CalendarTaxYear.Bounds(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+    assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), start)
+    assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), end)
+}
+
+// TestJurisdictionTaxSettings verifies the defaults of the US, UK and
+// Germany and that unknown countries have none
+func TestJurisdictionTaxSettings(t *testing.T) {
+    t.Parallel()
+
+    userID := uuid.New()
+
+    us, ok := models.JurisdictionTaxSettings(userID, "US")
+    require.True(t, ok)
+    assert.Equal(t, userID, us.UserID)
+    assert.Equal(t, models.CalendarTaxYear, us.TaxYear)
+    assert.Equal(t, 365*24*time.Hour, us.LongTermHoldingPeriod())
+    assert.True(t, us.WashSales)
+
+    gb, ok := models.JurisdictionTaxSettings(userID, "GB")
+    require.True(t, ok)
+    assert.Equal(t, models.TaxYear{StartMonth: time.April, StartDay: 6}, gb.TaxYear)
+    assert.Zero(t, gb.LongTermHoldingPeriod())
+    assert.False(t, gb.WashSales)
+
+    de, ok := models.JurisdictionTaxSettings(userID, "DE")
+    require.True(t, ok)
+    assert.Equal(t, 365, de.LongTermDays)
+    assert.False(t, de.WashSales)
+
+    _, ok = models.JurisdictionTaxSettings(userID, "FR")
+    assert.False(t, ok)
+
+    // A zero long-term period classifies every lot as short-term
+    acquired := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+    assert.Equal(t, models.HoldingShortTerm, models.ClassifyHolding(acquired, acquired.AddDate(3, 0, 0), gb.LongTermHoldingPeriod()))
+    assert.Equal(t, models.HoldingLongTerm, models.ClassifyHolding(acquired, acquired.AddDate(3, 0, 0), de.LongTermHoldingPeriod()))
+}
+
+// TestTaxSettingsValidate verifies the country code, tax year start and
+// long-term days are checked
+func TestTaxSettingsValidate(t *testing.T) {
+    t.Parallel()
+
+    valid := models.TaxSettings{UserID: uuid.New(), Country: "GB", TaxYear: models.TaxYear{StartMonth: time.April, StartDay: 6}}
+    require.NoError(t, valid.Validate())
+
+    invalid := map[string]func(*models.TaxSettings){
+        "lowercase country":  func(s *models.TaxSettings) { s.Country = "gb" },
+        "long country":       func(s *models.TaxSettings) { s.Country = "GBR" },
+        "zero month":         func(s *models.TaxSettings) { s.TaxYear.StartMonth = 0 },
+        "day past 28":        func(s *models.TaxSettings) { s.TaxYear.StartDay = 29 },
+        "zero day":           func(s *models.TaxSettings) { s.TaxYear.StartDay = 0 },
+        "negative long term": func(s *models.TaxSettings) { s.LongTermDays = -1 },
+    }
+    for name, change := range invalid {
+        settings := valid
+        change(&settings)
+        assert.ErrorIs(t, settings.Validate(), models.ErrInvalidTaxSettings, name)
+    }
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"                // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// transferCandidate builds a transfer of symbol in a portfolio at an offset
+// from noon on 1 March 2024
+func transferCandidate(portfolioID uuid.UUID, txType, symbol, amount, fee string, offset time.Duration) models.TransferCandidate {
+    return models.TransferCandidate{
+        Transaction: models.Transaction{
+            ID:          uuid.New(),
+            PortfolioID: portfolioID,
+            AssetID:     uuid.New(),
+            Type:        txType,
+            Amount:      decimal.RequireFromString(amount),
+            Fee:         decimal.RequireFromString(fee),
+            Timestamp:   time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC).Add(offset),
+        },
+        Symbol: symbol,
+    }
+}
+
+// TestMatchTransfers verifies transfer_outs are paired with the closest
+// transfer_in of the same symbol and amount in another portfolio
+func TestMatchTransfers(t *testing.T) {
+    t.Parallel()
+
+    userID := uuid.New()
+    exchange, wallet, other := uuid.New(), uuid.New(), uuid.New()
+
+    out := transferCandidate(exchange, "transfer_out", "ETH", "2", "0.01", 0)
+    late := transferCandidate(wallet, "transfer_in", "ETH", "2", "0", 30*time.Hour)
+    nearby := transferCandidate(other, "transfer_in", "ETH", "1.99", "0", 5*time.Minute)
+    samePortfolio := transferCandidate(exchange, "transfer_in", "ETH", "2", "0", time.Minute)
+    otherSymbol := transferCandidate(wallet, "transfer_in", "BTC", "2", "0", time.Minute)
+    tooLate := transferCandidate(wallet, "transfer_in", "SOL", "5", "0", models.TRANSFER_MATCH_WINDOW+time.Hour)
+    solOut := transferCandidate(exchange, "transfer_out", "SOL", "5", "0", 0)
+
+    at := time.Now().UTC()
+    links := models.MatchTransfers(userID, []models.TransferCandidate{late, tooLate, otherSymbol, nearby, samePortfolio, solOut, out}, at)
+
+    require.Len(t, links, 1)
+    link := links[0]
+    assert.Equal(t, out.Transaction.ID, link.OutTransactionID)
+    assert.Equal(t, nearby.Transaction.ID, link.InTransactionID, "amount less the fee, closest in time")
+    assert.Equal(t, exchange, link.OutPortfolioID)
+    assert.Equal(t, other, link.InPortfolioID)
+    assert.Equal(t, userID, link.UserID)
+    assert.Equal(t, models.TransferMatchedAuto, link.MatchedBy)
+    assert.True(t, link.Amount.Equal(decimal.RequireFromString("1.99")))
+
+    // Once the closest leg is taken, the next transfer_out gets the other
+    second := transferCandidate(exchange, "transfer_out", "ETH", "2", "0", time.Hour)
+    links = models.MatchTransfers(userID, []models.TransferCandidate{second, out, late, nearby}, at)
+    require.Len(t, links, 2)
+    assert.Equal(t, nearby.Transaction.ID, links[0].InTransactionID)
+    assert.Equal(t, late.Transaction.ID, links[1].InTransactionID)
+}
+
+// TestLinkedTransferCarriesCostBasis verifies a linked transfer_in is priced
+// at the cost basis its transfer_out took from its lots
+func TestLinkedTransferCarriesCostBasis(t *testing.T) {
+    t.Parallel()
+
+    portfolioID, assetID := uuid.New(), uuid.New()
+    buy := models.Transaction{
+        ID: uuid.New(), PortfolioID: portfolioID, AssetID: assetID, Type: "buy",
+        Amount: decimal.NewFromInt(4), Price: decimal.NewFromInt(1500),
+        Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+    }
+    out := models.Transaction{
+        ID: uuid.New(), PortfolioID: portfolioID, AssetID: assetID, Type: "transfer_out",
+        Amount: decimal.NewFromInt(2), Fee: decimal.RequireFromString("0.01"),
+        Timestamp: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+    }
+
+    disposal, ok := models.ReplayLots(nil, []models.Transaction{buy, out}, models.FeePolicyCapitalize).Disposal(out.ID)
+    require.True(t, ok)
+    assert.True(t, disposal.CostBasis.Equal(decimal.NewFromInt(3000)))
+
+    price := models.CarriedPrice(disposal, decimal.RequireFromString("1.99"))
+    assert.True(t, price.Mul(decimal.RequireFromString("1.99")).Round(8).Equal(decimal.NewFromInt(3000)), price.String())
+    assert.True(t, models.CarriedPrice(disposal, decimal.Zero).IsZero())
+
+    in := transferCandidate(uuid.New(), "transfer_in", "ETH", "3", "0", 0)
+    err := models.ValidateTransferPair(models.TransferCandidate{Transaction: out, Symbol: "ETH"}, in)
+    assert.ErrorIs(t, err, models.ErrInvalidTransferLink, "more received than sent")
+}
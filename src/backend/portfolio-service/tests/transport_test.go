@@ -0,0 +1,294 @@
+package tests
+
+import (
+    "compress/gzip"
+    "context"
+    "net"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"  // v1.8.0
+    "github.com/stretchr/testify/require" // v1.8.0
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/credentials/insecure"
+    grpcgzip "google.golang.org/grpc/encoding/gzip"
+    "google.golang.org/grpc/health/grpc_health_v1"
+    "google.golang.org/grpc/stats"
+    "google.golang.org/grpc/status"
+    "google.golang.org/grpc/test/bufconn"
+
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/ops"
+    "bookman/portfolio-service/internal/transport"
+)
+
+// holdMethod is the full name of the streaming method served by holdService
+const holdMethod = "/tests.Hold/Hold"
+
+// serveBufconn serves server over an in-memory listener and returns a client
+// connection to it
+func serveBufconn(t *testing.T, server *grpc.Server, opts ...grpc.DialOption) *grpc.ClientConn {
+    t.Helper()
+
+    listener := bufconn.Listen(1 << 20)
+    go func() { _ = server.Serve(listener) }()
+    t.Cleanup(server.Stop)
+
+    opts = append(opts,
+        grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+            return listener.DialContext(ctx)
+        }),
+        grpc.WithTransportCredentials(insecure.NewCredentials()),
+    )
+    conn, err := grpc.Dial("bufnet", opts...)
+    require.NoError(t, err)
+    t.Cleanup(func() { conn.Close() })
+    return conn
+}
+
+// newTransportServer creates a gRPC server with the transport options of cfg
+// serving a started health service
+func newTransportServer(t *testing.T, cfg *config.ServerConfig) *grpc.Server {
+    t.Helper()
+
+    opts, err := transport.ServerOptions(cfg)
+    require.NoError(t, err)
+    health := ops.NewHealth(nil)
+    health.MarkStarted()
+    server := grpc.NewServer(opts...)
+    grpc_health_v1.RegisterHealthServer(server, health)
+    return server
+}
+
+// encodingRecorder records the encoding of the responses a client receives
+type encodingRecorder struct {
+    mu        sync.Mutex
+    encodings []string
+}
+
+func (r *encodingRecorder) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+    return ctx
+}
+
+func (r *encodingRecorder) HandleRPC(_ context.Context, s stats.RPCStats) {
+    if header, ok := s.(*stats.InHeader); ok {
+        r.mu.Lock()
+        r.encodings = append(r.encodings, header.Compression)
+        r.mu.Unlock()
+    }
+}
+
+func (r *encodingRecorder) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+    return ctx
+}
+
+func (r *encodingRecorder) HandleConn(context.Context, stats.ConnStats) {}
+
+func (r *encodingRecorder) get() []string {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    return append([]string(nil), r.encodings...)
+}
+
+// transportConfig returns a server configuration with generous limits for
+// tests to narrow
+func transportConfig() *config.ServerConfig {
+    return &config.ServerConfig{
+        MaxRecvMsgSize: 1 << 20,
+        MaxSendMsgSize: 1 << 20,
+        GzipLevel:      gzip.DefaultCompression,
+    }
+}
+
+// holdService describes a streaming service whose calls report their start
+// on started and hold the stream open until the client cancels it
+func holdService(started chan<- struct{}) *grpc.ServiceDesc {
+    return &grpc.ServiceDesc{
+        ServiceName: "tests.Hold",
+        HandlerType: (*interface{})(nil),
+        Streams: []grpc.StreamDesc{{
+            StreamName:    "Hold",
+            ServerStreams: true,
+            Handler: func(_ interface{}, stream grpc.ServerStream) error {
+                started <- struct{}{}
+                <-stream.Context().Done()
+                return stream.Context().Err()
+            },
+        }},
+    }
+}
+
+// TestTransportMessageSizes verifies requests and responses over the
+// configured message sizes are rejected with ResourceExhausted. It sets the
+// process-wide gzip level, so it does not run in parallel.
+func TestTransportMessageSizes(t *testing.T) {
+    ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+    defer cancel()
+
+    cfg := transportConfig()
+    cfg.MaxRecvMsgSize = 64
+    client := grpc_health_v1.NewHealthClient(serveBufconn(t, newTransportServer(t, cfg)))
+
+    resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+    require.NoError(t, err)
+    assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+
+    _, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: strings.Repeat("a", 100)})
+    assert.Equal(t, codes.ResourceExhausted, status.Code(err), "request over max_recv_msg_size")
+
+    cfg = transportConfig()
+    cfg.MaxSendMsgSize = 1
+    client = grpc_health_v1.NewHealthClient(serveBufconn(t, newTransportServer(t, cfg)))
+
+    _, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+    assert.Equal(t, codes.ResourceExhausted, status.Code(err), "response over max_send_msg_size")
+}
+
+// TestTransportConcurrentStreams verifies a connection cannot open more
+// streams than the configured limit until one of its streams ends. It sets
+// the process-wide gzip level, so it does not run in parallel.
+func TestTransportConcurrentStreams(t *testing.T) {
+    ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+    defer cancel()
+
+    cfg := transportConfig()
+    cfg.MaxConcurrentStreams = 1
+    started := make(chan struct{}, 3)
+    opts, err := transport.ServerOptions(cfg)
+    require.NoError(t, err)
+    server := grpc.NewServer(opts...)
+    server.RegisterService(holdService(started), struct{}{})
+    conn := serveBufconn(t, server)
+    desc := &grpc.StreamDesc{StreamName: "Hold", ServerStreams: true}
+
+    firstCtx, cancelFirst := context.WithCancel(ctx)
+    defer cancelFirst()
+    _, err = conn.NewStream(firstCtx, desc, holdMethod)
+    require.NoError(t, err)
+    select {
+    case <-started:
+    case <-ctx.Done():
+        t.Fatal("first stream did not start")
+    }
+
+    blockedCtx, cancelBlocked := context.WithTimeout(ctx, 100*time.Millisecond)
+    defer cancelBlocked()
+    blocked, err := conn.NewStream(blockedCtx, desc, holdMethod)
+    if err == nil {
+        err = blocked.RecvMsg(&grpc_health_v1.HealthCheckResponse{})
+    }
+    assert.Equal(t, codes.DeadlineExceeded, status.Code(err), "second stream waits for the first")
+    assert.Empty(t, started)
+
+    cancelFirst()
+    _, err = conn.NewStream(ctx, desc, holdMethod)
+    require.NoError(t, err)
+    select {
+    case <-started:
+    case <-ctx.Done():
+        t.Fatal("stream did not start after the first ended")
+    }
+}
+
+// TestTransportReflection verifies the reflection service is registered only
+// when enabled. It sets the process-wide gzip level, so it does not run in
+// parallel.
+func TestTransportReflection(t *testing.T) {
+    reflected := func(enabled bool) bool {
+        cfg := transportConfig()
+        cfg.Reflection = enabled
+        server := newTransportServer(t, cfg)
+        transport.RegisterReflection(server, cfg)
+
+        for name := range server.GetServiceInfo() {
+            if strings.HasPrefix(name, "grpc.reflection.") {
+                return true
+            }
+        }
+        return false
+    }
+
+    assert.True(t, reflected(true))
+    assert.False(t, reflected(false))
+}
+
+// TestTransportCompression verifies responses are gzip compressed only for
+// clients that request it, and invalid levels are rejected. It sets the
+// process-wide gzip level, so it does not run in parallel.
+func TestTransportCompression(t *testing.T) {
+    ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+    defer cancel()
+
+    cfg := transportConfig()
+    cfg.GzipLevel = gzip.BestSpeed
+    recorder := &encodingRecorder{}
+    client := grpc_health_v1.NewHealthClient(serveBufconn(t, newTransportServer(t, cfg), grpc.WithStatsHandler(recorder)))
+
+    resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{}, grpc.UseCompressor(grpcgzip.Name))
+    require.NoError(t, err)
+    assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+
+    resp, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+    require.NoError(t, err)
+    assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+
+    encodings := recorder.get()
+    require.Len(t, encodings, 2)
+    assert.Equal(t, grpcgzip.Name, encodings[0], "requested gzip")
+    assert.NotEqual(t, grpcgzip.Name, encodings[1], "did not request gzip")
+
+    cfg = transportConfig()
+    cfg.GzipLevel = 10
+    _, err = transport.ServerOptions(cfg)
+    require.Error(t, err)
+    assert.Contains(t, err.Error(), "gzip level")
+}
+
+// TestTransportConfig tests defaults and validation of gRPC message sizes,
+// concurrent streams, reflection and compression
+func TestTransportConfig(t *testing.T) {
+    cfg, err := loadTestConfig(t, "")
+    require.NoError(t, err)
+    assert.Equal(t, 16<<20, cfg.Server.MaxRecvMsgSize)
+    assert.Equal(t, 16<<20, cfg.Server.MaxSendMsgSize)
+    assert.Equal(t, uint32(100), cfg.Server.MaxConcurrentStreams)
+    assert.True(t, cfg.Server.Reflection)
+    assert.Equal(t, gzip.DefaultCompression, cfg.Server.GzipLevel)
+
+    cfg, err = loadTestConfig(t, `
+server:
+  max_recv_msg_size: 4194304
+  max_concurrent_streams: 0
+  reflection: false
+  gzip_level: 1
+`)
+    require.NoError(t, err)
+    assert.Equal(t, 4<<20, cfg.Server.MaxRecvMsgSize)
+    assert.Zero(t, cfg.Server.MaxConcurrentStreams)
+    assert.False(t, cfg.Server.Reflection)
+    assert.Equal(t, gzip.BestSpeed, cfg.Server.GzipLevel)
+
+    _, err = loadTestConfig(t, `
+server:
+  max_recv_msg_size: 0
+`)
+    require.Error(t, err)
+    assert.Contains(t, err.Error(), "max_recv_msg_size")
+
+    _, err = loadTestConfig(t, `
+server:
+  max_send_msg_size: -1
+`)
+    require.Error(t, err)
+    assert.Contains(t, err.Error(), "max_send_msg_size")
+
+    _, err = loadTestConfig(t, `
+server:
+  gzip_level: 12
+`)
+    require.Error(t, err)
+    assert.Contains(t, err.Error(), "gzip_level")
+}
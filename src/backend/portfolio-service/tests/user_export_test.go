@@ -0,0 +1,90 @@
+package tests
+
+import (
+    "bytes"
+    "encoding/json"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"               // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/export"
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestWriteUserDataOmitsSecrets verifies user data exports carry the user's
+// portfolios and audit trail but never credentials or signing secrets
+func TestWriteUserDataOmitsSecrets(t *testing.T) {
+    t.Parallel()
+
+    userID := uuid.New()
+    portfolio := models.NewPortfolio(userID, "Retirement", "Long term holdings")
+    portfolio.Assets = []models.Asset{{ID: uuid.New(), Type: "cryptocurrency", Symbol: "BTC", Amount: decimal.NewFromFloat(0.5)}}
+
+    data := &models.UserDataExport{
+        Version:     models.USER_DATA_EXPORT_VERSION,
+        UserID:      userID,
+        GeneratedAt: time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC),
+        Portfolios: []models.PortfolioDataExport{{
+            Portfolio: portfolio,
+            AssetTags: map[uuid.UUID][]string{portfolio.Assets[0].ID: {"cold-storage"}},
+            ExchangeAccounts: []models.ExchangeAccount{{
+                ID:          uuid.New(),
+                PortfolioID: portfolio.ID,
+                Exchange:    "binance",
+                Credentials: []byte("sealed-api-key"),
+            }},
+        }},
+        Webhooks: []models.WebhookEndpoint{{
+            ID:     uuid.New(),
+            UserID: userID,
+            URL:    "https://hooks.example.com/portfolio",
+            Secret: "whsec_signing",
+        }},
+        AuditTrail: []models.AuditEntry{{
+            ID:        7,
+            TableName: "portfolios",
+            Operation: "INSERT",
+            NewData:   json.RawMessage(`{"name":"Retirement"}`),
+            ChangedBy: userID,
+        }},
+    }
+
+    var buf bytes.Buffer
+    require.NoError(t, export.WriteUserData(&buf, data))
+    assert.NotContains(t, buf.String(), "sealed-api-key")
+    assert.NotContains(t, buf.String(), "c2VhbGVkLWFwaS1rZXk")
+    assert.NotContains(t, buf.String(), "whsec_signing")
+
+    var decoded struct {
+        Version    int       `json:"version"`
+        UserID     uuid.UUID `json:"user_id"`
+        Portfolios []struct {
+            Portfolio struct {
+                Name   string `json:"name"`
+                Assets []struct {
+                    Symbol string `json:"symbol"`
+                } `json:"assets"`
+            } `json:"portfolio"`
+            AssetTags map[string][]string `json:"asset_tags"`
+        } `json:"portfolios"`
+        AuditTrail []struct {
+            Operation string          `json:"operation"`
+            NewData   json.RawMessage `json:"new_data"`
+        } `json:"audit_trail"`
+    }
+    require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+    assert.Equal(t, models.USER_DATA_EXPORT_VERSION, decoded.Version)
+    assert.Equal(t, userID, decoded.UserID)
+    require.Len(t, decoded.Portfolios, 1)
+    assert.Equal(t, "Retirement", decoded.Portfolios[0].Portfolio.Name)
+    assert.Equal(t, "BTC", decoded.Portfolios[0].Portfolio.Assets[0].Symbol)
+    assert.Equal(t, []string{"cold-storage"}, decoded.Portfolios[0].AssetTags[portfolio.Assets[0].ID.String()])
+    require.Len(t, decoded.AuditTrail, 1)
+    assert.JSONEq(t, `{"name":"Retirement"}`, string(decoded.AuditTrail[0].NewData))
+
+    assert.Error(t, export.WriteUserData(&buf, nil))
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"  // v1.8.0
+    "github.com/stretchr/testify/require" // v1.8.0
+    "go.uber.org/zap"                     // v1.24.0
+    "go.uber.org/zap/zaptest/observer"    // v1.24.0
+
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/metrics"
+    "bookman/portfolio-service/internal/repository"
+    "bookman/portfolio-service/internal/verifier"
+)
+
+// fakeInvariantRepository reports seeded violation counts and check failures
+// per invariant
+type fakeInvariantRepository struct {
+    mutex  sync.Mutex
+    counts map[string]int64
+    errs   map[string]error
+}
+
+func (r *fakeInvariantRepository) CountInvariantViolations(ctx context.Context, invariant string) (int64, error) {
+    r.mutex.Lock()
+    defer r.mutex.Unlock()
+    if err := ctx.Err(); err != nil {
+        return 0, err
+    }
+    if err := r.errs[invariant]; err != nil {
+        return 0, err
+    }
+    return r.counts[invariant], nil
+}
+
+func (r *fakeInvariantRepository) seed(counts map[string]int64, errs map[string]error) {
+    r.mutex.Lock()
+    defer r.mutex.Unlock()
+    r.counts = counts
+    r.errs = errs
+}
+
+// gatheredValue returns the value of the gauge or counter metric of family
+// name labelled invariant, or -1 when there is none
+func gatheredValue(t *testing.T, name, invariant string) float64 {
+    t.Helper()
+
+    families, err := metrics.Registry.Gather()
+    require.NoError(t, err)
+    for _, family := range families {
+        if family.GetName() != name {
+            continue
+        }
+        for _, metric := range family.Metric {
+            for _, label := range metric.Label {
+                if label.GetName() != "invariant" || label.GetValue() != invariant {
+                    continue
+                }
+                if metric.Gauge != nil {
+                    return metric.Gauge.GetValue()
+                }
+                return metric.Counter.GetValue()
+            }
+        }
+    }
+    return -1
+}
+
+// TestLedgerVerifier verifies seeded divergences are reported through the
+// violation gauges and logs, and that a failed check keeps its last count
+func TestLedgerVerifier(t *testing.T) {
+    t.Parallel()
+
+    const (
+        violationsMetric  = "portfolio_ledger_invariant_violations"
+        checkErrorsMetric = "portfolio_ledger_invariant_check_errors_total"
+    )
+
+    core, logs := observer.New(zap.InfoLevel)
+    repo := &fakeInvariantRepository{}
+    v, err := verifier.NewVerifier(repo, config.VerifierConfig{
+        Enabled:      true,
+        Interval:     time.Minute,
+        CheckTimeout: time.Second,
+    }, zap.New(core))
+    require.NoError(t, err)
+
+    // A ledger group whose entries do not balance and a negative balance
+    repo.seed(map[string]int64{
+        repository.InvariantNegativeBalance:  1,
+        repository.InvariantUnbalancedLedger: 2,
+    }, nil)
+    require.NoError(t, v.RunOnce(context.Background()))
+
+    assert.Equal(t, float64(1), gatheredValue(t, violationsMetric, repository.InvariantNegativeBalance))
+    assert.Equal(t, float64(2), gatheredValue(t, violationsMetric, repository.InvariantUnbalancedLedger))
+    assert.Equal(t, float64(0), gatheredValue(t, violationsMetric, repository.InvariantOrphanedAsset))
+
+    reported := logs.FilterMessage("Ledger invariant violated").All()
+    require.Len(t, reported, 2)
+    violated := make(map[string]int64)
+    for _, entry := range reported {
+        fields := entry.ContextMap()
+        violated[fields["invariant"].(string)] = fields["violations"].(int64)
+    }
+    assert.Equal(t, map[string]int64{
+        repository.InvariantNegativeBalance:  1,
+        repository.InvariantUnbalancedLedger: 2,
+    }, violated)
+
+    // The divergence is repaired while the balance check fails to run
+    checkErrors := gatheredValue(t, checkErrorsMetric, repository.InvariantNegativeBalance)
+    repo.seed(nil, map[string]error{
+        repository.InvariantNegativeBalance: errors.New("canceling statement due to statement timeout"),
+    })
+    err = v.RunOnce(context.Background())
+    assert.ErrorContains(t, err, "1 invariant checks failed")
+
+    assert.Equal(t, float64(1), gatheredValue(t, violationsMetric, repository.InvariantNegativeBalance), "a failed check keeps its last count")
+    assert.Equal(t, float64(0), gatheredValue(t, violationsMetric, repository.InvariantUnbalancedLedger))
+    assert.Equal(t, max(checkErrors, 0)+1, gatheredValue(t, checkErrorsMetric, repository.InvariantNegativeBalance))
+    assert.Len(t, logs.FilterMessage("Ledger invariant violated").All(), 2)
+    assert.Len(t, logs.FilterMessage("Invariant check failed").All(), 1)
+
+    // Cancellation ends the pass without counting check failures
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    assert.ErrorIs(t, v.RunOnce(ctx), context.Canceled)
+    assert.Len(t, logs.FilterMessage("Invariant check failed").All(), 1)
+}
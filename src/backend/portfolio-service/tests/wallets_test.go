@@ -0,0 +1,465 @@
+package tests
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/chains"
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/exchanges"
+    "bookman/portfolio-service/internal/models"
+)
+
+const (
+    walletAddress = "0x00000000000000000000000000000000000000aa"
+    walletPeer    = "0x00000000000000000000000000000000000000bb"
+    walletUSDC    = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+    walletSpoof   = "0x00000000000000000000000000000000000000cc"
+
+    // BIP84 test vector account key with its first receive and change
+    // addresses
+    walletZpub    = "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs"
+    walletReceive = "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"
+    walletChange  = "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el"
+    walletPayee   = "bc1qpayee"
+
+    // Solana wallet, one of its token accounts and a counterparty
+    walletSolana       = "Bswb3UyeD1pUTaGiE6WvqwFpJZsQSEY1xhJePCDTHdvp"
+    walletTokenAccount = "D2ZcUbtpG5sKq7XLeB4YnpNnTGSptKCxTddoNeydzJQq"
+    walletSolanaPeer   = "EBBduiozK9vBCemy4FcAjhVkby2FLPstxZxxN7jpgxtr"
+    walletSolanaUSDC   = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+)
+
+func TestWalletPriceAt(t *testing.T) {
+    day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+    closes := []models.PricePoint{
+        {Symbol: "ETH", Timestamp: day, Close: decimal.NewFromInt(3000)},
+        {Symbol: "ETH", Timestamp: day.AddDate(0, 0, 1), Close: decimal.NewFromInt(3100)},
+    }
+
+    price, ok := models.PriceAt(closes, day.Add(12*time.Hour))
+    require.True(t, ok)
+    assert.True(t, price.Equal(decimal.NewFromInt(3000)))
+
+    price, ok = models.PriceAt(closes, day.AddDate(0, 0, 5))
+    require.True(t, ok)
+    assert.True(t, price.Equal(decimal.NewFromInt(3100)))
+
+    // Transfers before the first close take the earliest known price
+    price, ok = models.PriceAt(closes, day.Add(-time.Hour))
+    require.True(t, ok)
+    assert.True(t, price.Equal(decimal.NewFromInt(3000)))
+
+    _, ok = models.PriceAt(nil, day)
+    assert.False(t, ok)
+}
+
+func TestWalletPortfolioName(t *testing.T) {
+    assert.Equal(t, "Ethereum wallet 0x000000...0000aa", models.WalletPortfolioName("ethereum", walletAddress))
+    assert.Equal(t, "Wallet 0xabc", models.WalletPortfolioName("", "0xabc"))
+}
+
+func TestExchangeFeeEntryMapping(t *testing.T) {
+    mapper := models.NewExchangeMapper(nil, exchangePrices(map[string]string{"ETH": "2000"}))
+    legs, err := mapper.MapEntry(models.ExchangeEntry{
+        ID:         "0xhash/gas",
+        Asset:      "ETH",
+        Type:       "fee",
+        Amount:     decimal.RequireFromString("0.001"),
+        ExecutedAt: time.Now(),
+    })
+    require.NoError(t, err)
+    require.Len(t, legs, 1)
+    assert.Equal(t, "fee", legs[0].Type)
+    assert.True(t, legs[0].Amount.Equal(decimal.RequireFromString("0.001")))
+    assert.True(t, legs[0].Price.Equal(decimal.NewFromInt(2000)))
+}
+
+// ethereumBackend serves a node at /rpc and an indexer at /api for a wallet
+// at block 100
+func ethereumBackend(t *testing.T) *httptest.Server {
+    t.Helper()
+    lists := map[string][]map[string]string{
+        "txlist": {
+            {"blockNumber": "10", "timeStamp": "1700000000", "hash": "0x01", "from": walletPeer, "to": walletAddress,
+                "value": "2000000000000000000", "gasUsed": "21000", "gasPrice": "1000000000", "isError": "0"},
+            {"blockNumber": "20", "timeStamp": "1700001000", "hash": "0x02", "from": walletAddress, "to": walletPeer,
+                "value": "500000000000000000", "gasUsed": "21000", "gasPrice": "1000000000", "isError": "0"},
+            {"blockNumber": "30", "timeStamp": "1700002000", "hash": "0x03", "from": walletAddress, "to": walletUSDC,
+                "value": "0", "gasUsed": "50000", "gasPrice": "1000000000", "isError": "0"},
+        },
+        "tokentx": {
+            {"blockNumber": "40", "timeStamp": "1700003000", "hash": "0x04", "from": walletPeer, "to": walletAddress,
+                "value": "2500000", "contractAddress": walletUSDC},
+            {"blockNumber": "41", "timeStamp": "1700003100", "hash": "0x05", "from": walletPeer, "to": walletAddress,
+                "value": "1000000", "contractAddress": walletSpoof},
+        },
+    }
+
+    mux := http.NewServeMux()
+    mux.HandleFunc("/rpc", func(w http.ResponseWriter, r *http.Request) {
+        var calls []struct {
+            ID     int    `json:"id"`
+            Method string `json:"method"`
+        }
+        require.NoError(t, json.NewDecoder(r.Body).Decode(&calls))
+
+        results := make([]map[string]interface{}, len(calls))
+        for i, call := range calls {
+            result := map[string]string{
+                "eth_blockNumber": "0x64",
+                "eth_getBalance":  "0x14d1120d7b160000",
+                "eth_call":        "0x00000000000000000000000000000000000000000000000000000000002625a0",
+            }[call.Method]
+            results[i] = map[string]interface{}{"jsonrpc": "2.0", "id": call.ID, "result": result}
+        }
+        require.NoError(t, json.NewEncoder(w).Encode(results))
+    })
+    mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+        assert.Equal(t, "88", r.URL.Query().Get("endblock"))
+        rows, ok := lists[r.URL.Query().Get("action")]
+        if !ok {
+            rows = []map[string]string{}
+        }
+        require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{"status": "1", "message": "OK", "result": rows}))
+    })
+    return httptest.NewServer(mux)
+}
+
+func TestEVMAdapter(t *testing.T) {
+    server := ethereumBackend(t)
+    defer server.Close()
+
+    evmConfig := func(chainID int64, native string) config.EVMConfig {
+        return config.EVMConfig{
+            RPCURL:            server.URL + "/rpc",
+            IndexerURL:        server.URL + "/api",
+            ChainID:           chainID,
+            Confirmations:     12,
+            NativeSymbol:      native,
+            RequestsPerSecond: 100,
+            Tokens:            []config.EVMToken{{Contract: walletUSDC, Symbol: "USDC", Decimals: 6}},
+        }
+    }
+    registry := chains.NewRegistry(server.Client(), exchanges.RetryPolicy{MaxAttempts: 1})
+    require.NoError(t, registry.Register(chains.EVM("ethereum", evmConfig(1, "ETH"))))
+    require.NoError(t, registry.Register(chains.EVM("polygon", evmConfig(137, "POL"))))
+    assert.Equal(t, []string{"ethereum", "polygon"}, registry.Names())
+
+    conn, err := registry.Adapter("ethereum")
+    require.NoError(t, err)
+
+    _, err = registry.Adapter("dogecoin")
+    assert.ErrorIs(t, err, chains.ErrUnsupportedChain)
+
+    _, err = conn.NormalizeAddress("0x1234")
+    assert.ErrorIs(t, err, chains.ErrInvalidAddress)
+    address, err := conn.NormalizeAddress(" 0x00000000000000000000000000000000000000AA ")
+    require.NoError(t, err)
+    assert.Equal(t, walletAddress, address)
+
+    ctx := context.Background()
+    balances, err := conn.FetchBalances(ctx, address)
+    require.NoError(t, err)
+    require.Len(t, balances, 2)
+    assert.Equal(t, "ETH", balances[0].Asset)
+    assert.True(t, balances[0].Total().Equal(decimal.RequireFromString("1.5")))
+    assert.Equal(t, "USDC", balances[1].Asset)
+    assert.True(t, balances[1].Total().Equal(decimal.RequireFromString("2.5")))
+
+    activity, cursor, err := conn.FetchTransfers(ctx, address, "")
+    require.NoError(t, err)
+    require.NotEmpty(t, cursor)
+
+    types := make(map[string]models.ExchangeEntry)
+    for _, e := range activity.Entries {
+        types[e.ID] = e
+    }
+    require.Len(t, types, 4, "untracked tokens are ignored")
+    assert.Equal(t, "transfer_in", types["0x01"].Type)
+    assert.Equal(t, "transfer_out", types["0x02"].Type)
+    assert.True(t, types["0x02"].Fee.Equal(decimal.RequireFromString("0.000021")), "gas is the withdrawal fee")
+    assert.Equal(t, "fee", types["0x03/gas"].Type)
+    assert.True(t, types["0x03/gas"].Amount.Equal(decimal.RequireFromString("0.00005")))
+    assert.Equal(t, "USDC", types["0x04/token/"+walletUSDC+"/0"].Asset)
+
+    // Nothing new is confirmed after the cursor
+    activity, next, err := conn.FetchTransfers(ctx, address, cursor)
+    require.NoError(t, err)
+    assert.Empty(t, activity.Entries)
+    assert.Equal(t, cursor, next)
+
+    _, _, err = conn.FetchTransfers(ctx, address, "not-a-cursor")
+    assert.ErrorIs(t, err, chains.ErrInvalidCursor)
+
+    // Sidechains report their own native asset
+    polygon, err := registry.Adapter("polygon")
+    require.NoError(t, err)
+    balances, err = polygon.FetchBalances(ctx, address)
+    require.NoError(t, err)
+    require.Len(t, balances, 2)
+    assert.Equal(t, "POL", balances[0].Asset)
+    activity, _, err = polygon.FetchTransfers(ctx, address, "")
+    require.NoError(t, err)
+    for _, e := range activity.Entries {
+        if e.ID == "0x03/gas" {
+            assert.Equal(t, "POL", e.Asset)
+        }
+    }
+}
+
+// esploraTx renders an Esplora transaction confirmed at height
+func esploraTx(txid string, height int, fee int64, inputs, outputs map[string]int64) map[string]interface{} {
+    vin := []map[string]interface{}{}
+    for address, value := range inputs {
+        vin = append(vin, map[string]interface{}{"prevout": map[string]interface{}{"scriptpubkey_address": address, "value": value}})
+    }
+    vout := []map[string]interface{}{}
+    for address, value := range outputs {
+        vout = append(vout, map[string]interface{}{"scriptpubkey_address": address, "value": value})
+    }
+    return map[string]interface{}{
+        "txid": txid,
+        "vin":  vin,
+        "vout": vout,
+        "fee":  fee,
+        "status": map[string]interface{}{
+            "confirmed":    true,
+            "block_height": height,
+            "block_time":   1700000000 + height*600,
+        },
+    }
+}
+
+func TestBitcoinAdapter(t *testing.T) {
+    funding := esploraTx("aa", 100, 500, map[string]int64{walletPayee: 100500}, map[string]int64{walletReceive: 100000})
+    payment := esploraTx("bb", 102, 1000, map[string]int64{walletReceive: 100000}, map[string]int64{walletPayee: 30000, walletChange: 69000})
+    unconfirmed := esploraTx("cc", 108, 1000, map[string]int64{walletChange: 69000}, map[string]int64{walletPayee: 68000})
+
+    mux := http.NewServeMux()
+    mux.HandleFunc("/blocks/tip/height", func(w http.ResponseWriter, r *http.Request) {
+        _, _ = w.Write([]byte("110"))
+    })
+    mux.HandleFunc("/address/", func(w http.ResponseWriter, r *http.Request) {
+        parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/address/"), "/")
+        var body interface{}
+        switch {
+        case len(parts) > 1 && parts[0] == walletReceive:
+            body = []interface{}{payment, funding}
+        case len(parts) > 1 && parts[0] == walletChange:
+            body = []interface{}{unconfirmed, payment}
+        case len(parts) > 1:
+            body = []interface{}{}
+        case parts[0] == walletReceive:
+            body = map[string]interface{}{"chain_stats": map[string]int64{"funded_txo_sum": 100000, "spent_txo_sum": 100000, "tx_count": 2}}
+        case parts[0] == walletChange:
+            body = map[string]interface{}{"chain_stats": map[string]int64{"funded_txo_sum": 69000, "tx_count": 1}}
+        default:
+            body = map[string]interface{}{"chain_stats": map[string]int64{}}
+        }
+        require.NoError(t, json.NewEncoder(w).Encode(body))
+    })
+    server := httptest.NewServer(mux)
+    defer server.Close()
+
+    registry := chains.NewRegistry(server.Client(), exchanges.RetryPolicy{MaxAttempts: 1})
+    require.NoError(t, registry.Register(chains.Bitcoin(config.BitcoinConfig{
+        EsploraURL:        server.URL + "/",
+        Confirmations:     6,
+        GapLimit:          3,
+        RequestsPerSecond: 100,
+    })))
+    conn, err := registry.Adapter("bitcoin")
+    require.NoError(t, err)
+
+    for _, key := range []string{
+        "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi",
+        walletReceive,
+        "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGut",
+    } {
+        _, err := conn.NormalizeAddress(key)
+        assert.ErrorIs(t, err, chains.ErrInvalidAddress, key)
+    }
+    xpub, err := conn.NormalizeAddress(" " + walletZpub + " ")
+    require.NoError(t, err)
+    assert.Equal(t, walletZpub, xpub)
+
+    ctx := context.Background()
+    balances, err := conn.FetchBalances(ctx, xpub)
+    require.NoError(t, err)
+    require.Len(t, balances, 1)
+    assert.Equal(t, "BTC", balances[0].Asset)
+    assert.True(t, balances[0].Total().Equal(decimal.RequireFromString("0.00069")))
+
+    activity, cursor, err := conn.FetchTransfers(ctx, xpub, "")
+    require.NoError(t, err)
+    require.Len(t, activity.Entries, 2, "transactions with fewer confirmations are not read")
+
+    received := activity.Entries[0]
+    assert.Equal(t, "aa", received.ID)
+    assert.Equal(t, "transfer_in", received.Type)
+    assert.True(t, received.Amount.Equal(decimal.RequireFromString("0.001")))
+    assert.Equal(t, time.Unix(1700060000, 0).UTC(), received.ExecutedAt)
+
+    // Change returned to the wallet is netted out of the payment
+    sent := activity.Entries[1]
+    assert.Equal(t, "bb", sent.ID)
+    assert.Equal(t, "transfer_out", sent.Type)
+    assert.True(t, sent.Amount.Equal(decimal.RequireFromString("0.0003")))
+    assert.True(t, sent.Fee.Equal(decimal.RequireFromString("0.00001")))
+
+    activity, next, err := conn.FetchTransfers(ctx, xpub, cursor)
+    require.NoError(t, err)
+    assert.Empty(t, activity.Entries)
+    assert.Equal(t, cursor, next)
+}
+
+// solanaTx renders a jsonParsed transaction of the wallet at slot with SOL
+// balances of its accounts and the wallet's USDC balances in base units
+func solanaTx(slot int, accounts []string, pre, post []uint64, preUSDC, postUSDC string) map[string]interface{} {
+    keys := []map[string]string{}
+    for _, account := range accounts {
+        keys = append(keys, map[string]string{"pubkey": account})
+    }
+    tokenBalances := func(amount string) []map[string]interface{} {
+        if amount == "" {
+            return []map[string]interface{}{}
+        }
+        return []map[string]interface{}{{
+            "mint":          walletSolanaUSDC,
+            "owner":         walletSolana,
+            "uiTokenAmount": map[string]interface{}{"amount": amount, "decimals": 6},
+        }}
+    }
+    return map[string]interface{}{
+        "slot":      slot,
+        "blockTime": 1700000000 + slot,
+        "meta": map[string]interface{}{
+            "fee":               5000,
+            "preBalances":       pre,
+            "postBalances":      post,
+            "preTokenBalances":  tokenBalances(preUSDC),
+            "postTokenBalances": tokenBalances(postUSDC),
+        },
+        "transaction": map[string]interface{}{
+            "message": map[string]interface{}{"accountKeys": keys},
+        },
+    }
+}
+
+func TestSolanaAdapter(t *testing.T) {
+    transactions := map[string]interface{}{
+        // The peer pays the fee of a transfer to the wallet
+        "sig1": solanaTx(200, []string{walletSolanaPeer, walletSolana}, []uint64{10000000000, 0}, []uint64{7999995000, 2000000000}, "", ""),
+        // The wallet pays the fee of a transfer to the peer
+        "sig2": solanaTx(250, []string{walletSolana, walletSolanaPeer}, []uint64{2000000000, 0}, []uint64{1499995000, 500000000}, "", ""),
+        // USDC arrives in the wallet's token account
+        "sig4": solanaTx(260, []string{walletSolanaPeer, walletTokenAccount}, []uint64{5000000, 2039280}, []uint64{4995000, 2039280}, "", "2500000"),
+    }
+    signatures := map[string][]map[string]interface{}{
+        walletSolana:       {{"signature": "sig3", "slot": 301}, {"signature": "sig2", "slot": 250}, {"signature": "sig1", "slot": 200}},
+        walletTokenAccount: {{"signature": "sig4", "slot": 260}, {"signature": "sig2", "slot": 250}},
+    }
+    tokenAccounts := map[string]interface{}{"value": []map[string]interface{}{{
+        "pubkey": walletTokenAccount,
+        "account": map[string]interface{}{"data": map[string]interface{}{"parsed": map[string]interface{}{"info": map[string]interface{}{
+            "mint":        walletSolanaUSDC,
+            "tokenAmount": map[string]interface{}{"amount": "2500000", "decimals": 6},
+        }}}},
+    }}}
+
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var calls []struct {
+            ID     int             `json:"id"`
+            Method string          `json:"method"`
+            Params json.RawMessage `json:"params"`
+        }
+        require.NoError(t, json.NewDecoder(r.Body).Decode(&calls))
+
+        results := make([]map[string]interface{}, len(calls))
+        for i, call := range calls {
+            var params []interface{}
+            require.NoError(t, json.Unmarshal(call.Params, &params))
+            var result interface{}
+            switch call.Method {
+            case "getSlot":
+                result = 300
+            case "getBalance":
+                result = map[string]interface{}{"value": 1499995000}
+            case "getTokenAccountsByOwner":
+                result = tokenAccounts
+            case "getSignaturesForAddress":
+                result = signatures[params[0].(string)]
+            case "getTransaction":
+                result = transactions[params[0].(string)]
+            }
+            results[i] = map[string]interface{}{"jsonrpc": "2.0", "id": call.ID, "result": result}
+        }
+        require.NoError(t, json.NewEncoder(w).Encode(results))
+    }))
+    defer server.Close()
+
+    registry := chains.NewRegistry(server.Client(), exchanges.RetryPolicy{MaxAttempts: 1})
+    require.NoError(t, registry.Register(chains.Solana(config.SolanaConfig{
+        RPCURL:            server.URL,
+        RequestsPerSecond: 100,
+        Tokens:            []config.SolanaToken{{Mint: walletSolanaUSDC, Symbol: "USDC"}},
+    })))
+    conn, err := registry.Adapter("solana")
+    require.NoError(t, err)
+
+    for _, address := range []string{"", walletAddress, "0OIl" + walletSolana[4:], walletSolana[:20]} {
+        _, err := conn.NormalizeAddress(address)
+        assert.ErrorIs(t, err, chains.ErrInvalidAddress, address)
+    }
+    address, err := conn.NormalizeAddress(" " + walletSolana + " ")
+    require.NoError(t, err)
+    assert.Equal(t, walletSolana, address)
+
+    ctx := context.Background()
+    balances, err := conn.FetchBalances(ctx, address)
+    require.NoError(t, err)
+    require.Len(t, balances, 2)
+    assert.Equal(t, "SOL", balances[0].Asset)
+    assert.True(t, balances[0].Total().Equal(decimal.RequireFromString("1.499995")))
+    assert.Equal(t, "USDC", balances[1].Asset)
+    assert.True(t, balances[1].Total().Equal(decimal.RequireFromString("2.5")))
+
+    activity, cursor, err := conn.FetchTransfers(ctx, address, "")
+    require.NoError(t, err)
+    require.Len(t, activity.Entries, 3, "transactions after the finalized slot are not read")
+
+    received := activity.Entries[0]
+    assert.Equal(t, "sig1", received.ID)
+    assert.Equal(t, "transfer_in", received.Type)
+    assert.True(t, received.Amount.Equal(decimal.NewFromInt(2)))
+    assert.Equal(t, time.Unix(1700000200, 0).UTC(), received.ExecutedAt)
+
+    // The fee is netted out of the amount sent
+    sent := activity.Entries[1]
+    assert.Equal(t, "sig2", sent.ID)
+    assert.Equal(t, "transfer_out", sent.Type)
+    assert.True(t, sent.Amount.Equal(decimal.RequireFromString("0.5")))
+    assert.True(t, sent.Fee.Equal(decimal.RequireFromString("0.000005")))
+
+    token := activity.Entries[2]
+    assert.Equal(t, "sig4/"+walletSolanaUSDC, token.ID)
+    assert.Equal(t, "USDC", token.Asset)
+    assert.Equal(t, "transfer_in", token.Type)
+    assert.True(t, token.Amount.Equal(decimal.RequireFromString("2.5")))
+
+    // Nothing new is finalized after the cursor
+    activity, next, err := conn.FetchTransfers(ctx, address, cursor)
+    require.NoError(t, err)
+    assert.Empty(t, activity.Entries)
+    assert.Equal(t, cursor, next)
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/shopspring/decimal"        // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+    "go.uber.org/zap"                      // v1.24.0
+
+    "bookman/portfolio-service/internal/repository"
+    "bookman/portfolio-service/internal/warmup"
+)
+
+// fakeHeldSymbols lists a fixed set of held symbols
+type fakeHeldSymbols []string
+
+func (f fakeHeldSymbols) ListHeldSymbols(ctx context.Context) ([]string, error) {
+    return f, nil
+}
+
+// fakeBatchPrices records the batches of symbols it is asked to price
+type fakeBatchPrices struct {
+    batches [][]string
+}
+
+func (f *fakeBatchPrices) GetCurrentPrices(ctx context.Context, symbols []string) (map[string]decimal.Decimal, error) {
+    f.batches = append(f.batches, append([]string(nil), symbols...))
+    prices := make(map[string]decimal.Decimal, len(symbols))
+    for _, symbol := range symbols {
+        if symbol != "UNPRICED" {
+            prices[symbol] = decimal.NewFromInt(1)
+        }
+    }
+    return prices, nil
+}
+
+// TestWarmupWait verifies dependencies are retried until available and the
+// last error is returned once the warm-up times out
+func TestWarmupWait(t *testing.T) {
+    t.Parallel()
+
+    attempts := 0
+    err := warmup.Wait(context.Background(), "database", time.Millisecond, zap.NewNop(), func(ctx context.Context) error {
+        attempts++
+        if attempts < 3 {
+            return errors.New("connection refused")
+        }
+        return nil
+    })
+    require.NoError(t, err)
+    assert.Equal(t, 3, attempts)
+
+    ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+    defer cancel()
+    err = warmup.Wait(ctx, "database", time.Millisecond, zap.NewNop(), func(ctx context.Context) error {
+        return errors.New("connection refused")
+    })
+    require.Error(t, err)
+    assert.Contains(t, err.Error(), "connection refused")
+}
+
+// TestWarmupPrimePrices verifies held symbols are priced in batches and
+// symbols without a price are not counted as primed
+func TestWarmupPrimePrices(t *testing.T) {
+    t.Parallel()
+
+    prices := &fakeBatchPrices{}
+    primed, err := warmup.PrimePrices(context.Background(), fakeHeldSymbols{"BTC", "ETH", "SOL", "UNPRICED", "ADA"}, prices, 2)
+    require.NoError(t, err)
+    assert.Equal(t, 4, primed)
+    assert.Equal(t, [][]string{{"BTC", "ETH"}, {"SOL", "UNPRICED"}, {"ADA"}}, prices.batches)
+}
+
+// TestSchemaVersion verifies startup is refused against a schema older than
+// the one the build depends on
+func TestSchemaVersion(t *testing.T) {
+    t.Parallel()
+
+    assert.NoError(t, repository.ValidateSchemaVersion(repository.SchemaVersion))
+    assert.NoError(t, repository.ValidateSchemaVersion(repository.SchemaVersion+1))
+
+    err := repository.ValidateSchemaVersion(repository.SchemaVersion - 1)
+    assert.ErrorIs(t, err, repository.ErrSchemaOutdated)
+
+    err = repository.ValidateSchemaVersion(0)
+    assert.ErrorIs(t, err, repository.ErrSchemaOutdated)
+    assert.Contains(t, err.Error(), "migration 0 applied")
+}
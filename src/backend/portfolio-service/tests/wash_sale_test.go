@@ -0,0 +1,47 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"                // v1.3.0
+    "github.com/shopspring/decimal"         // v1.3.1
+    "github.com/stretchr/testify/assert"    // v1.8.0
+    "github.com/stretchr/testify/require"   // v1.8.0
+
+    "bookman/portfolio-service/internal/models"
+)
+
+// TestReplayLotsWithWashSales verifies purchases within the window before and
+// after a loss sell take its disallowed loss into their cost, up to the
+// quantity sold
+func TestReplayLotsWithWashSales(t *testing.T) {
+    t.Parallel()
+
+    asset := models.Asset{ID: uuid.New(), Symbol: "ETH"}
+    buy := lotTransaction(asset.ID, 1, "buy", "2", "100")
+    before := lotTransaction(asset.ID, 5, "buy", "1", "50")
+    sell := lotTransaction(asset.ID, 10, "sell", "2", "60")
+    after := lotTransaction(asset.ID, 20, "buy", "2", "70")
+    txs := []models.Transaction{buy, before, sell, after}
+    rule := &models.WashSaleRule{Window: 30 * 24 * time.Hour}
+
+    replay := models.ReplayLotsWithWashSales(nil, txs, models.FeePolicyCapitalize, rule)
+    disposal, ok := replay.Disposal(sell.ID)
+    require.True(t, ok)
+    assert.True(t, disposal.Disallowed.Equal(decimal.NewFromInt(80)), disposal.Disallowed.String())
+    assert.Equal(t, []uuid.UUID{before.ID, after.ID}, disposal.Replacements)
+    assert.True(t, replay.RealizedPnL.IsZero(), replay.RealizedPnL.String())
+
+    require.Len(t, replay.Lots, 2)
+    assert.True(t, replay.Lots[0].CostBasis.Equal(decimal.NewFromInt(90)), replay.Lots[0].CostBasis.String())
+    assert.True(t, replay.Lots[1].WashSaleAdjustment.Equal(decimal.NewFromInt(40)), "only the quantity sold is replaced")
+    assert.True(t, replay.Lots[1].CostBasis.Equal(decimal.NewFromInt(180)), replay.Lots[1].CostBasis.String())
+
+    sales := models.WashSales(asset, replay.Disposals)
+    require.Len(t, sales, 1)
+    assert.True(t, sales[0].Loss.Equal(decimal.NewFromInt(80)), sales[0].Loss.String())
+
+    plain := models.ReplayLots(nil, txs, models.FeePolicyCapitalize)
+    assert.True(t, plain.RealizedPnL.Equal(decimal.NewFromInt(-80)), "no rule, no wash sale")
+}
@@ -0,0 +1,173 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "crypto/tls"
+    "encoding/binary"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"  // v1.8.0
+    "github.com/stretchr/testify/require" // v1.8.0
+    "go.uber.org/zap"                     // v1.24.0
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/credentials"
+    "google.golang.org/grpc/health/grpc_health_v1"
+    "google.golang.org/protobuf/proto"
+
+    "bookman/portfolio-service/internal/config"
+    "bookman/portfolio-service/internal/ops"
+    "bookman/portfolio-service/internal/web"
+)
+
+const (
+    // healthCheckPath is the gRPC method the gRPC-Web tests call
+    healthCheckPath = "/grpc.health.v1.Health/Check"
+
+    allowedOrigin = "https://app.bookman.example"
+)
+
+// newWebTestServer wraps a gRPC server exposing a started health service in
+// a gRPC-Web server allowing allowedOrigin
+func newWebTestServer(t *testing.T, tlsEnabled bool) *web.Server {
+    t.Helper()
+
+    health := ops.NewHealth(nil)
+    health.MarkStarted()
+    grpcServer := grpc.NewServer()
+    grpc_health_v1.RegisterHealthServer(grpcServer, health)
+    t.Cleanup(grpcServer.Stop)
+
+    server, err := web.NewServer(&config.ServerConfig{
+        TLSEnabled: tlsEnabled,
+        GRPCWeb: config.GRPCWebConfig{
+            Enabled:        true,
+            Port:           8081,
+            AllowedOrigins: []string{allowedOrigin},
+            IdleTimeout:    time.Minute,
+        },
+    }, grpcServer, zap.NewNop())
+    require.NoError(t, err)
+    return server
+}
+
+// grpcWebFrames splits a gRPC-Web response body into its message and
+// trailer frames
+func grpcWebFrames(t *testing.T, body []byte) (messages [][]byte, trailer string) {
+    t.Helper()
+
+    for len(body) > 0 {
+        require.GreaterOrEqual(t, len(body), 5)
+        flag, length := body[0], binary.BigEndian.Uint32(body[1:5])
+        require.GreaterOrEqual(t, uint32(len(body)-5), length)
+        frame := body[5 : 5+length]
+        if flag&0x80 != 0 {
+            trailer += string(frame)
+        } else {
+            messages = append(messages, frame)
+        }
+        body = body[5+length:]
+    }
+    return messages, trailer
+}
+
+// TestGRPCWebServesBrowserClients verifies gRPC-Web calls reach the gRPC
+// server with CORS headers for allowed origins
+func TestGRPCWebServesBrowserClients(t *testing.T) {
+    t.Parallel()
+
+    server := httptest.NewServer(newWebTestServer(t, false).Handler())
+    defer server.Close()
+
+    request, err := proto.Marshal(&grpc_health_v1.HealthCheckRequest{})
+    require.NoError(t, err)
+    frame := make([]byte, 5, 5+len(request))
+    binary.BigEndian.PutUint32(frame[1:], uint32(len(request)))
+    frame = append(frame, request...)
+
+    req, err := http.NewRequest(http.MethodPost, server.URL+healthCheckPath, bytes.NewReader(frame))
+    require.NoError(t, err)
+    req.Header.Set("Content-Type", "application/grpc-web+proto")
+    req.Header.Set("X-Grpc-Web", "1")
+    req.Header.Set("Origin", allowedOrigin)
+
+    resp, err := server.Client().Do(req)
+    require.NoError(t, err)
+    defer resp.Body.Close()
+    body, err := io.ReadAll(resp.Body)
+    require.NoError(t, err)
+
+    assert.Equal(t, http.StatusOK, resp.StatusCode)
+    assert.Equal(t, allowedOrigin, resp.Header.Get("Access-Control-Allow-Origin"))
+    assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "application/grpc-web"))
+
+    messages, trailer := grpcWebFrames(t, body)
+    require.Len(t, messages, 1)
+    var check grpc_health_v1.HealthCheckResponse
+    require.NoError(t, proto.Unmarshal(messages[0], &check))
+    assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, check.Status)
+    assert.Contains(t, strings.ToLower(trailer), "grpc-status:0")
+}
+
+// TestGRPCWebOriginAllowlist verifies CORS preflights succeed only for
+// allowed origins and registered methods, and other requests are refused
+func TestGRPCWebOriginAllowlist(t *testing.T) {
+    t.Parallel()
+
+    handler := newWebTestServer(t, false).Handler()
+
+    preflight := func(origin, path string) *httptest.ResponseRecorder {
+        req := httptest.NewRequest(http.MethodOptions, path, nil)
+        req.Header.Set("Origin", origin)
+        req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+        req.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web,authorization")
+        recorder := httptest.NewRecorder()
+        handler.ServeHTTP(recorder, req)
+        return recorder
+    }
+
+    allowed := preflight(allowedOrigin, healthCheckPath)
+    assert.Less(t, allowed.Code, 300)
+    assert.Equal(t, allowedOrigin, allowed.Header().Get("Access-Control-Allow-Origin"))
+    assert.Contains(t, strings.ToLower(allowed.Header().Get("Access-Control-Allow-Headers")), "authorization")
+
+    denied := preflight("https://evil.example", healthCheckPath)
+    assert.Empty(t, denied.Header().Get("Access-Control-Allow-Origin"))
+
+    unregistered := preflight(allowedOrigin, "/grpc.health.v1.Health/Unknown")
+    assert.Empty(t, unregistered.Header().Get("Access-Control-Allow-Origin"))
+
+    recorder := httptest.NewRecorder()
+    handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+    assert.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+}
+
+// TestGRPCWebPlainPassthrough verifies plain gRPC clients are served on the
+// gRPC-Web listener over HTTP/2
+func TestGRPCWebPlainPassthrough(t *testing.T) {
+    t.Parallel()
+
+    server := httptest.NewUnstartedServer(newWebTestServer(t, true).Handler())
+    server.EnableHTTP2 = true
+    server.StartTLS()
+    defer server.Close()
+
+    creds := credentials.NewTLS(&tls.Config{
+        RootCAs:    server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs,
+        NextProtos: []string{"h2"},
+    })
+    conn, err := grpc.Dial(strings.TrimPrefix(server.URL, "https://"), grpc.WithTransportCredentials(creds))
+    require.NoError(t, err)
+    defer conn.Close()
+
+    ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+    defer cancel()
+    resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+    require.NoError(t, err)
+    assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+}